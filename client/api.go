@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunRequest is the body accepted by /agent/run and /api/prompt.
+type RunRequest struct {
+	Prompt           string `json:"prompt"`
+	SessionID        string `json:"session_id,omitempty"`
+	EphemeralSession bool   `json:"ephemeral_session,omitempty"`
+	ProjectID        string `json:"project_id,omitempty"`
+	SystemPrompt     string `json:"system_prompt,omitempty"`
+	Image            bool   `json:"image,omitempty"`
+	ImageSize        string `json:"image_size,omitempty"`
+
+	// Specialist routes the run to a named specialist instead of the
+	// orchestrator. Team routes it to a team. Both are sent as query
+	// parameters.
+	Specialist string `json:"-"`
+	Team       string `json:"-"`
+}
+
+func (r RunRequest) query() url.Values {
+	q := url.Values{}
+	if s := strings.TrimSpace(r.Specialist); s != "" {
+		q.Set("specialist", s)
+	}
+	if t := strings.TrimSpace(r.Team); t != "" {
+		q.Set("team", t)
+	}
+	return q
+}
+
+// RunResult is the non-streaming response of an agent run.
+type RunResult struct {
+	Result          string            `json:"result"`
+	MatrixMessages  []json.RawMessage `json:"matrix_messages,omitempty"`
+	PolicyViolation json.RawMessage   `json:"policy_violation,omitempty"`
+}
+
+// AgentRun is an entry in the recent runs list.
+type AgentRun struct {
+	ID        string `json:"id"`
+	Prompt    string `json:"prompt"`
+	CreatedAt string `json:"createdAt"`
+	Status    string `json:"status"`
+	Tokens    int    `json:"tokens,omitempty"`
+}
+
+// AgentStatus describes an agent reported by /api/status. Entries with Kind
+// "llm_provider" report LLM circuit-breaker state instead of an agent.
+type AgentStatus struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	State     string         `json:"state"`
+	Model     string         `json:"model"`
+	UpdatedAt string         `json:"updatedAt"`
+	Kind      string         `json:"kind,omitempty"`
+	Breaker   *BreakerStatus `json:"breaker,omitempty"`
+}
+
+// BreakerStatus is the circuit-breaker state for one LLM base URL.
+type BreakerStatus struct {
+	BaseURL             string     `json:"baseUrl"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+}
+
+// ChatSession is a persisted chat session.
+type ChatSession struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	UserID             *int64    `json:"userId,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+	LastMessagePreview string    `json:"lastMessagePreview"`
+	Model              string    `json:"model"`
+	Summary            string    `json:"summary"`
+	SummarizedCount    int       `json:"summarizedCount"`
+}
+
+// ChatMessage is a single turn within a chat session.
+type ChatMessage struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"sessionId"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+	Title     string    `json:"title,omitempty"`
+	ToolArgs  string    `json:"toolArgs,omitempty"`
+	ToolID    string    `json:"toolId,omitempty"`
+}
+
+// Health returns nil when /healthz reports the process is alive.
+func (c *Client) Health(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/healthz", nil, nil, nil)
+}
+
+// Ready returns nil when /readyz reports the server can accept traffic.
+func (c *Client) Ready(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/readyz", nil, nil, nil)
+}
+
+// Status lists the agents reported by /api/status.
+func (c *Client) Status(ctx context.Context) ([]AgentStatus, error) {
+	var out []AgentStatus
+	if err := c.doJSON(ctx, http.MethodGet, "/api/status", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListRuns returns recent agent runs.
+func (c *Client) ListRuns(ctx context.Context) ([]AgentRun, error) {
+	var out []AgentRun
+	if err := c.doJSON(ctx, http.MethodGet, "/api/runs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Run executes an agent run and waits for the final result.
+func (c *Client) Run(ctx context.Context, req RunRequest) (*RunResult, error) {
+	return c.run(ctx, "/agent/run", req)
+}
+
+// RunStream executes an agent run and returns its event stream. The caller
+// must Close the stream.
+func (c *Client) RunStream(ctx context.Context, req RunRequest) (*Stream, error) {
+	return c.stream(ctx, "/agent/run", req)
+}
+
+// RunFunc executes an agent run, calls fn for each streamed event, and
+// returns the final event. A run that ends without one, e.g. after an
+// error event, returns a zero Event and a nil error; inspect the events
+// passed to fn.
+func (c *Client) RunFunc(ctx context.Context, req RunRequest, fn func(Event) error) (Event, error) {
+	stream, err := c.RunStream(ctx, req)
+	if err != nil {
+		return Event{}, err
+	}
+	if err := stream.Each(fn); err != nil {
+		return Event{}, err
+	}
+	final, _ := stream.Final()
+	return final, nil
+}
+
+// Prompt executes a run through /api/prompt, which honours SystemPrompt.
+func (c *Client) Prompt(ctx context.Context, req RunRequest) (*RunResult, error) {
+	return c.run(ctx, "/api/prompt", req)
+}
+
+// PromptStream is the streaming variant of Prompt.
+func (c *Client) PromptStream(ctx context.Context, req RunRequest) (*Stream, error) {
+	return c.stream(ctx, "/api/prompt", req)
+}
+
+func (c *Client) run(ctx context.Context, path string, req RunRequest) (*RunResult, error) {
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, req.query(), req, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out RunResult
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+func (c *Client) stream(ctx context.Context, path string, req RunRequest) (*Stream, error) {
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, req.query(), req, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		// Guardrail rejections and some errors are reported as JSON even when
+		// a stream was requested; surface them as a single final event.
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		var out RunResult
+		if err := json.Unmarshal(b, &out); err != nil {
+			return nil, fmt.Errorf("unexpected content type %q", ct)
+		}
+		frame, _ := json.Marshal(map[string]any{"type": EventFinal, "data": out.Result})
+		return newStream(io.NopCloser(strings.NewReader("data: " + string(frame) + "\n\n"))), nil
+	}
+	return newStream(resp.Body), nil
+}
+
+// ListSessions returns the chat sessions visible to the caller.
+func (c *Client) ListSessions(ctx context.Context) ([]ChatSession, error) {
+	var out []ChatSession
+	if err := c.doJSON(ctx, http.MethodGet, "/api/chat/sessions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateSession creates a chat session. An empty name lets the server pick one.
+func (c *Client) CreateSession(ctx context.Context, name string) (*ChatSession, error) {
+	var out ChatSession
+	body := map[string]string{"name": name}
+	if err := c.doJSON(ctx, http.MethodPost, "/api/chat/sessions", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSession fetches a chat session by ID.
+func (c *Client) GetSession(ctx context.Context, id string) (*ChatSession, error) {
+	var out ChatSession
+	if err := c.doJSON(ctx, http.MethodGet, sessionPath(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenameSession changes a chat session's name.
+func (c *Client) RenameSession(ctx context.Context, id, name string) (*ChatSession, error) {
+	var out ChatSession
+	body := map[string]string{"name": name}
+	if err := c.doJSON(ctx, http.MethodPatch, sessionPath(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSession removes a chat session and its messages.
+func (c *Client) DeleteSession(ctx context.Context, id string) error {
+	return c.doJSON(ctx, http.MethodDelete, sessionPath(id), nil, nil, nil)
+}
+
+// ListMessages returns messages in a session. A limit <= 0 returns all.
+func (c *Client) ListMessages(ctx context.Context, sessionID string, limit int) ([]ChatMessage, error) {
+	var q url.Values
+	if limit > 0 {
+		q = url.Values{"limit": {strconv.Itoa(limit)}}
+	}
+	var out []ChatMessage
+	if err := c.doJSON(ctx, http.MethodGet, sessionPath(sessionID)+"/messages", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func sessionPath(id string) string {
+	return "/api/chat/sessions/" + url.PathEscape(id)
+}
@@ -0,0 +1,330 @@
+// Package client is a Go SDK for the agentd HTTP API.
+//
+// It wraps the endpoints described by the generated OpenAPI document
+// (docs/openapi/openapi.json) with typed requests and responses, parses the
+// server-sent event stream returned by the agent run endpoints, retries
+// transient failures, and attaches session-cookie or bearer credentials.
+//
+// The package depends only on the standard library so other Go services can
+// import it without pulling in the server's dependency tree.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the address agentd listens on by default.
+const DefaultBaseURL = "http://localhost:32180"
+
+// DefaultCookieName is the default agentd session cookie name.
+const DefaultCookieName = "sio_session"
+
+// Client calls the agentd HTTP API. It is safe for concurrent use.
+type Client struct {
+	baseURL     *url.URL
+	httpClient  *http.Client
+	headers     http.Header
+	cookieName  string
+	cookieValue string
+	bearerToken string
+	userAgent   string
+	retry       RetryPolicy
+}
+
+// RetryPolicy controls how idempotent requests are retried on transient
+// failures (network errors, 429, 502, 503, 504).
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles after
+	// every attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries up to three times with exponential backoff.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: 250 * time.Millisecond, MaxBackoff: 4 * time.Second}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. Streaming requests rely on
+// the client having no overall Timeout; use context deadlines instead.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// WithSessionCookie authenticates requests with an agentd session cookie.
+// An empty name uses DefaultCookieName.
+func WithSessionCookie(name, value string) Option {
+	return func(c *Client) {
+		if strings.TrimSpace(name) != "" {
+			c.cookieName = strings.TrimSpace(name)
+		}
+		c.cookieValue = value
+	}
+}
+
+// WithBearerToken sends an Authorization: Bearer header on every request.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.bearerToken = token }
+}
+
+// WithHeader adds a static header to every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Add(key, value) }
+}
+
+// WithUserAgent overrides the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// New returns a Client for the agentd instance at baseURL. An empty baseURL
+// uses DefaultBaseURL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = DefaultBaseURL
+	}
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base url must be http or https: %q", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{},
+		headers:    http.Header{},
+		cookieName: DefaultCookieName,
+		userAgent:  "manifold-go-client",
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError is returned when agentd responds with a non-2xx status.
+// Code carries agentd's machine-readable error code, such as
+// "validation_failed" or "specialist_busy", when the body had one.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    json.RawMessage
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("agentd: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("agentd: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool { return statusIs(err, http.StatusNotFound) }
+
+// IsUnauthorized reports whether err is an APIError with status 401.
+func IsUnauthorized(err error) bool { return statusIs(err, http.StatusUnauthorized) }
+
+// HasCode reports whether err is an APIError with the given agentd error code.
+func HasCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func statusIs(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+// endpoint joins the base URL with path, whose segments are already
+// escaped with url.PathEscape.
+func (c *Client) endpoint(path string, query url.Values) string {
+	u := *c.baseURL
+	u.RawPath = strings.TrimRight(u.EscapedPath(), "/") + path
+	if p, err := url.PathUnescape(u.RawPath); err == nil {
+		u.Path = p
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
+	var rdr io.Reader
+	if body != nil {
+		rdr = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint(path, query), rdr)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	if c.cookieValue != "" {
+		req.AddCookie(&http.Cookie{Name: c.cookieName, Value: c.cookieValue})
+	}
+	return req, nil
+}
+
+// do sends a request and returns the response when the status is 2xx.
+// Only idempotent methods are retried; agent runs are never replayed.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in any, accept string) (*http.Response, error) {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		body = b
+	}
+
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 || !idempotent(method) {
+		attempts = 1
+	}
+	backoff := c.retry.InitialBackoff
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := c.newRequest(ctx, method, path, query, body)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = readAPIError(resp)
+			if !retryableStatus(resp.StatusCode) {
+				return nil, lastErr
+			}
+		}
+		if attempt == attempts || ctx.Err() != nil {
+			break
+		}
+		if err := sleepCtx(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+	return nil, lastErr
+}
+
+// doJSON sends in (if non-nil) and decodes the JSON response into out (if non-nil).
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	resp, err := c.do(ctx, method, path, query, in, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func readAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: b}
+	var payload struct {
+		Code    string          `json:"code"`
+		Error   string          `json:"error"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	}
+	if json.Unmarshal(b, &payload) == nil && (payload.Error != "" || payload.Message != "") {
+		apiErr.Code = payload.Code
+		apiErr.Details = payload.Details
+		apiErr.Message = payload.Error
+		if payload.Message != "" {
+			apiErr.Message = payload.Message
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(b))
+	}
+	return apiErr
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
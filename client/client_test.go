@@ -0,0 +1,274 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h http.Handler, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	opts = append([]Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})}, opts...)
+	c, err := New(srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func TestNewRejectsNonHTTPScheme(t *testing.T) {
+	if _, err := New("ftp://example.com"); err == nil {
+		t.Fatalf("expected error for ftp scheme")
+	}
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]AgentRun{{ID: "r1", Status: "completed"}})
+	}))
+
+	runs, err := c.ListRuns(context.Background())
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != "r1" || calls != 3 {
+		t.Fatalf("unexpected result %+v after %d calls", runs, calls)
+	}
+}
+
+func TestRunIsNotRetried(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+
+	_, err := c.Run(context.Background(), RunRequest{Prompt: "hi"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "busy" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("POST must not be retried, got %d calls", calls)
+	}
+}
+
+func TestStructuredAPIError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"validation_failed","message":"prompt: required","details":[{"field":"prompt","message":"required"}]}`))
+	}))
+
+	_, err := c.Run(context.Background(), RunRequest{Prompt: "hi"})
+	if !HasCode(err, "validation_failed") {
+		t.Fatalf("expected validation_failed, got %#v", err)
+	}
+	apiErr := err.(*APIError)
+	if apiErr.Message != "prompt: required" || !strings.Contains(string(apiErr.Details), `"field":"prompt"`) {
+		t.Fatalf("unexpected error: %#v", apiErr)
+	}
+}
+
+func TestAuthHeadersAndQuery(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("authorization = %q", got)
+		}
+		if ck, err := r.Cookie(DefaultCookieName); err != nil || ck.Value != "sess" {
+			t.Errorf("missing session cookie: %v", err)
+		}
+		if got := r.URL.Query().Get("specialist"); got != "coder" {
+			t.Errorf("specialist = %q", got)
+		}
+		var body RunRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": "echo:" + body.Prompt})
+	}), WithBearerToken("tok"), WithSessionCookie("", "sess"))
+
+	res, err := c.Run(context.Background(), RunRequest{Prompt: "hi", Specialist: "coder"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if res.Result != "echo:hi" {
+		t.Fatalf("result = %q", res.Result)
+	}
+}
+
+func TestRunStreamParsesEvents(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("accept = %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"delta\",\"data\":\"Hel\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"tool_start\",\"title\":\"Tool: run_cli\",\"tool_id\":\"t1\",\"args\":\"{}\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"final\",\"data\":\"Hello\"}\n\n")
+	}))
+
+	stream, err := c.RunStream(context.Background(), RunRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("run stream: %v", err)
+	}
+	defer stream.Close()
+
+	var types []string
+	for stream.Next() {
+		ev := stream.Event()
+		types = append(types, ev.Type)
+		if ev.Type == EventToolStart && (ev.ToolID != "t1" || ev.Title != "Tool: run_cli") {
+			t.Fatalf("unexpected tool event: %+v", ev)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream err: %v", err)
+	}
+	if fmt.Sprint(types) != "[delta tool_start final]" {
+		t.Fatalf("unexpected events: %v", types)
+	}
+	final, ok := stream.Final()
+	if !ok || final.Data != "Hello" {
+		t.Fatalf("unexpected final: %+v", final)
+	}
+}
+
+func TestParseEventStringPayloads(t *testing.T) {
+	if ev := parseEvent(`"(error) boom"`); ev.Type != EventError || ev.Data != "(error) boom" {
+		t.Fatalf("unexpected error event: %+v", ev)
+	}
+	if ev := parseEvent(`"chunk"`); ev.Type != EventDelta || ev.Data != "chunk" {
+		t.Fatalf("unexpected delta event: %+v", ev)
+	}
+	if ev := parseEvent(`{"type":"tool_result","data":{"ok":true}}`); ev.Data != `{"ok":true}` {
+		t.Fatalf("non-string data should be kept raw: %+v", ev)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	_, err := c.GetSession(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRunFuncCallsBackPerEvent(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: r1:1\ndata: {\"type\":\"delta\",\"data\":\"Hi\"}\n\n")
+		fmt.Fprint(w, "id: r1:2\ndata: {\"type\":\"final\",\"data\":\"Hi there\"}\n\n")
+	}))
+
+	var deltas []string
+	final, err := c.RunFunc(context.Background(), RunRequest{Prompt: "hi"}, func(ev Event) error {
+		if ev.Type == EventDelta {
+			deltas = append(deltas, ev.Data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run func: %v", err)
+	}
+	if fmt.Sprint(deltas) != "[Hi]" || final.Data != "Hi there" {
+		t.Fatalf("unexpected deltas %v final %+v", deltas, final)
+	}
+}
+
+func TestWorkflowStartAndWatch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/flows/v2/run", func(w http.ResponseWriter, r *http.Request) {
+		var req WorkflowRunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.WorkflowID != "wf1" {
+			t.Errorf("unexpected run request %+v (%v)", req, err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"run_id":"run-1","status":"running"}`))
+	})
+	mux.HandleFunc("GET /api/flows/v2/runs/run-1/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: run-1:1\ndata: {\"run_id\":\"run-1\",\"sequence\":1,\"type\":\"node_completed\",\"node_id\":\"n1\"}\n\n")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "id: run-1:2\ndata: {\"run_id\":\"run-1\",\"sequence\":2,\"type\":\"run_completed\",\"status\":\"completed\"}\n\n")
+	})
+	c := newTestClient(t, mux)
+
+	run, err := c.StartWorkflow(context.Background(), WorkflowRunRequest{WorkflowID: "wf1", Input: map[string]any{"query": "x"}})
+	if err != nil || run.RunID != "run-1" {
+		t.Fatalf("start workflow: %+v %v", run, err)
+	}
+	var nodes []string
+	last, err := c.WatchWorkflowRun(context.Background(), run.RunID, func(ev WorkflowRunEvent) error {
+		if ev.NodeID != "" {
+			nodes = append(nodes, ev.NodeID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if fmt.Sprint(nodes) != "[n1]" || last.Type != WorkflowRunCompleted {
+		t.Fatalf("unexpected nodes %v last %+v", nodes, last)
+	}
+}
+
+func TestListWorkflowRunsSendsFilter(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/workflow-runs", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("workflow_id") != "wf1" || q.Get("status") != "failed" || q.Get("since") != "2026-03-01T00:00:00Z" || q.Get("limit") != "5" || q.Has("until") {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"runs":[{"id":"run-1","workflow_id":"wf1","trigger":"webhook","status":"failed","steps":[{"node_id":"n1","status":"failed","error":"boom"}]}]}`))
+	})
+	c := newTestClient(t, mux)
+
+	runs, err := c.ListWorkflowRuns(context.Background(), WorkflowRunFilter{WorkflowID: "wf1", Status: "failed", Since: since, Limit: 5})
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Trigger != "webhook" || len(runs[0].Steps) != 1 || runs[0].Steps[0].Error != "boom" {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+}
+
+func TestPlaygroundExperimentRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/playground/experiments/{id}/runs", func(w http.ResponseWriter, r *http.Request) {
+		if id := r.PathValue("id"); id != "exp 1" {
+			t.Errorf("experiment id = %q", id)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"id":"run-9","experimentId":"exp 1","status":"pending"}`))
+	})
+	mux.HandleFunc("GET /api/v1/playground/runs/run-9/results", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"id":"r1","runId":"run-9","rowId":"row1","variantId":"a","scores":{"exact":1}}]}`))
+	})
+	c := newTestClient(t, mux)
+
+	run, err := c.StartExperimentRun(context.Background(), "exp 1")
+	if err != nil || run.ID != "run-9" {
+		t.Fatalf("start run: %+v %v", run, err)
+	}
+	results, err := c.ListExperimentResults(context.Background(), run.ID)
+	if err != nil {
+		t.Fatalf("results: %v", err)
+	}
+	if len(results) != 1 || results[0].Scores["exact"] != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
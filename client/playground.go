@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const playgroundBase = "/api/v1/playground"
+
+// Experiment runs prompt variants against a playground dataset and scores
+// them with evaluators.
+type Experiment struct {
+	ID          string                `json:"id,omitempty"`
+	ProjectID   string                `json:"projectId,omitempty"`
+	Name        string                `json:"name"`
+	DatasetID   string                `json:"datasetId"`
+	SnapshotID  string                `json:"snapshotId,omitempty"`
+	SliceExpr   string                `json:"sliceExpr,omitempty"`
+	Variants    []ExperimentVariant   `json:"variants"`
+	Evaluators  []ExperimentEvaluator `json:"evaluators,omitempty"`
+	Budgets     ExperimentBudgets     `json:"budgets"`
+	Concurrency ExperimentConcurrency `json:"concurrency"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	CreatedBy   string                `json:"createdBy,omitempty"`
+}
+
+// ExperimentVariant is one prompt and model combination.
+type ExperimentVariant struct {
+	ID              string         `json:"id"`
+	PromptVersionID string         `json:"promptVersionId,omitempty"`
+	Model           string         `json:"model,omitempty"`
+	Params          map[string]any `json:"params,omitempty"`
+	PromptTemplate  string         `json:"promptTemplate,omitempty"`
+}
+
+// ExperimentEvaluator selects an evaluator by name.
+type ExperimentEvaluator struct {
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params,omitempty"`
+	Weight float64        `json:"weight,omitempty"`
+}
+
+// ExperimentBudgets caps tokens and cost for a run. Zero means no limit.
+type ExperimentBudgets struct {
+	MaxTokens int     `json:"maxTokens"`
+	MaxCost   float64 `json:"maxCost"`
+}
+
+// ExperimentConcurrency controls how a run is sharded.
+type ExperimentConcurrency struct {
+	MaxWorkers        int `json:"maxWorkers"`
+	MaxRowsPerShard   int `json:"maxRowsPerShard"`
+	MaxVariantsPerRun int `json:"maxVariantsPerRun"`
+}
+
+// ExperimentRun is one execution of an experiment.
+type ExperimentRun struct {
+	ID              string             `json:"id"`
+	ExperimentID    string             `json:"experimentId"`
+	Status          string             `json:"status"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	StartedAt       time.Time          `json:"startedAt"`
+	EndedAt         time.Time          `json:"endedAt"`
+	Error           string             `json:"error,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	CompletedShards []string           `json:"completedShards,omitempty"`
+}
+
+// ExperimentResult is the scored output for one dataset row and variant.
+type ExperimentResult struct {
+	ID               string             `json:"id"`
+	RunID            string             `json:"runId"`
+	RowID            string             `json:"rowId"`
+	VariantID        string             `json:"variantId"`
+	Model            string             `json:"model,omitempty"`
+	Rendered         string             `json:"rendered,omitempty"`
+	Output           string             `json:"output,omitempty"`
+	Tokens           int                `json:"tokens,omitempty"`
+	PromptTokens     int                `json:"promptTokens,omitempty"`
+	CompletionTokens int                `json:"completionTokens,omitempty"`
+	Cost             float64            `json:"cost,omitempty"`
+	Latency          time.Duration      `json:"latency,omitempty"`
+	Scores           map[string]float64 `json:"scores,omitempty"`
+	Expected         any                `json:"expected,omitempty"`
+}
+
+// ListExperiments returns all playground experiments.
+func (c *Client) ListExperiments(ctx context.Context) ([]Experiment, error) {
+	var out struct {
+		Experiments []Experiment `json:"experiments"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, playgroundBase+"/experiments", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Experiments, nil
+}
+
+// GetExperiment fetches an experiment by ID.
+func (c *Client) GetExperiment(ctx context.Context, id string) (*Experiment, error) {
+	var out Experiment
+	if err := c.doJSON(ctx, http.MethodGet, experimentPath(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateExperiment saves an experiment. The server assigns an ID when
+// exp.ID is empty.
+func (c *Client) CreateExperiment(ctx context.Context, exp Experiment) (*Experiment, error) {
+	var out Experiment
+	if err := c.doJSON(ctx, http.MethodPost, playgroundBase+"/experiments", nil, exp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteExperiment removes an experiment.
+func (c *Client) DeleteExperiment(ctx context.Context, id string) error {
+	return c.doJSON(ctx, http.MethodDelete, experimentPath(id), nil, nil, nil)
+}
+
+// StartExperimentRun starts a run of the experiment in the background. It
+// fails with 409 while another run of the experiment is active.
+func (c *Client) StartExperimentRun(ctx context.Context, experimentID string) (*ExperimentRun, error) {
+	var out ExperimentRun
+	if err := c.doJSON(ctx, http.MethodPost, experimentPath(experimentID)+"/runs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResumeExperimentRun continues the experiment's latest interrupted run.
+func (c *Client) ResumeExperimentRun(ctx context.Context, experimentID string) (*ExperimentRun, error) {
+	var out ExperimentRun
+	if err := c.doJSON(ctx, http.MethodPost, experimentPath(experimentID)+"/resume", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListExperimentRuns returns the runs of an experiment.
+func (c *Client) ListExperimentRuns(ctx context.Context, experimentID string) ([]ExperimentRun, error) {
+	var out struct {
+		Runs []ExperimentRun `json:"runs"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, experimentPath(experimentID)+"/runs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Runs, nil
+}
+
+// ListExperimentResults returns the per-row results of a run.
+func (c *Client) ListExperimentResults(ctx context.Context, runID string) ([]ExperimentResult, error) {
+	var out struct {
+		Results []ExperimentResult `json:"results"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, playgroundBase+"/runs/"+url.PathEscape(runID)+"/results", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// ExperimentReport returns the comparison report for a completed run, or
+// for the latest completed run when runID is empty. The report is returned
+// as raw JSON.
+func (c *Client) ExperimentReport(ctx context.Context, experimentID, runID string) (json.RawMessage, error) {
+	var q url.Values
+	if runID != "" {
+		q = url.Values{"runId": {runID}}
+	}
+	var out json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, experimentPath(experimentID)+"/report", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func experimentPath(id string) string {
+	return playgroundBase + "/experiments/" + url.PathEscape(id)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Specialist is a named LLM configuration runs can be routed to with
+// RunRequest.Specialist.
+type Specialist struct {
+	ID                         int64             `json:"id,omitempty"`
+	Name                       string            `json:"name"`
+	Description                string            `json:"description"`
+	Provider                   string            `json:"provider"`
+	BaseURL                    string            `json:"baseURL"`
+	APIKey                     string            `json:"apiKey"`
+	Model                      string            `json:"model"`
+	SummaryContextWindowTokens int               `json:"summaryContextWindowTokens"`
+	EnableTools                bool              `json:"enableTools"`
+	AutoDiscover               *bool             `json:"autoDiscover,omitempty"`
+	Paused                     bool              `json:"paused"`
+	AllowTools                 []string          `json:"allowTools"`
+	ReasoningEffort            string            `json:"reasoningEffort"`
+	Voice                      string            `json:"voice,omitempty"`
+	System                     string            `json:"system"`
+	ExtraHeaders               map[string]string `json:"extraHeaders"`
+	ExtraParams                map[string]any    `json:"extraParams"`
+	Teams                      []string          `json:"teams,omitempty"`
+}
+
+// ListSpecialists returns the caller's specialists, including the
+// orchestrator.
+func (c *Client) ListSpecialists(ctx context.Context) ([]Specialist, error) {
+	var out []Specialist
+	if err := c.doJSON(ctx, http.MethodGet, "/api/specialists", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSpecialist fetches a specialist by name.
+func (c *Client) GetSpecialist(ctx context.Context, name string) (*Specialist, error) {
+	var out Specialist
+	if err := c.doJSON(ctx, http.MethodGet, specialistPath(name), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateSpecialist adds a specialist.
+func (c *Client) CreateSpecialist(ctx context.Context, sp Specialist) (*Specialist, error) {
+	var out Specialist
+	if err := c.doJSON(ctx, http.MethodPost, "/api/specialists", nil, sp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateSpecialist replaces the specialist called name.
+func (c *Client) UpdateSpecialist(ctx context.Context, name string, sp Specialist) (*Specialist, error) {
+	var out Specialist
+	if err := c.doJSON(ctx, http.MethodPut, specialistPath(name), nil, sp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSpecialist removes a specialist. The orchestrator cannot be deleted.
+func (c *Client) DeleteSpecialist(ctx context.Context, name string) error {
+	return c.doJSON(ctx, http.MethodDelete, specialistPath(name), nil, nil, nil)
+}
+
+func specialistPath(name string) string {
+	return "/api/specialists/" + url.PathEscape(name)
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Event types emitted by the agent run stream.
+const (
+	EventQueued          = "queued"
+	EventDelta           = "delta"
+	EventThoughtSummary  = "thought_summary"
+	EventToolStart       = "tool_start"
+	EventToolResult      = "tool_result"
+	EventSummary         = "summary"
+	EventImage           = "image"
+	EventTTSAudio        = "tts_audio"
+	EventError           = "error"
+	EventPolicyViolation = "policy_violation"
+	EventFinal           = "final"
+)
+
+// Event is a single server-sent event from an agent run. Common fields are
+// decoded for convenience; Raw holds the complete JSON payload.
+type Event struct {
+	Type   string          `json:"type"`
+	Data   string          `json:"-"`
+	Title  string          `json:"title,omitempty"`
+	ToolID string          `json:"tool_id,omitempty"`
+	Args   string          `json:"args,omitempty"`
+	Agent  bool            `json:"agent,omitempty"`
+	Stage  string          `json:"stage,omitempty"`
+	Raw    json.RawMessage `json:"-"`
+}
+
+// Stream reads events from an SSE response body.
+//
+//	for stream.Next() {
+//		ev := stream.Event()
+//		...
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type Stream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	event   Event
+	err     error
+	final   *Event
+}
+
+func newStream(body io.ReadCloser) *Stream {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &Stream{body: body, scanner: sc}
+}
+
+// Next advances to the next event. It returns false at end of stream or on
+// error. Keepalive comments are skipped.
+func (s *Stream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	var data []string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+			s.event = parseEvent(strings.Join(data, "\n"))
+			if s.event.Type == EventFinal {
+				ev := s.event
+				s.final = &ev
+			}
+			return true
+		case strings.HasPrefix(line, ":"):
+			continue
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	if len(data) > 0 {
+		s.event = parseEvent(strings.Join(data, "\n"))
+		return true
+	}
+	return false
+}
+
+// Event returns the most recent event read by Next.
+func (s *Stream) Event() Event { return s.event }
+
+// Err returns the first non-EOF error encountered by Next.
+func (s *Stream) Err() error { return s.err }
+
+// Close releases the underlying connection.
+func (s *Stream) Close() error { return s.body.Close() }
+
+// Final returns the final event once it has been read.
+func (s *Stream) Final() (Event, bool) {
+	if s.final == nil {
+		return Event{}, false
+	}
+	return *s.final, true
+}
+
+// Each calls fn for every remaining event and closes the stream. It stops at
+// the first error returned by fn or by the stream.
+func (s *Stream) Each(fn func(Event) error) error {
+	defer s.Close()
+	for s.Next() {
+		if err := fn(s.Event()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// parseEvent decodes a data payload. Objects are decoded into Event fields;
+// bare JSON strings (used by /api/prompt for errors) become EventError when
+// they carry the "(error)" prefix and EventDelta otherwise.
+func parseEvent(payload string) Event {
+	ev := Event{Raw: json.RawMessage(payload)}
+	trimmed := strings.TrimSpace(payload)
+	if strings.HasPrefix(trimmed, "{") {
+		var obj struct {
+			Event
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &obj); err == nil {
+			ev = obj.Event
+			ev.Raw = json.RawMessage(payload)
+			ev.Data = rawString(obj.Data)
+			return ev
+		}
+	}
+	var str string
+	if err := json.Unmarshal([]byte(trimmed), &str); err == nil {
+		ev.Data = str
+		if strings.HasPrefix(str, "(error)") {
+			ev.Type = EventError
+		} else {
+			ev.Type = EventDelta
+		}
+		return ev
+	}
+	ev.Data = payload
+	return ev
+}
+
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
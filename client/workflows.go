@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Workflow run event types reported by /api/flows/v2/runs/{id}/events.
+const (
+	WorkflowRunStarted    = "run_started"
+	WorkflowRunCompleted  = "run_completed"
+	WorkflowRunFailed     = "run_failed"
+	WorkflowRunCancelled  = "run_cancelled"
+	WorkflowNodeStarted   = "node_started"
+	WorkflowNodeCompleted = "node_completed"
+	WorkflowNodeFailed    = "node_failed"
+)
+
+// WorkflowSummary is an entry in the workflow list.
+type WorkflowSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// WorkflowDefinition is a saved workflow. Workflow and Canvas are kept as
+// raw JSON so callers can round-trip them without this package tracking
+// the flow schema.
+type WorkflowDefinition struct {
+	Workflow json.RawMessage `json:"workflow"`
+	Canvas   json.RawMessage `json:"canvas,omitempty"`
+}
+
+// WorkflowRunRequest starts a workflow run.
+type WorkflowRunRequest struct {
+	WorkflowID string         `json:"workflow_id"`
+	Input      map[string]any `json:"input,omitempty"`
+	ProjectID  string         `json:"project_id,omitempty"`
+}
+
+// WorkflowRun is the response to starting a run.
+type WorkflowRun struct {
+	RunID  string `json:"run_id"`
+	Status string `json:"status"`
+}
+
+// WorkflowRunEvent is a single node or run transition.
+type WorkflowRunEvent struct {
+	RunID      string         `json:"run_id"`
+	Sequence   int64          `json:"sequence"`
+	Type       string         `json:"type"`
+	NodeID     string         `json:"node_id,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Output     map[string]any `json:"output,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// WorkflowRunEvents is the recorded state of a workflow run.
+type WorkflowRunEvents struct {
+	RunID  string             `json:"run_id"`
+	Status string             `json:"status"`
+	Events []WorkflowRunEvent `json:"events"`
+}
+
+// Done reports whether the run has finished.
+func (r *WorkflowRunEvents) Done() bool {
+	switch r.Status {
+	case "completed", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// WorkflowRunStep is one node's part in a recorded workflow run.
+type WorkflowRunStep struct {
+	NodeID     string    `json:"node_id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// WorkflowRunRecord is an entry in the workflow run history. EndedAt is
+// zero while the run is in flight.
+type WorkflowRunRecord struct {
+	ID         string            `json:"id"`
+	WorkflowID string            `json:"workflow_id"`
+	Trigger    string            `json:"trigger"`
+	Status     string            `json:"status"`
+	Input      map[string]any    `json:"input,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Steps      []WorkflowRunStep `json:"steps"`
+	StartedAt  time.Time         `json:"started_at"`
+	EndedAt    time.Time         `json:"ended_at"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// WorkflowRunFilter narrows ListWorkflowRuns. Zero fields match everything.
+type WorkflowRunFilter struct {
+	WorkflowID string
+	Status     string
+	// Trigger is one of api, warpp, webhook or tool.
+	Trigger string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+func (f WorkflowRunFilter) query() url.Values {
+	q := url.Values{}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("workflow_id", f.WorkflowID)
+	set("status", f.Status)
+	set("trigger", f.Trigger)
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return q
+}
+
+// ListWorkflows returns the caller's workflows.
+func (c *Client) ListWorkflows(ctx context.Context) ([]WorkflowSummary, error) {
+	var out struct {
+		Workflows []WorkflowSummary `json:"workflows"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/api/flows/v2/workflows", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Workflows, nil
+}
+
+// GetWorkflow fetches a workflow and its canvas layout.
+func (c *Client) GetWorkflow(ctx context.Context, id string) (*WorkflowDefinition, error) {
+	var out WorkflowDefinition
+	if err := c.doJSON(ctx, http.MethodGet, workflowPath(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PutWorkflow creates or replaces a workflow.
+func (c *Client) PutWorkflow(ctx context.Context, id string, def WorkflowDefinition) (*WorkflowDefinition, error) {
+	var out WorkflowDefinition
+	if err := c.doJSON(ctx, http.MethodPut, workflowPath(id), nil, def, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWorkflow removes a workflow.
+func (c *Client) DeleteWorkflow(ctx context.Context, id string) error {
+	return c.doJSON(ctx, http.MethodDelete, workflowPath(id), nil, nil, nil)
+}
+
+// StartWorkflow starts a workflow run in the background.
+func (c *Client) StartWorkflow(ctx context.Context, req WorkflowRunRequest) (*WorkflowRun, error) {
+	if strings.TrimSpace(req.WorkflowID) == "" {
+		return nil, fmt.Errorf("workflow id is required")
+	}
+	var out WorkflowRun
+	if err := c.doJSON(ctx, http.MethodPost, "/api/flows/v2/run", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WorkflowRunEvents returns the events recorded so far for a run.
+func (c *Client) WorkflowRunEvents(ctx context.Context, runID string) (*WorkflowRunEvents, error) {
+	var out WorkflowRunEvents
+	if err := c.doJSON(ctx, http.MethodGet, workflowRunPath(runID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WatchWorkflowRun streams a run's events to fn until the run finishes, fn
+// returns an error, or ctx is done. Events already recorded are replayed
+// first. It returns the event that ended the run.
+func (c *Client) WatchWorkflowRun(ctx context.Context, runID string, fn func(WorkflowRunEvent) error) (WorkflowRunEvent, error) {
+	resp, err := c.do(ctx, http.MethodGet, workflowRunPath(runID), nil, nil, "text/event-stream")
+	if err != nil {
+		return WorkflowRunEvent{}, err
+	}
+	var last WorkflowRunEvent
+	err = newStream(resp.Body).Each(func(ev Event) error {
+		var wev WorkflowRunEvent
+		if err := json.Unmarshal(ev.Raw, &wev); err != nil {
+			return fmt.Errorf("decode workflow event: %w", err)
+		}
+		last = wev
+		return fn(wev)
+	})
+	return last, err
+}
+
+// ListWorkflowRuns returns recorded workflow runs matching filter, newest
+// first.
+func (c *Client) ListWorkflowRuns(ctx context.Context, filter WorkflowRunFilter) ([]WorkflowRunRecord, error) {
+	var out struct {
+		Runs []WorkflowRunRecord `json:"runs"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/api/workflow-runs", filter.query(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Runs, nil
+}
+
+// GetWorkflowRunRecord fetches the history entry for a run.
+func (c *Client) GetWorkflowRunRecord(ctx context.Context, runID string) (*WorkflowRunRecord, error) {
+	var out WorkflowRunRecord
+	if err := c.doJSON(ctx, http.MethodGet, "/api/workflow-runs/"+url.PathEscape(runID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func workflowPath(id string) string {
+	return "/api/flows/v2/workflows/" + url.PathEscape(id)
+}
+
+func workflowRunPath(runID string) string {
+	return "/api/flows/v2/runs/" + url.PathEscape(runID) + "/events"
+}
@@ -1,7 +1,17 @@
+// Command agentd starts the Manifold server. It is kept for existing
+// images and scripts; it is equivalent to "manifold serve", and
+// "agentd config validate" to "manifold config validate".
 package main
 
-import "manifold/internal/agentd"
+import (
+	"os"
+
+	"manifold/internal/manifoldcli"
+)
 
 func main() {
-	agentd.Run()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(manifoldcli.Main(os.Args[1:]))
+	}
+	os.Exit(manifoldcli.Main([]string{"serve"}))
 }
@@ -0,0 +1,335 @@
+// Command loadgen drives concurrent /agent/run traffic and reports latency
+// percentiles and allocation figures.
+//
+// By default it targets a running agentd over HTTP. With -inprocess it runs
+// the agent engine directly against a simulated provider, which isolates the
+// engine loop from network and model variance so regressions show up in the
+// numbers rather than in the noise.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"manifold/internal/agent"
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+type headerFlags []string
+
+func (h *headerFlags) String() string     { return strings.Join(*h, ", ") }
+func (h *headerFlags) Set(v string) error { *h = append(*h, v); return nil }
+
+type options struct {
+	url         string
+	prompt      string
+	concurrency int
+	requests    int
+	duration    time.Duration
+	timeout     time.Duration
+	headers     headerFlags
+	inProcess   bool
+	latency     time.Duration
+	toolCall    bool
+	cpuProfile  string
+	memProfile  string
+	jsonOut     bool
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.url, "url", "http://localhost:32180/agent/run", "agent run endpoint")
+	flag.StringVar(&o.prompt, "prompt", "Reply with the word ok.", "prompt to send")
+	flag.IntVar(&o.concurrency, "c", 8, "number of concurrent workers")
+	flag.IntVar(&o.requests, "n", 200, "total requests (ignored when -d is set)")
+	flag.DurationVar(&o.duration, "d", 0, "run for a fixed duration instead of -n requests")
+	flag.DurationVar(&o.timeout, "timeout", 2*time.Minute, "per-request timeout")
+	flag.Var(&o.headers, "H", "extra request header as 'Name: value' (repeatable)")
+	flag.BoolVar(&o.inProcess, "inprocess", false, "run the agent engine in-process against a simulated provider")
+	flag.DurationVar(&o.latency, "latency", 0, "simulated provider latency per model call (-inprocess)")
+	flag.BoolVar(&o.toolCall, "tool", true, "simulate one tool round-trip per run (-inprocess)")
+	flag.StringVar(&o.cpuProfile, "cpuprofile", "", "write a CPU profile to this file")
+	flag.StringVar(&o.memProfile, "memprofile", "", "write a heap allocation profile to this file")
+	flag.BoolVar(&o.jsonOut, "json", false, "print the report as JSON")
+	flag.Parse()
+
+	if o.concurrency <= 0 {
+		o.concurrency = 1
+	}
+	if o.duration <= 0 && o.requests <= 0 {
+		fmt.Fprintln(os.Stderr, "loadgen: -n or -d must be positive")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, o); err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, o options) error {
+	do, err := newRunner(o)
+	if err != nil {
+		return err
+	}
+
+	if o.cpuProfile != "" {
+		f, err := os.Create(o.cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	res := drive(ctx, o, do)
+
+	if o.memProfile != "" {
+		f, err := os.Create(o.memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+			return err
+		}
+	}
+
+	rep := res.report()
+	if o.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	rep.print(os.Stdout)
+	return nil
+}
+
+// newRunner returns the function each worker calls once per request.
+func newRunner(o options) (func(ctx context.Context, worker int) error, error) {
+	if o.inProcess {
+		provider := &testhelpers.ToolLoopProvider{Answer: "ok", Latency: o.latency}
+		if o.toolCall {
+			provider.ToolName = "lookup"
+			provider.ToolArgs = `{"q":"status"}`
+		}
+		return func(ctx context.Context, _ int) error {
+			reg := tools.NewRegistry()
+			reg.Register(lookupTool{})
+			eng := &agent.Engine{LLM: provider, Tools: reg, MaxSteps: 4, System: "You are a load test agent."}
+			_, err := eng.Run(ctx, o.prompt, nil)
+			return err
+		}, nil
+	}
+
+	headers := http.Header{}
+	for _, h := range o.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q (want 'Name: value')", h)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: o.concurrency}}
+	return func(ctx context.Context, worker int) error {
+		body, _ := json.Marshal(map[string]string{
+			"prompt":     o.prompt,
+			"session_id": fmt.Sprintf("loadgen-%d", worker),
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header = headers.Clone()
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}, nil
+}
+
+// lookupTool is the tool the simulated provider calls during -inprocess runs.
+type lookupTool struct{}
+
+func (lookupTool) Name() string { return "lookup" }
+func (lookupTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"description": "Look up a value.",
+		"parameters":  map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string"}}},
+	}
+}
+func (lookupTool) Call(context.Context, json.RawMessage) (any, error) {
+	return map[string]any{"ok": true}, nil
+}
+
+type results struct {
+	latencies []time.Duration
+	errors    map[string]int
+	elapsed   time.Duration
+	workers   int
+	mallocs   uint64
+	allocated uint64
+}
+
+func drive(ctx context.Context, o options, do func(context.Context, int) error) results {
+	if o.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.duration)
+		defer cancel()
+	}
+
+	var issued atomic.Int64
+	next := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		return o.duration > 0 || issued.Add(1) <= int64(o.requests)
+	}
+
+	var (
+		mu  sync.Mutex
+		res = results{errors: map[string]int{}, workers: o.concurrency}
+		wg  sync.WaitGroup
+	)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+	for w := range o.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				reqCtx, cancel := context.WithTimeout(ctx, o.timeout)
+				t0 := time.Now()
+				err := do(reqCtx, w)
+				d := time.Since(t0)
+				cancel()
+				// Requests cut short by -d or Ctrl-C are not failures.
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				mu.Lock()
+				if err != nil {
+					res.errors[err.Error()]++
+				} else {
+					res.latencies = append(res.latencies, d)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	res.elapsed = time.Since(started)
+	runtime.ReadMemStats(&after)
+	res.mallocs = after.Mallocs - before.Mallocs
+	res.allocated = after.TotalAlloc - before.TotalAlloc
+	return res
+}
+
+type report struct {
+	Requests     int            `json:"requests"`
+	Errors       int            `json:"errors"`
+	ErrorCounts  map[string]int `json:"error_counts,omitempty"`
+	Concurrency  int            `json:"concurrency"`
+	ElapsedMS    float64        `json:"elapsed_ms"`
+	Throughput   float64        `json:"requests_per_second"`
+	MinMS        float64        `json:"min_ms"`
+	MeanMS       float64        `json:"mean_ms"`
+	P50MS        float64        `json:"p50_ms"`
+	P95MS        float64        `json:"p95_ms"`
+	P99MS        float64        `json:"p99_ms"`
+	MaxMS        float64        `json:"max_ms"`
+	AllocsPerOp  uint64         `json:"allocs_per_op"`
+	BytesPerOp   uint64         `json:"bytes_per_op"`
+	TotalAllocMB float64        `json:"total_alloc_mb"`
+}
+
+func (r results) report() report {
+	errs := 0
+	for _, n := range r.errors {
+		errs += n
+	}
+	rep := report{
+		Requests:     len(r.latencies) + errs,
+		Errors:       errs,
+		ErrorCounts:  r.errors,
+		Concurrency:  r.workers,
+		ElapsedMS:    ms(r.elapsed),
+		TotalAllocMB: float64(r.allocated) / (1 << 20),
+	}
+	if r.elapsed > 0 {
+		rep.Throughput = float64(rep.Requests) / r.elapsed.Seconds()
+	}
+	if rep.Requests > 0 {
+		rep.AllocsPerOp = r.mallocs / uint64(rep.Requests)
+		rep.BytesPerOp = r.allocated / uint64(rep.Requests)
+	}
+	if len(r.latencies) == 0 {
+		return rep
+	}
+	lat := slices.Clone(r.latencies)
+	slices.Sort(lat)
+	var sum time.Duration
+	for _, d := range lat {
+		sum += d
+	}
+	rep.MinMS = ms(lat[0])
+	rep.MaxMS = ms(lat[len(lat)-1])
+	rep.MeanMS = ms(sum / time.Duration(len(lat)))
+	rep.P50MS = ms(percentile(lat, 50))
+	rep.P95MS = ms(percentile(lat, 95))
+	rep.P99MS = ms(percentile(lat, 99))
+	return rep
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func (r report) print(w io.Writer) {
+	fmt.Fprintf(w, "requests:    %d (%d errors) with %d workers in %.0fms\n", r.Requests, r.Errors, r.Concurrency, r.ElapsedMS)
+	fmt.Fprintf(w, "throughput:  %.1f req/s\n", r.Throughput)
+	fmt.Fprintf(w, "latency:     min %.2fms  mean %.2fms  p50 %.2fms  p95 %.2fms  p99 %.2fms  max %.2fms\n",
+		r.MinMS, r.MeanMS, r.P50MS, r.P95MS, r.P99MS, r.MaxMS)
+	fmt.Fprintf(w, "allocations: %d allocs/op  %d B/op  %.1f MB total (this process)\n", r.AllocsPerOp, r.BytesPerOp, r.TotalAllocMB)
+	for _, msg := range slices.Sorted(maps.Keys(r.ErrorCounts)) {
+		fmt.Fprintf(w, "error x%d:   %s\n", r.ErrorCounts[msg], msg)
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"manifold/internal/manifoldcli"
+)
+
+func main() {
+	os.Exit(manifoldcli.Main(os.Args[1:]))
+}
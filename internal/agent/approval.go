@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+	"manifold/internal/tools"
+)
+
+// ApprovalDecision is the outcome of asking a ToolApprover about a tool call.
+type ApprovalDecision struct {
+	Approved bool
+	Reason   string
+	// Reviewed is set when a person, rather than policy, approved the call.
+	Reviewed bool
+}
+
+// ToolApprover gates tool calls on an external, typically human, decision.
+// ApproveToolCall blocks until the call is decided and must approve calls to
+// tools that need no approval immediately. Implementations turn timeouts and
+// cancellation into denials.
+type ToolApprover interface {
+	ApproveToolCall(ctx context.Context, tc llm.ToolCall) ApprovalDecision
+}
+
+// approveToolCall consults e.ToolApprover and returns the tool payload to
+// report in place of the result when the call is denied. Calls a person
+// approved run with a context marked by tools.WithApproval.
+func (e *Engine) approveToolCall(ctx context.Context, tc llm.ToolCall) (context.Context, []byte, bool) {
+	if e.ToolApprover == nil {
+		return ctx, nil, true
+	}
+	d := e.ToolApprover.ApproveToolCall(ctx, tc)
+	if d.Approved {
+		if d.Reviewed {
+			ctx = tools.WithApproval(ctx, tc.Name)
+		}
+		return ctx, nil, true
+	}
+	observability.LoggerWithTrace(ctx).Info().Str("tool", tc.Name).Str("tool_id", tc.ID).Str("reason", d.Reason).Msg("engine_tool_call_denied")
+	msg := "tool call denied"
+	if d.Reason != "" {
+		msg += ": " + d.Reason
+	}
+	return ctx, tools.ErrorPayload(tools.NewError(tools.ErrPermissionDenied, msg)), false
+}
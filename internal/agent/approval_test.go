@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"manifold/internal/llm"
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+type fixedApprover struct {
+	decision ApprovalDecision
+	asked    []string
+}
+
+func (a *fixedApprover) ApproveToolCall(_ context.Context, tc llm.ToolCall) ApprovalDecision {
+	a.asked = append(a.asked, tc.Name)
+	return a.decision
+}
+
+func TestToolApproverGatesDispatch(t *testing.T) {
+	t.Parallel()
+
+	for _, approved := range []bool{true, false} {
+		tool := &countingTool{name: "run_cli"}
+		reg := tools.NewRegistry()
+		reg.Register(tool)
+		approver := &fixedApprover{decision: ApprovalDecision{Approved: approved, Reason: "not now"}}
+		var results []string
+		e := &Engine{
+			LLM:          &testhelpers.ToolLoopProvider{ToolName: "run_cli", Answer: "done"},
+			Tools:        reg,
+			MaxSteps:     3,
+			ToolApprover: approver,
+			OnTool:       func(_ string, _ []byte, result []byte, _ string) { results = append(results, string(result)) },
+		}
+		if _, err := e.Run(context.Background(), "list files", nil); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(approver.asked) != 1 || approver.asked[0] != "run_cli" {
+			t.Fatalf("approver asked about %v", approver.asked)
+		}
+		if approved != (tool.calls == 1) {
+			t.Fatalf("approved=%v but tool ran %d times", approved, tool.calls)
+		}
+		if !approved && (len(results) != 1 || !strings.Contains(results[0], "tool call denied: not now")) {
+			t.Fatalf("expected denial payload, got %v", results)
+		}
+	}
+}
+
+type approvalProbeTool struct{ approved []bool }
+
+func (t *approvalProbeTool) Name() string { return "email_send" }
+func (t *approvalProbeTool) JSONSchema() map[string]any {
+	return map[string]any{"description": "probe"}
+}
+func (t *approvalProbeTool) Call(ctx context.Context, _ json.RawMessage) (any, error) {
+	t.approved = append(t.approved, tools.Approved(ctx, "email_send"))
+	return map[string]any{"ok": true}, nil
+}
+
+func TestReviewedApprovalMarksToolContext(t *testing.T) {
+	t.Parallel()
+
+	for _, reviewed := range []bool{true, false} {
+		probe := &approvalProbeTool{}
+		reg := tools.NewRegistry()
+		reg.Register(probe)
+		e := &Engine{
+			LLM:          &testhelpers.ToolLoopProvider{ToolName: "email_send", Answer: "done"},
+			Tools:        reg,
+			MaxSteps:     3,
+			ToolApprover: &fixedApprover{decision: ApprovalDecision{Approved: true, Reviewed: reviewed}},
+		}
+		if _, err := e.Run(context.Background(), "send it", nil); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(probe.approved) != 1 || probe.approved[0] != reviewed {
+			t.Fatalf("reviewed=%v: tool saw approval %v", reviewed, probe.approved)
+		}
+	}
+}
+
+func TestQuotaCheckStopsRunBeforeModelRequest(t *testing.T) {
+	t.Parallel()
+
+	errQuota := errors.New("monthly token quota exceeded")
+	tool := &countingTool{name: "run_cli"}
+	reg := tools.NewRegistry()
+	reg.Register(tool)
+	checks := 0
+	e := &Engine{
+		LLM:      &testhelpers.ToolLoopProvider{ToolName: "run_cli", Answer: "done"},
+		Tools:    reg,
+		MaxSteps: 3,
+		// Allow the first step, then refuse.
+		QuotaCheck: func(context.Context) error {
+			checks++
+			if checks > 1 {
+				return errQuota
+			}
+			return nil
+		},
+	}
+	if _, err := e.Run(context.Background(), "list files", nil); !errors.Is(err, errQuota) {
+		t.Fatalf("Run error = %v, want quota error", err)
+	}
+	if checks != 2 || tool.calls != 1 {
+		t.Fatalf("checks=%d tool calls=%d", checks, tool.calls)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -14,12 +16,23 @@ import (
 	"manifold/internal/llm"
 	"manifold/internal/observability"
 	"manifold/internal/tools"
+	"manifold/internal/tools/resultcache"
 	"manifold/internal/tools/tts"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// agentTracerName is the tracer scope of the engine's tool call spans.
+const agentTracerName = "internal/agent"
+
+// noFinalText is returned when the step budget runs out before the model
+// produces a final answer.
+const noFinalText = "(no final text — increase max steps or check logs)"
+
 type Engine struct {
 	LLM       llm.Provider
 	Tools     tools.Registry
@@ -53,10 +66,17 @@ type Engine struct {
 	// MaxSummaryChunkTokens caps the size of the summary prompt (older
 	// conversation) in tokens.
 	SummaryMaxSummaryChunkTokens int
+	// ToolResultRelevance, if set, compresses or drops older tool results
+	// that are least relevant to the latest user message before falling
+	// back to summarization.
+	ToolResultRelevance *ToolResultRelevance
 	// Evolving memory configuration (Search → Synthesis → Evolve)
 	EvolvingMemory  *memory.EvolvingMemory  // nil = disabled
 	ReMemEnabled    bool                    // enable Think-Act-Refine mode
 	ReMemController *memory.ReMemController // nil unless ReMemEnabled
+	// LongTermMemory, when set, recalls user-scoped facts from earlier
+	// sessions into the system prompt and extracts new ones after each run.
+	LongTermMemory *memory.LongTermMemory
 	// OnAssistant, if set, is called with each assistant message the provider
 	// returns (including those containing tool calls and the final answer).
 	OnAssistant func(llm.Message)
@@ -72,10 +92,22 @@ type Engine struct {
 	// JSON arguments provided by the model (may still be partial JSON in some
 	// provider streaming implementations, but are generally complete here).
 	OnToolStart func(toolName string, args []byte, toolID string)
+	// OnToolUsage, if set, is called after each tool call or delegated agent
+	// run with its duration and outcome. Unlike OnTool it is not a per-request
+	// UI callback, so cloned engines keep it.
+	OnToolUsage func(ctx context.Context, u ToolUsage)
 	// OnTurnMessage, if set, is called for every message added to the conversation
 	// during this turn (including intermediate assistant messages with tool calls
 	// and tool response messages). This enables full conversation history capture.
 	OnTurnMessage func(llm.Message)
+	// OnStepContext, if set, is called immediately before each provider request
+	// with the step index and a copy of the exact messages being sent (after
+	// summarization and compaction). Useful for inspecting runs after the fact.
+	OnStepContext func(step int, msgs []llm.Message)
+	// OnStepEvent, if set, receives a StepEvent for every model request and
+	// response, tool call start and result, and summary compaction. Tool
+	// events may be reported from concurrent goroutines.
+	OnStepEvent func(StepEvent)
 	// OnSummaryTriggered, if set, is invoked when conversation summarization is triggered
 	// due to the message history exceeding the token budget. Parameters include:
 	// inputTokens, tokenBudget, messageCount, and messagesBeingSummarized.
@@ -88,7 +120,43 @@ type Engine struct {
 	Tokenizer llm.Tokenizer
 	// TokenizationFallbackToHeuristic allows falling back to heuristic on tokenization errors.
 	TokenizationFallbackToHeuristic bool
-	toolCallSeq                     uint64
+	// ToolCache, if set, is consulted before dispatching cacheable tools and
+	// updated with their results. nil disables caching.
+	ToolCache *resultcache.Cache
+	// Verifier, if set, checks the final answer of Run and RunStream. When it
+	// fails and EscalationModel is set, the final synthesis is re-run once
+	// with EscalationModel on the same provider.
+	Verifier        Verifier
+	EscalationModel string
+	// OnEscalation, if set, is called when a final answer fails verification.
+	OnEscalation func(Escalation)
+	// ToolApprover, if set, is consulted before every tool call and agent
+	// delegation; denied calls return an error payload to the model.
+	ToolApprover ToolApprover
+	// QuotaCheck, if set, is called before every step's model request and,
+	// in EngineModePlanExecute, before planning and the final synthesis. A
+	// non-nil error ends the run with it.
+	QuotaCheck func(ctx context.Context) error
+	// Mode selects the run strategy: EngineModeReAct (the default when
+	// empty) or EngineModePlanExecute.
+	Mode string
+	// Planner, Executor, and Critic drive EngineModePlanExecute. nil uses
+	// the LLM-backed defaults; DisableCritic skips step review entirely.
+	Planner       Planner
+	Executor      StepExecutor
+	Critic        Critic
+	DisableCritic bool
+	// MaxPlanSteps caps the steps the default planner may emit (default 8).
+	MaxPlanSteps int
+	// MaxStepRetries is how often a step rejected by the critic is retried
+	// with its feedback (default 1; negative disables retries).
+	MaxStepRetries int
+	// OnPlanEvent, if set, receives plan and step progress in
+	// EngineModePlanExecute. It may be called from concurrent goroutines.
+	OnPlanEvent func(PlanEvent)
+
+	toolCallSeq    uint64
+	toolCallPrefix string
 }
 
 // AttachTokenizer wires an accurate tokenizer into the engine when the provider exposes one.
@@ -103,6 +171,9 @@ func (e *Engine) AttachTokenizer(provider any, cache *llm.TokenCache) {
 		Tokenizer(cache *llm.TokenCache) llm.Tokenizer
 	}
 
+	if lp, ok := provider.(llm.Provider); ok {
+		provider = llm.Unwrap(lp)
+	}
 	p, ok := provider.(tokenizableProvider)
 	if !ok {
 		return
@@ -163,6 +234,9 @@ func (e *Engine) Run(ctx context.Context, userInput string, history []llm.Messag
 	if e.ReMemEnabled && e.ReMemController != nil {
 		return e.runWithReMem(ctx, userInput, history)
 	}
+	if e.Mode == EngineModePlanExecute {
+		return e.runPlanExecute(ctx, userInput, history, false)
+	}
 
 	msgs := BuildInitialLLMMessages(e.System, userInput, history)
 
@@ -173,18 +247,21 @@ func (e *Engine) Run(ctx context.Context, userInput string, history []llm.Messag
 	} else {
 		log.Debug().Bool("enabled", false).Msg("evolving_memory_disabled")
 	}
+	msgs = e.augmentWithLongTermMemory(ctx, userInput, msgs)
 
 	// Possibly summarize older history to avoid unbounded token growth.
 	if e.SummaryEnabled {
 		msgs = e.maybeSummarize(ctx, msgs)
 	}
 
-	final, err := e.runLoop(ctx, msgs)
+	final, msgs, err := e.runLoop(ctx, msgs)
 	if err != nil {
 		return "", err
 	}
+	final = e.verifyAndEscalate(ctx, userInput, final, msgs)
 
 	e.storeSuccessfulExperience(ctx, userInput, final)
+	e.rememberLongTerm(ctx, userInput, final)
 
 	return final, nil
 }
@@ -196,6 +273,9 @@ func (e *Engine) RunStream(ctx context.Context, userInput string, history []llm.
 	if e.ReMemEnabled && e.ReMemController != nil {
 		return e.runWithReMem(ctx, userInput, history)
 	}
+	if e.Mode == EngineModePlanExecute {
+		return e.runPlanExecute(ctx, userInput, history, true)
+	}
 
 	msgs := BuildInitialLLMMessages(e.System, userInput, history)
 
@@ -206,18 +286,21 @@ func (e *Engine) RunStream(ctx context.Context, userInput string, history []llm.
 	} else {
 		log.Debug().Bool("enabled", false).Msg("evolving_memory_disabled_stream")
 	}
+	msgs = e.augmentWithLongTermMemory(ctx, userInput, msgs)
 
 	// Possibly summarize older history to avoid unbounded token growth.
 	if e.SummaryEnabled {
 		msgs = e.maybeSummarize(ctx, msgs)
 	}
 
-	final, err := e.runStreamLoop(ctx, msgs)
+	final, msgs, err := e.runStreamLoop(ctx, msgs)
 	if err != nil {
 		return "", err
 	}
+	final = e.verifyAndEscalate(ctx, userInput, final, msgs)
 
 	e.storeSuccessfulExperience(ctx, userInput, final)
+	e.rememberLongTerm(ctx, userInput, final)
 
 	return final, nil
 }
@@ -293,18 +376,28 @@ func (h *streamHandler) OnThoughtSignature(sig string) {
 
 func (e *Engine) model() string { return e.Model }
 
+// checkQuota consults e.QuotaCheck before a model request.
+func (e *Engine) checkQuota(ctx context.Context) error {
+	if e.QuotaCheck == nil {
+		return nil
+	}
+	return e.QuotaCheck(ctx)
+}
+
 // runLoop contains the core non-streaming agent step loop shared by Run.
-// It returns the final assistant content or an error.
-func (e *Engine) runLoop(ctx context.Context, msgs []llm.Message) (string, error) {
+// It returns the final assistant content and the full conversation, or an
+// error.
+func (e *Engine) runLoop(ctx context.Context, msgs []llm.Message) (string, []llm.Message, error) {
 	log := observability.LoggerWithTrace(ctx)
 	var final string
 
 	for step := 0; step < e.MaxSteps; step++ {
 		log.Debug().Int("step", step).Int("history", len(msgs)).Msg("engine_step_start")
+		stepCtx := withEventStep(ctx, step)
 
 		// Re-summarize if context has grown too large during tool execution
 		if e.SummaryEnabled && step > 0 {
-			msgs = e.maybeSummarize(ctx, msgs)
+			msgs = e.maybeSummarize(stepCtx, msgs)
 		}
 
 		// Capture tool schemas once per step so we can log what the model sees.
@@ -315,10 +408,19 @@ func (e *Engine) runLoop(ctx context.Context, msgs []llm.Message) (string, error
 		}
 		log.Info().Strs("tools_sent_to_llm", toolNames).Msg("engine_tools_before_chat")
 
-		msg, err := e.LLM.Chat(ctx, msgs, schemas, e.model())
+		if err := e.checkQuota(ctx); err != nil {
+			return "", nil, err
+		}
+		e.emitStepContext(step, msgs)
+		var msg llm.Message
+		err := e.observeModelRequest(stepCtx, msgs, func(ctx context.Context) (int, error) {
+			var err error
+			msg, err = e.LLM.Chat(ctx, msgs, schemas, e.model())
+			return len(msg.ToolCalls), err
+		})
 		if err != nil {
 			log.Error().Err(err).Int("step", step).Msg("engine_step_error")
-			return "", err
+			return "", nil, err
 		}
 
 		msg.ToolCalls = e.ensureToolCallIDs(msgs, msg.ToolCalls)
@@ -337,26 +439,29 @@ func (e *Engine) runLoop(ctx context.Context, msgs []llm.Message) (string, error
 		}
 
 		log.Info().Int("step", step).Int("tool_calls", len(msg.ToolCalls)).Msg("engine_tool_calls")
-		msgs = e.dispatchTools(ctx, msgs, msg.ToolCalls)
+		msgs = e.dispatchTools(stepCtx, msgs, msg.ToolCalls)
 	}
 
 	if final == "" {
-		final = "(no final text — increase max steps or check logs)"
+		final = noFinalText
 	}
 
-	return final, nil
+	return final, msgs, nil
 }
 
 // runStreamLoop contains the core streaming agent step loop shared by RunStream.
-// It returns the final assistant content or an error.
-func (e *Engine) runStreamLoop(ctx context.Context, msgs []llm.Message) (string, error) {
+// It returns the final assistant content and the full conversation, or an
+// error.
+func (e *Engine) runStreamLoop(ctx context.Context, msgs []llm.Message) (string, []llm.Message, error) {
 	log := observability.LoggerWithTrace(ctx)
 	var final string
 
 	for step := 0; step < e.MaxSteps; step++ {
+		stepCtx := withEventStep(ctx, step)
+
 		// Re-summarize if context has grown too large during tool execution
 		if e.SummaryEnabled && step > 0 {
-			msgs = e.maybeSummarize(ctx, msgs)
+			msgs = e.maybeSummarize(stepCtx, msgs)
 		}
 
 		// Accumulate streaming content and tool calls for this step
@@ -400,9 +505,17 @@ func (e *Engine) runStreamLoop(ctx context.Context, msgs []llm.Message) (string,
 		}
 		log.Info().Strs("tools_sent_to_llm_stream", toolNames).Msg("engine_tools_before_stream")
 
-		if err := e.LLM.ChatStream(ctx, msgs, schemas, e.model(), handler); err != nil {
+		if err := e.checkQuota(ctx); err != nil {
+			return "", nil, err
+		}
+		e.emitStepContext(step, msgs)
+		err := e.observeModelRequest(stepCtx, msgs, func(ctx context.Context) (int, error) {
+			err := e.LLM.ChatStream(ctx, msgs, schemas, e.model(), handler)
+			return len(accumulatedToolCalls), err
+		})
+		if err != nil {
 			log.Error().Err(err).Int("step", step).Msg("engine_stream_step_error")
-			return "", err
+			return "", nil, err
 		}
 
 		accumulatedToolCalls = e.ensureToolCallIDs(msgs, accumulatedToolCalls)
@@ -429,14 +542,20 @@ func (e *Engine) runStreamLoop(ctx context.Context, msgs []llm.Message) (string,
 		}
 
 		log.Info().Int("step", step).Int("tool_calls", len(msg.ToolCalls)).Msg("engine_stream_tool_calls")
-		msgs = e.dispatchTools(ctx, msgs, msg.ToolCalls)
+		msgs = e.dispatchTools(stepCtx, msgs, msg.ToolCalls)
 	}
 
 	if final == "" {
-		final = "(no final text — increase max steps or check logs)"
+		final = noFinalText
 	}
 
-	return final, nil
+	return final, msgs, nil
+}
+
+func (e *Engine) emitStepContext(step int, msgs []llm.Message) {
+	if e.OnStepContext != nil {
+		e.OnStepContext(step, slices.Clone(msgs))
+	}
 }
 
 func (e *Engine) ensureToolCallIDs(msgs []llm.Message, toolCalls []llm.ToolCall) []llm.ToolCall {
@@ -476,7 +595,7 @@ func (e *Engine) ensureToolCallIDs(msgs []llm.Message, toolCalls []llm.ToolCall)
 
 func (e *Engine) nextToolCallID() string {
 	seq := atomic.AddUint64(&e.toolCallSeq, 1)
-	return fmt.Sprintf("engine-call-%d", seq)
+	return fmt.Sprintf("engine-call-%s%d", e.toolCallPrefix, seq)
 }
 
 // dispatchTools executes a batch of tool calls, appending their tool messages to msgs
@@ -524,6 +643,7 @@ func (e *Engine) dispatchTools(ctx context.Context, msgs []llm.Message, toolCall
 		if e.OnToolStart != nil {
 			e.OnToolStart(tc.Name, tc.Args, tc.ID)
 		}
+		e.emitStepEvent(ctx, StepEvent{Type: StepEventToolStart, Tool: tc.Name, ToolCallID: tc.ID})
 
 		sem <- struct{}{}
 		wg.Add(1)
@@ -545,9 +665,26 @@ func (e *Engine) dispatchTools(ctx context.Context, msgs []llm.Message, toolCall
 }
 
 func (e *Engine) executeToolCall(ctx context.Context, tc llm.ToolCall) llm.Message {
+	requested := time.Now()
+	ctx, denied, ok := e.approveToolCall(ctx, tc)
+	if !ok {
+		e.emitToolResult(ctx, tc, requested, "tool call denied", false)
+		if e.OnTool != nil {
+			e.OnTool(tc.Name, tc.Args, denied, tc.ID)
+		}
+		return llm.Message{Role: "tool", Content: string(denied), ToolID: tc.ID}
+	}
+	ctx, span := otel.Tracer(agentTracerName).Start(ctx, "tool "+tc.Name, trace.WithAttributes(
+		attribute.String("tool.name", tc.Name),
+		attribute.String("tool.call_id", tc.ID),
+	))
+	defer span.End()
 	// Handle agent delegation as a first-class engine feature (not a tool).
+	start := time.Now()
 	if e.Delegator != nil && isAgentCall(tc.Name) {
-		payload := e.runDelegatedAgent(ctx, tc)
+		payload := tools.NormalizeErrorPayload(e.runDelegatedAgent(ctx, tc))
+		e.reportToolUsage(ctx, tc.Name, payload, time.Since(start))
+		e.emitToolResult(ctx, tc, start, "", false)
 		if e.OnTool != nil {
 			e.OnTool(tc.Name, tc.Args, payload, tc.ID)
 		}
@@ -555,10 +692,32 @@ func (e *Engine) executeToolCall(ctx context.Context, tc llm.ToolCall) llm.Messa
 	}
 
 	observability.LoggerWithTrace(ctx).Info().Str("tool", tc.Name).RawJSON("args", observability.RedactJSON(tc.Args)).Msg("engine_tool_call")
-	payload, err := e.Tools.Dispatch(ctx, tc.Name, tc.Args)
-	if err != nil {
-		payload = []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	payload, ok := e.ToolCache.Lookup(ctx, tc.Name, tc.Args)
+	span.SetAttributes(attribute.Bool("tool.cache_hit", ok))
+	var (
+		errText  string
+		timedOut bool
+	)
+	if ok {
+		observability.LoggerWithTrace(ctx).Debug().Str("tool", tc.Name).Msg("tool_cache_hit")
+	} else {
+		var err error
+		payload, err = e.Tools.Dispatch(ctx, tc.Name, tc.Args)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			errText = err.Error()
+			payload = tools.ErrorPayload(err)
+		} else if timedOut = tools.TimedOut(payload); timedOut {
+			span.SetStatus(codes.Error, "tool timed out")
+		} else {
+			e.ToolCache.Store(ctx, tc.Name, tc.Args, payload)
+			e.ToolCache.Observe(ctx, tc.Name, payload)
+		}
+		span.SetAttributes(attribute.Bool("tool.timed_out", timedOut))
+		e.reportToolUsage(ctx, tc.Name, payload, time.Since(start))
 	}
+	e.emitToolResult(ctx, tc, start, errText, timedOut)
 	if e.OnTool != nil {
 		e.OnTool(tc.Name, tc.Args, payload, tc.ID)
 	}
@@ -614,6 +773,12 @@ func (e *Engine) runDelegatedAgent(ctx context.Context, tc llm.ToolCall) []byte
 	}
 	result, err := e.Delegator.Run(ctx, req, e.AgentTracer)
 	if err != nil {
+		// Errors carrying an HTTP status, such as a saturated specialist,
+		// report it so the model can tell retryable failures apart.
+		var coded interface{ StatusCode() int }
+		if errors.As(err, &coded) {
+			return []byte(fmt.Sprintf(`{"ok":false,"agent":%q,"status":%d,"error":%q}`, req.AgentName, coded.StatusCode(), err.Error()))
+		}
 		return []byte(fmt.Sprintf(`{"ok":false,"agent":%q,"error":%q}`, req.AgentName, err.Error()))
 	}
 	out := map[string]any{"ok": true, "agent": req.AgentName, "output": result}
@@ -674,6 +839,14 @@ func (e *Engine) maybeSummarize(ctx context.Context, msgs []llm.Message) []llm.M
 		return msgs
 	}
 
+	// Shed low-relevance tool results before summarizing whole turns.
+	if e.ToolResultRelevance != nil {
+		msgs, inputTokens = e.compactToolResults(ctx, msgs, inputTokens, tokenBudget)
+		if inputTokens <= tokenBudget {
+			return msgs
+		}
+	}
+
 	log := observability.LoggerWithTrace(ctx)
 	log.Info().
 		Int("messages", len(msgs)).
@@ -733,8 +906,24 @@ func (e *Engine) maybeSummarize(ctx context.Context, msgs []llm.Message) []llm.M
 	if e.OnSummaryTriggered != nil {
 		e.OnSummaryTriggered(inputTokens, tokenBudget, len(msgs), len(toSummarize))
 	}
+	if e.OnStepEvent == nil {
+		return e.buildSummarizedMessages(ctx, sysMsg, toSummarize, recent, len(recent))
+	}
 
-	return e.buildSummarizedMessages(ctx, sysMsg, toSummarize, recent, len(recent))
+	began := time.Now()
+	uctx, usage := llm.WithUsage(ctx)
+	out := e.buildSummarizedMessages(uctx, sysMsg, toSummarize, recent, len(recent))
+	ev := StepEvent{
+		Type:               StepEventSummaryCompaction,
+		Model:              e.model(),
+		InputTokens:        inputTokens,
+		TokenBudget:        tokenBudget,
+		SummarizedMessages: len(toSummarize),
+		DurationMillis:     time.Since(began).Milliseconds(),
+	}
+	ev.PromptTokens, ev.CompletionTokens = usage.Tokens()
+	e.emitStepEvent(ctx, ev)
+	return out
 }
 
 // adjustCutIndexForToolDeps ensures that if the kept "recent" tail includes any
@@ -994,6 +1183,7 @@ func (e *Engine) runWithReMem(ctx context.Context, userInput string, history []l
 	if e.EvolvingMemory != nil {
 		msgs = e.augmentWithMemory(ctx, userInput, msgs)
 	}
+	msgs = e.augmentWithLongTermMemory(ctx, userInput, msgs)
 
 	// Possibly summarize older history
 	if e.SummaryEnabled {
@@ -1001,10 +1191,11 @@ func (e *Engine) runWithReMem(ctx context.Context, userInput string, history []l
 	}
 
 	// Run the streaming loop to generate actual response (preserves streaming behavior)
-	final, err := e.runStreamLoop(ctx, msgs)
+	final, msgs, err := e.runStreamLoop(ctx, msgs)
 	if err != nil {
 		return "", err
 	}
+	final = e.verifyAndEscalate(ctx, userInput, final, msgs)
 
 	// Store the experience with reasoning trace AFTER we have the actual response
 	feedback := "success" // default; in practice could be derived from evaluation
@@ -1020,6 +1211,7 @@ func (e *Engine) runWithReMem(ctx context.Context, userInput string, history []l
 		}
 		log.Info().Str("feedback", fb).Int("reasoning_steps", len(traceMsgs)).Msg("remem_experience_stored")
 	}(bgCtx, userInput, final, feedback, reasoningTrace)
+	e.rememberLongTerm(ctx, userInput, final)
 
 	return final, nil
 }
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+func newBenchEngine() *Engine {
+	reg := tools.NewRegistry()
+	reg.Register(&countingTool{name: "lookup"})
+	return &Engine{
+		LLM:      &testhelpers.ToolLoopProvider{ToolName: "lookup", ToolArgs: `{"q":"status"}`, Answer: "done"},
+		Tools:    reg,
+		MaxSteps: 4,
+		System:   "You are a benchmark agent.",
+	}
+}
+
+// BenchmarkEngineRun measures one full agent turn: a tool call round-trip
+// followed by the final answer.
+func BenchmarkEngineRun(b *testing.B) {
+	e := newBenchEngine()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := e.Run(ctx, "check the status", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEngineRunParallel runs independent engines concurrently, as the
+// server does for simultaneous /agent/run requests.
+func BenchmarkEngineRunParallel(b *testing.B) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		e := newBenchEngine()
+		for pb.Next() {
+			if _, err := e.Run(ctx, "check the status", nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
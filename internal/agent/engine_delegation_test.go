@@ -52,3 +52,28 @@ func TestRunDelegatedAgentCarriesSessionID(t *testing.T) {
 		t.Fatal("expected payload from delegated run")
 	}
 }
+
+type busyError struct{}
+
+func (busyError) Error() string   { return "specialist writer is at capacity" }
+func (busyError) StatusCode() int { return 503 }
+
+func TestRunDelegatedAgentReportsErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	eng := &Engine{Delegator: &captureDelegator{err: busyError{}}}
+	args, _ := json.Marshal(map[string]any{"agent_name": "writer", "prompt": "draft this"})
+	payload := eng.runDelegatedAgent(context.Background(), llm.ToolCall{ID: "tool-1", Name: "agent_call", Args: args})
+
+	var out struct {
+		OK     bool   `json:"ok"`
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		t.Fatalf("decode payload %s: %v", payload, err)
+	}
+	if out.OK || out.Status != 503 || out.Error != "specialist writer is at capacity" {
+		t.Fatalf("unexpected payload: %s", payload)
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"manifold/internal/llm"
+)
+
+func TestOnStepContextReceivesProviderMessages(t *testing.T) {
+	t.Parallel()
+
+	for _, stream := range []bool{false, true} {
+		e := newBenchEngine()
+		var steps [][]llm.Message
+		e.OnStepContext = func(step int, msgs []llm.Message) {
+			if step != len(steps) {
+				t.Fatalf("step=%d want %d", step, len(steps))
+			}
+			steps = append(steps, msgs)
+		}
+		run := e.Run
+		if stream {
+			run = e.RunStream
+		}
+		if _, err := run(context.Background(), "check the status", nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 2 {
+			t.Fatalf("stream=%v: got %d steps want 2", stream, len(steps))
+		}
+		// Step 0 is system + user; step 1 adds the assistant tool call and tool result.
+		if len(steps[0]) != 2 || len(steps[1]) != 4 {
+			t.Fatalf("stream=%v: unexpected context sizes %d, %d", stream, len(steps[0]), len(steps[1]))
+		}
+		if steps[1][3].Role != "tool" {
+			t.Fatalf("stream=%v: last message role %q want tool", stream, steps[1][3].Role)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package agent
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"manifold/internal/llm"
 )
@@ -73,3 +75,109 @@ func TestMaybeSummarizeKeepsLatestUserInRecentTail(t *testing.T) {
 		t.Fatalf("expected assistant/tool to follow latest user, got %#v", summarized)
 	}
 }
+
+func TestMaybeSummarizeDropsIrrelevantToolResultsFirst(t *testing.T) {
+	t.Parallel()
+
+	embed := func(_ context.Context, texts []string) ([][]float32, error) {
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			lower := strings.ToLower(text)
+			out[i] = []float32{0, 0}
+			if strings.Contains(lower, "paris") {
+				out[i][0] = 1
+			}
+			if strings.Contains(lower, "stock") {
+				out[i][1] = 1
+			}
+		}
+		return out, nil
+	}
+	eng := &Engine{
+		LLM:                             &summaryOnlyProvider{},
+		SummaryEnabled:                  true,
+		ContextWindowTokens:             600,
+		SummaryReserveBufferTokens:      100,
+		SummaryMinKeepLastMessages:      2,
+		TokenizationFallbackToHeuristic: true,
+		ToolResultRelevance:             &ToolResultRelevance{Embed: embed},
+	}
+
+	relevant := strings.Repeat("paris weather forecast ", 10)
+	irrelevant := strings.Repeat("stock prices rallied ", 100)
+	msgs := []llm.Message{
+		{Role: "system", Content: "You are a helpful assistant."},
+		{Role: "user", Content: "What is the weather in Paris?"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "web_search", ID: "call_1"}}},
+		{Role: "tool", ToolID: "call_1", Content: relevant},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "web_search", ID: "call_2"}}},
+		{Role: "tool", ToolID: "call_2", Content: irrelevant},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "web_search", ID: "call_3"}}},
+		{Role: "tool", ToolID: "call_3", Content: "stock tickers"},
+	}
+
+	got := eng.maybeSummarize(context.Background(), msgs)
+
+	if len(got) != len(msgs) {
+		t.Fatalf("expected compaction without summarization, got %#v", got)
+	}
+	if got[3].Content != relevant {
+		t.Fatalf("relevant tool result should be kept, got %q", got[3].Content)
+	}
+	if got[5].Content != toolResultDroppedMarker {
+		t.Fatalf("irrelevant tool result should be dropped, got %q", got[5].Content)
+	}
+	if got[7].Content != "stock tickers" {
+		t.Fatalf("current step's tool result should be untouched, got %q", got[7].Content)
+	}
+	if msgs[5].Content != irrelevant {
+		t.Fatal("input messages must not be modified")
+	}
+}
+
+func TestCompactToolResultsKeepsUTF8Valid(t *testing.T) {
+	t.Parallel()
+
+	var embedded []string
+	embed := func(_ context.Context, texts []string) ([][]float32, error) {
+		embedded = append(embedded, texts...)
+		out := make([][]float32, len(texts))
+		for i := range texts {
+			out[i] = []float32{1, 0}
+			if i > 0 {
+				// Relevant enough to compress rather than drop.
+				out[i] = []float32{0.3, 0.954}
+			}
+		}
+		return out, nil
+	}
+	eng := &Engine{
+		TokenizationFallbackToHeuristic: true,
+		ToolResultRelevance:             &ToolResultRelevance{Embed: embed, CompressChars: 401},
+	}
+
+	// Every rune is three bytes, so byte offsets 401 and 4000 fall inside one.
+	result := strings.Repeat("日本語", 2000)
+	msgs := []llm.Message{
+		{Role: "user", Content: "Übersetze den Text"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "web_fetch", ID: "call_1"}}},
+		{Role: "tool", ToolID: "call_1", Content: result},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "web_fetch", ID: "call_2"}}},
+		{Role: "tool", ToolID: "call_2", Content: "ok"},
+	}
+
+	got, _ := eng.compactToolResults(context.Background(), msgs, 100000, 1)
+
+	compressed := strings.TrimSuffix(got[2].Content, toolResultCompressedMarker)
+	if compressed == got[2].Content || !utf8.ValidString(compressed) || len(compressed) != 399 {
+		t.Fatalf("expected a valid 399-byte prefix, got %d bytes (valid=%v)", len(compressed), utf8.ValidString(compressed))
+	}
+	for _, text := range embedded {
+		if !utf8.ValidString(text) {
+			t.Fatalf("invalid UTF-8 sent for embedding: %q", text[len(text)-3:])
+		}
+	}
+	if len(embedded) != 2 || len(embedded[1]) != 3999 {
+		t.Fatalf("expected the tool result clipped to 3999 bytes for embedding, got %d texts", len(embedded))
+	}
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+	"manifold/internal/tools/resultcache"
+)
+
+type countingTool struct {
+	name  string
+	calls int
+}
+
+func (t *countingTool) Name() string               { return t.name }
+func (t *countingTool) JSONSchema() map[string]any { return map[string]any{"description": t.name} }
+func (t *countingTool) Call(context.Context, json.RawMessage) (any, error) {
+	t.calls++
+	return map[string]any{"ok": true, "n": t.calls}, nil
+}
+
+func TestExecuteToolCallUsesToolCache(t *testing.T) {
+	t.Parallel()
+
+	fetch := &countingTool{name: "web_fetch"}
+	write := &countingTool{name: "file_write"}
+	reg := tools.NewRegistry()
+	reg.Register(fetch)
+	reg.Register(write)
+
+	cache, err := resultcache.NewWithBackend(resultcache.NewLRU(10), "", config.ToolCacheConfig{
+		Enabled:           true,
+		DefaultTTLSeconds: 60,
+		Tools:             map[string]config.ToolCachePolicy{"web_fetch": {InvalidateOn: []string{"file_write"}}},
+	})
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	eng := &Engine{Tools: reg, ToolCache: cache}
+	ctx := context.Background()
+	call := llm.ToolCall{ID: "t1", Name: "web_fetch", Args: json.RawMessage(`{"url":"https://example.com"}`)}
+
+	first := eng.executeToolCall(ctx, call)
+	second := eng.executeToolCall(ctx, call)
+	if fetch.calls != 1 || first.Content != second.Content {
+		t.Fatalf("expected cached second call, got %d calls (%s vs %s)", fetch.calls, first.Content, second.Content)
+	}
+
+	eng.executeToolCall(ctx, llm.ToolCall{ID: "t2", Name: "file_write", Args: json.RawMessage(`{}`)})
+	eng.executeToolCall(ctx, call)
+	if fetch.calls != 2 {
+		t.Fatalf("expected file_write to invalidate web_fetch cache, got %d calls", fetch.calls)
+	}
+}
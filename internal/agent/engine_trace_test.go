@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"manifold/internal/observability"
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+func TestToolCallSpansJoinRunTrace(t *testing.T) {
+	observability.EnableRunTracing()
+
+	reg := tools.NewRegistry()
+	reg.Register(&countingTool{name: "run_cli"})
+	e := &Engine{
+		LLM:      &testhelpers.ToolLoopProvider{ToolName: "run_cli", Answer: "done"},
+		Tools:    reg,
+		MaxSteps: 3,
+	}
+	ctx := observability.WithRun(context.Background(), observability.RunInfo{RunID: "engine-trace-run", SessionID: "s1", UserID: 5})
+	if _, err := e.Run(ctx, "list files", nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	_, roots, _, ok := observability.RunTrace("engine-trace-run")
+	if !ok {
+		t.Fatal("no spans recorded for the run")
+	}
+	var tool *observability.RunSpan
+	for _, s := range roots {
+		if s.Name == "tool run_cli" {
+			tool = s
+		}
+	}
+	if tool == nil {
+		t.Fatalf("missing tool span in %+v", roots)
+	}
+	if tool.Attributes["tool.name"] != "run_cli" || tool.Attributes[observability.SessionIDAttr] != "s1" || tool.Attributes[observability.UserIDAttr] != "5" {
+		t.Fatalf("tool span attributes = %v", tool.Attributes)
+	}
+}
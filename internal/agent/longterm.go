@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"manifold/internal/agent/memory"
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// longTermMemoryTimeout bounds background fact extraction after a turn.
+const longTermMemoryTimeout = 2 * time.Minute
+
+// augmentWithLongTermMemory appends the user's recalled long-term facts to the
+// system prompt. Recall failures are logged and never block the run.
+func (e *Engine) augmentWithLongTermMemory(ctx context.Context, userInput string, msgs []llm.Message) []llm.Message {
+	if e.LongTermMemory == nil {
+		return msgs
+	}
+	log := observability.LoggerWithTrace(ctx)
+	userID, _ := llm.UserIDFromContext(ctx)
+	facts, err := e.LongTermMemory.Recall(ctx, userID, userInput)
+	if err != nil {
+		log.Warn().Err(err).Msg("long_term_memory_recall_failed")
+		return msgs
+	}
+	block := memory.FormatLongTermContext(facts)
+	if block == "" {
+		return msgs
+	}
+	log.Debug().Int("facts", len(facts)).Msg("long_term_memory_recalled")
+	const heading = "## What You Remember About This User\n\n"
+	for i := range msgs {
+		if msgs[i].Role == "system" {
+			msgs[i].Content += "\n\n" + heading + block
+			return msgs
+		}
+	}
+	return append([]llm.Message{{Role: "system", Content: heading + block}}, msgs...)
+}
+
+// rememberLongTerm extracts durable facts from a finished turn in the
+// background.
+func (e *Engine) rememberLongTerm(ctx context.Context, userInput, final string) {
+	if e.LongTermMemory == nil {
+		return
+	}
+	log := observability.LoggerWithTrace(ctx)
+	userID, _ := llm.UserIDFromContext(ctx)
+	bgCtx := llm.WithUserID(context.Background(), userID)
+	if span := trace.SpanFromContext(ctx); span != nil {
+		bgCtx = trace.ContextWithSpanContext(bgCtx, span.SpanContext())
+	}
+	sessionID := e.SessionID
+	go func() {
+		ctx, cancel := context.WithTimeout(bgCtx, longTermMemoryTimeout)
+		defer cancel()
+		n, err := e.LongTermMemory.Remember(ctx, userID, sessionID, userInput, final)
+		if err != nil {
+			log.Warn().Err(err).Msg("long_term_memory_remember_failed")
+			return
+		}
+		if n > 0 {
+			log.Info().Int("facts", n).Msg("long_term_memory_stored")
+		}
+	}()
+}
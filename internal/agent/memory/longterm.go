@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"manifold/internal/config"
+	"manifold/internal/embedding"
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+// longTermVectorPrefix namespaces long-term memory vectors so they can share
+// the vector store with RAG chunks.
+const longTermVectorPrefix = "ltm:"
+
+// LongTermVectorMatch is a similarity hit returned by a LongTermVectorIndex.
+type LongTermVectorMatch struct {
+	ID    string
+	Score float64
+}
+
+// LongTermVectorIndex is the subset of a vector store used by LongTermMemory.
+// It is defined here so the memory package does not depend on database
+// backends; agentd adapts databases.VectorStore to it.
+type LongTermVectorIndex interface {
+	Upsert(ctx context.Context, id string, vector []float32, metadata map[string]string) error
+	Delete(ctx context.Context, id string) error
+	SimilaritySearch(ctx context.Context, vector []float32, k int, filter map[string]string) ([]LongTermVectorMatch, error)
+}
+
+// LongTermMemoryConfig configures LongTermMemory.
+type LongTermMemoryConfig struct {
+	Store           persistence.LongTermMemoryStore
+	Index           LongTermVectorIndex
+	EmbeddingConfig config.EmbeddingConfig
+	// EmbedFn defaults to embedding.EmbedText.
+	EmbedFn EmbedFunc
+	// LLM and Model extract facts from finished turns.
+	LLM   llm.Provider
+	Model string
+	// TopK is the number of facts recalled per prompt (default 5).
+	TopK int
+	// MinScore drops recalled facts below this similarity (default 0).
+	MinScore float64
+	// MaxFactsPerTurn caps facts extracted from a single turn (default 3).
+	MaxFactsPerTurn int
+	// DuplicateThreshold skips new facts at least this similar to an
+	// existing one (default 0.92).
+	DuplicateThreshold float64
+}
+
+// LongTermMemory embeds notable facts from conversations into a vector index
+// and recalls them across sessions. Facts are scoped per user.
+type LongTermMemory struct {
+	store     persistence.LongTermMemoryStore
+	index     LongTermVectorIndex
+	embedCfg  config.EmbeddingConfig
+	embedFn   EmbedFunc
+	llm       llm.Provider
+	model     string
+	topK      int
+	minScore  float64
+	maxFacts  int
+	dupThresh float64
+}
+
+// NewLongTermMemory returns nil when the store or index is missing.
+func NewLongTermMemory(cfg LongTermMemoryConfig) *LongTermMemory {
+	if cfg.Store == nil || cfg.Index == nil {
+		return nil
+	}
+	lt := &LongTermMemory{
+		store:     cfg.Store,
+		index:     cfg.Index,
+		embedCfg:  cfg.EmbeddingConfig,
+		embedFn:   cfg.EmbedFn,
+		llm:       cfg.LLM,
+		model:     cfg.Model,
+		topK:      cfg.TopK,
+		minScore:  cfg.MinScore,
+		maxFacts:  cfg.MaxFactsPerTurn,
+		dupThresh: cfg.DuplicateThreshold,
+	}
+	if lt.embedFn == nil {
+		lt.embedFn = embedding.EmbedText
+	}
+	if lt.topK <= 0 {
+		lt.topK = 5
+	}
+	if lt.maxFacts <= 0 {
+		lt.maxFacts = 3
+	}
+	if lt.dupThresh <= 0 {
+		lt.dupThresh = 0.92
+	}
+	return lt
+}
+
+func longTermFilter(userID int64) map[string]string {
+	return map[string]string{"kind": "long_term_memory", "user_id": strconv.FormatInt(userID, 10)}
+}
+
+func (lt *LongTermMemory) embedOne(ctx context.Context, text string) ([]float32, error) {
+	vecs, err := lt.embedFn(ctx, lt.embedCfg, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, errors.New("long-term memory: empty embedding")
+	}
+	return vecs[0], nil
+}
+
+// Recall returns the user's facts most relevant to query, best first.
+func (lt *LongTermMemory) Recall(ctx context.Context, userID int64, query string) ([]persistence.LongTermMemory, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	vec, err := lt.embedOne(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := lt.index.SimilaritySearch(ctx, vec, lt.topK, longTermFilter(userID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]persistence.LongTermMemory, 0, len(hits))
+	for _, h := range hits {
+		if h.Score < lt.minScore {
+			continue
+		}
+		id, ok := strings.CutPrefix(h.ID, longTermVectorPrefix)
+		if !ok {
+			continue
+		}
+		m, err := lt.store.Get(ctx, userID, id)
+		if errors.Is(err, persistence.ErrNotFound) {
+			// The fact was deleted but its vector lingered; clean it up.
+			_ = lt.index.Delete(ctx, h.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// Add stores a fact for userID unless a near-duplicate already exists. It
+// reports whether the fact was stored.
+func (lt *LongTermMemory) Add(ctx context.Context, userID int64, sessionID, text string) (persistence.LongTermMemory, bool, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return persistence.LongTermMemory{}, false, nil
+	}
+	vec, err := lt.embedOne(ctx, text)
+	if err != nil {
+		return persistence.LongTermMemory{}, false, err
+	}
+	hits, err := lt.index.SimilaritySearch(ctx, vec, 1, longTermFilter(userID))
+	if err != nil {
+		return persistence.LongTermMemory{}, false, err
+	}
+	if len(hits) > 0 && hits[0].Score >= lt.dupThresh {
+		return persistence.LongTermMemory{}, false, nil
+	}
+	m, err := lt.store.Put(ctx, persistence.LongTermMemory{UserID: userID, SessionID: sessionID, Text: text})
+	if err != nil {
+		return persistence.LongTermMemory{}, false, err
+	}
+	md := longTermFilter(userID)
+	md["session_id"] = sessionID
+	if err := lt.index.Upsert(ctx, longTermVectorPrefix+m.ID, vec, md); err != nil {
+		_ = lt.store.Delete(ctx, userID, m.ID)
+		return persistence.LongTermMemory{}, false, err
+	}
+	return m, true, nil
+}
+
+// Remember extracts notable facts from a finished turn and stores them. It
+// is a no-op without an extraction LLM.
+func (lt *LongTermMemory) Remember(ctx context.Context, userID int64, sessionID, userInput, answer string) (int, error) {
+	if lt.llm == nil || strings.TrimSpace(userInput) == "" {
+		return 0, nil
+	}
+	facts, err := lt.extractFacts(ctx, userInput, answer)
+	if err != nil {
+		return 0, err
+	}
+	stored := 0
+	for _, f := range facts {
+		_, ok, err := lt.Add(ctx, userID, sessionID, f)
+		if err != nil {
+			return stored, err
+		}
+		if ok {
+			stored++
+		}
+	}
+	return stored, nil
+}
+
+func (lt *LongTermMemory) extractFacts(ctx context.Context, userInput, answer string) ([]string, error) {
+	sys := fmt.Sprintf(`You maintain long-term memory about a user across conversations.
+From the exchange below, extract at most %d durable facts worth remembering in future sessions:
+stable preferences, personal or project details, decisions, and constraints.
+Skip transient requests, small talk, and anything only relevant to this turn.
+Write each fact as one short third-person sentence. Respond with a JSON array of strings only; use [] when nothing qualifies.`, lt.maxFacts)
+	user := fmt.Sprintf("User: %s\n\nAssistant: %s", truncate(userInput, 2000), truncate(answer, 2000))
+	resp, err := lt.llm.Chat(ctx, []llm.Message{
+		{Role: "system", Content: sys},
+		{Role: "user", Content: user},
+	}, nil, lt.model)
+	if err != nil {
+		return nil, err
+	}
+	return parseFactList(resp.Content, lt.maxFacts), nil
+}
+
+// parseFactList decodes a JSON string array, tolerating surrounding prose or
+// code fences in the model output.
+func parseFactList(raw string, limit int) []string {
+	start := strings.Index(raw, "[")
+	end := strings.LastIndex(raw, "]")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var facts []string
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &facts); err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(facts))
+	for _, f := range facts {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// List returns the user's stored facts, newest first.
+func (lt *LongTermMemory) List(ctx context.Context, userID int64, limit int) ([]persistence.LongTermMemory, error) {
+	return lt.store.List(ctx, userID, limit)
+}
+
+// Delete removes one fact and its embedding.
+func (lt *LongTermMemory) Delete(ctx context.Context, userID int64, id string) error {
+	if err := lt.store.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	return lt.index.Delete(ctx, longTermVectorPrefix+id)
+}
+
+// DeleteAll removes every fact for userID and returns how many were removed.
+func (lt *LongTermMemory) DeleteAll(ctx context.Context, userID int64) (int, error) {
+	ids, err := lt.store.DeleteAll(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := lt.index.Delete(ctx, longTermVectorPrefix+id); err != nil {
+			return len(ids), err
+		}
+	}
+	return len(ids), nil
+}
+
+// FormatLongTermContext renders recalled facts for the system prompt.
+func FormatLongTermContext(facts []persistence.LongTermMemory) string {
+	if len(facts) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, f := range facts {
+		sb.WriteString("- ")
+		sb.WriteString(f.Text)
+		sb.WriteByte('\n')
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+type fakeLongTermStore struct {
+	facts map[string]persistence.LongTermMemory
+	next  int
+}
+
+func (s *fakeLongTermStore) Init(context.Context) error { return nil }
+
+func (s *fakeLongTermStore) Put(_ context.Context, m persistence.LongTermMemory) (persistence.LongTermMemory, error) {
+	s.next++
+	m.ID = strconv.Itoa(s.next)
+	s.facts[m.ID] = m
+	return m, nil
+}
+
+func (s *fakeLongTermStore) Get(_ context.Context, userID int64, id string) (persistence.LongTermMemory, error) {
+	m, ok := s.facts[id]
+	if !ok || m.UserID != userID {
+		return persistence.LongTermMemory{}, persistence.ErrNotFound
+	}
+	return m, nil
+}
+
+func (s *fakeLongTermStore) List(_ context.Context, userID int64, _ int) ([]persistence.LongTermMemory, error) {
+	var out []persistence.LongTermMemory
+	for _, m := range s.facts {
+		if m.UserID == userID {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *fakeLongTermStore) Delete(_ context.Context, userID int64, id string) error {
+	if _, err := s.Get(context.Background(), userID, id); err != nil {
+		return err
+	}
+	delete(s.facts, id)
+	return nil
+}
+
+func (s *fakeLongTermStore) DeleteAll(_ context.Context, userID int64) ([]string, error) {
+	var ids []string
+	for id, m := range s.facts {
+		if m.UserID == userID {
+			ids = append(ids, id)
+			delete(s.facts, id)
+		}
+	}
+	return ids, nil
+}
+
+type fakeLongTermIndex struct {
+	vecs map[string][]float32
+	md   map[string]map[string]string
+}
+
+func (x *fakeLongTermIndex) Upsert(_ context.Context, id string, v []float32, md map[string]string) error {
+	x.vecs[id], x.md[id] = v, md
+	return nil
+}
+
+func (x *fakeLongTermIndex) Delete(_ context.Context, id string) error {
+	delete(x.vecs, id)
+	delete(x.md, id)
+	return nil
+}
+
+func (x *fakeLongTermIndex) SimilaritySearch(_ context.Context, v []float32, k int, filter map[string]string) ([]LongTermVectorMatch, error) {
+	var out []LongTermVectorMatch
+	for id, vec := range x.vecs {
+		match := true
+		for key, val := range filter {
+			if x.md[id][key] != val {
+				match = false
+			}
+		}
+		if match {
+			out = append(out, LongTermVectorMatch{ID: id, Score: cosineSimilarity(v, vec)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out, nil
+}
+
+// keywordEmbed maps texts onto two axes so similarity is predictable.
+func keywordEmbed(_ context.Context, _ config.EmbeddingConfig, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		switch {
+		case strings.Contains(t, "Go"), strings.Contains(t, "golang"):
+			out[i] = []float32{1, 0}
+		default:
+			out[i] = []float32{0, 1}
+		}
+	}
+	return out, nil
+}
+
+type factLLM struct{ content string }
+
+func (f factLLM) Chat(context.Context, []llm.Message, []llm.ToolSchema, string) (llm.Message, error) {
+	return llm.Message{Role: "assistant", Content: f.content}, nil
+}
+
+func (f factLLM) ChatStream(context.Context, []llm.Message, []llm.ToolSchema, string, llm.StreamHandler) error {
+	return errors.New("not implemented")
+}
+
+func TestLongTermMemoryRememberRecallDelete(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeLongTermStore{facts: map[string]persistence.LongTermMemory{}}
+	index := &fakeLongTermIndex{vecs: map[string][]float32{}, md: map[string]map[string]string{}}
+	lt := NewLongTermMemory(LongTermMemoryConfig{
+		Store:   store,
+		Index:   index,
+		EmbedFn: keywordEmbed,
+		LLM:     factLLM{content: "```json\n[\"The user prefers Go.\", \"The user writes golang daily.\", \"The user lives in Lisbon.\"]\n```"},
+	})
+
+	n, err := lt.Remember(ctx, 7, "s1", "I like Go and live in Lisbon", "Noted.")
+	if err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+	// The second Go fact embeds identically to the first and is skipped.
+	if n != 2 {
+		t.Fatalf("expected 2 stored facts, got %d", n)
+	}
+
+	got, err := lt.Recall(ctx, 7, "which golang version?")
+	if err != nil {
+		t.Fatalf("Recall: %v", err)
+	}
+	if len(got) == 0 || got[0].Text != "The user prefers Go." {
+		t.Fatalf("unexpected recall: %+v", got)
+	}
+	if other, _ := lt.Recall(ctx, 8, "golang"); len(other) != 0 {
+		t.Fatalf("recall leaked across users: %+v", other)
+	}
+
+	if err := lt.Delete(ctx, 7, got[0].ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := index.vecs[longTermVectorPrefix+got[0].ID]; ok {
+		t.Fatal("Delete left the embedding behind")
+	}
+	if n, err := lt.DeleteAll(ctx, 7); err != nil || n != 1 || len(index.vecs) != 0 {
+		t.Fatalf("DeleteAll: n=%d err=%v vecs=%d", n, err, len(index.vecs))
+	}
+}
+
+func TestParseFactList(t *testing.T) {
+	if got := parseFactList(`Sure: ["a", " ", "b", "c"]`, 2); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("unexpected facts: %v", got)
+	}
+	if got := parseFactList("nothing here", 3); got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
@@ -29,13 +29,14 @@ const compactionContinuationRule = "When continuing from prior context (includin
 // dualSummary stores both compaction (OpenAI-specific) and plain text summaries.
 // This allows sessions to switch between OpenAI and non-OpenAI models seamlessly.
 type dualSummary struct {
-	Compaction string `json:"compaction,omitempty"` // OpenAI Responses API compaction (encrypted)
-	Plain      string `json:"plain,omitempty"`      // Plain text summary for non-OpenAI models
+	Compaction string          `json:"compaction,omitempty"` // OpenAI Responses API compaction (encrypted)
+	Plain      string          `json:"plain,omitempty"`      // Plain text summary for non-OpenAI models
+	Pinned     []pinnedMessage `json:"pinned,omitempty"`     // Verbatim messages kept by StrategySelective
 }
 
 // encodeDualSummary encodes a dual summary to JSON for storage.
 func encodeDualSummary(ds dualSummary) string {
-	if ds.Compaction == "" && ds.Plain == "" {
+	if ds.Compaction == "" && ds.Plain == "" && len(ds.Pinned) == 0 {
 		return ""
 	}
 	raw, err := json.Marshal(ds)
@@ -57,8 +58,8 @@ func decodeDualSummary(summary string) dualSummary {
 	var ds dualSummary
 	if strings.HasPrefix(trimmed, "{") {
 		if err := json.Unmarshal([]byte(trimmed), &ds); err == nil {
-			// Check if it looks like a dual summary (has "plain" or "compaction" keys)
-			if ds.Compaction != "" || ds.Plain != "" {
+			// Check if it looks like a dual summary (has "plain", "compaction", or "pinned" keys)
+			if ds.Compaction != "" || ds.Plain != "" || len(ds.Pinned) > 0 {
 				return ds
 			}
 		}
@@ -72,6 +73,19 @@ func decodeDualSummary(summary string) dualSummary {
 	return dualSummary{Plain: trimmed}
 }
 
+// plainContext renders the plain summary and any pinned messages for models
+// that cannot consume compaction items.
+func (ds dualSummary) plainContext() string {
+	parts := make([]string, 0, 2)
+	if ds.Plain != "" {
+		parts = append(parts, ds.Plain)
+	}
+	if pinned := formatPinnedMessages(ds.Pinned); pinned != "" {
+		parts = append(parts, pinned)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // SummaryResult contains metadata about summarization that occurred during BuildContext.
 // Callers can use this to notify users (e.g., via SSE events) when summarization happens.
 type SummaryResult struct {
@@ -105,6 +119,13 @@ type Config struct {
 	SummaryModel string `yaml:"summaryModel" json:"summaryModel"`
 	// UseResponsesCompaction enables the Responses API compaction endpoint when supported.
 	UseResponsesCompaction bool `yaml:"useResponsesCompaction" json:"useResponsesCompaction"`
+
+	// Strategy selects how older messages are folded into the summary:
+	// StrategyRolling (default), StrategyMapReduce, or StrategySelective.
+	Strategy string `yaml:"strategy" json:"strategy"`
+	// RetainImportantMessages is how many messages StrategySelective keeps
+	// verbatim. Default: 5.
+	RetainImportantMessages int `yaml:"retainImportantMessages" json:"retainImportantMessages"`
 }
 
 // Manager coordinates persistence-backed chat memory with rolling summaries so that
@@ -123,6 +144,10 @@ type Manager struct {
 	maxSummaryChunkTokens  int
 	contextWindowTokens    int
 	useResponsesCompaction bool
+
+	strategyName            string
+	strategy                summaryStrategy
+	retainImportantMessages int
 }
 
 // Introspection helpers used by debug/observability surfaces.
@@ -142,19 +167,27 @@ func (m *Manager) MinKeepLastMessages() int { return m.minKeepLastMessages }
 // summary chunks.
 func (m *Manager) MaxSummaryChunkTokens() int { return m.maxSummaryChunkTokens }
 
+// Strategy returns the name of the active summarization strategy.
+func (m *Manager) Strategy() string { return m.strategyName }
+
 // NewManager returns a chat memory manager.
 func NewManager(store persistence.ChatStore, provider llm.Provider, cfg Config) *Manager {
 	m := &Manager{
-		store:                  store,
-		summary:                provider,
-		summaryModel:           cfg.SummaryModel,
-		enabled:                cfg.Enabled && provider != nil,
-		reserveBufferTokens:    cfg.ReserveBufferTokens,
-		minKeepLastMessages:    cfg.MinKeepLastMessages,
-		maxKeepLastMessages:    cfg.MaxKeepLastMessages,
-		maxSummaryChunkTokens:  cfg.MaxSummaryChunkTokens,
-		contextWindowTokens:    cfg.ContextWindowTokens,
-		useResponsesCompaction: cfg.UseResponsesCompaction,
+		store:                   store,
+		summary:                 provider,
+		summaryModel:            cfg.SummaryModel,
+		enabled:                 cfg.Enabled && provider != nil,
+		reserveBufferTokens:     cfg.ReserveBufferTokens,
+		minKeepLastMessages:     cfg.MinKeepLastMessages,
+		maxKeepLastMessages:     cfg.MaxKeepLastMessages,
+		maxSummaryChunkTokens:   cfg.MaxSummaryChunkTokens,
+		contextWindowTokens:     cfg.ContextWindowTokens,
+		useResponsesCompaction:  cfg.UseResponsesCompaction,
+		retainImportantMessages: cfg.RetainImportantMessages,
+	}
+	m.strategyName, m.strategy = newSummaryStrategy(cfg.Strategy)
+	if m.retainImportantMessages <= 0 {
+		m.retainImportantMessages = defaultRetainImportantMessages
 	}
 	if m.reserveBufferTokens <= 0 {
 		m.reserveBufferTokens = defaultReserveBuffer
@@ -335,20 +368,24 @@ func (m *Manager) BuildContextForProvider(ctx context.Context, userID *int64, se
 			// Target supports compaction and we have compaction data
 			if item, ok := decodeCompactionSummary(ds.Compaction); ok {
 				history = append(history, llm.Message{Role: "assistant", Compaction: &item})
+				// Pinned messages are not part of the compaction item.
+				if pinned := formatPinnedMessages(ds.Pinned); pinned != "" {
+					history = append(history, llm.Message{Role: "system", Content: pinned})
+				}
 			} else {
 				// Compaction decode failed, fall back to plain if available
-				if ds.Plain != "" {
+				if plain := ds.plainContext(); plain != "" {
 					history = append(history, llm.Message{
 						Role:    "system",
-						Content: "Conversation summary (for context only):\n" + ds.Plain,
+						Content: "Conversation summary (for context only):\n" + plain,
 					})
 				}
 			}
-		} else if ds.Plain != "" {
+		} else if plain := ds.plainContext(); plain != "" {
 			// Target doesn't support compaction or no compaction data - use plain text
 			history = append(history, llm.Message{
 				Role:    "system",
-				Content: "Conversation summary (for context only):\n" + ds.Plain,
+				Content: "Conversation summary (for context only):\n" + plain,
 			})
 		} else if ds.Compaction != "" && !targetSupportsCompaction {
 			// We only have compaction data but target doesn't support it
@@ -361,6 +398,10 @@ func (m *Manager) BuildContextForProvider(ctx context.Context, userID *int64, se
 	}
 	for i, msg := range messages[tailStart:] {
 		log.Debug().Int("index", i).Str("role", msg.Role).Int("content_len", len(msg.Content)).Str("content_preview", truncate(msg.Content, 100)).Msg("build_context_message")
+		if msg.Role == persistence.ChatRoleHandoff {
+			history = append(history, handoffContextMessage(msg))
+			continue
+		}
 		// Deserialize JSON-encoded messages (assistant with tool calls, tool messages)
 		if msg.Role == "assistant" && strings.HasPrefix(strings.TrimSpace(msg.Content), "{") {
 			var data struct {
@@ -620,7 +661,7 @@ func (m *Manager) ensureSummary(ctx context.Context, userID *int64, session pers
 		SummarizedCount: len(chunk),
 	}
 
-	summary, err := m.summarizeChunk(ctx, session.Summary, chunk)
+	summary, err := m.summarizeChunk(ctx, session.Summary, start, chunk)
 	if err != nil {
 		log.Error().Err(err).Str("session", session.ID).Msg("chat_summary_failed")
 		return session.Summary, summarizedCount, result
@@ -635,7 +676,9 @@ func (m *Manager) ensureSummary(ctx context.Context, userID *int64, session pers
 	return summary, target, result
 }
 
-func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, chunk []persistence.ChatMessage) (string, error) {
+// summarizeChunk folds chunk, which starts at message index offset, into the
+// stored summary using the configured strategy.
+func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, offset int, chunk []persistence.ChatMessage) (string, error) {
 	if m.summary == nil {
 		return existingSummary, fmt.Errorf("llm provider unavailable")
 	}
@@ -652,7 +695,7 @@ func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, ch
 			compactionErr error
 			plainErr      error
 			compactionRes string
-			plainRes      string
+			plainRes      dualSummary
 		)
 
 		wg.Add(2)
@@ -672,7 +715,7 @@ func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, ch
 		// Goroutine 2: Plain text summarization (for non-OpenAI model fallback)
 		go func() {
 			defer wg.Done()
-			res, err := m.plainSummarize(ctx, existing.Plain, chunk)
+			res, err := m.strategy.summarize(ctx, m, existing, offset, chunk)
 			if err != nil {
 				plainErr = err
 				log.Warn().Err(err).Msg("plain_summarization_failed")
@@ -686,12 +729,17 @@ func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, ch
 		// Build dual summary with whatever succeeded
 		ds := dualSummary{
 			Compaction: compactionRes,
-			Plain:      plainRes,
+			Plain:      plainRes.Plain,
+			Pinned:     plainRes.Pinned,
 		}
 
 		// If compaction failed but plain succeeded, use plain only
 		if compactionErr != nil && plainErr == nil {
-			return ds.Plain, nil
+			ds.Compaction = ""
+			if len(ds.Pinned) == 0 {
+				return ds.Plain, nil
+			}
+			return encodeDualSummary(ds), nil
 		}
 		// If both failed, return error
 		if compactionErr != nil && plainErr != nil {
@@ -703,12 +751,16 @@ func (m *Manager) summarizeChunk(ctx context.Context, existingSummary string, ch
 		return encodeDualSummary(ds), nil
 	}
 
-	// Non-compaction mode: just do plain text summarization
-	plainRes, err := m.plainSummarize(ctx, existing.Plain, chunk)
+	// Non-compaction mode: just do plain text summarization. Summaries without
+	// pinned messages keep the legacy plain-string format.
+	ds, err := m.strategy.summarize(ctx, m, dualSummary{Plain: existing.Plain, Pinned: existing.Pinned}, offset, chunk)
 	if err != nil {
 		return existingSummary, err
 	}
-	return plainRes, nil
+	if len(ds.Pinned) == 0 {
+		return ds.Plain, nil
+	}
+	return encodeDualSummary(ds), nil
 }
 
 // plainSummarize generates a plain text summary of the conversation chunk.
@@ -790,6 +842,11 @@ func buildSummaryPromptMessage(msg persistence.ChatMessage) summaryPromptMessage
 	if raw == "" {
 		return out
 	}
+	if msg.Role == persistence.ChatRoleHandoff {
+		rendered := handoffContextMessage(msg)
+		out.Role, out.Content = rendered.Role, rendered.Content
+		return out
+	}
 	if msg.Role == "assistant" && strings.HasPrefix(raw, "{") {
 		var data struct {
 			Content   string         `json:"content"`
@@ -895,6 +952,9 @@ func truncateForSummary(content string, limit int) string {
 }
 
 func decodePersistedChatMessage(msg persistence.ChatMessage) llm.Message {
+	if msg.Role == persistence.ChatRoleHandoff {
+		return handoffContextMessage(msg)
+	}
 	raw := strings.TrimSpace(msg.Content)
 	if msg.Role == "assistant" && strings.HasPrefix(raw, "{") {
 		var data struct {
@@ -939,7 +999,7 @@ func estimateMessagesTokens(msgs []llm.Message) int {
 }
 
 func (m *Manager) compactChunk(ctx context.Context, existingSummary string, chunk []persistence.ChatMessage) (string, error) {
-	compactor, ok := m.summary.(llm.CompactionProvider)
+	compactor, ok := llm.Unwrap(m.summary).(llm.CompactionProvider)
 	if !ok {
 		observability.LoggerWithTrace(ctx).Warn().Msg("responses_compaction_unavailable")
 		return existingSummary, nil
@@ -1052,3 +1112,21 @@ func encodeCompactionSummary(item llm.CompactionItem) string {
 	}
 	return string(raw)
 }
+
+// handoffContextMessage renders a stored handoff as a system message that
+// carries the handing-off agent's context package to the receiver.
+func handoffContextMessage(msg persistence.ChatMessage) llm.Message {
+	var h persistence.Handoff
+	if err := json.Unmarshal([]byte(msg.Content), &h); err != nil {
+		return llm.Message{Role: "system", Content: msg.Content}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "The conversation was handed off from %s to %s.", h.From, h.To)
+	if h.Reason != "" {
+		fmt.Fprintf(&b, " Reason: %s", h.Reason)
+	}
+	if h.Summary != "" {
+		b.WriteString("\nContext from the previous agent:\n" + h.Summary)
+	}
+	return llm.Message{Role: "system", Content: b.String()}
+}
@@ -428,7 +428,7 @@ func TestSummarizeChunkFormatsToolMessages(t *testing.T) {
 		t.Fatalf("marshal tool payload: %v", err)
 	}
 
-	_, err = manager.summarizeChunk(ctx, "", []persistence.ChatMessage{
+	_, err = manager.summarizeChunk(ctx, "", 0, []persistence.ChatMessage{
 		{Role: "assistant", Content: string(assistantRaw)},
 		{Role: "tool", Content: string(toolRaw)},
 	})
@@ -506,3 +506,34 @@ func TestManagerBuildContextForcesSummaryWhenTailTooLong(t *testing.T) {
 		t.Fatalf("expected summarized count 8, got %d", session.SummarizedCount)
 	}
 }
+
+func TestBuildContextForProvider_RendersHandoffAsSystemContext(t *testing.T) {
+	ctx := context.Background()
+	store := newStubChatStore()
+	if _, err := store.EnsureSession(ctx, nil, "sess", "Chat"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	payload, _ := json.Marshal(persistence.Handoff{From: "orchestrator", To: "coder", Reason: "needs code", Summary: "User wants a Go CLI."})
+	now := time.Now().UTC()
+	msgs := []persistence.ChatMessage{
+		{Role: "user", Content: "build me a CLI", CreatedAt: now},
+		{Role: "assistant", Content: "Handing you to coder.", CreatedAt: now.Add(time.Second)},
+		{Role: persistence.ChatRoleHandoff, Content: string(payload), CreatedAt: now.Add(2 * time.Second)},
+	}
+	if err := store.AppendMessages(ctx, nil, "sess", msgs, "", "model"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+
+	manager := NewManager(store, &stubLLM{response: "unused"}, Config{Enabled: false})
+	history, _, err := manager.BuildContextForProvider(ctx, nil, "sess", false)
+	if err != nil {
+		t.Fatalf("BuildContextForProvider: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %#v", len(history), history)
+	}
+	last := history[2]
+	if last.Role != "system" || !strings.Contains(last.Content, "from orchestrator to coder") || !strings.Contains(last.Content, "User wants a Go CLI.") {
+		t.Fatalf("unexpected handoff rendering: %#v", last)
+	}
+}
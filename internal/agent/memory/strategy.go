@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+// Summarization strategies selectable via Config.Strategy. They control how a
+// chunk of older messages is folded into the plain-text summary; Responses
+// API compaction, when enabled, runs alongside whichever strategy is chosen.
+const (
+	// StrategyRolling folds each chunk into a single running summary with one
+	// LLM call. This is the default.
+	StrategyRolling = "rolling"
+	// StrategyMapReduce summarizes a large chunk in independent segments and
+	// then merges them, so early turns get the same attention as late ones.
+	StrategyMapReduce = "map_reduce"
+	// StrategySelective rolls the summary like StrategyRolling but also keeps
+	// the highest-scoring messages verbatim alongside it.
+	StrategySelective = "selective"
+)
+
+const (
+	// defaultRetainImportantMessages is how many messages StrategySelective
+	// pins when Config.RetainImportantMessages is unset.
+	defaultRetainImportantMessages = 5
+	// minImportanceScore is the score a message needs to be pinned at all.
+	minImportanceScore = 3.0
+	// pinnedMessageLimit caps the characters kept per pinned message.
+	pinnedMessageLimit = 400
+)
+
+// pinnedMessage is a message StrategySelective keeps verbatim. Index is the
+// message's position in the session so pins stay in chronological order.
+type pinnedMessage struct {
+	Index   int     `json:"index"`
+	Role    string  `json:"role"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+// summaryStrategy folds chunk into the plain part of prev (Plain and Pinned)
+// and returns the result. Compaction is left untouched.
+type summaryStrategy interface {
+	summarize(ctx context.Context, m *Manager, prev dualSummary, offset int, chunk []persistence.ChatMessage) (dualSummary, error)
+}
+
+// newSummaryStrategy returns the strategy for name, falling back to rolling
+// for empty or unknown names.
+func newSummaryStrategy(name string) (string, summaryStrategy) {
+	switch normalizeStrategyName(name) {
+	case StrategyMapReduce:
+		return StrategyMapReduce, mapReduceStrategy{}
+	case StrategySelective:
+		return StrategySelective, selectiveStrategy{}
+	default:
+		return StrategyRolling, rollingStrategy{}
+	}
+}
+
+func normalizeStrategyName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+type rollingStrategy struct{}
+
+func (rollingStrategy) summarize(ctx context.Context, m *Manager, prev dualSummary, _ int, chunk []persistence.ChatMessage) (dualSummary, error) {
+	plain, err := m.plainSummarize(ctx, prev.Plain, chunk)
+	if err != nil {
+		return prev, err
+	}
+	prev.Plain = plain
+	return prev, nil
+}
+
+type mapReduceStrategy struct{}
+
+func (mapReduceStrategy) summarize(ctx context.Context, m *Manager, prev dualSummary, _ int, chunk []persistence.ChatMessage) (dualSummary, error) {
+	segments := m.splitSummaryChunk(chunk)
+	if len(segments) <= 1 {
+		return rollingStrategy{}.summarize(ctx, m, prev, 0, chunk)
+	}
+
+	partials := make([]string, len(segments))
+	errs := make([]error, len(segments))
+	var wg sync.WaitGroup
+	for i, seg := range segments {
+		wg.Add(1)
+		go func(i int, seg []persistence.ChatMessage) {
+			defer wg.Done()
+			partials[i], errs[i] = m.plainSummarize(ctx, "", seg)
+		}(i, seg)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return prev, fmt.Errorf("map segment %d: %w", i+1, err)
+		}
+	}
+
+	plain, err := m.reduceSummaries(ctx, prev.Plain, partials)
+	if err != nil {
+		return prev, err
+	}
+	prev.Plain = plain
+	return prev, nil
+}
+
+// splitSummaryChunk partitions chunk into consecutive segments whose
+// estimated size fits maxSummaryChunkTokens.
+func (m *Manager) splitSummaryChunk(chunk []persistence.ChatMessage) [][]persistence.ChatMessage {
+	limit := m.maxSummaryChunkTokens
+	if limit <= 0 {
+		limit = maxSummarizeChunkSize
+	}
+	var (
+		segments [][]persistence.ChatMessage
+		start    int
+		size     int
+	)
+	for i, msg := range chunk {
+		tokens := len([]rune(truncateForSummary(msg.Content, limit)))/4 + 1
+		if i > start && size+tokens > limit {
+			segments = append(segments, chunk[start:i])
+			start, size = i, 0
+		}
+		size += tokens
+	}
+	if start < len(chunk) {
+		segments = append(segments, chunk[start:])
+	}
+	return segments
+}
+
+// reduceSummaries merges the existing summary with per-segment summaries,
+// given in conversation order, into one summary.
+func (m *Manager) reduceSummaries(ctx context.Context, existing string, partials []string) (string, error) {
+	var userPrompt strings.Builder
+	userPrompt.WriteString("Merge these summaries of consecutive parts of one chat into a single running summary.\n")
+	userPrompt.WriteString("Keep details from every part, especially early user goals, preferences, decisions, and identifiers (files, URLs, IDs).\n")
+	userPrompt.WriteString("When later parts supersede earlier ones, keep the latest state.\n")
+	if strings.TrimSpace(existing) != "" {
+		userPrompt.WriteString("\nExisting summary:\n")
+		userPrompt.WriteString(strings.TrimSpace(existing))
+		userPrompt.WriteString("\n")
+	}
+	for i, p := range partials {
+		fmt.Fprintf(&userPrompt, "\nPart %d:\n%s\n", i+1, strings.TrimSpace(p))
+	}
+	userPrompt.WriteString("\nReturn only the merged summary. Aim for <= 1600 characters; use short bullets if helpful.")
+
+	resp, err := m.summary.Chat(ctx, []llm.Message{
+		{Role: "system", Content: "You are a concise summarizer. Maintain an accurate running summary of a conversation."},
+		{Role: "user", Content: userPrompt.String()},
+	}, nil, m.summaryModel)
+	if err != nil {
+		return existing, fmt.Errorf("reduce summaries: %w", err)
+	}
+	merged := strings.TrimSpace(resp.Content)
+	if merged == "" {
+		return existing, fmt.Errorf("empty summary returned")
+	}
+	return merged, nil
+}
+
+type selectiveStrategy struct{}
+
+func (selectiveStrategy) summarize(ctx context.Context, m *Manager, prev dualSummary, offset int, chunk []persistence.ChatMessage) (dualSummary, error) {
+	next, err := rollingStrategy{}.summarize(ctx, m, prev, offset, chunk)
+	if err != nil {
+		return prev, err
+	}
+	next.Pinned = selectPinnedMessages(prev.Pinned, offset, chunk, m.retainImportantMessages)
+	return next, nil
+}
+
+// selectPinnedMessages scores chunk, merges it with the existing pins, and
+// keeps the top limit by score, returned in chronological order.
+func selectPinnedMessages(existing []pinnedMessage, offset int, chunk []persistence.ChatMessage, limit int) []pinnedMessage {
+	candidates := append([]pinnedMessage(nil), existing...)
+	for i, msg := range chunk {
+		sm := buildSummaryPromptMessage(msg)
+		score := scoreImportance(sm)
+		if score < minImportanceScore {
+			continue
+		}
+		candidates = append(candidates, pinnedMessage{
+			Index:   offset + i,
+			Role:    sm.Role,
+			Content: truncateInline(strings.Join(strings.Fields(sm.Content), " "), pinnedMessageLimit),
+			Score:   score,
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		// Prefer earlier messages on ties; they are the ones rolling loses.
+		return candidates[i].Index < candidates[j].Index
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Index < candidates[j].Index })
+	return candidates
+}
+
+var (
+	importanceKeywords = []string{
+		"always", "never", "must", "prefer", "don't", "do not", "important",
+		"remember", "requirement", "constraint", "deadline", "decided",
+		"decision", "my name", "goal", "budget",
+	}
+	identifierPattern = regexp.MustCompile(`https?://\S+|\b[\w.-]+@[\w.-]+\.\w+\b|\b[\w./-]+\.[a-z]{1,5}\b|\b[A-Z]{2,}-\d+\b`)
+	errorPattern      = regexp.MustCompile(`(?i)\b(error|failed|failure|exception|panic)\b`)
+)
+
+// scoreImportance is a cheap heuristic for how costly it would be to lose
+// msg to summarization: user instructions and constraints, identifiers, and
+// tool failures score high; short acknowledgements score low.
+func scoreImportance(msg summaryPromptMessage) float64 {
+	content := strings.TrimSpace(msg.Content)
+	if content == "" {
+		return 0
+	}
+	var score float64
+	switch msg.Role {
+	case "user":
+		score += 2
+	case "assistant":
+		score++
+	}
+	lower := strings.ToLower(content)
+	hits := 0
+	for _, kw := range importanceKeywords {
+		if strings.Contains(lower, kw) {
+			hits++
+		}
+	}
+	if hits > 2 {
+		hits = 2
+	}
+	score += 1.5 * float64(hits)
+	if identifierPattern.MatchString(content) {
+		score++
+	}
+	if msg.Role == "tool" && errorPattern.MatchString(content) {
+		score += minImportanceScore
+	}
+	if len([]rune(content)) < 20 {
+		score--
+	}
+	return score
+}
+
+// formatPinnedMessages renders pins for the summary system message.
+func formatPinnedMessages(pins []pinnedMessage) string {
+	if len(pins) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Key earlier messages (verbatim):")
+	for _, p := range pins {
+		fmt.Fprintf(&sb, "\n- [%s] %s", p.Role, p.Content)
+	}
+	return sb.String()
+}
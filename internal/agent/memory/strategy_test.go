@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+// countingLLM returns a fixed summary and records every prompt. It is safe
+// for the concurrent map phase of StrategyMapReduce.
+type countingLLM struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (c *countingLLM) Chat(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, msgs[len(msgs)-1].Content)
+	return llm.Message{Role: "assistant", Content: "- user is planning a deployment"}, nil
+}
+
+func (c *countingLLM) ChatStream(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	return nil
+}
+
+func (c *countingLLM) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.prompts)
+}
+
+const earlyConstraint = "My name is Ada and we must deploy to eu-west-1 before the Friday deadline."
+
+// seedLongSession stores an early constraint followed by filler turns.
+func seedLongSession(t *testing.T, store *stubChatStore, turns int) []persistence.ChatMessage {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := store.EnsureSession(ctx, nil, "sess", "Chat"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	now := time.Now().UTC()
+	msgs := []persistence.ChatMessage{
+		{Role: "user", Content: earlyConstraint, CreatedAt: now},
+		{Role: "assistant", Content: "Understood, eu-west-1 before Friday.", CreatedAt: now},
+	}
+	filler := strings.Repeat("some routine discussion about the rollout ", 6)
+	for i := 0; i < turns; i++ {
+		msgs = append(msgs,
+			persistence.ChatMessage{Role: "user", Content: fmt.Sprintf("question %d: %s", i, filler), CreatedAt: now},
+			persistence.ChatMessage{Role: "assistant", Content: fmt.Sprintf("answer %d: %s", i, filler), CreatedAt: now},
+		)
+	}
+	if err := store.AppendMessages(ctx, nil, "sess", msgs, "", "model"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+	return msgs
+}
+
+func persistedTokens(msgs []persistence.ChatMessage) int {
+	out := make([]llm.Message, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, decodePersistedChatMessage(m))
+	}
+	return estimateMessagesTokens(out)
+}
+
+func TestSummaryStrategiesContextSizes(t *testing.T) {
+	ctx := context.Background()
+	sizes := map[string]int{}
+	contexts := map[string]string{}
+	var fullTokens int
+	for _, strategy := range []string{StrategyRolling, StrategyMapReduce, StrategySelective} {
+		store := newStubChatStore()
+		msgs := seedLongSession(t, store, 10)
+		fullTokens = persistedTokens(msgs)
+
+		manager := NewManager(store, &countingLLM{}, Config{
+			Enabled:               true,
+			ContextWindowTokens:   600,
+			ReserveBufferTokens:   100,
+			MinKeepLastMessages:   2,
+			MaxKeepLastMessages:   4,
+			MaxSummaryChunkTokens: 200,
+			SummaryModel:          "stub",
+			Strategy:              strategy,
+		})
+		if got := manager.Strategy(); got != strategy {
+			t.Fatalf("Strategy() = %q, want %q", got, strategy)
+		}
+		history, result, err := manager.BuildContextForProvider(ctx, nil, "sess", false)
+		if err != nil {
+			t.Fatalf("%s: BuildContext: %v", strategy, err)
+		}
+		if result == nil || !result.Triggered {
+			t.Fatalf("%s: expected summarization to trigger", strategy)
+		}
+		sizes[strategy] = estimateMessagesTokens(history)
+		var sb strings.Builder
+		for _, m := range history {
+			sb.WriteString(m.Content)
+			sb.WriteByte('\n')
+		}
+		contexts[strategy] = sb.String()
+	}
+
+	for strategy, size := range sizes {
+		if size >= fullTokens {
+			t.Fatalf("%s: context %d tokens is not smaller than the full transcript (%d)", strategy, size, fullTokens)
+		}
+	}
+	// Rolling and map-reduce both reduce the prefix to a single summary.
+	if sizes[StrategyMapReduce] != sizes[StrategyRolling] {
+		t.Fatalf("expected equal context sizes for rolling (%d) and map_reduce (%d)", sizes[StrategyRolling], sizes[StrategyMapReduce])
+	}
+	// Selective pays for its pins, but stays well under the full transcript.
+	if sizes[StrategySelective] <= sizes[StrategyRolling] {
+		t.Fatalf("expected selective context (%d) to exceed rolling (%d)", sizes[StrategySelective], sizes[StrategyRolling])
+	}
+	if sizes[StrategySelective] > fullTokens/2 {
+		t.Fatalf("selective context %d tokens exceeds half the transcript (%d)", sizes[StrategySelective], fullTokens)
+	}
+	// Only selective keeps the early constraint verbatim.
+	if strings.Contains(contexts[StrategyRolling], earlyConstraint) {
+		t.Fatalf("rolling context unexpectedly contains the early message verbatim")
+	}
+	if !strings.Contains(contexts[StrategySelective], earlyConstraint) {
+		t.Fatalf("selective context lost the early constraint:\n%s", contexts[StrategySelective])
+	}
+}
+
+func TestMapReduceSummarizesSegmentsThenMerges(t *testing.T) {
+	ctx := context.Background()
+	store := newStubChatStore()
+	seedLongSession(t, store, 10)
+	provider := &countingLLM{}
+	manager := NewManager(store, provider, Config{
+		Enabled:               true,
+		ContextWindowTokens:   600,
+		ReserveBufferTokens:   100,
+		MinKeepLastMessages:   2,
+		MaxKeepLastMessages:   4,
+		MaxSummaryChunkTokens: 200,
+		SummaryModel:          "stub",
+		Strategy:              "map-reduce",
+	})
+	if _, _, err := manager.BuildContextForProvider(ctx, nil, "sess", false); err != nil {
+		t.Fatalf("BuildContext: %v", err)
+	}
+
+	session, _ := store.GetSession(ctx, nil, "sess")
+	msgs, _ := store.ListMessages(ctx, nil, "sess", 0)
+	segments := manager.splitSummaryChunk(msgs[:session.SummarizedCount])
+	if len(segments) < 2 {
+		t.Fatalf("expected the summarized prefix to span several segments, got %d", len(segments))
+	}
+	if got, want := provider.calls(), len(segments)+1; got != want {
+		t.Fatalf("expected %d LLM calls (map + reduce), got %d", want, got)
+	}
+	reduce := provider.prompts[len(provider.prompts)-1]
+	for i := range segments {
+		if !strings.Contains(reduce, fmt.Sprintf("Part %d:", i+1)) {
+			t.Fatalf("reduce prompt missing part %d:\n%s", i+1, reduce)
+		}
+	}
+}
+
+func TestSelectiveSummaryPersistsPinsAcrossRolls(t *testing.T) {
+	ctx := context.Background()
+	store := newStubChatStore()
+	seedLongSession(t, store, 10)
+	manager := NewManager(store, &countingLLM{}, Config{
+		Enabled:                 true,
+		ContextWindowTokens:     600,
+		ReserveBufferTokens:     100,
+		MinKeepLastMessages:     2,
+		MaxKeepLastMessages:     4,
+		SummaryModel:            "stub",
+		Strategy:                StrategySelective,
+		RetainImportantMessages: 1,
+	})
+	if _, _, err := manager.BuildContextForProvider(ctx, nil, "sess", false); err != nil {
+		t.Fatalf("BuildContext: %v", err)
+	}
+
+	// A later roll must keep the earlier pin unless something outranks it.
+	more := []persistence.ChatMessage{
+		{Role: "user", Content: "ok"},
+		{Role: "assistant", Content: "sure"},
+		{Role: "user", Content: "thanks"},
+		{Role: "assistant", Content: "welcome"},
+		{Role: "user", Content: "next"},
+		{Role: "assistant", Content: "done"},
+	}
+	if err := store.AppendMessages(ctx, nil, "sess", more, "", "model"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+	if _, _, err := manager.BuildContextForProvider(ctx, nil, "sess", false); err != nil {
+		t.Fatalf("BuildContext: %v", err)
+	}
+
+	session, _ := store.GetSession(ctx, nil, "sess")
+	ds := decodeDualSummary(session.Summary)
+	if ds.Plain == "" {
+		t.Fatalf("expected plain summary to be kept")
+	}
+	if len(ds.Pinned) != 1 || ds.Pinned[0].Index != 0 || ds.Pinned[0].Content != earlyConstraint {
+		t.Fatalf("unexpected pins: %+v", ds.Pinned)
+	}
+}
+
+func TestSelectPinnedMessagesRanksAndOrders(t *testing.T) {
+	chunk := []persistence.ChatMessage{
+		{Role: "user", Content: "thanks"},
+		{Role: "user", Content: "Please always use tabs; see docs/style.md for the rules."},
+		{Role: "assistant", Content: "Here is a general overview of the options you might consider."},
+		{Role: "tool", Content: `{"content":"build failed: missing module","tool_id":"call-1"}`},
+	}
+	pins := selectPinnedMessages(nil, 10, chunk, 2)
+	if len(pins) != 2 {
+		t.Fatalf("expected 2 pins, got %+v", pins)
+	}
+	if pins[0].Index != 11 || pins[1].Index != 13 {
+		t.Fatalf("expected chronological pins at 11 and 13, got %+v", pins)
+	}
+	if pins[1].Role != "tool" || pins[1].Content != "build failed: missing module" {
+		t.Fatalf("expected decoded tool output pin, got %+v", pins[1])
+	}
+}
+
+func TestNewSummaryStrategyFallsBackToRolling(t *testing.T) {
+	for _, name := range []string{"", "unknown", " Rolling "} {
+		if got, _ := newSummaryStrategy(name); got != StrategyRolling {
+			t.Fatalf("newSummaryStrategy(%q) = %q, want rolling", name, got)
+		}
+	}
+	if got, _ := newSummaryStrategy("Map-Reduce"); got != StrategyMapReduce {
+		t.Fatalf("expected map_reduce, got %q", got)
+	}
+}
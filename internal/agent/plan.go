@@ -0,0 +1,550 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// Engine modes selectable per run via Engine.Mode.
+const (
+	// EngineModeReAct is the default tool-calling loop.
+	EngineModeReAct = "react"
+	// EngineModePlanExecute has a planner emit a step DAG, runs the steps
+	// concurrently as their dependencies complete, reviews each result with a
+	// critic, and synthesizes the final answer from the step results.
+	EngineModePlanExecute = "plan_execute"
+)
+
+const (
+	defaultMaxPlanSteps   = 8
+	defaultMaxStepRetries = 1
+	planMaxResultRunes    = 4000
+)
+
+// ErrInvalidPlan is returned by Plan.Validate for malformed step graphs.
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// IsEngineMode reports whether mode names a known engine mode. The empty
+// string selects the default.
+func IsEngineMode(mode string) bool {
+	switch mode {
+	case "", EngineModeReAct, EngineModePlanExecute:
+		return true
+	}
+	return false
+}
+
+// PlanStep is one node of a plan. DependsOn lists the IDs of steps whose
+// results the step needs.
+type PlanStep struct {
+	ID        string   `json:"id"`
+	Task      string   `json:"task"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// Plan is a DAG of steps produced by a Planner.
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+}
+
+// Validate checks that step IDs are unique and non-empty, every step has a
+// task, dependencies refer to known steps, and the graph has no cycles.
+func (p Plan) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidPlan)
+	}
+	index := make(map[string]int, len(p.Steps))
+	for i, s := range p.Steps {
+		if strings.TrimSpace(s.ID) == "" {
+			return fmt.Errorf("%w: step %d has no id", ErrInvalidPlan, i)
+		}
+		if strings.TrimSpace(s.Task) == "" {
+			return fmt.Errorf("%w: step %q has no task", ErrInvalidPlan, s.ID)
+		}
+		if _, dup := index[s.ID]; dup {
+			return fmt.Errorf("%w: duplicate step id %q", ErrInvalidPlan, s.ID)
+		}
+		index[s.ID] = i
+	}
+	for _, s := range p.Steps {
+		for _, dep := range s.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("%w: step %q depends on unknown step %q", ErrInvalidPlan, s.ID, dep)
+			}
+		}
+	}
+	// Depth-first search for back edges.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(p.Steps))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("%w: cycle through step %q", ErrInvalidPlan, p.Steps[i].ID)
+		case done:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range p.Steps[i].DependsOn {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		return nil
+	}
+	for i := range p.Steps {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StepResult is the outcome of executing one plan step.
+type StepResult struct {
+	StepID   string `json:"step_id"`
+	Output   string `json:"output,omitempty"`
+	Attempts int    `json:"attempts"`
+	Approved bool   `json:"approved"`
+	Feedback string `json:"feedback,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Critique is a critic's judgement of a step result.
+type Critique struct {
+	Approved bool   `json:"approved"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// Planner breaks a request into a plan.
+type Planner interface {
+	Plan(ctx context.Context, goal string, history []llm.Message, tools []llm.ToolSchema) (Plan, error)
+}
+
+// StepExecutor runs a single plan step. deps holds the results of the
+// step's dependencies; feedback is the critic's note on a previous attempt,
+// empty on the first.
+type StepExecutor interface {
+	Execute(ctx context.Context, goal string, step PlanStep, deps []StepResult, feedback string) (string, error)
+}
+
+// Critic reviews a step result. Errors are treated as approval so a broken
+// critic never blocks a run.
+type Critic interface {
+	Review(ctx context.Context, goal string, step PlanStep, output string) (Critique, error)
+}
+
+// PlanEvent reports progress of a plan_execute run. Type is "plan",
+// "step_start", or "step_done"; Plan is set for "plan" and Step/Result for
+// the step events.
+type PlanEvent struct {
+	Type   string      `json:"type"`
+	Plan   *Plan       `json:"plan,omitempty"`
+	Step   *PlanStep   `json:"step,omitempty"`
+	Result *StepResult `json:"result,omitempty"`
+}
+
+// LLMPlanner asks the model for a JSON step DAG.
+type LLMPlanner struct {
+	LLM      llm.Provider
+	Model    string
+	MaxSteps int
+}
+
+func (p *LLMPlanner) Plan(ctx context.Context, goal string, history []llm.Message, tools []llm.ToolSchema) (Plan, error) {
+	maxSteps := p.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxPlanSteps
+	}
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Name
+	}
+	sys := fmt.Sprintf("You plan work for an agent. Break the user's request into at most %d steps. "+
+		"Each step is a self-contained task an agent with tools can complete. "+
+		"List a step in depends_on only when it needs that step's result; independent steps run in parallel. "+
+		`Respond with JSON only: {"steps":[{"id":"s1","task":"...","depends_on":[]}]}.`, maxSteps)
+	if len(names) > 0 {
+		sys += "\nAvailable tools: " + strings.Join(names, ", ")
+	}
+	msgs := []llm.Message{{Role: "system", Content: sys}}
+	msgs = append(msgs, planHistory(history)...)
+	msgs = append(msgs, llm.Message{Role: "user", Content: goal})
+	resp, err := p.LLM.Chat(ctx, msgs, nil, p.Model)
+	if err != nil {
+		return Plan{}, fmt.Errorf("plan: %w", err)
+	}
+	raw := stripCodeFence(strings.TrimSpace(resp.Content))
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return Plan{}, fmt.Errorf("%w: planner returned no JSON object", ErrInvalidPlan)
+	}
+	var plan Plan
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &plan); err != nil {
+		return Plan{}, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	if len(plan.Steps) > maxSteps {
+		return Plan{}, fmt.Errorf("%w: %d steps exceeds limit of %d", ErrInvalidPlan, len(plan.Steps), maxSteps)
+	}
+	if err := plan.Validate(); err != nil {
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+// planHistory keeps the plain user/assistant turns of history so the
+// planner sees the conversation without tool plumbing.
+func planHistory(history []llm.Message) []llm.Message {
+	var out []llm.Message
+	for _, m := range history {
+		if (m.Role == "user" || m.Role == "assistant") && len(m.ToolCalls) == 0 && strings.TrimSpace(m.Content) != "" {
+			out = append(out, llm.Message{Role: m.Role, Content: m.Content})
+		}
+	}
+	return out
+}
+
+// LLMCritic asks the model whether a step result accomplishes its task.
+type LLMCritic struct {
+	LLM   llm.Provider
+	Model string
+}
+
+func (c *LLMCritic) Review(ctx context.Context, goal string, step PlanStep, output string) (Critique, error) {
+	sys := "You review intermediate results of a multi-step plan. Reject a result only if it is empty, " +
+		"does not address its task, or contains an obvious error. " +
+		`Respond with JSON only: {"approved": bool, "feedback": string}.`
+	user := fmt.Sprintf("Overall goal:\n%s\n\nStep task:\n%s\n\nStep result:\n%s",
+		truncateRunes(goal, verifierMaxAnswerRunes), step.Task, truncateRunes(output, verifierMaxAnswerRunes))
+	resp, err := c.LLM.Chat(ctx, []llm.Message{
+		{Role: "system", Content: sys},
+		{Role: "user", Content: user},
+	}, nil, c.Model)
+	if err != nil {
+		return Critique{Approved: true}, fmt.Errorf("critique: %w", err)
+	}
+	raw := resp.Content
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return Critique{Approved: true}, fmt.Errorf("critic returned no JSON object")
+	}
+	var out Critique
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &out); err != nil {
+		return Critique{Approved: true}, fmt.Errorf("decode critique: %w", err)
+	}
+	return out, nil
+}
+
+// engineStepExecutor runs each step through a child engine's tool loop.
+type engineStepExecutor struct {
+	e *Engine
+}
+
+func (x engineStepExecutor) Execute(ctx context.Context, goal string, step PlanStep, deps []StepResult, feedback string) (string, error) {
+	child := x.e.stepEngine(step.ID)
+	msgs := BuildInitialLLMMessages(child.System, stepPrompt(goal, step, deps, feedback), nil)
+	final, _, err := child.runLoop(ctx, msgs)
+	return final, err
+}
+
+// stepEngine returns a copy of e for running one plan step. Per-turn UI
+// callbacks are dropped because steps run concurrently and their
+// intermediate messages are not part of the visible conversation; tool
+// callbacks are kept so tool activity still streams. Tool call IDs are
+// prefixed with the step ID to stay unique across parallel steps.
+func (e *Engine) stepEngine(stepID string) *Engine {
+	c := *e
+	c.OnAssistant = nil
+	c.OnDelta = nil
+	c.OnThoughtSummary = nil
+	c.OnTurnMessage = nil
+	c.OnStepContext = nil
+	c.OnSummaryTriggered = nil
+	c.toolCallSeq = 0
+	c.toolCallPrefix = stepID + "-"
+	return &c
+}
+
+func stepPrompt(goal string, step PlanStep, deps []StepResult, feedback string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Overall goal:\n%s\n\nYour task (step %s):\n%s\n", goal, step.ID, step.Task)
+	if len(deps) > 0 {
+		b.WriteString("\nResults of the steps this one depends on:\n")
+		for _, d := range deps {
+			fmt.Fprintf(&b, "[%s] %s\n", d.StepID, truncateRunes(d.Output, planMaxResultRunes))
+		}
+	}
+	if feedback != "" {
+		fmt.Fprintf(&b, "\nA reviewer rejected your previous attempt:\n%s\n", feedback)
+	}
+	b.WriteString("\nComplete only this step and reply with its result.")
+	return b.String()
+}
+
+func (e *Engine) emitPlanEvent(ev PlanEvent) {
+	if e.OnPlanEvent != nil {
+		e.OnPlanEvent(ev)
+	}
+}
+
+// runPlanExecute is the EngineModePlanExecute counterpart of the Run and
+// RunStream tool loops. stream selects whether the final synthesis streams
+// through OnDelta.
+func (e *Engine) runPlanExecute(ctx context.Context, userInput string, history []llm.Message, stream bool) (string, error) {
+	log := observability.LoggerWithTrace(ctx)
+
+	if err := e.checkQuota(ctx); err != nil {
+		return "", err
+	}
+	planner := e.Planner
+	if planner == nil {
+		planner = &LLMPlanner{LLM: e.LLM, Model: e.Model, MaxSteps: e.MaxPlanSteps}
+	}
+	plan, err := planner.Plan(ctx, userInput, history, e.Tools.Schemas())
+	if err == nil {
+		err = plan.Validate()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		// A plan is an optimisation, not a requirement: fall back to a
+		// single step covering the whole request.
+		log.Warn().Err(err).Msg("plan_fallback_single_step")
+		plan = Plan{Steps: []PlanStep{{ID: "s1", Task: userInput}}}
+	}
+	log.Info().Int("steps", len(plan.Steps)).Msg("plan_created")
+	e.emitPlanEvent(PlanEvent{Type: "plan", Plan: &plan})
+
+	results, err := e.executePlan(ctx, userInput, plan)
+	if err != nil {
+		return "", err
+	}
+
+	msgs := BuildInitialLLMMessages(e.System, userInput, history)
+	msgs = e.augmentWithLongTermMemory(ctx, userInput, msgs)
+	if e.SummaryEnabled {
+		msgs = e.maybeSummarize(ctx, msgs)
+	}
+	msgs = append(msgs, llm.Message{Role: "user", Content: synthesisPrompt(plan, results)})
+
+	final, msgs, err := e.synthesize(ctx, msgs, stream)
+	if err != nil {
+		return "", err
+	}
+	final = e.verifyAndEscalate(ctx, userInput, final, msgs)
+
+	e.storeSuccessfulExperience(ctx, userInput, final)
+	e.rememberLongTerm(ctx, userInput, final)
+	return final, nil
+}
+
+// executePlan runs plan steps as their dependencies complete, at most
+// MaxToolParallelism at a time. A step whose dependency failed is skipped.
+// Results are returned in plan order.
+func (e *Engine) executePlan(ctx context.Context, goal string, plan Plan) ([]StepResult, error) {
+	executor := e.Executor
+	if executor == nil {
+		executor = engineStepExecutor{e: e}
+	}
+	critic := e.Critic
+	if critic == nil && !e.DisableCritic {
+		critic = &LLMCritic{LLM: e.LLM, Model: e.Model}
+	}
+	retries := e.MaxStepRetries
+	if retries < 0 {
+		retries = 0
+	} else if retries == 0 {
+		retries = defaultMaxStepRetries
+	}
+
+	n := len(plan.Steps)
+	index := make(map[string]int, n)
+	for i, s := range plan.Steps {
+		index[s.ID] = i
+	}
+	pending := make([]int, n)
+	dependents := make([][]int, n)
+	for i, s := range plan.Steps {
+		pending[i] = len(s.DependsOn)
+		for _, dep := range s.DependsOn {
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+	}
+
+	parallel := e.MaxToolParallelism
+	if parallel <= 0 || parallel > n {
+		parallel = n
+	}
+	sem := make(chan struct{}, parallel)
+	results := make([]StepResult, n)
+	doneCh := make(chan int, n)
+	var wg sync.WaitGroup
+
+	start := func(i int) {
+		step := plan.Steps[i]
+		var deps []StepResult
+		for _, dep := range step.DependsOn {
+			deps = append(deps, results[index[dep]])
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			e.emitPlanEvent(PlanEvent{Type: "step_start", Step: &step})
+			results[i] = e.runPlanStep(ctx, executor, critic, retries, goal, step, deps)
+			r := results[i]
+			e.emitPlanEvent(PlanEvent{Type: "step_done", Step: &step, Result: &r})
+			doneCh <- i
+		}()
+	}
+
+	for i := range plan.Steps {
+		if pending[i] == 0 {
+			start(i)
+		}
+	}
+	for finished := 0; finished < n; finished++ {
+		i := <-doneCh
+		for _, d := range dependents[i] {
+			pending[d]--
+			if pending[d] != 0 {
+				continue
+			}
+			if failed := failedDependency(plan.Steps[d], results, index); failed != "" {
+				results[d] = StepResult{StepID: plan.Steps[d].ID, Error: "skipped: dependency " + failed + " failed"}
+				r, step := results[d], plan.Steps[d]
+				e.emitPlanEvent(PlanEvent{Type: "step_done", Step: &step, Result: &r})
+				doneCh <- d
+				continue
+			}
+			start(d)
+		}
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func failedDependency(step PlanStep, results []StepResult, index map[string]int) string {
+	for _, dep := range step.DependsOn {
+		if results[index[dep]].Error != "" {
+			return dep
+		}
+	}
+	return ""
+}
+
+// runPlanStep executes step and, when a critic rejects the result, retries
+// it with the critic's feedback up to retries times.
+func (e *Engine) runPlanStep(ctx context.Context, executor StepExecutor, critic Critic, retries int, goal string, step PlanStep, deps []StepResult) StepResult {
+	log := observability.LoggerWithTrace(ctx)
+	res := StepResult{StepID: step.ID}
+	var feedback string
+	for attempt := 0; attempt <= retries; attempt++ {
+		res.Attempts++
+		out, err := executor.Execute(ctx, goal, step, deps, feedback)
+		if err != nil {
+			log.Error().Err(err).Str("step", step.ID).Msg("plan_step_failed")
+			res.Error = err.Error()
+			return res
+		}
+		res.Output, res.Error = out, ""
+		if critic == nil {
+			res.Approved = true
+			return res
+		}
+		c, err := critic.Review(ctx, goal, step, out)
+		if err != nil {
+			log.Warn().Err(err).Str("step", step.ID).Msg("plan_critic_failed")
+		}
+		res.Approved, res.Feedback = c.Approved, c.Feedback
+		if c.Approved {
+			return res
+		}
+		log.Info().Str("step", step.ID).Int("attempt", res.Attempts).Str("feedback", c.Feedback).Msg("plan_step_rejected")
+		feedback = c.Feedback
+	}
+	// Keep the last attempt; the synthesis sees the critic's note.
+	return res
+}
+
+func synthesisPrompt(plan Plan, results []StepResult) string {
+	var b strings.Builder
+	b.WriteString("The request above was broken into steps which have been carried out. Their results:\n")
+	for i, s := range plan.Steps {
+		r := results[i]
+		fmt.Fprintf(&b, "\n[%s] %s\n", s.ID, s.Task)
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(&b, "Failed: %s\n", r.Error)
+		case !r.Approved && r.Feedback != "":
+			fmt.Fprintf(&b, "Result (reviewer concern: %s):\n%s\n", r.Feedback, truncateRunes(r.Output, planMaxResultRunes))
+		default:
+			fmt.Fprintf(&b, "Result:\n%s\n", truncateRunes(r.Output, planMaxResultRunes))
+		}
+	}
+	b.WriteString("\nUsing these results, write the final answer to the request. Do not mention the steps unless asked.")
+	return b.String()
+}
+
+// synthesize produces the final answer without tools, streaming through
+// OnDelta when stream is set.
+func (e *Engine) synthesize(ctx context.Context, msgs []llm.Message, stream bool) (string, []llm.Message, error) {
+	if err := e.checkQuota(ctx); err != nil {
+		return "", nil, err
+	}
+	e.emitStepContext(0, msgs)
+	var msg llm.Message
+	if stream {
+		var content string
+		handler := &streamHandler{
+			onDelta: func(d string) {
+				content += d
+				if e.OnDelta != nil {
+					e.OnDelta(d)
+				}
+			},
+			onThoughtSummary: e.OnThoughtSummary,
+		}
+		if err := e.LLM.ChatStream(ctx, msgs, nil, e.model(), handler); err != nil {
+			return "", nil, err
+		}
+		msg = llm.Message{Role: "assistant", Content: content}
+	} else {
+		var err error
+		msg, err = e.LLM.Chat(ctx, msgs, nil, e.model())
+		if err != nil {
+			return "", nil, err
+		}
+		msg.Role = "assistant"
+		msg.ToolCalls = nil
+	}
+	msgs = append(msgs, msg)
+	if e.OnAssistant != nil {
+		e.OnAssistant(msg)
+	}
+	if e.OnTurnMessage != nil {
+		e.OnTurnMessage(msg)
+	}
+	final := msg.Content
+	if strings.TrimSpace(final) == "" {
+		final = noFinalText
+	}
+	return final, msgs, nil
+}
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+)
+
+// planScriptProvider answers planner prompts with plan and everything else
+// by echoing the last user message, recording the prompts it saw.
+type planScriptProvider struct {
+	mu      sync.Mutex
+	plan    string
+	prompts []string
+}
+
+func (p *planScriptProvider) Chat(_ context.Context, msgs []llm.Message, _ []llm.ToolSchema, _ string) (llm.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	last := msgs[len(msgs)-1].Content
+	p.prompts = append(p.prompts, last)
+	if strings.Contains(msgs[0].Content, "You plan work") {
+		return llm.Message{Role: "assistant", Content: p.plan}, nil
+	}
+	if strings.Contains(msgs[0].Content, "You review") {
+		return llm.Message{Role: "assistant", Content: `{"approved": true}`}, nil
+	}
+	return llm.Message{Role: "assistant", Content: "answer: " + last}, nil
+}
+
+func (p *planScriptProvider) ChatStream(ctx context.Context, msgs []llm.Message, schemas []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	msg, err := p.Chat(ctx, msgs, schemas, model)
+	if err != nil {
+		return err
+	}
+	h.OnDelta(msg.Content)
+	return nil
+}
+
+// barrierExecutor blocks s1 and s2 until both have started, proving they run
+// concurrently, and records the dependency results each step received.
+type barrierExecutor struct {
+	mu      sync.Mutex
+	arrived int
+	both    chan struct{}
+	deps    map[string][]string
+	calls   map[string]int
+}
+
+func (x *barrierExecutor) Execute(ctx context.Context, _ string, step PlanStep, deps []StepResult, feedback string) (string, error) {
+	x.mu.Lock()
+	if x.calls[step.ID]++; x.calls[step.ID] == 1 {
+		for _, d := range deps {
+			x.deps[step.ID] = append(x.deps[step.ID], d.StepID+"="+d.Output)
+		}
+	}
+	if step.ID == "s1" || step.ID == "s2" {
+		if x.arrived++; x.arrived == 2 {
+			close(x.both)
+		}
+	}
+	x.mu.Unlock()
+	if step.ID == "s1" || step.ID == "s2" {
+		select {
+		case <-x.both:
+		case <-time.After(2 * time.Second):
+			return "", errors.New("independent steps did not run concurrently")
+		}
+	}
+	if step.ID == "fail" {
+		return "", errors.New("boom")
+	}
+	if feedback != "" {
+		return step.ID + " revised", nil
+	}
+	return step.ID + " out", nil
+}
+
+// rejectOnceCritic rejects the first result of s3.
+type rejectOnceCritic struct {
+	mu       sync.Mutex
+	rejected bool
+}
+
+func (c *rejectOnceCritic) Review(_ context.Context, _ string, step PlanStep, _ string) (Critique, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if step.ID == "s3" && !c.rejected {
+		c.rejected = true
+		return Critique{Feedback: "too short"}, nil
+	}
+	return Critique{Approved: true}, nil
+}
+
+func TestPlanValidate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]Plan{
+		"empty":       {},
+		"missing id":  {Steps: []PlanStep{{Task: "x"}}},
+		"no task":     {Steps: []PlanStep{{ID: "a"}}},
+		"duplicate":   {Steps: []PlanStep{{ID: "a", Task: "x"}, {ID: "a", Task: "y"}}},
+		"unknown dep": {Steps: []PlanStep{{ID: "a", Task: "x", DependsOn: []string{"b"}}}},
+		"cycle": {Steps: []PlanStep{
+			{ID: "a", Task: "x", DependsOn: []string{"c"}},
+			{ID: "b", Task: "y", DependsOn: []string{"a"}},
+			{ID: "c", Task: "z", DependsOn: []string{"b"}},
+		}},
+	}
+	for name, p := range cases {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPlan) {
+			t.Errorf("%s: expected ErrInvalidPlan, got %v", name, err)
+		}
+	}
+	ok := Plan{Steps: []PlanStep{{ID: "a", Task: "x"}, {ID: "b", Task: "y", DependsOn: []string{"a"}}}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid plan rejected: %v", err)
+	}
+}
+
+func TestPlanExecuteRunsDAGWithCritic(t *testing.T) {
+	t.Parallel()
+
+	prov := &planScriptProvider{plan: `{"steps":[
+		{"id":"s1","task":"gather a"},
+		{"id":"s2","task":"gather b"},
+		{"id":"s3","task":"combine","depends_on":["s1","s2"]},
+		{"id":"fail","task":"break"},
+		{"id":"after","task":"never","depends_on":["fail"]}]}`}
+	exec := &barrierExecutor{both: make(chan struct{}), deps: map[string][]string{}, calls: map[string]int{}}
+	var (
+		mu     sync.Mutex
+		events []PlanEvent
+		turn   []llm.Message
+	)
+	e := &Engine{
+		LLM:           prov,
+		Tools:         tools.NewRegistry(),
+		MaxSteps:      2,
+		Mode:          EngineModePlanExecute,
+		Executor:      exec,
+		Critic:        &rejectOnceCritic{},
+		OnPlanEvent:   func(ev PlanEvent) { mu.Lock(); events = append(events, ev); mu.Unlock() },
+		OnTurnMessage: func(m llm.Message) { turn = append(turn, m) },
+	}
+
+	final, err := e.Run(context.Background(), "do the thing", nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := exec.deps["s3"]; len(got) != 2 || got[0] != "s1=s1 out" || got[1] != "s2=s2 out" {
+		t.Fatalf("s3 got deps %v", got)
+	}
+	if exec.calls["s3"] != 2 {
+		t.Fatalf("expected s3 to be retried once after rejection, got %d calls", exec.calls["s3"])
+	}
+	if exec.calls["after"] != 0 {
+		t.Fatalf("step depending on a failed step must be skipped")
+	}
+	for _, want := range []string{"[s3] combine\nResult:\ns3 revised", "[fail] break\nFailed: boom", "[after] never\nFailed: skipped: dependency fail failed"} {
+		if !strings.Contains(final, want) {
+			t.Fatalf("synthesis prompt missing %q:\n%s", want, final)
+		}
+	}
+	if len(turn) != 1 || turn[0].Content != final {
+		t.Fatalf("expected only the synthesized answer in the turn, got %+v", turn)
+	}
+	if len(events) != 10 || events[0].Type != "plan" || len(events[0].Plan.Steps) != 5 {
+		t.Fatalf("unexpected plan events: %d first=%+v", len(events), events[0])
+	}
+}
+
+func TestPlanExecuteFallsBackToSingleStep(t *testing.T) {
+	t.Parallel()
+
+	for _, stream := range []bool{false, true} {
+		prov := &planScriptProvider{plan: "I would rather not plan."}
+		var deltas strings.Builder
+		e := &Engine{
+			LLM:      prov,
+			Tools:    tools.NewRegistry(),
+			MaxSteps: 2,
+			Mode:     EngineModePlanExecute,
+			OnDelta:  func(d string) { deltas.WriteString(d) },
+		}
+		run := e.Run
+		if stream {
+			run = e.RunStream
+		}
+		final, err := run(context.Background(), "summarize the report", nil)
+		if err != nil {
+			t.Fatalf("stream=%v: %v", stream, err)
+		}
+		// planner, step, critic, synthesis
+		if len(prov.prompts) != 4 {
+			t.Fatalf("stream=%v: expected 4 provider calls, got %d: %q", stream, len(prov.prompts), prov.prompts)
+		}
+		if !strings.Contains(prov.prompts[1], "Your task (step s1):\nsummarize the report") {
+			t.Fatalf("stream=%v: fallback step prompt %q", stream, prov.prompts[1])
+		}
+		if !strings.Contains(final, "[s1] summarize the report") {
+			t.Fatalf("stream=%v: unexpected final %q", stream, final)
+		}
+		if stream && deltas.String() != final {
+			t.Fatalf("expected synthesis to stream, got %q", deltas.String())
+		}
+		if !stream && deltas.Len() != 0 {
+			t.Fatalf("non-streaming run must not emit deltas")
+		}
+	}
+}
+
+func TestStepEngineToolCallIDsArePrefixed(t *testing.T) {
+	t.Parallel()
+
+	e := &Engine{Tools: tools.NewRegistry(), ToolResultRelevance: &ToolResultRelevance{}, OnDelta: func(string) {}}
+	child := e.stepEngine("s2")
+	if id := child.nextToolCallID(); id != "engine-call-s2-1" {
+		t.Fatalf("unexpected tool call id %q", id)
+	}
+	if child.ToolResultRelevance != e.ToolResultRelevance || child.OnDelta != nil {
+		t.Fatalf("step engine should keep engine settings and drop per-turn callbacks")
+	}
+	if !IsEngineMode("") || !IsEngineMode(EngineModePlanExecute) || IsEngineMode("swarm") {
+		t.Fatalf("unexpected IsEngineMode results")
+	}
+}
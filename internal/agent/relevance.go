@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// Markers written into tool results the relevance policy has reduced. They
+// also keep a result from being scored again on later steps.
+const (
+	toolResultDroppedMarker    = "[tool result omitted: low relevance to the current request]"
+	toolResultCompressedMarker = "\n[tool result truncated: low relevance to the current request]"
+)
+
+// maxRelevanceEmbedChars caps how much of each text is sent for embedding.
+const maxRelevanceEmbedChars = 4000
+
+// ToolResultRelevance compacts older tool results by relevance instead of
+// age. Each result is scored by the cosine similarity of its embedding to
+// the latest user message; results below DropBelow are replaced by a short
+// marker and results below CompressBelow are truncated to CompressChars,
+// least relevant first, until the history fits the token budget.
+type ToolResultRelevance struct {
+	// Embed returns one vector per input text.
+	Embed func(ctx context.Context, texts []string) ([][]float32, error)
+	// DropBelow is the similarity under which a result is dropped.
+	// Default: 0.2.
+	DropBelow float64
+	// CompressBelow is the similarity under which a result is truncated.
+	// Default: 0.45.
+	CompressBelow float64
+	// CompressChars is how much of a compressed result is kept. Default: 400.
+	CompressChars int
+}
+
+func (p *ToolResultRelevance) thresholds() (drop, compress float64, chars int) {
+	drop, compress, chars = p.DropBelow, p.CompressBelow, p.CompressChars
+	if drop <= 0 {
+		drop = 0.2
+	}
+	if compress <= 0 {
+		compress = 0.45
+	}
+	if compress < drop {
+		compress = drop
+	}
+	if chars <= 0 {
+		chars = 400
+	}
+	return drop, compress, chars
+}
+
+// compactToolResults reduces low-relevance tool results in msgs until they
+// fit tokenBudget or nothing eligible is left. Results of the most recent
+// tool-call batch are never touched. It returns the (possibly copied)
+// messages and their token count; msgs itself is not modified.
+func (e *Engine) compactToolResults(ctx context.Context, msgs []llm.Message, inputTokens, tokenBudget int) ([]llm.Message, int) {
+	p := e.ToolResultRelevance
+	if p == nil || p.Embed == nil {
+		return msgs, inputTokens
+	}
+	query := latestUserText(msgs)
+	if query == "" {
+		return msgs, inputTokens
+	}
+
+	// Tool results after the last assistant tool call belong to the step in
+	// progress and stay intact.
+	protectFrom := len(msgs)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "assistant" && len(msgs[i].ToolCalls) > 0 {
+			protectFrom = i
+			break
+		}
+	}
+	var candidates []int
+	texts := []string{clipForEmbedding(query)}
+	for i := 0; i < protectFrom; i++ {
+		m := msgs[i]
+		if m.Role != "tool" || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		if m.Content == toolResultDroppedMarker || strings.HasSuffix(m.Content, toolResultCompressedMarker) {
+			continue
+		}
+		candidates = append(candidates, i)
+		texts = append(texts, clipForEmbedding(m.Content))
+	}
+	if len(candidates) == 0 {
+		return msgs, inputTokens
+	}
+
+	log := observability.LoggerWithTrace(ctx)
+	vecs, err := p.Embed(ctx, texts)
+	if err != nil || len(vecs) != len(texts) {
+		log.Warn().Err(err).Int("tool_results", len(candidates)).Msg("tool_relevance_embed_failed")
+		return msgs, inputTokens
+	}
+
+	type scored struct {
+		idx   int
+		score float64
+	}
+	ranked := make([]scored, len(candidates))
+	for i, idx := range candidates {
+		ranked[i] = scored{idx: idx, score: cosine(vecs[0], vecs[i+1])}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score < ranked[j].score })
+
+	drop, compress, chars := p.thresholds()
+	out := append([]llm.Message(nil), msgs...)
+	tokens := inputTokens
+	dropped, compressed := 0, 0
+	for _, r := range ranked {
+		if tokens <= tokenBudget || r.score >= compress {
+			break
+		}
+		m := out[r.idx]
+		before := e.countTokens(ctx, m.Content)
+		switch {
+		case r.score < drop:
+			m.Content = toolResultDroppedMarker
+			dropped++
+		case len(m.Content) > chars:
+			m.Content = cutUTF8(m.Content, chars) + toolResultCompressedMarker
+			compressed++
+		default:
+			continue
+		}
+		tokens -= before - e.countTokens(ctx, m.Content)
+		out[r.idx] = m
+	}
+	if dropped == 0 && compressed == 0 {
+		return msgs, inputTokens
+	}
+	tokens = e.countMessagesTokens(ctx, out)
+	log.Info().
+		Int("dropped", dropped).
+		Int("compressed", compressed).
+		Int("input_tokens", tokens).
+		Int("token_budget", tokenBudget).
+		Msg("tool_results_compacted")
+	return out, tokens
+}
+
+// latestUserText returns the newest user message without the current-request
+// framing added by BuildInitialLLMMessages.
+func latestUserText(msgs []llm.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			return strings.TrimSpace(strings.TrimPrefix(msgs[i].Content, currentRequestPrefix))
+		}
+	}
+	return ""
+}
+
+func clipForEmbedding(s string) string {
+	return cutUTF8(s, maxRelevanceEmbedChars)
+}
+
+// cutUTF8 returns at most the first n bytes of s, backing off to a rune
+// boundary so the result stays valid UTF-8.
+func cutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, magA, magB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		magA += float64(a[i]) * float64(a[i])
+		magB += float64(b[i]) * float64(b[i])
+	}
+	if magA == 0 || magB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(magA) * math.Sqrt(magB))
+}
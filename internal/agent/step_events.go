@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"manifold/internal/llm"
+)
+
+// Step event types reported to Engine.OnStepEvent.
+const (
+	StepEventLLMRequest        = "llm_request"
+	StepEventLLMResponse       = "llm_response"
+	StepEventToolStart         = "tool_start"
+	StepEventToolResult        = "tool_result"
+	StepEventSummaryCompaction = "summary_compaction"
+)
+
+// StepEvent is a structured record of one thing the engine did during a
+// step, for run timelines. Fields that do not apply to Type are zero.
+type StepEvent struct {
+	Type string    `json:"type"`
+	Step int       `json:"step"`
+	At   time.Time `json:"at"`
+	// Model is set for llm_request, llm_response and summary_compaction.
+	Model string `json:"model,omitempty"`
+	// Messages is how many messages an llm_request sent.
+	Messages int `json:"messages,omitempty"`
+	// PromptTokens and CompletionTokens are what the provider reported for
+	// an llm_response or the summary request of a summary_compaction.
+	PromptTokens     int `json:"promptTokens,omitempty"`
+	CompletionTokens int `json:"completionTokens,omitempty"`
+	// ToolCalls is how many tool calls an llm_response asked for.
+	ToolCalls  int    `json:"toolCalls,omitempty"`
+	Tool       string `json:"tool,omitempty"`
+	ToolCallID string `json:"toolCallId,omitempty"`
+	// InputTokens, TokenBudget and SummarizedMessages describe a
+	// summary_compaction.
+	InputTokens        int    `json:"inputTokens,omitempty"`
+	TokenBudget        int    `json:"tokenBudget,omitempty"`
+	SummarizedMessages int    `json:"summarizedMessages,omitempty"`
+	DurationMillis     int64  `json:"durationMillis,omitempty"`
+	Error              string `json:"error,omitempty"`
+	// TimedOut is set on a tool_result whose call was stopped at its timeout.
+	TimedOut bool `json:"timedOut,omitempty"`
+}
+
+type eventStepKey struct{}
+
+// withEventStep tags ctx with the step index reported in step events.
+func withEventStep(ctx context.Context, step int) context.Context {
+	return context.WithValue(ctx, eventStepKey{}, step)
+}
+
+// emitStepEvent stamps ev with the step in ctx and the current time and
+// passes it to e.OnStepEvent.
+func (e *Engine) emitStepEvent(ctx context.Context, ev StepEvent) {
+	if e.OnStepEvent == nil {
+		return
+	}
+	ev.Step, _ = ctx.Value(eventStepKey{}).(int)
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	e.OnStepEvent(ev)
+}
+
+// observeModelRequest runs call, a provider request with msgs, between an
+// llm_request and an llm_response event. call returns how many tool calls
+// the model asked for.
+func (e *Engine) observeModelRequest(ctx context.Context, msgs []llm.Message, call func(context.Context) (int, error)) error {
+	if e.OnStepEvent == nil {
+		_, err := call(ctx)
+		return err
+	}
+	e.emitStepEvent(ctx, StepEvent{Type: StepEventLLMRequest, Model: e.model(), Messages: len(msgs)})
+	start := time.Now()
+	uctx, usage := llm.WithUsage(ctx)
+	toolCalls, err := call(uctx)
+	ev := StepEvent{
+		Type:           StepEventLLMResponse,
+		Model:          e.model(),
+		ToolCalls:      toolCalls,
+		DurationMillis: time.Since(start).Milliseconds(),
+	}
+	ev.PromptTokens, ev.CompletionTokens = usage.Tokens()
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	e.emitStepEvent(ctx, ev)
+	return err
+}
+
+// emitToolResult reports the tool_result event of tc, started at start.
+func (e *Engine) emitToolResult(ctx context.Context, tc llm.ToolCall, start time.Time, errText string, timedOut bool) {
+	e.emitStepEvent(ctx, StepEvent{
+		Type:           StepEventToolResult,
+		Tool:           tc.Name,
+		ToolCallID:     tc.ID,
+		DurationMillis: time.Since(start).Milliseconds(),
+		Error:          errText,
+		TimedOut:       timedOut,
+	})
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"manifold/internal/llm"
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+// usageReportingProvider reports fixed token usage for every request.
+type usageReportingProvider struct{ testhelpers.ToolLoopProvider }
+
+func (p *usageReportingProvider) Chat(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Message, error) {
+	llm.RecordTokenMetricsFromContext(ctx, "", 100, 7)
+	return p.ToolLoopProvider.Chat(ctx, msgs, tools, model)
+}
+
+func (p *usageReportingProvider) ChatStream(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	llm.RecordTokenMetricsFromContext(ctx, "", 100, 7)
+	return p.ToolLoopProvider.ChatStream(ctx, msgs, tools, model, h)
+}
+
+func TestOnStepEventReportsTimeline(t *testing.T) {
+	t.Parallel()
+
+	for _, stream := range []bool{false, true} {
+		reg := tools.NewRegistry()
+		reg.Register(&countingTool{name: "run_cli"})
+		var events []StepEvent
+		e := &Engine{
+			LLM:         &usageReportingProvider{testhelpers.ToolLoopProvider{ToolName: "run_cli", Answer: "done"}},
+			Tools:       reg,
+			MaxSteps:    3,
+			Model:       "m",
+			OnStepEvent: func(ev StepEvent) { events = append(events, ev) },
+		}
+		run := e.Run
+		if stream {
+			run = e.RunStream
+		}
+		if _, err := run(context.Background(), "list files", nil); err != nil {
+			t.Fatal(err)
+		}
+		want := []struct {
+			typ  string
+			step int
+		}{
+			{StepEventLLMRequest, 0}, {StepEventLLMResponse, 0}, {StepEventToolStart, 0}, {StepEventToolResult, 0},
+			{StepEventLLMRequest, 1}, {StepEventLLMResponse, 1},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("stream=%v: got %d events: %+v", stream, len(events), events)
+		}
+		for i, w := range want {
+			if events[i].Type != w.typ || events[i].Step != w.step || events[i].At.IsZero() {
+				t.Fatalf("stream=%v: event %d = %+v, want %s at step %d", stream, i, events[i], w.typ, w.step)
+			}
+		}
+		if ev := events[1]; ev.PromptTokens != 100 || ev.CompletionTokens != 7 || ev.ToolCalls != 1 || ev.Model != "m" {
+			t.Fatalf("stream=%v: llm_response = %+v", stream, ev)
+		}
+		if ev := events[3]; ev.Tool != "run_cli" || ev.ToolCallID != "call_1" || ev.Error != "" {
+			t.Fatalf("stream=%v: tool_result = %+v", stream, ev)
+		}
+	}
+}
+
+// blockingTool waits for its context to end.
+type blockingTool struct{}
+
+func (blockingTool) Name() string               { return "run_cli" }
+func (blockingTool) JSONSchema() map[string]any { return map[string]any{"description": "blocks"} }
+func (blockingTool) Call(ctx context.Context, _ json.RawMessage) (any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestToolResultEventReportsTimeout(t *testing.T) {
+	t.Parallel()
+
+	reg := tools.NewRegistry()
+	reg.Register(blockingTool{})
+	var results []StepEvent
+	e := &Engine{
+		LLM:      &testhelpers.ToolLoopProvider{ToolName: "run_cli", Answer: "done"},
+		Tools:    tools.NewTimeoutRegistry(reg, tools.Timeouts{Default: 20 * time.Millisecond}),
+		MaxSteps: 3,
+		OnStepEvent: func(ev StepEvent) {
+			if ev.Type == StepEventToolResult {
+				results = append(results, ev)
+			}
+		},
+	}
+	if _, err := e.Run(context.Background(), "list files", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].TimedOut {
+		t.Fatalf("tool_result events = %+v", results)
+	}
+}
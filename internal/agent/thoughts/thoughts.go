@@ -0,0 +1,170 @@
+// Package thoughts defines the structured events behind the chat UI's
+// thoughts panel: plan updates, scratchpad edits, and retrieval citations.
+//
+// Events are derived from tool results and streamed alongside, not instead
+// of, the generic tool_result event, so clients can render typed panels
+// without parsing tool JSON. Every event carries the schema version in "v";
+// fields are only ever added within a version. See docs/thoughts-events.md.
+package thoughts
+
+import (
+	"encoding/json"
+	"strings"
+
+	"manifold/internal/tools/utility"
+)
+
+// SchemaVersion is the current event schema version.
+const SchemaVersion = 1
+
+// Event types.
+const (
+	TypePlan       = "plan"
+	TypeScratchpad = "scratchpad"
+	TypeCitations  = "citations"
+)
+
+// Citation sources.
+const (
+	SourceRAG = "rag"
+	SourceWeb = "web"
+)
+
+// Event is one thoughts-panel update. Exactly one of Plan, Scratchpad, or
+// Citations is set, matching Type.
+type Event struct {
+	Type       string      `json:"type"`
+	Version    int         `json:"v"`
+	ToolID     string      `json:"tool_id,omitempty"`
+	Plan       *Plan       `json:"plan,omitempty"`
+	Scratchpad *Scratchpad `json:"scratchpad,omitempty"`
+	Citations  []Citation  `json:"citations,omitempty"`
+}
+
+// Plan is the complete current plan; each event replaces the previous one.
+type Plan struct {
+	Explanation string             `json:"explanation,omitempty"`
+	Steps       []utility.PlanStep `json:"steps"`
+}
+
+// Scratchpad is a single edit to the working notes.
+type Scratchpad struct {
+	Op      string `json:"op"`
+	Content string `json:"content"`
+}
+
+// Citation is a source the agent retrieved and may cite. Index is 1-based
+// within the event.
+type Citation struct {
+	Index   int     `json:"index"`
+	Source  string  `json:"source"`
+	Query   string  `json:"query,omitempty"`
+	ID      string  `json:"id,omitempty"`
+	DocID   string  `json:"doc_id,omitempty"`
+	Title   string  `json:"title,omitempty"`
+	URL     string  `json:"url,omitempty"`
+	Snippet string  `json:"snippet,omitempty"`
+	Score   float64 `json:"score,omitempty"`
+}
+
+// FromToolResult derives a thoughts event from a completed tool call. It
+// returns false for tools that have no structured counterpart and for
+// failed calls.
+func FromToolResult(toolName string, result []byte, toolID string) (Event, bool) {
+	ev := Event{Version: SchemaVersion, ToolID: toolID}
+	switch toolName {
+	case utility.PlanToolName:
+		var r struct {
+			OK          bool               `json:"ok"`
+			Explanation string             `json:"explanation"`
+			Steps       []utility.PlanStep `json:"steps"`
+		}
+		if json.Unmarshal(result, &r) != nil || !r.OK {
+			return Event{}, false
+		}
+		ev.Type = TypePlan
+		ev.Plan = &Plan{Explanation: r.Explanation, Steps: r.Steps}
+	case utility.ScratchpadToolName:
+		var r struct {
+			OK      bool   `json:"ok"`
+			Op      string `json:"op"`
+			Content string `json:"content"`
+		}
+		if json.Unmarshal(result, &r) != nil || !r.OK {
+			return Event{}, false
+		}
+		ev.Type = TypeScratchpad
+		ev.Scratchpad = &Scratchpad{Op: r.Op, Content: r.Content}
+	case "rag_retrieve", "rag_query":
+		ev.Citations = ragCitations(result)
+	case "web_search":
+		ev.Citations = webCitations(result)
+	default:
+		return Event{}, false
+	}
+	if ev.Type == "" {
+		if len(ev.Citations) == 0 {
+			return Event{}, false
+		}
+		ev.Type = TypeCitations
+	}
+	return ev, true
+}
+
+func ragCitations(result []byte) []Citation {
+	// Retrieved items are serialised without json tags, hence the Go field
+	// names.
+	var r struct {
+		OK    bool   `json:"ok"`
+		Query string `json:"query"`
+		Items []struct {
+			ID      string
+			DocID   string
+			Score   float64
+			Snippet string
+			Doc     struct {
+				Title string `json:"title"`
+				URL   string `json:"url"`
+			}
+		} `json:"items"`
+	}
+	if json.Unmarshal(result, &r) != nil || !r.OK {
+		return nil
+	}
+	out := make([]Citation, 0, len(r.Items))
+	for _, it := range r.Items {
+		out = append(out, Citation{
+			Index:   len(out) + 1,
+			Source:  SourceRAG,
+			Query:   r.Query,
+			ID:      it.ID,
+			DocID:   it.DocID,
+			Title:   it.Doc.Title,
+			URL:     it.Doc.URL,
+			Snippet: strings.TrimSpace(it.Snippet),
+			Score:   it.Score,
+		})
+	}
+	return out
+}
+
+func webCitations(result []byte) []Citation {
+	var r struct {
+		OK      bool `json:"ok"`
+		Results []struct {
+			Title string `json:"title"`
+			URL   string `json:"url"`
+		} `json:"results"`
+	}
+	if json.Unmarshal(result, &r) != nil || !r.OK {
+		return nil
+	}
+	out := make([]Citation, 0, len(r.Results))
+	for _, res := range r.Results {
+		if res.URL == "" {
+			continue
+		}
+		out = append(out, Citation{Index: len(out) + 1, Source: SourceWeb, Title: res.Title, URL: res.URL})
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package thoughts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"manifold/internal/tools/utility"
+)
+
+func callTool(t *testing.T, call func(context.Context, json.RawMessage) (any, error), args string) []byte {
+	t.Helper()
+	out, err := call(context.Background(), json.RawMessage(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestPlanEvent(t *testing.T) {
+	t.Parallel()
+	res := callTool(t, utility.PlanTool{}.Call, `{"explanation":"start","steps":[{"step":"read","status":"completed"},{"step":"write","status":"in_progress"}]}`)
+	ev, ok := FromToolResult(utility.PlanToolName, res, "call_1")
+	if !ok || ev.Type != TypePlan || ev.Version != SchemaVersion || ev.ToolID != "call_1" {
+		t.Fatalf("unexpected event %+v ok=%v", ev, ok)
+	}
+	if len(ev.Plan.Steps) != 2 || ev.Plan.Steps[1].Status != utility.PlanStatusInProgress || ev.Plan.Explanation != "start" {
+		t.Fatalf("unexpected plan %+v", ev.Plan)
+	}
+
+	bad := callTool(t, utility.PlanTool{}.Call, `{"steps":[{"step":"a","status":"in_progress"},{"step":"b","status":"in_progress"}]}`)
+	if _, ok := FromToolResult(utility.PlanToolName, bad, ""); ok {
+		t.Fatal("rejected plan should not produce an event")
+	}
+}
+
+func TestScratchpadEvent(t *testing.T) {
+	t.Parallel()
+	res := callTool(t, utility.ScratchpadTool{}.Call, `{"content":"check the cache"}`)
+	ev, ok := FromToolResult(utility.ScratchpadToolName, res, "")
+	if !ok || ev.Type != TypeScratchpad || ev.Scratchpad.Op != utility.ScratchpadAppend || ev.Scratchpad.Content != "check the cache" {
+		t.Fatalf("unexpected event %+v ok=%v", ev, ok)
+	}
+}
+
+func TestCitationEvents(t *testing.T) {
+	t.Parallel()
+	rag := []byte(`{"ok":true,"query":"q","items":[{"ID":"c1","DocID":"d1","Score":0.8,"Snippet":" text ","Doc":{"title":"Doc","url":"https://x"}}]}`)
+	ev, ok := FromToolResult("rag_query", rag, "")
+	if !ok || ev.Type != TypeCitations || len(ev.Citations) != 1 {
+		t.Fatalf("unexpected event %+v ok=%v", ev, ok)
+	}
+	want := Citation{Index: 1, Source: SourceRAG, Query: "q", ID: "c1", DocID: "d1", Title: "Doc", URL: "https://x", Snippet: "text", Score: 0.8}
+	if ev.Citations[0] != want {
+		t.Fatalf("citation=%+v want %+v", ev.Citations[0], want)
+	}
+
+	web := []byte(`{"ok":true,"results":[{"title":"A","url":"https://a"},{"title":"no url"}]}`)
+	ev, ok = FromToolResult("web_search", web, "")
+	if !ok || len(ev.Citations) != 1 || ev.Citations[0].Source != SourceWeb {
+		t.Fatalf("unexpected event %+v ok=%v", ev, ok)
+	}
+
+	for name, res := range map[string]string{
+		"rag_retrieve": `{"ok":true,"items":[]}`,
+		"web_search":   `{"ok":false,"error":"rate limited"}`,
+		"run_cli":      `{"ok":true}`,
+	} {
+		if _, ok := FromToolResult(name, []byte(res), ""); ok {
+			t.Fatalf("%s: expected no event", name)
+		}
+	}
+}
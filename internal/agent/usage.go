@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ToolUsage describes one completed tool call. Agent is set for delegated
+// agent calls and names the specialist that ran.
+type ToolUsage struct {
+	Name     string
+	Agent    string
+	Duration time.Duration
+	Failed   bool
+}
+
+func (e *Engine) reportToolUsage(ctx context.Context, name string, payload []byte, dur time.Duration) {
+	if e.OnToolUsage == nil {
+		return
+	}
+	u := ToolUsage{Name: name, Duration: dur}
+	var res struct {
+		OK    *bool  `json:"ok"`
+		Error any    `json:"error"`
+		Agent string `json:"agent"`
+	}
+	if trimmed := strings.TrimSpace(string(payload)); strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &res) == nil {
+		u.Failed = (res.OK != nil && !*res.OK) || (res.Error != nil && res.Error != "")
+		if isAgentCall(name) {
+			u.Agent = res.Agent
+		}
+	}
+	e.OnToolUsage(ctx, u)
+}
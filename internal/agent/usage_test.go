@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+)
+
+type failingTool struct{}
+
+func (failingTool) Name() string               { return "broken" }
+func (failingTool) JSONSchema() map[string]any { return map[string]any{"description": "broken"} }
+func (failingTool) Call(context.Context, json.RawMessage) (any, error) {
+	return nil, errors.New("boom")
+}
+
+func TestExecuteToolCallReportsUsage(t *testing.T) {
+	t.Parallel()
+
+	reg := tools.NewRegistry()
+	reg.Register(&countingTool{name: "web_fetch"})
+	reg.Register(failingTool{})
+
+	var (
+		mu   sync.Mutex
+		seen []ToolUsage
+	)
+	eng := &Engine{Tools: reg, OnToolUsage: func(_ context.Context, u ToolUsage) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, u)
+	}}
+	ctx := context.Background()
+	eng.executeToolCall(ctx, llm.ToolCall{ID: "t1", Name: "web_fetch", Args: json.RawMessage(`{}`)})
+	eng.executeToolCall(ctx, llm.ToolCall{ID: "t2", Name: "broken", Args: json.RawMessage(`{}`)})
+
+	if len(seen) != 2 {
+		t.Fatalf("expected 2 usage reports, got %d", len(seen))
+	}
+	if seen[0].Name != "web_fetch" || seen[0].Failed {
+		t.Fatalf("unexpected usage for web_fetch: %+v", seen[0])
+	}
+	if seen[1].Name != "broken" || !seen[1].Failed {
+		t.Fatalf("expected broken tool to report failure: %+v", seen[1])
+	}
+}
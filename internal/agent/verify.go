@@ -0,0 +1,321 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// Verification check names reported in Verdict.Check.
+const (
+	CheckEmpty   = "empty"
+	CheckRefusal = "refusal"
+	CheckSchema  = "schema"
+	CheckModel   = "model"
+)
+
+const verifierMaxAnswerRunes = 8000
+
+// Verdict is the outcome of verifying a final answer.
+type Verdict struct {
+	OK     bool   `json:"ok"`
+	Check  string `json:"check,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Verifier inspects the final answer to a prompt. Errors are treated as a
+// pass so a broken verifier never blocks an answer.
+type Verifier interface {
+	Verify(ctx context.Context, prompt, answer string) (Verdict, error)
+}
+
+// Verifiers runs each verifier in order and returns the first failure.
+type Verifiers []Verifier
+
+func (vs Verifiers) Verify(ctx context.Context, prompt, answer string) (Verdict, error) {
+	for _, v := range vs {
+		if v == nil {
+			continue
+		}
+		verdict, err := v.Verify(ctx, prompt, answer)
+		if err != nil {
+			return Verdict{OK: true}, err
+		}
+		if !verdict.OK {
+			return verdict, nil
+		}
+	}
+	return Verdict{OK: true}, nil
+}
+
+// Escalation records a final answer that failed verification and was
+// re-synthesized with a stronger model.
+type Escalation struct {
+	FromModel string
+	ToModel   string
+	Check     string
+	Reason    string
+	// Answer is the escalated model's answer; empty when the retry failed.
+	Answer string
+	// Verified reports whether the escalated answer passed verification.
+	Verified bool
+	Err      error
+}
+
+var refusalPatterns = regexp.MustCompile(`(?i)^\s*(i'?m sorry,? but |sorry,? (but )?i (can'?t|cannot)|i (can'?t|cannot|won'?t|am unable to|'m unable to) (help|assist|comply|provide|do that)|as an ai( language model)?,? i (can'?t|cannot))`)
+
+// RuleVerifier applies cheap deterministic checks. A nil Schema disables the
+// schema check.
+type RuleVerifier struct {
+	Checks   []string
+	MinChars int
+	Schema   map[string]any
+}
+
+func (r *RuleVerifier) enabled(check string) bool {
+	if len(r.Checks) == 0 {
+		return true
+	}
+	for _, c := range r.Checks {
+		if strings.EqualFold(strings.TrimSpace(c), check) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RuleVerifier) Verify(_ context.Context, _ string, answer string) (Verdict, error) {
+	trimmed := strings.TrimSpace(answer)
+	if r.enabled(CheckEmpty) {
+		minChars := max(r.MinChars, 1)
+		if len([]rune(trimmed)) < minChars || trimmed == noFinalText {
+			return Verdict{Check: CheckEmpty, Reason: "answer is empty"}, nil
+		}
+	}
+	if r.enabled(CheckRefusal) && refusalPatterns.MatchString(trimmed) {
+		return Verdict{Check: CheckRefusal, Reason: "answer is a refusal"}, nil
+	}
+	if r.Schema != nil && r.enabled(CheckSchema) {
+		var v any
+		if err := json.Unmarshal([]byte(stripCodeFence(trimmed)), &v); err != nil {
+			return Verdict{Check: CheckSchema, Reason: "answer is not valid JSON"}, nil
+		}
+		if err := validateSchema(r.Schema, v, "$"); err != nil {
+			return Verdict{Check: CheckSchema, Reason: err.Error()}, nil
+		}
+	}
+	return Verdict{OK: true}, nil
+}
+
+// ModelVerifier asks an LLM (typically a cheap summary model) to grade the
+// answer.
+type ModelVerifier struct {
+	LLM   llm.Provider
+	Model string
+}
+
+func (m *ModelVerifier) Verify(ctx context.Context, prompt, answer string) (Verdict, error) {
+	sys := "You grade assistant answers. Fail an answer only if it is empty, refuses a reasonable request, " +
+		"ignores the question, or is clearly incomplete. " +
+		`Respond with JSON only: {"pass": bool, "reason": string}.`
+	user := fmt.Sprintf("Question:\n%s\n\nAnswer:\n%s", truncateRunes(prompt, verifierMaxAnswerRunes), truncateRunes(answer, verifierMaxAnswerRunes))
+	resp, err := m.LLM.Chat(ctx, []llm.Message{
+		{Role: "system", Content: sys},
+		{Role: "user", Content: user},
+	}, nil, m.Model)
+	if err != nil {
+		return Verdict{OK: true}, fmt.Errorf("verify: %w", err)
+	}
+	raw := resp.Content
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return Verdict{OK: true}, fmt.Errorf("verifier returned no JSON object")
+	}
+	var out struct {
+		Pass   bool   `json:"pass"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &out); err != nil {
+		return Verdict{OK: true}, fmt.Errorf("decode verifier verdict: %w", err)
+	}
+	if out.Pass {
+		return Verdict{OK: true}, nil
+	}
+	return Verdict{Check: CheckModel, Reason: out.Reason}, nil
+}
+
+// verifyAndEscalate runs the configured verifier on final. When it fails and
+// EscalationModel is set, the final synthesis is re-run once without tools
+// on the conversation that produced final.
+func (e *Engine) verifyAndEscalate(ctx context.Context, userInput, final string, msgs []llm.Message) string {
+	if e.Verifier == nil {
+		return final
+	}
+	log := observability.LoggerWithTrace(ctx)
+	verdict, err := e.Verifier.Verify(ctx, userInput, final)
+	if err != nil {
+		log.Warn().Err(err).Msg("verifier_failed")
+		return final
+	}
+	if verdict.OK {
+		return final
+	}
+	log.Info().Str("check", verdict.Check).Str("reason", verdict.Reason).Msg("verifier_rejected_answer")
+	esc := Escalation{FromModel: e.Model, ToModel: e.EscalationModel, Check: verdict.Check, Reason: verdict.Reason}
+	if e.EscalationModel == "" {
+		e.emitEscalation(esc)
+		return final
+	}
+
+	// Drop the rejected answer so the escalated model synthesizes from the
+	// same context the original model saw.
+	if n := len(msgs); n > 0 && msgs[n-1].Role == "assistant" && len(msgs[n-1].ToolCalls) == 0 {
+		msgs = msgs[:n-1]
+	}
+	msg, err := e.LLM.Chat(ctx, msgs, nil, e.EscalationModel)
+	if err != nil {
+		log.Error().Err(err).Str("model", e.EscalationModel).Msg("escalation_failed")
+		esc.Err = err
+		e.emitEscalation(esc)
+		return final
+	}
+	esc.Answer = msg.Content
+	if v, err := e.Verifier.Verify(ctx, userInput, msg.Content); err == nil {
+		esc.Verified = v.OK
+	}
+	log.Info().Str("from_model", esc.FromModel).Str("to_model", esc.ToModel).Bool("verified", esc.Verified).Msg("escalation_completed")
+	e.emitEscalation(esc)
+	if strings.TrimSpace(msg.Content) == "" {
+		return final
+	}
+	msg.Role = "assistant"
+	msg.ToolCalls = nil
+	if e.OnTurnMessage != nil {
+		e.OnTurnMessage(msg)
+	}
+	return msg.Content
+}
+
+func (e *Engine) emitEscalation(esc Escalation) {
+	if e.OnEscalation != nil {
+		e.OnEscalation(esc)
+	}
+	if e.AgentTracer != nil {
+		trace := AgentTrace{
+			Type:    "escalation",
+			Agent:   "orchestrator",
+			Model:   esc.ToModel,
+			Depth:   e.AgentDepth,
+			Title:   "Escalated " + esc.Check + " failure",
+			Content: esc.Reason,
+			Data:    esc.FromModel,
+		}
+		if esc.Err != nil {
+			trace.Error = esc.Err.Error()
+		}
+		e.AgentTracer.Trace(trace)
+	}
+}
+
+// validateSchema checks v against a small subset of JSON Schema: type,
+// required, properties, items, and enum.
+func validateSchema(schema map[string]any, v any, path string) error {
+	if t, ok := schema["type"].(string); ok && !jsonTypeMatches(t, v) {
+		return fmt.Errorf("%s: expected %s", path, t)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value not in enum", path)
+		}
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		if req, ok := schema["required"].([]any); ok {
+			for _, r := range req {
+				name, _ := r.(string)
+				if _, ok := val[name]; !ok {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, sub := range props {
+			subSchema, ok := sub.(map[string]any)
+			child, present := val[name]
+			if !ok || !present {
+				continue
+			}
+			if err := validateSchema(subSchema, child, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(t string, v any) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+// stripCodeFence removes a surrounding ``` fence, which models often add
+// around JSON answers.
+func stripCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
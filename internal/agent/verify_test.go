@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+)
+
+// modelAnswerProvider answers with a fixed reply per model name.
+type modelAnswerProvider struct {
+	answers map[string]string
+	models  []string
+	tools   []int
+}
+
+func (p *modelAnswerProvider) Chat(_ context.Context, _ []llm.Message, schemas []llm.ToolSchema, model string) (llm.Message, error) {
+	p.models = append(p.models, model)
+	p.tools = append(p.tools, len(schemas))
+	return llm.Message{Role: "assistant", Content: p.answers[model]}, nil
+}
+
+func (p *modelAnswerProvider) ChatStream(ctx context.Context, msgs []llm.Message, schemas []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	msg, _ := p.Chat(ctx, msgs, schemas, model)
+	h.OnDelta(msg.Content)
+	return nil
+}
+
+func TestRuleVerifier(t *testing.T) {
+	t.Parallel()
+
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	cases := []struct {
+		answer string
+		schema map[string]any
+		check  string
+	}{
+		{answer: "   ", check: CheckEmpty},
+		{answer: noFinalText, check: CheckEmpty},
+		{answer: "I'm sorry, but I can't help with that.", check: CheckRefusal},
+		{answer: "Paris is the capital of France."},
+		{answer: "not json", schema: schema, check: CheckSchema},
+		{answer: `{"tags":["a"]}`, schema: schema, check: CheckSchema},
+		{answer: `{"name":"x","tags":[1]}`, schema: schema, check: CheckSchema},
+		{answer: "```json\n{\"name\":\"x\",\"tags\":[\"a\"]}\n```", schema: schema},
+	}
+	for _, tc := range cases {
+		v, err := (&RuleVerifier{Schema: tc.schema}).Verify(context.Background(), "q", tc.answer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.OK != (tc.check == "") || v.Check != tc.check {
+			t.Errorf("answer %q: got %+v want check %q", tc.answer, v, tc.check)
+		}
+	}
+}
+
+func TestEngineEscalatesRejectedAnswer(t *testing.T) {
+	t.Parallel()
+
+	for _, stream := range []bool{false, true} {
+		prov := &modelAnswerProvider{answers: map[string]string{"small": "", "big": "The answer is 42."}}
+		var escalations []Escalation
+		var turn []llm.Message
+		e := &Engine{
+			LLM:             prov,
+			Tools:           tools.NewRegistry(),
+			MaxSteps:        2,
+			Model:           "small",
+			Verifier:        &RuleVerifier{},
+			EscalationModel: "big",
+			OnEscalation:    func(esc Escalation) { escalations = append(escalations, esc) },
+			OnTurnMessage:   func(m llm.Message) { turn = append(turn, m) },
+		}
+		run := e.Run
+		if stream {
+			run = e.RunStream
+		}
+		got, err := run(context.Background(), "what is the answer?", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "The answer is 42." {
+			t.Fatalf("stream=%v: final=%q", stream, got)
+		}
+		if len(prov.models) != 2 || prov.models[1] != "big" || prov.tools[1] != 0 {
+			t.Fatalf("stream=%v: unexpected provider calls models=%v tools=%v", stream, prov.models, prov.tools)
+		}
+		if len(escalations) != 1 || escalations[0].Check != CheckEmpty || escalations[0].FromModel != "small" || !escalations[0].Verified {
+			t.Fatalf("stream=%v: unexpected escalations %+v", stream, escalations)
+		}
+		if len(turn) != 2 || turn[1].Content != "The answer is 42." {
+			t.Fatalf("stream=%v: unexpected turn messages %+v", stream, turn)
+		}
+	}
+}
+
+func TestEngineVerifierWithoutEscalationModel(t *testing.T) {
+	t.Parallel()
+
+	prov := &modelAnswerProvider{answers: map[string]string{"small": "I cannot help with that."}}
+	var escalations []Escalation
+	e := &Engine{
+		LLM:          prov,
+		Tools:        tools.NewRegistry(),
+		MaxSteps:     2,
+		Model:        "small",
+		Verifier:     &RuleVerifier{},
+		OnEscalation: func(esc Escalation) { escalations = append(escalations, esc) },
+	}
+	got, err := e.Run(context.Background(), "do it", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "I cannot help with that." || len(prov.models) != 1 {
+		t.Fatalf("final=%q calls=%v", got, prov.models)
+	}
+	if len(escalations) != 1 || escalations[0].Check != CheckRefusal || escalations[0].Answer != "" {
+		t.Fatalf("unexpected escalations %+v", escalations)
+	}
+}
@@ -0,0 +1,250 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	persist "manifold/internal/persistence"
+)
+
+// chatRewindRequest is the body of the edit and regenerate endpoints. The
+// embedded run parameters are applied to the re-run turn; Prompt and
+// SessionID are derived from the rewound session and ignored if sent.
+type chatRewindRequest struct {
+	chatRunRequest
+	// Content replaces the user message text. Required for edit; optional for
+	// regenerate, where the original user message is re-sent by default.
+	Content string `json:"content,omitempty"`
+	// Branch keeps the original conversation intact and re-runs the turn in a
+	// forked session instead of truncating the original.
+	Branch bool `json:"branch,omitempty"`
+}
+
+// chatForkRequest is the body of POST /api/chat/sessions/{id}/fork.
+type chatForkRequest struct {
+	// MessageID is the last message copied into the fork. Empty copies the
+	// whole conversation.
+	MessageID string `json:"message_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// chatRewindSessionHeader reports which session a rewound turn ran in, since
+// branching moves it to a new session before the response streams.
+const chatRewindSessionHeader = "X-Chat-Session-ID"
+
+func writeChatStoreError(w http.ResponseWriter, r *http.Request, err error, sessionID, msg string) {
+	switch {
+	case errors.Is(err, persist.ErrForbidden):
+		apierror.Respond(w, http.StatusForbidden, "forbidden")
+	case errors.Is(err, persist.ErrNotFound):
+		http.NotFound(w, r)
+	default:
+		log.Error().Err(err).Str("session", sessionID).Msg(msg)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// rewindTurnIndex returns the index of the user message that starts the turn
+// containing msgs[idx]: the message itself when it is a user message,
+// otherwise the closest preceding user message.
+func rewindTurnIndex(msgs []persist.ChatMessage, idx int) int {
+	for i := idx; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+// forkChatSession copies msgs into a new session owned by the same user as
+// sess. The summary is carried over only when it covers no more than the
+// copied prefix.
+func forkChatSession(ctx context.Context, store persist.ChatStore, sess persist.ChatSession, msgs []persist.ChatMessage, name string) (persist.ChatSession, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = firstNonEmptyTrimmed(sess.Name, "Conversation") + " (branch)"
+	}
+	fork, err := store.CreateSession(ctx, sess.UserID, name)
+	if err != nil {
+		return persist.ChatSession{}, err
+	}
+	if len(msgs) > 0 {
+		copied := make([]persist.ChatMessage, 0, len(msgs))
+		for _, m := range msgs {
+			copied = append(copied, persist.ChatMessage{Role: m.Role, Content: m.Content, CreatedAt: m.CreatedAt})
+		}
+		preview := previewSnippet(copied[len(copied)-1].Content)
+		if err := store.AppendMessages(ctx, sess.UserID, fork.ID, copied, preview, sess.Model); err != nil {
+			return persist.ChatSession{}, err
+		}
+	}
+	if sess.Summary != "" && sess.SummarizedCount > 0 && sess.SummarizedCount <= len(msgs) {
+		if err := store.UpdateSummary(ctx, sess.UserID, fork.ID, sess.Summary, sess.SummarizedCount); err != nil {
+			return persist.ChatSession{}, err
+		}
+	}
+	return store.GetSession(ctx, sess.UserID, fork.ID)
+}
+
+// truncateChatSession removes msgs[idx] and everything after it, resetting
+// the summary when it covered any removed message.
+func (a *app) truncateChatSession(ctx context.Context, userID *int64, sess persist.ChatSession, msgs []persist.ChatMessage, idx int) error {
+	resetSummary := sess.SummarizedCount > idx
+	if atomicStore, ok := a.chatStore.(atomicChatTurnDeleteStore); ok {
+		return atomicStore.DeleteMessagesAfterWithRelated(ctx, userID, sess.ID, msgs[idx].ID, true, nil, resetSummary)
+	}
+	if err := a.chatStore.DeleteMessagesAfter(ctx, userID, sess.ID, msgs[idx].ID, true); err != nil {
+		return err
+	}
+	if resetSummary {
+		if err := a.chatStore.UpdateSummary(ctx, userID, sess.ID, "", 0); err != nil {
+			log.Error().Err(err).Str("session", sess.ID).Msg("reset_chat_summary")
+		}
+	}
+	return nil
+}
+
+// handleChatMessageRewind serves POST .../messages/{message_id}/edit and
+// .../regenerate. The conversation is rewound to just before the turn's user
+// message, either in place or in a fork, and the turn is re-run through the
+// regular chat dispatch so the response streams like /agent/run.
+func (a *app) handleChatMessageRewind(w http.ResponseWriter, r *http.Request, userID *int64, sessionID, messageID string, regenerate bool) {
+	if r.Method != http.MethodPost {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	defer r.Body.Close()
+	var req chatRewindRequest
+	if !apierror.DecodeOptional(w, r, &req, 0) {
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if !regenerate && content == "" {
+		apierror.RespondInvalid(w, "content", "required")
+		return
+	}
+
+	ctx := r.Context()
+	sess, err := a.chatStore.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		writeChatStoreError(w, r, err, sessionID, "get_chat_session")
+		return
+	}
+	msgs, err := a.chatStore.ListMessages(ctx, userID, sessionID, 0)
+	if err != nil {
+		writeChatStoreError(w, r, err, sessionID, "list_chat_messages")
+		return
+	}
+	msgIndex := -1
+	for i, m := range msgs {
+		if m.ID == messageID {
+			msgIndex = i
+			break
+		}
+	}
+	if msgIndex == -1 {
+		http.NotFound(w, r)
+		return
+	}
+	if !regenerate && msgs[msgIndex].Role != "user" {
+		apierror.Respond(w, http.StatusBadRequest, "only user messages can be edited")
+		return
+	}
+	turnIndex := rewindTurnIndex(msgs, msgIndex)
+	if turnIndex == -1 {
+		apierror.Respond(w, http.StatusBadRequest, "no user message to regenerate from")
+		return
+	}
+	if content == "" {
+		content = msgs[turnIndex].Content
+	}
+
+	runSessionID := sessionID
+	if req.Branch {
+		fork, err := forkChatSession(ctx, a.chatStore, sess, msgs[:turnIndex], "")
+		if err != nil {
+			writeChatStoreError(w, r, err, sessionID, "fork_chat_session")
+			return
+		}
+		runSessionID = fork.ID
+	} else if err := a.truncateChatSession(ctx, userID, sess, msgs, turnIndex); err != nil {
+		writeChatStoreError(w, r, err, sessionID, "truncate_chat_session")
+		return
+	}
+
+	run := req.chatRunRequest
+	run.Prompt = content
+	run.SessionID = runSessionID
+	run.EphemeralSession = false
+	run.normalize()
+	w.Header().Set(chatRewindSessionHeader, runSessionID)
+
+	state, ok := a.prepareChatHandlerState(w, r, run)
+	if !ok {
+		return
+	}
+	r = state.Request
+	specOwner := state.Owner
+
+	target := resolveChatDispatchTarget(r.URL.Query())
+	_, hasCustomTarget := a.describeChatTarget(target, run.SessionID, run.SystemPrompt, specOwner)
+
+	if a.cfg.OpenAI.APIKey == "" && !hasCustomTarget {
+		a.handleDevMockChat(w, r, run.Prompt)
+		return
+	}
+	a.handleChatTarget(w, r, target, run.Prompt, run.SessionID, run.EphemeralSession, run.SystemPrompt, state.UserID, specOwner, a.agentRunOrchestratorDescriptor(r.Context(), specOwner, run, state.CheckedOutWorkspace))
+}
+
+// handleChatSessionFork serves POST /api/chat/sessions/{id}/fork, copying the
+// conversation up to and including message_id into a new session.
+func (a *app) handleChatSessionFork(w http.ResponseWriter, r *http.Request, userID *int64, sessionID string) {
+	if r.Method != http.MethodPost {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	defer r.Body.Close()
+	var req chatForkRequest
+	if !apierror.DecodeOptional(w, r, &req, 0) {
+		return
+	}
+
+	ctx := r.Context()
+	sess, err := a.chatStore.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		writeChatStoreError(w, r, err, sessionID, "get_chat_session")
+		return
+	}
+	msgs, err := a.chatStore.ListMessages(ctx, userID, sessionID, 0)
+	if err != nil {
+		writeChatStoreError(w, r, err, sessionID, "list_chat_messages")
+		return
+	}
+	if messageID := strings.TrimSpace(req.MessageID); messageID != "" {
+		end := -1
+		for i, m := range msgs {
+			if m.ID == messageID {
+				end = i + 1
+				break
+			}
+		}
+		if end == -1 {
+			http.NotFound(w, r)
+			return
+		}
+		msgs = msgs[:end]
+	}
+	fork, err := forkChatSession(ctx, a.chatStore, sess, msgs, req.Name)
+	if err != nil {
+		writeChatStoreError(w, r, err, sessionID, "fork_chat_session")
+		return
+	}
+	writeJSON(w, http.StatusCreated, fork)
+}
@@ -0,0 +1,164 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+)
+
+// rewindChatStore extends promptHandlerChatStore with a working
+// DeleteMessagesAfter so truncation can be observed.
+type rewindChatStore struct {
+	*promptHandlerChatStore
+}
+
+func (s rewindChatStore) DeleteMessagesAfter(_ context.Context, _ *int64, sessionID, messageID string, inclusive bool) error {
+	msgs := s.messages[sessionID]
+	for i, m := range msgs {
+		if m.ID != messageID {
+			continue
+		}
+		if !inclusive {
+			i++
+		}
+		s.messages[sessionID] = msgs[:i]
+		return nil
+	}
+	return persistence.ErrNotFound
+}
+
+func newRewindTestApp(t *testing.T) (*app, rewindChatStore) {
+	t.Helper()
+	store := rewindChatStore{newPromptHandlerChatStore()}
+	ctx := context.Background()
+	if _, err := store.EnsureSession(ctx, nil, "sess", "Trip"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	_ = store.AppendMessages(ctx, nil, "sess", []persistence.ChatMessage{
+		{ID: "u1", Role: "user", Content: "plan a trip"},
+		{ID: "a1", Role: "assistant", Content: "where to?"},
+		{ID: "u2", Role: "user", Content: "lisbon"},
+		{ID: "a2", Role: "assistant", Content: "here is a plan"},
+	}, "", "")
+	if err := store.UpdateSummary(ctx, nil, "sess", "user wants a trip", 3); err != nil {
+		t.Fatalf("UpdateSummary: %v", err)
+	}
+	return &app{cfg: &config.Config{}, chatStore: store, runs: newRunStore()}, store
+}
+
+func messageIDs(msgs []persistence.ChatMessage) []string {
+	out := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+func TestChatMessageEditTruncatesAndReruns(t *testing.T) {
+	t.Parallel()
+	a, store := newRewindTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/sessions/sess/messages/u2/edit", bytes.NewBufferString(`{"content":"porto"}`))
+	rr := httptest.NewRecorder()
+	a.chatSessionDetailHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get(chatRewindSessionHeader); got != "sess" {
+		t.Fatalf("session header = %q", got)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp["result"] != "(dev) mock response: porto" {
+		t.Fatalf("expected re-run with edited prompt, got %q", resp["result"])
+	}
+	if got := messageIDs(store.messages["sess"]); len(got) != 2 || got[0] != "u1" || got[1] != "a1" {
+		t.Fatalf("expected truncation before edited message, got %v", got)
+	}
+	if sess := store.sessions["sess"]; sess.Summary != "" || sess.SummarizedCount != 0 {
+		t.Fatalf("expected summary reset, got %q/%d", sess.Summary, sess.SummarizedCount)
+	}
+}
+
+func TestChatMessageEditRejectsAssistantMessage(t *testing.T) {
+	t.Parallel()
+	a, _ := newRewindTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/sessions/sess/messages/a1/edit", bytes.NewBufferString(`{"content":"x"}`))
+	rr := httptest.NewRecorder()
+	a.chatSessionDetailHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rr.Code)
+	}
+}
+
+func TestChatMessageRegenerateBranchKeepsOriginal(t *testing.T) {
+	t.Parallel()
+	a, store := newRewindTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/sessions/sess/messages/a2/regenerate", bytes.NewBufferString(`{"branch":true}`))
+	rr := httptest.NewRecorder()
+	a.chatSessionDetailHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp["result"] != "(dev) mock response: lisbon" {
+		t.Fatalf("expected original prompt to be re-sent, got %q", resp["result"])
+	}
+	if got := len(store.messages["sess"]); got != 4 {
+		t.Fatalf("original session modified: %d messages", got)
+	}
+	forkID := rr.Header().Get(chatRewindSessionHeader)
+	if forkID == "" || forkID == "sess" {
+		t.Fatalf("expected fork session header, got %q", forkID)
+	}
+	fork := store.messages[forkID]
+	if len(fork) != 2 || fork[0].Content != "plan a trip" || fork[1].Content != "where to?" {
+		t.Fatalf("unexpected fork messages: %+v", fork)
+	}
+	if fork[0].ID == "u1" {
+		t.Fatalf("fork should not reuse source message IDs")
+	}
+	// The source summary covers three messages, more than the fork holds.
+	if sess := store.sessions[forkID]; sess.Summary != "" {
+		t.Fatalf("summary should not be carried into a shorter fork, got %q", sess.Summary)
+	}
+}
+
+func TestChatSessionForkCopiesPrefix(t *testing.T) {
+	t.Parallel()
+	a, store := newRewindTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/sessions/sess/fork", bytes.NewBufferString(`{"message_id":"u2","name":"alt"}`))
+	rr := httptest.NewRecorder()
+	a.chatSessionDetailHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var fork persistence.ChatSession
+	if err := json.Unmarshal(rr.Body.Bytes(), &fork); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := len(store.messages[fork.ID]); got != 3 {
+		t.Fatalf("fork has %d messages, want 3", got)
+	}
+	if fork.Summary != "user wants a trip" || fork.SummarizedCount != 3 {
+		t.Fatalf("expected summary carried over, got %q/%d", fork.Summary, fork.SummarizedCount)
+	}
+}
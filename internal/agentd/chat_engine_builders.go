@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/rs/zerolog/log"
+
 	"manifold/internal/agent"
 	"manifold/internal/agent/prompts"
 	"manifold/internal/config"
+	"manifold/internal/embedding"
 	"manifold/internal/llm"
 	llmproviders "manifold/internal/llm/providers"
 	persist "manifold/internal/persistence"
@@ -25,6 +28,9 @@ type chatEngineBuildResult struct {
 	ModelLabel string
 	StatusCode int
 	Err        error
+	// Acquire, when set, reserves capacity for running Engine. Its release
+	// func must be called once the run ends.
+	Acquire func(context.Context) (func(), error)
 }
 
 func (a *app) chatMaxSteps() int {
@@ -34,6 +40,23 @@ func (a *app) chatMaxSteps() int {
 	return 8
 }
 
+// toolResultRelevance builds the engine's tool result compaction policy, or
+// returns nil when summaryToolRelevance is disabled.
+func toolResultRelevance(cfg *config.Config) *agent.ToolResultRelevance {
+	if cfg == nil || !cfg.SummaryToolRelevance.Enabled {
+		return nil
+	}
+	embedCfg := cfg.Embedding
+	return &agent.ToolResultRelevance{
+		Embed: func(ctx context.Context, texts []string) ([][]float32, error) {
+			return embedding.EmbedText(ctx, embedCfg, texts)
+		},
+		DropBelow:     cfg.SummaryToolRelevance.DropBelow,
+		CompressBelow: cfg.SummaryToolRelevance.CompressBelow,
+		CompressChars: cfg.SummaryToolRelevance.CompressChars,
+	}
+}
+
 func (a *app) buildOrchestratorChatEngine(ctx context.Context, owner int64, sessionID, systemPromptOverride string, checkedOutWorkspace *workspaces.Workspace) chatEngineBuildResult {
 	eng := a.cloneEngineForUser(ctx, owner, sessionID)
 	if eng == nil {
@@ -45,9 +68,13 @@ func (a *app) buildOrchestratorChatEngine(ctx context.Context, owner int64, sess
 	if override := strings.TrimSpace(systemPromptOverride); override != "" {
 		eng.System = a.composeSystemPromptForUserWithOverride(ctx, owner, override)
 	}
+	eng.System = a.expandMCPPrompts(ctx, eng.System)
 	enableTools, autoDiscover := a.chatOrchestratorToolConfig(ctx, owner)
 	eng.System = a.ensureChatDiscoveryInstructions(eng.System, enableTools, autoDiscover)
-	eng.Tools, eng.System = a.applyChatSkillsMode(eng.Tools, eng.System, a.chatProjectDir(ctx, checkedOutWorkspace), enableTools, autoDiscover)
+	projectDir := a.chatProjectDir(ctx, checkedOutWorkspace)
+	eng.Tools, eng.System = a.applyChatSkillsMode(eng.Tools, eng.System, projectDir, enableTools, autoDiscover)
+	eng.System = a.appendRepoMap(ctx, eng.System, projectDir, enableTools)
+	eng.System = a.ensureLocaleInstructions(ctx, eng.System)
 	return chatEngineBuildResult{Engine: eng, ModelLabel: eng.Model}
 }
 
@@ -70,12 +97,14 @@ func (a *app) buildSpecialistChatEngine(ctx context.Context, name, systemPromptO
 		toolReg = tools.NewRegistry()
 	}
 
-	systemPrompt := prompts.EnsureMemoryInstructions(sp.System)
+	systemPrompt := prompts.EnsureMemoryInstructions(a.expandMCPPrompts(ctx, sp.System))
 	if override := strings.TrimSpace(systemPromptOverride); override != "" {
 		systemPrompt = prompts.EnsureMemoryInstructions(override)
 	}
 	systemPrompt = a.ensureChatDiscoveryInstructions(systemPrompt, sp.EnableTools, sp.AutoDiscover)
 	toolReg, systemPrompt = a.applyChatSkillsMode(toolReg, systemPrompt, a.chatProjectDir(ctx, nil), sp.EnableTools, sp.AutoDiscover)
+	systemPrompt = a.appendRepoMap(ctx, systemPrompt, a.chatProjectDir(ctx, nil), sp.EnableTools)
+	systemPrompt = a.ensureLocaleInstructions(ctx, systemPrompt)
 
 	eng := &agent.Engine{
 		LLM:                          prov,
@@ -88,12 +117,15 @@ func (a *app) buildSpecialistChatEngine(ctx context.Context, name, systemPromptO
 		SummaryReserveBufferTokens:   a.cfg.SummaryReserveBufferTokens,
 		SummaryMinKeepLastMessages:   a.cfg.SummaryMinKeepLastMessages,
 		SummaryMaxSummaryChunkTokens: a.cfg.SummaryMaxSummaryChunkTokens,
+		ToolResultRelevance:          toolResultRelevance(a.cfg),
+		ToolCache:                    a.toolCache,
 	}
 	em := a.attachSessionEvolvingMemory(eng, owner, sessionID)
 	eng.AttachTokenizer(prov, nil)
 	delegator := agenttools.NewDelegator(eng.Tools, reg, a.workspaceManager, a.chatMaxSteps())
 	delegator.SetDefaultTimeout(a.cfg.AgentRunTimeoutSeconds)
 	delegator.SetEvolvingMemory(em)
+	delegator.SetToolCache(a.toolCache)
 	if eng.ReMemEnabled {
 		delegator.ConfigureReMem(a.evolvingCfg.LLM, a.evolvingCfg.Model, a.rememMaxInnerSteps)
 	}
@@ -102,6 +134,7 @@ func (a *app) buildSpecialistChatEngine(ctx context.Context, name, systemPromptO
 	return chatEngineBuildResult{
 		Engine:     eng,
 		ModelLabel: chatModelLabel(name, sp.Model),
+		Acquire:    sp.Acquire,
 	}
 }
 
@@ -140,7 +173,7 @@ func (a *app) buildTeamChatEngine(ctx context.Context, name, sessionID string, o
 
 	currentModel := chatTeamModel(provider, llmCfg, sp)
 	toolReg := a.chatToolRegistry(sp.EnableTools, sp.AllowTools, sp.AutoDiscover)
-	basePrompt := strings.TrimSpace(sp.System)
+	basePrompt := strings.TrimSpace(a.expandMCPPrompts(ctx, sp.System))
 	if basePrompt == "" {
 		basePrompt = specialists.DefaultOrchestratorPrompt
 	}
@@ -148,7 +181,9 @@ func (a *app) buildTeamChatEngine(ctx context.Context, name, sessionID string, o
 	resolvedAutoDiscover := a.resolveAutoDiscover(sp.AutoDiscover)
 	systemPrompt = a.ensureChatDiscoveryInstructions(systemPrompt, sp.EnableTools, resolvedAutoDiscover)
 	toolReg, systemPrompt = a.applyChatSkillsMode(toolReg, systemPrompt, a.chatProjectDir(ctx, nil), sp.EnableTools, resolvedAutoDiscover)
+	systemPrompt = a.appendRepoMap(ctx, systemPrompt, a.chatProjectDir(ctx, nil), sp.EnableTools)
 	systemPrompt = teamReg.AppendToSystemPrompt(systemPrompt)
+	systemPrompt = a.ensureLocaleInstructions(ctx, systemPrompt)
 
 	eng := &agent.Engine{
 		LLM:                          userLLM,
@@ -161,12 +196,15 @@ func (a *app) buildTeamChatEngine(ctx context.Context, name, sessionID string, o
 		SummaryReserveBufferTokens:   a.cfg.SummaryReserveBufferTokens,
 		SummaryMinKeepLastMessages:   a.cfg.SummaryMinKeepLastMessages,
 		SummaryMaxSummaryChunkTokens: a.cfg.SummaryMaxSummaryChunkTokens,
+		ToolResultRelevance:          toolResultRelevance(a.cfg),
+		ToolCache:                    a.toolCache,
 	}
 	em := a.attachSessionEvolvingMemory(eng, owner, sessionID)
 	eng.AttachTokenizer(userLLM, nil)
 	delegator := agenttools.NewDelegator(eng.Tools, teamReg, a.workspaceManager, a.chatMaxSteps())
 	delegator.SetDefaultTimeout(a.cfg.AgentRunTimeoutSeconds)
 	delegator.SetEvolvingMemory(em)
+	delegator.SetToolCache(a.toolCache)
 	if eng.ReMemEnabled {
 		delegator.ConfigureReMem(a.evolvingCfg.LLM, a.evolvingCfg.Model, a.rememMaxInnerSteps)
 	}
@@ -203,6 +241,8 @@ func (a *app) buildTeamRegistry(ctx context.Context, owner int64, team persist.S
 	}
 	reg := specialists.NewRegistry(baseRegCfg, specialists.ConfigsFromStore(filtered), a.httpClient, a.baseToolRegistry)
 	reg.SetToolDiscovery(a.toolIndex, a.cfg.AutoDiscover, a.cfg.MaxDiscoveredTools)
+	reg.SetPromptResolver(a.expandMCPPrompts)
+	reg.SetLimits(a.specLimits)
 	return reg, nil
 }
 
@@ -252,6 +292,24 @@ func (a *app) applyChatSkillsMode(toolReg tools.Registry, systemPrompt, projectD
 	return tools.NewOverlayRegistry(toolReg, newSkillSearchTool(projectDir)), systemPrompt
 }
 
+// appendRepoMap adds a condensed map of the project's code to tool-enabled
+// chats when exec.codeSearch.repoMapTokens is set.
+func (a *app) appendRepoMap(ctx context.Context, systemPrompt, projectDir string, enableTools bool) string {
+	tokens := a.cfg.Exec.CodeSearch.RepoMapTokens
+	if a.codeIndex == nil || !enableTools || tokens <= 0 || strings.TrimSpace(projectDir) == "" {
+		return systemPrompt
+	}
+	repoMap, err := a.codeIndex.RepoMap(ctx, projectDir, tokens)
+	if err != nil {
+		log.Debug().Err(err).Str("projectDir", projectDir).Msg("repo_map_failed")
+		return systemPrompt
+	}
+	if repoMap == "" {
+		return systemPrompt
+	}
+	return systemPrompt + "\n\n## Repo map\nMost referenced files with their symbols (line: signature). Use code_search to look up symbols and references.\n" + repoMap
+}
+
 func (a *app) chatOrchestratorToolConfig(ctx context.Context, owner int64) (bool, bool) {
 	enableTools := a.cfg.EnableTools
 	autoDiscover := a.cfg.AutoDiscover
@@ -14,8 +14,11 @@ import (
 
 	"manifold/internal/agent"
 	agentmemory "manifold/internal/agent/memory"
+	"manifold/internal/agent/thoughts"
+	"manifold/internal/apierror"
 	"manifold/internal/llm"
 	"manifold/internal/sandbox"
+	"manifold/internal/specialists"
 	"manifold/internal/workspaces"
 )
 
@@ -31,6 +34,10 @@ type chatStreamOptions struct {
 	StoreModel            string
 	InitialSummary        *agentmemory.SummaryResult
 	Tracer                *agentStreamTracer
+	// Approvers lets users besides the run owner decide tool approvals.
+	Approvers func(userID int64) bool
+	// Agent names the specialist serving the turn; empty for the orchestrator.
+	Agent string
 }
 
 type chatJSONOptions struct {
@@ -39,12 +46,23 @@ type chatJSONOptions struct {
 	InheritImagePrompt    bool
 	TimeoutSeconds        int
 	StoreModel            string
+	// Agent names the specialist serving the turn; empty for the orchestrator.
+	Agent string
 }
 
 type chatSSEWriter struct {
 	w  io.Writer
 	fl http.Flusher
 	mu sync.Mutex
+	// broadcast, when set, receives each JSON event in place of w. Live
+	// sessions use it to fan a run out to every participant.
+	broadcast func([]byte)
+	// runID, when set by bindRun, numbers events with "<run>:<seq>" ids and
+	// keeps the closing ones in resume for clients that reconnect.
+	runID    string
+	seq      int64
+	envelope bool
+	resume   *sseResumeLog
 }
 
 func newChatSSEWriter(w http.ResponseWriter) (*chatSSEWriter, error) {
@@ -55,20 +73,51 @@ func newChatSSEWriter(w http.ResponseWriter) (*chatSSEWriter, error) {
 	return &chatSSEWriter{w: w, fl: fl}, nil
 }
 
+func newChatBroadcastWriter(broadcast func([]byte)) *chatSSEWriter {
+	return &chatSSEWriter{broadcast: broadcast}
+}
+
+// bindRun ties the stream to runID: events get SSE ids, the typed envelope
+// is used when requested, and closing events are logged for resume.
+func (s *chatSSEWriter) bindRun(resume *sseResumeLog, runID string, owner int64, envelope bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runID, s.envelope, s.resume = runID, envelope, resume
+	resume.open(runID, owner)
+}
+
 func (s *chatSSEWriter) write(payload any) {
 	b, err := json.Marshal(payload)
 	if err != nil {
 		return
 	}
+	s.writeEvent(sseEventType(b), b)
+}
+
+// writeEvent writes one JSON-encoded event of the given type.
+func (s *chatSSEWriter) writeEvent(typ string, b []byte) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	fmt.Fprintf(s.w, "data: %s\n\n", b)
+	if s.broadcast != nil {
+		s.broadcast(b)
+		return
+	}
+	s.seq++
+	if s.runID != "" && sseResumable[typ] {
+		s.resume.record(s.runID, sseFrame{seq: s.seq, typ: typ, data: b})
+	}
+	_, _ = s.w.Write(formatSSEEvent(s.runID, s.seq, typ, b, s.envelope))
 	s.fl.Flush()
 }
 
+// writeText writes raw SSE framing. Broadcast writers carry only JSON
+// events and ignore it.
 func (s *chatSSEWriter) writeText(text string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.broadcast != nil {
+		return
+	}
 	fmt.Fprint(s.w, text)
 	s.fl.Flush()
 }
@@ -122,6 +171,14 @@ func (c *chatTurnCollector) attach(eng *agent.Engine) {
 	}
 }
 
+// dropFinalAnswer removes the trailing final assistant message, which is
+// superseded when the verifier escalates to another model.
+func (c *chatTurnCollector) dropFinalAnswer() {
+	if n := len(c.turnMessages); n > 0 && c.turnMessages[n-1].Role == "assistant" && len(c.turnMessages[n-1].ToolCalls) == 0 {
+		c.turnMessages = c.turnMessages[:n-1]
+	}
+}
+
 func (c *chatTurnCollector) resultText(result string) string {
 	if len(c.savedImages) == 0 {
 		return result
@@ -184,6 +241,9 @@ func configureCommonStreamCallbacks(eng *agent.Engine, stream *chatSSEWriter, em
 			payload["agent"] = true
 		}
 		stream.write(payload)
+		if ev, ok := thoughts.FromToolResult(name, result, toolID); ok {
+			stream.write(ev)
+		}
 		if name == "text_to_speech" {
 			var resp map[string]any
 			if err := json.Unmarshal(result, &resp); err == nil {
@@ -239,6 +299,33 @@ func applyChatImagePrompt(ctx, runCtx context.Context, req chatRunRequest, inher
 	return ctx
 }
 
+// applyEngineMode sets the request's engine mode and, for streams, forwards
+// plan progress as "engine_plan", "engine_plan_step_start", and
+// "engine_plan_step_done" events.
+func applyEngineMode(eng *agent.Engine, req chatRunRequest, stream *chatSSEWriter) {
+	if eng == nil || req.EngineMode == "" {
+		return
+	}
+	eng.Mode = req.EngineMode
+	if stream == nil || eng.Mode != agent.EngineModePlanExecute {
+		return
+	}
+	eng.OnPlanEvent = func(ev agent.PlanEvent) {
+		payload := map[string]any{"type": "engine_plan_" + ev.Type}
+		if ev.Plan != nil {
+			payload["type"] = "engine_plan"
+			payload["steps"] = ev.Plan.Steps
+		}
+		if ev.Step != nil {
+			payload["step"] = ev.Step
+		}
+		if ev.Result != nil {
+			payload["result"] = ev.Result
+		}
+		stream.write(payload)
+	}
+}
+
 func chatStoreModel(eng *agent.Engine, override string) string {
 	if override != "" {
 		return override
@@ -253,18 +340,45 @@ func (a *app) executeStreamChat(w http.ResponseWriter, r *http.Request, runCtx c
 	if req.EphemeralSession {
 		defer cleanupEphemeralChatSession(a.chatStore, userID, req.SessionID)
 	}
+	w.Header().Set("X-Run-ID", runID)
 	stream, err := newChatSSEWriter(w)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	owner := systemUserID
+	if userID != nil {
+		owner = *userID
+	}
+	stream.bindRun(a.sseRuns, runID, owner, sseEnvelopeRequested(r))
+	defer a.sseRuns.finish(runID)
+	a.streamChatTurn(r, stream, runCtx, eng, req, history, runID, userID, checkedOutWorkspace, opts)
+}
+
+// streamChatTurn runs one streamed chat turn and stores it. r is the
+// originating request, or nil for turns started over a live session's
+// WebSocket; without it, storage uses runCtx.
+func (a *app) streamChatTurn(r *http.Request, stream *chatSSEWriter, runCtx context.Context, eng *agent.Engine, req chatRunRequest, history []llm.Message, runID string, userID *int64, checkedOutWorkspace *workspaces.Workspace, opts chatStreamOptions) {
+	reqCtx := runCtx
+	if r != nil {
+		reqCtx = r.Context()
+	}
+	runCtx, release, ok := a.drainer.track(runCtx)
+	defer release()
+	if !ok {
+		stream.write(map[string]string{"type": "error", "data": "(error) " + errShuttingDown.Error()})
+		a.runs.updateStatus(runID, "failed", 0)
 		return
 	}
 	if opts.Tracer != nil {
-		if opts.Tracer.mu == nil {
-			opts.Tracer.mu = &stream.mu
-		}
+		opts.Tracer.stream = stream
 		eng.AgentTracer = opts.Tracer
 	}
 	configureCommonStreamCallbacks(eng, stream, opts.EmitThoughtSummary, opts.EmitSummaryEvents)
+	if a.guardrails.ChecksOutput() {
+		// Withhold raw deltas until the complete response has been screened.
+		eng.OnDelta = func(string) {}
+	}
 	if opts.InitialSummary != nil && opts.InitialSummary.Triggered {
 		stream.write(map[string]any{
 			"type":             "summary",
@@ -284,34 +398,37 @@ func (a *app) executeStreamChat(w http.ResponseWriter, r *http.Request, runCtx c
 	}
 	ctx, cancel, dur := withMaybeTimeout(runCtx, seconds)
 	defer cancel()
+	ctx, span := startRunSpan(ctx, runID, req.SessionID, userID, opts.Endpoint)
+	defer span.End()
 	ctx = applyChatImagePrompt(ctx, runCtx, req, opts.InheritImagePrompt)
+	ctx, requestedHandoff := a.withChatHandoff(ctx, req, userID, opts.Agent)
 	logChatRunTimeout(opts.Endpoint, true, dur)
 
 	if opts.KeepAlive {
-		stopKeepalive := make(chan struct{})
-		go func() {
-			ticker := time.NewTicker(15 * time.Second)
-			defer ticker.Stop()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-stopKeepalive:
-					return
-				case <-ticker.C:
-					stream.writeText(": keepalive\n\n")
-				}
-			}
-		}()
-		defer close(stopKeepalive)
+		defer startSSEHeartbeat(ctx, stream.writeText)()
 	}
 
 	collector := newChatTurnCollector(sandbox.ResolveBaseDir(ctx, a.cfg.Workdir), req.ProjectID, stream)
 	collector.attach(eng)
+	a.recordRunContexts(eng, runID)
+	a.recordRunEvents(eng, runID, userID)
+	a.attachVerifier(eng, req, runID, collector, stream)
+	applyEngineMode(eng, req, stream)
+	a.attachToolApprover(eng, runID, userID, stream, opts.Approvers)
+	notifyDone := a.attachRunNotifications(eng, runID, userID, "", req.Prompt)
 
 	result, err := eng.RunStream(ctx, req.Prompt, history)
 	if err != nil {
-		logStreamContextDone(err, r, opts.Endpoint, req.SessionID, req.ProjectID, "")
+		failRunSpan(span, err)
+		status := "failed"
+		if interruptedByShutdown(ctx) {
+			err = fmt.Errorf("run interrupted: %w", errShuttingDown)
+			status = "interrupted"
+		}
+		notifyDone("", err)
+		if r != nil {
+			logStreamContextDone(err, r, opts.Endpoint, req.SessionID, req.ProjectID, "")
+		}
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			log.Warn().Err(err).Msg("agent run cancelled")
 		} else {
@@ -320,18 +437,34 @@ func (a *app) executeStreamChat(w http.ResponseWriter, r *http.Request, runCtx c
 		if opts.StructuredErrors {
 			stream.write(map[string]string{"type": "error", "data": "(error) " + err.Error()})
 		} else if b, err2 := json.Marshal("(error) " + err.Error()); err2 == nil {
-			stream.writeText(fmt.Sprintf("data: %s\n\n", b))
+			stream.writeEvent("error", b)
 		} else {
-			stream.writeText(fmt.Sprintf("data: %q\n\n", "(error)"))
+			stream.writeEvent("error", []byte(`"(error)"`))
 		}
-		a.runs.updateStatus(runID, "failed", 0)
+		a.runs.updateStatus(runID, status, 0)
 		a.commitWorkspace(ctx, checkedOutWorkspace)
 		return
 	}
 	result = collector.resultText(result)
-	stream.write(buildChatStreamFinalPayload(result, ctx, opts.IncludeMatrixMessages))
+	if res := a.guardrails.CheckOutput(ctx, result); !res.Allowed {
+		res.Message = a.localizeGuardrailMessage(reqCtx, res.Message)
+		stream.write(guardrailViolationPayload(res))
+		result = res.Message
+		collector.turnMessages = redactBlockedTurn(collector.turnMessages, result)
+	}
+	storedTurn, storedResult, masked := a.scrubTurnForStorage(ctx, collector.turnMessages, result)
+	final := buildChatStreamFinalPayload(result, ctx, opts.IncludeMatrixMessages)
+	if len(masked) > 0 {
+		final["pii_masked"] = masked
+	}
+	if h, ok := requestedHandoff(); ok {
+		stream.write(handoffEventPayload(h))
+		storedTurn = append(storedTurn, specialists.HandoffMessage(h))
+	}
+	stream.write(final)
 	a.runs.updateStatus(runID, "completed", 0)
-	if err := storeChatTurnWithHistory(r.Context(), a.chatStore, userID, req.SessionID, req.Prompt, collector.turnMessages, result, chatStoreModel(eng, opts.StoreModel)); err != nil {
+	notifyDone(result, nil)
+	if err := storeChatTurnWithHistory(reqCtx, a.chatStore, userID, req.SessionID, req.Prompt, storedTurn, storedResult, chatStoreModel(eng, opts.StoreModel)); err != nil {
 		log.Error().Err(err).Str("session", req.SessionID).Msg("store_chat_turn_stream")
 	}
 	a.commitWorkspace(ctx, checkedOutWorkspace)
@@ -341,35 +474,84 @@ func (a *app) executeJSONChat(w http.ResponseWriter, r *http.Request, runCtx con
 	if req.EphemeralSession {
 		defer cleanupEphemeralChatSession(a.chatStore, userID, req.SessionID)
 	}
+	runCtx, release, ok := a.drainer.track(runCtx)
+	defer release()
+	if !ok {
+		w.Header().Set("Retry-After", "5")
+		apierror.RespondCode(w, http.StatusServiceUnavailable, apierror.ShuttingDown, errShuttingDown.Error(), nil)
+		a.runs.updateStatus(runID, "failed", 0)
+		return
+	}
 	seconds := opts.TimeoutSeconds
 	if seconds <= 0 {
 		seconds = a.cfg.AgentRunTimeoutSeconds
 	}
 	ctx, cancel, dur := withMaybeTimeout(runCtx, seconds)
 	defer cancel()
+	ctx, span := startRunSpan(ctx, runID, req.SessionID, userID, opts.Endpoint)
+	defer span.End()
 	ctx = applyChatImagePrompt(ctx, runCtx, req, opts.InheritImagePrompt)
+	ctx, requestedHandoff := a.withChatHandoff(ctx, req, userID, opts.Agent)
 	logChatRunTimeout(opts.Endpoint, false, dur)
 
 	collector := newChatTurnCollector(sandbox.ResolveBaseDir(ctx, a.cfg.Workdir), req.ProjectID, nil)
 	collector.attach(eng)
+	a.recordRunContexts(eng, runID)
+	a.recordRunEvents(eng, runID, userID)
+	a.attachVerifier(eng, req, runID, collector, nil)
+	applyEngineMode(eng, req, nil)
+	a.attachToolApprover(eng, runID, userID, nil, nil)
+	notifyDone := a.attachRunNotifications(eng, runID, userID, "", req.Prompt)
 
 	result, err := eng.Run(ctx, req.Prompt, history)
 	if err != nil {
+		failRunSpan(span, err)
+		notifyDone("", err)
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			log.Warn().Err(err).Msg("agent run cancelled")
 		} else {
 			log.Error().Err(err).Msg("agent run error")
 		}
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		if interruptedByShutdown(ctx) {
+			w.Header().Set("Retry-After", "5")
+			apierror.RespondCode(w, http.StatusServiceUnavailable, apierror.ShuttingDown, "run interrupted: "+errShuttingDown.Error(), nil)
+			a.runs.updateStatus(runID, "interrupted", 0)
+			a.commitWorkspace(ctx, checkedOutWorkspace)
+			return
+		}
+		if errors.As(err, new(*quotaExceededError)) {
+			writeQuotaExceeded(w, err)
+		} else {
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+		}
 		a.runs.updateStatus(runID, "failed", 0)
 		a.commitWorkspace(ctx, checkedOutWorkspace)
 		return
 	}
 	result = collector.resultText(result)
+	res := a.guardrails.CheckOutput(ctx, result)
+	if !res.Allowed {
+		res.Message = a.localizeGuardrailMessage(r.Context(), res.Message)
+		result = res.Message
+		collector.turnMessages = redactBlockedTurn(collector.turnMessages, result)
+	}
+	payload := buildChatJSONPayload(result, ctx, opts.IncludeMatrixMessages)
+	if !res.Allowed {
+		payload["policy_violation"] = res
+	}
+	storedTurn, storedResult, masked := a.scrubTurnForStorage(ctx, collector.turnMessages, result)
+	if len(masked) > 0 {
+		payload["pii_masked"] = masked
+	}
+	if h, ok := requestedHandoff(); ok {
+		payload["handoff"] = h
+		storedTurn = append(storedTurn, specialists.HandoffMessage(h))
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(buildChatJSONPayload(result, ctx, opts.IncludeMatrixMessages))
+	json.NewEncoder(w).Encode(payload)
 	a.runs.updateStatus(runID, "completed", 0)
-	if err := storeChatTurnWithHistory(r.Context(), a.chatStore, userID, req.SessionID, req.Prompt, collector.turnMessages, result, chatStoreModel(eng, opts.StoreModel)); err != nil {
+	notifyDone(result, nil)
+	if err := storeChatTurnWithHistory(r.Context(), a.chatStore, userID, req.SessionID, req.Prompt, storedTurn, storedResult, chatStoreModel(eng, opts.StoreModel)); err != nil {
 		log.Error().Err(err).Str("session", req.SessionID).Msg("store_chat_turn")
 	}
 	a.commitWorkspace(ctx, checkedOutWorkspace)
@@ -2,8 +2,12 @@ package agentd
 
 import (
 	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"manifold/internal/agent"
 	"manifold/internal/llm"
 	"manifold/internal/sandbox"
 )
@@ -98,3 +102,76 @@ func contains(haystack, needle string) bool {
 		return false
 	})())
 }
+
+func TestStreamCallbacksEmitThoughtsEvents(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := &agent.Engine{}
+	configureCommonStreamCallbacks(eng, stream, false, false)
+
+	eng.OnTool("update_plan", nil, []byte(`{"ok":true,"steps":[{"step":"draft","status":"in_progress"}]}`), "call_1")
+	eng.OnTool("run_cli", nil, []byte(`{"ok":true}`), "call_2")
+
+	var types []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatalf("bad event %q: %v", data, err)
+		}
+		types = append(types, ev["type"].(string))
+		if ev["type"] == "plan" && (ev["v"] != float64(1) || ev["tool_id"] != "call_1") {
+			t.Fatalf("unexpected plan event: %#v", ev)
+		}
+	}
+	if got := strings.Join(types, ","); got != "tool_result,plan,tool_result" {
+		t.Fatalf("event sequence = %s", got)
+	}
+}
+
+func TestApplyEngineModeStreamsPlanEvents(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := &agent.Engine{}
+	applyEngineMode(eng, chatRunRequest{EngineMode: agent.EngineModePlanExecute}, stream)
+	if eng.Mode != agent.EngineModePlanExecute || eng.OnPlanEvent == nil {
+		t.Fatalf("expected plan_execute mode with plan events, got %q", eng.Mode)
+	}
+
+	step := agent.PlanStep{ID: "s1", Task: "look it up"}
+	eng.OnPlanEvent(agent.PlanEvent{Type: "plan", Plan: &agent.Plan{Steps: []agent.PlanStep{step}}})
+	eng.OnPlanEvent(agent.PlanEvent{Type: "step_done", Step: &step, Result: &agent.StepResult{StepID: "s1", Output: "found", Approved: true}})
+
+	var types []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var ev map[string]any
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				t.Fatalf("bad event %q: %v", data, err)
+			}
+			types = append(types, ev["type"].(string))
+		}
+	}
+	if got := strings.Join(types, ","); got != "engine_plan,engine_plan_step_done" {
+		t.Fatalf("event sequence = %s", got)
+	}
+
+	react := &agent.Engine{}
+	applyEngineMode(react, chatRunRequest{}, stream)
+	if react.Mode != "" || react.OnPlanEvent != nil {
+		t.Fatalf("default request must leave the engine untouched")
+	}
+}
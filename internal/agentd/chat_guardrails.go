@@ -0,0 +1,95 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/guardrails"
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+func guardrailViolationPayload(res guardrails.Result) map[string]any {
+	return map[string]any{
+		"type":       "policy_violation",
+		"stage":      res.Stage,
+		"data":       res.Message,
+		"violations": res.Violations,
+	}
+}
+
+// writeGuardrailViolation responds to a blocked prompt. Streaming clients
+// receive a policy_violation event followed by a final event carrying the
+// policy message; JSON clients receive a 422 with the same details.
+func writeGuardrailViolation(w http.ResponseWriter, r *http.Request, res guardrails.Result) {
+	if r.Header.Get("Accept") == "text/event-stream" {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		stream, err := newChatSSEWriter(w)
+		if err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		stream.write(guardrailViolationPayload(res))
+		stream.write(map[string]any{"type": "final", "data": res.Message})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":           res.Message,
+		"policy_violation": res,
+	}); err != nil {
+		log.Error().Err(err).Msg("encode_policy_violation")
+	}
+}
+
+// redactBlockedTurn replaces the final assistant reply in a turn with the
+// policy message so blocked output is never persisted to chat history.
+func redactBlockedTurn(turn []llm.Message, message string) []llm.Message {
+	out := make([]llm.Message, len(turn))
+	copy(out, turn)
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i].Role == "assistant" && len(out[i].ToolCalls) == 0 {
+			out[i].Content = message
+			out[i].Images = nil
+			return out
+		}
+	}
+	return append(out, llm.Message{Role: "assistant", Content: message})
+}
+
+// scrubTurnForStorage masks PII in the assistant output of a completed turn
+// so it is not written to chat history. The returned maskings annotate what
+// was replaced; the caller's turn slice is left untouched.
+func (a *app) scrubTurnForStorage(ctx context.Context, turn []llm.Message, result string) ([]llm.Message, string, []guardrails.Masking) {
+	if a.piiScrubber == nil {
+		return turn, result, nil
+	}
+	out := make([]llm.Message, len(turn))
+	copy(out, turn)
+	var sets [][]guardrails.Masking
+	for i := range out {
+		if out[i].Role != "assistant" {
+			continue
+		}
+		var m []guardrails.Masking
+		out[i].Content, m = a.piiScrubber.Scrub(out[i].Content)
+		sets = append(sets, m)
+	}
+	// The final result normally repeats the last assistant message, so it is
+	// scrubbed but not counted twice.
+	result, resultMasked := a.piiScrubber.Scrub(result)
+	masked := guardrails.MergeMaskings(sets...)
+	if len(masked) == 0 {
+		masked = resultMasked
+	}
+	if len(masked) > 0 {
+		observability.LoggerWithTrace(ctx).Info().Interface("masked", masked).Msg("pii_masked")
+	}
+	return out, result, masked
+}
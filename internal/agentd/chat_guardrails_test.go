@@ -0,0 +1,67 @@
+package agentd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"manifold/internal/guardrails"
+	"manifold/internal/llm"
+)
+
+func TestRedactBlockedTurnReplacesFinalAssistantMessage(t *testing.T) {
+	t.Parallel()
+
+	turn := []llm.Message{
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "run_cli", ID: "t1"}}},
+		{Role: "tool", ToolID: "t1", Content: "ok"},
+		{Role: "assistant", Content: "raw output"},
+	}
+	out := redactBlockedTurn(turn, "blocked")
+	if out[2].Content != "blocked" {
+		t.Fatalf("expected final assistant content to be replaced, got %q", out[2].Content)
+	}
+	if turn[2].Content != "raw output" {
+		t.Fatalf("input slice must not be mutated")
+	}
+	if len(out[0].ToolCalls) != 1 {
+		t.Fatalf("tool call message should be preserved")
+	}
+}
+
+func TestWriteGuardrailViolationJSONAndStream(t *testing.T) {
+	t.Parallel()
+
+	res := guardrails.Result{
+		Stage:      guardrails.StageInput,
+		Message:    "blocked",
+		Violations: []guardrails.Violation{{Stage: guardrails.StageInput, Category: guardrails.CategoryJailbreak, Rule: "dan_mode"}},
+	}
+
+	rec := httptest.NewRecorder()
+	writeGuardrailViolation(rec, httptest.NewRequest(http.MethodPost, "/agent/run", nil), res)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["result"] != "blocked" || body["policy_violation"] == nil {
+		t.Fatalf("unexpected body: %#v", body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/agent/run", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	rec = httptest.NewRecorder()
+	writeGuardrailViolation(rec, req, res)
+	out := rec.Body.String()
+	if !strings.Contains(out, `"type":"policy_violation"`) || !strings.Contains(out, `"type":"final"`) {
+		t.Fatalf("unexpected stream output: %s", out)
+	}
+	if strings.Index(out, "policy_violation") > strings.Index(out, `"type":"final"`) {
+		t.Fatalf("policy_violation event must precede final")
+	}
+}
@@ -3,7 +3,9 @@ package agentd
 import (
 	"net/http"
 
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
+	"manifold/internal/i18n"
 	"manifold/internal/llm"
 	persist "manifold/internal/persistence"
 	"manifold/internal/workspaces"
@@ -39,46 +41,49 @@ func (a *app) prepareChatHandlerState(w http.ResponseWriter, r *http.Request, re
 		u, ok := auth.CurrentUser(r.Context())
 		if !ok {
 			w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, i18n.T(a.headerLocale(r), "unauthorized"))
 			return nil, false
 		}
 		currentUser = u
 		id, _, err := resolveChatAccess(r.Context(), a.authStore, u)
 		if err != nil {
 			log.Error().Err(err).Msg("resolve_chat_access")
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, i18n.T(a.headerLocale(r), "internal server error"))
 			return nil, false
 		}
 		userID = id
 	}
 
+	r = r.WithContext(i18n.WithSettings(r.Context(), a.requestLocale(r, chatRequestOwner(currentUser, userID))))
+	ctx := r.Context()
+
 	r, checkedOutWorkspace, statusCode, err := a.prepareChatRunRequest(r, userID, req)
 	if err != nil {
 		switch statusCode {
 		case http.StatusBadRequest:
 			switch {
 			case err == workspaces.ErrInvalidProjectID:
-				http.Error(w, "invalid project_id", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, i18n.Tc(ctx, "invalid project_id"))
 			case err == workspaces.ErrProjectNotFound:
-				http.Error(w, "project not found (project_id must match the project directory/ID)", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, i18n.Tc(ctx, "project not found (project_id must match the project directory/ID)"))
 			default:
-				http.Error(w, "bad request", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, i18n.Tc(ctx, "bad request"))
 			}
 		case http.StatusInternalServerError:
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, i18n.Tc(ctx, "internal server error"))
 		default:
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, i18n.Tc(ctx, "internal server error"))
 		}
 		return nil, false
 	}
 
 	if _, err := ensureChatSession(r.Context(), a.chatStore, userID, req.SessionID); err != nil {
 		if err == persist.ErrForbidden {
-			http.Error(w, "forbidden", http.StatusForbidden)
+			apierror.Respond(w, http.StatusForbidden, i18n.Tc(ctx, "forbidden"))
 			return nil, false
 		}
 		log.Error().Err(err).Str("session", req.SessionID).Msg("ensure_chat_session")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, i18n.Tc(ctx, "internal server error"))
 		return nil, false
 	}
 
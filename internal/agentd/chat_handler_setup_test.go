@@ -1,14 +1,18 @@
 package agentd
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"manifold/internal/agent/memory"
 	"manifold/internal/auth"
 	"manifold/internal/config"
+	"manifold/internal/i18n"
 	"manifold/internal/llm"
+	"manifold/internal/persistence/databases"
 	"manifold/internal/testhelpers"
 	"manifold/internal/tools"
 )
@@ -83,3 +87,45 @@ func TestPrepareChatHandlerStateAppliesImagePrompt(t *testing.T) {
 		t.Fatalf("expected image size 1024x1024, got %q", opts.Size)
 	}
 }
+
+func TestPrepareChatHandlerStateResolvesLocale(t *testing.T) {
+	t.Parallel()
+
+	chatStore := newPromptHandlerChatStore()
+	baseProvider := &testhelpers.FakeProvider{Resp: llm.Message{Role: "assistant", Content: "ok"}}
+	prefs := databases.NewUserPreferencesStore(nil)
+	a := &app{
+		cfg:              &config.Config{I18n: config.I18nConfig{DefaultLocale: "en", TimeZone: "UTC"}},
+		llm:              baseProvider,
+		baseToolRegistry: tools.NewRegistry(),
+		chatStore:        chatStore,
+		chatMemory:       memory.NewManager(chatStore, baseProvider, memory.Config{}),
+		workspaceManager: stubWorkspaceManager{},
+		userPrefsStore:   prefs,
+	}
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/prompt", nil)
+	httpReq.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	state, ok := a.prepareChatHandlerState(httptest.NewRecorder(), httpReq, chatRunRequest{Prompt: "salut", SessionID: "sess-3"})
+	if !ok {
+		t.Fatal("expected prepareChatHandlerState to succeed")
+	}
+	if got := i18n.FromContext(state.Request.Context()); got.Locale != "fr-CA" || got.TimeZone != "UTC" {
+		t.Fatalf("expected Accept-Language locale, got %+v", got)
+	}
+
+	if err := prefs.SetLocale(context.Background(), systemUserID, "de-AT", "Europe/Vienna"); err != nil {
+		t.Fatalf("SetLocale: %v", err)
+	}
+	state, ok = a.prepareChatHandlerState(httptest.NewRecorder(), httpReq, chatRunRequest{Prompt: "hallo", SessionID: "sess-3"})
+	if !ok {
+		t.Fatal("expected prepareChatHandlerState to succeed")
+	}
+	got := i18n.FromContext(state.Request.Context())
+	if got.Locale != "de-AT" || got.TimeZone != "Europe/Vienna" {
+		t.Fatalf("expected saved preferences to win, got %+v", got)
+	}
+	if prompt := a.ensureLocaleInstructions(state.Request.Context(), "base"); !strings.Contains(prompt, "German (de-AT)") {
+		t.Fatalf("expected german locale instructions, got %q", prompt)
+	}
+}
@@ -0,0 +1,80 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	persist "manifold/internal/persistence"
+	"manifold/internal/specialists"
+)
+
+// withChatHandoff lets the handoff tool pass req's session to another agent
+// during the run. agentName is the specialist serving the turn, empty for the
+// orchestrator. The returned func reports the handoff requested, if any.
+// Ephemeral sessions have no later turns to hand over.
+func (a *app) withChatHandoff(ctx context.Context, req chatRunRequest, userID *int64, agentName string) (context.Context, func() (persist.Handoff, bool)) {
+	if req.SessionID == "" || req.EphemeralSession {
+		return ctx, func() (persist.Handoff, bool) { return persist.Handoff{}, false }
+	}
+	owner := chatRequestOwner(nil, userID)
+	return specialists.WithHandoff(ctx, agentName, func(name string) bool {
+		return a.specialistAvailable(ctx, owner, name)
+	})
+}
+
+// sessionHandoffTarget routes a request that names no specialist or team to
+// the specialist currently holding the session through a handoff.
+func (a *app) sessionHandoffTarget(ctx context.Context, target chatDispatchTarget, userID *int64, sessionID string, owner int64) chatDispatchTarget {
+	if target.SpecialistName != "" || target.TeamName != "" || sessionID == "" || a.chatStore == nil {
+		return target
+	}
+	msgs, err := a.chatStore.ListMessages(ctx, userID, sessionID, 0)
+	if err != nil {
+		if !errors.Is(err, persist.ErrNotFound) && !errors.Is(err, persist.ErrForbidden) {
+			log.Warn().Err(err).Str("session", sessionID).Msg("chat_handoff_lookup_failed")
+		}
+		return target
+	}
+	name := specialists.ActiveAgent(msgs)
+	if name == "" {
+		return target
+	}
+	if !a.specialistAvailable(ctx, owner, name) {
+		log.Warn().Str("session", sessionID).Str("specialist", name).Msg("chat_handoff_target_unavailable")
+		return target
+	}
+	target.SpecialistName = name
+	return target
+}
+
+func (a *app) specialistAvailable(ctx context.Context, owner int64, name string) bool {
+	reg, err := a.specialistsRegistryForUser(ctx, owner)
+	if err != nil || reg == nil {
+		return false
+	}
+	sp, ok := reg.Get(name)
+	return ok && sp != nil
+}
+
+// handoffEventPayload is the SSE event announcing a handoff.
+func handoffEventPayload(h persist.Handoff) map[string]any {
+	return map[string]any{
+		"type":    "handoff",
+		"from":    h.From,
+		"to":      h.To,
+		"reason":  h.Reason,
+		"summary": h.Summary,
+		"at":      h.At,
+	}
+}
+
+// handoffNotice is the text shown in place of a stored handoff message.
+func handoffNotice(h persist.Handoff) string {
+	if h.Reason != "" {
+		return fmt.Sprintf("Handed off from %s to %s: %s", h.From, h.To, h.Reason)
+	}
+	return fmt.Sprintf("Handed off from %s to %s", h.From, h.To)
+}
@@ -0,0 +1,61 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+	"manifold/internal/specialists"
+	"manifold/internal/tools"
+)
+
+func TestSessionHandoffTargetFollowsActiveSpecialist(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newPromptHandlerChatStore()
+	cfg := config.Config{}
+	a := &app{
+		cfg:          &cfg,
+		chatStore:    store,
+		specRegistry: specialists.NewRegistry(config.LLMClientConfig{Provider: "openai"}, []config.SpecialistConfig{{Name: "coder", Model: "m"}}, http.DefaultClient, tools.NewRegistry()),
+	}
+	handoff := func(to string) persistence.ChatMessage {
+		m := specialists.HandoffMessage(persistence.Handoff{From: specialists.OrchestratorName, To: to, Summary: "s"})
+		return persistence.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	store.messages["sess"] = []persistence.ChatMessage{{Role: "user", Content: "hi"}}
+
+	if got := a.sessionHandoffTarget(ctx, chatDispatchTarget{}, nil, "sess", systemUserID); got.SpecialistName != "" {
+		t.Fatalf("expected orchestrator without a handoff, got %q", got.SpecialistName)
+	}
+
+	store.messages["sess"] = append(store.messages["sess"], handoff("coder"))
+	if got := a.sessionHandoffTarget(ctx, chatDispatchTarget{}, nil, "sess", systemUserID); got.SpecialistName != "coder" {
+		t.Fatalf("expected coder after handoff, got %q", got.SpecialistName)
+	}
+	if got := a.sessionHandoffTarget(ctx, chatDispatchTarget{TeamName: "ops"}, nil, "sess", systemUserID); got.SpecialistName != "" {
+		t.Fatalf("explicit team target should win, got %q", got.SpecialistName)
+	}
+
+	store.messages["sess"] = append(store.messages["sess"], handoff("retired"))
+	if got := a.sessionHandoffTarget(ctx, chatDispatchTarget{}, nil, "sess", systemUserID); got.SpecialistName != "" {
+		t.Fatalf("expected fallback for an unknown specialist, got %q", got.SpecialistName)
+	}
+}
+
+func TestHydrateChatMessages_Handoff(t *testing.T) {
+	m := specialists.HandoffMessage(persistence.Handoff{From: "orchestrator", To: "coder", Reason: "needs code", Summary: "s"})
+	hydrated := hydrateChatMessages([]persistence.ChatMessage{{ID: "h1", Role: m.Role, Content: m.Content}})
+	if len(hydrated) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(hydrated))
+	}
+	if hydrated[0].Role != "status" || hydrated[0].Title != persistence.ChatRoleHandoff {
+		t.Fatalf("unexpected hydrated handoff: %#v", hydrated[0])
+	}
+	if hydrated[0].Content != "Handed off from orchestrator to coder: needs code" {
+		t.Fatalf("unexpected content: %q", hydrated[0].Content)
+	}
+}
@@ -1,6 +1,8 @@
 package agentd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
@@ -17,11 +19,19 @@ type chatRunRequest struct {
 	SessionID        string `json:"session_id,omitempty"`
 	EphemeralSession bool   `json:"ephemeral_session,omitempty"`
 	ProjectID        string `json:"project_id,omitempty"`
-	RoomID           string `json:"room_id,omitempty"`
-	BotID            string `json:"bot_id,omitempty"`
-	SystemPrompt     string `json:"system_prompt,omitempty"`
-	Image            bool   `json:"image,omitempty"`
-	ImageSize        string `json:"image_size,omitempty"`
+	// WorkspaceID is accepted as an alias for ProjectID.
+	WorkspaceID  string `json:"workspace_id,omitempty"`
+	RoomID       string `json:"room_id,omitempty"`
+	BotID        string `json:"bot_id,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	Image        bool   `json:"image,omitempty"`
+	ImageSize    string `json:"image_size,omitempty"`
+	// ResponseSchema is an optional JSON Schema the final answer must satisfy
+	// when the verifier is enabled.
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+	// EngineMode selects the agent engine strategy for this run: "react"
+	// (default) or "plan_execute".
+	EngineMode string `json:"engine_mode,omitempty"`
 }
 
 type chatDispatchTarget struct {
@@ -35,10 +45,15 @@ func (req *chatRunRequest) normalize() {
 		req.SessionID = "default"
 	}
 	req.ProjectID = strings.TrimSpace(req.ProjectID)
+	if req.ProjectID == "" {
+		req.ProjectID = strings.TrimSpace(req.WorkspaceID)
+	}
+	req.WorkspaceID = ""
 	req.RoomID = strings.TrimSpace(req.RoomID)
 	req.BotID = strings.TrimSpace(req.BotID)
 	req.SystemPrompt = strings.TrimSpace(req.SystemPrompt)
 	req.ImageSize = strings.TrimSpace(req.ImageSize)
+	req.EngineMode = strings.ToLower(strings.TrimSpace(req.EngineMode))
 }
 
 func resolveChatDispatchTarget(query url.Values) chatDispatchTarget {
@@ -52,6 +67,25 @@ func resolveChatDispatchTarget(query url.Values) chatDispatchTarget {
 	}
 }
 
+// withScratchDir gives an authenticated run without a project the user's
+// private scratch directory, so tools never fall back to the workdir shared
+// by all users. A base directory already on ctx is kept. Every run entry
+// point calls it before starting a project-less run.
+func (a *app) withScratchDir(ctx context.Context, userID int64) (context.Context, error) {
+	if a.cfg == nil || !a.cfg.Auth.Enabled {
+		return ctx, nil
+	}
+	if _, ok := sandbox.BaseDirFromContext(ctx); ok {
+		return ctx, nil
+	}
+	dir, err := workspaces.ScratchDir(a.cfg.Workdir, userID)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", userID).Msg("scratch_workspace_failed")
+		return ctx, err
+	}
+	return sandbox.WithBaseDir(ctx, dir), nil
+}
+
 func (a *app) prepareChatRunRequest(r *http.Request, userID *int64, req chatRunRequest) (*http.Request, *workspaces.Workspace, int, error) {
 	ctx := sandbox.WithSessionID(r.Context(), req.SessionID)
 	if req.RoomID != "" {
@@ -72,16 +106,37 @@ func (a *app) prepareChatRunRequest(r *http.Request, userID *int64, req chatRunR
 		}
 	}
 
-	r = r.WithContext(ctx)
-	if req.ProjectID == "" {
-		return r, nil, 0, nil
-	}
-
 	var resolvedUserID int64
 	if userID != nil {
 		resolvedUserID = *userID
 	}
 
+	if req.ProjectID == "" {
+		if userID != nil {
+			var err error
+			if ctx, err = a.withScratchDir(ctx, resolvedUserID); err != nil {
+				return r, nil, http.StatusInternalServerError, err
+			}
+		}
+		return r.WithContext(ctx), nil, 0, nil
+	}
+	r = r.WithContext(ctx)
+
+	// Load saved project env vars before checkout so a store failure does not
+	// leave a workspace checked out.
+	var env map[string]string
+	if a.projectEnv != nil {
+		vars, err := a.projectEnv.List(r.Context(), resolvedUserID, req.ProjectID)
+		if err != nil {
+			log.Error().Err(err).Str("project_id", req.ProjectID).Msg("project_env_load_failed")
+			return r, nil, http.StatusInternalServerError, err
+		}
+		env = make(map[string]string, len(vars))
+		for _, v := range vars {
+			env[v.Name] = v.Value
+		}
+	}
+
 	ws, err := a.workspaceManager.Checkout(r.Context(), resolvedUserID, req.ProjectID, req.SessionID)
 	if err != nil {
 		switch {
@@ -101,6 +156,9 @@ func (a *app) prepareChatRunRequest(r *http.Request, userID *int64, req chatRunR
 
 	ctx = sandbox.WithBaseDir(r.Context(), ws.BaseDir)
 	ctx = sandbox.WithProjectID(ctx, req.ProjectID)
+	if len(env) > 0 {
+		ctx = sandbox.WithEnv(ctx, env)
+	}
 	r = r.WithContext(ctx)
 	return r, &ws, 0, nil
 }
@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"testing"
 
 	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
 	"manifold/internal/sandbox"
 	"manifold/internal/workspaces"
 )
@@ -156,3 +158,69 @@ func TestPrepareChatRunRequestMapsWorkspaceErrors(t *testing.T) {
 		t.Fatalf("expected bad request status, got %d", statusCode)
 	}
 }
+
+func TestPrepareChatRunRequestInjectsProjectEnv(t *testing.T) {
+	t.Parallel()
+
+	store := databases.NewProjectEnvStore(nil)
+	if err := store.Set(context.Background(), 7, "project-1", "API_TOKEN", "s3cret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	a := &app{
+		cfg:        &config.Config{},
+		projectEnv: store,
+		workspaceManager: stubWorkspaceManager{checkout: func(ctx context.Context, userID int64, projectID, sessionID string) (workspaces.Workspace, error) {
+			return workspaces.Workspace{UserID: userID, ProjectID: projectID, BaseDir: "/tmp/project-1"}, nil
+		}},
+	}
+
+	req := chatRunRequest{WorkspaceID: " project-1 "}
+	req.normalize()
+	userID := int64(7)
+	httpReq, _, _, err := a.prepareChatRunRequest(httptest.NewRequest(http.MethodPost, "/agent/run", nil), &userID, req)
+	if err != nil {
+		t.Fatalf("prepareChatRunRequest returned error: %v", err)
+	}
+	if got, ok := sandbox.BaseDirFromContext(httpReq.Context()); !ok || got != "/tmp/project-1" {
+		t.Fatalf("expected workspace base dir, got %q ok=%v", got, ok)
+	}
+	if got := sandbox.EnvFromContext(httpReq.Context()); len(got) != 1 || got[0] != "API_TOKEN=s3cret" {
+		t.Fatalf("expected project env on context, got %v", got)
+	}
+
+	// Another user's run for the same project ID gets none of the variables.
+	other := int64(8)
+	httpReq, _, _, err = a.prepareChatRunRequest(httptest.NewRequest(http.MethodPost, "/agent/run", nil), &other, req)
+	if err != nil {
+		t.Fatalf("prepareChatRunRequest returned error: %v", err)
+	}
+	if got := sandbox.EnvFromContext(httpReq.Context()); got != nil {
+		t.Fatalf("expected no env for other user, got %v", got)
+	}
+}
+
+func TestPrepareChatRunRequestUsesScratchDirWithoutProject(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	a := &app{
+		cfg: &config.Config{Workdir: workdir, Auth: config.AuthConfig{Enabled: true}},
+		workspaceManager: stubWorkspaceManager{checkout: func(context.Context, int64, string, string) (workspaces.Workspace, error) {
+			t.Fatal("checkout should not be called without a project")
+			return workspaces.Workspace{}, nil
+		}},
+	}
+
+	userID := int64(5)
+	httpReq, ws, _, err := a.prepareChatRunRequest(httptest.NewRequest(http.MethodPost, "/agent/run", nil), &userID, chatRunRequest{SessionID: "s"})
+	if err != nil {
+		t.Fatalf("prepareChatRunRequest returned error: %v", err)
+	}
+	if ws != nil {
+		t.Fatalf("expected no checked out workspace, got %#v", ws)
+	}
+	want := filepath.Join(workdir, "users", "5", "scratch")
+	if got, ok := sandbox.BaseDirFromContext(httpReq.Context()); !ok || got != want {
+		t.Fatalf("expected scratch base dir %q, got %q ok=%v", want, got, ok)
+	}
+}
@@ -2,9 +2,13 @@ package agentd
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"manifold/internal/apierror"
+	"manifold/internal/i18n"
 	"manifold/internal/llm"
 	persist "manifold/internal/persistence"
 	"manifold/internal/specialists"
@@ -62,11 +66,13 @@ func (a *app) describeChatTarget(target chatDispatchTarget, sessionID, systemPro
 				EmitSummaryEvents:     true,
 				StructuredErrors:      true,
 				InheritImagePrompt:    true,
+				Agent:                 target.SpecialistName,
 			},
 			JSON: chatJSONOptions{
 				Endpoint:              "/agent/run",
 				IncludeMatrixMessages: true,
 				InheritImagePrompt:    true,
+				Agent:                 target.SpecialistName,
 			},
 		}, true
 	}
@@ -110,13 +116,13 @@ func newAgentStreamTracer(w http.ResponseWriter) *agentStreamTracer {
 func writeChatTargetBuildError(w http.ResponseWriter, build chatEngineBuildResult, notFoundMessage, internalMessage string) {
 	switch build.StatusCode {
 	case http.StatusNotFound:
-		http.Error(w, notFoundMessage, http.StatusNotFound)
+		apierror.Respond(w, http.StatusNotFound, notFoundMessage)
 	default:
 		statusCode := build.StatusCode
 		if statusCode == 0 {
 			statusCode = http.StatusInternalServerError
 		}
-		http.Error(w, internalMessage, statusCode)
+		apierror.Respond(w, statusCode, internalMessage)
 	}
 }
 
@@ -175,21 +181,100 @@ func (a *app) promptOrchestratorDescriptor(baseCtx context.Context, owner int64,
 }
 
 func (a *app) dispatchBuiltChatTarget(w http.ResponseWriter, r *http.Request, opts chatTargetDispatchOptions) bool {
+	if res := a.guardrails.CheckPrompt(r.Context(), opts.Prompt); !res.Allowed {
+		res.Message = a.localizeGuardrailMessage(r.Context(), res.Message)
+		writeGuardrailViolation(w, r, res)
+		return true
+	}
+
 	build := opts.Build(r.Context())
 	if build.Err != nil {
-		writeChatTargetBuildError(w, build, opts.NotFoundMessage, opts.InternalErrorMessage)
+		writeChatTargetBuildError(w, build, i18n.Tc(r.Context(), opts.NotFoundMessage), i18n.Tc(r.Context(), opts.InternalErrorMessage))
+		return true
+	}
+
+	owner := systemUserID
+	if opts.UserID != nil {
+		owner = *opts.UserID
+	}
+	quotaLimits, ok := a.admitRun(w, r, owner)
+	if !ok {
 		return true
 	}
 
+	// A streaming run that has to queue starts its SSE response early so
+	// the client sees its position; errors after that go out as events.
+	streaming := r.Header.Get("Accept") == "text/event-stream"
+	var (
+		queued *chatSSEWriter
+		prun   AgentRun
+	)
+	fail := func(status int, code apierror.Code, message string) {
+		if queued == nil {
+			apierror.RespondCode(w, status, code, message, nil)
+			return
+		}
+		a.runs.updateStatus(prun.ID, "failed", 0)
+		queued.write(map[string]string{"type": "error", "data": "(error) " + message})
+	}
+	var onWait func(int)
+	if streaming {
+		onWait = func(position int) {
+			if queued == nil {
+				stream, err := newChatSSEWriter(w)
+				if err != nil {
+					return
+				}
+				prun = a.runs.create(opts.Prompt)
+				a.runs.updateStatus(prun.ID, "queued", 0)
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("X-Run-ID", prun.ID)
+				queued = stream
+			}
+			queued.write(map[string]any{"type": "queued", "position": position, "run_id": prun.ID})
+		}
+	}
+	releaseRun, err := a.runQueue.acquire(r.Context(), onWait)
+	if err != nil {
+		if isRunQueueRejection(err) {
+			log.Warn().Err(err).Str("endpoint", opts.Stream.Endpoint).Msg("agent_run_rejected")
+			if queued == nil {
+				w.Header().Set("Retry-After", strconv.Itoa(a.runQueue.retryAfter()))
+			}
+			fail(http.StatusTooManyRequests, apierror.RunQueueFull, i18n.Tc(r.Context(), "server is busy, try again later"))
+		} else if queued != nil {
+			a.runs.updateStatus(prun.ID, "failed", 0)
+		}
+		return true
+	}
+	defer releaseRun()
+
+	if build.Acquire != nil {
+		release, err := build.Acquire(r.Context())
+		if err != nil {
+			var capErr *specialists.CapacityError
+			if errors.As(err, &capErr) {
+				fail(capErr.StatusCode(), apierror.SpecialistBusy, i18n.Tc(r.Context(), "specialist is busy, try again later"))
+			} else if queued != nil {
+				a.runs.updateStatus(prun.ID, "failed", 0)
+			}
+			return true
+		}
+		defer release()
+	}
+	a.recordRun(r.Context(), owner, opts.Stream.Endpoint)
+	a.attachQuotaCheck(build.Engine, owner, quotaLimits)
+
 	targetSupportsCompaction := providerSupportsCompaction(build.Engine.LLM)
 	history, summary, err := a.chatMemory.BuildContextForProvider(r.Context(), opts.UserID, opts.SessionID, targetSupportsCompaction)
 	if err != nil {
 		if err == persist.ErrForbidden {
-			http.Error(w, "forbidden", http.StatusForbidden)
+			fail(http.StatusForbidden, apierror.Forbidden, i18n.Tc(r.Context(), "forbidden"))
 			return true
 		}
 		log.Error().Err(err).Str("session", opts.SessionID).Msg("load_chat_history")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		fail(http.StatusInternalServerError, apierror.Internal, i18n.Tc(r.Context(), "internal server error"))
 		return true
 	}
 
@@ -199,10 +284,14 @@ func (a *app) dispatchBuiltChatTarget(w http.ResponseWriter, r *http.Request, op
 	}
 	req := chatRunRequest{Prompt: opts.Prompt, SessionID: opts.SessionID, EphemeralSession: opts.EphemeralSession}
 
-	if r.Header.Get("Accept") == "text/event-stream" {
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		prun := a.runs.create(opts.Prompt)
+	if streaming {
+		if queued == nil {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			prun = a.runs.create(opts.Prompt)
+		} else {
+			a.runs.updateStatus(prun.ID, "running", 0)
+		}
 		streamOpts := opts.Stream
 		if streamOpts.StoreModel == "" {
 			streamOpts.StoreModel = build.ModelLabel
@@ -217,7 +306,7 @@ func (a *app) dispatchBuiltChatTarget(w http.ResponseWriter, r *http.Request, op
 		return true
 	}
 
-	prun := a.runs.create(opts.Prompt)
+	prun = a.runs.create(opts.Prompt)
 	jsonOpts := opts.JSON
 	if jsonOpts.StoreModel == "" {
 		jsonOpts.StoreModel = build.ModelLabel
@@ -227,6 +316,9 @@ func (a *app) dispatchBuiltChatTarget(w http.ResponseWriter, r *http.Request, op
 }
 
 func (a *app) handleChatTarget(w http.ResponseWriter, r *http.Request, target chatDispatchTarget, prompt, sessionID string, ephemeralSession bool, systemPromptOverride string, userID *int64, owner int64, fallback chatTargetDescriptor) bool {
+	if !ephemeralSession {
+		target = a.sessionHandoffTarget(r.Context(), target, userID, sessionID, owner)
+	}
 	descriptor, ok := a.describeChatTarget(target, sessionID, systemPromptOverride, owner)
 	if !ok {
 		if fallback.Build == nil {
@@ -39,7 +39,7 @@ func TestDispatchBuiltChatTargetMapsNotFoundBuildError(t *testing.T) {
 	if rec.Code != http.StatusNotFound {
 		t.Fatalf("expected 404, got %d", rec.Code)
 	}
-	if body := rec.Body.String(); body != "specialist not found\n" {
+	if body := rec.Body.String(); body != `{"code":"not_found","message":"specialist not found"}`+"\n" {
 		t.Fatalf("unexpected body: %q", body)
 	}
 }
@@ -67,7 +67,7 @@ func TestDispatchBuiltChatTargetMapsInternalBuildError(t *testing.T) {
 	if rec.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500, got %d", rec.Code)
 	}
-	if body := rec.Body.String(); body != "failed to load team\n" {
+	if body := rec.Body.String(); body != `{"code":"internal_error","message":"failed to load team"}`+"\n" {
 		t.Fatalf("unexpected body: %q", body)
 	}
 }
@@ -5,28 +5,19 @@ import (
 	"net/http"
 
 	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	"manifold/internal/apierror"
 )
 
 type chatTransportOptions struct {
-	EnablePromptCORS bool
 	MaxBodyBytes     int64
 	DecodeErrorLabel string
 }
 
 func prepareChatTransport(w http.ResponseWriter, r *http.Request, opts chatTransportOptions) (chatRunRequest, bool) {
-	if opts.EnablePromptCORS {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Vary", "Origin")
-		if r.Method == http.MethodOptions {
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
-			w.WriteHeader(http.StatusNoContent)
-			return chatRunRequest{}, false
-		}
-	}
-
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		return chatRunRequest{}, false
 	}
 
@@ -42,9 +33,13 @@ func prepareChatTransport(w http.ResponseWriter, r *http.Request, opts chatTrans
 		if opts.DecodeErrorLabel != "" {
 			log.Printf("%s: %v", opts.DecodeErrorLabel, err)
 		}
-		http.Error(w, "bad request", http.StatusBadRequest)
+		apierror.RespondDecodeError(w, err)
 		return chatRunRequest{}, false
 	}
 	req.normalize()
+	if !agent.IsEngineMode(req.EngineMode) {
+		apierror.RespondInvalid(w, "engine_mode", "unknown engine mode")
+		return chatRunRequest{}, false
+	}
 	return req, true
 }
@@ -7,30 +7,6 @@ import (
 	"testing"
 )
 
-func TestPrepareChatTransportHandlesPromptPreflight(t *testing.T) {
-	t.Parallel()
-
-	req := httptest.NewRequest(http.MethodOptions, "/api/prompt", nil)
-	rr := httptest.NewRecorder()
-
-	_, ok := prepareChatTransport(rr, req, chatTransportOptions{EnablePromptCORS: true})
-	if ok {
-		t.Fatal("expected preflight to be handled without decoding a request")
-	}
-	if rr.Code != http.StatusNoContent {
-		t.Fatalf("expected status 204, got %d", rr.Code)
-	}
-	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
-		t.Fatalf("expected wildcard origin, got %q", got)
-	}
-	if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "POST, OPTIONS" {
-		t.Fatalf("expected prompt methods header, got %q", got)
-	}
-	if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Accept" {
-		t.Fatalf("expected prompt allow headers, got %q", got)
-	}
-}
-
 func TestPrepareChatTransportDecodesAndNormalizesPostBody(t *testing.T) {
 	t.Parallel()
 
@@ -49,3 +25,22 @@ func TestPrepareChatTransportDecodesAndNormalizesPostBody(t *testing.T) {
 		t.Fatalf("expected normalized default session, got %q", decoded.SessionID)
 	}
 }
+
+func TestPrepareChatTransportRejectsUnknownEngineMode(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.NewBufferString(`{"prompt":"hello","engine_mode":"swarm"}`)
+	req := httptest.NewRequest(http.MethodPost, "/agent/run", body)
+	rr := httptest.NewRecorder()
+
+	if _, ok := prepareChatTransport(rr, req, chatTransportOptions{}); ok || rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown engine_mode, got %d", rr.Code)
+	}
+
+	body = bytes.NewBufferString(`{"prompt":"hello","engine_mode":" Plan_Execute "}`)
+	rr = httptest.NewRecorder()
+	decoded, ok := prepareChatTransport(rr, httptest.NewRequest(http.MethodPost, "/agent/run", body), chatTransportOptions{})
+	if !ok || decoded.EngineMode != "plan_execute" {
+		t.Fatalf("expected normalized engine mode, got %q (ok=%v)", decoded.EngineMode, ok)
+	}
+}
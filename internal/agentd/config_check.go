@@ -0,0 +1,46 @@
+package agentd
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/tools"
+)
+
+// logConfigProblems warns about configuration keys the loader ignored.
+// Mistyped values already fail config.Load, so only unknown keys remain.
+func logConfigProblems() {
+	problems, err := config.CheckFiles()
+	if err != nil {
+		log.Debug().Err(err).Msg("config_schema_check")
+		return
+	}
+	for _, p := range problems {
+		log.Warn().Str("file", p.File).Int("line", p.Line).Str("field", p.Path).Msg("config: " + p.Message)
+	}
+}
+
+// warnUnknownAllowedTools warns about allowTools entries, top-level and per
+// specialist, that name no registered tool; a typo there otherwise only
+// shows up as a tool silently missing at run time.
+func warnUnknownAllowedTools(cfg *config.Config, reg tools.Registry) {
+	names := tools.SchemaNames(reg)
+	check := func(scope string, allow []string) {
+		for _, name := range allow {
+			if name == "" || slices.Contains(names, name) {
+				continue
+			}
+			ev := log.Warn().Str("scope", scope).Str("tool", name)
+			if s := config.Suggest(name, names); s != "" {
+				ev = ev.Str("suggestion", s)
+			}
+			ev.Msg("allowTools names an unknown tool")
+		}
+	}
+	check("allowTools", cfg.ToolAllowList)
+	for _, sc := range cfg.Specialists {
+		check("specialists."+sc.Name+".allowTools", sc.AllowTools)
+	}
+}
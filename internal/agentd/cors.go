@@ -0,0 +1,101 @@
+package agentd
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+)
+
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+var (
+	corsDefaultHeaders = []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID"}
+	corsDefaultExposed = []string{"X-Run-ID", chatRewindSessionHeader}
+)
+
+// corsPolicy applies server.cors to every API response. Requests without
+// an Origin header, and from origins not on the list, get no CORS headers,
+// so browsers keep enforcing the same-origin policy for them.
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	credentials bool
+	headers     string
+	exposed     string
+	maxAge      string
+}
+
+func newCORSPolicy(cfg config.CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:     map[string]bool{},
+		credentials: cfg.AllowCredentials,
+		headers:     strings.Join(append(append([]string{}, corsDefaultHeaders...), cfg.AllowedHeaders...), ", "),
+		exposed:     strings.Join(append(append([]string{}, corsDefaultExposed...), cfg.ExposedHeaders...), ", "),
+		maxAge:      strconv.Itoa(cfg.MaxAgeSeconds),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		p.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	// A wildcard is only ever sent without credentials: browsers reject the
+	// combination, and reflecting the origin instead would open the API to
+	// every site. The config loader refuses it too; this guards any other
+	// caller.
+	if p.anyOrigin {
+		p.credentials = false
+	}
+	return p
+}
+
+func (p *corsPolicy) allows(origin string) bool {
+	return p.anyOrigin || p.origins[strings.ToLower(origin)]
+}
+
+// wrap answers preflight requests itself and adds CORS headers to other
+// responses for allowed origins.
+func (p *corsPolicy) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !p.allows(origin) {
+			if preflight {
+				apierror.Respond(w, http.StatusForbidden, "origin not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if p.anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			if p.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+		h.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Headers", p.headers)
+			h.Set("Access-Control-Max-Age", p.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", p.exposed)
+		next.ServeHTTP(w, r)
+	})
+}
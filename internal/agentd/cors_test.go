@@ -9,11 +9,19 @@ import (
 	"manifold/internal/config"
 )
 
+func corsTestApp(cors config.CORSConfig) *app {
+	return &app{cfg: &config.Config{Server: config.ServerConfig{CORS: cors}}}
+}
+
 func TestWrapWithMiddlewarePreflight(t *testing.T) {
 	t.Parallel()
 
 	called := false
-	a := &app{cfg: &config.Config{}}
+	a := corsTestApp(config.CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:5173"},
+		AllowCredentials: true,
+		MaxAgeSeconds:    600,
+	})
 	handler := a.wrapWithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		called = true
 		w.WriteHeader(http.StatusTeapot)
@@ -39,12 +47,15 @@ func TestWrapWithMiddlewarePreflight(t *testing.T) {
 	if got := res.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
 		t.Fatalf("expected allow-credentials header, got %q", got)
 	}
-	if got := res.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Accept" {
-		t.Fatalf("expected allow-headers to reflect requested headers, got %q", got)
+	if got := res.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Content-Type") || !strings.Contains(got, "Accept") {
+		t.Fatalf("expected allow-headers to cover requested headers, got %q", got)
 	}
 	if got := res.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodPost) || !strings.Contains(got, http.MethodOptions) {
 		t.Fatalf("expected allow-methods to include POST and OPTIONS, got %q", got)
 	}
+	if got := res.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("expected max-age 600, got %q", got)
+	}
 	if vary := strings.Join(res.Header().Values("Vary"), ","); !strings.Contains(vary, "Origin") {
 		t.Fatalf("expected Vary header to include Origin, got %q", vary)
 	}
@@ -53,7 +64,10 @@ func TestWrapWithMiddlewarePreflight(t *testing.T) {
 func TestWrapWithMiddlewareAddsCORSHeadersToResponses(t *testing.T) {
 	t.Parallel()
 
-	a := &app{cfg: &config.Config{}}
+	a := corsTestApp(config.CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:5173"},
+		AllowCredentials: true,
+	})
 	handler := a.wrapWithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
@@ -77,4 +91,83 @@ func TestWrapWithMiddlewareAddsCORSHeadersToResponses(t *testing.T) {
 	if got := res.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodGet) {
 		t.Fatalf("expected allow-methods to include GET, got %q", got)
 	}
+	if got := res.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(got, "X-Run-ID") {
+		t.Fatalf("expected X-Run-ID to be exposed, got %q", got)
+	}
+}
+
+func TestWrapWithMiddlewareRejectsUnlistedOrigins(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	a := corsTestApp(config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+	handler := a.wrapWithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/projects", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if called || res.Code != http.StatusForbidden {
+		t.Fatalf("expected preflight from unlisted origin to be refused, got %d (called=%v)", res.Code, called)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no allow-origin for unlisted origin, got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no allow-credentials for unlisted origin, got %q", got)
+	}
+}
+
+func TestWrapWithMiddlewareWildcardOmitsCredentials(t *testing.T) {
+	t.Parallel()
+
+	a := corsTestApp(config.CORSConfig{AllowedOrigins: []string{"*"}})
+	handler := a.wrapWithMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/specialists/status", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected wildcard allow-origin, got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials with a wildcard origin, got %q", got)
+	}
+}
+
+func TestCORSPolicyWildcardNeverSendsCredentials(t *testing.T) {
+	t.Parallel()
+
+	// The loader rejects this config; the policy must stay safe without it.
+	handler := newCORSPolicy(config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}).wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		req := httptest.NewRequest(method, "/api/chat/sessions", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if got := res.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Fatalf("%s: expected wildcard allow-origin, got %q", method, got)
+		}
+		if got := res.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Fatalf("%s: expected no credentials with a wildcard origin, got %q", method, got)
+		}
+	}
 }
@@ -0,0 +1,148 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"manifold/internal/apierror"
+)
+
+// shutdownGrace bounds each shutdown step after the drain: cancelled runs
+// recording their outcome, closing the listener, and flushing telemetry.
+const shutdownGrace = 10 * time.Second
+
+// errShuttingDown is the cancellation cause of runs cut short by shutdown.
+var errShuttingDown = errors.New("server is shutting down")
+
+// runDrainer tracks in-flight agent and workflow runs so shutdown can stop
+// new ones, wait for the rest, and cancel those that outlive the drain
+// timeout.
+type runDrainer struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{}
+	stop     context.Context
+	cancel   context.CancelCauseFunc
+}
+
+func newRunDrainer() *runDrainer {
+	stop, cancel := context.WithCancelCause(context.Background())
+	return &runDrainer{stop: stop, cancel: cancel}
+}
+
+// track registers a run. The returned context is cancelled with
+// errShuttingDown if the run is still going when the drain times out, and
+// release must be called when the run ends. ok is false once draining has
+// begun. A nil drainer tracks nothing.
+func (d *runDrainer) track(ctx context.Context) (_ context.Context, release func(), ok bool) {
+	if d == nil {
+		return ctx, func() {}, true
+	}
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		return ctx, func() {}, false
+	}
+	d.active++
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stopAfter := context.AfterFunc(d.stop, func() { cancel(context.Cause(d.stop)) })
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			stopAfter()
+			cancel(nil)
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.active--
+			if d.active == 0 && d.idle != nil {
+				close(d.idle)
+				d.idle = nil
+			}
+		})
+	}, true
+}
+
+// beginDrain stops track from accepting new runs.
+func (d *runDrainer) beginDrain() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+}
+
+func (d *runDrainer) isDraining() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// drain waits up to timeout for in-flight runs to finish. Runs still going
+// after that are cancelled and given grace to record their outcome. It
+// returns the number of runs that had to be cancelled.
+func (d *runDrainer) drain(timeout, grace time.Duration) int {
+	if d == nil {
+		return 0
+	}
+	d.beginDrain()
+	if d.wait(timeout) {
+		return 0
+	}
+	d.mu.Lock()
+	n := d.active
+	d.mu.Unlock()
+	d.cancel(errShuttingDown)
+	d.wait(grace)
+	return n
+}
+
+// wait reports whether every tracked run ended within timeout.
+func (d *runDrainer) wait(timeout time.Duration) bool {
+	d.mu.Lock()
+	if d.active == 0 {
+		d.mu.Unlock()
+		return true
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.mu.Unlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// interruptedByShutdown reports whether ctx was cancelled by the drain.
+func interruptedByShutdown(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errShuttingDown)
+}
+
+// rejectWhileDraining answers run-starting requests with 503 once shutdown
+// has begun, so clients retry against another replica.
+func (a *app) rejectWhileDraining(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && a.drainer.isDraining() {
+			w.Header().Set("Retry-After", "5")
+			w.Header().Set("Connection", "close")
+			apierror.RespondCode(w, http.StatusServiceUnavailable, apierror.ShuttingDown, errShuttingDown.Error(), nil)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,77 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunDrainerWaitsForRuns(t *testing.T) {
+	t.Parallel()
+
+	d := newRunDrainer()
+	_, release, ok := d.track(context.Background())
+	if !ok {
+		t.Fatal("expected run to be tracked before draining")
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	if n := d.drain(time.Second, time.Second); n != 0 {
+		t.Fatalf("expected no cancelled runs, got %d", n)
+	}
+	if _, _, ok := d.track(context.Background()); ok {
+		t.Fatal("expected new runs to be refused while draining")
+	}
+}
+
+func TestRunDrainerCancelsStragglers(t *testing.T) {
+	t.Parallel()
+
+	d := newRunDrainer()
+	ctx, release, ok := d.track(context.Background())
+	if !ok {
+		t.Fatal("expected run to be tracked before draining")
+	}
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+	if n := d.drain(20*time.Millisecond, time.Second); n != 1 {
+		t.Fatalf("expected one cancelled run, got %d", n)
+	}
+	if !interruptedByShutdown(ctx) {
+		t.Fatalf("expected shutdown cause, got %v", context.Cause(ctx))
+	}
+}
+
+func TestRejectWhileDraining(t *testing.T) {
+	t.Parallel()
+
+	a := &app{drainer: newRunDrainer()}
+	h := a.rejectWhileDraining(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/agent/run", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected request to pass before draining, got %d", rec.Code)
+	}
+
+	a.drainer.beginDrain()
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/agent/run", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After while draining, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/agent/run", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected reads to pass while draining, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/flow"
+	"manifold/internal/playground"
+	playgroundregistry "manifold/internal/playground/registry"
+)
+
+// evalGateRunner evaluates playground eval gates. *playground.Service
+// satisfies it.
+type evalGateRunner interface {
+	EvaluateGate(ctx context.Context, gate playgroundregistry.EvalGate, promptVersionID string) (playground.GateResult, error)
+}
+
+// checkWorkflowEvalGate enforces wf.EvalGate before an upsert. It writes the
+// response and returns false when the workflow must not be saved.
+func (a *app) checkWorkflowEvalGate(w http.ResponseWriter, r *http.Request, wf flow.Workflow) bool {
+	if wf.EvalGate == nil {
+		return true
+	}
+	if a.evalGates == nil {
+		apierror.Respond(w, http.StatusServiceUnavailable, "eval gates require the playground to be enabled")
+		return false
+	}
+	gate := playgroundregistry.EvalGate{
+		ExperimentID: wf.EvalGate.ExperimentID,
+		Metric:       wf.EvalGate.Metric,
+		MinScore:     wf.EvalGate.MinScore,
+		Mode:         wf.EvalGate.Mode,
+	}
+	result, err := a.evalGates.EvaluateGate(r.Context(), gate, "")
+	if err == nil {
+		return true
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, playground.ErrGateFailed), errors.Is(err, playground.ErrGateNoRun):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, playground.ErrActiveRun):
+		status = http.StatusConflict
+	case errors.Is(err, playground.ErrUnknownExperiment):
+		status = http.StatusUnprocessableEntity
+	default:
+		log.Error().Err(err).Str("workflow", wf.ID).Str("experiment", gate.ExperimentID).Msg("workflow_eval_gate")
+	}
+	apierror.RespondCode(w, status, apierror.CodeForStatus(status), err.Error(), map[string]any{"gate": result})
+	return false
+}
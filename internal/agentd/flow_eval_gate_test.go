@@ -0,0 +1,110 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+	"manifold/internal/flow"
+	"manifold/internal/playground"
+	playgroundregistry "manifold/internal/playground/registry"
+)
+
+type stubEvalGates struct {
+	score float64
+	calls int
+}
+
+func (s *stubEvalGates) EvaluateGate(_ context.Context, gate playgroundregistry.EvalGate, _ string) (playground.GateResult, error) {
+	s.calls++
+	res := playground.GateResult{ExperimentID: gate.ExperimentID, Metric: gate.Metric, MinScore: gate.MinScore, Score: s.score, Passed: s.score >= gate.MinScore}
+	if !res.Passed {
+		return res, fmt.Errorf("%w: below minimum", playground.ErrGateFailed)
+	}
+	return res, nil
+}
+
+func gatedWorkflowBody(t *testing.T, minScore float64) []byte {
+	t.Helper()
+	body, err := json.Marshal(flow.PutWorkflowRequest{
+		Workflow: flow.Workflow{
+			ID:       "wf_gated",
+			Name:     "Gated Flow",
+			Trigger:  flow.Trigger{Type: flow.TriggerTypeManual},
+			Nodes:    []flow.Node{{ID: "n1", Name: "Step One", Kind: flow.NodeKindData, Type: "set"}},
+			EvalGate: &flow.EvalGate{ExperimentID: "exp-1", Metric: "accuracy", MinScore: minScore},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return body
+}
+
+func TestFlowV2UpsertBlockedByFailingEvalGate(t *testing.T) {
+	t.Parallel()
+	gates := &stubEvalGates{score: 0.6}
+	a := &app{cfg: &config.Config{}, flowV2: newFlowV2Runtime(nil, nil), evalGates: gates}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/flows/v2/workflows/wf_gated", bytes.NewReader(gatedWorkflowBody(t, 0.8)))
+	a.flowV2WorkflowDetailHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Code    apierror.Code `json:"code"`
+		Details struct {
+			Gate playground.GateResult `json:"gate"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Code != apierror.PreconditionFail {
+		t.Fatalf("expected precondition_failed, got %q", resp.Code)
+	}
+	if resp.Details.Gate.Passed || resp.Details.Gate.Score != 0.6 {
+		t.Fatalf("unexpected gate result: %+v", resp.Details.Gate)
+	}
+
+	getRec := httptest.NewRecorder()
+	a.flowV2WorkflowDetailHandler().ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, "/api/flows/v2/workflows/wf_gated", nil))
+	if getRec.Code != http.StatusNotFound {
+		t.Fatalf("workflow should not be saved when the gate fails, got %d", getRec.Code)
+	}
+}
+
+func TestFlowV2UpsertSavesWhenEvalGatePasses(t *testing.T) {
+	t.Parallel()
+	gates := &stubEvalGates{score: 0.9}
+	a := &app{cfg: &config.Config{}, flowV2: newFlowV2Runtime(nil, nil), evalGates: gates}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/flows/v2/workflows/wf_gated", bytes.NewReader(gatedWorkflowBody(t, 0.8)))
+	a.flowV2WorkflowDetailHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if gates.calls != 1 {
+		t.Fatalf("expected gate to run once, got %d", gates.calls)
+	}
+}
+
+func TestFlowV2UpsertEvalGateWithoutPlayground(t *testing.T) {
+	t.Parallel()
+	a := &app{cfg: &config.Config{}, flowV2: newFlowV2Runtime(nil, nil)}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/flows/v2/workflows/wf_gated", bytes.NewReader(gatedWorkflowBody(t, 0.8)))
+	a.flowV2WorkflowDetailHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
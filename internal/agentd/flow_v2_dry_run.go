@@ -0,0 +1,207 @@
+package agentd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"manifold/internal/flow"
+)
+
+// dryRunFlowV2 validates wf and simulates a run against the sample input
+// without calling any tool. Expressions are expanded with the sample input,
+// tool nodes produce placeholder outputs, and "if" nodes pick their branch
+// whenever the sample input decides the condition.
+func (a *app) dryRunFlowV2(wf flow.Workflow, input map[string]any) flow.ValidateResponse {
+	plan, diags := flow.CompileWorkflow(wf)
+	if plan == nil || hasFlowV2Errors(diags) {
+		return flow.ValidateResponse{Valid: false, Diagnostics: diags}
+	}
+	toolSet := flowV2ToolSet(a.flowV2ExecutionRegistry())
+	diags = append(diags, flow.AnalyzeWorkflow(wf, plan, func(name string) bool { return toolSet[name] })...)
+	steps, simulated := simulateFlowV2Run(wf, plan, input)
+	diags = append(diags, simulated...)
+	return flow.ValidateResponse{
+		Valid:       !hasFlowV2Errors(diags),
+		Diagnostics: diags,
+		Plan:        plan,
+		Steps:       steps,
+	}
+}
+
+// simulateFlowV2Run walks plan in order the way executeFlowV2Run would,
+// resolving guards, for_each lists and inputs against input. Values that
+// depend on a tool's output are unknown before the tool runs; they are
+// shown as "<runtime: expr>" instead of being reported. Expressions that
+// fail for any other reason are reported as warnings, since the sample
+// input may simply be incomplete.
+func simulateFlowV2Run(wf flow.Workflow, plan *flow.Plan, input map[string]any) ([]flow.DryRunStep, []flow.Diagnostic) {
+	nodeByID := make(map[string]flow.Node, len(wf.Nodes))
+	nodeIndex := make(map[string]int, len(wf.Nodes))
+	for i, n := range wf.Nodes {
+		nodeByID[n.ID] = n
+		nodeIndex[n.ID] = i
+	}
+	var diags []flow.Diagnostic
+	warn := func(code, msg, path string) {
+		diags = append(diags, flow.Diagnostic{Severity: flow.DiagnosticSeverityWarning, Code: code, Message: msg, Path: path})
+	}
+
+	outputs := make(map[string]map[string]any, len(wf.Nodes))
+	taken := make(map[string]int, len(wf.Nodes))
+	// runtimeOnly marks nodes whose real output only exists once tools run.
+	runtimeOnly := map[string]bool{}
+	dependsOnRuntime := func(expr string) bool {
+		for _, ref := range flow.NodeReferences(expr) {
+			if runtimeOnly[ref] {
+				return true
+			}
+		}
+		return false
+	}
+
+	steps := make([]flow.DryRunStep, 0, len(plan.NodeOrder))
+	for _, id := range plan.NodeOrder {
+		node := nodeByID[id]
+		path := fmt.Sprintf("workflow.nodes[%d]", nodeIndex[id])
+		step := flow.DryRunStep{NodeID: id, Status: "ready", Tool: node.Tool}
+		follow := func(e flow.Edge) bool { return e.Source.Port != flow.PortOnFailure }
+		var failure error
+
+		func() {
+			if len(plan.Incoming[id]) > 0 && taken[id] == 0 {
+				step.Status, step.Reason = "skipped", "no incoming branch taken"
+				return
+			}
+			if guard := strings.TrimSpace(node.Guard); guard != "" {
+				v, err := evalFlowExpression(guard, input, outputs)
+				switch {
+				case err != nil && dependsOnRuntime(guard):
+					step.Reason = "guard depends on tool output; assumed true"
+				case err != nil:
+					failure = fmt.Errorf("guard: %w", err)
+					warn("dryrun.guard.unresolved", failure.Error(), path+".guard")
+					return
+				default:
+					if ok, isBool := asBool(v); isBool && !ok {
+						step.Status, step.Reason = "skipped", "guard is false"
+						return
+					}
+				}
+			}
+
+			scope := outputs
+			if expr := strings.TrimSpace(node.ForEach); expr != "" {
+				var item any = "<runtime: $item>"
+				v, err := evalFlowExpression(expr, input, outputs)
+				switch {
+				case err != nil && dependsOnRuntime(expr):
+				case err != nil:
+					failure = fmt.Errorf("for_each: %w", err)
+					warn("dryrun.for_each.unresolved", failure.Error(), path+".for_each")
+					return
+				default:
+					list, ok := v.([]any)
+					if !ok {
+						failure = fmt.Errorf("for_each: %s is not a list", expr)
+						warn("dryrun.for_each.not_list", failure.Error(), path+".for_each")
+						return
+					}
+					if len(list) == 0 {
+						step.Reason = "for_each list is empty"
+					} else {
+						item = list[0]
+					}
+				}
+				scope = cloneNodeOutputs(outputs)
+				scope[flowLoopScope] = map[string]any{"item": item, "index": 0}
+			}
+
+			edgesOnly := node
+			edgesOnly.Inputs = nil
+			resolved, _ := resolveNodeInputs(edgesOnly, plan.Incoming[id], scope, input)
+			keys := make([]string, 0, len(node.Inputs))
+			for key := range node.Inputs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			conditionKnown := true
+			for _, key := range keys {
+				binding := node.Inputs[key]
+				expr := strings.TrimSpace(binding.Expression)
+				if expr == "" {
+					if binding.Literal != nil {
+						resolved[key] = binding.Literal
+					}
+					continue
+				}
+				v, err := evalFlowExpression(expr, input, scope)
+				switch {
+				case err != nil && dependsOnRuntime(expr):
+					resolved[key] = "<runtime: " + normalizeFlowExpression(expr) + ">"
+					if key == "condition" {
+						conditionKnown = false
+					}
+				case err != nil:
+					if failure == nil {
+						failure = fmt.Errorf("input %s: %w", key, err)
+					}
+					warn("dryrun.input.unresolved", fmt.Sprintf("input %s: %v", key, err), path+".inputs."+key+".expression")
+				default:
+					resolved[key] = v
+				}
+			}
+			step.Inputs = resolved
+			if failure != nil {
+				return
+			}
+
+			switch {
+			case node.Type == "tool" || strings.TrimSpace(node.ForEach) != "":
+				runtimeOnly[id] = true
+				outputs[id] = map[string]any{"inputs": cloneMap(resolved), "dry_run": true}
+			case node.Type == "if" && !conditionKnown:
+				step.Reason = "condition depends on tool output; both branches followed"
+				outputs[id] = map[string]any{"inputs": cloneMap(resolved)}
+				follow = func(flow.Edge) bool { return true }
+			case node.Type == "if":
+				result, _ := asBool(resolved["condition"])
+				outputs[id] = map[string]any{"result": result, "inputs": cloneMap(resolved)}
+				step.Reason = fmt.Sprintf("condition is %t", result)
+				follow = func(e flow.Edge) bool {
+					switch e.Source.Port {
+					case flow.PortTrue:
+						return result
+					case flow.PortFalse:
+						return !result
+					case flow.PortOnFailure:
+						return false
+					}
+					return true
+				}
+			default:
+				outputs[id] = map[string]any{"inputs": cloneMap(resolved)}
+			}
+		}()
+
+		switch {
+		case step.Status == "skipped":
+			follow = func(flow.Edge) bool { return false }
+		case failure != nil:
+			step.Status, step.Reason = "error", failure.Error()
+			if hasFailureBranch(plan.Outgoing[id]) {
+				outputs[id] = map[string]any{"error": failure.Error(), "node_id": id}
+				follow = func(e flow.Edge) bool { return e.Source.Port == flow.PortOnFailure }
+			} else {
+				follow = func(flow.Edge) bool { return false }
+			}
+		}
+		for _, e := range plan.Outgoing[id] {
+			if follow(e) {
+				taken[e.Target.NodeID]++
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, diags
+}
@@ -3,6 +3,7 @@ package agentd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 	"strconv"
@@ -11,15 +12,19 @@ import (
 	"time"
 
 	"manifold/internal/flow"
+	"manifold/internal/mcpclient"
 	persist "manifold/internal/persistence"
 	"manifold/internal/persistence/databases"
 	"manifold/internal/tools"
+
+	"github.com/rs/zerolog/log"
 )
 
 type flowV2RunRecord struct {
 	ID         string
 	UserID     int64
 	WorkflowID string
+	Trigger    string
 	Status     string
 	Input      map[string]any
 	Error      string
@@ -34,22 +39,32 @@ type flowV2Runtime struct {
 	mu    sync.RWMutex
 	store persist.FlowV2WorkflowStore
 	runs  map[string]*flowV2RunRecord
+	// history, when set, keeps an audit record of every run.
+	history persist.WorkflowRunStore
 }
 
 type flowNodeResult struct {
-	nodeID  string
-	output  map[string]any
-	err     error
-	skipped bool
+	nodeID     string
+	output     map[string]any
+	err        error
+	skipped    bool
+	skipReason string
 }
 
-func newFlowV2Runtime(store persist.FlowV2WorkflowStore) *flowV2Runtime {
+// flowLoopScope is the pseudo node ID under which for_each iterations expose
+// the current element to $item and $index expressions.
+const flowLoopScope = "$loop"
+
+// newFlowV2Runtime returns a runtime over store. history may be nil, in
+// which case runs are only kept in memory.
+func newFlowV2Runtime(store persist.FlowV2WorkflowStore, history persist.WorkflowRunStore) *flowV2Runtime {
 	if store == nil {
 		store = databases.NewPostgresFlowV2Store(nil)
 	}
 	return &flowV2Runtime{
-		store: store,
-		runs:  map[string]*flowV2RunRecord{},
+		store:   store,
+		runs:    map[string]*flowV2RunRecord{},
+		history: history,
 	}
 }
 
@@ -110,15 +125,17 @@ func (s *flowV2Runtime) deleteWorkflow(ctx context.Context, userID int64, workfl
 	return true, nil
 }
 
-func (s *flowV2Runtime) createRun(userID int64, workflowID string, input map[string]any) string {
+// createRun registers a run of workflowID started by trigger, one of the
+// persist.WorkflowTrigger values.
+func (s *flowV2Runtime) createRun(userID int64, workflowID, trigger string, input map[string]any) string {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	runID := fmt.Sprintf("flowrun_%d", time.Now().UnixNano())
 	now := time.Now().UTC()
-	s.runs[runID] = &flowV2RunRecord{
+	run := &flowV2RunRecord{
 		ID:         runID,
 		UserID:     userID,
 		WorkflowID: workflowID,
+		Trigger:    trigger,
 		Status:     "running",
 		Input:      cloneMap(input),
 		CreatedAt:  now,
@@ -126,6 +143,10 @@ func (s *flowV2Runtime) createRun(userID int64, workflowID string, input map[str
 		Events:     make([]flow.RunEvent, 0, 32),
 		Subs:       map[chan flow.RunEvent]struct{}{},
 	}
+	s.runs[runID] = run
+	record := run.auditRecord()
+	s.mu.Unlock()
+	s.saveHistory(record)
 	return runID
 }
 
@@ -158,12 +179,21 @@ func (s *flowV2Runtime) appendRunEvent(userID int64, runID string, event flow.Ru
 	case flow.RunEventTypeRunCancelled:
 		run.Status = "cancelled"
 	}
+	var record *persist.WorkflowRun
+	if run.Status != "running" {
+		h := run.auditRecord()
+		record = &h
+	}
 	subs := make([]chan flow.RunEvent, 0, len(run.Subs))
 	for ch := range run.Subs {
 		subs = append(subs, ch)
 	}
 	s.mu.Unlock()
 
+	if record != nil {
+		s.saveHistory(*record)
+	}
+
 	for _, ch := range subs {
 		select {
 		case ch <- event:
@@ -173,6 +203,73 @@ func (s *flowV2Runtime) appendRunEvent(userID int64, runID string, event flow.Ru
 	return true
 }
 
+// auditRecord returns the history entry for the run. Callers hold the
+// runtime lock.
+func (run *flowV2RunRecord) auditRecord() persist.WorkflowRun {
+	out := persist.WorkflowRun{
+		ID:         run.ID,
+		UserID:     run.UserID,
+		WorkflowID: run.WorkflowID,
+		Trigger:    run.Trigger,
+		Status:     run.Status,
+		Input:      cloneMap(run.Input),
+		Error:      run.Error,
+		Steps:      workflowRunSteps(run.Events),
+		StartedAt:  run.CreatedAt,
+	}
+	if run.Status != "running" {
+		out.EndedAt = run.UpdatedAt
+		out.DurationMs = run.UpdatedAt.Sub(run.CreatedAt).Milliseconds()
+	}
+	return out
+}
+
+// workflowRunSteps folds node events into one step per node, in the order
+// the nodes started.
+func workflowRunSteps(events []flow.RunEvent) []persist.WorkflowRunStep {
+	steps := []persist.WorkflowRunStep{}
+	index := map[string]int{}
+	for _, ev := range events {
+		if ev.NodeID == "" || ev.Type == flow.RunEventTypeNodeOutputDiff {
+			continue
+		}
+		i, ok := index[ev.NodeID]
+		if !ok {
+			i = len(steps)
+			index[ev.NodeID] = i
+			steps = append(steps, persist.WorkflowRunStep{NodeID: ev.NodeID, StartedAt: ev.OccurredAt})
+		}
+		step := &steps[i]
+		if ev.Status != "" {
+			step.Status = ev.Status
+		}
+		switch ev.Type {
+		case flow.RunEventTypeNodeStarted:
+			step.Attempts = 1
+		case flow.RunEventTypeNodeRetrying:
+			step.Attempts++
+		case flow.RunEventTypeNodeCompleted, flow.RunEventTypeNodeFailed, flow.RunEventTypeNodeSkipped:
+			step.Error = ev.Error
+			step.EndedAt = ev.OccurredAt
+			step.DurationMs = ev.OccurredAt.Sub(step.StartedAt).Milliseconds()
+		}
+	}
+	return steps
+}
+
+// saveHistory records run in the history store, if there is one. Failures
+// are logged; they never affect the run.
+func (s *flowV2Runtime) saveHistory(run persist.WorkflowRun) {
+	if s.history == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.history.Save(ctx, run); err != nil {
+		log.Warn().Err(err).Str("run", run.ID).Msg("workflow_run_history_save_failed")
+	}
+}
+
 func (s *flowV2Runtime) getRunEvents(userID int64, runID string) ([]flow.RunEvent, string, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -216,6 +313,18 @@ func (s *flowV2Runtime) unsubscribeRun(runID string, ch chan flow.RunEvent) {
 func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string, wf flow.Workflow, plan *flow.Plan, input map[string]any) {
 	emit := func(ev flow.RunEvent) {
 		_ = a.flowV2State().appendRunEvent(userID, runID, ev)
+		a.notifyFlowRunEvent(userID, runID, wf.ID, ev)
+	}
+	ctx, release, ok := a.drainer.track(ctx)
+	defer release()
+	if !ok {
+		emit(flow.RunEvent{
+			Type:    flow.RunEventTypeRunFailed,
+			Status:  "failed",
+			Error:   errShuttingDown.Error(),
+			Message: "run rejected",
+		})
+		return
 	}
 	emit(flow.RunEvent{
 		Type:    flow.RunEventTypeRunStarted,
@@ -229,12 +338,7 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 	}
 
 	reg := a.flowV2ExecutionRegistry()
-	toolSet := map[string]bool{}
-	if reg != nil {
-		for _, schema := range reg.Schemas() {
-			toolSet[schema.Name] = true
-		}
-	}
+	toolSet := flowV2ToolSet(reg)
 
 	defaultExec := wf.Settings.DefaultExecution
 	maxConcurrency := wf.Settings.MaxConcurrency
@@ -259,6 +363,9 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 
 	nodeOutputs := make(map[string]map[string]any, len(wf.Nodes))
 	launched := make(map[string]bool, len(wf.Nodes))
+	// taken counts incoming edges whose branch was followed; a node whose
+	// incoming edges were all left untaken is skipped.
+	taken := make(map[string]int, len(wf.Nodes))
 	var stateMu sync.RWMutex
 	var fatalErr error
 	var processed int
@@ -278,6 +385,12 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 			return false
 		}
 		launched[nodeID] = true
+		if len(plan.Incoming[nodeID]) > 0 && taken[nodeID] == 0 {
+			go func() {
+				resultCh <- flowNodeResult{nodeID: nodeID, skipped: true, skipReason: "node skipped: no incoming branch taken"}
+			}()
+			return true
+		}
 		go func(node flow.Node) {
 			outputsSnapshot := func() map[string]map[string]any {
 				stateMu.RLock()
@@ -304,6 +417,12 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 				Message: "node started",
 			})
 
+			if expr := strings.TrimSpace(node.ForEach); expr != "" {
+				output, err := a.executeFlowV2Loop(runCtx, node, expr, plan.Incoming[node.ID], outputsSnapshot, input, reg, toolSet, defaultExec, emit)
+				resultCh <- flowNodeResult{nodeID: node.ID, output: output, err: err}
+				return
+			}
+
 			resolvedInputs, err := resolveNodeInputs(node, plan.Incoming[node.ID], outputsSnapshot, input)
 			if err != nil {
 				resultCh <- flowNodeResult{nodeID: node.ID, err: err}
@@ -362,20 +481,35 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 		active--
 		processed++
 		node := nodeByID[res.nodeID]
+		// follow decides which outgoing edges of the finished node are taken.
+		follow := func(e flow.Edge) bool { return e.Source.Port != flow.PortOnFailure }
 
 		switch {
 		case res.skipped:
+			message := res.skipReason
+			if message == "" {
+				message = "node skipped"
+			}
 			emit(flow.RunEvent{
 				Type:    flow.RunEventTypeNodeSkipped,
 				NodeID:  res.nodeID,
 				Status:  "skipped",
-				Message: "node skipped",
+				Message: message,
 			})
+			follow = func(flow.Edge) bool { return false }
 		case res.err != nil:
 			message := "node failed"
 			if strings.Contains(res.err.Error(), "input ") || strings.Contains(res.err.Error(), "path not found") {
 				message = "node input resolution failed"
 			}
+			handled := hasFailureBranch(plan.Outgoing[res.nodeID])
+			if handled {
+				message += "; following on_failure branch"
+				stateMu.Lock()
+				nodeOutputs[res.nodeID] = map[string]any{"error": res.err.Error(), "node_id": res.nodeID}
+				stateMu.Unlock()
+				follow = func(e flow.Edge) bool { return e.Source.Port == flow.PortOnFailure }
+			}
 			emit(flow.RunEvent{
 				Type:    flow.RunEventTypeNodeFailed,
 				NodeID:  res.nodeID,
@@ -383,11 +517,25 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 				Error:   res.err.Error(),
 				Message: message,
 			})
-			if effectiveOnError(node, defaultExec) != flow.ErrorStrategyContinue && fatalErr == nil {
+			if !handled && effectiveOnError(node, defaultExec) != flow.ErrorStrategyContinue && fatalErr == nil {
 				fatalErr = res.err
 				cancelRun()
 			}
 		default:
+			if node.Type == "if" {
+				result, _ := res.output["result"].(bool)
+				follow = func(e flow.Edge) bool {
+					switch e.Source.Port {
+					case flow.PortTrue:
+						return result
+					case flow.PortFalse:
+						return !result
+					case flow.PortOnFailure:
+						return false
+					}
+					return true
+				}
+			}
 			clonedOutput := cloneMap(res.output)
 			stateMu.Lock()
 			nodeOutputs[res.nodeID] = clonedOutput
@@ -409,6 +557,9 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 		ready := make([]string, 0, len(plan.Outgoing[res.nodeID]))
 		for _, edge := range plan.Outgoing[res.nodeID] {
 			targetID := edge.Target.NodeID
+			if follow(edge) {
+				taken[targetID]++
+			}
 			remaining[targetID]--
 			if remaining[targetID] == 0 && !launched[targetID] {
 				ready = append(ready, targetID)
@@ -432,7 +583,7 @@ func (a *app) executeFlowV2Run(ctx context.Context, userID int64, runID string,
 		emit(flow.RunEvent{
 			Type:    flow.RunEventTypeRunFailed,
 			Status:  "failed",
-			Error:   ctx.Err().Error(),
+			Error:   context.Cause(ctx).Error(),
 			Message: "run cancelled",
 		})
 		return
@@ -471,14 +622,15 @@ func (a *app) executeFlowV2NodeWithRetries(
 			return output, nil
 		}
 		if attempt < attempts {
+			delay := flowRetryDelay(node, defaults, attempt)
 			emit(flow.RunEvent{
 				Type:    flow.RunEventTypeNodeRetrying,
 				NodeID:  node.ID,
 				Status:  "retrying",
-				Message: fmt.Sprintf("retry %d/%d", attempt, attempts-1),
+				Message: fmt.Sprintf("retry %d/%d in %s", attempt, attempts-1, delay),
 				Error:   runErr.Error(),
 			})
-			if !sleepFlowRetry(ctx, node, defaults, attempt) {
+			if !sleepFlowRetry(ctx, delay) {
 				return nil, context.Canceled
 			}
 		}
@@ -486,6 +638,53 @@ func (a *app) executeFlowV2NodeWithRetries(
 	return nil, runErr
 }
 
+// executeFlowV2Loop runs node once per element of the list expr resolves to,
+// stopping at the first failed iteration.
+func (a *app) executeFlowV2Loop(
+	ctx context.Context,
+	node flow.Node,
+	expr string,
+	incoming []flow.Edge,
+	outputs map[string]map[string]any,
+	runInput map[string]any,
+	reg tools.Registry,
+	toolSet map[string]bool,
+	defaults flow.NodeExecution,
+	emit func(flow.RunEvent),
+) (map[string]any, error) {
+	value, err := evalFlowExpression(expr, runInput, outputs)
+	if err != nil {
+		return nil, fmt.Errorf("node %s for_each: %w", node.ID, err)
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("node %s for_each: %s is not a list", node.ID, expr)
+	}
+	items := make([]any, 0, len(list))
+	for i, item := range list {
+		outputs[flowLoopScope] = map[string]any{"item": item, "index": i}
+		inputs, err := resolveNodeInputs(node, incoming, outputs, runInput)
+		if err != nil {
+			return nil, err
+		}
+		out, err := a.executeFlowV2NodeWithRetries(ctx, node, inputs, reg, toolSet, defaults, emit)
+		if err != nil {
+			return nil, fmt.Errorf("node %s item %d: %w", node.ID, i, err)
+		}
+		items = append(items, out)
+	}
+	return map[string]any{"items": items, "count": len(items)}, nil
+}
+
+func hasFailureBranch(edges []flow.Edge) bool {
+	for _, e := range edges {
+		if e.Source.Port == flow.PortOnFailure {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *app) executeFlowV2Node(ctx context.Context, node flow.Node, inputs map[string]any, reg tools.Registry, toolSet map[string]bool, defaults flow.NodeExecution) (map[string]any, error) {
 	execCfg := effectiveNodeExecution(node, defaults)
 	cctx := ctx
@@ -494,6 +693,14 @@ func (a *app) executeFlowV2Node(ctx context.Context, node flow.Node, inputs map[
 		cctx, cancel = context.WithTimeout(ctx, d)
 		defer cancel()
 	}
+	// timedOut labels failures caused by this attempt's own deadline, as
+	// opposed to the run being cancelled.
+	timedOut := func(err error) error {
+		if errors.Is(cctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return fmt.Errorf("node %s timed out after %s: %w", node.ID, execCfg.Timeout, err)
+		}
+		return err
+	}
 
 	switch node.Type {
 	case "tool":
@@ -506,10 +713,15 @@ func (a *app) executeFlowV2Node(ctx context.Context, node flow.Node, inputs map[
 		if !toolSet[node.Tool] {
 			return nil, fmt.Errorf("tool not found: %s", node.Tool)
 		}
+		var err error
+		inputs, err = a.resolveMCPPromptInputs(cctx, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", node.ID, err)
+		}
 		raw, _ := json.Marshal(inputs)
 		payload, err := reg.Dispatch(cctx, node.Tool, raw)
 		if err != nil {
-			return nil, err
+			return nil, timedOut(err)
 		}
 		out := map[string]any{
 			"inputs":  cloneMap(inputs),
@@ -521,7 +733,7 @@ func (a *app) executeFlowV2Node(ctx context.Context, node flow.Node, inputs map[
 			if m, ok := parsed.(map[string]any); ok {
 				if em, ok := m["error"].(string); ok && strings.TrimSpace(em) != "" {
 					if okv, hasOK := m["ok"].(bool); !hasOK || !okv {
-						return nil, fmt.Errorf("tool %s returned error: %s", node.Tool, em)
+						return nil, timedOut(fmt.Errorf("tool %s returned error: %s", node.Tool, em))
 					}
 				}
 				for k, v := range m {
@@ -547,6 +759,36 @@ func (a *app) executeFlowV2Node(ctx context.Context, node flow.Node, inputs map[
 	}
 }
 
+// resolveMCPPromptInputs replaces string inputs holding an MCP prompt
+// reference (mcp://<server>/prompts/<name>) with the rendered prompt.
+func (a *app) resolveMCPPromptInputs(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	var out map[string]any
+	for key, v := range inputs {
+		ref, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if _, _, _, ok := mcpclient.ParsePromptURI(ref); !ok {
+			continue
+		}
+		if a.mcpManager == nil {
+			return nil, fmt.Errorf("input %s: mcp unavailable", key)
+		}
+		text, err := a.mcpManager.ResolvePrompt(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", key, err)
+		}
+		if out == nil {
+			out = cloneMap(inputs)
+		}
+		out[key] = text
+	}
+	if out == nil {
+		return inputs, nil
+	}
+	return out, nil
+}
+
 func resolveNodeInputs(node flow.Node, incoming []flow.Edge, outputs map[string]map[string]any, runInput map[string]any) (map[string]any, error) {
 	resolved := map[string]any{}
 	for _, edge := range incoming {
@@ -632,6 +874,24 @@ func evalFlowExpression(expr string, runInput map[string]any, outputs map[string
 
 singleExpression:
 	norm := normalizeFlowExpression(expr)
+	if v, ok, err := evalFlowCondition(norm, runInput, outputs); ok {
+		return v, err
+	}
+	if norm == "$index" || norm == "$item" || strings.HasPrefix(norm, "$item.") {
+		scope := outputs[flowLoopScope]
+		if scope == nil {
+			return nil, fmt.Errorf("%s is only available in for_each nodes", norm)
+		}
+		if norm == "$index" {
+			return scope["index"], nil
+		}
+		path := strings.TrimPrefix(strings.TrimPrefix(norm, "$item"), ".")
+		v, ok := selectFlowPath(scope["item"], path)
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", norm)
+		}
+		return v, nil
+	}
 	if strings.HasPrefix(norm, "$run.input") {
 		path := strings.TrimPrefix(norm, "$run.input")
 		path = strings.TrimPrefix(path, ".")
@@ -681,6 +941,163 @@ singleExpression:
 	return nil, fmt.Errorf("unsupported expression: %s", expr)
 }
 
+// evalFlowCondition evaluates the boolean operators in a normalized
+// expression: "||", "&&", the comparisons ==, !=, >=, <=, > and <, and a
+// leading "!". Operands are flow expressions themselves, so
+// `$run.input.count > 3 && $node.check.output.ok` is valid. ok is false when
+// expr contains no operator.
+func evalFlowCondition(expr string, runInput map[string]any, outputs map[string]map[string]any) (value any, ok bool, err error) {
+	for _, op := range []string{"||", "&&"} {
+		parts := splitFlowOperator(expr, op)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, part := range parts {
+			v, err := evalFlowExpression(part, runInput, outputs)
+			if err != nil {
+				return nil, true, err
+			}
+			if truthy := flowTruthy(v); op == "||" && truthy {
+				return true, true, nil
+			} else if op == "&&" && !truthy {
+				return false, true, nil
+			}
+		}
+		return op == "&&", true, nil
+	}
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		parts := splitFlowOperator(expr, op)
+		if len(parts) < 2 {
+			continue
+		}
+		if len(parts) > 2 {
+			return nil, true, fmt.Errorf("chained %s in expression: %s", op, expr)
+		}
+		left, err := evalFlowExpression(parts[0], runInput, outputs)
+		if err != nil {
+			return nil, true, err
+		}
+		right, err := evalFlowExpression(parts[1], runInput, outputs)
+		if err != nil {
+			return nil, true, err
+		}
+		result, err := compareFlowValues(left, right, op)
+		return result, true, err
+	}
+	if strings.HasPrefix(expr, "!") {
+		v, err := evalFlowExpression(strings.TrimSpace(expr[1:]), runInput, outputs)
+		if err != nil {
+			return nil, true, err
+		}
+		return !flowTruthy(v), true, nil
+	}
+	return nil, false, nil
+}
+
+// splitFlowOperator splits expr on op, ignoring occurrences inside double
+// quoted strings.
+func splitFlowOperator(expr, op string) []string {
+	var parts []string
+	inString, escaped := false, false
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && inString:
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(expr[i:], op):
+			// Keep ">" and "<" from matching the first half of ">=" or "<=".
+			if (op == ">" || op == "<") && i+1 < len(expr) && expr[i+1] == '=' {
+				continue
+			}
+			parts = append(parts, strings.TrimSpace(expr[start:i]))
+			start = i + len(op)
+			i += len(op) - 1
+		}
+	}
+	if parts == nil {
+		return nil
+	}
+	return append(parts, strings.TrimSpace(expr[start:]))
+}
+
+func compareFlowValues(left, right any, op string) (bool, error) {
+	if l, ok := flowNumber(left); ok {
+		if r, ok := flowNumber(right); ok {
+			switch op {
+			case "==":
+				return l == r, nil
+			case "!=":
+				return l != r, nil
+			case ">=":
+				return l >= r, nil
+			case "<=":
+				return l <= r, nil
+			case ">":
+				return l > r, nil
+			case "<":
+				return l < r, nil
+			}
+		}
+	}
+	switch op {
+	case "==", "!=":
+		lb, _ := json.Marshal(left)
+		rb, _ := json.Marshal(right)
+		return (string(lb) == string(rb)) == (op == "=="), nil
+	}
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return false, fmt.Errorf("cannot compare %T %s %T", left, op, right)
+	}
+	switch op {
+	case ">=":
+		return l >= r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l < r, nil
+	}
+}
+
+func flowNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	}
+	return 0, false
+}
+
+// flowTruthy treats nil, false, zero, empty strings, and empty collections as
+// false.
+func flowTruthy(v any) bool {
+	if b, ok := asBool(v); ok {
+		return b
+	}
+	switch t := v.(type) {
+	case nil:
+		return false
+	case string:
+		return t != ""
+	case []any:
+		return len(t) > 0
+	case map[string]any:
+		return len(t) > 0
+	}
+	return true
+}
+
 func normalizeFlowExpression(expr string) string {
 	norm := strings.TrimSpace(expr)
 	if strings.HasPrefix(norm, "=") {
@@ -703,6 +1120,12 @@ func effectiveNodeExecution(node flow.Node, defaults flow.NodeExecution) flow.No
 	if out.Retries.Backoff == "" {
 		out.Retries.Backoff = defaults.Retries.Backoff
 	}
+	if strings.TrimSpace(out.Retries.Delay) == "" {
+		out.Retries.Delay = defaults.Retries.Delay
+	}
+	if strings.TrimSpace(out.Retries.MaxDelay) == "" {
+		out.Retries.MaxDelay = defaults.Retries.MaxDelay
+	}
 	if out.OnError == "" {
 		out.OnError = defaults.OnError
 	}
@@ -724,20 +1147,32 @@ func effectiveRetries(node flow.Node, defaults flow.NodeExecution) int {
 	return 1 + max
 }
 
-func sleepFlowRetry(ctx context.Context, node flow.Node, defaults flow.NodeExecution, attempt int) bool {
-	execCfg := effectiveNodeExecution(node, defaults)
-	backoff := execCfg.Retries.Backoff
-	if backoff == "" {
-		backoff = flow.BackoffFixed
-	}
-	delay := 200 * time.Millisecond
-	switch backoff {
+// flowRetryDelay returns how long to wait after the given failed attempt.
+func flowRetryDelay(node flow.Node, defaults flow.NodeExecution, attempt int) time.Duration {
+	retries := effectiveNodeExecution(node, defaults).Retries
+	delay := parseFlowDuration(retries.Delay)
+	if delay <= 0 {
+		delay = 200 * time.Millisecond
+	}
+	switch retries.Backoff {
 	case flow.BackoffExponential:
-		delay = delay * time.Duration(1<<(attempt-1))
-	case flow.BackoffFixed:
+		for i := 1; i < attempt && delay < time.Hour; i++ {
+			delay *= 2
+		}
+	case flow.BackoffFixed, flow.BackoffNone:
 		// base delay
 	default:
-		return true
+		return 0
+	}
+	if maxDelay := parseFlowDuration(retries.MaxDelay); maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func sleepFlowRetry(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
 	}
 	timer := time.NewTimer(delay)
 	defer timer.Stop()
@@ -913,3 +1348,14 @@ func (a *app) flowV2ExecutionRegistry() tools.Registry {
 	}
 	return a.toolRegistry
 }
+
+// flowV2ToolSet returns the names of the tools reg can dispatch.
+func flowV2ToolSet(reg tools.Registry) map[string]bool {
+	toolSet := map[string]bool{}
+	if reg != nil {
+		for _, schema := range reg.Schemas() {
+			toolSet[schema.Name] = true
+		}
+	}
+	return toolSet
+}
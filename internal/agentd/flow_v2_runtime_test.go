@@ -3,6 +3,7 @@ package agentd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"sort"
 	"strings"
 	"sync"
@@ -11,6 +12,8 @@ import (
 
 	"manifold/internal/flow"
 	"manifold/internal/llm"
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
 	"manifold/internal/tools"
 )
 
@@ -140,7 +143,7 @@ func TestExecuteFlowV2RunParallelFanOutAndConverge(t *testing.T) {
 		}},
 	)
 
-	a := &app{flowV2: newFlowV2Runtime(nil), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
 	wf := flow.Workflow{
 		ID:       "wf_parallel",
 		Name:     "Parallel",
@@ -164,7 +167,7 @@ func TestExecuteFlowV2RunParallelFanOutAndConverge(t *testing.T) {
 		t.Fatalf("unexpected diagnostics: %+v", diags)
 	}
 
-	runID := a.flowV2.createRun(0, wf.ID, nil)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
 	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
 
 	events, status, ok := a.flowV2.getRunEvents(0, runID)
@@ -228,7 +231,7 @@ func TestExecuteFlowV2RunMaxConcurrencyOneIsSerial(t *testing.T) {
 		}},
 	)
 
-	a := &app{flowV2: newFlowV2Runtime(nil), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
 	wf := flow.Workflow{
 		ID:       "wf_serial",
 		Name:     "Serial",
@@ -245,7 +248,7 @@ func TestExecuteFlowV2RunMaxConcurrencyOneIsSerial(t *testing.T) {
 		},
 	}
 	plan, _ := flow.CompileWorkflow(wf)
-	runID := a.flowV2.createRun(0, wf.ID, nil)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
 	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
 
 	mu.Lock()
@@ -277,7 +280,7 @@ func TestExecuteFlowV2RunFailFastCancelsSiblings(t *testing.T) {
 		}},
 	)
 
-	a := &app{flowV2: newFlowV2Runtime(nil), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
 	wf := flow.Workflow{
 		ID:       "wf_failfast",
 		Name:     "Fail Fast",
@@ -294,7 +297,7 @@ func TestExecuteFlowV2RunFailFastCancelsSiblings(t *testing.T) {
 		},
 	}
 	plan, _ := flow.CompileWorkflow(wf)
-	runID := a.flowV2.createRun(0, wf.ID, nil)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
 	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
 
 	select {
@@ -322,7 +325,7 @@ func TestExecuteFlowV2RunGuardSkip(t *testing.T) {
 	reg := newRuntimeStubRegistry(runtimeTestTool{name: "guarded", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
 		return map[string]any{"ok": true}, nil
 	}})
-	a := &app{flowV2: newFlowV2Runtime(nil), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
 	wf := flow.Workflow{
 		ID:      "wf_guard",
 		Name:    "Guard",
@@ -337,7 +340,7 @@ func TestExecuteFlowV2RunGuardSkip(t *testing.T) {
 		}},
 	}
 	plan, _ := flow.CompileWorkflow(wf)
-	runID := a.flowV2.createRun(0, wf.ID, nil)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
 	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
 
 	events, status, ok := a.flowV2.getRunEvents(0, runID)
@@ -355,13 +358,275 @@ func TestExecuteFlowV2RunGuardSkip(t *testing.T) {
 	}
 }
 
+func TestExecuteFlowV2RunIfElseBranches(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var called []string
+	record := func(name string) runtimeTestTool {
+		return runtimeTestTool{name: name, callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			mu.Lock()
+			called = append(called, name)
+			mu.Unlock()
+			return map[string]any{"ok": true, "from": name}, nil
+		}}
+	}
+	reg := newRuntimeStubRegistry(record("big"), record("small"), record("after_small"), record("join"))
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
+	wf := flow.Workflow{
+		ID:      "wf_if",
+		Name:    "If",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{
+			{ID: "check", Name: "Check", Kind: flow.NodeKindLogic, Type: "if", Inputs: map[string]flow.InputBinding{
+				"condition": {Expression: "={{ $run.input.count > 3 }}"},
+			}},
+			{ID: "big", Name: "Big", Kind: flow.NodeKindAction, Type: "tool", Tool: "big"},
+			{ID: "small", Name: "Small", Kind: flow.NodeKindAction, Type: "tool", Tool: "small"},
+			{ID: "after_small", Name: "After Small", Kind: flow.NodeKindAction, Type: "tool", Tool: "after_small"},
+			{ID: "join", Name: "Join", Kind: flow.NodeKindAction, Type: "tool", Tool: "join"},
+		},
+		Edges: []flow.Edge{
+			{Source: flow.PortRef{NodeID: "check", Port: flow.PortTrue}, Target: flow.PortRef{NodeID: "big", Port: "input"}},
+			{Source: flow.PortRef{NodeID: "check", Port: flow.PortFalse}, Target: flow.PortRef{NodeID: "small", Port: "input"}},
+			{Source: flow.PortRef{NodeID: "small", Port: "result"}, Target: flow.PortRef{NodeID: "after_small", Port: "input"}},
+			{Source: flow.PortRef{NodeID: "big", Port: "result"}, Target: flow.PortRef{NodeID: "join", Port: "big"}},
+			{Source: flow.PortRef{NodeID: "after_small", Port: "result"}, Target: flow.PortRef{NodeID: "join", Port: "small"}},
+		},
+	}
+	plan, diags := flow.CompileWorkflow(wf)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
+	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, map[string]any{"count": 5})
+
+	events, status, _ := a.flowV2.getRunEvents(0, runID)
+	if status != "completed" {
+		t.Fatalf("expected completed status, got %s with events=%+v", status, events)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(called, ",") != "big,join" {
+		t.Fatalf("expected only the true branch and join to run, got %v", called)
+	}
+	skipped := map[string]bool{}
+	for _, ev := range events {
+		if ev.Type == flow.RunEventTypeNodeSkipped {
+			skipped[ev.NodeID] = true
+		}
+	}
+	if !skipped["small"] || !skipped["after_small"] || len(skipped) != 2 {
+		t.Fatalf("expected the false branch to be skipped, got %v", skipped)
+	}
+}
+
+func TestExecuteFlowV2RunOnFailureBranch(t *testing.T) {
+	t.Parallel()
+
+	var handledErr string
+	reg := newRuntimeStubRegistry(
+		runtimeTestTool{name: "flaky", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			return map[string]any{"ok": false, "error": "upstream down"}, nil
+		}},
+		runtimeTestTool{name: "notify", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			var args map[string]any
+			_ = json.Unmarshal(raw, &args)
+			handledErr, _ = args["reason"].(string)
+			return map[string]any{"ok": true}, nil
+		}},
+		runtimeTestTool{name: "next", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			t.Error("success path should not run after a failure")
+			return map[string]any{"ok": true}, nil
+		}},
+	)
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
+	wf := flow.Workflow{
+		ID:      "wf_on_failure",
+		Name:    "On Failure",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{
+			{ID: "flaky", Name: "Flaky", Kind: flow.NodeKindAction, Type: "tool", Tool: "flaky"},
+			{ID: "next", Name: "Next", Kind: flow.NodeKindAction, Type: "tool", Tool: "next"},
+			{ID: "notify", Name: "Notify", Kind: flow.NodeKindAction, Type: "tool", Tool: "notify", Inputs: map[string]flow.InputBinding{
+				"reason": {Expression: "={{ $node.flaky.output.error }}"},
+			}},
+		},
+		Edges: []flow.Edge{
+			{Source: flow.PortRef{NodeID: "flaky", Port: "result"}, Target: flow.PortRef{NodeID: "next", Port: "input"}},
+			{Source: flow.PortRef{NodeID: "flaky", Port: flow.PortOnFailure}, Target: flow.PortRef{NodeID: "notify", Port: "failure"}},
+		},
+	}
+	plan, _ := flow.CompileWorkflow(wf)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
+	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
+
+	events, status, _ := a.flowV2.getRunEvents(0, runID)
+	if status != "completed" {
+		t.Fatalf("expected handled failure to complete the run, got %s with events=%+v", status, events)
+	}
+	if !strings.Contains(handledErr, "upstream down") {
+		t.Fatalf("expected on_failure node to see the error, got %q", handledErr)
+	}
+}
+
+func TestExecuteFlowV2RunForEach(t *testing.T) {
+	t.Parallel()
+
+	reg := newRuntimeStubRegistry(runtimeTestTool{name: "fetch", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var args map[string]any
+		_ = json.Unmarshal(raw, &args)
+		return map[string]any{"ok": true, "url": args["url"], "index": args["index"]}, nil
+	}})
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
+	wf := flow.Workflow{
+		ID:      "wf_for_each",
+		Name:    "For Each",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{{
+			ID:      "fetch",
+			Name:    "Fetch",
+			Kind:    flow.NodeKindAction,
+			Type:    "tool",
+			Tool:    "fetch",
+			ForEach: "={{ $run.input.pages }}",
+			Inputs: map[string]flow.InputBinding{
+				"url":   {Expression: "={{ $item.url }}"},
+				"index": {Expression: "={{ $index }}"},
+			},
+		}},
+	}
+	plan, _ := flow.CompileWorkflow(wf)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
+	input := map[string]any{"pages": []any{
+		map[string]any{"url": "https://a.example"},
+		map[string]any{"url": "https://b.example"},
+	}}
+	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, input)
+
+	events, status, _ := a.flowV2.getRunEvents(0, runID)
+	if status != "completed" {
+		t.Fatalf("expected completed status, got %s with events=%+v", status, events)
+	}
+	var output map[string]any
+	for _, ev := range events {
+		if ev.Type == flow.RunEventTypeNodeCompleted && ev.NodeID == "fetch" {
+			output = ev.Output
+		}
+	}
+	items, _ := output["items"].([]any)
+	if len(items) != 2 {
+		t.Fatalf("expected two iterations, got %+v", output)
+	}
+	second, _ := items[1].(map[string]any)
+	if second["url"] != "https://b.example" || second["index"] != float64(1) {
+		t.Fatalf("unexpected second iteration output %+v", second)
+	}
+}
+
+func TestExecuteFlowV2RunRetriesTimedOutAttempts(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	calls := 0
+	reg := newRuntimeStubRegistry(runtimeTestTool{name: "flaky_fetch", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n < 3 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return map[string]any{"ok": true}, nil
+	}})
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
+	wf := flow.Workflow{
+		ID:      "wf_retry",
+		Name:    "Retry",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{{
+			ID:   "fetch",
+			Name: "Fetch",
+			Kind: flow.NodeKindAction,
+			Type: "tool",
+			Tool: "flaky_fetch",
+			Execution: flow.NodeExecution{
+				Timeout: "20ms",
+				Retries: flow.RetryPolicy{Max: 2, Backoff: flow.BackoffExponential, Delay: "5ms", MaxDelay: "8ms"},
+			},
+		}},
+	}
+	plan, _ := flow.CompileWorkflow(wf)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
+	a.executeFlowV2Run(context.Background(), 0, runID, wf, plan, nil)
+
+	events, status, _ := a.flowV2.getRunEvents(0, runID)
+	if status != "completed" {
+		t.Fatalf("expected completed status after retries, got %s with events=%+v", status, events)
+	}
+	var retries []flow.RunEvent
+	for _, ev := range events {
+		if ev.Type == flow.RunEventTypeNodeRetrying {
+			retries = append(retries, ev)
+		}
+	}
+	if len(retries) != 2 {
+		t.Fatalf("expected two node_retrying events, got %+v", retries)
+	}
+	if retries[0].Message != "retry 1/2 in 5ms" || retries[1].Message != "retry 2/2 in 8ms" {
+		t.Fatalf("unexpected retry messages %q, %q", retries[0].Message, retries[1].Message)
+	}
+	if !strings.Contains(retries[0].Error, "timed out after 20ms") {
+		t.Fatalf("expected timeout in retry error, got %q", retries[0].Error)
+	}
+}
+
+func TestFlowRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	node := flow.Node{Execution: flow.NodeExecution{Retries: flow.RetryPolicy{Backoff: flow.BackoffExponential}}}
+	defaults := flow.NodeExecution{Retries: flow.RetryPolicy{Delay: "1s", MaxDelay: "3s"}}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for i, w := range want {
+		if got := flowRetryDelay(node, defaults, i+1); got != w {
+			t.Fatalf("attempt %d: got %s want %s", i+1, got, w)
+		}
+	}
+	if got := flowRetryDelay(flow.Node{}, flow.NodeExecution{}, 3); got != 200*time.Millisecond {
+		t.Fatalf("expected fixed 200ms default, got %s", got)
+	}
+}
+
+func TestEvalFlowExpressionConditions(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{"count": 5.0, "status": "open", "note": "a && b"}
+	outputs := map[string]map[string]any{"check": {"ok": true}}
+	cases := map[string]bool{
+		"={{ $run.input.count > 3 }}":                           true,
+		"$run.input.count >= 5 && $node.check.output.ok":        true,
+		"$run.input.count < 5 || $run.input.status == \"open\"": true,
+		"$run.input.status != \"open\"":                         false,
+		"!$node.check.output.ok":                                false,
+		"$run.input.note == \"a && b\"":                         true,
+		"$run.input.count <= 4":                                 false,
+	}
+	for expr, want := range cases {
+		got, err := evalFlowExpression(expr, input, outputs)
+		if err != nil || got != want {
+			t.Errorf("%s: got %v (err %v), want %v", expr, got, err, want)
+		}
+	}
+}
+
 func TestExecuteFlowV2RunUnknownPlannedNodeFailsWithoutHang(t *testing.T) {
 	t.Parallel()
 
 	reg := newRuntimeStubRegistry(runtimeTestTool{name: "root", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
 		return map[string]any{"ok": true}, nil
 	}})
-	a := &app{flowV2: newFlowV2Runtime(nil), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(nil, nil), baseToolRegistry: reg, toolRegistry: reg}
 	wf := flow.Workflow{
 		ID:      "wf_unknown_node",
 		Name:    "Unknown Node",
@@ -395,7 +660,7 @@ func TestExecuteFlowV2RunUnknownPlannedNodeFailsWithoutHang(t *testing.T) {
 		},
 	}
 
-	runID := a.flowV2.createRun(0, wf.ID, nil)
+	runID := a.flowV2.createRun(0, wf.ID, persist.WorkflowTriggerAPI, nil)
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
@@ -465,3 +730,57 @@ func assertMonotonicSequences(t *testing.T, events []flow.RunEvent) {
 		}
 	}
 }
+
+func TestFlowV2RunRecordedInHistory(t *testing.T) {
+	t.Parallel()
+
+	reg := newRuntimeStubRegistry(
+		runtimeTestTool{name: "ok", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			return map[string]any{"ok": true}, nil
+		}},
+		runtimeTestTool{name: "boom", callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			return nil, errors.New("boom")
+		}},
+	)
+	history := databases.NewWorkflowRunStore(nil)
+	a := &app{flowV2: newFlowV2Runtime(nil, history), baseToolRegistry: reg, toolRegistry: reg}
+	wf := flow.Workflow{
+		ID:      "wf_history",
+		Name:    "History",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{
+			{ID: "first", Name: "First", Kind: flow.NodeKindAction, Type: "tool", Tool: "ok"},
+			{ID: "second", Name: "Second", Kind: flow.NodeKindAction, Type: "tool", Tool: "boom", Execution: flow.NodeExecution{OnError: flow.ErrorStrategyFail}},
+		},
+		Edges: []flow.Edge{
+			{Source: flow.PortRef{NodeID: "first", Port: "result"}, Target: flow.PortRef{NodeID: "second", Port: "input"}},
+		},
+	}
+	plan, _ := flow.CompileWorkflow(wf)
+	ctx := context.Background()
+	runID := a.flowV2.createRun(7, wf.ID, persist.WorkflowTriggerWebhook, map[string]any{"ticket": "T-1"})
+
+	rec, found, err := history.Get(ctx, 7, runID)
+	if err != nil || !found || rec.Status != "running" {
+		t.Fatalf("expected running record at start, got %+v found=%v err=%v", rec, found, err)
+	}
+
+	a.executeFlowV2Run(ctx, 7, runID, wf, plan, nil)
+
+	rec, _, _ = history.Get(ctx, 7, runID)
+	if rec.Status != "failed" || rec.Trigger != persist.WorkflowTriggerWebhook || rec.Input["ticket"] != "T-1" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	if rec.EndedAt.IsZero() || rec.EndedAt.Before(rec.StartedAt) {
+		t.Fatalf("expected end time after start, got %+v", rec)
+	}
+	if len(rec.Steps) != 2 || rec.Steps[0].NodeID != "first" || rec.Steps[0].Status != "completed" {
+		t.Fatalf("unexpected steps %+v", rec.Steps)
+	}
+	if rec.Steps[1].Status != "failed" || !strings.Contains(rec.Steps[1].Error, "boom") || rec.Steps[1].Attempts != 1 {
+		t.Fatalf("unexpected failed step %+v", rec.Steps[1])
+	}
+	if runs, _ := history.List(ctx, 7, persist.WorkflowRunFilter{WorkflowID: wf.ID}); len(runs) != 1 {
+		t.Fatalf("expected one run in history, got %d", len(runs))
+	}
+}
@@ -0,0 +1,171 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"manifold/internal/grpcapi"
+	"manifold/internal/llm"
+	persist "manifold/internal/persistence"
+	"manifold/internal/sandbox"
+	"manifold/internal/tools/warpptool"
+)
+
+// grpcBackend serves the gRPC API from the same engine, chat store and
+// workflow runtime as the HTTP handlers.
+type grpcBackend struct {
+	a *app
+}
+
+func (b grpcBackend) Authenticate(ctx context.Context, token string) (int64, error) {
+	if !b.a.cfg.Auth.Enabled {
+		return systemUserID, nil
+	}
+	if token == "" || b.a.authStore == nil {
+		return 0, grpcapi.ErrUnauthenticated
+	}
+	_, user, err := b.a.authStore.GetSession(ctx, token)
+	if err != nil || user == nil {
+		return 0, grpcapi.ErrUnauthenticated
+	}
+	return user.ID, nil
+}
+
+// storeUser is the owner chat storage is scoped to: nil when auth is off,
+// as in the HTTP handlers.
+func (b grpcBackend) storeUser(userID int64) *int64 {
+	if !b.a.cfg.Auth.Enabled {
+		return nil
+	}
+	return &userID
+}
+
+func (b grpcBackend) RunAgent(ctx context.Context, userID int64, req *grpcapi.RunAgentRequest, send func(*grpcapi.RunAgentEvent) error) error {
+	a := b.a
+	prompt := strings.TrimSpace(req.GetPrompt())
+	sessionID := strings.TrimSpace(req.GetSessionId())
+	if sessionID == "" {
+		sessionID = "default"
+	}
+	storeUser := b.storeUser(userID)
+	if _, err := ensureChatSession(ctx, a.chatStore, storeUser, sessionID); err != nil {
+		if errors.Is(err, persist.ErrForbidden) {
+			return status.Error(codes.PermissionDenied, "forbidden")
+		}
+		return status.Error(codes.Internal, "failed to open session")
+	}
+
+	runCtx, err := a.withScratchDir(llm.WithUserID(sandbox.WithSessionID(ctx, sessionID), userID), userID)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to prepare workspace")
+	}
+	runCtx, cancel, _ := withMaybeTimeout(runCtx, a.cfg.StreamRunTimeoutSeconds)
+	defer cancel()
+
+	var sendErr error
+	forward := func(runID string) func([]byte) {
+		return func(raw []byte) {
+			if sendErr == nil {
+				sendErr = send(grpcRunEvent(runID, raw))
+			}
+		}
+	}
+	if res := a.guardrails.CheckPrompt(runCtx, prompt); !res.Allowed {
+		res.Message = a.localizeGuardrailMessage(runCtx, res.Message)
+		newChatBroadcastWriter(forward("")).write(guardrailViolationPayload(res))
+		return sendErr
+	}
+	quotaLimits, err := a.runQuota(runCtx, userID)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	releaseRun, err := a.runQueue.acquire(runCtx, func(position int) {
+		if raw, err := json.Marshal(map[string]any{"type": "queued", "position": position}); err == nil {
+			forward("")(raw)
+		}
+	})
+	if err != nil {
+		if isRunQueueRejection(err) {
+			return status.Error(codes.ResourceExhausted, "server is busy, try again later")
+		}
+		return status.FromContextError(err).Err()
+	}
+	defer releaseRun()
+	build := a.buildOrchestratorChatEngine(runCtx, userID, sessionID, "", nil)
+	if build.Err != nil {
+		return status.Error(codes.Unavailable, build.Err.Error())
+	}
+	history, summary, err := a.chatMemory.BuildContextForProvider(runCtx, storeUser, sessionID, providerSupportsCompaction(build.Engine.LLM))
+	if err != nil {
+		log.Error().Err(err).Str("session", sessionID).Msg("load_chat_history")
+		return status.Error(codes.Internal, "failed to load chat history")
+	}
+	a.recordRun(runCtx, userID, "grpc:RunAgent")
+	a.attachQuotaCheck(build.Engine, userID, quotaLimits)
+	run := a.runs.create(prompt)
+	a.streamChatTurn(nil, newChatBroadcastWriter(forward(run.ID)), runCtx, build.Engine, chatRunRequest{Prompt: prompt, SessionID: sessionID}, history, run.ID, storeUser, nil, chatStreamOptions{
+		Endpoint:           "grpc:RunAgent",
+		EmitThoughtSummary: true,
+		EmitSummaryEvents:  true,
+		StructuredErrors:   true,
+		StoreModel:         build.ModelLabel,
+		InitialSummary:     summary,
+	})
+	return sendErr
+}
+
+// grpcRunEvent lifts the common fields out of an /agent/run stream event
+// and keeps the whole event in Raw.
+func grpcRunEvent(runID string, raw []byte) *grpcapi.RunAgentEvent {
+	var fields map[string]any
+	_ = json.Unmarshal(raw, &fields)
+	ev := &grpcapi.RunAgentEvent{RunId: runID, Raw: string(raw)}
+	ev.Type, _ = fields["type"].(string)
+	ev.ToolId, _ = fields["tool_id"].(string)
+	ev.Title, _ = fields["title"].(string)
+	switch data := fields["data"].(type) {
+	case nil:
+	case string:
+		ev.Data = data
+	default:
+		b, _ := json.Marshal(data)
+		ev.Data = string(b)
+	}
+	return ev
+}
+
+func (b grpcBackend) ListSessions(ctx context.Context, userID int64) ([]*grpcapi.Session, error) {
+	sessions, err := b.a.chatStore.ListSessions(ctx, b.storeUser(userID))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list sessions")
+	}
+	out := make([]*grpcapi.Session, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, &grpcapi.Session{
+			Id:                 s.ID,
+			Name:               s.Name,
+			LastMessagePreview: s.LastMessagePreview,
+			Model:              s.Model,
+			UpdatedAt:          s.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return out, nil
+}
+
+func (b grpcBackend) ExecuteWorkflow(ctx context.Context, userID int64, workflowID string, input map[string]any) (map[string]any, error) {
+	result, err := b.a.ExecuteWorkflowSync(ctx, userID, workflowID, input)
+	if err != nil {
+		if errors.Is(err, warpptool.ErrWorkflowNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package agentd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/tools/cli"
+)
+
+// cliArtifactHandler serves GET /api/artifacts/cli/{id}: the full output of a
+// run_cli call whose stdout or stderr was truncated. Users only see their own
+// artifacts.
+func (a *app) cliArtifactHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		userID, err := a.requireUserID(r)
+		if err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if a.cliArtifacts == nil {
+			http.NotFound(w, r)
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/artifacts/cli/"), "/")
+		f, err := a.cliArtifacts.Open(userID, id)
+		if errors.Is(err, cli.ErrArtifactNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("artifact", id).Msg("cli_artifact_open_failed")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := io.Copy(w, f); err != nil {
+			log.Warn().Err(err).Str("artifact", id).Msg("cli_artifact_write_failed")
+		}
+	}
+}
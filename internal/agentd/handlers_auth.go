@@ -7,6 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 )
 
@@ -36,13 +39,6 @@ func (a *app) authLogoutHandler() http.HandlerFunc {
 	}
 	logout := a.authProvider.LogoutHandler(a.cfg.Auth.CookieSecure, a.cfg.Auth.CookieDomain)
 	return func(w http.ResponseWriter, r *http.Request) {
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		}
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -57,7 +53,30 @@ func (a *app) meHandler() http.HandlerFunc {
 			http.NotFound(w, r)
 		}
 	}
-	return a.authProvider.MeHandler()
+	me := a.authProvider.MeHandler()
+	if !a.cfg.Quotas.Enabled {
+		return me
+	}
+	// With quotas on, /api/me also reports the caller's remaining quota.
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := auth.CurrentUser(r.Context())
+		if !ok {
+			me(w, r)
+			return
+		}
+		quota, err := a.quotaStatus(r.Context(), u.ID, a.quotaLimits(r.Context(), u.ID), time.Now())
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", u.ID).Msg("quota status failed")
+			apierror.Respond(w, http.StatusInternalServerError, "quota lookup failed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"email":   u.Email,
+			"name":    u.Name,
+			"picture": u.Picture,
+			"quota":   quota,
+		})
+	}
 }
 
 func (a *app) usersHandler() http.HandlerFunc {
@@ -69,19 +88,10 @@ func (a *app) usersHandler() http.HandlerFunc {
 		if a.cfg.Auth.Enabled {
 			if _, ok := auth.CurrentUser(r.Context()); !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-		} else {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -92,16 +102,16 @@ func (a *app) usersHandler() http.HandlerFunc {
 			if u, ok := auth.CurrentUser(r.Context()); ok {
 				okRole, _ := a.authStore.HasRole(r.Context(), u.ID, "admin")
 				if !okRole {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 			} else {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			users, err := a.authStore.ListUsers(r.Context())
 			if err != nil {
-				http.Error(w, "error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "error")
 				return
 			}
 			type userOut struct {
@@ -129,11 +139,11 @@ func (a *app) usersHandler() http.HandlerFunc {
 			if u, ok := auth.CurrentUser(r.Context()); ok {
 				okRole, _ := a.authStore.HasRole(r.Context(), u.ID, "admin")
 				if !okRole {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 			} else {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
@@ -142,14 +152,13 @@ func (a *app) usersHandler() http.HandlerFunc {
 				Email, Name, Picture, Provider, Subject string
 				Roles                                   []string
 			}
-			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &in, 0) {
 				return
 			}
 			u := &auth.User{Email: in.Email, Name: in.Name, Picture: in.Picture, Provider: in.Provider, Subject: in.Subject}
 			usr, err := a.authStore.UpsertUser(r.Context(), u)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			_ = a.authStore.SetUserRoles(r.Context(), usr.ID, in.Roles)
@@ -161,7 +170,7 @@ func (a *app) usersHandler() http.HandlerFunc {
 				"updated_at": usr.UpdatedAt, "roles": roles,
 			})
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -175,7 +184,7 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 		if a.cfg.Auth.Enabled {
 			if _, ok := auth.CurrentUser(r.Context()); !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
@@ -187,7 +196,7 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 		}
 		var id int64
 		if _, err := fmt.Sscan(idStr, &id); err != nil {
-			http.Error(w, "bad id", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "bad id")
 			return
 		}
 
@@ -202,12 +211,12 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 		switch r.Method {
 		case http.MethodGet:
 			if !isAdmin {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			u, err := a.authStore.GetUserByID(r.Context(), id)
 			if err != nil {
-				http.Error(w, "not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "not found")
 				return
 			}
 			roles, _ := a.authStore.RolesForUser(r.Context(), u.ID)
@@ -219,7 +228,7 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 			})
 		case http.MethodPut:
 			if !isAdmin {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
@@ -228,13 +237,12 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 				Email, Name, Picture, Provider, Subject string
 				Roles                                   []string
 			}
-			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &in, 0) {
 				return
 			}
 			u := &auth.User{ID: id, Email: in.Email, Name: in.Name, Picture: in.Picture, Provider: in.Provider, Subject: in.Subject}
 			if err := a.authStore.UpdateUser(r.Context(), u); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			_ = a.authStore.SetUserRoles(r.Context(), id, in.Roles)
@@ -246,16 +254,16 @@ func (a *app) userDetailHandler() http.HandlerFunc {
 			})
 		case http.MethodDelete:
 			if !isAdmin {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			if err := a.authStore.DeleteUser(r.Context(), id); err != nil {
-				http.Error(w, "error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "error")
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
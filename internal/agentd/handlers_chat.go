@@ -9,26 +9,29 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"manifold/internal/agent"
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 	"manifold/internal/llm"
 	persist "manifold/internal/persistence"
+	"manifold/internal/specialists"
 	"manifold/internal/workspaces"
 )
 
 type agentStreamTracer struct {
 	w  io.Writer
 	fl http.Flusher
-	mu *sync.Mutex
+	// stream, once the run's writer exists, carries trace events so they
+	// share its event ids and locking.
+	stream *chatSSEWriter
 }
 
 func (t *agentStreamTracer) Trace(ev agent.AgentTrace) {
-	if t == nil || t.w == nil || t.fl == nil {
+	if t == nil || (t.stream == nil && (t.w == nil || t.fl == nil)) {
 		return
 	}
 	payload := map[string]any{
@@ -47,14 +50,14 @@ func (t *agentStreamTracer) Trace(ev agent.AgentTrace) {
 		"error":           ev.Error,
 		"thought_summary": ev.ThoughtSummary,
 	}
+	if t.stream != nil {
+		t.stream.write(payload)
+		return
+	}
 	b, err := json.Marshal(payload)
 	if err != nil {
 		return
 	}
-	if t.mu != nil {
-		t.mu.Lock()
-		defer t.mu.Unlock()
-	}
 	fmt.Fprintf(t.w, "data: %s\n\n", b)
 	t.fl.Flush()
 }
@@ -64,15 +67,14 @@ func (a *app) runsHandler() http.HandlerFunc {
 		if a.cfg.Auth.Enabled {
 			if _, ok := auth.CurrentUser(r.Context()); !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Content-Type", "application/json")
 
 		// Prefer ClickHouse-backed runs when available so the UI persists across restarts.
@@ -100,13 +102,13 @@ func (a *app) chatSessionsHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			id, admin, err := resolveChatAccess(r.Context(), a.authStore, u)
 			if err != nil {
 				log.Error().Err(err).Msg("resolve_chat_access")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			userID, isAdmin = id, admin
@@ -114,7 +116,6 @@ func (a *app) chatSessionsHandler() http.HandlerFunc {
 			isAdmin = true
 		}
 		_ = isAdmin
-		setChatCORSHeaders(w, r, "GET, POST, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -124,7 +125,7 @@ func (a *app) chatSessionsHandler() http.HandlerFunc {
 			sessions, err := a.chatStore.ListSessions(r.Context(), userID)
 			if err != nil {
 				log.Error().Err(err).Msg("list_chat_sessions")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -136,18 +137,17 @@ func (a *app) chatSessionsHandler() http.HandlerFunc {
 			var body struct {
 				Name string `json:"name"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.DecodeOptional(w, r, &body, 0) {
 				return
 			}
 			sess, err := a.chatStore.CreateSession(r.Context(), userID, body.Name)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				log.Error().Err(err).Msg("create_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -156,7 +156,7 @@ func (a *app) chatSessionsHandler() http.HandlerFunc {
 				log.Error().Err(err).Msg("encode_chat_session")
 			}
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -171,13 +171,13 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			id, admin, err := resolveChatAccess(r.Context(), a.authStore, u)
 			if err != nil {
 				log.Error().Err(err).Msg("resolve_chat_access")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			userID, isAdmin = id, admin
@@ -201,29 +201,41 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 		if len(parts) >= 3 {
 			subresourceID = parts[2]
 		}
-		switch subresource {
-		case "messages":
-			setChatCORSHeaders(w, r, "GET, DELETE, OPTIONS")
-		case "title":
-			setChatCORSHeaders(w, r, "POST, OPTIONS")
-		default:
-			setChatCORSHeaders(w, r, "GET, PATCH, DELETE, OPTIONS")
+		rewindAction := ""
+		if subresource == "messages" && len(parts) == 4 && (parts[3] == "edit" || parts[3] == "regenerate") {
+			rewindAction = parts[3]
 		}
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
+		if rewindAction != "" {
+			a.handleChatMessageRewind(w, r, userID, id, subresourceID, rewindAction == "regenerate")
+			return
+		}
+		if subresource == "fork" {
+			a.handleChatSessionFork(w, r, userID, id)
+			return
+		}
+		if subresource == "live" && subresourceID == "" {
+			a.handleLiveSession(w, r, userID, id)
+			return
+		}
+		if subresource == "participants" && len(parts) <= 3 {
+			a.handleLiveParticipants(w, r, userID, id, subresourceID)
+			return
+		}
 		if subresource == "messages" {
 			if subresourceID != "" {
 				if r.Method != http.MethodDelete {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 					return
 				}
 				// Load messages to determine summary impact and related tool outputs.
 				msgs, err := a.chatStore.ListMessages(r.Context(), userID, id, 0)
 				if err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -231,7 +243,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("list_chat_messages")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				msgIndex := -1
@@ -250,7 +262,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				sess, err := a.chatStore.GetSession(r.Context(), userID, id)
 				if err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -258,7 +270,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("get_chat_session")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				relatedMessageIDs := relatedToolMessageIDs(msgs, target)
@@ -266,7 +278,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				if atomicStore, ok := a.chatStore.(atomicChatTurnDeleteStore); ok {
 					if err := atomicStore.DeleteMessageWithRelated(r.Context(), userID, id, subresourceID, relatedMessageIDs, resetSummary); err != nil {
 						if errors.Is(err, persist.ErrForbidden) {
-							http.Error(w, "forbidden", http.StatusForbidden)
+							apierror.Respond(w, http.StatusForbidden, "forbidden")
 							return
 						}
 						if errors.Is(err, persist.ErrNotFound) {
@@ -274,7 +286,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 							return
 						}
 						log.Error().Err(err).Str("session", id).Msg("delete_chat_message")
-						http.Error(w, "internal server error", http.StatusInternalServerError)
+						apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 						return
 					}
 
@@ -285,7 +297,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				// Delete target message first.
 				if err := a.chatStore.DeleteMessage(r.Context(), userID, id, subresourceID); err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -293,7 +305,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("delete_chat_message")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 
@@ -324,7 +336,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			if r.Method == http.MethodDelete {
 				afterID := strings.TrimSpace(r.URL.Query().Get("after"))
 				if afterID == "" {
-					http.Error(w, "missing after", http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, "missing after")
 					return
 				}
 				inclusive := false
@@ -336,7 +348,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				msgs, err := a.chatStore.ListMessages(r.Context(), userID, id, 0)
 				if err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -344,7 +356,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("list_chat_messages")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				msgIndex := -1
@@ -363,7 +375,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				sess, err := a.chatStore.GetSession(r.Context(), userID, id)
 				if err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -371,7 +383,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("get_chat_session")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 
@@ -387,7 +399,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 				if atomicStore, ok := a.chatStore.(atomicChatTurnDeleteStore); ok {
 					if err := atomicStore.DeleteMessagesAfterWithRelated(r.Context(), userID, id, afterID, inclusive, relatedMessageIDs, resetSummary); err != nil {
 						if errors.Is(err, persist.ErrForbidden) {
-							http.Error(w, "forbidden", http.StatusForbidden)
+							apierror.Respond(w, http.StatusForbidden, "forbidden")
 							return
 						}
 						if errors.Is(err, persist.ErrNotFound) {
@@ -395,7 +407,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 							return
 						}
 						log.Error().Err(err).Str("session", id).Msg("delete_chat_messages_after")
-						http.Error(w, "internal server error", http.StatusInternalServerError)
+						apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 						return
 					}
 
@@ -405,7 +417,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 
 				if err := a.chatStore.DeleteMessagesAfter(r.Context(), userID, id, afterID, inclusive); err != nil {
 					if errors.Is(err, persist.ErrForbidden) {
-						http.Error(w, "forbidden", http.StatusForbidden)
+						apierror.Respond(w, http.StatusForbidden, "forbidden")
 						return
 					}
 					if errors.Is(err, persist.ErrNotFound) {
@@ -413,7 +425,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 						return
 					}
 					log.Error().Err(err).Str("session", id).Msg("delete_chat_messages_after")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 
@@ -443,7 +455,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			}
 
 			if r.Method != http.MethodGet {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			limit := 0
@@ -455,7 +467,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			msgs, err := a.chatStore.ListMessages(r.Context(), userID, id, limit)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -463,7 +475,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("list_chat_messages")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			msgs = hydrateChatMessages(msgs)
@@ -475,26 +487,25 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 		}
 		if subresource == "title" {
 			if r.Method != http.MethodPost {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			defer r.Body.Close()
 			var body struct {
 				Prompt string `json:"prompt"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &body, 0) {
 				return
 			}
 			prompt := strings.TrimSpace(body.Prompt)
 			if prompt == "" {
-				http.Error(w, "prompt required", http.StatusBadRequest)
+				apierror.RespondInvalid(w, "prompt", "required")
 				return
 			}
 			sess, err := a.chatStore.GetSession(r.Context(), userID, id)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -502,7 +513,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("get_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if !isDefaultSessionName(sess.Name) {
@@ -519,7 +530,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			updated, err := a.chatStore.RenameSession(r.Context(), userID, id, title)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -527,7 +538,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("rename_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -536,12 +547,40 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			}
 			return
 		}
+		if subresource == "graph" {
+			if r.Method != http.MethodGet {
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			sess, err := a.chatStore.GetSession(r.Context(), userID, id)
+			if err != nil {
+				if errors.Is(err, persist.ErrForbidden) {
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
+					return
+				}
+				if errors.Is(err, persist.ErrNotFound) {
+					http.NotFound(w, r)
+					return
+				}
+				log.Error().Err(err).Str("session", id).Msg("get_chat_session")
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			owner := systemUserID
+			if userID != nil {
+				owner = *userID
+			} else if sess.UserID != nil {
+				owner = *sess.UserID
+			}
+			a.handleChatSessionGraph(w, r, sess, owner)
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			sess, err := a.chatStore.GetSession(r.Context(), userID, id)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -549,7 +588,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("get_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -561,14 +600,13 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 			var body struct {
 				Name string `json:"name"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &body, 0) {
 				return
 			}
 			sess, err := a.chatStore.RenameSession(r.Context(), userID, id, body.Name)
 			if err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -576,7 +614,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("rename_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -586,7 +624,7 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 		case http.MethodDelete:
 			if err := a.chatStore.DeleteSession(r.Context(), userID, id); err != nil {
 				if errors.Is(err, persist.ErrForbidden) {
-					http.Error(w, "forbidden", http.StatusForbidden)
+					apierror.Respond(w, http.StatusForbidden, "forbidden")
 					return
 				}
 				if errors.Is(err, persist.ErrNotFound) {
@@ -594,12 +632,12 @@ func (a *app) chatSessionDetailHandler() http.HandlerFunc {
 					return
 				}
 				log.Error().Err(err).Str("session", id).Msg("delete_chat_session")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -621,7 +659,12 @@ func hydrateChatMessages(raw []persist.ChatMessage) []persist.ChatMessage {
 		m := msg
 		trimmed := strings.TrimSpace(m.Content)
 
-		if m.Role == "assistant" && strings.HasPrefix(trimmed, "{") {
+		if h, ok := specialists.ParseHandoff(m); ok {
+			// Handoffs render as status lines in the chat pane.
+			m.Role = "status"
+			m.Title = persist.ChatRoleHandoff
+			m.Content = handoffNotice(h)
+		} else if m.Role == "assistant" && strings.HasPrefix(trimmed, "{") {
 			var data struct {
 				Content   string         `json:"content"`
 				ToolCalls []llm.ToolCall `json:"tool_calls"`
@@ -743,6 +786,18 @@ func toolIDFromMessage(msg persist.ChatMessage) string {
 
 func (a *app) agentRunHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if id := lastEventID(r); id != "" && r.Method == http.MethodPost {
+			// A client reconnecting mid-run: replay the run's ending
+			// instead of starting it again.
+			owner, err := a.requireUserID(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			a.resumeSSE(w, r, owner, id)
+			return
+		}
 		req, ok := prepareChatTransport(w, r, chatTransportOptions{})
 		if !ok {
 			return
@@ -785,7 +840,6 @@ func (a *app) commitWorkspace(ctx context.Context, ws *workspaces.Workspace) {
 func (a *app) promptHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req, ok := prepareChatTransport(w, r, chatTransportOptions{
-			EnablePromptCORS: true,
 			MaxBodyBytes:     64 * 1024,
 			DecodeErrorLabel: "decode prompt",
 		})
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"manifold/internal/apierror"
 )
 
 // agentdSettings mirrors the frontend AgentdSettings shape.
@@ -67,7 +69,7 @@ func (a *app) agentdConfigHandler() http.HandlerFunc {
 			a.handleUpdateAgentdConfig(w, r)
 		default:
 			w.Header().Set("Allow", "GET, POST, PUT, PATCH")
-			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
 		}
 	}
 }
@@ -77,7 +79,7 @@ func (a *app) handleGetAgentdConfig(w http.ResponseWriter, r *http.Request) {
 	if a.cfg.Auth.Enabled {
 		if _, err := a.requireUserID(r); err != nil {
 			w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 	}
@@ -89,13 +91,12 @@ func (a *app) handleUpdateAgentdConfig(w http.ResponseWriter, r *http.Request) {
 	if a.cfg.Auth.Enabled {
 		if _, err := a.requireUserID(r); err != nil {
 			w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 	}
 	var payload agentdSettings
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !apierror.Decode(w, r, &payload, 0) {
 		return
 	}
 	payload = normalizeAgentdSettings(payload)
@@ -123,5 +124,5 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]any{"error": err.Error()})
+	apierror.Respond(w, status, err.Error())
 }
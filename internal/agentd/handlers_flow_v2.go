@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"manifold/internal/apierror"
 	"manifold/internal/flow"
 	persist "manifold/internal/persistence"
 	"manifold/internal/sandbox"
@@ -20,12 +22,12 @@ func (a *app) flowV2WorkflowsHandler() http.HandlerFunc {
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		workflows, err := a.flowV2State().listWorkflowSummaries(r.Context(), userID)
 		if err != nil {
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 		resp := flow.ListWorkflowsResponse{Workflows: workflows}
@@ -49,11 +51,11 @@ func (a *app) flowV2WorkflowDetailHandler() http.HandlerFunc {
 		case http.MethodGet:
 			wf, canvas, found, err := a.flowV2State().getWorkflow(r.Context(), userID, workflowID)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if !found {
-				http.Error(w, "workflow not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "workflow not found")
 				return
 			}
 			writeFlowV2JSON(w, http.StatusOK, flow.GetWorkflowResponse{
@@ -64,15 +66,14 @@ func (a *app) flowV2WorkflowDetailHandler() http.HandlerFunc {
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 			defer r.Body.Close()
 			var req flow.PutWorkflowRequest
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &req, 0) {
 				return
 			}
 			if strings.TrimSpace(req.Workflow.ID) == "" {
 				req.Workflow.ID = workflowID
 			}
 			if req.Workflow.ID != workflowID {
-				http.Error(w, "workflow id mismatch", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "workflow id mismatch")
 				return
 			}
 			diags := flow.ValidateWorkflow(req.Workflow)
@@ -83,9 +84,12 @@ func (a *app) flowV2WorkflowDetailHandler() http.HandlerFunc {
 				})
 				return
 			}
+			if !a.checkWorkflowEvalGate(w, r, req.Workflow) {
+				return
+			}
 			saved, created, err := a.flowV2State().upsertWorkflow(r.Context(), userID, req.Workflow, req.Canvas)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if userID == systemUserID {
@@ -102,11 +106,11 @@ func (a *app) flowV2WorkflowDetailHandler() http.HandlerFunc {
 		case http.MethodDelete:
 			deleted, err := a.flowV2State().deleteWorkflow(r.Context(), userID, workflowID)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if !deleted {
-				http.Error(w, "workflow not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "workflow not found")
 				return
 			}
 			if userID == systemUserID {
@@ -114,7 +118,7 @@ func (a *app) flowV2WorkflowDetailHandler() http.HandlerFunc {
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -125,15 +129,14 @@ func (a *app) flowV2ValidateHandler() http.HandlerFunc {
 			return
 		}
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 		defer r.Body.Close()
 
 		var req flow.ValidateRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 		plan, diags := flow.CompileWorkflow(req.Workflow)
@@ -146,6 +149,42 @@ func (a *app) flowV2ValidateHandler() http.HandlerFunc {
 	}
 }
 
+// flowV2DryRunHandler validates a workflow, inline or saved, and simulates
+// a run against sample input without calling any tool.
+func (a *app) flowV2DryRunHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := a.requireFlowV2User(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodPost {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+		defer r.Body.Close()
+
+		var req flow.ValidateRequest
+		if !apierror.Decode(w, r, &req, 0) {
+			return
+		}
+		wf := req.Workflow
+		if id := strings.TrimSpace(req.WorkflowID); id != "" {
+			saved, _, found, err := a.flowV2State().getWorkflow(r.Context(), userID, id)
+			if err != nil {
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if !found {
+				apierror.Respond(w, http.StatusNotFound, "workflow not found")
+				return
+			}
+			wf = saved
+		}
+		writeFlowV2JSON(w, http.StatusOK, a.dryRunFlowV2(wf, req.Input))
+	}
+}
+
 func (a *app) flowV2RunHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := a.requireFlowV2User(w, r)
@@ -153,28 +192,31 @@ func (a *app) flowV2RunHandler() http.HandlerFunc {
 			return
 		}
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
 		defer r.Body.Close()
 
 		var req flow.RunRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 		if strings.TrimSpace(req.WorkflowID) == "" {
-			http.Error(w, "workflow_id required", http.StatusBadRequest)
+			apierror.RespondInvalid(w, "workflow_id", "required")
 			return
 		}
 		wf, _, found, err := a.flowV2State().getWorkflow(r.Context(), userID, req.WorkflowID)
 		if err != nil {
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 		if !found {
-			http.Error(w, "workflow not found", http.StatusNotFound)
+			apierror.Respond(w, http.StatusNotFound, "workflow not found")
+			return
+		}
+		if req.DryRun {
+			writeFlowV2JSON(w, http.StatusOK, a.dryRunFlowV2(wf, req.Input))
 			return
 		}
 		plan, diags := flow.CompileWorkflow(wf)
@@ -190,20 +232,23 @@ func (a *app) flowV2RunHandler() http.HandlerFunc {
 		if p := strings.TrimSpace(req.ProjectID); p != "" {
 			cleanP := filepath.Clean(p)
 			if cleanP != p || strings.HasPrefix(cleanP, "..") || strings.Contains(cleanP, string(filepath.Separator)+"..") || filepath.IsAbs(cleanP) {
-				http.Error(w, "invalid project_id", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "invalid project_id")
 				return
 			}
 			baseRoot := filepath.Join(a.cfg.Workdir, "users", fmt.Sprint(userID), "projects")
 			base := filepath.Join(baseRoot, cleanP)
 			if !strings.HasPrefix(base, baseRoot+string(filepath.Separator)) && base != baseRoot {
-				http.Error(w, "invalid project_id", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "invalid project_id")
 				return
 			}
 			ctx = sandbox.WithBaseDir(ctx, base)
 			ctx = sandbox.WithProjectID(ctx, cleanP)
+		} else if ctx, err = a.withScratchDir(ctx, userID); err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, "failed to prepare workspace")
+			return
 		}
 
-		runID := a.flowV2State().createRun(userID, wf.ID, req.Input)
+		runID := a.flowV2State().createRun(userID, wf.ID, persist.WorkflowTriggerAPI, req.Input)
 		seconds := a.cfg.WorkflowTimeoutSeconds
 		if seconds <= 0 {
 			seconds = a.cfg.AgentRunTimeoutSeconds
@@ -227,7 +272,7 @@ func (a *app) flowV2RunEventsHandler() http.HandlerFunc {
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		runPath := strings.TrimPrefix(r.URL.Path, "/api/flows/v2/runs/")
@@ -248,27 +293,50 @@ func (a *app) flowV2RunEventsHandler() http.HandlerFunc {
 			w.Header().Set("Cache-Control", "no-cache")
 			fl, ok := w.(http.Flusher)
 			if !ok {
-				http.Error(w, "streaming not supported", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "streaming not supported")
 				return
 			}
 			snapshot, ch, done, ok := a.flowV2State().subscribeRun(userID, runID)
 			if !ok {
-				http.Error(w, "run not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "run not found")
 				return
 			}
+			// Reconnecting clients skip the events they already have.
+			var after int64
+			if id := lastEventID(r); id != "" {
+				if rid, seq, ok := parseSSEEventID(id); ok && rid == runID {
+					after = seq
+				}
+			}
+			envelope := sseEnvelopeRequested(r)
+			var mu sync.Mutex
+			write := func(ev flow.RunEvent) {
+				if ev.Sequence <= after {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				writeFlowV2SSE(w, fl, ev, envelope)
+			}
 			for _, ev := range snapshot {
-				writeFlowV2SSE(w, fl, ev)
+				write(ev)
 			}
 			if done {
 				return
 			}
 			defer a.flowV2State().unsubscribeRun(runID, ch)
+			defer startSSEHeartbeat(r.Context(), func(text string) {
+				mu.Lock()
+				defer mu.Unlock()
+				_, _ = w.Write([]byte(text))
+				fl.Flush()
+			})()
 			for {
 				select {
 				case <-r.Context().Done():
 					return
 				case ev := <-ch:
-					writeFlowV2SSE(w, fl, ev)
+					write(ev)
 					if ev.Type == flow.RunEventTypeRunCompleted || ev.Type == flow.RunEventTypeRunFailed || ev.Type == flow.RunEventTypeRunCancelled {
 						return
 					}
@@ -278,7 +346,7 @@ func (a *app) flowV2RunEventsHandler() http.HandlerFunc {
 
 		events, status, ok := a.flowV2State().getRunEvents(userID, runID)
 		if !ok {
-			http.Error(w, "run not found", http.StatusNotFound)
+			apierror.Respond(w, http.StatusNotFound, "run not found")
 			return
 		}
 		writeFlowV2JSON(w, http.StatusOK, map[string]any{
@@ -295,7 +363,7 @@ func (a *app) requireFlowV2User(w http.ResponseWriter, r *http.Request) (int64,
 		if a.cfg.Auth.Enabled {
 			w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 		}
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 		return 0, false
 	}
 	return userID, true
@@ -316,19 +384,22 @@ func writeFlowV2JSON(w http.ResponseWriter, status int, payload any) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeFlowV2SSE(w http.ResponseWriter, fl http.Flusher, event flow.RunEvent) {
+func writeFlowV2SSE(w http.ResponseWriter, fl http.Flusher, event flow.RunEvent, envelope bool) {
 	b, _ := json.Marshal(event)
-	_, _ = w.Write([]byte("data: " + string(b) + "\n\n"))
+	_, _ = w.Write(formatSSEEvent(event.RunID, event.Sequence, string(event.Type), b, envelope))
 	fl.Flush()
 }
 
 func (a *app) flowV2State() *flowV2Runtime {
 	if a.flowV2 == nil {
-		var store persist.FlowV2WorkflowStore
+		var (
+			store   persist.FlowV2WorkflowStore
+			history persist.WorkflowRunStore
+		)
 		if a.mgr != nil {
-			store = a.mgr.FlowV2
+			store, history = a.mgr.FlowV2, a.mgr.WorkflowRuns
 		}
-		a.flowV2 = newFlowV2Runtime(store)
+		a.flowV2 = newFlowV2Runtime(store, history)
 	}
 	return a.flowV2
 }
@@ -21,7 +21,7 @@ func TestFlowV2WorkflowCRUD(t *testing.T) {
 
 	a := &app{
 		cfg:    &config.Config{},
-		flowV2: newFlowV2Runtime(nil),
+		flowV2: newFlowV2Runtime(nil, nil),
 	}
 
 	putReqBody, _ := json.Marshal(flow.PutWorkflowRequest{
@@ -99,7 +99,7 @@ func TestFlowV2WorkflowPersistsAcrossRuntimeRestart(t *testing.T) {
 	store := databases.NewPostgresFlowV2Store(nil)
 	a := &app{
 		cfg:    &config.Config{},
-		flowV2: newFlowV2Runtime(store),
+		flowV2: newFlowV2Runtime(store, nil),
 	}
 
 	putReqBody, _ := json.Marshal(flow.PutWorkflowRequest{
@@ -134,7 +134,7 @@ func TestFlowV2WorkflowPersistsAcrossRuntimeRestart(t *testing.T) {
 
 	restarted := &app{
 		cfg:    &config.Config{},
-		flowV2: newFlowV2Runtime(store),
+		flowV2: newFlowV2Runtime(store, nil),
 	}
 
 	getRec := httptest.NewRecorder()
@@ -167,7 +167,7 @@ func TestFlowV2RunLifecycle(t *testing.T) {
 	a := &app{
 		cfg:          &config.Config{},
 		toolRegistry: reg,
-		flowV2:       newFlowV2Runtime(nil),
+		flowV2:       newFlowV2Runtime(nil, nil),
 	}
 
 	_, _, _ = a.flowV2.upsertWorkflow(context.Background(), 0, flow.Workflow{
@@ -258,7 +258,7 @@ func TestFlowV2RunUsesBaseToolRegistry(t *testing.T) {
 		cfg:              &config.Config{},
 		baseToolRegistry: baseReg,
 		toolRegistry:     filteredReg,
-		flowV2:           newFlowV2Runtime(nil),
+		flowV2:           newFlowV2Runtime(nil, nil),
 	}
 
 	_, _, _ = a.flowV2.upsertWorkflow(context.Background(), 0, flow.Workflow{
@@ -356,7 +356,7 @@ func TestFlowV2RunAgentResponseTool(t *testing.T) {
 		cfg:              &config.Config{},
 		baseToolRegistry: reg,
 		toolRegistry:     reg,
-		flowV2:           newFlowV2Runtime(nil),
+		flowV2:           newFlowV2Runtime(nil, nil),
 	}
 
 	_, _, _ = a.flowV2.upsertWorkflow(context.Background(), 0, flow.Workflow{
@@ -460,6 +460,91 @@ func TestFlowV2ToolsEndpoint(t *testing.T) {
 	}
 }
 
+func TestFlowV2DryRun(t *testing.T) {
+	t.Parallel()
+
+	reg := tools.NewRegistry()
+	reg.Register(utility.NewTextboxTool())
+	a := &app{
+		cfg:              &config.Config{},
+		baseToolRegistry: reg,
+		flowV2:           newFlowV2Runtime(nil, nil),
+	}
+
+	wf := flow.Workflow{
+		ID:      "wf_dry_run",
+		Name:    "Dry Run Flow",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{
+			{
+				ID:   "check",
+				Name: "Check",
+				Kind: flow.NodeKindLogic,
+				Type: "if",
+				Inputs: map[string]flow.InputBinding{
+					"condition": {Expression: "={{$run.input.count > 2}}"},
+				},
+			},
+			{
+				ID:   "textbox",
+				Name: "Textbox",
+				Kind: flow.NodeKindAction,
+				Type: "tool",
+				Tool: "utility_textbox",
+				Inputs: map[string]flow.InputBinding{
+					"text": {Expression: "={{$run.input.text}}"},
+				},
+			},
+			{
+				ID:   "missing",
+				Name: "Missing Tool",
+				Kind: flow.NodeKindAction,
+				Type: "tool",
+				Tool: "no_such_tool",
+				Inputs: map[string]flow.InputBinding{
+					"text": {Expression: "={{$node.textbox.output.payload}}"},
+				},
+			},
+		},
+		Edges: []flow.Edge{
+			{Source: flow.PortRef{NodeID: "check", Port: flow.PortTrue}, Target: flow.PortRef{NodeID: "textbox", Port: "input"}},
+			{Source: flow.PortRef{NodeID: "textbox", Port: "result"}, Target: flow.PortRef{NodeID: "missing", Port: "input"}},
+		},
+	}
+
+	body, _ := json.Marshal(flow.ValidateRequest{Workflow: wf, Input: map[string]any{"count": 3, "text": "hello"}})
+	rec := httptest.NewRecorder()
+	a.flowV2DryRunHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/warpp/validate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp flow.ValidateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Valid || len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Code != "node.tool.unknown" {
+		t.Fatalf("expected only the unknown tool error, got %+v", resp.Diagnostics)
+	}
+	if len(resp.Steps) != 3 || resp.Steps[1].Inputs["text"] != "hello" || resp.Steps[2].Inputs["text"] != "<runtime: $node.textbox.output.payload>" {
+		t.Fatalf("unexpected steps %+v", resp.Steps)
+	}
+
+	_, _, _ = a.flowV2.upsertWorkflow(context.Background(), 0, wf, flow.WorkflowCanvas{})
+	runBody, _ := json.Marshal(flow.RunRequest{WorkflowID: wf.ID, Input: map[string]any{"count": 1}, DryRun: true})
+	runRec := httptest.NewRecorder()
+	a.flowV2RunHandler().ServeHTTP(runRec, httptest.NewRequest(http.MethodPost, "/api/flows/v2/run", bytes.NewReader(runBody)))
+	if runRec.Code != http.StatusOK {
+		t.Fatalf("dry run should not start a run, got %d body=%s", runRec.Code, runRec.Body.String())
+	}
+	resp = flow.ValidateResponse{}
+	if err := json.Unmarshal(runRec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Steps) != 3 || resp.Steps[1].Status != "skipped" || resp.Steps[2].Status != "skipped" {
+		t.Fatalf("false condition should skip the branch, got %+v", resp.Steps)
+	}
+}
+
 func hasRunEvent(events []flow.RunEvent, typ flow.RunEventType) bool {
 	for _, ev := range events {
 		if ev.Type == typ {
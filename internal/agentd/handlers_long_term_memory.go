@@ -0,0 +1,137 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"manifold/internal/agent/memory"
+	"manifold/internal/apierror"
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
+)
+
+// longTermVectorIndex adapts a databases.VectorStore to the memory package's
+// LongTermVectorIndex.
+type longTermVectorIndex struct{ store databases.VectorStore }
+
+func (v longTermVectorIndex) Upsert(ctx context.Context, id string, vector []float32, metadata map[string]string) error {
+	return v.store.Upsert(ctx, id, vector, metadata)
+}
+
+func (v longTermVectorIndex) Delete(ctx context.Context, id string) error {
+	return v.store.Delete(ctx, id)
+}
+
+func (v longTermVectorIndex) SimilaritySearch(ctx context.Context, vector []float32, k int, filter map[string]string) ([]memory.LongTermVectorMatch, error) {
+	res, err := v.store.SimilaritySearch(ctx, vector, k, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]memory.LongTermVectorMatch, 0, len(res))
+	for _, r := range res {
+		out = append(out, memory.LongTermVectorMatch{ID: r.ID, Score: r.Score})
+	}
+	return out, nil
+}
+
+// initLongTermMemory attaches long-term memory to the base engine when it is
+// enabled and both a store and a vector backend are available.
+func (a *app) initLongTermMemory() {
+	cfg := a.cfg.LongTermMemory
+	if !cfg.Enabled || a.mgr == nil || a.mgr.LongTermMemory == nil || a.mgr.Vector == nil {
+		return
+	}
+	a.longTermMemory = memory.NewLongTermMemory(memory.LongTermMemoryConfig{
+		Store:              a.mgr.LongTermMemory,
+		Index:              longTermVectorIndex{store: a.mgr.Vector},
+		EmbeddingConfig:    a.cfg.Embedding,
+		LLM:                a.summaryLLM,
+		Model:              firstNonEmptyTrimmed(cfg.Model, a.cfg.OpenAI.SummaryModel),
+		TopK:               cfg.TopK,
+		MinScore:           cfg.MinScore,
+		MaxFactsPerTurn:    cfg.MaxFactsPerTurn,
+		DuplicateThreshold: cfg.DuplicateThreshold,
+	})
+	if a.engine != nil {
+		a.engine.LongTermMemory = a.longTermMemory
+	}
+}
+
+// longTermMemoriesHandler handles /api/memories: GET lists the caller's
+// long-term memories (?limit=N) and DELETE forgets all of them.
+func (a *app) longTermMemoriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.longTermMemory == nil {
+			apierror.Respond(w, http.StatusNotFound, "long-term memory disabled")
+			return
+		}
+		userID, err := a.requireUserID(r)
+		if err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			limit := 0
+			if raw := r.URL.Query().Get("limit"); raw != "" {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n < 0 {
+					apierror.Respond(w, http.StatusBadRequest, "invalid limit")
+					return
+				}
+				limit = n
+			}
+			list, err := a.longTermMemory.List(r.Context(), userID, limit)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		case http.MethodDelete:
+			n, err := a.longTermMemory.DeleteAll(r.Context(), userID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"deleted": n})
+		default:
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// longTermMemoryDetailHandler handles DELETE /api/memories/{id}.
+func (a *app) longTermMemoryDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.longTermMemory == nil {
+			apierror.Respond(w, http.StatusNotFound, "long-term memory disabled")
+			return
+		}
+		userID, err := a.requireUserID(r)
+		if err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if r.Method != http.MethodDelete {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/memories/"), "/")
+		if id == "" || strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if err := a.longTermMemory.Delete(r.Context(), userID, id); err != nil {
+			if errors.Is(err, persist.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
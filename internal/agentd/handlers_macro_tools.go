@@ -0,0 +1,110 @@
+package agentd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+	"manifold/internal/tools/macrotool"
+)
+
+// macroToolsHandler handles GET /api/tools/macros (list) and POST (create or
+// replace). Changes made here last until restart; permanent macros belong in
+// the macroTools config section.
+func (a *app) macroToolsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.macroTools == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "macro tools unavailable")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if _, err := a.requireUserID(r); err != nil {
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			writeJSON(w, http.StatusOK, a.macroTools.List())
+		case http.MethodPost:
+			if !a.requireAdmin(w, r) {
+				return
+			}
+			a.upsertMacroTool(w, r, "")
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// macroToolDetailHandler handles GET, PUT, and DELETE /api/tools/macros/{name}.
+func (a *app) macroToolDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.macroTools == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "macro tools unavailable")
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tools/macros/"), "/")
+		if name == "" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if _, err := a.requireUserID(r); err != nil {
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			def, ok := a.macroTools.Get(name)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(w, http.StatusOK, def)
+		case http.MethodPut:
+			if !a.requireAdmin(w, r) {
+				return
+			}
+			a.upsertMacroTool(w, r, name)
+		case http.MethodDelete:
+			if !a.requireAdmin(w, r) {
+				return
+			}
+			if err := a.macroTools.Delete(name); err != nil {
+				if errors.Is(err, macrotool.ErrNotFound) {
+					http.NotFound(w, r)
+					return
+				}
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+func (a *app) upsertMacroTool(w http.ResponseWriter, r *http.Request, name string) {
+	r.Body = http.MaxBytesReader(w, r.Body, 256*1024)
+	var def config.MacroToolConfig
+	if !apierror.Decode(w, r, &def, 0) {
+		return
+	}
+	if name != "" {
+		if def.Name != "" && def.Name != name {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("body name %q does not match path", def.Name))
+			return
+		}
+		def.Name = name
+	}
+	if err := a.macroTools.Upsert(def); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	saved, _ := a.macroTools.Get(strings.TrimSpace(def.Name))
+	writeJSON(w, http.StatusOK, saved)
+}
@@ -21,7 +21,9 @@ import (
 	oauthex "github.com/modelcontextprotocol/go-sdk/oauthex"
 	"golang.org/x/oauth2"
 
+	"manifold/internal/apierror"
 	"manifold/internal/config"
+	"manifold/internal/mcpclient"
 	"manifold/internal/persistence"
 )
 
@@ -218,7 +220,7 @@ func (a *app) mcpServersHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
@@ -228,7 +230,7 @@ func (a *app) mcpServersHandler() http.HandlerFunc {
 		case http.MethodPost:
 			a.handleCreateMCPServer(w, r, userID)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -237,7 +239,7 @@ func (a *app) mcpServerDetailHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		name := strings.TrimPrefix(r.URL.Path, "/api/mcp/servers/")
@@ -253,16 +255,106 @@ func (a *app) mcpServerDetailHandler() http.HandlerFunc {
 		case http.MethodDelete:
 			a.handleDeleteMCPServer(w, r, userID, name)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
 
+// mcpResourcesHandler lists the resources of connected MCP servers, or reads
+// one when server and uri are given.
+func (a *app) mcpResourcesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := a.requireUserID(r); err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		server := strings.TrimSpace(r.URL.Query().Get("server"))
+		uri := strings.TrimSpace(r.URL.Query().Get("uri"))
+		if uri == "" {
+			resources := []mcpclient.Resource{}
+			if a.mcpManager != nil {
+				resources = a.mcpManager.ListResources(r.Context())
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"resources": resources})
+			return
+		}
+		if server == "" {
+			apierror.RespondInvalid(w, "server", "required")
+			return
+		}
+		if a.mcpManager == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "mcp unavailable")
+			return
+		}
+		contents, err := a.mcpManager.ReadResource(r.Context(), server, uri)
+		if err != nil {
+			apierror.Respond(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"server": server, "uri": uri, "contents": contents})
+	}
+}
+
+// mcpPromptsHandler lists the prompts of connected MCP servers, or renders
+// the one a uri reference points to.
+func (a *app) mcpPromptsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := a.requireUserID(r); err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		uri := strings.TrimSpace(r.URL.Query().Get("uri"))
+		if uri == "" {
+			prompts := []mcpclient.Prompt{}
+			if a.mcpManager != nil {
+				prompts = a.mcpManager.ListPrompts(r.Context())
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"prompts": prompts})
+			return
+		}
+		if _, _, _, ok := mcpclient.ParsePromptURI(uri); !ok {
+			apierror.Respond(w, http.StatusBadRequest, "uri must look like mcp://<server>/prompts/<name>")
+			return
+		}
+		if a.mcpManager == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "mcp unavailable")
+			return
+		}
+		text, err := a.mcpManager.ResolvePrompt(r.Context(), uri)
+		if err != nil {
+			apierror.Respond(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"uri": uri, "text": text})
+	}
+}
+
+// expandMCPPrompts resolves MCP prompt references in a system prompt; see
+// mcpclient.Manager.ExpandPromptRefs.
+func (a *app) expandMCPPrompts(ctx context.Context, text string) string {
+	if a.mcpManager == nil {
+		return text
+	}
+	return a.mcpManager.ExpandPromptRefs(ctx, text)
+}
+
 func (a *app) handleListMCPServers(w http.ResponseWriter, r *http.Request, userID int64) {
 	// 1. Get DB servers
 	dbServers, err := a.mcpStore.List(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -337,19 +429,18 @@ func (a *app) handleListMCPServers(w http.ResponseWriter, r *http.Request, userI
 
 func (a *app) handleCreateMCPServer(w http.ResponseWriter, r *http.Request, userID int64) {
 	var req persistence.MCPServer
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+	if !apierror.Decode(w, r, &req, 0) {
 		return
 	}
 	req.Name = strings.TrimSpace(req.Name)
 	if req.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
+		apierror.RespondInvalid(w, "name", "required")
 		return
 	}
 
 	saved, err := a.mcpStore.Upsert(r.Context(), userID, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -373,15 +464,14 @@ func (a *app) handleCreateMCPServer(w http.ResponseWriter, r *http.Request, user
 
 func (a *app) handleUpdateMCPServer(w http.ResponseWriter, r *http.Request, userID int64, name string) {
 	var req persistence.MCPServer
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+	if !apierror.Decode(w, r, &req, 0) {
 		return
 	}
 	req.Name = name // Force name from URL
 
 	saved, err := a.mcpStore.Upsert(r.Context(), userID, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -403,7 +493,7 @@ func (a *app) handleUpdateMCPServer(w http.ResponseWriter, r *http.Request, user
 
 func (a *app) handleDeleteMCPServer(w http.ResponseWriter, r *http.Request, userID int64, name string) {
 	if err := a.mcpStore.Delete(r.Context(), userID, name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	// Disconnect
@@ -428,6 +518,7 @@ func convertToConfig(s persistence.MCPServer) config.MCPServerConfig {
 		ProtocolVersion:  s.ProtocolVersion,
 		KeepAliveSeconds: s.KeepAliveSeconds,
 		BearerToken:      token,
+		OwnerID:          s.UserID,
 	}
 }
 
@@ -554,7 +645,7 @@ func (a *app) mcpOAuthStartHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
@@ -562,13 +653,12 @@ func (a *app) mcpOAuthStartHandler() http.HandlerFunc {
 			ServerID int64  `json:"serverId"`
 			URL      string `json:"url"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 		authURL, statusCode, err := a.prepareMCPOAuthRedirect(w, r, userID, mcpOAuthStartRequest{ServerID: req.ServerID, URL: req.URL})
 		if err != nil {
-			http.Error(w, err.Error(), statusCode)
+			apierror.Respond(w, statusCode, err.Error())
 			return
 		}
 		json.NewEncoder(w).Encode(map[string]string{"redirectUrl": authURL})
@@ -578,21 +668,21 @@ func (a *app) mcpOAuthStartHandler() http.HandlerFunc {
 func (a *app) mcpOAuthBootstrapHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		if a.cfg.Auth.Enabled {
-			http.Error(w, "forbidden", http.StatusForbidden)
+			apierror.Respond(w, http.StatusForbidden, "forbidden")
 			return
 		}
 		serverID, err := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("serverId")), 10, 64)
 		if err != nil || serverID == 0 {
-			http.Error(w, "serverId required", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "serverId required")
 			return
 		}
 		authURL, statusCode, err := a.prepareMCPOAuthRedirect(w, r, systemUserID, mcpOAuthStartRequest{ServerID: serverID})
 		if err != nil {
-			http.Error(w, err.Error(), statusCode)
+			apierror.Respond(w, statusCode, err.Error())
 			return
 		}
 		http.Redirect(w, r, authURL, http.StatusFound)
@@ -603,7 +693,7 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		state := r.URL.Query().Get("state")
 		if state == "" {
-			http.Error(w, "state missing", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "state missing")
 			return
 		}
 
@@ -616,12 +706,12 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 		// 1. Verify state
 		cookie, err := r.Cookie(stateCookieName)
 		if err != nil {
-			http.Error(w, "state cookie missing", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "state cookie missing")
 			return
 		}
 		parts := strings.SplitN(cookie.Value, "|", 4)
 		if len(parts) < 4 {
-			http.Error(w, "invalid state cookie", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "invalid state cookie")
 			return
 		}
 		expectedState, targetURL := parts[0], parts[1]
@@ -634,31 +724,31 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 		// Load PKCE verifier
 		pkceCookie, err := r.Cookie(pkceCookieName)
 		if err != nil || pkceCookie.Value == "" {
-			http.Error(w, "pkce verifier missing", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "pkce verifier missing")
 			return
 		}
 
 		if state != expectedState {
-			http.Error(w, "state mismatch", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "state mismatch")
 			return
 		}
 
 		code := r.URL.Query().Get("code")
 		if code == "" {
-			http.Error(w, "code missing", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "code missing")
 			return
 		}
 
 		// 2. Exchange code
 		prm, err := a.discoverResourceMetadata(r.Context(), targetURL)
 		if err != nil {
-			http.Error(w, "metadata rediscovery failed", http.StatusBadGateway)
+			apierror.Respond(w, http.StatusBadGateway, "metadata rediscovery failed")
 			return
 		}
 		issuer := prm.AuthorizationServers[0]
 		asm, err := a.discoverAuthServerMeta(r.Context(), issuer)
 		if err != nil {
-			http.Error(w, "auth meta rediscovery failed", http.StatusBadGateway)
+			apierror.Respond(w, http.StatusBadGateway, "auth meta rediscovery failed")
 			return
 		}
 
@@ -682,7 +772,7 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 			clientSecret = strings.TrimSpace(os.Getenv("MCP_OAUTH_CLIENT_SECRET"))
 		}
 		if clientID == "" {
-			http.Error(w, "mcp oauth client id not configured for this server", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "mcp oauth client id not configured for this server")
 			return
 		}
 		redirectBase := computeBaseOrigin(a.cfg.Auth.RedirectURL)
@@ -703,7 +793,7 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 			oauth2.SetAuthURLParam("resource", targetURL),
 		)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("token exchange failed: %v", err), http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, fmt.Sprintf("token exchange failed: %v", err))
 			return
 		}
 
@@ -717,7 +807,7 @@ func (a *app) mcpOAuthCallbackHandler() http.HandlerFunc {
 					s.OAuthRefreshToken = token.RefreshToken
 					s.OAuthExpiresAt = token.Expiry
 					if _, err := a.mcpStore.Upsert(r.Context(), s.UserID, s); err != nil {
-						http.Error(w, "failed to persist token", http.StatusInternalServerError)
+						apierror.Respond(w, http.StatusInternalServerError, "failed to persist token")
 						return
 					}
 					// Hot-reload server tools with new token (async to avoid delaying user response)
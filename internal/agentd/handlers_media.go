@@ -15,6 +15,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 	llmpkg "manifold/internal/llm"
 	anthropicllm "manifold/internal/llm/anthropic"
@@ -22,6 +23,7 @@ import (
 	openaillm "manifold/internal/llm/openai"
 	persist "manifold/internal/persistence"
 	"manifold/internal/specialists"
+	"manifold/internal/stt"
 )
 
 type visionClientSelection struct {
@@ -52,28 +54,28 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			id, _, err := resolveChatAccess(r.Context(), a.authStore, u)
 			if err != nil {
 				log.Error().Err(err).Msg("resolve_chat_access")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			userID = id
 		}
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		if err := r.ParseMultipartForm(32 << 20); err != nil {
-			http.Error(w, "bad form", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "bad form")
 			return
 		}
 		prompt := strings.TrimSpace(r.FormValue("prompt"))
 		if prompt == "" {
-			http.Error(w, "prompt required", http.StatusBadRequest)
+			apierror.RespondInvalid(w, "prompt", "required")
 			return
 		}
 		sessionID := strings.TrimSpace(r.FormValue("session_id"))
@@ -82,11 +84,11 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 		}
 		if _, err := ensureChatSession(r.Context(), a.chatStore, userID, sessionID); err != nil {
 			if errors.Is(err, persist.ErrForbidden) {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			log.Error().Err(err).Str("session", sessionID).Msg("ensure_chat_session")
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 		form := r.MultipartForm
@@ -100,7 +102,7 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 			}
 		}
 		if len(files) == 0 {
-			http.Error(w, "no images provided", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "no images provided")
 			return
 		}
 
@@ -122,7 +124,7 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 			teamName,
 		)
 		if resolveErr != nil {
-			http.Error(w, resolveErr.Error(), statusCode)
+			apierror.Respond(w, statusCode, resolveErr.Error())
 			return
 		}
 
@@ -155,11 +157,11 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 		history, _, err := a.chatMemory.BuildContextForProvider(r.Context(), userID, sessionID, targetSupportsCompaction)
 		if err != nil {
 			if errors.Is(err, persist.ErrForbidden) {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
 			log.Error().Err(err).Str("session", sessionID).Msg("load_chat_history")
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
 
@@ -171,18 +173,18 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 		for _, fh := range files {
 			f, err := fh.Open()
 			if err != nil {
-				http.Error(w, "file open", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "file open")
 				return
 			}
 			data, err := io.ReadAll(f)
 			f.Close()
 			if err != nil {
-				http.Error(w, "file read", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "file read")
 				return
 			}
 			mt := http.DetectContentType(data)
 			if mt != "image/png" && mt != "image/jpeg" && mt != "image/jpg" && mt != "image/webp" {
-				http.Error(w, "unsupported image type", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "unsupported image type")
 				return
 			}
 			if mt == "image/jpg" {
@@ -232,17 +234,18 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 		}
 		if callErr != nil {
 			log.Error().Err(callErr).Msg("vision chat error")
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			a.runs.updateStatus(vrun.ID, "failed", 0)
 			return
 		}
 
+		_, stored, _ := a.scrubTurnForStorage(r.Context(), nil, out.Content)
 		if r.Header.Get("Accept") == "text/event-stream" {
 			w.Header().Set("Content-Type", "text/event-stream")
 			w.Header().Set("Cache-Control", "no-cache")
 			fl, ok := w.(http.Flusher)
 			if !ok {
-				http.Error(w, "streaming not supported", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "streaming not supported")
 				return
 			}
 			payload := map[string]string{"type": "final", "data": out.Content}
@@ -250,7 +253,7 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 			fmt.Fprintf(w, "data: %s\n\n", b)
 			fl.Flush()
 			a.runs.updateStatus(vrun.ID, "completed", 0)
-			if err := storeChatTurn(r.Context(), a.chatStore, userID, sessionID, prompt, out.Content, visionSel.Model); err != nil {
+			if err := storeChatTurn(r.Context(), a.chatStore, userID, sessionID, prompt, stored, visionSel.Model); err != nil {
 				log.Error().Err(err).Str("session", sessionID).Msg("store_chat_turn_vision_stream")
 			}
 			return
@@ -258,7 +261,7 @@ func (a *app) agentVisionHandler() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"result": out.Content})
 		a.runs.updateStatus(vrun.ID, "completed", 0)
-		if err := storeChatTurn(r.Context(), a.chatStore, userID, sessionID, prompt, out.Content, visionSel.Model); err != nil {
+		if err := storeChatTurn(r.Context(), a.chatStore, userID, sessionID, prompt, stored, visionSel.Model); err != nil {
 			log.Error().Err(err).Str("session", sessionID).Msg("store_chat_turn_vision")
 		}
 	}
@@ -403,12 +406,12 @@ func (a *app) resolveVisionClientAndModel(ctx context.Context, owner int64, spec
 func (a *app) audioServeHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		filename := strings.TrimPrefix(r.URL.Path, "/audio/")
 		if filename == "" {
-			http.Error(w, "file not specified", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "file not specified")
 			return
 		}
 		http.ServeFile(w, r, filename)
@@ -417,126 +420,255 @@ func (a *app) audioServeHandler() http.HandlerFunc {
 
 func (a *app) sttHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Resolve user for per-user API key lookup
-		var userID int64
-		if a.cfg.Auth.Enabled {
-			u, ok := auth.CurrentUser(r.Context())
-			if !ok {
-				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
-				return
-			}
-			id, _, err := resolveChatAccess(r.Context(), a.authStore, u)
-			if err != nil {
-				log.Error().Err(err).Msg("stt_resolve_chat_access")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
-				return
-			}
-			if id != nil {
-				userID = *id
-			}
+		userID, ok := a.sttUser(w, r)
+		if !ok {
+			return
 		}
 
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		if err := r.ParseMultipartForm(32 << 20); err != nil {
-			http.Error(w, "bad form", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "bad form")
 			return
 		}
 		file, _, err := r.FormFile("audio")
 		if err != nil {
-			http.Error(w, "missing audio", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "missing audio")
 			return
 		}
 		defer file.Close()
 		data, err := io.ReadAll(file)
 		if err != nil {
-			http.Error(w, "read error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "read error")
 			return
 		}
-
-		// Get per-user orchestrator config (includes API key)
-		orch := a.orchestratorSpecialist(r.Context(), userID)
-
-		model := strings.TrimSpace(a.cfg.STT.Model)
-		if model == "" {
-			model = "gpt-4o-mini-transcribe"
-		}
-		baseURL := strings.TrimSpace(a.cfg.STT.BaseURL)
-		if baseURL == "" {
-			baseURL = strings.TrimSpace(orch.BaseURL)
-		}
-		if baseURL == "" {
-			baseURL = "https://api.openai.com"
-		}
-		baseURL = strings.TrimRight(baseURL, "/")
-		baseURL = strings.TrimSuffix(baseURL, "/v1")
-		reqURL := baseURL + "/v1/audio/transcriptions"
-		log.Debug().Str("endpoint", reqURL).Str("model", model).Int64("user_id", userID).Msg("stt_request")
-
-		var buf bytes.Buffer
-		mw := multipart.NewWriter(&buf)
-		fw, err := mw.CreateFormFile("file", "prompt.wav")
+		wav, err := normalizeSTTAudio(r.Context(), data, a.cfg.STT.FFmpegPath)
 		if err != nil {
-			http.Error(w, "form error", http.StatusInternalServerError)
+			log.Warn().Err(err).Int("bytes", len(data)).Msg("stt_audio_convert_failed")
+			if errors.Is(err, errSTTUnsupportedAudio) {
+				apierror.Respond(w, http.StatusUnsupportedMediaType, err.Error())
+				return
+			}
+			apierror.Respond(w, http.StatusInternalServerError, "audio conversion failed")
 			return
 		}
-		if _, err := fw.Write(data); err != nil {
-			http.Error(w, "form error", http.StatusInternalServerError)
-			return
+
+		flag := func(key string) bool {
+			v := r.FormValue(key)
+			return v == "1" || strings.EqualFold(v, "true")
 		}
-		if err := mw.WriteField("model", model); err != nil {
-			http.Error(w, "form error", http.StatusInternalServerError)
-			return
+		opts := stt.Options{
+			Timestamps: flag("timestamps"),
+			Diarize:    flag("diarize"),
+			Language:   r.FormValue("language"),
 		}
-		if err := mw.WriteField("response_format", "json"); err != nil {
-			http.Error(w, "form error", http.StatusInternalServerError)
+		tr, err := a.transcribe(r.Context(), userID, wav, opts)
+		if err != nil {
+			var se *sttError
+			if !errors.As(err, &se) {
+				se = &sttError{status: http.StatusInternalServerError, msg: "internal server error"}
+			}
+			if se.status == http.StatusServiceUnavailable {
+				w.Header().Set("Retry-After", "1")
+			}
+			apierror.Respond(w, se.status, se.msg)
 			return
 		}
-		if err := mw.Close(); err != nil {
-			http.Error(w, "form error", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		if opts.Verbose() {
+			_ = json.NewEncoder(w).Encode(tr)
 			return
 		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"text": tr.Text})
+	}
+}
 
-		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-		defer cancel()
-		started := time.Now()
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, &buf)
-		if err != nil {
-			http.Error(w, "request error", http.StatusInternalServerError)
-			return
-		}
-		req.Header.Set("Content-Type", mw.FormDataContentType())
-		if orch.APIKey != "" {
-			req.Header.Set("Authorization", "Bearer "+orch.APIKey)
-		}
+// sttUser resolves the caller for per-user API key lookup. It replies and
+// returns false when the caller is not allowed.
+func (a *app) sttUser(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	var userID int64
+	if !a.cfg.Auth.Enabled {
+		return userID, true
+	}
+	u, ok := auth.CurrentUser(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
+		apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+		return 0, false
+	}
+	id, _, err := resolveChatAccess(r.Context(), a.authStore, u)
+	if err != nil {
+		log.Error().Err(err).Msg("stt_resolve_chat_access")
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+		return 0, false
+	}
+	if id != nil {
+		userID = *id
+	}
+	return userID, true
+}
 
-		resp, err := a.httpClient.Do(req)
-		if err != nil {
-			log.Warn().Err(err).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_request_failed")
-			http.Error(w, "stt request failed", http.StatusBadGateway)
+// ttsProvidersHandler lists the TTS providers with their capabilities,
+// health and voices, along with the named voices from tts.voices.
+func (a *app) ttsProvidersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := a.sttUser(w, r); !ok {
 			return
 		}
-		defer func() { _ = resp.Body.Close() }()
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
-			log.Warn().Int("status", resp.StatusCode).Str("body", strings.TrimSpace(string(b))).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_request_error")
-			http.Error(w, strings.TrimSpace(string(b)), resp.StatusCode)
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
-
-		var out struct {
-			Text string `json:"text"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-			log.Warn().Err(err).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_response_decode_failed")
-			http.Error(w, "invalid stt response", http.StatusBadGateway)
+		if a.tts == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "tts not configured")
 			return
 		}
-		log.Debug().Str("endpoint", reqURL).Int("text_len", len(out.Text)).Dur("elapsed", time.Since(started)).Msg("stt_response")
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]string{"text": strings.TrimSpace(out.Text)})
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"defaultVoice": a.cfg.TTS.Voice,
+			"providers":    a.tts.Providers(ctx),
+			"voices":       a.tts.Voices(),
+		})
+	}
+}
+
+// appTranscriber lets tools registered before the app exists transcribe
+// through it. Audio is converted like /stt uploads and billed to the user
+// running the tool.
+type appTranscriber struct {
+	app *app
+}
+
+func (t *appTranscriber) Transcribe(ctx context.Context, audio []byte, opts stt.Options) (stt.Transcript, error) {
+	if t.app == nil {
+		return stt.Transcript{}, errors.New("stt: not ready")
+	}
+	wav, err := normalizeSTTAudio(ctx, audio, t.app.cfg.STT.FFmpegPath)
+	if err != nil {
+		return stt.Transcript{}, err
+	}
+	userID, _ := llmpkg.UserIDFromContext(ctx)
+	return t.app.transcribe(ctx, userID, wav, opts)
+}
+
+// sttError is a failed transcription with the status /stt answers.
+type sttError struct {
+	status int
+	msg    string
+}
+
+func (e *sttError) Error() string { return fmt.Sprintf("stt: %s (%d)", e.msg, e.status) }
+
+// transcribeWAV sends a WAV clip to the STT endpoint and returns the
+// trimmed transcript text.
+func (a *app) transcribeWAV(ctx context.Context, userID int64, data []byte) (string, error) {
+	tr, err := a.transcribe(ctx, userID, data, stt.Options{})
+	return tr.Text, err
+}
+
+// transcribe sends a WAV clip to the STT endpoint. Segments with timestamps
+// and speakers are requested through verbose_json when opts asks for them.
+// It waits for a slot in the STT pool first. Failures are *sttError values.
+func (a *app) transcribe(ctx context.Context, userID int64, data []byte, opts stt.Options) (stt.Transcript, error) {
+	// Get per-user orchestrator config (includes API key)
+	orch := a.orchestratorSpecialist(ctx, userID)
+
+	model := strings.TrimSpace(a.cfg.STT.Model)
+	if model == "" {
+		model = "gpt-4o-mini-transcribe"
+	}
+	baseURL := strings.TrimSpace(a.cfg.STT.BaseURL)
+	if baseURL == "" {
+		baseURL = strings.TrimSpace(orch.BaseURL)
+	}
+	if baseURL == "" {
+		baseURL = "https://api.openai.com"
+	}
+	reqURL := sttTranscriptionsURL(baseURL)
+	log.Debug().Str("endpoint", reqURL).Str("model", model).Int64("user_id", userID).Msg("stt_request")
+
+	formErr := &sttError{status: http.StatusInternalServerError, msg: "form error"}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "prompt.wav")
+	if err != nil {
+		return stt.Transcript{}, formErr
+	}
+	if _, err := fw.Write(data); err != nil {
+		return stt.Transcript{}, formErr
+	}
+	if err := mw.WriteField("model", model); err != nil {
+		return stt.Transcript{}, formErr
+	}
+	fields := [][2]string{{"response_format", "json"}}
+	if opts.Verbose() {
+		fields = [][2]string{{"response_format", "verbose_json"}, {"timestamp_granularities[]", "segment"}}
+	}
+	if opts.Diarize {
+		// whisper.cpp's server emits speaker turns with tinydiarize models.
+		fields = append(fields, [2]string{"tinydiarize", "true"})
+	}
+	if lang := strings.TrimSpace(opts.Language); lang != "" {
+		fields = append(fields, [2]string{"language", lang})
+	}
+	for _, f := range fields {
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return stt.Transcript{}, formErr
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return stt.Transcript{}, formErr
+	}
+
+	queued := time.Now()
+	release, err := a.sttPool.acquire(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("endpoint", reqURL).Dur("queued", time.Since(queued)).Msg("stt_queue_timeout")
+		return stt.Transcript{}, &sttError{status: http.StatusServiceUnavailable, msg: "stt busy"}
+	}
+	defer release()
+	if wait := time.Since(queued); wait > time.Second {
+		log.Debug().Dur("queued", wait).Int("in_flight", a.sttPool.inFlight()).Msg("stt_queued")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	started := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, &buf)
+	if err != nil {
+		return stt.Transcript{}, &sttError{status: http.StatusInternalServerError, msg: "request error"}
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if orch.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.secrets.ResolveFor(orch.APIKey, orch.UserID, req.URL.Hostname()))
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		log.Warn().Err(err).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_request_failed")
+		return stt.Transcript{}, &sttError{status: http.StatusBadGateway, msg: "stt request failed"}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		log.Warn().Int("status", resp.StatusCode).Str("body", strings.TrimSpace(string(b))).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_request_error")
+		return stt.Transcript{}, &sttError{status: resp.StatusCode, msg: strings.TrimSpace(string(b))}
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		log.Warn().Err(err).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_response_read_failed")
+		return stt.Transcript{}, &sttError{status: http.StatusBadGateway, msg: "invalid stt response"}
+	}
+	out, err := stt.ParseResponse(b, opts)
+	if err != nil {
+		log.Warn().Err(err).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("stt_response_decode_failed")
+		return stt.Transcript{}, &sttError{status: http.StatusBadGateway, msg: "invalid stt response"}
 	}
+	log.Debug().Str("endpoint", reqURL).Int("text_len", len(out.Text)).Int("segments", len(out.Segments)).Dur("elapsed", time.Since(started)).Msg("stt_response")
+	return out, nil
 }
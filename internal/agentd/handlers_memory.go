@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"manifold/internal/agent/memory"
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 	"manifold/internal/llm"
 	"manifold/internal/persistence"
@@ -63,21 +64,17 @@ func (a *app) debugMemoryHandler() http.HandlerFunc {
 		if a.cfg.Auth.Enabled {
 			if _, ok := auth.CurrentUser(r.Context()); !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
 
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Vary", "Origin")
 		if r.Method == http.MethodOptions {
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 
@@ -131,7 +128,7 @@ func (a *app) handleDebugMemorySessions(w http.ResponseWriter, r *http.Request)
 	if a.cfg.Auth.Enabled {
 		uid, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		userID = &uid
@@ -139,7 +136,7 @@ func (a *app) handleDebugMemorySessions(w http.ResponseWriter, r *http.Request)
 	sessions, err := a.chatStore.ListSessions(r.Context(), userID)
 	if err != nil {
 		log.Error().Err(err).Msg("debug_memory_list_sessions")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 	ownerID := systemUserID
@@ -192,7 +189,7 @@ func (a *app) handleDebugMemorySessionDetail(w http.ResponseWriter, r *http.Requ
 	if a.cfg.Auth.Enabled {
 		uid, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		userID = &uid
@@ -201,7 +198,7 @@ func (a *app) handleDebugMemorySessionDetail(w http.ResponseWriter, r *http.Requ
 	sess, err := a.chatStore.GetSession(r.Context(), userID, sessionID)
 	if err != nil {
 		log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_get_session")
-		http.Error(w, "not found", http.StatusNotFound)
+		apierror.Respond(w, http.StatusNotFound, "not found")
 		return
 	}
 
@@ -216,17 +213,17 @@ func (a *app) handleDebugMemorySessionDetail(w http.ResponseWriter, r *http.Requ
 		}
 		if statusCode >= http.StatusInternalServerError {
 			log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_resolve_target")
-			http.Error(w, "internal server error", statusCode)
+			apierror.Respond(w, statusCode, "internal server error")
 			return
 		}
-		http.Error(w, err.Error(), statusCode)
+		apierror.Respond(w, statusCode, err.Error())
 		return
 	}
 
 	ctxMsgs, _, err := a.chatMemory.BuildContextForProvider(r.Context(), userID, sessionID, targetSupportsCompaction)
 	if err != nil {
 		log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_build_context")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -248,7 +245,7 @@ func (a *app) handleDebugMemoryEntries(w http.ResponseWriter, r *http.Request) {
 	if a.cfg.Auth.Enabled {
 		uid, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		userID = &uid
@@ -265,14 +262,14 @@ func (a *app) handleDebugMemoryEntries(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if sessionID == "" {
-		http.Error(w, "session_id is required", http.StatusBadRequest)
+		apierror.Respond(w, http.StatusBadRequest, "session_id is required")
 		return
 	}
 
 	msgs, err := a.chatStore.ListMessages(r.Context(), userID, sessionID, limit)
 	if err != nil {
 		log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_list_messages")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 	writeJSON(w, http.StatusOK, msgs)
@@ -283,7 +280,7 @@ func (a *app) handleDebugMemoryPlan(w http.ResponseWriter, r *http.Request) {
 	if a.cfg.Auth.Enabled {
 		uid, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		userID = &uid
@@ -292,14 +289,14 @@ func (a *app) handleDebugMemoryPlan(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	sessionID := strings.TrimSpace(q.Get("session_id"))
 	if sessionID == "" {
-		http.Error(w, "session_id is required", http.StatusBadRequest)
+		apierror.Respond(w, http.StatusBadRequest, "session_id is required")
 		return
 	}
 
 	sess, err := a.chatStore.GetSession(r.Context(), userID, sessionID)
 	if err != nil {
 		log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_get_session")
-		http.Error(w, "not found", http.StatusNotFound)
+		apierror.Respond(w, http.StatusNotFound, "not found")
 		return
 	}
 
@@ -314,17 +311,17 @@ func (a *app) handleDebugMemoryPlan(w http.ResponseWriter, r *http.Request) {
 		}
 		if statusCode >= http.StatusInternalServerError {
 			log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_resolve_target")
-			http.Error(w, "internal server error", statusCode)
+			apierror.Respond(w, statusCode, "internal server error")
 			return
 		}
-		http.Error(w, err.Error(), statusCode)
+		apierror.Respond(w, statusCode, err.Error())
 		return
 	}
 
 	ctxMsgs, _, err := a.chatMemory.BuildContextForProvider(r.Context(), userID, sessionID, targetSupportsCompaction)
 	if err != nil {
 		log.Error().Err(err).Str("session", sessionID).Msg("debug_memory_build_context")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -409,7 +406,7 @@ func (a *app) handleDebugMemoryEvolving(w http.ResponseWriter, r *http.Request)
 	if a.cfg.Auth.Enabled {
 		uid, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		userID = uid
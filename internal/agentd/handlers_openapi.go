@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"manifold/internal/apidocs"
+	"manifold/internal/apierror"
 )
 
 const openAPIDocsHTML = `<!doctype html>
@@ -76,7 +77,7 @@ const openAPIDocsHTML = `<!doctype html>
 func (a *app) openapiSpecHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 
@@ -86,7 +87,7 @@ func (a *app) openapiSpecHandler() http.HandlerFunc {
 			AuthCookieName: a.cfg.Auth.CookieName,
 		})
 		if err != nil {
-			http.Error(w, "failed to generate openapi", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "failed to generate openapi")
 			return
 		}
 
@@ -99,7 +100,7 @@ func (a *app) openapiSpecHandler() http.HandlerFunc {
 func (a *app) openapiDocsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -3,8 +3,12 @@ package agentd
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
+	"manifold/internal/i18n"
 
 	"github.com/rs/zerolog/log"
 )
@@ -12,16 +16,6 @@ import (
 // userPreferencesHandler handles GET /api/me/preferences and PUT /api/me/preferences.
 func (a *app) userPreferencesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// CORS headers
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-		} else {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -33,14 +27,14 @@ func (a *app) userPreferencesHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"manifold\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			userID = u.ID
 		}
 
 		if a.userPrefsStore == nil {
-			http.Error(w, "preferences not available", http.StatusServiceUnavailable)
+			apierror.Respond(w, http.StatusServiceUnavailable, "preferences not available")
 			return
 		}
 
@@ -50,7 +44,7 @@ func (a *app) userPreferencesHandler() http.HandlerFunc {
 		case http.MethodPut:
 			a.handleSetPreferences(w, r, userID)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -59,7 +53,7 @@ func (a *app) handleGetPreferences(w http.ResponseWriter, r *http.Request, userI
 	prefs, err := a.userPrefsStore.Get(r.Context(), userID)
 	if err != nil {
 		log.Error().Err(err).Int64("userId", userID).Msg("failed to get user preferences")
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
@@ -68,28 +62,71 @@ func (a *app) handleGetPreferences(w http.ResponseWriter, r *http.Request, userI
 }
 
 func (a *app) handleSetPreferences(w http.ResponseWriter, r *http.Request, userID int64) {
+	// Pointer fields distinguish omitted keys (left unchanged) from explicit
+	// empty strings (cleared).
 	var req struct {
-		ActiveProjectID string `json:"activeProjectId"`
+		ActiveProjectID *string `json:"activeProjectId"`
+		Locale          *string `json:"locale"`
+		TimeZone        *string `json:"timeZone"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	if !apierror.Decode(w, r, &req, 0) {
 		return
 	}
 
-	if err := a.userPrefsStore.SetActiveProject(r.Context(), userID, req.ActiveProjectID); err != nil {
+	if req.Locale != nil || req.TimeZone != nil {
+		current, err := a.userPrefsStore.Get(r.Context(), userID)
+		if err != nil {
+			log.Error().Err(err).Int64("userId", userID).Msg("failed to get user preferences")
+			apierror.Respond(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		locale, timeZone := current.Locale, current.TimeZone
+		if req.Locale != nil {
+			locale = strings.TrimSpace(*req.Locale)
+			if locale != "" {
+				norm, ok := i18n.Normalize(locale)
+				if !ok {
+					apierror.Respond(w, http.StatusBadRequest, i18n.T(a.headerLocale(r), "invalid locale"))
+					return
+				}
+				locale = norm
+			}
+		}
+		if req.TimeZone != nil {
+			timeZone = strings.TrimSpace(*req.TimeZone)
+			if timeZone != "" {
+				if _, err := time.LoadLocation(timeZone); err != nil {
+					apierror.Respond(w, http.StatusBadRequest, i18n.T(a.headerLocale(r), "invalid time zone"))
+					return
+				}
+			}
+		}
+		if err := a.userPrefsStore.SetLocale(r.Context(), userID, locale, timeZone); err != nil {
+			log.Error().Err(err).Int64("userId", userID).Msg("failed to set locale")
+			apierror.Respond(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	if req.ActiveProjectID == nil {
+		a.handleGetPreferences(w, r, userID)
+		return
+	}
+	activeProjectID := *req.ActiveProjectID
+	if err := a.userPrefsStore.SetActiveProject(r.Context(), userID, activeProjectID); err != nil {
 		log.Error().Err(err).Int64("userId", userID).Msg("failed to set active project")
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	// Set up per-user MCP sessions for the new project when required
-	if a.mcpPool != nil && a.mcpPool.RequiresPerUserMCP() && req.ActiveProjectID != "" {
-		ws, err := a.workspaceManager.Checkout(r.Context(), userID, req.ActiveProjectID, "")
+	if a.mcpPool != nil && a.mcpPool.RequiresPerUserMCP() && activeProjectID != "" {
+		ws, err := a.workspaceManager.Checkout(r.Context(), userID, activeProjectID, "")
 		if err != nil {
-			log.Warn().Err(err).Int64("userId", userID).Str("projectId", req.ActiveProjectID).Msg("workspace_checkout_for_mcp_failed")
+			log.Warn().Err(err).Int64("userId", userID).Str("projectId", activeProjectID).Msg("workspace_checkout_for_mcp_failed")
 		} else if ws.BaseDir != "" {
-			if err := a.mcpPool.EnsureUserSession(r.Context(), a.baseToolRegistry, userID, req.ActiveProjectID, ws.BaseDir); err != nil {
-				log.Warn().Err(err).Int64("userId", userID).Str("projectId", req.ActiveProjectID).Msg("mcp_session_setup_failed")
+			if err := a.mcpPool.EnsureUserSession(r.Context(), a.baseToolRegistry, userID, activeProjectID, ws.BaseDir); err != nil {
+				log.Warn().Err(err).Int64("userId", userID).Str("projectId", activeProjectID).Msg("mcp_session_setup_failed")
 			}
 		}
 	}
@@ -98,7 +135,7 @@ func (a *app) handleSetPreferences(w http.ResponseWriter, r *http.Request, userI
 	prefs, err := a.userPrefsStore.Get(r.Context(), userID)
 	if err != nil {
 		log.Error().Err(err).Int64("userId", userID).Msg("failed to get updated preferences")
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
@@ -110,22 +147,12 @@ func (a *app) handleSetPreferences(w http.ResponseWriter, r *http.Request, userI
 // This is a convenience endpoint for setting just the active project.
 func (a *app) setActiveProjectHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// CORS headers
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-		} else {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 
@@ -135,28 +162,27 @@ func (a *app) setActiveProjectHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"manifold\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			userID = u.ID
 		}
 
 		if a.userPrefsStore == nil {
-			http.Error(w, "preferences not available", http.StatusServiceUnavailable)
+			apierror.Respond(w, http.StatusServiceUnavailable, "preferences not available")
 			return
 		}
 
 		var req struct {
 			ProjectID string `json:"projectId"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 
 		if err := a.userPrefsStore.SetActiveProject(r.Context(), userID, req.ProjectID); err != nil {
 			log.Error().Err(err).Int64("userId", userID).Str("projectId", req.ProjectID).Msg("failed to set active project")
-			http.Error(w, "internal error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal error")
 			return
 		}
 
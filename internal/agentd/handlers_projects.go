@@ -8,16 +8,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 	persist "manifold/internal/persistence"
+	"manifold/internal/projects"
+	"manifold/internal/sandbox"
 	"manifold/internal/workspaces"
 )
 
@@ -52,7 +57,6 @@ func isAllowedTextFile(name string) bool {
 
 func (a *app) projectsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.projectsCORS(w, r, "GET, POST, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -60,10 +64,10 @@ func (a *app) projectsHandler() http.HandlerFunc {
 		userID, ok, err := a.resolveProjectsUser(r)
 		if !ok || err != nil {
 			if errors.Is(err, persist.ErrForbidden) {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		switch r.Method {
@@ -71,7 +75,7 @@ func (a *app) projectsHandler() http.HandlerFunc {
 			list, err := a.projectsService.ListProjects(r.Context(), userID)
 			if err != nil {
 				log.Error().Err(err).Msg("list_projects")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			out := make([]map[string]any, 0, len(list))
@@ -93,28 +97,26 @@ func (a *app) projectsHandler() http.HandlerFunc {
 			var in struct {
 				Name string `json:"name"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.DecodeOptional(w, r, &in, 0) {
 				return
 			}
 			p, err := a.projectsService.CreateProject(r.Context(), userID, in.Name)
 			if err != nil {
 				log.Error().Err(err).Msg("create_project")
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusCreated)
 			_ = json.NewEncoder(w).Encode(p)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
 
 func (a *app) projectDetailHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.projectsCORS(w, r, "GET, POST, DELETE, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -122,10 +124,10 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 		userID, ok, err := a.resolveProjectsUser(r)
 		if !ok || err != nil {
 			if errors.Is(err, persist.ErrForbidden) {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, http.StatusForbidden, "forbidden")
 				return
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		path := strings.TrimPrefix(r.URL.Path, "/api/projects/")
@@ -138,7 +140,7 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 		projectID := parts[0]
 		cleanPID, err := workspaces.ValidateProjectID(projectID)
 		if err != nil || cleanPID == "" {
-			http.Error(w, "bad request", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "bad request")
 			return
 		}
 		projectID = cleanPID
@@ -147,7 +149,7 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 			case http.MethodDelete:
 				if err := a.projectsService.DeleteProject(r.Context(), userID, projectID); err != nil {
 					log.Error().Err(err).Str("project", projectID).Msg("delete_project")
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				w.WriteHeader(http.StatusNoContent)
@@ -156,7 +158,7 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 				entries, err := a.projectsService.ListTree(r.Context(), userID, projectID, ".")
 				if err != nil {
 					log.Error().Err(err).Str("project", projectID).Msg("list_tree_root")
-					http.Error(w, "not found", http.StatusNotFound)
+					apierror.Respond(w, http.StatusNotFound, "not found")
 					return
 				}
 				rows := make([]map[string]any, 0, len(entries))
@@ -173,7 +175,7 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 				_ = json.NewEncoder(w).Encode(map[string]any{"entries": rows})
 				return
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 		}
@@ -185,21 +187,21 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 		switch parts[1] {
 		case "archive":
 			if r.Method != http.MethodGet {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			a.streamProjectArchive(w, r, userID, projectID)
 			return
 		case "tree":
 			if r.Method != http.MethodGet {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			p := r.URL.Query().Get("path")
 			entries, err := a.projectsService.ListTree(r.Context(), userID, projectID, p)
 			if err != nil {
 				log.Error().Err(err).Str("project", projectID).Str("path", p).Msg("list_tree")
-				http.Error(w, "not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "not found")
 				return
 			}
 			rows := make([]map[string]any, 0, len(entries))
@@ -220,13 +222,13 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 			case http.MethodGet:
 				p := r.URL.Query().Get("path")
 				if p == "" {
-					http.Error(w, "missing path", http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, "missing path")
 					return
 				}
 				rc, err := a.projectsService.ReadFile(r.Context(), userID, projectID, p)
 				if err != nil {
 					log.Error().Err(err).Str("project", projectID).Str("path", p).Msg("read_file")
-					http.Error(w, "not found", http.StatusNotFound)
+					apierror.Respond(w, http.StatusNotFound, "not found")
 					return
 				}
 				defer rc.Close()
@@ -254,12 +256,12 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 				ct := r.Header.Get("Content-Type")
 				if strings.HasPrefix(strings.ToLower(ct), "multipart/") {
 					if err := r.ParseMultipartForm(64 << 20); err != nil {
-						http.Error(w, "bad request", http.StatusBadRequest)
+						apierror.Respond(w, http.StatusBadRequest, "bad request")
 						return
 					}
 					file, fh, err := r.FormFile("file")
 					if err != nil {
-						http.Error(w, "bad request", http.StatusBadRequest)
+						apierror.Respond(w, http.StatusBadRequest, "bad request")
 						return
 					}
 					defer file.Close()
@@ -271,23 +273,31 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 					}
 					if err := a.projectsService.UploadFile(r.Context(), userID, projectID, p, name, file); err != nil {
 						log.Error().Err(err).Str("project", projectID).Str("path", p).Str("name", name).Msg("upload_file")
-						http.Error(w, "error", http.StatusBadRequest)
+						if errors.Is(err, projects.ErrQuotaExceeded) {
+							apierror.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+							return
+						}
+						apierror.Respond(w, http.StatusBadRequest, "error")
 						return
 					}
 					w.WriteHeader(http.StatusCreated)
 					return
 				}
 				if name == "" {
-					http.Error(w, "missing name", http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, "missing name")
 					return
 				}
 				if !isAllowedTextFile(name) {
-					http.Error(w, "unsupported file type", http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, "unsupported file type")
 					return
 				}
 				if err := a.projectsService.UploadFile(r.Context(), userID, projectID, p, name, r.Body); err != nil {
 					log.Error().Err(err).Str("project", projectID).Str("path", p).Str("name", name).Msg("upload_file_raw")
-					http.Error(w, "error", http.StatusBadRequest)
+					if errors.Is(err, projects.ErrQuotaExceeded) {
+						apierror.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+						return
+					}
+					apierror.Respond(w, http.StatusBadRequest, "error")
 					return
 				}
 				w.WriteHeader(http.StatusCreated)
@@ -295,30 +305,40 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 				p := r.URL.Query().Get("path")
 				if err := a.projectsService.DeleteFile(r.Context(), userID, projectID, p); err != nil {
 					log.Error().Err(err).Str("project", projectID).Str("path", p).Msg("delete_file")
-					http.Error(w, "error", http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, "error")
 					return
 				}
 				w.WriteHeader(http.StatusNoContent)
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			}
+			return
+		case "preview":
+			if r.Method != http.MethodGet {
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
 			}
+			a.projectFilePreview(w, r, userID, projectID)
 			return
 		case "dirs":
 			if r.Method != http.MethodPost {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			p := r.URL.Query().Get("path")
 			if err := a.projectsService.CreateDir(r.Context(), userID, projectID, p); err != nil {
 				log.Error().Err(err).Str("project", projectID).Str("path", p).Msg("create_dir")
-				http.Error(w, "error", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "error")
 				return
 			}
 			w.WriteHeader(http.StatusCreated)
 			return
+		case "env":
+			a.projectEnvHandler(w, r, userID, projectID)
+			return
 		case "move":
 			if r.Method != http.MethodPost {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 				return
 			}
 			defer r.Body.Close()
@@ -326,13 +346,12 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 				From string `json:"from"`
 				To   string `json:"to"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &in, 0) {
 				return
 			}
 			if err := a.projectsService.MovePath(r.Context(), userID, projectID, in.From, in.To); err != nil {
 				log.Error().Err(err).Str("project", projectID).Str("from", in.From).Str("to", in.To).Msg("move_path")
-				http.Error(w, "error", http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, "error")
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
@@ -342,17 +361,69 @@ func (a *app) projectDetailHandler() http.HandlerFunc {
 	}
 }
 
-func (a *app) projectsCORS(w http.ResponseWriter, r *http.Request, methods string) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Vary", "Origin")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-	} else {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+// projectEnvHandler manages a project's saved environment variables. Values
+// are write-only: GET lists names and update times, PUT/POST sets one
+// variable, and DELETE ?name= removes one. Variables are injected into tool
+// execution for runs that specify the project.
+func (a *app) projectEnvHandler(w http.ResponseWriter, r *http.Request, userID int64, projectID string) {
+	if a.projectEnv == nil {
+		apierror.Respond(w, http.StatusServiceUnavailable, "project env unavailable")
+		return
 	}
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
-	if methods != "" {
-		w.Header().Set("Access-Control-Allow-Methods", methods)
+	switch r.Method {
+	case http.MethodGet:
+		vars, err := a.projectEnv.List(r.Context(), userID, projectID)
+		if err != nil {
+			log.Error().Err(err).Str("project", projectID).Msg("list_project_env")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if vars == nil {
+			vars = []persist.ProjectEnvVar{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"vars": vars})
+	case http.MethodPut, http.MethodPost:
+		defer r.Body.Close()
+		var in struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}
+		if !apierror.Decode(w, r, &in, 64<<10) {
+			return
+		}
+		in.Name = strings.TrimSpace(in.Name)
+		if err := sandbox.ValidateEnvName(in.Name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := a.projectsService.ListTree(r.Context(), userID, projectID, "."); err != nil {
+			apierror.Respond(w, http.StatusNotFound, "not found")
+			return
+		}
+		if err := a.projectEnv.Set(r.Context(), userID, projectID, in.Name, in.Value); err != nil {
+			log.Error().Err(err).Str("project", projectID).Str("name", in.Name).Msg("set_project_env")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if name == "" {
+			apierror.Respond(w, http.StatusBadRequest, "missing name")
+			return
+		}
+		if err := a.projectEnv.Delete(r.Context(), userID, projectID, name); err != nil {
+			if errors.Is(err, persist.ErrNotFound) {
+				apierror.Respond(w, http.StatusNotFound, "not found")
+				return
+			}
+			log.Error().Err(err).Str("project", projectID).Str("name", name).Msg("delete_project_env")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
@@ -429,6 +500,39 @@ func (a *app) archiveSingleFile(ctx context.Context, tw *tar.Writer, userID int6
 }
 
 // streamProjectArchive creates a tar.gz archive of a project or subpath and streams it.
+// projectFilePreview serves GET /api/projects/{id}/preview?path=..., a typed
+// preview (CSV rows, rendered markdown, image thumbnail, audio duration) so
+// callers can inspect a file without downloading it. Optional rows and thumb
+// query params bound the CSV row count and thumbnail edge.
+func (a *app) projectFilePreview(w http.ResponseWriter, r *http.Request, userID int64, projectID string) {
+	q := r.URL.Query()
+	p := q.Get("path")
+	if p == "" {
+		apierror.Respond(w, http.StatusBadRequest, "missing path")
+		return
+	}
+	previewer := a.projectPreviewer
+	if previewer == nil {
+		previewer = projects.NewPreviewer(a.projectsService, 1)
+	}
+	opts := projects.PreviewOptions{}
+	opts.Rows, _ = strconv.Atoi(q.Get("rows"))
+	opts.ThumbnailSize, _ = strconv.Atoi(q.Get("thumb"))
+	preview, err := previewer.Preview(r.Context(), userID, projectID, p, opts)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			apierror.Respond(w, http.StatusNotFound, "not found")
+			return
+		}
+		log.Error().Err(err).Str("project", projectID).Str("path", p).Msg("preview_file")
+		apierror.Respond(w, http.StatusUnprocessableEntity, "preview unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	_ = json.NewEncoder(w).Encode(preview)
+}
+
 func (a *app) streamProjectArchive(w http.ResponseWriter, r *http.Request, userID int64, projectID string) {
 	ctx := r.Context()
 	sourcePath := strings.TrimSpace(r.URL.Query().Get("path"))
@@ -440,7 +544,7 @@ func (a *app) streamProjectArchive(w http.ResponseWriter, r *http.Request, userI
 	projects, err := a.projectsService.ListProjects(ctx, userID)
 	if err != nil {
 		log.Error().Err(err).Str("project", projectID).Msg("archive_list_projects")
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		apierror.Respond(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 	projectName := projectID
@@ -464,7 +568,7 @@ func (a *app) streamProjectArchive(w http.ResponseWriter, r *http.Request, userI
 					Str("project", projectID).
 					Str("path", sourcePath).
 					Msg("archive_path_not_found")
-				http.Error(w, "not found", http.StatusNotFound)
+				apierror.Respond(w, http.StatusNotFound, "not found")
 				return
 			}
 			_ = rc.Close()
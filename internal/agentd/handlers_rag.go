@@ -0,0 +1,82 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/rag/retrieve"
+)
+
+// ragQuerier runs hybrid retrieval; implemented by the rag_query tool so the
+// HTTP endpoint and the tool share one configuration.
+type ragQuerier interface {
+	Query(ctx context.Context, query string, k int, tenant string, filter map[string]string) (retrieve.RetrieveResponse, error)
+}
+
+// buildRAGReranker returns the configured reranker, or nil when reranking
+// is disabled.
+func buildRAGReranker(cfg config.RAGRerankConfig, summaryLLM llm.Provider, summaryModel string, httpClient *http.Client) retrieve.Reranker {
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "llm":
+		model := strings.TrimSpace(cfg.Model)
+		if model == "" {
+			model = summaryModel
+		}
+		return retrieve.LLMReranker{Provider: summaryLLM, Model: model}
+	case "cross-encoder", "cross_encoder":
+		return retrieve.CrossEncoderReranker{BaseURL: cfg.BaseURL, Model: cfg.Model, APIKey: cfg.APIKey, Client: httpClient}
+	default:
+		return nil
+	}
+}
+
+// ragQueryHandler handles GET /api/rag/query?q=...&k=...&tenant=...
+// Additional query parameters prefixed with "filter." become metadata filters.
+func (a *app) ragQueryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if _, err := a.requireUserID(r); err != nil {
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if a.ragQuery == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "rag unavailable")
+			return
+		}
+		qs := r.URL.Query()
+		q := strings.TrimSpace(qs.Get("q"))
+		if q == "" {
+			apierror.Respond(w, http.StatusBadRequest, "q is required")
+			return
+		}
+		k := 0
+		if raw := qs.Get("k"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 || n > 100 {
+				apierror.Respond(w, http.StatusBadRequest, "k must be between 1 and 100")
+				return
+			}
+			k = n
+		}
+		filter := map[string]string{}
+		for key, vals := range qs {
+			if name, ok := strings.CutPrefix(key, "filter."); ok && name != "" && len(vals) > 0 {
+				filter[name] = vals[0]
+			}
+		}
+		resp, err := a.ragQuery.Query(r.Context(), q, k, qs.Get("tenant"), filter)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"query": resp.Query, "items": resp.Items})
+	}
+}
@@ -0,0 +1,123 @@
+package agentd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	persist "manifold/internal/persistence"
+	"manifold/internal/secrets"
+)
+
+// secretsHandler handles GET /api/secrets, listing secret names and
+// versions, and POST /api/secrets, creating or rotating one. Values are
+// write-only.
+func (a *app) secretsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.requireAdmin(w, r) {
+			return
+		}
+		if a.secrets == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "secrets are disabled; set secrets.masterKey")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			list, err := a.secrets.List(r.Context())
+			if err != nil {
+				log.Error().Err(err).Msg("list_secrets")
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if list == nil {
+				list = []persist.Secret{}
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"secrets": list})
+		case http.MethodPost:
+			var in struct {
+				Name string `json:"name"`
+				secretBody
+			}
+			if !decodeSecretBody(w, r, &in) {
+				return
+			}
+			a.putSecret(w, r, strings.TrimSpace(in.Name), in.secretBody)
+		default:
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// secretDetailHandler handles PUT /api/secrets/{name}, rotating the value
+// and scope, and DELETE /api/secrets/{name}.
+func (a *app) secretDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.requireAdmin(w, r) {
+			return
+		}
+		if a.secrets == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "secrets are disabled; set secrets.masterKey")
+			return
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/secrets/"))
+		if name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var in secretBody
+			if !decodeSecretBody(w, r, &in) {
+				return
+			}
+			a.putSecret(w, r, name, in)
+		case http.MethodDelete:
+			if err := a.secrets.Delete(r.Context(), name); err != nil {
+				if errors.Is(err, persist.ErrNotFound) {
+					apierror.Respond(w, http.StatusNotFound, "not found")
+					return
+				}
+				log.Error().Err(err).Str("name", name).Msg("delete_secret")
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// secretBody is the value and scope of a created or rotated secret. Tools
+// send it only to hosts under Domains and only for runs of UserIDs.
+type secretBody struct {
+	Value   string   `json:"value"`
+	Domains []string `json:"domains"`
+	UserIDs []int64  `json:"userIds"`
+}
+
+func decodeSecretBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	defer r.Body.Close()
+	return apierror.Decode(w, r, v, 64<<10)
+}
+
+func (a *app) putSecret(w http.ResponseWriter, r *http.Request, name string, in secretBody) {
+	if in.Value == "" {
+		apierror.RespondInvalid(w, "value", "required")
+		return
+	}
+	sec, err := a.secrets.Set(r.Context(), name, in.Value, secrets.Scope{Domains: in.Domains, UserIDs: in.UserIDs})
+	if err != nil {
+		if errors.Is(err, secrets.ErrInvalidName) || errors.Is(err, secrets.ErrNoDomains) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		log.Error().Err(err).Str("name", name).Msg("set_secret")
+		apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, sec)
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"manifold/internal/apierror"
 	llmproviders "manifold/internal/llm/providers"
+	"manifold/internal/llm/resilient"
 	persist "manifold/internal/persistence"
 	"manifold/internal/specialists"
 	"manifold/internal/tools"
@@ -24,26 +26,47 @@ func (a *app) statusHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		type agentStatus struct {
 			ID        string `json:"id"`
 			Name      string `json:"name"`
 			State     string `json:"state"`
 			Model     string `json:"model"`
 			UpdatedAt string `json:"updatedAt"`
+			// Kind is "llm_provider" for circuit-breaker entries; empty for agents.
+			Kind    string                   `json:"kind,omitempty"`
+			Breaker *resilient.BreakerStatus `json:"breaker,omitempty"`
+			// LatencyMs and Error come from the last health probe, if any.
+			LatencyMs int64  `json:"latencyMs,omitempty"`
+			Error     string `json:"error,omitempty"`
+			// Concurrency reports the specialist's request limiter, if any.
+			Concurrency *specialists.LimitStats `json:"concurrency,omitempty"`
 		}
 		list, err := a.specStore.List(r.Context(), userID)
 		if err != nil {
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 			return
 		}
+		health := map[string]persist.SpecialistHealth{}
+		if a.specHealth != nil {
+			probes, err := a.specHealth.List(r.Context(), userID)
+			if err != nil {
+				log.Warn().Err(err).Msg("specialist_health_list_failed")
+			}
+			for _, h := range probes {
+				health[h.Name] = h
+			}
+		}
+		reg, err := a.specialistsRegistryForUser(r.Context(), userID)
+		if err != nil {
+			log.Warn().Err(err).Msg("specialist_registry_unavailable")
+		}
 		now := time.Now().UTC().Format(time.RFC3339)
 		out := make([]agentStatus, 0, len(list))
 		for _, s := range list {
@@ -52,30 +75,93 @@ func (a *app) statusHandler() http.HandlerFunc {
 				// from the Overview cards so they read as "offline".
 				continue
 			}
-			out = append(out, agentStatus{
+			st := agentStatus{
 				ID:        s.Name,
 				Name:      s.Name,
-				State:     "online",
+				State:     specialists.HealthOnline,
 				Model:     s.Model,
 				UpdatedAt: now,
+			}
+			// Specialists that have not been probed yet keep reading as online.
+			if h, ok := health[s.Name]; ok {
+				st.State = h.State
+				st.UpdatedAt = h.CheckedAt.UTC().Format(time.RFC3339)
+				st.LatencyMs = h.LatencyMs
+				st.Error = h.Error
+			}
+			if reg != nil {
+				if sp, ok := reg.Get(s.Name); ok && sp != nil {
+					if ls, ok := sp.LimitStats(); ok {
+						st.Concurrency = &ls
+					}
+				}
+			}
+			out = append(out, st)
+		}
+		for _, br := range resilient.Breakers() {
+			state := "online"
+			switch br.State {
+			case resilient.StateOpen:
+				state = "offline"
+			case resilient.StateHalfOpen:
+				state = "degraded"
+			}
+			out = append(out, agentStatus{
+				ID:        "llm:" + br.BaseURL,
+				Name:      br.BaseURL,
+				State:     state,
+				UpdatedAt: now,
+				Kind:      "llm_provider",
+				Breaker:   &br,
 			})
 		}
 		w.Header().Set("Content-Type", "application/json")
+		// The default response stays a bare array for existing dashboards;
+		// ?insights=1 wraps it with aggregate usage for today.
+		if v := r.URL.Query().Get("insights"); v == "1" || strings.EqualFold(v, "true") {
+			insights, err := a.statusInsights(r.Context(), userID)
+			if err != nil {
+				log.Error().Err(err).Msg("status_insights_failed")
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"agents": out, "insights": insights})
+			return
+		}
 		json.NewEncoder(w).Encode(out)
 	}
 }
 
+// specialistHealthUsers lists the users whose specialists the health checker
+// probes: the system user plus every account when auth is enabled.
+func (a *app) specialistHealthUsers(ctx context.Context) ([]int64, error) {
+	ids := []int64{systemUserID}
+	if !a.cfg.Auth.Enabled || a.authStore == nil {
+		return ids, nil
+	}
+	users, err := a.authStore.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.ID != systemUserID {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
 func (a *app) specialistDefaultsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if _, err := a.requireUserID(r); err != nil {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -102,14 +188,14 @@ func (a *app) specialistsHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		switch r.Method {
 		case http.MethodGet:
 			list, err := a.listSpecialistsForUser(r.Context(), userID)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -119,13 +205,12 @@ func (a *app) specialistsHandler() http.HandlerFunc {
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 			defer r.Body.Close()
 			var sp persist.Specialist
-			if err := json.NewDecoder(r.Body).Decode(&sp); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &sp, 0) {
 				return
 			}
 			saved, status, err := a.createSpecialistForUser(r.Context(), userID, sp)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -133,7 +218,7 @@ func (a *app) specialistsHandler() http.HandlerFunc {
 			json.NewEncoder(w).Encode(saved)
 
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -145,7 +230,7 @@ func (a *app) specialistDetailHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		name := strings.TrimPrefix(r.URL.Path, "/api/specialists/")
@@ -159,7 +244,7 @@ func (a *app) specialistDetailHandler() http.HandlerFunc {
 		case http.MethodGet:
 			sp, ok, err := a.getSpecialistForUser(r.Context(), userID, name)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if !ok {
@@ -172,13 +257,12 @@ func (a *app) specialistDetailHandler() http.HandlerFunc {
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 			defer r.Body.Close()
 			var sp persist.Specialist
-			if err := json.NewDecoder(r.Body).Decode(&sp); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &sp, 0) {
 				return
 			}
 			saved, err := a.updateSpecialistForUser(r.Context(), userID, name, sp)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -186,15 +270,15 @@ func (a *app) specialistDetailHandler() http.HandlerFunc {
 		case http.MethodDelete:
 			if err := a.deleteSpecialistForUser(r.Context(), userID, name); err != nil {
 				if err == errOrchestratorDelete {
-					http.Error(w, err.Error(), http.StatusBadRequest)
+					apierror.Respond(w, http.StatusBadRequest, err.Error())
 					return
 				}
-				http.Error(w, "error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "error")
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"manifold/internal/apierror"
 	"manifold/internal/persistence"
 	"manifold/internal/specialists"
 )
@@ -16,14 +17,14 @@ func (a *app) teamsHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		switch r.Method {
 		case http.MethodGet:
 			list, err := a.listTeamsForUser(r.Context(), userID)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -32,20 +33,19 @@ func (a *app) teamsHandler() http.HandlerFunc {
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 			defer r.Body.Close()
 			var g persistence.SpecialistTeam
-			if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &g, 0) {
 				return
 			}
 			saved, err := a.createTeamForUser(r.Context(), userID, g)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(saved)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -57,7 +57,7 @@ func (a *app) teamDetailHandler() http.HandlerFunc {
 			if a.cfg.Auth.Enabled {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
 			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		path := strings.TrimPrefix(r.URL.Path, "/api/teams/")
@@ -74,18 +74,18 @@ func (a *app) teamDetailHandler() http.HandlerFunc {
 						http.NotFound(w, r)
 						return
 					}
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				w.WriteHeader(http.StatusNoContent)
 			case http.MethodDelete:
 				if err := a.removeSpecialistFromTeamForUser(r.Context(), userID, teamName, specialistName); err != nil {
-					http.Error(w, "internal server error", http.StatusInternalServerError)
+					apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 					return
 				}
 				w.WriteHeader(http.StatusNoContent)
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			}
 			return
 		}
@@ -100,7 +100,7 @@ func (a *app) teamDetailHandler() http.HandlerFunc {
 		case http.MethodGet:
 			g, ok, err := a.getTeamForUser(r.Context(), userID, name)
 			if err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			if !ok {
@@ -113,25 +113,24 @@ func (a *app) teamDetailHandler() http.HandlerFunc {
 			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 			defer r.Body.Close()
 			var g persistence.SpecialistTeam
-			if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-				http.Error(w, "bad request", http.StatusBadRequest)
+			if !apierror.Decode(w, r, &g, 0) {
 				return
 			}
 			saved, err := a.updateTeamForUser(r.Context(), userID, name, g)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(saved)
 		case http.MethodDelete:
 			if err := a.deleteTeamForUser(r.Context(), userID, name); err != nil {
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Respond(w, http.StatusInternalServerError, "internal server error")
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
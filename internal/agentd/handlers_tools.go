@@ -11,6 +11,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"manifold/internal/apierror"
 	"manifold/internal/auth"
 	llmpkg "manifold/internal/llm"
 )
@@ -43,26 +44,25 @@ func (a *app) metricsTokensHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			uid = u.ID
 		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 
 		window, err := parseWindowParam(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		if window < 0 {
-			http.Error(w, "window must be positive", http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, "window must be positive")
 			return
 		}
 
@@ -117,21 +117,20 @@ func (a *app) metricsTracesHandler() http.HandlerFunc {
 			u, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			uid = u.ID
 		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 
 		window, err := parseWindowParam(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		limit := parseLimitParam(r, 200)
@@ -183,20 +182,19 @@ func (a *app) metricsLogsHandler() http.HandlerFunc {
 			_, ok := auth.CurrentUser(r.Context())
 			if !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 
 		window, err := parseWindowParam(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Respond(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		limit := parseLimitParam(r, 200)
@@ -308,12 +306,12 @@ func (a *app) toolsCatalogHandler() http.HandlerFunc {
 		if a.cfg.Auth.Enabled {
 			if _, ok := auth.CurrentUser(r.Context()); !ok {
 				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
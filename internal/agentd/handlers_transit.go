@@ -1,12 +1,12 @@
 package agentd
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"manifold/internal/apierror"
 	"manifold/internal/persistence"
 	transitdomain "manifold/internal/transit"
 )
@@ -19,7 +19,7 @@ func (a *app) transitMemoriesHandler() http.HandlerFunc {
 		}
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		switch r.Method {
@@ -27,8 +27,7 @@ func (a *app) transitMemoriesHandler() http.HandlerFunc {
 			var req struct {
 				Items []transitdomain.CreateMemoryItem `json:"items"`
 			}
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				writeError(w, http.StatusBadRequest, err)
+			if !apierror.Decode(w, r, &req, 0) {
 				return
 			}
 			records, err := a.transitService.CreateMemory(r.Context(), userID, userID, req.Items)
@@ -53,7 +52,7 @@ func (a *app) transitMemoriesHandler() http.HandlerFunc {
 			}
 			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
@@ -66,7 +65,7 @@ func (a *app) transitMemoryDetailHandler() http.HandlerFunc {
 		}
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		key := strings.TrimPrefix(r.URL.Path, "/api/transit/memories/")
@@ -76,12 +75,11 @@ func (a *app) transitMemoryDetailHandler() http.HandlerFunc {
 			return
 		}
 		if r.Method != http.MethodPut {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		var req transitdomain.UpdateMemoryRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, err)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 		req.KeyName = key
@@ -102,11 +100,11 @@ func (a *app) transitKeysHandler() http.HandlerFunc {
 		}
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		items, err := a.transitService.ListKeys(r.Context(), userID, transitdomain.ListRequest{
@@ -129,11 +127,11 @@ func (a *app) transitRecentHandler() http.HandlerFunc {
 		}
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		items, err := a.transitService.ListRecent(r.Context(), userID, transitdomain.ListRequest{
@@ -164,16 +162,15 @@ func (a *app) transitSearchLikeHandler(discover bool) http.HandlerFunc {
 		}
 		userID, err := a.requireUserID(r)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 		var req transitdomain.SearchRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, err)
+		if !apierror.Decode(w, r, &req, 0) {
 			return
 		}
 		if discover {
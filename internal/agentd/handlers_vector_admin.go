@@ -0,0 +1,139 @@
+package agentd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"manifold/internal/apierror"
+	"manifold/internal/auth"
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+)
+
+// requireAdmin allows the request when auth is disabled (single-user mode)
+// or the current user has the admin role. It writes the error response and
+// returns false otherwise.
+func (a *app) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !a.cfg.Auth.Enabled || a.authStore == nil {
+		return true
+	}
+	u, ok := auth.CurrentUser(r.Context())
+	if !ok || u == nil {
+		apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+		return false
+	}
+	if okRole, _ := a.authStore.HasRole(r.Context(), u.ID, "admin"); !okRole {
+		apierror.Respond(w, http.StatusForbidden, "forbidden")
+		return false
+	}
+	return true
+}
+
+// vectorIndexManager returns the configured vector store's index manager,
+// or nil when the backend does not manage ANN indexes.
+func (a *app) vectorIndexManager() databases.VectorIndexManager {
+	if a.mgr == nil {
+		return nil
+	}
+	im, _ := a.mgr.Vector.(databases.VectorIndexManager)
+	return im
+}
+
+// vectorIndexHandler handles GET /api/admin/vector/index?recall=1, reporting
+// ANN index health and optionally a sampled recall estimate.
+func (a *app) vectorIndexHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !a.requireAdmin(w, r) {
+			return
+		}
+		im := a.vectorIndexManager()
+		if im == nil {
+			apierror.Respond(w, http.StatusNotImplemented, "vector backend does not support index management")
+			return
+		}
+		recall := strings.TrimSpace(r.URL.Query().Get("recall"))
+		st, err := im.VectorIndexStatus(r.Context(), recall == "1" || strings.EqualFold(recall, "true"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, st)
+	}
+}
+
+// vectorReindexHandler handles POST /api/admin/vector/reindex. Omitted body
+// fields fall back to databases.vector in the config.
+func (a *app) vectorReindexHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !a.requireAdmin(w, r) {
+			return
+		}
+		im := a.vectorIndexManager()
+		if im == nil {
+			apierror.Respond(w, http.StatusNotImplemented, "vector backend does not support index management")
+			return
+		}
+		var in struct {
+			databases.VectorIndexOptions
+			Concurrently bool `json:"concurrently"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+		defer r.Body.Close()
+		if !apierror.DecodeOptional(w, r, &in, 0) {
+			return
+		}
+		opts := mergeVectorIndexOptions(in.VectorIndexOptions, a.cfg.Databases.Vector)
+		switch opts.Type {
+		case databases.VectorIndexIVFFlat, databases.VectorIndexHNSW:
+		case "":
+			apierror.Respond(w, http.StatusBadRequest, "type is required (ivfflat or hnsw)")
+			return
+		default:
+			apierror.Respond(w, http.StatusBadRequest, "type must be ivfflat or hnsw")
+			return
+		}
+		st, err := im.ReindexVectors(r.Context(), opts, in.Concurrently)
+		if err != nil {
+			if errors.Is(err, databases.ErrVectorIndexUnsupported) {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, st)
+	}
+}
+
+// mergeVectorIndexOptions fills unset request fields from the vector config.
+func mergeVectorIndexOptions(in databases.VectorIndexOptions, cfg config.VectorConfig) databases.VectorIndexOptions {
+	in.Type = strings.ToLower(strings.TrimSpace(in.Type))
+	if in.Type == "" {
+		in.Type = strings.ToLower(strings.TrimSpace(cfg.IndexType))
+	}
+	if in.Lists <= 0 {
+		in.Lists = cfg.Lists
+	}
+	if in.Probes <= 0 {
+		in.Probes = cfg.Probes
+	}
+	if in.M <= 0 {
+		in.M = cfg.M
+	}
+	if in.EfConstruction <= 0 {
+		in.EfConstruction = cfg.EfConstruction
+	}
+	if in.EfSearch <= 0 {
+		in.EfSearch = cfg.EfSearch
+	}
+	return in
+}
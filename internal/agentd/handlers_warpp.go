@@ -0,0 +1,224 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"manifold/internal/apierror"
+	"manifold/internal/flow"
+	persist "manifold/internal/persistence"
+)
+
+// warppRunHandler runs a saved workflow and waits for it to finish. With
+// Accept: text/event-stream it streams the run using the /agent/run event
+// shapes: tool_start and tool_result per step, then final or error.
+// Otherwise it responds once with the run result.
+func (a *app) warppRunHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := a.requireFlowV2User(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodPost {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+		defer r.Body.Close()
+
+		var req flow.RunRequest
+		if !apierror.Decode(w, r, &req, 0) {
+			return
+		}
+		if strings.TrimSpace(req.WorkflowID) == "" {
+			apierror.RespondInvalid(w, "workflow_id", "required")
+			return
+		}
+		wf, _, found, err := a.flowV2State().getWorkflow(r.Context(), userID, req.WorkflowID)
+		if err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if !found {
+			apierror.Respond(w, http.StatusNotFound, "workflow not found")
+			return
+		}
+		if req.DryRun {
+			writeFlowV2JSON(w, http.StatusOK, a.dryRunFlowV2(wf, req.Input))
+			return
+		}
+		plan, diags := flow.CompileWorkflow(wf)
+		if hasFlowV2Errors(diags) || plan == nil {
+			writeFlowV2JSON(w, http.StatusUnprocessableEntity, flow.ValidateResponse{
+				Valid:       false,
+				Diagnostics: diags,
+			})
+			return
+		}
+
+		ctx := r.Context()
+		projectID := strings.TrimSpace(req.ProjectID)
+		if projectID == "" {
+			projectID = strings.TrimSpace(wf.ProjectID)
+		}
+		if projectID != "" {
+			if ctx, err = workflowToolContext(ctx, a.cfg, userID, projectID); err != nil {
+				apierror.Respond(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		} else if ctx, err = a.withScratchDir(ctx, userID); err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, "failed to prepare workspace")
+			return
+		}
+		seconds := a.cfg.WorkflowTimeoutSeconds
+		if seconds <= 0 {
+			seconds = a.cfg.AgentRunTimeoutSeconds
+		}
+		runCtx, cancel, _ := withMaybeTimeout(ctx, seconds)
+		defer cancel()
+
+		runID := a.flowV2State().createRun(userID, wf.ID, persist.WorkflowTriggerWARPP, req.Input)
+		if r.Header.Get("Accept") != "text/event-stream" {
+			a.executeFlowV2Run(runCtx, userID, runID, wf, plan, req.Input)
+			events, status, _ := a.flowV2State().getRunEvents(userID, runID)
+			result, err := workflowRunResult(wf, plan, runID, events, status)
+			if err != nil {
+				writeFlowV2JSON(w, http.StatusInternalServerError, map[string]any{
+					"run_id": runID,
+					"status": status,
+					"error":  err.Error(),
+				})
+				return
+			}
+			writeFlowV2JSON(w, http.StatusOK, result)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Run-ID", runID)
+		stream, err := newChatSSEWriter(w)
+		if err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		stream.bindRun(a.sseRuns, runID, userID, sseEnvelopeRequested(r))
+		defer a.sseRuns.finish(runID)
+		defer startSSEHeartbeat(runCtx, stream.writeText)()
+		a.streamWarppRun(runCtx, stream, userID, runID, wf, plan, req.Input)
+	}
+}
+
+// streamWarppRun executes the run and forwards its node events to stream as
+// they happen. Subscriptions drop events when the subscriber falls behind,
+// so gaps in the sequence are filled from the run's event log.
+func (a *app) streamWarppRun(ctx context.Context, stream *chatSSEWriter, userID int64, runID string, wf flow.Workflow, plan *flow.Plan, input map[string]any) {
+	state := a.flowV2State()
+	_, ch, _, _ := state.subscribeRun(userID, runID)
+	defer state.unsubscribeRun(runID, ch)
+
+	nodeByID := make(map[string]flow.Node, len(wf.Nodes))
+	for _, n := range wf.Nodes {
+		nodeByID[n.ID] = n
+	}
+	var last int64
+	forward := func(ev flow.RunEvent) {
+		if ev.Sequence <= last {
+			return
+		}
+		last = ev.Sequence
+		if payload := warppStreamEvent(ev, nodeByID[ev.NodeID]); payload != nil {
+			stream.write(payload)
+		}
+	}
+	catchUp := func() {
+		events, _, _ := state.getRunEvents(userID, runID)
+		for _, ev := range events {
+			forward(ev)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.executeFlowV2Run(ctx, userID, runID, wf, plan, input)
+	}()
+	for running := true; running; {
+		select {
+		case ev := <-ch:
+			if ev.Sequence > last+1 {
+				catchUp()
+			}
+			forward(ev)
+		case <-done:
+			catchUp()
+			running = false
+		}
+	}
+
+	events, status, _ := state.getRunEvents(userID, runID)
+	result, err := workflowRunResult(wf, plan, runID, events, status)
+	if err != nil {
+		stream.write(map[string]any{"type": "error", "data": "(error) " + err.Error(), "run_id": runID})
+		return
+	}
+	stream.write(map[string]any{"type": "final", "data": warppFinalText(result), "run_id": runID, "result": result})
+}
+
+// warppStreamEvent maps a node event to the /agent/run stream shapes. It
+// returns nil for events that have no equivalent, such as retries and
+// run-level events.
+func warppStreamEvent(ev flow.RunEvent, node flow.Node) map[string]any {
+	title := "Step: " + node.Name
+	if node.Type == "tool" && strings.TrimSpace(node.Tool) != "" {
+		title = "Tool: " + node.Tool
+	}
+	payload := map[string]any{"title": title, "tool_id": ev.NodeID, "node_id": ev.NodeID, "run_id": ev.RunID}
+	switch ev.Type {
+	case flow.RunEventTypeNodeStarted:
+		payload["type"] = "tool_start"
+	case flow.RunEventTypeNodeCompleted:
+		payload["type"] = "tool_result"
+		payload["status"] = "completed"
+		payload["data"] = warppStepData(ev.Output)
+	case flow.RunEventTypeNodeFailed:
+		payload["type"] = "tool_result"
+		payload["status"] = "failed"
+		payload["data"] = "(error) " + ev.Error
+		payload["error"] = ev.Error
+	case flow.RunEventTypeNodeSkipped:
+		payload["type"] = "tool_result"
+		payload["status"] = "skipped"
+		payload["data"] = ev.Message
+	default:
+		return nil
+	}
+	return payload
+}
+
+// warppStepData renders a step output the way /agent/run renders tool
+// results: the raw tool payload when there is one, JSON otherwise.
+func warppStepData(output map[string]any) string {
+	if payload, ok := output["payload"].(string); ok {
+		return payload
+	}
+	b, _ := json.Marshal(output)
+	return string(b)
+}
+
+func warppFinalText(result map[string]any) string {
+	if payload, ok := result["payload"].(string); ok {
+		return payload
+	}
+	v, ok := result["payload"]
+	if !ok {
+		v = result["final_output"]
+	}
+	if v == nil {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,151 @@
+package agentd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/flow"
+	"manifold/internal/tools"
+	"manifold/internal/tools/utility"
+)
+
+func newWarppTestApp(t *testing.T) *app {
+	t.Helper()
+	reg := tools.NewRegistry()
+	reg.Register(utility.NewTextboxTool())
+	a := &app{
+		cfg:              &config.Config{},
+		baseToolRegistry: reg,
+		flowV2:           newFlowV2Runtime(nil, nil),
+	}
+	_, _, _ = a.flowV2.upsertWorkflow(context.Background(), 0, flow.Workflow{
+		ID:      "wf_warpp",
+		Name:    "WARPP Flow",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{
+			{
+				ID:   "first",
+				Name: "First",
+				Kind: flow.NodeKindAction,
+				Type: "tool",
+				Tool: "utility_textbox",
+				Inputs: map[string]flow.InputBinding{
+					"text": {Expression: "={{$run.input.text}}"},
+				},
+			},
+			{
+				ID:   "second",
+				Name: "Second",
+				Kind: flow.NodeKindAction,
+				Type: "tool",
+				Tool: "utility_textbox",
+				Inputs: map[string]flow.InputBinding{
+					"text": {Expression: "={{$node.first.output.text}}"},
+				},
+			},
+		},
+		Edges: []flow.Edge{
+			{Source: flow.PortRef{NodeID: "first", Port: "result"}, Target: flow.PortRef{NodeID: "second", Port: "input"}},
+		},
+	}, flow.WorkflowCanvas{})
+	return a
+}
+
+func TestWarppRunStreamsStepEvents(t *testing.T) {
+	t.Parallel()
+
+	a := newWarppTestApp(t)
+	body, _ := json.Marshal(flow.RunRequest{WorkflowID: "wf_warpp", Input: map[string]any{"text": "hello"}})
+	req := httptest.NewRequest(http.MethodPost, "/api/warpp/run", bytes.NewReader(body))
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	a.warppRunHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	runID := rec.Header().Get("X-Run-ID")
+	var types, ids []string
+	var final map[string]any
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		if id, ok := strings.CutPrefix(sc.Text(), "id: "); ok {
+			ids = append(ids, id)
+			continue
+		}
+		line, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("bad event %q: %v", line, err)
+		}
+		types = append(types, ev["type"].(string))
+		if ev["type"] == "final" {
+			final = ev
+		}
+	}
+	want := []string{"tool_start", "tool_result", "tool_start", "tool_result", "final"}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	if len(ids) != len(types) {
+		t.Fatalf("got %d ids for %d events: %v", len(ids), len(types), ids)
+	}
+	for i, id := range ids {
+		if want := sseEventID(runID, int64(i+1)); id != want {
+			t.Fatalf("event %d id = %q, want %q", i, id, want)
+		}
+	}
+	if data, _ := final["data"].(string); !strings.Contains(data, "hello") {
+		t.Fatalf("final event should carry the last step's payload, got %+v", final)
+	}
+}
+
+func TestWarppRunJSONResult(t *testing.T) {
+	t.Parallel()
+
+	a := newWarppTestApp(t)
+	body, _ := json.Marshal(flow.RunRequest{WorkflowID: "wf_warpp", Input: map[string]any{"text": "hi"}})
+	rec := httptest.NewRecorder()
+	a.warppRunHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/warpp/run", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var result map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if result["status"] != "completed" || result["final_node_id"] != "second" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// A missing input fails the first step, and the run reports the error.
+	body, _ = json.Marshal(flow.RunRequest{WorkflowID: "wf_warpp"})
+	rec = httptest.NewRecorder()
+	a.warppRunHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/warpp/run", bytes.NewReader(body)))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "run.input.text") {
+		t.Fatalf("expected a failed run, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWarppStreamEventShapes(t *testing.T) {
+	t.Parallel()
+
+	node := flow.Node{ID: "n1", Name: "Step", Type: "tool", Tool: "web_fetch"}
+	ev := warppStreamEvent(flow.RunEvent{Type: flow.RunEventTypeNodeCompleted, NodeID: "n1", Output: map[string]any{"payload": "body"}}, node)
+	if ev["type"] != "tool_result" || ev["title"] != "Tool: web_fetch" || ev["tool_id"] != "n1" || ev["data"] != "body" {
+		t.Fatalf("unexpected payload %+v", ev)
+	}
+	if warppStreamEvent(flow.RunEvent{Type: flow.RunEventTypeNodeRetrying, NodeID: "n1"}, node) != nil {
+		t.Fatal("retries have no /agent/run equivalent")
+	}
+}
@@ -0,0 +1,113 @@
+package agentd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	persist "manifold/internal/persistence"
+)
+
+// workflowRunsHandler serves GET /api/workflow-runs: the caller's workflow
+// run history, newest first, filtered by workflow_id, status, trigger,
+// since, until and limit.
+func (a *app) workflowRunsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := a.requireFlowV2User(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		history := a.flowV2State().history
+		if history == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "workflow run history unavailable")
+			return
+		}
+		filter, err := parseWorkflowRunFilter(r.URL.Query())
+		if err != nil {
+			apierror.Respond(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		runs, err := history.List(r.Context(), userID, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("list_workflow_runs")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		writeFlowV2JSON(w, http.StatusOK, map[string]any{"runs": runs})
+	}
+}
+
+// workflowRunDetailHandler serves GET /api/workflow-runs/{id}.
+func (a *app) workflowRunDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := a.requireFlowV2User(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		runID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/workflow-runs/"), "/")
+		if runID == "" || strings.Contains(runID, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		history := a.flowV2State().history
+		if history == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "workflow run history unavailable")
+			return
+		}
+		run, found, err := history.Get(r.Context(), userID, runID)
+		if err != nil {
+			log.Error().Err(err).Str("run", runID).Msg("get_workflow_run")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if !found {
+			apierror.Respond(w, http.StatusNotFound, "run not found")
+			return
+		}
+		writeFlowV2JSON(w, http.StatusOK, run)
+	}
+}
+
+func parseWorkflowRunFilter(q url.Values) (persist.WorkflowRunFilter, error) {
+	filter := persist.WorkflowRunFilter{
+		WorkflowID: strings.TrimSpace(q.Get("workflow_id")),
+		Status:     strings.TrimSpace(q.Get("status")),
+		Trigger:    strings.TrimSpace(q.Get("trigger")),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		raw := strings.TrimSpace(q.Get(p.name))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: expected an RFC 3339 time", p.name)
+		}
+		*p.dst = t.UTC()
+	}
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			return filter, fmt.Errorf("invalid limit: expected a positive integer")
+		}
+		filter.Limit = v
+	}
+	return filter, nil
+}
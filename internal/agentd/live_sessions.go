@@ -0,0 +1,599 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/auth"
+	"manifold/internal/llm"
+	persist "manifold/internal/persistence"
+	"manifold/internal/sandbox"
+)
+
+const (
+	liveSendBuffer   = 256
+	liveWriteTimeout = 10 * time.Second
+	livePingInterval = 30 * time.Second
+)
+
+var (
+	errLiveSessionFull = errors.New("live session is full")
+	errLiveRunBusy     = errors.New("a run is already in progress")
+)
+
+// liveUpgrader keeps gorilla's same-origin check so a third-party page cannot
+// join a session with the visitor's cookies.
+var liveUpgrader = websocket.Upgrader{ReadBufferSize: 4096, WriteBufferSize: 4096}
+
+// liveGrant is what the session owner allows an invited user to do.
+type liveGrant struct {
+	CanApproveTools bool `json:"can_approve_tools"`
+}
+
+// liveParticipant is one WebSocket connection to a live session. A user may
+// hold several.
+type liveParticipant struct {
+	userID int64
+	name   string
+	send   chan []byte
+	// close drops the connection; nil in tests.
+	close func()
+}
+
+// liveSession fans the runs of one chat session out to every participant.
+// Runs execute as the session owner, one at a time.
+type liveSession struct {
+	id    string
+	owner int64
+
+	mu           sync.Mutex
+	participants map[*liveParticipant]struct{}
+	grants       map[int64]liveGrant
+	cancelRun    context.CancelFunc
+}
+
+// liveSessionHub tracks live sessions by chat session id. Invitations and
+// grants live in memory and reset on restart.
+type liveSessionHub struct {
+	mu              sync.Mutex
+	maxParticipants int
+	sessions        map[string]*liveSession
+}
+
+func newLiveSessionHub(maxParticipants int) *liveSessionHub {
+	return &liveSessionHub{maxParticipants: maxParticipants, sessions: map[string]*liveSession{}}
+}
+
+// session returns the live session for id, creating it for owner.
+func (h *liveSessionHub) session(id string, owner int64) *liveSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ls, ok := h.sessions[id]
+	if !ok {
+		ls = &liveSession{id: id, owner: owner, participants: map[*liveParticipant]struct{}{}, grants: map[int64]liveGrant{}}
+		h.sessions[id] = ls
+	}
+	return ls
+}
+
+// lookup returns the live session for id if one exists.
+func (h *liveSessionHub) lookup(id string) (*liveSession, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ls, ok := h.sessions[id]
+	return ls, ok
+}
+
+// release forgets ls once nobody is connected or invited.
+func (h *liveSessionHub) release(ls *liveSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if len(ls.participants) == 0 && len(ls.grants) == 0 && h.sessions[ls.id] == ls {
+		delete(h.sessions, ls.id)
+	}
+}
+
+// join adds p unless the session already has max connections.
+func (ls *liveSession) join(p *liveParticipant, max int) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if max > 0 && len(ls.participants) >= max {
+		return errLiveSessionFull
+	}
+	ls.participants[p] = struct{}{}
+	return nil
+}
+
+// leave removes p and cancels the active run when the last participant goes.
+func (ls *liveSession) leave(p *liveParticipant) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if _, ok := ls.participants[p]; !ok {
+		return
+	}
+	delete(ls.participants, p)
+	close(p.send)
+	if len(ls.participants) == 0 && ls.cancelRun != nil {
+		ls.cancelRun()
+	}
+}
+
+// invited reports whether userID may join.
+func (ls *liveSession) invited(userID int64) bool {
+	if userID == ls.owner {
+		return true
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	_, ok := ls.grants[userID]
+	return ok
+}
+
+// canApprove reports whether a participant other than the owner may decide
+// tool approvals for the session's runs.
+func (ls *liveSession) canApprove(userID int64) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.grants[userID].CanApproveTools
+}
+
+func (ls *liveSession) grant(userID int64, g liveGrant) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.grants[userID] = g
+}
+
+// revoke removes userID's invitation and disconnects them.
+func (ls *liveSession) revoke(userID int64) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	_, ok := ls.grants[userID]
+	delete(ls.grants, userID)
+	for p := range ls.participants {
+		if p.userID == userID && p.close != nil {
+			p.close()
+		}
+	}
+	return ok
+}
+
+// beginRun claims the session for one run and returns its context, which is
+// cancelled when every participant has left.
+func (ls *liveSession) beginRun() (context.Context, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.cancelRun != nil {
+		return nil, errLiveRunBusy
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ls.cancelRun = cancel
+	return ctx, nil
+}
+
+func (ls *liveSession) endRun() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.cancelRun != nil {
+		ls.cancelRun()
+		ls.cancelRun = nil
+	}
+}
+
+// broadcast queues b for every participant. A participant too slow to keep
+// up is disconnected rather than left with a gap in the stream.
+func (ls *liveSession) broadcast(b []byte) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	for p := range ls.participants {
+		ls.enqueue(p, b)
+	}
+}
+
+// sendTo queues payload for p alone.
+func (ls *liveSession) sendTo(p *liveParticipant, payload any) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if _, ok := ls.participants[p]; ok {
+		ls.enqueue(p, b)
+	}
+}
+
+func (ls *liveSession) broadcastEvent(payload any) {
+	if b, err := json.Marshal(payload); err == nil {
+		ls.broadcast(b)
+	}
+}
+
+// enqueue must be called with ls.mu held.
+func (ls *liveSession) enqueue(p *liveParticipant, b []byte) {
+	select {
+	case p.send <- b:
+	default:
+		log.Warn().Str("session", ls.id).Int64("user_id", p.userID).Msg("live_participant_too_slow")
+		if p.close != nil {
+			p.close()
+		}
+	}
+}
+
+type liveParticipantInfo struct {
+	UserID          int64  `json:"user_id"`
+	Name            string `json:"name,omitempty"`
+	Owner           bool   `json:"owner,omitempty"`
+	Connected       bool   `json:"connected"`
+	CanApproveTools bool   `json:"can_approve_tools"`
+}
+
+// roster lists the owner, invited users, and connected participants.
+func (ls *liveSession) roster() []liveParticipantInfo {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	byUser := map[int64]*liveParticipantInfo{
+		ls.owner: {UserID: ls.owner, Owner: true, CanApproveTools: true},
+	}
+	for id, g := range ls.grants {
+		if id != ls.owner {
+			byUser[id] = &liveParticipantInfo{UserID: id, CanApproveTools: g.CanApproveTools}
+		}
+	}
+	for p := range ls.participants {
+		info, ok := byUser[p.userID]
+		if !ok {
+			continue
+		}
+		info.Name = p.name
+		info.Connected = true
+	}
+	out := make([]liveParticipantInfo, 0, len(byUser))
+	for _, info := range byUser {
+		out = append(out, *info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Owner != out[j].Owner {
+			return out[i].Owner
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
+
+// liveSessionFor resolves the live session behind sessionID and the caller's
+// identity. Callers must own the session or have been invited to it.
+func (a *app) liveSessionFor(ctx context.Context, userID *int64, sessionID string) (*liveSession, int64, int, error) {
+	sess, err := a.chatStore.GetSession(ctx, nil, sessionID)
+	if errors.Is(err, persist.ErrNotFound) {
+		return nil, 0, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, err
+	}
+	owner := systemUserID
+	if sess.UserID != nil {
+		owner = *sess.UserID
+	}
+	caller := systemUserID
+	if userID != nil {
+		caller = *userID
+	}
+	ls := a.liveSessions.session(sessionID, owner)
+	if !ls.invited(caller) {
+		a.liveSessions.release(ls)
+		return nil, 0, http.StatusForbidden, persist.ErrForbidden
+	}
+	return ls, caller, 0, nil
+}
+
+// liveMessage is a message sent by a participant over the WebSocket.
+type liveMessage struct {
+	Type       string `json:"type"`
+	Prompt     string `json:"prompt,omitempty"`
+	RunID      string `json:"run_id,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Decision   string `json:"decision,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// handleLiveSession serves GET /api/chat/sessions/{id}/live, upgrading to a
+// WebSocket shared by every participant of the session. Clients send
+// {"type":"prompt","prompt":"..."} and {"type":"approval","run_id":"...",
+// "tool_call_id":"...","decision":"approve"|"deny"}; the server broadcasts the
+// same events as the SSE chat stream plus user_message, participant_joined,
+// and participant_left.
+func (a *app) handleLiveSession(w http.ResponseWriter, r *http.Request, userID *int64, sessionID string) {
+	if a.liveSessions == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ls, caller, status, err := a.liveSessionFor(r.Context(), userID, sessionID)
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			log.Error().Err(err).Str("session", sessionID).Msg("live_session_lookup")
+		}
+		apierror.Respond(w, status, http.StatusText(status))
+		return
+	}
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied.
+		a.liveSessions.release(ls)
+		return
+	}
+	var closeOnce sync.Once
+	p := &liveParticipant{
+		userID: caller,
+		name:   liveParticipantName(r.Context(), caller),
+		send:   make(chan []byte, liveSendBuffer),
+		close:  func() { closeOnce.Do(func() { conn.Close() }) },
+	}
+	if err := ls.join(p, a.liveSessions.maxParticipants); err != nil {
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()), time.Now().Add(liveWriteTimeout))
+		p.close()
+		a.liveSessions.release(ls)
+		return
+	}
+	go liveWritePump(conn, p)
+	defer func() {
+		ls.leave(p)
+		p.close()
+		ls.broadcastEvent(map[string]any{"type": "participant_left", "user_id": p.userID, "name": p.name})
+		a.liveSessions.release(ls)
+	}()
+
+	ls.sendTo(p, map[string]any{
+		"type":         "session_state",
+		"session_id":   sessionID,
+		"user_id":      p.userID,
+		"participants": ls.roster(),
+	})
+	ls.broadcastEvent(map[string]any{"type": "participant_joined", "user_id": p.userID, "name": p.name})
+
+	conn.SetReadLimit(256 << 10)
+	for {
+		var msg liveMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "prompt":
+			a.startLivePrompt(ls, p, msg.Prompt)
+		case "approval":
+			a.decideLiveApproval(ls, p, msg)
+		default:
+			ls.sendTo(p, map[string]any{"type": "error", "data": fmt.Sprintf("unknown message type %q", msg.Type)})
+		}
+	}
+}
+
+func liveWritePump(conn *websocket.Conn, p *liveParticipant) {
+	ticker := time.NewTicker(livePingInterval)
+	defer ticker.Stop()
+	defer p.close()
+	for {
+		select {
+		case b, ok := <-p.send:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// liveParticipantName is the speaker label shown to other participants.
+func liveParticipantName(ctx context.Context, userID int64) string {
+	if u, ok := auth.CurrentUser(ctx); ok && u != nil {
+		if name := strings.TrimSpace(u.Name); name != "" {
+			return name
+		}
+		if email := strings.TrimSpace(u.Email); email != "" {
+			return email
+		}
+	}
+	if userID == systemUserID {
+		return "user"
+	}
+	return "user " + strconv.FormatInt(userID, 10)
+}
+
+// liveSpeakerPrompt attributes a prompt to its speaker in the stored history
+// so the agent and later readers can tell participants apart.
+func liveSpeakerPrompt(name, prompt string) string {
+	return "[" + name + "] " + prompt
+}
+
+// startLivePrompt runs prompt on behalf of the session owner and streams the
+// run to every participant. Only one run is active per session.
+func (a *app) startLivePrompt(ls *liveSession, p *liveParticipant, prompt string) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		ls.sendTo(p, map[string]any{"type": "error", "data": "prompt is required"})
+		return
+	}
+	runCtx, err := ls.beginRun()
+	if err != nil {
+		ls.sendTo(p, map[string]any{"type": "error", "data": err.Error()})
+		return
+	}
+	runCtx, err = a.withScratchDir(llm.WithUserID(sandbox.WithSessionID(runCtx, ls.id), ls.owner), ls.owner)
+	if err != nil {
+		ls.endRun()
+		ls.sendTo(p, map[string]any{"type": "error", "data": "failed to prepare workspace"})
+		return
+	}
+	if res := a.guardrails.CheckPrompt(runCtx, prompt); !res.Allowed {
+		ls.endRun()
+		res.Message = a.localizeGuardrailMessage(runCtx, res.Message)
+		ls.sendTo(p, guardrailViolationPayload(res))
+		return
+	}
+	quotaLimits, err := a.runQuota(runCtx, ls.owner)
+	if err != nil {
+		ls.endRun()
+		ls.sendTo(p, map[string]any{"type": "error", "data": err.Error()})
+		return
+	}
+	var storeUser *int64
+	if a.cfg.Auth.Enabled {
+		owner := ls.owner
+		storeUser = &owner
+	}
+
+	go func() {
+		defer ls.endRun()
+		releaseRun, err := a.runQueue.acquire(runCtx, func(position int) {
+			ls.broadcastEvent(map[string]any{"type": "queued", "position": position})
+		})
+		if err != nil {
+			if isRunQueueRejection(err) {
+				ls.broadcastEvent(map[string]any{"type": "error", "data": "(error) server is busy, try again later"})
+			}
+			return
+		}
+		defer releaseRun()
+		build := a.buildOrchestratorChatEngine(runCtx, ls.owner, ls.id, "", nil)
+		if build.Err != nil {
+			ls.broadcastEvent(map[string]any{"type": "error", "data": "(error) " + build.Err.Error()})
+			return
+		}
+		history, summary, err := a.chatMemory.BuildContextForProvider(runCtx, storeUser, ls.id, providerSupportsCompaction(build.Engine.LLM))
+		if err != nil {
+			log.Error().Err(err).Str("session", ls.id).Msg("load_chat_history")
+			ls.broadcastEvent(map[string]any{"type": "error", "data": "(error) failed to load chat history"})
+			return
+		}
+		a.recordRun(runCtx, ls.owner, "/api/chat/sessions/live")
+		a.attachQuotaCheck(build.Engine, ls.owner, quotaLimits)
+		run := a.runs.create(prompt)
+		ls.broadcastEvent(map[string]any{
+			"type":    "user_message",
+			"run_id":  run.ID,
+			"speaker": map[string]any{"user_id": p.userID, "name": p.name},
+			"data":    prompt,
+		})
+		req := chatRunRequest{Prompt: liveSpeakerPrompt(p.name, prompt), SessionID: ls.id}
+		a.streamChatTurn(nil, newChatBroadcastWriter(ls.broadcast), runCtx, build.Engine, req, history, run.ID, storeUser, nil, chatStreamOptions{
+			Endpoint:           "/api/chat/sessions/live",
+			EmitThoughtSummary: true,
+			EmitSummaryEvents:  true,
+			StructuredErrors:   true,
+			StoreModel:         build.ModelLabel,
+			InitialSummary:     summary,
+			Approvers:          ls.canApprove,
+		})
+	}()
+}
+
+// decideLiveApproval applies a participant's tool approval decision. The
+// broker checks that the participant is the owner or was granted approval
+// rights.
+func (a *app) decideLiveApproval(ls *liveSession, p *liveParticipant, msg liveMessage) {
+	d, err := parseApprovalDecision(msg.Decision, msg.Reason)
+	if err == nil {
+		err = a.toolApprovals.decide(msg.RunID, msg.ToolCallID, p.userID, d)
+	}
+	if err != nil {
+		ls.sendTo(p, map[string]any{"type": "error", "run_id": msg.RunID, "tool_call_id": msg.ToolCallID, "data": err.Error()})
+		return
+	}
+	ls.broadcastEvent(map[string]any{
+		"type":         "tool_approval_decided",
+		"run_id":       msg.RunID,
+		"tool_call_id": msg.ToolCallID,
+		"approved":     d.Approved,
+		"decided_by":   map[string]any{"user_id": p.userID, "name": p.name},
+	})
+}
+
+// handleLiveParticipants serves the owner's view of who may join:
+// GET /api/chat/sessions/{id}/participants lists them, PUT .../{userID} with
+// {"can_approve_tools": bool} invites or updates a user, and DELETE
+// .../{userID} revokes the invitation and disconnects them.
+func (a *app) handleLiveParticipants(w http.ResponseWriter, r *http.Request, userID *int64, sessionID, participant string) {
+	if a.liveSessions == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := a.chatStore.GetSession(r.Context(), userID, sessionID); err != nil {
+		switch {
+		case errors.Is(err, persist.ErrForbidden):
+			apierror.Respond(w, http.StatusForbidden, "forbidden")
+		case errors.Is(err, persist.ErrNotFound):
+			http.NotFound(w, r)
+		default:
+			log.Error().Err(err).Str("session", sessionID).Msg("live_participants_session")
+			apierror.Respond(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+	owner := systemUserID
+	if userID != nil {
+		owner = *userID
+	}
+	ls := a.liveSessions.session(sessionID, owner)
+	defer a.liveSessions.release(ls)
+
+	if participant == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"session_id": sessionID, "participants": ls.roster()})
+		return
+	}
+	target, err := strconv.ParseInt(participant, 10, 64)
+	if err != nil || target == owner {
+		writeError(w, http.StatusBadRequest, errors.New("participant must be another user's id"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		var g liveGrant
+		if !apierror.Decode(w, r, &g, 64<<10) {
+			return
+		}
+		ls.grant(target, g)
+		ls.broadcastEvent(map[string]any{"type": "participants_changed", "participants": ls.roster()})
+		writeJSON(w, http.StatusOK, liveParticipantInfo{UserID: target, CanApproveTools: g.CanApproveTools})
+	case http.MethodDelete:
+		if !ls.revoke(target) {
+			http.NotFound(w, r)
+			return
+		}
+		ls.broadcastEvent(map[string]any{"type": "participants_changed", "participants": ls.roster()})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,130 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"manifold/internal/agent"
+	"manifold/internal/llm"
+)
+
+func newTestParticipant(userID int64) *liveParticipant {
+	return &liveParticipant{userID: userID, name: liveParticipantName(context.Background(), userID), send: make(chan []byte, 4)}
+}
+
+func TestLiveSessionInvitesAndBroadcasts(t *testing.T) {
+	t.Parallel()
+
+	hub := newLiveSessionHub(2)
+	ls := hub.session("s1", 1)
+	if !ls.invited(1) || ls.invited(2) {
+		t.Fatalf("only the owner may join before invitations")
+	}
+	ls.grant(2, liveGrant{})
+	if !ls.invited(2) || ls.canApprove(2) {
+		t.Fatalf("invited user should join without approval rights")
+	}
+
+	owner, guest := newTestParticipant(1), newTestParticipant(2)
+	if err := ls.join(owner, hub.maxParticipants); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.join(guest, hub.maxParticipants); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.join(newTestParticipant(2), hub.maxParticipants); err != errLiveSessionFull {
+		t.Fatalf("expected errLiveSessionFull, got %v", err)
+	}
+
+	ls.broadcastEvent(map[string]any{"type": "delta", "data": "hi"})
+	for _, p := range []*liveParticipant{owner, guest} {
+		var ev map[string]any
+		if err := json.Unmarshal(<-p.send, &ev); err != nil || ev["data"] != "hi" {
+			t.Fatalf("participant %d missed the broadcast: %v %v", p.userID, ev, err)
+		}
+	}
+
+	roster := ls.roster()
+	if len(roster) != 2 || !roster[0].Owner || !roster[1].Connected || roster[1].Name != "user 2" {
+		t.Fatalf("unexpected roster %+v", roster)
+	}
+
+	ls.leave(guest)
+	if _, open := <-guest.send; open {
+		t.Fatalf("leaving should close the participant's queue")
+	}
+	if !ls.revoke(2) || ls.invited(2) {
+		t.Fatalf("revoke should remove the invitation")
+	}
+	hub.release(ls)
+	if _, ok := hub.lookup("s1"); !ok {
+		t.Fatalf("session with a connected owner must be kept")
+	}
+	ls.leave(owner)
+	hub.release(ls)
+	if _, ok := hub.lookup("s1"); ok {
+		t.Fatalf("idle session should be released")
+	}
+}
+
+func TestLiveSessionSerializesRunsAndCancelsWhenEmpty(t *testing.T) {
+	t.Parallel()
+
+	ls := newLiveSessionHub(0).session("s1", 1)
+	p := newTestParticipant(1)
+	if err := ls.join(p, 0); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := ls.beginRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.beginRun(); err != errLiveRunBusy {
+		t.Fatalf("expected errLiveRunBusy, got %v", err)
+	}
+	ls.leave(p)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("run should be cancelled once everyone has left")
+	}
+	ls.endRun()
+	if _, err := ls.beginRun(); err != nil {
+		t.Fatalf("a new run should start after the last one ended: %v", err)
+	}
+}
+
+func TestToolApprovalHonoursLiveGrants(t *testing.T) {
+	t.Parallel()
+
+	a := newApprovalTestApp(5)
+	ls := newLiveSessionHub(0).session("s1", 1)
+	ls.grant(2, liveGrant{CanApproveTools: true})
+	ls.grant(3, liveGrant{})
+
+	eng := &agent.Engine{}
+	owner := int64(1)
+	a.attachToolApprover(eng, "run_live", &owner, nil, ls.canApprove)
+	done := make(chan agent.ApprovalDecision, 1)
+	go func() {
+		done <- eng.ToolApprover.ApproveToolCall(context.Background(), llm.ToolCall{Name: "run_cli", ID: "call_1"})
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(a.toolApprovals.list("run_live", 2)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(2 * time.Millisecond)
+	}
+	if len(a.toolApprovals.list("run_live", 3)) != 0 {
+		t.Fatalf("participants without approval rights must not see pending approvals")
+	}
+	if err := a.toolApprovals.decide("run_live", "call_1", 3, agent.ApprovalDecision{Approved: true}); err != errApprovalForbidden {
+		t.Fatalf("expected errApprovalForbidden, got %v", err)
+	}
+	if err := a.toolApprovals.decide("run_live", "call_1", 2, agent.ApprovalDecision{Approved: true}); err != nil {
+		t.Fatalf("granted participant should decide: %v", err)
+	}
+	if d := <-done; !d.Approved {
+		t.Fatalf("expected approval, got %+v", d)
+	}
+}
@@ -0,0 +1,63 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"manifold/internal/i18n"
+)
+
+// requestLocale resolves locale settings for a request. The user's saved
+// preferences win, then the Accept-Language header, then the i18n config.
+func (a *app) requestLocale(r *http.Request, userID int64) i18n.Settings {
+	s := i18n.Settings{}
+	if a.cfg != nil {
+		s.Locale = a.cfg.I18n.DefaultLocale
+		s.TimeZone = a.cfg.I18n.TimeZone
+	}
+	if neg := i18n.Negotiate(r.Header.Get("Accept-Language")); neg != "" {
+		s.Locale = neg
+	}
+	if a.userPrefsStore != nil {
+		if prefs, err := a.userPrefsStore.Get(r.Context(), userID); err == nil {
+			if strings.TrimSpace(prefs.Locale) != "" {
+				s.Locale = prefs.Locale
+			}
+			if strings.TrimSpace(prefs.TimeZone) != "" {
+				s.TimeZone = prefs.TimeZone
+			}
+		}
+	}
+	if s.Locale == "" {
+		s.Locale = i18n.DefaultLocale
+	}
+	return s
+}
+
+// headerLocale resolves the locale for unauthenticated responses, where no
+// user preferences are available.
+func (a *app) headerLocale(r *http.Request) string {
+	if neg := i18n.Negotiate(r.Header.Get("Accept-Language")); neg != "" {
+		return neg
+	}
+	if a.cfg != nil && a.cfg.I18n.DefaultLocale != "" {
+		return a.cfg.I18n.DefaultLocale
+	}
+	return i18n.DefaultLocale
+}
+
+// ensureLocaleInstructions appends the [locale] prompt section for the
+// settings attached to ctx.
+func (a *app) ensureLocaleInstructions(ctx context.Context, systemPrompt string) string {
+	return i18n.EnsurePromptInstructions(systemPrompt, i18n.FromContext(ctx))
+}
+
+// localizeGuardrailMessage translates the built-in policy message. Operator
+// configured messages are returned unchanged.
+func (a *app) localizeGuardrailMessage(ctx context.Context, message string) string {
+	if a.cfg != nil && strings.TrimSpace(a.cfg.Guardrails.Message) != "" {
+		return message
+	}
+	return i18n.Tc(ctx, message)
+}
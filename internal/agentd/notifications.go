@@ -0,0 +1,91 @@
+package agentd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"manifold/internal/agent"
+	"manifold/internal/flow"
+	"manifold/internal/notify"
+)
+
+// attachRunNotifications reports run_started for an agent run and wraps
+// eng.OnTool to report failed tool calls. The returned func reports the run's
+// outcome. It is a no-op when no notification targets are configured.
+func (a *app) attachRunNotifications(eng *agent.Engine, runID string, userID *int64, schedule, prompt string) func(result string, err error) {
+	if a.notifier == nil {
+		return func(string, error) {}
+	}
+	base := notify.Event{RunID: runID, UserID: systemUserID, Schedule: schedule, Prompt: prompt}
+	if userID != nil {
+		base.UserID = *userID
+	}
+	ev := base
+	ev.Type = notify.EventRunStarted
+	a.notifier.Notify(ev)
+
+	prev := eng.OnTool
+	eng.OnTool = func(toolName string, args []byte, result []byte, toolID string) {
+		if prev != nil {
+			prev(toolName, args, result, toolID)
+		}
+		if msg, failed := toolResultError(result); failed {
+			ev := base
+			ev.Type, ev.Tool, ev.Error = notify.EventToolError, toolName, msg
+			a.notifier.Notify(ev)
+		}
+	}
+	return func(result string, err error) {
+		ev := base
+		if err != nil {
+			ev.Type, ev.Error = notify.EventRunFailed, err.Error()
+		} else {
+			ev.Type, ev.Result = notify.EventRunCompleted, result
+		}
+		a.notifier.Notify(ev)
+	}
+}
+
+// toolResultError reports the message of a standard tool error payload
+// ({"ok":false,"error":"..."}).
+func toolResultError(result []byte) (string, bool) {
+	if !strings.Contains(string(result), `"error"`) {
+		return "", false
+	}
+	var res struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(result, &res); err != nil || res.Error == "" {
+		return "", false
+	}
+	if res.OK != nil && *res.OK {
+		return "", false
+	}
+	return res.Error, true
+}
+
+// notifyFlowRunEvent maps workflow run events onto notifications: node
+// failures become tool_error and run events keep their type.
+func (a *app) notifyFlowRunEvent(userID int64, runID, workflowID string, ev flow.RunEvent) {
+	if a.notifier == nil {
+		return
+	}
+	out := notify.Event{RunID: runID, UserID: userID, Schedule: "workflow:" + workflowID, Error: ev.Error}
+	switch ev.Type {
+	case flow.RunEventTypeRunStarted:
+		out.Type = notify.EventRunStarted
+	case flow.RunEventTypeRunCompleted:
+		out.Type = notify.EventRunCompleted
+	case flow.RunEventTypeRunFailed, flow.RunEventTypeRunCancelled:
+		out.Type = notify.EventRunFailed
+		if out.Error == "" {
+			out.Error = ev.Message
+		}
+	case flow.RunEventTypeNodeFailed:
+		out.Type, out.Tool = notify.EventToolError, ev.NodeID
+	default:
+		return
+	}
+	a.notifier.Notify(out)
+}
@@ -0,0 +1,46 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+
+	"manifold/internal/auth"
+	persist "manifold/internal/persistence"
+	"manifold/internal/playground/dataset"
+)
+
+// playgroundChatSource lets playground datasets import the caller's chat
+// sessions.
+type playgroundChatSource struct{ a *app }
+
+func (s playgroundChatSource) ChatTurns(ctx context.Context, sessionID string) ([]dataset.ChatTurn, error) {
+	var userID *int64
+	if s.a.cfg.Auth.Enabled {
+		u, ok := auth.CurrentUser(ctx)
+		if !ok {
+			return nil, dataset.ErrChatSessionNotFound
+		}
+		id, _, err := resolveChatAccess(ctx, s.a.authStore, u)
+		if err != nil {
+			return nil, err
+		}
+		userID = id
+	}
+	msgs, err := s.a.chatStore.ListMessages(ctx, userID, sessionID, 0)
+	if err != nil {
+		if errors.Is(err, persist.ErrNotFound) || errors.Is(err, persist.ErrForbidden) {
+			return nil, dataset.ErrChatSessionNotFound
+		}
+		return nil, err
+	}
+	turns := make([]dataset.ChatTurn, 0, len(msgs))
+	for _, m := range msgs {
+		// Assistant messages that only carry tool calls are intermediate
+		// steps, not answers.
+		if len(toolCallIDsFromMessage(m)) > 0 {
+			continue
+		}
+		turns = append(turns, dataset.ChatTurn{Role: m.Role, Content: m.Content})
+	}
+	return turns, nil
+}
@@ -0,0 +1,251 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+	persist "manifold/internal/persistence"
+)
+
+// quotaUsage is what a user has used in the current quota period.
+type quotaUsage struct {
+	Tokens int64   `json:"tokens"`
+	Cost   float64 `json:"cost"`
+	Runs   int64   `json:"runs"`
+}
+
+// quotaRemaining is what is left of each limit; nil means unlimited.
+type quotaRemaining struct {
+	Tokens *int64   `json:"tokens"`
+	Cost   *float64 `json:"cost"`
+	Runs   *int64   `json:"runs"`
+}
+
+// quotaStatus reports a user's monthly quota. It is part of /api/me and the
+// details of quota_exceeded errors.
+type quotaStatus struct {
+	Period    string             `json:"period"`
+	ResetsAt  time.Time          `json:"resetsAt"`
+	Currency  string             `json:"currency"`
+	Limits    config.QuotaLimits `json:"limits"`
+	Used      quotaUsage         `json:"used"`
+	Remaining quotaRemaining     `json:"remaining"`
+	// Exceeded names the limits used up: "tokens", "cost" or "runs".
+	Exceeded []string `json:"exceeded,omitempty"`
+}
+
+// quotaExceededError is returned once a user has used up a monthly limit.
+type quotaExceededError struct {
+	status quotaStatus
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("monthly %s quota exceeded; resets %s", strings.Join(e.status.Exceeded, " and "), e.status.ResetsAt.Format(time.DateOnly))
+}
+
+// resolveQuotaLimits picks the limits for a user: their own entry in
+// cfg.Users, else the most generous of their roles' limits, else
+// cfg.Default. Per limit, an unlimited (zero) role value wins.
+func resolveQuotaLimits(cfg config.QuotasConfig, email string, roles []string) config.QuotaLimits {
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		for key, l := range cfg.Users {
+			if strings.ToLower(strings.TrimSpace(key)) == email {
+				return l
+			}
+		}
+	}
+	var (
+		out     config.QuotaLimits
+		matched bool
+	)
+	for _, role := range roles {
+		l, ok := cfg.Roles[role]
+		if !ok {
+			continue
+		}
+		if !matched {
+			out, matched = l, true
+			continue
+		}
+		out.Tokens = moreGenerous(out.Tokens, l.Tokens)
+		out.Runs = moreGenerous(out.Runs, l.Runs)
+		out.Cost = moreGenerous(out.Cost, l.Cost)
+	}
+	if !matched {
+		return cfg.Default
+	}
+	return out
+}
+
+func moreGenerous[T int64 | float64](a, b T) T {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return max(a, b)
+}
+
+// quotaLimits returns userID's limits, looking up their email and roles
+// when auth is enabled. It returns no limits while quotas are disabled.
+func (a *app) quotaLimits(ctx context.Context, userID int64) config.QuotaLimits {
+	if !a.cfg.Quotas.Enabled {
+		return config.QuotaLimits{}
+	}
+	if !a.cfg.Auth.Enabled || a.authStore == nil || userID == systemUserID {
+		return a.cfg.Quotas.Default
+	}
+	var email string
+	if u, err := a.authStore.GetUserByID(ctx, userID); err == nil && u != nil {
+		email = u.Email
+	}
+	roles, err := a.authStore.RolesForUser(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("quota role lookup failed")
+	}
+	return resolveQuotaLimits(a.cfg.Quotas, email, roles)
+}
+
+// quotaStatus computes userID's usage for the calendar month of now.
+func (a *app) quotaStatus(ctx context.Context, userID int64, limits config.QuotaLimits, now time.Time) (quotaStatus, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	st := quotaStatus{
+		Period:   start.Format("2006-01"),
+		ResetsAt: end,
+		Currency: a.cfg.Pricing.Currency,
+		Limits:   limits,
+	}
+	if a.mgr != nil && a.mgr.TokenUsage != nil {
+		filter := persist.TokenUsageFilter{Since: start, Until: end}
+		days, err := a.mgr.TokenUsage.Query(ctx, userID, filter)
+		if err != nil {
+			return st, err
+		}
+		if a.tokenUsage != nil {
+			days = mergeUsageDays(days, a.tokenUsage.pendingFor(userID, filter))
+		}
+		for _, d := range days {
+			st.Used.Tokens += d.PromptTokens + d.CompletionTokens
+			if cost, ok := usageCost(a.cfg.Pricing.Models, d.Model, d.PromptTokens, d.CompletionTokens); ok {
+				st.Used.Cost += cost
+			}
+		}
+	}
+	if a.mgr != nil && a.mgr.Usage != nil {
+		runs, err := a.mgr.Usage.Count(ctx, userID, persist.UsageKindRun, start)
+		if err != nil {
+			return st, err
+		}
+		st.Used.Runs = runs
+	}
+	if limits.Tokens > 0 {
+		left := max(limits.Tokens-st.Used.Tokens, 0)
+		st.Remaining.Tokens = &left
+		if left == 0 {
+			st.Exceeded = append(st.Exceeded, "tokens")
+		}
+	}
+	if limits.Cost > 0 {
+		left := max(limits.Cost-st.Used.Cost, 0)
+		st.Remaining.Cost = &left
+		if left == 0 {
+			st.Exceeded = append(st.Exceeded, "cost")
+		}
+	}
+	if limits.Runs > 0 {
+		left := max(limits.Runs-st.Used.Runs, 0)
+		st.Remaining.Runs = &left
+		if left == 0 {
+			st.Exceeded = append(st.Exceeded, "runs")
+		}
+	}
+	return st, nil
+}
+
+// checkQuota returns a *quotaExceededError when userID has used up a limit.
+// Starting a run also needs a run left; later model requests of the same
+// run only need tokens and cost. Errors reading usage are logged and let
+// the request through.
+func (a *app) checkQuota(ctx context.Context, userID int64, limits config.QuotaLimits, startingRun bool) error {
+	if !startingRun {
+		limits.Runs = 0
+	}
+	if !a.cfg.Quotas.Enabled || limits == (config.QuotaLimits{}) {
+		return nil
+	}
+	st, err := a.quotaStatus(ctx, userID, limits, time.Now())
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("quota usage lookup failed")
+		return nil
+	}
+	if len(st.Exceeded) > 0 {
+		return &quotaExceededError{status: st}
+	}
+	return nil
+}
+
+// runQuota checks userID's quota before an agent run starts, returning a
+// *quotaExceededError when it is used up. The returned limits are for
+// attachQuotaCheck.
+func (a *app) runQuota(ctx context.Context, userID int64) (config.QuotaLimits, error) {
+	if !a.cfg.Quotas.Enabled {
+		return config.QuotaLimits{}, nil
+	}
+	limits := a.quotaLimits(ctx, userID)
+	return limits, a.checkQuota(ctx, userID, limits, true)
+}
+
+// admitRun is runQuota for HTTP handlers: it writes a 429 quota_exceeded
+// response when the quota is used up.
+func (a *app) admitRun(w http.ResponseWriter, r *http.Request, userID int64) (config.QuotaLimits, bool) {
+	limits, err := a.runQuota(r.Context(), userID)
+	if err != nil {
+		writeQuotaExceeded(w, err)
+		return limits, false
+	}
+	return limits, true
+}
+
+// recordRun counts a started agent run towards userID's run quota.
+func (a *app) recordRun(ctx context.Context, userID int64, endpoint string) {
+	if a.mgr == nil || a.mgr.Usage == nil {
+		return
+	}
+	ev := persist.UsageEvent{UserID: userID, Kind: persist.UsageKindRun, Name: endpoint, OK: true}
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := a.mgr.Usage.Record(rctx, ev); err != nil {
+		log.Debug().Err(err).Str("endpoint", endpoint).Msg("usage_record_failed")
+	}
+}
+
+// attachQuotaCheck stops the run once userID's token or cost quota is used
+// up, checking before each model request.
+func (a *app) attachQuotaCheck(eng *agent.Engine, userID int64, limits config.QuotaLimits) {
+	if eng == nil || !a.cfg.Quotas.Enabled || limits == (config.QuotaLimits{}) {
+		return
+	}
+	eng.QuotaCheck = func(ctx context.Context) error {
+		return a.checkQuota(ctx, userID, limits, false)
+	}
+}
+
+func writeQuotaExceeded(w http.ResponseWriter, err error) {
+	var qe *quotaExceededError
+	if !errors.As(err, &qe) {
+		apierror.RespondCode(w, http.StatusTooManyRequests, apierror.QuotaExceeded, err.Error(), nil)
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(qe.status.ResetsAt).Seconds())+1))
+	apierror.RespondCode(w, http.StatusTooManyRequests, apierror.QuotaExceeded, qe.Error(), qe.status)
+}
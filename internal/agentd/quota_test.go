@@ -0,0 +1,151 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"manifold/internal/agent"
+	"manifold/internal/config"
+	"manifold/internal/grpcapi"
+	llmpkg "manifold/internal/llm"
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
+)
+
+func TestResolveQuotaLimits(t *testing.T) {
+	cfg := config.QuotasConfig{
+		Default: config.QuotaLimits{Tokens: 1000, Runs: 10},
+		Roles: map[string]config.QuotaLimits{
+			"team-a": {Tokens: 5000, Cost: 5, Runs: 50},
+			"team-b": {Tokens: 20000, Cost: 2},
+		},
+		Users: map[string]config.QuotaLimits{"Ada@example.com": {Tokens: 1}},
+	}
+	if got := resolveQuotaLimits(cfg, "ada@example.com", []string{"team-a"}); got != (config.QuotaLimits{Tokens: 1}) {
+		t.Fatalf("user entry should win: %+v", got)
+	}
+	if got := resolveQuotaLimits(cfg, "bob@example.com", []string{"user", "team-a", "team-b"}); got != (config.QuotaLimits{Tokens: 20000, Cost: 5}) {
+		t.Fatalf("roles should combine to the most generous limits: %+v", got)
+	}
+	if got := resolveQuotaLimits(cfg, "bob@example.com", []string{"user"}); got != cfg.Default {
+		t.Fatalf("unlisted roles should get the default: %+v", got)
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	tokens := databases.NewTokenUsageStore(nil)
+	usage := databases.NewUsageStore(nil)
+	if err := tokens.Add(ctx, []persist.TokenUsageDay{
+		{Day: now, UserID: 3, Model: "gpt-4o", PromptTokens: 800_000, CompletionTokens: 100_000, Calls: 4},
+		// Last month does not count.
+		{Day: now.AddDate(0, -1, 0), UserID: 3, Model: "gpt-4o", PromptTokens: 5_000_000, Calls: 9},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Pricing: config.PricingConfig{Currency: "USD", Models: map[string]config.ModelPrice{"gpt-4o": {InputPerMillion: 2.5, OutputPerMillion: 10}}},
+		Quotas:  config.QuotasConfig{Enabled: true},
+	}
+	a := &app{cfg: cfg, mgr: &databases.Manager{TokenUsage: tokens, Usage: usage}, tokenUsage: newTokenUsageRecorder(tokens)}
+	a.recordRun(ctx, 3, "/agent/run")
+
+	st, err := a.quotaStatus(ctx, 3, config.QuotaLimits{Tokens: 1_000_000, Cost: 5, Runs: 2}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Used.Tokens != 900_000 || st.Used.Cost != 3 || st.Used.Runs != 1 {
+		t.Fatalf("used = %+v", st.Used)
+	}
+	if *st.Remaining.Tokens != 100_000 || *st.Remaining.Cost != 2 || *st.Remaining.Runs != 1 || len(st.Exceeded) != 0 {
+		t.Fatalf("remaining = %+v exceeded=%v", st.Remaining, st.Exceeded)
+	}
+
+	if err := a.checkQuota(ctx, 3, config.QuotaLimits{Runs: 1}, true); !errors.As(err, new(*quotaExceededError)) {
+		t.Fatalf("run quota: got %v", err)
+	}
+	if err := a.checkQuota(ctx, 3, config.QuotaLimits{Runs: 1}, false); err != nil {
+		t.Fatalf("a started run should not be stopped by its own run count: %v", err)
+	}
+
+	// Unflushed usage counts too.
+	eng := &agent.Engine{}
+	a.attachQuotaCheck(eng, 3, config.QuotaLimits{Tokens: 1_000_000})
+	if err := eng.QuotaCheck(ctx); err != nil {
+		t.Fatalf("under quota: %v", err)
+	}
+	a.tokenUsage.add(llmpkg.TokenUsage{UserID: 3, Model: "gpt-4o", Prompt: 100_000, At: now})
+	err = eng.QuotaCheck(ctx)
+	var qe *quotaExceededError
+	if !errors.As(err, &qe) || qe.status.Exceeded[0] != "tokens" {
+		t.Fatalf("expected token quota error, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	writeQuotaExceeded(rec, err)
+	var body struct {
+		Code    string      `json:"code"`
+		Details quotaStatus `json:"details"`
+	}
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != "quota_exceeded" || body.Details.Used.Tokens != 1_000_000 {
+		t.Fatalf("body = %s (%v)", rec.Body.String(), err)
+	}
+}
+
+// newRunQuotaExhaustedApp returns an app whose single allowed run this
+// month has been used.
+func newRunQuotaExhaustedApp(t *testing.T) *app {
+	t.Helper()
+	a := &app{
+		cfg:       &config.Config{Quotas: config.QuotasConfig{Enabled: true, Default: config.QuotaLimits{Runs: 1}}},
+		mgr:       &databases.Manager{Usage: databases.NewUsageStore(nil)},
+		chatStore: newPromptHandlerChatStore(),
+		runs:      newRunStore(),
+	}
+	a.recordRun(context.Background(), systemUserID, "/agent/run")
+	return a
+}
+
+func TestGRPCRunAgentEnforcesQuota(t *testing.T) {
+	a := newRunQuotaExhaustedApp(t)
+	err := grpcBackend{a: a}.RunAgent(context.Background(), systemUserID, &grpcapi.RunAgentRequest{Prompt: "hi"}, func(*grpcapi.RunAgentEvent) error {
+		t.Fatal("no events should be sent for a rejected run")
+		return nil
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestLivePromptEnforcesQuota(t *testing.T) {
+	a := newRunQuotaExhaustedApp(t)
+	ls := newLiveSessionHub(0).session("s1", systemUserID)
+	p := newTestParticipant(systemUserID)
+	if err := ls.join(p, 0); err != nil {
+		t.Fatal(err)
+	}
+	a.startLivePrompt(ls, p, "hi")
+	var ev map[string]any
+	if err := json.Unmarshal(<-p.send, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ev["data"].(string); ev["type"] != "error" || !strings.Contains(data, "quota exceeded") {
+		t.Fatalf("expected a quota error frame, got %v", ev)
+	}
+	if _, err := ls.beginRun(); err != nil {
+		t.Fatalf("a rejected prompt must not hold the session's run: %v", err)
+	}
+}
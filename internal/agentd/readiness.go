@@ -0,0 +1,296 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+)
+
+// Readiness phases reported by /readyz.
+const (
+	readinessStarting = "starting"
+	readinessReady    = "ready"
+	readinessDraining = "draining"
+)
+
+// Dependency states.
+const (
+	depPending  = "pending"
+	depOK       = "ok"
+	depDegraded = "degraded"
+	depFailed   = "failed"
+)
+
+// Dependencies tracked during startup.
+const (
+	depDatabase = "database"
+	depStores   = "stores"
+	depMCP      = "mcp"
+	depLLM      = "llm"
+	depEmbedder = "embedding"
+)
+
+const (
+	readinessCheckTimeout = 2 * time.Second
+	readinessRecheckTTL   = 15 * time.Second
+	readinessDrainDelay   = 5 * time.Second
+)
+
+// dependencyStatus is served on the unauthenticated /readyz, so Detail, which
+// may hold raw errors with hosts and addresses, is only logged.
+type dependencyStatus struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Required  bool      `json:"required"`
+	Detail    string    `json:"-"`
+	CheckedAt time.Time `json:"checkedAt,omitempty"`
+}
+
+type readinessReport struct {
+	Status       string             `json:"status"`
+	Phase        string             `json:"phase"`
+	Dependencies []dependencyStatus `json:"dependencies"`
+}
+
+// readiness tracks startup and shutdown state for the /readyz probe. The
+// server is ready only once startup has finished, it is not draining, and
+// every required dependency is ok. Optional dependencies may be degraded.
+type readiness struct {
+	mu      sync.Mutex
+	phase   string
+	deps    map[string]*dependencyStatus
+	checks  map[string]func(context.Context) error
+	probing map[string]bool
+	now     func() time.Time
+}
+
+func newReadiness() *readiness {
+	return &readiness{
+		phase:   readinessStarting,
+		deps:    map[string]*dependencyStatus{},
+		checks:  map[string]func(context.Context) error{},
+		probing: map[string]bool{},
+		now:     time.Now,
+	}
+}
+
+// expect registers a dependency in the pending state.
+func (r *readiness) expect(name string, required bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d, ok := r.deps[name]; ok {
+		d.Required = required
+		return
+	}
+	r.deps[name] = &dependencyStatus{Name: name, State: depPending, Required: required}
+}
+
+// set records the outcome of initializing a dependency.
+func (r *readiness) set(name, state, detail string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deps[name]
+	if !ok {
+		d = &dependencyStatus{Name: name}
+		r.deps[name] = d
+	}
+	d.State = state
+	d.Detail = detail
+	d.CheckedAt = r.now().UTC()
+	if state == depFailed || state == depDegraded {
+		log.Warn().Str("dependency", name).Str("state", state).Str("detail", detail).Msg("readiness_dependency_unhealthy")
+	}
+}
+
+// setErr marks a dependency ok when err is nil and failed otherwise.
+func (r *readiness) setErr(name string, err error) {
+	if err != nil {
+		r.set(name, depFailed, err.Error())
+		return
+	}
+	r.set(name, depOK, "")
+}
+
+// watch records a check that /readyz re-runs when the dependency is not ok,
+// so a provider that was unreachable at boot can recover without a restart.
+func (r *readiness) watch(name string, check func(context.Context) error) {
+	if r == nil || check == nil {
+		return
+	}
+	r.mu.Lock()
+	r.checks[name] = check
+	r.mu.Unlock()
+}
+
+func (r *readiness) markReady() { r.setPhase(readinessReady) }
+
+func (r *readiness) markDraining() { r.setPhase(readinessDraining) }
+
+func (r *readiness) setPhase(phase string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.phase = phase
+	r.mu.Unlock()
+}
+
+// recheck re-runs the checks of dependencies that are not ok and were last
+// checked at least readinessRecheckTTL ago. Only one caller probes a given
+// dependency at a time; concurrent /readyz hits report the cached state.
+func (r *readiness) recheck(ctx context.Context) {
+	r.mu.Lock()
+	due := map[string]func(context.Context) error{}
+	for name, check := range r.checks {
+		d := r.deps[name]
+		if d == nil || d.State == depOK || r.probing[name] || r.now().Sub(d.CheckedAt) < readinessRecheckTTL {
+			continue
+		}
+		r.probing[name] = true
+		due[name] = check
+	}
+	r.mu.Unlock()
+
+	// A client hanging up must not record its cancellation as a failure.
+	ctx = context.WithoutCancel(ctx)
+	for name, check := range due {
+		cctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		err := check(cctx)
+		cancel()
+		r.setErr(name, err)
+		r.mu.Lock()
+		delete(r.probing, name)
+		r.mu.Unlock()
+	}
+}
+
+func (r *readiness) report(ctx context.Context) (readinessReport, bool) {
+	if r == nil {
+		return readinessReport{Status: "ready", Phase: readinessReady, Dependencies: []dependencyStatus{}}, true
+	}
+	r.recheck(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := readinessReport{Phase: r.phase, Dependencies: make([]dependencyStatus, 0, len(r.deps))}
+	ready := r.phase == readinessReady
+	for _, d := range r.deps {
+		rep.Dependencies = append(rep.Dependencies, *d)
+		if d.Required && d.State != depOK {
+			ready = false
+		}
+	}
+	sort.Slice(rep.Dependencies, func(i, j int) bool { return rep.Dependencies[i].Name < rep.Dependencies[j].Name })
+	rep.Status = "not_ready"
+	if ready {
+		rep.Status = "ready"
+	}
+	return rep, ready
+}
+
+func (r *readiness) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		rep, ready := r.report(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(rep)
+	}
+}
+
+// llmProbeURL returns the base URL used to check that the configured chat
+// provider is reachable, or "" when the provider has no HTTP endpoint to probe.
+func llmProbeURL(cfg *config.Config) string {
+	switch strings.ToLower(strings.TrimSpace(cfg.LLMClient.Provider)) {
+	case "", "openai", "local":
+		if u := strings.TrimSpace(cfg.LLMClient.OpenAI.BaseURL); u != "" {
+			return u
+		}
+		if u := strings.TrimSpace(cfg.OpenAI.BaseURL); u != "" {
+			return u
+		}
+		return "https://api.openai.com/v1"
+	case "anthropic":
+		if u := strings.TrimSpace(cfg.LLMClient.Anthropic.BaseURL); u != "" {
+			return u
+		}
+		return "https://api.anthropic.com"
+	case "google":
+		if u := strings.TrimSpace(cfg.LLMClient.Google.BaseURL); u != "" {
+			return u
+		}
+		return "https://generativelanguage.googleapis.com"
+	default:
+		return ""
+	}
+}
+
+// probeHTTP reports whether url answers HTTP at all. Any status code counts as
+// reachable; only transport errors fail the probe.
+func probeHTTP(httpClient *http.Client, url string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+}
+
+// startupHandler serves probes while newApp is still initializing and returns
+// 503 for everything else.
+func startupHandler(ready *readiness) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", ready.handler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		apierror.Respond(w, http.StatusServiceUnavailable, "starting")
+	})
+	return mux
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// swappableHandler lets the listener start before the full router exists.
+type swappableHandler struct {
+	mu sync.RWMutex
+	h  http.Handler
+}
+
+func (s *swappableHandler) set(h http.Handler) {
+	s.mu.Lock()
+	s.h = h
+	s.mu.Unlock()
+}
+
+func (s *swappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	h := s.h
+	s.mu.RUnlock()
+	h.ServeHTTP(w, r)
+}
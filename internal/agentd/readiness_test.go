@@ -0,0 +1,153 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+)
+
+func serveReadyz(t *testing.T, r *readiness) (int, readinessReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.handler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep readinessReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("decode readyz: %v", err)
+	}
+	return rec.Code, rep
+}
+
+func TestReadinessPhases(t *testing.T) {
+	r := newReadiness()
+	r.expect(depDatabase, true)
+	r.expect(depMCP, false)
+
+	if code, rep := serveReadyz(t, r); code != http.StatusServiceUnavailable || rep.Phase != readinessStarting {
+		t.Fatalf("expected 503 while starting, got %d %+v", code, rep)
+	}
+
+	r.setErr(depDatabase, nil)
+	r.set(depMCP, depDegraded, "server down")
+	r.markReady()
+	code, rep := serveReadyz(t, r)
+	if code != http.StatusOK || rep.Status != "ready" {
+		t.Fatalf("optional degraded dependency should not block readiness, got %d %+v", code, rep)
+	}
+	if len(rep.Dependencies) != 2 || rep.Dependencies[0].Name != depDatabase {
+		t.Fatalf("unexpected dependency detail: %+v", rep.Dependencies)
+	}
+
+	r.markDraining()
+	if code, rep := serveReadyz(t, r); code != http.StatusServiceUnavailable || rep.Phase != readinessDraining {
+		t.Fatalf("expected 503 while draining, got %d %+v", code, rep)
+	}
+}
+
+func TestReadinessRequiredFailureAndRecheck(t *testing.T) {
+	now := time.Now()
+	r := newReadiness()
+	r.now = func() time.Time { return now }
+	r.expect(depLLM, true)
+	r.setErr(depLLM, errors.New("dial tcp: connection refused"))
+	calls := 0
+	r.watch(depLLM, func(context.Context) error {
+		calls++
+		return nil
+	})
+	r.markReady()
+
+	code, rep := serveReadyz(t, r)
+	if code != http.StatusServiceUnavailable || rep.Dependencies[0].State != depFailed {
+		t.Fatalf("expected failed llm to block readiness, got %d %+v", code, rep)
+	}
+	if calls != 0 {
+		t.Fatalf("recheck should wait for ttl, ran %d times", calls)
+	}
+
+	now = now.Add(readinessRecheckTTL)
+	if code, _ := serveReadyz(t, r); code != http.StatusOK {
+		t.Fatalf("expected recovery after recheck, got %d", code)
+	}
+	serveReadyz(t, r)
+	if calls != 1 {
+		t.Fatalf("healthy dependency should not be rechecked, ran %d times", calls)
+	}
+}
+
+func TestReadinessRecheckSingleFlightAndRedacted(t *testing.T) {
+	now := time.Now()
+	r := newReadiness()
+	r.now = func() time.Time { return now }
+	r.expect(depLLM, true)
+	r.setErr(depLLM, errors.New("dial tcp 10.0.0.5:8080: connection refused"))
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r.watch(depLLM, func(context.Context) error {
+		calls.Add(1)
+		close(entered)
+		<-release
+		return errors.New("dial tcp 10.0.0.5:8080: connection refused")
+	})
+	r.markReady()
+	now = now.Add(readinessRecheckTTL)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.handler()(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	r.handler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	close(release)
+	<-done
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("concurrent readyz hits should share one probe, ran %d", n)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected cached failure while probing, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "10.0.0.5") || strings.Contains(body, "connection refused") {
+		t.Fatalf("readyz leaked error detail: %s", body)
+	}
+}
+
+func TestStartupHandlerRejectsAPIRequests(t *testing.T) {
+	h := startupHandler(newReadiness())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz should be live during startup, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/agent/run", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d", rec.Code)
+	}
+}
+
+func TestLLMProbeURL(t *testing.T) {
+	cfg := &config.Config{LLMClient: config.LLMClientConfig{Provider: "anthropic"}}
+	if got := llmProbeURL(cfg); got != "https://api.anthropic.com" {
+		t.Fatalf("unexpected anthropic probe url %q", got)
+	}
+	cfg = &config.Config{LLMClient: config.LLMClientConfig{Provider: "local", OpenAI: config.OpenAIConfig{BaseURL: "http://localhost:11434/v1"}}}
+	if got := llmProbeURL(cfg); got != "http://localhost:11434/v1" {
+		t.Fatalf("unexpected local probe url %q", got)
+	}
+	if got := llmProbeURL(&config.Config{LLMClient: config.LLMClientConfig{Provider: "custom"}}); got != "" {
+		t.Fatalf("unknown providers should skip probing, got %q", got)
+	}
+}
@@ -1,7 +1,6 @@
 package agentd
 
 import (
-	"fmt"
 	"net/http"
 )
 
@@ -31,17 +30,15 @@ func newRouter(a *app) *http.ServeMux {
 	mux.HandleFunc("/api/me/preferences", a.userPreferencesHandler())
 	mux.HandleFunc("/api/me/preferences/project", a.setActiveProjectHandler())
 
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, "ok")
-	})
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, "ready")
-	})
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", a.readiness.handler())
 
 	mux.HandleFunc("/api/projects", a.projectsHandler())
 	mux.HandleFunc("/api/projects/", a.projectDetailHandler())
 
 	mux.HandleFunc("/api/runs", a.runsHandler())
+	mux.HandleFunc("/api/runs/", a.runDetailHandler())
+	mux.HandleFunc("/api/artifacts/cli/", a.cliArtifactHandler())
 	mux.HandleFunc("/api/chat/sessions", a.chatSessionsHandler())
 	mux.HandleFunc("/api/chat/sessions/", a.chatSessionDetailHandler())
 	if a.cfg.Transit.Enabled {
@@ -58,6 +55,14 @@ func newRouter(a *app) *http.ServeMux {
 		mux.HandleFunc("/api/users/", a.userDetailHandler())
 	}
 
+	mux.HandleFunc("/api/rag/query", a.ragQueryHandler())
+	mux.HandleFunc("/api/memories", a.longTermMemoriesHandler())
+	mux.HandleFunc("/api/memories/", a.longTermMemoryDetailHandler())
+	mux.HandleFunc("/api/admin/vector/index", a.vectorIndexHandler())
+	mux.HandleFunc("/api/admin/vector/reindex", a.vectorReindexHandler())
+	mux.HandleFunc("/api/secrets", a.secretsHandler())
+	mux.HandleFunc("/api/secrets/", a.secretDetailHandler())
+
 	mux.HandleFunc("/api/status", a.statusHandler())
 	mux.HandleFunc("/api/specialists/defaults", a.specialistDefaultsHandler())
 	mux.HandleFunc("/api/specialists", a.specialistsHandler())
@@ -66,26 +71,39 @@ func newRouter(a *app) *http.ServeMux {
 	mux.HandleFunc("/api/teams/", a.teamDetailHandler())
 
 	mux.HandleFunc("/api/metrics/tokens", a.metricsTokensHandler())
+	mux.HandleFunc("/api/metrics/usage", a.metricsUsageHandler())
 	mux.HandleFunc("/api/metrics/traces", a.metricsTracesHandler())
 	mux.HandleFunc("/api/metrics/logs", a.metricsLogsHandler())
 	// Agentd configuration (GET + POST/PUT/PATCH)
 	mux.HandleFunc("/api/config/agentd", a.agentdConfigHandler())
 	mux.HandleFunc("/api/flows/v2/tools", a.flowV2ToolsHandler())
+	mux.HandleFunc("/api/tools/macros", a.macroToolsHandler())
+	mux.HandleFunc("/api/tools/macros/", a.macroToolDetailHandler())
 	mux.HandleFunc("/api/flows/v2/workflows", a.flowV2WorkflowsHandler())
 	mux.HandleFunc("/api/flows/v2/workflows/", a.flowV2WorkflowDetailHandler())
 	mux.HandleFunc("/api/flows/v2/validate", a.flowV2ValidateHandler())
-	mux.HandleFunc("/api/flows/v2/run", a.flowV2RunHandler())
+	// WARPP dry run: validation plus a tool-free simulation with sample input.
+	mux.HandleFunc("/api/warpp/validate", a.flowV2DryRunHandler())
+	mux.HandleFunc("/api/warpp/run", a.rejectWhileDraining(a.warppRunHandler()))
+	mux.HandleFunc("/api/flows/v2/run", a.rejectWhileDraining(a.flowV2RunHandler()))
 	mux.HandleFunc("/api/flows/v2/runs/", a.flowV2RunEventsHandler())
+	mux.HandleFunc("/api/workflow-runs", a.workflowRunsHandler())
+	mux.HandleFunc("/api/workflow-runs/", a.workflowRunDetailHandler())
+	mux.HandleFunc("/api/hooks/", a.rejectWhileDraining(a.webhookHandler()))
 
-	mux.HandleFunc("/agent/run", a.agentRunHandler())
-	mux.HandleFunc("/agent/vision", a.agentVisionHandler())
-	mux.HandleFunc("/api/prompt", a.promptHandler())
+	mux.HandleFunc("/agent/run", a.rejectWhileDraining(a.agentRunHandler()))
+	mux.HandleFunc("/agent/vision", a.rejectWhileDraining(a.agentVisionHandler()))
+	mux.HandleFunc("/api/prompt", a.rejectWhileDraining(a.promptHandler()))
 
 	mux.HandleFunc("/audio/", a.audioServeHandler())
 	mux.HandleFunc("/stt", a.sttHandler())
+	mux.HandleFunc("/stt/stream", a.sttStreamHandler())
+	mux.HandleFunc("/api/tts/providers", a.ttsProvidersHandler())
 
 	mux.HandleFunc("/api/mcp/servers", a.mcpServersHandler())
 	mux.HandleFunc("/api/mcp/servers/", a.mcpServerDetailHandler())
+	mux.HandleFunc("/api/mcp/resources", a.mcpResourcesHandler())
+	mux.HandleFunc("/api/mcp/prompts", a.mcpPromptsHandler())
 	mux.HandleFunc("/api/mcp/oauth/start", a.mcpOAuthStartHandler())
 	mux.HandleFunc("/api/mcp/oauth/bootstrap", a.mcpOAuthBootstrapHandler())
 	mux.HandleFunc("/api/mcp/oauth/callback", a.mcpOAuthCallbackHandler())
@@ -4,28 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 
 	"manifold/internal/agent"
 	"manifold/internal/agent/memory"
 	"manifold/internal/auth"
 	"manifold/internal/config"
+	"manifold/internal/docloader"
+	"manifold/internal/grpcapi"
+	"manifold/internal/guardrails"
 	"manifold/internal/httpapi"
 	llmpkg "manifold/internal/llm"
 	openaillm "manifold/internal/llm/openai"
 	llmproviders "manifold/internal/llm/providers"
 	"manifold/internal/mcpclient"
+	"manifold/internal/notify"
 	"manifold/internal/observability"
 	persist "manifold/internal/persistence"
 	"manifold/internal/persistence/databases"
@@ -40,20 +49,31 @@ import (
 	"manifold/internal/projects"
 	"manifold/internal/rag/embedder"
 	ragservice "manifold/internal/rag/service"
+	"manifold/internal/secrets"
 	"manifold/internal/skills"
 	"manifold/internal/specialists"
+	"manifold/internal/storagegc"
 	"manifold/internal/tools"
 	agenttools "manifold/internal/tools/agents"
+	"manifold/internal/tools/browser"
+	calendartool "manifold/internal/tools/calendar"
 	"manifold/internal/tools/cli"
 	codeevolvetool "manifold/internal/tools/codeevolve"
+	"manifold/internal/tools/codesearch"
 	tooldiscovery "manifold/internal/tools/discovery"
+	emailtool "manifold/internal/tools/email"
 	"manifold/internal/tools/filetool"
+	gittool "manifold/internal/tools/git"
+	httptool "manifold/internal/tools/http"
 	"manifold/internal/tools/imagetool"
 	"manifold/internal/tools/llmparallel"
+	"manifold/internal/tools/macrotool"
 	matrixroomtool "manifold/internal/tools/matrixroom"
 	"manifold/internal/tools/patchtool"
 	pulsetool "manifold/internal/tools/pulse"
+	pythontool "manifold/internal/tools/python"
 	ragtool "manifold/internal/tools/rag"
+	"manifold/internal/tools/resultcache"
 	"manifold/internal/tools/textsplitter"
 	transittools "manifold/internal/tools/transit"
 	"manifold/internal/tools/tts"
@@ -75,6 +95,8 @@ const (
 type app struct {
 	cfg                *config.Config
 	httpClient         *http.Client
+	sttPool            *sttPool
+	tts                *tts.Tool
 	mgr                *databases.Manager
 	llm                llmpkg.Provider
 	baseToolRegistry   tools.Registry
@@ -83,20 +105,35 @@ type app struct {
 	specRegistry       *specialists.Registry
 	specRegMu          sync.RWMutex
 	userSpecRegs       map[int64]*specialists.Registry
+	specLimits         *specialists.Limits
 	summaryLLM         llmpkg.Provider
 	flowV2             *flowV2Runtime
 	evolvingMu         sync.RWMutex
 	userEvolving       map[int64]map[string]*memory.EvolvingMemory
 	evolvingLastUsed   map[int64]map[string]time.Time
 	evolvingCfg        memory.EvolvingMemoryConfig
+	longTermMemory     *memory.LongTermMemory
 	evolvingSessionTTL time.Duration
 	rememMaxInnerSteps int
 	engine             *agent.Engine
 	chatStore          persist.ChatStore
 	chatMemory         *memory.Manager
 	runs               *runStore
+	runQueue           *runQueue
+	toolApprovals      *toolApprovalBroker
+	liveSessions       *liveSessionHub
+	webhooks           *webhookTriggers
+	sseRuns            *sseResumeLog
+	drainer            *runDrainer
+	notifier           *notify.Notifier
+	runContexts        persist.RunContextStore
+	projectEnv         persist.ProjectEnvStore
+	secrets            *secrets.Service
+	codeIndex          *codesearch.Index
 	playgroundHandler  http.Handler
+	evalGates          evalGateRunner
 	projectsService    projects.ProjectService
+	projectPreviewer   *projects.Previewer
 	workspaceManager   workspaces.WorkspaceManager
 	warppToolMu        sync.Mutex
 	warppToolNames     []string
@@ -104,16 +141,25 @@ type app struct {
 	authProvider       auth.Provider
 	specStore          persist.SpecialistsStore
 	teamStore          persist.SpecialistTeamsStore
+	specHealth         persist.SpecialistHealthStore
 	mcpStore           persist.MCPStore
 	userPrefsStore     persist.UserPreferencesStore
 	mcpManager         *mcpclient.Manager
 	mcpPool            *mcpclient.MCPServerPool
 	startupMCPOAuthIDs []int64
 	tokenMetrics       tokenMetricsProvider
+	tokenUsage         *tokenUsageRecorder
 	traceMetrics       *clickhouseTraceMetrics
 	runMetrics         *clickhouseRunMetrics
 	logMetrics         *clickhouseLogMetrics
 	transitService     *transitdomain.Service
+	guardrails         *guardrails.Guard
+	piiScrubber        *guardrails.Scrubber
+	ragQuery           ragQuerier
+	readiness          *readiness
+	toolCache          *resultcache.Cache
+	cliArtifacts       *cli.OutputStore
+	macroTools         *macrotool.Manager
 }
 
 type tokenMetricsProvider interface {
@@ -437,6 +483,7 @@ func Run() {
 	}
 
 	observability.InitLogger(cfg.LogPath, cfg.LogLevel)
+	logConfigProblems()
 
 	shutdown, err := observability.InitOTel(context.Background(), cfg.Obs)
 	if err != nil {
@@ -447,14 +494,47 @@ func Run() {
 		observability.EnableOTelLogging(cfg.Obs.ServiceName)
 	}
 	if shutdown != nil {
-		defer func() { _ = shutdown(context.Background()) }()
+		// Flush buffered spans, metrics and logs last, after runs drain.
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				log.Warn().Err(err).Msg("otel shutdown")
+			}
+		}()
+	}
+
+	// Start listening before initialization so orchestrators can observe
+	// /healthz and a 503 from /readyz while dependencies come up.
+	ready := newReadiness()
+	handler := &swappableHandler{h: startupHandler(ready)}
+	// Cancelling serveCtx after the drain ends long-lived streams, which
+	// would otherwise hold Shutdown open.
+	serveCtx, cancelServe := context.WithCancel(context.Background())
+	defer cancelServe()
+	srv, err := newListener(&cfg, handler, serveCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("server setup failed")
+	}
+	var grpcOpts []grpc.ServerOption
+	if cfg.GRPC.Enabled {
+		grpcOpts, err = grpcServerOptions(cfg.GRPC, srv.srv.TLSConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("grpc setup failed")
+		}
 	}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info().Str("addr", cfg.Server.Addr).Bool("tls", srv.secure()).Msg("agentd listening")
+		serveErr <- srv.serve()
+	}()
 
 	ctx := context.Background()
-	a, err := newApp(ctx, &cfg)
+	a, err := newApp(ctx, &cfg, ready)
 	if err != nil {
 		log.Fatal().Err(err).Msg("initialization failed")
 	}
+	go a.warmup(ctx)
 
 	mux := newRouter(a)
 	if err := a.registerFrontend(mux); err != nil {
@@ -468,14 +548,90 @@ func Run() {
 		// redirect back to (e.g. localhost vs 127.0.0.1 are different cookie origins).
 		oauthBase := computeBaseOrigin(a.cfg.Auth.RedirectURL)
 		if oauthBase == "" || oauthBase == a.cfg.Auth.RedirectURL {
-			oauthBase = "http://localhost:32180"
+			oauthBase = strings.Replace(loopbackURL(a.cfg.Server), "127.0.0.1", "localhost", 1)
 		}
 		go a.launchStartupMCPOAuthPrompts(oauthBase)
 	}
 
-	log.Info().Msg("agentd listening on :32180")
-	if err := http.ListenAndServe(":32180", root); err != nil {
-		log.Fatal().Err(err).Msg("server failed")
+	handler.set(root)
+	ready.markReady()
+	log.Info().Msg("agentd ready")
+
+	var grpcSrv *grpc.Server
+	if a.cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", a.cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatal().Err(err).Str("addr", a.cfg.GRPC.Addr).Msg("grpc listen failed")
+		}
+		grpcSrv = grpcapi.NewServer(grpcBackend{a: a}, grpcOpts...)
+		go func() {
+			log.Info().Str("addr", a.cfg.GRPC.Addr).Bool("tls", grpcOpts != nil).Msg("grpc listening")
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Error().Err(err).Msg("grpc server stopped")
+			}
+		}()
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("server failed")
+		}
+		return
+	case <-sigCtx.Done():
+	}
+
+	// Report not-ready first so load balancers stop routing new traffic,
+	// and refuse new runs while in-flight ones finish. Runs still going
+	// after the drain timeout are cancelled and recorded as interrupted.
+	ready.markDraining()
+	a.drainer.beginDrain()
+	log.Info().Dur("delay", readinessDrainDelay).Msg("agentd draining")
+	time.Sleep(readinessDrainDelay)
+	drainTimeout := time.Duration(a.cfg.ShutdownDrainSeconds) * time.Second
+	if n := a.drainer.drain(drainTimeout, shutdownGrace); n > 0 {
+		log.Warn().Int("runs", n).Dur("timeout", drainTimeout).Msg("agentd drain timed out, runs cancelled")
+	}
+	if n := a.runs.interruptRunning(); n > 0 {
+		log.Warn().Int("runs", n).Msg("runs interrupted by shutdown")
+	}
+
+	cancelServe()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	if err := srv.shutdown(shutdownCtx); err != nil {
+		log.Warn().Err(err).Msg("server shutdown")
+	}
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcSrv.Stop()
+		}
+	}
+	a.close()
+	log.Info().Msg("agentd stopped")
+}
+
+// close releases MCP sessions and database pools once the server has
+// stopped.
+func (a *app) close() {
+	if a.mcpManager != nil {
+		a.mcpManager.Close()
+	}
+	if a.tokenUsage != nil {
+		llmpkg.SetTokenUsageHook(nil)
+		a.tokenUsage.close()
+	}
+	if a.mgr != nil {
+		a.mgr.Close()
 	}
 }
 
@@ -728,7 +884,16 @@ func resolveEvolvingMemoryLLM(cfg *config.Config, mainLLM llmpkg.Provider, summa
 	return mainLLM, resolveLLMClientModel(cfg.LLMClient), strings.ToLower(strings.TrimSpace(cfg.LLMClient.Provider)), nil
 }
 
-func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
+func newApp(ctx context.Context, cfg *config.Config, ready *readiness) (*app, error) {
+	if ready == nil {
+		ready = newReadiness()
+	}
+	ready.expect(depDatabase, true)
+	ready.expect(depStores, true)
+	ready.expect(depLLM, true)
+	ready.expect(depEmbedder, true)
+	ready.expect(depMCP, false)
+
 	httpClient := observability.NewHTTPClient(nil)
 	if len(cfg.OpenAI.ExtraHeaders) > 0 {
 		httpClient = observability.WithHeaders(httpClient, cfg.OpenAI.ExtraHeaders)
@@ -737,47 +902,171 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	llmpkg.ConfigureLogging(cfg.LogPayloads, cfg.OutputTruncateByte)
 	llm, err := llmproviders.Build(*cfg, httpClient)
 	if err != nil {
+		ready.setErr(depLLM, err)
 		return nil, fmt.Errorf("build llm provider: %w", err)
 	}
+	if probeURL := llmProbeURL(cfg); probeURL != "" {
+		check := probeHTTP(httpClient, probeURL)
+		ctxProbe, cancelProbe := context.WithTimeout(ctx, readinessCheckTimeout)
+		if err := check(ctxProbe); err != nil {
+			log.Warn().Err(err).Str("url", probeURL).Msg("llm_provider_unreachable")
+			ready.setErr(depLLM, err)
+		} else {
+			ready.setErr(depLLM, nil)
+		}
+		cancelProbe()
+		ready.watch(depLLM, check)
+	} else {
+		ready.set(depLLM, depOK, "probe skipped")
+	}
 	summaryCfg := cfg.OpenAI
 	summaryCfg.Model = cfg.OpenAI.SummaryModel
 	summaryCfg.BaseURL = cfg.OpenAI.SummaryBaseURL
 	summaryLLM := openaillm.New(summaryCfg, httpClient)
 
-	toolRegistry := tools.NewRegistryWithLogging(cfg.LogPayloads)
+	guard, err := guardrails.New(cfg.Guardrails, summaryLLM, cfg.OpenAI.SummaryModel)
+	if err != nil {
+		return nil, fmt.Errorf("init guardrails: %w", err)
+	}
+	piiScrubber, err := guardrails.NewScrubber(cfg.PIIScrubbing)
+	if err != nil {
+		return nil, fmt.Errorf("init pii scrubbing: %w", err)
+	}
+	if piiScrubber != nil {
+		observability.SetStringScrubber(piiScrubber.ScrubString)
+	}
+
+	toolCache, err := resultcache.New(cfg.ToolCache)
+	if err != nil {
+		return nil, fmt.Errorf("init tool cache: %w", err)
+	}
+
+	toolRegistry := tools.NewTimeoutRegistry(tools.NewRegistryWithLogging(cfg.LogPayloads), tools.TimeoutsFromConfig(cfg.ToolTimeouts))
 	baseToolRegistry := toolRegistry
 
 	mgr, err := databases.NewManager(ctx, cfg.Databases)
+	ready.setErr(depDatabase, err)
 	if err != nil {
 		return nil, fmt.Errorf("init databases: %w", err)
 	}
 
+	// Stored secrets resolve {{secret:NAME}} references in specialist API
+	// keys, MCP server credentials and http_request calls, each only for the
+	// users and domains the secret is scoped to.
+	var secretsSvc *secrets.Service
+	if strings.TrimSpace(cfg.Secrets.MasterKey) != "" {
+		secretsSvc, err = secrets.NewService(mgr.Secrets, cfg.Secrets.MasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("init secrets: %w", err)
+		}
+		if err := secretsSvc.Load(ctx); err != nil {
+			log.Warn().Err(err).Msg("secrets_load_failed")
+		}
+		specialists.SetSecretResolver(secretsSvc.ResolveFor)
+		mcpclient.SetSecretResolver(secretsSvc.ResolveFor)
+	}
+
 	exec := cli.NewExecutor(cfg.Exec, cfg.Workdir, cfg.OutputTruncateByte)
+	cliArtifacts := cli.NewOutputStore(filepath.Join(cfg.Workdir, "cli-artifacts"))
+	exec.SetOutputStore(cliArtifacts)
 	toolRegistry.Register(cli.NewTool(exec))
+	if cfg.Exec.Python.Enabled {
+		toolRegistry.Register(pythontool.NewTool(cfg.Exec, cfg.Workdir, cfg.OutputTruncateByte))
+	}
+	if !slices.Contains(cfg.Exec.BlockBinaries, "git") {
+		git := gittool.NewRunner(cfg.Exec, cfg.Workdir, cfg.OutputTruncateByte)
+		toolRegistry.Register(gittool.NewCloneTool(git))
+		toolRegistry.Register(gittool.NewStatusTool(git))
+		toolRegistry.Register(gittool.NewDiffTool(git))
+		toolRegistry.Register(gittool.NewBranchTool(git))
+		toolRegistry.Register(gittool.NewCommitTool(git))
+		toolRegistry.Register(gittool.NewPushTool(git))
+	}
+	codeIndex := codesearch.NewIndex(cfg.Exec.CodeSearch, mgr.Search)
+	toolRegistry.Register(codesearch.NewTool(codeIndex, cfg.Workdir))
 	toolRegistry.Register(web.NewScreenshotTool())
+	toolRegistry.Register(browser.NewTool(browser.NewPool(cfg.Web.Browser.PoolSize, cfg.Web.Browser.ExecPath), cfg.Web.Browser))
 	toolRegistry.Register(web.NewFetchTool(mgr.Search))
+	// A plain client: traced transports record full URLs, which may carry secrets.
+	httpTool := httptool.NewTool(cfg.Web.HTTP, nil)
+	if secretsSvc != nil {
+		httpTool.SetSecretLookup(func(name string) (httptool.StoredSecret, bool) {
+			v, scope, ok := secretsSvc.Scoped(name)
+			return httptool.StoredSecret{Value: v, Domains: scope.Domains, UserIDs: scope.UserIDs}, ok
+		})
+	}
+	toolRegistry.Register(httpTool)
+	if cfg.Email.Enabled {
+		var lookup func(string) (string, bool)
+		if secretsSvc != nil {
+			lookup = secretsSvc.Lookup
+		} else {
+			log.Warn().Msg("email tools need secrets.masterKey to read account passwords")
+		}
+		mail := emailtool.NewClient(cfg.Email, lookup)
+		toolRegistry.Register(emailtool.NewSearchTool(mail))
+		toolRegistry.Register(emailtool.NewReadTool(mail))
+		toolRegistry.Register(emailtool.NewSendTool(mail))
+	}
+	if cfg.Calendar.Enabled {
+		var lookup func(string) (string, bool)
+		if secretsSvc != nil {
+			lookup = secretsSvc.Lookup
+		}
+		cal := calendartool.NewClient(cfg.Calendar, lookup)
+		toolRegistry.Register(calendartool.NewListTool(cal))
+		toolRegistry.Register(calendartool.NewFreeSlotsTool(cal))
+		if !cfg.Calendar.ReadOnly {
+			toolRegistry.Register(calendartool.NewCreateTool(cal))
+		}
+	}
 	toolRegistry.Register(patchtool.New(cfg.Workdir))
 	allowedRoots := []string{cfg.Workdir}
+	fileQuota := filetool.Quota{
+		MaxBytes: cfg.Projects.MaxBytesPerUser,
+		Usage:    projects.NewService(cfg.Workdir, "").Usage,
+	}
 	toolRegistry.Register(filetool.NewReadTool(allowedRoots, cfg.OutputTruncateByte))
-	toolRegistry.Register(filetool.NewWriteTool(allowedRoots, 0))
-	toolRegistry.Register(filetool.NewPatchTool(allowedRoots, 0))
+	fileWrite := filetool.NewWriteTool(allowedRoots, 0)
+	fileWrite.SetQuota(fileQuota)
+	toolRegistry.Register(fileWrite)
+	filePatch := filetool.NewPatchTool(allowedRoots, 0)
+	filePatch.SetQuota(fileQuota)
+	toolRegistry.Register(filePatch)
 	toolRegistry.Register(filetool.NewDeleteTool(allowedRoots))
-	toolRegistry.Register(textsplitter.New())
+	toolRegistry.Register(filetool.NewListTool(allowedRoots))
+	toolRegistry.Register(filetool.NewStatTool(allowedRoots))
+	transcriber := &appTranscriber{}
+	toolRegistry.Register(filetool.NewTranscribeAudioTool(allowedRoots, transcriber))
+	toolRegistry.Register(textsplitter.NewForEmbedding(cfg.Embedding))
 	toolRegistry.Register(utility.NewTextboxTool())
+	toolRegistry.Register(utility.NewPlanTool())
+	toolRegistry.Register(utility.NewScratchpadTool())
 	toolRegistry.Register(utility.NewAgentResponseTool())
 	toolRegistry.Register(matrixroomtool.New())
 	toolRegistry.Register(pulsetool.New(mgr.Pulse))
 	toolRegistry.Register(llmparallel.New(httpClient, cfg.OpenAI.BaseURL, cfg.OpenAI.Model, cfg.OpenAI.APIKey))
-	toolRegistry.Register(tts.New(*cfg, httpClient))
+	ttsTool := tts.New(*cfg, httpClient)
+	toolRegistry.Register(ttsTool)
 
 	// Register RAG tools backed by the internal rag service.
 	// Create a real embedder using the configured embedding service.
 	emb := embedder.NewClient(cfg.Embedding, cfg.Databases.Vector.Dimensions)
 	if err := emb.Ping(ctx); err != nil {
+		ready.setErr(depEmbedder, err)
 		return nil, fmt.Errorf("embedding service reachability check failed: %w", err)
 	}
+	ready.setErr(depEmbedder, nil)
+	reranker := buildRAGReranker(cfg.RAG.Rerank, summaryLLM, cfg.OpenAI.SummaryModel, httpClient)
 	toolRegistry.Register(ragtool.NewIngestTool(mgr, ragservice.WithEmbedder(emb)))
-	toolRegistry.Register(ragtool.NewRetrieveTool(mgr, ragservice.WithEmbedder(emb)))
+	docOpts, err := docloader.OptionsFromConfig(cfg.OCR)
+	if err != nil {
+		log.Warn().Err(err).Msg("ocr_disabled")
+	}
+	toolRegistry.Register(filetool.NewLoadDocumentTool(allowedRoots, cfg.OutputTruncateByte, docOpts, ragtool.NewDocumentIngester(mgr, ragservice.WithEmbedder(emb))))
+	toolRegistry.Register(ragtool.NewRetrieveTool(mgr, ragservice.WithEmbedder(emb), ragservice.WithReranker(reranker)))
+	ragQuery := ragtool.NewQueryTool(mgr, cfg.RAG.Alpha, reranker != nil, cfg.RAG.Rerank.Candidates, ragservice.WithEmbedder(emb), ragservice.WithReranker(reranker))
+	toolRegistry.Register(ragQuery)
 
 	// Register the AlphaEvolve-inspired code evolution tool.
 	toolRegistry.Register(codeevolvetool.New(cfg, llm))
@@ -826,11 +1115,18 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	agentCallTool := agenttools.NewAgentCallTool(toolRegistry, specReg, wsMgr)
 	agentCallTool.SetDefaultTimeoutSeconds(cfg.AgentRunTimeoutSeconds)
 	toolRegistry.Register(agentCallTool)
-	toolRegistry.Register(agenttools.NewAskAgentTool(httpClient, "http://127.0.0.1:32180", cfg.AgentRunTimeoutSeconds))
+	// ask_agent and delegate_to_team call back into this server.
+	selfURL := loopbackURL(cfg.Server)
+	selfClient, err := loopbackClient(cfg.Server, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	toolRegistry.Register(agenttools.NewAskAgentTool(selfClient, selfURL, cfg.AgentRunTimeoutSeconds))
 	// Team delegation uses 0 timeout (no default timeout) because team tasks are
 	// long-running multi-agent workflows. The team's internal agent runs have their
 	// own timeout management via the parent context.
-	toolRegistry.Register(agenttools.NewDelegateToTeamTool(httpClient, "http://127.0.0.1:32180", 0))
+	toolRegistry.Register(agenttools.NewDelegateToTeamTool(selfClient, selfURL, 0))
+	toolRegistry.Register(agenttools.NewHandoffTool())
 
 	mcpMgr := mcpclient.NewManager()
 	ctxInit, cancelInit := context.WithTimeout(ctx, 30*time.Second)
@@ -914,6 +1210,9 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	ctxPool, cancelPool := context.WithTimeout(ctx, 30*time.Second)
 	if err := mcpPool.RegisterFromConfig(ctxPool, baseToolRegistry); err != nil {
 		log.Warn().Err(err).Msg("mcp_pool_registration_failed")
+		ready.set(depMCP, depDegraded, err.Error())
+	} else {
+		ready.set(depMCP, depOK, fmt.Sprintf("%d configured servers attempted", len(cfg.MCP.Servers)))
 	}
 
 	// Discover and register tools from path-dependent MCP servers for UI display
@@ -928,6 +1227,15 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 		mcpPool.StartReaper(ctx, baseToolRegistry, 15*time.Minute, 1*time.Hour)
 	}
 
+	// Macro tools go last so their steps can reference any registered tool.
+	macroTools := macrotool.NewManager(baseToolRegistry)
+	for _, def := range cfg.MacroTools {
+		if err := macroTools.Upsert(def); err != nil {
+			return nil, fmt.Errorf("macro tools: %w", err)
+		}
+	}
+
+	warnUnknownAllowedTools(cfg, baseToolRegistry)
 	toolIndex := tooldiscovery.NewToolIndex(baseToolRegistry.Schemas())
 	if cfg.AutoDiscover && cfg.EnableTools {
 		toolRegistry = tooldiscovery.NewDiscoverableRegistry(baseToolRegistry, toolIndex, cfg.ToolAllowList, cfg.MaxDiscoveredTools)
@@ -935,12 +1243,27 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 		toolRegistry = tools.ApplyTopLevelPolicy(baseToolRegistry, cfg.EnableTools, cfg.ToolAllowList)
 	}
 	specReg.SetToolDiscovery(toolIndex, cfg.AutoDiscover, cfg.MaxDiscoveredTools)
+	specReg.SetPromptResolver(mcpMgr.ExpandPromptRefs)
+	specLimits := specialists.NewLimits(cfg.SpecialistLimits)
+	specReg.SetLimits(specLimits)
 
 	log.Info().Bool("enableTools", cfg.EnableTools).Bool("autoDiscover", cfg.AutoDiscover).Strs("allowList", cfg.ToolAllowList).Strs("tools", tools.SchemaNames(toolRegistry)).Msg("tool_registry_contents")
 
+	webhooks, err := newWebhookTriggers(cfg.Webhooks)
+	if err != nil {
+		return nil, fmt.Errorf("webhooks: %w", err)
+	}
+	notifier, err := notify.New(cfg.Notifications, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("notifications: %w", err)
+	}
+
 	app := &app{
 		cfg:                cfg,
 		httpClient:         httpClient,
+		sttPool:            newSTTPool(cfg.STT),
+		runQueue:           newRunQueue(cfg.RunQueue),
+		tts:                ttsTool,
 		mgr:                &mgr,
 		llm:                llm,
 		summaryLLM:         summaryLLM,
@@ -949,15 +1272,32 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 		toolIndex:          toolIndex,
 		specRegistry:       specReg,
 		userSpecRegs:       map[int64]*specialists.Registry{systemUserID: specReg},
+		specLimits:         specLimits,
 		runs:               newRunStore(),
-		flowV2:             newFlowV2Runtime(mgr.FlowV2),
+		toolApprovals:      newToolApprovalBroker(),
+		webhooks:           webhooks,
+		sseRuns:            newSSEResumeLog(sseResumeTTL),
+		drainer:            newRunDrainer(),
+		notifier:           notifier,
+		flowV2:             newFlowV2Runtime(mgr.FlowV2, mgr.WorkflowRuns),
 		evolvingSessionTTL: defaultEvolvingSessionTTL,
 		mcpStore:           mgr.MCP,
 		userPrefsStore:     mgr.UserPreferences,
+		runContexts:        mgr.RunContexts,
+		projectEnv:         mgr.ProjectEnv,
+		secrets:            secretsSvc,
+		codeIndex:          codeIndex,
 		mcpManager:         mcpMgr,
 		mcpPool:            mcpPool,
 		workspaceManager:   wsMgr,
 		transitService:     transitSvc,
+		guardrails:         guard,
+		piiScrubber:        piiScrubber,
+		ragQuery:           ragQuery,
+		readiness:          ready,
+		toolCache:          toolCache,
+		cliArtifacts:       cliArtifacts,
+		macroTools:         macroTools,
 	}
 	janitorInterval := defaultEvolvingJanitorInterval
 	if cfg.EvolvingMemory.SessionTTLMinutes > 0 {
@@ -967,6 +1307,27 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 		janitorInterval = time.Duration(cfg.EvolvingMemory.JanitorIntervalMinutes) * time.Minute
 	}
 	app.startEvolvingSessionJanitor(ctx, janitorInterval)
+	observability.EnableRunTracing()
+	if mgr.TokenUsage != nil {
+		app.tokenUsage = newTokenUsageRecorder(mgr.TokenUsage)
+		app.tokenUsage.start(tokenUsageFlushInterval)
+		llmpkg.SetTokenUsageHook(app.tokenUsage.add)
+	}
+	if cfg.StorageGC.Enabled {
+		storagegc.Start(ctx, storagegc.Rules(cfg.Workdir, cfg.StorageGC), time.Duration(cfg.StorageGC.IntervalMinutes)*time.Minute, cfg.StorageGC.DryRun)
+	}
+	webhooks.dispatch = app.dispatchWebhook
+	transcriber.app = app
+	if mgr.FileDir != "" {
+		runs, err := newFileRunStore(filepath.Join(mgr.FileDir, "runs.json"))
+		if err != nil {
+			return nil, fmt.Errorf("load runs: %w", err)
+		}
+		app.runs = runs
+	}
+	if cfg.LiveSessions.Enabled {
+		app.liveSessions = newLiveSessionHub(cfg.LiveSessions.MaxParticipants)
+	}
 
 	systemPrompt := app.composeSystemPrompt()
 
@@ -985,12 +1346,19 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 		SummaryReserveBufferTokens:   cfg.SummaryReserveBufferTokens,
 		SummaryMinKeepLastMessages:   cfg.SummaryMinKeepLastMessages,
 		SummaryMaxSummaryChunkTokens: cfg.SummaryMaxSummaryChunkTokens,
+		ToolResultRelevance:          toolResultRelevance(cfg),
+		ToolCache:                    toolCache,
+		Verifier:                     newAnswerVerifier(cfg.Verifier, summaryLLM, nil),
+		EscalationModel:              strings.TrimSpace(cfg.Verifier.EscalationModel),
 	}
 	app.engine.AttachTokenizer(llm, nil)
 
 	delegator := agenttools.NewDelegator(toolRegistry, specReg, wsMgr, cfg.MaxSteps)
 	delegator.SetDefaultTimeout(cfg.AgentRunTimeoutSeconds)
+	delegator.SetToolCache(toolCache)
 	app.engine.Delegator = delegator
+	app.engine.OnToolUsage = app.recordToolUsage
+	app.initLongTermMemory()
 
 	// Initialize evolving memory if enabled
 	if cfg.EvolvingMemory.Enabled {
@@ -1072,6 +1440,7 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 
 	app.chatStore = mgr.Chat
 	if app.chatStore == nil {
+		ready.set(depStores, depFailed, "chat store not initialized")
 		return nil, fmt.Errorf("chat store not initialized")
 	}
 	// Derive a context window for chat-memory budgeting.
@@ -1090,14 +1459,16 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	useResponsesCompaction := (cfg.LLMClient.Provider == "" || cfg.LLMClient.Provider == "openai") &&
 		strings.EqualFold(cfg.OpenAI.API, "responses")
 	app.chatMemory = memory.NewManager(app.chatStore, summaryLLM, memory.Config{
-		Enabled:                cfg.SummaryEnabled,
-		ReserveBufferTokens:    cfg.SummaryReserveBufferTokens,
-		MinKeepLastMessages:    cfg.SummaryMinKeepLastMessages,
-		MaxKeepLastMessages:    cfg.SummaryMaxKeepLastMessages,
-		MaxSummaryChunkTokens:  cfg.SummaryMaxSummaryChunkTokens,
-		ContextWindowTokens:    summaryCtxSize,
-		SummaryModel:           cfg.OpenAI.SummaryModel,
-		UseResponsesCompaction: useResponsesCompaction,
+		Enabled:                 cfg.SummaryEnabled,
+		ReserveBufferTokens:     cfg.SummaryReserveBufferTokens,
+		MinKeepLastMessages:     cfg.SummaryMinKeepLastMessages,
+		MaxKeepLastMessages:     cfg.SummaryMaxKeepLastMessages,
+		MaxSummaryChunkTokens:   cfg.SummaryMaxSummaryChunkTokens,
+		ContextWindowTokens:     summaryCtxSize,
+		SummaryModel:            cfg.OpenAI.SummaryModel,
+		UseResponsesCompaction:  useResponsesCompaction,
+		Strategy:                cfg.SummaryStrategy,
+		RetainImportantMessages: cfg.SummaryRetainImportantMessages,
 	})
 
 	if mgr.Playground == nil {
@@ -1106,14 +1477,32 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	artifactDir := filepath.Join(cfg.Workdir, "playground-artifacts")
 	artifactStore := artifacts.NewFilesystemStore(artifactDir)
 	playgroundRegistry := playgroundregistry.New(mgr.Playground)
-	playgroundDataset := dataset.NewService(mgr.Playground)
+	playgroundDataset := dataset.NewService(mgr.Playground).WithChatSource(playgroundChatSource{a: app})
 	playgroundRepo := experiment.NewRepository()
 	playgroundPlanner := experiment.NewPlanner(experiment.PlannerConfig{MaxRowsPerShard: 32, MaxVariantsPerShard: 4})
 	playgroundProvider := provider.NewLLMAdapter(llm, cfg.OpenAI.Model)
 	playgroundWorker := worker.NewWorker(playgroundProvider, artifactStore)
-	playgroundEvals := eval.NewRunner(eval.NewRegistry(), playgroundProvider)
+	// The playground falls back to the global pricing table for models it
+	// does not price itself.
+	playgroundPrices := make(provider.PriceTable, len(cfg.Pricing.Models)+len(cfg.Playground.Prices))
+	for model, p := range cfg.Pricing.Models {
+		playgroundPrices[model] = provider.Price{InputPerMillion: p.InputPerMillion, OutputPerMillion: p.OutputPerMillion}
+	}
+	for model, p := range cfg.Playground.Prices {
+		playgroundPrices[model] = provider.Price{InputPerMillion: p.InputPerMillion, OutputPerMillion: p.OutputPerMillion}
+	}
+	playgroundWorker.SetPrices(playgroundPrices)
+	playgroundEvalRegistry := eval.NewRegistry()
+	for _, ev := range cfg.Playground.Evaluators {
+		def := eval.ScriptConfig{Command: ev.Command, URL: ev.URL, Timeout: time.Duration(ev.TimeoutSeconds) * time.Second}
+		if err := playgroundEvalRegistry.RegisterScript(ev.Name, def); err != nil {
+			return nil, fmt.Errorf("playground evaluators: %w", err)
+		}
+	}
+	playgroundEvals := eval.NewRunner(playgroundEvalRegistry, playgroundProvider)
 	playgroundService := playground.NewService(playground.Config{MaxConcurrentShards: 4}, playgroundRegistry, playgroundDataset, playgroundRepo, playgroundPlanner, playgroundWorker, playgroundEvals, mgr.Playground)
 	app.playgroundHandler = httpapi.NewServer(playgroundService)
+	app.evalGates = playgroundService
 
 	// Filesystem backend only.
 	defaultSkillsDir := ""
@@ -1132,7 +1521,9 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	}
 
 	fsService := projects.NewService(cfg.Workdir, defaultSkillsDir)
+	fsService.SetQuota(cfg.Projects.MaxBytesPerUser)
 	app.projectsService = fsService
+	app.projectPreviewer = projects.NewPreviewer(fsService, 0)
 	log.Info().Str("workdir", cfg.Workdir).Msg("projects_filesystem_backend_initialized")
 
 	// Initialize skills cache service (local only).
@@ -1151,6 +1542,10 @@ func newApp(ctx context.Context, cfg *config.Config) (*app, error) {
 	if err := app.initSpecialists(ctx); err != nil {
 		return nil, err
 	}
+	if cfg.SpecialistHealth.Enabled && app.specHealth != nil {
+		checker := specialists.NewHealthChecker(cfg.SpecialistHealth, cfg.LLMClient, app.specStore, app.specHealth, app.httpClient, app.specialistHealthUsers)
+		checker.Start(ctx, time.Duration(cfg.SpecialistHealth.IntervalSeconds)*time.Second)
+	}
 
 	// Ensure ClickHouse tables exist before initializing metrics providers.
 	if strings.TrimSpace(cfg.Obs.ClickHouse.DSN) == "" {
@@ -1205,15 +1600,19 @@ func (a *app) initAuth(ctx context.Context) error {
 		return nil
 	}
 
-	dsn := a.cfg.Databases.DefaultDSN
-	if dsn == "" {
-		return fmt.Errorf("auth enabled but databases.defaultDSN is empty")
-	}
-	pool, err := databases.OpenPool(ctx, dsn)
-	if err != nil {
-		return fmt.Errorf("auth db connect failed: %w", err)
+	if a.mgr != nil && a.mgr.SQLite != nil {
+		a.authStore = auth.NewSQLiteStore(a.mgr.SQLite, a.cfg.Auth.SessionTTLHours)
+	} else {
+		dsn := a.cfg.Databases.DefaultDSN
+		if dsn == "" {
+			return fmt.Errorf("auth enabled but databases.defaultDSN is empty")
+		}
+		pool, err := databases.OpenPool(ctx, dsn)
+		if err != nil {
+			return fmt.Errorf("auth db connect failed: %w", err)
+		}
+		a.authStore = auth.NewStore(pool, a.cfg.Auth.SessionTTLHours)
 	}
-	a.authStore = auth.NewStore(pool, a.cfg.Auth.SessionTTLHours)
 	if err := a.authStore.InitSchema(ctx); err != nil {
 		return fmt.Errorf("auth schema init failed: %w", err)
 	}
@@ -1291,11 +1690,29 @@ func (a *app) initSpecialists(ctx context.Context) error {
 		}
 	}
 	specStore := databases.NewSpecialistsStore(pg)
-	_ = specStore.Init(ctx)
+	if a.mgr != nil && a.mgr.SQLite != nil {
+		specStore = databases.NewSQLiteSpecialistsStore(a.mgr.SQLite)
+	} else if a.mgr != nil && a.mgr.FileDir != "" {
+		specStore = databases.NewFileSpecialistsStore(filepath.Join(a.mgr.FileDir, "specialists.json"))
+	}
+	specErr := specStore.Init(ctx)
+	if specErr != nil {
+		log.Warn().Err(specErr).Msg("init specialists store")
+	}
 	a.specStore = specStore
 	teamStore := databases.NewSpecialistTeamsStore(pg)
-	_ = teamStore.Init(ctx)
+	teamErr := teamStore.Init(ctx)
+	if teamErr != nil {
+		log.Warn().Err(teamErr).Msg("init specialist teams store")
+	}
 	a.teamStore = teamStore
+	healthStore := databases.NewSpecialistHealthStore(pg)
+	if err := healthStore.Init(ctx); err != nil {
+		log.Warn().Err(err).Msg("init specialist health store")
+	} else {
+		a.specHealth = healthStore
+	}
+	a.readiness.setErr(depStores, errors.Join(specErr, teamErr))
 
 	if err := specialists.SeedStore(ctx, specStore, systemUserID, a.cfg.Specialists); err != nil {
 		log.Warn().Err(err).Msg("seed specialists")
@@ -1321,9 +1738,11 @@ func (a *app) initSpecialists(ctx context.Context) error {
 
 func (a *app) wrapWithMiddleware(handler http.Handler) http.Handler {
 	if a.cfg.Auth.Enabled && a.authStore != nil {
-		return auth.Middleware(a.authStore, a.cfg.Auth.CookieName, false)(handler)
+		handler = auth.Middleware(a.authStore, a.cfg.Auth.CookieName, false)(handler)
 	}
-	return handler
+	// CORS runs before auth so preflights, which carry no credentials, are
+	// answered.
+	return newCORSPolicy(a.cfg.Server.CORS).wrap(handler)
 }
 
 func (a *app) registerFrontend(mux *http.ServeMux) error {
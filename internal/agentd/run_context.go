@@ -0,0 +1,124 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	"manifold/internal/apierror"
+	"manifold/internal/llm"
+	persist "manifold/internal/persistence"
+)
+
+// recordRunContexts persists the provider context of every step of a run so
+// it can be inspected later via /api/runs/{id}/context.
+func (a *app) recordRunContexts(eng *agent.Engine, runID string) {
+	if a.runContexts == nil || eng == nil || runID == "" {
+		return
+	}
+	store := a.runContexts
+	model := eng.Model
+	eng.OnStepContext = func(step int, msgs []llm.Message) {
+		raw, err := json.Marshal(msgs)
+		if err != nil {
+			log.Warn().Err(err).Str("run_id", runID).Int("step", step).Msg("run_context_marshal_failed")
+			return
+		}
+		snap := persist.RunContextSnapshot{
+			RunID:        runID,
+			Step:         step,
+			Model:        model,
+			MessageCount: len(msgs),
+			Messages:     raw,
+			CreatedAt:    time.Now().UTC(),
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := store.Record(ctx, snap); err != nil {
+				log.Warn().Err(err).Str("run_id", runID).Int("step", step).Msg("run_context_record_failed")
+			}
+		}()
+	}
+}
+
+// runDetailHandler serves /api/runs/{id}/context?step=N, which returns the
+// exact messages sent to the provider at step N of the run. Without a step
+// it lists the recorded steps. /api/runs/{id}/approvals is handled by
+// handleRunApprovals and /api/runs/{id}/trace by handleRunTrace.
+func (a *app) runDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := a.requireUserID(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
+			apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/runs/"), "/")
+		runID, sub, _ := strings.Cut(rest, "/")
+		sub, toolCallID, _ := strings.Cut(sub, "/")
+		if runID != "" && sub == "approvals" && !strings.Contains(toolCallID, "/") {
+			a.handleRunApprovals(w, r, userID, runID, toolCallID)
+			return
+		}
+		if runID != "" && sub == "events" && toolCallID == "" {
+			a.handleRunEvents(w, r, userID, runID)
+			return
+		}
+		if runID != "" && sub == "trace" && toolCallID == "" {
+			a.handleRunTrace(w, r, userID, runID)
+			return
+		}
+		if runID == "" || sub != "context" || toolCallID != "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if a.runContexts == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "run context store unavailable")
+			return
+		}
+
+		rawStep := strings.TrimSpace(r.URL.Query().Get("step"))
+		if rawStep == "" {
+			steps, err := a.runContexts.Steps(r.Context(), runID)
+			if err != nil {
+				log.Error().Err(err).Str("run_id", runID).Msg("run_context_steps")
+				writeError(w, http.StatusInternalServerError, errors.New("failed to list run steps"))
+				return
+			}
+			if len(steps) == 0 {
+				writeError(w, http.StatusNotFound, errors.New("no context recorded for run"))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"runId": runID, "steps": steps})
+			return
+		}
+		step, err := strconv.Atoi(rawStep)
+		if err != nil || step < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("step must be a non-negative integer"))
+			return
+		}
+		snap, err := a.runContexts.Get(r.Context(), runID, step)
+		if errors.Is(err, persist.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("no context recorded for run step"))
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("run_id", runID).Int("step", step).Msg("run_context_get")
+			writeError(w, http.StatusInternalServerError, errors.New("failed to load run context"))
+			return
+		}
+		writeJSON(w, http.StatusOK, snap)
+	}
+}
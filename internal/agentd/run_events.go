@@ -0,0 +1,53 @@
+package agentd
+
+import (
+	"net/http"
+	"strings"
+
+	"manifold/internal/agent"
+	"manifold/internal/apierror"
+)
+
+// recordRunEvents adds the engine's step events to the timeline of run
+// runID in the runs store.
+func (a *app) recordRunEvents(eng *agent.Engine, runID string, userID *int64) {
+	if a.runs == nil || eng == nil || runID == "" {
+		return
+	}
+	runs := a.runs
+	if userID != nil {
+		runs.setOwner(runID, *userID)
+	}
+	eng.OnStepEvent = func(ev agent.StepEvent) {
+		runs.appendEvent(runID, ev)
+	}
+}
+
+// wantsRunTimeline reports whether a GET /api/runs/{id}/events request asks
+// for the JSON timeline rather than resuming the run's SSE stream.
+func wantsRunTimeline(r *http.Request) bool {
+	if lastEventID(r) != "" || r.URL.Query().Has("sse_schema") {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/event-stream")
+}
+
+// handleRunTimeline serves the step timeline of a run: model requests and
+// responses, tool starts and results, and summary compactions.
+func (a *app) handleRunTimeline(w http.ResponseWriter, r *http.Request, userID int64, runID string) {
+	if r.Method != http.MethodGet {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	run, ok := a.runs.get(runID)
+	if !ok || (a.cfg.Auth.Enabled && run.UserID != userID) {
+		apierror.Respond(w, http.StatusNotFound, "run not found")
+		return
+	}
+	events := run.Events
+	if events == nil {
+		events = []agent.StepEvent{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"runId": run.ID, "status": run.Status, "events": events})
+}
@@ -0,0 +1,70 @@
+package agentd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"manifold/internal/agent"
+	"manifold/internal/config"
+)
+
+func TestRunTimeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	runs, err := newFileRunStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &app{cfg: &config.Config{}, runs: runs}
+	run := runs.create("list files")
+	owner := int64(4)
+	eng := &agent.Engine{}
+	a.recordRunEvents(eng, run.ID, &owner)
+	eng.OnStepEvent(agent.StepEvent{Type: agent.StepEventLLMResponse, PromptTokens: 12, CompletionTokens: 3})
+	eng.OnStepEvent(agent.StepEvent{Type: agent.StepEventToolStart, Tool: "run_cli"})
+	runs.updateStatus(run.ID, "completed", 0)
+
+	if listed := runs.list(); len(listed) != 1 || listed[0].Events != nil {
+		t.Fatalf("run lists should leave out timelines: %+v", listed)
+	}
+
+	get := func(userID int64, accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/runs/"+run.ID+"/events", nil)
+		req.Header.Set("Accept", accept)
+		if wantsRunTimeline(req) {
+			a.handleRunTimeline(rec, req, userID, run.ID)
+		}
+		return rec
+	}
+	rec := get(4, "application/json")
+	var body struct {
+		Status string            `json:"status"`
+		Events []agent.StepEvent `json:"events"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body = %s (%v)", rec.Code, rec.Body.String(), err)
+	}
+	if body.Status != "completed" || len(body.Events) != 2 || body.Events[0].PromptTokens != 12 || body.Events[1].Tool != "run_cli" {
+		t.Fatalf("timeline = %+v", body)
+	}
+	if rec := get(4, "text/event-stream"); rec.Body.Len() != 0 {
+		t.Fatal("SSE clients should keep resuming the stream")
+	}
+
+	a.cfg.Auth.Enabled = true
+	if rec := get(5, "application/json"); rec.Code != http.StatusNotFound {
+		t.Fatalf("another user's timeline: status = %d", rec.Code)
+	}
+
+	// The timeline is saved with the run.
+	reloaded, err := newFileRunStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reloaded.get(run.ID); !ok || len(got.Events) != 2 || got.UserID != 4 {
+		t.Fatalf("reloaded run = %+v", got)
+	}
+}
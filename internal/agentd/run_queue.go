@@ -0,0 +1,172 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+)
+
+var (
+	// errRunQueueFull is returned when every run slot is taken and the
+	// queue holds runQueue.queueDepth waiters already.
+	errRunQueueFull = errors.New("run queue is full")
+	// errRunQueueTimeout is returned when a queued run waits longer than
+	// runQueue.queueTimeoutSeconds.
+	errRunQueueTimeout = errors.New("timed out waiting in the run queue")
+)
+
+// runQueue bounds concurrent agent runs so a burst of requests cannot
+// overload the LLM backend or CPU-heavy tools. Runs past the limit wait in
+// FIFO order and are told their position as it changes. A nil queue
+// imposes no limit.
+type runQueue struct {
+	max     int
+	depth   int
+	timeout time.Duration
+
+	mu      sync.Mutex
+	running int
+	waiting []*runWaiter
+}
+
+type runWaiter struct {
+	// ready is closed when release hands the waiter a slot.
+	ready chan struct{}
+	// moved is signalled when the waiter's position changes.
+	moved chan struct{}
+}
+
+// newRunQueue returns nil when cfg.MaxConcurrent is unset.
+func newRunQueue(cfg config.RunQueueConfig) *runQueue {
+	if cfg.MaxConcurrent <= 0 {
+		return nil
+	}
+	timeout := time.Duration(cfg.QueueTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return &runQueue{max: cfg.MaxConcurrent, depth: cfg.QueueDepth, timeout: timeout}
+}
+
+// acquire takes a run slot and returns its release func. When no slot is
+// free it queues the caller and calls onWait with its 1-based position,
+// again each time the position changes. It fails with errRunQueueFull when
+// the queue is full, errRunQueueTimeout when the wait times out, or ctx's
+// error if ctx ends first.
+func (q *runQueue) acquire(ctx context.Context, onWait func(position int)) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	q.mu.Lock()
+	if q.running < q.max && len(q.waiting) == 0 {
+		q.running++
+		q.mu.Unlock()
+		return q.releaseFunc(), nil
+	}
+	if len(q.waiting) >= q.depth {
+		q.mu.Unlock()
+		return nil, errRunQueueFull
+	}
+	rw := &runWaiter{ready: make(chan struct{}), moved: make(chan struct{}, 1)}
+	q.waiting = append(q.waiting, rw)
+	pos := len(q.waiting)
+	q.mu.Unlock()
+
+	if onWait != nil {
+		onWait(pos)
+	}
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-rw.ready:
+			return q.releaseFunc(), nil
+		case <-rw.moved:
+			if p := q.position(rw); p > 0 && p != pos {
+				pos = p
+				if onWait != nil {
+					onWait(pos)
+				}
+			}
+		case <-timer.C:
+			return nil, q.abandon(rw, errRunQueueTimeout)
+		case <-ctx.Done():
+			return nil, q.abandon(rw, ctx.Err())
+		}
+	}
+}
+
+// abandon removes rw from the queue. If release handed rw a slot in the
+// meantime, the slot is passed on instead.
+func (q *runQueue) abandon(rw *runWaiter, err error) error {
+	q.mu.Lock()
+	for i, w := range q.waiting {
+		if w == rw {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			q.notifyLocked(i)
+			q.mu.Unlock()
+			return err
+		}
+	}
+	q.mu.Unlock()
+	// Not waiting any more, so ready was closed: give the slot back.
+	q.release()
+	return err
+}
+
+func (q *runQueue) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(q.release) }
+}
+
+// release hands the slot to the longest waiter, or frees it.
+func (q *runQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		q.running--
+		return
+	}
+	next := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	close(next.ready)
+	q.notifyLocked(0)
+}
+
+// notifyLocked tells waiters from index i on that they moved up.
+func (q *runQueue) notifyLocked(i int) {
+	for _, w := range q.waiting[i:] {
+		select {
+		case w.moved <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *runQueue) position(rw *runWaiter) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, w := range q.waiting {
+		if w == rw {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// isRunQueueRejection reports whether err means the run was turned away by
+// a full queue or a timed-out wait rather than by its own context ending.
+func isRunQueueRejection(err error) bool {
+	return errors.Is(err, errRunQueueFull) || errors.Is(err, errRunQueueTimeout)
+}
+
+// retryAfter suggests how long a rejected client should wait, in seconds.
+func (q *runQueue) retryAfter() int {
+	if q == nil {
+		return 1
+	}
+	return max(1, int(q.timeout/time.Second)/2)
+}
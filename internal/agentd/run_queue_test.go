@@ -0,0 +1,150 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"manifold/internal/config"
+	"manifold/internal/grpcapi"
+)
+
+func TestRunQueueDisabledWhenUnset(t *testing.T) {
+	q := newRunQueue(config.RunQueueConfig{})
+	if q != nil {
+		t.Fatalf("expected nil queue without maxConcurrent")
+	}
+	release, err := q.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("nil queue acquire: %v", err)
+	}
+	release()
+}
+
+func TestRunQueueRejectsWhenFull(t *testing.T) {
+	q := newRunQueue(config.RunQueueConfig{MaxConcurrent: 1})
+	release, err := q.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if _, err := q.acquire(context.Background(), nil); !errors.Is(err, errRunQueueFull) {
+		t.Fatalf("expected errRunQueueFull with no queue, got %v", err)
+	}
+	release()
+	release() // a second call must not free another slot
+	release, err = q.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	if _, err := q.acquire(context.Background(), nil); !errors.Is(err, errRunQueueFull) {
+		t.Fatalf("expected errRunQueueFull after double release, got %v", err)
+	}
+	release()
+}
+
+func TestRunQueueTimesOut(t *testing.T) {
+	q := newRunQueue(config.RunQueueConfig{MaxConcurrent: 1, QueueDepth: 1})
+	q.timeout = 20 * time.Millisecond
+	release, _ := q.acquire(context.Background(), nil)
+	defer release()
+	var positions []int
+	_, err := q.acquire(context.Background(), func(p int) { positions = append(positions, p) })
+	if !errors.Is(err, errRunQueueTimeout) {
+		t.Fatalf("expected errRunQueueTimeout, got %v", err)
+	}
+	if len(positions) != 1 || positions[0] != 1 {
+		t.Fatalf("expected one position report of 1, got %v", positions)
+	}
+	if len(q.waiting) != 0 {
+		t.Fatalf("timed out waiter left in queue")
+	}
+}
+
+func TestRunQueueServesWaitersInOrder(t *testing.T) {
+	q := newRunQueue(config.RunQueueConfig{MaxConcurrent: 1, QueueDepth: 2})
+	release, _ := q.acquire(context.Background(), nil)
+
+	type result struct {
+		name    string
+		release func()
+	}
+	done := make(chan result, 2)
+	wait := func(name string, positions chan<- int) {
+		rel, err := q.acquire(context.Background(), func(p int) { positions <- p })
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			return
+		}
+		done <- result{name, rel}
+	}
+	firstPos := make(chan int, 4)
+	secondPos := make(chan int, 4)
+	go wait("first", firstPos)
+	if p := <-firstPos; p != 1 {
+		t.Fatalf("first waiter position = %d, want 1", p)
+	}
+	go wait("second", secondPos)
+	if p := <-secondPos; p != 2 {
+		t.Fatalf("second waiter position = %d, want 2", p)
+	}
+
+	release()
+	first := <-done
+	if first.name != "first" {
+		t.Fatalf("expected first waiter to run first, got %s", first.name)
+	}
+	if p := <-secondPos; p != 1 {
+		t.Fatalf("second waiter moved to %d, want 1", p)
+	}
+	first.release()
+	second := <-done
+	if second.name != "second" {
+		t.Fatalf("expected second waiter next, got %s", second.name)
+	}
+	second.release()
+	if q.running != 0 {
+		t.Fatalf("expected all slots free, running=%d", q.running)
+	}
+}
+
+func TestRunQueueCancelledWaiterFreesPlace(t *testing.T) {
+	q := newRunQueue(config.RunQueueConfig{MaxConcurrent: 1, QueueDepth: 1})
+	release, _ := q.acquire(context.Background(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(ctx, func(int) { cancel() })
+		errc <- err
+	}()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	release()
+	release, err := q.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("acquire after cancelled waiter: %v", err)
+	}
+	release()
+}
+
+func TestRunQueueLimitsGRPCRuns(t *testing.T) {
+	a := &app{
+		cfg:       &config.Config{},
+		chatStore: newPromptHandlerChatStore(),
+		runs:      newRunStore(),
+		runQueue:  newRunQueue(config.RunQueueConfig{MaxConcurrent: 1}),
+	}
+	release, err := a.runQueue.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	err = grpcBackend{a: a}.RunAgent(context.Background(), systemUserID, &grpcapi.RunAgentRequest{Prompt: "hi"}, func(*grpcapi.RunAgentEvent) error { return nil })
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
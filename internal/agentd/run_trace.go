@@ -0,0 +1,63 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"manifold/internal/apierror"
+	"manifold/internal/observability"
+)
+
+const agentdTracerName = "internal/agentd"
+
+// startRunSpan tags ctx with the run's IDs, so every LLM, tool and database
+// span below it carries them, and starts the run's root span.
+func startRunSpan(ctx context.Context, runID, sessionID string, userID *int64, endpoint string) (context.Context, trace.Span) {
+	owner := systemUserID
+	if userID != nil {
+		owner = *userID
+	}
+	ctx = observability.WithRun(ctx, observability.RunInfo{RunID: runID, SessionID: sessionID, UserID: owner})
+	return otel.Tracer(agentdTracerName).Start(ctx, "agent.run", trace.WithAttributes(attribute.String("agent.endpoint", endpoint)))
+}
+
+// failRunSpan marks the run's root span as failed.
+func failRunSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+type runTraceResponse struct {
+	RunID     string                   `json:"runId"`
+	SessionID string                   `json:"sessionId,omitempty"`
+	UserID    int64                    `json:"userId"`
+	Spans     []*observability.RunSpan `json:"spans"`
+	// Dropped counts spans left out once the run hit the per-run limit.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// handleRunTrace serves /api/runs/{id}/trace: the spans recorded for the
+// run by this process, assembled into a tree.
+func (a *app) handleRunTrace(w http.ResponseWriter, r *http.Request, userID int64, runID string) {
+	if r.Method != http.MethodGet {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	info, spans, dropped, ok := observability.RunTrace(runID)
+	if !ok || (a.cfg.Auth.Enabled && info.UserID != userID) {
+		apierror.Respond(w, http.StatusNotFound, "no trace recorded for run")
+		return
+	}
+	writeJSON(w, http.StatusOK, runTraceResponse{
+		RunID:     runID,
+		SessionID: info.SessionID,
+		UserID:    info.UserID,
+		Spans:     spans,
+		Dropped:   dropped,
+	})
+}
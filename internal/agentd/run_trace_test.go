@@ -0,0 +1,55 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"manifold/internal/config"
+	"manifold/internal/observability"
+)
+
+func TestHandleRunTrace(t *testing.T) {
+	observability.EnableRunTracing()
+	owner := int64(9)
+	ctx, span := startRunSpan(context.Background(), "run-trace-handler", "sess-1", &owner, "/agent/run")
+	_, child := otel.Tracer("test").Start(ctx, "chat")
+	child.End()
+	failRunSpan(span, errors.New("boom"))
+	span.End()
+
+	get := func(a *app, userID int64, runID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.handleRunTrace(rec, httptest.NewRequest(http.MethodGet, "/api/runs/"+runID+"/trace", nil), userID, runID)
+		return rec
+	}
+	a := &app{cfg: &config.Config{}}
+	rec := get(a, systemUserID, "run-trace-handler")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp runTraceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SessionID != "sess-1" || resp.UserID != 9 || len(resp.Spans) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	root := resp.Spans[0]
+	if root.Name != "agent.run" || root.Status != "error" || len(root.Children) != 1 || root.Children[0].Attributes[observability.RunIDAttr] != "run-trace-handler" {
+		t.Fatalf("root = %+v", root)
+	}
+
+	a.cfg.Auth.Enabled = true
+	if rec := get(a, 10, "run-trace-handler"); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user: status = %d", rec.Code)
+	}
+	if rec := get(a, 9, "unknown-run"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown run: status = %d", rec.Code)
+	}
+}
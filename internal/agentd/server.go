@@ -0,0 +1,299 @@
+package agentd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"manifold/internal/config"
+	"manifold/internal/observability"
+)
+
+// listener is the agentd HTTP server with its optional ACME challenge
+// server.
+type listener struct {
+	srv  *http.Server
+	acme *http.Server
+}
+
+// newListener builds the HTTP server from cfg.Server. With TLS configured it
+// serves HTTPS, negotiating HTTP/2 unless disabled; without TLS it serves
+// HTTP/1.1 and, when enabled, cleartext HTTP/2.
+func newListener(cfg *config.Config, handler http.Handler, base context.Context) (*listener, error) {
+	sc := cfg.Server
+	srv := &http.Server{
+		Addr:              sc.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 30 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return base },
+	}
+	l := &listener{srv: srv}
+
+	tlsCfg, challenge, err := serverTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	http2 := sc.HTTP2 == nil || *sc.HTTP2
+	var protos http.Protocols
+	protos.SetHTTP1(true)
+	protos.SetHTTP2(tlsCfg != nil && http2)
+	protos.SetUnencryptedHTTP2(tlsCfg == nil && sc.H2C)
+	srv.Protocols = &protos
+	if tlsCfg == nil {
+		return l, nil
+	}
+	if !http2 {
+		tlsCfg.NextProtos = slices.DeleteFunc(slices.Clone(tlsCfg.NextProtos), func(p string) bool { return p == "h2" })
+	}
+	srv.TLSConfig = tlsCfg
+	if challenge != nil && sc.TLS.ACME.HTTPAddr != "" {
+		l.acme = &http.Server{
+			Addr:              sc.TLS.ACME.HTTPAddr,
+			Handler:           challenge,
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+	}
+	return l, nil
+}
+
+func (l *listener) secure() bool { return l.srv.TLSConfig != nil }
+
+// serve runs the server until it is shut down.
+func (l *listener) serve() error {
+	if l.acme != nil {
+		go func() {
+			if err := l.acme.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Str("addr", l.acme.Addr).Msg("acme http listener stopped")
+			}
+		}()
+	}
+	if l.secure() {
+		return l.srv.ListenAndServeTLS("", "")
+	}
+	return l.srv.ListenAndServe()
+}
+
+func (l *listener) shutdown(ctx context.Context) error {
+	if l.acme != nil {
+		_ = l.acme.Shutdown(ctx)
+	}
+	return l.srv.Shutdown(ctx)
+}
+
+// errGRPCPlaintext refuses a gRPC listener without TLS, which would send
+// bearer tokens in the clear.
+var errGRPCPlaintext = errors.New("grpc: server.tls is not configured; set grpc.plaintext to serve gRPC without TLS")
+
+// grpcServerOptions secures the gRPC listener with the HTTP listener's TLS
+// config, so certificates, ACME and mTLS apply to both. Without TLS it
+// fails unless cfg.Plaintext opts into cleartext.
+func grpcServerOptions(cfg config.GRPCConfig, tlsCfg *tls.Config) ([]grpc.ServerOption, error) {
+	if tlsCfg == nil {
+		if !cfg.Plaintext {
+			return nil, errGRPCPlaintext
+		}
+		return nil, nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}, nil
+}
+
+// serverTLSConfig returns the listener TLS config, or nil when TLS is off.
+// With ACME it also returns the HTTP-01 challenge handler, which redirects
+// other requests to HTTPS.
+func serverTLSConfig(cfg *config.Config) (*tls.Config, http.Handler, error) {
+	t := cfg.Server.TLS
+	var (
+		tlsCfg    *tls.Config
+		challenge http.Handler
+	)
+	switch {
+	case t.ACME.Enabled:
+		cacheDir := t.ACME.CacheDir
+		if cacheDir == "" {
+			cacheDir = filepath.Join(cfg.Workdir, ".acme")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(t.ACME.Domains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      t.ACME.Email,
+		}
+		if t.ACME.DirectoryURL != "" {
+			m.Client = &acme.Client{DirectoryURL: t.ACME.DirectoryURL}
+		}
+		tlsCfg = m.TLSConfig()
+		challenge = m.HTTPHandler(nil)
+	case t.CertFile != "":
+		certs, err := newCertReloader(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsCfg = &tls.Config{
+			GetCertificate: certs.getCertificate,
+			NextProtos:     []string{"h2", "http/1.1"},
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	tlsCfg.MinVersion = tls.VersionTLS12
+	if t.MinVersion == "1.3" {
+		tlsCfg.MinVersion = tls.VersionTLS13
+	}
+	if t.ClientCAFile != "" {
+		pool, err := loadCertPool(t.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("server.tls.clientCAFile: %w", err)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if t.ClientAuth == "optional" {
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return tlsCfg, challenge, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return pool, nil
+}
+
+// certReloader serves a certificate pair from disk and picks up renewals,
+// such as those written by cert-manager, without a restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.load(); err != nil {
+		return nil, fmt.Errorf("server.tls: %w", err)
+	}
+	return r, nil
+}
+
+// load reads the pair again when the certificate file changed. Files are
+// checked at most every ten seconds.
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && time.Since(r.checked) < 10*time.Second {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+	st, err := os.Stat(r.certFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	if r.cert != nil && st.ModTime().Equal(r.modTime) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			// Keep serving the old pair while a renewal is half written.
+			log.Warn().Err(err).Msg("tls certificate reload failed")
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	r.cert, r.modTime = &cert, st.ModTime()
+	return r.cert, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.load()
+}
+
+// loopbackURL is the base URL agentd uses to call its own API, as the
+// ask_agent and delegate_to_team tools do.
+func loopbackURL(sc config.ServerConfig) string {
+	scheme := "http"
+	if sc.TLS.CertFile != "" || sc.TLS.ACME.Enabled {
+		scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(sc.Addr)
+	if err != nil {
+		return scheme + "://127.0.0.1:32180"
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// loopbackClient returns the client for loopbackURL. With TLS on, it checks
+// the server certificate against its configured name rather than the
+// loopback address, trusts a self-signed certificate from certFile, and
+// presents that same pair when mTLS is required.
+func loopbackClient(sc config.ServerConfig, base *http.Client) (*http.Client, error) {
+	t := sc.TLS
+	if t.CertFile == "" && !t.ACME.Enabled {
+		return base, nil
+	}
+	clientTLS := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.ACME.Enabled {
+		clientTLS.ServerName = t.ACME.Domains[0]
+	} else {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("server.tls: %w", err)
+		}
+		leaf := cert.Leaf
+		if leaf == nil {
+			if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+				return nil, fmt.Errorf("server.tls: %w", err)
+			}
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		roots.AddCert(leaf)
+		clientTLS.RootCAs = roots
+		if len(leaf.DNSNames) > 0 {
+			clientTLS.ServerName = leaf.DNSNames[0]
+		}
+		if t.ClientCAFile != "" {
+			clientTLS.Certificates = []tls.Certificate{cert}
+		}
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = clientTLS
+	c := &http.Client{Transport: tr}
+	if base != nil {
+		c.Timeout = base.Timeout
+	}
+	return observability.NewHTTPClient(c), nil
+}
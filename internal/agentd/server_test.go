@@ -0,0 +1,191 @@
+package agentd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/grpcapi"
+)
+
+// writeSelfSigned writes a certificate for manifold.test usable for both
+// server and client auth, and returns the cert and key paths.
+func writeSelfSigned(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "manifold.test"},
+		DNSNames:              []string{"manifold.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestListenerServesMutualTLSWithHTTP2(t *testing.T) {
+	certFile, keyFile := writeSelfSigned(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Server: config.ServerConfig{
+		Addr: ln.Addr().String(),
+		TLS: config.ServerTLSConfig{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: certFile,
+			ClientAuth:   "require",
+		},
+	}}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	l, err := newListener(cfg, handler, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = l.srv.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = l.shutdown(context.Background()) })
+
+	client, err := loopbackClient(cfg.Server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(loopbackURL(cfg.Server) + "/healthz")
+	if err != nil {
+		t.Fatalf("loopback request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+
+	// Without a client certificate the handshake is refused.
+	roots := x509.NewCertPool()
+	pemBytes, _ := os.ReadFile(certFile)
+	roots.AppendCertsFromPEM(pemBytes)
+	anon := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "manifold.test"}}}
+	if resp, err := anon.Get(loopbackURL(cfg.Server) + "/healthz"); err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected request without a client certificate to fail")
+	}
+}
+
+func TestGRPCServerOptionsRequireTLS(t *testing.T) {
+	if _, err := grpcServerOptions(config.GRPCConfig{}, nil); !errors.Is(err, errGRPCPlaintext) {
+		t.Fatalf("expected plaintext gRPC to be refused, got %v", err)
+	}
+	if opts, err := grpcServerOptions(config.GRPCConfig{Plaintext: true}, nil); err != nil || opts != nil {
+		t.Fatalf("expected opted-in plaintext, got %v %v", opts, err)
+	}
+
+	certFile, keyFile := writeSelfSigned(t)
+	tlsCfg, _, err := serverTLSConfig(&config.Config{Server: config.ServerConfig{TLS: config.ServerTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: certFile,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := grpcServerOptions(config.GRPCConfig{}, tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpcapi.NewServer(grpcBackend{a: &app{}}, opts...)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	roots := x509.NewCertPool()
+	pemBytes, _ := os.ReadFile(certFile)
+	roots.AppendCertsFromPEM(pemBytes)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func(certs []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			RootCAs:      roots,
+			ServerName:   "manifold.test",
+			Certificates: certs,
+			NextProtos:   []string{"h2"},
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// TLS 1.3 reports a rejected client certificate on the first read.
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+			return err
+		}
+		return nil
+	}
+	if err := dial([]tls.Certificate{cert}); err != nil {
+		t.Fatalf("mTLS handshake: %v", err)
+	}
+	if err := dial(nil); err == nil {
+		t.Fatal("expected a handshake without a client certificate to fail")
+	}
+}
+
+func TestLoopbackURL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		server config.ServerConfig
+		want   string
+	}{
+		{config.ServerConfig{Addr: ":32180"}, "http://127.0.0.1:32180"},
+		{config.ServerConfig{Addr: "0.0.0.0:8080"}, "http://127.0.0.1:8080"},
+		{config.ServerConfig{Addr: "10.0.0.5:8443", TLS: config.ServerTLSConfig{CertFile: "c", KeyFile: "k"}}, "https://10.0.0.5:8443"},
+		{config.ServerConfig{Addr: "[::]:443", TLS: config.ServerTLSConfig{ACME: config.ACMEConfig{Enabled: true}}}, "https://127.0.0.1:443"},
+	}
+	for _, tc := range cases {
+		if got := loopbackURL(tc.server); got != tc.want {
+			t.Errorf("loopbackURL(%q) = %q, want %q", tc.server.Addr, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package agentd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"manifold/internal/agent/memory"
+	"manifold/internal/apierror"
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
+)
+
+const (
+	// sessionGraphDefaultDepth and sessionGraphMaxDepth bound the graph store
+	// traversal from the session node.
+	sessionGraphDefaultDepth = 2
+	sessionGraphMaxDepth     = 4
+	// sessionGraphMaxNodes caps the export so large projects stay renderable.
+	sessionGraphMaxNodes = 500
+	// sessionGraphLabelLimit truncates node labels taken from memory text.
+	sessionGraphLabelLimit = 80
+)
+
+// sessionGraphNode is a vertex in a session knowledge graph export.
+type sessionGraphNode struct {
+	ID     string         `json:"id"`
+	Kind   string         `json:"kind"`
+	Label  string         `json:"label"`
+	Labels []string       `json:"labels,omitempty"`
+	Props  map[string]any `json:"props,omitempty"`
+}
+
+// sessionGraphEdge is a directed relation in a session knowledge graph export.
+type sessionGraphEdge struct {
+	Source string         `json:"source"`
+	Target string         `json:"target"`
+	Rel    string         `json:"rel"`
+	Props  map[string]any `json:"props,omitempty"`
+}
+
+// sessionGraph is what the agent knows about a session: its evolving memory
+// entries and the graph store neighbourhood of the session node.
+type sessionGraph struct {
+	SessionID string             `json:"sessionId"`
+	Nodes     []sessionGraphNode `json:"nodes"`
+	Edges     []sessionGraphEdge `json:"edges"`
+	Truncated bool               `json:"truncated,omitempty"`
+}
+
+// sessionGraphNodeID is the graph store node that other components link
+// session-scoped entities and decisions to.
+func sessionGraphNodeID(sessionID string) string { return "session:" + sessionID }
+
+type sessionGraphBuilder struct {
+	g     sessionGraph
+	seen  map[string]bool
+	edges map[string]bool
+}
+
+func newSessionGraphBuilder(sessionID string) *sessionGraphBuilder {
+	return &sessionGraphBuilder{
+		g:     sessionGraph{SessionID: sessionID, Nodes: []sessionGraphNode{}, Edges: []sessionGraphEdge{}},
+		seen:  map[string]bool{},
+		edges: map[string]bool{},
+	}
+}
+
+func (b *sessionGraphBuilder) addNode(n sessionGraphNode) bool {
+	if b.seen[n.ID] {
+		return true
+	}
+	if len(b.g.Nodes) >= sessionGraphMaxNodes {
+		b.g.Truncated = true
+		return false
+	}
+	b.seen[n.ID] = true
+	b.g.Nodes = append(b.g.Nodes, n)
+	return true
+}
+
+func (b *sessionGraphBuilder) addEdge(e sessionGraphEdge) {
+	key := e.Source + "\x00" + e.Rel + "\x00" + e.Target
+	if b.edges[key] || !b.seen[e.Source] || !b.seen[e.Target] {
+		return
+	}
+	b.edges[key] = true
+	b.g.Edges = append(b.g.Edges, e)
+}
+
+// addMemories links evolving memory entries to the session node. Entries
+// carrying a strategy card are reported as decisions; tags become shared
+// topic nodes.
+func (b *sessionGraphBuilder) addMemories(rootID string, entries []*memory.MemoryEntry) {
+	sorted := append([]*memory.MemoryEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+	for _, e := range sorted {
+		if e == nil || e.ID == "" {
+			continue
+		}
+		kind := string(e.MemoryType)
+		if kind == "" {
+			kind = string(memory.MemoryFactual)
+		}
+		if strings.TrimSpace(e.StrategyCard) != "" {
+			kind = "decision"
+		}
+		label := firstNonEmptyTrimmed(e.Summary, e.StrategyCard, e.Input)
+		props := map[string]any{"createdAt": e.CreatedAt}
+		if e.Feedback != "" {
+			props["feedback"] = e.Feedback
+		}
+		id := "memory:" + e.ID
+		if !b.addNode(sessionGraphNode{ID: id, Kind: kind, Label: truncateGraphLabel(label), Props: props}) {
+			return
+		}
+		b.addEdge(sessionGraphEdge{Source: rootID, Rel: "remembers", Target: id})
+		if tag, _ := e.Metadata["tag"].(string); strings.TrimSpace(tag) != "" {
+			tagID := "tag:" + strings.TrimSpace(tag)
+			if b.addNode(sessionGraphNode{ID: tagID, Kind: "topic", Label: strings.TrimSpace(tag)}) {
+				b.addEdge(sessionGraphEdge{Source: id, Rel: "tagged", Target: tagID})
+			}
+		}
+	}
+}
+
+// addGraphNeighbourhood walks outgoing edges from the session node in the
+// graph store, breadth first, up to depth hops.
+func (b *sessionGraphBuilder) addGraphNeighbourhood(ctx context.Context, g databases.GraphDB, rootID string, depth int) error {
+	lister, ok := g.(databases.GraphEdgeLister)
+	if !ok {
+		return nil
+	}
+	frontier := []string{rootID}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			edges, err := lister.OutEdges(ctx, id)
+			if err != nil {
+				return err
+			}
+			for _, e := range edges {
+				if !b.seen[e.Target] {
+					if !b.addNode(graphStoreNode(ctx, g, e.Target)) {
+						return nil
+					}
+					next = append(next, e.Target)
+				}
+				b.addEdge(sessionGraphEdge{Source: e.Source, Rel: e.Rel, Target: e.Target, Props: e.Props})
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+func graphStoreNode(ctx context.Context, g databases.GraphDB, id string) sessionGraphNode {
+	n := sessionGraphNode{ID: id, Kind: "entity", Label: id}
+	node, ok := g.GetNode(ctx, id)
+	if !ok {
+		return n
+	}
+	n.Labels = node.Labels
+	n.Props = node.Props
+	if len(node.Labels) > 0 {
+		n.Kind = strings.ToLower(node.Labels[0])
+	}
+	for _, key := range []string{"name", "title", "label"} {
+		if v, ok := node.Props[key].(string); ok && strings.TrimSpace(v) != "" {
+			n.Label = truncateGraphLabel(v)
+			break
+		}
+	}
+	return n
+}
+
+// buildSessionGraph assembles the export for sess.
+func (a *app) buildSessionGraph(ctx context.Context, owner int64, sess persist.ChatSession, depth int) (sessionGraph, error) {
+	b := newSessionGraphBuilder(sess.ID)
+	rootID := sessionGraphNodeID(sess.ID)
+	b.addNode(sessionGraphNode{
+		ID:    rootID,
+		Kind:  "session",
+		Label: firstNonEmptyTrimmed(sess.Name, sess.ID),
+		Props: map[string]any{"model": sess.Model, "updatedAt": sess.UpdatedAt},
+	})
+	if em := a.getOrCreateEvolvingMemoryForSession(owner, sess.ID); em != nil {
+		b.addMemories(rootID, em.ExportMemories())
+	}
+	if a.mgr != nil && a.mgr.Graph != nil {
+		if err := b.addGraphNeighbourhood(ctx, a.mgr.Graph, rootID, depth); err != nil {
+			return sessionGraph{}, err
+		}
+	}
+	return b.g, nil
+}
+
+// handleChatSessionGraph serves GET /api/chat/sessions/{id}/graph as JSON, or
+// as Graphviz DOT with ?format=dot.
+func (a *app) handleChatSessionGraph(w http.ResponseWriter, r *http.Request, sess persist.ChatSession, owner int64) {
+	qs := r.URL.Query()
+	depth := sessionGraphDefaultDepth
+	if raw := strings.TrimSpace(qs.Get("depth")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > sessionGraphMaxDepth {
+			apierror.Respond(w, http.StatusBadRequest, fmt.Sprintf("depth must be between 0 and %d", sessionGraphMaxDepth))
+			return
+		}
+		depth = n
+	}
+	format := strings.ToLower(strings.TrimSpace(qs.Get("format")))
+	if format != "" && format != "json" && format != "dot" {
+		apierror.Respond(w, http.StatusBadRequest, "format must be json or dot")
+		return
+	}
+	g, err := a.buildSessionGraph(r.Context(), owner, sess, depth)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		_, _ = w.Write([]byte(renderSessionGraphDOT(g)))
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// renderSessionGraphDOT renders g in Graphviz DOT syntax, shaping nodes by
+// kind so sessions, memories, decisions, and entities are distinguishable.
+func renderSessionGraphDOT(g sessionGraph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph session {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), dotShape(n.Kind))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.Rel))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case "session":
+		return "doubleoctagon"
+	case "decision":
+		return "diamond"
+	case string(memory.MemoryFactual), string(memory.MemoryProcedural), string(memory.MemoryEpisodic):
+		return "note"
+	case "topic":
+		return "ellipse"
+	default:
+		return "box"
+	}
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+func truncateGraphLabel(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > sessionGraphLabelLimit {
+		return string(r[:sessionGraphLabelLimit-1]) + "…"
+	}
+	return s
+}
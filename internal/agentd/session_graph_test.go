@@ -0,0 +1,68 @@
+package agentd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"manifold/internal/agent/memory"
+	"manifold/internal/persistence/databases"
+)
+
+func TestSessionGraphBuilder(t *testing.T) {
+	ctx := context.Background()
+	g := databases.NewMemoryGraph()
+	root := sessionGraphNodeID("s1")
+	_ = g.UpsertNode(ctx, "svc:api", []string{"Service"}, map[string]any{"name": "API gateway"})
+	_ = g.UpsertNode(ctx, "db:main", []string{"Database"}, nil)
+	_ = g.UpsertEdge(ctx, root, "mentions", "svc:api", nil)
+	_ = g.UpsertEdge(ctx, "svc:api", "depends_on", "db:main", nil)
+
+	b := newSessionGraphBuilder("s1")
+	b.addNode(sessionGraphNode{ID: root, Kind: "session", Label: "s1"})
+	now := time.Now()
+	b.addMemories(root, []*memory.MemoryEntry{
+		{ID: "m2", Summary: "Use blue/green deploys", StrategyCard: "deploy", CreatedAt: now},
+		{ID: "m1", Summary: "API runs on port 8080", MemoryType: memory.MemoryFactual, Metadata: map[string]any{"tag": "infra"}, CreatedAt: now.Add(-time.Minute)},
+	})
+	if err := b.addGraphNeighbourhood(ctx, g, root, 1); err != nil {
+		t.Fatalf("addGraphNeighbourhood: %v", err)
+	}
+
+	kinds := map[string]string{}
+	for _, n := range b.g.Nodes {
+		kinds[n.ID] = n.Kind
+	}
+	if kinds["memory:m1"] != "factual" || kinds["memory:m2"] != "decision" || kinds["tag:infra"] != "topic" || kinds["svc:api"] != "service" {
+		t.Fatalf("unexpected node kinds: %v", kinds)
+	}
+	if _, ok := kinds["db:main"]; ok {
+		t.Fatal("depth 1 should not reach db:main")
+	}
+	if b.g.Nodes[1].ID != "memory:m1" {
+		t.Fatalf("memories should be ordered by creation time, got %q first", b.g.Nodes[1].ID)
+	}
+	if len(b.g.Edges) != 4 {
+		t.Fatalf("expected 4 edges, got %+v", b.g.Edges)
+	}
+}
+
+func TestRenderSessionGraphDOT(t *testing.T) {
+	dot := renderSessionGraphDOT(sessionGraph{
+		Nodes: []sessionGraphNode{
+			{ID: "session:s1", Kind: "session", Label: `Say "hi"`},
+			{ID: "memory:m1", Kind: "decision", Label: "line\nbreak"},
+		},
+		Edges: []sessionGraphEdge{{Source: "session:s1", Target: "memory:m1", Rel: "remembers"}},
+	})
+	for _, want := range []string{
+		`"session:s1" [label="Say \"hi\"", shape=doubleoctagon];`,
+		`"memory:m1" [label="line\nbreak", shape=diamond];`,
+		`"session:s1" -> "memory:m1" [label="remembers"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("dot output missing %q:\n%s", want, dot)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package agentd
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	persist "manifold/internal/persistence"
+	"manifold/internal/sandbox"
 	"manifold/internal/specialists"
 )
 
@@ -102,6 +104,11 @@ func (a *app) deleteSpecialistForUser(ctx context.Context, userID int64, name st
 func (a *app) saveSpecialistForUser(ctx context.Context, userID int64, name string, sp persist.Specialist) (persist.Specialist, error) {
 	sp.Name = name
 	sp.UserID = userID
+	switch sp.ExecBackend = strings.TrimSpace(sp.ExecBackend); sp.ExecBackend {
+	case "", sandbox.ExecBackendHost, sandbox.ExecBackendContainer:
+	default:
+		return persist.Specialist{}, fmt.Errorf("execBackend must be %q or %q", sandbox.ExecBackendHost, sandbox.ExecBackendContainer)
+	}
 	saved, err := a.specStore.Upsert(ctx, userID, sp)
 	if err != nil {
 		return persist.Specialist{}, err
@@ -28,6 +28,8 @@ func (a *app) specialistsRegistryForUser(ctx context.Context, userID int64) (*sp
 	}
 	reg := specialists.NewRegistryFromStore(base, nil, list, nil, a.httpClient, a.baseToolRegistry, a.cfg.Workdir)
 	reg.SetToolDiscovery(a.toolIndex, a.cfg.AutoDiscover, a.cfg.MaxDiscoveredTools)
+	reg.SetPromptResolver(a.expandMCPPrompts)
+	reg.SetLimits(a.specLimits)
 
 	a.specRegMu.Lock()
 	if a.userSpecRegs == nil {
@@ -0,0 +1,308 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/apierror"
+)
+
+// sseSchemaVersion is the version of the typed SSE envelope. Clients opt in
+// with "Accept: text/event-stream; schema=1" or ?sse_schema=1. Everyone else
+// keeps receiving the bare event objects, now with an id line.
+const sseSchemaVersion = 1
+
+const (
+	// sseHeartbeatInterval keeps idle streams alive through proxies that
+	// close connections after a quiet period.
+	sseHeartbeatInterval = 15 * time.Second
+	// sseResumeTTL is how long the closing events of a finished run stay
+	// available to clients that reconnect with Last-Event-ID.
+	sseResumeTTL = 10 * time.Minute
+)
+
+// sseEnvelope is the typed form of one stream event. ID is "<run>:<seq>"
+// and matches the SSE id line; Payload is the event object itself.
+type sseEnvelope struct {
+	Version int             `json:"v"`
+	ID      string          `json:"id"`
+	Seq     int64           `json:"seq"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// sseEnvelopeRequested reports whether r asked for the typed envelope.
+func sseEnvelopeRequested(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	if v := r.URL.Query().Get("sse_schema"); v != "" {
+		return v == strconv.Itoa(sseSchemaVersion)
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(k, "schema") && strings.Trim(v, `"`) == strconv.Itoa(sseSchemaVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// sseEventType returns the "type" field of a JSON event, or "message" for
+// payloads without one.
+func sseEventType(b []byte) string {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(b, &probe) == nil && probe.Type != "" {
+		return probe.Type
+	}
+	return "message"
+}
+
+func sseEventID(runID string, seq int64) string {
+	return runID + ":" + strconv.FormatInt(seq, 10)
+}
+
+// parseSSEEventID splits a Last-Event-ID value into its run and sequence.
+func parseSSEEventID(id string) (string, int64, bool) {
+	i := strings.LastIndexByte(id, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil || seq < 0 {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+// lastEventID reads the resume point from the Last-Event-ID header, or the
+// last_event_id query parameter for clients that cannot set headers.
+func lastEventID(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get("Last-Event-ID")); v != "" {
+		return v
+	}
+	return strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+}
+
+// formatSSEEvent frames data as one SSE event. runID may be empty, in which
+// case no id line is written.
+func formatSSEEvent(runID string, seq int64, typ string, data []byte, envelope bool) []byte {
+	var buf bytes.Buffer
+	if runID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", sseEventID(runID, seq))
+	}
+	if envelope {
+		env, err := json.Marshal(sseEnvelope{
+			Version: sseSchemaVersion,
+			ID:      sseEventID(runID, seq),
+			Seq:     seq,
+			Type:    typ,
+			Payload: data,
+		})
+		if err == nil {
+			fmt.Fprintf(&buf, "event: %s\n", typ)
+			data = env
+		}
+	}
+	fmt.Fprintf(&buf, "data: %s\n\n", data)
+	return buf.Bytes()
+}
+
+// sseResumable lists the closing event types kept for Last-Event-ID resume.
+var sseResumable = map[string]bool{
+	"final":            true,
+	"error":            true,
+	"handoff":          true,
+	"policy_violation": true,
+}
+
+type sseFrame struct {
+	seq  int64
+	typ  string
+	data []byte
+}
+
+type sseRunLog struct {
+	owner    int64
+	frames   []sseFrame
+	done     chan struct{}
+	finished time.Time
+}
+
+// sseResumeLog keeps the closing events of streamed runs so a client that
+// lost its connection mid-run can reconnect and still receive them.
+type sseResumeLog struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	runs map[string]*sseRunLog
+}
+
+func newSSEResumeLog(ttl time.Duration) *sseResumeLog {
+	return &sseResumeLog{ttl: ttl, runs: map[string]*sseRunLog{}}
+}
+
+// open starts logging runID for owner and drops runs past their TTL.
+func (l *sseResumeLog) open(runID string, owner int64) {
+	if l == nil || runID == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for id, rl := range l.runs {
+		if !rl.finished.IsZero() && now.Sub(rl.finished) > l.ttl {
+			delete(l.runs, id)
+		}
+	}
+	if _, ok := l.runs[runID]; !ok {
+		l.runs[runID] = &sseRunLog{owner: owner, done: make(chan struct{})}
+	}
+}
+
+func (l *sseResumeLog) record(runID string, f sseFrame) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rl, ok := l.runs[runID]; ok && rl.finished.IsZero() {
+		rl.frames = append(rl.frames, f)
+	}
+}
+
+// finish marks runID complete and wakes clients waiting to resume it.
+func (l *sseResumeLog) finish(runID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rl, ok := l.runs[runID]; ok && rl.finished.IsZero() {
+		rl.finished = time.Now()
+		close(rl.done)
+	}
+}
+
+// has reports whether runID can be resumed by owner.
+func (l *sseResumeLog) has(runID string, owner int64) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rl, ok := l.runs[runID]
+	return ok && rl.owner == owner && (rl.finished.IsZero() || time.Since(rl.finished) <= l.ttl)
+}
+
+// wait blocks until runID finishes and returns its closing events after
+// seq. It reports false for unknown or expired runs and runs owned by
+// someone else.
+func (l *sseResumeLog) wait(ctx context.Context, runID string, owner int64, after int64) ([]sseFrame, bool) {
+	if !l.has(runID, owner) {
+		return nil, false
+	}
+	l.mu.Lock()
+	rl, ok := l.runs[runID]
+	l.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-rl.done:
+	case <-ctx.Done():
+		return nil, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []sseFrame
+	for _, f := range rl.frames {
+		if f.seq > after {
+			out = append(out, f)
+		}
+	}
+	return out, true
+}
+
+// startSSEHeartbeat writes a comment line every interval until ctx ends or
+// the returned stop func is called.
+func startSSEHeartbeat(ctx context.Context, writeText func(string)) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sseHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				writeText(": keepalive\n\n")
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }
+}
+
+// resumeSSE serves a client reconnecting with Last-Event-ID: it waits for
+// the run to finish, with heartbeats in the meantime, and replays the
+// closing events the client has not seen.
+func (a *app) resumeSSE(w http.ResponseWriter, r *http.Request, owner int64, eventID string) {
+	runID, seq, ok := parseSSEEventID(eventID)
+	if !ok {
+		apierror.Respond(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+	if !a.sseRuns.has(runID, owner) {
+		apierror.Respond(w, http.StatusNotFound, "run not found or expired")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	stream, err := newChatSSEWriter(w)
+	if err != nil {
+		apierror.Respond(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stream.writeText(": resumed\n\n")
+	stop := startSSEHeartbeat(r.Context(), stream.writeText)
+	frames, _ := a.sseRuns.wait(r.Context(), runID, owner, seq)
+	stop()
+	envelope := sseEnvelopeRequested(r)
+	for _, f := range frames {
+		stream.writeText(string(formatSSEEvent(runID, f.seq, f.typ, f.data, envelope)))
+	}
+}
+
+// handleRunEvents serves GET /api/runs/{id}/events, the resume endpoint for
+// streamed runs. Without Last-Event-ID every closing event is replayed.
+// Requests that accept only JSON get the run's step timeline instead.
+func (a *app) handleRunEvents(w http.ResponseWriter, r *http.Request, userID int64, runID string) {
+	if r.Method != http.MethodGet {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if wantsRunTimeline(r) {
+		a.handleRunTimeline(w, r, userID, runID)
+		return
+	}
+	id := lastEventID(r)
+	if id == "" {
+		id = sseEventID(runID, 0)
+	}
+	if rid, _, ok := parseSSEEventID(id); ok && rid != runID {
+		apierror.Respond(w, http.StatusBadRequest, "Last-Event-ID belongs to another run")
+		return
+	}
+	a.resumeSSE(w, r, userID, id)
+}
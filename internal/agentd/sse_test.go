@@ -0,0 +1,123 @@
+package agentd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatSSEWriterNumbersEvents(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.bindRun(nil, "run_1", systemUserID, false)
+	stream.write(map[string]string{"type": "delta", "data": "hi"})
+	stream.write(map[string]string{"type": "final", "data": "hi"})
+
+	want := "id: run_1:1\ndata: {\"data\":\"hi\",\"type\":\"delta\"}\n\n" +
+		"id: run_1:2\ndata: {\"data\":\"hi\",\"type\":\"final\"}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("unexpected stream:\n%s", got)
+	}
+}
+
+func TestChatSSEWriterEnvelope(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.bindRun(nil, "run_1", systemUserID, true)
+	stream.write(map[string]string{"type": "final", "data": "done"})
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || lines[0] != "id: run_1:1" || lines[1] != "event: final" {
+		t.Fatalf("unexpected framing: %q", lines)
+	}
+	var env sseEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Version != sseSchemaVersion || env.ID != "run_1:1" || env.Seq != 1 || env.Type != "final" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if string(env.Payload) != `{"data":"done","type":"final"}` {
+		t.Fatalf("unexpected payload %s", env.Payload)
+	}
+}
+
+func TestSSEEnvelopeRequested(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"text/event-stream":             false,
+		"text/event-stream; schema=1":   true,
+		`text/event-stream; schema="1"`: true,
+		"text/event-stream; schema=2":   false,
+	}
+	for accept, want := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/agent/run", nil)
+		r.Header.Set("Accept", accept)
+		if got := sseEnvelopeRequested(r); got != want {
+			t.Errorf("Accept %q: got %v, want %v", accept, got, want)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/runs/x/events?sse_schema=1", nil)
+	if !sseEnvelopeRequested(r) {
+		t.Error("expected ?sse_schema=1 to request the envelope")
+	}
+}
+
+func TestResumeReplaysClosingEvents(t *testing.T) {
+	t.Parallel()
+
+	a := &app{sseRuns: newSSEResumeLog(time.Minute)}
+	live := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.bindRun(a.sseRuns, "run_7", 42, false)
+	stream.write(map[string]string{"type": "delta", "data": "partial"})
+
+	// The client drops after the delta and reconnects while the run is
+	// still going.
+	got := make(chan string)
+	go func() {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/runs/run_7/events", nil)
+		req.Header.Set("Last-Event-ID", "run_7:1")
+		a.handleRunEvents(rec, req, 42, "run_7")
+		got <- rec.Body.String()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	stream.write(map[string]string{"type": "final", "data": "all done"})
+	a.sseRuns.finish("run_7")
+
+	select {
+	case body := <-got:
+		if !strings.Contains(body, "id: run_7:2\ndata: {\"data\":\"all done\",\"type\":\"final\"}\n\n") {
+			t.Fatalf("final event not replayed: %q", body)
+		}
+		if strings.Contains(body, "partial") {
+			t.Fatalf("replayed an event the client already had: %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("resume did not return after the run finished")
+	}
+
+	rec := httptest.NewRecorder()
+	a.handleRunEvents(rec, httptest.NewRequest(http.MethodGet, "/api/runs/run_7/events", nil), 7, "run_7")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected another user's run to be hidden, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package agentd
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	llmpkg "manifold/internal/llm"
+	persist "manifold/internal/persistence"
+)
+
+// statusInsightsLimit caps each insights list in /api/status.
+const statusInsightsLimit = 5
+
+// statusInsights is the aggregate usage overview returned by
+// /api/status?insights=1.
+type statusInsights struct {
+	persist.UsageInsights
+	TokensToday      []llmpkg.TokenTotal `json:"tokensToday"`
+	TokensTodayTotal int64               `json:"tokensTodayTotal"`
+	TokenSource      string              `json:"tokenSource"`
+}
+
+// recordToolUsage persists a completed tool call or specialist delegation.
+// It is attached to the base engine so every cloned run engine reports usage.
+func (a *app) recordToolUsage(ctx context.Context, u agent.ToolUsage) {
+	if a.mgr == nil || a.mgr.Usage == nil {
+		return
+	}
+	uid, ok := llmpkg.UserIDFromContext(ctx)
+	if !ok {
+		uid = systemUserID
+	}
+	ev := persist.UsageEvent{
+		UserID:         uid,
+		Kind:           persist.UsageKindTool,
+		Name:           u.Name,
+		OK:             !u.Failed,
+		DurationMillis: u.Duration.Milliseconds(),
+	}
+	if u.Agent != "" {
+		ev.Kind = persist.UsageKindSpecialist
+		ev.Name = u.Agent
+	}
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := a.mgr.Usage.Record(rctx, ev); err != nil {
+		log.Debug().Err(err).Str("name", ev.Name).Msg("usage_record_failed")
+	}
+}
+
+// statusInsights aggregates usage since the start of the current UTC day.
+func (a *app) statusInsights(ctx context.Context, userID int64) (statusInsights, error) {
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	out := statusInsights{
+		UsageInsights: persist.UsageInsights{
+			Since:              since,
+			TopTools:           []persist.UsageStat{},
+			FailingTools:       []persist.UsageStat{},
+			SlowestSpecialists: []persist.UsageStat{},
+		},
+		TokensToday: []llmpkg.TokenTotal{},
+		TokenSource: "process",
+	}
+	if a.mgr != nil && a.mgr.Usage != nil {
+		usage, err := a.mgr.Usage.Insights(ctx, userID, since, statusInsightsLimit)
+		if err != nil {
+			return statusInsights{}, err
+		}
+		out.UsageInsights = usage
+	}
+
+	window := now.Sub(since)
+	if a.tokenMetrics != nil {
+		var (
+			totals []llmpkg.TokenTotal
+			err    error
+		)
+		if a.cfg.Auth.Enabled {
+			totals, _, err = a.tokenMetrics.TokenTotalsForUser(ctx, userID, window)
+		} else {
+			totals, _, err = a.tokenMetrics.TokenTotals(ctx, window)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("status token metrics query failed")
+		} else {
+			out.TokensToday = totals
+			out.TokenSource = a.tokenMetrics.Source()
+		}
+	} else if !a.cfg.Auth.Enabled {
+		// In-process counters are not attributed per user.
+		out.TokensToday, _ = llmpkg.TokenTotalsForWindow(window)
+	}
+	if out.TokensToday == nil {
+		out.TokensToday = []llmpkg.TokenTotal{}
+	}
+	for _, t := range out.TokensToday {
+		out.TokensTodayTotal += t.Total
+	}
+	return out, nil
+}
@@ -0,0 +1,215 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errSTTUnsupportedAudio is returned for uploads that can be neither
+// converted in process nor handed to ffmpeg.
+var errSTTUnsupportedAudio = errors.New("stt: unsupported audio format")
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	// WAV files outside these rates are treated as malformed rather than
+	// resampled, so a bogus header cannot blow up the output size.
+	wavMinSampleRate = 4000
+	wavMaxSampleRate = 192000
+
+	// sttMaxAudioSeconds caps how much audio an upload is converted to;
+	// anything past it is dropped.
+	sttMaxAudioSeconds = 30 * 60
+)
+
+// wavInfo describes the sample layout of a WAV file.
+type wavInfo struct {
+	format     uint16
+	channels   int
+	sampleRate int
+	bits       int
+	data       []byte
+}
+
+// normalizeSTTAudio converts an upload to the 16 kHz mono 16-bit PCM WAV the
+// STT server transcribes best. PCM and float WAV files are resampled in
+// process; other formats (mp3, ogg, webm, m4a, flac) are decoded with
+// ffmpeg. Clips already in the target format are returned unchanged; others
+// are cut at sttMaxAudioSeconds.
+func normalizeSTTAudio(ctx context.Context, data []byte, ffmpegPath string) ([]byte, error) {
+	if info, ok := parseWAV(data); ok {
+		if info.format == wavFormatPCM && info.channels == 1 && info.sampleRate == sttStreamSampleRate && info.bits == 16 {
+			return data, nil
+		}
+		if samples, ok := info.mono(); ok {
+			return pcmWAV(floatToPCM16(resampleLinear(samples, info.sampleRate, sttStreamSampleRate, sttMaxAudioSeconds*sttStreamSampleRate))), nil
+		}
+	}
+	return ffmpegToPCMWAV(ctx, data, ffmpegPath)
+}
+
+// parseWAV reads the fmt and data chunks of a RIFF WAVE file. A data chunk
+// whose size overruns the file, as written by streaming encoders, is cut to
+// the bytes present.
+func parseWAV(b []byte) (wavInfo, bool) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return wavInfo{}, false
+	}
+	var info wavInfo
+	var haveFmt bool
+	for off := 12; off+8 <= len(b); {
+		id := string(b[off : off+4])
+		size := int(binary.LittleEndian.Uint32(b[off+4 : off+8]))
+		body := b[off+8:]
+		if size < 0 || size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return wavInfo{}, false
+			}
+			info.format = binary.LittleEndian.Uint16(body[0:2])
+			info.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			info.sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			info.bits = int(binary.LittleEndian.Uint16(body[14:16]))
+			if info.format == wavFormatExtensible && len(body) >= 26 {
+				// The sub-format GUID starts with the actual format code.
+				info.format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt || info.channels <= 0 || info.sampleRate < wavMinSampleRate || info.sampleRate > wavMaxSampleRate {
+				return wavInfo{}, false
+			}
+			info.data = body
+			return info, true
+		}
+		off += 8 + size + size%2
+	}
+	return wavInfo{}, false
+}
+
+// mono decodes the samples to floats in [-1, 1], averaging channels. It
+// reports false for encodings it does not understand.
+func (w wavInfo) mono() ([]float32, bool) {
+	width := w.bits / 8
+	switch {
+	case w.format == wavFormatPCM && (w.bits == 8 || w.bits == 16 || w.bits == 24 || w.bits == 32):
+	case w.format == wavFormatFloat && (w.bits == 32 || w.bits == 64):
+	default:
+		return nil, false
+	}
+	frame := width * w.channels
+	n := len(w.data) / frame
+	out := make([]float32, n)
+	for i := 0; i < n; i++ {
+		var sum float64
+		for c := 0; c < w.channels; c++ {
+			s := w.data[i*frame+c*width:]
+			switch {
+			case w.format == wavFormatFloat && w.bits == 32:
+				sum += float64(math.Float32frombits(binary.LittleEndian.Uint32(s)))
+			case w.format == wavFormatFloat:
+				sum += math.Float64frombits(binary.LittleEndian.Uint64(s))
+			case w.bits == 8:
+				sum += (float64(s[0]) - 128) / 128
+			case w.bits == 16:
+				sum += float64(int16(binary.LittleEndian.Uint16(s))) / (1 << 15)
+			case w.bits == 24:
+				v := int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24) >> 8
+				sum += float64(v) / (1 << 23)
+			default:
+				sum += float64(int32(binary.LittleEndian.Uint32(s))) / (1 << 31)
+			}
+		}
+		out[i] = float32(sum / float64(w.channels))
+	}
+	return out, true
+}
+
+// resampleLinear converts samples from inRate to outRate by linear
+// interpolation, keeping at most maxOut output samples.
+func resampleLinear(in []float32, inRate, outRate, maxOut int) []float32 {
+	if inRate == outRate || len(in) == 0 {
+		return in[:min(len(in), maxOut)]
+	}
+	ratio := float64(inRate) / float64(outRate)
+	out := make([]float32, min(int(float64(len(in))/ratio), maxOut))
+	for i := range out {
+		pos := float64(i) * ratio
+		i0 := int(pos)
+		i1 := min(i0+1, len(in)-1)
+		frac := float32(pos - float64(i0))
+		out[i] = in[i0]*(1-frac) + in[i1]*frac
+	}
+	return out
+}
+
+// floatToPCM16 encodes samples as little-endian 16-bit PCM, clipping to
+// [-1, 1].
+func floatToPCM16(in []float32) []byte {
+	out := make([]byte, 2*len(in))
+	for i, v := range in {
+		v = max(-1, min(1, v))
+		s := int16(v * math.MaxInt16)
+		if v < 0 {
+			s = int16(v * -math.MinInt16)
+		}
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(s))
+	}
+	return out
+}
+
+// ffmpegToPCMWAV decodes any format ffmpeg understands. The input goes
+// through a temp file because containers such as m4a may need seeking.
+func ffmpegToPCMWAV(ctx context.Context, data []byte, path string) ([]byte, error) {
+	if path == "" {
+		path = "ffmpeg"
+	}
+	bin, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ffmpeg is not installed", errSTTUnsupportedAudio)
+	}
+	f, err := os.CreateTemp("", "stt-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, "-hide_banner", "-loglevel", "error", "-nostdin",
+		"-i", f.Name(), "-t", strconv.Itoa(sttMaxAudioSeconds), "-vn", "-ac", "1", "-ar", "16000", "-f", "s16le", "-acodec", "pcm_s16le", "pipe:1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %s", errSTTUnsupportedAudio, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("%w: no audio stream", errSTTUnsupportedAudio)
+	}
+	return pcmWAV(stdout.Bytes()), nil
+}
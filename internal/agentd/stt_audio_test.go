@@ -0,0 +1,209 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+	"manifold/internal/stt"
+)
+
+// testWAV builds a WAV file with the given layout holding a 440 Hz tone.
+func testWAV(format uint16, channels, rate, bits int, d time.Duration) []byte {
+	n := int(d.Seconds() * float64(rate))
+	width := bits / 8
+	var data bytes.Buffer
+	for i := 0; i < n; i++ {
+		v := 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(rate))
+		for c := 0; c < channels; c++ {
+			switch {
+			case format == wavFormatFloat:
+				_ = binary.Write(&data, binary.LittleEndian, float32(v))
+			case bits == 8:
+				data.WriteByte(byte(128 + v*127))
+			case bits == 24:
+				s := int32(v * (1<<23 - 1))
+				data.Write([]byte{byte(s), byte(s >> 8), byte(s >> 16)})
+			default:
+				_ = binary.Write(&data, binary.LittleEndian, int16(v*math.MaxInt16))
+			}
+		}
+	}
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+16+8+data.Len()))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, struct {
+		Size                 uint32
+		Format, Channels     uint16
+		SampleRate, ByteRate uint32
+		BlockAlign, Bits     uint16
+	}{16, format, uint16(channels), uint32(rate), uint32(rate * channels * width), uint16(channels * width), uint16(bits)})
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+	return b.Bytes()
+}
+
+func TestNormalizeSTTAudioResamplesWAV(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   uint16
+		channels int
+		rate     int
+		bits     int
+	}{
+		{"44.1kHz stereo 16-bit", wavFormatPCM, 2, 44100, 16},
+		{"48kHz mono float", wavFormatFloat, 1, 48000, 32},
+		{"8kHz mono 8-bit", wavFormatPCM, 1, 8000, 8},
+		{"22.05kHz mono 24-bit", wavFormatPCM, 1, 22050, 24},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := normalizeSTTAudio(context.Background(), testWAV(tc.format, tc.channels, tc.rate, tc.bits, time.Second), "/nonexistent/ffmpeg")
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			info, ok := parseWAV(out)
+			if !ok {
+				t.Fatalf("output is not a WAV file")
+			}
+			if info.format != wavFormatPCM || info.channels != 1 || info.sampleRate != 16000 || info.bits != 16 {
+				t.Fatalf("unexpected layout %+v", info)
+			}
+			if got := len(info.data) / 2; got < 15990 || got > 16000 {
+				t.Fatalf("expected about one second of samples, got %d", got)
+			}
+			samples, _ := info.mono()
+			var peak float32
+			for _, s := range samples {
+				peak = max(peak, s)
+			}
+			if peak < 0.45 || peak > 0.55 {
+				t.Fatalf("expected the tone to keep its level, peak %.3f", peak)
+			}
+		})
+	}
+}
+
+func TestNormalizeSTTAudioRejectsBadSampleRates(t *testing.T) {
+	for _, rate := range []int{1, wavMinSampleRate - 1, wavMaxSampleRate + 1} {
+		in := testWAV(wavFormatPCM, 1, rate, 16, 10*time.Millisecond)
+		if _, ok := parseWAV(in); ok {
+			t.Fatalf("%d Hz: expected parseWAV to refuse the header", rate)
+		}
+		if _, err := normalizeSTTAudio(context.Background(), in, "/nonexistent/ffmpeg"); !errors.Is(err, errSTTUnsupportedAudio) {
+			t.Fatalf("%d Hz: expected errSTTUnsupportedAudio, got %v", rate, err)
+		}
+	}
+}
+
+func TestResampleLinearCapsOutput(t *testing.T) {
+	in := make([]float32, 1000)
+	if got := len(resampleLinear(in, 8000, 16000, 300)); got != 300 {
+		t.Fatalf("upsampled length %d, want 300", got)
+	}
+	if got := len(resampleLinear(in, 16000, 16000, 300)); got != 300 {
+		t.Fatalf("pass-through length %d, want 300", got)
+	}
+	if got := len(resampleLinear(in, 48000, 16000, 1000)); got != 333 {
+		t.Fatalf("downsampled length %d, want 333", got)
+	}
+}
+
+func TestNormalizeSTTAudioKeepsTargetFormat(t *testing.T) {
+	in := silentWAV(200 * time.Millisecond)
+	out, err := normalizeSTTAudio(context.Background(), in, "/nonexistent/ffmpeg")
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if !bytes.Equal(in, out) {
+		t.Fatalf("expected a 16 kHz mono clip to pass through unchanged")
+	}
+}
+
+func TestNormalizeSTTAudioNeedsFFmpegForOtherFormats(t *testing.T) {
+	ogg := append([]byte("OggS"), make([]byte, 64)...)
+	_, err := normalizeSTTAudio(context.Background(), ogg, "/nonexistent/ffmpeg")
+	if !errors.Is(err, errSTTUnsupportedAudio) {
+		t.Fatalf("expected errSTTUnsupportedAudio, got %v", err)
+	}
+}
+
+func TestNormalizeSTTAudioDecodesWithFFmpeg(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	// ffmpeg decodes a WAV layout this package leaves to it (A-law).
+	in := testWAV(wavFormatPCM, 1, 8000, 16, 500*time.Millisecond)
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-acodec", "pcm_alaw", "-f", "wav", "pipe:1")
+	cmd.Stdin = bytes.NewReader(in)
+	alaw, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("encode a-law: %v", err)
+	}
+	out, err := normalizeSTTAudio(context.Background(), alaw, "ffmpeg")
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	info, ok := parseWAV(out)
+	if !ok || info.sampleRate != 16000 || info.channels != 1 {
+		t.Fatalf("unexpected output %+v", info)
+	}
+}
+
+func TestSTTHandlerReturnsSegments(t *testing.T) {
+	var fields map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields = r.MultipartForm.Value
+		_, _ = w.Write([]byte(`{"text":"hi [SPEAKER_TURN] hello","segments":[{"id":0,"start":0,"end":1,"text":"hi [SPEAKER_TURN]"},{"id":1,"start":1,"end":2,"text":"hello"}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{STT: config.STTConfig{BaseURL: srv.URL, Model: "whisper-1"}}
+	a := &app{cfg: cfg, httpClient: srv.Client(), specStore: databases.NewSpecialistsStore(nil)}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("audio", "clip.wav")
+	_, _ = fw.Write(testWAV(wavFormatPCM, 2, 44100, 16, 100*time.Millisecond))
+	_ = mw.WriteField("timestamps", "true")
+	_ = mw.WriteField("diarize", "1")
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/stt", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	a.sttHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if got := fields["response_format"]; len(got) != 1 || got[0] != "verbose_json" {
+		t.Fatalf("expected verbose_json, got %v", got)
+	}
+	if got := fields["tinydiarize"]; len(got) != 1 || got[0] != "true" {
+		t.Fatalf("expected tinydiarize, got %v", got)
+	}
+	var tr stt.Transcript
+	if err := json.Unmarshal(rec.Body.Bytes(), &tr); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if tr.Text != "hi hello" || len(tr.Segments) != 2 || tr.Segments[1].Speaker != "SPEAKER_2" {
+		t.Fatalf("unexpected transcript %+v", tr)
+	}
+}
@@ -0,0 +1,58 @@
+package agentd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"manifold/internal/config"
+)
+
+// errSTTBusy is returned when no transcription slot frees up in time.
+var errSTTBusy = errors.New("stt: all transcription slots busy")
+
+// sttPool bounds concurrent transcriptions sent to the STT server so a
+// self-hosted whisper server with N contexts sees at most N requests at once.
+// A nil pool imposes no limit.
+type sttPool struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// newSTTPool returns nil when cfg.MaxConcurrent is unset.
+func newSTTPool(cfg config.STTConfig) *sttPool {
+	if cfg.MaxConcurrent <= 0 {
+		return nil
+	}
+	timeout := time.Duration(cfg.QueueTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &sttPool{slots: make(chan struct{}, cfg.MaxConcurrent), queueTimeout: timeout}
+}
+
+// acquire waits for a free slot and returns its release func. It fails with
+// errSTTBusy after the queue timeout, or with ctx's error if ctx ends first.
+func (p *sttPool) acquire(ctx context.Context) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-timer.C:
+		return nil, errSTTBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// inFlight reports the number of held slots.
+func (p *sttPool) inFlight() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.slots)
+}
@@ -0,0 +1,105 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+)
+
+func TestSTTPoolDisabledWhenUnset(t *testing.T) {
+	p := newSTTPool(config.STTConfig{})
+	if p != nil {
+		t.Fatalf("expected nil pool without maxConcurrent")
+	}
+	release, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("nil pool acquire: %v", err)
+	}
+	release()
+}
+
+func TestSTTPoolTimesOutWhenFull(t *testing.T) {
+	p := newSTTPool(config.STTConfig{MaxConcurrent: 1})
+	p.queueTimeout = 20 * time.Millisecond
+	release, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := p.acquire(context.Background()); !errors.Is(err, errSTTBusy) {
+		t.Fatalf("expected errSTTBusy, got %v", err)
+	}
+	release()
+	release, err = p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
+
+func sttRequest(t *testing.T) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("audio", "clip.wav")
+	if err != nil {
+		t.Fatalf("form: %v", err)
+	}
+	_, _ = fw.Write(silentWAV(100 * time.Millisecond))
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/stt", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestSTTHandlerBoundsUpstreamConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"text":"hi"}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{STT: config.STTConfig{BaseURL: srv.URL, Model: "whisper-1", MaxConcurrent: 2, QueueTimeoutSeconds: 5}}
+	a := &app{cfg: cfg, httpClient: srv.Client(), specStore: databases.NewSpecialistsStore(nil), sttPool: newSTTPool(cfg.STT)}
+	handler := a.sttHandler()
+
+	var wg sync.WaitGroup
+	codes := make([]int, 6)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, sttRequest(t))
+			codes[i] = rec.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, code)
+		}
+	}
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected peak upstream concurrency 2, got %d", got)
+	}
+}
@@ -0,0 +1,303 @@
+package agentd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+)
+
+const (
+	sttStreamSampleRate = 16000
+	// sttFrameBytes is one 20 ms VAD frame of 16-bit samples.
+	sttFrameBytes = sttStreamSampleRate / 50 * 2
+	// sttPreRollFrames keeps the audio just before speech is detected so
+	// the first syllable is not clipped.
+	sttPreRollFrames = 10
+	// sttMinSpeechFrames ignores clicks and pops shorter than 100 ms.
+	sttMinSpeechFrames = 5
+	sttStreamQueue     = 8
+)
+
+// sttSegmentEvent is emitted by the segmenter as speech comes and goes.
+// Kind is "speech_start", "partial" or "final"; PCM holds the utterance so
+// far for partial and final.
+type sttSegmentEvent struct {
+	Kind    string
+	Segment int
+	PCM     []byte
+}
+
+// sttSegmenter splits a stream of 16 kHz mono 16-bit PCM into utterances
+// with an energy-based voice activity detector.
+type sttSegmenter struct {
+	threshold     float64
+	silenceFrames int
+	partialFrames int
+	maxFrames     int
+
+	pending      []byte
+	preRoll      [][]byte
+	segment      []byte
+	frames       int
+	voiced       int
+	silent       int
+	sincePartial int
+	started      bool
+	seq          int
+}
+
+func newSTTSegmenter(cfg config.STTStreamConfig) *sttSegmenter {
+	frames := func(ms, def int) int {
+		if ms <= 0 {
+			ms = def
+		}
+		return max(ms/20, 1)
+	}
+	threshold := cfg.VADThreshold
+	if threshold <= 0 {
+		threshold = 0.015
+	}
+	return &sttSegmenter{
+		threshold:     threshold,
+		silenceFrames: frames(cfg.SilenceMs, 700),
+		partialFrames: frames(cfg.PartialIntervalMs, 1000),
+		maxFrames:     frames(cfg.MaxSegmentSeconds*1000, 30000),
+	}
+}
+
+// write feeds audio to the segmenter. Chunks need not align to frames or
+// samples.
+func (s *sttSegmenter) write(b []byte) []sttSegmentEvent {
+	s.pending = append(s.pending, b...)
+	var events []sttSegmentEvent
+	for len(s.pending) >= sttFrameBytes {
+		frame := s.pending[:sttFrameBytes:sttFrameBytes]
+		s.pending = s.pending[sttFrameBytes:]
+		events = append(events, s.frame(frame)...)
+	}
+	if len(s.pending) == 0 {
+		s.pending = nil
+	}
+	return events
+}
+
+// flush ends the current utterance, if any, as if speech had stopped.
+func (s *sttSegmenter) flush() []sttSegmentEvent {
+	if len(s.pending) > 0 && s.segment != nil {
+		s.segment = append(s.segment, s.pending[:len(s.pending)&^1]...)
+	}
+	s.pending = nil
+	return s.end()
+}
+
+func (s *sttSegmenter) frame(f []byte) []sttSegmentEvent {
+	speech := frameRMS(f) >= s.threshold
+	if s.segment == nil {
+		if !speech {
+			s.preRoll = append(s.preRoll, f)
+			if len(s.preRoll) > sttPreRollFrames {
+				s.preRoll = s.preRoll[1:]
+			}
+			return nil
+		}
+		for _, p := range s.preRoll {
+			s.segment = append(s.segment, p...)
+		}
+		s.preRoll = nil
+		s.segment = append(s.segment, f...)
+		s.frames, s.voiced, s.silent, s.sincePartial = 1, 1, 0, 1
+		return nil
+	}
+
+	s.segment = append(s.segment, f...)
+	s.frames++
+	s.sincePartial++
+	if speech {
+		s.voiced++
+		s.silent = 0
+	} else {
+		s.silent++
+	}
+	var events []sttSegmentEvent
+	if !s.started && s.voiced >= sttMinSpeechFrames {
+		s.started = true
+		s.seq++
+		events = append(events, sttSegmentEvent{Kind: "speech_start", Segment: s.seq})
+	}
+	switch {
+	case s.silent >= s.silenceFrames || s.frames >= s.maxFrames:
+		events = append(events, s.end()...)
+	case s.started && s.sincePartial >= s.partialFrames:
+		s.sincePartial = 0
+		events = append(events, sttSegmentEvent{Kind: "partial", Segment: s.seq, PCM: append([]byte(nil), s.segment...)})
+	}
+	return events
+}
+
+// end closes the current utterance. Utterances too short to count as speech
+// are dropped without a final event.
+func (s *sttSegmenter) end() []sttSegmentEvent {
+	seg, started := s.segment, s.started
+	s.segment, s.started = nil, false
+	s.frames, s.voiced, s.silent, s.sincePartial = 0, 0, 0, 0
+	if !started {
+		return nil
+	}
+	return []sttSegmentEvent{{Kind: "final", Segment: s.seq, PCM: seg}}
+}
+
+// frameRMS is the root mean square level of a PCM frame, from 0 to 1.
+func frameRMS(f []byte) float64 {
+	n := len(f) / 2
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(f[2*i:]))) / 32768
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// sttStreamConn serialises writes to a dictation WebSocket.
+type sttStreamConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *sttStreamConn) send(payload any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+	_ = c.conn.WriteJSON(payload)
+}
+
+// sttStreamHandler serves GET /stt/stream, a WebSocket for live dictation.
+// Clients send binary frames of 16 kHz mono little-endian 16-bit PCM and
+// {"type":"end"} when they stop recording. The server answers with ready,
+// then speech_start, partial and final events per utterance, each tagged with
+// its segment number, and done once every final transcript has been sent.
+// Partial transcripts rerun the STT model on the utterance so far and are
+// skipped while an earlier one is still being transcribed.
+func (a *app) sttStreamHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := a.sttUser(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		conn, err := liveUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already replied.
+			return
+		}
+		defer conn.Close()
+		out := &sttStreamConn{conn: conn}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		jobs := make(chan sttSegmentEvent, sttStreamQueue)
+		var partialPending atomic.Bool
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for ev := range jobs {
+				if ev.Kind == "partial" {
+					partialPending.Store(false)
+				}
+				if ctx.Err() != nil {
+					continue
+				}
+				text, err := a.transcribeWAV(ctx, userID, pcmWAV(ev.PCM))
+				if err != nil {
+					if ctx.Err() != nil || ev.Kind == "partial" {
+						continue
+					}
+					msg := "transcription failed"
+					var se *sttError
+					if errors.As(err, &se) {
+						msg = se.msg
+					}
+					out.send(map[string]any{"type": "error", "segment": ev.Segment, "data": msg})
+					continue
+				}
+				out.send(map[string]any{"type": ev.Kind, "segment": ev.Segment, "text": text})
+			}
+		}()
+
+		dispatch := func(events []sttSegmentEvent) {
+			for _, ev := range events {
+				switch ev.Kind {
+				case "speech_start":
+					out.send(map[string]any{"type": ev.Kind, "segment": ev.Segment})
+				case "partial":
+					if !partialPending.CompareAndSwap(false, true) {
+						continue
+					}
+					select {
+					case jobs <- ev:
+					default:
+						partialPending.Store(false)
+					}
+				default:
+					select {
+					case jobs <- ev:
+					case <-ctx.Done():
+					}
+				}
+			}
+		}
+
+		seg := newSTTSegmenter(a.cfg.STT.Stream)
+		out.send(map[string]any{"type": "ready", "sample_rate": sttStreamSampleRate})
+		conn.SetReadLimit(1 << 20)
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				// The client went away; drop any queued transcriptions.
+				cancel()
+				break
+			}
+			if kind == websocket.BinaryMessage {
+				dispatch(seg.write(data))
+				continue
+			}
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "end" {
+				out.send(map[string]any{"type": "error", "data": "expected binary audio or {\"type\":\"end\"}"})
+				continue
+			}
+			dispatch(seg.flush())
+			break
+		}
+		close(jobs)
+		<-done
+		if ctx.Err() != nil {
+			return
+		}
+		out.send(map[string]any{"type": "done"})
+		out.mu.Lock()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(liveWriteTimeout))
+		out.mu.Unlock()
+		log.Debug().Int64("user_id", userID).Msg("stt_stream_closed")
+	}
+}
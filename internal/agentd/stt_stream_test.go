@@ -0,0 +1,162 @@
+package agentd
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+)
+
+// tonePCM returns d of 16 kHz PCM at a constant level (0 for silence).
+func tonePCM(d time.Duration, level int16) []byte {
+	n := int(d.Seconds() * sttStreamSampleRate)
+	b := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		v := level
+		if i%2 == 1 {
+			v = -level
+		}
+		binary.LittleEndian.PutUint16(b[2*i:], uint16(v))
+	}
+	return b
+}
+
+func segmentKinds(events []sttSegmentEvent) []string {
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind)
+	}
+	return kinds
+}
+
+func TestSTTSegmenterSplitsUtterances(t *testing.T) {
+	seg := newSTTSegmenter(config.STTStreamConfig{VADThreshold: 0.015, SilenceMs: 200, PartialIntervalMs: 400, MaxSegmentSeconds: 30})
+
+	// A click shorter than the minimum speech length is ignored.
+	if ev := seg.write(append(tonePCM(40*time.Millisecond, 8000), tonePCM(300*time.Millisecond, 0)...)); len(ev) != 0 {
+		t.Fatalf("expected click to be ignored, got %v", segmentKinds(ev))
+	}
+
+	// Feed one second of speech in odd-sized chunks, then a pause.
+	speech := tonePCM(time.Second, 8000)
+	var events []sttSegmentEvent
+	for len(speech) > 0 {
+		n := min(333, len(speech))
+		events = append(events, seg.write(speech[:n])...)
+		speech = speech[n:]
+	}
+	events = append(events, seg.write(tonePCM(300*time.Millisecond, 0))...)
+
+	got := strings.Join(segmentKinds(events), ",")
+	if got != "speech_start,partial,partial,final" {
+		t.Fatalf("unexpected events %s", got)
+	}
+	final := events[len(events)-1]
+	if final.Segment != 1 {
+		t.Fatalf("expected segment 1, got %d", final.Segment)
+	}
+	// The final clip holds the silence left over after the click as pre-roll,
+	// the speech and the pause that ended it.
+	if want := (5 + 50 + 10) * sttFrameBytes; len(final.PCM) != want {
+		t.Fatalf("final clip is %d bytes, want %d", len(final.PCM), want)
+	}
+
+	// flush ends an utterance that is still in progress.
+	seg.write(tonePCM(200*time.Millisecond, 8000))
+	events = seg.flush()
+	if len(events) != 1 || events[0].Kind != "final" || events[0].Segment != 2 {
+		t.Fatalf("unexpected flush events %+v", segmentKinds(events))
+	}
+	if ev := seg.flush(); len(ev) != 0 {
+		t.Fatalf("expected nothing to flush, got %v", segmentKinds(ev))
+	}
+}
+
+func TestSTTStreamHandlerEmitsPartialAndFinal(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		mr, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var clip int
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if p.FormName() == "file" {
+				b, _ := io.ReadAll(p)
+				clip = len(b)
+			}
+		}
+		if clip <= 44 {
+			http.Error(w, "empty clip", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"text":" hello "}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{STT: config.STTConfig{
+		BaseURL: srv.URL,
+		Model:   "whisper-1",
+		Stream:  config.STTStreamConfig{SilenceMs: 200, PartialIntervalMs: 400},
+	}}
+	a := &app{cfg: cfg, httpClient: srv.Client(), specStore: databases.NewSpecialistsStore(nil), sttPool: newSTTPool(cfg.STT)}
+	ts := httptest.NewServer(a.sttStreamHandler())
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.BinaryMessage, tonePCM(time.Second, 8000)); err != nil {
+		t.Fatalf("write audio: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"end"}`)); err != nil {
+		t.Fatalf("write end: %v", err)
+	}
+
+	var kinds []string
+	var final map[string]any
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg map[string]any
+		if err := conn.ReadJSON(&msg); err != nil {
+			break
+		}
+		kind, _ := msg["type"].(string)
+		if kind != "partial" {
+			kinds = append(kinds, kind)
+		}
+		if kind == "final" {
+			final = msg
+		}
+		if kind == "done" {
+			break
+		}
+	}
+	if got := strings.Join(kinds, ","); got != "ready,speech_start,final,done" {
+		t.Fatalf("unexpected events %s", got)
+	}
+	if final["text"] != "hello" || final["segment"] != float64(1) {
+		t.Fatalf("unexpected final %v", final)
+	}
+	if calls.Load() < 1 {
+		t.Fatalf("expected the STT server to be called")
+	}
+}
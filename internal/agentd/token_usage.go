@@ -0,0 +1,351 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/auth"
+	"manifold/internal/config"
+	llmpkg "manifold/internal/llm"
+	persist "manifold/internal/persistence"
+)
+
+const (
+	tokenUsageFlushInterval = 15 * time.Second
+	defaultUsageRangeDays   = 30
+	maxUsageRangeDays       = 366
+)
+
+type tokenUsageKey struct {
+	day    time.Time
+	userID int64
+	model  string
+}
+
+// tokenUsageRecorder batches the model calls reported by the llm package
+// into per-user, per-model, per-day counts and flushes them to the token
+// usage store, so usage survives restarts without a write per call.
+type tokenUsageRecorder struct {
+	store persist.TokenUsageStore
+
+	mu      sync.Mutex
+	pending map[tokenUsageKey]persist.TokenUsageDay
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTokenUsageRecorder(store persist.TokenUsageStore) *tokenUsageRecorder {
+	return &tokenUsageRecorder{
+		store:   store,
+		pending: map[tokenUsageKey]persist.TokenUsageDay{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// add counts one model call. It is the llm package's token usage hook.
+func (r *tokenUsageRecorder) add(u llmpkg.TokenUsage) {
+	model := strings.TrimSpace(u.Model)
+	if model == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.merge(persist.TokenUsageDay{
+		Day:              usageDay(u.At),
+		UserID:           u.UserID,
+		Model:            model,
+		PromptTokens:     int64(u.Prompt),
+		CompletionTokens: int64(u.Completion),
+		Calls:            1,
+	})
+}
+
+// merge adds e to the pending counts. r.mu must be held.
+func (r *tokenUsageRecorder) merge(e persist.TokenUsageDay) {
+	k := tokenUsageKey{day: e.Day, userID: e.UserID, model: e.Model}
+	cur, ok := r.pending[k]
+	if !ok {
+		cur = persist.TokenUsageDay{Day: e.Day, UserID: e.UserID, Model: e.Model}
+	}
+	cur.PromptTokens += e.PromptTokens
+	cur.CompletionTokens += e.CompletionTokens
+	cur.Calls += e.Calls
+	r.pending[k] = cur
+}
+
+// flush writes the pending counts to the store. On failure they are kept
+// and retried with the next flush.
+func (r *tokenUsageRecorder) flush(ctx context.Context) error {
+	r.mu.Lock()
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	batch := make([]persist.TokenUsageDay, 0, len(r.pending))
+	for _, e := range r.pending {
+		batch = append(batch, e)
+	}
+	r.pending = map[tokenUsageKey]persist.TokenUsageDay{}
+	r.mu.Unlock()
+
+	if err := r.store.Add(ctx, batch); err != nil {
+		r.mu.Lock()
+		for _, e := range batch {
+			r.merge(e)
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// pendingFor returns userID's counts not yet flushed that match filter.
+func (r *tokenUsageRecorder) pendingFor(userID int64, filter persist.TokenUsageFilter) []persist.TokenUsageDay {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []persist.TokenUsageDay
+	for _, e := range r.pending {
+		if e.UserID != userID || (filter.Model != "" && e.Model != filter.Model) {
+			continue
+		}
+		if e.Day.Before(filter.Since) || !e.Day.Before(filter.Until) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func (r *tokenUsageRecorder) start(interval time.Duration) {
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				if err := r.flush(context.Background()); err != nil {
+					log.Warn().Err(err).Msg("token usage flush failed")
+				}
+			}
+		}
+	}()
+}
+
+// close stops the flush loop and writes what is left.
+func (r *tokenUsageRecorder) close() {
+	close(r.stop)
+	<-r.done
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.flush(ctx); err != nil {
+		log.Warn().Err(err).Msg("final token usage flush failed")
+	}
+}
+
+func usageDay(t time.Time) time.Time {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// usageCost estimates the cost of the given tokens of model. ok is false
+// when the price table has no entry for the model.
+func usageCost(prices map[string]config.ModelPrice, model string, promptTokens, completionTokens int64) (cost float64, ok bool) {
+	p, ok := prices[model]
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1e6, true
+}
+
+type tokenUsageDayResponse struct {
+	persist.TokenUsageDay
+	// Cost is nil for models missing from the price table.
+	Cost *float64 `json:"cost"`
+}
+
+type tokenUsageModelTotal struct {
+	Model            string   `json:"model"`
+	PromptTokens     int64    `json:"promptTokens"`
+	CompletionTokens int64    `json:"completionTokens"`
+	TotalTokens      int64    `json:"totalTokens"`
+	Calls            int64    `json:"calls"`
+	Cost             *float64 `json:"cost"`
+}
+
+type tokenUsageResponse struct {
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+	Currency string    `json:"currency"`
+	// EstimatedCost sums the cost of the priced models.
+	EstimatedCost float64                 `json:"estimatedCost"`
+	Models        []tokenUsageModelTotal  `json:"models"`
+	Days          []tokenUsageDayResponse `json:"days"`
+}
+
+// metricsUsageHandler reports the caller's persisted token usage per day and
+// model between since (inclusive) and until (exclusive), with cost
+// estimates from the pricing table.
+func (a *app) metricsUsageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var uid int64 = systemUserID
+		if a.cfg.Auth.Enabled {
+			u, ok := auth.CurrentUser(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer realm=\"sio\"")
+				apierror.Respond(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			uid = u.ID
+		}
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if a.mgr == nil || a.mgr.TokenUsage == nil {
+			apierror.Respond(w, http.StatusServiceUnavailable, "token usage store not initialized")
+			return
+		}
+		filter, err := parseUsageRange(r, time.Now())
+		if err != nil {
+			apierror.Respond(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		days, err := a.mgr.TokenUsage.Query(r.Context(), uid, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("token usage query failed")
+			apierror.Respond(w, http.StatusInternalServerError, "token usage query failed")
+			return
+		}
+		if a.tokenUsage != nil {
+			days = mergeUsageDays(days, a.tokenUsage.pendingFor(uid, filter))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(buildUsageResponse(filter, days, a.cfg.Pricing)); err != nil {
+			log.Warn().Err(err).Msg("failed to encode token usage response")
+		}
+	}
+}
+
+// parseUsageRange reads since, until and model from the query. since and
+// until take RFC 3339 timestamps or YYYY-MM-DD dates and are rounded to UTC
+// days; a date until is inclusive. The default range is the last 30 days.
+func parseUsageRange(r *http.Request, now time.Time) (persist.TokenUsageFilter, error) {
+	q := r.URL.Query()
+	filter := persist.TokenUsageFilter{
+		Until: usageDay(now).AddDate(0, 0, 1),
+		Model: strings.TrimSpace(q.Get("model")),
+	}
+	if raw := strings.TrimSpace(q.Get("until")); raw != "" {
+		if d, err := time.Parse(time.DateOnly, raw); err == nil {
+			filter.Until = d.AddDate(0, 0, 1)
+		} else if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+			filter.Until = usageDay(ts)
+			if !ts.UTC().Equal(filter.Until) {
+				filter.Until = filter.Until.AddDate(0, 0, 1)
+			}
+		} else {
+			return filter, errors.New("invalid until parameter: want YYYY-MM-DD or RFC 3339")
+		}
+	}
+	filter.Since = filter.Until.AddDate(0, 0, -defaultUsageRangeDays)
+	if raw := strings.TrimSpace(q.Get("since")); raw != "" {
+		if d, err := time.Parse(time.DateOnly, raw); err == nil {
+			filter.Since = d
+		} else if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+			filter.Since = usageDay(ts)
+		} else {
+			return filter, errors.New("invalid since parameter: want YYYY-MM-DD or RFC 3339")
+		}
+	}
+	if !filter.Since.Before(filter.Until) {
+		return filter, errors.New("since must be before until")
+	}
+	if filter.Until.Sub(filter.Since) > maxUsageRangeDays*24*time.Hour {
+		return filter, errors.New("range must not exceed 366 days")
+	}
+	return filter, nil
+}
+
+// mergeUsageDays adds the unflushed counts to the stored ones.
+func mergeUsageDays(stored, pending []persist.TokenUsageDay) []persist.TokenUsageDay {
+	if len(pending) == 0 {
+		return stored
+	}
+	r := &tokenUsageRecorder{pending: map[tokenUsageKey]persist.TokenUsageDay{}}
+	for _, e := range stored {
+		r.merge(e)
+	}
+	for _, e := range pending {
+		r.merge(e)
+	}
+	out := make([]persist.TokenUsageDay, 0, len(r.pending))
+	for _, e := range r.pending {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Day.Equal(out[j].Day) {
+			return out[i].Day.Before(out[j].Day)
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
+
+func buildUsageResponse(filter persist.TokenUsageFilter, days []persist.TokenUsageDay, pricing config.PricingConfig) tokenUsageResponse {
+	resp := tokenUsageResponse{
+		Since:    filter.Since,
+		Until:    filter.Until,
+		Currency: pricing.Currency,
+		Models:   []tokenUsageModelTotal{},
+		Days:     make([]tokenUsageDayResponse, 0, len(days)),
+	}
+	totals := map[string]*tokenUsageModelTotal{}
+	for _, d := range days {
+		row := tokenUsageDayResponse{TokenUsageDay: d}
+		if cost, ok := usageCost(pricing.Models, d.Model, d.PromptTokens, d.CompletionTokens); ok {
+			row.Cost = &cost
+		}
+		resp.Days = append(resp.Days, row)
+
+		t := totals[d.Model]
+		if t == nil {
+			t = &tokenUsageModelTotal{Model: d.Model}
+			totals[d.Model] = t
+		}
+		t.PromptTokens += d.PromptTokens
+		t.CompletionTokens += d.CompletionTokens
+		t.TotalTokens += d.PromptTokens + d.CompletionTokens
+		t.Calls += d.Calls
+	}
+	for _, t := range totals {
+		if cost, ok := usageCost(pricing.Models, t.Model, t.PromptTokens, t.CompletionTokens); ok {
+			t.Cost = &cost
+			resp.EstimatedCost += cost
+		}
+		resp.Models = append(resp.Models, *t)
+	}
+	sort.Slice(resp.Models, func(i, j int) bool {
+		if resp.Models[i].TotalTokens != resp.Models[j].TotalTokens {
+			return resp.Models[i].TotalTokens > resp.Models[j].TotalTokens
+		}
+		return resp.Models[i].Model < resp.Models[j].Model
+	})
+	return resp
+}
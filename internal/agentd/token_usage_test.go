@@ -0,0 +1,126 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	llmpkg "manifold/internal/llm"
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
+)
+
+type failingTokenUsageStore struct{ persist.TokenUsageStore }
+
+func (failingTokenUsageStore) Add(context.Context, []persist.TokenUsageDay) error {
+	return errors.New("database down")
+}
+
+func TestTokenUsageRecorderFlush(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, 5, 4, 13, 0, 0, 0, time.UTC)
+	rec := newTokenUsageRecorder(failingTokenUsageStore{})
+	rec.add(llmpkg.TokenUsage{UserID: 7, Model: "gpt-4o", Prompt: 100, Completion: 20, At: at})
+	rec.add(llmpkg.TokenUsage{UserID: 7, Model: "gpt-4o", Prompt: 50, Completion: 5, At: at.Add(time.Hour)})
+	if err := rec.flush(ctx); err == nil {
+		t.Fatal("expected flush error")
+	}
+	filter := persist.TokenUsageFilter{Since: usageDay(at), Until: usageDay(at).AddDate(0, 0, 1)}
+	kept := rec.pendingFor(7, filter)
+	if len(kept) != 1 || kept[0].PromptTokens != 150 || kept[0].Calls != 2 {
+		t.Fatalf("failed flush should keep usage pending: %+v", kept)
+	}
+
+	store := databases.NewTokenUsageStore(nil)
+	rec.store = store
+	if err := rec.flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := rec.pendingFor(7, filter); len(got) != 0 {
+		t.Fatalf("pending after flush: %+v", got)
+	}
+	stored, err := store.Query(ctx, 7, filter)
+	if err != nil || len(stored) != 1 || stored[0].CompletionTokens != 25 {
+		t.Fatalf("stored = %+v (%v)", stored, err)
+	}
+}
+
+func TestParseUsageRange(t *testing.T) {
+	now := time.Date(2026, 5, 4, 13, 0, 0, 0, time.UTC)
+	parse := func(query string) (persist.TokenUsageFilter, error) {
+		return parseUsageRange(httptest.NewRequest(http.MethodGet, "/api/metrics/usage?"+query, nil), now)
+	}
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	f, err := parse("")
+	if err != nil || !f.Until.Equal(day("2026-05-05")) || !f.Since.Equal(day("2026-04-05")) {
+		t.Fatalf("default range = %v..%v (%v)", f.Since, f.Until, err)
+	}
+	f, err = parse("since=2026-01-01&until=2026-01-31&model=gpt-4o")
+	if err != nil || !f.Since.Equal(day("2026-01-01")) || !f.Until.Equal(day("2026-02-01")) || f.Model != "gpt-4o" {
+		t.Fatalf("date range = %+v (%v)", f, err)
+	}
+	f, err = parse("since=2026-01-01T10:00:00Z&until=2026-01-03T00:00:00Z")
+	if err != nil || !f.Since.Equal(day("2026-01-01")) || !f.Until.Equal(day("2026-01-03")) {
+		t.Fatalf("timestamp range = %+v (%v)", f, err)
+	}
+	for _, bad := range []string{"since=yesterday", "since=2026-02-01&until=2026-01-01", "since=2020-01-01&until=2026-01-01"} {
+		if _, err := parse(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestMetricsUsageHandler(t *testing.T) {
+	ctx := context.Background()
+	store := databases.NewTokenUsageStore(nil)
+	today := usageDay(time.Now())
+	if err := store.Add(ctx, []persist.TokenUsageDay{
+		{Day: today.AddDate(0, 0, -1), UserID: systemUserID, Model: "gpt-4o", PromptTokens: 1_000_000, CompletionTokens: 100_000, Calls: 3},
+		{Day: today.AddDate(0, 0, -1), UserID: systemUserID, Model: "local", PromptTokens: 10, Calls: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Pricing: config.PricingConfig{
+		Currency: "USD",
+		Models:   map[string]config.ModelPrice{"gpt-4o": {InputPerMillion: 2.5, OutputPerMillion: 10}},
+	}}
+	a := &app{cfg: cfg, mgr: &databases.Manager{TokenUsage: store}, tokenUsage: newTokenUsageRecorder(store)}
+	a.tokenUsage.add(llmpkg.TokenUsage{Model: "gpt-4o", Prompt: 1_000_000, At: time.Now()})
+
+	rec := httptest.NewRecorder()
+	a.metricsUsageHandler()(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/usage", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp tokenUsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Days) != 3 {
+		t.Fatalf("days = %+v", resp.Days)
+	}
+	if len(resp.Models) != 2 || resp.Models[0].Model != "gpt-4o" || resp.Models[0].PromptTokens != 2_000_000 || resp.Models[0].Calls != 4 {
+		t.Fatalf("models = %+v", resp.Models)
+	}
+	if resp.Models[0].Cost == nil || *resp.Models[0].Cost != 6 || resp.Models[1].Cost != nil {
+		t.Fatalf("costs = %v, %v", resp.Models[0].Cost, resp.Models[1].Cost)
+	}
+	if resp.EstimatedCost != 6 || resp.Currency != "USD" {
+		t.Fatalf("estimatedCost = %v %s", resp.EstimatedCost, resp.Currency)
+	}
+
+	rec = httptest.NewRecorder()
+	a.metricsUsageHandler()(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/usage?since=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad since: status = %d", rec.Code)
+	}
+}
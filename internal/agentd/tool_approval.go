@@ -0,0 +1,298 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	"manifold/internal/apierror"
+	"manifold/internal/llm"
+)
+
+var (
+	errApprovalNotFound  = errors.New("no pending approval for tool call")
+	errApprovalForbidden = errors.New("approval belongs to another user")
+)
+
+// pendingToolApproval is a tool call paused until a client approves or
+// denies it.
+type pendingToolApproval struct {
+	RunID       string          `json:"runId"`
+	ToolCallID  string          `json:"toolCallId"`
+	Tool        string          `json:"tool"`
+	Args        json.RawMessage `json:"args,omitempty"`
+	RequestedAt time.Time       `json:"requestedAt"`
+	ExpiresAt   time.Time       `json:"expiresAt"`
+
+	userID int64
+	// approvers, when set, lets users other than userID decide, such as
+	// participants of a live session granted approval rights.
+	approvers func(userID int64) bool
+	decision  chan agent.ApprovalDecision
+}
+
+// canDecide reports whether userID may approve or deny p.
+func (p *pendingToolApproval) canDecide(userID int64) bool {
+	return p.userID == userID || (p.approvers != nil && p.approvers(userID))
+}
+
+// toolApprovalBroker hands client decisions to the runs waiting on them.
+type toolApprovalBroker struct {
+	mu      sync.Mutex
+	pending map[string]*pendingToolApproval
+}
+
+func newToolApprovalBroker() *toolApprovalBroker {
+	return &toolApprovalBroker{pending: map[string]*pendingToolApproval{}}
+}
+
+func approvalKey(runID, toolCallID string) string { return runID + "/" + toolCallID }
+
+// wait registers p and blocks until it is decided, it expires, or ctx ends.
+// Expiry and cancellation deny the call.
+func (b *toolApprovalBroker) wait(ctx context.Context, p *pendingToolApproval) agent.ApprovalDecision {
+	key := approvalKey(p.RunID, p.ToolCallID)
+	p.decision = make(chan agent.ApprovalDecision, 1)
+	b.mu.Lock()
+	b.pending[key] = p
+	b.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(p.ExpiresAt))
+	defer timer.Stop()
+	var d agent.ApprovalDecision
+	select {
+	case d = <-p.decision:
+		return d
+	case <-timer.C:
+		d = agent.ApprovalDecision{Reason: "approval timed out"}
+	case <-ctx.Done():
+		d = agent.ApprovalDecision{Reason: "run cancelled"}
+	}
+
+	b.mu.Lock()
+	if _, ok := b.pending[key]; !ok {
+		// A decision raced the timeout and won.
+		b.mu.Unlock()
+		return <-p.decision
+	}
+	delete(b.pending, key)
+	b.mu.Unlock()
+	auditToolApproval(p, "expired", 0, d.Reason)
+	return d
+}
+
+// decide delivers d to the run waiting on toolCallID. Only the user who
+// started the run, or one of the run's approvers, may decide.
+func (b *toolApprovalBroker) decide(runID, toolCallID string, userID int64, d agent.ApprovalDecision) error {
+	key := approvalKey(runID, toolCallID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.pending[key]
+	if !ok {
+		return errApprovalNotFound
+	}
+	if !p.canDecide(userID) {
+		return errApprovalForbidden
+	}
+	delete(b.pending, key)
+	p.decision <- d
+	outcome := "denied"
+	if d.Approved {
+		outcome = "approved"
+	}
+	auditToolApproval(p, outcome, userID, d.Reason)
+	return nil
+}
+
+// list returns the pending approvals for runID that userID may decide.
+func (b *toolApprovalBroker) list(runID string, userID int64) []pendingToolApproval {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []pendingToolApproval{}
+	for _, p := range b.pending {
+		if p.RunID == runID && p.canDecide(userID) {
+			out = append(out, *p)
+		}
+	}
+	return out
+}
+
+// auditToolApproval writes the audit record for one step of an approval.
+func auditToolApproval(p *pendingToolApproval, event string, decidedBy int64, reason string) {
+	ev := log.Info().
+		Bool("audit", true).
+		Str("event", event).
+		Str("run_id", p.RunID).
+		Str("tool_call_id", p.ToolCallID).
+		Str("tool", p.Tool).
+		Int64("user_id", p.userID)
+	if decidedBy != 0 {
+		ev = ev.Int64("decided_by", decidedBy)
+	}
+	if reason != "" {
+		ev = ev.Str("reason", reason)
+	}
+	ev.Msg("tool_approval")
+}
+
+// runToolApprover gates one run's calls to the configured tools.
+type runToolApprover struct {
+	broker  *toolApprovalBroker
+	tools   map[string]struct{}
+	timeout time.Duration
+	runID   string
+	userID  int64
+	stream  *chatSSEWriter
+	// approvers extends who may decide beyond userID; see
+	// pendingToolApproval.approvers.
+	approvers func(userID int64) bool
+}
+
+func (p *runToolApprover) ApproveToolCall(ctx context.Context, tc llm.ToolCall) agent.ApprovalDecision {
+	if _, ok := p.tools[tc.Name]; !ok {
+		return agent.ApprovalDecision{Approved: true}
+	}
+	now := time.Now().UTC()
+	pending := &pendingToolApproval{
+		RunID:       p.runID,
+		ToolCallID:  tc.ID,
+		Tool:        tc.Name,
+		Args:        json.RawMessage(tc.Args),
+		RequestedAt: now,
+		ExpiresAt:   now.Add(p.timeout),
+		userID:      p.userID,
+		approvers:   p.approvers,
+	}
+	if !json.Valid(pending.Args) {
+		pending.Args = nil
+	}
+	auditToolApproval(pending, "requested", 0, "")
+	if p.stream != nil {
+		p.stream.write(map[string]any{
+			"type":         "tool_approval_request",
+			"run_id":       p.runID,
+			"tool_call_id": tc.ID,
+			"tool":         tc.Name,
+			"args":         string(tc.Args),
+			"expires_at":   pending.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	d := p.broker.wait(ctx, pending)
+	d.Reviewed = d.Approved
+	if p.stream != nil {
+		p.stream.write(map[string]any{
+			"type":         "tool_approval_result",
+			"run_id":       p.runID,
+			"tool_call_id": tc.ID,
+			"tool":         tc.Name,
+			"approved":     d.Approved,
+			"reason":       d.Reason,
+		})
+	}
+	return d
+}
+
+// attachToolApprover gates the run's calls to tools listed in
+// toolApproval.requiresApproval, plus email_send while email is draft-only
+// and git_push unless direct pushes are allowed. Streams announce each
+// pending call with a "tool_approval_request" event.
+func (a *app) attachToolApprover(eng *agent.Engine, runID string, userID *int64, stream *chatSSEWriter, approvers func(int64) bool) {
+	if eng == nil || a.toolApprovals == nil {
+		return
+	}
+	tools := make(map[string]struct{}, len(a.cfg.ToolApproval.RequiresApproval)+2)
+	for _, name := range a.cfg.ToolApproval.RequiresApproval {
+		if name = strings.TrimSpace(name); name != "" {
+			tools[name] = struct{}{}
+		}
+	}
+	if a.cfg.Email.Enabled && !a.cfg.Email.AllowDirectSend {
+		tools["email_send"] = struct{}{}
+	}
+	if !a.cfg.Exec.Git.AllowDirectPush {
+		tools["git_push"] = struct{}{}
+	}
+	if len(tools) == 0 {
+		return
+	}
+	owner := systemUserID
+	if userID != nil {
+		owner = *userID
+	}
+	eng.ToolApprover = &runToolApprover{
+		broker:    a.toolApprovals,
+		tools:     tools,
+		timeout:   time.Duration(a.cfg.ToolApproval.TimeoutSeconds) * time.Second,
+		runID:     runID,
+		userID:    owner,
+		stream:    stream,
+		approvers: approvers,
+	}
+}
+
+// handleRunApprovals serves GET /api/runs/{id}/approvals, listing the
+// caller's pending approvals, and POST /api/runs/{id}/approvals/{toolCallID}
+// with {"decision": "approve"|"deny", "reason": "..."}.
+func (a *app) handleRunApprovals(w http.ResponseWriter, r *http.Request, userID int64, runID, toolCallID string) {
+	if a.toolApprovals == nil {
+		apierror.Respond(w, http.StatusServiceUnavailable, "tool approvals unavailable")
+		return
+	}
+	if toolCallID == "" {
+		if r.Method != http.MethodGet {
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"runId": runID, "approvals": a.toolApprovals.list(runID, userID)})
+		return
+	}
+	if r.Method != http.MethodPost {
+		apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var body struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+	if !apierror.Decode(w, r, &body, 64<<10) {
+		return
+	}
+	d, err := parseApprovalDecision(body.Decision, body.Reason)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	switch err := a.toolApprovals.decide(runID, toolCallID, userID, d); {
+	case errors.Is(err, errApprovalNotFound):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, errApprovalForbidden):
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"runId": runID, "toolCallId": toolCallID, "approved": d.Approved})
+}
+
+// parseApprovalDecision converts a client's "approve"/"deny" into a decision.
+func parseApprovalDecision(decision, reason string) (agent.ApprovalDecision, error) {
+	var d agent.ApprovalDecision
+	switch strings.ToLower(strings.TrimSpace(decision)) {
+	case "approve", "approved":
+		d.Approved = true
+	case "deny", "denied":
+		d.Reason = strings.TrimSpace(reason)
+		if d.Reason == "" {
+			d.Reason = "denied by user"
+		}
+	default:
+		return d, errors.New(`decision must be "approve" or "deny"`)
+	}
+	return d, nil
+}
@@ -0,0 +1,138 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"manifold/internal/agent"
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+func newApprovalTestApp(timeoutSeconds int) *app {
+	return &app{
+		cfg:           &config.Config{ToolApproval: config.ToolApprovalConfig{RequiresApproval: []string{"run_cli"}, TimeoutSeconds: timeoutSeconds}},
+		toolApprovals: newToolApprovalBroker(),
+	}
+}
+
+// awaitPending waits until runID has a pending approval.
+func awaitPending(t *testing.T, a *app, runID string) pendingToolApproval {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if p := a.toolApprovals.list(runID, systemUserID); len(p) == 1 {
+			return p[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no pending approval for %s", runID)
+	return pendingToolApproval{}
+}
+
+func TestToolApprovalApproveAndDenyOverHTTP(t *testing.T) {
+	t.Parallel()
+
+	a := newApprovalTestApp(5)
+	rec := httptest.NewRecorder()
+	stream, err := newChatSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := &agent.Engine{}
+	a.attachToolApprover(eng, "run_1", nil, stream, nil)
+	handler := a.runDetailHandler()
+
+	if d := eng.ToolApprover.ApproveToolCall(context.Background(), llm.ToolCall{Name: "web_fetch", ID: "call_0"}); !d.Approved {
+		t.Fatalf("ungated tool must be approved immediately")
+	}
+
+	for _, tc := range []struct {
+		body     string
+		approved bool
+		reason   string
+	}{
+		{body: `{"decision":"approve"}`, approved: true},
+		{body: `{"decision":"deny","reason":"too risky"}`, reason: "too risky"},
+	} {
+		done := make(chan agent.ApprovalDecision, 1)
+		go func() {
+			done <- eng.ToolApprover.ApproveToolCall(context.Background(), llm.ToolCall{Name: "run_cli", ID: "call_1", Args: []byte(`{"cmd":"ls"}`)})
+		}()
+		p := awaitPending(t, a, "run_1")
+		if p.Tool != "run_cli" || p.ToolCallID != "call_1" || string(p.Args) != `{"cmd":"ls"}` {
+			t.Fatalf("unexpected pending approval: %+v", p)
+		}
+
+		list := httptest.NewRecorder()
+		handler.ServeHTTP(list, httptest.NewRequest(http.MethodGet, "/api/runs/run_1/approvals", nil))
+		if list.Code != http.StatusOK || !strings.Contains(list.Body.String(), `"toolCallId":"call_1"`) {
+			t.Fatalf("list approvals: %d %s", list.Code, list.Body.String())
+		}
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/runs/run_1/approvals/call_1", bytes.NewBufferString(tc.body)))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("decide: %d %s", resp.Code, resp.Body.String())
+		}
+		d := <-done
+		if d.Approved != tc.approved || d.Reason != tc.reason {
+			t.Fatalf("got decision %+v, want approved=%v reason=%q", d, tc.approved, tc.reason)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/runs/run_1/approvals/call_1", bytes.NewBufferString(`{"decision":"approve"}`)))
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once decided, got %d", resp.Code)
+	}
+	for _, want := range []string{`"type":"tool_approval_request"`, `"type":"tool_approval_result"`, `"run_id":"run_1"`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("stream missing %s:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestToolApprovalTimesOutAndChecksOwner(t *testing.T) {
+	t.Parallel()
+
+	a := newApprovalTestApp(5)
+	eng := &agent.Engine{}
+	other := int64(42)
+	a.attachToolApprover(eng, "run_2", &other, nil, nil)
+	eng.ToolApprover.(*runToolApprover).timeout = 50 * time.Millisecond
+
+	done := make(chan agent.ApprovalDecision, 1)
+	go func() {
+		done <- eng.ToolApprover.ApproveToolCall(context.Background(), llm.ToolCall{Name: "run_cli", ID: "call_1"})
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(a.toolApprovals.list("run_2", other)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := a.toolApprovals.decide("run_2", "call_1", systemUserID, agent.ApprovalDecision{Approved: true}); err != errApprovalForbidden {
+		t.Fatalf("expected errApprovalForbidden, got %v", err)
+	}
+	if d := <-done; d.Approved || d.Reason != "approval timed out" {
+		t.Fatalf("expected timeout denial, got %+v", d)
+	}
+	if len(a.toolApprovals.list("run_2", other)) != 0 {
+		t.Fatalf("expired approval must be removed")
+	}
+}
+
+func TestToolApprovalRejectsBadDecision(t *testing.T) {
+	t.Parallel()
+
+	a := newApprovalTestApp(5)
+	resp := httptest.NewRecorder()
+	a.runDetailHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/runs/run_3/approvals/call_1", bytes.NewBufferString(`{"decision":"maybe"}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+}
@@ -6,35 +6,78 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"manifold/internal/agent"
 	"manifold/internal/auth"
 	"manifold/internal/llm"
 	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/databases"
 )
 
-// AgentRun represents a single agent invocation for the Runs view (in-memory only).
+// AgentRun represents a single agent invocation for the Runs view. Runs are
+// kept in memory, and also saved to disk with the "file" database backend.
 type AgentRun struct {
 	ID        string `json:"id"`
 	Prompt    string `json:"prompt"`
 	CreatedAt string `json:"createdAt"`
-	Status    string `json:"status"` // running | failed | completed
+	Status    string `json:"status"` // running | failed | completed | interrupted
 	Tokens    int    `json:"tokens,omitempty"`
+	// EscalatedModel is set when the verifier replaced the final answer.
+	EscalatedModel string `json:"escalatedModel,omitempty"`
+	// UserID is who started the run, recorded with its timeline.
+	UserID int64 `json:"userId,omitempty"`
+	// Events is the run's step timeline, served by /api/runs/{id}/events
+	// and left out of run lists.
+	Events []agent.StepEvent `json:"events,omitempty"`
 }
 
+// maxRunEvents caps the timeline kept per run.
+const maxRunEvents = 1000
+
 type runStore struct {
 	mu   sync.RWMutex
 	runs []AgentRun
+	// file, when set, is rewritten after every change.
+	file *databases.JSONFile
 }
 
 func newRunStore() *runStore {
 	return &runStore{runs: make([]AgentRun, 0, 64)}
 }
 
+// newFileRunStore returns a run store loaded from and saved to path. Runs
+// still marked running or queued when loaded were cut short by a restart
+// and are reported as failed.
+func newFileRunStore(path string) (*runStore, error) {
+	s := newRunStore()
+	s.file = databases.NewJSONFile(path)
+	if err := s.file.Load(&s.runs); err != nil {
+		return nil, err
+	}
+	for i := range s.runs {
+		if s.runs[i].Status == "running" || s.runs[i].Status == "queued" {
+			s.runs[i].Status = "failed"
+		}
+	}
+	return s, nil
+}
+
+// save writes the runs to file; callers hold s.mu.
+func (s *runStore) save() {
+	if s.file == nil {
+		return
+	}
+	if err := s.file.Save(s.runs); err != nil {
+		log.Warn().Err(err).Msg("save_runs")
+	}
+}
+
 func (s *runStore) create(prompt string) AgentRun {
 	return s.createWithID(fmt.Sprintf("run_%d", time.Now().UnixNano()), prompt, time.Now().UTC())
 }
@@ -49,6 +92,7 @@ func (s *runStore) createWithID(id string, prompt string, createdAt time.Time) A
 		Status:    "running",
 	}
 	s.runs = append(s.runs, run)
+	s.save()
 	return run
 }
 
@@ -61,16 +105,89 @@ func (s *runStore) updateStatus(id string, status string, tokens int) {
 			if tokens > 0 {
 				s.runs[i].Tokens = tokens
 			}
+			s.save()
+			break
+		}
+	}
+}
+
+// interruptRunning marks runs still running as interrupted, recording
+// them before shutdown. It returns how many it marked.
+func (s *runStore) interruptRunning() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range s.runs {
+		if s.runs[i].Status == "running" {
+			s.runs[i].Status = "interrupted"
+			n++
+		}
+	}
+	if n > 0 {
+		s.save()
+	}
+	return n
+}
+
+func (s *runStore) markEscalated(id, model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		if s.runs[i].ID == id {
+			s.runs[i].EscalatedModel = model
+			s.save()
 			break
 		}
 	}
 }
 
+func (s *runStore) setOwner(id string, userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		if s.runs[i].ID == id {
+			s.runs[i].UserID = userID
+			return
+		}
+	}
+}
+
+// appendEvent adds ev to the timeline of run id. The timeline is saved to
+// file with the run's next status change rather than on every event.
+func (s *runStore) appendEvent(id string, ev agent.StepEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		if s.runs[i].ID == id {
+			if len(s.runs[i].Events) < maxRunEvents {
+				s.runs[i].Events = append(s.runs[i].Events, ev)
+			}
+			return
+		}
+	}
+}
+
+// get returns run id with a copy of its timeline.
+func (s *runStore) get(id string) (AgentRun, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, run := range s.runs {
+		if run.ID == id {
+			run.Events = slices.Clone(run.Events)
+			return run, true
+		}
+	}
+	return AgentRun{}, false
+}
+
 func (s *runStore) list() []AgentRun {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	out := make([]AgentRun, len(s.runs))
 	copy(out, s.runs)
+	for i := range out {
+		out[i].Events = nil
+	}
 	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
 		out[i], out[j] = out[j], out[i]
 	}
@@ -229,20 +346,6 @@ func resolveChatAccess(_ context.Context, authStore *auth.Store, user *auth.User
 	return &id, false, nil
 }
 
-func setChatCORSHeaders(w http.ResponseWriter, r *http.Request, methods string) {
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Vary", "Origin")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-	} else {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-	}
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
-	if methods != "" {
-		w.Header().Set("Access-Control-Allow-Methods", methods)
-	}
-}
-
 func (a *app) requireUserID(r *http.Request) (int64, error) {
 	if !a.cfg.Auth.Enabled {
 		return systemUserID, nil
@@ -261,6 +364,6 @@ func providerSupportsCompaction(provider llm.Provider) bool {
 	if provider == nil {
 		return false
 	}
-	_, ok := provider.(llm.CompactionProvider)
+	_, ok := llm.Unwrap(provider).(llm.CompactionProvider)
 	return ok
 }
@@ -0,0 +1,64 @@
+package agentd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/agent"
+	"manifold/internal/config"
+	llmpkg "manifold/internal/llm"
+)
+
+// newAnswerVerifier builds the final-answer verifier from configuration.
+// schema is the optional per-request response schema. It returns nil when
+// verification is disabled.
+func newAnswerVerifier(cfg config.VerifierConfig, summaryLLM llmpkg.Provider, schema map[string]any) agent.Verifier {
+	if !cfg.Enabled {
+		return nil
+	}
+	vs := agent.Verifiers{&agent.RuleVerifier{Checks: cfg.Checks, MinChars: cfg.MinAnswerChars, Schema: schema}}
+	if model := strings.TrimSpace(cfg.Model); model != "" && summaryLLM != nil {
+		vs = append(vs, &agent.ModelVerifier{LLM: summaryLLM, Model: model})
+	}
+	return vs
+}
+
+// attachVerifier applies the request's response schema to the engine's
+// verifier and records escalations on the run and, for streams without an
+// agent tracer, as an "escalation" event.
+func (a *app) attachVerifier(eng *agent.Engine, req chatRunRequest, runID string, collector *chatTurnCollector, stream *chatSSEWriter) {
+	if eng == nil || eng.Verifier == nil {
+		return
+	}
+	if len(req.ResponseSchema) > 0 {
+		var schema map[string]any
+		if err := json.Unmarshal(req.ResponseSchema, &schema); err != nil {
+			log.Warn().Err(err).Str("run_id", runID).Msg("response_schema_invalid")
+		} else {
+			eng.Verifier = newAnswerVerifier(a.cfg.Verifier, a.summaryLLM, schema)
+		}
+	}
+	eng.OnEscalation = func(esc agent.Escalation) {
+		if esc.Answer != "" {
+			a.runs.markEscalated(runID, esc.ToModel)
+			collector.dropFinalAnswer()
+		}
+		if stream == nil || eng.AgentTracer != nil {
+			return
+		}
+		payload := map[string]any{
+			"type":       "escalation",
+			"check":      esc.Check,
+			"reason":     esc.Reason,
+			"from_model": esc.FromModel,
+			"model":      esc.ToModel,
+			"verified":   esc.Verified,
+		}
+		if esc.Err != nil {
+			payload["error"] = esc.Err.Error()
+		}
+		stream.write(payload)
+	}
+}
@@ -0,0 +1,209 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/llm"
+)
+
+// hostedVendorHosts are managed APIs where models are always resident, so
+// only connection priming is worthwhile.
+var hostedVendorHosts = map[string]bool{
+	"api.openai.com":                    true,
+	"api.anthropic.com":                 true,
+	"generativelanguage.googleapis.com": true,
+}
+
+// isSelfHosted reports whether baseURL points at something other than a
+// managed vendor API (llama.cpp, vLLM, Ollama, a local whisper server, ...).
+func isSelfHosted(baseURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return !hostedVendorHosts[strings.ToLower(u.Hostname())]
+}
+
+func sttTranscriptionsURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+	return baseURL + "/v1/audio/transcriptions"
+}
+
+type warmupTarget struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// warmupTargets lists distinct provider origins to prime. The embedding
+// client uses http.DefaultClient, so its connection is primed there.
+func (a *app) warmupTargets() []warmupTarget {
+	candidates := []warmupTarget{
+		{name: "llm", url: llmProbeURL(a.cfg), client: a.httpClient},
+		{name: "summary", url: a.cfg.OpenAI.SummaryBaseURL, client: a.httpClient},
+		{name: "embedding", url: a.cfg.Embedding.BaseURL, client: http.DefaultClient},
+		{name: "tts", url: a.cfg.TTS.BaseURL, client: a.httpClient},
+		{name: "stt", url: a.cfg.STT.BaseURL, client: a.httpClient},
+	}
+	seen := map[string]bool{}
+	var out []warmupTarget
+	for _, t := range candidates {
+		u, err := url.Parse(strings.TrimSpace(t.url))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		key := u.Scheme + "://" + u.Host
+		if t.client == http.DefaultClient {
+			key += "|default"
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// warmup primes provider connections and, when configured, loads self-hosted
+// models so the first user request does not pay the cold-start cost. It runs
+// in the background and only logs failures.
+func (a *app) warmup(ctx context.Context) {
+	wc := a.cfg.Warmup
+	if !wc.Enabled {
+		return
+	}
+	timeout := time.Duration(wc.TimeoutSeconds) * time.Second
+	var wg sync.WaitGroup
+	for _, t := range a.warmupTargets() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stepCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			started := time.Now()
+			if err := probeHTTP(t.client, t.url)(stepCtx); err != nil {
+				log.Warn().Err(err).Str("target", t.name).Str("url", t.url).Msg("warmup_connection_failed")
+				return
+			}
+			log.Info().Str("target", t.name).Str("url", t.url).Dur("elapsed", time.Since(started)).Msg("warmup_connection_primed")
+		}()
+	}
+	if wc.Completion && a.llm != nil && isSelfHosted(llmProbeURL(a.cfg)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.warmupCompletion(ctx, timeout)
+		}()
+	}
+	if wc.STT && isSelfHosted(a.cfg.STT.BaseURL) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.warmupSTT(ctx, timeout)
+		}()
+	}
+	wg.Wait()
+}
+
+func (a *app) warmupCompletion(ctx context.Context, timeout time.Duration) {
+	type chatWithOptions interface {
+		ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	msgs := []llm.Message{{Role: "user", Content: "hi"}}
+	started := time.Now()
+	var err error
+	if co, ok := a.llm.(chatWithOptions); ok {
+		_, err = co.ChatWithOptions(ctx, msgs, nil, "", map[string]any{"max_tokens": 1})
+	} else {
+		_, err = a.llm.Chat(ctx, msgs, nil, "")
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("warmup_completion_failed")
+		return
+	}
+	log.Info().Dur("elapsed", time.Since(started)).Msg("warmup_model_loaded")
+}
+
+func (a *app) warmupSTT(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	model := strings.TrimSpace(a.cfg.STT.Model)
+	if model == "" {
+		model = "gpt-4o-mini-transcribe"
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "warmup.wav")
+	if err == nil {
+		_, err = fw.Write(silentWAV(500 * time.Millisecond))
+	}
+	if err == nil {
+		err = mw.WriteField("model", model)
+	}
+	if err == nil {
+		err = mw.Close()
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("warmup_stt_failed")
+		return
+	}
+	reqURL := sttTranscriptionsURL(a.cfg.STT.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, &buf)
+	if err != nil {
+		log.Warn().Err(err).Msg("warmup_stt_failed")
+		return
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	started := time.Now()
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		log.Warn().Err(err).Str("endpoint", reqURL).Msg("warmup_stt_failed")
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	// Any response means the server has loaded the model far enough to
+	// answer; a rejected silent clip is still a successful warmup.
+	log.Info().Int("status", resp.StatusCode).Str("endpoint", reqURL).Dur("elapsed", time.Since(started)).Msg("warmup_stt_loaded")
+}
+
+// silentWAV returns a 16 kHz mono 16-bit PCM WAV file of silence.
+func silentWAV(d time.Duration) []byte {
+	return pcmWAV(make([]byte, int(d.Seconds()*16000)*2))
+}
+
+// pcmWAV wraps 16 kHz mono little-endian 16-bit PCM in a WAV header.
+func pcmWAV(pcm []byte) []byte {
+	const sampleRate, bytesPerSample = 16000, 2
+	dataLen := len(pcm)
+	var b bytes.Buffer
+	b.Grow(44 + dataLen)
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+dataLen))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, struct {
+		Size                 uint32
+		Format, Channels     uint16
+		SampleRate, ByteRate uint32
+		BlockAlign, Bits     uint16
+	}{16, 1, 1, sampleRate, sampleRate * bytesPerSample, bytesPerSample, 16})
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(dataLen))
+	b.Write(pcm)
+	return b.Bytes()
+}
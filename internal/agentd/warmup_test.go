@@ -0,0 +1,70 @@
+package agentd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+)
+
+func TestIsSelfHosted(t *testing.T) {
+	cases := map[string]bool{
+		"https://api.openai.com/v1":    false,
+		"https://api.anthropic.com":    false,
+		"http://localhost:8080/v1":     true,
+		"http://10.0.0.5:11434":        true,
+		"":                             false,
+		"https://llm.internal.example": true,
+	}
+	for in, want := range cases {
+		if got := isSelfHosted(in); got != want {
+			t.Errorf("isSelfHosted(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestSilentWAVHeader(t *testing.T) {
+	b := silentWAV(500 * time.Millisecond)
+	if len(b) != 44+16000 {
+		t.Fatalf("unexpected wav size %d", len(b))
+	}
+	if string(b[:4]) != "RIFF" || string(b[8:16]) != "WAVEfmt " || string(b[36:40]) != "data" {
+		t.Fatalf("malformed wav header: %q", b[:44])
+	}
+}
+
+func TestWarmupPrimesConnectionsAndSTT(t *testing.T) {
+	var probes, transcriptions atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/audio/transcriptions" {
+			transcriptions.Add(1)
+			if err := r.ParseMultipartForm(1 << 20); err != nil || r.FormValue("model") != "whisper-1" {
+				t.Errorf("unexpected stt form: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"text":""}`))
+			return
+		}
+		probes.Add(1)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		LLMClient: config.LLMClientConfig{Provider: "local", OpenAI: config.OpenAIConfig{BaseURL: srv.URL + "/v1"}},
+		STT:       config.STTConfig{BaseURL: srv.URL, Model: "whisper-1"},
+		Warmup:    config.WarmupConfig{Enabled: true, STT: true, TimeoutSeconds: 5},
+	}
+	a := &app{cfg: cfg, httpClient: srv.Client()}
+	a.warmup(context.Background())
+
+	// The LLM and STT share an origin, so the connection is primed once.
+	if probes.Load() != 1 {
+		t.Fatalf("expected one connection probe, got %d", probes.Load())
+	}
+	if transcriptions.Load() != 1 {
+		t.Fatalf("expected one warmup transcription, got %d", transcriptions.Load())
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"manifold/internal/config"
 	"manifold/internal/flow"
+	persist "manifold/internal/persistence"
 	"manifold/internal/sandbox"
 	"manifold/internal/tools/warpptool"
 )
@@ -61,25 +62,33 @@ func (a *app) ExecuteWorkflowSync(ctx context.Context, userID int64, workflowID
 		return nil, fmt.Errorf("load workflow: %w", err)
 	}
 	if !found {
-		return nil, fmt.Errorf("workflow not found")
+		return nil, warpptool.ErrWorkflowNotFound
 	}
 	plan, diags := flow.CompileWorkflow(wf)
 	if hasFlowV2Errors(diags) || plan == nil {
 		return nil, fmt.Errorf("workflow validation failed: %s", flowDiagnosticSummary(diags))
 	}
+	var ctxErr error
 	if projectID := strings.TrimSpace(wf.ProjectID); projectID != "" {
-		var projectErr error
-		runCtx, projectErr = workflowToolContext(runCtx, a.cfg, userID, projectID)
-		if projectErr != nil {
-			return nil, projectErr
-		}
+		runCtx, ctxErr = workflowToolContext(runCtx, a.cfg, userID, projectID)
+	} else {
+		runCtx, ctxErr = a.withScratchDir(runCtx, userID)
+	}
+	if ctxErr != nil {
+		return nil, ctxErr
 	}
-	runID := a.flowV2State().createRun(userID, wf.ID, input)
+	runID := a.flowV2State().createRun(userID, wf.ID, persist.WorkflowTriggerTool, input)
 	a.executeFlowV2Run(runCtx, userID, runID, wf, plan, input)
 	events, status, ok := a.flowV2State().getRunEvents(userID, runID)
 	if !ok {
 		return nil, fmt.Errorf("run result unavailable")
 	}
+	return workflowRunResult(wf, plan, runID, events, status)
+}
+
+// workflowRunResult summarizes a finished run: the output of every completed
+// node plus the output of the last one in plan order, or the run's error.
+func workflowRunResult(wf flow.Workflow, plan *flow.Plan, runID string, events []flow.RunEvent, status string) (map[string]any, error) {
 	outputs := make(map[string]map[string]any)
 	var runErr string
 	for _, event := range events {
@@ -4,12 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"manifold/internal/config"
 	"manifold/internal/flow"
 	llmpkg "manifold/internal/llm"
 	persist "manifold/internal/persistence"
+	"manifold/internal/sandbox"
 	"manifold/internal/tools"
 )
 
@@ -74,7 +80,7 @@ func TestExecuteWorkflowSyncReturnsFinalOutput(t *testing.T) {
 			},
 		},
 	}}
-	a := &app{flowV2: newFlowV2Runtime(store), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(store, nil), baseToolRegistry: reg, toolRegistry: reg}
 	result, err := a.ExecuteWorkflowSync(context.Background(), 0, "wf-1", map[string]any{"query": "hello"})
 	if err != nil {
 		t.Fatalf("ExecuteWorkflowSync error = %v", err)
@@ -90,6 +96,53 @@ func TestExecuteWorkflowSyncReturnsFinalOutput(t *testing.T) {
 	}
 }
 
+func TestExecuteWorkflowSyncUsesScratchDirWithoutProject(t *testing.T) {
+	t.Parallel()
+	var baseDir string
+	reg := newRuntimeStubRegistry(runtimeTestTool{
+		name: "test_tool",
+		callFn: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			baseDir, _ = sandbox.BaseDirFromContext(ctx)
+			return map[string]any{"payload": "done"}, nil
+		},
+	})
+	wf := flow.Workflow{
+		ID:      "wf-1",
+		Name:    "Test Workflow",
+		Trigger: flow.Trigger{Type: flow.TriggerTypeManual},
+		Nodes: []flow.Node{{
+			ID:   "finish",
+			Name: "Finish",
+			Kind: flow.NodeKindAction,
+			Type: "tool",
+			Tool: "test_tool",
+		}},
+	}
+	store := &stubFlowV2Store{records: map[int64]map[string]persist.FlowV2WorkflowRecord{
+		7: {"wf-1": {UserID: 7, Workflow: wf}},
+	}}
+	workdir := t.TempDir()
+	cfg := &config.Config{Workdir: workdir}
+	cfg.Auth.Enabled = true
+	a := &app{cfg: cfg, flowV2: newFlowV2Runtime(store, nil), baseToolRegistry: reg, toolRegistry: reg}
+	if _, err := a.ExecuteWorkflowSync(context.Background(), 7, "wf-1", nil); err != nil {
+		t.Fatalf("ExecuteWorkflowSync error = %v", err)
+	}
+	if want := filepath.Join(workdir, "users", "7", "scratch"); baseDir != want {
+		t.Fatalf("tool base dir = %q, want %q", baseDir, want)
+	}
+}
+
+func TestGRPCExecuteWorkflowNotFound(t *testing.T) {
+	t.Parallel()
+	store := &stubFlowV2Store{records: map[int64]map[string]persist.FlowV2WorkflowRecord{}}
+	a := &app{flowV2: newFlowV2Runtime(store, nil)}
+	_, err := grpcBackend{a: a}.ExecuteWorkflow(context.Background(), 0, "missing", nil)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("ExecuteWorkflow error = %v, want NotFound", err)
+	}
+}
+
 type schemaRegistry struct{ names []string }
 
 func (s *schemaRegistry) Schemas() []llmpkg.ToolSchema {
@@ -113,7 +166,7 @@ func TestSyncWarppToolsRegistersSystemWorkflows(t *testing.T) {
 		},
 	}}
 	reg := &schemaRegistry{}
-	a := &app{flowV2: newFlowV2Runtime(store), baseToolRegistry: reg, toolRegistry: reg}
+	a := &app{flowV2: newFlowV2Runtime(store, nil), baseToolRegistry: reg, toolRegistry: reg}
 	a.syncWarppTools(context.Background())
 	if len(a.warppToolNames) != 1 || a.warppToolNames[0] != "warpp_wf_1" {
 		t.Fatalf("warppToolNames = %#v, want [warpp_wf_1]", a.warppToolNames)
@@ -0,0 +1,360 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/apierror"
+	"manifold/internal/config"
+	"manifold/internal/flow"
+	"manifold/internal/llm"
+	persist "manifold/internal/persistence"
+)
+
+const webhookMaxBodyBytes = 1 << 20
+
+var (
+	errWebhookNotFound  = errors.New("workflow not found")
+	errWebhookRejected  = errors.New("prompt rejected by guardrails")
+	errWebhookAgentDown = errors.New("agent unavailable")
+)
+
+// webhookTrigger is a compiled config.WebhookConfig.
+type webhookTrigger struct {
+	cfg     config.WebhookConfig
+	attrs   map[string]*template.Template
+	prompt  *template.Template
+	limiter *webhookLimiter
+}
+
+// webhookTriggers holds the configured hooks keyed by ID. dispatch starts the
+// hook's run and returns its ID; tests replace it.
+type webhookTriggers struct {
+	hooks    map[string]*webhookTrigger
+	dispatch func(ctx context.Context, hook *webhookTrigger, attrs map[string]any) (string, error)
+}
+
+// newWebhookTriggers validates hooks and parses their templates.
+func newWebhookTriggers(hooks []config.WebhookConfig) (*webhookTriggers, error) {
+	out := &webhookTriggers{hooks: make(map[string]*webhookTrigger, len(hooks))}
+	for _, h := range hooks {
+		id := strings.TrimSpace(h.ID)
+		if id == "" || strings.Contains(id, "/") {
+			return nil, fmt.Errorf("webhook id %q must be a non-empty path segment", h.ID)
+		}
+		if _, dup := out.hooks[id]; dup {
+			return nil, fmt.Errorf("duplicate webhook id %q", id)
+		}
+		hasWorkflow, hasPrompt := strings.TrimSpace(h.Workflow) != "", strings.TrimSpace(h.Prompt) != ""
+		if hasWorkflow == hasPrompt {
+			return nil, fmt.Errorf("webhook %q: set exactly one of workflow and prompt", id)
+		}
+		// /api/hooks/ sits outside auth, so the signature is the only thing
+		// keeping strangers from starting runs as the hook owner.
+		if h.Secret == "" {
+			return nil, fmt.Errorf("webhook %q: secret is required", id)
+		}
+		h.ID = id
+		hook := &webhookTrigger{cfg: h, attrs: make(map[string]*template.Template, len(h.Attributes))}
+		for name, text := range h.Attributes {
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q attribute %q: %w", id, name, err)
+			}
+			hook.attrs[name] = tmpl
+		}
+		if hasPrompt {
+			tmpl, err := template.New(id).Option("missingkey=error").Parse(h.Prompt)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q prompt: %w", id, err)
+			}
+			hook.prompt = tmpl
+		}
+		if h.RateLimitPerMinute > 0 {
+			hook.limiter = newWebhookLimiter(h.RateLimitPerMinute, time.Minute)
+		}
+		out.hooks[id] = hook
+	}
+	return out, nil
+}
+
+// owner returns the user that owns runs started by the hook.
+func (h *webhookTrigger) owner() int64 {
+	if h.cfg.UserID > 0 {
+		return h.cfg.UserID
+	}
+	return systemUserID
+}
+
+// verify checks the request signature against the hook secret.
+func (h *webhookTrigger) verify(header http.Header, body []byte) bool {
+	if h.cfg.Secret == "" {
+		return false
+	}
+	sig := strings.TrimSpace(header.Get(h.cfg.SignatureHeader))
+	sig = strings.TrimPrefix(sig, "sha256=")
+	got, err := hex.DecodeString(sig)
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.cfg.Secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// extract renders each attribute template against the decoded body.
+func (h *webhookTrigger) extract(payload any) (map[string]any, error) {
+	attrs := make(map[string]any, len(h.attrs))
+	for name, tmpl := range h.attrs {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, payload); err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		attrs[name] = buf.String()
+	}
+	return attrs, nil
+}
+
+// renderPrompt renders the prompt template with the extracted attributes.
+func (h *webhookTrigger) renderPrompt(attrs map[string]any) (string, error) {
+	var buf bytes.Buffer
+	if err := h.prompt.Execute(&buf, attrs); err != nil {
+		return "", fmt.Errorf("prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// webhookLimiter is a token bucket holding up to capacity deliveries that
+// refills one token every interval/capacity.
+type webhookLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	refill   time.Duration
+	last     time.Time
+	now      func() time.Time
+}
+
+func newWebhookLimiter(capacity int, interval time.Duration) *webhookLimiter {
+	return &webhookLimiter{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		refill:   interval / time.Duration(capacity),
+		now:      time.Now,
+	}
+}
+
+// allow takes a token, or reports how long until one is available.
+func (l *webhookLimiter) allow() (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.capacity, l.tokens+float64(now.Sub(l.last))/float64(l.refill))
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - l.tokens) * float64(l.refill))
+}
+
+func (a *app) webhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			apierror.Respond(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/hooks/"), "/")
+		var hook *webhookTrigger
+		if a.webhooks != nil {
+			hook = a.webhooks.hooks[id]
+		}
+		if hook == nil {
+			apierror.Respond(w, http.StatusNotFound, "hook not found")
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBodyBytes))
+		if err != nil {
+			apierror.Respond(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if !hook.verify(r.Header, body) {
+			log.Warn().Str("hook", id).Str("remote_addr", r.RemoteAddr).Msg("webhook_signature_invalid")
+			apierror.Respond(w, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+		if ok, wait := hook.limiter.allow(); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			apierror.Respond(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		var payload any = map[string]any{}
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, &payload); err != nil {
+				apierror.Respond(w, http.StatusBadRequest, "body must be JSON")
+				return
+			}
+		}
+		attrs, err := hook.extract(payload)
+		if err != nil {
+			apierror.Respond(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+
+		runID, err := a.webhooks.dispatch(context.WithoutCancel(r.Context()), hook, attrs)
+		switch {
+		case errors.Is(err, errWebhookNotFound):
+			apierror.Respond(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, errWebhookRejected):
+			apierror.Respond(w, http.StatusForbidden, err.Error())
+			return
+		case errors.As(err, new(*quotaExceededError)):
+			writeQuotaExceeded(w, err)
+			return
+		case errors.Is(err, errShuttingDown):
+			w.Header().Set("Retry-After", "5")
+			apierror.RespondCode(w, http.StatusServiceUnavailable, apierror.ShuttingDown, err.Error(), nil)
+			return
+		case isRunQueueRejection(err):
+			w.Header().Set("Retry-After", strconv.Itoa(a.runQueue.retryAfter()))
+			apierror.RespondCode(w, http.StatusTooManyRequests, apierror.RunQueueFull, "server is busy, try again later", nil)
+			return
+		case err != nil:
+			log.Error().Err(err).Str("hook", id).Msg("webhook_dispatch_failed")
+			apierror.Respond(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		log.Info().Str("hook", id).Str("run_id", runID).Msg("webhook_triggered")
+		writeFlowV2JSON(w, http.StatusAccepted, map[string]any{
+			"hook":       id,
+			"run_id":     runID,
+			"status":     "running",
+			"attributes": attrs,
+		})
+	}
+}
+
+// dispatchWebhook starts the hook's workflow or agent prompt in the
+// background and returns the run ID.
+func (a *app) dispatchWebhook(ctx context.Context, hook *webhookTrigger, attrs map[string]any) (string, error) {
+	if hook.prompt != nil {
+		prompt, err := hook.renderPrompt(attrs)
+		if err != nil {
+			return "", err
+		}
+		return a.startWebhookPrompt(ctx, hook, prompt)
+	}
+	return a.startWebhookWorkflow(ctx, hook, attrs)
+}
+
+func (a *app) startWebhookWorkflow(ctx context.Context, hook *webhookTrigger, input map[string]any) (string, error) {
+	userID := hook.owner()
+	wf, _, found, err := a.flowV2State().getWorkflow(ctx, userID, hook.cfg.Workflow)
+	if err != nil {
+		return "", fmt.Errorf("load workflow: %w", err)
+	}
+	if !found {
+		return "", errWebhookNotFound
+	}
+	plan, diags := flow.CompileWorkflow(wf)
+	if hasFlowV2Errors(diags) || plan == nil {
+		return "", fmt.Errorf("workflow validation failed: %s", flowDiagnosticSummary(diags))
+	}
+	if projectID := strings.TrimSpace(wf.ProjectID); projectID != "" {
+		ctx, err = workflowToolContext(ctx, a.cfg, userID, projectID)
+	} else {
+		ctx, err = a.withScratchDir(ctx, userID)
+	}
+	if err != nil {
+		return "", err
+	}
+	runID := a.flowV2State().createRun(userID, wf.ID, persist.WorkflowTriggerWebhook, input)
+	seconds := workflowLikeTimeout(a.cfg.WorkflowTimeoutSeconds, a.cfg.AgentRunTimeoutSeconds)
+	go func() {
+		runCtx, cancel, _ := withMaybeTimeout(ctx, seconds)
+		defer cancel()
+		a.executeFlowV2Run(runCtx, userID, runID, wf, plan, input)
+	}()
+	return runID, nil
+}
+
+func (a *app) startWebhookPrompt(ctx context.Context, hook *webhookTrigger, prompt string) (string, error) {
+	if res := a.guardrails.CheckPrompt(ctx, prompt); !res.Allowed {
+		return "", errWebhookRejected
+	}
+	owner := hook.owner()
+	ctx, err := a.withScratchDir(llm.WithUserID(ctx, owner), owner)
+	if err != nil {
+		return "", err
+	}
+	quotaLimits, err := a.runQuota(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+	releaseRun, err := a.runQueue.acquire(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	build := a.buildOrchestratorChatEngine(ctx, owner, "", "", nil)
+	if build.Err != nil {
+		releaseRun()
+		return "", errWebhookAgentDown
+	}
+	trackedCtx, releaseDrain, ok := a.drainer.track(ctx)
+	if !ok {
+		releaseRun()
+		return "", errShuttingDown
+	}
+	a.recordRun(ctx, owner, "webhook")
+	a.attachQuotaCheck(build.Engine, owner, quotaLimits)
+	run := a.runs.create("[hook:" + hook.cfg.ID + "] " + prompt)
+	go func() {
+		defer releaseRun()
+		defer releaseDrain()
+		runCtx, cancel, _ := withMaybeTimeout(trackedCtx, a.cfg.AgentRunTimeoutSeconds)
+		defer cancel()
+		runCtx, span := startRunSpan(runCtx, run.ID, "", &owner, "webhook")
+		defer span.End()
+		a.recordRunEvents(build.Engine, run.ID, &owner)
+		a.attachToolApprover(build.Engine, run.ID, &owner, nil, nil)
+		notifyDone := a.attachRunNotifications(build.Engine, run.ID, &owner, "hook:"+hook.cfg.ID, prompt)
+		result, err := build.Engine.Run(runCtx, prompt, nil)
+		status := "failed"
+		if err != nil && interruptedByShutdown(runCtx) {
+			err = fmt.Errorf("run interrupted: %w", errShuttingDown)
+			status = "interrupted"
+		}
+		notifyDone(result, err)
+		if err != nil {
+			failRunSpan(span, err)
+			log.Error().Err(err).Str("hook", hook.cfg.ID).Str("run_id", run.ID).Msg("webhook_run_failed")
+			a.runs.updateStatus(run.ID, status, 0)
+			return
+		}
+		a.runs.updateStatus(run.ID, "completed", 0)
+	}()
+	return run.ID, nil
+}
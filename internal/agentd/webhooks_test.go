@@ -0,0 +1,317 @@
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"manifold/internal/agent"
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/sandbox"
+	"manifold/internal/testhelpers"
+	"manifold/internal/tools"
+)
+
+func newWebhookTestApp(t *testing.T, hooks ...config.WebhookConfig) (*app, *[]map[string]any) {
+	t.Helper()
+	for i := range hooks {
+		if hooks[i].SignatureHeader == "" {
+			hooks[i].SignatureHeader = "X-Hub-Signature-256"
+		}
+	}
+	triggers, err := newWebhookTriggers(hooks)
+	if err != nil {
+		t.Fatalf("newWebhookTriggers: %v", err)
+	}
+	var calls []map[string]any
+	triggers.dispatch = func(_ context.Context, hook *webhookTrigger, attrs map[string]any) (string, error) {
+		if hook.prompt != nil {
+			prompt, err := hook.renderPrompt(attrs)
+			if err != nil {
+				return "", err
+			}
+			attrs = map[string]any{"prompt": prompt}
+		}
+		calls = append(calls, attrs)
+		return "run_hook", nil
+	}
+	return &app{cfg: &config.Config{}, webhooks: triggers}, &calls
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookSignatureAndPromptTemplate(t *testing.T) {
+	t.Parallel()
+
+	a, calls := newWebhookTestApp(t, config.WebhookConfig{
+		ID:         "github-pr",
+		Secret:     "s3cret",
+		Prompt:     "Review PR #{{.number}}: {{.title}}",
+		Attributes: map[string]string{"number": "{{.pull_request.number}}", "title": "{{.pull_request.title}}"},
+	})
+	handler := a.webhookHandler()
+	body := []byte(`{"pull_request":{"number":42,"title":"Fix flaky test"}}`)
+
+	for _, tc := range []struct {
+		name string
+		sig  string
+		code int
+	}{
+		{name: "missing", code: http.StatusUnauthorized},
+		{name: "wrong", sig: signWebhook("other", body), code: http.StatusUnauthorized},
+		{name: "valid", sig: signWebhook("s3cret", body), code: http.StatusAccepted},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/hooks/github-pr", bytes.NewReader(body))
+		if tc.sig != "" {
+			req.Header.Set("X-Hub-Signature-256", tc.sig)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatalf("%s signature: expected %d, got %d %s", tc.name, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+	if len(*calls) != 1 || (*calls)[0]["prompt"] != "Review PR #42: Fix flaky test" {
+		t.Fatalf("unexpected dispatches: %v", *calls)
+	}
+}
+
+func TestWebhookMissingAttributeAndUnknownHook(t *testing.T) {
+	t.Parallel()
+
+	a, calls := newWebhookTestApp(t, config.WebhookConfig{
+		ID:         "alerts",
+		Secret:     "s3cret",
+		Workflow:   "triage",
+		Attributes: map[string]string{"severity": "{{.labels.severity}}"},
+	})
+	handler := a.webhookHandler()
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", signWebhook("s3cret", []byte(body)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := post("/api/hooks/alerts", `{"labels":{"severity":"page"}}`)
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"run_id":"run_hook"`) {
+		t.Fatalf("expected accepted run, got %d %s", rec.Code, rec.Body.String())
+	}
+	if len(*calls) != 1 || (*calls)[0]["severity"] != "page" {
+		t.Fatalf("unexpected workflow input: %v", *calls)
+	}
+
+	rec = post("/api/hooks/alerts", `{"status":"firing"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for missing attribute, got %d", rec.Code)
+	}
+
+	rec = post("/api/hooks/nope", `{}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown hook, got %d", rec.Code)
+	}
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newWebhookTestApp(t, config.WebhookConfig{ID: "limited", Secret: "s3cret", Workflow: "wf", RateLimitPerMinute: 2})
+	limiter := a.webhooks.hooks["limited"].limiter
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+	handler := a.webhookHandler()
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/hooks/limited", nil)
+		req.Header.Set("X-Hub-Signature-256", signWebhook("s3cret", nil))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		if rec := post(); rec.Code != http.StatusAccepted {
+			t.Fatalf("delivery %d: expected 202, got %d", i, rec.Code)
+		}
+	}
+	rec := post()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "31" {
+		t.Fatalf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	now = now.Add(30 * time.Second)
+	if rec := post(); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected refill after 30s, got %d", rec.Code)
+	}
+}
+
+func TestNewWebhookTriggersValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, hooks := range [][]config.WebhookConfig{
+		{{ID: "", Secret: "s", Workflow: "wf"}},
+		{{ID: "a", Secret: "s", Workflow: "wf", Prompt: "p"}},
+		{{ID: "a", Secret: "s"}},
+		{{ID: "a", Secret: "s", Workflow: "wf"}, {ID: "a", Secret: "s", Workflow: "wf"}},
+		{{ID: "a", Secret: "s", Prompt: "{{.x"}},
+		{{ID: "a", Workflow: "wf"}},
+		{{ID: "a", Prompt: "p"}},
+	} {
+		if _, err := newWebhookTriggers(hooks); err == nil {
+			t.Fatalf("expected error for %+v", hooks)
+		}
+	}
+}
+
+func TestWebhookRejectsUnsignedRequest(t *testing.T) {
+	t.Parallel()
+
+	a, calls := newWebhookTestApp(t, config.WebhookConfig{ID: "alerts", Secret: "s3cret", Workflow: "triage"})
+	// A hook whose secret was cleared after validation must still refuse
+	// unsigned deliveries rather than skip verification.
+	a.webhooks.hooks["open"] = &webhookTrigger{cfg: config.WebhookConfig{ID: "open", Workflow: "triage", SignatureHeader: "X-Hub-Signature-256"}}
+	handler := a.webhookHandler()
+
+	for _, path := range []string{"/api/hooks/alerts", "/api/hooks/open"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`)))
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401 for unsigned request, got %d", path, rec.Code)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("unsigned request dispatched: %v", *calls)
+	}
+}
+
+func TestWebhookPromptRejectedWhenRunQueueFull(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newWebhookTestApp(t, config.WebhookConfig{ID: "alerts", Secret: "s3cret", Prompt: "Investigate the alert"})
+	a.webhooks.dispatch = a.dispatchWebhook
+	a.runs = newRunStore()
+	a.runQueue = newRunQueue(config.RunQueueConfig{MaxConcurrent: 1})
+	release, err := a.runQueue.acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	body := []byte(`{}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/hooks/alerts", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", signWebhook("s3cret", body))
+	rec := httptest.NewRecorder()
+	a.webhookHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// blockingProvider holds Chat until the run's context ends.
+type blockingProvider struct {
+	testhelpers.FakeProvider
+	started chan struct{}
+}
+
+func (p *blockingProvider) Chat(ctx context.Context, _ []llm.Message, _ []llm.ToolSchema, _ string) (llm.Message, error) {
+	close(p.started)
+	<-ctx.Done()
+	return llm.Message{}, ctx.Err()
+}
+
+func TestWebhookPromptRunsAreDrained(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newWebhookTestApp(t, config.WebhookConfig{ID: "alerts", Secret: "s3cret", Prompt: "Investigate the alert"})
+	a.webhooks.dispatch = a.dispatchWebhook
+	a.runs = newRunStore()
+	a.drainer = newRunDrainer()
+	provider := &blockingProvider{started: make(chan struct{})}
+	a.engine = &agent.Engine{LLM: provider, Tools: tools.NewRegistry(), MaxSteps: 1}
+
+	body := []byte(`{}`)
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/hooks/alerts", bytes.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", signWebhook("s3cret", body))
+		rec := httptest.NewRecorder()
+		a.webhookHandler().ServeHTTP(rec, req)
+		return rec
+	}
+	rec := post()
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %s", rec.Code, rec.Body.String())
+	}
+	var accepted struct {
+		RunID string `json:"run_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &accepted); err != nil {
+		t.Fatal(err)
+	}
+	<-provider.started
+
+	if n := a.drainer.drain(20*time.Millisecond, time.Second); n != 1 {
+		t.Fatalf("expected the webhook run to be cancelled by the drain, got %d", n)
+	}
+	if run, ok := a.runs.get(accepted.RunID); !ok || run.Status != "interrupted" {
+		t.Fatalf("expected an interrupted run, got %+v", run)
+	}
+	if rec := post(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// baseDirProvider reports the sandbox base dir the run's context carries.
+type baseDirProvider struct {
+	testhelpers.FakeProvider
+	dirs chan string
+}
+
+func (p *baseDirProvider) Chat(ctx context.Context, _ []llm.Message, _ []llm.ToolSchema, _ string) (llm.Message, error) {
+	dir, _ := sandbox.BaseDirFromContext(ctx)
+	p.dirs <- dir
+	return llm.Message{Role: "assistant", Content: "done"}, nil
+}
+
+func TestWebhookPromptUsesOwnerScratchDir(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newWebhookTestApp(t, config.WebhookConfig{ID: "alerts", Secret: "s3cret", Prompt: "Investigate the alert"})
+	a.cfg.Auth.Enabled = true
+	a.cfg.Workdir = t.TempDir()
+	a.webhooks.dispatch = a.dispatchWebhook
+	a.runs = newRunStore()
+	a.drainer = newRunDrainer()
+	provider := &baseDirProvider{dirs: make(chan string, 1)}
+	a.engine = &agent.Engine{LLM: provider, Tools: tools.NewRegistry(), MaxSteps: 1}
+
+	body := []byte(`{}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/hooks/alerts", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", signWebhook("s3cret", body))
+	rec := httptest.NewRecorder()
+	a.webhookHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case dir := <-provider.dirs:
+		if want := filepath.Join(a.cfg.Workdir, "users", "0", "scratch"); dir != want {
+			t.Fatalf("run base dir = %q, want %q", dir, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook run never reached the provider")
+	}
+	a.drainer.drain(20*time.Millisecond, time.Second)
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"manifold/internal/apierror"
 	"manifold/internal/version"
 )
 
@@ -120,12 +121,8 @@ func buildSpec(opts Options) map[string]any {
 					"type":                 "object",
 					"additionalProperties": true,
 				},
-				"Error": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"error": map[string]any{"type": "string"},
-					},
-				},
+				"Error":      errorSchema(),
+				"FieldError": fieldErrorSchema(),
 			},
 		},
 	}
@@ -158,6 +155,8 @@ func buildTags(tagSet map[string]struct{}) []map[string]any {
 		"Media":       "Audio and image media endpoints.",
 		"MCP":         "Model Context Protocol server management APIs.",
 		"Flow":        "Flow v2 APIs.",
+		"Tools":       "Macro tool management APIs.",
+		"Memory":      "Transit shared memory and RAG retrieval APIs.",
 		"Debug":       "Memory and observability debugging endpoints.",
 		"Playground":  "Prompt, dataset, and experiment playground APIs.",
 	}
@@ -174,6 +173,8 @@ func buildTags(tagSet map[string]struct{}) []map[string]any {
 		"Media",
 		"MCP",
 		"Flow",
+		"Tools",
+		"Memory",
 		"Debug",
 		"Playground",
 	}
@@ -318,7 +319,7 @@ func buildResponses(op operationSpec) map[string]any {
 	}
 
 	// Common error responses.
-	for _, code := range []int{400, 401, 403, 404, 500} {
+	for _, code := range []int{400, 401, 403, 404, 413, 429, 500, 503} {
 		key := strconv.Itoa(code)
 		if _, exists := responses[key]; exists {
 			continue
@@ -335,6 +336,52 @@ func buildResponses(op operationSpec) map[string]any {
 	return responses
 }
 
+// errorSchema describes the apierror.Error body shared by every error
+// response. The code enum and its description come from apierror.Codes so
+// the spec cannot drift from what handlers send.
+func errorSchema() map[string]any {
+	codes := make([]string, 0, len(apierror.Codes))
+	var table strings.Builder
+	table.WriteString("Machine-readable error code. Clients should branch on this, not on message.\n\n| code | status | meaning |\n| --- | --- | --- |\n")
+	for _, c := range apierror.Codes {
+		codes = append(codes, string(c.Code))
+		table.WriteString("| `" + string(c.Code) + "` | " + strconv.Itoa(c.Status) + " | " + c.Description + " |\n")
+	}
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"code", "message"},
+		"properties": map[string]any{
+			"code": map[string]any{
+				"type":        "string",
+				"enum":        codes,
+				"description": table.String(),
+			},
+			"message": map[string]any{
+				"type":        "string",
+				"description": "Human-readable description; wording may change between releases.",
+			},
+			"details": map[string]any{
+				"description": "Optional structured context. For validation_failed and invalid_json it is a list of FieldError.",
+				"oneOf": []any{
+					map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/FieldError"}},
+					map[string]any{"type": "object", "additionalProperties": true},
+				},
+			},
+		},
+	}
+}
+
+func fieldErrorSchema() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"field", "message"},
+		"properties": map[string]any{
+			"field":   map[string]any{"type": "string"},
+			"message": map[string]any{"type": "string"},
+		},
+	}
+}
+
 func operationID(method, path string) string {
 	name := strings.Trim(path, "/")
 	if name == "" {
@@ -408,7 +455,7 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodGet, "System", "Health check", false, withDescription("Simple liveness probe.")),
 		}},
 		{path: "/readyz", operations: []operationSpec{
-			jsonOp(http.MethodGet, "System", "Readiness check", false, withDescription("Simple readiness probe.")),
+			jsonOp(http.MethodGet, "System", "Readiness check", false, withDescription("Readiness probe. Returns 503 with per-dependency detail during startup, shutdown drain, or when a required dependency is unavailable.")),
 		}},
 		{path: "/openapi.json", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Docs", "OpenAPI spec", false, withDescription("OpenAPI JSON document for this server."), withSuccess(http.StatusOK)),
@@ -432,7 +479,7 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodGet, "Auth", "Logout", false, withDescription("Ends local session and may redirect to upstream IdP logout."), withSuccess(http.StatusFound), withResponseMode("none")),
 		}},
 		{path: "/api/me", operations: []operationSpec{
-			jsonOp(http.MethodGet, "Auth", "Current user profile", true),
+			jsonOp(http.MethodGet, "Auth", "Current user profile", true, withDescription("Returns email, name and picture. With quotas.enabled it also returns quota: the month's limits, used and remaining tokens, cost and runs (null remaining means unlimited), and resetsAt.")),
 		}},
 		{path: "/api/users", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Auth", "List users", true),
@@ -444,17 +491,74 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodDelete, "Auth", "Delete user", true, withResponseMode("none")),
 		}},
 		{path: "/api/status", operations: []operationSpec{
-			jsonOp(http.MethodGet, "System", "Specialist status", true),
+			jsonOp(http.MethodGet, "System", "Specialist status", true, withQuery(
+				qp("insights", "boolean", "Wrap the status list with today's tool, specialist, and token usage insights.", false),
+			)),
+		}},
+		{path: "/api/memories", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "List long-term memories", true, withQuery(
+				qp("limit", "integer", "Maximum memories to return, newest first.", false),
+			)),
+			jsonOp(http.MethodDelete, "Chat", "Forget all long-term memories", true, withSuccess(http.StatusOK)),
+		}},
+		{path: "/api/memories/{id}", operations: []operationSpec{
+			jsonOp(http.MethodDelete, "Chat", "Forget one long-term memory", true, withSuccess(http.StatusNoContent), withResponseMode("none")),
+		}},
+		{path: "/api/admin/vector/index", operations: []operationSpec{
+			jsonOp(http.MethodGet, "System", "Vector index health", true, withQuery(
+				qp("recall", "boolean", "Sample stored vectors and estimate index recall against an exact scan.", false),
+			)),
+		}},
+		{path: "/api/admin/vector/reindex", operations: []operationSpec{
+			jsonOp(http.MethodPost, "System", "Rebuild the vector ANN index", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Builds an ivfflat or hnsw index for the configured metric and swaps it in. Omitted fields default to databases.vector in the config.")),
+		}},
+		{path: "/api/secrets", operations: []operationSpec{
+			jsonOp(http.MethodGet, "System", "List stored secrets", true, withDescription("Admin only. Returns names, versions and timestamps; values are never returned.")),
+			jsonOp(http.MethodPost, "System", "Create or rotate a secret", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Admin only. Body: {\"name\": \"OPENAI_KEY\", \"value\": \"...\", \"domains\": [\"api.openai.com\"], \"userIds\": [1]}. The value is encrypted with secrets.masterKey. Specialist API keys, MCP server env/headers/tokens and http_request calls reference it as {{secret:NAME}}; domains is required, and the secret is sent only to those domains and only for runs, specialists and MCP servers of the listed users (MCP stdio env values go to localhost).")),
+		}},
+		{path: "/api/secrets/{name}", operations: []operationSpec{
+			jsonOp(http.MethodPut, "System", "Rotate a secret", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Admin only. Body: {\"value\": \"...\", \"domains\": [...], \"userIds\": [...]}. Replaces the value and scope and bumps the secret's version.")),
+			jsonOp(http.MethodDelete, "System", "Delete a secret", true, withSuccess(http.StatusNoContent), withResponseMode("none")),
 		}},
 		{path: "/api/runs", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Metrics", "List recent runs", true),
 		}},
+		{path: "/api/runs/{id}/context", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Metrics", "Inspect provider context for a run step", true, withQuery(
+				qp("step", "integer", "Zero-based step index; omit to list recorded steps.", false),
+			)),
+		}},
+		{path: "/api/runs/{id}/trace", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Metrics", "Inspect the span tree of a run", true, withDescription("Returns the spans this server recorded for the run (the agent.run root, LLM requests, tool calls, Postgres queries and outbound HTTP calls) nested by parent span. Every span carries run.id, session.id and enduser.id attributes. The most recent 500 runs are kept in memory.")),
+		}},
+		{path: "/api/runs/{id}/events", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "Resume a streamed run or read its timeline", true, withResponseMode("sse"), withDescription("Waits for a streamed run to finish and replays its closing events (final, error, handoff, policy_violation) after the Last-Event-ID header or last_event_id query value. Stream event ids have the form <run>:<seq>; the run id is also returned in the X-Run-ID header. Closing events stay available for 10 minutes after the run ends. Requests with Accept: application/json and no Last-Event-ID instead get the run's step timeline as JSON: {runId, status, events}, where each event has a type (llm_request, llm_response, tool_start, tool_result, summary_compaction), step, timestamp and, where they apply, token counts, tool name and duration."), withQuery(
+				qp("last_event_id", "string", "Resume point for clients that cannot send Last-Event-ID.", false),
+				qp("sse_schema", "integer", "Set to 1 for the typed event envelope.", false),
+			)),
+		}},
+		{path: "/api/runs/{id}/approvals", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "List pending tool approvals for a run", true),
+		}},
+		{path: "/api/runs/{id}/approvals/{toolCallID}", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Chat", "Approve or deny a paused tool call", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Body: {\"decision\": \"approve\"|\"deny\", \"reason\": \"...\"}. Tools listed in toolApproval.requiresApproval pause the run and emit a tool_approval_request event until decided; unanswered calls are denied after toolApproval.timeoutSeconds. Only the user who started the run, or a live session participant granted can_approve_tools, may decide.")),
+		}},
+		{path: "/api/artifacts/cli/{id}", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "Fetch full run_cli output", true, withResponseMode("binary"), withDescription("Returns the complete stdout or stderr, as text/plain, of a run_cli call whose output exceeded outputTruncateBytes. The tool result references it as stdout_artifact or stderr_artifact.")),
+		}},
 		{path: "/api/metrics/tokens", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Metrics", "Token usage metrics", true, withQuery(
 				qp("window", "string", "Lookback duration (e.g. 1h, 24h, 7d).", false),
 				qp("windowSeconds", "integer", "Lookback window in seconds.", false),
 			)),
 		}},
+		{path: "/api/metrics/usage", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Metrics", "Persisted token usage and cost estimates", true, withDescription("Returns the caller's token usage per UTC day and model, summed per model, with costs from the pricing table. Usage is stored in the database and survives restarts. Models without a price have a null cost and are left out of estimatedCost."), withQuery(
+				qp("since", "string", "First day, as YYYY-MM-DD or RFC 3339. Default: 30 days before until.", false),
+				qp("until", "string", "Last day (inclusive) as YYYY-MM-DD, or an RFC 3339 end time. Default: today.", false),
+				qp("model", "string", "Only report this model.", false),
+			)),
+		}},
 		{path: "/api/metrics/traces", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Metrics", "Trace metrics", true, withQuery(
 				qp("window", "string", "Lookback duration.", false),
@@ -476,7 +580,7 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodPatch, "System", "Patch runtime config", true, withRequestBody("json"), withSuccess(http.StatusOK)),
 		}},
 		{path: "/agent/run", operations: []operationSpec{
-			jsonOp(http.MethodPost, "Chat", "Run orchestrator agent", true, withRequestBody("json"), withSuccess(http.StatusOK), withResponseMode("sse"), withQuery(
+			jsonOp(http.MethodPost, "Chat", "Run orchestrator agent", true, withRequestBody("json"), withSuccess(http.StatusOK), withResponseMode("sse"), withDescription("Set engine_mode to \"plan_execute\" to plan the prompt as a step DAG, run the steps concurrently with critic review, and synthesize the answer; streams then emit engine_plan events. The default is \"react\". Each SSE event has an id of the form <run>:<seq>, and idle streams get a keepalive comment every 15 seconds. Send Accept: text/event-stream; schema=1 for the typed envelope {v, id, seq, type, payload} with event lines. Re-sending the request with Last-Event-ID replays the run's closing events instead of starting a new run. When runQueue.maxConcurrent runs are already going, the run waits in a queue and streams queued events with its position; a full queue or a timed-out wait returns 429 with code run_queue_full. A caller who has used up a monthly quota gets 429 with code quota_exceeded; a run that crosses its token or cost quota ends with that error before its next model request."), withQuery(
 				qp("specialist", "string", "Force a specific specialist.", false),
 				qp("team", "string", "Route the run through a team orchestrator.", false),
 				qp("group", "string", "Legacy alias of team.", false),
@@ -494,6 +598,12 @@ func routeCatalog() []routeSpec {
 		{path: "/stt", operations: []operationSpec{
 			jsonOp(http.MethodPost, "Media", "Speech-to-text transcription", true, withRequestBody("multipart"), withSuccess(http.StatusOK)),
 		}},
+		{path: "/stt/stream", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Media", "Live dictation WebSocket", true, withResponseMode("none"), withSuccess(http.StatusSwitchingProtocols), withDescription("Upgrades to a WebSocket. Send binary frames of 16 kHz mono 16-bit PCM and {\"type\":\"end\"} to finish; the server sends ready, speech_start, partial, final, and done events.")),
+		}},
+		{path: "/api/tts/providers", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Media", "List TTS providers and voices", true),
+		}},
 		{path: "/api/me/preferences", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Projects", "Get user preferences", true),
 			jsonOp(http.MethodPut, "Projects", "Update user preferences", true, withRequestBody("json"), withSuccess(http.StatusOK)),
@@ -531,6 +641,13 @@ func routeCatalog() []routeSpec {
 				qp("path", "string", "File path to remove.", true),
 			)),
 		}},
+		{path: "/api/projects/{project_id}/preview", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Projects", "Preview file (CSV rows, rendered markdown, image thumbnail, audio duration)", true, withQuery(
+				qp("path", "string", "File path within the project.", true),
+				qp("rows", "integer", "CSV rows to return (default 20, max 500).", false),
+				qp("thumb", "integer", "Longest thumbnail edge in pixels (default 256, max 1024).", false),
+			)),
+		}},
 		{path: "/api/projects/{project_id}/dirs", operations: []operationSpec{
 			jsonOp(http.MethodPost, "Projects", "Create directory", true, withSuccess(http.StatusCreated), withResponseMode("none"), withQuery(
 				qp("path", "string", "Directory path to create.", true),
@@ -539,6 +656,13 @@ func routeCatalog() []routeSpec {
 		{path: "/api/projects/{project_id}/move", operations: []operationSpec{
 			jsonOp(http.MethodPost, "Projects", "Move/rename path", true, withRequestBody("json"), withSuccess(http.StatusNoContent), withResponseMode("none")),
 		}},
+		{path: "/api/projects/{project_id}/env", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Projects", "List project environment variable names", true),
+			jsonOp(http.MethodPut, "Projects", "Set project environment variable", true, withRequestBody("json"), withSuccess(http.StatusNoContent), withResponseMode("none")),
+			jsonOp(http.MethodDelete, "Projects", "Delete project environment variable", true, withResponseMode("none"), withSuccess(http.StatusNoContent), withQuery(
+				qp("name", "string", "Variable name.", true),
+			)),
+		}},
 		{path: "/api/chat/sessions", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Chat", "List chat sessions", true),
 			jsonOp(http.MethodPost, "Chat", "Create chat session", true, withRequestBody("json"), withSuccess(http.StatusCreated)),
@@ -560,9 +684,34 @@ func routeCatalog() []routeSpec {
 		{path: "/api/chat/sessions/{session_id}/messages/{message_id}", operations: []operationSpec{
 			jsonOp(http.MethodDelete, "Chat", "Delete one chat message", true, withSuccess(http.StatusNoContent), withResponseMode("none")),
 		}},
+		{path: "/api/chat/sessions/{session_id}/messages/{message_id}/edit", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Chat", "Edit a user message and re-run", true, withRequestBody("json"), withDescription("Replaces the user message with content, removes it and every later message (or, with branch=true, forks the conversation before it), then re-runs the turn like /agent/run. The X-Chat-Session-ID header names the session the turn ran in.")),
+		}},
+		{path: "/api/chat/sessions/{session_id}/messages/{message_id}/regenerate", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Chat", "Regenerate a chat turn", true, withRequestBody("json"), withDescription("Rewinds to the user message that starts the turn containing message_id and re-runs it, truncating or (with branch=true) forking the conversation. The X-Chat-Session-ID header names the session the turn ran in.")),
+		}},
+		{path: "/api/chat/sessions/{session_id}/fork", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Chat", "Fork chat session", true, withRequestBody("json"), withSuccess(http.StatusCreated), withDescription("Copies the conversation up to and including message_id (or all of it) into a new session.")),
+		}},
 		{path: "/api/chat/sessions/{session_id}/title", operations: []operationSpec{
 			jsonOp(http.MethodPost, "Chat", "Generate/apply session title", true, withRequestBody("json"), withSuccess(http.StatusOK)),
 		}},
+		{path: "/api/chat/sessions/{session_id}/graph", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "Export session knowledge graph", true, withDescription("Evolving memory entries and the graph store neighbourhood of the session node, as JSON or Graphviz DOT."), withQuery(
+				qp("format", "string", "json (default) or dot.", false),
+				qp("depth", "integer", "Graph store traversal depth from the session node (0-4, default 2).", false),
+			)),
+		}},
+		{path: "/api/chat/sessions/{session_id}/live", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "Join a live collaborative session", true, withSuccess(http.StatusSwitchingProtocols), withResponseMode("none"), withDescription("Upgrades to a WebSocket shared by the session owner and invited users (liveSessions.enabled). Send {\"type\":\"prompt\",\"prompt\":\"...\"} to run a turn as the owner, attributed to the speaker, or {\"type\":\"approval\",\"run_id\",\"tool_call_id\",\"decision\"} to decide a paused tool call. Every participant receives the chat stream events plus user_message, participant_joined, and participant_left.")),
+		}},
+		{path: "/api/chat/sessions/{session_id}/participants", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Chat", "List live session participants", true, withDescription("Owner only. Lists the owner, invited users, and who is connected.")),
+		}},
+		{path: "/api/chat/sessions/{session_id}/participants/{user_id}", operations: []operationSpec{
+			jsonOp(http.MethodPut, "Chat", "Invite a live session participant", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Owner only. Body: {\"can_approve_tools\": bool}. Invitations are kept in memory until restart.")),
+			jsonOp(http.MethodDelete, "Chat", "Remove a live session participant", true, withSuccess(http.StatusNoContent), withResponseMode("none"), withDescription("Revokes the invitation and disconnects the user.")),
+		}},
 		{path: "/api/specialists/defaults", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Specialists", "Get provider defaults", true),
 		}},
@@ -602,12 +751,46 @@ func routeCatalog() []routeSpec {
 		{path: "/api/flows/v2/validate", operations: []operationSpec{
 			jsonOp(http.MethodPost, "Flow", "Validate Flow v2 workflow", true, withRequestBody("json"), withSuccess(http.StatusOK)),
 		}},
+		{path: "/api/warpp/validate", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Flow", "Dry-run workflow", true, withRequestBody("json"), withSuccess(http.StatusOK),
+				withDescription("Validates an inline workflow, or the saved one named by workflow_id, and simulates a run against the sample input without calling tools. Reports unknown tools, references to nodes that do not run first, unresolved expressions and unreachable steps, plus each node's expanded inputs.")),
+		}},
+		{path: "/api/warpp/run", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Flow", "Run workflow and wait", true, withRequestBody("json"), withSuccess(http.StatusOK), withResponseMode("sse"),
+				withDescription("Runs a saved workflow to completion. With Accept: text/event-stream, streams tool_start and tool_result events per step, as /agent/run does for tool calls, then a final event carrying the run result or an error event. Otherwise returns the run result as JSON.")),
+		}},
 		{path: "/api/flows/v2/run", operations: []operationSpec{
-			jsonOp(http.MethodPost, "Flow", "Start Flow v2 run", true, withRequestBody("json"), withSuccess(http.StatusAccepted)),
+			jsonOp(http.MethodPost, "Flow", "Start Flow v2 run", true, withRequestBody("json"), withSuccess(http.StatusAccepted),
+				withDescription("Starts the run asynchronously. With dry_run set, nothing is executed and the dry-run report is returned with 200 instead.")),
 		}},
 		{path: "/api/flows/v2/runs/{run_id}/events", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Flow", "Get or stream Flow v2 run events", true, withSuccess(http.StatusOK), withResponseMode("sse")),
 		}},
+		{path: "/api/workflow-runs", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Flow", "List workflow run history", true, withDescription("Returns {runs} newest first. Every Flow v2 run is recorded, whether started through the API, WARPP, a webhook or an agent tool, with its input, per-node steps, status and duration. Runs are kept in Postgres when databases.defaultDSN is set, otherwise in memory."), withQuery(
+				qp("workflow_id", "string", "Only runs of this workflow.", false),
+				qp("status", "string", "running, completed, failed or cancelled.", false),
+				qp("trigger", "string", "api, warpp, webhook or tool.", false),
+				qp("since", "string", "RFC 3339 time; runs started at or after it.", false),
+				qp("until", "string", "RFC 3339 time; runs started before it.", false),
+				qp("limit", "integer", "Maximum runs to return (default 50, max 500).", false),
+			)),
+		}},
+		{path: "/api/workflow-runs/{run_id}", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Flow", "Get a workflow run record", true),
+		}},
+		{path: "/api/tools/macros", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Tools", "List macro tools", true),
+			jsonOp(http.MethodPost, "Tools", "Create or replace a macro tool", true, withRequestBody("json"), withSuccess(http.StatusOK), withDescription("Admin only. Body matches a macroTools config entry: {name, description, parameters, steps: [{id, tool, args}], output}. String args are text/templates over .args and .steps; {{json ...}} keeps structured values. Runtime changes last until restart.")),
+		}},
+		{path: "/api/tools/macros/{name}", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Tools", "Get a macro tool", true),
+			jsonOp(http.MethodPut, "Tools", "Create or replace a macro tool by name", true, withRequestBody("json"), withSuccess(http.StatusOK)),
+			jsonOp(http.MethodDelete, "Tools", "Delete a macro tool", true, withSuccess(http.StatusNoContent), withResponseMode("none")),
+		}},
+		{path: "/api/hooks/{hookID}", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Flow", "Trigger a configured webhook", false, withRequestBody("json"), withSuccess(http.StatusAccepted), withDescription("Renders the hook's attribute templates against the JSON body, then starts its workflow with the attributes as input or sends its rendered prompt to the orchestrator. Hooks with a secret require an HMAC-SHA256 hex digest of the body in their signature header (default X-Hub-Signature-256, \"sha256=\" prefix optional). Returns 429 with Retry-After past rateLimitPerMinute.")),
+		}},
 		{path: "/api/mcp/servers", operations: []operationSpec{
 			jsonOp(http.MethodGet, "MCP", "List MCP servers", true),
 			jsonOp(http.MethodPost, "MCP", "Create MCP server", true, withRequestBody("json"), withSuccess(http.StatusCreated)),
@@ -616,12 +799,65 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodPut, "MCP", "Update MCP server", true, withRequestBody("json"), withSuccess(http.StatusOK)),
 			jsonOp(http.MethodDelete, "MCP", "Delete MCP server", true, withSuccess(http.StatusNoContent), withResponseMode("none")),
 		}},
+		{path: "/api/mcp/resources", operations: []operationSpec{
+			jsonOp(http.MethodGet, "MCP", "List or read MCP resources", true, withQuery(
+				qp("server", "string", "Server to read from; required with uri.", false),
+				qp("uri", "string", "Resource URI to read. Without it, resources of all connected servers are listed.", false),
+			)),
+		}},
+		{path: "/api/mcp/prompts", operations: []operationSpec{
+			jsonOp(http.MethodGet, "MCP", "List or render MCP prompts", true, withDescription("Prompts are referenced as mcp://<server>/prompts/<name>, with prompt arguments as query parameters. A specialist system prompt or a workflow input holding such a reference on a line of its own receives the rendered prompt."), withQuery(
+				qp("uri", "string", "Prompt reference to render. Without it, prompts of all connected servers are listed.", false),
+			)),
+		}},
 		{path: "/api/mcp/oauth/start", operations: []operationSpec{
 			jsonOp(http.MethodPost, "MCP", "Start MCP OAuth flow", true, withRequestBody("json"), withSuccess(http.StatusOK)),
 		}},
 		{path: "/api/mcp/oauth/callback", operations: []operationSpec{
 			jsonOp(http.MethodGet, "MCP", "MCP OAuth callback", false, withResponseMode("html"), withSuccess(http.StatusOK)),
 		}},
+		{path: "/api/mcp/oauth/bootstrap", operations: []operationSpec{
+			jsonOp(http.MethodGet, "MCP", "Start MCP OAuth flow by redirect", false, withDescription("Redirects to the MCP server's authorization page. Only available when auth is disabled."), withSuccess(http.StatusFound), withResponseMode("none"), withQuery(
+				qp("serverId", "integer", "MCP server ID.", true),
+			)),
+		}},
+		{path: "/api/rag/query", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Memory", "Query the RAG index", true, withDescription("Additional query parameters named filter.<key> become metadata filters."), withQuery(
+				qp("q", "string", "Query text.", true),
+				qp("k", "integer", "Number of results (1-100).", false),
+				qp("tenant", "string", "Tenant to search.", false),
+			)),
+		}},
+		{path: "/api/transit/memories", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Memory", "Get transit memories by key", true, withQuery(
+				qp("keys", "string", "Comma-separated keys; key may also be repeated.", false),
+			)),
+			jsonOp(http.MethodPost, "Memory", "Create transit memories", true, withRequestBody("json")),
+			jsonOp(http.MethodDelete, "Memory", "Delete transit memories by key", true, withSuccess(http.StatusOK), withQuery(
+				qp("keys", "string", "Comma-separated keys; key may also be repeated.", false),
+			)),
+		}},
+		{path: "/api/transit/memories/{key}", operations: []operationSpec{
+			jsonOp(http.MethodPut, "Memory", "Update a transit memory", true, withRequestBody("json")),
+		}},
+		{path: "/api/transit/keys", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Memory", "List transit keys", true, withQuery(
+				qp("prefix", "string", "Key prefix.", false),
+				qp("limit", "integer", "Maximum number of keys.", false),
+			)),
+		}},
+		{path: "/api/transit/recent", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Memory", "List recently updated transit memories", true, withQuery(
+				qp("prefix", "string", "Key prefix.", false),
+				qp("limit", "integer", "Maximum number of memories.", false),
+			)),
+		}},
+		{path: "/api/transit/search", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Memory", "Search transit memories", true, withRequestBody("json"), withSuccess(http.StatusOK)),
+		}},
+		{path: "/api/transit/discover", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Memory", "Discover related transit memories", true, withRequestBody("json"), withSuccess(http.StatusOK)),
+		}},
 		{path: "/debug/memory", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Debug", "Memory debug root", true),
 		}},
@@ -685,10 +921,16 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodGet, "Playground", "List prompt versions", false),
 			jsonOp(http.MethodPost, "Playground", "Create prompt version", false, withRequestBody("json"), withSuccess(http.StatusCreated)),
 		}},
+		{path: "/api/v1/playground/prompts/{promptID}/versions/{versionID}/deploy", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Playground", "Deploy prompt version after its eval gate passes", false, withSuccess(http.StatusOK)),
+		}},
 		{path: "/api/v1/playground/datasets", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Playground", "List datasets", false),
 			jsonOp(http.MethodPost, "Playground", "Create dataset", false, withRequestBody("json"), withSuccess(http.StatusCreated)),
 		}},
+		{path: "/api/v1/playground/datasets/import", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Playground", "Import dataset from CSV, JSONL or a chat session", false, withRequestBody("multipart"), withSuccess(http.StatusCreated), withDescription("Multipart: a JSON \"spec\" part ({dataset, format, mapping, skipInvalid}) followed by a \"file\" part holding CSV or JSONL. Send application/json ({dataset, sessionId, inputKey, includeHistory, split}) to import a chat session instead.")),
+		}},
 		{path: "/api/v1/playground/datasets/{datasetID}", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Playground", "Get dataset", false),
 			jsonOp(http.MethodPut, "Playground", "Update dataset", false, withRequestBody("json"), withSuccess(http.StatusOK)),
@@ -706,6 +948,12 @@ func routeCatalog() []routeSpec {
 			jsonOp(http.MethodGet, "Playground", "List experiment runs", false),
 			jsonOp(http.MethodPost, "Playground", "Start experiment run", false, withSuccess(http.StatusAccepted)),
 		}},
+		{path: "/api/v1/playground/experiments/{experimentID}/resume", operations: []operationSpec{
+			jsonOp(http.MethodPost, "Playground", "Resume interrupted experiment run", false, withSuccess(http.StatusAccepted)),
+		}},
+		{path: "/api/v1/playground/experiments/{experimentID}/report", operations: []operationSpec{
+			jsonOp(http.MethodGet, "Playground", "Get experiment report", false),
+		}},
 		{path: "/api/v1/playground/runs/{runID}/results", operations: []operationSpec{
 			jsonOp(http.MethodGet, "Playground", "List run results", false),
 		}},
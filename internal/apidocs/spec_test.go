@@ -2,10 +2,18 @@ package apidocs
 
 import (
 	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"manifold/internal/apierror"
 )
 
 func TestGenerateSpecJSONIncludesCoreEndpoints(t *testing.T) {
@@ -78,3 +86,96 @@ func TestGenerateSpecJSONAddsCookieAuthWhenEnabled(t *testing.T) {
 	_, hasSecurity = healthGet["security"]
 	assert.False(t, hasSecurity, "public endpoint should not include security requirements")
 }
+
+func TestGenerateSpecJSONDocumentsErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	data, err := GenerateSpecJSON(Options{})
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	errSchema, ok := schemas["Error"].(map[string]any)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []any{"code", "message"}, errSchema["required"])
+	code := errSchema["properties"].(map[string]any)["code"].(map[string]any)
+	enum, ok := code["enum"].([]any)
+	require.True(t, ok)
+	require.Len(t, enum, len(apierror.Codes))
+	assert.Contains(t, enum, string(apierror.ValidationFailed))
+	assert.Contains(t, enum, string(apierror.SpecialistBusy))
+
+	runPost := doc["paths"].(map[string]any)["/agent/run"].(map[string]any)["post"].(map[string]any)
+	responses := runPost["responses"].(map[string]any)
+	require.Contains(t, responses, "429")
+	require.Contains(t, responses, "503")
+}
+
+// registeredRoutes returns the mux patterns passed to Handle/HandleFunc in
+// the non-test Go files of dir.
+func registeredRoutes(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	require.NoError(t, err)
+	var routes []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, nil, 0)
+		require.NoError(t, err)
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "HandleFunc" && sel.Sel.Name != "Handle") {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				pattern, err := strconv.Unquote(lit.Value)
+				require.NoError(t, err)
+				routes = append(routes, pattern)
+			}
+			return true
+		})
+	}
+	require.NotEmpty(t, routes, "no routes found in %s", dir)
+	return routes
+}
+
+func TestSpecCoversRegisteredRoutes(t *testing.T) {
+	t.Parallel()
+
+	paths := buildSpec(Options{})["paths"].(map[string]any)
+
+	// agentd registers bare paths. Subtree routes and mounted handlers such
+	// as the playground have their operations documented below the prefix.
+	for _, route := range registeredRoutes(t, "../agentd") {
+		if _, ok := paths[route]; ok {
+			continue
+		}
+		prefix := strings.TrimSuffix(route, "/")
+		found := false
+		for p := range paths {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found, "agentd route %q is missing from the OpenAPI spec", route)
+	}
+
+	// The playground API registers "METHOD /path" patterns.
+	for _, route := range registeredRoutes(t, "../httpapi") {
+		method, path, ok := strings.Cut(route, " ")
+		require.True(t, ok, "playground route %q has no method", route)
+		item, ok := paths[path].(map[string]any)
+		if assert.True(t, ok, "playground path %q is missing from the OpenAPI spec", path) {
+			assert.Contains(t, item, strings.ToLower(method), "playground route %q is missing from the OpenAPI spec", route)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package apierror defines the JSON error format returned by agentd
+// handlers and a request decoder that reports problems in it.
+//
+// Every error response has the shape {"code", "message", "details"}.
+// Clients branch on code; message is for humans and may change.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Code is a stable, machine-readable error identifier.
+type Code string
+
+const (
+	BadRequest       Code = "bad_request"
+	InvalidJSON      Code = "invalid_json"
+	ValidationFailed Code = "validation_failed"
+	Unauthorized     Code = "unauthorized"
+	Forbidden        Code = "forbidden"
+	NotFound         Code = "not_found"
+	MethodNotAllowed Code = "method_not_allowed"
+	Conflict         Code = "conflict"
+	PreconditionFail Code = "precondition_failed"
+	PayloadTooLarge  Code = "payload_too_large"
+	UnsupportedMedia Code = "unsupported_media_type"
+	Unprocessable    Code = "unprocessable"
+	RateLimited      Code = "rate_limited"
+	SpecialistBusy   Code = "specialist_busy"
+	RunQueueFull     Code = "run_queue_full"
+	QuotaExceeded    Code = "quota_exceeded"
+	Internal         Code = "internal_error"
+	NotImplemented   Code = "not_implemented"
+	Upstream         Code = "upstream_error"
+	Unavailable      Code = "unavailable"
+	ShuttingDown     Code = "shutting_down"
+	Timeout          Code = "timeout"
+)
+
+// CodeInfo documents a code for the OpenAPI spec.
+type CodeInfo struct {
+	Code        Code
+	Status      int
+	Description string
+}
+
+// Codes lists every error code with its usual HTTP status.
+var Codes = []CodeInfo{
+	{BadRequest, http.StatusBadRequest, "The request is malformed, e.g. a missing or invalid query parameter."},
+	{InvalidJSON, http.StatusBadRequest, "The request body is not valid JSON or has a field of the wrong type."},
+	{ValidationFailed, http.StatusBadRequest, "The request body failed validation; details lists the fields."},
+	{Unauthorized, http.StatusUnauthorized, "No valid session or token was sent."},
+	{Forbidden, http.StatusForbidden, "The caller may not access this resource."},
+	{NotFound, http.StatusNotFound, "The resource does not exist or is not visible to the caller."},
+	{MethodNotAllowed, http.StatusMethodNotAllowed, "The endpoint does not support this HTTP method."},
+	{Conflict, http.StatusConflict, "The request conflicts with the current state, e.g. a duplicate name."},
+	{PreconditionFail, http.StatusPreconditionFailed, "A required check did not pass, e.g. a workflow eval gate; details has the result."},
+	{PayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body exceeds the endpoint's size limit."},
+	{UnsupportedMedia, http.StatusUnsupportedMediaType, "The uploaded content type is not supported."},
+	{Unprocessable, http.StatusUnprocessableEntity, "The request is well-formed but cannot be processed."},
+	{RateLimited, http.StatusTooManyRequests, "Too many requests; retry after the Retry-After delay."},
+	{SpecialistBusy, http.StatusServiceUnavailable, "The specialist is at its concurrency limit; retry later."},
+	{RunQueueFull, http.StatusTooManyRequests, "The server is running its maximum number of agent runs and the queue is full or the wait timed out; retry after the Retry-After delay."},
+	{QuotaExceeded, http.StatusTooManyRequests, "The caller has used up a monthly token, cost or run quota; details has the usage, limits and reset time."},
+	{Internal, http.StatusInternalServerError, "An unexpected server error."},
+	{NotImplemented, http.StatusNotImplemented, "The feature is not supported by the configured backend."},
+	{Upstream, http.StatusBadGateway, "An upstream service such as an LLM provider or MCP server failed."},
+	{Unavailable, http.StatusServiceUnavailable, "A required subsystem is disabled or not ready."},
+	{ShuttingDown, http.StatusServiceUnavailable, "The server is draining for shutdown; retry against another replica."},
+	{Timeout, http.StatusGatewayTimeout, "The operation did not finish in time."},
+}
+
+// Error is the JSON body of an error response.
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// FieldError describes one invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// CodeForStatus returns the generic code for an HTTP status.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusPreconditionFailed:
+		return PreconditionFail
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMedia
+	case http.StatusUnprocessableEntity:
+		return Unprocessable
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusNotImplemented:
+		return NotImplemented
+	case http.StatusBadGateway:
+		return Upstream
+	case http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusGatewayTimeout:
+		return Timeout
+	}
+	if status >= 500 {
+		return Internal
+	}
+	return BadRequest
+}
+
+// Respond writes an error with the generic code for status.
+func Respond(w http.ResponseWriter, status int, message string) {
+	RespondCode(w, status, CodeForStatus(status), message, nil)
+}
+
+// RespondCode writes an error with an explicit code and optional details.
+func RespondCode(w http.ResponseWriter, status int, code Code, message string, details any) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Error{Code: code, Message: strings.TrimSpace(message), Details: details})
+}
+
+// Validator is implemented by request bodies that check their own fields
+// after decoding.
+type Validator interface {
+	Validate() []FieldError
+}
+
+// RespondInvalid writes a validation_failed error for a single field.
+func RespondInvalid(w http.ResponseWriter, field, message string) {
+	RespondCode(w, http.StatusBadRequest, ValidationFailed, field+": "+message, []FieldError{{Field: field, Message: message}})
+}
+
+// Decode reads a JSON body into dst, limited to maxBytes when positive, and
+// runs dst's Validate method if it has one. On failure it writes the error
+// response and returns false.
+func Decode(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) bool {
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	if err := json.NewDecoder(body).Decode(dst); err != nil {
+		RespondDecodeError(w, err)
+		return false
+	}
+	return validate(w, dst)
+}
+
+func validate(w http.ResponseWriter, dst any) bool {
+	v, ok := dst.(Validator)
+	if !ok {
+		return true
+	}
+	if errs := v.Validate(); len(errs) > 0 {
+		RespondCode(w, http.StatusBadRequest, ValidationFailed, errs[0].Field+": "+errs[0].Message, errs)
+		return false
+	}
+	return true
+}
+
+// DecodeOptional is Decode for endpoints where the body may be omitted; an
+// empty body leaves dst unchanged.
+func DecodeOptional(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) bool {
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	if err := json.NewDecoder(body).Decode(dst); err != nil && !errors.Is(err, io.EOF) {
+		RespondDecodeError(w, err)
+		return false
+	}
+	return validate(w, dst)
+}
+
+// RespondDecodeError writes the error for a failed JSON decode.
+func RespondDecodeError(w http.ResponseWriter, err error) {
+	var (
+		tooLarge *http.MaxBytesError
+		syntax   *json.SyntaxError
+		typeErr  *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		RespondCode(w, http.StatusRequestEntityTooLarge, PayloadTooLarge,
+			fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), nil)
+	case errors.Is(err, io.EOF):
+		RespondCode(w, http.StatusBadRequest, InvalidJSON, "request body is empty", nil)
+	case errors.As(err, &syntax), errors.Is(err, io.ErrUnexpectedEOF):
+		var details any
+		if syntax != nil {
+			details = map[string]any{"offset": syntax.Offset}
+		}
+		RespondCode(w, http.StatusBadRequest, InvalidJSON, "malformed JSON", details)
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "(body)"
+		}
+		RespondCode(w, http.StatusBadRequest, InvalidJSON,
+			fmt.Sprintf("%s: expected %s", field, typeErr.Type), []FieldError{{Field: field, Message: "expected " + typeErr.Type.String()}})
+	default:
+		RespondCode(w, http.StatusBadRequest, InvalidJSON, "invalid request body", nil)
+	}
+}
@@ -0,0 +1,146 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createReq struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (r createReq) Validate() []FieldError {
+	if strings.TrimSpace(r.Name) == "" {
+		return []FieldError{{Field: "name", Message: "required"}}
+	}
+	return nil
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) Error {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content type = %q", ct)
+	}
+	var e Error
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("decode error body %q: %v", rec.Body.String(), err)
+	}
+	return e
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		body   string
+		limit  int64
+		status int
+		code   Code
+	}{
+		{"ok", `{"name":"a","count":1}`, 0, 0, ""},
+		{"empty", ``, 0, http.StatusBadRequest, InvalidJSON},
+		{"malformed", `{"name":`, 0, http.StatusBadRequest, InvalidJSON},
+		{"syntax", `{"name" "a"}`, 0, http.StatusBadRequest, InvalidJSON},
+		{"wrong type", `{"name":"a","count":"x"}`, 0, http.StatusBadRequest, InvalidJSON},
+		{"too large", `{"name":"aaaaaaaaaaaaaaaa"}`, 8, http.StatusRequestEntityTooLarge, PayloadTooLarge},
+		{"invalid", `{"name":" "}`, 0, http.StatusBadRequest, ValidationFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			var dst createReq
+			ok := Decode(rec, req, &dst, tc.limit)
+			if tc.code == "" {
+				if !ok || dst.Name != "a" || dst.Count != 1 {
+					t.Fatalf("expected success, got ok=%v dst=%+v body=%q", ok, dst, rec.Body.String())
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("expected failure for %q", tc.body)
+			}
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if e := decodeError(t, rec); e.Code != tc.code || e.Message == "" {
+				t.Fatalf("unexpected error %+v", e)
+			}
+		})
+	}
+}
+
+func TestDecodeReportsFieldDetails(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a","count":"x"}`))
+	Decode(rec, req, &createReq{}, 0)
+	var body struct {
+		Details []FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Details) != 1 || body.Details[0].Field != "count" {
+		t.Fatalf("expected count field error, got %+v", body.Details)
+	}
+}
+
+func TestDecodeOptionalAllowsEmptyBody(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	dst := struct {
+		Limit int `json:"limit"`
+	}{Limit: 5}
+	if !DecodeOptional(rec, req, &dst, 0) || dst.Limit != 5 {
+		t.Fatalf("expected empty body to be accepted, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{"))
+	if DecodeOptional(rec, req, &dst, 0) || rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected malformed body to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestRespondUsesCodeForStatus(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	Respond(rec, http.StatusNotFound, "project not found\n")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if e := decodeError(t, rec); e.Code != NotFound || e.Message != "project not found" || e.Details != nil {
+		t.Fatalf("unexpected error %+v", e)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("nosniff header = %q", got)
+	}
+}
+
+func TestCodesCoverStatusMapping(t *testing.T) {
+	t.Parallel()
+
+	known := map[Code]bool{}
+	for _, c := range Codes {
+		if known[c.Code] {
+			t.Fatalf("duplicate code %q", c.Code)
+		}
+		known[c.Code] = true
+	}
+	for status := 400; status < 600; status++ {
+		if c := CodeForStatus(status); !known[c] {
+			t.Fatalf("CodeForStatus(%d) = %q, which is not documented in Codes", status, c)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"manifold/internal/persistence/sqlite"
+)
+
+// authDB abstracts the Postgres pool and a database/sql SQLite handle so the
+// Store queries are written once in the Postgres dialect.
+type authDB interface {
+	authConn
+	Begin(ctx context.Context) (authTx, error)
+	// Schema translates shared DDL for the backend.
+	Schema(ddl string) string
+	// ErrNoRows is the backend's "no rows" sentinel.
+	ErrNoRows() error
+}
+
+type authConn interface {
+	Exec(ctx context.Context, query string, args ...any) error
+	QueryRow(ctx context.Context, query string, args ...any) authRow
+	Query(ctx context.Context, query string, args ...any) (authRows, error)
+}
+
+type authTx interface {
+	authConn
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+type authRow interface {
+	Scan(dest ...any) error
+}
+
+type authRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close()
+}
+
+// --- pgx ---
+
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type pgxConn struct{ q pgxQuerier }
+
+func (c pgxConn) Exec(ctx context.Context, query string, args ...any) error {
+	_, err := c.q.Exec(ctx, query, args...)
+	return err
+}
+
+func (c pgxConn) QueryRow(ctx context.Context, query string, args ...any) authRow {
+	return c.q.QueryRow(ctx, query, args...)
+}
+
+func (c pgxConn) Query(ctx context.Context, query string, args ...any) (authRows, error) {
+	return c.q.Query(ctx, query, args...)
+}
+
+type pgxDB struct {
+	pgxConn
+	pool *pgxpool.Pool
+}
+
+func (d pgxDB) Begin(ctx context.Context) (authTx, error) {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxTx{pgxConn: pgxConn{q: tx}, tx: tx}, nil
+}
+
+func (pgxDB) Schema(ddl string) string { return ddl }
+func (pgxDB) ErrNoRows() error         { return pgx.ErrNoRows }
+
+type pgxTx struct {
+	pgxConn
+	tx pgx.Tx
+}
+
+func (t pgxTx) Commit(ctx context.Context) error   { return t.tx.Commit(ctx) }
+func (t pgxTx) Rollback(ctx context.Context) error { return t.tx.Rollback(ctx) }
+
+// --- database/sql (SQLite) ---
+
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type sqliteConn struct{ q sqlQuerier }
+
+func (c sqliteConn) Exec(ctx context.Context, query string, args ...any) error {
+	_, err := c.q.ExecContext(ctx, sqlite.Rebind(query), args...)
+	return err
+}
+
+func (c sqliteConn) QueryRow(ctx context.Context, query string, args ...any) authRow {
+	return c.q.QueryRowContext(ctx, sqlite.Rebind(query), args...)
+}
+
+func (c sqliteConn) Query(ctx context.Context, query string, args ...any) (authRows, error) {
+	rows, err := c.q.QueryContext(ctx, sqlite.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	return sqlRows{rows}, nil
+}
+
+type sqliteDB struct {
+	sqliteConn
+	db *sql.DB
+}
+
+func (d sqliteDB) Begin(ctx context.Context) (authTx, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sqliteTx{sqliteConn: sqliteConn{q: tx}, tx: tx}, nil
+}
+
+func (sqliteDB) Schema(ddl string) string { return sqlite.Schema(ddl) }
+func (sqliteDB) ErrNoRows() error         { return sql.ErrNoRows }
+
+type sqliteTx struct {
+	sqliteConn
+	tx *sql.Tx
+}
+
+func (t sqliteTx) Commit(context.Context) error   { return t.tx.Commit() }
+func (t sqliteTx) Rollback(context.Context) error { return t.tx.Rollback() }
+
+// sqlRows adapts *sql.Rows, whose Close returns an error.
+type sqlRows struct{ *sql.Rows }
+
+func (r sqlRows) Close() { _ = r.Rows.Close() }
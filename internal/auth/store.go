@@ -3,31 +3,39 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
 	"errors"
 	"strings"
 	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Store provides user/session persistence and RBAC checks.
 type Store struct {
-	pool       *pgxpool.Pool
+	db         authDB
 	sessionTTL time.Duration
 }
 
 func NewStore(pool *pgxpool.Pool, sessionTTLHours int) *Store {
+	return newStore(pgxDB{pgxConn: pgxConn{q: pool}, pool: pool}, sessionTTLHours)
+}
+
+// NewSQLiteStore returns a Store backed by a SQLite database opened with
+// sqlite.Open.
+func NewSQLiteStore(db *sql.DB, sessionTTLHours int) *Store {
+	return newStore(sqliteDB{sqliteConn: sqliteConn{q: db}, db: db}, sessionTTLHours)
+}
+
+func newStore(db authDB, sessionTTLHours int) *Store {
 	if sessionTTLHours <= 0 {
 		sessionTTLHours = 72
 	}
-	return &Store{pool: pool, sessionTTL: time.Duration(sessionTTLHours) * time.Hour}
+	return &Store{db: db, sessionTTL: time.Duration(sessionTTLHours) * time.Hour}
 }
 
-// InitSchema creates required auth tables if they do not exist.
-func (s *Store) InitSchema(ctx context.Context) error {
-	_, err := s.pool.Exec(ctx, `
+const authSchema = `
 CREATE TABLE IF NOT EXISTS users (
   id BIGSERIAL PRIMARY KEY,
   email TEXT UNIQUE NOT NULL,
@@ -55,12 +63,18 @@ CREATE TABLE IF NOT EXISTS sessions (
 	id_token TEXT NOT NULL DEFAULT '',
   created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
-`)
-	if err != nil {
+`
+
+// InitSchema creates required auth tables if they do not exist.
+func (s *Store) InitSchema(ctx context.Context) error {
+	if err := s.db.Exec(ctx, s.db.Schema(authSchema)); err != nil {
 		return err
 	}
+	if _, ok := s.db.(sqliteDB); ok {
+		return nil
+	}
 	// Ensure id_token column exists on sessions for RP-initiated logout
-	_, _ = s.pool.Exec(ctx, `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id_token TEXT NOT NULL DEFAULT ''`)
+	_ = s.db.Exec(ctx, `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id_token TEXT NOT NULL DEFAULT ''`)
 	return nil
 }
 
@@ -68,8 +82,7 @@ CREATE TABLE IF NOT EXISTS sessions (
 func (s *Store) EnsureDefaultRoles(ctx context.Context) error {
 	roles := []string{"admin", "user"}
 	for _, name := range roles {
-		_, err := s.pool.Exec(ctx, `INSERT INTO roles(name) VALUES($1) ON CONFLICT (name) DO NOTHING`, name)
-		if err != nil {
+		if err := s.db.Exec(ctx, `INSERT INTO roles(name) VALUES($1) ON CONFLICT (name) DO NOTHING`, name); err != nil {
 			return err
 		}
 	}
@@ -81,7 +94,7 @@ func (s *Store) UpsertUser(ctx context.Context, u *User) (*User, error) {
 	if u.Email == "" || u.Provider == "" || u.Subject == "" {
 		return nil, errors.New("missing required user fields")
 	}
-	row := s.pool.QueryRow(ctx, `
+	row := s.db.QueryRow(ctx, `
 INSERT INTO users(email, name, picture, provider, subject)
 VALUES ($1,$2,$3,$4,$5)
 ON CONFLICT (email) DO UPDATE SET
@@ -99,18 +112,17 @@ RETURNING id, created_at, updated_at
 // AddRole assigns a role to a user.
 func (s *Store) AddRole(ctx context.Context, userID int64, roleName string) error {
 	var roleID int64
-	err := s.pool.QueryRow(ctx, `SELECT id FROM roles WHERE name=$1`, roleName).Scan(&roleID)
+	err := s.db.QueryRow(ctx, `SELECT id FROM roles WHERE name=$1`, roleName).Scan(&roleID)
 	if err != nil {
 		return err
 	}
-	_, err = s.pool.Exec(ctx, `INSERT INTO user_roles(user_id, role_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, userID, roleID)
-	return err
+	return s.db.Exec(ctx, `INSERT INTO user_roles(user_id, role_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, userID, roleID)
 }
 
 // HasRole returns true if the user has the given role.
 func (s *Store) HasRole(ctx context.Context, userID int64, roleName string) (bool, error) {
 	var exists bool
-	err := s.pool.QueryRow(ctx, `
+	err := s.db.QueryRow(ctx, `
 SELECT EXISTS (
   SELECT 1
   FROM user_roles ur
@@ -123,7 +135,7 @@ SELECT EXISTS (
 
 // RolesForUser returns a list of role names for the given user.
 func (s *Store) RolesForUser(ctx context.Context, userID int64) ([]string, error) {
-	rows, err := s.pool.Query(ctx, `
+	rows, err := s.db.Query(ctx, `
 SELECT r.name
 FROM user_roles ur
 JOIN roles r ON r.id = ur.role_id
@@ -147,7 +159,7 @@ ORDER BY r.name
 
 // ListUsers returns all users.
 func (s *Store) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := s.pool.Query(ctx, `
+	rows, err := s.db.Query(ctx, `
 SELECT id, email, name, picture, provider, subject, created_at, updated_at
 FROM users
 ORDER BY id DESC`)
@@ -169,7 +181,7 @@ ORDER BY id DESC`)
 // GetUserByID fetches a user by ID.
 func (s *Store) GetUserByID(ctx context.Context, id int64) (*User, error) {
 	var u User
-	err := s.pool.QueryRow(ctx, `
+	err := s.db.QueryRow(ctx, `
 SELECT id, email, name, picture, provider, subject, created_at, updated_at
 FROM users WHERE id=$1`, id).Scan(&u.ID, &u.Email, &u.Name, &u.Picture, &u.Provider, &u.Subject, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
@@ -184,23 +196,21 @@ func (s *Store) UpdateUser(ctx context.Context, u *User) error {
 		return errors.New("invalid user")
 	}
 	// Email, provider, and subject are treated as identifiers for OIDC; allow updating with care.
-	_, err := s.pool.Exec(ctx, `
+	return s.db.Exec(ctx, `
 UPDATE users
 SET email=$1, name=$2, picture=$3, provider=$4, subject=$5, updated_at=now()
 WHERE id=$6
 `, u.Email, u.Name, u.Picture, u.Provider, u.Subject, u.ID)
-	return err
 }
 
 // DeleteUser deletes a user by ID (cascades to sessions and user_roles).
 func (s *Store) DeleteUser(ctx context.Context, id int64) error {
-	_, err := s.pool.Exec(ctx, `DELETE FROM users WHERE id=$1`, id)
-	return err
+	return s.db.Exec(ctx, `DELETE FROM users WHERE id=$1`, id)
 }
 
 // SetUserRoles replaces the set of roles for a user.
 func (s *Store) SetUserRoles(ctx context.Context, userID int64, roles []string) error {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -208,7 +218,7 @@ func (s *Store) SetUserRoles(ctx context.Context, userID int64, roles []string)
 		// rollback if still in progress
 		_ = tx.Rollback(ctx)
 	}()
-	if _, err := tx.Exec(ctx, `DELETE FROM user_roles WHERE user_id=$1`, userID); err != nil {
+	if err := tx.Exec(ctx, `DELETE FROM user_roles WHERE user_id=$1`, userID); err != nil {
 		return err
 	}
 	for _, name := range roles {
@@ -224,7 +234,7 @@ RETURNING id`, name).Scan(&roleID)
 		if err != nil {
 			return err
 		}
-		if _, err := tx.Exec(ctx, `INSERT INTO user_roles(user_id, role_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, userID, roleID); err != nil {
+		if err := tx.Exec(ctx, `INSERT INTO user_roles(user_id, role_id) VALUES($1,$2) ON CONFLICT DO NOTHING`, userID, roleID); err != nil {
 			return err
 		}
 	}
@@ -238,8 +248,7 @@ func (s *Store) CreateSession(ctx context.Context, userID int64) (*Session, erro
 		return nil, err
 	}
 	sess := &Session{ID: id, UserID: userID, ExpiresAt: time.Now().Add(s.sessionTTL)}
-	_, err = s.pool.Exec(ctx, `INSERT INTO sessions(id, user_id, expires_at, id_token) VALUES($1,$2,$3,'')`, sess.ID, sess.UserID, sess.ExpiresAt)
-	if err != nil {
+	if err := s.db.Exec(ctx, `INSERT INTO sessions(id, user_id, expires_at, id_token) VALUES($1,$2,$3,'')`, sess.ID, sess.UserID, sess.ExpiresAt); err != nil {
 		return nil, err
 	}
 	return sess, nil
@@ -248,18 +257,18 @@ func (s *Store) CreateSession(ctx context.Context, userID int64) (*Session, erro
 // GetSession returns the session and associated user if valid.
 func (s *Store) GetSession(ctx context.Context, id string) (*Session, *User, error) {
 	var sess Session
-	err := s.pool.QueryRow(ctx, `SELECT id, user_id, expires_at, created_at, id_token FROM sessions WHERE id=$1`, id).
+	err := s.db.QueryRow(ctx, `SELECT id, user_id, expires_at, created_at, id_token FROM sessions WHERE id=$1`, id).
 		Scan(&sess.ID, &sess.UserID, &sess.ExpiresAt, &sess.CreatedAt, &sess.IDToken)
 	if err != nil {
 		return nil, nil, err
 	}
 	if time.Now().After(sess.ExpiresAt) {
 		// best-effort cleanup
-		_, _ = s.pool.Exec(ctx, `DELETE FROM sessions WHERE id=$1`, id)
-		return nil, nil, pgx.ErrNoRows
+		_ = s.db.Exec(ctx, `DELETE FROM sessions WHERE id=$1`, id)
+		return nil, nil, s.db.ErrNoRows()
 	}
 	var u User
-	err = s.pool.QueryRow(ctx, `SELECT id, email, name, picture, provider, subject, created_at, updated_at FROM users WHERE id=$1`, sess.UserID).
+	err = s.db.QueryRow(ctx, `SELECT id, email, name, picture, provider, subject, created_at, updated_at FROM users WHERE id=$1`, sess.UserID).
 		Scan(&u.ID, &u.Email, &u.Name, &u.Picture, &u.Provider, &u.Subject, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return nil, nil, err
@@ -269,14 +278,12 @@ func (s *Store) GetSession(ctx context.Context, id string) (*Session, *User, err
 
 // SetSessionIDToken stores the OIDC ID token for a session (used for RP-initiated logout).
 func (s *Store) SetSessionIDToken(ctx context.Context, id string, idToken string) error {
-	_, err := s.pool.Exec(ctx, `UPDATE sessions SET id_token=$2 WHERE id=$1`, id, idToken)
-	return err
+	return s.db.Exec(ctx, `UPDATE sessions SET id_token=$2 WHERE id=$1`, id, idToken)
 }
 
 // DeleteSession removes a session by id.
 func (s *Store) DeleteSession(ctx context.Context, id string) error {
-	_, err := s.pool.Exec(ctx, `DELETE FROM sessions WHERE id=$1`, id)
-	return err
+	return s.db.Exec(ctx, `DELETE FROM sessions WHERE id=$1`, id)
 }
 
 func randomID(n int) (string, error) {
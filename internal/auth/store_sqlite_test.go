@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"manifold/internal/persistence/sqlite"
+)
+
+func TestSQLiteStoreSchemaAndUser(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "auth.db"))
+	if errors.Is(err, sqlite.ErrDriverUnavailable) {
+		t.Skip("sqlite driver not compiled in")
+	}
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	st := NewSQLiteStore(db, 1)
+	if err := st.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	// Schema setup and role seeding are idempotent across restarts.
+	if err := st.InitSchema(ctx); err != nil {
+		t.Fatalf("schema again: %v", err)
+	}
+	if err := st.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatalf("seed roles: %v", err)
+	}
+	if err := st.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatalf("seed roles again: %v", err)
+	}
+	u := &User{Email: "test@example.com", Name: "Test", Provider: "oidc", Subject: "sub123"}
+	if _, err := st.UpsertUser(ctx, u); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := st.SetUserRoles(ctx, u.ID, []string{"user", "admin"}); err != nil {
+		t.Fatalf("set roles: %v", err)
+	}
+	ok, err := st.HasRole(ctx, u.ID, "admin")
+	if err != nil || !ok {
+		t.Fatalf("has role: %v ok=%v", err, ok)
+	}
+	sess, err := st.CreateSession(ctx, u.ID)
+	if err != nil || sess == nil {
+		t.Fatalf("session: %v", err)
+	}
+	_, got, err := st.GetSession(ctx, sess.ID)
+	if err != nil || got.Email != u.Email {
+		t.Fatalf("get session: %+v err=%v", got, err)
+	}
+	if err := st.DeleteUser(ctx, u.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, _, err := st.GetSession(ctx, sess.ID); err == nil {
+		t.Fatalf("session outlived its user")
+	}
+}
@@ -30,7 +30,16 @@ type Config struct {
 	SummaryMaxKeepLastMessages int `yaml:"summaryMaxKeepLastMessages" json:"summaryMaxKeepLastMessages"`
 	// SummaryMaxSummaryChunkTokens caps the size of the summary prompt in tokens.
 	SummaryMaxSummaryChunkTokens int `yaml:"summaryMaxSummaryChunkTokens" json:"summaryMaxSummaryChunkTokens"`
-	OutputTruncateByte           int `yaml:"outputTruncateBytes" json:"outputTruncateBytes"`
+	// SummaryStrategy selects how chat memory folds older messages into the
+	// summary: "rolling" (default), "map_reduce", or "selective".
+	SummaryStrategy string `yaml:"summaryStrategy" json:"summaryStrategy"`
+	// SummaryRetainImportantMessages is how many high-importance messages the
+	// "selective" strategy keeps verbatim next to the summary. Default: 5.
+	SummaryRetainImportantMessages int `yaml:"summaryRetainImportantMessages" json:"summaryRetainImportantMessages"`
+	// SummaryToolRelevance compacts older tool results by relevance to the
+	// latest user message before whole turns are summarized.
+	SummaryToolRelevance ToolRelevanceConfig `yaml:"summaryToolRelevance" json:"summaryToolRelevance"`
+	OutputTruncateByte   int                 `yaml:"outputTruncateBytes" json:"outputTruncateBytes"`
 	// Maximum number of reasoning steps the agent can take
 	MaxSteps int `yaml:"maxSteps" json:"maxSteps"`
 	// MaxToolParallelism controls how many tool calls may run concurrently within a single step.
@@ -59,6 +68,11 @@ type Config struct {
 	// disabled per specialist so the request contains no tool schema at all.
 	Specialists      []SpecialistConfig `yaml:"specialists" json:"specialists"`
 	SpecialistRoutes []SpecialistRoute  `yaml:"routes" json:"routes"`
+	// SpecialistRouting selects specialists by description when no route
+	// rule matches.
+	SpecialistRouting SpecialistRoutingConfig `yaml:"specialistRouting" json:"specialistRouting"`
+	// SpecialistLimits bounds concurrent requests to each specialist.
+	SpecialistLimits SpecialistLimitsConfig `yaml:"specialistLimits" json:"specialistLimits"`
 	// Databases describes pluggable backends for search, vector embeddings,
 	// and graph operations. Each backend can be configured independently via
 	// YAML or environment variables.
@@ -76,14 +90,22 @@ type Config struct {
 	MaxDiscoveredTools int `yaml:"maxDiscoveredTools" json:"maxDiscoveredTools"`
 	// Embedding configures the embedding service endpoint for text embeddings.
 	Embedding EmbeddingConfig `yaml:"embedding" json:"embedding"`
+	// RAG configures hybrid retrieval for rag_query and /api/rag/query.
+	RAG RAGConfig `yaml:"rag" json:"rag"`
+	// OCR configures text recognition for scanned PDFs and images.
+	OCR OCRConfig `yaml:"ocr" json:"ocr"`
 	// EvolvingMemory configures the Search-Synthesis-Evolve memory system.
 	EvolvingMemory EvolvingMemoryConfig `yaml:"evolvingMemory" json:"evolvingMemory"`
+	// LongTermMemory configures cross-session, per-user fact memory.
+	LongTermMemory LongTermMemoryConfig `yaml:"longTermMemory" json:"longTermMemory"`
 	// Transit configures the shared durable memory system.
 	Transit TransitConfig `yaml:"transit" json:"transit"`
 	// TTS configures text-to-speech defaults and endpoint.
 	TTS TTSConfig `yaml:"tts" json:"tts"`
 	// STT configures speech-to-text defaults and endpoint.
 	STT STTConfig `yaml:"stt" json:"stt"`
+	// Warmup primes model backends at startup to reduce first-request latency.
+	Warmup WarmupConfig `yaml:"warmup" json:"warmup"`
 	// AgentRunTimeoutSeconds sets an upper wall-clock bound for a single agent
 	// Run() invocation. 0 or negative disables the global timeout (recommended
 	// for long-running, tool-bounded workflows where per-tool timeouts and
@@ -94,10 +116,63 @@ type Config struct {
 	StreamRunTimeoutSeconds int `yaml:"streamRunTimeoutSeconds" json:"streamRunTimeoutSeconds"`
 	// WorkflowTimeoutSeconds bounds orchestrator workflow execution; 0 disables.
 	WorkflowTimeoutSeconds int `yaml:"workflowTimeoutSeconds" json:"workflowTimeoutSeconds"`
+	// ShutdownDrainSeconds is how long agentd waits on SIGTERM for in-flight
+	// runs to finish before cancelling them. Defaults to 30.
+	ShutdownDrainSeconds int `yaml:"shutdownDrainSeconds" json:"shutdownDrainSeconds"`
+	// RunQueue bounds concurrent agent runs started through /agent/run and
+	// /api/prompt.
+	RunQueue RunQueueConfig `yaml:"runQueue" json:"runQueue"`
 	// Projects controls per-user projects service behavior.
 	Projects ProjectsConfig `yaml:"projects" json:"projects"`
+	// StorageGC configures the janitor that removes stale files under Workdir.
+	StorageGC StorageGCConfig `yaml:"storageGC" json:"storageGC"`
+	// Playground configures the prompt playground.
+	Playground PlaygroundConfig `yaml:"playground" json:"playground"`
+	// Pricing is the token price table behind the cost estimates of
+	// /api/metrics/usage.
+	Pricing PricingConfig `yaml:"pricing" json:"pricing"`
+	// Quotas caps each user's monthly tokens, cost and agent runs.
+	Quotas QuotasConfig `yaml:"quotas" json:"quotas"`
+	// SpecialistHealth configures background probing of specialist endpoints.
+	SpecialistHealth SpecialistHealthConfig `yaml:"specialistHealth" json:"specialistHealth"`
+	// Secrets configures the encrypted store for named secrets that
+	// specialists, MCP servers and tools reference instead of plaintext keys.
+	Secrets SecretsConfig `yaml:"secrets" json:"secrets"`
 	// Tokenization configures accurate token counting for summarization.
 	Tokenization TokenizationConfig `yaml:"tokenization" json:"tokenization"`
+	// Guardrails configures prompt and output filtering for chat endpoints.
+	Guardrails GuardrailsConfig `yaml:"guardrails" json:"guardrails"`
+	// PIIScrubbing masks personal data in assistant output before it is
+	// persisted or logged.
+	PIIScrubbing PIIScrubbingConfig `yaml:"piiScrubbing" json:"piiScrubbing"`
+	// ToolCache configures result caching for deterministic tools.
+	ToolCache ToolCacheConfig `yaml:"toolCache" json:"toolCache"`
+	// Verifier checks final answers and retries low-quality ones with an
+	// escalated model.
+	Verifier VerifierConfig `yaml:"verifier" json:"verifier"`
+	// ToolApproval pauses runs for a human decision before selected tools run.
+	ToolApproval ToolApprovalConfig `yaml:"toolApproval" json:"toolApproval"`
+	// ToolTimeouts bounds how long a single tool call may run.
+	ToolTimeouts ToolTimeoutsConfig `yaml:"toolTimeouts" json:"toolTimeouts"`
+	// LiveSessions lets several users share one chat session over WebSocket.
+	LiveSessions LiveSessionsConfig `yaml:"liveSessions" json:"liveSessions"`
+	// Server configures the HTTP listener: address, TLS and HTTP/2.
+	Server ServerConfig `yaml:"server" json:"server"`
+	// GRPC serves agent runs, sessions and workflows to non-browser clients.
+	GRPC GRPCConfig `yaml:"grpc" json:"grpc"`
+	// Webhooks are inbound /api/hooks/{id} endpoints that trigger workflows
+	// or agent prompts.
+	Webhooks []WebhookConfig `yaml:"webhooks" json:"webhooks"`
+	// MacroTools are composite tools that chain existing tools.
+	MacroTools []MacroToolConfig `yaml:"macroTools" json:"macroTools"`
+	// Notifications posts run lifecycle events to external endpoints.
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
+	// I18n configures locale defaults for prompts and server messages.
+	I18n I18nConfig `yaml:"i18n" json:"i18n"`
+	// Email enables the email_search, email_read and email_send tools.
+	Email EmailConfig `yaml:"email" json:"email"`
+	// Calendar enables the CalDAV calendar tools.
+	Calendar CalendarConfig `yaml:"calendar" json:"calendar"`
 }
 
 // TokenizationConfig controls how tokens are counted for summarization decisions.
@@ -116,6 +191,107 @@ type TokenizationConfig struct {
 
 // ProjectsConfig controls project storage and workspace behavior.
 type ProjectsConfig struct {
+	// MaxBytesPerUser caps the bytes agent file tools and project uploads may
+	// store across a user's projects. Zero means unlimited.
+	MaxBytesPerUser int64 `yaml:"maxBytesPerUser" json:"maxBytesPerUser"`
+}
+
+// SpecialistHealthConfig controls the specialist health checker, whose
+// results replace the fixed "online" state in /api/status.
+type SpecialistHealthConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalSeconds is how often every specialist is probed. Default: 300.
+	IntervalSeconds int `yaml:"intervalSeconds" json:"intervalSeconds"`
+	// TimeoutSeconds bounds each probe. Default: 10.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// DegradedLatencyMs marks reachable specialists slower than this as
+	// degraded. Default: 3000.
+	DegradedLatencyMs int `yaml:"degradedLatencyMs" json:"degradedLatencyMs"`
+	// CompletionProbe also sends a one-word completion when the model list
+	// succeeds. Endpoints without a model list are always probed this way.
+	CompletionProbe bool `yaml:"completionProbe" json:"completionProbe"`
+}
+
+// PlaygroundConfig configures the prompt playground.
+type PlaygroundConfig struct {
+	// Prices maps model names to token prices, used to estimate the cost of
+	// playground runs. Models without an entry cost nothing.
+	Prices map[string]ModelPrice `yaml:"prices" json:"prices"`
+	// Evaluators registers external evaluators that experiments reference by
+	// name alongside the built-in ones.
+	Evaluators []PlaygroundEvaluatorConfig `yaml:"evaluators" json:"evaluators"`
+}
+
+// PlaygroundEvaluatorConfig declares an evaluator that scores each result by
+// running Command (payload on stdin, scores on stdout) or by POSTing the
+// payload to URL. Exactly one of the two must be set.
+type PlaygroundEvaluatorConfig struct {
+	Name    string   `yaml:"name" json:"name"`
+	Command []string `yaml:"command" json:"command"`
+	URL     string   `yaml:"url" json:"url"`
+	// TimeoutSeconds bounds each call. Default: 30.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+}
+
+// PricingConfig maps model names to token prices. Models without an entry
+// are reported without a cost.
+type PricingConfig struct {
+	// Currency labels the prices, e.g. "USD". Default: USD.
+	Currency string                `yaml:"currency" json:"currency"`
+	Models   map[string]ModelPrice `yaml:"models" json:"models"`
+}
+
+// QuotasConfig sets monthly usage limits. A user's limits are their entry
+// in Users (keyed by email), else the most generous limits among their roles
+// in Roles, else Default.
+type QuotasConfig struct {
+	Enabled bool                   `yaml:"enabled" json:"enabled"`
+	Default QuotaLimits            `yaml:"default" json:"default"`
+	Roles   map[string]QuotaLimits `yaml:"roles" json:"roles"`
+	Users   map[string]QuotaLimits `yaml:"users" json:"users"`
+}
+
+// QuotaLimits caps usage per calendar month (UTC). Zero means unlimited.
+type QuotaLimits struct {
+	// Tokens caps prompt plus completion tokens.
+	Tokens int64 `yaml:"tokens" json:"tokens"`
+	// Cost caps the estimated cost in pricing.currency. Models missing from
+	// pricing.models count as free.
+	Cost float64 `yaml:"cost" json:"cost"`
+	// Runs caps agent runs started.
+	Runs int64 `yaml:"runs" json:"runs"`
+}
+
+// ModelPrice is a model's price per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `yaml:"inputPerMillion" json:"inputPerMillion"`
+	OutputPerMillion float64 `yaml:"outputPerMillion" json:"outputPerMillion"`
+}
+
+// StorageGCConfig controls the storage janitor. Each retention is an age in
+// hours after which matching files are removed.
+type StorageGCConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DryRun logs what would be removed without deleting anything.
+	DryRun bool `yaml:"dryRun" json:"dryRun"`
+	// IntervalMinutes is how often the janitor runs. Default: 60.
+	IntervalMinutes int `yaml:"intervalMinutes" json:"intervalMinutes"`
+	// CLIArtifactsHours keeps truncated command output (cli-artifacts).
+	// Default: 168 (7 days).
+	CLIArtifactsHours int `yaml:"cliArtifactsHours" json:"cliArtifactsHours"`
+	// PlaygroundArtifactsHours keeps playground run artifacts. Default: 720
+	// (30 days).
+	PlaygroundArtifactsHours int `yaml:"playgroundArtifactsHours" json:"playgroundArtifactsHours"`
+	// UploadTempHours keeps temp files left in projects by interrupted
+	// uploads. Default: 24.
+	UploadTempHours int `yaml:"uploadTempHours" json:"uploadTempHours"`
+}
+
+// SecretsConfig configures encryption of stored secrets.
+type SecretsConfig struct {
+	// MasterKey is a base64-encoded 32-byte AES key, usually supplied as
+	// "${MANIFOLD_SECRETS_KEY}". Without it the secrets API is disabled.
+	MasterKey string `yaml:"masterKey" json:"-"`
 }
 
 // TTSConfig holds text-to-speech specific configuration.
@@ -125,10 +301,43 @@ type TTSConfig struct {
 	BaseURL string `yaml:"baseURL" json:"baseURL"`
 	// Model is the default TTS model to use when creating speech.
 	Model string `yaml:"model" json:"model"`
-	// Voice is the default voice name to request from the TTS endpoint.
+	// Voice is the default voice name to request from the TTS endpoint. It
+	// may also name an entry in Voices.
+	Voice string `yaml:"voice" json:"voice"`
+	// Providers adds TTS backends beside the OpenAI-compatible one set by
+	// BaseURL, which is named "default". When the chosen provider fails, the
+	// others are tried in order.
+	Providers []TTSProviderConfig `yaml:"providers" json:"providers"`
+	// Voices names provider and voice pairs that requests and specialists
+	// can select by name.
+	Voices map[string]TTSVoiceConfig `yaml:"voices" json:"voices"`
+	// CooldownSeconds is how long a failing provider is skipped before it is
+	// tried again. Default: 30.
+	CooldownSeconds int `yaml:"cooldownSeconds" json:"cooldownSeconds"`
+}
+
+// TTSProviderConfig is one speech synthesis backend.
+type TTSProviderConfig struct {
+	Name string `yaml:"name" json:"name"`
+	// Type is "openai" (OpenAI-compatible /v1/audio/speech), "elevenlabs"
+	// (ElevenLabs-compatible /v1/text-to-speech) or "piper" (Piper HTTP
+	// server).
+	Type    string `yaml:"type" json:"type"`
+	BaseURL string `yaml:"baseURL" json:"baseURL"`
+	APIKey  string `yaml:"apiKey" json:"apiKey"`
+	// Model and Voice are used when a request names neither.
+	Model string `yaml:"model" json:"model"`
 	Voice string `yaml:"voice" json:"voice"`
 }
 
+// TTSVoiceConfig selects a voice of one provider. Model overrides the
+// provider's model.
+type TTSVoiceConfig struct {
+	Provider string `yaml:"provider" json:"provider"`
+	Voice    string `yaml:"voice" json:"voice"`
+	Model    string `yaml:"model" json:"model"`
+}
+
 // STTConfig holds speech-to-text specific configuration.
 type STTConfig struct {
 	// BaseURL is the HTTP base for STT requests. Requests will be POSTed to
@@ -136,11 +345,136 @@ type STTConfig struct {
 	BaseURL string `yaml:"baseURL" json:"baseURL"`
 	// Model is the default STT model to use when transcribing audio.
 	Model string `yaml:"model" json:"model"`
+	// MaxConcurrent bounds in-flight transcriptions sent to the STT server.
+	// Size it to the number of whisper contexts the server runs; extra
+	// requests queue instead of piling onto the server. 0 means unlimited.
+	// Backend (CPU threads, Metal, CUDA) is chosen when building and starting
+	// the whisper server, not here.
+	MaxConcurrent int `yaml:"maxConcurrent" json:"maxConcurrent"`
+	// QueueTimeoutSeconds bounds how long a transcription waits for a free
+	// slot before /stt answers 503. Default: 30.
+	QueueTimeoutSeconds int `yaml:"queueTimeoutSeconds" json:"queueTimeoutSeconds"`
+	// FFmpegPath is the ffmpeg binary used to decode mp3, ogg, webm and other
+	// non-WAV uploads to /stt. WAV files are resampled without it.
+	// Default: ffmpeg.
+	FFmpegPath string `yaml:"ffmpegPath" json:"ffmpegPath"`
+	// Stream tunes live dictation over /stt/stream.
+	Stream STTStreamConfig `yaml:"stream" json:"stream"`
+}
+
+// STTStreamConfig controls how /stt/stream splits live audio into utterances.
+type STTStreamConfig struct {
+	// VADThreshold is the RMS level, from 0 to 1, above which a 20 ms frame
+	// counts as speech. Default: 0.015.
+	VADThreshold float64 `yaml:"vadThreshold" json:"vadThreshold"`
+	// SilenceMs is how long speech must pause before the utterance is
+	// transcribed as final. Default: 700.
+	SilenceMs int `yaml:"silenceMs" json:"silenceMs"`
+	// PartialIntervalMs is how much new speech triggers another partial
+	// transcript of the utterance so far. Default: 1000.
+	PartialIntervalMs int `yaml:"partialIntervalMs" json:"partialIntervalMs"`
+	// MaxSegmentSeconds forces a final transcript once an utterance gets this
+	// long. Default: 30.
+	MaxSegmentSeconds int `yaml:"maxSegmentSeconds" json:"maxSegmentSeconds"`
+}
+
+// WarmupConfig controls the asynchronous warmup agentd runs after startup.
+type WarmupConfig struct {
+	// Enabled primes connections (DNS, TLS, HTTP/2) to configured providers.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Completion sends a one-token completion to self-hosted LLM backends so
+	// the model is loaded into memory before the first user request.
+	Completion bool `yaml:"completion" json:"completion"`
+	// STT sends a short silent clip to a self-hosted speech-to-text endpoint
+	// so the whisper model is loaded.
+	STT bool `yaml:"stt" json:"stt"`
+	// TimeoutSeconds bounds each warmup step. Default: 120.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
 }
 
 type ExecConfig struct {
 	BlockBinaries     []string `yaml:"blockBinaries" json:"blockBinaries"`
 	MaxCommandSeconds int      `yaml:"maxCommandSeconds" json:"maxCommandSeconds"`
+	// Container configures the container backend for run_cli. Specialists
+	// can opt in or out per specialist with execBackend.
+	Container ExecContainerConfig `yaml:"container" json:"container"`
+	// Python configures the run_python tool.
+	Python ExecPythonConfig `yaml:"python" json:"python"`
+	// Git configures the git_* repository tools.
+	Git ExecGitConfig `yaml:"git" json:"git"`
+	// CodeSearch configures the code_search tool and the repo map.
+	CodeSearch ExecCodeSearchConfig `yaml:"codeSearch" json:"codeSearch"`
+}
+
+// ExecCodeSearchConfig configures code_search, which indexes source files
+// in the working directory into symbols and file summaries.
+type ExecCodeSearchConfig struct {
+	// Ctags is a Universal Ctags binary used for languages without a
+	// built-in extractor. Empty looks up "ctags" on PATH; "off" disables it.
+	Ctags string `yaml:"ctags" json:"ctags"`
+	// MaxFiles caps the files indexed per project (default 5000).
+	MaxFiles int `yaml:"maxFiles" json:"maxFiles"`
+	// MaxFileBytes skips larger files (default 512 KiB).
+	MaxFileBytes int64 `yaml:"maxFileBytes" json:"maxFileBytes"`
+	// RepoMapTokens sizes the condensed repo map appended to chat system
+	// prompts for project runs. 0 leaves it out.
+	RepoMapTokens int `yaml:"repoMapTokens" json:"repoMapTokens"`
+}
+
+// ExecGitConfig configures the git tools, which operate on repositories
+// inside the run's working directory. They run git on the run_cli backend;
+// with the container backend, exec.container.image must include git.
+type ExecGitConfig struct {
+	// AllowDirectPush lets git_push run without a person approving the
+	// call. When false git_push is added to the tool approval gate.
+	AllowDirectPush bool `yaml:"allowDirectPush" json:"allowDirectPush"`
+	// AuthorName and AuthorEmail are the commit identity used when a
+	// repository has none configured (default "Manifold Agent" and
+	// "agent@manifold.local").
+	AuthorName  string `yaml:"authorName" json:"authorName"`
+	AuthorEmail string `yaml:"authorEmail" json:"authorEmail"`
+}
+
+// ExecPythonConfig configures run_python, which runs code in an
+// interpreter subprocess with resource limits, on the host or in a
+// container like run_cli.
+type ExecPythonConfig struct {
+	// Enabled registers run_python. Default false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Interpreter is the python executable on the host (default "python3").
+	// It is refused when its name is in exec.blockBinaries.
+	Interpreter string `yaml:"interpreter" json:"interpreter"`
+	// Image is the container image used with the container backend
+	// (default "python:3-slim"); code runs with its python3.
+	Image string `yaml:"image" json:"image"`
+	// PassEnv names server environment variables passed to host runs in
+	// addition to PATH, HOME, LANG, LC_ALL, TZ and TMPDIR. Nothing else
+	// from the server environment reaches the code.
+	PassEnv []string `yaml:"passEnv" json:"passEnv"`
+	// TimeoutSeconds bounds a single run (default 60).
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// MemoryMB caps the interpreter's address space (default 1024).
+	MemoryMB int `yaml:"memoryMB" json:"memoryMB"`
+}
+
+// ExecContainerConfig runs run_cli commands in an ephemeral container with
+// the working directory bind-mounted at /workspace.
+type ExecContainerConfig struct {
+	// Enabled makes the container the default backend instead of the host.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Runtime is the container CLI: "docker" (default) or "podman".
+	Runtime string `yaml:"runtime" json:"runtime"`
+	// Image is the image commands run in (default "alpine:3").
+	Image string `yaml:"image" json:"image"`
+	// CPUs and Memory cap the container, e.g. "1.5" and "512m". Empty
+	// leaves the runtime default.
+	CPUs   string `yaml:"cpus" json:"cpus"`
+	Memory string `yaml:"memory" json:"memory"`
+	// Network is passed to --network (default "none").
+	Network string `yaml:"network" json:"network"`
+	// User is passed to --user, e.g. "1000:1000", so files written to the
+	// workdir keep a non-root owner. Empty uses the image default.
+	User string `yaml:"user" json:"user"`
 }
 
 // LLMClientConfig selects the LLM provider and holds provider-specific configs.
@@ -149,6 +483,59 @@ type LLMClientConfig struct {
 	OpenAI    OpenAIConfig    `yaml:"openai" json:"openai"`
 	Anthropic AnthropicConfig `yaml:"anthropic" json:"anthropic"`
 	Google    GoogleConfig    `yaml:"google" json:"google"`
+	// Resilience configures retries, a per-base-URL circuit breaker, and
+	// failover to Fallback.
+	Resilience LLMResilienceConfig `yaml:"resilience" json:"resilience"`
+	// Fallback is a secondary provider used when the primary keeps failing or
+	// its breaker is open. Only honored when resilience is enabled; a nested
+	// fallback is ignored.
+	Fallback *LLMClientConfig `yaml:"fallback,omitempty" json:"fallback,omitempty"`
+	// Routing selects among additional models per request by capability and
+	// cost. The provider configured above remains the default route.
+	Routing LLMRoutingConfig `yaml:"routing" json:"routing"`
+}
+
+// LLMRoutingConfig declares the candidate models for per-request routing.
+type LLMRoutingConfig struct {
+	Enabled bool             `yaml:"enabled" json:"enabled"`
+	Routes  []LLMRouteConfig `yaml:"routes" json:"routes"`
+}
+
+// LLMRouteConfig is one routable model. A request is eligible for the route
+// when it fits every declared capability; among eligible routes the lowest
+// CostWeight wins and the default provider is used when none qualify.
+type LLMRouteConfig struct {
+	Name string `yaml:"name" json:"name"`
+	// Client configures the provider and model for this route. Its own
+	// routing and fallback settings are ignored.
+	Client LLMClientConfig `yaml:"client" json:"client"`
+	// Vision marks models that accept image inputs.
+	Vision bool `yaml:"vision" json:"vision"`
+	// Tools marks models that support function calling.
+	Tools bool `yaml:"tools" json:"tools"`
+	// ContextWindowTokens bounds the estimated prompt size; 0 means unbounded.
+	ContextWindowTokens int `yaml:"contextWindowTokens" json:"contextWindowTokens"`
+	// CostWeight is a relative cost; lower is preferred.
+	CostWeight float64 `yaml:"costWeight" json:"costWeight"`
+}
+
+// LLMResilienceConfig controls retry and circuit-breaker behavior for LLM
+// provider calls. Retries apply to 429, 5xx, and transport errors.
+type LLMResilienceConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxRetries is the number of retries after the first attempt. Default: 2.
+	MaxRetries int `yaml:"maxRetries" json:"maxRetries"`
+	// InitialBackoffMillis is the first retry delay; it doubles per attempt
+	// with full jitter. Default: 500.
+	InitialBackoffMillis int `yaml:"initialBackoffMillis" json:"initialBackoffMillis"`
+	// MaxBackoffMillis caps the retry delay. Default: 8000.
+	MaxBackoffMillis int `yaml:"maxBackoffMillis" json:"maxBackoffMillis"`
+	// BreakerFailureThreshold is the number of consecutive failures that opens
+	// the breaker for a base URL. Default: 5.
+	BreakerFailureThreshold int `yaml:"breakerFailureThreshold" json:"breakerFailureThreshold"`
+	// BreakerCooldownSeconds is how long an open breaker rejects calls before
+	// allowing a trial request. Default: 30.
+	BreakerCooldownSeconds int `yaml:"breakerCooldownSeconds" json:"breakerCooldownSeconds"`
 }
 
 type OpenAIConfig struct {
@@ -238,6 +625,16 @@ type SpecialistConfig struct {
 	System          string            `yaml:"system" json:"system"`
 	ExtraHeaders    map[string]string `yaml:"extraHeaders" json:"extraHeaders"`
 	ExtraParams     map[string]any    `yaml:"extraParams" json:"extraParams"`
+	// ExecBackend selects where this specialist's run_cli commands execute:
+	// "host" or "container". Empty follows exec.container.enabled.
+	ExecBackend string `yaml:"execBackend" json:"execBackend"`
+	// Voice is the text_to_speech voice this specialist speaks with: a
+	// tts.voices name or a voice of the default TTS provider.
+	Voice string `yaml:"voice" json:"voice"`
+	// OwnerID is the user a stored specialist belongs to; APIKey may only
+	// reference secrets scoped to that user. Config-file specialists belong
+	// to the system user, 0.
+	OwnerID int64 `yaml:"-" json:"-"`
 }
 
 // SpecialistRoute defines simple pre-dispatch rules. If the user's prompt
@@ -249,6 +646,62 @@ type SpecialistRoute struct {
 	Regex    []string `yaml:"regex" json:"regex"`
 }
 
+// SpecialistRoutingConfig controls description-based specialist routing.
+// Route rules always take precedence.
+type SpecialistRoutingConfig struct {
+	// Mode is "rules" (default: rules only), "embedding" (similarity between
+	// the prompt and specialist descriptions) or "classifier" (a model call
+	// picks the specialist).
+	Mode string `yaml:"mode" json:"mode"`
+	// MinConfidence is the score below which prompts stay with the
+	// orchestrator: cosine similarity for embedding, the model's own
+	// confidence for classifier. Default: 0.5.
+	MinConfidence float64 `yaml:"minConfidence" json:"minConfidence"`
+	// MinMargin is how far the best embedding match must lead the runner-up.
+	// Default: 0.05.
+	MinMargin float64 `yaml:"minMargin" json:"minMargin"`
+	// ClassifierModel is the model asked in classifier mode. Default: the
+	// main model.
+	ClassifierModel string `yaml:"classifierModel" json:"classifierModel"`
+	// DecisionLog, when set, appends every routing decision as a JSON line
+	// to this path for evaluation.
+	DecisionLog string `yaml:"decisionLog" json:"decisionLog"`
+}
+
+// SpecialistLimit bounds concurrent requests to one specialist so a slow
+// self-hosted model is not overwhelmed.
+type SpecialistLimit struct {
+	// MaxConcurrent caps in-flight requests. 0 means unlimited.
+	MaxConcurrent int `yaml:"maxConcurrent" json:"maxConcurrent"`
+	// QueueDepth is how many further requests may wait for a slot; beyond
+	// it requests are rejected at once. 0 means no queue.
+	QueueDepth int `yaml:"queueDepth" json:"queueDepth"`
+	// QueueTimeoutSeconds bounds how long a queued request waits before it
+	// is rejected. Default: 30.
+	QueueTimeoutSeconds int `yaml:"queueTimeoutSeconds" json:"queueTimeoutSeconds"`
+}
+
+// RunQueueConfig caps how many agent runs execute at once across all
+// users. Runs over the cap wait in a FIFO queue; streaming clients get
+// "queued" events with their position while they wait.
+type RunQueueConfig struct {
+	// MaxConcurrent caps running agent runs. 0 means unlimited.
+	MaxConcurrent int `yaml:"maxConcurrent" json:"maxConcurrent"`
+	// QueueDepth is how many further runs may wait for a slot; beyond it
+	// requests are rejected with 429 at once. 0 means no queue.
+	QueueDepth int `yaml:"queueDepth" json:"queueDepth"`
+	// QueueTimeoutSeconds bounds how long a queued run waits before it is
+	// rejected. Default: 60.
+	QueueTimeoutSeconds int `yaml:"queueTimeoutSeconds" json:"queueTimeoutSeconds"`
+}
+
+// SpecialistLimitsConfig sets concurrency limits for specialists. Entries in
+// Specialists, keyed by specialist name, replace Default for that specialist.
+type SpecialistLimitsConfig struct {
+	Default     SpecialistLimit            `yaml:"default" json:"default"`
+	Specialists map[string]SpecialistLimit `yaml:"specialists" json:"specialists"`
+}
+
 type ClickHouseConfig struct {
 	DSN                  string `yaml:"dsn" json:"dsn"`
 	Database             string `yaml:"database" json:"database"`
@@ -274,6 +727,48 @@ type ObsConfig struct {
 
 type WebConfig struct {
 	SearXNGURL string `yaml:"searXNGURL" json:"searXNGURL"`
+	// Browser configures the headless browser tool.
+	Browser BrowserConfig `yaml:"browser" json:"browser"`
+	// HTTP configures the http_request tool.
+	HTTP HTTPRequestConfig `yaml:"http" json:"http"`
+}
+
+// HTTPRequestConfig configures the http_request tool, which calls REST
+// endpoints on behalf of agents.
+type HTTPRequestConfig struct {
+	// AllowedDomains restricts requests to these domains and their
+	// subdomains; empty allows any.
+	AllowedDomains []string `yaml:"allowedDomains" json:"allowedDomains"`
+	// TimeoutSeconds bounds a single request. Default: 30.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// MaxResponseBytes caps how much of a response body is read. Default: 1MB.
+	MaxResponseBytes int `yaml:"maxResponseBytes" json:"maxResponseBytes"`
+	// Credentials form the vault requests reference as {{secret:NAME}}.
+	Credentials []HTTPCredentialConfig `yaml:"credentials" json:"credentials"`
+}
+
+// HTTPCredentialConfig is a named secret the http_request tool substitutes
+// server-side, so the model only ever sees its name.
+type HTTPCredentialConfig struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"-"`
+	// Domains lists where the credential may be sent. Empty falls back to
+	// the tool's AllowedDomains; with both empty it cannot be used.
+	Domains []string `yaml:"domains" json:"domains"`
+}
+
+// BrowserConfig configures the headless Chrome pool behind the browser tool.
+type BrowserConfig struct {
+	// PoolSize caps the browser tabs open at once. Default: 2.
+	PoolSize int `yaml:"poolSize" json:"poolSize"`
+	// TimeoutSeconds bounds a single browser tool call. Default: 30.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// AllowedDomains restricts navigation to these domains and their
+	// subdomains; empty allows any.
+	AllowedDomains []string `yaml:"allowedDomains" json:"allowedDomains"`
+	// ExecPath overrides the Chrome/Chromium binary. CHROME_PATH is used
+	// when empty.
+	ExecPath string `yaml:"execPath" json:"execPath"`
 }
 
 // AuthConfig holds OAuth2/OIDC and session cookie settings. If Enabled is true,
@@ -326,11 +821,32 @@ type DBConfig struct {
 	// DefaultDSN is an optional shared connection string. If a per-subsystem
 	// DSN is not provided, this value will be used. When set, the factory can
 	// automatically select a Postgres backend if reachable.
-	DefaultDSN string       `yaml:"defaultDSN" json:"defaultDSN"`
-	Search     SearchConfig `yaml:"search" json:"search"`
-	Vector     VectorConfig `yaml:"vector" json:"vector"`
-	Graph      GraphConfig  `yaml:"graph" json:"graph"`
-	Chat       ChatConfig   `yaml:"chat" json:"chat"`
+	DefaultDSN string `yaml:"defaultDSN" json:"defaultDSN"`
+	// Backend selects the relational store used for chat, specialists,
+	// projects, workflows, and auth: "postgres" (default), "sqlite", which
+	// needs a cgo build, or "file", which keeps chat, specialists,
+	// workflows, and runs in JSON files and needs no database.
+	Backend string       `yaml:"backend" json:"backend"`
+	SQLite  SQLiteConfig `yaml:"sqlite" json:"sqlite"`
+	File    FileDBConfig `yaml:"file" json:"file"`
+	Search  SearchConfig `yaml:"search" json:"search"`
+	Vector  VectorConfig `yaml:"vector" json:"vector"`
+	Graph   GraphConfig  `yaml:"graph" json:"graph"`
+	Chat    ChatConfig   `yaml:"chat" json:"chat"`
+}
+
+// SQLiteConfig configures the single-file SQLite database used when
+// DBConfig.Backend is "sqlite".
+type SQLiteConfig struct {
+	// Path is the database file. Default: data/manifold.db.
+	Path string `yaml:"path" json:"path"`
+}
+
+// FileDBConfig configures the JSON files used when DBConfig.Backend is
+// "file".
+type FileDBConfig struct {
+	// Dir holds the files. Default: data.
+	Dir string `yaml:"dir" json:"dir"`
 }
 
 // SearchConfig configures the full-text search backend.
@@ -350,6 +866,18 @@ type VectorConfig struct {
 	Index      string `yaml:"index" json:"index"`
 	Dimensions int    `yaml:"dimensions" json:"dimensions"`
 	Metric     string `yaml:"metric" json:"metric"`
+	// IndexType selects the Postgres ANN index created at startup when
+	// missing: "" (none, sequential scan), "ivfflat", or "hnsw".
+	IndexType string `yaml:"indexType" json:"indexType"`
+	// Lists is the ivfflat list count. Zero derives it from the row count.
+	Lists int `yaml:"lists" json:"lists"`
+	// Probes sets ivfflat.probes for queries. Zero keeps the server default.
+	Probes int `yaml:"probes" json:"probes"`
+	// M and EfConstruction are HNSW build parameters (defaults 16 and 64).
+	M              int `yaml:"m" json:"m"`
+	EfConstruction int `yaml:"efConstruction" json:"efConstruction"`
+	// EfSearch sets hnsw.ef_search for queries. Zero keeps the server default.
+	EfSearch int `yaml:"efSearch" json:"efSearch"`
 }
 
 // GraphConfig configures the graph database backend.
@@ -362,6 +890,20 @@ type GraphConfig struct {
 type ChatConfig struct {
 	Backend string `yaml:"backend" json:"backend"`
 	DSN     string `yaml:"dsn" json:"dsn"`
+	// Encryption enables application-layer encryption of chat content.
+	Encryption ChatEncryptionConfig `yaml:"encryption" json:"encryption"`
+}
+
+// ChatEncryptionConfig configures AES-GCM encryption of chat message content,
+// session summaries, and previews. Per-user data keys are derived from the
+// master keys, so rows are only readable through the owning user's key.
+type ChatEncryptionConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Keys maps key IDs to base64-encoded 32-byte master keys. Retired keys
+	// stay listed so existing rows remain readable after rotation.
+	Keys map[string]string `yaml:"keys" json:"-"`
+	// ActiveKeyID selects the key used for new writes.
+	ActiveKeyID string `yaml:"activeKeyId" json:"activeKeyId"`
 }
 
 // MCPConfig is the root configuration for MCP clients.
@@ -402,6 +944,26 @@ type MCPServerConfig struct {
 	ProtocolVersion string `yaml:"protocolVersion" json:"protocolVersion"`
 	// HTTP controls timeouts, TLS, and proxy settings for remote connections.
 	HTTP MCPHTTPClientConfig `yaml:"http" json:"http"`
+	// OAuth, when TokenURL is set, obtains bearer tokens for a remote server
+	// with the client-credentials grant and renews them as they expire. It
+	// takes precedence over BearerToken.
+	OAuth MCPOAuthConfig `yaml:"oauth" json:"oauth"`
+	// HealthCheckSeconds, when positive, pings the server at this interval,
+	// re-lists its tools, and reconnects with exponential backoff after a
+	// failed ping or connect. 0 keeps the single connect at registration.
+	HealthCheckSeconds int `yaml:"healthCheckSeconds" json:"healthCheckSeconds"`
+	// OwnerID is the user a stored server belongs to; its credentials may
+	// only reference secrets scoped to that user. Config-file servers belong
+	// to the system user, 0.
+	OwnerID int64 `yaml:"-" json:"-"`
+}
+
+// MCPOAuthConfig configures OAuth2 client-credentials auth for a remote MCP server.
+type MCPOAuthConfig struct {
+	TokenURL     string   `yaml:"tokenURL" json:"tokenURL"`
+	ClientID     string   `yaml:"clientID" json:"clientID"`
+	ClientSecret string   `yaml:"clientSecret" json:"clientSecret"`
+	Scopes       []string `yaml:"scopes" json:"scopes"`
 }
 
 // MCPHTTPClientConfig configures the HTTP client used for remote MCP servers.
@@ -428,6 +990,68 @@ type EmbeddingConfig struct {
 	Headers   map[string]string `yaml:"headers" json:"headers"`     // optional additional headers
 	Path      string            `yaml:"path" json:"path"`           // default: /v1/embeddings
 	Timeout   int               `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// Provider selects the endpoint flavour: openai, llamacpp, or ollama.
+	// Empty treats non-OpenAI hosts as llama.cpp-compatible servers.
+	Provider string `yaml:"provider" json:"provider"`
+	// BatchSize caps inputs per request (0 = provider default).
+	BatchSize int `yaml:"batchSize" json:"batchSize"`
+	// RequestsPerSecond throttles calls to the endpoint (0 = unlimited).
+	RequestsPerSecond float64 `yaml:"requestsPerSecond" json:"requestsPerSecond"`
+	// MaxRetries bounds retries of rate-limited or failed requests.
+	MaxRetries int `yaml:"maxRetries" json:"maxRetries"`
+	// Fallbacks are tried in order when this endpoint fails. They should
+	// serve a model with the same dimensionality.
+	Fallbacks []EmbeddingConfig `yaml:"fallbacks,omitempty" json:"fallbacks,omitempty"`
+	// HealthCheckSeconds is how long a failed endpoint is skipped before it
+	// is tried again, and how often queued texts are retried. Default: 30.
+	HealthCheckSeconds int `yaml:"healthCheckSeconds,omitempty" json:"healthCheckSeconds,omitempty"`
+	// QueueSize caps texts held for re-embedding while every endpoint is
+	// down. Default: 10000; negative disables queueing.
+	QueueSize int `yaml:"queueSize,omitempty" json:"queueSize,omitempty"`
+}
+
+// RAGConfig configures hybrid (full-text + vector) retrieval.
+type RAGConfig struct {
+	// Alpha weights full-text vs vector ranks in fusion (0..1). Default: 0.5.
+	Alpha float64 `yaml:"alpha" json:"alpha"`
+	// Rerank configures the optional rerank stage applied after fusion.
+	Rerank RAGRerankConfig `yaml:"rerank" json:"rerank"`
+}
+
+// RAGRerankConfig selects a reranker for fused retrieval results.
+type RAGRerankConfig struct {
+	// Provider is "llm" (chat model), "cross-encoder" (hosted rerank API),
+	// or empty to disable reranking.
+	Provider string `yaml:"provider" json:"provider"`
+	// Model is the rerank model; for "llm" it defaults to openai.summaryModel.
+	Model string `yaml:"model" json:"model"`
+	// BaseURL and APIKey configure the cross-encoder endpoint ({BaseURL}/rerank).
+	BaseURL string `yaml:"baseURL" json:"baseURL"`
+	APIKey  string `yaml:"apiKey" json:"apiKey"`
+	// Candidates is how many fused results are reranked before cutting to k.
+	// Default: 30.
+	Candidates int `yaml:"candidates" json:"candidates"`
+}
+
+// OCRConfig selects an OCR backend for scanned documents.
+type OCRConfig struct {
+	// Enabled turns on OCR for image files and PDF pages without a text layer.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend is "tesseract" (local CLI) or "http" (OCR service). Default: tesseract.
+	Backend string `yaml:"backend" json:"backend"`
+	// TesseractPath is the tesseract binary. Default: tesseract.
+	TesseractPath string `yaml:"tesseractPath" json:"tesseractPath"`
+	// Languages is passed to tesseract -l (e.g. "eng+deu"). Default: eng.
+	Languages string `yaml:"languages" json:"languages"`
+	// URL and APIKey configure the HTTP backend, which receives the image as
+	// the request body and returns {"text": "...", "confidence": 0.93}.
+	URL    string `yaml:"url" json:"url"`
+	APIKey string `yaml:"apiKey" json:"apiKey"`
+	// TimeoutSeconds bounds each page recognition. Default: 120.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+	// MinPageChars is the extracted text length below which a PDF page is
+	// treated as scanned and sent to OCR. Default: 16.
+	MinPageChars int `yaml:"minPageChars" json:"minPageChars"`
 }
 
 // EvolvingMemoryConfig configures the Search-Synthesis-Evolve memory system.
@@ -453,6 +1077,23 @@ type EvolvingMemoryConfig struct {
 	MinRelevance     float64 `yaml:"minRelevance" json:"minRelevance"`         // minimum relevance to avoid pruning (default 0.1)
 }
 
+// LongTermMemoryConfig configures the long-term semantic memory layer, which
+// embeds notable facts from conversations into the vector store and recalls
+// them for the same user in later sessions.
+type LongTermMemoryConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Model extracts facts after each turn; empty uses the summary model.
+	Model string `yaml:"model" json:"model"`
+	// TopK facts are recalled per prompt (default 5).
+	TopK int `yaml:"topK" json:"topK"`
+	// MinScore drops recalled facts below this similarity (default 0).
+	MinScore float64 `yaml:"minScore" json:"minScore"`
+	// MaxFactsPerTurn caps facts extracted from one turn (default 3).
+	MaxFactsPerTurn int `yaml:"maxFactsPerTurn" json:"maxFactsPerTurn"`
+	// DuplicateThreshold skips facts this similar to a stored one (default 0.92).
+	DuplicateThreshold float64 `yaml:"duplicateThreshold" json:"duplicateThreshold"`
+}
+
 // TransitConfig configures the shared durable memory system.
 type TransitConfig struct {
 	Enabled            bool `yaml:"enabled" json:"enabled"`
@@ -461,3 +1102,382 @@ type TransitConfig struct {
 	MaxBatchSize       int  `yaml:"maxBatchSize" json:"maxBatchSize"`
 	EnableVectorSearch bool `yaml:"enableVectorSearch" json:"enableVectorSearch"`
 }
+
+// GuardrailsConfig configures content filtering applied to user prompts and
+// assistant outputs in agentd.
+type GuardrailsConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Message is returned to the client in place of blocked content.
+	Message string `yaml:"message" json:"message"`
+	// Input rules are applied to user prompts before the agent runs.
+	Input GuardrailRuleSet `yaml:"input" json:"input"`
+	// Output rules are applied to assistant responses before they are returned
+	// or persisted. When any output rule is configured, streamed deltas are
+	// withheld until the full response has been screened.
+	Output GuardrailRuleSet `yaml:"output" json:"output"`
+	// Classifier configures the optional LLM-based classifier.
+	Classifier GuardrailClassifierConfig `yaml:"classifier" json:"classifier"`
+}
+
+// GuardrailRuleSet selects which rules apply to one side of a turn.
+type GuardrailRuleSet struct {
+	// Blocklist holds case-insensitive regular expressions.
+	Blocklist []string `yaml:"blocklist" json:"blocklist"`
+	// PII lists detectors to enable: email, phone, ssn, credit_card, ip_address.
+	PII []string `yaml:"pii" json:"pii"`
+	// Jailbreak enables heuristic prompt-injection detection.
+	Jailbreak bool `yaml:"jailbreak" json:"jailbreak"`
+	// Classifier runs the LLM classifier (requires classifier.enabled).
+	Classifier bool `yaml:"classifier" json:"classifier"`
+}
+
+// GuardrailClassifierConfig configures the LLM-based content classifier.
+type GuardrailClassifierConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Model overrides the classifier model; defaults to the summary model.
+	Model string `yaml:"model" json:"model"`
+	// Categories lists the policy categories the classifier should flag.
+	Categories []string `yaml:"categories" json:"categories"`
+	// FailOpen allows content through when the classifier call fails.
+	FailOpen bool `yaml:"failOpen" json:"failOpen"`
+}
+
+// PIIScrubbingConfig configures masking of personal data in assistant
+// responses. Unlike guardrails, scrubbing never blocks a response; matches are
+// replaced in persisted chat history and payload logs.
+type PIIScrubbingConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Detectors lists PII types to mask: email, phone, ssn, credit_card,
+	// ip_address. Default: email, phone, credit_card.
+	Detectors []string `yaml:"detectors" json:"detectors"`
+	// Mask replaces each match; %s expands to the detector name.
+	// Default: "[redacted:%s]".
+	Mask string `yaml:"mask" json:"mask"`
+}
+
+// VerifierConfig configures the optional pass that checks the orchestrator's
+// final answer. When a check fails, the final synthesis is re-run once with
+// EscalationModel.
+type VerifierConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Checks lists the rule-based checks to run: empty, refusal, schema.
+	// The schema check only applies to requests that send response_schema.
+	// Default: empty, refusal, schema.
+	Checks []string `yaml:"checks" json:"checks"`
+	// MinAnswerChars is the trimmed length below which an answer counts as
+	// empty. Default: 1.
+	MinAnswerChars int `yaml:"minAnswerChars" json:"minAnswerChars"`
+	// Model enables an additional LLM grading pass with a cheap model; the
+	// summary provider is used. Empty disables model grading.
+	Model string `yaml:"model" json:"model"`
+	// EscalationModel is requested from the orchestrator's provider when a
+	// check fails. Empty records the failure without retrying.
+	EscalationModel string `yaml:"escalationModel" json:"escalationModel"`
+}
+
+// ToolApprovalConfig lists tools whose calls wait for a client to approve or
+// deny them via /api/runs/{id}/approvals/{toolCallID}.
+type ToolApprovalConfig struct {
+	// RequiresApproval holds the tool names that need approval. Empty
+	// disables the gate.
+	RequiresApproval []string `yaml:"requiresApproval" json:"requiresApproval"`
+	// TimeoutSeconds is how long a call waits before it is denied.
+	// Default: 300.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+}
+
+// ToolTimeoutsConfig caps tool call durations. A call past its timeout is
+// cancelled, any commands it started are killed, and the model gets a
+// timeout error result.
+type ToolTimeoutsConfig struct {
+	// DefaultSeconds applies to tools without an entry in Tools. Negative
+	// disables the limit. Default: 300.
+	DefaultSeconds int `yaml:"defaultSeconds" json:"defaultSeconds"`
+	// Tools overrides DefaultSeconds by tool name; a negative value
+	// disables the limit for that tool. Without an entry, run_cli gets
+	// exec.maxCommandSeconds plus 5 when that exceeds DefaultSeconds.
+	Tools map[string]int `yaml:"tools" json:"tools"`
+}
+
+// LiveSessionsConfig controls collaborative chat sessions, where invited
+// users join a session's WebSocket, see its output as it streams, and send
+// prompts attributed to them.
+type LiveSessionsConfig struct {
+	// Enabled exposes /api/chat/sessions/{id}/live and /participants.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxParticipants caps concurrent connections per session. Default: 8.
+	MaxParticipants int `yaml:"maxParticipants" json:"maxParticipants"`
+}
+
+// EmailConfig configures per-user mailboxes for the email tools.
+type EmailConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// AllowDirectSend lets email_send deliver without a person approving
+	// the call. When false (draft-only mode) email_send is added to the
+	// tool approval gate and only sends once a person approves the draft.
+	AllowDirectSend bool                 `yaml:"allowDirectSend" json:"allowDirectSend"`
+	Accounts        []EmailAccountConfig `yaml:"accounts" json:"accounts"`
+}
+
+// EmailAccountConfig is one user's mailbox. The password is read from the
+// secrets store, never from this file.
+type EmailAccountConfig struct {
+	// UserID is the owning user; 0 is the account used with auth disabled.
+	UserID int64 `yaml:"userId" json:"userId"`
+	// Address is the From address for sent mail.
+	Address  string `yaml:"address" json:"address"`
+	Username string `yaml:"username" json:"username"`
+	// PasswordSecret names the secret holding the IMAP/SMTP password.
+	PasswordSecret string `yaml:"passwordSecret" json:"passwordSecret"`
+	// IMAPAddr is host:port of an implicit-TLS IMAP server, e.g.
+	// "imap.example.com:993".
+	IMAPAddr string `yaml:"imapAddr" json:"imapAddr"`
+	// SMTPAddr is host:port of the submission server: port 465 uses
+	// implicit TLS, others STARTTLS.
+	SMTPAddr string `yaml:"smtpAddr" json:"smtpAddr"`
+}
+
+// CalendarConfig configures per-user CalDAV calendars for the calendar
+// tools.
+type CalendarConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// ReadOnly registers only the listing and free-slot tools.
+	ReadOnly bool `yaml:"readOnly" json:"readOnly"`
+	// Timezone is the IANA zone used when a call does not name one and for
+	// working hours, e.g. "Europe/Berlin". Defaults to UTC.
+	Timezone string `yaml:"timezone" json:"timezone"`
+	// WorkdayStart and WorkdayEnd bound the slots calendar_find_free_slots
+	// suggests, as "HH:MM" in the calendar timezone. Default 09:00-17:00.
+	WorkdayStart string                  `yaml:"workdayStart" json:"workdayStart"`
+	WorkdayEnd   string                  `yaml:"workdayEnd" json:"workdayEnd"`
+	Accounts     []CalendarAccountConfig `yaml:"accounts" json:"accounts"`
+}
+
+// CalendarAccountConfig is one user's calendar. The password is read from
+// the secrets store, never from this file.
+type CalendarAccountConfig struct {
+	// UserID is the owning user; 0 is the account used with auth disabled.
+	UserID int64 `yaml:"userId" json:"userId"`
+	// URL is the CalDAV calendar collection, e.g.
+	// "https://dav.example.com/calendars/me/work/".
+	URL      string `yaml:"url" json:"url"`
+	Username string `yaml:"username" json:"username"`
+	// PasswordSecret names the secret holding the CalDAV password.
+	PasswordSecret string `yaml:"passwordSecret" json:"passwordSecret"`
+}
+
+// ServerConfig controls the agentd HTTP listener.
+type ServerConfig struct {
+	// Addr is the listen address. Default: ":32180".
+	Addr string `yaml:"addr" json:"addr"`
+	// HTTP2 negotiates HTTP/2 over TLS. Nil means enabled.
+	HTTP2 *bool `yaml:"http2" json:"http2"`
+	// H2C serves cleartext HTTP/2 when TLS is off, for callers behind a
+	// TLS-terminating proxy or service mesh.
+	H2C  bool            `yaml:"h2c" json:"h2c"`
+	TLS  ServerTLSConfig `yaml:"tls" json:"tls"`
+	CORS CORSConfig      `yaml:"cors" json:"cors"`
+}
+
+// CORSConfig controls cross-origin access to the API. The bundled UI is
+// served from the same origin and needs none of it.
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as "https://app.example.com" that
+	// may call the API. "*" allows any origin, but only without credentials.
+	AllowedOrigins []string `yaml:"allowedOrigins" json:"allowedOrigins"`
+	// AllowCredentials lets allowed origins send cookies and Authorization.
+	AllowCredentials bool `yaml:"allowCredentials" json:"allowCredentials"`
+	// AllowedHeaders are request headers allowed besides Accept,
+	// Authorization, Content-Type and Last-Event-ID.
+	AllowedHeaders []string `yaml:"allowedHeaders" json:"allowedHeaders"`
+	// ExposedHeaders are response headers readable by scripts besides
+	// X-Run-ID and X-Chat-Session-ID.
+	ExposedHeaders []string `yaml:"exposedHeaders" json:"exposedHeaders"`
+	// MaxAgeSeconds is how long browsers cache a preflight. Default: 600.
+	MaxAgeSeconds int `yaml:"maxAgeSeconds" json:"maxAgeSeconds"`
+}
+
+// ServerTLSConfig enables native TLS with either a certificate pair or ACME.
+type ServerTLSConfig struct {
+	CertFile string     `yaml:"certFile" json:"certFile"`
+	KeyFile  string     `yaml:"keyFile" json:"keyFile"`
+	ACME     ACMEConfig `yaml:"acme" json:"acme"`
+	// ClientCAFile enables mTLS: client certificates are verified against
+	// this PEM bundle.
+	ClientCAFile string `yaml:"clientCAFile" json:"clientCAFile"`
+	// ClientAuth is "require" (default) or "optional". With "optional",
+	// clients without a certificate are let through to the normal auth.
+	ClientAuth string `yaml:"clientAuth" json:"clientAuth"`
+	// MinVersion is "1.2" (default) or "1.3".
+	MinVersion string `yaml:"minVersion" json:"minVersion"`
+}
+
+// ACMEConfig obtains certificates automatically, e.g. from Let's Encrypt.
+type ACMEConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Domains are the host names certificates are issued for. Required.
+	Domains []string `yaml:"domains" json:"domains"`
+	Email   string   `yaml:"email" json:"email"`
+	// CacheDir stores issued certificates. Default: "$workdir/.acme".
+	CacheDir string `yaml:"cacheDir" json:"cacheDir"`
+	// DirectoryURL overrides the ACME directory, e.g. for a staging or
+	// private CA. Empty uses Let's Encrypt.
+	DirectoryURL string `yaml:"directoryURL" json:"directoryURL"`
+	// HTTPAddr serves HTTP-01 challenges and redirects plain HTTP to HTTPS.
+	// Empty leaves only TLS-ALPN-01 challenges on the TLS listener.
+	HTTPAddr string `yaml:"httpAddr" json:"httpAddr"`
+}
+
+// GRPCConfig controls the gRPC API described in internal/grpcapi.
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Addr is the listen address. Default: ":32181".
+	Addr string `yaml:"addr" json:"addr"`
+	// The listener uses the server.tls certificate and mTLS settings and
+	// refuses to start without them unless Plaintext is set. Plaintext is
+	// only safe on loopback or behind a TLS-terminating proxy.
+	Plaintext bool `yaml:"plaintext" json:"plaintext"`
+}
+
+// WebhookConfig maps an inbound /api/hooks/{id} endpoint to a workflow or an
+// agent prompt. Exactly one of Workflow and Prompt should be set.
+type WebhookConfig struct {
+	// ID is the path segment after /api/hooks/.
+	ID string `yaml:"id" json:"id"`
+	// Secret is the HMAC-SHA256 key used to verify request bodies. Required.
+	Secret string `yaml:"secret" json:"-"`
+	// SignatureHeader carries the hex digest, optionally prefixed with
+	// "sha256=". Default: X-Hub-Signature-256.
+	SignatureHeader string `yaml:"signatureHeader" json:"signatureHeader"`
+	// Workflow is the ID of the flow workflow to run with the extracted
+	// attributes as input.
+	Workflow string `yaml:"workflow" json:"workflow"`
+	// Prompt is a text/template rendered with the extracted attributes and
+	// sent to the orchestrator agent.
+	Prompt string `yaml:"prompt" json:"prompt"`
+	// Attributes maps attribute names to text/templates rendered against the
+	// decoded JSON body, e.g. {{.pull_request.title}}.
+	Attributes map[string]string `yaml:"attributes" json:"attributes"`
+	// UserID owns the triggered runs and resolves Workflow. Default: the
+	// system user.
+	UserID int64 `yaml:"userID" json:"userID"`
+	// RateLimitPerMinute caps accepted deliveries. 0 disables the limit.
+	RateLimitPerMinute int `yaml:"rateLimitPerMinute" json:"rateLimitPerMinute"`
+}
+
+// MacroToolConfig defines a composite tool registered under Name that runs
+// Steps in order. Step arguments are rendered as text/templates with .args
+// (the macro's arguments) and .steps (earlier step results by ID).
+type MacroToolConfig struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	// Parameters is the JSON schema object for the macro's arguments.
+	Parameters map[string]any  `yaml:"parameters" json:"parameters"`
+	Steps      []MacroToolStep `yaml:"steps" json:"steps"`
+	// Output optionally renders the macro's result from the same data;
+	// by default the last step's result is returned.
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// MacroToolStep calls one tool. ID names its result for later steps and
+// defaults to the tool name.
+type MacroToolStep struct {
+	ID   string         `yaml:"id,omitempty" json:"id,omitempty"`
+	Tool string         `yaml:"tool" json:"tool"`
+	Args map[string]any `yaml:"args" json:"args"`
+}
+
+// NotificationsConfig lists endpoints that receive run lifecycle events
+// (run_started, tool_error, run_completed, run_failed).
+type NotificationsConfig struct {
+	Targets []NotificationTarget `yaml:"targets" json:"targets"`
+}
+
+// NotificationTarget is one webhook or Slack-compatible endpoint. Empty
+// filters match every event.
+type NotificationTarget struct {
+	Name string `yaml:"name" json:"name"`
+	// URL receives a POST per matching event.
+	URL string `yaml:"url" json:"-"`
+	// Format is "json" (default) or "slack". Slack targets receive
+	// {"text": <rendered template>}.
+	Format string `yaml:"format" json:"format"`
+	// Template is a text/template rendered with the event. JSON targets
+	// send it as the body; without one they send the event as JSON.
+	Template string `yaml:"template" json:"template"`
+	// Headers are added to every request, e.g. an Authorization token.
+	Headers map[string]string `yaml:"headers" json:"-"`
+	// Events limits the target to these event types.
+	Events []string `yaml:"events" json:"events"`
+	// Users limits the target to runs owned by these user IDs.
+	Users []int64 `yaml:"users" json:"users"`
+	// Schedules limits the target to runs started by these triggers:
+	// "hook:<id>" for webhooks and "workflow:<id>" for workflow runs.
+	Schedules []string `yaml:"schedules" json:"schedules"`
+	// MaxRetries bounds retries of failed deliveries. Default: 3.
+	MaxRetries int `yaml:"maxRetries" json:"maxRetries"`
+	// TimeoutSeconds bounds each request. Default: 10.
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds"`
+}
+
+// ToolCacheConfig configures the tool-result cache consulted by the agent
+// engine before dispatching a tool call. Only tools listed in Tools are cached.
+type ToolCacheConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend selects the cache store: "memory" (default) or "redis".
+	Backend string `yaml:"backend" json:"backend"`
+	// MaxEntries bounds the in-memory LRU. Default: 1000.
+	MaxEntries int `yaml:"maxEntries" json:"maxEntries"`
+	// DefaultTTLSeconds applies to tools that do not set ttlSeconds. Default: 600.
+	DefaultTTLSeconds int `yaml:"defaultTTLSeconds" json:"defaultTTLSeconds"`
+	// Redis configures the redis backend.
+	Redis ToolCacheRedisConfig `yaml:"redis" json:"redis"`
+	// Tools maps tool names to their cache policy.
+	Tools map[string]ToolCachePolicy `yaml:"tools" json:"tools"`
+}
+
+// ToolCacheRedisConfig holds connection settings for the redis cache backend.
+type ToolCacheRedisConfig struct {
+	Addr     string `yaml:"addr" json:"addr"`
+	Password string `yaml:"password" json:"password"`
+	DB       int    `yaml:"db" json:"db"`
+	// KeyPrefix namespaces cache keys. Default: "manifold:toolcache:".
+	KeyPrefix string `yaml:"keyPrefix" json:"keyPrefix"`
+}
+
+// ToolCachePolicy controls caching for a single tool.
+type ToolCachePolicy struct {
+	// TTLSeconds overrides toolCache.defaultTTLSeconds for this tool.
+	TTLSeconds int `yaml:"ttlSeconds" json:"ttlSeconds"`
+	// InvalidateOn lists tools whose successful execution clears this tool's
+	// cached results (for example file_write invalidating file_read).
+	InvalidateOn []string `yaml:"invalidateOn" json:"invalidateOn"`
+}
+
+// I18nConfig sets deployment-wide locale defaults. Users may override both
+// values through their preferences.
+type I18nConfig struct {
+	// DefaultLocale is a BCP 47 tag (e.g. "en", "de-DE") used when neither the
+	// user's preferences nor the Accept-Language header select a locale.
+	// Default: "en".
+	DefaultLocale string `yaml:"defaultLocale" json:"defaultLocale"`
+	// TimeZone is an IANA zone name used when formatting dates in prompts.
+	// Default: "UTC".
+	TimeZone string `yaml:"timeZone" json:"timeZone"`
+}
+
+// ToolRelevanceConfig scores tool results by embedding similarity to the
+// latest user message (using the embedding endpoint) and drops or truncates
+// the least relevant ones first when the context is over budget.
+type ToolRelevanceConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DropBelow is the similarity under which a result is replaced by a
+	// short marker. Default: 0.2.
+	DropBelow float64 `yaml:"dropBelow" json:"dropBelow"`
+	// CompressBelow is the similarity under which a result is truncated.
+	// Default: 0.45.
+	CompressBelow float64 `yaml:"compressBelow" json:"compressBelow"`
+	// CompressChars is how many characters of a truncated result are kept.
+	// Default: 400.
+	CompressChars int `yaml:"compressChars" json:"compressChars"`
+}
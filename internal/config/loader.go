@@ -1,12 +1,15 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	yaml "gopkg.in/yaml.v3"
@@ -47,6 +50,10 @@ func loadMainConfig(path string, cfg *Config) error {
 		return err
 	}
 	if err := yaml.Unmarshal(data, cfg); err != nil {
+		// Report every mistyped field rather than only the first.
+		if problems := typeProblems(path, data); len(problems) > 0 {
+			return problems
+		}
 		return fmt.Errorf("%s: could not parse configuration: %w", path, err)
 	}
 
@@ -205,6 +212,21 @@ func applyDefaults(cfg *Config) {
 	if cfg.LLMClient.OpenAI.API == "" {
 		cfg.LLMClient.OpenAI.API = "completions"
 	}
+	if cfg.LLMClient.Resilience.MaxRetries <= 0 {
+		cfg.LLMClient.Resilience.MaxRetries = 2
+	}
+	if cfg.LLMClient.Resilience.InitialBackoffMillis <= 0 {
+		cfg.LLMClient.Resilience.InitialBackoffMillis = 500
+	}
+	if cfg.LLMClient.Resilience.MaxBackoffMillis <= 0 {
+		cfg.LLMClient.Resilience.MaxBackoffMillis = 8000
+	}
+	if cfg.LLMClient.Resilience.BreakerFailureThreshold <= 0 {
+		cfg.LLMClient.Resilience.BreakerFailureThreshold = 5
+	}
+	if cfg.LLMClient.Resilience.BreakerCooldownSeconds <= 0 {
+		cfg.LLMClient.Resilience.BreakerCooldownSeconds = 30
+	}
 	if cfg.Obs.ServiceName == "" {
 		cfg.Obs.ServiceName = "manifold"
 	}
@@ -244,9 +266,90 @@ func applyDefaults(cfg *Config) {
 	if cfg.Web.SearXNGURL == "" {
 		cfg.Web.SearXNGURL = "http://localhost:8080"
 	}
+	if cfg.Web.Browser.PoolSize <= 0 {
+		cfg.Web.Browser.PoolSize = 2
+	}
+	if cfg.Web.Browser.TimeoutSeconds <= 0 {
+		cfg.Web.Browser.TimeoutSeconds = 30
+	}
+	if cfg.Web.HTTP.TimeoutSeconds <= 0 {
+		cfg.Web.HTTP.TimeoutSeconds = 30
+	}
+	if cfg.Web.HTTP.MaxResponseBytes <= 0 {
+		cfg.Web.HTTP.MaxResponseBytes = 1 << 20
+	}
 	if cfg.Exec.MaxCommandSeconds <= 0 {
 		cfg.Exec.MaxCommandSeconds = 30
 	}
+	if cfg.Exec.Container.Runtime == "" {
+		cfg.Exec.Container.Runtime = "docker"
+	}
+	if cfg.Exec.Container.Image == "" {
+		cfg.Exec.Container.Image = "alpine:3"
+	}
+	if cfg.Exec.Container.Network == "" {
+		cfg.Exec.Container.Network = "none"
+	}
+	if cfg.Exec.Python.Interpreter == "" {
+		cfg.Exec.Python.Interpreter = "python3"
+	}
+	if cfg.Exec.Python.Image == "" {
+		cfg.Exec.Python.Image = "python:3-slim"
+	}
+	if cfg.Exec.Python.TimeoutSeconds <= 0 {
+		cfg.Exec.Python.TimeoutSeconds = 60
+	}
+	if cfg.Exec.Python.MemoryMB <= 0 {
+		cfg.Exec.Python.MemoryMB = 1024
+	}
+	if cfg.Exec.Git.AuthorName == "" {
+		cfg.Exec.Git.AuthorName = "Manifold Agent"
+	}
+	if cfg.Exec.Git.AuthorEmail == "" {
+		cfg.Exec.Git.AuthorEmail = "agent@manifold.local"
+	}
+	if cfg.Exec.CodeSearch.MaxFiles <= 0 {
+		cfg.Exec.CodeSearch.MaxFiles = 5000
+	}
+	if cfg.Exec.CodeSearch.MaxFileBytes <= 0 {
+		cfg.Exec.CodeSearch.MaxFileBytes = 512 << 10
+	}
+	if cfg.StorageGC.IntervalMinutes <= 0 {
+		cfg.StorageGC.IntervalMinutes = 60
+	}
+	if cfg.StorageGC.CLIArtifactsHours == 0 {
+		cfg.StorageGC.CLIArtifactsHours = 168
+	}
+	if cfg.StorageGC.PlaygroundArtifactsHours == 0 {
+		cfg.StorageGC.PlaygroundArtifactsHours = 720
+	}
+	if cfg.StorageGC.UploadTempHours == 0 {
+		cfg.StorageGC.UploadTempHours = 24
+	}
+	if strings.TrimSpace(cfg.Pricing.Currency) == "" {
+		cfg.Pricing.Currency = "USD"
+	}
+	if cfg.SpecialistHealth.IntervalSeconds <= 0 {
+		cfg.SpecialistHealth.IntervalSeconds = 300
+	}
+	if cfg.SpecialistHealth.TimeoutSeconds <= 0 {
+		cfg.SpecialistHealth.TimeoutSeconds = 10
+	}
+	if cfg.SpecialistHealth.DegradedLatencyMs <= 0 {
+		cfg.SpecialistHealth.DegradedLatencyMs = 3000
+	}
+	if cfg.SpecialistRouting.MinConfidence <= 0 {
+		cfg.SpecialistRouting.MinConfidence = 0.5
+	}
+	if cfg.SpecialistRouting.MinMargin <= 0 {
+		cfg.SpecialistRouting.MinMargin = 0.05
+	}
+	if cfg.Calendar.WorkdayStart == "" {
+		cfg.Calendar.WorkdayStart = "09:00"
+	}
+	if cfg.Calendar.WorkdayEnd == "" {
+		cfg.Calendar.WorkdayEnd = "17:00"
+	}
 	if cfg.OutputTruncateByte <= 0 {
 		cfg.OutputTruncateByte = 64 * 1024
 	}
@@ -265,12 +368,139 @@ func applyDefaults(cfg *Config) {
 	if cfg.WorkflowTimeoutSeconds < 0 {
 		cfg.WorkflowTimeoutSeconds = 0
 	}
+	if cfg.ShutdownDrainSeconds <= 0 {
+		cfg.ShutdownDrainSeconds = 30
+	}
+	if cfg.RunQueue.QueueTimeoutSeconds <= 0 {
+		cfg.RunQueue.QueueTimeoutSeconds = 60
+	}
 	if cfg.Tokenization.CacheSize <= 0 {
 		cfg.Tokenization.CacheSize = 1000
 	}
 	if cfg.Tokenization.CacheTTLSeconds <= 0 {
 		cfg.Tokenization.CacheTTLSeconds = 3600
 	}
+	if cfg.ToolCache.Backend == "" {
+		cfg.ToolCache.Backend = "memory"
+	}
+	if cfg.ToolCache.MaxEntries <= 0 {
+		cfg.ToolCache.MaxEntries = 1000
+	}
+	if cfg.ToolCache.DefaultTTLSeconds <= 0 {
+		cfg.ToolCache.DefaultTTLSeconds = 600
+	}
+	if cfg.ToolCache.Redis.KeyPrefix == "" {
+		cfg.ToolCache.Redis.KeyPrefix = "manifold:toolcache:"
+	}
+	if cfg.I18n.DefaultLocale == "" {
+		cfg.I18n.DefaultLocale = "en"
+	}
+	if cfg.I18n.TimeZone == "" {
+		cfg.I18n.TimeZone = "UTC"
+	}
+	if cfg.RAG.Alpha <= 0 || cfg.RAG.Alpha > 1 {
+		cfg.RAG.Alpha = 0.5
+	}
+	if cfg.RAG.Rerank.Candidates <= 0 {
+		cfg.RAG.Rerank.Candidates = 30
+	}
+	if cfg.Warmup.TimeoutSeconds <= 0 {
+		cfg.Warmup.TimeoutSeconds = 120
+	}
+	if cfg.TTS.CooldownSeconds <= 0 {
+		cfg.TTS.CooldownSeconds = 30
+	}
+	if cfg.STT.MaxConcurrent < 0 {
+		cfg.STT.MaxConcurrent = 0
+	}
+	if cfg.STT.QueueTimeoutSeconds <= 0 {
+		cfg.STT.QueueTimeoutSeconds = 30
+	}
+	if cfg.STT.FFmpegPath == "" {
+		cfg.STT.FFmpegPath = "ffmpeg"
+	}
+	if cfg.STT.Stream.VADThreshold <= 0 || cfg.STT.Stream.VADThreshold >= 1 {
+		cfg.STT.Stream.VADThreshold = 0.015
+	}
+	if cfg.STT.Stream.SilenceMs <= 0 {
+		cfg.STT.Stream.SilenceMs = 700
+	}
+	if cfg.STT.Stream.PartialIntervalMs <= 0 {
+		cfg.STT.Stream.PartialIntervalMs = 1000
+	}
+	if cfg.STT.Stream.MaxSegmentSeconds <= 0 {
+		cfg.STT.Stream.MaxSegmentSeconds = 30
+	}
+	if cfg.OCR.Backend == "" {
+		cfg.OCR.Backend = "tesseract"
+	}
+	if cfg.OCR.TesseractPath == "" {
+		cfg.OCR.TesseractPath = "tesseract"
+	}
+	if cfg.OCR.Languages == "" {
+		cfg.OCR.Languages = "eng"
+	}
+	if cfg.OCR.TimeoutSeconds <= 0 {
+		cfg.OCR.TimeoutSeconds = 120
+	}
+	if cfg.OCR.MinPageChars <= 0 {
+		cfg.OCR.MinPageChars = 16
+	}
+	if len(cfg.Verifier.Checks) == 0 {
+		cfg.Verifier.Checks = []string{"empty", "refusal", "schema"}
+	}
+	if cfg.Verifier.MinAnswerChars <= 0 {
+		cfg.Verifier.MinAnswerChars = 1
+	}
+	if cfg.ToolApproval.TimeoutSeconds <= 0 {
+		cfg.ToolApproval.TimeoutSeconds = 300
+	}
+	if cfg.ToolTimeouts.DefaultSeconds == 0 {
+		cfg.ToolTimeouts.DefaultSeconds = 300
+	}
+	// run_cli enforces exec.maxCommandSeconds itself. Unless run_cli has its
+	// own entry, keep the default tool limit from cutting longer commands
+	// short, leaving a few seconds to kill the command and collect output.
+	if _, ok := cfg.ToolTimeouts.Tools["run_cli"]; !ok && cfg.ToolTimeouts.DefaultSeconds > 0 && cfg.Exec.MaxCommandSeconds >= cfg.ToolTimeouts.DefaultSeconds {
+		if cfg.ToolTimeouts.Tools == nil {
+			cfg.ToolTimeouts.Tools = map[string]int{}
+		}
+		cfg.ToolTimeouts.Tools["run_cli"] = cfg.Exec.MaxCommandSeconds + 5
+	}
+	if cfg.LiveSessions.MaxParticipants <= 0 {
+		cfg.LiveSessions.MaxParticipants = 8
+	}
+	if strings.TrimSpace(cfg.Server.Addr) == "" {
+		cfg.Server.Addr = ":32180"
+	}
+	if cfg.Server.TLS.ClientCAFile != "" && cfg.Server.TLS.ClientAuth == "" {
+		cfg.Server.TLS.ClientAuth = "require"
+	}
+	if cfg.Server.CORS.MaxAgeSeconds <= 0 {
+		cfg.Server.CORS.MaxAgeSeconds = 600
+	}
+	if cfg.Server.TLS.MinVersion == "" {
+		cfg.Server.TLS.MinVersion = "1.2"
+	}
+	if strings.TrimSpace(cfg.GRPC.Addr) == "" {
+		cfg.GRPC.Addr = ":32181"
+	}
+	for i := range cfg.Notifications.Targets {
+		if cfg.Notifications.Targets[i].MaxRetries <= 0 {
+			cfg.Notifications.Targets[i].MaxRetries = 3
+		}
+	}
+	for i := range cfg.Webhooks {
+		if strings.TrimSpace(cfg.Webhooks[i].SignatureHeader) == "" {
+			cfg.Webhooks[i].SignatureHeader = "X-Hub-Signature-256"
+		}
+	}
+	if len(cfg.PIIScrubbing.Detectors) == 0 {
+		cfg.PIIScrubbing.Detectors = []string{"email", "phone", "credit_card"}
+	}
+	if cfg.PIIScrubbing.Mask == "" {
+		cfg.PIIScrubbing.Mask = "[redacted:%s]"
+	}
 	if cfg.Embedding.BaseURL == "" {
 		cfg.Embedding.BaseURL = "https://api.openai.com"
 	}
@@ -281,11 +511,46 @@ func applyDefaults(cfg *Config) {
 		cfg.Embedding.APIHeader = "Authorization"
 	}
 	if cfg.Embedding.Path == "" {
-		cfg.Embedding.Path = "/v1/embeddings"
+		if strings.EqualFold(strings.TrimSpace(cfg.Embedding.Provider), "ollama") {
+			cfg.Embedding.Path = "/api/embed"
+		} else {
+			cfg.Embedding.Path = "/v1/embeddings"
+		}
 	}
 	if cfg.Embedding.Timeout <= 0 {
 		cfg.Embedding.Timeout = 30
 	}
+	if cfg.Embedding.MaxRetries <= 0 {
+		cfg.Embedding.MaxRetries = 2
+	}
+	if cfg.Embedding.HealthCheckSeconds <= 0 {
+		cfg.Embedding.HealthCheckSeconds = 30
+	}
+	if cfg.Embedding.QueueSize == 0 {
+		cfg.Embedding.QueueSize = 10000
+	}
+	for i := range cfg.Embedding.Fallbacks {
+		fb := &cfg.Embedding.Fallbacks[i]
+		if fb.Model == "" {
+			fb.Model = cfg.Embedding.Model
+		}
+		if fb.APIHeader == "" {
+			fb.APIHeader = "Authorization"
+		}
+		if fb.Path == "" {
+			if strings.EqualFold(strings.TrimSpace(fb.Provider), "ollama") {
+				fb.Path = "/api/embed"
+			} else {
+				fb.Path = "/v1/embeddings"
+			}
+		}
+		if fb.Timeout <= 0 {
+			fb.Timeout = cfg.Embedding.Timeout
+		}
+		if fb.MaxRetries <= 0 {
+			fb.MaxRetries = cfg.Embedding.MaxRetries
+		}
+	}
 	for i := range cfg.MCP.Servers {
 		if cfg.MCP.Servers[i].HTTP.TimeoutSeconds <= 0 {
 			cfg.MCP.Servers[i].HTTP.TimeoutSeconds = 30
@@ -312,8 +577,17 @@ func applyDefaults(cfg *Config) {
 			cfg.Databases.Graph.Backend = "memory"
 		}
 	}
+	cfg.Databases.Backend = strings.ToLower(strings.TrimSpace(cfg.Databases.Backend))
+	if cfg.Databases.Backend == "sqlite" && strings.TrimSpace(cfg.Databases.SQLite.Path) == "" {
+		cfg.Databases.SQLite.Path = "data/manifold.db"
+	}
+	if cfg.Databases.Backend == "file" && strings.TrimSpace(cfg.Databases.File.Dir) == "" {
+		cfg.Databases.File.Dir = "data"
+	}
 	if cfg.Databases.Chat.Backend == "" {
-		if cfg.Databases.DefaultDSN != "" {
+		if cfg.Databases.Backend == "sqlite" || cfg.Databases.Backend == "file" {
+			cfg.Databases.Chat.Backend = cfg.Databases.Backend
+		} else if cfg.Databases.DefaultDSN != "" {
 			cfg.Databases.Chat.Backend = "auto"
 		} else {
 			cfg.Databases.Chat.Backend = "memory"
@@ -341,6 +615,12 @@ func applyDerivedConfig(cfg *Config) {
 	if cfg.LLMClient.Provider == "local" {
 		cfg.LLMClient.OpenAI.API = "completions"
 	}
+	if fb := cfg.LLMClient.Fallback; fb != nil {
+		fb.Provider = strings.ToLower(strings.TrimSpace(fb.Provider))
+		if fb.Provider == "local" {
+			fb.OpenAI.API = "completions"
+		}
+	}
 	cfg.OpenAI = cfg.LLMClient.OpenAI
 
 	for i := range cfg.Specialists {
@@ -354,6 +634,16 @@ func validateConfig(cfg *Config) error {
 	if err := validateProvider("llm_client.provider", cfg.LLMClient.Provider); err != nil {
 		return err
 	}
+	for i, route := range cfg.LLMClient.Routing.Routes {
+		if err := validateProvider(fmt.Sprintf("llm_client.routing.routes[%d].client.provider", i), strings.ToLower(strings.TrimSpace(route.Client.Provider))); err != nil {
+			return err
+		}
+	}
+	if fb := cfg.LLMClient.Fallback; fb != nil && fb.Provider != "" {
+		if err := validateProvider("llm_client.fallback.provider", fb.Provider); err != nil {
+			return err
+		}
+	}
 	if cfg.EvolvingMemory.Provider != "" {
 		if err := validateProvider("evolvingMemory.provider", cfg.EvolvingMemory.Provider); err != nil {
 			return err
@@ -401,7 +691,210 @@ func validateConfig(cfg *Config) error {
 			return fmt.Errorf("exec.blockBinaries must contain bare binary names only (no paths): %q", binary)
 		}
 	}
+	switch cfg.Exec.Container.Runtime {
+	case "docker", "podman":
+	default:
+		return fmt.Errorf("exec.container.runtime must be docker or podman, got %q", cfg.Exec.Container.Runtime)
+	}
+	for _, sc := range cfg.Specialists {
+		switch sc.ExecBackend {
+		case "", "host", "container":
+		default:
+			return fmt.Errorf("specialist %q: execBackend must be host or container, got %q", sc.Name, sc.ExecBackend)
+		}
+	}
+	if cfg.Projects.MaxBytesPerUser < 0 {
+		return fmt.Errorf("projects.maxBytesPerUser must not be negative")
+	}
+	if cfg.StorageGC.CLIArtifactsHours < 0 || cfg.StorageGC.PlaygroundArtifactsHours < 0 || cfg.StorageGC.UploadTempHours < 0 {
+		return fmt.Errorf("storageGC retention hours must not be negative")
+	}
+	for model, price := range cfg.Playground.Prices {
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			return fmt.Errorf("playground.prices[%q] must not be negative", model)
+		}
+	}
+	for model, price := range cfg.Pricing.Models {
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			return fmt.Errorf("pricing.models[%q] must not be negative", model)
+		}
+	}
+	if err := validateQuotaLimits("quotas.default", cfg.Quotas.Default); err != nil {
+		return err
+	}
+	for role, l := range cfg.Quotas.Roles {
+		if err := validateQuotaLimits(fmt.Sprintf("quotas.roles[%q]", role), l); err != nil {
+			return err
+		}
+	}
+	for email, l := range cfg.Quotas.Users {
+		if err := validateQuotaLimits(fmt.Sprintf("quotas.users[%q]", email), l); err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.SpecialistRouting.Mode)) {
+	case "", "rules", "embedding", "classifier":
+	default:
+		return fmt.Errorf("specialistRouting.mode must be rules, embedding or classifier")
+	}
+	if cfg.SpecialistRouting.MinConfidence > 1 {
+		return fmt.Errorf("specialistRouting.minConfidence must be at most 1")
+	}
+	if q := cfg.RunQueue; q.MaxConcurrent < 0 || q.QueueDepth < 0 {
+		return fmt.Errorf("runQueue: values must not be negative")
+	}
+	negativeLimit := func(l SpecialistLimit) bool {
+		return l.MaxConcurrent < 0 || l.QueueDepth < 0 || l.QueueTimeoutSeconds < 0
+	}
+	if negativeLimit(cfg.SpecialistLimits.Default) {
+		return fmt.Errorf("specialistLimits.default: values must not be negative")
+	}
+	for name, l := range cfg.SpecialistLimits.Specialists {
+		if negativeLimit(l) {
+			return fmt.Errorf("specialistLimits.specialists.%s: values must not be negative", name)
+		}
+	}
+	ttsProviders := map[string]bool{"default": true}
+	for i, p := range cfg.TTS.Providers {
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			return fmt.Errorf("tts.providers[%d].name is required", i)
+		}
+		if ttsProviders[name] {
+			return fmt.Errorf("tts.providers: duplicate name %q", name)
+		}
+		ttsProviders[name] = true
+		switch p.Type {
+		case "openai", "elevenlabs", "piper":
+		default:
+			return fmt.Errorf("tts.providers[%q].type must be openai, elevenlabs or piper, got %q", name, p.Type)
+		}
+		if strings.TrimSpace(p.BaseURL) == "" && p.Type != "openai" {
+			return fmt.Errorf("tts.providers[%q].baseURL is required", name)
+		}
+	}
+	for name, v := range cfg.TTS.Voices {
+		if !ttsProviders[strings.TrimSpace(v.Provider)] {
+			return fmt.Errorf("tts.voices.%s: unknown provider %q", name, v.Provider)
+		}
+	}
+	seenEvaluators := map[string]bool{}
+	for i, ev := range cfg.Playground.Evaluators {
+		name := strings.ToLower(strings.TrimSpace(ev.Name))
+		if name == "" {
+			return fmt.Errorf("playground.evaluators[%d].name is required", i)
+		}
+		if seenEvaluators[name] {
+			return fmt.Errorf("playground.evaluators: duplicate name %q", ev.Name)
+		}
+		seenEvaluators[name] = true
+		if (len(ev.Command) == 0) == (strings.TrimSpace(ev.URL) == "") {
+			return fmt.Errorf("playground.evaluators[%q] needs exactly one of command or url", ev.Name)
+		}
+		if ev.TimeoutSeconds < 0 {
+			return fmt.Errorf("playground.evaluators[%q].timeoutSeconds must not be negative", ev.Name)
+		}
+	}
+	if k := strings.TrimSpace(cfg.Secrets.MasterKey); k != "" {
+		if raw, err := base64.StdEncoding.DecodeString(k); err != nil || len(raw) != 32 {
+			return fmt.Errorf("secrets.masterKey must be a base64-encoded 32-byte key")
+		}
+	}
+	seenEmailUsers := map[int64]bool{}
+	for _, acct := range cfg.Email.Accounts {
+		if seenEmailUsers[acct.UserID] {
+			return fmt.Errorf("email.accounts: duplicate account for user %d", acct.UserID)
+		}
+		seenEmailUsers[acct.UserID] = true
+		if cfg.Email.Enabled && strings.TrimSpace(acct.PasswordSecret) == "" {
+			return fmt.Errorf("email.accounts: user %d: passwordSecret is required", acct.UserID)
+		}
+	}
+	if tz := strings.TrimSpace(cfg.Calendar.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("calendar.timezone: %w", err)
+		}
+	}
+	start, errStart := time.Parse("15:04", cfg.Calendar.WorkdayStart)
+	end, errEnd := time.Parse("15:04", cfg.Calendar.WorkdayEnd)
+	if errStart != nil || errEnd != nil || !start.Before(end) {
+		return fmt.Errorf("calendar.workdayStart and calendar.workdayEnd must be HH:MM with start before end")
+	}
+	seenCalendarUsers := map[int64]bool{}
+	for _, acct := range cfg.Calendar.Accounts {
+		if seenCalendarUsers[acct.UserID] {
+			return fmt.Errorf("calendar.accounts: duplicate account for user %d", acct.UserID)
+		}
+		seenCalendarUsers[acct.UserID] = true
+		if !strings.HasPrefix(acct.URL, "https://") && !strings.HasPrefix(acct.URL, "http://") {
+			return fmt.Errorf("calendar.accounts: user %d: url must be an http(s) URL", acct.UserID)
+		}
+	}
+	if err := validateServer(cfg.Server); err != nil {
+		return err
+	}
+	if cfg.Databases.Backend == "sqlite" && !sqliteDriverBuilt {
+		return errors.New(`databases.backend: sqlite needs a binary built with cgo (CGO_ENABLED=1); use "postgres" or "file" instead`)
+	}
+	seenCreds := map[string]bool{}
+	for _, c := range cfg.Web.HTTP.Credentials {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			return fmt.Errorf("web.http.credentials: name is required")
+		}
+		if seenCreds[name] {
+			return fmt.Errorf("web.http.credentials: duplicate name %q", name)
+		}
+		seenCreds[name] = true
+	}
+
+	return nil
+}
 
+func validateServer(s ServerConfig) error {
+	t := s.TLS
+	hasPair := t.CertFile != "" || t.KeyFile != ""
+	if hasPair && (t.CertFile == "" || t.KeyFile == "") {
+		return errors.New("server.tls: certFile and keyFile must be set together")
+	}
+	if hasPair && t.ACME.Enabled {
+		return errors.New("server.tls: use either certFile/keyFile or acme, not both")
+	}
+	if t.ACME.Enabled && len(t.ACME.Domains) == 0 {
+		return errors.New("server.tls.acme.domains is required")
+	}
+	if t.ClientCAFile != "" && !hasPair && !t.ACME.Enabled {
+		return errors.New("server.tls.clientCAFile requires certFile/keyFile or acme")
+	}
+	switch t.ClientAuth {
+	case "", "require", "optional":
+	default:
+		return fmt.Errorf("server.tls.clientAuth must be require or optional, got %q", t.ClientAuth)
+	}
+	switch t.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("server.tls.minVersion must be 1.2 or 1.3, got %q", t.MinVersion)
+	}
+	for _, origin := range s.CORS.AllowedOrigins {
+		if origin == "*" {
+			if s.CORS.AllowCredentials {
+				return errors.New("server.cors: allowCredentials cannot be used with the \"*\" origin")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			return fmt.Errorf("server.cors.allowedOrigins: %q must be scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+func validateQuotaLimits(path string, l QuotaLimits) error {
+	if l.Tokens < 0 || l.Cost < 0 || l.Runs < 0 {
+		return fmt.Errorf("%s limits must not be negative", path)
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -371,6 +372,87 @@ llm_client:
 	if !cfg.Tokenization.FallbackToHeuristic {
 		t.Fatalf("expected default tokenization fallback true")
 	}
+	if cfg.Server.Addr != ":32180" || cfg.Server.TLS.MinVersion != "1.2" {
+		t.Fatalf("expected default server settings, got %+v", cfg.Server)
+	}
+}
+
+func TestApplyDefaultsRunCLIToolTimeout(t *testing.T) {
+	cfg := &Config{Exec: ExecConfig{MaxCommandSeconds: 900}}
+	applyDefaults(cfg)
+	if got := cfg.ToolTimeouts.Tools["run_cli"]; got != 905 {
+		t.Fatalf("expected run_cli tool timeout to cover maxCommandSeconds, got %d", got)
+	}
+
+	cfg = &Config{Exec: ExecConfig{MaxCommandSeconds: 900}, ToolTimeouts: ToolTimeoutsConfig{Tools: map[string]int{"run_cli": 60}}}
+	applyDefaults(cfg)
+	if got := cfg.ToolTimeouts.Tools["run_cli"]; got != 60 {
+		t.Fatalf("expected explicit run_cli timeout to be kept, got %d", got)
+	}
+
+	cfg = &Config{}
+	applyDefaults(cfg)
+	if _, ok := cfg.ToolTimeouts.Tools["run_cli"]; ok {
+		t.Fatalf("expected no run_cli override for short commands, got %v", cfg.ToolTimeouts.Tools)
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	cases := map[string]ServerTLSConfig{
+		"half pair":      {CertFile: "tls.crt"},
+		"pair and acme":  {CertFile: "tls.crt", KeyFile: "tls.key", ACME: ACMEConfig{Enabled: true, Domains: []string{"a.example"}}},
+		"acme no domain": {ACME: ACMEConfig{Enabled: true}},
+		"mtls no tls":    {ClientCAFile: "ca.pem"},
+		"bad clientAuth": {CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.pem", ClientAuth: "maybe"},
+	}
+	for name, tlsCfg := range cases {
+		if err := validateServer(ServerConfig{TLS: tlsCfg}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	corsCases := map[string]CORSConfig{
+		"wildcard with credentials": {AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		"origin with path":          {AllowedOrigins: []string{"https://app.example.com/ui"}},
+		"origin without scheme":     {AllowedOrigins: []string{"app.example.com"}},
+	}
+	for name, cors := range corsCases {
+		if err := validateServer(ServerConfig{CORS: cors}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	ok := ServerTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.pem", ClientAuth: "optional", MinVersion: "1.3"}
+	cors := CORSConfig{AllowedOrigins: []string{"https://app.example.com", "http://localhost:5173/"}, AllowCredentials: true}
+	if err := validateServer(ServerConfig{TLS: ok, CORS: cors}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_SQLiteBackendNeedsDriver(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	t.Setenv("OPENAI_API_KEY", "dummy")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(`workdir: .
+llm_client:
+  provider: openai
+  openai:
+    apiKey: "${OPENAI_API_KEY}"
+databases:
+  backend: sqlite
+`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	built := sqliteDriverBuilt
+	t.Cleanup(func() { sqliteDriverBuilt = built })
+	sqliteDriverBuilt = false
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "cgo") {
+		t.Fatalf("expected sqlite backend to be rejected without the driver, got %v", err)
+	}
+	sqliteDriverBuilt = true
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
 }
 
 func TestLoad_MissingRequiredFields(t *testing.T) {
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Problem is a configuration issue located in a YAML file.
+type Problem struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	// Path is the key path, e.g. "specialists[1].allowTools".
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	// Unknown marks keys the loader ignores. They do not stop startup, but
+	// usually mean a misspelling.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.File != "" {
+		b.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+		}
+		b.WriteString(": ")
+	}
+	if p.Path != "" {
+		b.WriteString(p.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Problems is returned by Load when configuration values have the wrong
+// type, so every bad field is reported at once instead of the first.
+type Problems []Problem
+
+func (ps Problems) Error() string {
+	lines := make([]string, len(ps))
+	for i, p := range ps {
+		lines[i] = p.String()
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// configAliases are top-level keys accepted besides Config's own fields.
+var configAliases = []string{"outputTruncateByte"}
+
+// CheckFiles checks config.yaml and the optional specialists.yaml and
+// mcp.yaml against the configuration schema. It reports every unknown key
+// and every value of the wrong type with its line; it does not apply
+// defaults or run the semantic checks Load does.
+func CheckFiles() ([]Problem, error) {
+	_ = godotenv.Overload()
+
+	configPath, err := findRequiredFile("config.yaml", "config.yml")
+	if err != nil {
+		return nil, err
+	}
+	problems, err := checkFile(configPath, reflect.TypeOf(Config{}), configAliases...)
+	if err != nil {
+		return nil, err
+	}
+
+	path, found, err := findOptionalConfigFile(os.Getenv("SPECIALISTS_CONFIG"), "specialists.yaml", "specialists.yml")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		ps, err := checkFile(path, reflect.TypeOf(specialistsFileSchema{}))
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+
+	path, found, err = findOptionalConfigFile(os.Getenv("MCP_CONFIG"), "mcp.yaml", "mcp.yml")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		ps, err := checkFile(path, reflect.TypeOf(mcpFileSchema{}))
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+	return problems, nil
+}
+
+// Validate checks the configuration files and then loads them, returning
+// the schema problems plus any error Load reports.
+func Validate() ([]Problem, error) {
+	problems, err := CheckFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		if !p.Unknown {
+			// Load would only repeat the first type error.
+			return problems, nil
+		}
+	}
+	if _, err := Load(); err != nil {
+		problems = append(problems, Problem{Message: err.Error()})
+	}
+	return problems, nil
+}
+
+type specialistsFileSchema struct {
+	Specialists []SpecialistConfig `yaml:"specialists"`
+	Routes      []SpecialistRoute  `yaml:"routes"`
+}
+
+type mcpFileSchema struct {
+	Servers []MCPServerConfig `yaml:"servers"`
+	MCP     MCPConfig         `yaml:"mcp"`
+}
+
+// checkFile parses the expanded YAML at path and walks it against schema.
+func checkFile(path string, schema reflect.Type, aliases ...string) ([]Problem, error) {
+	data, err := readExpandedYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	problems, err := checkYAML(data, schema, aliases...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not parse configuration: %w", path, err)
+	}
+	for i := range problems {
+		problems[i].File = path
+	}
+	return problems, nil
+}
+
+func checkYAML(data []byte, schema reflect.Type, aliases ...string) ([]Problem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	// specialists.yaml may also be a bare list of specialists.
+	if schema == reflect.TypeOf(specialistsFileSchema{}) && root.Kind == yaml.SequenceNode {
+		schema = reflect.TypeOf([]SpecialistConfig{})
+	}
+	c := &schemaChecker{aliases: aliases}
+	c.walk(root, schema, "")
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		if c.problems[i].Line != c.problems[j].Line {
+			return c.problems[i].Line < c.problems[j].Line
+		}
+		return c.problems[i].Column < c.problems[j].Column
+	})
+	return c.problems, nil
+}
+
+type schemaChecker struct {
+	aliases  []string
+	problems []Problem
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+func (c *schemaChecker) report(n *yaml.Node, path, msg string, unknown bool) {
+	c.problems = append(c.problems, Problem{Line: n.Line, Column: n.Column, Path: path, Message: msg, Unknown: unknown})
+}
+
+func (c *schemaChecker) walk(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n == nil || (n.Kind == yaml.ScalarNode && n.Tag == "!!null") {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(unmarshalerType) {
+		c.decode(n, t, path)
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			c.report(n, path, fmt.Sprintf("expected a mapping, got %s", nodeKind(n)), false)
+			return
+		}
+		c.walkStruct(n, t, path)
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			c.report(n, path, fmt.Sprintf("expected a mapping, got %s", nodeKind(n)), false)
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			c.walk(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value))
+		}
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yaml.SequenceNode {
+			c.report(n, path, fmt.Sprintf("expected a list, got %s", nodeKind(n)), false)
+			return
+		}
+		for i, item := range n.Content {
+			c.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		if n.Kind != yaml.ScalarNode {
+			c.report(n, path, fmt.Sprintf("expected a %s, got %s", t.Kind(), nodeKind(n)), false)
+			return
+		}
+		c.decode(n, t, path)
+	}
+}
+
+// decode reports values yaml.v3 would refuse to unmarshal into t.
+func (c *schemaChecker) decode(n *yaml.Node, t reflect.Type, path string) {
+	if err := n.Decode(reflect.New(t).Interface()); err != nil {
+		msg := err.Error()
+		var te *yaml.TypeError
+		if errors.As(err, &te) && len(te.Errors) > 0 {
+			msg = te.Errors[0]
+			// Drop yaml.v3's own "line N: " prefix; the problem carries it.
+			if _, rest, ok := strings.Cut(msg, ": "); ok && strings.HasPrefix(msg, "line ") {
+				msg = rest
+			}
+		}
+		c.report(n, path, msg, false)
+	}
+}
+
+func (c *schemaChecker) walkStruct(n *yaml.Node, t reflect.Type, path string) {
+	fields := yamlFields(t)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		if key.Tag == "!!merge" {
+			c.walk(val, t, path)
+			continue
+		}
+		ft, ok := fields[key.Value]
+		if !ok {
+			if path == "" && slices.Contains(c.aliases, key.Value) {
+				continue
+			}
+			msg := "unknown field"
+			if s := Suggest(key.Value, fieldNames(fields)); s != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", s)
+			}
+			c.report(key, joinPath(path, key.Value), msg, true)
+			continue
+		}
+		c.walk(val, ft, joinPath(path, key.Value))
+	}
+}
+
+// yamlFields maps the YAML keys of struct t to their field types, following
+// yaml.v3's naming rules including ",inline" fields.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range yamlFields(ft) {
+					out[k] = v
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+func fieldNames(fields map[string]reflect.Type) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nodeKind(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", n.Value)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Suggest returns the candidate closest to name, ignoring case, when it is
+// near enough to be a likely typo; otherwise "".
+func Suggest(name string, candidates []string) string {
+	best, bestDist := "", -1
+	lower := strings.ToLower(name)
+	for _, c := range candidates {
+		d := editDistance(lower, strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist < 0 || bestDist > max(2, len(name)/4) {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// typeProblems lists the mistyped values in a main config file, ignoring
+// unknown keys.
+func typeProblems(path string, data []byte) Problems {
+	all, err := checkYAML(data, reflect.TypeOf(Config{}), configAliases...)
+	if err != nil {
+		return nil
+	}
+	var out Problems
+	for _, p := range all {
+		if !p.Unknown {
+			p.File = path
+			out = append(out, p)
+		}
+	}
+	return out
+}
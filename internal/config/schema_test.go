@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckYAML_ReportsUnknownAndMistypedFields(t *testing.T) {
+	data := []byte(`workdir: .
+maxSteps: many
+alowTools:
+  - run_cli
+outputTruncateByte: 100
+runQueue:
+  maxConcurent: 2
+specialists:
+  - name: coder
+    enableTools: yes please
+    allowTools: run_cli
+`)
+	problems, err := checkYAML(data, reflect.TypeOf(Config{}), configAliases...)
+	if err != nil {
+		t.Fatalf("checkYAML: %v", err)
+	}
+	want := []struct {
+		line    int
+		path    string
+		unknown bool
+		msg     string
+	}{
+		{2, "maxSteps", false, "cannot unmarshal"},
+		{3, "alowTools", true, `did you mean "allowTools"?`},
+		{7, "runQueue.maxConcurent", true, `did you mean "maxConcurrent"?`},
+		{10, "specialists[0].enableTools", false, "cannot unmarshal"},
+		{11, "specialists[0].allowTools", false, "expected a list"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.Line != w.line || p.Path != w.path || p.Unknown != w.unknown || !strings.Contains(p.Message, w.msg) {
+			t.Errorf("problem %d = %+v, want line %d path %s unknown=%v containing %q", i, p, w.line, w.path, w.unknown, w.msg)
+		}
+	}
+}
+
+func TestCheckYAML_ExampleFilesAreClean(t *testing.T) {
+	for file, schema := range map[string]reflect.Type{
+		"config.yaml.example":      reflect.TypeOf(Config{}),
+		"specialists.yaml.example": reflect.TypeOf(specialistsFileSchema{}),
+		"mcp.yaml.example":         reflect.TypeOf(mcpFileSchema{}),
+	} {
+		data, err := os.ReadFile(filepath.Join("..", "..", file))
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		problems, err := checkYAML(data, schema, configAliases...)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, p := range problems {
+			t.Errorf("%s: %s", file, p)
+		}
+	}
+}
+
+func TestLoad_ReportsAllMistypedFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(`workdir: .
+maxSteps: many
+maxToolParallelism: lots
+`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err := Load()
+	var problems Problems
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected Problems error, got %v", err)
+	}
+	if len(problems) != 2 || problems[0].Line != 2 || problems[1].Line != 3 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if !strings.Contains(err.Error(), "config.yaml:3:21: maxToolParallelism:") {
+		t.Fatalf("error lacks line reference: %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{"allowTools", "enableTools", "maxSteps"}
+	if got := Suggest("allowtool", names); got != "allowTools" {
+		t.Fatalf("Suggest(allowtool) = %q", got)
+	}
+	if got := Suggest("database", names); got != "" {
+		t.Fatalf("Suggest(database) = %q, want none", got)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package config
+
+// sqliteDriverBuilt reports whether the binary includes the SQLite driver,
+// which internal/persistence/sqlite registers only in cgo builds.
+var sqliteDriverBuilt = false
@@ -0,0 +1,299 @@
+// Package docloader extracts structured text from uploaded documents (PDF,
+// DOCX, HTML, plain text, and, with an OCR backend, scanned PDFs and images)
+// so it can be split with textsplitters and ingested into RAG.
+package docloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/textsplitters"
+)
+
+// Format identifies a document type.
+type Format string
+
+const (
+	FormatPDF      Format = "pdf"
+	FormatDOCX     Format = "docx"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	FormatText     Format = "text"
+	// FormatImage covers raster images, which require OCR.
+	FormatImage Format = "image"
+)
+
+// DefaultMaxBytes bounds the size of documents LoadFile will read.
+const DefaultMaxBytes = 32 << 20
+
+var (
+	// ErrUnsupportedFormat is returned when the format cannot be detected or
+	// has no loader.
+	ErrUnsupportedFormat = errors.New("docloader: unsupported document format")
+	// ErrEncrypted is returned for password-protected documents.
+	ErrEncrypted = errors.New("docloader: document is encrypted")
+	// ErrTooLarge is returned when a file exceeds the size limit.
+	ErrTooLarge = errors.New("docloader: document exceeds size limit")
+	// ErrMalformed is returned when a document's structure is too damaged
+	// to parse.
+	ErrMalformed = errors.New("docloader: malformed document")
+)
+
+// Page is the text of one page. Numbers are 1-based.
+type Page struct {
+	Number int    `json:"page"`
+	Text   string `json:"text"`
+	// OCR is set when the text was recognized from an image, with the OCR
+	// backend's confidence in [0, 1].
+	OCR        bool    `json:"ocr,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Options controls loading.
+type Options struct {
+	// MaxBytes bounds file size for LoadFileWithOptions (DefaultMaxBytes
+	// when <= 0).
+	MaxBytes int64
+	// OCR recognizes image files and PDF pages without a text layer. nil
+	// disables OCR.
+	OCR OCR
+	// MinPageChars is the extracted text length below which a PDF page is
+	// sent to OCR. Default: 16.
+	MinPageChars int
+}
+
+// OptionsFromConfig returns loading options with the configured OCR backend.
+func OptionsFromConfig(cfg config.OCRConfig) (Options, error) {
+	ocr, err := NewOCR(cfg)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{OCR: ocr, MinPageChars: cfg.MinPageChars}, nil
+}
+
+// Document is the extracted content of a file.
+type Document struct {
+	Format Format `json:"format"`
+	Title  string `json:"title,omitempty"`
+	// Text is the full document text. For paged formats it is the page
+	// texts joined by blank lines.
+	Text string `json:"text"`
+	// Pages is set for formats with page structure (PDF, and DOCX when page
+	// breaks are present).
+	Pages    []Page            `json:"pages,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Chunk is a split fragment of a document with its source page, if known.
+type Chunk struct {
+	Text string `json:"text"`
+	Page int    `json:"page,omitempty"`
+}
+
+// Detect determines the format from the file name and, failing that, the
+// content.
+func Detect(name string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		return FormatPDF
+	case ".docx":
+		return FormatDOCX
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".txt", ".text", ".csv", ".log":
+		return FormatText
+	case ".png", ".jpg", ".jpeg", ".tif", ".tiff", ".bmp", ".gif", ".webp":
+		return FormatImage
+	}
+	head := data[:min(len(data), 512)]
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return FormatPDF
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")) && bytes.Contains(data, []byte("word/document.xml")):
+		return FormatDOCX
+	case imageMIME(head) != "":
+		return FormatImage
+	}
+	lower := bytes.ToLower(bytes.TrimSpace(head))
+	if bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html")) {
+		return FormatHTML
+	}
+	if utf8.Valid(head) && !bytes.ContainsRune(head, 0) {
+		return FormatText
+	}
+	return ""
+}
+
+// Load extracts text from data without OCR. The name is used for format
+// detection and as a fallback title.
+func Load(ctx context.Context, name string, data []byte) (*Document, error) {
+	return LoadWithOptions(ctx, name, data, Options{})
+}
+
+// LoadWithOptions is Load with OCR and other options applied.
+func LoadWithOptions(ctx context.Context, name string, data []byte, opts Options) (doc *Document, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// The parsers read untrusted uploads inside agent tool calls; a parser
+	// bug on a crafted file must fail the load, not the process.
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, r)
+		}
+	}()
+	switch f := Detect(name, data); f {
+	case FormatPDF:
+		doc, err = loadPDF(ctx, data, opts)
+	case FormatDOCX:
+		doc, err = loadDOCX(data)
+	case FormatHTML:
+		doc, err = loadHTML(data)
+	case FormatMarkdown, FormatText:
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%w: %s is not valid UTF-8 text", ErrUnsupportedFormat, name)
+		}
+		doc = &Document{Format: f, Text: strings.TrimSpace(string(data))}
+	case FormatImage:
+		doc, err = loadImage(ctx, data, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.Title == "" && name != "" {
+		doc.Title = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+	if doc.Text == "" && len(doc.Pages) > 0 {
+		doc.Text = joinPages(doc.Pages)
+	}
+	return doc, nil
+}
+
+// LoadFile reads and extracts path without OCR, refusing files larger than
+// maxBytes (DefaultMaxBytes when <= 0).
+func LoadFile(ctx context.Context, path string, maxBytes int64) (*Document, error) {
+	return LoadFileWithOptions(ctx, path, Options{MaxBytes: maxBytes})
+}
+
+// LoadFileWithOptions is LoadFile with OCR and other options applied.
+func LoadFileWithOptions(ctx context.Context, path string, opts Options) (*Document, error) {
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrTooLarge, maxBytes)
+	}
+	return LoadWithOptions(ctx, path, data, opts)
+}
+
+// imageMIME sniffs the image types OCR backends commonly accept.
+func imageMIME(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return "image/tiff"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case bytes.HasPrefix(head, []byte("BM")) && len(head) > 14:
+		return "image/bmp"
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WEBP":
+		return "image/webp"
+	}
+	return ""
+}
+
+func loadImage(ctx context.Context, data []byte, opts Options) (*Document, error) {
+	if opts.OCR == nil {
+		return nil, ErrOCRUnavailable
+	}
+	mime := imageMIME(data[:min(len(data), 16)])
+	if mime == "" {
+		return nil, fmt.Errorf("%w: unrecognized image data", ErrUnsupportedFormat)
+	}
+	res, err := opts.OCR.Recognize(ctx, data, mime)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		Format: FormatImage,
+		Pages:  []Page{{Number: 1, Text: strings.TrimSpace(res.Text), OCR: true, Confidence: res.Confidence}},
+	}, nil
+}
+
+func joinPages(pages []Page) string {
+	parts := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if t := strings.TrimSpace(p.Text); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Split chunks the document with s. Paged documents are split page by page
+// so every chunk keeps its page number.
+func (d *Document) Split(s textsplitters.Splitter) []Chunk {
+	var out []Chunk
+	if len(d.Pages) == 0 {
+		for _, c := range s.Split(d.Text) {
+			out = append(out, Chunk{Text: c})
+		}
+		return out
+	}
+	for _, p := range d.Pages {
+		for _, c := range s.Split(p.Text) {
+			out = append(out, Chunk{Text: c, Page: p.Number})
+		}
+	}
+	return out
+}
+
+// IngestMetadata returns document metadata for RAG ingestion: extracted
+// metadata plus the format and page count.
+func (d *Document) IngestMetadata() map[string]any {
+	md := make(map[string]any, len(d.Metadata)+2)
+	for k, v := range d.Metadata {
+		md[k] = v
+	}
+	md["format"] = string(d.Format)
+	if len(d.Pages) > 0 {
+		md["page_count"] = strconv.Itoa(len(d.Pages))
+	}
+	var ocrPages []map[string]any
+	for _, p := range d.Pages {
+		if p.OCR {
+			ocrPages = append(ocrPages, map[string]any{"page": p.Number, "confidence": p.Confidence})
+		}
+	}
+	if len(ocrPages) > 0 {
+		md["ocr"] = "true"
+		md["ocr_pages"] = ocrPages
+	}
+	return md
+}
@@ -0,0 +1,199 @@
+package docloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const maxDOCXPart = 64 << 20
+
+// loadDOCX extracts paragraphs from word/document.xml. Headings become
+// markdown headings, list items get a "- " prefix, and table cells are
+// separated by " | ". Page numbers follow explicit and Word-rendered page
+// breaks, so they approximate the printed layout.
+func loadDOCX(data []byte) (*Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("docloader: open docx: %w", err)
+	}
+	body, err := readZipPart(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{Format: FormatDOCX}
+	if core, err := readZipPart(zr, "docProps/core.xml"); err == nil {
+		doc.Metadata = docxCoreProps(core)
+		doc.Title = doc.Metadata["title"]
+	}
+	pages, err := docxPages(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > 1 {
+		doc.Pages = pages
+	} else if len(pages) == 1 {
+		doc.Text = pages[0].Text
+	}
+	return doc, nil
+}
+
+func readZipPart(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxDOCXPart))
+		if err != nil {
+			return nil, fmt.Errorf("docloader: read %s: %w", name, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("docloader: docx is missing %s", name)
+}
+
+func docxPages(body []byte) ([]Page, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	var (
+		pages     []Page
+		page      []string
+		para      strings.Builder
+		prefix    string
+		row       []string
+		inText    bool
+		tableCell int
+	)
+	flushPage := func() {
+		pages = append(pages, Page{Number: len(pages) + 1, Text: strings.Join(page, "\n\n")})
+		page = nil
+	}
+	attr := func(se xml.StartElement, local string) string {
+		for _, a := range se.Attr {
+			if a.Name.Local == local {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("docloader: parse docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				prefix = ""
+			case "pStyle":
+				prefix = docxStylePrefix(attr(t, "val"), prefix)
+			case "numPr":
+				if prefix == "" {
+					prefix = "- "
+				}
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteByte('\t')
+			case "br", "cr":
+				if attr(t, "type") == "page" {
+					if s := strings.TrimSpace(para.String()); s != "" && tableCell == 0 {
+						page = append(page, prefix+s)
+						para.Reset()
+					}
+					flushPage()
+				} else {
+					para.WriteByte('\n')
+				}
+			case "lastRenderedPageBreak":
+				if len(page) > 0 && tableCell == 0 {
+					flushPage()
+				}
+			case "tc":
+				tableCell++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				s := strings.TrimSpace(para.String())
+				if tableCell > 0 {
+					if s != "" {
+						row = append(row, s)
+					}
+					continue
+				}
+				if s != "" {
+					page = append(page, prefix+s)
+				}
+			case "tc":
+				tableCell--
+			case "tr":
+				if len(row) > 0 {
+					page = append(page, strings.Join(row, " | "))
+				}
+				row = nil
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+	if len(page) > 0 || len(pages) == 0 {
+		flushPage()
+	}
+	return pages, nil
+}
+
+// docxStylePrefix maps Word paragraph styles to markdown prefixes.
+func docxStylePrefix(style, current string) string {
+	s := strings.ToLower(style)
+	switch {
+	case s == "title":
+		return "# "
+	case strings.HasPrefix(s, "heading"):
+		if n, err := strconv.Atoi(strings.TrimPrefix(s, "heading")); err == nil && n >= 1 && n <= 6 {
+			return strings.Repeat("#", n) + " "
+		}
+	case strings.HasPrefix(s, "listparagraph"), strings.HasPrefix(s, "listbullet"):
+		return "- "
+	}
+	return current
+}
+
+func docxCoreProps(core []byte) map[string]string {
+	var props struct {
+		Title   string `xml:"title"`
+		Subject string `xml:"subject"`
+		Creator string `xml:"creator"`
+		Created string `xml:"created"`
+	}
+	if err := xml.Unmarshal(core, &props); err != nil {
+		return nil
+	}
+	out := map[string]string{}
+	for k, v := range map[string]string{"title": props.Title, "subject": props.Subject, "author": props.Creator, "created": props.Created} {
+		if v = strings.TrimSpace(v); v != "" {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
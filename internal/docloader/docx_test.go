@@ -0,0 +1,98 @@
+package docloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"manifold/internal/textsplitters"
+)
+
+func buildDOCX(t *testing.T, document, core string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{"word/document.xml": document, "docProps/core.xml": core} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const sampleDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Overview</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">First </w:t></w:r><w:r><w:t>paragraph.</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/></w:numPr></w:pPr><w:r><w:t>item one</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>A</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>B</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:r><w:br w:type="page"/></w:r></w:p>
+<w:p><w:r><w:t>Second page.</w:t></w:r></w:p>
+</w:body>
+</w:document>`
+
+const sampleCore = `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>Quarterly Plan</dc:title><dc:creator>Grace</dc:creator>
+</cp:coreProperties>`
+
+func TestLoadDOCX(t *testing.T) {
+	t.Parallel()
+	doc, err := Load(context.Background(), "plan.docx", buildDOCX(t, sampleDocument, sampleCore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Quarterly Plan" || doc.Metadata["author"] != "Grace" {
+		t.Fatalf("unexpected metadata: %+v", doc)
+	}
+	if len(doc.Pages) != 2 {
+		t.Fatalf("pages=%+v", doc.Pages)
+	}
+	if want := "# Overview\n\nFirst paragraph.\n\n- item one\n\nA | B"; doc.Pages[0].Text != want {
+		t.Fatalf("page 1 = %q want %q", doc.Pages[0].Text, want)
+	}
+	if doc.Pages[1].Text != "Second page." {
+		t.Fatalf("page 2 = %q", doc.Pages[1].Text)
+	}
+}
+
+func TestDetectAndSplitKeepsPages(t *testing.T) {
+	t.Parallel()
+	if f := Detect("upload", []byte("%PDF-1.4\n")); f != FormatPDF {
+		t.Fatalf("Detect pdf = %q", f)
+	}
+	if f := Detect("notes.md", nil); f != FormatMarkdown {
+		t.Fatalf("Detect md = %q", f)
+	}
+	if f := Detect("blob", []byte{0, 1, 2, 0xff}); f != "" {
+		t.Fatalf("Detect binary = %q", f)
+	}
+
+	doc := &Document{Format: FormatPDF, Pages: []Page{{Number: 1, Text: "abcdef"}, {Number: 2, Text: "ghi"}}}
+	s, err := textsplitters.NewFromConfig(textsplitters.Config{Kind: textsplitters.KindFixed, Fixed: textsplitters.FixedConfig{Unit: textsplitters.UnitChars, Size: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := doc.Split(s)
+	want := []Chunk{{"abcd", 1}, {"ef", 1}, {"ghi", 2}}
+	if len(chunks) != len(want) {
+		t.Fatalf("chunks=%+v", chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Fatalf("chunk %d = %+v want %+v", i, chunks[i], want[i])
+		}
+	}
+	if md := doc.IngestMetadata(); md["format"] != "pdf" || md["page_count"] != "2" {
+		t.Fatalf("metadata=%v", md)
+	}
+}
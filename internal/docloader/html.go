@@ -0,0 +1,47 @@
+package docloader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// loadHTML converts HTML to markdown so headings, lists, and tables survive
+// for the markdown-aware splitters.
+func loadHTML(data []byte) (*Document, error) {
+	r, err := charset.NewReader(bytes.NewReader(data), "text/html")
+	if err != nil {
+		return nil, fmt.Errorf("docloader: decode html: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("docloader: decode html: %w", err)
+	}
+	src := buf.String()
+	md, err := htmltomarkdown.ConvertString(src)
+	if err != nil {
+		return nil, fmt.Errorf("docloader: convert html: %w", err)
+	}
+	return &Document{Format: FormatHTML, Title: htmlTitle(src), Text: strings.TrimSpace(md)}, nil
+}
+
+func htmlTitle(src string) string {
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			if name, _ := z.TagName(); string(name) == "title" {
+				if z.Next() == html.TextToken {
+					return strings.TrimSpace(string(z.Text()))
+				}
+				return ""
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package docloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"manifold/internal/config"
+)
+
+// ErrOCRUnavailable is returned for image files when no OCR backend is
+// configured.
+var ErrOCRUnavailable = errors.New("docloader: ocr is not configured")
+
+// OCRResult is the recognized text of one image. Confidence is in [0, 1].
+type OCRResult struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+}
+
+// OCR recognizes text in an image.
+type OCR interface {
+	Recognize(ctx context.Context, image []byte, mimeType string) (OCRResult, error)
+}
+
+// NewOCR builds the backend selected by cfg. It returns nil when OCR is
+// disabled.
+func NewOCR(cfg config.OCRConfig) (OCR, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "tesseract":
+		return &TesseractOCR{Path: cfg.TesseractPath, Languages: cfg.Languages, Timeout: timeout}, nil
+	case "http":
+		if strings.TrimSpace(cfg.URL) == "" {
+			return nil, errors.New("docloader: ocr backend http requires url")
+		}
+		return &HTTPOCR{URL: cfg.URL, APIKey: cfg.APIKey, Client: &http.Client{Timeout: timeout}}, nil
+	default:
+		return nil, fmt.Errorf("docloader: unsupported ocr backend %q", cfg.Backend)
+	}
+}
+
+// TesseractOCR runs the tesseract CLI, reading the image from stdin and
+// parsing its TSV output for word confidences.
+type TesseractOCR struct {
+	Path      string
+	Languages string
+	Timeout   time.Duration
+}
+
+func (t *TesseractOCR) Recognize(ctx context.Context, image []byte, _ string) (OCRResult, error) {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	path := t.Path
+	if path == "" {
+		path = "tesseract"
+	}
+	args := []string{"stdin", "stdout"}
+	if t.Languages != "" {
+		args = append(args, "-l", t.Languages)
+	}
+	args = append(args, "tsv")
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return OCRResult{}, fmt.Errorf("docloader: tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(stdout.Bytes()), nil
+}
+
+// parseTesseractTSV rebuilds lines and paragraphs from tesseract's TSV
+// output. Confidence is the mean word confidence.
+func parseTesseractTSV(data []byte) OCRResult {
+	var (
+		out      strings.Builder
+		lastLine string
+		lastPara string
+		confSum  float64
+		words    int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		cols := strings.Split(sc.Text(), "\t")
+		// level page block par line word left top width height conf text
+		if len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		word := strings.TrimSpace(cols[11])
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		if word == "" || err != nil || conf < 0 {
+			continue
+		}
+		para := cols[1] + "/" + cols[2] + "/" + cols[3]
+		line := para + "/" + cols[4]
+		switch {
+		case out.Len() == 0:
+		case para != lastPara:
+			out.WriteString("\n\n")
+		case line != lastLine:
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(' ')
+		}
+		out.WriteString(word)
+		lastPara, lastLine = para, line
+		confSum += conf
+		words++
+	}
+	res := OCRResult{Text: out.String()}
+	if words > 0 {
+		res.Confidence = confSum / float64(words) / 100
+	}
+	return res
+}
+
+// HTTPOCR posts the image to an OCR service. The service receives the raw
+// image with its Content-Type and must respond with
+// {"text": "...", "confidence": 0.93}; confidences above 1 are treated as
+// percentages.
+type HTTPOCR struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+func (h *HTTPOCR) Recognize(ctx context.Context, image []byte, mimeType string) (OCRResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(image))
+	if err != nil {
+		return OCRResult{}, err
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("Accept", "application/json")
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("docloader: ocr request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("docloader: ocr response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return OCRResult{}, fmt.Errorf("docloader: ocr service returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var res OCRResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		return OCRResult{}, fmt.Errorf("docloader: decode ocr response: %w", err)
+	}
+	if res.Confidence > 1 {
+		res.Confidence /= 100
+	}
+	res.Text = strings.TrimSpace(res.Text)
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package docloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+)
+
+type fakeOCR struct {
+	mimes  []string
+	images [][]byte
+}
+
+func (f *fakeOCR) Recognize(_ context.Context, img []byte, mimeType string) (OCRResult, error) {
+	f.mimes = append(f.mimes, mimeType)
+	f.images = append(f.images, img)
+	return OCRResult{Text: " Scanned text ", Confidence: 0.87}, nil
+}
+
+func TestLoadPDFOCRScannedPage(t *testing.T) {
+	t.Parallel()
+	// Page 1 has a text layer; page 2 is a 4x2 8-bit gray scan.
+	pixels := string([]byte{0, 32, 64, 96, 128, 160, 192, 255})
+	data := buildPDF(map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		2: "<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 >>",
+		3: "<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 8 0 R >> >> >>",
+		4: stream("", "BT /F1 12 Tf 72 720 Td (This page has a real text layer.) Tj ET"),
+		5: "<< /Type /Page /Parent 2 0 R /Contents 6 0 R /Resources << /XObject << /Im1 7 0 R >> >> >>",
+		6: stream("", "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+		7: stream("/Type /XObject /Subtype /Image /Width 4 /Height 2 /BitsPerComponent 8 /ColorSpace /DeviceGray /Filter /FlateDecode", deflate(t, pixels)),
+		8: "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}, "<< /Root 1 0 R >>")
+
+	ocr := &fakeOCR{}
+	doc, err := LoadWithOptions(context.Background(), "scan.pdf", data, Options{OCR: ocr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ocr.mimes) != 1 || ocr.mimes[0] != "image/png" {
+		t.Fatalf("expected one PNG sent to OCR, got %v", ocr.mimes)
+	}
+	img, err := png.Decode(bytes.NewReader(ocr.images[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 2 {
+		t.Fatalf("image bounds %v", b)
+	}
+	if doc.Pages[0].OCR || !doc.Pages[1].OCR || doc.Pages[1].Text != "Scanned text" || doc.Pages[1].Confidence != 0.87 {
+		t.Fatalf("unexpected pages %+v", doc.Pages)
+	}
+	md := doc.IngestMetadata()
+	pages, _ := md["ocr_pages"].([]map[string]any)
+	if md["ocr"] != "true" || len(pages) != 1 || pages[0]["page"] != 2 || pages[0]["confidence"] != 0.87 {
+		t.Fatalf("unexpected ingest metadata %+v", md)
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(context.Background(), "receipt.png", buf.Bytes()); !errors.Is(err, ErrOCRUnavailable) {
+		t.Fatalf("err=%v want ErrOCRUnavailable", err)
+	}
+	if f := Detect("upload", buf.Bytes()); f != FormatImage {
+		t.Fatalf("Detect=%q want image", f)
+	}
+	doc, err := LoadWithOptions(context.Background(), "receipt.png", buf.Bytes(), Options{OCR: &fakeOCR{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Format != FormatImage || doc.Text != "Scanned text" || doc.Title != "receipt" || !doc.Pages[0].OCR {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+	t.Parallel()
+	tsv := strings.Join([]string{
+		"level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext",
+		"1\t1\t0\t0\t0\t0\t0\t0\t100\t100\t-1\t",
+		"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t90\tHello",
+		"5\t1\t1\t1\t1\t2\t0\t0\t10\t10\t80\tworld",
+		"5\t1\t1\t1\t2\t1\t0\t0\t10\t10\t70\tnext",
+		"5\t1\t2\t1\t1\t1\t0\t0\t10\t10\t60\tpara",
+	}, "\n")
+	res := parseTesseractTSV([]byte(tsv))
+	if want := "Hello world\nnext\n\npara"; res.Text != want {
+		t.Fatalf("text=%q want %q", res.Text, want)
+	}
+	if res.Confidence < 0.749 || res.Confidence > 0.751 {
+		t.Fatalf("confidence=%v want 0.75", res.Confidence)
+	}
+}
+
+func TestHTTPOCR(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "image/jpeg" || r.Header.Get("Authorization") != "Bearer k" || string(body) != "img" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"text":"  from service ","confidence":91}`))
+	}))
+	defer srv.Close()
+
+	ocr, err := NewOCR(config.OCRConfig{Enabled: true, Backend: "http", URL: srv.URL, APIKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ocr.Recognize(context.Background(), []byte("img"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "from service" || res.Confidence != 0.91 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
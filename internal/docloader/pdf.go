@@ -0,0 +1,434 @@
+package docloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxDecodedStream caps a single decompressed stream to guard against
+// decompression bombs.
+const maxDecodedStream = 64 << 20
+
+// maxObjectNumber bounds object numbers; the PDF spec caps them at 8388607.
+const maxObjectNumber = 1<<23 - 1
+
+// pdfFile is a parsed PDF. Objects are located by scanning for "n g obj"
+// rather than trusting the xref table, which tolerates the truncated and
+// incrementally-updated files users tend to upload.
+type pdfFile struct {
+	objects map[int]any
+	trailer pdfDict
+}
+
+var pdfObjRe = regexp.MustCompile(`(?m)(?:^|[\s>])(\d+)\s+(\d+)\s+obj\b`)
+
+// loadPDF extracts text page by page. Only text drawn by content streams is
+// returned; text inside images requires OCR and is not recovered.
+func loadPDF(ctx context.Context, data []byte, opts Options) (*Document, error) {
+	f, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.encrypted() {
+		return nil, ErrEncrypted
+	}
+	pages := f.pages()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("docloader: pdf has no pages")
+	}
+	doc := &Document{Format: FormatPDF, Metadata: f.info()}
+	if t := doc.Metadata["title"]; t != "" {
+		doc.Title = t
+	}
+	for i, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p := Page{Number: i + 1, Text: f.pageText(page)}
+		if opts.OCR != nil && utf8.RuneCountInString(p.Text) < minPageChars(opts) {
+			if img, mime, ok := f.pageImage(page); ok {
+				res, err := opts.OCR.Recognize(ctx, img, mime)
+				if err != nil {
+					return nil, fmt.Errorf("docloader: ocr page %d: %w", p.Number, err)
+				}
+				p.Text, p.OCR, p.Confidence = strings.TrimSpace(res.Text), true, res.Confidence
+			}
+		}
+		doc.Pages = append(doc.Pages, p)
+	}
+	return doc, nil
+}
+
+func minPageChars(opts Options) int {
+	if opts.MinPageChars > 0 {
+		return opts.MinPageChars
+	}
+	return 16
+}
+
+func parsePDF(data []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("docloader: not a pdf file")
+	}
+	f := &pdfFile{objects: map[int]any{}, trailer: pdfDict{}}
+	for _, m := range pdfObjRe.FindAllSubmatchIndex(data, -1) {
+		num := atoiBytes(data[m[2]:m[3]])
+		l := &pdfLexer{data: data, pos: m[1]}
+		v, ok := l.object()
+		if !ok {
+			continue
+		}
+		if d, isDict := v.(pdfDict); isDict {
+			save := l.pos
+			if kw, ok := l.token(); ok && kw == pdfKeyword("stream") {
+				v = &pdfStream{dict: d, raw: streamBody(data, l.pos, d)}
+			} else {
+				l.pos = save
+			}
+		}
+		// Later definitions win, matching incremental updates.
+		f.objects[num] = v
+	}
+	for idx := 0; ; {
+		i := bytes.Index(data[idx:], []byte("trailer"))
+		if i < 0 {
+			break
+		}
+		l := &pdfLexer{data: data, pos: idx + i + len("trailer")}
+		if d, ok := l.object(); ok {
+			if dict, isDict := d.(pdfDict); isDict {
+				for k, v := range dict {
+					f.trailer[k] = v
+				}
+			}
+		}
+		idx += i + len("trailer")
+	}
+	f.expandObjectStreams()
+	// Cross-reference streams (PDF 1.5+) carry the trailer keys.
+	for _, v := range f.objects {
+		if s, ok := v.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") {
+			for _, k := range []pdfName{"Root", "Info", "Encrypt"} {
+				if _, have := f.trailer[k]; !have && s.dict[k] != nil {
+					f.trailer[k] = s.dict[k]
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// boundedInt converts a number read from the file to an int in [0, limit].
+// Values in a PDF are untrusted, so negative, NaN and oversized values are
+// rejected rather than converted.
+func boundedInt(n float64, limit int) (int, bool) {
+	if !(n >= 0) || n > float64(limit) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func atoiBytes(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+// streamBody returns the raw bytes following the "stream" keyword at pos.
+func streamBody(data []byte, pos int, d pdfDict) []byte {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	if pos > len(data) {
+		pos = len(data)
+	}
+	if n, ok := d["Length"].(float64); ok {
+		length, valid := boundedInt(n, len(data)-pos)
+		end := pos + length
+		if valid && bytes.HasPrefix(bytes.TrimLeft(data[end:min(end+32, len(data))], " \r\n"), []byte("endstream")) {
+			return data[pos:end]
+		}
+	}
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:]
+	}
+	return bytes.TrimRight(data[pos:pos+end], "\r\n")
+}
+
+// expandObjectStreams adds objects stored in compressed object streams.
+func (f *pdfFile) expandObjectStreams() {
+	for _, v := range f.objects {
+		s, ok := v.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		body, err := f.decode(s)
+		if err != nil {
+			continue
+		}
+		// Every entry takes at least two bytes of the body, so larger
+		// counts and offsets are corrupt.
+		nv, _ := f.resolve(s.dict["N"]).(float64)
+		fv, _ := f.resolve(s.dict["First"]).(float64)
+		n, okN := boundedInt(nv, len(body)/2)
+		first, okFirst := boundedInt(fv, len(body))
+		if !okN || !okFirst {
+			continue
+		}
+		l := &pdfLexer{data: body}
+		type entry struct{ num, off int }
+		entries := make([]entry, 0, n)
+		for range n {
+			a, ok1 := l.token()
+			b, ok2 := l.token()
+			an, isA := a.(float64)
+			bn, isB := b.(float64)
+			if !ok1 || !ok2 || !isA || !isB {
+				break
+			}
+			num, okNum := boundedInt(an, maxObjectNumber)
+			off, okOff := boundedInt(bn, len(body))
+			if !okNum || !okOff {
+				break
+			}
+			entries = append(entries, entry{num, off})
+		}
+		for _, e := range entries {
+			if _, exists := f.objects[e.num]; exists {
+				continue
+			}
+			pos := first + e.off
+			if pos >= len(body) {
+				continue
+			}
+			ol := &pdfLexer{data: body, pos: pos}
+			if obj, ok := ol.object(); ok {
+				f.objects[e.num] = obj
+			}
+		}
+	}
+}
+
+func (f *pdfFile) resolve(v any) any {
+	for range 32 {
+		r, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[r.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v any) pdfDict {
+	switch d := f.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+func (f *pdfFile) encrypted() bool {
+	return f.trailer["Encrypt"] != nil
+}
+
+// decode applies the stream's filters.
+func (f *pdfFile) decode(s *pdfStream) ([]byte, error) {
+	return f.applyFilters(s.raw, f.filters(s))
+}
+
+func (f *pdfFile) filters(s *pdfStream) []pdfName {
+	var raw []any
+	switch fl := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		raw = []any{fl}
+	case []any:
+		raw = fl
+	}
+	names := make([]pdfName, 0, len(raw))
+	for _, r := range raw {
+		name, _ := f.resolve(r).(pdfName)
+		names = append(names, name)
+	}
+	return names
+}
+
+func (f *pdfFile) applyFilters(data []byte, filters []pdfName) ([]byte, error) {
+	for _, name := range filters {
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		default:
+			return nil, fmt.Errorf("docloader: unsupported pdf filter %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxDecodedStream))
+	// Many producers write streams without a valid checksum; keep what was
+	// inflated.
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func asciiHexDecode(data []byte) ([]byte, error) {
+	var clean []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if _, ok := hexVal(c); ok {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	return hex.DecodeString(string(clean))
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// pages returns page dictionaries in document order, with inherited
+// Resources applied.
+func (f *pdfFile) pages() []pdfDict {
+	var out []pdfDict
+	seen := map[int]bool{}
+	var walk func(node any, inherited any)
+	walk = func(node any, inherited any) {
+		if r, ok := node.(pdfRef); ok {
+			if seen[r.num] {
+				return
+			}
+			seen[r.num] = true
+		}
+		d := f.dict(node)
+		if d == nil {
+			return
+		}
+		res := inherited
+		if d["Resources"] != nil {
+			res = d["Resources"]
+		}
+		if kids, ok := f.resolve(d["Kids"]).([]any); ok && d["Type"] != pdfName("Page") {
+			for _, k := range kids {
+				walk(k, res)
+			}
+			return
+		}
+		page := pdfDict{}
+		for k, v := range d {
+			page[k] = v
+		}
+		page["Resources"] = res
+		out = append(out, page)
+	}
+	if root := f.dict(f.trailer["Root"]); root != nil {
+		walk(root["Pages"], nil)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	// No usable catalog: fall back to every page object in number order.
+	nums := make([]int, 0, len(f.objects))
+	for n, v := range f.objects {
+		if d, ok := v.(pdfDict); ok && d["Type"] == pdfName("Page") {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		walk(pdfRef{num: n}, nil)
+	}
+	return out
+}
+
+func (f *pdfFile) info() map[string]string {
+	info := f.dict(f.trailer["Info"])
+	if info == nil {
+		return nil
+	}
+	out := map[string]string{}
+	for key, name := range map[pdfName]string{"Title": "title", "Author": "author", "Subject": "subject", "Creator": "creator", "Producer": "producer", "CreationDate": "created"} {
+		if s, ok := f.resolve(info[key]).(pdfString); ok {
+			if v := strings.TrimSpace(pdfTextString(s)); v != "" {
+				out[name] = v
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// pdfTextString decodes a PDF "text string": UTF-16BE with a BOM, otherwise
+// PDFDocEncoding (treated as Latin-1).
+func pdfTextString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return utf16BE(b[2:])
+	}
+	return latin1(b)
+}
+
+func utf16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+func latin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
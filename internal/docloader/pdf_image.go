@@ -0,0 +1,154 @@
+package docloader
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// pageImage returns the largest image on the page, encoded for OCR. Scanned
+// pages are typically a single full-page image. JPEG and JPEG 2000 data is
+// passed through; uncompressed or Flate-compressed gray, RGB, and CMYK
+// samples at 1 or 8 bits per component are re-encoded as PNG.
+func (f *pdfFile) pageImage(page pdfDict) ([]byte, string, bool) {
+	res := f.dict(page["Resources"])
+	if res == nil {
+		return nil, "", false
+	}
+	var (
+		best     *pdfStream
+		bestArea int
+	)
+	for _, ref := range f.dict(res["XObject"]) {
+		s, ok := f.resolve(ref).(*pdfStream)
+		if !ok || s.dict["Subtype"] != pdfName("Image") {
+			continue
+		}
+		if area := f.intValue(s.dict["Width"]) * f.intValue(s.dict["Height"]); area > bestArea {
+			best, bestArea = s, area
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+	return f.encodeImage(best)
+}
+
+// intValue returns a non-negative integer value, or 0 when v is missing or
+// out of range.
+func (f *pdfFile) intValue(v any) int {
+	n, _ := f.resolve(v).(float64)
+	i, _ := boundedInt(n, 1<<30)
+	return i
+}
+
+func (f *pdfFile) encodeImage(s *pdfStream) ([]byte, string, bool) {
+	filters := f.filters(s)
+	if n := len(filters); n > 0 {
+		switch filters[n-1] {
+		case "DCTDecode", "DCT":
+			data, err := f.applyFilters(s.raw, filters[:n-1])
+			return data, "image/jpeg", err == nil
+		case "JPXDecode":
+			data, err := f.applyFilters(s.raw, filters[:n-1])
+			return data, "image/jp2", err == nil
+		}
+	}
+	if parms := f.dict(s.dict["DecodeParms"]); parms != nil && f.intValue(parms["Predictor"]) > 1 {
+		return nil, "", false
+	}
+	data, err := f.applyFilters(s.raw, filters)
+	if err != nil {
+		return nil, "", false
+	}
+	w, h := f.intValue(s.dict["Width"]), f.intValue(s.dict["Height"])
+	bpc := f.intValue(s.dict["BitsPerComponent"])
+	comps := 1
+	if s.dict["ImageMask"] == true {
+		bpc = 1
+	} else {
+		comps = f.colorComponents(s.dict["ColorSpace"])
+	}
+	img := rasterImage(data, w, h, bpc, comps)
+	if img == nil {
+		return nil, "", false
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", false
+	}
+	return buf.Bytes(), "image/png", true
+}
+
+// colorComponents returns the number of components for supported color
+// spaces, or 0.
+func (f *pdfFile) colorComponents(cs any) int {
+	switch v := f.resolve(cs).(type) {
+	case pdfName:
+		switch v {
+		case "DeviceGray", "G", "CalGray":
+			return 1
+		case "DeviceRGB", "RGB", "CalRGB":
+			return 3
+		case "DeviceCMYK", "CMYK":
+			return 4
+		}
+	case []any:
+		if len(v) == 2 && f.resolve(v[0]) == pdfName("ICCBased") {
+			if s, ok := f.resolve(v[1]).(*pdfStream); ok {
+				return f.intValue(s.dict["N"])
+			}
+		}
+	}
+	return 0
+}
+
+// rasterImage converts raw PDF samples to an image. Rows are padded to a
+// byte boundary.
+func rasterImage(data []byte, w, h, bpc, comps int) image.Image {
+	if w <= 0 || h <= 0 || w > 100_000_000/h {
+		return nil
+	}
+	switch {
+	case bpc == 1 && comps == 1:
+		stride := (w + 7) / 8
+		if len(data) < stride*h {
+			return nil
+		}
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := range h {
+			for x := range w {
+				if data[y*stride+x/8]&(0x80>>(x%8)) != 0 {
+					img.Pix[y*img.Stride+x] = 0xff
+				}
+			}
+		}
+		return img
+	case bpc == 8 && comps == 1:
+		if len(data) < w*h {
+			return nil
+		}
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		copy(img.Pix, data[:w*h])
+		return img
+	case bpc == 8 && (comps == 3 || comps == 4):
+		if len(data) < w*h*comps {
+			return nil
+		}
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := range w * h {
+			p := data[i*comps:]
+			var c color.RGBA
+			if comps == 3 {
+				c = color.RGBA{p[0], p[1], p[2], 0xff}
+			} else {
+				r, g, b := color.CMYKToRGB(p[0], p[1], p[2], p[3])
+				c = color.RGBA{r, g, b, 0xff}
+			}
+			img.SetRGBA(i%w, i/w, c)
+		}
+		return img
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package docloader
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// PDF object model. Values parsed from a file are one of: nil, bool,
+// float64, pdfName, pdfString, pdfKeyword, pdfRef, []any, pdfDict, or
+// *pdfStream.
+type (
+	pdfName    string
+	pdfString  []byte
+	pdfKeyword string
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+)
+
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+// pdfLexer tokenises PDF syntax for both object bodies and content streams.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhite(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFWhite(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next token. Structural delimiters are returned as
+// pdfKeyword values ("[", "]", "<<", ">>"). ok is false at end of input.
+func (l *pdfLexer) token() (tok any, ok bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, false
+	}
+	c := l.data[l.pos]
+	switch c {
+	case '[', ']', '{', '}':
+		l.pos++
+		return pdfKeyword(c), true
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), true
+		}
+		return l.hexString(), true
+	case '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), true
+		}
+		l.pos++
+		return pdfKeyword(">"), true
+	case '(':
+		return l.literalString(), true
+	case '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFWhite(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodeNameEscapes(l.data[start:l.pos])), true
+	case ')':
+		l.pos++
+		return pdfKeyword(")"), true
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhite(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+	if len(word) == 0 {
+		l.pos++
+		return pdfKeyword(c), true
+	}
+	if f, err := strconv.ParseFloat(string(word), 64); err == nil && (word[0] == '-' || word[0] == '+' || word[0] == '.' || (word[0] >= '0' && word[0] <= '9')) {
+		return f, true
+	}
+	switch string(word) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return pdfKeyword(word), true
+}
+
+func decodeNameEscapes(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++ // '<'
+	var out []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		v, ok := hexVal(l.data[l.pos])
+		l.pos++
+		if !ok {
+			continue
+		}
+		if half {
+			out = append(out, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	l.pos++ // '>'
+	return out
+}
+
+func hexVal(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for range 2 {
+						if l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7' {
+							v = v*8 + int(l.data[l.pos]-'0')
+							l.pos++
+						}
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// object parses one complete value, resolving "n g R" references and
+// dictionaries. Keywords other than structural delimiters are returned as
+// pdfKeyword.
+func (l *pdfLexer) object() (any, bool) {
+	tok, ok := l.token()
+	if !ok {
+		return nil, false
+	}
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "[":
+			var arr []any
+			for {
+				save := l.pos
+				next, ok := l.token()
+				if !ok || next == pdfKeyword("]") {
+					return arr, true
+				}
+				l.pos = save
+				v, ok := l.object()
+				if !ok {
+					return arr, true
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			d := pdfDict{}
+			for {
+				key, ok := l.token()
+				if !ok || key == pdfKeyword(">>") {
+					return d, true
+				}
+				name, isName := key.(pdfName)
+				if !isName {
+					continue
+				}
+				v, ok := l.object()
+				if !ok {
+					return d, true
+				}
+				d[name] = v
+			}
+		}
+		return t, true
+	case float64:
+		// Look ahead for "gen R".
+		save := l.pos
+		if gen, ok := l.token(); ok {
+			if g, isNum := gen.(float64); isNum {
+				if r, ok := l.token(); ok && r == pdfKeyword("R") {
+					return pdfRef{num: int(t), gen: int(g)}, true
+				}
+			}
+		}
+		l.pos = save
+		return t, true
+	}
+	return tok, true
+}
@@ -0,0 +1,152 @@
+package docloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func deflate(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// buildPDF assembles objects (keyed by number) into a PDF file.
+func buildPDF(objs map[int]string, trailer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	for _, n := range slices.Sorted(maps.Keys(objs)) {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n, objs[n])
+	}
+	fmt.Fprintf(&b, "trailer\n%s\nstartxref\n0\n%%%%EOF\n", trailer)
+	return b.Bytes()
+}
+
+func stream(dict, body string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(body), body)
+}
+
+func samplePDF(t *testing.T) []byte {
+	t.Helper()
+	page1 := "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\) world.) Tj 0 -14 Td [(Second) -300 (line)] TJ ET"
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0001> <0048> <0002> <0069> endbfchar
+1 beginbfrange <0010> <0012> <0061> endbfrange
+endcmap`
+	page2 := deflate(t, "BT /F2 12 Tf 1 0 0 1 72 700 Tm <00010002> Tj 1 0 0 1 72 680 Tm <001000110012> Tj ET")
+	// Objects 9 and 10 live in a compressed object stream.
+	font2, fonts := "<< /Type /Font /Subtype /Type0 /ToUnicode 8 0 R >> ", "<< /F2 9 0 R >>"
+	objStmHeader := fmt.Sprintf("9 0 10 %d ", len(font2))
+	objStmBody := objStmHeader + font2 + fonts
+	objs := map[int]string{
+		1:  "<< /Type /Catalog /Pages 2 0 R >>",
+		2:  "<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 /Resources << /Font << /F1 7 0 R >> >> >>",
+		3:  "<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		4:  stream("", page1),
+		5:  "<< /Type /Page /Parent 2 0 R /Contents 6 0 R /Resources << /Font 10 0 R >> >>",
+		6:  stream("/Filter /FlateDecode", page2),
+		7:  "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		8:  stream("/Filter /FlateDecode", deflate(t, cmap)),
+		11: stream(fmt.Sprintf("/Type /ObjStm /N 2 /First %d /Filter /FlateDecode", len(objStmHeader)), deflate(t, objStmBody)),
+		12: "<< /Title <FEFF005200650070006F00720074> /Author (Ada) >>",
+	}
+	return buildPDF(objs, "<< /Root 1 0 R /Info 12 0 R /Size 13 >>")
+}
+
+func TestLoadPDF(t *testing.T) {
+	t.Parallel()
+	doc, err := Load(context.Background(), "report.pdf", samplePDF(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Format != FormatPDF || doc.Title != "Report" || doc.Metadata["author"] != "Ada" {
+		t.Fatalf("unexpected metadata: %+v", doc)
+	}
+	if len(doc.Pages) != 2 {
+		t.Fatalf("pages=%d want 2", len(doc.Pages))
+	}
+	if want := "Hello (PDF) world.\nSecond line"; doc.Pages[0].Text != want {
+		t.Fatalf("page 1 = %q want %q", doc.Pages[0].Text, want)
+	}
+	if want := "Hi\nabc"; doc.Pages[1].Text != want {
+		t.Fatalf("page 2 = %q want %q", doc.Pages[1].Text, want)
+	}
+	if doc.Pages[1].Number != 2 || !strings.Contains(doc.Text, "Second line\n\nHi") {
+		t.Fatalf("unexpected text %q", doc.Text)
+	}
+}
+
+func TestLoadPDFEncrypted(t *testing.T) {
+	t.Parallel()
+	data := buildPDF(map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		2: "<< /Type /Pages /Kids [] /Count 0 >>",
+		3: "<< /Filter /Standard /V 2 >>",
+	}, "<< /Root 1 0 R /Encrypt 3 0 R >>")
+	if _, err := Load(context.Background(), "locked.pdf", data); !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("err=%v want ErrEncrypted", err)
+	}
+}
+
+// malformedPDF is a one-page PDF whose object 5 is replaced by extra.
+func malformedPDF(extra string) []byte {
+	return buildPDF(map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		2: "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		3: "<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /XObject << /Im1 5 0 R >> >> >>",
+		4: stream("", "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+		5: extra,
+	}, "<< /Root 1 0 R >>")
+}
+
+func TestLoadPDFMalformedNumbers(t *testing.T) {
+	t.Parallel()
+	header := "9 0 "
+	body := header + "<< /A 1 >>"
+	cases := map[string]string{
+		"negative N":      fmt.Sprintf("<< /Type /ObjStm /N -1 /First 4 /Length %d >>\nstream\n%s\nendstream", len(body), body),
+		"huge N":          fmt.Sprintf("<< /Type /ObjStm /N 1000000000000000000 /First 4 /Length %d >>\nstream\n%s\nendstream", len(body), body),
+		"huge First":      fmt.Sprintf("<< /Type /ObjStm /N 1 /First 1e300 /Length %d >>\nstream\n%s\nendstream", len(body), body),
+		"huge offset":     "<< /Type /ObjStm /N 1 /First 0 /Length 19 >>\nstream\n9 9223372036854775807\nendstream",
+		"huge Length":     "<< /Length 1e19 >>\nstream\nabc\nendstream",
+		"negative Length": "<< /Length -5 >>\nstream\nabc\nendstream",
+		"huge image":      stream("/Subtype /Image /Width 4294967296 /Height 4294967296 /BitsPerComponent 8 /ColorSpace /DeviceGray", "abcd"),
+		"negative image":  stream("/Subtype /Image /Width -4 /Height -2 /BitsPerComponent 8 /ColorSpace /DeviceGray", "abcd"),
+	}
+	for name, obj := range cases {
+		if _, err := LoadWithOptions(context.Background(), "bad.pdf", malformedPDF(obj), Options{OCR: &fakeOCR{}}); errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: parser panicked: %v", name, err)
+		}
+	}
+}
+
+func FuzzLoadPDF(f *testing.F) {
+	f.Add(buildPDF(map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		2: "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		3: "<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		4: stream("", "BT /F1 12 Tf 72 720 Td (Hello) Tj ET"),
+	}, "<< /Root 1 0 R >>"))
+	f.Add(malformedPDF("<< /Type /ObjStm /N 3 /First 2 /Length 8 >>\nstream\n1 0 2 5\nendstream"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Call the parser directly, below the recover in LoadWithOptions, so
+		// any panic fails the fuzzer. Errors are expected.
+		_, _ = loadPDF(context.Background(), data, Options{OCR: &fakeOCR{}})
+	})
+}
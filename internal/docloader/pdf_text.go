@@ -0,0 +1,337 @@
+package docloader
+
+import (
+	"bytes"
+	"math"
+	"strings"
+)
+
+// pdfFont decodes string operands for one font resource.
+type pdfFont struct {
+	cmap *toUnicodeCMap
+	// twoByte is set for composite (Type0) fonts without a ToUnicode map;
+	// their codes are usually glyph IDs that cannot be mapped to text.
+	twoByte bool
+}
+
+func (ft *pdfFont) decode(b []byte) string {
+	if ft == nil {
+		return latin1(b)
+	}
+	if ft.cmap != nil {
+		return ft.cmap.decode(b)
+	}
+	if ft.twoByte {
+		return ""
+	}
+	return latin1(b)
+}
+
+// pageFonts loads the fonts referenced by a page's resources.
+func (f *pdfFile) pageFonts(page pdfDict) map[pdfName]*pdfFont {
+	fonts := map[pdfName]*pdfFont{}
+	res := f.dict(page["Resources"])
+	if res == nil {
+		return fonts
+	}
+	for name, ref := range f.dict(res["Font"]) {
+		fd := f.dict(ref)
+		if fd == nil {
+			continue
+		}
+		ft := &pdfFont{twoByte: fd["Subtype"] == pdfName("Type0")}
+		if s, ok := f.resolve(fd["ToUnicode"]).(*pdfStream); ok {
+			if body, err := f.decode(s); err == nil {
+				ft.cmap = parseToUnicode(body)
+			}
+		}
+		fonts[name] = ft
+	}
+	return fonts
+}
+
+func (f *pdfFile) pageContent(page pdfDict) []byte {
+	var parts []any
+	switch c := f.resolve(page["Contents"]).(type) {
+	case *pdfStream:
+		parts = []any{c}
+	case []any:
+		parts = c
+	}
+	var buf bytes.Buffer
+	for _, p := range parts {
+		s, ok := f.resolve(p).(*pdfStream)
+		if !ok {
+			continue
+		}
+		body, err := f.decode(s)
+		if err != nil {
+			continue
+		}
+		buf.Write(body)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// pageText interprets the text operators of a page's content stream. Line
+// breaks are inferred from vertical movement and word gaps from large
+// negative TJ adjustments.
+func (f *pdfFile) pageText(page pdfDict) string {
+	fonts := f.pageFonts(page)
+	content := f.pageContent(page)
+	var (
+		out      strings.Builder
+		font     *pdfFont
+		operands []any
+		lastY    = math.NaN()
+		leading  float64
+	)
+	newline := func() {
+		s := out.String()
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		s := out.String()
+		if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			out.WriteByte(' ')
+		}
+	}
+	moveTo := func(y float64) {
+		if !math.IsNaN(lastY) && math.Abs(y-lastY) > 0.5 {
+			newline()
+		}
+		lastY = y
+	}
+	show := func(b pdfString) { out.WriteString(font.decode(b)) }
+	num := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		v, _ := operands[i].(float64)
+		return v
+	}
+
+	l := &pdfLexer{data: content}
+	for {
+		save := l.pos
+		tok, ok := l.token()
+		if !ok {
+			break
+		}
+		kw, isKW := tok.(pdfKeyword)
+		if !isKW || kw == "[" || kw == "<<" {
+			l.pos = save
+			v, ok := l.object()
+			if !ok {
+				break
+			}
+			operands = append(operands, v)
+			continue
+		}
+		switch kw {
+		case "BT":
+			lastY = math.NaN()
+			newline()
+		case "ET":
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = fonts[name]
+				}
+			}
+		case "TL":
+			leading = num(0)
+		case "Td":
+			if ty := num(1); ty != 0 {
+				if math.IsNaN(lastY) {
+					lastY = 0
+				}
+				moveTo(lastY + ty)
+			} else if num(0) > 0 {
+				space()
+			}
+		case "TD":
+			leading = -num(1)
+			if math.IsNaN(lastY) {
+				lastY = 0
+			}
+			moveTo(lastY + num(1))
+		case "Tm":
+			moveTo(num(5))
+		case "T*":
+			newline()
+			if !math.IsNaN(lastY) {
+				lastY -= leading
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[len(operands)-1].([]any)
+				for _, el := range arr {
+					switch e := el.(type) {
+					case pdfString:
+						show(e)
+					case float64:
+						if e < -200 {
+							space()
+						}
+					}
+				}
+			}
+		case "BI":
+			// Skip inline image data up to EI.
+			if i := bytes.Index(content[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			}
+		}
+		operands = operands[:0]
+	}
+	return cleanPageText(out.String())
+}
+
+func cleanPageText(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, ln := range lines {
+		ln = strings.Join(strings.Fields(ln), " ")
+		if ln != "" {
+			out = append(out, ln)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// toUnicodeCMap maps character codes to Unicode text using a font's
+// ToUnicode stream.
+type toUnicodeCMap struct {
+	codeLen int
+	m       map[uint32]string
+}
+
+func parseToUnicode(body []byte) *toUnicodeCMap {
+	c := &toUnicodeCMap{codeLen: 1, m: map[uint32]string{}}
+	l := &pdfLexer{data: body}
+	var operands []any
+	code := func(b pdfString) uint32 {
+		var v uint32
+		for _, x := range b {
+			v = v<<8 | uint32(x)
+		}
+		return v
+	}
+	for {
+		tok, ok := l.token()
+		if !ok {
+			break
+		}
+		kw, isKW := tok.(pdfKeyword)
+		if !isKW {
+			operands = append(operands, tok)
+			continue
+		}
+		switch kw {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			operands = operands[:0]
+			continue
+		case "[":
+			var arr []any
+			for {
+				t, ok := l.token()
+				if !ok || t == pdfKeyword("]") {
+					break
+				}
+				arr = append(arr, t)
+			}
+			operands = append(operands, arr)
+			continue
+		case "endcodespacerange":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].(pdfString); ok && len(s) > 0 {
+					c.codeLen = len(s)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					c.m[code(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := code(lo), code(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []byte(dst)
+					for cd := start; cd <= end; cd++ {
+						c.m[cd] = utf16BE(base)
+						base = incrementLast(base)
+					}
+				case []any:
+					for j, d := range dst {
+						if s, ok := d.(pdfString); ok && start+uint32(j) <= end {
+							c.m[start+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return c
+}
+
+func incrementLast(b []byte) []byte {
+	out := bytes.Clone(b)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i]++
+		if out[i] != 0 {
+			break
+		}
+	}
+	return out
+}
+
+func (c *toUnicodeCMap) decode(b []byte) string {
+	var sb strings.Builder
+	n := c.codeLen
+	for i := 0; i < len(b); i += n {
+		if i+n > len(b) {
+			break
+		}
+		var v uint32
+		for _, x := range b[i : i+n] {
+			v = v<<8 | uint32(x)
+		}
+		if s, ok := c.m[v]; ok {
+			sb.WriteString(s)
+		} else if n == 1 {
+			sb.WriteRune(rune(v))
+		}
+	}
+	return sb.String()
+}
@@ -1,103 +1,56 @@
+// Package embedding exposes convenience helpers over internal/llm/embeddings
+// for callers that embed text with a plain config value.
 package embedding
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"sync"
 
 	"manifold/internal/config"
+	"manifold/internal/llm/embeddings"
 )
 
-type embedReq struct {
-	Model string   `json:"model"`
-	Input []string `json:"input"`
-}
-
-type embedResp struct {
-	Data []struct {
-		Embedding []float32 `json:"embedding"`
-	} `json:"data"`
-}
+// failovers holds one embeddings.Failover per distinct config, keyed by a
+// hash of the config, so endpoint health, cooldowns, pacing and the deferred
+// queue carry over between EmbedText calls.
+var failovers sync.Map
 
 // EmbedText calls the configured embedding endpoint and returns one embedding
 // per input string. Caller should provide cfg loaded from config.Load().
+// Batching, retries, and rate limiting follow cfg, and cfg.Fallbacks are tried
+// in order when the primary endpoint is down. Calls with an equal cfg share
+// one Failover, so a failed endpoint stays skipped until its cooldown passes.
 func EmbedText(ctx context.Context, cfg config.EmbeddingConfig, inputs []string) ([][]float32, error) {
 	if len(inputs) == 0 {
 		return nil, fmt.Errorf("no inputs")
 	}
-	reqBody, _ := json.Marshal(embedReq{Model: cfg.Model, Input: inputs})
-	timeout := time.Duration(cfg.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
-	}
-	cctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-	url := cfg.BaseURL + cfg.Path
-	req, err := http.NewRequestWithContext(cctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	p, err := failoverFor(cfg)
 	if err != nil {
 		return nil, err
 	}
-	// Apply headers from the map first. Map entries take precedence and are used verbatim.
-	if len(cfg.Headers) > 0 {
-		for k, v := range cfg.Headers {
-			if k == "" {
-				continue
-			}
-			req.Header.Set(k, v)
-		}
-		// If Authorization header not present in headers map, fall back to legacy APIHeader/APIKey
-		if _, ok := cfg.Headers["Authorization"]; !ok {
-			if cfg.APIHeader == "Authorization" && cfg.APIKey != "" {
-				req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
-			} else if cfg.APIHeader != "" && cfg.APIKey != "" {
-				req.Header.Set(cfg.APIHeader, cfg.APIKey)
-			}
-		}
-	} else {
-		// Backwards compatible behavior when no headers map provided.
-		if cfg.APIHeader == "Authorization" {
-			req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
-		} else if cfg.APIHeader != "" {
-			req.Header.Set(cfg.APIHeader, cfg.APIKey)
-		}
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	return p.Embed(ctx, inputs)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// failoverFor returns the shared embeddings.Failover for cfg, building it on
+// first use.
+func failoverFor(cfg config.EmbeddingConfig) (*embeddings.Failover, error) {
+	raw, err := json.Marshal(cfg)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode/100 != 2 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embeddings error: %s: %s", resp.Status, string(b))
+	key := sha256.Sum256(raw)
+	if f, ok := failovers.Load(key); ok {
+		return f.(*embeddings.Failover), nil
 	}
-
-	// Read the response body first so we can provide better error messages
-	bodyBytes, err := io.ReadAll(resp.Body)
+	f, err := embeddings.NewFailover(cfg, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var er embedResp
-	if err := json.Unmarshal(bodyBytes, &er); err != nil {
-		return nil, fmt.Errorf("failed to parse embedding response (input count: %d, response: %s): %w",
-			len(inputs), string(bodyBytes[:min(200, len(bodyBytes))]), err)
-	}
-	if len(er.Data) != len(inputs) {
-		// still return what we have, but consider it an error
-		return nil, fmt.Errorf("unexpected embedding count: got %d, want %d", len(er.Data), len(inputs))
-	}
-	out := make([][]float32, len(er.Data))
-	for i := range er.Data {
-		out[i] = er.Data[i].Embedding
+		return nil, err
 	}
-	return out, nil
+	actual, _ := failovers.LoadOrStore(key, f)
+	return actual.(*embeddings.Failover), nil
 }
 
 // CheckReachability verifies that the embedding endpoint is reachable and
@@ -109,10 +62,3 @@ func CheckReachability(ctx context.Context, cfg config.EmbeddingConfig) error {
 	}
 	return nil
 }
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
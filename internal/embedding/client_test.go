@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"manifold/internal/config"
@@ -68,3 +69,43 @@ func TestEmbedText_MixedHeadersPrecedence(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestEmbedText_FailedPrimaryStaysSkipped(t *testing.T) {
+	var primaryHits, secondaryHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryHits.Add(1)
+		resp := map[string]interface{}{"data": []map[string]interface{}{{"embedding": []float32{0.1}}}}
+		b, _ := json.Marshal(resp)
+		w.Write(b)
+	}))
+	defer secondary.Close()
+
+	cfg := config.EmbeddingConfig{
+		BaseURL: primary.URL, Path: "/", Model: "m",
+		HealthCheckSeconds: 3600,
+		Fallbacks:          []config.EmbeddingConfig{{BaseURL: secondary.URL, Path: "/", Model: "m"}},
+	}
+	if _, err := EmbedText(context.Background(), cfg, []string{"x"}); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	afterFirst := primaryHits.Load()
+	if afterFirst == 0 {
+		t.Fatalf("expected the primary to be tried first")
+	}
+	for range 3 {
+		if _, err := EmbedText(context.Background(), cfg, []string{"y"}); err != nil {
+			t.Fatalf("later call: %v", err)
+		}
+	}
+	if got := primaryHits.Load(); got != afterFirst {
+		t.Fatalf("primary in cooldown was called again: %d hits, want %d", got, afterFirst)
+	}
+	if got := secondaryHits.Load(); got != 4 {
+		t.Fatalf("secondary hits = %d, want 4", got)
+	}
+}
@@ -0,0 +1,180 @@
+package flow
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	nodeRefPattern = regexp.MustCompile(`\$node\.([A-Za-z0-9_\-]+)`)
+	loopRefPattern = regexp.MustCompile(`\$(item|index)\b`)
+)
+
+// NodeReferences returns the node IDs referenced by $node expressions in
+// expr, sorted and without duplicates.
+func NodeReferences(expr string) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, m := range nodeRefPattern.FindAllStringSubmatch(expr, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			ids = append(ids, m[1])
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// AnalyzeWorkflow runs the checks behind a dry run against a compiled plan.
+// It reports $node references to unknown nodes or to nodes that do not run
+// before the referencing node, $item and $index outside for_each nodes, tool
+// nodes whose tool is unknown (when knownTool is non-nil), and nodes that no
+// branch can reach because every path to them leaves an "if" node whose
+// condition is a constant.
+func AnalyzeWorkflow(wf Workflow, plan *Plan, knownTool func(string) bool) []Diagnostic {
+	diags := make([]Diagnostic, 0)
+	if plan == nil {
+		return diags
+	}
+	nodeIndex := make(map[string]int, len(wf.Nodes))
+	for i, n := range wf.Nodes {
+		nodeIndex[n.ID] = i
+	}
+
+	// upstream[id] holds every node that finishes before id starts.
+	upstream := make(map[string]map[string]bool, len(plan.NodeOrder))
+	for _, id := range plan.NodeOrder {
+		set := map[string]bool{}
+		for _, e := range plan.Incoming[id] {
+			set[e.Source.NodeID] = true
+			for anc := range upstream[e.Source.NodeID] {
+				set[anc] = true
+			}
+		}
+		upstream[id] = set
+	}
+
+	for i, n := range wf.Nodes {
+		idxPath := fmt.Sprintf("workflow.nodes[%d]", i)
+		if knownTool != nil && n.Type == "tool" && strings.TrimSpace(n.Tool) != "" && !knownTool(n.Tool) {
+			diags = append(diags, Diagnostic{
+				Severity: DiagnosticSeverityError,
+				Code:     "node.tool.unknown",
+				Message:  fmt.Sprintf("tool %q is not registered", n.Tool),
+				Path:     idxPath + ".tool",
+			})
+		}
+
+		exprs := []struct{ expr, path string }{
+			{n.Guard, idxPath + ".guard"},
+			{n.ForEach, idxPath + ".for_each"},
+		}
+		keys := make([]string, 0, len(n.Inputs))
+		for key := range n.Inputs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			exprs = append(exprs, struct{ expr, path string }{n.Inputs[key].Expression, idxPath + ".inputs." + key + ".expression"})
+		}
+		for _, x := range exprs {
+			if strings.TrimSpace(x.expr) == "" {
+				continue
+			}
+			for _, ref := range NodeReferences(x.expr) {
+				switch {
+				case ref == n.ID:
+					diags = append(diags, Diagnostic{
+						Severity: DiagnosticSeverityError,
+						Code:     "node.expression.self_reference",
+						Message:  fmt.Sprintf("$node.%s refers to the node's own output", ref),
+						Path:     x.path,
+					})
+				case !hasNode(nodeIndex, ref):
+					diags = append(diags, Diagnostic{
+						Severity: DiagnosticSeverityError,
+						Code:     "node.expression.unknown_node",
+						Message:  fmt.Sprintf("$node.%s does not exist", ref),
+						Path:     x.path,
+					})
+				case !upstream[n.ID][ref]:
+					diags = append(diags, Diagnostic{
+						Severity: DiagnosticSeverityError,
+						Code:     "node.expression.not_upstream",
+						Message:  fmt.Sprintf("$node.%s is not guaranteed to run before %s; connect it with an edge", ref, n.ID),
+						Path:     x.path,
+					})
+				}
+			}
+			inLoop := strings.TrimSpace(n.ForEach) != "" && strings.HasPrefix(x.path, idxPath+".inputs.")
+			if !inLoop && loopRefPattern.MatchString(x.expr) {
+				diags = append(diags, Diagnostic{
+					Severity: DiagnosticSeverityError,
+					Code:     "node.expression.loop_scope",
+					Message:  "$item and $index are only available in the inputs of for_each nodes",
+					Path:     x.path,
+				})
+			}
+		}
+	}
+
+	for _, id := range unreachableNodes(wf, plan) {
+		diags = append(diags, Diagnostic{
+			Severity: DiagnosticSeverityWarning,
+			Code:     "node.unreachable",
+			Message:  fmt.Sprintf("node %s can never run: every branch leading to it is never taken", id),
+			Path:     fmt.Sprintf("workflow.nodes[%d]", nodeIndex[id]),
+		})
+	}
+	return diags
+}
+
+// unreachableNodes returns, in plan order, the nodes no execution can reach.
+// Roots are always reachable; an edge is live when its source is reachable
+// and, for "if" nodes with a literal boolean condition, its port matches
+// that constant.
+func unreachableNodes(wf Workflow, plan *Plan) []string {
+	nodeByID := make(map[string]Node, len(wf.Nodes))
+	for _, n := range wf.Nodes {
+		nodeByID[n.ID] = n
+	}
+	reachable := make(map[string]bool, len(plan.NodeOrder))
+	var out []string
+	for _, id := range plan.NodeOrder {
+		incoming := plan.Incoming[id]
+		live := len(incoming) == 0
+		for _, e := range incoming {
+			if reachable[e.Source.NodeID] && edgeCanFire(nodeByID[e.Source.NodeID], e) {
+				live = true
+				break
+			}
+		}
+		reachable[id] = live
+		if !live {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func edgeCanFire(src Node, e Edge) bool {
+	if src.Type != "if" || (e.Source.Port != PortTrue && e.Source.Port != PortFalse) {
+		return true
+	}
+	cond, ok := src.Inputs["condition"]
+	if !ok || strings.TrimSpace(cond.Expression) != "" {
+		return true
+	}
+	value, ok := cond.Literal.(bool)
+	if !ok {
+		return true
+	}
+	return value == (e.Source.Port == PortTrue)
+}
+
+func hasNode(index map[string]int, id string) bool {
+	_, ok := index[id]
+	return ok
+}
@@ -0,0 +1,71 @@
+package flow
+
+import "testing"
+
+func TestAnalyzeWorkflowReferencesAndTools(t *testing.T) {
+	t.Parallel()
+
+	wf := validWorkflow()
+	plan, diags := CompileWorkflow(wf)
+	if plan == nil {
+		t.Fatalf("compile failed: %#v", diags)
+	}
+	known := func(name string) bool { return name != "llm_transform" }
+	diags = AnalyzeWorkflow(wf, plan, known)
+	if len(diags) != 1 || diags[0].Code != "node.tool.unknown" || diags[0].Path != "workflow.nodes[2].tool" {
+		t.Fatalf("expected only the unknown tool, got: %#v", diags)
+	}
+
+	wf.Nodes[0].Inputs["query"] = InputBinding{Expression: "={{$node.summarize.output.text}}"}
+	wf.Nodes[1].Inputs["extra"] = InputBinding{Expression: "={{$node.missing.output}}"}
+	wf.Nodes[2].Inputs["n"] = InputBinding{Expression: "={{$index}}"}
+	plan, _ = CompileWorkflow(wf)
+	diags = AnalyzeWorkflow(wf, plan, nil)
+	for _, code := range []string{"node.expression.not_upstream", "node.expression.unknown_node", "node.expression.loop_scope"} {
+		if !hasCode(diags, code) {
+			t.Fatalf("expected %s, got: %#v", code, diags)
+		}
+	}
+
+	wf = validWorkflow()
+	wf.Nodes[1].ForEach = "={{$node.search.output.urls}}"
+	wf.Nodes[1].Inputs["urls"] = InputBinding{Expression: "={{$item.url}}"}
+	plan, _ = CompileWorkflow(wf)
+	if diags := AnalyzeWorkflow(wf, plan, nil); len(diags) != 0 {
+		t.Fatalf("$item inside for_each inputs is valid, got: %#v", diags)
+	}
+}
+
+func TestAnalyzeWorkflowUnreachable(t *testing.T) {
+	t.Parallel()
+
+	wf := validWorkflow()
+	wf.Nodes = append(wf.Nodes, Node{
+		ID:     "check",
+		Name:   "Check",
+		Kind:   NodeKindLogic,
+		Type:   "if",
+		Inputs: map[string]InputBinding{"condition": {Literal: false}},
+	})
+	wf.Edges = []Edge{
+		{Source: PortRef{NodeID: "check", Port: PortTrue}, Target: PortRef{NodeID: "search", Port: "input"}},
+		{Source: PortRef{NodeID: "search", Port: "result"}, Target: PortRef{NodeID: "fetch", Port: "input"}},
+		{Source: PortRef{NodeID: "check", Port: PortFalse}, Target: PortRef{NodeID: "summarize", Port: "input"}},
+	}
+	plan, diags := CompileWorkflow(wf)
+	if plan == nil {
+		t.Fatalf("compile failed: %#v", diags)
+	}
+	got := unreachableNodes(wf, plan)
+	if len(got) != 2 || got[0] != "search" || got[1] != "fetch" {
+		t.Fatalf("unexpected unreachable nodes: %v", got)
+	}
+	if diags := AnalyzeWorkflow(wf, plan, nil); countSeverity(diags, DiagnosticSeverityWarning) != 2 {
+		t.Fatalf("expected two unreachable warnings, got: %#v", diags)
+	}
+
+	wf.Nodes[3].Inputs["condition"] = InputBinding{Expression: "={{$run.input.go}}"}
+	if got := unreachableNodes(wf, plan); len(got) != 0 {
+		t.Fatalf("dynamic conditions keep both branches reachable, got %v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 )
 
 type DiagnosticSeverity string
@@ -122,6 +123,34 @@ func ValidateWorkflow(wf Workflow) []Diagnostic {
 		}
 	}
 
+	if wf.EvalGate != nil {
+		if strings.TrimSpace(wf.EvalGate.ExperimentID) == "" {
+			add(
+				DiagnosticSeverityError,
+				"workflow.eval_gate.experiment_id.required",
+				"eval gate requires experiment_id",
+				"workflow.eval_gate.experiment_id",
+			)
+		}
+		if mode := strings.ToLower(strings.TrimSpace(wf.EvalGate.Mode)); mode != "" && mode != "run" && mode != "verify" {
+			add(
+				DiagnosticSeverityError,
+				"workflow.eval_gate.mode.invalid",
+				`eval gate mode must be "run" or "verify"`,
+				"workflow.eval_gate.mode",
+			)
+		}
+	}
+
+	for _, path := range invalidDurations(wf.Settings.DefaultExecution, "workflow.settings.default_execution") {
+		add(
+			DiagnosticSeverityError,
+			"workflow.settings.duration.invalid",
+			"must be a positive duration such as 500ms or 30s",
+			path,
+		)
+	}
+
 	if len(wf.Nodes) == 0 {
 		add(
 			DiagnosticSeverityError,
@@ -183,6 +212,22 @@ func ValidateWorkflow(wf Workflow) []Diagnostic {
 				idxPath+".execution.retries.backoff",
 			)
 		}
+		for _, path := range invalidDurations(n.Execution, idxPath+".execution") {
+			add(
+				DiagnosticSeverityError,
+				"node.execution.duration.invalid",
+				"must be a positive duration such as 500ms or 30s",
+				path,
+			)
+		}
+		if expr := strings.TrimSpace(n.ForEach); expr != "" && !isReferenceExpression(expr) {
+			add(
+				DiagnosticSeverityError,
+				"node.for_each.invalid",
+				"for_each must reference a list via $run.input or $node expressions",
+				idxPath+".for_each",
+			)
+		}
 		for key, binding := range n.Inputs {
 			path := idxPath + ".inputs." + key
 			hasExpr := strings.TrimSpace(binding.Expression) != ""
@@ -237,6 +282,16 @@ func ValidateWorkflow(wf Workflow) []Diagnostic {
 		if strings.TrimSpace(e.Target.Port) == "" {
 			add(DiagnosticSeverityError, "edge.target.port.required", "edge target port is required", idxPath+".target.port")
 		}
+		if port := strings.TrimSpace(e.Source.Port); port == PortTrue || port == PortFalse {
+			if src, ok := nodeByID[e.Source.NodeID]; ok && src.Type != "if" {
+				add(
+					DiagnosticSeverityError,
+					"edge.source.port.branch_requires_if",
+					fmt.Sprintf("%q and %q ports are only valid on if nodes", PortTrue, PortFalse),
+					idxPath+".source.port",
+				)
+			}
+		}
 		if e.Source.NodeID != "" && e.Source.NodeID == e.Target.NodeID {
 			add(DiagnosticSeverityError, "edge.self_loop", "self-loop edges are not supported", idxPath)
 		}
@@ -365,6 +420,33 @@ func CompileWorkflow(wf Workflow) (*Plan, []Diagnostic) {
 	return plan, diags
 }
 
+// invalidDurations returns the paths of duration fields in exec that are set
+// but are not positive Go durations.
+func invalidDurations(exec NodeExecution, path string) []string {
+	var bad []string
+	for _, f := range []struct{ value, path string }{
+		{exec.Timeout, path + ".timeout"},
+		{exec.Retries.Delay, path + ".retries.delay"},
+		{exec.Retries.MaxDelay, path + ".retries.max_delay"},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(f.value)); err != nil || d <= 0 {
+			bad = append(bad, f.path)
+		}
+	}
+	return bad
+}
+
+// isReferenceExpression reports whether expr, after stripping the optional
+// "=" and "{{ }}" wrappers, is a $run.input or $node path.
+func isReferenceExpression(expr string) bool {
+	expr = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr), "="))
+	expr = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(expr, "{{"), "}}"))
+	return strings.HasPrefix(expr, "$run.input") || strings.HasPrefix(expr, "$node.")
+}
+
 func hasError(diags []Diagnostic) bool {
 	for _, d := range diags {
 		if d.Severity == DiagnosticSeverityError {
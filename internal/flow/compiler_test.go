@@ -23,6 +23,49 @@ func TestValidateWorkflow(t *testing.T) {
 	}
 }
 
+func TestValidateWorkflowBranching(t *testing.T) {
+	t.Parallel()
+
+	wf := validWorkflow()
+	wf.Nodes[1].ForEach = "={{$node.search.output.results}}"
+	wf.Edges[0].Source.Port = PortOnFailure
+	if diags := ValidateWorkflow(wf); countSeverity(diags, DiagnosticSeverityError) != 0 {
+		t.Fatalf("expected no errors, got: %#v", diags)
+	}
+
+	wf.Nodes[1].ForEach = "[1,2]"
+	wf.Edges[0].Source.Port = PortTrue
+	diags := ValidateWorkflow(wf)
+	if !hasCode(diags, "node.for_each.invalid") {
+		t.Fatalf("expected for_each error, got: %#v", diags)
+	}
+	if !hasCode(diags, "edge.source.port.branch_requires_if") {
+		t.Fatalf("expected branch port error, got: %#v", diags)
+	}
+}
+
+func TestValidateWorkflowExecutionDurations(t *testing.T) {
+	t.Parallel()
+
+	wf := validWorkflow()
+	wf.Nodes[2].Execution.Timeout = "30s"
+	wf.Nodes[2].Execution.Retries.Delay = "1s"
+	wf.Nodes[2].Execution.Retries.MaxDelay = "10s"
+	if diags := ValidateWorkflow(wf); countSeverity(diags, DiagnosticSeverityError) != 0 {
+		t.Fatalf("expected no errors, got: %#v", diags)
+	}
+
+	wf.Nodes[2].Execution.Retries.Delay = "soon"
+	wf.Settings.DefaultExecution.Timeout = "-5s"
+	diags := ValidateWorkflow(wf)
+	if !hasCode(diags, "node.execution.duration.invalid") {
+		t.Fatalf("expected node duration error, got: %#v", diags)
+	}
+	if !hasCode(diags, "workflow.settings.duration.invalid") {
+		t.Fatalf("expected settings duration error, got: %#v", diags)
+	}
+}
+
 func TestCompileWorkflow(t *testing.T) {
 	t.Parallel()
 
@@ -24,18 +24,40 @@ type PutWorkflowRequest struct {
 
 type ValidateRequest struct {
 	Workflow Workflow `json:"workflow"`
+	// WorkflowID validates a saved workflow instead of Workflow. Only the
+	// dry-run endpoint accepts it.
+	WorkflowID string `json:"workflow_id,omitempty"`
+	// Input is the sample run input a dry run expands expressions against.
+	Input map[string]any `json:"input,omitempty"`
 }
 
 type ValidateResponse struct {
 	Valid       bool         `json:"valid"`
 	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 	Plan        *Plan        `json:"plan,omitempty"`
+	// Steps is the simulated outcome of each node, in plan order. Only dry
+	// runs fill it.
+	Steps []DryRunStep `json:"steps,omitempty"`
+}
+
+// DryRunStep reports what a node would do with the sample input. Status is
+// "ready" when its inputs resolved, "skipped" when a guard or branch leaves
+// it out, and "error" when an expression cannot be resolved.
+type DryRunStep struct {
+	NodeID string         `json:"node_id"`
+	Status string         `json:"status"`
+	Tool   string         `json:"tool,omitempty"`
+	Inputs map[string]any `json:"inputs,omitempty"`
+	Reason string         `json:"reason,omitempty"`
 }
 
 type RunRequest struct {
 	WorkflowID string         `json:"workflow_id"`
 	Input      map[string]any `json:"input,omitempty"`
 	ProjectID  string         `json:"project_id,omitempty"`
+	// DryRun validates and simulates the run without calling any tool and
+	// returns a ValidateResponse instead of starting it.
+	DryRun bool `json:"dry_run,omitempty"`
 }
 
 type RunResponse struct {
@@ -12,6 +12,19 @@ type Workflow struct {
 	Nodes       []Node           `json:"nodes"`
 	Edges       []Edge           `json:"edges,omitempty"`
 	Settings    WorkflowSettings `json:"settings,omitempty"`
+	// EvalGate, when set, must pass before an upsert replaces the stored
+	// workflow.
+	EvalGate *EvalGate `json:"eval_gate,omitempty"`
+}
+
+// EvalGate references a playground experiment whose score on Metric must
+// reach MinScore. Mode is "run" (default) to execute the experiment, or
+// "verify" to accept its latest completed run.
+type EvalGate struct {
+	ExperimentID string  `json:"experiment_id"`
+	Metric       string  `json:"metric,omitempty"`
+	MinScore     float64 `json:"min_score"`
+	Mode         string  `json:"mode,omitempty"`
 }
 
 // Trigger defines how workflow execution starts.
@@ -56,7 +69,12 @@ type Node struct {
 	PublishResult bool                    `json:"publish_result,omitempty"`
 	PublishMode   string                  `json:"publish_mode,omitempty"`
 	Inputs        map[string]InputBinding `json:"inputs,omitempty"`
-	Execution     NodeExecution           `json:"execution,omitempty"`
+	// ForEach is an expression resolving to a list. When set, the node runs
+	// once per element; inputs may reference the element as $item and its
+	// position as $index, and the node output collects each iteration under
+	// "items".
+	ForEach   string        `json:"for_each,omitempty"`
+	Execution NodeExecution `json:"execution,omitempty"`
 }
 
 type NodeKind string
@@ -75,6 +93,8 @@ type InputBinding struct {
 
 // NodeExecution configures runtime behavior for an individual node.
 type NodeExecution struct {
+	// Timeout bounds each attempt separately, so a hung call is retried
+	// rather than stalling the run.
 	Timeout string        `json:"timeout,omitempty"`
 	Retries RetryPolicy   `json:"retries,omitempty"`
 	OnError ErrorStrategy `json:"on_error,omitempty"`
@@ -83,6 +103,10 @@ type NodeExecution struct {
 type RetryPolicy struct {
 	Max     int             `json:"max,omitempty"`
 	Backoff BackoffStrategy `json:"backoff,omitempty"`
+	// Delay is the wait before the first retry (default 200ms). Exponential
+	// backoff doubles it on every further retry, up to MaxDelay.
+	Delay    string `json:"delay,omitempty"`
+	MaxDelay string `json:"max_delay,omitempty"`
 }
 
 type BackoffStrategy string
@@ -109,6 +133,17 @@ type Edge struct {
 	Mapping []FieldMapping `json:"mapping,omitempty"`
 }
 
+// Branch ports. Edges leaving an "if" node on PortTrue or PortFalse are only
+// followed when its condition matches. Edges leaving any node on
+// PortOnFailure are only followed when that node fails, and the failure is
+// then treated as handled. A node whose incoming edges were all left
+// untaken is skipped.
+const (
+	PortTrue      = "true"
+	PortFalse     = "false"
+	PortOnFailure = "on_failure"
+)
+
 type PortRef struct {
 	NodeID string `json:"node_id"`
 	Port   string `json:"port"`
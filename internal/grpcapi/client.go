@@ -0,0 +1,56 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client wraps the generated ManifoldClient and attaches the bearer token.
+type Client struct {
+	rpc   ManifoldClient
+	token string
+}
+
+// NewClient returns a client over conn. A non-empty token is sent as a
+// bearer token with every call.
+func NewClient(conn grpc.ClientConnInterface, token string) *Client {
+	return &Client{rpc: NewManifoldClient(conn), token: token}
+}
+
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	if c.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+}
+
+// RunAgent starts a run and calls onEvent for each streamed event until the
+// run ends.
+func (c *Client) RunAgent(ctx context.Context, req *RunAgentRequest, onEvent func(*RunAgentEvent)) error {
+	stream, err := c.rpc.RunAgent(c.outgoing(ctx), req)
+	if err != nil {
+		return err
+	}
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		onEvent(ev)
+	}
+}
+
+func (c *Client) ListSessions(ctx context.Context) (*ListSessionsResponse, error) {
+	return c.rpc.ListSessions(c.outgoing(ctx), &ListSessionsRequest{})
+}
+
+func (c *Client) ExecuteWorkflow(ctx context.Context, req *ExecuteWorkflowRequest) (*ExecuteWorkflowResponse, error) {
+	return c.rpc.ExecuteWorkflow(c.outgoing(ctx), req)
+}
@@ -0,0 +1,217 @@
+// Manifold gRPC API.
+//
+// Authenticate with an "authorization: Bearer <session token>" metadata entry
+// when the server has auth enabled.
+//
+// Regenerate manifold.pb.go and manifold_grpc.pb.go with go generate after
+// editing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: manifold.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Manifold_RunAgent_FullMethodName        = "/manifold.v1.Manifold/RunAgent"
+	Manifold_ListSessions_FullMethodName    = "/manifold.v1.Manifold/ListSessions"
+	Manifold_ExecuteWorkflow_FullMethodName = "/manifold.v1.Manifold/ExecuteWorkflow"
+)
+
+// ManifoldClient is the client API for Manifold service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ManifoldClient interface {
+	// RunAgent runs one orchestrator turn and streams its events: the same
+	// delta, tool_start, tool_result, final and error events /agent/run emits
+	// over SSE.
+	RunAgent(ctx context.Context, in *RunAgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunAgentEvent], error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// ExecuteWorkflow runs a saved workflow to completion.
+	ExecuteWorkflow(ctx context.Context, in *ExecuteWorkflowRequest, opts ...grpc.CallOption) (*ExecuteWorkflowResponse, error)
+}
+
+type manifoldClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewManifoldClient(cc grpc.ClientConnInterface) ManifoldClient {
+	return &manifoldClient{cc}
+}
+
+func (c *manifoldClient) RunAgent(ctx context.Context, in *RunAgentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunAgentEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manifold_ServiceDesc.Streams[0], Manifold_RunAgent_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunAgentRequest, RunAgentEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Manifold_RunAgentClient = grpc.ServerStreamingClient[RunAgentEvent]
+
+func (c *manifoldClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, Manifold_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *manifoldClient) ExecuteWorkflow(ctx context.Context, in *ExecuteWorkflowRequest, opts ...grpc.CallOption) (*ExecuteWorkflowResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecuteWorkflowResponse)
+	err := c.cc.Invoke(ctx, Manifold_ExecuteWorkflow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ManifoldServer is the server API for Manifold service.
+// All implementations must embed UnimplementedManifoldServer
+// for forward compatibility.
+type ManifoldServer interface {
+	// RunAgent runs one orchestrator turn and streams its events: the same
+	// delta, tool_start, tool_result, final and error events /agent/run emits
+	// over SSE.
+	RunAgent(*RunAgentRequest, grpc.ServerStreamingServer[RunAgentEvent]) error
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// ExecuteWorkflow runs a saved workflow to completion.
+	ExecuteWorkflow(context.Context, *ExecuteWorkflowRequest) (*ExecuteWorkflowResponse, error)
+	mustEmbedUnimplementedManifoldServer()
+}
+
+// UnimplementedManifoldServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedManifoldServer struct{}
+
+func (UnimplementedManifoldServer) RunAgent(*RunAgentRequest, grpc.ServerStreamingServer[RunAgentEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method RunAgent not implemented")
+}
+func (UnimplementedManifoldServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedManifoldServer) ExecuteWorkflow(context.Context, *ExecuteWorkflowRequest) (*ExecuteWorkflowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteWorkflow not implemented")
+}
+func (UnimplementedManifoldServer) mustEmbedUnimplementedManifoldServer() {}
+func (UnimplementedManifoldServer) testEmbeddedByValue()                  {}
+
+// UnsafeManifoldServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ManifoldServer will
+// result in compilation errors.
+type UnsafeManifoldServer interface {
+	mustEmbedUnimplementedManifoldServer()
+}
+
+func RegisterManifoldServer(s grpc.ServiceRegistrar, srv ManifoldServer) {
+	// If the following call pancis, it indicates UnimplementedManifoldServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Manifold_ServiceDesc, srv)
+}
+
+func _Manifold_RunAgent_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunAgentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManifoldServer).RunAgent(m, &grpc.GenericServerStream[RunAgentRequest, RunAgentEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Manifold_RunAgentServer = grpc.ServerStreamingServer[RunAgentEvent]
+
+func _Manifold_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManifoldServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manifold_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManifoldServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manifold_ExecuteWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteWorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManifoldServer).ExecuteWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manifold_ExecuteWorkflow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManifoldServer).ExecuteWorkflow(ctx, req.(*ExecuteWorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Manifold_ServiceDesc is the grpc.ServiceDesc for Manifold service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Manifold_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "manifold.v1.Manifold",
+	HandlerType: (*ManifoldServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _Manifold_ListSessions_Handler,
+		},
+		{
+			MethodName: "ExecuteWorkflow",
+			Handler:    _Manifold_ExecuteWorkflow_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunAgent",
+			Handler:       _Manifold_RunAgent_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "manifold.proto",
+}
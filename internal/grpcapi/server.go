@@ -0,0 +1,131 @@
+// Package grpcapi serves agent runs, chat sessions and workflow execution
+// over gRPC for non-browser clients. The service contract is manifold.proto;
+// the message types and service stubs are generated from it.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative manifold.proto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrUnauthenticated is returned by Backend.Authenticate for a missing or
+// invalid token.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Backend carries out the calls. Methods may return gRPC status errors to
+// pick the response code; other errors are reported as codes.Unknown.
+type Backend interface {
+	// Authenticate resolves the bearer token from the request metadata,
+	// which is empty when none was sent, to a user ID.
+	Authenticate(ctx context.Context, token string) (int64, error)
+	// RunAgent runs one turn and calls send for each event, in order.
+	RunAgent(ctx context.Context, userID int64, req *RunAgentRequest, send func(*RunAgentEvent) error) error
+	ListSessions(ctx context.Context, userID int64) ([]*Session, error)
+	ExecuteWorkflow(ctx context.Context, userID int64, workflowID string, input map[string]any) (map[string]any, error)
+}
+
+// NewServer returns a gRPC server with the Manifold service registered.
+func NewServer(b Backend, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	RegisterManifoldServer(srv, &service{backend: b})
+	return srv
+}
+
+type service struct {
+	UnimplementedManifoldServer
+	backend Backend
+}
+
+// authenticate reads "authorization: Bearer <token>" from the incoming
+// metadata and resolves it through the backend.
+func (s *service) authenticate(ctx context.Context) (int64, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("authorization"); len(vals) > 0 {
+			token = strings.TrimSpace(vals[0])
+			if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+				token = strings.TrimSpace(token[7:])
+			}
+		}
+	}
+	userID, err := s.backend.Authenticate(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrUnauthenticated) {
+			return 0, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (s *service) RunAgent(req *RunAgentRequest, stream grpc.ServerStreamingServer[RunAgentEvent]) error {
+	ctx := stream.Context()
+	userID, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.GetPrompt()) == "" {
+		return status.Error(codes.InvalidArgument, "prompt is required")
+	}
+	return s.backend.RunAgent(ctx, userID, req, stream.Send)
+}
+
+func (s *service) ListSessions(ctx context.Context, _ *ListSessionsRequest) (*ListSessionsResponse, error) {
+	userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.backend.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &ListSessionsResponse{Sessions: sessions}, nil
+}
+
+func (s *service) ExecuteWorkflow(ctx context.Context, req *ExecuteWorkflowRequest) (*ExecuteWorkflowResponse, error) {
+	userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.GetWorkflowId()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "workflow_id is required")
+	}
+	result, err := s.backend.ExecuteWorkflow(ctx, userID, req.GetWorkflowId(), req.GetInput().AsMap())
+	if err != nil {
+		return nil, err
+	}
+	out, err := toStruct(result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode workflow result: %v", err)
+	}
+	return &ExecuteWorkflowResponse{Result: out}, nil
+}
+
+// toStruct converts a workflow result to a Struct. It goes through JSON
+// because results may hold values, such as typed slices, that
+// structpb.NewStruct rejects.
+func toStruct(m map[string]any) (*structpb.Struct, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	out := new(structpb.Struct)
+	if err := protojson.Unmarshal(b, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type fakeBackend struct{}
+
+func (fakeBackend) Authenticate(_ context.Context, token string) (int64, error) {
+	if token != "secret" {
+		return 0, ErrUnauthenticated
+	}
+	return 7, nil
+}
+
+func (fakeBackend) RunAgent(_ context.Context, userID int64, req *RunAgentRequest, send func(*RunAgentEvent) error) error {
+	for _, ev := range []*RunAgentEvent{
+		{Type: "delta", Data: "hel"},
+		{Type: "delta", Data: "lo"},
+		{Type: "final", Data: req.Prompt},
+	} {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fakeBackend) ListSessions(_ context.Context, userID int64) ([]*Session, error) {
+	return []*Session{{Id: "s1", Name: "user session"}}, nil
+}
+
+func (fakeBackend) ExecuteWorkflow(_ context.Context, userID int64, workflowID string, input map[string]any) (map[string]any, error) {
+	if workflowID != "wf" {
+		return nil, status.Error(codes.NotFound, "workflow not found")
+	}
+	return map[string]any{"echo": input["text"], "user": userID}, nil
+}
+
+func dialTestServer(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(fakeBackend{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestServerRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := NewClient(dialTestServer(t), "secret")
+
+	var events []*RunAgentEvent
+	if err := client.RunAgent(ctx, &RunAgentRequest{Prompt: "hello"}, func(ev *RunAgentEvent) { events = append(events, ev) }); err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	if len(events) != 3 || events[2].Type != "final" || events[2].Data != "hello" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	sessions, err := client.ListSessions(ctx)
+	if err != nil || len(sessions.Sessions) != 1 || sessions.Sessions[0].GetId() != "s1" {
+		t.Fatalf("ListSessions: %+v (%v)", sessions, err)
+	}
+
+	input, _ := structpb.NewStruct(map[string]any{"text": "hi"})
+	res, err := client.ExecuteWorkflow(ctx, &ExecuteWorkflowRequest{WorkflowId: "wf", Input: input})
+	if err != nil {
+		t.Fatalf("ExecuteWorkflow: %v", err)
+	}
+	if result := res.GetResult().AsMap(); result["echo"] != "hi" || result["user"] != float64(7) {
+		t.Fatalf("ExecuteWorkflow: %+v", result)
+	}
+	if _, err := client.ExecuteWorkflow(ctx, &ExecuteWorkflowRequest{WorkflowId: "nope"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if _, err := client.ExecuteWorkflow(ctx, &ExecuteWorkflowRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	t.Parallel()
+
+	conn := dialTestServer(t)
+	ctx := context.Background()
+	if _, err := NewClient(conn, "").ListSessions(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+	err := NewClient(conn, "wrong").RunAgent(ctx, &RunAgentRequest{Prompt: "hi"}, func(*RunAgentEvent) {})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for a bad token, got %v", err)
+	}
+}
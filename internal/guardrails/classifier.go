@@ -0,0 +1,109 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"manifold/internal/llm"
+)
+
+const classifierMaxInputRunes = 8000
+
+var defaultClassifierCategories = []string{
+	"violence",
+	"self_harm",
+	"sexual_minors",
+	"hate",
+	"illegal_activity",
+	"prompt_injection",
+}
+
+// classifierRule asks an LLM (typically the summary model) to label text.
+type classifierRule struct {
+	provider   llm.Provider
+	model      string
+	categories []string
+}
+
+// NewClassifierRule returns a rule that classifies text with the given provider.
+// When categories is empty a conservative default set is used.
+func NewClassifierRule(provider llm.Provider, model string, categories []string) Rule {
+	cats := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if c = strings.TrimSpace(c); c != "" {
+			cats = append(cats, c)
+		}
+	}
+	if len(cats) == 0 {
+		cats = defaultClassifierCategories
+	}
+	return &classifierRule{provider: provider, model: model, categories: cats}
+}
+
+func (r *classifierRule) Name() string { return "classifier" }
+
+type classifierVerdict struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+	Reason     string   `json:"reason"`
+}
+
+func (r *classifierRule) Check(ctx context.Context, stage Stage, text string) ([]Violation, error) {
+	subject := "user prompt"
+	if stage == StageOutput {
+		subject = "assistant response"
+	}
+	sys := "You are a content safety classifier. Decide whether the text violates any of these categories: " +
+		strings.Join(r.categories, ", ") +
+		`. Respond with JSON only: {"flagged": bool, "categories": [string], "reason": string}.`
+	user := fmt.Sprintf("Classify the following %s:\n\n%s", subject, truncateRunes(text, classifierMaxInputRunes))
+
+	msgs := []llm.Message{
+		{Role: "system", Content: sys},
+		{Role: "user", Content: user},
+	}
+	resp, err := r.provider.Chat(ctx, msgs, nil, r.model)
+	if err != nil {
+		return nil, fmt.Errorf("classify: %w", err)
+	}
+	verdict, err := parseVerdict(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	if !verdict.Flagged {
+		return nil, nil
+	}
+	if len(verdict.Categories) == 0 {
+		verdict.Categories = []string{"unspecified"}
+	}
+	out := make([]Violation, 0, len(verdict.Categories))
+	for _, c := range verdict.Categories {
+		out = append(out, Violation{Stage: stage, Category: CategoryClassifier, Rule: c, Reason: verdict.Reason})
+	}
+	return out, nil
+}
+
+// parseVerdict extracts the JSON object from a model reply, tolerating code
+// fences or surrounding prose.
+func parseVerdict(raw string) (classifierVerdict, error) {
+	var v classifierVerdict
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return v, fmt.Errorf("classifier returned no JSON object")
+	}
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &v); err != nil {
+		return v, fmt.Errorf("decode classifier verdict: %w", err)
+	}
+	return v, nil
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,170 @@
+// Package guardrails screens user prompts and assistant outputs against
+// configurable rule sets before they reach the model or the client.
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+// Stage identifies which side of a conversation turn is being screened.
+type Stage string
+
+const (
+	// StageInput screens user prompts before the agent runs.
+	StageInput Stage = "input"
+	// StageOutput screens assistant responses before they are returned or persisted.
+	StageOutput Stage = "output"
+)
+
+// Violation categories reported by the built-in rules.
+const (
+	CategoryBlocklist  = "blocklist"
+	CategoryPII        = "pii"
+	CategoryJailbreak  = "jailbreak"
+	CategoryClassifier = "classifier"
+)
+
+const defaultViolationMessage = "This request was blocked by content policy."
+
+// Violation describes a single rule match.
+type Violation struct {
+	Stage    Stage  `json:"stage"`
+	Category string `json:"category"`
+	Rule     string `json:"rule"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Result is the outcome of screening a piece of text.
+type Result struct {
+	Stage      Stage       `json:"stage"`
+	Allowed    bool        `json:"allowed"`
+	Message    string      `json:"message,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Rule inspects text and reports any violations it finds.
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, stage Stage, text string) ([]Violation, error)
+}
+
+// Guard applies the configured input and output rule sets.
+type Guard struct {
+	input    []Rule
+	output   []Rule
+	message  string
+	failOpen bool
+}
+
+// New builds a Guard from configuration. The provider and model are used by
+// the optional LLM classifier; when provider is nil the classifier is skipped.
+// New returns nil when guardrails are disabled.
+func New(cfg config.GuardrailsConfig, provider llm.Provider, model string) (*Guard, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if m := strings.TrimSpace(cfg.Classifier.Model); m != "" {
+		model = m
+	}
+	var classifier Rule
+	if cfg.Classifier.Enabled && provider != nil {
+		classifier = NewClassifierRule(provider, model, cfg.Classifier.Categories)
+	}
+
+	input, err := buildRules(cfg.Input, classifier)
+	if err != nil {
+		return nil, fmt.Errorf("guardrails.input: %w", err)
+	}
+	output, err := buildRules(cfg.Output, classifier)
+	if err != nil {
+		return nil, fmt.Errorf("guardrails.output: %w", err)
+	}
+
+	msg := strings.TrimSpace(cfg.Message)
+	if msg == "" {
+		msg = defaultViolationMessage
+	}
+	return &Guard{input: input, output: output, message: msg, failOpen: cfg.Classifier.FailOpen}, nil
+}
+
+func buildRules(set config.GuardrailRuleSet, classifier Rule) ([]Rule, error) {
+	var rules []Rule
+	if len(set.Blocklist) > 0 {
+		r, err := NewBlocklistRule(set.Blocklist)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	if len(set.PII) > 0 {
+		r, err := NewPIIRule(set.PII)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	if set.Jailbreak {
+		rules = append(rules, NewJailbreakRule())
+	}
+	if set.Classifier && classifier != nil {
+		rules = append(rules, classifier)
+	}
+	return rules, nil
+}
+
+// ChecksInput reports whether any input rules are configured.
+func (g *Guard) ChecksInput() bool { return g != nil && len(g.input) > 0 }
+
+// ChecksOutput reports whether any output rules are configured.
+func (g *Guard) ChecksOutput() bool { return g != nil && len(g.output) > 0 }
+
+// CheckPrompt screens a user prompt.
+func (g *Guard) CheckPrompt(ctx context.Context, text string) Result {
+	if g == nil {
+		return Result{Stage: StageInput, Allowed: true}
+	}
+	return g.check(ctx, StageInput, g.input, text)
+}
+
+// CheckOutput screens an assistant response.
+func (g *Guard) CheckOutput(ctx context.Context, text string) Result {
+	if g == nil {
+		return Result{Stage: StageOutput, Allowed: true}
+	}
+	return g.check(ctx, StageOutput, g.output, text)
+}
+
+func (g *Guard) check(ctx context.Context, stage Stage, rules []Rule, text string) Result {
+	res := Result{Stage: stage, Allowed: true}
+	if strings.TrimSpace(text) == "" {
+		return res
+	}
+	for _, rule := range rules {
+		violations, err := rule.Check(ctx, stage, text)
+		if err != nil {
+			log.Warn().Err(err).Str("rule", rule.Name()).Str("stage", string(stage)).Msg("guardrail_rule_failed")
+			if !g.failOpen {
+				violations = append(violations, Violation{
+					Stage:    stage,
+					Category: CategoryClassifier,
+					Rule:     rule.Name(),
+					Reason:   "rule evaluation failed",
+				})
+			}
+		}
+		res.Violations = append(res.Violations, violations...)
+	}
+	if len(res.Violations) > 0 {
+		res.Allowed = false
+		res.Message = g.message
+		log.Info().Str("stage", string(stage)).Int("violations", len(res.Violations)).Msg("guardrail_violation")
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// blocklistRule rejects text matching any of a set of regular expressions.
+type blocklistRule struct {
+	patterns []*regexp.Regexp
+}
+
+// NewBlocklistRule compiles the given patterns into a rule. Patterns are
+// matched case-insensitively.
+func NewBlocklistRule(patterns []string) (Rule, error) {
+	r := &blocklistRule{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func (r *blocklistRule) Name() string { return "blocklist" }
+
+func (r *blocklistRule) Check(_ context.Context, stage Stage, text string) ([]Violation, error) {
+	var out []Violation
+	for _, re := range r.patterns {
+		if re.MatchString(text) {
+			out = append(out, Violation{Stage: stage, Category: CategoryBlocklist, Rule: re.String()[len("(?i)"):]})
+		}
+	}
+	return out, nil
+}
+
+// piiDetector pairs a pattern with an optional validator to reduce false positives.
+type piiDetector struct {
+	pattern  *regexp.Regexp
+	validate func(string) bool
+}
+
+var piiDetectors = map[string]piiDetector{
+	"email":       {pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	"phone":       {pattern: regexp.MustCompile(`(?:\+?1[\s.\-]?)?\(?\b\d{3}\)?[\s.\-]\d{3}[\s.\-]\d{4}\b`)},
+	"ssn":         {pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	"credit_card": {pattern: regexp.MustCompile(`\b(?:\d[ \-]?){13,19}\b`), validate: luhnValid},
+	"ip_address":  {pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+}
+
+// PIIDetectors lists the detector names accepted by NewPIIRule.
+func PIIDetectors() []string {
+	return []string{"credit_card", "email", "ip_address", "phone", "ssn"}
+}
+
+type piiRule struct {
+	names     []string
+	detectors []piiDetector
+}
+
+// NewPIIRule builds a rule from named detectors (see PIIDetectors).
+func NewPIIRule(names []string) (Rule, error) {
+	r := &piiRule{}
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		d, ok := piiDetectors[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown pii detector %q (expected one of %s)", name, strings.Join(PIIDetectors(), ", "))
+		}
+		r.names = append(r.names, key)
+		r.detectors = append(r.detectors, d)
+	}
+	return r, nil
+}
+
+func (r *piiRule) Name() string { return "pii" }
+
+func (r *piiRule) Check(_ context.Context, stage Stage, text string) ([]Violation, error) {
+	var out []Violation
+	for i, d := range r.detectors {
+		for _, match := range d.pattern.FindAllString(text, -1) {
+			if d.validate != nil && !d.validate(match) {
+				continue
+			}
+			out = append(out, Violation{Stage: stage, Category: CategoryPII, Rule: r.names[i]})
+			break
+		}
+	}
+	return out, nil
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// jailbreakPatterns are common prompt-injection phrasings. They are heuristics,
+// not a guarantee, and are intended to catch low-effort attempts.
+var jailbreakPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\b.{0,30}\b(previous|prior|above|all|your)\b.{0,30}\b(instructions|rules|prompts?|guidelines)\b`)},
+	{"reveal_system_prompt", regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,30}\b(system|hidden|initial)\s+(prompt|instructions|message)\b`)},
+	// DAN is matched only in upper case and in a jailbreak phrasing, so the
+	// name Dan and "dan" in other words pass.
+	{"dan_mode", regexp.MustCompile(`(?i:\b(you are|you're|act as|pretend to be|become|enable)\s+(now\s+)?)DAN\b|\bDAN(?i:\s+(mode|prompt|jailbreak))\b|(?i:\b(do anything now|developer mode|jailbreak(ed)? mode)\b)`)},
+	// no_restrictions needs the phrase to be addressed to the assistant, so
+	// "a road with no restrictions" passes.
+	{"no_restrictions", regexp.MustCompile(`(?i)\b((respond|answer|reply|talk|speak|write|act|behave|operate)\b.{0,20}\b(without|with no|free of|free from)|you\s+(have|are|now have)\s+(no|without|free of|free from))\b.{0,20}\b(restrictions|filters|limitations|guardrails|censorship)\b`)},
+	{"roleplay_override", regexp.MustCompile(`(?i)\bpretend\b.{0,40}\b(no|without)\b.{0,20}\b(rules|policies|guidelines)\b`)},
+}
+
+type jailbreakRule struct{}
+
+// NewJailbreakRule returns a heuristic prompt-injection detector.
+func NewJailbreakRule() Rule { return jailbreakRule{} }
+
+func (jailbreakRule) Name() string { return "jailbreak" }
+
+func (jailbreakRule) Check(_ context.Context, stage Stage, text string) ([]Violation, error) {
+	var out []Violation
+	for _, p := range jailbreakPatterns {
+		if p.pattern.MatchString(text) {
+			out = append(out, Violation{Stage: stage, Category: CategoryJailbreak, Rule: p.name})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+package guardrails
+
+import (
+	"fmt"
+	"strings"
+
+	"manifold/internal/config"
+)
+
+// scrubOrder applies detectors from most to least specific so, for example,
+// card numbers are not partially consumed by the phone pattern.
+var scrubOrder = []string{"credit_card", "ssn", "email", "phone", "ip_address"}
+
+// Masking records how many matches of one detector were replaced. The
+// original values are never retained.
+type Masking struct {
+	Detector string `json:"detector"`
+	Count    int    `json:"count"`
+}
+
+// Scrubber masks PII in text. A nil Scrubber leaves text unchanged.
+type Scrubber struct {
+	names     []string
+	detectors []piiDetector
+	mask      string
+}
+
+// NewScrubber builds a Scrubber from configuration. It returns nil when
+// scrubbing is disabled.
+func NewScrubber(cfg config.PIIScrubbingConfig) (*Scrubber, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	want := map[string]bool{}
+	for _, name := range cfg.Detectors {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		if _, ok := piiDetectors[key]; !ok {
+			return nil, fmt.Errorf("piiScrubbing: unknown detector %q (expected one of %s)", name, strings.Join(PIIDetectors(), ", "))
+		}
+		want[key] = true
+	}
+	s := &Scrubber{mask: cfg.Mask}
+	if s.mask == "" {
+		s.mask = "[redacted:%s]"
+	}
+	for _, name := range scrubOrder {
+		if want[name] {
+			s.names = append(s.names, name)
+			s.detectors = append(s.detectors, piiDetectors[name])
+		}
+	}
+	return s, nil
+}
+
+// Scrub returns text with detected PII masked and a summary of what was
+// replaced, in detector order.
+func (s *Scrubber) Scrub(text string) (string, []Masking) {
+	if s == nil || strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+	var masked []Masking
+	for i, d := range s.detectors {
+		replacement := s.replacement(s.names[i])
+		n := 0
+		text = d.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if d.validate != nil && !d.validate(match) {
+				return match
+			}
+			n++
+			return replacement
+		})
+		if n > 0 {
+			masked = append(masked, Masking{Detector: s.names[i], Count: n})
+		}
+	}
+	return text, masked
+}
+
+// ScrubString is Scrub without the annotation, for use as a log filter.
+func (s *Scrubber) ScrubString(text string) string {
+	out, _ := s.Scrub(text)
+	return out
+}
+
+func (s *Scrubber) replacement(name string) string {
+	if strings.Contains(s.mask, "%s") {
+		return strings.ReplaceAll(s.mask, "%s", name)
+	}
+	return s.mask
+}
+
+// MergeMaskings sums counts per detector across several Scrub calls.
+func MergeMaskings(sets ...[]Masking) []Masking {
+	var out []Masking
+	idx := map[string]int{}
+	for _, set := range sets {
+		for _, m := range set {
+			if i, ok := idx[m.Detector]; ok {
+				out[i].Count += m.Count
+				continue
+			}
+			idx[m.Detector] = len(out)
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package guardrails
+
+import (
+	"testing"
+
+	"manifold/internal/config"
+)
+
+func TestScrubberMasksAndAnnotates(t *testing.T) {
+	s, err := NewScrubber(config.PIIScrubbingConfig{Enabled: true, Detectors: []string{"email", "phone", "credit_card"}})
+	if err != nil {
+		t.Fatalf("NewScrubber: %v", err)
+	}
+	in := "Mail jane@example.com or bob@example.org, call 555-123-4567. Card 4111 1111 1111 1111, order 1234567890123."
+	out, masked := s.Scrub(in)
+	want := "Mail [redacted:email] or [redacted:email], call [redacted:phone]. Card [redacted:credit_card], order 1234567890123."
+	if out != want {
+		t.Fatalf("unexpected scrubbed text:\n got %q\nwant %q", out, want)
+	}
+	expected := []Masking{{Detector: "credit_card", Count: 1}, {Detector: "email", Count: 2}, {Detector: "phone", Count: 1}}
+	if len(masked) != len(expected) {
+		t.Fatalf("unexpected maskings: %+v", masked)
+	}
+	for i := range expected {
+		if masked[i] != expected[i] {
+			t.Fatalf("unexpected maskings: %+v", masked)
+		}
+	}
+}
+
+func TestScrubberDisabledAndInvalid(t *testing.T) {
+	s, err := NewScrubber(config.PIIScrubbingConfig{})
+	if err != nil || s != nil {
+		t.Fatalf("expected nil scrubber when disabled, got %v %v", s, err)
+	}
+	if out, masked := s.Scrub("a@b.co"); out != "a@b.co" || masked != nil {
+		t.Fatalf("nil scrubber should be a no-op")
+	}
+	if _, err := NewScrubber(config.PIIScrubbingConfig{Enabled: true, Detectors: []string{"passport"}}); err == nil {
+		t.Fatal("expected unknown detector error")
+	}
+}
+
+func TestMergeMaskings(t *testing.T) {
+	got := MergeMaskings([]Masking{{"email", 1}}, []Masking{{"phone", 2}, {"email", 3}})
+	if len(got) != 2 || got[0] != (Masking{"email", 4}) || got[1] != (Masking{"phone", 2}) {
+		t.Fatalf("unexpected merge: %+v", got)
+	}
+}
@@ -3,8 +3,13 @@ package httpapi
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -106,6 +111,23 @@ func (s *Server) handleListPromptVersions(w http.ResponseWriter, r *http.Request
 	respondJSON(w, http.StatusOK, map[string]any{"versions": versions})
 }
 
+func (s *Server) handleDeployPromptVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	promptID := r.PathValue("promptID")
+	versionID := r.PathValue("versionID")
+	prompt, gate, err := s.service.DeployPromptVersion(ctx, promptID, versionID)
+	if err != nil {
+		status := statusFromError(err)
+		if gate == nil {
+			respondError(w, status, err)
+			return
+		}
+		respondJSON(w, status, map[string]any{"error": err.Error(), "gate": gate})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"prompt": prompt, "gate": gate})
+}
+
 func (s *Server) handleCreateDataset(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var payload struct {
@@ -208,6 +230,118 @@ func (s *Server) handleUpdateDataset(w http.ResponseWriter, r *http.Request) {
 	}{Dataset: updated, Rows: rows})
 }
 
+// datasetImportSpec describes a file import. In a multipart request it is
+// the JSON "spec" part, which must precede the "file" part.
+type datasetImportSpec struct {
+	Dataset     dataset.Dataset       `json:"dataset"`
+	Format      string                `json:"format"`
+	Mapping     dataset.ColumnMapping `json:"mapping"`
+	SkipInvalid bool                  `json:"skipInvalid"`
+}
+
+// chatImportRequest imports the turns of a chat session.
+type chatImportRequest struct {
+	Dataset   dataset.Dataset `json:"dataset"`
+	SessionID string          `json:"sessionId"`
+	dataset.ChatImportOptions
+}
+
+// handleImportDataset creates a dataset from an uploaded CSV or JSONL file
+// (multipart/form-data) or from a chat session (application/json). Files are
+// parsed as they stream in rather than buffered whole.
+func (s *Server) handleImportDataset(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var (
+		res dataset.ImportResult
+		err error
+	)
+	switch mediaType {
+	case "multipart/form-data":
+		res, err = s.importDatasetFile(r)
+	case "application/json":
+		res, err = s.importChatDataset(r)
+	default:
+		respondError(w, http.StatusUnsupportedMediaType, errors.New("send multipart/form-data with spec and file parts, or application/json to import a chat session"))
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, dataset.ErrInvalidImport):
+			status = http.StatusBadRequest
+		case errors.Is(err, dataset.ErrChatSessionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, dataset.ErrNoChatSource):
+			status = http.StatusNotImplemented
+		}
+		if len(res.Errors) > 0 {
+			respondJSON(w, status, map[string]any{"error": err.Error(), "errors": res.Errors})
+			return
+		}
+		respondError(w, status, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) importDatasetFile(r *http.Request) (dataset.ImportResult, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return dataset.ImportResult{}, fmt.Errorf("%w: %v", dataset.ErrInvalidImport, err)
+	}
+	var spec *datasetImportSpec
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return dataset.ImportResult{}, fmt.Errorf("%w: missing file part", dataset.ErrInvalidImport)
+		}
+		if err != nil {
+			return dataset.ImportResult{}, fmt.Errorf("%w: %v", dataset.ErrInvalidImport, err)
+		}
+		switch part.FormName() {
+		case "spec":
+			spec = &datasetImportSpec{}
+			if err := json.NewDecoder(io.LimitReader(part, 1<<20)).Decode(spec); err != nil {
+				return dataset.ImportResult{}, fmt.Errorf("%w: spec: %v", dataset.ErrInvalidImport, err)
+			}
+		case "file":
+			if spec == nil {
+				return dataset.ImportResult{}, fmt.Errorf("%w: spec part must precede file part", dataset.ErrInvalidImport)
+			}
+			ds := spec.Dataset
+			if ds.ID == "" {
+				ds.ID = uuid.NewString()
+			}
+			if ds.Name == "" {
+				ds.Name = strings.TrimSuffix(part.FileName(), path.Ext(part.FileName()))
+			}
+			format := spec.Format
+			if format == "" {
+				format = dataset.FormatFromFilename(part.FileName())
+			}
+			return s.service.ImportDataset(r.Context(), ds, part, dataset.ImportOptions{
+				Format:      format,
+				Mapping:     spec.Mapping,
+				SkipInvalid: spec.SkipInvalid,
+			})
+		}
+	}
+}
+
+func (s *Server) importChatDataset(r *http.Request) (dataset.ImportResult, error) {
+	var req chatImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return dataset.ImportResult{}, fmt.Errorf("%w: %v", dataset.ErrInvalidImport, err)
+	}
+	if req.Dataset.ID == "" {
+		req.Dataset.ID = uuid.NewString()
+	}
+	if req.Dataset.Name == "" && req.SessionID != "" {
+		req.Dataset.Name = "Chat " + req.SessionID
+	}
+	return s.service.ImportChatDataset(r.Context(), req.Dataset, req.SessionID, req.ChatImportOptions)
+}
+
 func (s *Server) handleCreateExperiment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var spec experiment.ExperimentSpec
@@ -280,6 +414,22 @@ func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusAccepted, run)
 }
 
+func (s *Server) handleResumeRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.service.ResumeRun(r.Context(), r.PathValue("experimentID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, playground.ErrActiveRun):
+			status = http.StatusConflict
+		case errors.Is(err, playground.ErrUnknownExperiment), errors.Is(err, playground.ErrNoResumableRun):
+			status = http.StatusNotFound
+		}
+		respondError(w, status, err)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, run)
+}
+
 func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	experimentID := r.PathValue("experimentID")
@@ -291,6 +441,19 @@ func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]any{"runs": runs})
 }
 
+func (s *Server) handleExperimentReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.service.ExperimentReport(r.Context(), r.PathValue("experimentID"), r.URL.Query().Get("runId"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, playground.ErrUnknownExperiment) || errors.Is(err, playground.ErrNoCompletedRun) {
+			status = http.StatusNotFound
+		}
+		respondError(w, status, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
 func (s *Server) handleListRunResults(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	runID := r.PathValue("runID")
@@ -316,6 +479,14 @@ func statusFromError(err error) int {
 	switch {
 	case errors.Is(err, registry.ErrPromptExists):
 		return http.StatusConflict
+	case errors.Is(err, registry.ErrPromptNotFound), errors.Is(err, registry.ErrPromptVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, playground.ErrGateFailed), errors.Is(err, playground.ErrGateNoRun):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, playground.ErrActiveRun):
+		return http.StatusConflict
+	case errors.Is(err, playground.ErrUnknownExperiment):
+		return http.StatusUnprocessableEntity
 	default:
 		return http.StatusInternalServerError
 	}
@@ -32,11 +32,13 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("DELETE /api/v1/playground/prompts/{promptID}", s.handleDeletePrompt)
 	s.mux.HandleFunc("POST /api/v1/playground/prompts/{promptID}/versions", s.handleCreatePromptVersion)
 	s.mux.HandleFunc("GET /api/v1/playground/prompts/{promptID}/versions", s.handleListPromptVersions)
+	s.mux.HandleFunc("POST /api/v1/playground/prompts/{promptID}/versions/{versionID}/deploy", s.handleDeployPromptVersion)
 
 	// Datasets
 	s.mux.HandleFunc("GET /api/v1/playground/datasets", s.handleListDatasets)
 	s.mux.HandleFunc("GET /api/v1/playground/datasets/{datasetID}", s.handleGetDataset)
 	s.mux.HandleFunc("POST /api/v1/playground/datasets", s.handleCreateDataset)
+	s.mux.HandleFunc("POST /api/v1/playground/datasets/import", s.handleImportDataset)
 	s.mux.HandleFunc("PUT /api/v1/playground/datasets/{datasetID}", s.handleUpdateDataset)
 	s.mux.HandleFunc("DELETE /api/v1/playground/datasets/{datasetID}", s.handleDeleteDataset)
 	// Experiments
@@ -46,5 +48,7 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("DELETE /api/v1/playground/experiments/{experimentID}", s.handleDeleteExperiment)
 	s.mux.HandleFunc("POST /api/v1/playground/experiments/{experimentID}/runs", s.handleStartRun)
 	s.mux.HandleFunc("GET /api/v1/playground/experiments/{experimentID}/runs", s.handleListRuns)
+	s.mux.HandleFunc("POST /api/v1/playground/experiments/{experimentID}/resume", s.handleResumeRun)
+	s.mux.HandleFunc("GET /api/v1/playground/experiments/{experimentID}/report", s.handleExperimentReport)
 	s.mux.HandleFunc("GET /api/v1/playground/runs/{runID}/results", s.handleListRunResults)
 }
@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format describes how dates and numbers are written in a locale.
+type Format struct {
+	// Language is the English name of the language, used in prompts.
+	Language string
+	// DateLayout is a Go time layout for short dates.
+	DateLayout string
+	// Decimal and Group are the decimal and digit-grouping separators.
+	Decimal string
+	Group   string
+}
+
+var formats = map[string]Format{
+	"en":    {Language: "English", DateLayout: "2006-01-02", Decimal: ".", Group: ","},
+	"en-US": {Language: "English", DateLayout: "01/02/2006", Decimal: ".", Group: ","},
+	"en-GB": {Language: "English", DateLayout: "02/01/2006", Decimal: ".", Group: ","},
+	"es":    {Language: "Spanish", DateLayout: "02/01/2006", Decimal: ",", Group: "."},
+	"fr":    {Language: "French", DateLayout: "02/01/2006", Decimal: ",", Group: " "},
+	"fr-CH": {Language: "French", DateLayout: "02.01.2006", Decimal: ".", Group: "'"},
+	"de":    {Language: "German", DateLayout: "02.01.2006", Decimal: ",", Group: "."},
+	"de-CH": {Language: "German", DateLayout: "02.01.2006", Decimal: ".", Group: "'"},
+	"pt":    {Language: "Portuguese", DateLayout: "02/01/2006", Decimal: ",", Group: "."},
+	"ja":    {Language: "Japanese", DateLayout: "2006/01/02", Decimal: ".", Group: ","},
+}
+
+// FormatFor returns the formatting conventions for locale, preferring an
+// exact region match, then the base language, then English.
+func FormatFor(locale string) Format {
+	if f, ok := formats[locale]; ok {
+		return f
+	}
+	if f, ok := formats[Base(locale)]; ok {
+		return f
+	}
+	return formats[DefaultLocale]
+}
+
+// FormatNumber renders v with two decimals using the locale's separators.
+func FormatNumber(locale string, v float64) string {
+	f := FormatFor(locale)
+	s := fmt.Sprintf("%.2f", v)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	intPart, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.Group)
+		}
+		b.WriteRune(r)
+	}
+	out := b.String() + f.Decimal + frac
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+// PromptInstructions returns a [locale] system prompt section telling the
+// model which language and formats to use. It returns "" for English in UTC,
+// which is the models' default behavior.
+func PromptInstructions(s Settings, now time.Time) string {
+	locale := s.Locale
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	tz := strings.TrimSpace(s.TimeZone)
+	if Base(locale) == DefaultLocale && (tz == "" || tz == "UTC") {
+		return ""
+	}
+	loc := time.UTC
+	if tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		} else {
+			tz = "UTC"
+		}
+	} else {
+		tz = "UTC"
+	}
+	f := FormatFor(locale)
+	return fmt.Sprintf(`
+[locale]
+- Respond in %s (%s) unless the user explicitly asks for another language.
+- Write dates like %s and numbers like %s.
+- The user's time zone is %s; interpret and present times in it.
+- Keep code, identifiers, file paths, and tool arguments unchanged.
+[/locale]`, f.Language, locale, now.In(loc).Format(f.DateLayout), FormatNumber(locale, 1234567.89), tz)
+}
+
+// EnsurePromptInstructions appends the [locale] section to systemPrompt if it
+// is not already present.
+func EnsurePromptInstructions(systemPrompt string, s Settings) string {
+	if strings.Contains(systemPrompt, "[locale]") {
+		return systemPrompt
+	}
+	return systemPrompt + PromptInstructions(s, time.Now())
+}
@@ -0,0 +1,157 @@
+// Package i18n provides locale negotiation, a message catalog for
+// server-generated text, and locale-aware system prompt instructions.
+//
+// Catalog keys are the English source strings, so untranslated messages fall
+// back to readable English and call sites stay greppable.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no other locale can be resolved.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	loadOnce sync.Once
+	catalogs map[string]map[string]string
+)
+
+func loadCatalogs() {
+	catalogs = map[string]map[string]string{DefaultLocale: {}}
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		raw, err := localeFS.ReadFile(path.Join("locales", name))
+		if err != nil {
+			continue
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(raw, &msgs); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", name, err))
+		}
+		catalogs[strings.TrimSuffix(name, ".json")] = msgs
+	}
+}
+
+// Supported returns the base languages with a catalog, sorted.
+func Supported() []string {
+	loadOnce.Do(loadCatalogs)
+	out := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		out = append(out, lang)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Normalize canonicalizes a BCP 47 style tag ("pt_br" -> "pt-BR") and reports
+// whether its base language is supported.
+func Normalize(tag string) (string, bool) {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" {
+		return "", false
+	}
+	parts := strings.Split(tag, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		} else {
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	loadOnce.Do(loadCatalogs)
+	_, ok := catalogs[parts[0]]
+	return strings.Join(parts, "-"), ok
+}
+
+// Base returns the language subtag of a locale ("de-AT" -> "de").
+func Base(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return strings.ToLower(lang)
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header.
+// It returns "" when no listed language is supported.
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if tag == "*" || q <= 0 {
+			continue
+		}
+		norm, ok := Normalize(tag)
+		if ok && q > bestQ {
+			best, bestQ = norm, q
+		}
+	}
+	return best
+}
+
+// T returns the translation of msg for locale, formatting args with
+// fmt.Sprintf when present. Missing translations fall back to msg itself.
+func T(locale, msg string, args ...any) string {
+	loadOnce.Do(loadCatalogs)
+	out := msg
+	if cat, ok := catalogs[Base(locale)]; ok {
+		if v, ok := cat[msg]; ok && v != "" {
+			out = v
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(out, args...)
+	}
+	return out
+}
+
+// Settings carries the locale preferences resolved for a request.
+type Settings struct {
+	Locale   string
+	TimeZone string
+}
+
+type ctxKey struct{}
+
+// WithSettings attaches locale settings to ctx.
+func WithSettings(ctx context.Context, s Settings) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// FromContext returns the settings attached to ctx, defaulting to English/UTC.
+func FromContext(ctx context.Context) Settings {
+	if ctx != nil {
+		if s, ok := ctx.Value(ctxKey{}).(Settings); ok {
+			return s
+		}
+	}
+	return Settings{Locale: DefaultLocale, TimeZone: "UTC"}
+}
+
+// Tc is T using the locale attached to ctx.
+func Tc(ctx context.Context, msg string, args ...any) string {
+	return T(FromContext(ctx).Locale, msg, args...)
+}
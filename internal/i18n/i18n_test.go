@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]struct {
+		want string
+		ok   bool
+	}{
+		"de_at": {"de-AT", true},
+		"PT-br": {"pt-BR", true},
+		"en":    {"en", true},
+		"xx-YY": {"xx-YY", false},
+		"  ":    {"", false},
+	}
+	for in, tc := range cases {
+		got, ok := Normalize(in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	if got := Negotiate("xx, fr-CA;q=0.7, de;q=0.9, *;q=1"); got != "de" {
+		t.Fatalf("expected de, got %q", got)
+	}
+	if got := Negotiate("zz, xx;q=0.5"); got != "" {
+		t.Fatalf("expected no match, got %q", got)
+	}
+}
+
+func TestTranslateFallsBackToSource(t *testing.T) {
+	if got := T("de-AT", "team not found"); got != "Team nicht gefunden" {
+		t.Fatalf("unexpected translation %q", got)
+	}
+	if got := T("xx", "team not found"); got != "team not found" {
+		t.Fatalf("expected english fallback, got %q", got)
+	}
+	if got := T("es", "Approval required: the agent wants to run %s.", "run_cli"); !strings.Contains(got, "run_cli") {
+		t.Fatalf("expected formatted argument, got %q", got)
+	}
+	ctx := WithSettings(context.Background(), Settings{Locale: "fr"})
+	if got := Tc(ctx, "forbidden"); got != "interdit" {
+		t.Fatalf("unexpected context translation %q", got)
+	}
+}
+
+func TestCatalogsCoverSameKeys(t *testing.T) {
+	loadOnce.Do(loadCatalogs)
+	ref := catalogs["de"]
+	for lang, cat := range catalogs {
+		if lang == DefaultLocale {
+			continue
+		}
+		for k := range ref {
+			if _, ok := cat[k]; !ok {
+				t.Errorf("catalog %s missing %q", lang, k)
+			}
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	if got := FormatNumber("de", 1234567.891); got != "1.234.567,89" {
+		t.Fatalf("unexpected de number %q", got)
+	}
+	if got := FormatNumber("en-US", -1000); got != "-1,000.00" {
+		t.Fatalf("unexpected en number %q", got)
+	}
+}
+
+func TestPromptInstructions(t *testing.T) {
+	now := time.Date(2025, 3, 4, 23, 30, 0, 0, time.UTC)
+	if got := PromptInstructions(Settings{Locale: "en", TimeZone: "UTC"}, now); got != "" {
+		t.Fatalf("expected no instructions for english/utc, got %q", got)
+	}
+	got := PromptInstructions(Settings{Locale: "de-DE", TimeZone: "Europe/Berlin"}, now)
+	for _, want := range []string{"[locale]", "German (de-DE)", "05.03.2025", "1.234.567,89", "Europe/Berlin"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	once := EnsurePromptInstructions("base", Settings{Locale: "ja"})
+	if EnsurePromptInstructions(once, Settings{Locale: "ja"}) != once {
+		t.Fatalf("expected instructions to be appended only once")
+	}
+}
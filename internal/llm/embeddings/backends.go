@@ -0,0 +1,102 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"manifold/internal/config"
+)
+
+// openAIBackend speaks the OpenAI /v1/embeddings protocol, which llama.cpp's
+// server also implements.
+type openAIBackend struct {
+	cfg  config.EmbeddingConfig
+	http *http.Client
+}
+
+type openAIRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (b *openAIBackend) embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	var resp openAIResponse
+	if err := postJSON(ctx, b.http, b.cfg, openAIRequest{Model: b.cfg.Model, Input: inputs}, &resp); err != nil {
+		return nil, fmt.Errorf("%w (input count: %d)", err, len(inputs))
+	}
+	// Servers may return items out of order; a stable sort keeps positional
+	// order when index is omitted.
+	sort.SliceStable(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+	out := make([][]float32, len(resp.Data))
+	for i, d := range resp.Data {
+		out[i] = d.Embedding
+	}
+	return out, nil
+}
+
+// ollamaBackend uses Ollama's native /api/embed endpoint.
+type ollamaBackend struct {
+	cfg  config.EmbeddingConfig
+	http *http.Client
+}
+
+type ollamaRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (b *ollamaBackend) embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	var resp ollamaResponse
+	if err := postJSON(ctx, b.http, b.cfg, ollamaRequest{Model: b.cfg.Model, Input: inputs}, &resp); err != nil {
+		return nil, fmt.Errorf("%w (input count: %d)", err, len(inputs))
+	}
+	return resp.Embeddings, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, cfg config.EmbeddingConfig, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	cctx, cancel := context.WithTimeout(ctx, requestTimeout(cfg))
+	defer cancel()
+	req, err := http.NewRequestWithContext(cctx, http.MethodPost, cfg.BaseURL+cfg.Path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	applyAuth(req, cfg)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{Code: resp.StatusCode, Body: string(data[:min(500, len(data))])}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse embedding response (response: %s): %w", string(data[:min(200, len(data))]), err)
+	}
+	return nil
+}
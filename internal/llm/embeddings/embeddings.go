@@ -0,0 +1,218 @@
+// Package embeddings provides a common interface over embedding endpoints
+// (OpenAI, llama.cpp, Ollama) with batching, rate limiting, and retries.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm/resilient"
+	"manifold/internal/observability"
+)
+
+const (
+	ProviderOpenAI   = "openai"
+	ProviderLlamaCpp = "llamacpp"
+	ProviderOllama   = "ollama"
+)
+
+// Provider turns text into embedding vectors, one per input.
+type Provider interface {
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+	// Model returns the embedding model identifier.
+	Model() string
+}
+
+// backend performs a single embedding request without batching or retries.
+type backend interface {
+	embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
+// StatusError is returned for non-2xx responses so retry logic can classify
+// rate limits and server errors.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("embeddings error: %d %s: %s", e.Code, http.StatusText(e.Code), e.Body)
+}
+
+// StatusCode implements resilient.StatusCoder.
+func (e *StatusError) StatusCode() int { return e.Code }
+
+// Client is the Provider returned by New. It splits inputs into batches,
+// paces requests, and retries transient failures.
+type Client struct {
+	cfg        config.EmbeddingConfig
+	backend    backend
+	batchSize  int
+	maxRetries int
+
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+
+	sleep func(context.Context, time.Duration) error
+}
+
+// ResolveProvider returns the backend name for cfg. An empty provider keeps
+// the legacy behaviour: OpenAI for api.openai.com, llama.cpp-compatible
+// (one input per request) for everything else.
+func ResolveProvider(cfg config.EmbeddingConfig) string {
+	switch p := strings.ToLower(strings.TrimSpace(cfg.Provider)); p {
+	case ProviderOpenAI, ProviderLlamaCpp, ProviderOllama:
+		return p
+	case "llama.cpp", "llama-cpp", "local":
+		return ProviderLlamaCpp
+	case "":
+		if u, err := url.Parse(cfg.BaseURL); err == nil && strings.EqualFold(u.Hostname(), "api.openai.com") {
+			return ProviderOpenAI
+		}
+		return ProviderLlamaCpp
+	default:
+		return p
+	}
+}
+
+// New builds a Provider for cfg. A nil httpClient uses http.DefaultClient.
+func New(cfg config.EmbeddingConfig, httpClient *http.Client) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	c := &Client{cfg: cfg, maxRetries: cfg.MaxRetries, sleep: sleepCtx}
+	switch name := ResolveProvider(cfg); name {
+	case ProviderOpenAI:
+		c.backend, c.batchSize = &openAIBackend{cfg: cfg, http: httpClient}, 64
+	case ProviderLlamaCpp:
+		// llama.cpp servers are prone to failures with batched inputs.
+		c.backend, c.batchSize = &openAIBackend{cfg: cfg, http: httpClient}, 1
+	case ProviderOllama:
+		c.backend, c.batchSize = &ollamaBackend{cfg: cfg, http: httpClient}, 32
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Provider)
+	}
+	if cfg.BatchSize > 0 {
+		c.batchSize = cfg.BatchSize
+	}
+	if cfg.RequestsPerSecond > 0 {
+		c.interval = time.Duration(float64(time.Second) / cfg.RequestsPerSecond)
+	}
+	if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+	return c, nil
+}
+
+func (c *Client) Model() string { return c.cfg.Model }
+
+// Embed returns one vector per input, issuing as many batched requests as
+// needed. Results are returned in input order.
+func (c *Client) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	out := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += c.batchSize {
+		end := min(start+c.batchSize, len(inputs))
+		vecs, err := c.embedBatch(ctx, inputs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+func (c *Client) embedBatch(ctx context.Context, batch []string) ([][]float32, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(rand.Int64N(int64(250*time.Millisecond<<attempt))) + 1
+			observability.LoggerWithTrace(ctx).Warn().Err(lastErr).Int("attempt", attempt).Dur("backoff", delay).Msg("embedding_retry")
+			if err := c.sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+		vecs, err := c.backend.embed(ctx, batch)
+		if err == nil {
+			if len(vecs) != len(batch) {
+				return nil, fmt.Errorf("unexpected embedding count: got %d, want %d", len(vecs), len(batch))
+			}
+			return vecs, nil
+		}
+		lastErr = err
+		if !resilient.Retryable(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// wait blocks until the rate limiter admits another request.
+func (c *Client) wait(ctx context.Context) error {
+	if c.interval <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(c.interval)
+	c.mu.Unlock()
+	return c.sleep(ctx, time.Until(at))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func requestTimeout(cfg config.EmbeddingConfig) time.Duration {
+	if cfg.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(cfg.Timeout) * time.Second
+}
+
+// applyAuth sets configured headers. Entries in cfg.Headers are used verbatim;
+// APIHeader/APIKey fill in when no explicit Authorization header is given.
+func applyAuth(req *http.Request, cfg config.EmbeddingConfig) {
+	for k, v := range cfg.Headers {
+		if k == "" {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if _, ok := cfg.Headers["Authorization"]; ok {
+		return
+	}
+	switch {
+	case cfg.APIHeader == "Authorization" && (cfg.APIKey != "" || len(cfg.Headers) == 0):
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	case cfg.APIHeader != "" && (cfg.APIKey != "" || len(cfg.Headers) == 0):
+		req.Header.Set(cfg.APIHeader, cfg.APIKey)
+	}
+}
@@ -0,0 +1,146 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+)
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestResolveProvider(t *testing.T) {
+	cases := map[string]config.EmbeddingConfig{
+		ProviderOpenAI:   {BaseURL: "https://api.openai.com"},
+		ProviderLlamaCpp: {BaseURL: "http://localhost:8080"},
+		ProviderOllama:   {Provider: "Ollama", BaseURL: "http://localhost:11434"},
+	}
+	for want, cfg := range cases {
+		if got := ResolveProvider(cfg); got != want {
+			t.Errorf("ResolveProvider(%+v) = %q, want %q", cfg, got, want)
+		}
+	}
+	if _, err := New(config.EmbeddingConfig{Provider: "bogus"}, nil); err == nil {
+		t.Fatal("expected unsupported provider error")
+	}
+}
+
+func TestOpenAIBatchingAndOrder(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req openAIRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		// Respond in reverse order to exercise index handling.
+		data := make([]item, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(req.Input[i]))}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer ts.Close()
+
+	c, err := New(config.EmbeddingConfig{Provider: ProviderOpenAI, BaseURL: ts.URL, Path: "/v1/embeddings", BatchSize: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vecs, err := c.Embed(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("expected 3 batched requests, got %d", requests.Load())
+	}
+	for i, v := range vecs {
+		if int(v[0]) != i+1 {
+			t.Fatalf("vector %d out of order: %v", i, v)
+		}
+	}
+}
+
+func TestOllamaBackend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"embeddings": [][]float32{{1}, {2}}})
+	}))
+	defer ts.Close()
+
+	c, err := New(config.EmbeddingConfig{Provider: ProviderOllama, BaseURL: ts.URL, Path: "/api/embed", Model: "nomic-embed-text"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vecs, err := c.Embed(context.Background(), []string{"x", "y"})
+	if err != nil || len(vecs) != 2 || vecs[1][0] != 2 {
+		t.Fatalf("unexpected result %v %v", vecs, err)
+	}
+}
+
+func TestRetriesRateLimitedRequests(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"embedding": []float32{1}}}})
+	}))
+	defer ts.Close()
+
+	c, err := New(config.EmbeddingConfig{BaseURL: ts.URL, MaxRetries: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.sleep = noSleep
+	if _, err := c.Embed(context.Background(), []string{"x"}); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad model", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	c, _ := New(config.EmbeddingConfig{BaseURL: ts.URL, MaxRetries: 3}, nil)
+	c.sleep = noSleep
+	if _, err := c.Embed(context.Background(), []string{"x"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single call, got %d", calls.Load())
+	}
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	c, _ := New(config.EmbeddingConfig{BaseURL: "http://example", RequestsPerSecond: 10}, nil)
+	var waits []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	for range 3 {
+		if err := c.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if waits[2] < 150*time.Millisecond {
+		t.Fatalf("expected third request to be delayed ~200ms, got %v", waits)
+	}
+}
@@ -0,0 +1,226 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/observability"
+)
+
+var (
+	// ErrUnavailable is returned when every configured endpoint is down.
+	ErrUnavailable = errors.New("all embedding endpoints unavailable")
+	// ErrQueueFull is returned by Defer when queueing is disabled or the
+	// queue has no room for the texts.
+	ErrQueueFull = errors.New("embedding queue full")
+)
+
+// DeferredFunc receives the vectors for texts queued with Defer.
+type DeferredFunc func(ctx context.Context, vecs [][]float32) error
+
+// Failover is a Provider over an ordered list of endpoints. Embed uses the
+// first healthy endpoint and fails over to the next on outage errors; an
+// endpoint that fails is skipped until its cooldown passes. While every
+// endpoint is down, callers may queue texts with Defer and they are
+// re-embedded in the background once an endpoint recovers.
+type Failover struct {
+	endpoints []*failoverEndpoint
+	cooldown  time.Duration
+	queueCap  int
+
+	mu       sync.Mutex
+	queue    []deferredJob
+	queued   int
+	draining bool
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+type failoverEndpoint struct {
+	name      string
+	provider  Provider
+	downUntil time.Time
+}
+
+type deferredJob struct {
+	texts []string
+	fn    DeferredFunc
+}
+
+// NewFailover builds a Failover over cfg followed by cfg.Fallbacks.
+func NewFailover(cfg config.EmbeddingConfig, httpClient *http.Client) (*Failover, error) {
+	primary, err := New(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	providers := []Provider{primary}
+	names := []string{cfg.BaseURL}
+	for i, fb := range cfg.Fallbacks {
+		p, err := New(fb, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("embedding fallback %d: %w", i, err)
+		}
+		providers = append(providers, p)
+		names = append(names, fb.BaseURL)
+	}
+	f := newFailover(providers, time.Duration(cfg.HealthCheckSeconds)*time.Second, cfg.QueueSize)
+	for i, n := range names {
+		f.endpoints[i].name = n
+	}
+	return f, nil
+}
+
+func newFailover(providers []Provider, cooldown time.Duration, queueCap int) *Failover {
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	f := &Failover{
+		cooldown: cooldown,
+		queueCap: queueCap,
+		now:      time.Now,
+		sleep:    sleepCtx,
+	}
+	for i, p := range providers {
+		f.endpoints = append(f.endpoints, &failoverEndpoint{name: fmt.Sprintf("endpoint-%d", i), provider: p})
+	}
+	return f
+}
+
+// Model returns the primary endpoint's model.
+func (f *Failover) Model() string { return f.endpoints[0].provider.Model() }
+
+// Embed returns one vector per input from the first healthy endpoint. It
+// returns an error wrapping ErrUnavailable when no endpoint could serve the
+// request.
+func (f *Failover) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	var lastErr error
+	for i, ep := range f.endpoints {
+		if !f.healthy(ep) {
+			continue
+		}
+		vecs, err := ep.provider.Embed(ctx, inputs)
+		if err == nil {
+			f.markUp(ctx, ep)
+			return vecs, nil
+		}
+		if ctx.Err() != nil || !isOutage(err) {
+			return nil, err
+		}
+		f.markDown(ep)
+		lastErr = err
+		observability.LoggerWithTrace(ctx).Warn().Err(err).Str("endpoint", ep.name).Int("position", i).Dur("cooldown", f.cooldown).Msg("embedding_endpoint_down")
+	}
+	if lastErr == nil {
+		return nil, ErrUnavailable
+	}
+	return nil, fmt.Errorf("%w: %w", ErrUnavailable, lastErr)
+}
+
+// isOutage reports whether err means the endpoint, rather than the request,
+// is at fault. Rejected requests would fail on every endpoint.
+func isOutage(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch se.Code {
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Failover) healthy(ep *failoverEndpoint) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.now().Before(ep.downUntil)
+}
+
+func (f *Failover) markDown(ep *failoverEndpoint) {
+	f.mu.Lock()
+	ep.downUntil = f.now().Add(f.cooldown)
+	f.mu.Unlock()
+}
+
+func (f *Failover) markUp(ctx context.Context, ep *failoverEndpoint) {
+	f.mu.Lock()
+	recovered := !ep.downUntil.IsZero()
+	ep.downUntil = time.Time{}
+	f.mu.Unlock()
+	if recovered {
+		observability.LoggerWithTrace(ctx).Info().Str("endpoint", ep.name).Msg("embedding_endpoint_recovered")
+	}
+}
+
+// Defer queues texts for embedding once an endpoint recovers and calls fn
+// with the vectors. fn runs on a background goroutine with its own context.
+func (f *Failover) Defer(texts []string, fn DeferredFunc) error {
+	if len(texts) == 0 {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.queueCap < 0 || f.queued+len(texts) > f.queueCap {
+		return ErrQueueFull
+	}
+	f.queue = append(f.queue, deferredJob{texts: append([]string(nil), texts...), fn: fn})
+	f.queued += len(texts)
+	if !f.draining {
+		f.draining = true
+		go f.drain()
+	}
+	return nil
+}
+
+// Pending returns the number of queued texts.
+func (f *Failover) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queued
+}
+
+// drain embeds queued jobs in order, waiting a cooldown between attempts
+// while every endpoint is down. It exits once the queue is empty.
+func (f *Failover) drain() {
+	for {
+		f.mu.Lock()
+		if len(f.queue) == 0 {
+			f.draining = false
+			f.mu.Unlock()
+			return
+		}
+		job := f.queue[0]
+		f.mu.Unlock()
+
+		// Each request carries its own timeout, so a background context is
+		// enough here.
+		ctx := context.Background()
+		vecs, err := f.Embed(ctx, job.texts)
+		if errors.Is(err, ErrUnavailable) {
+			_ = f.sleep(ctx, f.cooldown)
+			continue
+		}
+		if err == nil && job.fn != nil {
+			err = job.fn(ctx, vecs)
+		}
+		logger := observability.LoggerWithTrace(ctx)
+
+		f.mu.Lock()
+		f.queue = f.queue[1:]
+		f.queued -= len(job.texts)
+		f.mu.Unlock()
+		if err != nil {
+			logger.Error().Err(err).Int("texts", len(job.texts)).Msg("embedding_queue_drop")
+			continue
+		}
+		logger.Info().Int("texts", len(job.texts)).Msg("embedding_queue_flushed")
+	}
+}
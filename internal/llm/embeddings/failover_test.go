@@ -0,0 +1,138 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubProvider fails with err while down and returns one-element vectors
+// tagged with id otherwise.
+type stubProvider struct {
+	id    float32
+	calls atomic.Int32
+
+	mu   sync.Mutex
+	err  error
+	down bool
+}
+
+func (p *stubProvider) Model() string { return "stub" }
+
+func (p *stubProvider) Embed(_ context.Context, inputs []string) ([][]float32, error) {
+	p.calls.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		err := p.err
+		if err == nil {
+			err = &StatusError{Code: http.StatusServiceUnavailable}
+		}
+		return nil, err
+	}
+	out := make([][]float32, len(inputs))
+	for i := range inputs {
+		out[i] = []float32{p.id}
+	}
+	return out, nil
+}
+
+func (p *stubProvider) setDown(down bool) {
+	p.mu.Lock()
+	p.down = down
+	p.mu.Unlock()
+}
+
+func TestFailoverOrderAndCooldown(t *testing.T) {
+	primary := &stubProvider{id: 1, down: true}
+	secondary := &stubProvider{id: 2}
+	f := newFailover([]Provider{primary, secondary}, time.Minute, 0)
+	now := time.Unix(0, 0)
+	f.now = func() time.Time { return now }
+
+	vecs, err := f.Embed(context.Background(), []string{"a"})
+	if err != nil || vecs[0][0] != 2 {
+		t.Fatalf("expected secondary vectors, got %v, %v", vecs, err)
+	}
+	// The primary is skipped during its cooldown.
+	primary.setDown(false)
+	if vecs, _ := f.Embed(context.Background(), []string{"a"}); vecs[0][0] != 2 || primary.calls.Load() != 1 {
+		t.Fatalf("primary should be skipped while cooling down: vecs=%v calls=%d", vecs, primary.calls.Load())
+	}
+	now = now.Add(time.Minute)
+	if vecs, _ := f.Embed(context.Background(), []string{"a"}); vecs[0][0] != 1 {
+		t.Fatalf("expected primary after cooldown, got %v", vecs)
+	}
+}
+
+func TestFailoverUnavailableAndRejected(t *testing.T) {
+	primary := &stubProvider{id: 1, down: true}
+	secondary := &stubProvider{id: 2, down: true}
+	f := newFailover([]Provider{primary, secondary}, time.Minute, 0)
+	if _, err := f.Embed(context.Background(), []string{"a"}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	// Both endpoints are cooling down, so no request is sent.
+	if _, err := f.Embed(context.Background(), []string{"a"}); !errors.Is(err, ErrUnavailable) || primary.calls.Load() != 1 {
+		t.Fatalf("expected fast ErrUnavailable, got %v (calls=%d)", err, primary.calls.Load())
+	}
+
+	// A rejected request is the caller's fault and does not fail over.
+	bad := &stubProvider{id: 1, down: true, err: &StatusError{Code: http.StatusBadRequest}}
+	other := &stubProvider{id: 2}
+	f = newFailover([]Provider{bad, other}, time.Minute, 0)
+	if _, err := f.Embed(context.Background(), []string{"a"}); err == nil || errors.Is(err, ErrUnavailable) || other.calls.Load() != 0 {
+		t.Fatalf("expected the 400 to be returned without failover, got %v", err)
+	}
+}
+
+func TestFailoverDeferDrainsOnRecovery(t *testing.T) {
+	primary := &stubProvider{id: 1, down: true}
+	f := newFailover([]Provider{primary}, time.Millisecond, 3)
+	retried := make(chan struct{}, 8)
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		retried <- struct{}{}
+		return sleepCtx(ctx, d)
+	}
+
+	got := make(chan [][]float32, 1)
+	if err := f.Defer([]string{"a", "b"}, func(_ context.Context, vecs [][]float32) error {
+		got <- vecs
+		return nil
+	}); err != nil {
+		t.Fatalf("Defer: %v", err)
+	}
+	if err := f.Defer([]string{"c", "d"}, nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if f.Pending() != 2 {
+		t.Fatalf("expected 2 pending texts, got %d", f.Pending())
+	}
+
+	<-retried
+	primary.setDown(false)
+	select {
+	case vecs := <-got:
+		if len(vecs) != 2 || vecs[0][0] != 1 {
+			t.Fatalf("unexpected vectors %v", vecs)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued texts were not re-embedded after recovery")
+	}
+	deadline := time.Now().Add(time.Second)
+	for f.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if f.Pending() != 0 {
+		t.Fatalf("expected empty queue, got %d", f.Pending())
+	}
+
+	f = newFailover([]Provider{primary}, time.Millisecond, -1)
+	if err := f.Defer([]string{"a"}, nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected queueing disabled, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"manifold/internal/observability"
@@ -36,7 +37,8 @@ func WithUserID(ctx context.Context, userID int64) context.Context {
 	return context.WithValue(ctx, userIDKey{}, userID)
 }
 
-func userIDFromContext(ctx context.Context) (int64, bool) {
+// UserIDFromContext returns the user ID set by WithUserID.
+func UserIDFromContext(ctx context.Context) (int64, bool) {
 	if ctx == nil {
 		return 0, false
 	}
@@ -56,13 +58,21 @@ const endUserIDAttr = "enduser.id"
 // user in ctx (if present). This enables per-user token aggregation in
 // backends like ClickHouse.
 func RecordTokenMetricsFromContext(ctx context.Context, model string, promptTokens, completionTokens int) {
-	if model == "" || (promptTokens == 0 && completionTokens == 0) {
+	if promptTokens == 0 && completionTokens == 0 {
+		return
+	}
+	addUsage(ctx, promptTokens, completionTokens)
+	if model == "" {
 		return
 	}
 	// Always update in-process totals (deployment-wide).
-	recordTokenMetrics(model, promptTokens, completionTokens, timeNow())
+	now := timeNow()
+	recordTokenMetrics(model, promptTokens, completionTokens, now)
 
-	uid, ok := userIDFromContext(ctx)
+	uid, ok := UserIDFromContext(ctx)
+	if hook := tokenUsageHook.Load(); hook != nil {
+		(*hook)(TokenUsage{UserID: uid, Model: model, Prompt: promptTokens, Completion: completionTokens, At: now})
+	}
 	if !ok || uid == 0 {
 		return
 	}
@@ -81,6 +91,29 @@ func RecordTokenMetricsFromContext(ctx context.Context, model string, promptToke
 	}
 }
 
+// TokenUsage is the token count of one model call, passed to the hook set
+// with SetTokenUsageHook. UserID is 0 when the call had no user.
+type TokenUsage struct {
+	UserID     int64
+	Model      string
+	Prompt     int
+	Completion int
+	At         time.Time
+}
+
+var tokenUsageHook atomic.Pointer[func(TokenUsage)]
+
+// SetTokenUsageHook registers fn to receive every recorded model call, so
+// usage can be persisted beyond the in-process totals. fn runs on the
+// caller's goroutine and must not block. A nil fn removes the hook.
+func SetTokenUsageHook(fn func(TokenUsage)) {
+	if fn == nil {
+		tokenUsageHook.Store(nil)
+		return
+	}
+	tokenUsageHook.Store(&fn)
+}
+
 // --- Token metrics aggregation (exposed to web UI) ---------------------------
 var (
 	tokenOnce         sync.Once
@@ -311,7 +344,7 @@ func StartRequestSpan(ctx context.Context, operation string, model string, tools
 		attribute.Int("llm.tools", tools),
 		attribute.Int("llm.messages", messages),
 	}
-	if uid, ok := userIDFromContext(ctx); ok {
+	if uid, ok := UserIDFromContext(ctx); ok {
 		attrs = append(attrs, attribute.String(endUserIDAttr, fmt.Sprint(uid)))
 	}
 	span.SetAttributes(attrs...)
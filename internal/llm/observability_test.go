@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -57,6 +58,34 @@ func TestTokenTotalsForWindow(t *testing.T) {
 	}
 }
 
+func TestTokenUsageHook(t *testing.T) {
+	resetTokenMetricsStateForTest()
+	defer resetTokenMetricsStateForTest()
+
+	var got []TokenUsage
+	SetTokenUsageHook(func(u TokenUsage) { got = append(got, u) })
+	defer SetTokenUsageHook(nil)
+
+	RecordTokenMetricsFromContext(WithUserID(context.Background(), 7), "gpt-5", 100, 20)
+	RecordTokenMetricsFromContext(context.Background(), "gpt-5", 5, 1)
+	RecordTokenMetricsFromContext(context.Background(), "", 5, 1)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 hook calls, got %+v", got)
+	}
+	if got[0].UserID != 7 || got[0].Model != "gpt-5" || got[0].Prompt != 100 || got[0].Completion != 20 || got[0].At.IsZero() {
+		t.Fatalf("unexpected usage %+v", got[0])
+	}
+	if got[1].UserID != 0 {
+		t.Fatalf("expected no user on the second call, got %+v", got[1])
+	}
+
+	SetTokenUsageHook(nil)
+	RecordTokenMetricsFromContext(context.Background(), "gpt-5", 1, 1)
+	if len(got) != 2 {
+		t.Fatalf("hook called after removal")
+	}
+}
+
 func TestTokenTotalsRetention(t *testing.T) {
 	resetTokenMetricsStateForTest()
 	defer resetTokenMetricsStateForTest()
@@ -63,3 +63,17 @@ type Provider interface {
 	Chat(ctx context.Context, msgs []Message, tools []ToolSchema, model string) (Message, error)
 	ChatStream(ctx context.Context, msgs []Message, tools []ToolSchema, model string, h StreamHandler) error
 }
+
+// Unwrap returns the innermost provider for wrappers (such as the resilience
+// layer) that expose an Unwrap method. Use it before type-asserting optional
+// provider capabilities.
+func Unwrap(p Provider) Provider {
+	for p != nil {
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return p
+		}
+		p = u.Unwrap()
+	}
+	return p
+}
@@ -10,6 +10,8 @@ import (
 	"manifold/internal/llm/anthropic"
 	"manifold/internal/llm/google"
 	openaillm "manifold/internal/llm/openai"
+	"manifold/internal/llm/resilient"
+	"manifold/internal/llm/routing"
 )
 
 // Build constructs an llm.Provider based on the configured provider name.
@@ -21,7 +23,57 @@ func Build(cfg config.Config, httpClient *http.Client) (llm.Provider, error) {
 }
 
 // BuildFromLLMClientConfig constructs an llm.Provider from an LLM client config.
+// When cfg.Resilience is enabled the provider is wrapped with retries, a
+// circuit breaker, and the optional fallback provider. When cfg.Routing is
+// enabled the result becomes the default route of a capability/cost router.
 func BuildFromLLMClientConfig(cfg config.LLMClientConfig, httpClient *http.Client) (llm.Provider, error) {
+	p, err := buildResilient(cfg, httpClient)
+	if err != nil || !cfg.Routing.Enabled || len(cfg.Routing.Routes) == 0 {
+		return p, err
+	}
+	routes := make([]routing.Route, 0, len(cfg.Routing.Routes))
+	for i, rc := range cfg.Routing.Routes {
+		client := rc.Client
+		client.Routing = config.LLMRoutingConfig{}
+		rp, err := buildResilient(client, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("build llm route %d (%s): %w", i, rc.Name, err)
+		}
+		name := strings.TrimSpace(rc.Name)
+		if name == "" {
+			name = fmt.Sprintf("route-%d", i)
+		}
+		routes = append(routes, routing.Route{
+			Name:                name,
+			Provider:            rp,
+			Model:               model(client),
+			Vision:              rc.Vision,
+			Tools:               rc.Tools,
+			ContextWindowTokens: rc.ContextWindowTokens,
+			CostWeight:          rc.CostWeight,
+		})
+	}
+	return routing.New(p, routes), nil
+}
+
+func buildResilient(cfg config.LLMClientConfig, httpClient *http.Client) (llm.Provider, error) {
+	p, err := buildProvider(cfg, httpClient)
+	if err != nil || !cfg.Resilience.Enabled {
+		return p, err
+	}
+	primary := resilient.Target{Provider: p, BaseURL: BaseURL(cfg)}
+	var fallback *resilient.Target
+	if fb := cfg.Fallback; fb != nil && strings.TrimSpace(fb.Provider) != "" {
+		fp, err := buildProvider(*fb, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("build fallback llm provider: %w", err)
+		}
+		fallback = &resilient.Target{Provider: fp, BaseURL: BaseURL(*fb), Model: model(*fb)}
+	}
+	return resilient.New(cfg.Resilience, primary, fallback), nil
+}
+
+func buildProvider(cfg config.LLMClientConfig, httpClient *http.Client) (llm.Provider, error) {
 	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
 	case "", "openai":
 		return openaillm.New(cfg.OpenAI, httpClient), nil
@@ -41,3 +93,33 @@ func BuildFromLLMClientConfig(cfg config.LLMClientConfig, httpClient *http.Clien
 		return nil, fmt.Errorf("unsupported llm provider: %s", cfg.Provider)
 	}
 }
+
+// BaseURL returns the endpoint the configured provider talks to, applying
+// vendor defaults when unset.
+func BaseURL(cfg config.LLMClientConfig) string {
+	var u, def string
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "anthropic":
+		u, def = cfg.Anthropic.BaseURL, "https://api.anthropic.com"
+	case "google":
+		u, def = cfg.Google.BaseURL, "https://generativelanguage.googleapis.com"
+	default:
+		u, def = cfg.OpenAI.BaseURL, "https://api.openai.com/v1"
+	}
+	u = strings.TrimRight(strings.TrimSpace(u), "/")
+	if u == "" {
+		return def
+	}
+	return u
+}
+
+func model(cfg config.LLMClientConfig) string {
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "anthropic":
+		return strings.TrimSpace(cfg.Anthropic.Model)
+	case "google":
+		return strings.TrimSpace(cfg.Google.Model)
+	default:
+		return strings.TrimSpace(cfg.OpenAI.Model)
+	}
+}
@@ -0,0 +1,171 @@
+package resilient
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Breaker states.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Breaker is a consecutive-failure circuit breaker. After Threshold failures
+// it opens and rejects calls until Cooldown elapses, then admits a single
+// trial call whose outcome closes or re-opens it.
+type Breaker struct {
+	key       string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	trialBusy bool
+	lastErr   string
+	lastFail  time.Time
+}
+
+// BreakerStatus is a point-in-time view of a breaker for status endpoints.
+type BreakerStatus struct {
+	BaseURL             string     `json:"baseUrl"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+}
+
+func newBreaker(key string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &Breaker{key: key, threshold: threshold, cooldown: cooldown, now: time.Now, state: StateClosed}
+}
+
+// Allow reports whether a call may proceed. In the half-open state only one
+// trial call is admitted at a time.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.trialBusy = true
+		return true
+	case StateHalfOpen:
+		if b.trialBusy {
+			return false
+		}
+		b.trialBusy = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful call and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = StateClosed
+	b.failures = 0
+	b.trialBusy = false
+}
+
+// Failure records a failed call, opening the breaker once the threshold is
+// reached or immediately when a half-open trial fails.
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastFail = b.now()
+	if err != nil {
+		b.lastErr = err.Error()
+	}
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+	b.trialBusy = false
+}
+
+// Release ends a call whose outcome says nothing about provider health (for
+// example a 400 or a cancelled context) without changing the failure count.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateHalfOpen {
+		b.trialBusy = false
+	}
+}
+
+// Status returns a snapshot of the breaker.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStatus{
+		BaseURL:             b.key,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+	}
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		st.State = StateHalfOpen
+	}
+	if !b.openedAt.IsZero() && st.State != StateClosed {
+		t := b.openedAt
+		st.OpenedAt = &t
+	}
+	if !b.lastFail.IsZero() {
+		t := b.lastFail
+		st.LastFailureAt = &t
+	}
+	return st
+}
+
+// breakers are shared process-wide so per-request provider clones pointing
+// at the same base URL see the same health.
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*Breaker{}
+)
+
+func breakerFor(key string, threshold int, cooldown time.Duration) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	if b, ok := breakers[key]; ok {
+		return b
+	}
+	b := newBreaker(key, threshold, cooldown)
+	breakers[key] = b
+	return b
+}
+
+// Breakers returns the status of every breaker created so far, sorted by base
+// URL.
+func Breakers() []BreakerStatus {
+	breakersMu.Lock()
+	list := make([]*Breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+	out := make([]BreakerStatus, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.Status())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].BaseURL < out[j].BaseURL })
+	return out
+}
@@ -0,0 +1,203 @@
+// Package resilient wraps llm.Provider implementations with retries,
+// a per-base-URL circuit breaker, and failover to a secondary provider.
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// ErrBreakerOpen is returned when a provider's breaker rejects a call and no
+// fallback is available.
+var ErrBreakerOpen = errors.New("llm provider circuit breaker open")
+
+// Target is a provider together with the identity used for its breaker.
+type Target struct {
+	Provider llm.Provider
+	// BaseURL keys the shared circuit breaker.
+	BaseURL string
+	// Model overrides the caller's model when this target is used as the
+	// fallback. Empty lets the provider use its configured default.
+	Model string
+}
+
+// Provider implements llm.Provider over a primary and optional fallback.
+type Provider struct {
+	primary  target
+	fallback *target
+
+	maxRetries int
+	initial    time.Duration
+	max        time.Duration
+	sleep      func(context.Context, time.Duration) error
+}
+
+type target struct {
+	Target
+	breaker *Breaker
+}
+
+// New wraps primary according to cfg. fallback may be nil.
+func New(cfg config.LLMResilienceConfig, primary Target, fallback *Target) *Provider {
+	cooldown := time.Duration(cfg.BreakerCooldownSeconds) * time.Second
+	p := &Provider{
+		primary:    target{Target: primary, breaker: breakerFor(primary.BaseURL, cfg.BreakerFailureThreshold, cooldown)},
+		maxRetries: max(cfg.MaxRetries, 0),
+		initial:    time.Duration(cfg.InitialBackoffMillis) * time.Millisecond,
+		max:        time.Duration(cfg.MaxBackoffMillis) * time.Millisecond,
+		sleep:      sleepCtx,
+	}
+	if fallback != nil && fallback.Provider != nil {
+		p.fallback = &target{Target: *fallback, breaker: breakerFor(fallback.BaseURL, cfg.BreakerFailureThreshold, cooldown)}
+	}
+	return p
+}
+
+// Unwrap returns the primary provider so capability checks (compaction,
+// tokenization, vendor-specific helpers) see the concrete client.
+func (p *Provider) Unwrap() llm.Provider { return p.primary.Provider }
+
+func (p *Provider) Chat(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Message, error) {
+	var out llm.Message
+	err := p.call(ctx, model, func(ctx context.Context, prov llm.Provider, model string) error {
+		var err error
+		out, err = prov.Chat(ctx, msgs, tools, model)
+		return err
+	})
+	return out, err
+}
+
+// ChatWithOptions forwards extra request fields when the underlying provider
+// supports them and falls back to Chat otherwise.
+func (p *Provider) ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error) {
+	type chatWithOptions interface {
+		ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error)
+	}
+	var out llm.Message
+	err := p.call(ctx, model, func(ctx context.Context, prov llm.Provider, model string) error {
+		var err error
+		if co, ok := prov.(chatWithOptions); ok {
+			out, err = co.ChatWithOptions(ctx, msgs, tools, model, extra)
+		} else {
+			out, err = prov.Chat(ctx, msgs, tools, model)
+		}
+		return err
+	})
+	return out, err
+}
+
+// ChatStream retries and fails over only while nothing has been emitted to
+// h; once output has started, errors are returned as-is to avoid duplicated
+// deltas or tool calls.
+func (p *Provider) ChatStream(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	sh := &startedHandler{StreamHandler: h}
+	return p.call(ctx, model, func(ctx context.Context, prov llm.Provider, model string) error {
+		err := prov.ChatStream(ctx, msgs, tools, model, sh)
+		if err != nil && sh.started {
+			return permanent{err}
+		}
+		return err
+	})
+}
+
+func (p *Provider) call(ctx context.Context, model string, fn func(context.Context, llm.Provider, string) error) error {
+	err := p.attempt(ctx, p.primary, model, fn)
+	if err == nil || p.fallback == nil || ctx.Err() != nil {
+		return unwrapPermanent(err)
+	}
+	var perm permanent
+	if errors.As(err, &perm) || (!errors.Is(err, ErrBreakerOpen) && !Retryable(err)) {
+		return unwrapPermanent(err)
+	}
+	observability.LoggerWithTrace(ctx).Warn().Err(err).
+		Str("primary", p.primary.BaseURL).
+		Str("fallback", p.fallback.BaseURL).
+		Msg("llm_failover")
+	fbErr := p.attempt(ctx, *p.fallback, p.fallback.Model, fn)
+	if fbErr != nil {
+		return fmt.Errorf("%w (fallback: %v)", unwrapPermanent(err), unwrapPermanent(fbErr))
+	}
+	return nil
+}
+
+// attempt runs fn against t with retries, consulting t's breaker before each
+// try.
+func (p *Provider) attempt(ctx context.Context, t target, model string, fn func(context.Context, llm.Provider, string) error) error {
+	var last error
+	for n := 0; n <= p.maxRetries; n++ {
+		if n > 0 {
+			if err := p.sleep(ctx, backoff(n-1, p.initial, p.max)); err != nil {
+				return last
+			}
+		}
+		if !t.breaker.Allow() {
+			if last == nil {
+				last = fmt.Errorf("%w: %s", ErrBreakerOpen, t.BaseURL)
+			}
+			return last
+		}
+		err := fn(ctx, t.Provider, model)
+		if err == nil {
+			t.breaker.Success()
+			return nil
+		}
+		last = err
+		var perm permanent
+		if errors.As(err, &perm) {
+			t.breaker.Release()
+			return err
+		}
+		if !Retryable(err) {
+			t.breaker.Release()
+			return err
+		}
+		t.breaker.Failure(err)
+		observability.LoggerWithTrace(ctx).Debug().Err(err).
+			Str("base_url", t.BaseURL).
+			Int("status", statusOf(err)).
+			Int("attempt", n+1).
+			Msg("llm_call_retryable_error")
+	}
+	return last
+}
+
+// permanent marks an error that must not be retried or failed over.
+type permanent struct{ err error }
+
+func (p permanent) Error() string { return p.err.Error() }
+func (p permanent) Unwrap() error { return p.err }
+
+func unwrapPermanent(err error) error {
+	var perm permanent
+	if errors.As(err, &perm) {
+		return perm.err
+	}
+	return err
+}
+
+// startedHandler records whether any output reached the wrapped handler.
+type startedHandler struct {
+	llm.StreamHandler
+	started bool
+}
+
+func (s *startedHandler) OnDelta(content string) {
+	s.started = true
+	s.StreamHandler.OnDelta(content)
+}
+
+func (s *startedHandler) OnToolCall(tc llm.ToolCall) {
+	s.started = true
+	s.StreamHandler.OnToolCall(tc)
+}
+
+func (s *startedHandler) OnImage(img llm.GeneratedImage) {
+	s.started = true
+	s.StreamHandler.OnImage(img)
+}
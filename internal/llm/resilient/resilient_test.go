@@ -0,0 +1,195 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+type statusErr int
+
+func (e statusErr) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusErr) StatusCode() int { return int(e) }
+
+type scriptedProvider struct {
+	errs   []error
+	calls  int
+	models []string
+	stream bool
+}
+
+func (p *scriptedProvider) next() error {
+	p.calls++
+	if len(p.errs) == 0 {
+		return nil
+	}
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return err
+}
+
+func (p *scriptedProvider) Chat(_ context.Context, _ []llm.Message, _ []llm.ToolSchema, model string) (llm.Message, error) {
+	p.models = append(p.models, model)
+	if err := p.next(); err != nil {
+		return llm.Message{}, err
+	}
+	return llm.Message{Role: "assistant", Content: "ok"}, nil
+}
+
+func (p *scriptedProvider) ChatStream(_ context.Context, _ []llm.Message, _ []llm.ToolSchema, _ string, h llm.StreamHandler) error {
+	if p.stream {
+		h.OnDelta("partial")
+	}
+	return p.next()
+}
+
+type nopHandler struct{ deltas int }
+
+func (h *nopHandler) OnDelta(string)                { h.deltas++ }
+func (h *nopHandler) OnToolCall(llm.ToolCall)       {}
+func (h *nopHandler) OnImage(llm.GeneratedImage)    {}
+func (h *nopHandler) OnThoughtSummary(string)       {}
+func (h *nopHandler) OnThoughtSignature(sig string) {}
+
+func testConfig() config.LLMResilienceConfig {
+	return config.LLMResilienceConfig{Enabled: true, MaxRetries: 2, BreakerFailureThreshold: 3, BreakerCooldownSeconds: 60}
+}
+
+func newTestProvider(t *testing.T, primary, fallback llm.Provider) *Provider {
+	t.Helper()
+	var fb *Target
+	if fallback != nil {
+		fb = &Target{Provider: fallback, BaseURL: t.Name() + "/fallback", Model: "fallback-model"}
+	}
+	p := New(testConfig(), Target{Provider: primary, BaseURL: t.Name() + "/primary"}, fb)
+	p.sleep = func(context.Context, time.Duration) error { return nil }
+	return p
+}
+
+func TestRetryable(t *testing.T) {
+	cases := map[error]bool{
+		statusErr(429):                          true,
+		statusErr(503):                          true,
+		statusErr(400):                          false,
+		context.Canceled:                        false,
+		errors.New(`POST "x": 502 Bad Gateway`): true,
+		errors.New("Error 500, Message: boom"):  true,
+		errors.New("invalid api key"):           false,
+	}
+	for err, want := range cases {
+		if got := Retryable(err); got != want {
+			t.Errorf("Retryable(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestChatRetriesTransientErrors(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{statusErr(429), statusErr(500)}}
+	p := newTestProvider(t, primary, nil)
+
+	msg, err := p.Chat(context.Background(), nil, nil, "m")
+	if err != nil || msg.Content != "ok" {
+		t.Fatalf("expected success after retries, got %v %v", msg, err)
+	}
+	if primary.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", primary.calls)
+	}
+}
+
+func TestChatDoesNotRetryClientErrors(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{statusErr(400)}}
+	fallback := &scriptedProvider{}
+	p := newTestProvider(t, primary, fallback)
+
+	if _, err := p.Chat(context.Background(), nil, nil, "m"); err == nil {
+		t.Fatal("expected client error to be returned")
+	}
+	if primary.calls != 1 || fallback.calls != 0 {
+		t.Fatalf("expected single attempt without failover, got %d/%d", primary.calls, fallback.calls)
+	}
+}
+
+func TestBreakerOpensAndFailsOver(t *testing.T) {
+	down := statusErr(503)
+	primary := &scriptedProvider{errs: []error{down, down, down, down}}
+	fallback := &scriptedProvider{}
+	p := newTestProvider(t, primary, fallback)
+
+	if _, err := p.Chat(context.Background(), nil, nil, "primary-model"); err != nil {
+		t.Fatalf("expected failover success, got %v", err)
+	}
+	if fallback.calls != 1 || fallback.models[0] != "fallback-model" {
+		t.Fatalf("expected fallback call with its own model, got %d %v", fallback.calls, fallback.models)
+	}
+	if st := p.primary.breaker.Status(); st.State != StateOpen {
+		t.Fatalf("expected primary breaker open, got %+v", st)
+	}
+
+	// With the breaker open the primary is skipped entirely.
+	before := primary.calls
+	if _, err := p.Chat(context.Background(), nil, nil, "primary-model"); err != nil {
+		t.Fatalf("expected fallback success, got %v", err)
+	}
+	if primary.calls != before {
+		t.Fatalf("expected open breaker to skip primary")
+	}
+
+	found := false
+	for _, st := range Breakers() {
+		if st.BaseURL == t.Name()+"/primary" && st.State == StateOpen {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected open breaker in Breakers()")
+	}
+}
+
+func TestBreakerHalfOpenTrial(t *testing.T) {
+	now := time.Now()
+	b := newBreaker("x", 1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure(errors.New("down"))
+	if b.Allow() {
+		t.Fatal("expected open breaker to reject")
+	}
+	now = now.Add(2 * time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected trial call after cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("expected only one concurrent trial")
+	}
+	b.Success()
+	if st := b.Status(); st.State != StateClosed || st.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker, got %+v", st)
+	}
+}
+
+func TestChatStreamDoesNotRetryAfterOutput(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{statusErr(502)}, stream: true}
+	fallback := &scriptedProvider{}
+	p := newTestProvider(t, primary, fallback)
+	h := &nopHandler{}
+
+	if err := p.ChatStream(context.Background(), nil, nil, "m", h); err == nil {
+		t.Fatal("expected error once output has started")
+	}
+	if primary.calls != 1 || fallback.calls != 0 || h.deltas != 1 {
+		t.Fatalf("expected no retry after output, got %d/%d deltas=%d", primary.calls, fallback.calls, h.deltas)
+	}
+}
+
+func TestUnwrapExposesPrimary(t *testing.T) {
+	primary := &scriptedProvider{}
+	p := newTestProvider(t, primary, nil)
+	if llm.Unwrap(p) != llm.Provider(primary) {
+		t.Fatal("expected Unwrap to return the primary provider")
+	}
+}
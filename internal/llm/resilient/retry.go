@@ -0,0 +1,85 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// StatusCoder is implemented by errors that carry an HTTP status code.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// statusPattern matches the status codes vendor SDKs embed in error strings,
+// e.g. `POST "…": 429 Too Many Requests` or `Error 503, Message: …`.
+var statusPattern = regexp.MustCompile(`(?:^|[\s:(])(429|5\d\d)(?:[\s,)]|$)`)
+
+// Retryable reports whether err is worth retrying: rate limits, server
+// errors, and transport failures. Context cancellation is never retryable.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		code := sc.StatusCode()
+		return code == 429 || code >= 500
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return statusPattern.MatchString(err.Error())
+}
+
+// statusOf extracts a status code from err when one is present.
+func statusOf(err error) int {
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// backoff returns the delay before retry attempt n (0-based) using
+// exponential growth with full jitter.
+func backoff(n int, initial, max time.Duration) time.Duration {
+	d := initial
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
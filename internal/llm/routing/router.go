@@ -0,0 +1,129 @@
+// Package routing selects among several configured models per request based
+// on the capabilities a request needs (vision, tools, context size) and each
+// model's relative cost.
+package routing
+
+import (
+	"context"
+	"strings"
+
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// Route is one candidate model.
+type Route struct {
+	Name     string
+	Provider llm.Provider
+	// Model is passed to Provider; empty uses the provider default.
+	Model               string
+	Vision              bool
+	Tools               bool
+	ContextWindowTokens int
+	CostWeight          float64
+}
+
+// Requirements describes what a request needs from a model.
+type Requirements struct {
+	Vision          bool
+	Tools           bool
+	EstimatedTokens int
+}
+
+// Router implements llm.Provider by dispatching each call to the cheapest
+// eligible route, or to the default provider when no route qualifies.
+type Router struct {
+	def    llm.Provider
+	routes []Route
+}
+
+// New returns a Router over routes with def as the default provider.
+func New(def llm.Provider, routes []Route) *Router {
+	return &Router{def: def, routes: append([]Route(nil), routes...)}
+}
+
+// Unwrap returns the default provider so capability checks see it.
+func (r *Router) Unwrap() llm.Provider { return r.def }
+
+// RequirementsFor inspects a request and reports the capabilities it needs.
+func RequirementsFor(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema) Requirements {
+	req := Requirements{Tools: len(tools) > 0}
+	if _, ok := llm.ImagePromptFromContext(ctx); ok {
+		req.Vision = true
+	}
+	for _, m := range msgs {
+		if len(m.Images) > 0 || strings.Contains(m.Content, "data:image/") {
+			req.Vision = true
+		}
+		req.EstimatedTokens += llm.EstimateTokens(m.Content)
+	}
+	for _, t := range tools {
+		req.EstimatedTokens += llm.EstimateTokens(t.Name + t.Description)
+	}
+	return req
+}
+
+// Select returns the cheapest route satisfying req. ok is false when the
+// default provider should be used.
+func (r *Router) Select(req Requirements) (Route, bool) {
+	var (
+		best  Route
+		found bool
+	)
+	for _, rt := range r.routes {
+		if rt.Provider == nil {
+			continue
+		}
+		if req.Vision && !rt.Vision {
+			continue
+		}
+		if req.Tools && !rt.Tools {
+			continue
+		}
+		if rt.ContextWindowTokens > 0 && req.EstimatedTokens > rt.ContextWindowTokens {
+			continue
+		}
+		if !found || rt.CostWeight < best.CostWeight {
+			best, found = rt, true
+		}
+	}
+	return best, found
+}
+
+func (r *Router) pick(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Provider, string) {
+	req := RequirementsFor(ctx, msgs, tools)
+	rt, ok := r.Select(req)
+	ev := observability.LoggerWithTrace(ctx).Info().
+		Bool("vision", req.Vision).
+		Bool("tools", req.Tools).
+		Int("est_tokens", req.EstimatedTokens)
+	if !ok {
+		ev.Str("route", "default").Str("model", model).Msg("llm_route_selected")
+		return r.def, model
+	}
+	ev.Str("route", rt.Name).Str("model", rt.Model).Float64("cost_weight", rt.CostWeight).Msg("llm_route_selected")
+	return rt.Provider, rt.Model
+}
+
+func (r *Router) Chat(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Message, error) {
+	p, m := r.pick(ctx, msgs, tools, model)
+	return p.Chat(ctx, msgs, tools, m)
+}
+
+func (r *Router) ChatStream(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	p, m := r.pick(ctx, msgs, tools, model)
+	return p.ChatStream(ctx, msgs, tools, m, h)
+}
+
+// ChatWithOptions forwards extra request fields when the selected provider
+// supports them.
+func (r *Router) ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error) {
+	type chatWithOptions interface {
+		ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error)
+	}
+	p, m := r.pick(ctx, msgs, tools, model)
+	if co, ok := p.(chatWithOptions); ok {
+		return co.ChatWithOptions(ctx, msgs, tools, m, extra)
+	}
+	return p.Chat(ctx, msgs, tools, m)
+}
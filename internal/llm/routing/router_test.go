@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"manifold/internal/llm"
+)
+
+type namedProvider struct {
+	name   string
+	models []string
+}
+
+func (p *namedProvider) Chat(_ context.Context, _ []llm.Message, _ []llm.ToolSchema, model string) (llm.Message, error) {
+	p.models = append(p.models, model)
+	return llm.Message{Role: "assistant", Content: p.name}, nil
+}
+
+func (p *namedProvider) ChatStream(_ context.Context, _ []llm.Message, _ []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	p.models = append(p.models, model)
+	h.OnDelta(p.name)
+	return nil
+}
+
+func newTestRouter() (*Router, *namedProvider, *namedProvider, *namedProvider) {
+	def := &namedProvider{name: "default"}
+	local := &namedProvider{name: "local"}
+	cloud := &namedProvider{name: "cloud"}
+	r := New(def, []Route{
+		{Name: "local", Provider: local, Model: "small", ContextWindowTokens: 100, CostWeight: 0.1},
+		{Name: "cloud", Provider: cloud, Model: "large", Vision: true, Tools: true, CostWeight: 1},
+	})
+	return r, def, local, cloud
+}
+
+func TestRouterPrefersCheapestEligibleRoute(t *testing.T) {
+	r, _, local, _ := newTestRouter()
+	out, err := r.Chat(context.Background(), []llm.Message{{Role: "user", Content: "hi"}}, nil, "main")
+	if err != nil || out.Content != "local" {
+		t.Fatalf("expected local route, got %q %v", out.Content, err)
+	}
+	if local.models[0] != "small" {
+		t.Fatalf("expected route model, got %q", local.models[0])
+	}
+}
+
+func TestRouterCapabilities(t *testing.T) {
+	r, _, _, _ := newTestRouter()
+	tools := []llm.ToolSchema{{Name: "run_cli"}}
+
+	if out, _ := r.Chat(context.Background(), []llm.Message{{Role: "user", Content: "hi"}}, tools, "main"); out.Content != "cloud" {
+		t.Fatalf("expected tool request to route to cloud, got %q", out.Content)
+	}
+
+	img := []llm.Message{{Role: "user", Content: "describe ![image](data:image/png;base64,AAAA)"}}
+	if out, _ := r.Chat(context.Background(), img, nil, "main"); out.Content != "cloud" {
+		t.Fatalf("expected vision request to route to cloud, got %q", out.Content)
+	}
+
+	long := []llm.Message{{Role: "user", Content: strings.Repeat("word ", 400)}}
+	if out, _ := r.Chat(context.Background(), long, nil, "main"); out.Content != "cloud" {
+		t.Fatalf("expected long prompt to exceed local window, got %q", out.Content)
+	}
+}
+
+func TestRouterFallsBackToDefault(t *testing.T) {
+	def := &namedProvider{name: "default"}
+	r := New(def, []Route{{Name: "local", Provider: &namedProvider{name: "local"}, CostWeight: 0.1}})
+
+	out, err := r.Chat(context.Background(), nil, []llm.ToolSchema{{Name: "x"}}, "main")
+	if err != nil || out.Content != "default" {
+		t.Fatalf("expected default provider, got %q %v", out.Content, err)
+	}
+	if def.models[0] != "main" {
+		t.Fatalf("expected caller model on default route, got %q", def.models[0])
+	}
+	if llm.Unwrap(r) != llm.Provider(def) {
+		t.Fatal("expected Unwrap to return the default provider")
+	}
+}
@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage accumulates the token counts providers report for calls made with a
+// context returned by WithUsage.
+type Usage struct {
+	mu               sync.Mutex
+	promptTokens     int
+	completionTokens int
+	// parent is the Usage of an enclosing WithUsage, which counts the same
+	// calls.
+	parent *Usage
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context whose provider calls add their reported token
+// usage to the returned Usage, and to any Usage ctx already carries.
+func WithUsage(ctx context.Context) (context.Context, *Usage) {
+	u := &Usage{}
+	u.parent, _ = ctx.Value(usageKey{}).(*Usage)
+	return context.WithValue(ctx, usageKey{}, u), u
+}
+
+// Tokens returns the prompt and completion tokens recorded so far.
+func (u *Usage) Tokens() (prompt, completion int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.promptTokens, u.completionTokens
+}
+
+func addUsage(ctx context.Context, prompt, completion int) {
+	if ctx == nil {
+		return
+	}
+	for u, _ := ctx.Value(usageKey{}).(*Usage); u != nil; u = u.parent {
+		u.mu.Lock()
+		u.promptTokens += prompt
+		u.completionTokens += completion
+		u.mu.Unlock()
+	}
+}
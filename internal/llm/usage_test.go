@@ -0,0 +1,20 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithUsageNests(t *testing.T) {
+	ctx, outer := WithUsage(context.Background())
+	addUsage(ctx, 10, 1)
+	inner, step := WithUsage(ctx)
+	addUsage(inner, 5, 2)
+
+	if p, c := step.Tokens(); p != 5 || c != 2 {
+		t.Fatalf("inner usage = %d/%d", p, c)
+	}
+	if p, c := outer.Tokens(); p != 15 || c != 3 {
+		t.Fatalf("outer usage = %d/%d, want the inner calls counted too", p, c)
+	}
+}
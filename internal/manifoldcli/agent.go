@@ -0,0 +1,310 @@
+package manifoldcli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"manifold/client"
+	"manifold/internal/agent"
+	"manifold/internal/agent/prompts"
+	"manifold/internal/config"
+	"manifold/internal/embedding"
+	llmpkg "manifold/internal/llm"
+	llmproviders "manifold/internal/llm/providers"
+	"manifold/internal/mcpclient"
+	"manifold/internal/observability"
+	"manifold/internal/persistence/databases"
+	"manifold/internal/specialists"
+	"manifold/internal/tools"
+	"manifold/internal/tools/cli"
+	"manifold/internal/tools/patchtool"
+	"manifold/internal/tools/textsplitter"
+	"manifold/internal/tools/tts"
+	"manifold/internal/tools/utility"
+	"manifold/internal/tools/web"
+)
+
+const systemUserID int64 = 0
+
+const (
+	defaultRunTimeout = 2 * time.Minute
+	mcpInitTimeout    = 20 * time.Second
+)
+
+// agentRunOptions are the flags of "agent run".
+type agentRunOptions struct {
+	query      string
+	maxSteps   int
+	specialist string
+	remote     remoteFlags
+	session    string
+	project    string
+}
+
+// newAgentRunCommand runs one request through the agent. By default the
+// engine runs in-process with the same tools, specialists and MCP servers
+// agentd would use. With --server it is sent to a running agentd instead,
+// so the run shares that server's sessions, memory and tool permissions
+// with the web UI.
+func newAgentRunCommand(inv *invocation) *cobra.Command {
+	var opts agentRunOptions
+	cmd := &cobra.Command{
+		Use:   `run [-q "..." | request ...]`,
+		Short: "run one request through the agent and print the answer",
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runAgent(inv, opts, args)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVarP(&opts.query, "query", "q", "", "User request")
+	fs.IntVar(&opts.maxSteps, "max-steps", 0, "Max reasoning steps (default: maxSteps from config; in-process only)")
+	fs.StringVar(&opts.specialist, "specialist", "", "Name of specialist agent to use (inference-only; no tool calls unless enabled)")
+	opts.remote = addRemoteFlags(fs, "", "run on this agentd instead of in-process, e.g. "+defaultServerURL)
+	fs.StringVar(&opts.session, "session", "", "chat session to continue (--server only)")
+	fs.StringVar(&opts.project, "project", "", "project to run in (--server only)")
+	return cmd
+}
+
+func runAgent(inv *invocation, opts agentRunOptions, args []string) error {
+	query := strings.TrimSpace(opts.query)
+	if query == "" {
+		query = strings.TrimSpace(strings.Join(args, " "))
+	}
+	if query == "" {
+		return usageError(`a request is required: -q "..."`)
+	}
+
+	if strings.TrimSpace(*opts.remote.server) != "" {
+		if opts.maxSteps != 0 {
+			return usageError("--max-steps applies only to in-process runs")
+		}
+		c, err := opts.remote.client()
+		if err != nil {
+			return err
+		}
+		return runRemoteAgent(inv, c, client.RunRequest{
+			Prompt:     query,
+			SessionID:  strings.TrimSpace(opts.session),
+			ProjectID:  strings.TrimSpace(opts.project),
+			Specialist: strings.TrimSpace(opts.specialist),
+		})
+	}
+	if opts.session != "" || opts.project != "" {
+		return usageError("--session and --project need --server")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	maxSteps := opts.maxSteps
+	if maxSteps <= 0 {
+		maxSteps = cfg.MaxSteps
+	}
+	return run(inv, cfg, query, maxSteps, opts.specialist)
+}
+
+func run(inv *invocation, cfg *config.Config, query string, maxSteps int, specialistName string) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	log.Info().Msg("agent starting")
+	baseCtx := context.Background()
+	shutdown, err := observability.InitOTel(baseCtx, cfg.Obs)
+	if err != nil {
+		log.Warn().Err(err).Msg("otel init failed, continuing without observability")
+		shutdown = nil
+	} else {
+		// Bridge zerolog to OTLP log exporter
+		observability.EnableOTelLogging(cfg.Obs.ServiceName)
+	}
+	if shutdown != nil {
+		defer func() { _ = shutdown(context.Background()) }()
+	}
+
+	// Configure global LLM payload logging/truncation before creating providers.
+	llmpkg.ConfigureLogging(cfg.LogPayloads, cfg.OutputTruncateByte)
+
+	// Initialize the specialists store and apply DB-backed overrides so the CLI
+	// mirrors agentd behavior (specialists and orchestrator loaded from DB).
+	var specPool *pgxpool.Pool
+	if cfg.Databases.DefaultDSN != "" {
+		p, err := databases.OpenPool(baseCtx, cfg.Databases.DefaultDSN)
+		if err != nil {
+			log.Warn().Err(err).Msg("open specialists db")
+		} else {
+			specPool = p
+		}
+	}
+	if specPool != nil {
+		defer specPool.Close()
+	}
+	specStore := databases.NewSpecialistsStore(specPool)
+	if err := specStore.Init(baseCtx); err != nil {
+		log.Warn().Err(err).Msg("init specialists store")
+	}
+	if err := specialists.SeedStore(baseCtx, specStore, systemUserID, cfg.Specialists); err != nil {
+		log.Warn().Err(err).Msg("seed specialists")
+	}
+	specList, specListErr := specStore.List(baseCtx, systemUserID)
+	if specListErr != nil {
+		log.Warn().Err(specListErr).Msg("list specialists")
+	}
+	sp, ok, spErr := specStore.GetByName(baseCtx, systemUserID, specialists.OrchestratorName)
+	if spErr != nil {
+		log.Warn().Err(spErr).Msg("load orchestrator specialist")
+	}
+	if ok {
+		specialists.ApplyOrchestratorConfig(cfg, sp)
+		if strings.TrimSpace(cfg.SystemPrompt) == "" {
+			cfg.SystemPrompt = specialists.DefaultOrchestratorPrompt
+		}
+	} else {
+		// Ensure a safe default system prompt when no DB record exists.
+		cfg.SystemPrompt = specialists.DefaultOrchestratorPrompt
+	}
+
+	httpClient := observability.NewHTTPClient(nil)
+	// Inject global headers for the main agent if configured.
+	if len(cfg.OpenAI.ExtraHeaders) > 0 {
+		httpClient = observability.WithHeaders(httpClient, cfg.OpenAI.ExtraHeaders)
+	}
+
+	// Create the LLM provider after potential DB overrides.
+	llm, err := llmproviders.Build(*cfg, httpClient)
+	if err != nil {
+		return fmt.Errorf("build llm provider: %w", err)
+	}
+
+	// Build specialists registry from DB (fallback to YAML) so the CLI resolves
+	// the same set as agentd.
+	specReg := specialists.NewRegistryFromStore(cfg.LLMClient, cfg.Specialists, specList, specListErr, httpClient, nil, cfg.Workdir)
+
+	// If a specialist was requested, route the query directly and exit.
+	if strings.TrimSpace(specialistName) != "" {
+		a, ok := specReg.Get(specialistName)
+		if !ok {
+			return fmt.Errorf("unknown specialist %q; available: %v", specialistName, specReg.Names())
+		}
+		log.Info().Str("specialist", specialistName).Msg("direct specialist invocation")
+		ctx, cancel := context.WithTimeout(baseCtx, defaultRunTimeout)
+		defer cancel()
+		out, err := a.Inference(ctx, query, nil)
+		if err != nil {
+			return fmt.Errorf("specialist %q: %w", specialistName, err)
+		}
+		fmt.Fprintln(inv.stdout, out)
+		return nil
+	}
+
+	registry := tools.NewTimeoutRegistry(tools.NewRegistryWithLogging(cfg.LogPayloads), tools.TimeoutsFromConfig(cfg.ToolTimeouts))
+	mgr, err := databases.NewManager(baseCtx, cfg.Databases)
+	if err != nil {
+		return fmt.Errorf("init databases: %w", err)
+	}
+	defer mgr.Close()
+	exec := cli.NewExecutor(cfg.Exec, cfg.Workdir, cfg.OutputTruncateByte)
+	registry.Register(cli.NewTool(exec)) // provides run_cli
+	//registry.Register(web.NewTool(cfg.Web.SearXNGURL)) // provides web_search
+	registry.Register(web.NewFetchTool(mgr.Search)) // provides web_fetch
+	// Register patch application tool (unified diff).
+	registry.Register(patchtool.New(cfg.Workdir)) // provides apply_patch
+	// Register text splitting tool (RAG ingestion helpers).
+	registry.Register(textsplitter.NewForEmbedding(cfg.Embedding)) // provides split_text
+	registry.Register(utility.NewTextboxTool())
+	registry.Register(utility.NewPlanTool())
+	registry.Register(utility.NewScratchpadTool())
+	// Register TTS tool.
+	registry.Register(tts.New(*cfg, httpClient))
+
+	// Register specialists tool for LLM-driven routing (prefer DB-backed registry to stay in sync with agentd).
+	specReg = specialists.NewRegistryFromStore(cfg.LLMClient, cfg.Specialists, specList, specListErr, httpClient, registry, cfg.Workdir)
+
+	// If tools are globally disabled, use an empty registry.
+	registry = tools.ApplyTopLevelPolicy(registry, cfg.EnableTools, cfg.ToolAllowList)
+
+	// Log which tools are exposed after filtering to diagnose missing registrations at runtime.
+	log.Info().Bool("enableTools", cfg.EnableTools).Strs("allowList", cfg.ToolAllowList).Strs("tools", tools.SchemaNames(registry)).Msg("tool_registry_contents")
+
+	// Connect to configured MCP servers and register their tools.
+	mcpMgr := mcpclient.NewManager()
+	defer mcpMgr.Close()
+	ctxInit, cancelInit := context.WithTimeout(baseCtx, mcpInitTimeout)
+	if err := mcpMgr.RegisterFromConfig(ctxInit, registry, cfg.MCP); err != nil {
+		log.Warn().Err(err).Msg("mcp init")
+	}
+	cancelInit()
+
+	// Call a specialist directly if pre-dispatch routing picks one.
+	if d := newSpecialistRouter(cfg, llm).Route(baseCtx, query, specReg.Candidates()); d.Specialist != "" {
+		name := d.Specialist
+		log.Info().Str("route", name).Str("method", d.Method).Float64("confidence", d.Confidence).Msg("pre-dispatch specialist route matched")
+		a, ok := specReg.Get(name)
+		if !ok {
+			log.Error().Str("route", name).Msg("specialist not found for route")
+		} else {
+			ctx, cancel := context.WithTimeout(baseCtx, defaultRunTimeout)
+			defer cancel()
+			out, err := a.Inference(ctx, query, nil)
+			if err != nil {
+				return fmt.Errorf("specialist pre-dispatch %q: %w", name, err)
+			}
+			fmt.Fprintln(inv.stdout, out)
+			return nil
+		}
+	}
+
+	systemPrompt := prompts.DefaultSystemPrompt(cfg.Workdir, cfg.SystemPrompt)
+	systemPrompt = specReg.AppendToSystemPrompt(systemPrompt)
+
+	eng := agent.Engine{
+		LLM:                        llm,
+		Tools:                      registry,
+		MaxSteps:                   maxSteps,
+		System:                     systemPrompt,
+		SummaryEnabled:             cfg.SummaryEnabled,
+		SummaryReserveBufferTokens: cfg.SummaryReserveBufferTokens,
+	}
+
+	// Honor the configured run timeout; 0 disables the deadline.
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if cfg.AgentRunTimeoutSeconds > 0 {
+		ctx, cancel = context.WithTimeout(baseCtx, time.Duration(cfg.AgentRunTimeoutSeconds)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(baseCtx)
+	}
+	defer cancel()
+
+	final, err := eng.Run(ctx, query, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(inv.stdout, final)
+	return nil
+}
+
+// newSpecialistRouter builds the pre-dispatch router from config, using the
+// main provider as the classifier and the configured embedding endpoint.
+func newSpecialistRouter(cfg *config.Config, llm llmpkg.Provider) *specialists.Router {
+	var record func(specialists.Decision)
+	if path := strings.TrimSpace(cfg.SpecialistRouting.DecisionLog); path != "" {
+		rec, err := specialists.NewDecisionLog(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("open specialist route log")
+		} else {
+			record = rec
+		}
+	}
+	embed := func(ctx context.Context, texts []string) ([][]float32, error) {
+		return embedding.EmbedText(ctx, cfg.Embedding, texts)
+	}
+	return specialists.NewRouter(cfg.SpecialistRouting, cfg.SpecialistRoutes, embed, llm, record)
+}
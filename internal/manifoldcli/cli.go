@@ -0,0 +1,158 @@
+// Package manifoldcli implements the manifold command line: one binary
+// whose subcommands serve the API, run the agent or a workflow, validate
+// configuration, embed text and list skills. cmd/manifold is the entry
+// point; cmd/agentd and cmd/agent are thin wrappers kept for existing
+// scripts and images.
+package manifoldcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"manifold/internal/config"
+	"manifold/internal/observability"
+)
+
+// invocation carries the output streams of the command being run.
+type invocation struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// usageError is a misuse of a command; Run reports it with status 2.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// errNoCommand is returned when a command group is run without a
+// subcommand; its help has already been printed.
+var errNoCommand = errors.New("no command given")
+
+// newRootCommand builds the command tree writing to inv's streams.
+func newRootCommand(inv *invocation) *cobra.Command {
+	root := group("manifold", "Manifold agent server and command line")
+	root.AddCommand(
+		newServeCommand(),
+		group("agent", "run the agent from the command line", newAgentRunCommand(inv)),
+		group("workflow", "work with workflows on a running server", newWorkflowRunCommand(inv)),
+		group("config", "inspect the configuration", newConfigValidateCommand(inv)),
+		newEmbedCommand(inv),
+		group("skills", "inspect project skills", newSkillsListCommand(inv)),
+	)
+	root.SetOut(inv.stdout)
+	root.SetErr(inv.stderr)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError(err.Error())
+	})
+	return root
+}
+
+// group returns a command that only holds subcommands. Run without one, or
+// with an unknown one, it prints its help and fails as a usage error.
+func group(use, short string, subs ...*cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SetOut(cmd.ErrOrStderr())
+			_ = cmd.Help()
+			if len(args) == 0 {
+				return errNoCommand
+			}
+			return usageError(fmt.Sprintf("unknown command %q", args[0]))
+		},
+	}
+	cmd.AddCommand(subs...)
+	return cmd
+}
+
+// noArgs rejects positional arguments as a usage error.
+func noArgs(_ *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return usageError("unexpected arguments")
+	}
+	return nil
+}
+
+// Main runs the command line in args (without the program name) and
+// returns the process exit status.
+func Main(args []string) int {
+	return Run(args, os.Stdout, os.Stderr)
+}
+
+// Run is Main with explicit output streams.
+func Run(args []string, stdout, stderr io.Writer) int {
+	root := newRootCommand(&invocation{stdout: stdout, stderr: stderr})
+	root.SetArgs(legacyFlags(root, args))
+	cmd, err := root.ExecuteC()
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errNoCommand):
+		return 2
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "%s: %s\nRun '%s --help' for usage.\n", cmd.CommandPath(), usage, cmd.CommandPath())
+		return 2
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", cmd.CommandPath(), err)
+		return 1
+	}
+}
+
+// legacyFlag matches a single-dash long flag such as -server or -max-steps=5.
+var legacyFlag = regexp.MustCompile(`^-([a-z][a-z0-9-]+)(=.*)?$`)
+
+// legacyFlags rewrites the single-dash long flags the flag-package CLI
+// accepted to the double-dash form, for flags the target command defines,
+// so existing scripts keep working.
+func legacyFlags(root *cobra.Command, args []string) []string {
+	cmd, _, err := root.Find(args)
+	if err != nil {
+		return args
+	}
+	out := make([]string, len(args))
+	copy(out, args)
+	for i, arg := range out {
+		if arg == "--" {
+			break
+		}
+		m := legacyFlag.FindStringSubmatch(arg)
+		if m == nil {
+			continue
+		}
+		if cmd.Flags().Lookup(m[1]) != nil || cmd.InheritedFlags().Lookup(m[1]) != nil {
+			out[i] = "-" + arg
+		}
+	}
+	return out
+}
+
+// loadConfig loads the runtime configuration from the working directory
+// and sets up logging from it, as agentd does at startup.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	observability.InitLogger(cfg.LogPath, cfg.LogLevel)
+	return &cfg, nil
+}
+
+// envOr returns the trimmed environment variable key, or def when unset.
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,172 @@
+package manifoldcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"manifold/client"
+)
+
+func invoke(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = Run(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRunDispatch(t *testing.T) {
+	if code, _, stderr := invoke(t); code != 2 || !strings.Contains(stderr, "serve") {
+		t.Fatalf("no args: code=%d stderr=%q", code, stderr)
+	}
+	if code, _, stderr := invoke(t, "frobnicate"); code != 2 || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Fatalf("unknown command: code=%d stderr=%q", code, stderr)
+	}
+	if code, _, stderr := invoke(t, "workflow"); code != 2 || !strings.Contains(stderr, "manifold workflow [command]") {
+		t.Fatalf("group without subcommand: code=%d stderr=%q", code, stderr)
+	}
+	if code, _, stderr := invoke(t, "workflow", "frobnicate"); code != 2 || !strings.Contains(stderr, `manifold workflow: unknown command "frobnicate"`) {
+		t.Fatalf("unknown subcommand: code=%d stderr=%q", code, stderr)
+	}
+	if code, _, _ := invoke(t, "skills", "list", "--nope"); code != 2 {
+		t.Fatalf("bad flag: code=%d", code)
+	}
+	if code, stdout, _ := invoke(t, "skills", "list", "--help"); code != 0 || !strings.Contains(stdout, "manifold skills list [dir]") {
+		t.Fatalf("help: code=%d stdout=%q", code, stdout)
+	}
+	if code, _, stderr := invoke(t, "workflow", "run"); code != 2 || !strings.Contains(stderr, "manifold workflow run: exactly one workflow id") {
+		t.Fatalf("missing workflow id: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestSkillsList(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		path := filepath.Join(dir, ".skills", name, "SKILL.md")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("review", "---\nname: review\ndescription: Review a change\n---\nSteps.\n")
+
+	code, stdout, _ := invoke(t, "skills", "list", dir)
+	if code != 0 || !strings.Contains(stdout, "review") || !strings.Contains(stdout, "Review a change") {
+		t.Fatalf("code=%d stdout=%q", code, stdout)
+	}
+
+	write("broken", "---\ndescription: no name\n---\n")
+	code, _, stderr := invoke(t, "skills", "list", dir)
+	if code != 1 || !strings.Contains(stderr, "missing field `name`") {
+		t.Fatalf("broken skill: code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestWorkflowRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/flows/v2/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing bearer token")
+		}
+		var req struct {
+			WorkflowID string         `json:"workflow_id"`
+			Input      map[string]any `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.WorkflowID != "wf1" || req.Input["query"] != "x" {
+			t.Errorf("unexpected run request %+v (%v)", req, err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"run_id":"run-1","status":"running"}`))
+	})
+	mux.HandleFunc("GET /api/flows/v2/runs/run-1/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"run_id\":\"run-1\",\"sequence\":1,\"type\":\"node_started\",\"node_id\":\"n1\"}\n\n")
+		fmt.Fprint(w, "data: {\"run_id\":\"run-1\",\"sequence\":2,\"type\":\"node_completed\",\"node_id\":\"n1\",\"output\":{\"answer\":42}}\n\n")
+		fmt.Fprint(w, "data: {\"run_id\":\"run-1\",\"sequence\":3,\"type\":\"run_completed\",\"status\":\"completed\"}\n\n")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	code, stdout, stderr := invoke(t, "workflow", "run", "-server", srv.URL, "-token", "secret", "-input", `{"query":"x"}`, "wf1")
+	if code != 0 {
+		t.Fatalf("code=%d stderr=%q", code, stderr)
+	}
+	if !strings.Contains(stderr, "n1 started") || !strings.Contains(stderr, "n1 completed") {
+		t.Fatalf("node progress missing: %q", stderr)
+	}
+	var outputs map[string]map[string]any
+	if err := json.Unmarshal([]byte(stdout), &outputs); err != nil || outputs["n1"]["answer"] != float64(42) {
+		t.Fatalf("outputs = %q (%v)", stdout, err)
+	}
+
+	if code, _, stderr := invoke(t, "workflow", "run", "--server", srv.URL, "--input", "[1]", "wf1"); code != 2 || !strings.Contains(stderr, "--input must be a JSON object") {
+		t.Fatalf("bad input: code=%d stderr=%q", code, stderr)
+	}
+	if code, stdout, stderr := invoke(t, "workflow", "run", "--server="+srv.URL, "--token=secret", "--detach", "--input", `{"query":"x"}`, "wf1"); code != 0 || stdout != "run-1\n" {
+		t.Fatalf("detach: code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+}
+
+func TestLegacyFlags(t *testing.T) {
+	root := newRootCommand(&invocation{stdout: io.Discard, stderr: io.Discard})
+	got := legacyFlags(root, []string{"agent", "run", "-q", "hi", "-max-steps=3", "-server", "http://x", "-nope", "--", "-session"})
+	want := []string{"agent", "run", "-q", "hi", "--max-steps=3", "--server", "http://x", "-nope", "--", "-session"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("legacyFlags = %q, want %q", got, want)
+	}
+}
+
+func TestRunRemoteAgent(t *testing.T) {
+	var body string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /agent/run", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt    string `json:"prompt"`
+			SessionID string `json:"session_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Prompt != "hi" || req.SessionID != "s1" || r.URL.Query().Get("specialist") != "coder" {
+			t.Errorf("unexpected request %+v specialist=%q", req, r.URL.Query().Get("specialist"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := client.RunRequest{Prompt: "hi", SessionID: "s1", Specialist: "coder"}
+
+	body = "data: {\"type\":\"queued\",\"position\":2}\n\n" +
+		"data: {\"type\":\"delta\",\"data\":\"Hel\"}\n\n" +
+		"data: {\"type\":\"tool_start\",\"title\":\"Tool: run_cli\"}\n\n" +
+		"data: {\"type\":\"delta\",\"data\":\"lo\"}\n\n" +
+		"data: {\"type\":\"final\",\"data\":\"Hello\"}\n\n"
+	var out, errOut bytes.Buffer
+	if err := runRemoteAgent(&invocation{stdout: &out, stderr: &errOut}, c, req); err != nil {
+		t.Fatalf("runRemoteAgent: %v", err)
+	}
+	if out.String() != "Hello\n" {
+		t.Fatalf("stdout = %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "queued at position 2") || !strings.Contains(errOut.String(), "[Tool: run_cli]") {
+		t.Fatalf("stderr = %q", errOut.String())
+	}
+
+	body = "data: {\"type\":\"error\",\"data\":\"(error) server is busy\"}\n\n"
+	out.Reset()
+	if err := runRemoteAgent(&invocation{stdout: &out, stderr: &errOut}, c, req); err == nil || err.Error() != "server is busy" {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package manifoldcli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"manifold/internal/embedding"
+)
+
+// newEmbedCommand embeds each argument, or each non-empty stdin line when
+// there are none, and prints one JSON object per input.
+func newEmbedCommand(inv *invocation) *cobra.Command {
+	return &cobra.Command{
+		Use:   "embed [text ...]",
+		Short: "embed text with the configured embedding endpoint",
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runEmbed(inv, args)
+		},
+	}
+}
+
+func runEmbed(inv *invocation, inputs []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				inputs = append(inputs, line)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+	}
+	if len(inputs) == 0 {
+		return usageError("no text to embed")
+	}
+	vectors, err := embedding.EmbedText(context.Background(), cfg.Embedding, inputs)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(inv.stdout)
+	for i, v := range vectors {
+		if err := enc.Encode(map[string]any{"input": inputs[i], "embedding": v}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package manifoldcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"manifold/client"
+)
+
+// defaultServerURL is where commands that talk to agentd look by default.
+const defaultServerURL = "http://localhost:32180"
+
+// remoteFlags locate and authenticate against a running agentd. The
+// credentials default to the MANIFOLD_* variables manibot uses.
+type remoteFlags struct {
+	server     *string
+	token      *string
+	cookie     *string
+	cookieName *string
+}
+
+func addRemoteFlags(fs *pflag.FlagSet, serverDefault, serverUsage string) remoteFlags {
+	return remoteFlags{
+		server:     fs.String("server", serverDefault, serverUsage),
+		token:      fs.String("token", os.Getenv("MANIFOLD_AUTH_BEARER_TOKEN"), "bearer token (MANIFOLD_AUTH_BEARER_TOKEN)"),
+		cookie:     fs.String("session-cookie", os.Getenv("MANIFOLD_SESSION_COOKIE"), "session cookie value (MANIFOLD_SESSION_COOKIE)"),
+		cookieName: fs.String("session-cookie-name", envOr("MANIFOLD_SESSION_COOKIE_NAME", "sio_session"), "session cookie name"),
+	}
+}
+
+func (rf remoteFlags) client() (*client.Client, error) {
+	var opts []client.Option
+	if *rf.token != "" {
+		opts = append(opts, client.WithBearerToken(*rf.token))
+	}
+	if *rf.cookie != "" {
+		opts = append(opts, client.WithSessionCookie(*rf.cookieName, *rf.cookie))
+	}
+	return client.New(*rf.server, opts...)
+}
+
+// runRemoteAgent sends the request to agentd's /agent/run and streams the
+// answer to stdout as it arrives. Tool calls and queue positions go to
+// stderr.
+func runRemoteAgent(inv *invocation, c *client.Client, req client.RunRequest) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	streamed := false
+	var runErr error
+	final, err := c.RunFunc(ctx, req, func(ev client.Event) error {
+		switch ev.Type {
+		case client.EventDelta:
+			fmt.Fprint(inv.stdout, ev.Data)
+			streamed = streamed || ev.Data != ""
+		case client.EventQueued:
+			var q struct {
+				Position int `json:"position"`
+			}
+			_ = json.Unmarshal(ev.Raw, &q)
+			fmt.Fprintf(inv.stderr, "queued at position %d\n", q.Position)
+		case client.EventToolStart:
+			fmt.Fprintf(inv.stderr, "[%s]\n", ev.Title)
+		case client.EventError:
+			runErr = errors.New(strings.TrimPrefix(ev.Data, "(error) "))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if final.Type == "" {
+		return errors.New("stream ended without a final answer")
+	}
+	if streamed {
+		fmt.Fprintln(inv.stdout)
+	} else {
+		fmt.Fprintln(inv.stdout, final.Data)
+	}
+	return nil
+}
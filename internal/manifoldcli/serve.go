@@ -0,0 +1,46 @@
+package manifoldcli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"manifold/internal/agentd"
+	"manifold/internal/config"
+)
+
+func newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "start the agentd HTTP server",
+		Args:  noArgs,
+		RunE: func(*cobra.Command, []string) error {
+			agentd.Run()
+			return nil
+		},
+	}
+}
+
+// newConfigValidateCommand checks the configuration files in the working
+// directory and fails when anything is wrong, including unknown keys.
+func newConfigValidateCommand(inv *invocation) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "check config.yaml, specialists.yaml and mcp.yaml",
+		Args:  noArgs,
+		RunE: func(*cobra.Command, []string) error {
+			problems, err := config.Validate()
+			if err != nil {
+				return err
+			}
+			for _, p := range problems {
+				fmt.Fprintln(inv.stderr, p)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%d configuration problem(s) found", len(problems))
+			}
+			fmt.Fprintln(inv.stdout, "configuration OK")
+			return nil
+		},
+	}
+}
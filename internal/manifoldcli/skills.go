@@ -0,0 +1,58 @@
+package manifoldcli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"manifold/internal/skills"
+)
+
+// newSkillsListCommand lists the skills agents would load for a project
+// checkout, read from <dir>/.skills. Skills that fail to parse are reported
+// and make the command fail.
+func newSkillsListCommand(inv *invocation) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [dir]",
+		Short: "list the skills under a directory's .skills folder",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return usageError("at most one directory may be given")
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			var dir string
+			if len(args) == 1 {
+				dir = args[0]
+			} else {
+				wd, err := os.Getwd()
+				if err != nil {
+					return err
+				}
+				dir = wd
+			}
+			return listSkills(inv, dir)
+		},
+	}
+}
+
+func listSkills(inv *invocation, dir string) error {
+	outcome := skills.LoadFromDir(dir)
+	tw := tabwriter.NewWriter(inv.stdout, 0, 4, 2, ' ', 0)
+	for _, s := range outcome.Skills {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, s.Description, s.Path)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, e := range outcome.Errors {
+		fmt.Fprintf(inv.stderr, "%s: %s\n", e.Path, e.Message)
+	}
+	if len(outcome.Errors) > 0 {
+		return fmt.Errorf("%d skill(s) could not be loaded", len(outcome.Errors))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package manifoldcli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"manifold/client"
+)
+
+// workflowRunOptions are the flags of "workflow run".
+type workflowRunOptions struct {
+	remote  remoteFlags
+	input   string
+	project string
+	detach  bool
+}
+
+func newWorkflowRunCommand(inv *invocation) *cobra.Command {
+	var opts workflowRunOptions
+	cmd := &cobra.Command{
+		Use:   "run [flags] <workflow-id>",
+		Short: "start a workflow and follow it until it finishes",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return usageError("exactly one workflow id is required")
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runWorkflow(inv, opts, args[0])
+		},
+	}
+	fs := cmd.Flags()
+	opts.remote = addRemoteFlags(fs, envOr("MANIFOLD_BASE_URL", defaultServerURL), "agentd base URL (MANIFOLD_BASE_URL)")
+	fs.StringVar(&opts.input, "input", "", "workflow input as a JSON object")
+	fs.StringVar(&opts.project, "project", "", "project id to run the workflow in")
+	fs.BoolVar(&opts.detach, "detach", false, "print the run id and exit without following the run")
+	return cmd
+}
+
+// runWorkflow starts a saved workflow on a running agentd, prints its node
+// transitions until the run ends, then prints the node outputs as JSON.
+func runWorkflow(inv *invocation, opts workflowRunOptions, workflowID string) error {
+	req := client.WorkflowRunRequest{WorkflowID: workflowID, ProjectID: strings.TrimSpace(opts.project)}
+	if strings.TrimSpace(opts.input) != "" {
+		if err := json.Unmarshal([]byte(opts.input), &req.Input); err != nil {
+			return usageError(fmt.Sprintf("--input must be a JSON object: %v", err))
+		}
+	}
+
+	c, err := opts.remote.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	run, err := c.StartWorkflow(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(inv.stderr, "run %s %s\n", run.RunID, run.Status)
+	if opts.detach {
+		fmt.Fprintln(inv.stdout, run.RunID)
+		return nil
+	}
+
+	outputs := map[string]map[string]any{}
+	last, err := c.WatchWorkflowRun(ctx, run.RunID, func(ev client.WorkflowRunEvent) error {
+		if ev.NodeID == "" || ev.Type == "node_output_diff" {
+			return nil
+		}
+		line := ev.NodeID + " " + strings.TrimPrefix(ev.Type, "node_")
+		if ev.Error != "" {
+			line += ": " + ev.Error
+		}
+		fmt.Fprintln(inv.stderr, line)
+		if ev.Type == client.WorkflowNodeCompleted && ev.Output != nil {
+			outputs[ev.NodeID] = ev.Output
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	switch last.Type {
+	case client.WorkflowRunCompleted:
+		// Node outputs go to stdout so they can be piped on.
+		enc := json.NewEncoder(inv.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(outputs)
+	case client.WorkflowRunFailed, client.WorkflowRunCancelled:
+		msg := last.Error
+		if msg == "" {
+			msg = last.Message
+		}
+		return fmt.Errorf("run %s %s: %s", run.RunID, strings.TrimPrefix(last.Type, "run_"), msg)
+	default:
+		return fmt.Errorf("run %s: stream ended before the run finished", run.RunID)
+	}
+}
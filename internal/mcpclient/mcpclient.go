@@ -11,11 +11,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	mcppkg "github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 
 	"manifold/internal/config"
 	"manifold/internal/tools"
@@ -24,27 +27,63 @@ import (
 
 const defaultRemoteMCPHTTPTimeout = 30 * time.Second
 
+const (
+	// connectTimeout bounds a reconnect attempt made by a health monitor.
+	connectTimeout = 30 * time.Second
+	// Reconnect attempts back off exponentially between these delays.
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 5 * time.Minute
+)
+
 // Manager holds active MCP client sessions and generated tool wrappers.
 type Manager struct {
+	mu        sync.Mutex
+	servers   map[string]*server
 	sessions  map[string]*mcppkg.ClientSession
 	toolNames map[string][]string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// server is one registration made through RegisterOne. Its session and tools
+// live in the Manager maps; a stale pointer means the server was removed or
+// re-registered, and work started for it is discarded.
+type server struct {
+	cfg    config.MCPServerConfig
+	reg    tools.Registry
+	cancel context.CancelFunc // stops the health monitor, if any
 }
 
 // NewManager creates a new Manager.
 func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Manager{
+		servers:   map[string]*server{},
 		sessions:  map[string]*mcppkg.ClientSession{},
 		toolNames: map[string][]string{},
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
-// Close closes all active sessions.
+// Close stops health monitors and closes all active sessions.
 func (m *Manager) Close() {
+	m.cancel()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, s := range m.sessions {
 		_ = s.Close()
 	}
 }
 
+// ToolNames returns the registered tool names for the named server.
+func (m *Manager) ToolNames(name string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.toolNames[name]...)
+}
+
 // RegisterFromConfig connects to configured MCP servers, lists their tools, and
 // registers wrappers into the provided registry. Tools are registered with names
 // in the form "<server>_<tool>" to avoid collisions.
@@ -59,7 +98,8 @@ func (m *Manager) RegisterFromConfig(ctx context.Context, reg tools.Registry, mc
 			continue
 		}
 		if err := m.RegisterOne(ctx, reg, srv); err != nil {
-			// Don't fail entire setup; just skip this server.
+			// Don't fail entire setup; just skip this server. Servers with
+			// health checks keep retrying in the background.
 			continue
 		}
 	}
@@ -67,29 +107,78 @@ func (m *Manager) RegisterFromConfig(ctx context.Context, reg tools.Registry, mc
 }
 
 // RegisterOne connects to a single MCP server and registers its tools.
+// When srv.HealthCheckSeconds is set the server stays registered even if this
+// first connect fails, and a monitor reconnects it with backoff.
 func (m *Manager) RegisterOne(ctx context.Context, reg tools.Registry, srv config.MCPServerConfig) error {
 	if strings.TrimSpace(srv.Name) == "" {
 		return fmt.Errorf("server name required")
 	}
+	if _, err := newTransport(srv); err != nil {
+		return err
+	}
 
 	// If already exists, close it first (implicit update/replace)
 	m.RemoveOne(srv.Name, reg)
 
-	// Create client
-	opts := &mcppkg.ClientOptions{}
-	if srv.KeepAliveSeconds > 0 {
-		opts.KeepAlive = time.Duration(srv.KeepAliveSeconds) * time.Second
+	s := &server{cfg: srv, reg: reg}
+	m.mu.Lock()
+	m.servers[srv.Name] = s
+	m.mu.Unlock()
+
+	err := m.connect(ctx, s)
+	if srv.HealthCheckSeconds > 0 {
+		monCtx, cancel := context.WithCancel(m.ctx)
+		m.mu.Lock()
+		if m.servers[srv.Name] == s {
+			s.cancel = cancel
+			go m.monitor(monCtx, s)
+		} else {
+			cancel()
+		}
+		m.mu.Unlock()
+		return err
 	}
-	client := mcppkg.NewClient(&mcppkg.Implementation{Name: "manifold", Version: version.Version}, opts)
+	if err != nil {
+		m.mu.Lock()
+		if m.servers[srv.Name] == s {
+			delete(m.servers, srv.Name)
+		}
+		m.mu.Unlock()
+	}
+	return err
+}
 
-	var session *mcppkg.ClientSession
-	var err error
+// RemoveOne closes the session for the named server and unregisters its tools.
+func (m *Manager) RemoveOne(name string, reg tools.Registry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.servers[name]; ok {
+		if s.cancel != nil {
+			s.cancel()
+		}
+		delete(m.servers, name)
+	}
+	if s, ok := m.sessions[name]; ok {
+		_ = s.Close()
+		delete(m.sessions, name)
+	}
+	if names, ok := m.toolNames[name]; ok {
+		for _, tName := range names {
+			reg.Unregister(tName)
+		}
+		delete(m.toolNames, name)
+	}
+}
 
+// newTransport validates srv and builds a fresh transport for it. A transport
+// serves a single connection, so each reconnect asks for a new one.
+func newTransport(srv config.MCPServerConfig) (mcppkg.Transport, error) {
+	srv = resolveSecrets(srv)
 	if strings.TrimSpace(srv.Command) != "" {
 		// Build command (validated)
 		cleanCmd := filepath.Clean(srv.Command)
 		if cleanCmd != srv.Command || filepath.IsAbs(cleanCmd) || strings.Contains(cleanCmd, string(os.PathSeparator)+"..") {
-			return fmt.Errorf("invalid command path")
+			return nil, fmt.Errorf("invalid command path")
 		}
 		cmd := exec.Command(cleanCmd, srv.Args...)
 		// Merge env
@@ -100,60 +189,205 @@ func (m *Manager) RegisterOne(ctx context.Context, reg tools.Registry, srv confi
 			}
 			cmd.Env = env
 		}
-		log.Debug().
-			Str("server", srv.Name).
-			Str("command", cleanCmd).
-			Strs("args", srv.Args).
-			Msg("mcp_connecting_stdio")
 		// Connect via stdio transport (SDK v1)
-		session, err = client.Connect(ctx, &mcppkg.CommandTransport{Command: cmd}, nil)
-	} else if strings.TrimSpace(srv.URL) != "" {
+		return &mcppkg.CommandTransport{Command: cmd}, nil
+	}
+	if strings.TrimSpace(srv.URL) != "" {
 		// Connect via Streamable HTTP transport to remote server
 		httpClient := buildMCPHTTPClient(srv)
 		// Some remote MCP servers, including Recorded Future, hang the standalone
 		// session-bound SSE GET stream. Disabling it keeps the standard POST-based
 		// handshake and request/response flow working reliably.
-		transport := &mcppkg.StreamableClientTransport{Endpoint: srv.URL, HTTPClient: httpClient, DisableStandaloneSSE: true}
-		session, err = client.Connect(ctx, transport, nil)
-	} else {
-		return fmt.Errorf("invalid config: neither command nor url provided")
+		return &mcppkg.StreamableClientTransport{Endpoint: srv.URL, HTTPClient: httpClient, DisableStandaloneSSE: true}, nil
+	}
+	return nil, fmt.Errorf("invalid config: neither command nor url provided")
+}
+
+// connect opens a session for s and registers its tools.
+func (m *Manager) connect(ctx context.Context, s *server) error {
+	srv := s.cfg
+	transport, err := newTransport(srv)
+	if err != nil {
+		return err
+	}
+
+	// Create client
+	opts := &mcppkg.ClientOptions{
+		ToolListChangedHandler: func(_ context.Context, req *mcppkg.ToolListChangedRequest) {
+			// Listing tools from inside the handler would block the
+			// session's message loop, so refresh from a goroutine.
+			go func() {
+				ctx, cancel := context.WithTimeout(m.ctx, connectTimeout)
+				defer cancel()
+				if err := m.refreshTools(ctx, s, req.Session); err != nil {
+					log.Warn().Err(err).Str("server", srv.Name).Msg("mcp_tool_refresh_failed")
+				}
+			}()
+		},
+	}
+	if srv.KeepAliveSeconds > 0 {
+		opts.KeepAlive = time.Duration(srv.KeepAliveSeconds) * time.Second
+	}
+	client := mcppkg.NewClient(&mcppkg.Implementation{Name: "manifold", Version: version.Version}, opts)
+
+	if srv.Command != "" {
+		log.Debug().
+			Str("server", srv.Name).
+			Str("command", srv.Command).
+			Strs("args", srv.Args).
+			Msg("mcp_connecting_stdio")
 	}
+	session, err := client.Connect(ctx, transport, nil)
 	if err != nil {
 		log.Debug().Err(err).Str("server", srv.Name).Msg("mcp_connect_failed")
 		return err
 	}
 	log.Debug().Str("server", srv.Name).Msg("mcp_connected_successfully")
-	m.sessions[srv.Name] = session
 
 	// List all tools and register wrappers
-	// Use iterator to fetch all pages.
 	log.Debug().Str("server", srv.Name).Msg("mcp_listing_tools")
-	var tNames []string
+	list, err := listTools(ctx, srv.Name, session)
+	if err != nil {
+		// Keep whatever was listed before the error.
+		log.Debug().Err(err).Str("server", srv.Name).Msg("mcp_tool_iteration_error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.servers[srv.Name] != s {
+		// Removed or replaced while connecting.
+		_ = session.Close()
+		return fmt.Errorf("mcp server %s was removed", srv.Name)
+	}
+	if old, ok := m.sessions[srv.Name]; ok {
+		_ = old.Close()
+	}
+	m.sessions[srv.Name] = session
+	m.installToolsLocked(s, list)
+	return nil
+}
+
+// listTools fetches all pages of the server's tool list.
+func listTools(ctx context.Context, server string, session *mcppkg.ClientSession) ([]*mcpTool, error) {
+	var list []*mcpTool
 	for tool, err := range session.Tools(ctx, nil) {
 		if err != nil {
-			log.Debug().Err(err).Str("server", srv.Name).Msg("mcp_tool_iteration_error")
-			break
+			return list, err
 		}
-		t := &mcpTool{server: srv.Name, session: session, tool: tool}
-		reg.Register(t)
+		list = append(list, &mcpTool{server: server, session: session, tool: tool})
+	}
+	return list, nil
+}
+
+// installToolsLocked registers list as the server's tools, unregistering any
+// earlier tool that is no longer offered. m.mu must be held.
+func (m *Manager) installToolsLocked(s *server, list []*mcpTool) {
+	keep := make(map[string]bool, len(list))
+	tNames := make([]string, 0, len(list))
+	for _, t := range list {
+		s.reg.Register(t)
+		keep[t.Name()] = true
 		tNames = append(tNames, t.Name())
 	}
-	m.toolNames[srv.Name] = tNames
-	return nil
+	for _, old := range m.toolNames[s.cfg.Name] {
+		if !keep[old] {
+			s.reg.Unregister(old)
+		}
+	}
+	m.toolNames[s.cfg.Name] = tNames
 }
 
-// RemoveOne closes the session for the named server and unregisters its tools.
-func (m *Manager) RemoveOne(name string, reg tools.Registry) {
-	if s, ok := m.sessions[name]; ok {
-		_ = s.Close()
-		delete(m.sessions, name)
+// refreshTools re-lists the tools of session and re-registers them if it is
+// still the server's current session.
+func (m *Manager) refreshTools(ctx context.Context, s *server, session *mcppkg.ClientSession) error {
+	list, err := listTools(ctx, s.cfg.Name, session)
+	if err != nil {
+		return err
 	}
-	if names, ok := m.toolNames[name]; ok {
-		for _, tName := range names {
-			reg.Unregister(tName)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.servers[s.cfg.Name] != s || m.sessions[s.cfg.Name] != session {
+		return nil
+	}
+	m.installToolsLocked(s, list)
+	return nil
+}
+
+// disconnect drops session and the server's tools after a failed health
+// check, leaving the registration in place for the monitor to reconnect.
+func (m *Manager) disconnect(s *server, session *mcppkg.ClientSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = session.Close()
+	if m.servers[s.cfg.Name] != s || m.sessions[s.cfg.Name] != session {
+		return
+	}
+	delete(m.sessions, s.cfg.Name)
+	for _, tName := range m.toolNames[s.cfg.Name] {
+		s.reg.Unregister(tName)
+	}
+	delete(m.toolNames, s.cfg.Name)
+}
+
+// monitor pings the server every HealthCheckSeconds and re-lists its tools.
+// While it is down, reconnects are retried with exponential backoff.
+func (m *Manager) monitor(ctx context.Context, s *server) {
+	interval := time.Duration(s.cfg.HealthCheckSeconds) * time.Second
+	var backoff time.Duration
+	for {
+		m.mu.Lock()
+		session := m.sessions[s.cfg.Name]
+		m.mu.Unlock()
+
+		wait := interval
+		if session == nil {
+			backoff = nextBackoff(backoff)
+			wait = backoff
 		}
-		delete(m.toolNames, name)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if session != nil {
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			err := session.Ping(checkCtx, nil)
+			if err == nil {
+				err = m.refreshTools(checkCtx, s, session)
+			}
+			cancel()
+			if err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Str("server", s.cfg.Name).Msg("mcp_health_check_failed")
+			m.disconnect(s, session)
+		}
+
+		connCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+		err := m.connect(connCtx, s)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("server", s.cfg.Name).Dur("retry_in", nextBackoff(backoff)).Msg("mcp_reconnect_failed")
+			continue
+		}
+		backoff = 0
+		log.Info().Str("server", s.cfg.Name).Msg("mcp_reconnected")
+	}
+}
+
+// nextBackoff doubles d between reconnectMinDelay and reconnectMaxDelay.
+func nextBackoff(d time.Duration) time.Duration {
+	if d <= 0 {
+		return reconnectMinDelay
 	}
+	return min(2*d, reconnectMaxDelay)
 }
 
 // mcpTool adapts an MCP tool to the local tools.Tool interface.
@@ -329,6 +563,10 @@ func buildMCPHTTPClient(srv config.MCPServerConfig) *http.Client {
 		}
 	}
 	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: srv.HTTP.TLS.InsecureSkipVerify} // #nosec G402
+	timeout := defaultRemoteMCPHTTPTimeout
+	if srv.HTTP.TimeoutSeconds > 0 {
+		timeout = time.Duration(srv.HTTP.TimeoutSeconds) * time.Second
+	}
 	rt := &headerRoundTripper{
 		base:     tr,
 		headers:  srv.Headers,
@@ -336,13 +574,30 @@ func buildMCPHTTPClient(srv config.MCPServerConfig) *http.Client {
 		origin:   defaultOrigin(srv.Origin),
 		protocol: strings.TrimSpace(srv.ProtocolVersion),
 	}
-	cli := &http.Client{Transport: rt}
-	if srv.HTTP.TimeoutSeconds > 0 {
-		cli.Timeout = time.Duration(srv.HTTP.TimeoutSeconds) * time.Second
-	} else {
-		cli.Timeout = defaultRemoteMCPHTTPTimeout
+	if src := oauthTokenSource(srv, &http.Client{Transport: tr, Timeout: timeout}); src != nil {
+		rt.base = &oauth2.Transport{Source: src, Base: tr}
+		rt.bearer = ""
 	}
-	return cli
+	return &http.Client{Transport: rt, Timeout: timeout}
+}
+
+// oauthTokenSource returns a cached, self-renewing client-credentials token
+// source for srv, or nil when OAuth is not configured. Token requests go
+// through hc so they share the server's proxy and TLS settings.
+func oauthTokenSource(srv config.MCPServerConfig, hc *http.Client) oauth2.TokenSource {
+	o := srv.OAuth
+	if strings.TrimSpace(o.TokenURL) == "" {
+		return nil
+	}
+	cc := &clientcredentials.Config{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		TokenURL:     o.TokenURL,
+		Scopes:       o.Scopes,
+		// RFC 8707 resource indicator, as in the interactive MCP OAuth flow.
+		EndpointParams: url.Values{"resource": {srv.URL}},
+	}
+	return cc.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, hc))
 }
 
 type headerRoundTripper struct {
@@ -2,10 +2,16 @@ package mcpclient
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"manifold/internal/config"
+	"manifold/internal/tools"
 
 	mcppkg "github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -45,3 +51,128 @@ func TestBuildMCPHTTPClient_DefaultTimeout(t *testing.T) {
 		t.Fatalf("expected default timeout of 30s, got %s", client.Timeout)
 	}
 }
+
+func TestBuildMCPHTTPClient_OAuthClientCredentials(t *testing.T) {
+	var tokenCalls atomic.Int32
+	var resource atomic.Value
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls.Add(1)
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		resource.Store(r.Form.Get("resource"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"tok","token_type":"bearer","expires_in":3600}`)
+	}))
+	defer tokenSrv.Close()
+
+	var auth atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	client := buildMCPHTTPClient(config.MCPServerConfig{
+		URL:         api.URL,
+		BearerToken: "static",
+		OAuth:       config.MCPOAuthConfig{TokenURL: tokenSrv.URL, ClientID: "id", ClientSecret: "secret"},
+	})
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(api.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if got := auth.Load(); got != "Bearer tok" {
+		t.Fatalf("expected oauth token to replace the static bearer, got %v", got)
+	}
+	if n := tokenCalls.Load(); n != 1 {
+		t.Fatalf("expected the token to be cached, got %d token requests", n)
+	}
+	if got := resource.Load(); got != api.URL {
+		t.Fatalf("expected resource %q, got %v", api.URL, got)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	d := nextBackoff(0)
+	if d != reconnectMinDelay {
+		t.Fatalf("expected first delay %s, got %s", reconnectMinDelay, d)
+	}
+	for i := 0; i < 20; i++ {
+		next := nextBackoff(d)
+		if next < d || next > reconnectMaxDelay {
+			t.Fatalf("backoff went from %s to %s", d, next)
+		}
+		d = next
+	}
+	if d != reconnectMaxDelay {
+		t.Fatalf("expected backoff to cap at %s, got %s", reconnectMaxDelay, d)
+	}
+}
+
+func addTestTool(s *mcppkg.Server, name string) {
+	mcppkg.AddTool(s, &mcppkg.Tool{Name: name, Description: name}, func(context.Context, *mcppkg.CallToolRequest, struct{}) (*mcppkg.CallToolResult, any, error) {
+		return &mcppkg.CallToolResult{}, nil, nil
+	})
+}
+
+func waitForToolNames(t *testing.T, m *Manager, server string, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got := m.ToolNames(server)
+		slices.Sort(got)
+		if slices.Equal(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("tools for %s: got %v, want %v", server, got, want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestManager_HealthCheckReconnectsAndRefreshesTools(t *testing.T) {
+	srv := mcppkg.NewServer(&mcppkg.Implementation{Name: "test", Version: "v0"}, nil)
+	addTestTool(srv, "echo")
+	handler := mcppkg.NewStreamableHTTPHandler(func(*http.Request) *mcppkg.Server { return srv }, nil)
+	var down atomic.Bool
+	down.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	m := NewManager()
+	defer m.Close()
+	reg := tools.NewRegistry()
+	cfg := config.MCPServerConfig{Name: "remote", URL: ts.URL, Origin: ts.URL, HealthCheckSeconds: 1}
+
+	// The first connect fails but the server stays registered for retries.
+	if err := m.RegisterOne(context.Background(), reg, cfg); err == nil {
+		t.Fatalf("expected first connect to fail")
+	}
+	down.Store(false)
+	waitForToolNames(t, m, "remote", "remote_echo")
+
+	addTestTool(srv, "reverse")
+	waitForToolNames(t, m, "remote", "remote_echo", "remote_reverse")
+
+	down.Store(true)
+	waitForToolNames(t, m, "remote")
+	down.Store(false)
+	waitForToolNames(t, m, "remote", "remote_echo", "remote_reverse")
+
+	m.RemoveOne("remote", reg)
+	if len(reg.Schemas()) != 0 {
+		t.Fatalf("expected tools to be unregistered, got %v", tools.SchemaNames(reg))
+	}
+}
@@ -140,7 +140,7 @@ func (p *MCPServerPool) RegisterFromConfig(ctx context.Context, reg tools.Regist
 
 		// Skip remote HTTP servers without a bearer token — they likely require
 		// OAuth which is handled later by RefreshMCPServersOnStartup.
-		if srv.URL != "" && srv.BearerToken == "" {
+		if srv.URL != "" && srv.BearerToken == "" && srv.OAuth.TokenURL == "" {
 			log.Debug().Str("server", srv.Name).Msg("deferring_remote_mcp_server_without_token")
 			continue
 		}
@@ -153,7 +153,7 @@ func (p *MCPServerPool) RegisterFromConfig(ctx context.Context, reg tools.Regist
 
 		// Track tool names for this server
 		p.mu.Lock()
-		p.sharedToolNames[srv.Name] = p.shared.toolNames[srv.Name]
+		p.sharedToolNames[srv.Name] = p.shared.ToolNames(srv.Name)
 		p.mu.Unlock()
 	}
 	return nil
@@ -196,7 +196,7 @@ func (p *MCPServerPool) RegisterPathDependentToolsForDiscovery(ctx context.Conte
 
 		// Track discovered tools for this server
 		p.mu.Lock()
-		if names, ok := discoveryMgr.toolNames[srv.Name]; ok {
+		if names := discoveryMgr.ToolNames(srv.Name); len(names) > 0 {
 			log.Info().Str("server", srv.Name).Int("tools", len(names)).Strs("toolNames", names).Msg("mcp_tools_discovered")
 		}
 		p.mu.Unlock()
@@ -251,7 +251,7 @@ func (p *MCPServerPool) EnsureUserSession(
 			continue
 		}
 		// Track registered tools
-		registeredTools = append(registeredTools, mgr.toolNames[srv.Name]...)
+		registeredTools = append(registeredTools, mgr.ToolNames(srv.Name)...)
 	}
 
 	p.perUser[userID] = &userMCPState{
@@ -0,0 +1,262 @@
+package mcpclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	mcppkg "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// promptURIScheme prefixes references to MCP prompts, written as
+// mcp://<server>/prompts/<name>, optionally followed by ?arg=value pairs
+// that are passed as prompt arguments.
+const promptURIScheme = "mcp://"
+
+// Resource describes a resource offered by a connected MCP server.
+type Resource struct {
+	Server      string `json:"server"`
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// ResourceContent is one part of a resource read. Binary parts carry Blob,
+// which is base64 encoded when marshalled.
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     []byte `json:"blob,omitempty"`
+}
+
+// Prompt describes a prompt offered by a connected MCP server. URI is the
+// reference workflows and specialists use to include it.
+type Prompt struct {
+	Server      string           `json:"server"`
+	Name        string           `json:"name"`
+	URI         string           `json:"uri"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument accepted by a Prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptURI returns the reference for the named prompt on server.
+func PromptURI(server, name string) string {
+	return promptURIScheme + server + "/prompts/" + name
+}
+
+// ParsePromptURI splits a prompt reference into its server, prompt name and
+// arguments. ok is false when s is not a prompt reference.
+func ParsePromptURI(s string) (server, name string, args map[string]string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(s), promptURIScheme)
+	if !found {
+		return "", "", nil, false
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+	server, name, found = strings.Cut(rest, "/prompts/")
+	if !found || server == "" || name == "" || strings.ContainsAny(server, "/ ") {
+		return "", "", nil, false
+	}
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return "", "", nil, false
+		}
+		args = make(map[string]string, len(values))
+		for k, v := range values {
+			if len(v) > 0 {
+				args[k] = v[0]
+			}
+		}
+	}
+	return server, name, args, true
+}
+
+// sessionSnapshot returns the connected sessions ordered by server name.
+func (m *Manager) sessionSnapshot() ([]string, map[string]*mcppkg.ClientSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.sessions))
+	sessions := make(map[string]*mcppkg.ClientSession, len(m.sessions))
+	for name, s := range m.sessions {
+		names = append(names, name)
+		sessions[name] = s
+	}
+	sort.Strings(names)
+	return names, sessions
+}
+
+func (m *Manager) session(server string) (*mcppkg.ClientSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[server]
+	if !ok {
+		return nil, fmt.Errorf("mcp server %q is not connected", server)
+	}
+	return s, nil
+}
+
+// serverCapabilities reports whether the session's server advertised
+// resources and prompts during initialization.
+func serverCapabilities(s *mcppkg.ClientSession) (resources, prompts bool) {
+	init := s.InitializeResult()
+	if init == nil || init.Capabilities == nil {
+		return false, false
+	}
+	return init.Capabilities.Resources != nil, init.Capabilities.Prompts != nil
+}
+
+// ListResources lists the resources of every connected server that offers
+// them. A server that fails to list is logged and skipped.
+func (m *Manager) ListResources(ctx context.Context) []Resource {
+	names, sessions := m.sessionSnapshot()
+	out := []Resource{}
+	for _, name := range names {
+		if ok, _ := serverCapabilities(sessions[name]); !ok {
+			continue
+		}
+		for r, err := range sessions[name].Resources(ctx, nil) {
+			if err != nil {
+				log.Warn().Err(err).Str("server", name).Msg("mcp_list_resources_failed")
+				break
+			}
+			out = append(out, Resource{
+				Server:      name,
+				URI:         r.URI,
+				Name:        r.Name,
+				Title:       r.Title,
+				Description: r.Description,
+				MIMEType:    r.MIMEType,
+				Size:        r.Size,
+			})
+		}
+	}
+	return out
+}
+
+// ReadResource reads uri from the named server.
+func (m *Manager) ReadResource(ctx context.Context, server, uri string) ([]ResourceContent, error) {
+	s, err := m.session(server)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.ReadResource(ctx, &mcppkg.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ResourceContent, 0, len(res.Contents))
+	for _, c := range res.Contents {
+		if c == nil {
+			continue
+		}
+		out = append(out, ResourceContent{URI: c.URI, MIMEType: c.MIMEType, Text: c.Text, Blob: c.Blob})
+	}
+	return out, nil
+}
+
+// ListPrompts lists the prompts of every connected server that offers them.
+// A server that fails to list is logged and skipped.
+func (m *Manager) ListPrompts(ctx context.Context) []Prompt {
+	names, sessions := m.sessionSnapshot()
+	out := []Prompt{}
+	for _, name := range names {
+		if _, ok := serverCapabilities(sessions[name]); !ok {
+			continue
+		}
+		for p, err := range sessions[name].Prompts(ctx, nil) {
+			if err != nil {
+				log.Warn().Err(err).Str("server", name).Msg("mcp_list_prompts_failed")
+				break
+			}
+			prompt := Prompt{
+				Server:      name,
+				Name:        p.Name,
+				URI:         PromptURI(name, p.Name),
+				Title:       p.Title,
+				Description: p.Description,
+			}
+			for _, a := range p.Arguments {
+				if a != nil {
+					prompt.Arguments = append(prompt.Arguments, PromptArgument{Name: a.Name, Description: a.Description, Required: a.Required})
+				}
+			}
+			out = append(out, prompt)
+		}
+	}
+	return out
+}
+
+// GetPrompt renders the named prompt with args and returns the text of its
+// messages, separated by blank lines.
+func (m *Manager) GetPrompt(ctx context.Context, server, name string, args map[string]string) (string, error) {
+	s, err := m.session(server)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.GetPrompt(ctx, &mcppkg.GetPromptParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", fmt.Errorf("mcp prompt %s/%s: %w", server, name, err)
+	}
+	parts := make([]string, 0, len(res.Messages))
+	for _, msg := range res.Messages {
+		if msg == nil {
+			continue
+		}
+		switch c := msg.Content.(type) {
+		case *mcppkg.TextContent:
+			parts = append(parts, c.Text)
+		case *mcppkg.EmbeddedResource:
+			if c.Resource != nil && c.Resource.Text != "" {
+				parts = append(parts, c.Resource.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// ResolvePrompt renders the prompt a mcp://<server>/prompts/<name> reference
+// points to.
+func (m *Manager) ResolvePrompt(ctx context.Context, ref string) (string, error) {
+	server, name, args, ok := ParsePromptURI(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid mcp prompt reference %q", ref)
+	}
+	return m.GetPrompt(ctx, server, name, args)
+}
+
+// ExpandPromptRefs replaces every line of text that holds only a prompt
+// reference with the rendered prompt. References that fail to resolve are
+// logged and left in place.
+func (m *Manager) ExpandPromptRefs(ctx context.Context, text string) string {
+	if !strings.Contains(text, promptURIScheme) {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if _, _, _, ok := ParsePromptURI(line); !ok {
+			continue
+		}
+		rendered, err := m.ResolvePrompt(ctx, line)
+		if err != nil {
+			log.Warn().Err(err).Str("ref", strings.TrimSpace(line)).Msg("mcp_prompt_ref_unresolved")
+			continue
+		}
+		lines[i] = rendered
+	}
+	return strings.Join(lines, "\n")
+}
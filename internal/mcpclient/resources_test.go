@@ -0,0 +1,77 @@
+package mcpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/tools"
+
+	mcppkg "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParsePromptURI(t *testing.T) {
+	server, name, args, ok := ParsePromptURI(" mcp://docs/prompts/review?lang=go&tone=brief ")
+	if !ok || server != "docs" || name != "review" || args["lang"] != "go" || args["tone"] != "brief" {
+		t.Fatalf("unexpected parse: %q %q %v %v", server, name, args, ok)
+	}
+	if got := PromptURI("docs", "review"); got != "mcp://docs/prompts/review" {
+		t.Fatalf("unexpected uri %q", got)
+	}
+	for _, bad := range []string{"", "docs/prompts/review", "mcp://docs/review", "mcp:///prompts/x", "mcp://docs/prompts/", "see mcp://docs/prompts/x"} {
+		if _, _, _, ok := ParsePromptURI(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestManager_ResourcesAndPrompts(t *testing.T) {
+	srv := mcppkg.NewServer(&mcppkg.Implementation{Name: "test", Version: "v0"}, nil)
+	srv.AddResource(&mcppkg.Resource{URI: "file:///guide.md", Name: "guide", MIMEType: "text/markdown"}, func(_ context.Context, req *mcppkg.ReadResourceRequest) (*mcppkg.ReadResourceResult, error) {
+		return &mcppkg.ReadResourceResult{Contents: []*mcppkg.ResourceContents{{URI: req.Params.URI, MIMEType: "text/markdown", Text: "# Guide"}}}, nil
+	})
+	srv.AddPrompt(&mcppkg.Prompt{Name: "style", Arguments: []*mcppkg.PromptArgument{{Name: "lang", Required: true}}}, func(_ context.Context, req *mcppkg.GetPromptRequest) (*mcppkg.GetPromptResult, error) {
+		return &mcppkg.GetPromptResult{Messages: []*mcppkg.PromptMessage{
+			{Role: "user", Content: &mcppkg.TextContent{Text: "Write idiomatic " + req.Params.Arguments["lang"] + "."}},
+		}}, nil
+	})
+	handler := mcppkg.NewStreamableHTTPHandler(func(*http.Request) *mcppkg.Server { return srv }, nil)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	m := NewManager()
+	defer m.Close()
+	ctx := context.Background()
+	if err := m.RegisterOne(ctx, tools.NewRegistry(), config.MCPServerConfig{Name: "docs", URL: ts.URL, Origin: ts.URL}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	resources := m.ListResources(ctx)
+	if len(resources) != 1 || resources[0].Server != "docs" || resources[0].URI != "file:///guide.md" {
+		t.Fatalf("unexpected resources %+v", resources)
+	}
+	contents, err := m.ReadResource(ctx, "docs", "file:///guide.md")
+	if err != nil || len(contents) != 1 || contents[0].Text != "# Guide" {
+		t.Fatalf("ReadResource: %+v (%v)", contents, err)
+	}
+	if _, err := m.ReadResource(ctx, "missing", "file:///guide.md"); err == nil {
+		t.Fatalf("expected error for unknown server")
+	}
+
+	prompts := m.ListPrompts(ctx)
+	if len(prompts) != 1 || prompts[0].URI != "mcp://docs/prompts/style" || len(prompts[0].Arguments) != 1 || !prompts[0].Arguments[0].Required {
+		t.Fatalf("unexpected prompts %+v", prompts)
+	}
+	text, err := m.ResolvePrompt(ctx, "mcp://docs/prompts/style?lang=Go")
+	if err != nil || text != "Write idiomatic Go." {
+		t.Fatalf("ResolvePrompt: %q (%v)", text, err)
+	}
+
+	expanded := m.ExpandPromptRefs(ctx, "You review code.\nmcp://docs/prompts/style?lang=Go\nmcp://docs/prompts/unknown")
+	want := "You review code.\nWrite idiomatic Go.\nmcp://docs/prompts/unknown"
+	if expanded != want {
+		t.Fatalf("ExpandPromptRefs:\n got %q\nwant %q", expanded, want)
+	}
+}
@@ -0,0 +1,65 @@
+package mcpclient
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"manifold/internal/config"
+)
+
+// localHost is the destination checked for env values of stdio servers,
+// which run on this machine.
+const localHost = "localhost"
+
+var secretResolver atomic.Pointer[func(v string, userID int64, host string) string]
+
+// SetSecretResolver installs fn to expand {{secret:NAME}} references in
+// server env values, headers, bearer tokens and OAuth client secrets when a
+// transport is built. fn receives the server's owner and the host each value
+// is sent to, and must only expand secrets scoped to both. A nil fn removes
+// it.
+func SetSecretResolver(fn func(v string, userID int64, host string) string) {
+	if fn == nil {
+		secretResolver.Store(nil)
+		return
+	}
+	secretResolver.Store(&fn)
+}
+
+// resolveSecrets returns a copy of srv with secret references expanded.
+// Headers and the bearer token go to the server URL's host, the OAuth client
+// secret to the token URL's host, and env values to localhost. The stored
+// config keeps the references so values never round-trip to clients.
+func resolveSecrets(srv config.MCPServerConfig) config.MCPServerConfig {
+	fn := secretResolver.Load()
+	if fn == nil {
+		return srv
+	}
+	resolve := *fn
+	serverHost := urlHost(srv.URL)
+	if len(srv.Env) > 0 {
+		srv.Env = maps.Clone(srv.Env)
+		for k, v := range srv.Env {
+			srv.Env[k] = resolve(v, srv.OwnerID, localHost)
+		}
+	}
+	if len(srv.Headers) > 0 {
+		srv.Headers = maps.Clone(srv.Headers)
+		for k, v := range srv.Headers {
+			srv.Headers[k] = resolve(v, srv.OwnerID, serverHost)
+		}
+	}
+	srv.BearerToken = resolve(srv.BearerToken, srv.OwnerID, serverHost)
+	srv.OAuth.ClientSecret = resolve(srv.OAuth.ClientSecret, srv.OwnerID, urlHost(srv.OAuth.TokenURL))
+	return srv
+}
+
+func urlHost(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
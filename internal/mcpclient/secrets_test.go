@@ -0,0 +1,43 @@
+package mcpclient
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	var seen []string
+	SetSecretResolver(func(v string, userID int64, host string) string {
+		seen = append(seen, fmt.Sprintf("%d@%s", userID, host))
+		if userID != 7 || host == "attacker.example" {
+			return v
+		}
+		return strings.ReplaceAll(v, "{{secret:TOKEN}}", "t0k")
+	})
+	defer SetSecretResolver(nil)
+
+	srv := config.MCPServerConfig{
+		URL:         "https://mcp.example.com/mcp",
+		Env:         map[string]string{"API_KEY": "{{secret:TOKEN}}"},
+		Headers:     map[string]string{"X-Key": "k {{secret:TOKEN}}"},
+		BearerToken: "{{secret:TOKEN}}",
+		OAuth:       config.MCPOAuthConfig{TokenURL: "https://attacker.example/token", ClientSecret: "{{secret:TOKEN}}"},
+		OwnerID:     7,
+	}
+	got := resolveSecrets(srv)
+	if got.Env["API_KEY"] != "t0k" || got.Headers["X-Key"] != "k t0k" || got.BearerToken != "t0k" {
+		t.Fatalf("unexpected resolved config %+v", got)
+	}
+	if got.OAuth.ClientSecret != "{{secret:TOKEN}}" {
+		t.Fatalf("client secret resolved for a disallowed token host: %q", got.OAuth.ClientSecret)
+	}
+	if want := "7@localhost 7@mcp.example.com 7@mcp.example.com 7@attacker.example"; strings.Join(seen, " ") != want {
+		t.Fatalf("resolver calls = %v, want %s", seen, want)
+	}
+	if srv.Env["API_KEY"] != "{{secret:TOKEN}}" || srv.Headers["X-Key"] != "k {{secret:TOKEN}}" {
+		t.Fatalf("stored config was modified: %+v", srv)
+	}
+}
@@ -0,0 +1,228 @@
+// Package notify posts run lifecycle events to configured webhook and
+// Slack-compatible endpoints.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+)
+
+// Event types delivered to targets.
+const (
+	EventRunStarted   = "run_started"
+	EventToolError    = "tool_error"
+	EventRunCompleted = "run_completed"
+	EventRunFailed    = "run_failed"
+)
+
+const (
+	formatJSON  = "json"
+	formatSlack = "slack"
+
+	defaultSlackTemplate = "{{.Type}} run {{.RunID}}{{if .Schedule}} ({{.Schedule}}){{end}}{{if .Tool}} tool={{.Tool}}{{end}}{{if .Error}}: {{.Error}}{{end}}"
+	maxTextChars         = 2000
+)
+
+// Event describes one run lifecycle change.
+type Event struct {
+	Type  string `json:"type"`
+	RunID string `json:"runId"`
+	// UserID owns the run; 0 means the system user.
+	UserID int64 `json:"userId"`
+	// Schedule identifies the trigger that started the run, such as
+	// "hook:<id>" or "workflow:<id>". Empty for interactive runs.
+	Schedule string    `json:"schedule,omitempty"`
+	Prompt   string    `json:"prompt,omitempty"`
+	Tool     string    `json:"tool,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Result   string    `json:"result,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+type target struct {
+	cfg     config.NotificationTarget
+	tmpl    *template.Template
+	timeout time.Duration
+}
+
+// Notifier fans events out to matching targets. Deliveries run in the
+// background and are retried with exponential backoff. A nil Notifier drops
+// every event.
+type Notifier struct {
+	targets []*target
+	client  *http.Client
+	backoff time.Duration
+	wg      sync.WaitGroup
+}
+
+// New validates cfg and parses target templates. It returns nil when no
+// targets are configured.
+func New(cfg config.NotificationsConfig, client *http.Client) (*Notifier, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, nil
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	n := &Notifier{client: client, backoff: time.Second}
+	for i, t := range cfg.Targets {
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("target %d", i)
+		}
+		if strings.TrimSpace(t.URL) == "" {
+			return nil, fmt.Errorf("notification %s: url required", name)
+		}
+		format := strings.ToLower(strings.TrimSpace(t.Format))
+		if format == "" {
+			format = formatJSON
+		}
+		if format != formatJSON && format != formatSlack {
+			return nil, fmt.Errorf("notification %s: unknown format %q", name, t.Format)
+		}
+		t.Name, t.Format = name, format
+		text := t.Template
+		if text == "" && format == formatSlack {
+			text = defaultSlackTemplate
+		}
+		tg := &target{cfg: t, timeout: time.Duration(t.TimeoutSeconds) * time.Second}
+		if tg.timeout <= 0 {
+			tg.timeout = 10 * time.Second
+		}
+		if text != "" {
+			tmpl, err := template.New(name).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("notification %s template: %w", name, err)
+			}
+			tg.tmpl = tmpl
+		}
+		n.targets = append(n.targets, tg)
+	}
+	return n, nil
+}
+
+// Notify delivers ev to every matching target in the background.
+func (n *Notifier) Notify(ev Event) {
+	if n == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	for _, t := range n.targets {
+		if !t.matches(ev) {
+			continue
+		}
+		n.wg.Add(1)
+		go func(t *target) {
+			defer n.wg.Done()
+			if err := n.deliver(context.Background(), t, ev); err != nil {
+				log.Warn().Err(err).Str("target", t.cfg.Name).Str("event", ev.Type).Str("run_id", ev.RunID).Msg("notification_failed")
+			}
+		}(t)
+	}
+}
+
+// Wait blocks until in-flight deliveries finish.
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+func (t *target) matches(ev Event) bool {
+	if len(t.cfg.Events) > 0 && !slices.Contains(t.cfg.Events, ev.Type) {
+		return false
+	}
+	if len(t.cfg.Users) > 0 && !slices.Contains(t.cfg.Users, ev.UserID) {
+		return false
+	}
+	if len(t.cfg.Schedules) > 0 && !slices.Contains(t.cfg.Schedules, ev.Schedule) {
+		return false
+	}
+	return true
+}
+
+// body renders the request body for ev. JSON targets send the rendered
+// template verbatim, or the event itself without one; Slack targets send the
+// rendered text as {"text": ...}.
+func (t *target) body(ev Event) ([]byte, error) {
+	if t.tmpl == nil {
+		return json.Marshal(ev)
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, ev); err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+	if t.cfg.Format == formatSlack {
+		text := buf.String()
+		if len(text) > maxTextChars {
+			text = strings.ToValidUTF8(text[:maxTextChars], "") + "…"
+		}
+		return json.Marshal(map[string]string{"text": text})
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *Notifier) deliver(ctx context.Context, t *target, ev Event) error {
+	body, err := t.body(ev)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt <= t.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(n.backoff << (attempt - 1)):
+			}
+		}
+		retry, err := n.post(ctx, t, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// post sends one request and reports whether a failure may be retried.
+func (n *Notifier) post(ctx context.Context, t *target, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("notification endpoint returned %s", resp.Status)
+}
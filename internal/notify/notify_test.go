@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *recorder) handler(status func(n int) int) http.HandlerFunc {
+	var calls atomic.Int32
+	return func(w http.ResponseWriter, req *http.Request) {
+		n := int(calls.Add(1))
+		b, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(b))
+		r.mu.Unlock()
+		w.WriteHeader(status(n))
+	}
+}
+
+func TestNotifierFiltersAndFormats(t *testing.T) {
+	var slack, hook recorder
+	ok := func(int) int { return http.StatusOK }
+	slackSrv := httptest.NewServer(slack.handler(ok))
+	defer slackSrv.Close()
+	hookSrv := httptest.NewServer(hook.handler(ok))
+	defer hookSrv.Close()
+
+	n, err := New(config.NotificationsConfig{Targets: []config.NotificationTarget{
+		{Name: "slack", URL: slackSrv.URL, Format: "slack", Template: "{{.Type}} {{.RunID}}: {{.Error}}", Events: []string{EventRunFailed}},
+		{Name: "ci", URL: hookSrv.URL, Users: []int64{7}, Schedules: []string{"hook:ci"}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	n.Notify(Event{Type: EventRunStarted, RunID: "run_1", UserID: 7, Schedule: "hook:ci"})
+	n.Notify(Event{Type: EventRunFailed, RunID: "run_2", UserID: 3, Error: "boom"})
+	n.Notify(Event{Type: EventRunCompleted, RunID: "run_3", UserID: 7})
+	n.Wait()
+
+	if len(slack.bodies) != 1 || slack.bodies[0] != `{"text":"run_failed run_2: boom"}` {
+		t.Fatalf("unexpected slack deliveries: %v", slack.bodies)
+	}
+	if len(hook.bodies) != 1 {
+		t.Fatalf("expected one matching json delivery, got %v", hook.bodies)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(hook.bodies[0]), &ev); err != nil || ev.RunID != "run_1" || ev.Type != EventRunStarted {
+		t.Fatalf("unexpected json event %q (%v)", hook.bodies[0], err)
+	}
+}
+
+func TestNotifierRetriesServerErrors(t *testing.T) {
+	var rec recorder
+	srv := httptest.NewServer(rec.handler(func(n int) int {
+		if n < 3 {
+			return http.StatusBadGateway
+		}
+		return http.StatusNoContent
+	}))
+	defer srv.Close()
+
+	n, err := New(config.NotificationsConfig{Targets: []config.NotificationTarget{{URL: srv.URL, MaxRetries: 3}}}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	n.backoff = time.Millisecond
+	if err := n.deliver(t.Context(), n.targets[0], Event{Type: EventRunCompleted}); err != nil {
+		t.Fatalf("expected delivery after retries, got %v", err)
+	}
+	if len(rec.bodies) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(rec.bodies))
+	}
+
+	var rejected recorder
+	badSrv := httptest.NewServer(rejected.handler(func(int) int { return http.StatusBadRequest }))
+	defer badSrv.Close()
+	n.targets[0].cfg.URL = badSrv.URL
+	if err := n.deliver(t.Context(), n.targets[0], Event{Type: EventRunCompleted}); err == nil || len(rejected.bodies) != 1 {
+		t.Fatalf("expected a single rejected attempt, got %v after %d", err, len(rejected.bodies))
+	}
+}
+
+func TestNewValidatesTargets(t *testing.T) {
+	if n, err := New(config.NotificationsConfig{}, nil); n != nil || err != nil {
+		t.Fatalf("expected nil notifier without targets, got %v %v", n, err)
+	}
+	for _, tg := range []config.NotificationTarget{
+		{Name: "no-url"},
+		{URL: "http://x", Format: "xml"},
+		{URL: "http://x", Template: "{{.Type"},
+	} {
+		if _, err := New(config.NotificationsConfig{Targets: []config.NotificationTarget{tg}}, nil); err == nil {
+			t.Fatalf("expected error for %+v", tg)
+		}
+	}
+	// A nil notifier is safe to use.
+	var n *Notifier
+	n.Notify(Event{Type: EventRunStarted})
+	n.Wait()
+}
@@ -80,6 +80,7 @@ func InitOTel(ctx context.Context, obs config.ObsConfig) (func(context.Context)
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(trExp),
 		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(runProcessor),
 	)
 
 	mExp, err := otlpmetrichttp.New(ctx, metricExporterOptions(obs.OTLP)...)
@@ -92,7 +93,10 @@ func InitOTel(ctx context.Context, obs config.ObsConfig) (func(context.Context)
 		metric.WithResource(res),
 	)
 
+	runTracingMu.Lock()
 	otel.SetTracerProvider(tp)
+	runTracingTP = tp
+	runTracingMu.Unlock()
 	otel.SetMeterProvider(mp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
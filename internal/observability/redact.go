@@ -3,12 +3,25 @@ package observability
 import (
 	"encoding/json"
 	"strings"
+	"sync/atomic"
 )
 
 var sensitiveKeys = []string{
 	"api_key", "apikey", "apiKey", "x-api-key", "authorization", "auth", "token", "access_token", "refresh_token", "password", "secret", "bearer",
 }
 
+var stringScrubber atomic.Pointer[func(string) string]
+
+// SetStringScrubber installs fn to rewrite every string value passed through
+// RedactJSON, e.g. to mask PII in logged payloads. A nil fn removes it.
+func SetStringScrubber(fn func(string) string) {
+	if fn == nil {
+		stringScrubber.Store(nil)
+		return
+	}
+	stringScrubber.Store(&fn)
+}
+
 // RedactJSON takes a JSON payload and redacts sensitive values based on common key names.
 func RedactJSON(raw json.RawMessage) json.RawMessage {
 	if len(raw) == 0 {
@@ -44,6 +57,11 @@ func redactValue(v any) any {
 			out[i] = redactValue(val[i])
 		}
 		return out
+	case string:
+		if fn := stringScrubber.Load(); fn != nil {
+			return (*fn)(val)
+		}
+		return val
 	default:
 		return v
 	}
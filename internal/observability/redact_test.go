@@ -59,3 +59,12 @@ func TestRedactJSON_EmptyAndInvalid(t *testing.T) {
 		t.Errorf("expected original bytes for invalid json, got %s", string(res))
 	}
 }
+
+func TestRedactJSON_StringScrubber(t *testing.T) {
+	SetStringScrubber(func(s string) string { return "x" + s })
+	defer SetStringScrubber(nil)
+	out := RedactJSON(json.RawMessage(`{"note":"a","n":1,"list":["b"]}`))
+	if string(out) != `{"list":["xb"],"n":1,"note":"xa"}` {
+		t.Fatalf("unexpected scrubbed payload: %s", out)
+	}
+}
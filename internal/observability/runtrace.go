@@ -0,0 +1,271 @@
+package observability
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys that tie a span to the agent run it belongs to.
+const (
+	RunIDAttr     = "run.id"
+	SessionIDAttr = "session.id"
+	UserIDAttr    = "enduser.id"
+)
+
+const (
+	maxTracedRuns   = 500
+	maxSpansPerRun  = 2000
+	maxSpanAttrSize = 2048
+)
+
+// RunInfo identifies the agent run a context belongs to.
+type RunInfo struct {
+	RunID     string
+	SessionID string
+	UserID    int64
+}
+
+type runInfoKey struct{}
+
+// WithRun returns a derived context whose spans are tagged with the run's
+// IDs and collected for RunTrace.
+func WithRun(ctx context.Context, info RunInfo) context.Context {
+	if info.RunID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, runInfoKey{}, info)
+}
+
+// RunFromContext returns the run set by WithRun.
+func RunFromContext(ctx context.Context) (RunInfo, bool) {
+	if ctx == nil {
+		return RunInfo{}, false
+	}
+	info, ok := ctx.Value(runInfoKey{}).(RunInfo)
+	return info, ok
+}
+
+// Attributes returns the span attributes identifying the run.
+func (ri RunInfo) Attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String(RunIDAttr, ri.RunID)}
+	if ri.SessionID != "" {
+		attrs = append(attrs, attribute.String(SessionIDAttr, ri.SessionID))
+	}
+	if ri.UserID != 0 {
+		attrs = append(attrs, attribute.String(UserIDAttr, strconv.FormatInt(ri.UserID, 10)))
+	}
+	return attrs
+}
+
+// RunSpan is a completed span recorded for a run.
+type RunSpan struct {
+	TraceID        string         `json:"traceId"`
+	SpanID         string         `json:"spanId"`
+	ParentSpanID   string         `json:"parentSpanId,omitempty"`
+	Name           string         `json:"name"`
+	Scope          string         `json:"scope,omitempty"`
+	Kind           string         `json:"kind"`
+	Status         string         `json:"status"`
+	StatusMessage  string         `json:"statusMessage,omitempty"`
+	StartTime      time.Time      `json:"startTime"`
+	EndTime        time.Time      `json:"endTime"`
+	DurationMillis int64          `json:"durationMillis"`
+	Attributes     map[string]any `json:"attributes,omitempty"`
+	Children       []*RunSpan     `json:"children,omitempty"`
+}
+
+type runSpans struct {
+	info  RunInfo
+	spans []RunSpan
+	// dropped counts spans beyond maxSpansPerRun.
+	dropped int
+}
+
+// runSpanProcessor stamps the run attributes on every span started within
+// a run, including spans whose context lost the run but not the trace, and
+// keeps the finished spans of recent runs in memory.
+type runSpanProcessor struct {
+	mu     sync.Mutex
+	traces map[trace.TraceID]RunInfo
+	runs   map[string]*runSpans
+	order  []string
+}
+
+var (
+	runProcessor = &runSpanProcessor{
+		traces: map[trace.TraceID]RunInfo{},
+		runs:   map[string]*runSpans{},
+	}
+	runTracingMu sync.Mutex
+	runTracingTP *sdktrace.TracerProvider
+)
+
+// EnableRunTracing registers the run span processor on the global tracer
+// provider, installing an SDK provider when none is configured so runs can
+// be traced without an OTLP endpoint. InitOTel registers it on the provider
+// it creates.
+func EnableRunTracing() {
+	runTracingMu.Lock()
+	defer runTracingMu.Unlock()
+	tp, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		tp = sdktrace.NewTracerProvider()
+		otel.SetTracerProvider(tp)
+	}
+	if tp == runTracingTP {
+		return
+	}
+	tp.RegisterSpanProcessor(runProcessor)
+	runTracingTP = tp
+}
+
+func (p *runSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	traceID := s.SpanContext().TraceID()
+	info, ok := RunFromContext(parent)
+	p.mu.Lock()
+	if ok {
+		if _, seen := p.runs[info.RunID]; !seen {
+			p.addRunLocked(info)
+		}
+		p.traces[traceID] = info
+	} else {
+		info, ok = p.traces[traceID]
+	}
+	p.mu.Unlock()
+	if ok {
+		s.SetAttributes(info.Attributes()...)
+	}
+}
+
+// addRunLocked starts collecting spans for info, evicting the oldest run
+// once maxTracedRuns are kept. p.mu must be held.
+func (p *runSpanProcessor) addRunLocked(info RunInfo) {
+	p.runs[info.RunID] = &runSpans{info: info}
+	p.order = append(p.order, info.RunID)
+	for len(p.order) > maxTracedRuns {
+		oldest := p.order[0]
+		p.order = p.order[1:]
+		delete(p.runs, oldest)
+		for id, ri := range p.traces {
+			if ri.RunID == oldest {
+				delete(p.traces, id)
+			}
+		}
+	}
+}
+
+func (p *runSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	var runID string
+	for _, kv := range s.Attributes() {
+		if kv.Key == RunIDAttr {
+			runID = kv.Value.AsString()
+			break
+		}
+	}
+	if runID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rs, ok := p.runs[runID]
+	if !ok {
+		return
+	}
+	if len(rs.spans) >= maxSpansPerRun {
+		rs.dropped++
+		return
+	}
+	rs.spans = append(rs.spans, snapshotSpan(s))
+}
+
+func (p *runSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *runSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+func snapshotSpan(s sdktrace.ReadOnlySpan) RunSpan {
+	out := RunSpan{
+		TraceID:        s.SpanContext().TraceID().String(),
+		SpanID:         s.SpanContext().SpanID().String(),
+		Name:           s.Name(),
+		Scope:          s.InstrumentationScope().Name,
+		Kind:           s.SpanKind().String(),
+		Status:         "ok",
+		StartTime:      s.StartTime(),
+		EndTime:        s.EndTime(),
+		DurationMillis: s.EndTime().Sub(s.StartTime()).Milliseconds(),
+	}
+	if parent := s.Parent(); parent.HasSpanID() {
+		out.ParentSpanID = parent.SpanID().String()
+	}
+	if st := s.Status(); st.Code == codes.Error {
+		out.Status = "error"
+		out.StatusMessage = st.Description
+	}
+	if attrs := s.Attributes(); len(attrs) > 0 {
+		out.Attributes = make(map[string]any, len(attrs))
+		for _, kv := range attrs {
+			v := kv.Value.AsInterface()
+			if str, ok := v.(string); ok && len(str) > maxSpanAttrSize {
+				v = str[:maxSpanAttrSize] + "…"
+			}
+			out.Attributes[string(kv.Key)] = v
+		}
+	}
+	return out
+}
+
+// RunTrace returns the finished spans of runID as a tree: spans whose
+// parent was not recorded are roots. Roots and children are ordered by
+// start time. ok is false when no spans are kept for the run; dropped
+// counts spans left out because the run hit the per-run limit.
+func RunTrace(runID string) (info RunInfo, roots []*RunSpan, dropped int, ok bool) {
+	runProcessor.mu.Lock()
+	rs, found := runProcessor.runs[runID]
+	if !found || len(rs.spans) == 0 {
+		runProcessor.mu.Unlock()
+		return RunInfo{}, nil, 0, false
+	}
+	info, dropped = rs.info, rs.dropped
+	spans := make([]RunSpan, len(rs.spans))
+	copy(spans, rs.spans)
+	runProcessor.mu.Unlock()
+	return info, BuildSpanTree(spans), dropped, true
+}
+
+// BuildSpanTree links spans to their parents by span ID.
+func BuildSpanTree(spans []RunSpan) []*RunSpan {
+	nodes := make(map[string]*RunSpan, len(spans))
+	for i := range spans {
+		spans[i].Children = nil
+		nodes[spans[i].SpanID] = &spans[i]
+	}
+	var roots []*RunSpan
+	for i := range spans {
+		s := &spans[i]
+		if parent, ok := nodes[s.ParentSpanID]; ok && s.ParentSpanID != "" && parent != s {
+			parent.Children = append(parent.Children, s)
+			continue
+		}
+		roots = append(roots, s)
+	}
+	sortSpans(roots)
+	for _, s := range nodes {
+		sortSpans(s.Children)
+	}
+	return roots
+}
+
+func sortSpans(spans []*RunSpan) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+}
@@ -0,0 +1,53 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRunSpanProcessor(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(runProcessor))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	tracer := tp.Tracer("test")
+
+	ctx := WithRun(context.Background(), RunInfo{RunID: "run-trace-1", SessionID: "s1", UserID: 42})
+	ctx, root := tracer.Start(ctx, "agent.run")
+	_, llmSpan := tracer.Start(ctx, "chat")
+	llmSpan.End()
+
+	// A background context that only keeps the span context still belongs
+	// to the run.
+	bg := trace.ContextWithSpanContext(context.Background(), root.SpanContext())
+	_, tool := tracer.Start(bg, "tool run_cli")
+	tool.RecordError(errors.New("exit 1"))
+	tool.SetStatus(codes.Error, "exit 1")
+	tool.End()
+
+	_, unrelated := tracer.Start(context.Background(), "unrelated")
+	unrelated.End()
+	root.End()
+
+	info, roots, dropped, ok := RunTrace("run-trace-1")
+	if !ok || dropped != 0 || info.SessionID != "s1" || info.UserID != 42 {
+		t.Fatalf("RunTrace = %+v ok=%v dropped=%d", info, ok, dropped)
+	}
+	if len(roots) != 1 || roots[0].Name != "agent.run" || len(roots[0].Children) != 2 {
+		t.Fatalf("unexpected tree: %+v", roots)
+	}
+	for _, child := range roots[0].Children {
+		if child.Attributes[RunIDAttr] != "run-trace-1" || child.Attributes[SessionIDAttr] != "s1" || child.Attributes[UserIDAttr] != "42" {
+			t.Fatalf("%s attributes = %v", child.Name, child.Attributes)
+		}
+	}
+	if c := roots[0].Children[1]; c.Name != "tool run_cli" || c.Status != "error" || c.StatusMessage != "exit 1" {
+		t.Fatalf("tool span = %+v", c)
+	}
+	if _, _, _, ok := RunTrace("missing"); ok {
+		t.Fatal("expected no trace for an unknown run")
+	}
+}
@@ -0,0 +1,344 @@
+package databases
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"manifold/internal/config"
+	"manifold/internal/observability"
+	"manifold/internal/persistence"
+)
+
+// encryptedPrefix marks ciphertext values: enc:v1:<keyID>:<base64(nonce|sealed)>.
+const encryptedPrefix = "enc:v1:"
+
+// undecryptablePlaceholder replaces content that cannot be decrypted (for
+// example after a master key was removed from config).
+const undecryptablePlaceholder = "[encrypted content unavailable]"
+
+func isEncryptedValue(s string) bool { return strings.HasPrefix(s, encryptedPrefix) }
+
+// chatCipher seals chat content with AES-GCM under per-user data keys derived
+// from a master key via HKDF. The session ID is bound as additional data so
+// ciphertext cannot be replayed into another user's or session's rows.
+type chatCipher struct {
+	masters  map[string][]byte
+	activeID string
+	aeads    sync.Map // keyID|userID -> cipher.AEAD
+}
+
+func newChatCipher(cfg config.ChatEncryptionConfig) (*chatCipher, error) {
+	c := &chatCipher{masters: map[string][]byte{}, activeID: strings.TrimSpace(cfg.ActiveKeyID)}
+	for id, encoded := range cfg.Keys {
+		id = strings.TrimSpace(id)
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("chat encryption: invalid key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("chat encryption: key %s: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("chat encryption: key %s must be 32 bytes, got %d", id, len(key))
+		}
+		c.masters[id] = key
+	}
+	if c.activeID == "" && len(c.masters) == 1 {
+		for id := range c.masters {
+			c.activeID = id
+		}
+	}
+	if _, ok := c.masters[c.activeID]; !ok {
+		return nil, fmt.Errorf("chat encryption: active key %q is not configured", c.activeID)
+	}
+	return c, nil
+}
+
+func (c *chatCipher) aead(keyID string, owner int64) (cipher.AEAD, error) {
+	cacheKey := keyID + "|" + strconv.FormatInt(owner, 10)
+	if v, ok := c.aeads.Load(cacheKey); ok {
+		return v.(cipher.AEAD), nil
+	}
+	master, ok := c.masters[keyID]
+	if !ok {
+		return nil, fmt.Errorf("chat encryption: unknown key %q", keyID)
+	}
+	dek, err := hkdf.Key(sha256.New, master, nil, "manifold chat data key v1 user:"+strconv.FormatInt(owner, 10), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c.aeads.Store(cacheKey, gcm)
+	return gcm, nil
+}
+
+func chatAAD(owner int64, sessionID string) []byte {
+	return []byte("manifold-chat|" + strconv.FormatInt(owner, 10) + "|" + sessionID)
+}
+
+// encrypt always seals plaintext, even text that happens to look like
+// ciphertext, so user content can never be stored in the clear.
+func (c *chatCipher) encrypt(owner int64, sessionID, plaintext string) (string, error) {
+	if plaintext == "" {
+		return plaintext, nil
+	}
+	gcm, err := c.aead(c.activeID, owner)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), chatAAD(owner, sessionID))
+	return encryptedPrefix + c.activeID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns plaintext for encrypted values and passes legacy plaintext
+// through unchanged.
+func (c *chatCipher) decrypt(owner int64, sessionID, value string) (string, error) {
+	if !isEncryptedValue(value) {
+		return value, nil
+	}
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", errors.New("chat encryption: malformed ciphertext")
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("chat encryption: %w", err)
+	}
+	gcm, err := c.aead(keyID, owner)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("chat encryption: ciphertext too short")
+	}
+	pt, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], chatAAD(owner, sessionID))
+	if err != nil {
+		return "", fmt.Errorf("chat encryption: %w", err)
+	}
+	return string(pt), nil
+}
+
+// encryptedChatStore wraps a ChatStore, encrypting message content, session
+// summaries, and previews before they reach the backend and decrypting them
+// on read with the session owner's data key.
+type encryptedChatStore struct {
+	inner  persistence.ChatStore
+	cipher *chatCipher
+}
+
+// NewEncryptedChatStore wraps inner with application-layer encryption.
+func NewEncryptedChatStore(inner persistence.ChatStore, cfg config.ChatEncryptionConfig) (persistence.ChatStore, error) {
+	c, err := newChatCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedChatStore{inner: inner, cipher: c}, nil
+}
+
+func ownerID(owner *int64) int64 {
+	if owner == nil {
+		return 0
+	}
+	return *owner
+}
+
+func (s *encryptedChatStore) open(ctx context.Context, owner *int64, sessionID, value string) string {
+	pt, err := s.cipher.decrypt(ownerID(owner), sessionID, value)
+	if err != nil {
+		observability.LoggerWithTrace(ctx).Warn().Err(err).Str("session", sessionID).Msg("chat_decrypt_failed")
+		return undecryptablePlaceholder
+	}
+	return pt
+}
+
+func (s *encryptedChatStore) openSession(ctx context.Context, sess persistence.ChatSession) persistence.ChatSession {
+	sess.Summary = s.open(ctx, sess.UserID, sess.ID, sess.Summary)
+	if isEncryptedValue(sess.LastMessagePreview) {
+		// Stores may recompute previews from raw (encrypted) message content,
+		// so the stored value is the full ciphertext; trim after decrypting.
+		sess.LastMessagePreview = snippetForPreview(s.open(ctx, sess.UserID, sess.ID, sess.LastMessagePreview))
+	}
+	return sess
+}
+
+func (s *encryptedChatStore) sessionOwner(ctx context.Context, userID *int64, sessionID string) (int64, error) {
+	sess, err := s.inner.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	return ownerID(sess.UserID), nil
+}
+
+func (s *encryptedChatStore) Init(ctx context.Context) error { return s.inner.Init(ctx) }
+
+func (s *encryptedChatStore) Close() { closeIfPossible(s.inner) }
+
+func (s *encryptedChatStore) EnsureSession(ctx context.Context, userID *int64, id string, name string) (persistence.ChatSession, error) {
+	sess, err := s.inner.EnsureSession(ctx, userID, id, name)
+	if err != nil {
+		return sess, err
+	}
+	return s.openSession(ctx, sess), nil
+}
+
+func (s *encryptedChatStore) ListSessions(ctx context.Context, userID *int64) ([]persistence.ChatSession, error) {
+	list, err := s.inner.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i] = s.openSession(ctx, list[i])
+	}
+	return list, nil
+}
+
+func (s *encryptedChatStore) GetSession(ctx context.Context, userID *int64, id string) (persistence.ChatSession, error) {
+	sess, err := s.inner.GetSession(ctx, userID, id)
+	if err != nil {
+		return sess, err
+	}
+	return s.openSession(ctx, sess), nil
+}
+
+func (s *encryptedChatStore) CreateSession(ctx context.Context, userID *int64, name string) (persistence.ChatSession, error) {
+	sess, err := s.inner.CreateSession(ctx, userID, name)
+	if err != nil {
+		return sess, err
+	}
+	return s.openSession(ctx, sess), nil
+}
+
+func (s *encryptedChatStore) RenameSession(ctx context.Context, userID *int64, id, name string) (persistence.ChatSession, error) {
+	sess, err := s.inner.RenameSession(ctx, userID, id, name)
+	if err != nil {
+		return sess, err
+	}
+	return s.openSession(ctx, sess), nil
+}
+
+func (s *encryptedChatStore) DeleteSession(ctx context.Context, userID *int64, id string) error {
+	return s.inner.DeleteSession(ctx, userID, id)
+}
+
+func (s *encryptedChatStore) ListMessages(ctx context.Context, userID *int64, sessionID string, limit int) ([]persistence.ChatMessage, error) {
+	msgs, err := s.inner.ListMessages(ctx, userID, sessionID, limit)
+	if err != nil || len(msgs) == 0 {
+		return msgs, err
+	}
+	owner, err := s.sessionOwner(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		msgs[i].Content = s.open(ctx, &owner, sessionID, msgs[i].Content)
+	}
+	return msgs, nil
+}
+
+func (s *encryptedChatStore) DeleteMessage(ctx context.Context, userID *int64, sessionID string, messageID string) error {
+	return s.inner.DeleteMessage(ctx, userID, sessionID, messageID)
+}
+
+func (s *encryptedChatStore) DeleteMessagesAfter(ctx context.Context, userID *int64, sessionID string, messageID string, inclusive bool) error {
+	return s.inner.DeleteMessagesAfter(ctx, userID, sessionID, messageID, inclusive)
+}
+
+// DeleteMessageWithRelated forwards to the backend's atomic delete when
+// available so the wrapper keeps the same capabilities as the stores it wraps.
+func (s *encryptedChatStore) DeleteMessageWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, relatedMessageIDs []string, resetSummary bool) error {
+	type atomicDelete interface {
+		DeleteMessageWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, relatedMessageIDs []string, resetSummary bool) error
+	}
+	if inner, ok := s.inner.(atomicDelete); ok {
+		return inner.DeleteMessageWithRelated(ctx, userID, sessionID, messageID, relatedMessageIDs, resetSummary)
+	}
+	for _, id := range uniqueChatMessageIDs(messageID, relatedMessageIDs) {
+		if err := s.inner.DeleteMessage(ctx, userID, sessionID, id); err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return err
+		}
+	}
+	if resetSummary {
+		return s.inner.UpdateSummary(ctx, userID, sessionID, "", 0)
+	}
+	return nil
+}
+
+func (s *encryptedChatStore) DeleteMessagesAfterWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, inclusive bool, relatedMessageIDs []string, resetSummary bool) error {
+	type atomicDeleteAfter interface {
+		DeleteMessagesAfterWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, inclusive bool, relatedMessageIDs []string, resetSummary bool) error
+	}
+	if inner, ok := s.inner.(atomicDeleteAfter); ok {
+		return inner.DeleteMessagesAfterWithRelated(ctx, userID, sessionID, messageID, inclusive, relatedMessageIDs, resetSummary)
+	}
+	if err := s.inner.DeleteMessagesAfter(ctx, userID, sessionID, messageID, inclusive); err != nil {
+		return err
+	}
+	for _, id := range relatedMessageIDs {
+		if err := s.inner.DeleteMessage(ctx, userID, sessionID, id); err != nil && !errors.Is(err, persistence.ErrNotFound) {
+			return err
+		}
+	}
+	if resetSummary {
+		return s.inner.UpdateSummary(ctx, userID, sessionID, "", 0)
+	}
+	return nil
+}
+
+func (s *encryptedChatStore) AppendMessages(ctx context.Context, userID *int64, sessionID string, messages []persistence.ChatMessage, preview string, model string) error {
+	owner, err := s.sessionOwner(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	sealed := make([]persistence.ChatMessage, len(messages))
+	for i, m := range messages {
+		if m.Content, err = s.cipher.encrypt(owner, sessionID, m.Content); err != nil {
+			return err
+		}
+		sealed[i] = m
+	}
+	if preview, err = s.cipher.encrypt(owner, sessionID, preview); err != nil {
+		return err
+	}
+	if err := s.inner.AppendMessages(ctx, userID, sessionID, sealed, preview, model); err != nil {
+		return err
+	}
+	// Backends fill in IDs and timestamps in place; mirror them to the caller.
+	for i := range messages {
+		messages[i].ID, messages[i].SessionID, messages[i].CreatedAt = sealed[i].ID, sealed[i].SessionID, sealed[i].CreatedAt
+	}
+	return nil
+}
+
+func (s *encryptedChatStore) UpdateSummary(ctx context.Context, userID *int64, sessionID string, summary string, summarizedCount int) error {
+	if summary != "" {
+		owner, err := s.sessionOwner(ctx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		if summary, err = s.cipher.encrypt(owner, sessionID, summary); err != nil {
+			return err
+		}
+	}
+	return s.inner.UpdateSummary(ctx, userID, sessionID, summary, summarizedCount)
+}
@@ -0,0 +1,128 @@
+package databases
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+)
+
+func testEncryptionConfig() config.ChatEncryptionConfig {
+	return config.ChatEncryptionConfig{
+		Enabled:     true,
+		ActiveKeyID: "k1",
+		Keys:        map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))},
+	}
+}
+
+func TestEncryptedChatStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	inner := newMemoryChatStore()
+	store, err := NewEncryptedChatStore(inner, testEncryptionConfig())
+	if err != nil {
+		t.Fatalf("NewEncryptedChatStore: %v", err)
+	}
+	owner := int64ptr(7)
+	if _, err := store.EnsureSession(ctx, owner, "s1", "Secret"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	long := strings.Repeat("classified ", 20)
+	msgs := []persistence.ChatMessage{{Role: "user", Content: "hello"}, {Role: "assistant", Content: long}}
+	if err := store.AppendMessages(ctx, owner, "s1", msgs, snippetForPreview(long), "m"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+	if msgs[0].ID == "" {
+		t.Fatal("expected backend-assigned IDs to propagate to caller")
+	}
+	if err := store.UpdateSummary(ctx, owner, "s1", "summary text", 2); err != nil {
+		t.Fatalf("UpdateSummary: %v", err)
+	}
+
+	raw, _ := inner.ListMessages(ctx, owner, "s1", 0)
+	rawSess, _ := inner.GetSession(ctx, owner, "s1")
+	for _, v := range []string{raw[0].Content, raw[1].Content, rawSess.Summary, rawSess.LastMessagePreview} {
+		if !isEncryptedValue(v) || strings.Contains(v, "classified") || strings.Contains(v, "summary") {
+			t.Fatalf("expected ciphertext at rest, got %q", v)
+		}
+	}
+
+	got, err := store.ListMessages(ctx, owner, "s1", 0)
+	if err != nil || got[0].Content != "hello" || got[1].Content != long {
+		t.Fatalf("unexpected decrypted messages: %+v %v", got, err)
+	}
+	sess, err := store.GetSession(ctx, owner, "s1")
+	if err != nil || sess.Summary != "summary text" || sess.LastMessagePreview != snippetForPreview(long) {
+		t.Fatalf("unexpected decrypted session: %+v %v", sess, err)
+	}
+
+	// Deleting the last message makes the backend recompute the preview from
+	// stored ciphertext, which must still decrypt.
+	if err := store.DeleteMessage(ctx, owner, "s1", got[1].ID); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	sess, _ = store.GetSession(ctx, owner, "s1")
+	if sess.LastMessagePreview != "hello" {
+		t.Fatalf("expected recomputed preview, got %q", sess.LastMessagePreview)
+	}
+}
+
+func TestChatCipherBindsOwnerAndSession(t *testing.T) {
+	c, err := newChatCipher(testEncryptionConfig())
+	if err != nil {
+		t.Fatalf("newChatCipher: %v", err)
+	}
+	ct, err := c.encrypt(1, "s1", "secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := c.decrypt(2, "s1", ct); err == nil {
+		t.Fatal("expected decrypt with another user's key to fail")
+	}
+	if _, err := c.decrypt(1, "s2", ct); err == nil {
+		t.Fatal("expected decrypt under another session to fail")
+	}
+	if pt, err := c.decrypt(1, "s1", "legacy plaintext"); err != nil || pt != "legacy plaintext" {
+		t.Fatalf("expected plaintext passthrough, got %q %v", pt, err)
+	}
+}
+
+func TestEncryptedChatStoreSealsCiphertextLookalikes(t *testing.T) {
+	ctx := context.Background()
+	inner := newMemoryChatStore()
+	store, err := NewEncryptedChatStore(inner, testEncryptionConfig())
+	if err != nil {
+		t.Fatalf("NewEncryptedChatStore: %v", err)
+	}
+	owner := int64ptr(7)
+	if _, err := store.EnsureSession(ctx, owner, "s1", "Secret"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	typed := encryptedPrefix + "k1:not really ciphertext"
+	if err := store.AppendMessages(ctx, owner, "s1", []persistence.ChatMessage{{Role: "user", Content: typed}}, typed, "m"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+	raw, _ := inner.ListMessages(ctx, owner, "s1", 0)
+	if raw[0].Content == typed || strings.Contains(raw[0].Content, "not really") {
+		t.Fatalf("expected the message to be sealed, got %q", raw[0].Content)
+	}
+	got, err := store.ListMessages(ctx, owner, "s1", 0)
+	if err != nil || got[0].Content != typed {
+		t.Fatalf("expected %q back, got %+v %v", typed, got, err)
+	}
+}
+
+func TestNewChatCipherRejectsBadKeys(t *testing.T) {
+	cfg := testEncryptionConfig()
+	cfg.Keys["k1"] = base64.StdEncoding.EncodeToString([]byte("short"))
+	if _, err := newChatCipher(cfg); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+	cfg = testEncryptionConfig()
+	cfg.ActiveKeyID = "missing"
+	if _, err := newChatCipher(cfg); err == nil {
+		t.Fatal("expected unknown active key to be rejected")
+	}
+}
@@ -8,7 +8,8 @@ func snippetForPreview(content string) string {
 		return ""
 	}
 	const maxLen = 120
-	if len(trimmed) <= maxLen {
+	// Ciphertext must stay intact; encryptedChatStore trims after decrypting.
+	if len(trimmed) <= maxLen || isEncryptedValue(trimmed) {
 		return trimmed
 	}
 	return trimmed[:maxLen]
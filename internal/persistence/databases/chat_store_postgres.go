@@ -34,7 +34,22 @@ func (s *pgChatStore) Init(ctx context.Context) error {
 	if s.pool == nil {
 		return errors.New("postgres chat store requires pool")
 	}
-	_, err := s.pool.Exec(ctx, `
+	_, err := s.pool.Exec(ctx, chatTablesSchema+`
+ALTER TABLE chat_sessions
+    ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE chat_sessions
+    ADD COLUMN IF NOT EXISTS summarized_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE chat_sessions
+    ADD COLUMN IF NOT EXISTS user_id BIGINT;
+`+chatIndexSchema)
+	return err
+}
+
+// chatTablesSchema and chatIndexSchema are shared by the Postgres and SQLite
+// chat stores. Postgres-only column migrations run between them.
+const chatTablesSchema = `
 CREATE TABLE IF NOT EXISTS chat_sessions (
     id UUID PRIMARY KEY,
     name TEXT NOT NULL,
@@ -56,21 +71,12 @@ CREATE TABLE IF NOT EXISTS chat_messages (
 );
 
 CREATE INDEX IF NOT EXISTS chat_messages_session_created_idx ON chat_messages(session_id, created_at);
+`
 
-ALTER TABLE chat_sessions
-    ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
-
-ALTER TABLE chat_sessions
-    ADD COLUMN IF NOT EXISTS summarized_count INTEGER NOT NULL DEFAULT 0;
-
-ALTER TABLE chat_sessions
-    ADD COLUMN IF NOT EXISTS user_id BIGINT;
-
+const chatIndexSchema = `
 CREATE INDEX IF NOT EXISTS chat_sessions_user_updated_idx ON chat_sessions(user_id, updated_at DESC);
 CREATE INDEX IF NOT EXISTS chat_sessions_user_created_idx ON chat_sessions(user_id, created_at DESC);
-`)
-	return err
-}
+`
 
 func hasAccess(userID *int64, owner *int64) bool {
 	if userID == nil {
@@ -0,0 +1,382 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
+)
+
+// NewSQLiteChatStore returns a chat history store backed by a shared SQLite
+// database. The store does not own db and never closes it.
+func NewSQLiteChatStore(db *sql.DB) persistence.ChatStore {
+	return &sqliteChatStore{db: db}
+}
+
+type sqliteChatStore struct {
+	db *sql.DB
+}
+
+const sqliteChatSessionColumns = `id, name, user_id, created_at, updated_at, last_message_preview, model, summary, summarized_count`
+
+func (s *sqliteChatStore) Init(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("sqlite chat store requires db")
+	}
+	return sqlite.Init(ctx, s.db, chatTablesSchema+chatIndexSchema)
+}
+
+func (s *sqliteChatStore) scanSession(row interface{ Scan(...any) error }) (persistence.ChatSession, error) {
+	var cs persistence.ChatSession
+	var owner sql.NullInt64
+	if err := row.Scan(&cs.ID, &cs.Name, &owner, &cs.CreatedAt, &cs.UpdatedAt, &cs.LastMessagePreview, &cs.Model, &cs.Summary, &cs.SummarizedCount); err != nil {
+		return persistence.ChatSession{}, err
+	}
+	if owner.Valid {
+		v := owner.Int64
+		cs.UserID = &v
+	}
+	return cs, nil
+}
+
+func (s *sqliteChatStore) getSession(ctx context.Context, q sqliteQuerier, id string) (persistence.ChatSession, error) {
+	cs, err := s.scanSession(q.QueryRowContext(ctx, `SELECT `+sqliteChatSessionColumns+` FROM chat_sessions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.ChatSession{}, persistence.ErrNotFound
+	}
+	return cs, err
+}
+
+// authorize loads the session and enforces ownership the same way the
+// Postgres store does: missing sessions are ErrNotFound, foreign ones
+// ErrForbidden.
+func (s *sqliteChatStore) authorize(ctx context.Context, q sqliteQuerier, userID *int64, id string) (persistence.ChatSession, error) {
+	cs, err := s.getSession(ctx, q, id)
+	if err != nil {
+		return persistence.ChatSession{}, err
+	}
+	if !hasAccess(userID, cs.UserID) {
+		return persistence.ChatSession{}, persistence.ErrForbidden
+	}
+	return cs, nil
+}
+
+func (s *sqliteChatStore) EnsureSession(ctx context.Context, userID *int64, id, name string) (persistence.ChatSession, error) {
+	if strings.TrimSpace(id) == "" {
+		return persistence.ChatSession{}, errors.New("id required")
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "New Chat"
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO chat_sessions (id, user_id, name, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (id) DO NOTHING`, id, nullableUserID(userID), name, now, now); err != nil {
+		return persistence.ChatSession{}, err
+	}
+	return s.authorize(ctx, s.db, userID, id)
+}
+
+func (s *sqliteChatStore) ListSessions(ctx context.Context, userID *int64) ([]persistence.ChatSession, error) {
+	query := `SELECT ` + sqliteChatSessionColumns + ` FROM chat_sessions`
+	args := []any{}
+	if userID != nil {
+		query += ` WHERE user_id = ?`
+		args = append(args, *userID)
+	}
+	query += ` ORDER BY updated_at DESC, created_at DESC`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]persistence.ChatSession, 0)
+	for rows.Next() {
+		cs, err := s.scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cs)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteChatStore) GetSession(ctx context.Context, userID *int64, id string) (persistence.ChatSession, error) {
+	return s.authorize(ctx, s.db, userID, id)
+}
+
+func (s *sqliteChatStore) CreateSession(ctx context.Context, userID *int64, name string) (persistence.ChatSession, error) {
+	if strings.TrimSpace(name) == "" {
+		name = "New Chat"
+	}
+	id := uuid.NewString()
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO chat_sessions (id, user_id, name, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?)`, id, nullableUserID(userID), name, now, now); err != nil {
+		return persistence.ChatSession{}, err
+	}
+	return s.getSession(ctx, s.db, id)
+}
+
+func (s *sqliteChatStore) RenameSession(ctx context.Context, userID *int64, id, name string) (persistence.ChatSession, error) {
+	if strings.TrimSpace(name) == "" {
+		return persistence.ChatSession{}, errors.New("name required")
+	}
+	if _, err := s.authorize(ctx, s.db, userID, id); err != nil {
+		return persistence.ChatSession{}, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE chat_sessions SET name = ?, updated_at = ? WHERE id = ?`, name, time.Now().UTC(), id); err != nil {
+		return persistence.ChatSession{}, err
+	}
+	return s.getSession(ctx, s.db, id)
+}
+
+func (s *sqliteChatStore) DeleteSession(ctx context.Context, userID *int64, id string) error {
+	if _, err := s.authorize(ctx, s.db, userID, id); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM chat_sessions WHERE id = ?`, id)
+	return err
+}
+
+func (s *sqliteChatStore) ListMessages(ctx context.Context, userID *int64, sessionID string, limit int) ([]persistence.ChatMessage, error) {
+	if _, err := s.authorize(ctx, s.db, userID, sessionID); err != nil {
+		return nil, err
+	}
+	query := `
+SELECT id, session_id, role, content, created_at
+FROM chat_messages
+WHERE session_id = ?
+ORDER BY created_at ASC, id ASC`
+	args := []any{sessionID}
+	if limit > 0 {
+		query = `
+SELECT id, session_id, role, content, created_at FROM (
+    SELECT id, session_id, role, content, created_at
+    FROM chat_messages
+    WHERE session_id = ?
+    ORDER BY created_at DESC, id DESC
+    LIMIT ?
+) sub
+ORDER BY created_at ASC, id ASC`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]persistence.ChatMessage, 0)
+	for rows.Next() {
+		var msg persistence.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.SessionID, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, msg)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteChatStore) DeleteMessage(ctx context.Context, userID *int64, sessionID string, messageID string) error {
+	return s.DeleteMessageWithRelated(ctx, userID, sessionID, messageID, nil, false)
+}
+
+func (s *sqliteChatStore) DeleteMessageWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, relatedMessageIDs []string, resetSummary bool) error {
+	if strings.TrimSpace(messageID) == "" {
+		return persistence.ErrNotFound
+	}
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.authorize(ctx, tx, userID, sessionID); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE session_id = ? AND id = ?`, sessionID, messageID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return persistence.ErrNotFound
+		}
+		if err := s.deleteMessagesTx(ctx, tx, sessionID, relatedMessageIDs); err != nil {
+			return err
+		}
+		return s.finalizeDeleteTx(ctx, tx, sessionID, resetSummary)
+	})
+}
+
+func (s *sqliteChatStore) DeleteMessagesAfter(ctx context.Context, userID *int64, sessionID string, messageID string, inclusive bool) error {
+	return s.DeleteMessagesAfterWithRelated(ctx, userID, sessionID, messageID, inclusive, nil, false)
+}
+
+func (s *sqliteChatStore) DeleteMessagesAfterWithRelated(ctx context.Context, userID *int64, sessionID string, messageID string, inclusive bool, relatedMessageIDs []string, resetSummary bool) error {
+	if strings.TrimSpace(messageID) == "" {
+		return persistence.ErrNotFound
+	}
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.authorize(ctx, tx, userID, sessionID); err != nil {
+			return err
+		}
+		// Timestamps are stored as text, so compare them in Go rather than
+		// relying on lexical ordering in SQL.
+		rows, err := tx.QueryContext(ctx, `SELECT id, created_at FROM chat_messages WHERE session_id = ?`, sessionID)
+		if err != nil {
+			return err
+		}
+		type msgKey struct {
+			id        string
+			createdAt time.Time
+		}
+		var all []msgKey
+		var target *msgKey
+		for rows.Next() {
+			var k msgKey
+			if err := rows.Scan(&k.id, &k.createdAt); err != nil {
+				rows.Close()
+				return err
+			}
+			all = append(all, k)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i := range all {
+			if all[i].id == messageID {
+				target = &all[i]
+				break
+			}
+		}
+		if target == nil {
+			return persistence.ErrNotFound
+		}
+		var doomed []string
+		for _, k := range all {
+			after := k.createdAt.After(target.createdAt) ||
+				(k.createdAt.Equal(target.createdAt) && (k.id > target.id || (inclusive && k.id == target.id)))
+			if after {
+				doomed = append(doomed, k.id)
+			}
+		}
+		if err := s.deleteMessagesTx(ctx, tx, sessionID, append(doomed, relatedMessageIDs...)); err != nil {
+			return err
+		}
+		return s.finalizeDeleteTx(ctx, tx, sessionID, resetSummary)
+	})
+}
+
+func (s *sqliteChatStore) AppendMessages(ctx context.Context, userID *int64, sessionID string, messages []persistence.ChatMessage, preview string, model string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.authorize(ctx, tx, userID, sessionID); err != nil {
+			return err
+		}
+		for _, message := range messages {
+			id := message.ID
+			if id == "" {
+				id = uuid.NewString()
+			}
+			createdAt := message.CreatedAt
+			if createdAt.IsZero() {
+				createdAt = time.Now().UTC()
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO chat_messages (id, session_id, role, content, created_at)
+VALUES (?, ?, ?, ?, ?)`, id, sessionID, message.Role, message.Content, createdAt.UTC()); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, `
+UPDATE chat_sessions
+SET updated_at = ?,
+    last_message_preview = ?,
+    model = CASE WHEN ? = '' THEN model ELSE ? END
+WHERE id = ?`, time.Now().UTC(), preview, strings.TrimSpace(model), strings.TrimSpace(model), sessionID)
+		return err
+	})
+}
+
+func (s *sqliteChatStore) UpdateSummary(ctx context.Context, userID *int64, sessionID string, summary string, summarizedCount int) error {
+	if _, err := s.authorize(ctx, s.db, userID, sessionID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE chat_sessions
+SET summary = ?, summarized_count = ?, updated_at = ?
+WHERE id = ?`, summary, summarizedCount, time.Now().UTC(), sessionID)
+	return err
+}
+
+func (s *sqliteChatStore) deleteMessagesTx(ctx context.Context, tx *sql.Tx, sessionID string, ids []string) error {
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE session_id = ? AND id = ?`, sessionID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqliteChatStore) finalizeDeleteTx(ctx context.Context, tx *sql.Tx, sessionID string, resetSummary bool) error {
+	now := time.Now().UTC()
+	if resetSummary {
+		if _, err := tx.ExecContext(ctx, `UPDATE chat_sessions SET summary = '', summarized_count = 0, updated_at = ? WHERE id = ?`, now, sessionID); err != nil {
+			return err
+		}
+	}
+	var lastContent string
+	err := tx.QueryRowContext(ctx, `
+SELECT content
+FROM chat_messages
+WHERE session_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT 1`, sessionID).Scan(&lastContent)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE chat_sessions SET updated_at = ?, last_message_preview = ? WHERE id = ?`, now, snippetForPreview(lastContent), sessionID)
+	return err
+}
+
+func (s *sqliteChatStore) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	return sqliteWithTx(ctx, s.db, fn)
+}
+
+// sqliteQuerier is satisfied by *sql.DB and *sql.Tx.
+type sqliteQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func sqliteWithTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func nullableUserID(userID *int64) any {
+	if userID == nil {
+		return nil
+	}
+	return *userID
+}
@@ -65,6 +65,14 @@ func TestMemoryGraph_Basics(t *testing.T) {
 	if n, ok := g.GetNode(ctx, "n1"); !ok || n.Props["name"] != "Alice" {
 		t.Fatalf("unexpected node: %#v exists=%v", n, ok)
 	}
+	_ = g.UpsertEdge(ctx, "n1", "ADMIRES", "n2", nil)
+	edges, err := g.(GraphEdgeLister).OutEdges(ctx, "n1")
+	if err != nil {
+		t.Fatalf("out edges error: %v", err)
+	}
+	if len(edges) != 2 || edges[0].Rel != "ADMIRES" || edges[1].Rel != "KNOWS" || edges[1].Props["since"] != 2020 {
+		t.Fatalf("unexpected out edges: %#v", edges)
+	}
 }
 
 func TestFactory_DefaultsAndNone(t *testing.T) {
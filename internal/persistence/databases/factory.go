@@ -2,17 +2,21 @@ package databases
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"manifold/internal/config"
 	"manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // NewManager constructs database backends based on configuration.
-// Supported backends: memory, none, auto, postgres.
+// Supported backends: memory, none, auto, postgres, sqlite, file.
 func NewManager(ctx context.Context, cfg config.DBConfig) (m Manager, err error) {
 	defer func() {
 		if err != nil {
@@ -26,6 +30,16 @@ func NewManager(ctx context.Context, cfg config.DBConfig) (m Manager, err error)
 	graphDSN := firstNonEmpty(cfg.Graph.DSN, cfg.DefaultDSN)
 	chatDSN := firstNonEmpty(cfg.Chat.DSN, cfg.DefaultDSN)
 
+	if cfg.Backend == "sqlite" {
+		m.SQLite, err = sqlite.Open(ctx, cfg.SQLite.Path)
+		if err != nil {
+			return Manager{}, fmt.Errorf("open sqlite: %w", err)
+		}
+	}
+	if cfg.Backend == "file" {
+		m.FileDir = cfg.File.Dir
+	}
+
 	m.Search, err = buildSearchStore(ctx, cfg.Search.Backend, searchDSN)
 	if err != nil {
 		return Manager{}, err
@@ -41,7 +55,7 @@ func NewManager(ctx context.Context, cfg config.DBConfig) (m Manager, err error)
 		return Manager{}, err
 	}
 
-	m.Chat, err = buildChatStore(ctx, cfg.Chat.Backend, chatDSN)
+	m.Chat, err = buildChatStore(ctx, cfg.Chat.Backend, chatDSN, m.SQLite, m.FileDir)
 	if err != nil {
 		return Manager{}, err
 	}
@@ -49,6 +63,12 @@ func NewManager(ctx context.Context, cfg config.DBConfig) (m Manager, err error)
 	if m.Chat == nil {
 		m.Chat = newMemoryChatStore()
 	}
+	if cfg.Chat.Encryption.Enabled {
+		m.Chat, err = NewEncryptedChatStore(m.Chat, cfg.Chat.Encryption)
+		if err != nil {
+			return Manager{}, err
+		}
+	}
 	if err := initStore(ctx, "chat store", m.Chat); err != nil {
 		return Manager{}, err
 	}
@@ -85,13 +105,24 @@ func buildSearchStore(ctx context.Context, backend, dsn string) (FullTextSearch,
 	}
 }
 
+func vectorIndexOptions(cfg config.VectorConfig) VectorIndexOptions {
+	return VectorIndexOptions{
+		Type:           strings.ToLower(strings.TrimSpace(cfg.IndexType)),
+		Lists:          cfg.Lists,
+		Probes:         cfg.Probes,
+		M:              cfg.M,
+		EfConstruction: cfg.EfConstruction,
+		EfSearch:       cfg.EfSearch,
+	}
+}
+
 func buildVectorStore(ctx context.Context, cfg config.VectorConfig, dsn string) (VectorStore, error) {
 	switch cfg.Backend {
 	case "", "memory":
 		return NewMemoryVector(), nil
 	case "auto":
 		if pool := openOptionalPostgresPool(ctx, dsn); pool != nil {
-			return NewPostgresVector(pool, cfg.Dimensions, cfg.Metric), nil
+			return NewPostgresVectorWithIndex(pool, cfg.Dimensions, cfg.Metric, vectorIndexOptions(cfg)), nil
 		}
 		return NewMemoryVector(), nil
 	case "postgres", "pgvector", "pg":
@@ -102,7 +133,7 @@ func buildVectorStore(ctx context.Context, cfg config.VectorConfig, dsn string)
 		if err != nil {
 			return nil, fmt.Errorf("connect postgres (vector): %w", err)
 		}
-		return NewPostgresVector(pool, cfg.Dimensions, cfg.Metric), nil
+		return NewPostgresVectorWithIndex(pool, cfg.Dimensions, cfg.Metric, vectorIndexOptions(cfg)), nil
 	case "qdrant":
 		if dsn == "" {
 			return nil, fmt.Errorf("vector backend qdrant requires DSN")
@@ -144,7 +175,7 @@ func buildGraphStore(ctx context.Context, backend, dsn string) (GraphDB, error)
 	}
 }
 
-func buildChatStore(ctx context.Context, backend, dsn string) (persistence.ChatStore, error) {
+func buildChatStore(ctx context.Context, backend, dsn string, db *sql.DB, fileDir string) (persistence.ChatStore, error) {
 	switch backend {
 	case "", "memory", "none", "disabled":
 		return newMemoryChatStore(), nil
@@ -162,6 +193,16 @@ func buildChatStore(ctx context.Context, backend, dsn string) (persistence.ChatS
 			return nil, fmt.Errorf("connect postgres (chat): %w", err)
 		}
 		return NewPostgresChatStore(pool), nil
+	case "sqlite":
+		if db == nil {
+			return nil, fmt.Errorf("chat backend sqlite requires databases.backend: sqlite")
+		}
+		return NewSQLiteChatStore(db), nil
+	case "file":
+		if fileDir == "" {
+			return nil, fmt.Errorf("chat backend file requires databases.backend: file")
+		}
+		return NewFileChatStore(filepath.Join(fileDir, "chat.json")), nil
 	default:
 		return nil, fmt.Errorf("unsupported chat backend: %s", backend)
 	}
@@ -170,11 +211,22 @@ func buildChatStore(ctx context.Context, backend, dsn string) (persistence.ChatS
 func initializeDefaultStores(ctx context.Context, m *Manager, cfg config.DBConfig, chatDSN string) error {
 	configureDefaultPostgresStores(ctx, m, cfg.DefaultDSN)
 
-	m.FlowV2 = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewPostgresFlowV2Store)
+	if m.SQLite != nil {
+		m.FlowV2 = NewSQLiteFlowV2Store(m.SQLite)
+	} else if m.FileDir != "" {
+		m.FlowV2 = NewFileFlowV2Store(filepath.Join(m.FileDir, "workflows.json"))
+	} else {
+		m.FlowV2 = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewPostgresFlowV2Store)
+	}
 	if err := initStore(ctx, "flow v2 store", m.FlowV2); err != nil {
 		return err
 	}
 
+	m.WorkflowRuns = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewWorkflowRunStore)
+	if err := initStore(ctx, "workflow run store", m.WorkflowRuns); err != nil {
+		return err
+	}
+
 	playgroundDSN := firstNonEmpty(chatDSN, cfg.DefaultDSN)
 	if playgroundDSN != "" {
 		store, err := NewPlaygroundStoreFromDSN(ctx, playgroundDSN)
@@ -189,7 +241,11 @@ func initializeDefaultStores(ctx context.Context, m *Manager, cfg config.DBConfi
 		return err
 	}
 
-	m.Projects = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewPostgresProjectsStore)
+	if m.SQLite != nil {
+		m.Projects = NewSQLiteProjectsStore(m.SQLite)
+	} else {
+		m.Projects = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewPostgresProjectsStore)
+	}
 	if err := initStore(ctx, "projects store", m.Projects); err != nil {
 		return err
 	}
@@ -204,6 +260,47 @@ func initializeDefaultStores(ctx context.Context, m *Manager, cfg config.DBConfi
 		return err
 	}
 
+	if m.FileDir != "" {
+		m.RunContexts = NewFileRunContextStore(filepath.Join(m.FileDir, "run_contexts"))
+	} else {
+		m.RunContexts = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewRunContextStore)
+	}
+	if err := initStore(ctx, "run context store", m.RunContexts); err != nil {
+		return err
+	}
+
+	m.ProjectEnv = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewProjectEnvStore)
+	if cfg.Chat.Encryption.Enabled {
+		store, err := NewEncryptedProjectEnvStore(m.ProjectEnv, cfg.Chat.Encryption)
+		if err != nil {
+			return fmt.Errorf("init project env encryption: %w", err)
+		}
+		m.ProjectEnv = store
+	}
+	if err := initStore(ctx, "project env store", m.ProjectEnv); err != nil {
+		return err
+	}
+
+	m.Secrets = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewSecretsStore)
+	if err := initStore(ctx, "secrets store", m.Secrets); err != nil {
+		return err
+	}
+
+	m.Usage = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewUsageStore)
+	if err := initStore(ctx, "usage store", m.Usage); err != nil {
+		return err
+	}
+
+	m.TokenUsage = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewTokenUsageStore)
+	if err := initStore(ctx, "token usage store", m.TokenUsage); err != nil {
+		return err
+	}
+
+	m.LongTermMemory = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewLongTermMemoryStore)
+	if err := initStore(ctx, "long-term memory store", m.LongTermMemory); err != nil {
+		return err
+	}
+
 	m.Transit = newStoreWithOptionalPool(ctx, cfg.DefaultDSN, NewPostgresTransitStore)
 	if err := initStore(ctx, "transit store", m.Transit); err != nil {
 		return err
@@ -297,6 +394,7 @@ func newPgPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 	cfg.MinConns = 0
 	cfg.MaxConnLifetime = time.Hour
 	cfg.MaxConnIdleTime = 5 * time.Minute
+	cfg.ConnConfig.Tracer = pgQueryTracer{}
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
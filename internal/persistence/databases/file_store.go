@@ -0,0 +1,402 @@
+package databases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"manifold/internal/persistence"
+)
+
+// JSONFile stores one value as a JSON document. Saves write a temporary file
+// and rename it into place, so a crash leaves either the old or the new
+// document, never a partial one.
+type JSONFile struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONFile returns a JSONFile at path. Nothing is created until Save.
+func NewJSONFile(path string) *JSONFile {
+	return &JSONFile{path: path}
+}
+
+// Load decodes the file into v. A missing file leaves v untouched.
+func (f *JSONFile) Load(v any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Save replaces the file with v.
+func (f *JSONFile) Save(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return writeFileAtomic(f.path, b)
+}
+
+// Remove deletes the file if it exists.
+func (f *JSONFile) Remove() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// --- chat ---
+
+// NewFileChatStore returns a chat store kept in memory and saved to path
+// after every change.
+func NewFileChatStore(path string) persistence.ChatStore {
+	return &fileChatStore{
+		memChatStore: &memChatStore{
+			sessions: map[string]persistence.ChatSession{},
+			messages: map[string][]persistence.ChatMessage{},
+		},
+		file: NewJSONFile(path),
+	}
+}
+
+type fileChatStore struct {
+	*memChatStore
+	file *JSONFile
+}
+
+type chatFileSnapshot struct {
+	Sessions map[string]persistence.ChatSession   `json:"sessions"`
+	Messages map[string][]persistence.ChatMessage `json:"messages"`
+}
+
+func (s *fileChatStore) Init(ctx context.Context) error {
+	var snap chatFileSnapshot
+	if err := s.file.Load(&snap); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snap.Sessions != nil {
+		s.sessions = snap.Sessions
+	}
+	if snap.Messages != nil {
+		s.messages = snap.Messages
+	}
+	return nil
+}
+
+func (s *fileChatStore) save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.Save(chatFileSnapshot{Sessions: s.sessions, Messages: s.messages})
+}
+
+func (s *fileChatStore) EnsureSession(ctx context.Context, userID *int64, id, name string) (persistence.ChatSession, error) {
+	s.mu.RLock()
+	_, existed := s.sessions[id]
+	s.mu.RUnlock()
+	sess, err := s.memChatStore.EnsureSession(ctx, userID, id, name)
+	if err != nil || existed {
+		return sess, err
+	}
+	return sess, s.save()
+}
+
+func (s *fileChatStore) CreateSession(ctx context.Context, userID *int64, name string) (persistence.ChatSession, error) {
+	sess, err := s.memChatStore.CreateSession(ctx, userID, name)
+	if err != nil {
+		return sess, err
+	}
+	return sess, s.save()
+}
+
+func (s *fileChatStore) RenameSession(ctx context.Context, userID *int64, id, name string) (persistence.ChatSession, error) {
+	sess, err := s.memChatStore.RenameSession(ctx, userID, id, name)
+	if err != nil {
+		return sess, err
+	}
+	return sess, s.save()
+}
+
+func (s *fileChatStore) DeleteSession(ctx context.Context, userID *int64, id string) error {
+	if err := s.memChatStore.DeleteSession(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileChatStore) DeleteMessage(ctx context.Context, userID *int64, sessionID, messageID string) error {
+	if err := s.memChatStore.DeleteMessage(ctx, userID, sessionID, messageID); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileChatStore) DeleteMessagesAfter(ctx context.Context, userID *int64, sessionID, messageID string, inclusive bool) error {
+	if err := s.memChatStore.DeleteMessagesAfter(ctx, userID, sessionID, messageID, inclusive); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileChatStore) AppendMessages(ctx context.Context, userID *int64, sessionID string, messages []persistence.ChatMessage, preview, model string) error {
+	if err := s.memChatStore.AppendMessages(ctx, userID, sessionID, messages, preview, model); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileChatStore) UpdateSummary(ctx context.Context, userID *int64, sessionID, summary string, summarizedCount int) error {
+	if err := s.memChatStore.UpdateSummary(ctx, userID, sessionID, summary, summarizedCount); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// --- specialists ---
+
+// NewFileSpecialistsStore returns a specialists store saved to path after
+// every change.
+func NewFileSpecialistsStore(path string) persistence.SpecialistsStore {
+	return &fileSpecStore{
+		mem:  &memSpecStore{m: map[int64]map[string]persistence.Specialist{}},
+		file: NewJSONFile(path),
+	}
+}
+
+// fileSpecStore serializes access because memSpecStore is not safe for
+// concurrent use.
+type fileSpecStore struct {
+	mu   sync.Mutex
+	mem  *memSpecStore
+	file *JSONFile
+}
+
+func (s *fileSpecStore) Init(ctx context.Context) error {
+	snap := map[int64]map[string]persistence.Specialist{}
+	if err := s.file.Load(&snap); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.m = snap
+	return nil
+}
+
+func (s *fileSpecStore) List(ctx context.Context, userID int64) ([]persistence.Specialist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.List(ctx, userID)
+}
+
+func (s *fileSpecStore) GetByName(ctx context.Context, userID int64, name string) (persistence.Specialist, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.GetByName(ctx, userID, name)
+}
+
+func (s *fileSpecStore) Upsert(ctx context.Context, userID int64, sp persistence.Specialist) (persistence.Specialist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved, err := s.mem.Upsert(ctx, userID, sp)
+	if err != nil {
+		return saved, err
+	}
+	return saved, s.file.Save(s.mem.m)
+}
+
+func (s *fileSpecStore) Delete(ctx context.Context, userID int64, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.mem.Delete(ctx, userID, name); err != nil {
+		return err
+	}
+	return s.file.Save(s.mem.m)
+}
+
+// --- flow v2 workflows ---
+
+// NewFileFlowV2Store returns a Flow v2 workflow store saved to path after
+// every change.
+func NewFileFlowV2Store(path string) persistence.FlowV2WorkflowStore {
+	return &fileFlowV2Store{
+		memFlowV2Store: &memFlowV2Store{records: map[int64]map[string]persistence.FlowV2WorkflowRecord{}},
+		file:           NewJSONFile(path),
+	}
+}
+
+type fileFlowV2Store struct {
+	*memFlowV2Store
+	file *JSONFile
+}
+
+func (s *fileFlowV2Store) Init(ctx context.Context) error {
+	snap := map[int64]map[string]persistence.FlowV2WorkflowRecord{}
+	if err := s.file.Load(&snap); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = snap
+	return nil
+}
+
+func (s *fileFlowV2Store) save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.Save(s.records)
+}
+
+func (s *fileFlowV2Store) UpsertWorkflow(ctx context.Context, userID int64, record persistence.FlowV2WorkflowRecord) (persistence.FlowV2WorkflowRecord, bool, error) {
+	saved, created, err := s.memFlowV2Store.UpsertWorkflow(ctx, userID, record)
+	if err != nil {
+		return saved, created, err
+	}
+	return saved, created, s.save()
+}
+
+func (s *fileFlowV2Store) DeleteWorkflow(ctx context.Context, userID int64, workflowID string) error {
+	if err := s.memFlowV2Store.DeleteWorkflow(ctx, userID, workflowID); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// --- run contexts ---
+
+// NewFileRunContextStore returns a run context store that keeps one JSON
+// file per run in dir, so recording a step rewrites only that run. Like the
+// in-memory store it retains the newest maxMemoryRunContexts runs.
+func NewFileRunContextStore(dir string) persistence.RunContextStore {
+	return &fileRunContextStore{
+		memRunContextStore: &memRunContextStore{runs: map[string]map[int]persistence.RunContextSnapshot{}},
+		dir:                dir,
+	}
+}
+
+type fileRunContextStore struct {
+	*memRunContextStore
+	dir string
+}
+
+func (s *fileRunContextStore) runFile(runID string) *JSONFile {
+	return NewJSONFile(filepath.Join(s.dir, runID+".json"))
+}
+
+func (s *fileRunContextStore) Init(ctx context.Context) error {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	type loaded struct {
+		runID string
+		steps map[int]persistence.RunContextSnapshot
+		first int64
+	}
+	var runs []loaded
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		runID := strings.TrimSuffix(name, ".json")
+		steps := map[int]persistence.RunContextSnapshot{}
+		if err := s.runFile(runID).Load(&steps); err != nil {
+			return err
+		}
+		var first int64
+		for _, snap := range steps {
+			if t := snap.CreatedAt.UnixNano(); first == 0 || t < first {
+				first = t
+			}
+		}
+		runs = append(runs, loaded{runID: runID, steps: steps, first: first})
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].first < runs[j].first })
+	if len(runs) > maxMemoryRunContexts {
+		runs = runs[len(runs)-maxMemoryRunContexts:]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range runs {
+		s.runs[r.runID] = r.steps
+		s.order = append(s.order, r.runID)
+	}
+	return nil
+}
+
+func (s *fileRunContextStore) Record(ctx context.Context, snap persistence.RunContextSnapshot) error {
+	runID := strings.TrimSpace(snap.RunID)
+	if strings.ContainsAny(runID, `/\`) || runID == "." || runID == ".." {
+		return fmt.Errorf("run context: invalid run id %q", runID)
+	}
+	s.mu.RLock()
+	oldest := ""
+	if len(s.order) > 0 {
+		oldest = s.order[0]
+	}
+	s.mu.RUnlock()
+	if err := s.memRunContextStore.Record(ctx, snap); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	_, kept := s.runs[oldest]
+	err := s.runFile(runID).Save(s.runs[runID])
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if oldest != "" && !kept {
+		return s.runFile(oldest).Remove()
+	}
+	return nil
+}
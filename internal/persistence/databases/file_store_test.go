@@ -0,0 +1,160 @@
+package databases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/flow"
+	"manifold/internal/persistence"
+)
+
+func TestFileChatStoreSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chat.json")
+	store := NewFileChatStore(path)
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	owner := int64ptr(1)
+	if _, err := store.EnsureSession(ctx, owner, "s1", "First"); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	if err := store.AppendMessages(ctx, owner, "s1", []persistence.ChatMessage{
+		{ID: "m1", Role: "user", Content: "hello", CreatedAt: time.Now().UTC()},
+	}, "hello", "model-a"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+	if err := store.UpdateSummary(ctx, owner, "s1", "greeting", 1); err != nil {
+		t.Fatalf("UpdateSummary: %v", err)
+	}
+
+	reopened := NewFileChatStore(path)
+	if err := reopened.Init(ctx); err != nil {
+		t.Fatalf("reopen Init: %v", err)
+	}
+	sess, err := reopened.GetSession(ctx, owner, "s1")
+	if err != nil || sess.Summary != "greeting" || sess.Model != "model-a" {
+		t.Fatalf("unexpected session after reopen: %+v (%v)", sess, err)
+	}
+	if _, err := reopened.GetSession(ctx, int64ptr(2), "s1"); !errors.Is(err, persistence.ErrForbidden) {
+		t.Fatalf("ownership must survive reopen, got %v", err)
+	}
+	msgs, err := reopened.ListMessages(ctx, owner, "s1", 0)
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "hello" {
+		t.Fatalf("unexpected messages after reopen: %+v (%v)", msgs, err)
+	}
+
+	if err := reopened.DeleteSession(ctx, owner, "s1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	again := NewFileChatStore(path)
+	if err := again.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if sessions, _ := again.ListSessions(ctx, owner); len(sessions) != 0 {
+		t.Fatalf("deleted session came back: %+v", sessions)
+	}
+}
+
+func TestFileSpecialistsAndWorkflowsSurviveReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	specs := NewFileSpecialistsStore(filepath.Join(dir, "specialists.json"))
+	if err := specs.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := specs.Upsert(ctx, 1, persistence.Specialist{Name: "coder", Model: "m1", APIKey: "secret"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if _, err := specs.Upsert(ctx, 1, persistence.Specialist{Name: "coder", Model: "m2"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	specs = NewFileSpecialistsStore(filepath.Join(dir, "specialists.json"))
+	if err := specs.Init(ctx); err != nil {
+		t.Fatalf("reopen Init: %v", err)
+	}
+	sp, ok, err := specs.GetByName(ctx, 1, "coder")
+	if err != nil || !ok || sp.Model != "m2" || sp.APIKey != "secret" {
+		t.Fatalf("unexpected specialist after reopen: %+v ok=%v (%v)", sp, ok, err)
+	}
+
+	flows := NewFileFlowV2Store(filepath.Join(dir, "workflows.json"))
+	if err := flows.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, created, err := flows.UpsertWorkflow(ctx, 1, persistence.FlowV2WorkflowRecord{Workflow: flow.Workflow{ID: "wf", Name: "Workflow"}}); err != nil || !created {
+		t.Fatalf("UpsertWorkflow: created=%v err=%v", created, err)
+	}
+	flows = NewFileFlowV2Store(filepath.Join(dir, "workflows.json"))
+	if err := flows.Init(ctx); err != nil {
+		t.Fatalf("reopen Init: %v", err)
+	}
+	rec, ok, err := flows.GetWorkflow(ctx, 1, "wf")
+	if err != nil || !ok || rec.Workflow.Name != "Workflow" || rec.CreatedAt.IsZero() {
+		t.Fatalf("unexpected workflow after reopen: %+v ok=%v (%v)", rec, ok, err)
+	}
+}
+
+func TestFileRunContextStoreKeepsOneFilePerRun(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "run_contexts")
+	store := NewFileRunContextStore(dir)
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init on a missing dir: %v", err)
+	}
+	for step := 0; step < 2; step++ {
+		if err := store.Record(ctx, persistence.RunContextSnapshot{RunID: "run_1", Step: step, Messages: json.RawMessage(`[]`)}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := store.Record(ctx, persistence.RunContextSnapshot{RunID: "../escape", Step: 0}); err == nil {
+		t.Fatal("expected an error for a run id with a path separator")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run_1.json")); err != nil {
+		t.Fatalf("run file missing: %v", err)
+	}
+
+	reopened := NewFileRunContextStore(dir)
+	if err := reopened.Init(ctx); err != nil {
+		t.Fatalf("reopen Init: %v", err)
+	}
+	steps, err := reopened.Steps(ctx, "run_1")
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("unexpected steps after reopen: %v (%v)", steps, err)
+	}
+}
+
+func TestNewManagerFileBackend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m, err := NewManager(ctx, config.DBConfig{Backend: "file", File: config.FileDBConfig{Dir: dir}, Chat: config.ChatConfig{Backend: "file"}})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Close()
+	if m.FileDir != dir {
+		t.Fatalf("FileDir = %q, want %q", m.FileDir, dir)
+	}
+	if _, err := m.Chat.EnsureSession(ctx, nil, "s1", ""); err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	if _, _, err := m.FlowV2.UpsertWorkflow(ctx, 0, persistence.FlowV2WorkflowRecord{Workflow: flow.Workflow{ID: "wf"}}); err != nil {
+		t.Fatalf("UpsertWorkflow: %v", err)
+	}
+	for _, name := range []string{"chat.json", "workflows.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("%s not written: %v", name, err)
+		}
+	}
+
+	if _, err := NewManager(ctx, config.DBConfig{Chat: config.ChatConfig{Backend: "file"}}); err == nil {
+		t.Fatal("chat backend file without databases.backend file should fail")
+	}
+}
@@ -94,7 +94,12 @@ func (s *memFlowV2Store) DeleteWorkflow(_ context.Context, userID int64, workflo
 type pgFlowV2Store struct{ pool *pgxpool.Pool }
 
 func (s *pgFlowV2Store) Init(ctx context.Context) error {
-	_, err := s.pool.Exec(ctx, `
+	_, err := s.pool.Exec(ctx, flowV2Schema)
+	return err
+}
+
+// flowV2Schema is shared by the Postgres and SQLite Flow v2 stores.
+const flowV2Schema = `
 CREATE TABLE IF NOT EXISTS flow_v2_workflows (
   id SERIAL PRIMARY KEY,
   user_id BIGINT NOT NULL DEFAULT 0,
@@ -106,9 +111,7 @@ CREATE TABLE IF NOT EXISTS flow_v2_workflows (
 );
 
 CREATE UNIQUE INDEX IF NOT EXISTS flow_v2_workflows_user_workflow_idx ON flow_v2_workflows(user_id, workflow_id);
-`)
-	return err
-}
+`
 
 func (s *pgFlowV2Store) ListWorkflows(ctx context.Context, userID int64) ([]persist.FlowV2WorkflowRecord, error) {
 	rows, err := s.pool.Query(ctx, `
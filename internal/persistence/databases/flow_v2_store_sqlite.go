@@ -0,0 +1,114 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	persist "manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
+)
+
+// NewSQLiteFlowV2Store returns a Flow v2 workflow store backed by a shared
+// SQLite database.
+func NewSQLiteFlowV2Store(db *sql.DB) persist.FlowV2WorkflowStore {
+	return &sqliteFlowV2Store{db: db}
+}
+
+type sqliteFlowV2Store struct{ db *sql.DB }
+
+func (s *sqliteFlowV2Store) Init(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("sqlite flow v2 store requires db")
+	}
+	return sqlite.Init(ctx, s.db, flowV2Schema)
+}
+
+func (s *sqliteFlowV2Store) ListWorkflows(ctx context.Context, userID int64) ([]persist.FlowV2WorkflowRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT workflow, canvas, created_at, updated_at
+FROM flow_v2_workflows
+WHERE user_id=?
+ORDER BY workflow_id
+`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []persist.FlowV2WorkflowRecord{}
+	for rows.Next() {
+		record, err := scanFlowV2WorkflowRecord(rows, userID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, record)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteFlowV2Store) GetWorkflow(ctx context.Context, userID int64, workflowID string) (persist.FlowV2WorkflowRecord, bool, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT workflow, canvas, created_at, updated_at
+FROM flow_v2_workflows
+WHERE user_id=? AND workflow_id=?
+`, userID, workflowID)
+	record, err := scanFlowV2WorkflowRecord(row, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return persist.FlowV2WorkflowRecord{}, false, nil
+		}
+		return persist.FlowV2WorkflowRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+func (s *sqliteFlowV2Store) UpsertWorkflow(ctx context.Context, userID int64, record persist.FlowV2WorkflowRecord) (persist.FlowV2WorkflowRecord, bool, error) {
+	workflowID := strings.TrimSpace(record.Workflow.ID)
+	if workflowID == "" {
+		return persist.FlowV2WorkflowRecord{}, false, errors.New("workflow id required")
+	}
+
+	_, existed, err := s.GetWorkflow(ctx, userID, workflowID)
+	if err != nil {
+		return persist.FlowV2WorkflowRecord{}, false, err
+	}
+
+	workflowDoc, err := json.Marshal(record.Workflow)
+	if err != nil {
+		return persist.FlowV2WorkflowRecord{}, false, err
+	}
+	canvasDoc, err := json.Marshal(record.Canvas)
+	if err != nil {
+		return persist.FlowV2WorkflowRecord{}, false, err
+	}
+
+	now := time.Now().UTC()
+	row := s.db.QueryRowContext(ctx, `
+INSERT INTO flow_v2_workflows(user_id, workflow_id, workflow, canvas, created_at, updated_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?5)
+ON CONFLICT (user_id, workflow_id) DO UPDATE
+SET workflow = excluded.workflow,
+	canvas = excluded.canvas,
+	updated_at = excluded.updated_at
+RETURNING created_at, updated_at
+`, userID, workflowID, string(workflowDoc), string(canvasDoc), now)
+
+	var createdAt time.Time
+	var updatedAt time.Time
+	if err := row.Scan(&createdAt, &updatedAt); err != nil {
+		return persist.FlowV2WorkflowRecord{}, false, err
+	}
+	record.UserID = userID
+	record.CreatedAt = createdAt
+	record.UpdatedAt = updatedAt
+	return record, !existed, nil
+}
+
+func (s *sqliteFlowV2Store) DeleteWorkflow(ctx context.Context, userID int64, workflowID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM flow_v2_workflows WHERE user_id=? AND workflow_id=?`, userID, workflowID)
+	return err
+}
@@ -2,6 +2,7 @@ package databases
 
 import (
 	"context"
+	"database/sql"
 	"reflect"
 
 	"manifold/internal/agent/memory"
@@ -57,6 +58,21 @@ type GraphDB interface {
 	GetNode(ctx context.Context, id string) (Node, bool)
 }
 
+// Edge is a directed, labelled relation between two graph nodes.
+type Edge struct {
+	Source string
+	Rel    string
+	Target string
+	Props  map[string]any
+}
+
+// GraphEdgeLister is implemented by graph stores that can enumerate the
+// outgoing edges of a node across all relations, which traversal and export
+// need since Neighbors is scoped to a single relation.
+type GraphEdgeLister interface {
+	OutEdges(ctx context.Context, id string) ([]Edge, error)
+}
+
 // Manager holds concrete database backends resolved from configuration.
 type Manager struct {
 	Search          FullTextSearch
@@ -66,11 +82,24 @@ type Manager struct {
 	EvolvingMemory  memory.EvolvingMemoryStore
 	Playground      *PlaygroundStore
 	FlowV2          persistence.FlowV2WorkflowStore
+	WorkflowRuns    persistence.WorkflowRunStore
 	MCP             persistence.MCPStore
 	Projects        persistence.ProjectsStore
 	UserPreferences persistence.UserPreferencesStore
 	Pulse           persistence.PulseStore
+	RunContexts     persistence.RunContextStore
+	ProjectEnv      persistence.ProjectEnvStore
+	Secrets         persistence.SecretsStore
+	Usage           persistence.UsageStore
+	TokenUsage      persistence.TokenUsageStore
+	LongTermMemory  persistence.LongTermMemoryStore
 	Transit         transit.Store
+	// SQLite is the shared database handle when DBConfig.Backend is
+	// "sqlite"; nil otherwise.
+	SQLite *sql.DB
+	// FileDir is the directory of the JSON stores when DBConfig.Backend is
+	// "file"; empty otherwise.
+	FileDir string
 }
 
 // Close attempts to close any underlying pools. It's a no-op for memory backends.
@@ -81,11 +110,19 @@ func (m Manager) Close() {
 	closeIfPossible(m.Chat)
 	closeIfPossible(m.EvolvingMemory)
 	closeIfPossible(m.Playground)
+	closeIfPossible(m.WorkflowRuns)
 	closeIfPossible(m.MCP)
 	closeIfPossible(m.Projects)
 	closeIfPossible(m.UserPreferences)
 	closeIfPossible(m.Pulse)
+	closeIfPossible(m.RunContexts)
+	closeIfPossible(m.ProjectEnv)
+	closeIfPossible(m.Secrets)
+	closeIfPossible(m.Usage)
+	closeIfPossible(m.TokenUsage)
+	closeIfPossible(m.LongTermMemory)
 	closeIfPossible(m.Transit)
+	closeIfPossible(m.SQLite)
 }
 
 func closeIfPossible(value any) {
@@ -0,0 +1,215 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewLongTermMemoryStore returns a Postgres-backed long-term memory store
+// when a pool is provided, otherwise an in-memory implementation.
+func NewLongTermMemoryStore(pool *pgxpool.Pool) persistence.LongTermMemoryStore {
+	if pool == nil {
+		return &memLongTermMemoryStore{facts: map[int64]map[string]persistence.LongTermMemory{}}
+	}
+	return &pgLongTermMemoryStore{pool: pool}
+}
+
+func normalizeLongTermMemory(m persistence.LongTermMemory) (persistence.LongTermMemory, error) {
+	m.Text = strings.TrimSpace(m.Text)
+	if m.Text == "" {
+		return m, errors.New("long-term memory: missing text")
+	}
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	return m, nil
+}
+
+type memLongTermMemoryStore struct {
+	mu    sync.RWMutex
+	facts map[int64]map[string]persistence.LongTermMemory
+}
+
+func (s *memLongTermMemoryStore) Init(ctx context.Context) error { return nil }
+
+func (s *memLongTermMemoryStore) Put(ctx context.Context, m persistence.LongTermMemory) (persistence.LongTermMemory, error) {
+	m, err := normalizeLongTermMemory(m)
+	if err != nil {
+		return m, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.facts[m.UserID] == nil {
+		s.facts[m.UserID] = map[string]persistence.LongTermMemory{}
+	}
+	s.facts[m.UserID][m.ID] = m
+	return m, nil
+}
+
+func (s *memLongTermMemoryStore) Get(ctx context.Context, userID int64, id string) (persistence.LongTermMemory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.facts[userID][id]
+	if !ok {
+		return persistence.LongTermMemory{}, persistence.ErrNotFound
+	}
+	return m, nil
+}
+
+func (s *memLongTermMemoryStore) List(ctx context.Context, userID int64, limit int) ([]persistence.LongTermMemory, error) {
+	s.mu.RLock()
+	out := make([]persistence.LongTermMemory, 0, len(s.facts[userID]))
+	for _, m := range s.facts[userID] {
+		out = append(out, m)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memLongTermMemoryStore) Delete(ctx context.Context, userID int64, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.facts[userID][id]; !ok {
+		return persistence.ErrNotFound
+	}
+	delete(s.facts[userID], id)
+	return nil
+}
+
+func (s *memLongTermMemoryStore) DeleteAll(ctx context.Context, userID int64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.facts[userID]))
+	for id := range s.facts[userID] {
+		ids = append(ids, id)
+	}
+	delete(s.facts, userID)
+	sort.Strings(ids)
+	return ids, nil
+}
+
+type pgLongTermMemoryStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgLongTermMemoryStore) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+func (s *pgLongTermMemoryStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS long_term_memories (
+    id TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    session_id TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS long_term_memories_user_created_idx ON long_term_memories(user_id, created_at DESC);
+`)
+	return err
+}
+
+func (s *pgLongTermMemoryStore) Put(ctx context.Context, m persistence.LongTermMemory) (persistence.LongTermMemory, error) {
+	m, err := normalizeLongTermMemory(m)
+	if err != nil {
+		return m, err
+	}
+	_, err = s.pool.Exec(ctx, `
+INSERT INTO long_term_memories (id, user_id, session_id, text, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET text = EXCLUDED.text`, m.ID, m.UserID, m.SessionID, m.Text, m.CreatedAt)
+	return m, err
+}
+
+func (s *pgLongTermMemoryStore) Get(ctx context.Context, userID int64, id string) (persistence.LongTermMemory, error) {
+	m := persistence.LongTermMemory{ID: id, UserID: userID}
+	err := s.pool.QueryRow(ctx, `
+SELECT session_id, text, created_at FROM long_term_memories WHERE user_id = $1 AND id = $2`, userID, id).Scan(&m.SessionID, &m.Text, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return persistence.LongTermMemory{}, persistence.ErrNotFound
+	}
+	return m, err
+}
+
+func (s *pgLongTermMemoryStore) List(ctx context.Context, userID int64, limit int) ([]persistence.LongTermMemory, error) {
+	query := `
+SELECT id, session_id, text, created_at FROM long_term_memories
+WHERE user_id = $1
+ORDER BY created_at DESC, id`
+	args := []any{userID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]persistence.LongTermMemory, 0)
+	for rows.Next() {
+		m := persistence.LongTermMemory{UserID: userID}
+		if err := rows.Scan(&m.ID, &m.SessionID, &m.Text, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgLongTermMemoryStore) Delete(ctx context.Context, userID int64, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM long_term_memories WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (s *pgLongTermMemoryStore) DeleteAll(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := s.pool.Query(ctx, `DELETE FROM long_term_memories WHERE user_id = $1 RETURNING id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
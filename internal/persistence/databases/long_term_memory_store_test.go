@@ -0,0 +1,55 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemLongTermMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLongTermMemoryStore(nil)
+	base := time.Now().UTC()
+
+	first, err := store.Put(ctx, persistence.LongTermMemory{UserID: 1, Text: " prefers Go ", CreatedAt: base})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if first.ID == "" || first.Text != "prefers Go" {
+		t.Fatalf("unexpected stored memory: %+v", first)
+	}
+	if _, err := store.Put(ctx, persistence.LongTermMemory{UserID: 1, Text: "works on manifold", CreatedAt: base.Add(time.Second)}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := store.Put(ctx, persistence.LongTermMemory{UserID: 2, Text: "other user"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := store.Put(ctx, persistence.LongTermMemory{UserID: 1, Text: "  "}); err == nil {
+		t.Fatal("expected error for empty text")
+	}
+
+	list, err := store.List(ctx, 1, 0)
+	if err != nil || len(list) != 2 || list[0].Text != "works on manifold" {
+		t.Fatalf("List: %+v err=%v", list, err)
+	}
+	if _, err := store.Get(ctx, 2, first.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound across users, got %v", err)
+	}
+
+	if err := store.Delete(ctx, 1, first.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, 1, first.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+	}
+	ids, err := store.DeleteAll(ctx, 1)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("DeleteAll: %v err=%v", ids, err)
+	}
+	if list, _ := store.List(ctx, 2, 0); len(list) != 1 {
+		t.Fatalf("DeleteAll removed another user's memories: %+v", list)
+	}
+}
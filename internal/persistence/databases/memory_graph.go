@@ -59,6 +59,27 @@ func (m *memoryGraph) Neighbors(_ context.Context, id string, rel string) ([]str
 	return out, nil
 }
 
+func (m *memoryGraph) OutEdges(_ context.Context, id string) ([]Edge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []Edge{}
+	for key, dsts := range m.edges {
+		if key.src != id {
+			continue
+		}
+		for dst, props := range dsts {
+			out = append(out, Edge{Source: id, Rel: key.rel, Target: dst, Props: props})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rel != out[j].Rel {
+			return out[i].Rel < out[j].Rel
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out, nil
+}
+
 func (m *memoryGraph) GetNode(_ context.Context, id string) (Node, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -0,0 +1,90 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+const benchVectorDim = 768
+
+func randomVector(r *rand.Rand) []float32 {
+	v := make([]float32, benchVectorDim)
+	for i := range v {
+		v[i] = r.Float32()*2 - 1
+	}
+	return v
+}
+
+func seededMemoryVector(b *testing.B, n int) (VectorStore, *rand.Rand) {
+	b.Helper()
+	r := rand.New(rand.NewPCG(1, 2))
+	store := NewMemoryVector()
+	ctx := context.Background()
+	for i := range n {
+		md := map[string]string{"tenant": fmt.Sprintf("t%d", i%4)}
+		if err := store.Upsert(ctx, fmt.Sprintf("doc-%d", i), randomVector(r), md); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return store, r
+}
+
+func BenchmarkMemoryVectorUpsert(b *testing.B) {
+	store := NewMemoryVector()
+	r := rand.New(rand.NewPCG(1, 2))
+	vec := randomVector(r)
+	md := map[string]string{"tenant": "t0"}
+	ctx := context.Background()
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		if err := store.Upsert(ctx, fmt.Sprintf("doc-%d", i%10000), vec, md); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+}
+
+func BenchmarkMemoryVectorSearch(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		store, r := seededMemoryVector(b, n)
+		query := randomVector(r)
+		ctx := context.Background()
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := store.SimilaritySearch(ctx, query, 10, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("n=%d/filtered", n), func(b *testing.B) {
+			filter := map[string]string{"tenant": "t1"}
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := store.SimilaritySearch(ctx, query, 10, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMemoryVectorSearchParallel models concurrent retrieval from many
+// in-flight agent runs sharing one store.
+func BenchmarkMemoryVectorSearchParallel(b *testing.B) {
+	store, _ := seededMemoryVector(b, 10000)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		query := randomVector(rand.New(rand.NewPCG(3, 4)))
+		for pb.Next() {
+			if _, err := store.SimilaritySearch(ctx, query, 10, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package databases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pgTracerName       = "internal/persistence/databases"
+	maxTracedStatement = 1024
+)
+
+// pgQueryTracer records a span per Postgres query issued within a trace, so
+// queries made during an agent run show up in its trace. Queries outside a
+// trace, such as background maintenance, are not traced.
+type pgQueryTracer struct{}
+
+func (pgQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	stmt := strings.TrimSpace(data.SQL)
+	if len(stmt) > maxTracedStatement {
+		stmt = stmt[:maxTracedStatement]
+	}
+	ctx, _ = otel.Tracer(pgTracerName).Start(ctx, "db.query", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", stmt),
+	))
+	return ctx
+}
+
+func (pgQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
@@ -142,6 +142,23 @@ func (s *PlaygroundStore) CreatePrompt(ctx context.Context, prompt registry.Prom
 	return prompt, nil
 }
 
+// UpdatePrompt replaces the prompt payload.
+func (s *PlaygroundStore) UpdatePrompt(ctx context.Context, prompt registry.Prompt) (registry.Prompt, error) {
+	data, err := json.Marshal(prompt)
+	if err != nil {
+		return registry.Prompt{}, err
+	}
+	uid := userIDFromContext(ctx)
+	cmd, err := s.pool.Exec(ctx, `UPDATE playground_prompts SET payload=$3 WHERE id=$1 AND user_id=$2`, prompt.ID, uid, data)
+	if err != nil {
+		return registry.Prompt{}, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return registry.Prompt{}, registry.ErrPromptNotFound
+	}
+	return prompt, nil
+}
+
 // GetPrompt loads a prompt by ID.
 func (s *PlaygroundStore) GetPrompt(ctx context.Context, id string) (registry.Prompt, bool, error) {
 	uid := userIDFromContext(ctx)
@@ -536,6 +553,35 @@ func (s *PlaygroundStore) AppendResults(ctx context.Context, runID string, resul
 	return br.Close()
 }
 
+// CompleteShard persists a finished shard's results and appends the shard to
+// the run's completedShards in a single transaction, so a resumed run never
+// sees results for a shard it will execute again.
+func (s *PlaygroundStore) CompleteShard(ctx context.Context, runID, shardID string, results []playground.RunResult) error {
+	uid := userIDFromContext(ctx)
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, res := range results {
+		payload, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO playground_run_results (id, run_id, user_id, payload) VALUES ($1,$2,$3,$4)
+			ON CONFLICT (id) DO UPDATE SET run_id=EXCLUDED.run_id, payload=EXCLUDED.payload`, res.ID, runID, uid, payload); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE playground_runs
+		SET payload = jsonb_set(payload, '{completedShards}', COALESCE(payload->'completedShards', '[]'::jsonb) || to_jsonb($1::text))
+		WHERE id=$2 AND user_id=$3`, shardID, runID, uid); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // ListRuns returns runs for an experiment ordered by creation time.
 func (s *PlaygroundStore) ListRuns(ctx context.Context, experimentID string) ([]playground.Run, error) {
 	uid := userIDFromContext(ctx)
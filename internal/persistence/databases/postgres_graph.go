@@ -77,6 +77,23 @@ func (g *pgGraph) Neighbors(ctx context.Context, id string, rel string) ([]strin
 	return out, rows.Err()
 }
 
+func (g *pgGraph) OutEdges(ctx context.Context, id string) ([]Edge, error) {
+	rows, err := g.pool.Query(ctx, `SELECT rel, target, props FROM edges WHERE source=$1 ORDER BY rel, target`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Edge{}
+	for rows.Next() {
+		e := Edge{Source: id}
+		if err := rows.Scan(&e.Rel, &e.Target, &e.Props); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
 func (g *pgGraph) GetNode(ctx context.Context, id string) (Node, bool) {
 	row := g.pool.QueryRow(ctx, `SELECT labels, props FROM nodes WHERE id=$1`, id)
 	var labels []string
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,9 +13,19 @@ type pgVector struct {
 	pool       *pgxpool.Pool
 	dimensions int
 	metric     string // cosine|l2|ip
+
+	mu    sync.RWMutex // guards index, which reindexing may retune
+	index VectorIndexOptions
 }
 
 func NewPostgresVector(pool *pgxpool.Pool, dimensions int, metric string) VectorStore {
+	return NewPostgresVectorWithIndex(pool, dimensions, metric, VectorIndexOptions{})
+}
+
+// NewPostgresVectorWithIndex is NewPostgresVector with ANN index options.
+// When opts.Type is set and the table has no ANN index yet, one is built
+// before returning.
+func NewPostgresVectorWithIndex(pool *pgxpool.Pool, dimensions int, metric string, opts VectorIndexOptions) VectorStore {
 	ctx := context.Background()
 	_, _ = pool.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS vector`)
 	vecType := "vector"
@@ -28,8 +39,9 @@ CREATE TABLE IF NOT EXISTS embeddings (
   metadata JSONB NOT NULL DEFAULT '{}'::jsonb
 );
 `, vecType))
-	// Index creation left to DBA/tuning; default scan is fine for small data
-	return &pgVector{pool: pool, dimensions: dimensions, metric: strings.ToLower(strings.TrimSpace(metric))}
+	p := &pgVector{pool: pool, dimensions: dimensions, metric: strings.ToLower(strings.TrimSpace(metric)), index: opts}
+	p.ensureIndex(ctx)
+	return p
 }
 
 func (p *pgVector) Upsert(ctx context.Context, id string, vector []float32, metadata map[string]string) error {
@@ -51,14 +63,12 @@ func (p *pgVector) SimilaritySearch(ctx context.Context, vector []float32, k int
 		k = 10
 	}
 	vecLit := toVectorLiteral(vector)
-	op := "<=>" // cosine distance
-	scoreExpr := "1 - (vec <=> $1::vector)"
-	switch p.metric {
-	case "l2", "euclidean":
-		op = "<->"
+	op, _ := vectorMetricOps(p.metric)
+	scoreExpr := "1 - (vec <=> $1::vector)" // cosine distance
+	switch op {
+	case "<->":
 		scoreExpr = "-(vec <-> $1::vector)" // higher is better (less distance)
-	case "ip", "dot":
-		op = "<#>"
+	case "<#>":
 		scoreExpr = "-(vec <#> $1::vector)" // maximize inner product
 	}
 	args := []any{vecLit, k}
@@ -68,7 +78,17 @@ func (p *pgVector) SimilaritySearch(ctx context.Context, vector []float32, k int
 		args = []any{vecLit, k, filter}
 	}
 	query := fmt.Sprintf(`SELECT id, %s AS score, metadata FROM embeddings %s ORDER BY vec %s $1::vector LIMIT $2`, scoreExpr, where, op)
-	rows, err := p.pool.Query(ctx, query, args...)
+	var out []VectorResult
+	err := p.withSearchSettings(ctx, p.searchSettings(), func(q pgxQuerier) error {
+		var err error
+		out, err = scanVectorResults(ctx, q, k, query, args...)
+		return err
+	})
+	return out, err
+}
+
+func scanVectorResults(ctx context.Context, q pgxQuerier, k int, query string, args ...any) ([]VectorResult, error) {
+	rows, err := q.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,356 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// Vector index types supported by pgvector.
+const (
+	VectorIndexNone    = "none"
+	VectorIndexIVFFlat = "ivfflat"
+	VectorIndexHNSW    = "hnsw"
+)
+
+// vectorIndexName is the ANN index managed on the embeddings table.
+const vectorIndexName = "embeddings_vec_ann_idx"
+
+// recallSampleSize and recallK bound the recall estimate so it stays cheap
+// on large tables.
+const (
+	recallSampleSize = 20
+	recallK          = 10
+)
+
+// ErrVectorIndexUnsupported is returned when the store cannot build an ANN
+// index, e.g. because the embedding column has no fixed dimensionality.
+var ErrVectorIndexUnsupported = errors.New("vector index unsupported")
+
+// VectorIndexOptions configures the ANN index build and query-time tuning.
+// Zero values select pgvector defaults.
+type VectorIndexOptions struct {
+	Type           string `json:"type"`
+	Lists          int    `json:"lists,omitempty"`
+	Probes         int    `json:"probes,omitempty"`
+	M              int    `json:"m,omitempty"`
+	EfConstruction int    `json:"efConstruction,omitempty"`
+	EfSearch       int    `json:"efSearch,omitempty"`
+}
+
+// VectorRecallEstimate compares index results against an exact scan for a
+// sample of stored vectors.
+type VectorRecallEstimate struct {
+	Recall     float64 `json:"recall"`
+	SampleSize int     `json:"sampleSize"`
+	K          int     `json:"k"`
+}
+
+// VectorIndexStatus reports the health of the ANN index on the embeddings
+// table.
+type VectorIndexStatus struct {
+	Table      string                `json:"table"`
+	Name       string                `json:"name,omitempty"`
+	Type       string                `json:"type"`
+	Metric     string                `json:"metric"`
+	Valid      bool                  `json:"valid"`
+	Definition string                `json:"definition,omitempty"`
+	SizeBytes  int64                 `json:"sizeBytes"`
+	Rows       int64                 `json:"rows"`
+	Probes     int                   `json:"probes,omitempty"`
+	EfSearch   int                   `json:"efSearch,omitempty"`
+	Recall     *VectorRecallEstimate `json:"recall,omitempty"`
+}
+
+// VectorIndexManager is implemented by vector stores that manage an ANN
+// index. Callers type-assert a VectorStore to discover support.
+type VectorIndexManager interface {
+	VectorIndexStatus(ctx context.Context, estimateRecall bool) (VectorIndexStatus, error)
+	ReindexVectors(ctx context.Context, opts VectorIndexOptions, concurrently bool) (VectorIndexStatus, error)
+}
+
+// pgxQuerier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// vectorMetricOps maps a configured metric to its pgvector distance operator
+// and operator class.
+func vectorMetricOps(metric string) (op, opclass string) {
+	switch strings.ToLower(strings.TrimSpace(metric)) {
+	case "l2", "euclidean":
+		return "<->", "vector_l2_ops"
+	case "ip", "dot":
+		return "<#>", "vector_ip_ops"
+	default:
+		return "<=>", "vector_cosine_ops"
+	}
+}
+
+// defaultIVFFlatLists follows the pgvector guidance: rows/1000 up to 1M rows,
+// sqrt(rows) beyond.
+func defaultIVFFlatLists(rows int64) int {
+	lists := int(rows / 1000)
+	if rows > 1_000_000 {
+		lists = int(math.Sqrt(float64(rows)))
+	}
+	return max(lists, 1)
+}
+
+// vectorIndexDDL renders the CREATE INDEX statement for opts.
+func vectorIndexDDL(name, metric string, opts VectorIndexOptions, rows int64, concurrently bool) (string, error) {
+	_, opclass := vectorMetricOps(metric)
+	conc := ""
+	if concurrently {
+		conc = "CONCURRENTLY "
+	}
+	switch strings.ToLower(strings.TrimSpace(opts.Type)) {
+	case VectorIndexIVFFlat:
+		lists := opts.Lists
+		if lists <= 0 {
+			lists = defaultIVFFlatLists(rows)
+		}
+		return fmt.Sprintf(`CREATE INDEX %s%s ON embeddings USING ivfflat (vec %s) WITH (lists = %d)`, conc, name, opclass, lists), nil
+	case VectorIndexHNSW:
+		m := opts.M
+		if m <= 0 {
+			m = 16
+		}
+		efc := opts.EfConstruction
+		if efc <= 0 {
+			efc = 64
+		}
+		return fmt.Sprintf(`CREATE INDEX %s%s ON embeddings USING hnsw (vec %s) WITH (m = %d, ef_construction = %d)`, conc, name, opclass, m, efc), nil
+	default:
+		return "", fmt.Errorf("unknown vector index type %q", opts.Type)
+	}
+}
+
+// searchSettings returns the SET LOCAL statements applied to similarity
+// queries.
+func (p *pgVector) searchSettings() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var out []string
+	if p.index.Probes > 0 {
+		out = append(out, "SET LOCAL ivfflat.probes = "+strconv.Itoa(p.index.Probes))
+	}
+	if p.index.EfSearch > 0 {
+		out = append(out, "SET LOCAL hnsw.ef_search = "+strconv.Itoa(p.index.EfSearch))
+	}
+	return out
+}
+
+// withSearchSettings runs fn directly on the pool when there is nothing to
+// set, otherwise inside a read-only transaction scoped to the settings.
+func (p *pgVector) withSearchSettings(ctx context.Context, settings []string, fn func(q pgxQuerier) error) error {
+	if len(settings) == 0 {
+		return fn(p.pool)
+	}
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	for _, stmt := range settings {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ensureIndex builds the configured index at startup when none exists.
+// Failures are logged; similarity search falls back to a sequential scan.
+func (p *pgVector) ensureIndex(ctx context.Context) {
+	opts := p.indexOptions()
+	if opts.Type == "" || p.dimensions <= 0 {
+		return
+	}
+	st, err := p.VectorIndexStatus(ctx, false)
+	if err != nil {
+		log.Warn().Err(err).Msg("vector_index_status_failed")
+		return
+	}
+	if st.Type != VectorIndexNone {
+		return
+	}
+	if _, err := p.ReindexVectors(ctx, opts, false); err != nil {
+		log.Warn().Err(err).Str("type", opts.Type).Msg("vector_index_create_failed")
+		return
+	}
+	log.Info().Str("type", opts.Type).Msg("vector_index_created")
+}
+
+func (p *pgVector) indexOptions() VectorIndexOptions {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.index
+}
+
+// VectorIndexStatus reports the ANN index on the embeddings table, preferring
+// the managed index when several exist.
+func (p *pgVector) VectorIndexStatus(ctx context.Context, estimateRecall bool) (VectorIndexStatus, error) {
+	opts := p.indexOptions()
+	st := VectorIndexStatus{
+		Table:    "embeddings",
+		Type:     VectorIndexNone,
+		Metric:   p.metric,
+		Probes:   opts.Probes,
+		EfSearch: opts.EfSearch,
+	}
+	if st.Metric == "" {
+		st.Metric = "cosine"
+	}
+	if err := p.pool.QueryRow(ctx, `
+SELECT GREATEST(reltuples, 0)::BIGINT FROM pg_class WHERE oid = 'embeddings'::regclass`).Scan(&st.Rows); err != nil {
+		return st, err
+	}
+	var method string
+	err := p.pool.QueryRow(ctx, `
+SELECT c.relname, am.amname, i.indisvalid, pg_get_indexdef(c.oid), pg_relation_size(c.oid)
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+JOIN pg_am am ON am.oid = c.relam
+WHERE i.indrelid = 'embeddings'::regclass AND am.amname IN ('ivfflat', 'hnsw')
+ORDER BY (c.relname = $1) DESC, c.relname
+LIMIT 1`, vectorIndexName).Scan(&st.Name, &method, &st.Valid, &st.Definition, &st.SizeBytes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	st.Type = method
+	if estimateRecall && st.Valid {
+		est, err := p.estimateRecall(ctx)
+		if err != nil {
+			return st, err
+		}
+		st.Recall = est
+	}
+	return st, nil
+}
+
+// ReindexVectors builds a fresh ANN index alongside the current one and swaps
+// it in, so queries keep an index until the new one is ready. With
+// concurrently set, writes are not blocked during the build.
+func (p *pgVector) ReindexVectors(ctx context.Context, opts VectorIndexOptions, concurrently bool) (VectorIndexStatus, error) {
+	if p.dimensions <= 0 {
+		return VectorIndexStatus{}, fmt.Errorf("%w: embeddings need fixed dimensions", ErrVectorIndexUnsupported)
+	}
+	var rows int64
+	if err := p.pool.QueryRow(ctx, `SELECT COUNT(*) FROM embeddings`).Scan(&rows); err != nil {
+		return VectorIndexStatus{}, err
+	}
+	tmpName := vectorIndexName + "_new"
+	ddl, err := vectorIndexDDL(tmpName, p.metric, opts, rows, concurrently)
+	if err != nil {
+		return VectorIndexStatus{}, err
+	}
+	conc := ""
+	if concurrently {
+		conc = "CONCURRENTLY "
+	}
+	// CONCURRENTLY cannot run inside a transaction, so each step is issued
+	// on its own.
+	steps := []string{
+		`DROP INDEX ` + conc + `IF EXISTS ` + tmpName,
+		ddl,
+		`DROP INDEX ` + conc + `IF EXISTS ` + vectorIndexName,
+		`ALTER INDEX ` + tmpName + ` RENAME TO ` + vectorIndexName,
+	}
+	for _, stmt := range steps {
+		if _, err := p.pool.Exec(ctx, stmt); err != nil {
+			return VectorIndexStatus{}, err
+		}
+	}
+	p.mu.Lock()
+	p.index.Type = strings.ToLower(strings.TrimSpace(opts.Type))
+	if opts.Probes > 0 {
+		p.index.Probes = opts.Probes
+	}
+	if opts.EfSearch > 0 {
+		p.index.EfSearch = opts.EfSearch
+	}
+	p.mu.Unlock()
+	return p.VectorIndexStatus(ctx, false)
+}
+
+// estimateRecall samples stored vectors and measures how many of the exact
+// top-k neighbours the index returns.
+func (p *pgVector) estimateRecall(ctx context.Context) (*VectorRecallEstimate, error) {
+	rows, err := p.pool.Query(ctx, `SELECT vec::text FROM embeddings WHERE vec IS NOT NULL ORDER BY random() LIMIT $1`, recallSampleSize)
+	if err != nil {
+		return nil, err
+	}
+	var samples []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	est := &VectorRecallEstimate{SampleSize: len(samples), K: recallK}
+	if len(samples) == 0 {
+		return est, nil
+	}
+
+	op, _ := vectorMetricOps(p.metric)
+	query := fmt.Sprintf(`SELECT id, 0::float8, '{}'::jsonb FROM embeddings ORDER BY vec %s $1::vector LIMIT $2`, op)
+	exactSettings := []string{"SET LOCAL enable_indexscan = off", "SET LOCAL enable_bitmapscan = off"}
+	var hits, total int
+	for _, s := range samples {
+		var approx, exact []VectorResult
+		if err := p.withSearchSettings(ctx, p.searchSettings(), func(q pgxQuerier) error {
+			approx, err = scanVectorResults(ctx, q, recallK, query, s, recallK)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		if err := p.withSearchSettings(ctx, exactSettings, func(q pgxQuerier) error {
+			exact, err = scanVectorResults(ctx, q, recallK, query, s, recallK)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		hits += vectorResultOverlap(approx, exact)
+		total += len(exact)
+	}
+	if total > 0 {
+		est.Recall = float64(hits) / float64(total)
+	}
+	return est, nil
+}
+
+func vectorResultOverlap(a, b []VectorResult) int {
+	seen := make(map[string]struct{}, len(b))
+	for _, r := range b {
+		seen[r.ID] = struct{}{}
+	}
+	n := 0
+	for _, r := range a {
+		if _, ok := seen[r.ID]; ok {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,45 @@
+package databases
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultIVFFlatLists(t *testing.T) {
+	cases := map[int64]int{0: 1, 500: 1, 50_000: 50, 4_000_000: 2000}
+	for rows, want := range cases {
+		if got := defaultIVFFlatLists(rows); got != want {
+			t.Errorf("defaultIVFFlatLists(%d) = %d, want %d", rows, got, want)
+		}
+	}
+}
+
+func TestVectorIndexDDL(t *testing.T) {
+	ddl, err := vectorIndexDDL("idx", "l2", VectorIndexOptions{Type: "ivfflat"}, 20_000, true)
+	if err != nil {
+		t.Fatalf("ivfflat: %v", err)
+	}
+	if want := "CREATE INDEX CONCURRENTLY idx ON embeddings USING ivfflat (vec vector_l2_ops) WITH (lists = 20)"; ddl != want {
+		t.Fatalf("ivfflat ddl = %q", ddl)
+	}
+
+	ddl, err = vectorIndexDDL("idx", "cosine", VectorIndexOptions{Type: "HNSW", M: 32}, 0, false)
+	if err != nil {
+		t.Fatalf("hnsw: %v", err)
+	}
+	if !strings.Contains(ddl, "USING hnsw (vec vector_cosine_ops) WITH (m = 32, ef_construction = 64)") || strings.Contains(ddl, "CONCURRENTLY") {
+		t.Fatalf("hnsw ddl = %q", ddl)
+	}
+
+	if _, err := vectorIndexDDL("idx", "cosine", VectorIndexOptions{Type: "btree"}, 0, false); err == nil {
+		t.Fatal("expected error for unknown index type")
+	}
+}
+
+func TestPgVectorSearchSettings(t *testing.T) {
+	p := &pgVector{index: VectorIndexOptions{Probes: 8, EfSearch: 100}}
+	got := p.searchSettings()
+	if len(got) != 2 || got[0] != "SET LOCAL ivfflat.probes = 8" || got[1] != "SET LOCAL hnsw.ef_search = 100" {
+		t.Fatalf("unexpected settings: %v", got)
+	}
+}
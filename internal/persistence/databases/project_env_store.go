@@ -0,0 +1,185 @@
+package databases
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewProjectEnvStore returns a Postgres-backed project env store when a pool
+// is provided, otherwise an in-memory implementation.
+func NewProjectEnvStore(pool *pgxpool.Pool) persistence.ProjectEnvStore {
+	if pool == nil {
+		return &memProjectEnvStore{vars: map[string]map[string]persistence.ProjectEnvVar{}}
+	}
+	return &pgProjectEnvStore{pool: pool}
+}
+
+func projectEnvKey(userID int64, projectID string) string {
+	return strconv.FormatInt(userID, 10) + "\x00" + strings.TrimSpace(projectID)
+}
+
+func sortEnvVars(vars []persistence.ProjectEnvVar) {
+	slices.SortFunc(vars, func(a, b persistence.ProjectEnvVar) int { return strings.Compare(a.Name, b.Name) })
+}
+
+type memProjectEnvStore struct {
+	mu   sync.RWMutex
+	vars map[string]map[string]persistence.ProjectEnvVar
+}
+
+func (s *memProjectEnvStore) Init(ctx context.Context) error { return nil }
+
+func (s *memProjectEnvStore) List(ctx context.Context, userID int64, projectID string) ([]persistence.ProjectEnvVar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m := s.vars[projectEnvKey(userID, projectID)]
+	out := make([]persistence.ProjectEnvVar, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	sortEnvVars(out)
+	return out, nil
+}
+
+func (s *memProjectEnvStore) Set(ctx context.Context, userID int64, projectID, name, value string) error {
+	key := projectEnvKey(userID, projectID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.vars[key] == nil {
+		s.vars[key] = map[string]persistence.ProjectEnvVar{}
+	}
+	s.vars[key][name] = persistence.ProjectEnvVar{Name: name, Value: value, UpdatedAt: time.Now().UTC()}
+	return nil
+}
+
+func (s *memProjectEnvStore) Delete(ctx context.Context, userID int64, projectID, name string) error {
+	key := projectEnvKey(userID, projectID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.vars[key][name]; !ok {
+		return persistence.ErrNotFound
+	}
+	delete(s.vars[key], name)
+	return nil
+}
+
+type pgProjectEnvStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgProjectEnvStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS project_env_vars (
+    user_id BIGINT NOT NULL,
+    project_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, project_id, name)
+);
+`)
+	return err
+}
+
+func (s *pgProjectEnvStore) List(ctx context.Context, userID int64, projectID string) ([]persistence.ProjectEnvVar, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT name, value, updated_at FROM project_env_vars
+WHERE user_id = $1 AND project_id = $2
+ORDER BY name
+`, userID, strings.TrimSpace(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []persistence.ProjectEnvVar
+	for rows.Next() {
+		var v persistence.ProjectEnvVar
+		if err := rows.Scan(&v.Name, &v.Value, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgProjectEnvStore) Set(ctx context.Context, userID int64, projectID, name, value string) error {
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO project_env_vars (user_id, project_id, name, value, updated_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (user_id, project_id, name) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+`, userID, strings.TrimSpace(projectID), name, value)
+	return err
+}
+
+func (s *pgProjectEnvStore) Delete(ctx context.Context, userID int64, projectID, name string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM project_env_vars WHERE user_id = $1 AND project_id = $2 AND name = $3`, userID, strings.TrimSpace(projectID), name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+// encryptedProjectEnvStore seals values with the chat encryption keys so
+// secrets are not stored in plaintext. The project and variable name are
+// bound into the ciphertext.
+type encryptedProjectEnvStore struct {
+	inner  persistence.ProjectEnvStore
+	cipher *chatCipher
+}
+
+// NewEncryptedProjectEnvStore wraps inner with application-layer encryption
+// using the chat encryption keys.
+func NewEncryptedProjectEnvStore(inner persistence.ProjectEnvStore, cfg config.ChatEncryptionConfig) (persistence.ProjectEnvStore, error) {
+	c, err := newChatCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedProjectEnvStore{inner: inner, cipher: c}, nil
+}
+
+func projectEnvScope(projectID, name string) string {
+	return "project-env:" + strings.TrimSpace(projectID) + ":" + name
+}
+
+func (s *encryptedProjectEnvStore) Init(ctx context.Context) error { return s.inner.Init(ctx) }
+
+func (s *encryptedProjectEnvStore) Close() { closeIfPossible(s.inner) }
+
+func (s *encryptedProjectEnvStore) List(ctx context.Context, userID int64, projectID string) ([]persistence.ProjectEnvVar, error) {
+	vars, err := s.inner.List(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range vars {
+		pt, err := s.cipher.decrypt(userID, projectEnvScope(projectID, vars[i].Name), vars[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		vars[i].Value = pt
+	}
+	return vars, nil
+}
+
+func (s *encryptedProjectEnvStore) Set(ctx context.Context, userID int64, projectID, name, value string) error {
+	sealed, err := s.cipher.encrypt(userID, projectEnvScope(projectID, name), value)
+	if err != nil {
+		return err
+	}
+	return s.inner.Set(ctx, userID, projectID, name, sealed)
+}
+
+func (s *encryptedProjectEnvStore) Delete(ctx context.Context, userID int64, projectID, name string) error {
+	return s.inner.Delete(ctx, userID, projectID, name)
+}
@@ -0,0 +1,63 @@
+package databases
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+)
+
+func TestMemProjectEnvStore_SetListDelete(t *testing.T) {
+	store := NewProjectEnvStore(nil)
+	ctx := context.Background()
+
+	for name, value := range map[string]string{"B_TOKEN": "b", "A_KEY": "a"} {
+		if err := store.Set(ctx, 1, "proj", name, value); err != nil {
+			t.Fatalf("Set error: %v", err)
+		}
+	}
+	vars, err := store.List(ctx, 1, "proj")
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(vars) != 2 || vars[0].Name != "A_KEY" || vars[0].Value != "a" || vars[1].Name != "B_TOKEN" {
+		t.Fatalf("unexpected vars: %+v", vars)
+	}
+	if other, _ := store.List(ctx, 2, "proj"); len(other) != 0 {
+		t.Errorf("expected vars scoped to user, got %+v", other)
+	}
+	if err := store.Delete(ctx, 1, "proj", "A_KEY"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := store.Delete(ctx, 1, "proj", "A_KEY"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedProjectEnvStore_SealsValues(t *testing.T) {
+	inner := NewProjectEnvStore(nil)
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	store, err := NewEncryptedProjectEnvStore(inner, config.ChatEncryptionConfig{Enabled: true, Keys: map[string]string{"k1": key}})
+	if err != nil {
+		t.Fatalf("NewEncryptedProjectEnvStore error: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Set(ctx, 1, "proj", "API_KEY", "s3cret"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	raw, _ := inner.List(ctx, 1, "proj")
+	if len(raw) != 1 || !isEncryptedValue(raw[0].Value) {
+		t.Fatalf("expected ciphertext at rest, got %+v", raw)
+	}
+	vars, err := store.List(ctx, 1, "proj")
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(vars) != 1 || vars[0].Value != "s3cret" {
+		t.Fatalf("unexpected vars: %+v", vars)
+	}
+}
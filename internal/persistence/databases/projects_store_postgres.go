@@ -32,7 +32,18 @@ func (s *pgProjectsStore) Init(ctx context.Context) error {
 	if s.pool == nil {
 		return errors.New("postgres projects store requires pool")
 	}
-	_, err := s.pool.Exec(ctx, `
+	_, err := s.pool.Exec(ctx, projectsSchema+`
+-- Index for listing files in a directory (parent path lookup)
+CREATE INDEX IF NOT EXISTS project_files_parent_idx ON project_files(project_id, (CASE 
+    WHEN path NOT LIKE '%/%' THEN '.'
+    ELSE LEFT(path, LENGTH(path) - LENGTH(SUBSTRING(path FROM '[^/]*$')) - 1)
+END));
+`)
+	return err
+}
+
+// projectsSchema is shared by the Postgres and SQLite projects stores.
+const projectsSchema = `
 -- Projects table stores project metadata
 CREATE TABLE IF NOT EXISTS projects (
     id UUID PRIMARY KEY,
@@ -61,15 +72,7 @@ CREATE TABLE IF NOT EXISTS project_files (
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     PRIMARY KEY (project_id, path)
 );
-
--- Index for listing files in a directory (parent path lookup)
-CREATE INDEX IF NOT EXISTS project_files_parent_idx ON project_files(project_id, (CASE 
-    WHEN path NOT LIKE '%/%' THEN '.'
-    ELSE LEFT(path, LENGTH(path) - LENGTH(SUBSTRING(path FROM '[^/]*$')) - 1)
-END));
-`)
-	return err
-}
+`
 
 func (s *pgProjectsStore) Create(ctx context.Context, userID int64, name string) (persistence.Project, error) {
 	if strings.TrimSpace(name) == "" {
@@ -0,0 +1,234 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
+)
+
+// NewSQLiteProjectsStore returns a projects store backed by a shared SQLite
+// database.
+func NewSQLiteProjectsStore(db *sql.DB) persistence.ProjectsStore {
+	return &sqliteProjectsStore{db: db}
+}
+
+type sqliteProjectsStore struct {
+	db *sql.DB
+}
+
+const (
+	projectColumns     = `id, user_id, name, created_at, updated_at, revision, bytes, file_count, storage_backend`
+	projectFileColumns = `project_id, path, name, is_dir, size, mod_time, etag, updated_at`
+)
+
+func (s *sqliteProjectsStore) Init(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("sqlite projects store requires db")
+	}
+	return sqlite.Init(ctx, s.db, projectsSchema)
+}
+
+func (s *sqliteProjectsStore) Create(ctx context.Context, userID int64, name string) (persistence.Project, error) {
+	if strings.TrimSpace(name) == "" {
+		name = "Untitled"
+	}
+	id := uuid.NewString()
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO projects (id, user_id, name, created_at, updated_at, revision, bytes, file_count, storage_backend)
+VALUES (?, ?, ?, ?, ?, 1, 0, 0, 'filesystem')`, id, userID, name, now, now); err != nil {
+		return persistence.Project{}, err
+	}
+	return s.get(ctx, id)
+}
+
+func (s *sqliteProjectsStore) get(ctx context.Context, projectID string) (persistence.Project, error) {
+	var p persistence.Project
+	err := s.db.QueryRowContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE id = ?`, projectID).
+		Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt, &p.UpdatedAt, &p.Revision, &p.Bytes, &p.FileCount, &p.StorageBackend)
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.Project{}, persistence.ErrNotFound
+	}
+	return p, err
+}
+
+func (s *sqliteProjectsStore) Get(ctx context.Context, userID int64, projectID string) (persistence.Project, error) {
+	p, err := s.get(ctx, projectID)
+	if err != nil {
+		return persistence.Project{}, err
+	}
+	if p.UserID != userID {
+		return persistence.Project{}, persistence.ErrForbidden
+	}
+	return p, nil
+}
+
+func (s *sqliteProjectsStore) List(ctx context.Context, userID int64) ([]persistence.Project, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+projectColumns+`
+FROM projects
+WHERE user_id = ?
+ORDER BY updated_at DESC, name ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []persistence.Project{}
+	for rows.Next() {
+		var p persistence.Project
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt, &p.UpdatedAt, &p.Revision, &p.Bytes, &p.FileCount, &p.StorageBackend); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+func (s *sqliteProjectsStore) Update(ctx context.Context, p persistence.Project) (persistence.Project, error) {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE projects
+SET name = ?, updated_at = ?, revision = revision + 1, storage_backend = ?
+WHERE id = ? AND revision = ?`, p.Name, time.Now().UTC(), p.StorageBackend, p.ID, p.Revision)
+	if err != nil {
+		return persistence.Project{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := s.get(ctx, p.ID); err != nil {
+			return persistence.Project{}, err
+		}
+		return persistence.Project{}, persistence.ErrRevisionConflict
+	}
+	return s.get(ctx, p.ID)
+}
+
+func (s *sqliteProjectsStore) UpdateStats(ctx context.Context, projectID string, bytes int64, fileCount int) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE projects SET bytes = ?, file_count = ?, updated_at = ? WHERE id = ?`,
+		bytes, fileCount, time.Now().UTC(), projectID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqliteProjectsStore) Delete(ctx context.Context, userID int64, projectID string) error {
+	p, err := s.get(ctx, projectID)
+	if errors.Is(err, persistence.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.UserID != userID {
+		return persistence.ErrForbidden
+	}
+	_, err = s.db.ExecContext(ctx, `DELETE FROM projects WHERE id = ?`, projectID)
+	return err
+}
+
+// --- File Index Operations ---
+
+func (s *sqliteProjectsStore) IndexFile(ctx context.Context, f persistence.ProjectFile) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO project_files (`+projectFileColumns+`)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (project_id, path) DO UPDATE SET
+    name = excluded.name,
+    is_dir = excluded.is_dir,
+    size = excluded.size,
+    mod_time = excluded.mod_time,
+    etag = excluded.etag,
+    updated_at = excluded.updated_at`,
+		f.ProjectID, f.Path, f.Name, f.IsDir, f.Size, f.ModTime.UTC(), f.ETag, time.Now().UTC())
+	return err
+}
+
+func (s *sqliteProjectsStore) RemoveFileIndex(ctx context.Context, projectID, filePath string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM project_files WHERE project_id = ? AND path = ?`, projectID, filePath)
+	return err
+}
+
+func (s *sqliteProjectsStore) RemoveFileIndexPrefix(ctx context.Context, projectID, pathPrefix string) error {
+	pattern := pathPrefix
+	if !strings.HasSuffix(pattern, "/") {
+		pattern += "/"
+	}
+	_, err := s.db.ExecContext(ctx, `
+DELETE FROM project_files
+WHERE project_id = ? AND (path = ? OR path LIKE ?)`, projectID, pathPrefix, pattern+"%")
+	return err
+}
+
+func (s *sqliteProjectsStore) ListFiles(ctx context.Context, projectID, dirPath string) ([]persistence.ProjectFile, error) {
+	dirPath = normalizePath(dirPath)
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if dirPath == "." || dirPath == "" {
+		rows, err = s.db.QueryContext(ctx, `
+SELECT `+projectFileColumns+`
+FROM project_files
+WHERE project_id = ? AND path NOT LIKE '%/%'
+ORDER BY is_dir DESC, name ASC`, projectID)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `
+SELECT `+projectFileColumns+`
+FROM project_files
+WHERE project_id = ?1
+  AND path LIKE ?2 || '/%'
+  AND path NOT LIKE ?2 || '/%/%'
+ORDER BY is_dir DESC, name ASC`, projectID, dirPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []persistence.ProjectFile{}
+	for rows.Next() {
+		f, err := scanSQLiteProjectFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+func (s *sqliteProjectsStore) GetFile(ctx context.Context, projectID, filePath string) (persistence.ProjectFile, error) {
+	filePath = normalizePath(filePath)
+	f, err := scanSQLiteProjectFile(s.db.QueryRowContext(ctx, `
+SELECT `+projectFileColumns+`
+FROM project_files
+WHERE project_id = ? AND path = ?`, projectID, filePath))
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.ProjectFile{}, persistence.ErrNotFound
+	}
+	return f, err
+}
+
+func scanSQLiteProjectFile(row interface{ Scan(...any) error }) (persistence.ProjectFile, error) {
+	var f persistence.ProjectFile
+	var modTime, updatedAt sql.NullTime
+	if err := row.Scan(&f.ProjectID, &f.Path, &f.Name, &f.IsDir, &f.Size, &modTime, &f.ETag, &updatedAt); err != nil {
+		return persistence.ProjectFile{}, err
+	}
+	if modTime.Valid {
+		f.ModTime = modTime.Time
+	}
+	if updatedAt.Valid {
+		f.UpdatedAt = updatedAt.Time
+	}
+	return f, nil
+}
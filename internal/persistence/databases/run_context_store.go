@@ -0,0 +1,158 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxMemoryRunContexts bounds how many runs the in-memory store retains;
+// the oldest run is evicted first.
+const maxMemoryRunContexts = 256
+
+// NewRunContextStore returns a Postgres-backed run context store when a pool
+// is provided, otherwise an in-memory implementation.
+func NewRunContextStore(pool *pgxpool.Pool) persistence.RunContextStore {
+	if pool == nil {
+		return &memRunContextStore{runs: map[string]map[int]persistence.RunContextSnapshot{}}
+	}
+	return &pgRunContextStore{pool: pool}
+}
+
+type memRunContextStore struct {
+	mu    sync.RWMutex
+	runs  map[string]map[int]persistence.RunContextSnapshot
+	order []string
+}
+
+func (s *memRunContextStore) Init(ctx context.Context) error { return nil }
+
+func (s *memRunContextStore) Record(ctx context.Context, snap persistence.RunContextSnapshot) error {
+	snap.RunID = strings.TrimSpace(snap.RunID)
+	if snap.RunID == "" {
+		return errors.New("run context: missing run id")
+	}
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now().UTC()
+	}
+	snap.Messages = slices.Clone(snap.Messages)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	steps, ok := s.runs[snap.RunID]
+	if !ok {
+		if len(s.order) >= maxMemoryRunContexts {
+			delete(s.runs, s.order[0])
+			s.order = s.order[1:]
+		}
+		steps = map[int]persistence.RunContextSnapshot{}
+		s.runs[snap.RunID] = steps
+		s.order = append(s.order, snap.RunID)
+	}
+	steps[snap.Step] = snap
+	return nil
+}
+
+func (s *memRunContextStore) Get(ctx context.Context, runID string, step int) (persistence.RunContextSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap, ok := s.runs[strings.TrimSpace(runID)][step]
+	if !ok {
+		return persistence.RunContextSnapshot{}, persistence.ErrNotFound
+	}
+	snap.Messages = slices.Clone(snap.Messages)
+	return snap, nil
+}
+
+func (s *memRunContextStore) Steps(ctx context.Context, runID string) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	steps := make([]int, 0, len(s.runs[strings.TrimSpace(runID)]))
+	for step := range s.runs[strings.TrimSpace(runID)] {
+		steps = append(steps, step)
+	}
+	slices.Sort(steps)
+	return steps, nil
+}
+
+type pgRunContextStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgRunContextStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS run_contexts (
+    run_id TEXT NOT NULL,
+    step INTEGER NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    message_count INTEGER NOT NULL DEFAULT 0,
+    messages JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (run_id, step)
+);
+CREATE INDEX IF NOT EXISTS idx_run_contexts_created_at ON run_contexts(created_at);
+`)
+	return err
+}
+
+func (s *pgRunContextStore) Record(ctx context.Context, snap persistence.RunContextSnapshot) error {
+	snap.RunID = strings.TrimSpace(snap.RunID)
+	if snap.RunID == "" {
+		return errors.New("run context: missing run id")
+	}
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO run_contexts (run_id, step, model, message_count, messages, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (run_id, step) DO UPDATE SET
+    model = EXCLUDED.model,
+    message_count = EXCLUDED.message_count,
+    messages = EXCLUDED.messages,
+    created_at = EXCLUDED.created_at
+`, snap.RunID, snap.Step, snap.Model, snap.MessageCount, []byte(snap.Messages), snap.CreatedAt)
+	return err
+}
+
+func (s *pgRunContextStore) Get(ctx context.Context, runID string, step int) (persistence.RunContextSnapshot, error) {
+	snap := persistence.RunContextSnapshot{RunID: strings.TrimSpace(runID), Step: step}
+	var messages []byte
+	err := s.pool.QueryRow(ctx, `
+SELECT model, message_count, messages, created_at
+FROM run_contexts WHERE run_id = $1 AND step = $2
+`, snap.RunID, step).Scan(&snap.Model, &snap.MessageCount, &messages, &snap.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return persistence.RunContextSnapshot{}, persistence.ErrNotFound
+	}
+	if err != nil {
+		return persistence.RunContextSnapshot{}, err
+	}
+	snap.Messages = messages
+	return snap, nil
+}
+
+func (s *pgRunContextStore) Steps(ctx context.Context, runID string) ([]int, error) {
+	rows, err := s.pool.Query(ctx, `SELECT step FROM run_contexts WHERE run_id = $1 ORDER BY step`, strings.TrimSpace(runID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var steps []int
+	for rows.Next() {
+		var step int
+		if err := rows.Scan(&step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
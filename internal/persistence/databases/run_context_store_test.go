@@ -0,0 +1,59 @@
+package databases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemRunContextStore_RecordAndGet(t *testing.T) {
+	store := NewRunContextStore(nil)
+	ctx := context.Background()
+
+	for step := range 3 {
+		snap := persistence.RunContextSnapshot{RunID: "run_1", Step: step, MessageCount: step + 1, Messages: json.RawMessage(fmt.Sprintf(`[{"n":%d}]`, step))}
+		if err := store.Record(ctx, snap); err != nil {
+			t.Fatalf("Record error: %v", err)
+		}
+	}
+
+	got, err := store.Get(ctx, "run_1", 1)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if got.MessageCount != 2 || string(got.Messages) != `[{"n":1}]` || got.CreatedAt.IsZero() {
+		t.Errorf("unexpected snapshot: %+v", got)
+	}
+	steps, err := store.Steps(ctx, "run_1")
+	if err != nil {
+		t.Fatalf("Steps error: %v", err)
+	}
+	if !slices.Equal(steps, []int{0, 1, 2}) {
+		t.Errorf("expected steps [0 1 2], got %v", steps)
+	}
+	if _, err := store.Get(ctx, "run_1", 9); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemRunContextStore_EvictsOldestRun(t *testing.T) {
+	store := NewRunContextStore(nil)
+	ctx := context.Background()
+
+	for i := range maxMemoryRunContexts + 1 {
+		if err := store.Record(ctx, persistence.RunContextSnapshot{RunID: fmt.Sprintf("run_%d", i), Messages: json.RawMessage(`[]`)}); err != nil {
+			t.Fatalf("Record error: %v", err)
+		}
+	}
+	if _, err := store.Get(ctx, "run_0", 0); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("expected oldest run to be evicted, got %v", err)
+	}
+	if _, err := store.Get(ctx, fmt.Sprintf("run_%d", maxMemoryRunContexts), 0); err != nil {
+		t.Errorf("expected newest run to be retained, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewSecretsStore returns a Postgres-backed secrets store when a pool is
+// provided, otherwise an in-memory implementation.
+func NewSecretsStore(pool *pgxpool.Pool) persistence.SecretsStore {
+	if pool == nil {
+		return &memSecretsStore{secrets: map[string]persistence.Secret{}}
+	}
+	return &pgSecretsStore{pool: pool}
+}
+
+type memSecretsStore struct {
+	mu      sync.RWMutex
+	secrets map[string]persistence.Secret
+}
+
+func (s *memSecretsStore) Init(ctx context.Context) error { return nil }
+
+func (s *memSecretsStore) List(ctx context.Context) ([]persistence.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]persistence.Secret, 0, len(s.secrets))
+	for _, sec := range s.secrets {
+		out = append(out, sec)
+	}
+	slices.SortFunc(out, func(a, b persistence.Secret) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (s *memSecretsStore) Get(ctx context.Context, name string) (persistence.Secret, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sec, ok := s.secrets[name]
+	return sec, ok, nil
+}
+
+func (s *memSecretsStore) Put(ctx context.Context, in persistence.Secret) (persistence.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	sec, ok := s.secrets[in.Name]
+	if !ok {
+		sec = persistence.Secret{Name: in.Name, CreatedAt: now}
+	}
+	sec.Value = in.Value
+	sec.Domains = slices.Clone(in.Domains)
+	sec.UserIDs = slices.Clone(in.UserIDs)
+	sec.Version++
+	sec.UpdatedAt = now
+	s.secrets[in.Name] = sec
+	return sec, nil
+}
+
+func (s *memSecretsStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[name]; !ok {
+		return persistence.ErrNotFound
+	}
+	delete(s.secrets, name)
+	return nil
+}
+
+type pgSecretsStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgSecretsStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS secrets (
+    name TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS domains TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS user_ids BIGINT[] NOT NULL DEFAULT '{}';
+`)
+	return err
+}
+
+func (s *pgSecretsStore) List(ctx context.Context) ([]persistence.Secret, error) {
+	rows, err := s.pool.Query(ctx, `SELECT name, value, domains, user_ids, version, created_at, updated_at FROM secrets ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []persistence.Secret
+	for rows.Next() {
+		var sec persistence.Secret
+		if err := rows.Scan(&sec.Name, &sec.Value, &sec.Domains, &sec.UserIDs, &sec.Version, &sec.CreatedAt, &sec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, sec)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgSecretsStore) Get(ctx context.Context, name string) (persistence.Secret, bool, error) {
+	var sec persistence.Secret
+	err := s.pool.QueryRow(ctx, `SELECT name, value, domains, user_ids, version, created_at, updated_at FROM secrets WHERE name = $1`, name).
+		Scan(&sec.Name, &sec.Value, &sec.Domains, &sec.UserIDs, &sec.Version, &sec.CreatedAt, &sec.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return persistence.Secret{}, false, nil
+	}
+	if err != nil {
+		return persistence.Secret{}, false, err
+	}
+	return sec, true, nil
+}
+
+func (s *pgSecretsStore) Put(ctx context.Context, in persistence.Secret) (persistence.Secret, error) {
+	domains, userIDs := in.Domains, in.UserIDs
+	if domains == nil {
+		domains = []string{}
+	}
+	if userIDs == nil {
+		userIDs = []int64{}
+	}
+	var sec persistence.Secret
+	err := s.pool.QueryRow(ctx, `
+INSERT INTO secrets (name, value, domains, user_ids, version, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 1, NOW(), NOW())
+ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, domains = EXCLUDED.domains, user_ids = EXCLUDED.user_ids,
+    version = secrets.version + 1, updated_at = NOW()
+RETURNING name, value, domains, user_ids, version, created_at, updated_at
+`, in.Name, in.Value, domains, userIDs).Scan(&sec.Name, &sec.Value, &sec.Domains, &sec.UserIDs, &sec.Version, &sec.CreatedAt, &sec.UpdatedAt)
+	return sec, err
+}
+
+func (s *pgSecretsStore) Delete(ctx context.Context, name string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM secrets WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (s *pgSecretsStore) Close() { s.pool.Close() }
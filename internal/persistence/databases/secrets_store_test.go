@@ -0,0 +1,32 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemSecretsStore_PutBumpsVersion(t *testing.T) {
+	store := NewSecretsStore(nil)
+	ctx := context.Background()
+
+	first, err := store.Put(ctx, persistence.Secret{Name: "API_KEY", Value: "a", Domains: []string{"api.example.com"}})
+	if err != nil || first.Version != 1 {
+		t.Fatalf("Put: %+v (%v)", first, err)
+	}
+	second, _ := store.Put(ctx, persistence.Secret{Name: "API_KEY", Value: "b", UserIDs: []int64{7}})
+	if second.Version != 2 || second.Value != "b" || !second.CreatedAt.Equal(first.CreatedAt) || len(second.Domains) != 0 || len(second.UserIDs) != 1 {
+		t.Fatalf("rotate: %+v", second)
+	}
+	if got, ok, _ := store.Get(ctx, "API_KEY"); !ok || got.Value != "b" {
+		t.Fatalf("Get: %+v %v", got, ok)
+	}
+	if err := store.Delete(ctx, "API_KEY"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "API_KEY"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
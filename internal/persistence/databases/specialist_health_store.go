@@ -0,0 +1,100 @@
+package databases
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewSpecialistHealthStore returns a Postgres-backed health store when a pool
+// is provided, otherwise an in-memory one.
+func NewSpecialistHealthStore(pool *pgxpool.Pool) persistence.SpecialistHealthStore {
+	if pool == nil {
+		return &memSpecialistHealthStore{m: map[int64]map[string]persistence.SpecialistHealth{}}
+	}
+	return &pgSpecialistHealthStore{pool: pool}
+}
+
+type memSpecialistHealthStore struct {
+	mu sync.RWMutex
+	m  map[int64]map[string]persistence.SpecialistHealth
+}
+
+func (s *memSpecialistHealthStore) Init(ctx context.Context) error { return nil }
+
+func (s *memSpecialistHealthStore) Record(ctx context.Context, h persistence.SpecialistHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m[h.UserID] == nil {
+		s.m[h.UserID] = map[string]persistence.SpecialistHealth{}
+	}
+	s.m[h.UserID][h.Name] = h
+	return nil
+}
+
+func (s *memSpecialistHealthStore) List(ctx context.Context, userID int64) ([]persistence.SpecialistHealth, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]persistence.SpecialistHealth, 0, len(s.m[userID]))
+	for _, h := range s.m[userID] {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+type pgSpecialistHealthStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgSpecialistHealthStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS specialist_health (
+  user_id BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  state TEXT NOT NULL,
+  latency_ms BIGINT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  checked_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (user_id, name)
+);`)
+	return err
+}
+
+func (s *pgSpecialistHealthStore) Record(ctx context.Context, h persistence.SpecialistHealth) error {
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO specialist_health (user_id, name, state, latency_ms, error, checked_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, name) DO UPDATE SET
+  state = EXCLUDED.state,
+  latency_ms = EXCLUDED.latency_ms,
+  error = EXCLUDED.error,
+  checked_at = EXCLUDED.checked_at`,
+		h.UserID, h.Name, h.State, h.LatencyMs, h.Error, h.CheckedAt)
+	return err
+}
+
+func (s *pgSpecialistHealthStore) List(ctx context.Context, userID int64) ([]persistence.SpecialistHealth, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT user_id, name, state, latency_ms, error, checked_at
+FROM specialist_health
+WHERE user_id = $1
+ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []persistence.SpecialistHealth{}
+	for rows.Next() {
+		var h persistence.SpecialistHealth
+		if err := rows.Scan(&h.UserID, &h.Name, &h.State, &h.LatencyMs, &h.Error, &h.CheckedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
@@ -80,7 +80,37 @@ type pgSpecStore struct {
 }
 
 func (s *pgSpecStore) Init(ctx context.Context) error {
-	_, err := s.pool.Exec(ctx, `
+	_, err := s.pool.Exec(ctx, specialistsTableSchema+`
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS user_id BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS summary_context_window_tokens INT NOT NULL DEFAULT 0;
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS auto_discover BOOLEAN DEFAULT NULL;
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS exec_backend TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE specialists
+	ADD COLUMN IF NOT EXISTS voice TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE specialists
+	DROP CONSTRAINT IF EXISTS specialists_name_key;
+`+specialistsIndexSchema)
+	return err
+}
+
+// specialistsTableSchema and specialistsIndexSchema are shared by the
+// Postgres and SQLite specialists stores.
+const specialistsTableSchema = `
 CREATE TABLE IF NOT EXISTS specialists (
 	id SERIAL PRIMARY KEY,
 	user_id BIGINT NOT NULL DEFAULT 0,
@@ -98,34 +128,18 @@ CREATE TABLE IF NOT EXISTS specialists (
 	system TEXT NOT NULL DEFAULT '',
 	extra_headers JSONB NOT NULL DEFAULT '{}',
 	extra_params JSONB NOT NULL DEFAULT '{}',
-	provider TEXT NOT NULL DEFAULT ''
+	provider TEXT NOT NULL DEFAULT '',
+	exec_backend TEXT NOT NULL DEFAULT '',
+	voice TEXT NOT NULL DEFAULT ''
 );
+`
 
-ALTER TABLE specialists
-	ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
-
-ALTER TABLE specialists
-	ADD COLUMN IF NOT EXISTS user_id BIGINT NOT NULL DEFAULT 0;
-
-ALTER TABLE specialists
-	ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT '';
-
-ALTER TABLE specialists
-	ADD COLUMN IF NOT EXISTS summary_context_window_tokens INT NOT NULL DEFAULT 0;
-
-ALTER TABLE specialists
-	ADD COLUMN IF NOT EXISTS auto_discover BOOLEAN DEFAULT NULL;
-
-ALTER TABLE specialists
-	DROP CONSTRAINT IF EXISTS specialists_name_key;
-
+const specialistsIndexSchema = `
 CREATE UNIQUE INDEX IF NOT EXISTS specialists_user_name_idx ON specialists(user_id, name);
-`)
-	return err
-}
+`
 
 func (s *pgSpecStore) List(ctx context.Context, userID int64) ([]persistence.Specialist, error) {
-	rows, err := s.pool.Query(ctx, `SELECT id,user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider FROM specialists WHERE user_id=$1 ORDER BY LOWER(name)`, userID)
+	rows, err := s.pool.Query(ctx, `SELECT id,user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider,exec_backend,voice FROM specialists WHERE user_id=$1 ORDER BY LOWER(name)`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +148,7 @@ func (s *pgSpecStore) List(ctx context.Context, userID int64) ([]persistence.Spe
 	for rows.Next() {
 		var sp persistence.Specialist
 		var allow, headers, params []byte
-		if err := rows.Scan(&sp.ID, &sp.UserID, &sp.Name, &sp.Description, &sp.BaseURL, &sp.APIKey, &sp.Model, &sp.SummaryContextWindowTokens, &sp.EnableTools, &sp.AutoDiscover, &sp.Paused, &allow, &sp.ReasoningEffort, &sp.System, &headers, &params, &sp.Provider); err != nil {
+		if err := rows.Scan(&sp.ID, &sp.UserID, &sp.Name, &sp.Description, &sp.BaseURL, &sp.APIKey, &sp.Model, &sp.SummaryContextWindowTokens, &sp.EnableTools, &sp.AutoDiscover, &sp.Paused, &allow, &sp.ReasoningEffort, &sp.System, &headers, &params, &sp.Provider, &sp.ExecBackend, &sp.Voice); err != nil {
 			return nil, err
 		}
 		_ = json.Unmarshal(allow, &sp.AllowTools)
@@ -146,10 +160,10 @@ func (s *pgSpecStore) List(ctx context.Context, userID int64) ([]persistence.Spe
 }
 
 func (s *pgSpecStore) GetByName(ctx context.Context, userID int64, name string) (persistence.Specialist, bool, error) {
-	row := s.pool.QueryRow(ctx, `SELECT id,user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider FROM specialists WHERE user_id=$1 AND name=$2`, userID, name)
+	row := s.pool.QueryRow(ctx, `SELECT id,user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider,exec_backend,voice FROM specialists WHERE user_id=$1 AND name=$2`, userID, name)
 	var sp persistence.Specialist
 	var allow, headers, params []byte
-	if err := row.Scan(&sp.ID, &sp.UserID, &sp.Name, &sp.Description, &sp.BaseURL, &sp.APIKey, &sp.Model, &sp.SummaryContextWindowTokens, &sp.EnableTools, &sp.AutoDiscover, &sp.Paused, &allow, &sp.ReasoningEffort, &sp.System, &headers, &params, &sp.Provider); err != nil {
+	if err := row.Scan(&sp.ID, &sp.UserID, &sp.Name, &sp.Description, &sp.BaseURL, &sp.APIKey, &sp.Model, &sp.SummaryContextWindowTokens, &sp.EnableTools, &sp.AutoDiscover, &sp.Paused, &allow, &sp.ReasoningEffort, &sp.System, &headers, &params, &sp.Provider, &sp.ExecBackend, &sp.Voice); err != nil {
 		return persistence.Specialist{}, false, nil
 	}
 	_ = json.Unmarshal(allow, &sp.AllowTools)
@@ -166,8 +180,8 @@ func (s *pgSpecStore) Upsert(ctx context.Context, userID int64, sp persistence.S
 	headers, _ := json.Marshal(sp.ExtraHeaders)
 	params, _ := json.Marshal(sp.ExtraParams)
 	row := s.pool.QueryRow(ctx, `
-INSERT INTO specialists(user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider)
-VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
+INSERT INTO specialists(user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider,exec_backend,voice)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
 	ON CONFLICT (user_id, name) DO UPDATE SET description=EXCLUDED.description, base_url=EXCLUDED.base_url,
 		api_key=CASE
 			WHEN NULLIF(BTRIM(EXCLUDED.api_key), '') IS NULL THEN specialists.api_key
@@ -175,8 +189,9 @@ VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
 		END,
 		model=EXCLUDED.model,
 	summary_context_window_tokens=EXCLUDED.summary_context_window_tokens, enable_tools=EXCLUDED.enable_tools, auto_discover=EXCLUDED.auto_discover, paused=EXCLUDED.paused, allow_tools=EXCLUDED.allow_tools,
-	reasoning_effort=EXCLUDED.reasoning_effort, system=EXCLUDED.system, extra_headers=EXCLUDED.extra_headers, extra_params=EXCLUDED.extra_params, provider=EXCLUDED.provider
-RETURNING id;`, userID, sp.Name, sp.Description, sp.BaseURL, sp.APIKey, sp.Model, sp.SummaryContextWindowTokens, sp.EnableTools, sp.AutoDiscover, sp.Paused, allow, sp.ReasoningEffort, sp.System, headers, params, sp.Provider)
+	reasoning_effort=EXCLUDED.reasoning_effort, system=EXCLUDED.system, extra_headers=EXCLUDED.extra_headers, extra_params=EXCLUDED.extra_params, provider=EXCLUDED.provider,
+	exec_backend=EXCLUDED.exec_backend, voice=EXCLUDED.voice
+RETURNING id;`, userID, sp.Name, sp.Description, sp.BaseURL, sp.APIKey, sp.Model, sp.SummaryContextWindowTokens, sp.EnableTools, sp.AutoDiscover, sp.Paused, allow, sp.ReasoningEffort, sp.System, headers, params, sp.Provider, sp.ExecBackend, sp.Voice)
 	if err := row.Scan(&sp.ID); err != nil {
 		return persistence.Specialist{}, err
 	}
@@ -0,0 +1,109 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
+)
+
+// NewSQLiteSpecialistsStore returns a specialists store backed by a shared
+// SQLite database.
+func NewSQLiteSpecialistsStore(db *sql.DB) persistence.SpecialistsStore {
+	return &sqliteSpecStore{db: db}
+}
+
+type sqliteSpecStore struct {
+	db *sql.DB
+}
+
+const specialistColumns = `id,user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider,exec_backend,voice`
+
+func (s *sqliteSpecStore) Init(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("sqlite specialists store requires db")
+	}
+	if err := sqlite.Init(ctx, s.db, specialistsTableSchema+specialistsIndexSchema); err != nil {
+		return err
+	}
+	if err := sqlite.AddColumn(ctx, s.db, "specialists", "exec_backend", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	return sqlite.AddColumn(ctx, s.db, "specialists", "voice", "TEXT NOT NULL DEFAULT ''")
+}
+
+func scanSQLiteSpecialist(row interface{ Scan(...any) error }) (persistence.Specialist, error) {
+	var sp persistence.Specialist
+	var allow, headers, params []byte
+	if err := row.Scan(&sp.ID, &sp.UserID, &sp.Name, &sp.Description, &sp.BaseURL, &sp.APIKey, &sp.Model, &sp.SummaryContextWindowTokens, &sp.EnableTools, &sp.AutoDiscover, &sp.Paused, &allow, &sp.ReasoningEffort, &sp.System, &headers, &params, &sp.Provider, &sp.ExecBackend, &sp.Voice); err != nil {
+		return persistence.Specialist{}, err
+	}
+	_ = json.Unmarshal(allow, &sp.AllowTools)
+	_ = json.Unmarshal(headers, &sp.ExtraHeaders)
+	_ = json.Unmarshal(params, &sp.ExtraParams)
+	return sp, nil
+}
+
+func (s *sqliteSpecStore) List(ctx context.Context, userID int64) ([]persistence.Specialist, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+specialistColumns+` FROM specialists WHERE user_id=? ORDER BY LOWER(name)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []persistence.Specialist
+	for rows.Next() {
+		sp, err := scanSQLiteSpecialist(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sp)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteSpecStore) GetByName(ctx context.Context, userID int64, name string) (persistence.Specialist, bool, error) {
+	sp, err := scanSQLiteSpecialist(s.db.QueryRowContext(ctx, `SELECT `+specialistColumns+` FROM specialists WHERE user_id=? AND name=?`, userID, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.Specialist{}, false, nil
+	}
+	if err != nil {
+		return persistence.Specialist{}, false, err
+	}
+	return sp, true, nil
+}
+
+func (s *sqliteSpecStore) Upsert(ctx context.Context, userID int64, sp persistence.Specialist) (persistence.Specialist, error) {
+	if strings.TrimSpace(sp.Name) == "" {
+		return persistence.Specialist{}, errors.New("name required")
+	}
+	allow, _ := json.Marshal(sp.AllowTools)
+	headers, _ := json.Marshal(sp.ExtraHeaders)
+	params, _ := json.Marshal(sp.ExtraParams)
+	row := s.db.QueryRowContext(ctx, `
+INSERT INTO specialists(user_id,name,description,base_url,api_key,model,summary_context_window_tokens,enable_tools,auto_discover,paused,allow_tools,reasoning_effort,system,extra_headers,extra_params,provider,exec_backend,voice)
+VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+ON CONFLICT (user_id, name) DO UPDATE SET description=excluded.description, base_url=excluded.base_url,
+	api_key=CASE
+		WHEN NULLIF(TRIM(excluded.api_key), '') IS NULL THEN specialists.api_key
+		ELSE excluded.api_key
+	END,
+	model=excluded.model,
+	summary_context_window_tokens=excluded.summary_context_window_tokens, enable_tools=excluded.enable_tools, auto_discover=excluded.auto_discover, paused=excluded.paused, allow_tools=excluded.allow_tools,
+	reasoning_effort=excluded.reasoning_effort, system=excluded.system, extra_headers=excluded.extra_headers, extra_params=excluded.extra_params, provider=excluded.provider,
+	exec_backend=excluded.exec_backend, voice=excluded.voice
+RETURNING id`, userID, sp.Name, sp.Description, sp.BaseURL, sp.APIKey, sp.Model, sp.SummaryContextWindowTokens, sp.EnableTools, sp.AutoDiscover, sp.Paused, string(allow), sp.ReasoningEffort, sp.System, string(headers), string(params), sp.Provider, sp.ExecBackend, sp.Voice)
+	if err := row.Scan(&sp.ID); err != nil {
+		return persistence.Specialist{}, err
+	}
+	sp.UserID = userID
+	return sp, nil
+}
+
+func (s *sqliteSpecStore) Delete(ctx context.Context, userID int64, name string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM specialists WHERE user_id=? AND name=?`, userID, name)
+	return err
+}
@@ -0,0 +1,155 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"manifold/internal/flow"
+	"manifold/internal/persistence"
+	"manifold/internal/persistence/sqlite"
+)
+
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "manifold.db"))
+	if errors.Is(err, sqlite.ErrDriverUnavailable) {
+		t.Skip("sqlite driver not compiled in")
+	}
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLiteChatStore(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	store := NewSQLiteChatStore(db)
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	owner := int64ptr(1)
+	sess, err := store.EnsureSession(ctx, owner, "s1", "First")
+	if err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	if _, err := store.GetSession(ctx, int64ptr(2), sess.ID); !errors.Is(err, persistence.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for other user, got %v", err)
+	}
+
+	base := time.Now().UTC()
+	if err := store.AppendMessages(ctx, owner, sess.ID, []persistence.ChatMessage{
+		{ID: "m1", Role: "user", Content: "one", CreatedAt: base},
+		{ID: "m2", Role: "assistant", Content: "two", CreatedAt: base.Add(time.Second)},
+		{ID: "m3", Role: "user", Content: "three", CreatedAt: base.Add(2 * time.Second)},
+	}, "three", "model-a"); err != nil {
+		t.Fatalf("AppendMessages: %v", err)
+	}
+
+	if err := store.DeleteMessagesAfter(ctx, owner, sess.ID, "m2", true); err != nil {
+		t.Fatalf("DeleteMessagesAfter: %v", err)
+	}
+	msgs, err := store.ListMessages(ctx, owner, sess.ID, 0)
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "m1" {
+		t.Fatalf("unexpected messages after delete: %+v", msgs)
+	}
+
+	got, err := store.GetSession(ctx, owner, sess.ID)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.Model != "model-a" || got.LastMessagePreview != "one" {
+		t.Fatalf("unexpected session after delete: %+v", got)
+	}
+
+	if err := store.DeleteSession(ctx, owner, sess.ID); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := store.GetSession(ctx, owner, sess.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestSQLiteSpecialistsAndFlowStores(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+
+	specs := NewSQLiteSpecialistsStore(db)
+	if err := specs.Init(ctx); err != nil {
+		t.Fatalf("specialists Init: %v", err)
+	}
+	if _, err := specs.Upsert(ctx, 1, persistence.Specialist{Name: "coder", APIKey: "secret", AllowTools: []string{"run_cli"}}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	// An empty API key keeps the stored one.
+	if _, err := specs.Upsert(ctx, 1, persistence.Specialist{Name: "coder", Model: "m"}); err != nil {
+		t.Fatalf("Upsert update: %v", err)
+	}
+	sp, ok, err := specs.GetByName(ctx, 1, "coder")
+	if err != nil || !ok {
+		t.Fatalf("GetByName: ok=%v err=%v", ok, err)
+	}
+	if sp.APIKey != "secret" || sp.Model != "m" {
+		t.Fatalf("unexpected specialist: %+v", sp)
+	}
+
+	flows := NewSQLiteFlowV2Store(db)
+	if err := flows.Init(ctx); err != nil {
+		t.Fatalf("flow Init: %v", err)
+	}
+	rec := persistence.FlowV2WorkflowRecord{Workflow: flow.Workflow{ID: "wf", Name: "Workflow"}}
+	if _, created, err := flows.UpsertWorkflow(ctx, 1, rec); err != nil || !created {
+		t.Fatalf("UpsertWorkflow: created=%v err=%v", created, err)
+	}
+	if _, created, err := flows.UpsertWorkflow(ctx, 1, rec); err != nil || created {
+		t.Fatalf("UpsertWorkflow update: created=%v err=%v", created, err)
+	}
+	list, err := flows.ListWorkflows(ctx, 1)
+	if err != nil || len(list) != 1 || list[0].Workflow.Name != "Workflow" {
+		t.Fatalf("ListWorkflows: %+v err=%v", list, err)
+	}
+}
+
+func TestSQLiteProjectsStore(t *testing.T) {
+	db := openTestSQLite(t)
+	ctx := context.Background()
+	store := NewSQLiteProjectsStore(db)
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	p, err := store.Create(ctx, 1, "Notes")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := store.Get(ctx, 2, p.ID); !errors.Is(err, persistence.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for other user, got %v", err)
+	}
+	p.Name = "Renamed"
+	updated, err := store.Update(ctx, p)
+	if err != nil || updated.Revision != p.Revision+1 {
+		t.Fatalf("Update: %+v err=%v", updated, err)
+	}
+	if _, err := store.Update(ctx, p); !errors.Is(err, persistence.ErrRevisionConflict) {
+		t.Fatalf("expected ErrRevisionConflict for a stale revision, got %v", err)
+	}
+	list, err := store.List(ctx, 1)
+	if err != nil || len(list) != 1 || list[0].Name != "Renamed" {
+		t.Fatalf("List: %+v err=%v", list, err)
+	}
+	if err := store.Delete(ctx, 1, p.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, 1, p.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
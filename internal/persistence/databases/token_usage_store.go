@@ -0,0 +1,177 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewTokenUsageStore returns a Postgres-backed token usage store when a
+// pool is provided, otherwise an in-memory implementation.
+func NewTokenUsageStore(pool *pgxpool.Pool) persistence.TokenUsageStore {
+	if pool == nil {
+		return &memTokenUsageStore{days: map[tokenUsageKey]persistence.TokenUsageDay{}}
+	}
+	return &pgTokenUsageStore{pool: pool}
+}
+
+type tokenUsageKey struct {
+	day    time.Time
+	userID int64
+	model  string
+}
+
+func normalizeTokenUsage(u persistence.TokenUsageDay) (persistence.TokenUsageDay, error) {
+	u.Model = strings.TrimSpace(u.Model)
+	if u.Model == "" {
+		return u, errors.New("token usage: missing model")
+	}
+	if u.Day.IsZero() {
+		u.Day = time.Now()
+	}
+	u.Day = utcDay(u.Day)
+	return u, nil
+}
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func inTokenUsageRange(u persistence.TokenUsageDay, userID int64, filter persistence.TokenUsageFilter) bool {
+	return u.UserID == userID &&
+		(filter.Model == "" || u.Model == filter.Model) &&
+		(filter.Since.IsZero() || !u.Day.Before(utcDay(filter.Since))) &&
+		(filter.Until.IsZero() || u.Day.Before(filter.Until))
+}
+
+type memTokenUsageStore struct {
+	mu   sync.RWMutex
+	days map[tokenUsageKey]persistence.TokenUsageDay
+}
+
+func (s *memTokenUsageStore) Init(context.Context) error { return nil }
+
+func (s *memTokenUsageStore) Add(_ context.Context, entries []persistence.TokenUsageDay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range entries {
+		e, err := normalizeTokenUsage(e)
+		if err != nil {
+			return err
+		}
+		k := tokenUsageKey{day: e.Day, userID: e.UserID, model: e.Model}
+		cur, ok := s.days[k]
+		if !ok {
+			cur = persistence.TokenUsageDay{Day: e.Day, UserID: e.UserID, Model: e.Model}
+		}
+		cur.PromptTokens += e.PromptTokens
+		cur.CompletionTokens += e.CompletionTokens
+		cur.Calls += e.Calls
+		s.days[k] = cur
+	}
+	return nil
+}
+
+func (s *memTokenUsageStore) Query(_ context.Context, userID int64, filter persistence.TokenUsageFilter) ([]persistence.TokenUsageDay, error) {
+	s.mu.RLock()
+	out := []persistence.TokenUsageDay{}
+	for _, u := range s.days {
+		if inTokenUsageRange(u, userID, filter) {
+			out = append(out, u)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Day.Equal(out[j].Day) {
+			return out[i].Day.Before(out[j].Day)
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out, nil
+}
+
+type pgTokenUsageStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgTokenUsageStore) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+func (s *pgTokenUsageStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS token_usage_daily (
+    day DATE NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    model TEXT NOT NULL,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    calls BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, model)
+);
+`)
+	return err
+}
+
+func (s *pgTokenUsageStore) Add(ctx context.Context, entries []persistence.TokenUsageDay) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		e, err := normalizeTokenUsage(e)
+		if err != nil {
+			return err
+		}
+		batch.Queue(`
+INSERT INTO token_usage_daily (day, user_id, model, prompt_tokens, completion_tokens, calls)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, day, model) DO UPDATE
+SET prompt_tokens = token_usage_daily.prompt_tokens + EXCLUDED.prompt_tokens,
+    completion_tokens = token_usage_daily.completion_tokens + EXCLUDED.completion_tokens,
+    calls = token_usage_daily.calls + EXCLUDED.calls
+`, e.Day, e.UserID, e.Model, e.PromptTokens, e.CompletionTokens, e.Calls)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+func (s *pgTokenUsageStore) Query(ctx context.Context, userID int64, filter persistence.TokenUsageFilter) ([]persistence.TokenUsageDay, error) {
+	since := time.Time{}
+	if !filter.Since.IsZero() {
+		since = utcDay(filter.Since)
+	}
+	until := filter.Until
+	if until.IsZero() {
+		until = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	rows, err := s.pool.Query(ctx, `
+SELECT day, user_id, model, prompt_tokens, completion_tokens, calls
+FROM token_usage_daily
+WHERE user_id = $1 AND day >= $2::date AND day < $3 AND ($4 = '' OR model = $4)
+ORDER BY day, model`, userID, since, until, filter.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []persistence.TokenUsageDay{}
+	for rows.Next() {
+		var u persistence.TokenUsageDay
+		if err := rows.Scan(&u.Day, &u.UserID, &u.Model, &u.PromptTokens, &u.CompletionTokens, &u.Calls); err != nil {
+			return nil, err
+		}
+		u.Day = utcDay(u.Day)
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
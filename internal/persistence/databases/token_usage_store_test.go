@@ -0,0 +1,55 @@
+package databases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemTokenUsageStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewTokenUsageStore(nil)
+	day1 := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	err := store.Add(ctx, []persistence.TokenUsageDay{
+		{Day: day1, UserID: 1, Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 10, Calls: 1},
+		{Day: day1.Add(time.Hour), UserID: 1, Model: "gpt-4o", PromptTokens: 50, CompletionTokens: 5, Calls: 1},
+		{Day: day1, UserID: 1, Model: "claude", PromptTokens: 7, Calls: 1},
+		{Day: day2, UserID: 1, Model: "gpt-4o", PromptTokens: 1, CompletionTokens: 1, Calls: 1},
+		{Day: day1, UserID: 2, Model: "gpt-4o", PromptTokens: 999, Calls: 1},
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := store.Add(ctx, []persistence.TokenUsageDay{{UserID: 1}}); err == nil {
+		t.Fatal("expected error for missing model")
+	}
+
+	got, err := store.Query(ctx, 1, persistence.TokenUsageFilter{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 3 || got[0].Model != "claude" || got[1].Model != "gpt-4o" || !got[2].Day.Equal(utcDay(day2)) {
+		t.Fatalf("unexpected rows: %+v", got)
+	}
+	if got[1].PromptTokens != 150 || got[1].CompletionTokens != 15 || got[1].Calls != 2 || !got[1].Day.Equal(utcDay(day1)) {
+		t.Fatalf("same-day usage not merged: %+v", got[1])
+	}
+
+	got, err = store.Query(ctx, 1, persistence.TokenUsageFilter{Since: day2, Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 1 || got[0].PromptTokens != 1 {
+		t.Fatalf("since filter: %+v", got)
+	}
+	got, err = store.Query(ctx, 1, persistence.TokenUsageFilter{Until: utcDay(day2)})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("until filter should exclude day2: %+v", got)
+	}
+}
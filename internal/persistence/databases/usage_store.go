@@ -0,0 +1,246 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxMemoryUsageEvents bounds the in-memory usage log; the oldest events are
+// dropped first.
+const maxMemoryUsageEvents = 10000
+
+// NewUsageStore returns a Postgres-backed usage store when a pool is
+// provided, otherwise an in-memory implementation.
+func NewUsageStore(pool *pgxpool.Pool) persistence.UsageStore {
+	if pool == nil {
+		return &memUsageStore{}
+	}
+	return &pgUsageStore{pool: pool}
+}
+
+func normalizeUsageEvent(ev persistence.UsageEvent) (persistence.UsageEvent, error) {
+	ev.Name = strings.TrimSpace(ev.Name)
+	if ev.Name == "" {
+		return ev, errors.New("usage: missing name")
+	}
+	switch ev.Kind {
+	case persistence.UsageKindTool, persistence.UsageKindSpecialist, persistence.UsageKindRun:
+	default:
+		return ev, errors.New("usage: invalid kind")
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now().UTC()
+	}
+	return ev, nil
+}
+
+type memUsageStore struct {
+	mu     sync.RWMutex
+	events []persistence.UsageEvent
+}
+
+func (s *memUsageStore) Init(ctx context.Context) error { return nil }
+
+func (s *memUsageStore) Record(ctx context.Context, ev persistence.UsageEvent) error {
+	ev, err := normalizeUsageEvent(ev)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) >= maxMemoryUsageEvents {
+		s.events = s.events[len(s.events)-maxMemoryUsageEvents+1:]
+	}
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func (s *memUsageStore) Insights(ctx context.Context, userID int64, since time.Time, limit int) (persistence.UsageInsights, error) {
+	type acc struct {
+		calls, failures, durMillis int64
+	}
+	tools := map[string]*acc{}
+	specs := map[string]*acc{}
+	s.mu.RLock()
+	for _, ev := range s.events {
+		if ev.UserID != userID || ev.CreatedAt.Before(since) || ev.Kind == persistence.UsageKindRun {
+			continue
+		}
+		m := tools
+		if ev.Kind == persistence.UsageKindSpecialist {
+			m = specs
+		}
+		a := m[ev.Name]
+		if a == nil {
+			a = &acc{}
+			m[ev.Name] = a
+		}
+		a.calls++
+		a.durMillis += ev.DurationMillis
+		if !ev.OK {
+			a.failures++
+		}
+	}
+	s.mu.RUnlock()
+
+	toStats := func(m map[string]*acc) []persistence.UsageStat {
+		out := make([]persistence.UsageStat, 0, len(m))
+		for name, a := range m {
+			out = append(out, persistence.UsageStat{Name: name, Calls: a.calls, Failures: a.failures, AvgDurationMillis: a.durMillis / a.calls})
+		}
+		return out
+	}
+	toolStats := toStats(tools)
+	out := persistence.UsageInsights{Since: since}
+
+	out.TopTools = append(make([]persistence.UsageStat, 0, len(toolStats)), toolStats...)
+	sort.Slice(out.TopTools, func(i, j int) bool {
+		if out.TopTools[i].Calls != out.TopTools[j].Calls {
+			return out.TopTools[i].Calls > out.TopTools[j].Calls
+		}
+		return out.TopTools[i].Name < out.TopTools[j].Name
+	})
+
+	out.FailingTools = make([]persistence.UsageStat, 0)
+	for _, st := range toolStats {
+		if st.Failures > 0 {
+			out.FailingTools = append(out.FailingTools, st)
+		}
+	}
+	sort.Slice(out.FailingTools, func(i, j int) bool {
+		if out.FailingTools[i].Failures != out.FailingTools[j].Failures {
+			return out.FailingTools[i].Failures > out.FailingTools[j].Failures
+		}
+		return out.FailingTools[i].Name < out.FailingTools[j].Name
+	})
+
+	out.SlowestSpecialists = toStats(specs)
+	sort.Slice(out.SlowestSpecialists, func(i, j int) bool {
+		if out.SlowestSpecialists[i].AvgDurationMillis != out.SlowestSpecialists[j].AvgDurationMillis {
+			return out.SlowestSpecialists[i].AvgDurationMillis > out.SlowestSpecialists[j].AvgDurationMillis
+		}
+		return out.SlowestSpecialists[i].Name < out.SlowestSpecialists[j].Name
+	})
+
+	if limit > 0 {
+		out.TopTools = out.TopTools[:min(limit, len(out.TopTools))]
+		out.FailingTools = out.FailingTools[:min(limit, len(out.FailingTools))]
+		out.SlowestSpecialists = out.SlowestSpecialists[:min(limit, len(out.SlowestSpecialists))]
+	}
+	return out, nil
+}
+
+func (s *memUsageStore) Count(ctx context.Context, userID int64, kind string, since time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n int64
+	for _, ev := range s.events {
+		if ev.UserID == userID && ev.Kind == kind && !ev.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type pgUsageStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgUsageStore) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+func (s *pgUsageStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS usage_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL,
+    ok BOOLEAN NOT NULL DEFAULT TRUE,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS usage_events_user_created_idx ON usage_events(user_id, created_at);
+`)
+	return err
+}
+
+func (s *pgUsageStore) Record(ctx context.Context, ev persistence.UsageEvent) error {
+	ev, err := normalizeUsageEvent(ev)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `
+INSERT INTO usage_events (user_id, kind, name, ok, duration_ms, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)`, ev.UserID, ev.Kind, ev.Name, ev.OK, ev.DurationMillis, ev.CreatedAt)
+	return err
+}
+
+func (s *pgUsageStore) Insights(ctx context.Context, userID int64, since time.Time, limit int) (persistence.UsageInsights, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	out := persistence.UsageInsights{Since: since}
+	var err error
+	out.TopTools, err = s.stats(ctx, persistence.UsageKindTool, `TRUE`, `calls DESC, name`, userID, since, limit)
+	if err != nil {
+		return persistence.UsageInsights{}, err
+	}
+	out.FailingTools, err = s.stats(ctx, persistence.UsageKindTool, `COUNT(*) FILTER (WHERE NOT ok) > 0`, `failures DESC, name`, userID, since, limit)
+	if err != nil {
+		return persistence.UsageInsights{}, err
+	}
+	out.SlowestSpecialists, err = s.stats(ctx, persistence.UsageKindSpecialist, `TRUE`, `avg_ms DESC, name`, userID, since, limit)
+	if err != nil {
+		return persistence.UsageInsights{}, err
+	}
+	return out, nil
+}
+
+func (s *pgUsageStore) Count(ctx context.Context, userID int64, kind string, since time.Time) (int64, error) {
+	var n int64
+	err := s.pool.QueryRow(ctx, `
+SELECT COUNT(*) FROM usage_events
+WHERE user_id = $1 AND kind = $2 AND created_at >= $3`, userID, kind, since).Scan(&n)
+	return n, err
+}
+
+// stats aggregates events of one kind per name. having and order are
+// trusted SQL fragments supplied by Insights.
+func (s *pgUsageStore) stats(ctx context.Context, kind, having, order string, userID int64, since time.Time, limit int) ([]persistence.UsageStat, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT name,
+       COUNT(*) AS calls,
+       COUNT(*) FILTER (WHERE NOT ok) AS failures,
+       COALESCE(AVG(duration_ms), 0)::BIGINT AS avg_ms
+FROM usage_events
+WHERE user_id = $1 AND created_at >= $2 AND kind = $3
+GROUP BY name
+HAVING `+having+`
+ORDER BY `+order+`
+LIMIT $4`, userID, since, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]persistence.UsageStat, 0)
+	for rows.Next() {
+		var st persistence.UsageStat
+		if err := rows.Scan(&st.Name, &st.Calls, &st.Failures, &st.AvgDurationMillis); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,85 @@
+package databases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemUsageStoreInsights(t *testing.T) {
+	ctx := context.Background()
+	store := NewUsageStore(nil)
+	now := time.Now().UTC()
+	events := []persistence.UsageEvent{
+		{UserID: 1, Kind: persistence.UsageKindTool, Name: "run_cli", OK: true, DurationMillis: 10},
+		{UserID: 1, Kind: persistence.UsageKindTool, Name: "run_cli", OK: false, DurationMillis: 30},
+		{UserID: 1, Kind: persistence.UsageKindTool, Name: "web_fetch", OK: true, DurationMillis: 5},
+		{UserID: 1, Kind: persistence.UsageKindSpecialist, Name: "coder", OK: true, DurationMillis: 100},
+		{UserID: 1, Kind: persistence.UsageKindSpecialist, Name: "writer", OK: true, DurationMillis: 900},
+		{UserID: 2, Kind: persistence.UsageKindTool, Name: "web_fetch", OK: true},
+		{UserID: 1, Kind: persistence.UsageKindTool, Name: "web_fetch", OK: true, CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	for _, ev := range events {
+		if err := store.Record(ctx, ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := store.Record(ctx, persistence.UsageEvent{Kind: "bogus", Name: "x"}); err == nil {
+		t.Fatal("expected error for invalid kind")
+	}
+
+	got, err := store.Insights(ctx, 1, now.Add(-time.Hour), 5)
+	if err != nil {
+		t.Fatalf("Insights: %v", err)
+	}
+	if len(got.TopTools) != 2 || got.TopTools[0].Name != "run_cli" || got.TopTools[0].Calls != 2 || got.TopTools[0].AvgDurationMillis != 20 {
+		t.Fatalf("unexpected top tools: %+v", got.TopTools)
+	}
+	if got.TopTools[1].Name != "web_fetch" || got.TopTools[1].Calls != 1 {
+		t.Fatalf("old or foreign events counted: %+v", got.TopTools[1])
+	}
+	if len(got.FailingTools) != 1 || got.FailingTools[0].Name != "run_cli" || got.FailingTools[0].Failures != 1 {
+		t.Fatalf("unexpected failing tools: %+v", got.FailingTools)
+	}
+	if len(got.SlowestSpecialists) != 2 || got.SlowestSpecialists[0].Name != "writer" {
+		t.Fatalf("unexpected slowest specialists: %+v", got.SlowestSpecialists)
+	}
+
+	limited, err := store.Insights(ctx, 1, now.Add(-time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Insights: %v", err)
+	}
+	if len(limited.TopTools) != 1 || len(limited.SlowestSpecialists) != 1 {
+		t.Fatalf("limit not applied: %+v", limited)
+	}
+}
+
+func TestMemUsageStoreCountsRuns(t *testing.T) {
+	ctx := context.Background()
+	store := NewUsageStore(nil)
+	now := time.Now().UTC()
+	for _, ev := range []persistence.UsageEvent{
+		{UserID: 1, Kind: persistence.UsageKindRun, Name: "/agent/run", OK: true},
+		{UserID: 1, Kind: persistence.UsageKindRun, Name: "/agent/run", OK: true},
+		{UserID: 1, Kind: persistence.UsageKindRun, Name: "/agent/run", OK: true, CreatedAt: now.AddDate(0, -2, 0)},
+		{UserID: 2, Kind: persistence.UsageKindRun, Name: "/agent/run", OK: true},
+		{UserID: 1, Kind: persistence.UsageKindTool, Name: "run_cli", OK: true},
+	} {
+		if err := store.Record(ctx, ev); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	n, err := store.Count(ctx, 1, persistence.UsageKindRun, now.AddDate(0, -1, 0))
+	if err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v; want 2", n, err)
+	}
+	insights, err := store.Insights(ctx, 1, now.Add(-time.Hour), 5)
+	if err != nil {
+		t.Fatalf("Insights: %v", err)
+	}
+	if len(insights.TopTools) != 1 || insights.TopTools[0].Name != "run_cli" {
+		t.Fatalf("runs leaked into tool insights: %+v", insights.TopTools)
+	}
+}
@@ -40,11 +40,23 @@ func (s *memUserPreferencesStore) Get(ctx context.Context, userID int64) (persis
 func (s *memUserPreferencesStore) SetActiveProject(ctx context.Context, userID int64, projectID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.m[userID] = persistence.UserPreferences{
-		UserID:          userID,
-		ActiveProjectID: projectID,
-		UpdatedAt:       time.Now(),
-	}
+	prefs := s.m[userID]
+	prefs.UserID = userID
+	prefs.ActiveProjectID = projectID
+	prefs.UpdatedAt = time.Now()
+	s.m[userID] = prefs
+	return nil
+}
+
+func (s *memUserPreferencesStore) SetLocale(ctx context.Context, userID int64, locale, timeZone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs := s.m[userID]
+	prefs.UserID = userID
+	prefs.Locale = locale
+	prefs.TimeZone = timeZone
+	prefs.UpdatedAt = time.Now()
+	s.m[userID] = prefs
 	return nil
 }
 
@@ -60,6 +72,8 @@ CREATE TABLE IF NOT EXISTS user_preferences (
     active_project_id TEXT,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS locale TEXT;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS time_zone TEXT;
 CREATE INDEX IF NOT EXISTS idx_user_preferences_active_project
     ON user_preferences(active_project_id)
     WHERE active_project_id IS NOT NULL;
@@ -69,13 +83,13 @@ CREATE INDEX IF NOT EXISTS idx_user_preferences_active_project
 
 func (s *pgUserPreferencesStore) Get(ctx context.Context, userID int64) (persistence.UserPreferences, error) {
 	var prefs persistence.UserPreferences
-	var activeProjectID *string
+	var activeProjectID, locale, timeZone *string
 
 	err := s.pool.QueryRow(ctx, `
-		SELECT user_id, active_project_id, updated_at
+		SELECT user_id, active_project_id, locale, time_zone, updated_at
 		FROM user_preferences
 		WHERE user_id = $1
-	`, userID).Scan(&prefs.UserID, &activeProjectID, &prefs.UpdatedAt)
+	`, userID).Scan(&prefs.UserID, &activeProjectID, &locale, &timeZone, &prefs.UpdatedAt)
 
 	if err != nil {
 		// If not found, return zero-value with user ID set
@@ -84,6 +98,12 @@ func (s *pgUserPreferencesStore) Get(ctx context.Context, userID int64) (persist
 	if activeProjectID != nil {
 		prefs.ActiveProjectID = *activeProjectID
 	}
+	if locale != nil {
+		prefs.Locale = *locale
+	}
+	if timeZone != nil {
+		prefs.TimeZone = *timeZone
+	}
 	return prefs, nil
 }
 
@@ -102,3 +122,23 @@ func (s *pgUserPreferencesStore) SetActiveProject(ctx context.Context, userID in
 	`, userID, activeProjectID)
 	return err
 }
+
+func (s *pgUserPreferencesStore) SetLocale(ctx context.Context, userID int64, locale, timeZone string) error {
+	var localeVal, timeZoneVal *string
+	if locale != "" {
+		localeVal = &locale
+	}
+	if timeZone != "" {
+		timeZoneVal = &timeZone
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO user_preferences (user_id, locale, time_zone, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			locale = EXCLUDED.locale,
+			time_zone = EXCLUDED.time_zone,
+			updated_at = EXCLUDED.updated_at
+	`, userID, localeVal, timeZoneVal)
+	return err
+}
@@ -88,3 +88,27 @@ func TestMemUserPreferencesStore_MultiUser(t *testing.T) {
 		t.Errorf("user2: expected 'user2-proj', got %q", prefs2.ActiveProjectID)
 	}
 }
+
+func TestMemUserPreferencesStore_LocalePreservedAcrossProjectChange(t *testing.T) {
+	store := NewUserPreferencesStore(nil)
+	ctx := context.Background()
+
+	if err := store.SetLocale(ctx, 7, "de-DE", "Europe/Berlin"); err != nil {
+		t.Fatalf("SetLocale error: %v", err)
+	}
+	_ = store.SetActiveProject(ctx, 7, "proj-1")
+
+	prefs, _ := store.Get(ctx, 7)
+	if prefs.Locale != "de-DE" || prefs.TimeZone != "Europe/Berlin" {
+		t.Errorf("expected locale to survive project change, got %q/%q", prefs.Locale, prefs.TimeZone)
+	}
+	if prefs.ActiveProjectID != "proj-1" {
+		t.Errorf("expected ActiveProjectID='proj-1', got %q", prefs.ActiveProjectID)
+	}
+
+	_ = store.SetLocale(ctx, 7, "", "")
+	prefs, _ = store.Get(ctx, 7)
+	if prefs.Locale != "" || prefs.ActiveProjectID != "proj-1" {
+		t.Errorf("expected cleared locale and kept project, got %+v", prefs)
+	}
+}
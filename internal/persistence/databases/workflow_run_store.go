@@ -0,0 +1,262 @@
+package databases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/persistence"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxMemoryWorkflowRuns bounds the in-memory run history; the oldest
+	// runs are dropped first.
+	maxMemoryWorkflowRuns   = 1000
+	defaultWorkflowRunLimit = 50
+	maxWorkflowRunLimit     = 500
+)
+
+// NewWorkflowRunStore returns a Postgres-backed workflow run history when a
+// pool is provided, otherwise an in-memory implementation.
+func NewWorkflowRunStore(pool *pgxpool.Pool) persistence.WorkflowRunStore {
+	if pool == nil {
+		return &memWorkflowRunStore{}
+	}
+	return &pgWorkflowRunStore{pool: pool}
+}
+
+func normalizeWorkflowRun(run persistence.WorkflowRun) (persistence.WorkflowRun, error) {
+	run.ID = strings.TrimSpace(run.ID)
+	if run.ID == "" {
+		return run, errors.New("workflow run: missing id")
+	}
+	if strings.TrimSpace(run.WorkflowID) == "" {
+		return run, errors.New("workflow run: missing workflow id")
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	if run.Steps == nil {
+		run.Steps = []persistence.WorkflowRunStep{}
+	}
+	return run, nil
+}
+
+func workflowRunLimit(limit int) int {
+	if limit <= 0 {
+		return defaultWorkflowRunLimit
+	}
+	return min(limit, maxWorkflowRunLimit)
+}
+
+type memWorkflowRunStore struct {
+	mu   sync.RWMutex
+	runs []persistence.WorkflowRun
+}
+
+func (s *memWorkflowRunStore) Init(context.Context) error { return nil }
+
+func (s *memWorkflowRunStore) Save(_ context.Context, run persistence.WorkflowRun) error {
+	run, err := normalizeWorkflowRun(run)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		if s.runs[i].ID == run.ID {
+			s.runs[i] = run
+			return nil
+		}
+	}
+	if len(s.runs) >= maxMemoryWorkflowRuns {
+		s.runs = s.runs[len(s.runs)-maxMemoryWorkflowRuns+1:]
+	}
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *memWorkflowRunStore) Get(_ context.Context, userID int64, id string) (persistence.WorkflowRun, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, run := range s.runs {
+		if run.ID == id && run.UserID == userID {
+			return run, true, nil
+		}
+	}
+	return persistence.WorkflowRun{}, false, nil
+}
+
+func (s *memWorkflowRunStore) List(_ context.Context, userID int64, filter persistence.WorkflowRunFilter) ([]persistence.WorkflowRun, error) {
+	s.mu.RLock()
+	out := []persistence.WorkflowRun{}
+	for _, run := range s.runs {
+		if run.UserID != userID ||
+			(filter.WorkflowID != "" && run.WorkflowID != filter.WorkflowID) ||
+			(filter.Status != "" && run.Status != filter.Status) ||
+			(filter.Trigger != "" && run.Trigger != filter.Trigger) ||
+			(!filter.Since.IsZero() && run.StartedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !run.StartedAt.Before(filter.Until)) {
+			continue
+		}
+		out = append(out, run)
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if limit := workflowRunLimit(filter.Limit); len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+type pgWorkflowRunStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgWorkflowRunStore) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+func (s *pgWorkflowRunStore) Init(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    id TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    workflow_id TEXT NOT NULL,
+    triggered_by TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    input JSONB NOT NULL DEFAULT '{}'::jsonb,
+    error TEXT NOT NULL DEFAULT '',
+    steps JSONB NOT NULL DEFAULT '[]'::jsonb,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS workflow_runs_user_started_idx ON workflow_runs(user_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS workflow_runs_user_workflow_idx ON workflow_runs(user_id, workflow_id, started_at DESC);
+`)
+	return err
+}
+
+func (s *pgWorkflowRunStore) Save(ctx context.Context, run persistence.WorkflowRun) error {
+	run, err := normalizeWorkflowRun(run)
+	if err != nil {
+		return err
+	}
+	input := run.Input
+	if input == nil {
+		input = map[string]any{}
+	}
+	inputDoc, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	stepsDoc, err := json.Marshal(run.Steps)
+	if err != nil {
+		return err
+	}
+	var endedAt *time.Time
+	if !run.EndedAt.IsZero() {
+		endedAt = &run.EndedAt
+	}
+	_, err = s.pool.Exec(ctx, `
+INSERT INTO workflow_runs (id, user_id, workflow_id, triggered_by, status, input, error, steps, started_at, ended_at, duration_ms)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (id) DO UPDATE
+SET status = EXCLUDED.status,
+    error = EXCLUDED.error,
+    steps = EXCLUDED.steps,
+    ended_at = EXCLUDED.ended_at,
+    duration_ms = EXCLUDED.duration_ms
+`, run.ID, run.UserID, run.WorkflowID, run.Trigger, run.Status, inputDoc, run.Error, stepsDoc, run.StartedAt, endedAt, run.DurationMs)
+	return err
+}
+
+const workflowRunColumns = `id, user_id, workflow_id, triggered_by, status, input, error, steps, started_at, ended_at, duration_ms`
+
+func (s *pgWorkflowRunStore) Get(ctx context.Context, userID int64, id string) (persistence.WorkflowRun, bool, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+workflowRunColumns+` FROM workflow_runs WHERE user_id=$1 AND id=$2`, userID, id)
+	run, err := scanWorkflowRun(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return persistence.WorkflowRun{}, false, nil
+		}
+		return persistence.WorkflowRun{}, false, err
+	}
+	return run, true, nil
+}
+
+func (s *pgWorkflowRunStore) List(ctx context.Context, userID int64, filter persistence.WorkflowRunFilter) ([]persistence.WorkflowRun, error) {
+	where := []string{"user_id=$1"}
+	args := []any{userID}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.WorkflowID != "" {
+		add("workflow_id=$%d", filter.WorkflowID)
+	}
+	if filter.Status != "" {
+		add("status=$%d", filter.Status)
+	}
+	if filter.Trigger != "" {
+		add("triggered_by=$%d", filter.Trigger)
+	}
+	if !filter.Since.IsZero() {
+		add("started_at>=$%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("started_at<$%d", filter.Until)
+	}
+	args = append(args, workflowRunLimit(filter.Limit))
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`SELECT %s FROM workflow_runs WHERE %s ORDER BY started_at DESC LIMIT $%d`,
+		workflowRunColumns, strings.Join(where, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []persistence.WorkflowRun{}
+	for rows.Next() {
+		run, err := scanWorkflowRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+func scanWorkflowRun(row pgx.Row) (persistence.WorkflowRun, error) {
+	var (
+		run      persistence.WorkflowRun
+		inputDoc []byte
+		stepsDoc []byte
+		endedAt  *time.Time
+	)
+	if err := row.Scan(&run.ID, &run.UserID, &run.WorkflowID, &run.Trigger, &run.Status, &inputDoc, &run.Error, &stepsDoc, &run.StartedAt, &endedAt, &run.DurationMs); err != nil {
+		return persistence.WorkflowRun{}, err
+	}
+	if len(inputDoc) > 0 {
+		if err := json.Unmarshal(inputDoc, &run.Input); err != nil {
+			return persistence.WorkflowRun{}, err
+		}
+	}
+	if err := json.Unmarshal(stepsDoc, &run.Steps); err != nil {
+		return persistence.WorkflowRun{}, err
+	}
+	if endedAt != nil {
+		run.EndedAt = endedAt.UTC()
+	}
+	run.StartedAt = run.StartedAt.UTC()
+	return run, nil
+}
@@ -0,0 +1,92 @@
+package databases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"manifold/internal/persistence"
+)
+
+func TestMemWorkflowRunStoreListFilters(t *testing.T) {
+	ctx := context.Background()
+	store := NewWorkflowRunStore(nil)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	runs := []persistence.WorkflowRun{
+		{ID: "r1", UserID: 1, WorkflowID: "build", Trigger: persistence.WorkflowTriggerAPI, Status: "completed", StartedAt: base},
+		{ID: "r2", UserID: 1, WorkflowID: "build", Trigger: persistence.WorkflowTriggerWebhook, Status: "failed", StartedAt: base.Add(time.Hour)},
+		{ID: "r3", UserID: 1, WorkflowID: "deploy", Trigger: persistence.WorkflowTriggerAPI, Status: "running", StartedAt: base.Add(2 * time.Hour)},
+		{ID: "r4", UserID: 2, WorkflowID: "build", Trigger: persistence.WorkflowTriggerAPI, Status: "completed", StartedAt: base},
+	}
+	for _, run := range runs {
+		if err := store.Save(ctx, run); err != nil {
+			t.Fatalf("Save %s: %v", run.ID, err)
+		}
+	}
+	if err := store.Save(ctx, persistence.WorkflowRun{ID: "bad"}); err == nil {
+		t.Fatal("expected error for run without workflow id")
+	}
+
+	ids := func(filter persistence.WorkflowRunFilter) []string {
+		t.Helper()
+		got, err := store.List(ctx, 1, filter)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		out := make([]string, len(got))
+		for i, run := range got {
+			out[i] = run.ID
+		}
+		return out
+	}
+	cases := []struct {
+		name   string
+		filter persistence.WorkflowRunFilter
+		want   []string
+	}{
+		{"all newest first", persistence.WorkflowRunFilter{}, []string{"r3", "r2", "r1"}},
+		{"workflow", persistence.WorkflowRunFilter{WorkflowID: "build"}, []string{"r2", "r1"}},
+		{"status", persistence.WorkflowRunFilter{Status: "failed"}, []string{"r2"}},
+		{"trigger", persistence.WorkflowRunFilter{Trigger: persistence.WorkflowTriggerAPI}, []string{"r3", "r1"}},
+		{"window", persistence.WorkflowRunFilter{Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)}, []string{"r2"}},
+		{"limit", persistence.WorkflowRunFilter{Limit: 1}, []string{"r3"}},
+	}
+	for _, tc := range cases {
+		got := ids(tc.filter)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestMemWorkflowRunStoreSaveReplaces(t *testing.T) {
+	ctx := context.Background()
+	store := NewWorkflowRunStore(nil)
+	run := persistence.WorkflowRun{ID: "r1", UserID: 1, WorkflowID: "build", Status: "running"}
+	if err := store.Save(ctx, run); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	run.Status = "completed"
+	run.Steps = []persistence.WorkflowRunStep{{NodeID: "a", Status: "completed"}}
+	if err := store.Save(ctx, run); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, found, err := store.Get(ctx, 1, "r1")
+	if err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+	if got.Status != "completed" || len(got.Steps) != 1 {
+		t.Fatalf("run not replaced: %+v", got)
+	}
+	if _, found, _ := store.Get(ctx, 2, "r1"); found {
+		t.Fatal("run visible to another user")
+	}
+	if all, _ := store.List(ctx, 1, persistence.WorkflowRunFilter{}); len(all) != 1 {
+		t.Fatalf("expected one stored run, got %d", len(all))
+	}
+}
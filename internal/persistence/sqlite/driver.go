@@ -0,0 +1,5 @@
+//go:build cgo
+
+package sqlite
+
+import _ "github.com/mattn/go-sqlite3"
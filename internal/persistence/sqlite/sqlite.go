@@ -1,3 +1,119 @@
+// Package sqlite provides the embedded SQLite backend used when agentd runs
+// without Postgres. Stores share their schema with the Postgres
+// implementations; Schema and Rebind translate the Postgres dialect.
+//
+// The driver, github.com/mattn/go-sqlite3, needs cgo. Host builds with a C
+// compiler include it; CGO_ENABLED=0 builds (make cross, the Docker images)
+// do not: config loading rejects databases.backend: sqlite there, and Open
+// reports ErrDriverUnavailable.
 package sqlite
 
-// Placeholder for a durable sqlite-backed store.
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DriverName is the database/sql driver name registered by go-sqlite3.
+const DriverName = "sqlite3"
+
+// ErrDriverUnavailable is returned by Open when the binary was built without
+// cgo.
+var ErrDriverUnavailable = errors.New("sqlite driver not compiled in (build with CGO_ENABLED=1)")
+
+// Open opens (creating if needed) the database at path with foreign keys
+// enabled and WAL journaling. SQLite allows a single writer, so the pool is
+// limited to one connection to avoid SQLITE_BUSY under concurrent requests.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if !slices.Contains(sql.Drivers(), DriverName) {
+		return nil, ErrDriverUnavailable
+	}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("sqlite path required")
+	}
+	if path != ":memory:" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("create sqlite dir: %w", err)
+			}
+		}
+	}
+	dsn := "file:" + path + "?_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL"
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+type replacement struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+var (
+	queryReplacements = []replacement{
+		{regexp.MustCompile(`\$(\d+)`), `?$1`},
+		{regexp.MustCompile(`(?i)\bnow\(\)`), "CURRENT_TIMESTAMP"},
+		{regexp.MustCompile(`(?i)\bBTRIM\(`), "TRIM("},
+		{regexp.MustCompile(`(?i)::jsonb\b`), ""},
+	}
+	schemaReplacements = []replacement{
+		{regexp.MustCompile(`(?i)\b(BIG)?SERIAL\s+PRIMARY\s+KEY`), "INTEGER PRIMARY KEY AUTOINCREMENT"},
+		{regexp.MustCompile(`(?i)\bTIMESTAMPTZ\b|\bTIMESTAMP WITH TIME ZONE\b`), "TIMESTAMP"},
+		{regexp.MustCompile(`(?i)\bJSONB\b`), "TEXT"},
+		{regexp.MustCompile(`(?i)\bUUID\b`), "TEXT"},
+	}
+)
+
+// Rebind converts a Postgres-style query to SQLite: numbered $N placeholders
+// become ?N, and now(), BTRIM, and ::jsonb casts are translated.
+func Rebind(query string) string {
+	for _, r := range queryReplacements {
+		query = r.re.ReplaceAllString(query, r.repl)
+	}
+	return query
+}
+
+// Schema converts Postgres DDL to SQLite column types (SERIAL, TIMESTAMPTZ,
+// JSONB, UUID) and applies Rebind. Postgres-only statements such as
+// ALTER ... ADD COLUMN IF NOT EXISTS must be kept out of shared DDL.
+func Schema(ddl string) string {
+	ddl = Rebind(ddl)
+	for _, r := range schemaReplacements {
+		ddl = r.re.ReplaceAllString(ddl, r.repl)
+	}
+	return ddl
+}
+
+// Init executes shared Postgres DDL against db after translating it.
+func Init(ctx context.Context, db *sql.DB, ddl string) error {
+	_, err := db.ExecContext(ctx, Schema(ddl))
+	return err
+}
+
+// AddColumn adds column to table unless it already exists. It stands in for
+// Postgres' ADD COLUMN IF NOT EXISTS when a shared schema gains a column.
+func AddColumn(ctx context.Context, db *sql.DB, table, column, def string) error {
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?`, table, column).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+def)
+	return err
+}
@@ -1,7 +1,79 @@
 package sqlite
 
-import "testing"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
 
-func TestPlaceholder(t *testing.T) {
-	// Placeholder
+func TestSchema(t *testing.T) {
+	t.Parallel()
+	got := Schema(`CREATE TABLE t (
+  id BIGSERIAL PRIMARY KEY,
+  ref UUID NOT NULL,
+  doc JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`)
+	want := `CREATE TABLE t (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ref TEXT NOT NULL,
+  doc TEXT NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+	if got != want {
+		t.Fatalf("Schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	t.Parallel()
+	got := Rebind(`UPDATE users SET name=$2, updated_at=NOW() WHERE id=$1 AND NULLIF(BTRIM($10), '') IS NULL`)
+	want := `UPDATE users SET name=?2, updated_at=CURRENT_TIMESTAMP WHERE id=?1 AND NULLIF(TRIM(?10), '') IS NULL`
+	if got != want {
+		t.Fatalf("Rebind: %s\nwant: %s", got, want)
+	}
+}
+
+func TestOpenWithoutDriver(t *testing.T) {
+	t.Parallel()
+	if slices.Contains(sql.Drivers(), DriverName) {
+		t.Skip("sqlite driver compiled in")
+	}
+	if _, err := Open(context.Background(), t.TempDir()+"/db.sqlite"); !errors.Is(err, ErrDriverUnavailable) {
+		t.Fatalf("err=%v want ErrDriverUnavailable", err)
+	}
+}
+
+func TestOpenInitAndAddColumn(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, err := Open(ctx, t.TempDir()+"/nested/db.sqlite")
+	if errors.Is(err, ErrDriverUnavailable) {
+		t.Skip("sqlite driver not compiled in")
+	}
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	if err := Init(ctx, db, `CREATE TABLE IF NOT EXISTS t (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT NOW());`); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	for range 2 {
+		if err := AddColumn(ctx, db, "t", "note", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			t.Fatalf("AddColumn: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, Rebind(`INSERT INTO t (note, name) VALUES ($2, $1)`), "a", "b"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	var name, note string
+	if err := db.QueryRowContext(ctx, `SELECT name, note FROM t`).Scan(&name, &note); err != nil || name != "a" || note != "b" {
+		t.Fatalf("row = %q %q (%v)", name, note, err)
+	}
+	var fk int
+	if err := db.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&fk); err != nil || fk != 1 {
+		t.Fatalf("foreign_keys = %d (%v)", fk, err)
+	}
 }
@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -20,10 +21,13 @@ var (
 // Store is a placeholder for transcripts/state persistence.
 type Store interface{}
 
-// UserPreferences represents a user's persistent settings.
+// UserPreferences represents a user's persistent settings. Locale is a BCP 47
+// tag and TimeZone an IANA zone name; empty values defer to config defaults.
 type UserPreferences struct {
 	UserID          int64     `json:"userId"`
 	ActiveProjectID string    `json:"activeProjectId,omitempty"`
+	Locale          string    `json:"locale,omitempty"`
+	TimeZone        string    `json:"timeZone,omitempty"`
 	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
@@ -35,6 +39,9 @@ type UserPreferencesStore interface {
 	Get(ctx context.Context, userID int64) (UserPreferences, error)
 	// SetActiveProject updates the user's active project selection.
 	SetActiveProject(ctx context.Context, userID int64, projectID string) error
+	// SetLocale updates the user's locale and time zone. Empty values clear
+	// the preference so deployment defaults apply.
+	SetLocale(ctx context.Context, userID int64, locale, timeZone string) error
 }
 
 // PulseRoom stores per-Matrix-room automation settings.
@@ -94,6 +101,152 @@ type PulseStore interface {
 	CompleteRoomPulse(ctx context.Context, roomID, botID, token string, completedAt time.Time, summary, pulseErr string, dueTaskIDs []string) error
 }
 
+// RunContextSnapshot records the exact message array sent to the provider at
+// one step of an agent run, after summarization and compaction were applied.
+type RunContextSnapshot struct {
+	RunID        string          `json:"runId"`
+	Step         int             `json:"step"`
+	Model        string          `json:"model,omitempty"`
+	MessageCount int             `json:"messageCount"`
+	Messages     json.RawMessage `json:"messages"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// RunContextStore persists per-step provider contexts so a run can be
+// inspected after the fact.
+type RunContextStore interface {
+	Init(ctx context.Context) error
+	// Record stores a snapshot, replacing any existing snapshot for the same run and step.
+	Record(ctx context.Context, snap RunContextSnapshot) error
+	// Get returns the snapshot for a run step or ErrNotFound.
+	Get(ctx context.Context, runID string, step int) (RunContextSnapshot, error)
+	// Steps lists the recorded step numbers for a run in ascending order.
+	Steps(ctx context.Context, runID string) ([]int, error)
+}
+
+// Usage event kinds.
+const (
+	UsageKindTool       = "tool"
+	UsageKindSpecialist = "specialist"
+	// UsageKindRun marks the start of an agent run; Name is the endpoint.
+	UsageKindRun = "run"
+)
+
+// UsageEvent records one tool call, specialist delegation or agent run.
+type UsageEvent struct {
+	UserID         int64     `json:"userId"`
+	Kind           string    `json:"kind"`
+	Name           string    `json:"name"`
+	OK             bool      `json:"ok"`
+	DurationMillis int64     `json:"durationMillis"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// UsageStat aggregates usage events for one tool or specialist.
+type UsageStat struct {
+	Name              string `json:"name"`
+	Calls             int64  `json:"calls"`
+	Failures          int64  `json:"failures"`
+	AvgDurationMillis int64  `json:"avgDurationMillis"`
+}
+
+// UsageInsights summarizes recent usage for operator dashboards.
+type UsageInsights struct {
+	Since time.Time `json:"since"`
+	// TopTools is ordered by call count.
+	TopTools []UsageStat `json:"topTools"`
+	// FailingTools lists tools with at least one failure, ordered by failures.
+	FailingTools []UsageStat `json:"failingTools"`
+	// SlowestSpecialists is ordered by average duration.
+	SlowestSpecialists []UsageStat `json:"slowestSpecialists"`
+}
+
+// UsageStore persists usage events and computes aggregate insights.
+type UsageStore interface {
+	Init(ctx context.Context) error
+	Record(ctx context.Context, ev UsageEvent) error
+	// Insights aggregates events for userID created at or after since,
+	// returning at most limit entries per list.
+	Insights(ctx context.Context, userID int64, since time.Time, limit int) (UsageInsights, error)
+	// Count returns how many events of kind userID has recorded at or after
+	// since.
+	Count(ctx context.Context, userID int64, kind string, since time.Time) (int64, error)
+}
+
+// LongTermMemory is a durable fact about a user distilled from a
+// conversation and recalled in later sessions.
+type LongTermMemory struct {
+	ID        string    `json:"id"`
+	UserID    int64     `json:"userId"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LongTermMemoryStore persists long-term memory facts per user. Embeddings
+// live in the vector store; this store is the source of truth for listing
+// and deletion.
+type LongTermMemoryStore interface {
+	Init(ctx context.Context) error
+	// Put stores a fact, assigning ID and CreatedAt when empty.
+	Put(ctx context.Context, m LongTermMemory) (LongTermMemory, error)
+	// Get returns ErrNotFound when the fact does not exist for userID.
+	Get(ctx context.Context, userID int64, id string) (LongTermMemory, error)
+	// List returns the user's facts, newest first. limit <= 0 returns all.
+	List(ctx context.Context, userID int64, limit int) ([]LongTermMemory, error)
+	// Delete removes one fact. Deleting a missing fact returns ErrNotFound.
+	Delete(ctx context.Context, userID int64, id string) error
+	// DeleteAll removes every fact for userID and returns the removed IDs.
+	DeleteAll(ctx context.Context, userID int64) ([]string, error)
+}
+
+// ProjectEnvVar is a saved environment variable that is injected into tool
+// execution for runs scoped to its project.
+type ProjectEnvVar struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"-"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ProjectEnvStore persists per-project environment variables (secrets).
+type ProjectEnvStore interface {
+	Init(ctx context.Context) error
+	// List returns the variables for a project ordered by name.
+	List(ctx context.Context, userID int64, projectID string) ([]ProjectEnvVar, error)
+	// Set creates or replaces a variable.
+	Set(ctx context.Context, userID int64, projectID, name, value string) error
+	// Delete removes a variable. Deleting a missing variable returns ErrNotFound.
+	Delete(ctx context.Context, userID int64, projectID, name string) error
+}
+
+// Secret is a named, instance-wide secret. Value holds the sealed
+// ciphertext; the plaintext never leaves internal/secrets.
+type Secret struct {
+	Name  string `json:"name"`
+	Value string `json:"-"`
+	// Domains lists the hosts tools may send the secret to.
+	Domains []string `json:"domains"`
+	// UserIDs lists the users whose agents may send it from tools; runs
+	// without a user may send it only while UserIDs is empty.
+	UserIDs   []int64   `json:"userIds"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SecretsStore persists named secrets.
+type SecretsStore interface {
+	Init(ctx context.Context) error
+	// List returns every secret ordered by name.
+	List(ctx context.Context) ([]Secret, error)
+	Get(ctx context.Context, name string) (Secret, bool, error)
+	// Put creates sec at version 1 or replaces its value, domains and users
+	// and bumps the version.
+	Put(ctx context.Context, sec Secret) (Secret, error)
+	// Delete removes a secret. Deleting a missing secret returns ErrNotFound.
+	Delete(ctx context.Context, name string) error
+}
+
 // ReactiveClaimStore persists short-lived room leases for reactive Matrix replies.
 type ReactiveClaimStore interface {
 	Init(ctx context.Context) error
@@ -120,6 +273,8 @@ type Specialist struct {
 	Paused                     bool              `json:"paused"`
 	AllowTools                 []string          `json:"allowTools"`
 	ReasoningEffort            string            `json:"reasoningEffort"`
+	ExecBackend                string            `json:"execBackend,omitempty"`
+	Voice                      string            `json:"voice,omitempty"`
 	System                     string            `json:"system"`
 	ExtraHeaders               map[string]string `json:"extraHeaders"`
 	ExtraParams                map[string]any    `json:"extraParams"`
@@ -147,6 +302,24 @@ type SpecialistsStore interface {
 	Delete(ctx context.Context, userID int64, name string) error
 }
 
+// SpecialistHealth is the latest probe result for a specialist's endpoint.
+type SpecialistHealth struct {
+	UserID int64  `json:"userId"`
+	Name   string `json:"name"`
+	// State is "online", "degraded" or "offline".
+	State     string    `json:"state"`
+	LatencyMs int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// SpecialistHealthStore keeps the latest health probe per specialist.
+type SpecialistHealthStore interface {
+	Init(ctx context.Context) error
+	Record(ctx context.Context, h SpecialistHealth) error
+	List(ctx context.Context, userID int64) ([]SpecialistHealth, error)
+}
+
 // SpecialistTeamsStore defines CRUD over specialist teams and memberships.
 type SpecialistTeamsStore interface {
 	Init(ctx context.Context) error
@@ -185,6 +358,20 @@ type ChatMessage struct {
 	ToolID   string `json:"toolId,omitempty"`
 }
 
+// ChatRoleHandoff is the role of chat messages that record a handoff between
+// agents. Their content is a JSON-encoded Handoff.
+const ChatRoleHandoff = "handoff"
+
+// Handoff records a chat session passing from one agent to another. Summary
+// is the context package the handing-off agent prepared for the receiver.
+type Handoff struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	Summary string    `json:"summary"`
+	At      time.Time `json:"at"`
+}
+
 // ChatStore persists chat sessions and messages.
 type ChatStore interface {
 	Init(ctx context.Context) error
@@ -219,6 +406,93 @@ type FlowV2WorkflowStore interface {
 	DeleteWorkflow(ctx context.Context, userID int64, workflowID string) error
 }
 
+// Workflow run triggers recorded in WorkflowRun.Trigger.
+const (
+	WorkflowTriggerAPI     = "api"
+	WorkflowTriggerWARPP   = "warpp"
+	WorkflowTriggerWebhook = "webhook"
+	WorkflowTriggerTool    = "tool"
+)
+
+// WorkflowRunStep is one node's part in a workflow run.
+type WorkflowRunStep struct {
+	NodeID     string    `json:"node_id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// WorkflowRun is the audit record of one Flow v2 workflow run. EndedAt is
+// zero while the run is in flight.
+type WorkflowRun struct {
+	ID         string            `json:"id"`
+	UserID     int64             `json:"user_id"`
+	WorkflowID string            `json:"workflow_id"`
+	Trigger    string            `json:"trigger"`
+	Status     string            `json:"status"`
+	Input      map[string]any    `json:"input,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Steps      []WorkflowRunStep `json:"steps"`
+	StartedAt  time.Time         `json:"started_at"`
+	EndedAt    time.Time         `json:"ended_at"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// WorkflowRunFilter narrows WorkflowRunStore.List. Empty fields match
+// everything.
+type WorkflowRunFilter struct {
+	WorkflowID string
+	Status     string
+	Trigger    string
+	// Since and Until bound StartedAt, inclusive and exclusive.
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// WorkflowRunStore keeps the history of workflow runs for auditing.
+type WorkflowRunStore interface {
+	Init(ctx context.Context) error
+	// Save inserts the run or replaces the stored run with the same ID.
+	Save(ctx context.Context, run WorkflowRun) error
+	Get(ctx context.Context, userID int64, id string) (WorkflowRun, bool, error)
+	// List returns userID's runs matching filter, newest first.
+	List(ctx context.Context, userID int64, filter WorkflowRunFilter) ([]WorkflowRun, error)
+}
+
+// TokenUsageDay is one user's token usage of one model on one UTC day.
+type TokenUsageDay struct {
+	// Day is midnight UTC of the day counted.
+	Day              time.Time `json:"day"`
+	UserID           int64     `json:"userId"`
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"promptTokens"`
+	CompletionTokens int64     `json:"completionTokens"`
+	Calls            int64     `json:"calls"`
+}
+
+// TokenUsageFilter narrows TokenUsageStore.Query to the days in
+// [Since, Until) and, when set, one model.
+type TokenUsageFilter struct {
+	Since time.Time
+	Until time.Time
+	Model string
+}
+
+// TokenUsageStore persists daily token usage per user and model.
+type TokenUsageStore interface {
+	Init(ctx context.Context) error
+	// Add adds each entry's counts to the stored totals for its day, user
+	// and model.
+	Add(ctx context.Context, entries []TokenUsageDay) error
+	// Query returns userID's usage in the filter range, ordered by day and
+	// then model.
+	Query(ctx context.Context, userID int64, filter TokenUsageFilter) ([]TokenUsageDay, error)
+}
+
 // MCPServer represents a stored MCP server configuration.
 type MCPServer struct {
 	ID               int64             `json:"id"`
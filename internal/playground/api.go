@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"sync"
 	"time"
 
 	"manifold/internal/auth"
@@ -19,6 +22,8 @@ var (
 	ErrActiveRun = errors.New("playground: experiment already has an active run")
 	// ErrUnknownExperiment is returned when attempting to interact with an experiment that has not been registered.
 	ErrUnknownExperiment = errors.New("playground: unknown experiment")
+	// ErrNoResumableRun is returned by ResumeRun when the experiment has no interrupted run.
+	ErrNoResumableRun = errors.New("playground: no interrupted run to resume")
 )
 
 // Service wires together the playground components and provides a cohesive
@@ -33,6 +38,11 @@ type Service struct {
 	workers     worker.Executor
 	evals       *eval.Runner
 	store       RunStore
+
+	// active holds runs executing in this process, which ResumeRun must not
+	// pick up.
+	mu     sync.Mutex
+	active map[string]bool
 }
 
 // RunStore captures the persistence requirements the service expects.
@@ -43,6 +53,9 @@ type RunStore interface {
 	CreateRun(ctx context.Context, run Run) (Run, error)
 	UpdateRunStatus(ctx context.Context, id string, status RunStatus, endedAt time.Time, errMsg string, metrics map[string]float64) error
 	AppendResults(ctx context.Context, runID string, results []RunResult) error
+	// CompleteShard stores a finished shard's results and records the shard
+	// in the run's CompletedShards in one step.
+	CompleteShard(ctx context.Context, runID, shardID string, results []RunResult) error
 	ListRuns(ctx context.Context, experimentID string) ([]Run, error)
 	ListRunResults(ctx context.Context, runID string) ([]RunResult, error)
 	DeleteExperiment(ctx context.Context, id string) error
@@ -64,6 +77,7 @@ func NewService(cfg Config, reg *registry.Registry, datasets *dataset.Service, r
 		workers:     workers,
 		evals:       evals,
 		store:       store,
+		active:      make(map[string]bool),
 	}
 }
 
@@ -107,6 +121,16 @@ func (s *Service) RegisterDataset(ctx context.Context, ds dataset.Dataset, rows
 	return s.datasets.CreateDataset(ctx, ds, rows)
 }
 
+// ImportDataset creates a dataset from a CSV or JSONL stream.
+func (s *Service) ImportDataset(ctx context.Context, ds dataset.Dataset, src io.Reader, opts dataset.ImportOptions) (dataset.ImportResult, error) {
+	return s.datasets.Import(ctx, ds, src, opts)
+}
+
+// ImportChatDataset creates a dataset from the turns of a chat session.
+func (s *Service) ImportChatDataset(ctx context.Context, ds dataset.Dataset, sessionID string, opts dataset.ChatImportOptions) (dataset.ImportResult, error) {
+	return s.datasets.ImportChat(ctx, ds, sessionID, opts)
+}
+
 // UpdateDataset updates dataset metadata and rows.
 func (s *Service) UpdateDataset(ctx context.Context, ds dataset.Dataset, rows []dataset.Row) (dataset.Dataset, error) {
 	return s.datasets.UpdateDataset(ctx, ds, rows)
@@ -185,7 +209,12 @@ func (s *Service) StartRun(ctx context.Context, experimentID string) (Run, error
 		return Run{}, ErrUnknownExperiment
 	}
 	s.experiments.Save(spec)
+	return s.executeRun(ctx, spec)
+}
 
+// executeRun plans and executes spec, recording the run against spec.ID.
+func (s *Service) executeRun(ctx context.Context, spec experiment.ExperimentSpec) (Run, error) {
+	experimentID := spec.ID
 	runs, err := s.store.ListRuns(ctx, experimentID)
 	if err != nil {
 		return Run{}, err
@@ -220,27 +249,102 @@ func (s *Service) StartRun(ctx context.Context, experimentID string) (Run, error
 	if err != nil {
 		return Run{}, err
 	}
+	return s.runShards(ctx, spec, run, nil)
+}
+
+// ResumeRun continues the most recent interrupted run of an experiment from
+// its first incomplete shard, reusing the results already stored. Runs that
+// failed, or that were left pending or running by a crashed process, can be
+// resumed.
+func (s *Service) ResumeRun(ctx context.Context, experimentID string) (Run, error) {
+	spec, ok, err := s.store.GetExperiment(ctx, experimentID)
+	if err != nil {
+		return Run{}, err
+	}
+	if !ok {
+		return Run{}, ErrUnknownExperiment
+	}
+	runs, err := s.store.ListRuns(ctx, experimentID)
+	if err != nil {
+		return Run{}, err
+	}
+	slices.SortStableFunc(runs, func(a, b Run) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	if len(runs) == 0 || runs[0].Status == RunStatusCompleted {
+		return Run{}, ErrNoResumableRun
+	}
+	run := runs[0]
+	if s.isActive(run.ID) {
+		return Run{}, ErrActiveRun
+	}
+	stored, err := s.store.ListRunResults(ctx, run.ID)
+	if err != nil {
+		return Run{}, err
+	}
+	done := make(map[string]bool, len(run.CompletedShards))
+	for _, id := range run.CompletedShards {
+		done[id] = true
+	}
+	prior := make([]worker.Result, 0, len(stored))
+	for _, res := range stored {
+		// Results of a shard that never completed are rerun.
+		if done[res.ShardID] {
+			prior = append(prior, workerResultFromRun(res))
+		}
+	}
+	run.Error = ""
+	return s.runShards(ctx, spec, run, prior)
+}
+
+// runShards executes the shards of run not yet in CompletedShards,
+// checkpointing each, then evaluates prior and new results together.
+func (s *Service) runShards(ctx context.Context, spec experiment.ExperimentSpec, run Run, prior []worker.Result) (Run, error) {
+	s.mu.Lock()
+	if s.active[run.ID] {
+		s.mu.Unlock()
+		return Run{}, ErrActiveRun
+	}
+	s.active[run.ID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, run.ID)
+		s.mu.Unlock()
+	}()
 
-	// Execute synchronously shard by shard.
 	run.Status = RunStatusRunning
-	run.StartedAt = time.Now().UTC()
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
 	if err := s.store.UpdateRunStatus(ctx, run.ID, RunStatusRunning, time.Time{}, "", nil); err != nil {
 		return Run{}, err
 	}
 
-	var workerResults []worker.Result
+	workerResults := prior
 	for _, shard := range run.Plan.Shards {
+		if slices.Contains(run.CompletedShards, shard.ID) {
+			continue
+		}
 		if err := ctx.Err(); err != nil {
 			return s.failRun(ctx, run, err)
 		}
 		tasks := worker.TasksFromShard(run.ID, spec, shard)
+		shardResults := make([]worker.Result, 0, len(tasks))
 		for _, task := range tasks {
 			res, execErr := s.workers.ExecuteTask(ctx, task)
 			if execErr != nil {
 				return s.failRun(ctx, run, execErr)
 			}
-			workerResults = append(workerResults, res)
+			shardResults = append(shardResults, res)
+		}
+		checkpoint := make([]RunResult, 0, len(shardResults))
+		for _, res := range shardResults {
+			checkpoint = append(checkpoint, RunResultFromWorker(res))
+		}
+		if err := s.store.CompleteShard(ctx, run.ID, shard.ID, checkpoint); err != nil {
+			return s.failRun(ctx, run, fmt.Errorf("checkpoint shard %s: %w", shard.ID, err))
 		}
+		run.CompletedShards = append(run.CompletedShards, shard.ID)
+		workerResults = append(workerResults, shardResults...)
 	}
 
 	metrics, updatedResults, err := s.evals.Evaluate(ctx, spec, workerResults)
@@ -264,6 +368,12 @@ func (s *Service) StartRun(ctx context.Context, experimentID string) (Run, error
 	return run, nil
 }
 
+func (s *Service) isActive(runID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active[runID]
+}
+
 // ListRuns returns existing runs for an experiment.
 func (s *Service) ListRuns(ctx context.Context, experimentID string) ([]Run, error) {
 	return s.store.ListRuns(ctx, experimentID)
@@ -311,7 +421,8 @@ func (s *Service) failRun(ctx context.Context, run Run, err error) (Run, error)
 	run.Status = RunStatusFailed
 	run.EndedAt = time.Now().UTC()
 	run.Error = err.Error()
-	_ = s.store.UpdateRunStatus(ctx, run.ID, run.Status, run.EndedAt, run.Error, nil)
+	// Record the failure even when ctx was cancelled so the run can be resumed.
+	_ = s.store.UpdateRunStatus(context.WithoutCancel(ctx), run.ID, run.Status, run.EndedAt, run.Error, nil)
 	return run, err
 }
 
@@ -323,20 +434,48 @@ func (s *Service) ListRunResults(ctx context.Context, runID string) ([]RunResult
 // RunResultFromWorker adapts worker results into the public RunResult type.
 func RunResultFromWorker(res worker.Result) RunResult {
 	return RunResult{
-		ID:              res.ID,
-		RunID:           res.RunID,
-		RowID:           res.RowID,
-		VariantID:       res.VariantID,
-		PromptVersionID: res.PromptVersionID,
-		Model:           res.Model,
-		Rendered:        res.RenderedPrompt,
-		Output:          res.Output,
-		Tokens:          res.Tokens,
-		Latency:         res.Latency,
-		ProviderName:    res.ProviderName,
-		Artifacts:       cloneStringMap(res.Artifacts),
-		Scores:          cloneScores(res.Scores),
-		Expected:        res.Expected,
+		ID:               res.ID,
+		RunID:            res.RunID,
+		RowID:            res.RowID,
+		VariantID:        res.VariantID,
+		ShardID:          res.ShardID,
+		PromptVersionID:  res.PromptVersionID,
+		Model:            res.Model,
+		Rendered:         res.RenderedPrompt,
+		Output:           res.Output,
+		Tokens:           res.Tokens,
+		PromptTokens:     res.PromptTokens,
+		CompletionTokens: res.CompletionTokens,
+		Cost:             res.Cost,
+		Latency:          res.Latency,
+		ProviderName:     res.ProviderName,
+		Artifacts:        cloneStringMap(res.Artifacts),
+		Scores:           cloneScores(res.Scores),
+		Expected:         res.Expected,
+	}
+}
+
+// workerResultFromRun is the inverse of RunResultFromWorker, used to feed
+// checkpointed results back into evaluation.
+func workerResultFromRun(res RunResult) worker.Result {
+	return worker.Result{
+		ID:               res.ID,
+		RunID:            res.RunID,
+		ShardID:          res.ShardID,
+		RowID:            res.RowID,
+		VariantID:        res.VariantID,
+		PromptVersionID:  res.PromptVersionID,
+		Model:            res.Model,
+		RenderedPrompt:   res.Rendered,
+		Output:           res.Output,
+		Tokens:           res.Tokens,
+		PromptTokens:     res.PromptTokens,
+		CompletionTokens: res.CompletionTokens,
+		Cost:             res.Cost,
+		Latency:          res.Latency,
+		ProviderName:     res.ProviderName,
+		Artifacts:        cloneStringMap(res.Artifacts),
+		Expected:         res.Expected,
 	}
 }
 
@@ -53,6 +53,7 @@ type Store interface {
 type Service struct {
 	store Store
 	clock Clock
+	chats ChatSource
 }
 
 // Clock makes dataset services testable.
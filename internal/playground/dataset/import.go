@@ -0,0 +1,489 @@
+package dataset
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Import formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+const (
+	// DefaultMaxImportRows caps the rows of one import when
+	// ImportOptions.MaxRows is zero.
+	DefaultMaxImportRows = 100000
+	// maxJSONLLine bounds a single JSONL record.
+	maxJSONLLine = 16 << 20
+	// maxReportedErrors caps the row errors returned by an import.
+	maxReportedErrors = 100
+)
+
+var (
+	// ErrInvalidImport indicates an import whose source, mapping or rows
+	// are invalid. Nothing is stored when it is returned.
+	ErrInvalidImport = errors.New("playground/dataset: invalid import")
+	// ErrChatSessionNotFound indicates a chat session that does not exist
+	// or belongs to another user.
+	ErrChatSessionNotFound = errors.New("playground/dataset: chat session not found")
+	// ErrNoChatSource indicates chat imports are not available.
+	ErrNoChatSource = errors.New("playground/dataset: chat import not configured")
+)
+
+// ColumnMapping maps source columns (CSV headers or JSONL keys) onto row
+// fields. Empty fields are not mapped.
+type ColumnMapping struct {
+	// ID names the column holding row IDs. Rows are numbered when empty.
+	ID string `json:"id"`
+	// Inputs lists the columns copied into Row.Inputs. When empty, every
+	// column not mapped elsewhere becomes an input.
+	Inputs   []string `json:"inputs"`
+	Expected string   `json:"expected"`
+	Split    string   `json:"split"`
+	Meta     []string `json:"meta"`
+}
+
+// ImportOptions controls how a file is read into rows.
+type ImportOptions struct {
+	// Format is FormatCSV or FormatJSONL.
+	Format  string
+	Mapping ColumnMapping
+	// SkipInvalid drops invalid rows instead of failing the import.
+	SkipInvalid bool
+	// MaxRows caps the rows read. Zero means DefaultMaxImportRows.
+	MaxRows int
+}
+
+// RowError reports an invalid source row. Line is 1-based.
+type RowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Dataset  Dataset `json:"dataset"`
+	Imported int     `json:"imported"`
+	Skipped  int     `json:"skipped"`
+	// Errors lists invalid rows, up to the first 100.
+	Errors []RowError `json:"errors,omitempty"`
+}
+
+// FormatFromFilename infers the import format from a file extension.
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	}
+	return ""
+}
+
+// ReadRows reads rows from src one record at a time, so large files are
+// never held in memory as a whole. Invalid rows are returned in the result;
+// unless opts.SkipInvalid is set, any of them fails the import with
+// ErrInvalidImport.
+func ReadRows(src io.Reader, opts ImportOptions) ([]Row, ImportResult, error) {
+	b := &rowBuilder{opts: opts, seen: make(map[string]struct{})}
+	if b.opts.MaxRows <= 0 {
+		b.opts.MaxRows = DefaultMaxImportRows
+	}
+	var err error
+	switch strings.ToLower(opts.Format) {
+	case FormatCSV:
+		err = b.readCSV(src)
+	case FormatJSONL:
+		err = b.readJSONL(src)
+	default:
+		err = fmt.Errorf("%w: unsupported format %q (want csv or jsonl)", ErrInvalidImport, opts.Format)
+	}
+	if err != nil {
+		return nil, b.result, err
+	}
+	if b.overflow {
+		return nil, b.result, fmt.Errorf("%w: more than %d rows", ErrInvalidImport, b.opts.MaxRows)
+	}
+	if len(b.result.Errors) > 0 && !opts.SkipInvalid {
+		return nil, b.result, fmt.Errorf("%w: %d invalid rows", ErrInvalidImport, b.invalid)
+	}
+	if len(b.rows) == 0 {
+		return nil, b.result, fmt.Errorf("%w: no rows", ErrInvalidImport)
+	}
+	b.result.Imported = len(b.rows)
+	return b.rows, b.result, nil
+}
+
+type rowBuilder struct {
+	opts ImportOptions
+	// native is set for JSONL records already in Row shape.
+	native   bool
+	rows     []Row
+	seen     map[string]struct{}
+	records  int
+	invalid  int
+	overflow bool
+	result   ImportResult
+}
+
+func (b *rowBuilder) readCSV(src io.Reader) error {
+	r := csv.NewReader(src)
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: empty file", ErrInvalidImport)
+		}
+		return fmt.Errorf("%w: read header: %v", ErrInvalidImport, err)
+	}
+	header = append([]string(nil), header...)
+	if err := b.checkMapping(header); err != nil {
+		return err
+	}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			b.records++
+			if !b.reject(perr.Line, perr.Err.Error()) {
+				return nil
+			}
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		values := make(map[string]any, len(header))
+		for i, col := range header {
+			values[col] = record[i]
+		}
+		if !b.add(line, values) {
+			return nil
+		}
+	}
+}
+
+func (b *rowBuilder) readJSONL(src io.Reader) error {
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	checked := false
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var values map[string]any
+		if err := json.Unmarshal([]byte(text), &values); err != nil || values == nil {
+			b.records++
+			if !b.reject(line, "not a JSON object") {
+				return nil
+			}
+			continue
+		}
+		if !checked {
+			// JSONL keys may vary by line; only check the mapping names
+			// against the first record.
+			if err := b.checkMapping(keysOf(values)); err != nil {
+				return err
+			}
+			checked = true
+		}
+		if !b.add(line, values) {
+			return nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return nil
+}
+
+// checkMapping verifies that every mapped column exists in columns.
+func (b *rowBuilder) checkMapping(columns []string) error {
+	if b.native = b.nativeRows(columns); b.native {
+		return nil
+	}
+	present := make(map[string]struct{}, len(columns))
+	for _, c := range columns {
+		present[c] = struct{}{}
+	}
+	m := b.opts.Mapping
+	mapped := append(append([]string{m.ID, m.Expected, m.Split}, m.Inputs...), m.Meta...)
+	for _, c := range mapped {
+		if c == "" {
+			continue
+		}
+		if _, ok := present[c]; !ok {
+			return fmt.Errorf("%w: mapped column %q not found", ErrInvalidImport, c)
+		}
+	}
+	return nil
+}
+
+// nativeRows reports whether records are already in Row shape: JSONL with an
+// "inputs" object and no mapping.
+func (b *rowBuilder) nativeRows(columns []string) bool {
+	m := b.opts.Mapping
+	if !strings.EqualFold(b.opts.Format, FormatJSONL) || m.ID != "" || len(m.Inputs) > 0 || m.Expected != "" || m.Split != "" || len(m.Meta) > 0 {
+		return false
+	}
+	for _, c := range columns {
+		if c == "inputs" {
+			return true
+		}
+	}
+	return false
+}
+
+// add maps values to a row and validates it. It returns false once the
+// import should stop reading.
+func (b *rowBuilder) add(line int, values map[string]any) bool {
+	b.records++
+	row, err := b.mapRow(values)
+	if err == nil {
+		if row.ID == "" {
+			row.ID = fmt.Sprintf("row-%06d", b.records)
+		}
+		if _, dup := b.seen[row.ID]; dup {
+			err = fmt.Errorf("duplicate row id %q", row.ID)
+		}
+	}
+	if err != nil {
+		return b.reject(line, err.Error())
+	}
+	if len(b.rows) >= b.opts.MaxRows {
+		b.overflow = true
+		return false
+	}
+	b.seen[row.ID] = struct{}{}
+	b.rows = append(b.rows, row)
+	return true
+}
+
+// reject records an invalid row. It returns false once reading should stop.
+func (b *rowBuilder) reject(line int, msg string) bool {
+	b.invalid++
+	if b.opts.SkipInvalid {
+		b.result.Skipped++
+	}
+	if len(b.result.Errors) < maxReportedErrors {
+		b.result.Errors = append(b.result.Errors, RowError{Line: line, Error: msg})
+	}
+	// Without SkipInvalid the import fails anyway; stop once the report is
+	// full rather than reading the rest of a large file.
+	return b.opts.SkipInvalid || len(b.result.Errors) < maxReportedErrors
+}
+
+func (b *rowBuilder) mapRow(values map[string]any) (Row, error) {
+	if b.native {
+		raw, _ := json.Marshal(values)
+		var row Row
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return Row{}, fmt.Errorf("invalid row: %v", err)
+		}
+		return row, validateRow(row)
+	}
+	m := b.opts.Mapping
+	row := Row{Inputs: make(map[string]any)}
+	if m.ID != "" {
+		row.ID = strings.TrimSpace(fmt.Sprint(values[m.ID]))
+	}
+	if m.Expected != "" {
+		row.Expected = values[m.Expected]
+	}
+	if m.Split != "" {
+		if s, ok := values[m.Split].(string); ok {
+			row.Split = strings.ToLower(strings.TrimSpace(s))
+		}
+	}
+	if len(m.Meta) > 0 {
+		row.Meta = make(map[string]any, len(m.Meta))
+		for _, c := range m.Meta {
+			row.Meta[c] = values[c]
+		}
+	}
+	if len(m.Inputs) > 0 {
+		for _, c := range m.Inputs {
+			row.Inputs[c] = values[c]
+		}
+	} else {
+		skip := map[string]bool{m.ID: true, m.Expected: true, m.Split: true}
+		for _, c := range m.Meta {
+			skip[c] = true
+		}
+		for k, v := range values {
+			if !skip[k] {
+				row.Inputs[k] = v
+			}
+		}
+	}
+	return row, validateRow(row)
+}
+
+func validateRow(row Row) error {
+	empty := true
+	for _, v := range row.Inputs {
+		if v != nil && v != "" {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return errors.New("row has no inputs")
+	}
+	switch row.Split {
+	case "", "train", "validation", "test":
+		return nil
+	}
+	return fmt.Errorf("unknown split %q (want train, validation or test)", row.Split)
+}
+
+func keysOf(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Import reads rows from src and creates ds with them as its initial
+// snapshot. Nothing is stored when the source is invalid.
+func (s *Service) Import(ctx context.Context, ds Dataset, src io.Reader, opts ImportOptions) (ImportResult, error) {
+	if strings.TrimSpace(ds.Name) == "" {
+		return ImportResult{}, fmt.Errorf("%w: dataset name is required", ErrInvalidImport)
+	}
+	rows, res, err := ReadRows(src, opts)
+	if err != nil {
+		return res, err
+	}
+	created, err := s.CreateDataset(ctx, ds, rows)
+	if err != nil {
+		return res, err
+	}
+	res.Dataset = created
+	return res, nil
+}
+
+// ChatTurn is one message of a chat session.
+type ChatTurn struct {
+	Role    string
+	Content string
+}
+
+// ChatSource loads the messages of a chat session visible to the caller.
+type ChatSource interface {
+	ChatTurns(ctx context.Context, sessionID string) ([]ChatTurn, error)
+}
+
+// WithChatSource enables ImportChat.
+func (s *Service) WithChatSource(src ChatSource) *Service {
+	s.chats = src
+	return s
+}
+
+// ChatImportOptions controls how chat turns become rows.
+type ChatImportOptions struct {
+	// InputKey names the input holding the user message. Default: "prompt".
+	InputKey string `json:"inputKey"`
+	// IncludeHistory adds the earlier user and assistant messages of the
+	// session as a "history" input.
+	IncludeHistory bool `json:"includeHistory"`
+	// Split is assigned to every row.
+	Split string `json:"split"`
+}
+
+// RowsFromChat turns each user message and the assistant reply that
+// follows it into a row. Tool messages are ignored; when the assistant
+// answers in several messages, the last one is the expected output. User
+// messages without a reply are dropped.
+func RowsFromChat(sessionID string, turns []ChatTurn, opts ChatImportOptions) []Row {
+	key := opts.InputKey
+	if key == "" {
+		key = "prompt"
+	}
+	var (
+		rows    []Row
+		history []map[string]string
+		prompt  string
+		answer  string
+		pending bool
+	)
+	flush := func() {
+		if pending && answer != "" {
+			inputs := map[string]any{key: prompt}
+			if opts.IncludeHistory {
+				inputs["history"] = append([]map[string]string{}, history...)
+			}
+			rows = append(rows, Row{
+				ID:       fmt.Sprintf("%s-%04d", sessionID, len(rows)+1),
+				Inputs:   inputs,
+				Expected: answer,
+				Meta:     map[string]any{"source": "chat", "sessionId": sessionID, "turn": len(rows) + 1},
+				Split:    opts.Split,
+			})
+			history = append(history,
+				map[string]string{"role": "user", "content": prompt},
+				map[string]string{"role": "assistant", "content": answer})
+		}
+		pending, prompt, answer = false, "", ""
+	}
+	for _, t := range turns {
+		content := strings.TrimSpace(t.Content)
+		switch t.Role {
+		case "user":
+			flush()
+			if content != "" {
+				pending, prompt = true, content
+			}
+		case "assistant":
+			if pending && content != "" {
+				answer = content
+			}
+		}
+	}
+	flush()
+	return rows
+}
+
+// ImportChat creates ds from the user/assistant turns of a chat session.
+func (s *Service) ImportChat(ctx context.Context, ds Dataset, sessionID string, opts ChatImportOptions) (ImportResult, error) {
+	if s.chats == nil {
+		return ImportResult{}, ErrNoChatSource
+	}
+	if strings.TrimSpace(ds.Name) == "" {
+		return ImportResult{}, fmt.Errorf("%w: dataset name is required", ErrInvalidImport)
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return ImportResult{}, fmt.Errorf("%w: sessionId is required", ErrInvalidImport)
+	}
+	switch opts.Split {
+	case "", "train", "validation", "test":
+	default:
+		return ImportResult{}, fmt.Errorf("%w: unknown split %q", ErrInvalidImport, opts.Split)
+	}
+	turns, err := s.chats.ChatTurns(ctx, sessionID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	rows := RowsFromChat(sessionID, turns, opts)
+	if len(rows) == 0 {
+		return ImportResult{}, fmt.Errorf("%w: session has no answered user messages", ErrInvalidImport)
+	}
+	created, err := s.CreateDataset(ctx, ds, rows)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return ImportResult{Dataset: created, Imported: len(rows)}, nil
+}
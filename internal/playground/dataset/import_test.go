@@ -0,0 +1,179 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type memStore struct {
+	datasets map[string]Dataset
+	rows     map[string][]Row
+}
+
+func newMemStore() *memStore {
+	return &memStore{datasets: map[string]Dataset{}, rows: map[string][]Row{}}
+}
+
+func (m *memStore) CreateDataset(_ context.Context, ds Dataset) (Dataset, error) {
+	m.datasets[ds.ID] = ds
+	return ds, nil
+}
+
+func (m *memStore) UpdateDataset(_ context.Context, ds Dataset) (Dataset, error) {
+	m.datasets[ds.ID] = ds
+	return ds, nil
+}
+
+func (m *memStore) GetDataset(_ context.Context, id string) (Dataset, bool, error) {
+	ds, ok := m.datasets[id]
+	return ds, ok, nil
+}
+
+func (m *memStore) ListDatasets(context.Context) ([]Dataset, error) { return nil, nil }
+
+func (m *memStore) CreateSnapshot(_ context.Context, s Snapshot, rows []Row) (Snapshot, error) {
+	m.rows[s.DatasetID] = rows
+	return s, nil
+}
+
+func (m *memStore) ListSnapshotRows(_ context.Context, datasetID, _ string) ([]Row, error) {
+	return m.rows[datasetID], nil
+}
+
+func (m *memStore) DeleteDataset(context.Context, string) error { return nil }
+
+func TestImportCSVWithMapping(t *testing.T) {
+	store := newMemStore()
+	svc := NewService(store)
+	src := "id,question,context,answer,split,source\n" +
+		"q1,What is 2+2?,math,4,test,quiz\n" +
+		"q2,Capital of France?,geo,Paris,validation,quiz\n"
+	res, err := svc.Import(context.Background(), Dataset{ID: "ds", Name: "quiz"}, strings.NewReader(src), ImportOptions{
+		Format:  FormatCSV,
+		Mapping: ColumnMapping{ID: "id", Expected: "answer", Split: "split", Meta: []string{"source"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 2 || res.Dataset.ID != "ds" {
+		t.Fatalf("result = %+v", res)
+	}
+	rows := store.rows["ds"]
+	if len(rows) != 2 {
+		t.Fatalf("stored %d rows", len(rows))
+	}
+	r := rows[1]
+	if r.ID != "q2" || r.Expected != "Paris" || r.Split != "validation" || r.Meta["source"] != "quiz" {
+		t.Fatalf("row = %+v", r)
+	}
+	if len(r.Inputs) != 2 || r.Inputs["question"] != "Capital of France?" || r.Inputs["context"] != "geo" {
+		t.Fatalf("inputs = %+v", r.Inputs)
+	}
+}
+
+func TestImportRejectsInvalidRows(t *testing.T) {
+	src := "q,a\nhello,hi\n,empty\nbye,ciao,extra\n"
+	opts := ImportOptions{Format: FormatCSV, Mapping: ColumnMapping{Expected: "a"}}
+
+	store := newMemStore()
+	res, err := NewService(store).Import(context.Background(), Dataset{ID: "ds", Name: "x"}, strings.NewReader(src), opts)
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(res.Errors) != 2 || res.Errors[0].Line != 3 || res.Errors[1].Line != 4 {
+		t.Fatalf("errors = %+v", res.Errors)
+	}
+	if len(store.datasets) != 0 {
+		t.Fatal("invalid import stored a dataset")
+	}
+
+	opts.SkipInvalid = true
+	res, err = NewService(store).Import(context.Background(), Dataset{ID: "ds", Name: "x"}, strings.NewReader(src), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 1 || res.Skipped != 2 || store.rows["ds"][0].ID != "row-000001" {
+		t.Fatalf("result = %+v rows = %+v", res, store.rows["ds"])
+	}
+
+	_, _, err = ReadRows(strings.NewReader(src), ImportOptions{Format: FormatCSV, Mapping: ColumnMapping{Expected: "missing"}})
+	if !errors.Is(err, ErrInvalidImport) || !strings.Contains(err.Error(), `"missing"`) {
+		t.Fatalf("unknown column err = %v", err)
+	}
+}
+
+func TestReadRowsJSONL(t *testing.T) {
+	native := `{"id":"a","inputs":{"q":"one"},"expected":"1"}` + "\n\n" +
+		`{"id":"b","inputs":{"q":"two"},"expected":"2","split":"train"}` + "\n"
+	rows, _, err := ReadRows(strings.NewReader(native), ImportOptions{Format: FormatJSONL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1].ID != "b" || rows[1].Inputs["q"] != "two" || rows[1].Split != "train" {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	mapped := `{"prompt":"hi","reply":"hello","n":3}` + "\n" + `not json` + "\n"
+	rows, res, err := ReadRows(strings.NewReader(mapped), ImportOptions{
+		Format:      FormatJSONL,
+		Mapping:     ColumnMapping{Inputs: []string{"prompt", "n"}, Expected: "reply"},
+		SkipInvalid: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Inputs["n"] != float64(3) || rows[0].Expected != "hello" || res.Skipped != 1 || res.Errors[0].Line != 2 {
+		t.Fatalf("rows = %+v result = %+v", rows, res)
+	}
+
+	_, _, err = ReadRows(strings.NewReader(native), ImportOptions{Format: FormatJSONL, MaxRows: 1})
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("row cap err = %v", err)
+	}
+}
+
+type staticChats []ChatTurn
+
+func (c staticChats) ChatTurns(_ context.Context, sessionID string) ([]ChatTurn, error) {
+	if sessionID != "s1" {
+		return nil, ErrChatSessionNotFound
+	}
+	return c, nil
+}
+
+func TestImportChat(t *testing.T) {
+	store := newMemStore()
+	svc := NewService(store).WithChatSource(staticChats{
+		{Role: "user", Content: "list files"},
+		{Role: "tool", Content: "a.go b.go"},
+		{Role: "assistant", Content: "a.go and b.go"},
+		{Role: "user", Content: "never answered"},
+		{Role: "user", Content: "thanks"},
+		{Role: "assistant", Content: "working"},
+		{Role: "assistant", Content: "you're welcome"},
+	})
+	res, err := svc.ImportChat(context.Background(), Dataset{ID: "ds", Name: "chat"}, "s1", ChatImportOptions{IncludeHistory: true, Split: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := store.rows["ds"]
+	if res.Imported != 2 || len(rows) != 2 {
+		t.Fatalf("result = %+v rows = %+v", res, rows)
+	}
+	if rows[0].ID != "s1-0001" || rows[0].Inputs["prompt"] != "list files" || rows[0].Expected != "a.go and b.go" || rows[0].Split != "test" {
+		t.Fatalf("first row = %+v", rows[0])
+	}
+	history, _ := rows[1].Inputs["history"].([]map[string]string)
+	if rows[1].Expected != "you're welcome" || len(history) != 2 || history[1]["content"] != "a.go and b.go" {
+		t.Fatalf("second row = %+v", rows[1])
+	}
+
+	if _, err := svc.ImportChat(context.Background(), Dataset{ID: "x", Name: "x"}, "other", ChatImportOptions{}); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := NewService(store).ImportChat(context.Background(), Dataset{ID: "x", Name: "x"}, "s1", ChatImportOptions{}); !errors.Is(err, ErrNoChatSource) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"manifold/internal/playground/experiment"
 	"manifold/internal/playground/provider"
@@ -30,11 +31,32 @@ type Registry struct {
 	factories map[string]Factory
 }
 
-// NewRegistry constructs a registry with built-in evaluators.
+var (
+	pluginsMu sync.Mutex
+	plugins   = map[string]Factory{}
+)
+
+// RegisterPlugin makes an evaluator available to every registry created
+// afterwards, so packages outside eval can add metrics from an init
+// function. Plugins cannot replace built-in evaluators.
+func RegisterPlugin(name string, factory Factory) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins[strings.ToLower(name)] = factory
+}
+
+// NewRegistry constructs a registry with built-in evaluators and registered
+// plugins.
 func NewRegistry() *Registry {
 	r := &Registry{factories: make(map[string]Factory)}
+	pluginsMu.Lock()
+	for name, factory := range plugins {
+		r.Register(name, factory)
+	}
+	pluginsMu.Unlock()
 	r.Register("format", newFormatEvaluator)
 	r.Register("llm-judge", newJudgeEvaluator)
+	r.Register("pairwise-judge", newPairwiseEvaluator)
 	return r
 }
 
@@ -0,0 +1,228 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
+	"manifold/internal/playground/worker"
+)
+
+// PairwiseMetric is the per-result score of a candidate against the
+// baseline on the same row: 1 for a win, 0.5 for a tie and 0 for a loss.
+const PairwiseMetric = "pairwise/win"
+
+// pairwiseZ is the normal quantile for the 95% confidence intervals.
+const pairwiseZ = 1.96
+
+const pairwisePrompt = `You are comparing two responses to the same input.
+%s
+Input:
+%s
+
+Response A:
+%s
+
+Response B:
+%s
+
+Which response is better? Answer with exactly one word: A, B or TIE.`
+
+// pairwiseEvaluator asks a judge model to pick the better of the baseline
+// and each other variant's output on every dataset row.
+type pairwiseEvaluator struct {
+	provider provider.Provider
+	baseline string
+	model    string
+	criteria string
+}
+
+func newPairwiseEvaluator(cfg experiment.EvaluatorConfig, prov provider.Provider) (Evaluator, error) {
+	e := &pairwiseEvaluator{provider: prov}
+	if cfg.Params != nil {
+		e.baseline, _ = cfg.Params["baseline"].(string)
+		e.model, _ = cfg.Params["model"].(string)
+		e.criteria, _ = cfg.Params["criteria"].(string)
+	}
+	return e, nil
+}
+
+func (p *pairwiseEvaluator) Name() string { return "pairwise-judge" }
+
+// PairwiseBaseline returns the variant the spec's pairwise evaluator compares
+// against: its "baseline" parameter, or the first variant. It is empty when
+// the spec has no pairwise evaluator.
+func PairwiseBaseline(spec experiment.ExperimentSpec) string {
+	for _, cfg := range spec.Evaluators {
+		if !strings.EqualFold(cfg.Name, "pairwise-judge") {
+			continue
+		}
+		if b, _ := cfg.Params["baseline"].(string); b != "" {
+			return b
+		}
+		if len(spec.Variants) > 0 {
+			return spec.Variants[0].ID
+		}
+	}
+	return ""
+}
+
+func (p *pairwiseEvaluator) Evaluate(ctx context.Context, spec experiment.ExperimentSpec, results []worker.Result) (Outcome, error) {
+	baseline := p.baseline
+	if baseline == "" && len(spec.Variants) > 0 {
+		baseline = spec.Variants[0].ID
+	}
+	baseByRow := make(map[string]worker.Result)
+	for _, r := range results {
+		if r.VariantID == baseline {
+			baseByRow[r.RowID] = r
+		}
+	}
+	if len(baseByRow) == 0 {
+		return Outcome{}, fmt.Errorf("playground/eval: pairwise baseline %q produced no results", baseline)
+	}
+
+	scores := make(map[int]map[string]float64)
+	byVariant := make(map[string][]float64)
+	var order []string
+	for idx, res := range results {
+		if err := ctx.Err(); err != nil {
+			return Outcome{}, err
+		}
+		base, ok := baseByRow[res.RowID]
+		if res.VariantID == baseline || !ok {
+			continue
+		}
+		// Alternate which side the candidate is shown on to offset the
+		// judge's position bias.
+		swap := len(byVariant[res.VariantID])%2 == 1
+		score, err := p.compare(ctx, base, res, swap)
+		if err != nil {
+			return Outcome{}, err
+		}
+		scores[idx] = map[string]float64{PairwiseMetric: score}
+		if _, seen := byVariant[res.VariantID]; !seen {
+			order = append(order, res.VariantID)
+		}
+		byVariant[res.VariantID] = append(byVariant[res.VariantID], score)
+	}
+
+	aggregate := make(map[string]float64, len(order)*3)
+	for _, v := range order {
+		st := NewPairwiseStats(baseline, v, byVariant[v])
+		prefix := "pairwise/" + v + "/"
+		aggregate[prefix+"win_rate"] = st.WinRate
+		aggregate[prefix+"ci_low"] = st.CILow
+		aggregate[prefix+"ci_high"] = st.CIHigh
+	}
+	return Outcome{Aggregate: aggregate, Scores: scores}, nil
+}
+
+// compare returns the candidate's score against base. Without a provider it
+// falls back to matching the row's expected value.
+func (p *pairwiseEvaluator) compare(ctx context.Context, base, cand worker.Result, swap bool) (float64, error) {
+	if p.provider == nil {
+		return expectationScore(base, cand), nil
+	}
+	a, b := base.Output, cand.Output
+	if swap {
+		a, b = b, a
+	}
+	criteria := ""
+	if p.criteria != "" {
+		criteria = "Judge by: " + p.criteria + "\n"
+	}
+	model := p.model
+	if model == "" {
+		model = cand.Model
+	}
+	resp, err := p.provider.Complete(ctx, provider.Request{
+		Model:  model,
+		Prompt: fmt.Sprintf(pairwisePrompt, criteria, cand.RenderedPrompt, a, b),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("pairwise judge: %w", err)
+	}
+	switch parseVerdict(resp.Output) {
+	case "A":
+		return boolScore(swap), nil
+	case "B":
+		return boolScore(!swap), nil
+	default:
+		return 0.5, nil
+	}
+}
+
+func expectationScore(base, cand worker.Result) float64 {
+	if cand.Expected == nil {
+		return 0.5
+	}
+	want := strings.TrimSpace(fmt.Sprint(cand.Expected))
+	baseOK := strings.EqualFold(strings.TrimSpace(base.Output), want)
+	candOK := strings.EqualFold(strings.TrimSpace(cand.Output), want)
+	switch {
+	case candOK == baseOK:
+		return 0.5
+	case candOK:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseVerdict reads A, B or TIE from the start of a judge reply.
+func parseVerdict(out string) string {
+	fields := strings.Fields(strings.ToUpper(out))
+	if len(fields) == 0 {
+		return ""
+	}
+	v := strings.Trim(fields[0], ".,:;!*\"'()[]")
+	if strings.HasPrefix(v, "RESPONSE") && len(fields) > 1 {
+		v = strings.Trim(fields[1], ".,:;!*\"'()[]")
+	}
+	return v
+}
+
+// PairwiseStats is a candidate variant's head-to-head record against the
+// baseline.
+type PairwiseStats struct {
+	Baseline  string  `json:"baseline"`
+	Candidate string  `json:"candidate"`
+	Wins      int     `json:"wins"`
+	Losses    int     `json:"losses"`
+	Ties      int     `json:"ties"`
+	WinRate   float64 `json:"winRate"`
+	CILow     float64 `json:"ciLow"`
+	CIHigh    float64 `json:"ciHigh"`
+}
+
+// NewPairwiseStats tallies PairwiseMetric scores. Ties count as half a win
+// in the win rate, and the bounds are a 95% Wilson score interval.
+func NewPairwiseStats(baseline, candidate string, scores []float64) PairwiseStats {
+	st := PairwiseStats{Baseline: baseline, Candidate: candidate}
+	for _, s := range scores {
+		switch {
+		case s >= 1:
+			st.Wins++
+		case s <= 0:
+			st.Losses++
+		default:
+			st.Ties++
+		}
+	}
+	n := float64(len(scores))
+	if n == 0 {
+		return st
+	}
+	p := (float64(st.Wins) + 0.5*float64(st.Ties)) / n
+	st.WinRate = p
+	z2 := pairwiseZ * pairwiseZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := pairwiseZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	st.CILow = math.Max(0, center-margin)
+	st.CIHigh = math.Min(1, center+margin)
+	return st
+}
@@ -0,0 +1,82 @@
+package eval
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
+	"manifold/internal/playground/worker"
+)
+
+// preferProvider answers for whichever side shows the preferred output.
+type preferProvider struct {
+	prefer string
+	calls  int
+}
+
+func (p *preferProvider) Name() string { return "prefer" }
+
+func (p *preferProvider) Complete(_ context.Context, req provider.Request) (provider.Response, error) {
+	p.calls++
+	a := req.Prompt[strings.Index(req.Prompt, "Response A:"):strings.Index(req.Prompt, "Response B:")]
+	if strings.Contains(a, p.prefer) {
+		return provider.Response{Output: "A"}, nil
+	}
+	return provider.Response{Output: "**B**."}, nil
+}
+
+func TestPairwiseEvaluator(t *testing.T) {
+	spec := experiment.ExperimentSpec{
+		Variants:   []experiment.Variant{{ID: "base"}, {ID: "cand"}},
+		Evaluators: []experiment.EvaluatorConfig{{Name: "pairwise-judge"}},
+	}
+	var results []worker.Result
+	for _, row := range []string{"r1", "r2", "r3", "r4"} {
+		results = append(results,
+			worker.Result{RowID: row, VariantID: "base", Output: "plain"},
+			worker.Result{RowID: row, VariantID: "cand", Output: "better"})
+	}
+	prov := &preferProvider{prefer: "better"}
+	ev, err := NewRegistry().Instantiate(spec.Evaluators[0], prov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ev.Evaluate(context.Background(), spec, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prov.calls != 4 {
+		t.Fatalf("expected one judge call per row, got %d", prov.calls)
+	}
+	for idx, r := range results {
+		score, ok := out.Scores[idx][PairwiseMetric]
+		if r.VariantID == "base" && ok {
+			t.Fatalf("baseline result %d should not be scored", idx)
+		}
+		if r.VariantID == "cand" && score != 1 {
+			t.Fatalf("candidate result %d scored %v, want a win regardless of position", idx, score)
+		}
+	}
+	if out.Aggregate["pairwise/cand/win_rate"] != 1 || out.Aggregate["pairwise/cand/ci_high"] != 1 || out.Aggregate["pairwise/cand/ci_low"] >= 1 {
+		t.Fatalf("unexpected aggregate %v", out.Aggregate)
+	}
+	if PairwiseBaseline(spec) != "base" {
+		t.Fatalf("unexpected baseline %q", PairwiseBaseline(spec))
+	}
+}
+
+func TestNewPairwiseStats(t *testing.T) {
+	st := NewPairwiseStats("a", "b", []float64{1, 1, 1, 0, 0.5, 0.5, 1, 0, 1, 1})
+	if st.Wins != 6 || st.Losses != 2 || st.Ties != 2 || st.WinRate != 0.7 {
+		t.Fatalf("unexpected tally %+v", st)
+	}
+	if math.Abs(st.CILow-0.3968) > 1e-3 || math.Abs(st.CIHigh-0.8922) > 1e-3 {
+		t.Fatalf("unexpected interval [%v, %v]", st.CILow, st.CIHigh)
+	}
+	if empty := NewPairwiseStats("a", "b", nil); empty.WinRate != 0 || empty.CIHigh != 0 {
+		t.Fatalf("unexpected empty stats %+v", empty)
+	}
+}
@@ -0,0 +1,197 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
+	"manifold/internal/playground/worker"
+)
+
+const defaultScriptTimeout = 30 * time.Second
+
+// maxScriptResponse bounds how much of a script's reply is read.
+const maxScriptResponse = 1 << 20
+
+// ScriptConfig describes an evaluator that runs outside the process: either
+// a command that reads a JSON payload on stdin and writes scores to stdout,
+// or a webhook that receives the payload as a POST body.
+type ScriptConfig struct {
+	Command []string
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// ScriptPayload is sent to a script evaluator for every result.
+type ScriptPayload struct {
+	ExperimentID string         `json:"experimentId"`
+	RowID        string         `json:"rowId"`
+	VariantID    string         `json:"variantId"`
+	Model        string         `json:"model,omitempty"`
+	Prompt       string         `json:"prompt"`
+	Output       string         `json:"output"`
+	Expected     any            `json:"expected,omitempty"`
+	Params       map[string]any `json:"params,omitempty"`
+}
+
+// ScriptReply is what a script evaluator returns. A bare score is reported
+// as the "score" metric.
+type ScriptReply struct {
+	Score  *float64           `json:"score,omitempty"`
+	Scores map[string]float64 `json:"scores,omitempty"`
+}
+
+// RegisterScript registers an external evaluator under name. Metrics it
+// returns are reported as "<name>/<metric>", averaged across results for the
+// aggregate.
+func (r *Registry) RegisterScript(name string, def ScriptConfig) error {
+	if (len(def.Command) == 0) == (def.URL == "") {
+		return fmt.Errorf("playground/eval: script evaluator %q needs exactly one of command or url", name)
+	}
+	if _, exists := r.factories[strings.ToLower(name)]; exists {
+		return fmt.Errorf("playground/eval: evaluator %q is already registered", name)
+	}
+	if def.Timeout <= 0 {
+		def.Timeout = defaultScriptTimeout
+	}
+	if def.Client == nil {
+		def.Client = &http.Client{}
+	}
+	r.Register(name, func(cfg experiment.EvaluatorConfig, _ provider.Provider) (Evaluator, error) {
+		return &scriptEvaluator{name: name, def: def, params: cfg.Params}, nil
+	})
+	return nil
+}
+
+// scriptEvaluator scores each result by calling its command or webhook.
+type scriptEvaluator struct {
+	name   string
+	def    ScriptConfig
+	params map[string]any
+}
+
+func (s *scriptEvaluator) Name() string { return s.name }
+
+func (s *scriptEvaluator) Evaluate(ctx context.Context, spec experiment.ExperimentSpec, results []worker.Result) (Outcome, error) {
+	scores := make(map[int]map[string]float64, len(results))
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for idx, res := range results {
+		if err := ctx.Err(); err != nil {
+			return Outcome{}, err
+		}
+		payload, err := json.Marshal(ScriptPayload{
+			ExperimentID: spec.ID,
+			RowID:        res.RowID,
+			VariantID:    res.VariantID,
+			Model:        res.Model,
+			Prompt:       res.RenderedPrompt,
+			Output:       res.Output,
+			Expected:     res.Expected,
+			Params:       s.params,
+		})
+		if err != nil {
+			return Outcome{}, err
+		}
+		reply, err := s.call(ctx, payload)
+		if err != nil {
+			return Outcome{}, fmt.Errorf("evaluator %s row %s: %w", s.name, res.RowID, err)
+		}
+		row := make(map[string]float64, len(reply.Scores)+1)
+		for metric, v := range reply.Scores {
+			row[s.name+"/"+metric] = v
+		}
+		if reply.Score != nil {
+			row[s.name+"/score"] = *reply.Score
+		}
+		for metric, v := range row {
+			sums[metric] += v
+			counts[metric]++
+		}
+		scores[idx] = row
+	}
+	aggregate := make(map[string]float64, len(sums))
+	for metric, sum := range sums {
+		aggregate[metric] = sum / float64(counts[metric])
+	}
+	return Outcome{Aggregate: aggregate, Scores: scores}, nil
+}
+
+func (s *scriptEvaluator) call(ctx context.Context, payload []byte) (ScriptReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.def.Timeout)
+	defer cancel()
+	var out []byte
+	var err error
+	if s.def.URL != "" {
+		out, err = s.post(ctx, payload)
+	} else {
+		out, err = s.run(ctx, payload)
+	}
+	if err != nil {
+		return ScriptReply{}, err
+	}
+	var reply ScriptReply
+	if err := json.Unmarshal(out, &reply); err != nil {
+		return ScriptReply{}, fmt.Errorf("invalid reply: %w", err)
+	}
+	if reply.Score == nil && len(reply.Scores) == 0 {
+		return ScriptReply{}, errors.New("reply has no scores")
+	}
+	return reply, nil
+}
+
+func (s *scriptEvaluator) run(ctx context.Context, payload []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, s.def.Command[0], s.def.Command[1:]...)
+	c.Stdin = bytes.NewReader(payload)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, truncate(msg, 512))
+		}
+		return nil, err
+	}
+	if stdout.Len() > maxScriptResponse {
+		return nil, errors.New("reply too large")
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s *scriptEvaluator) post(ctx context.Context, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.def.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.def.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScriptResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("webhook returned %s: %s", resp.Status, truncate(strings.TrimSpace(string(body)), 512))
+	}
+	return body, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,113 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
+	"manifold/internal/playground/worker"
+)
+
+var scriptResults = []worker.Result{
+	{RowID: "r1", VariantID: "v", Output: "SELECT 1", Expected: "SELECT 1"},
+	{RowID: "r2", VariantID: "v", Output: "nope"},
+}
+
+func TestScriptEvaluatorWebhook(t *testing.T) {
+	var got []ScriptPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p ScriptPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		got = append(got, p)
+		score := 0.0
+		if strings.HasPrefix(p.Output, "SELECT") {
+			score = 1
+		}
+		_ = json.NewEncoder(w).Encode(ScriptReply{Scores: map[string]float64{"valid": score}})
+	}))
+	defer srv.Close()
+
+	reg := NewRegistry()
+	if err := reg.RegisterScript("sql", ScriptConfig{URL: srv.URL}); err != nil {
+		t.Fatalf("RegisterScript: %v", err)
+	}
+	cfg := experiment.EvaluatorConfig{Name: "SQL", Params: map[string]any{"dialect": "postgres"}}
+	ev, err := reg.Instantiate(cfg, nil)
+	if err != nil {
+		t.Fatalf("Instantiate: %v", err)
+	}
+	out, err := ev.Evaluate(context.Background(), experiment.ExperimentSpec{ID: "exp"}, scriptResults)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if out.Aggregate["sql/valid"] != 0.5 || out.Scores[0]["sql/valid"] != 1 || out.Scores[1]["sql/valid"] != 0 {
+		t.Fatalf("unexpected outcome %+v", out)
+	}
+	if len(got) != 2 || got[0].ExperimentID != "exp" || got[0].Expected != "SELECT 1" || got[1].Params["dialect"] != "postgres" {
+		t.Fatalf("unexpected payloads %+v", got)
+	}
+}
+
+func TestScriptEvaluatorCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	reg := NewRegistry()
+	if err := reg.RegisterScript("len", ScriptConfig{Command: []string{"sh", "-c", `wc -c >/dev/null; echo '{"score": 0.25}'`}}); err != nil {
+		t.Fatalf("RegisterScript: %v", err)
+	}
+	ev, _ := reg.Instantiate(experiment.EvaluatorConfig{Name: "len"}, nil)
+	out, err := ev.Evaluate(context.Background(), experiment.ExperimentSpec{}, scriptResults)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if out.Aggregate["len/score"] != 0.25 || out.Scores[1]["len/score"] != 0.25 {
+		t.Fatalf("unexpected outcome %+v", out)
+	}
+
+	if err := reg.RegisterScript("broken", ScriptConfig{Command: []string{"sh", "-c", "echo boom >&2; exit 3"}}); err != nil {
+		t.Fatalf("RegisterScript: %v", err)
+	}
+	ev, _ = reg.Instantiate(experiment.EvaluatorConfig{Name: "broken"}, nil)
+	if _, err := ev.Evaluate(context.Background(), experiment.ExperimentSpec{}, scriptResults); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected stderr in error, got %v", err)
+	}
+}
+
+func TestRegisterScriptRejectsInvalid(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.RegisterScript("x", ScriptConfig{}); err == nil {
+		t.Fatal("expected error without command or url")
+	}
+	if err := reg.RegisterScript("format", ScriptConfig{URL: "http://example.com"}); err == nil {
+		t.Fatal("expected error when shadowing a built-in evaluator")
+	}
+}
+
+type constEvaluator struct{}
+
+func (constEvaluator) Name() string { return "const" }
+
+func (constEvaluator) Evaluate(context.Context, experiment.ExperimentSpec, []worker.Result) (Outcome, error) {
+	return Outcome{Aggregate: map[string]float64{"const/one": 1}}, nil
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	RegisterPlugin("const", func(experiment.EvaluatorConfig, provider.Provider) (Evaluator, error) {
+		return constEvaluator{}, nil
+	})
+	agg, _, err := NewRunner(NewRegistry(), nil).Evaluate(context.Background(), experiment.ExperimentSpec{
+		Evaluators: []experiment.EvaluatorConfig{{Name: "const"}},
+	}, nil)
+	if err != nil || agg["const/one"] != 1 {
+		t.Fatalf("plugin not used: %v %v", agg, err)
+	}
+}
@@ -0,0 +1,170 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/registry"
+)
+
+var (
+	// ErrGateFailed is returned when an eval gate's score is below its minimum.
+	ErrGateFailed = errors.New("playground: eval gate failed")
+	// ErrGateNoRun is returned by verify-mode gates when no completed run of the
+	// gate experiment covers the candidate.
+	ErrGateNoRun = errors.New("playground: no completed run satisfies eval gate")
+)
+
+// GateResult reports how an eval gate was evaluated.
+type GateResult struct {
+	ExperimentID string  `json:"experimentId"`
+	Metric       string  `json:"metric"`
+	MinScore     float64 `json:"minScore"`
+	Score        float64 `json:"score"`
+	Passed       bool    `json:"passed"`
+	RunID        string  `json:"runId,omitempty"`
+	// Ran is true when the gate started a new run rather than reusing one.
+	Ran bool `json:"ran"`
+}
+
+// EvaluateGate checks gate against the experiment it names. When
+// promptVersionID is set the experiment's variants are pointed at that
+// version, so the score reflects the candidate rather than whatever the
+// experiment was authored with; otherwise the experiment is used as-is. A
+// failing gate returns the result alongside ErrGateFailed.
+func (s *Service) EvaluateGate(ctx context.Context, gate registry.EvalGate, promptVersionID string) (GateResult, error) {
+	if err := gate.Validate(); err != nil {
+		return GateResult{}, err
+	}
+	result := GateResult{ExperimentID: gate.ExperimentID, Metric: gate.Metric, MinScore: gate.MinScore}
+
+	spec, ok, err := s.GetExperiment(ctx, gate.ExperimentID)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		return result, ErrUnknownExperiment
+	}
+
+	var run Run
+	if gate.Mode == registry.GateModeVerify {
+		run, ok, err = s.latestGateRun(ctx, spec.ID, promptVersionID)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			return result, ErrGateNoRun
+		}
+	} else {
+		run, err = s.executeRun(ctx, gateSpec(spec, promptVersionID))
+		if err != nil {
+			return result, fmt.Errorf("run gate experiment: %w", err)
+		}
+		result.Ran = true
+	}
+	result.RunID = run.ID
+
+	metric, err := gateMetric(gate.Metric, run.Metrics)
+	if err != nil {
+		return result, err
+	}
+	result.Metric = metric
+	result.Score = run.Metrics[metric]
+	result.Passed = result.Score >= gate.MinScore
+	if !result.Passed {
+		return result, fmt.Errorf("%w: %s %.4f < %.4f", ErrGateFailed, metric, result.Score, gate.MinScore)
+	}
+	return result, nil
+}
+
+// DeployPromptVersion activates versionID for promptID after enforcing the
+// version's eval gate, if it declares one. The gate result is nil for
+// ungated versions.
+func (s *Service) DeployPromptVersion(ctx context.Context, promptID, versionID string) (registry.Prompt, *GateResult, error) {
+	version, ok, err := s.registry.GetPromptVersion(ctx, versionID)
+	if err != nil {
+		return registry.Prompt{}, nil, err
+	}
+	if !ok || version.PromptID != promptID {
+		return registry.Prompt{}, nil, registry.ErrPromptVersionNotFound
+	}
+	var gate *GateResult
+	if version.EvalGate != nil {
+		result, err := s.EvaluateGate(ctx, *version.EvalGate, version.ID)
+		if err != nil {
+			return registry.Prompt{}, &result, err
+		}
+		gate = &result
+	}
+	prompt, err := s.registry.SetActiveVersion(ctx, promptID, versionID)
+	return prompt, gate, err
+}
+
+// gateSpec returns a copy of spec whose variants all run promptVersionID.
+// Model and parameter choices are kept so the candidate is scored under the
+// same conditions as the experiment's baseline.
+func gateSpec(spec experiment.ExperimentSpec, promptVersionID string) experiment.ExperimentSpec {
+	if promptVersionID == "" {
+		return spec
+	}
+	out := spec
+	out.Variants = make([]experiment.Variant, len(spec.Variants))
+	for i, v := range spec.Variants {
+		v.PromptVersionID = promptVersionID
+		v.PromptTemplate = ""
+		v.Variables = nil
+		out.Variants[i] = v
+	}
+	return out
+}
+
+// latestGateRun returns the most recent completed run of experimentID. When
+// promptVersionID is set only runs whose plan exercised that version count.
+func (s *Service) latestGateRun(ctx context.Context, experimentID, promptVersionID string) (Run, bool, error) {
+	runs, err := s.store.ListRuns(ctx, experimentID)
+	if err != nil {
+		return Run{}, false, err
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].CreatedAt.After(runs[j].CreatedAt) })
+	for _, run := range runs {
+		if run.Status != RunStatusCompleted {
+			continue
+		}
+		if promptVersionID == "" || planUsesPromptVersion(run.Plan, promptVersionID) {
+			return run, true, nil
+		}
+	}
+	return Run{}, false, nil
+}
+
+func planUsesPromptVersion(plan experiment.RunPlan, promptVersionID string) bool {
+	for _, shard := range plan.Shards {
+		for _, v := range shard.Variants {
+			if v.PromptVersionID == promptVersionID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gateMetric resolves the metric a gate compares, defaulting to the only
+// metric when the run produced exactly one.
+func gateMetric(metric string, metrics map[string]float64) (string, error) {
+	if metric != "" {
+		if _, ok := metrics[metric]; !ok {
+			return metric, fmt.Errorf("gate metric %q not reported by run", metric)
+		}
+		return metric, nil
+	}
+	if len(metrics) != 1 {
+		return "", fmt.Errorf("gate metric must be set when the run reports %d metrics", len(metrics))
+	}
+	for name := range metrics {
+		return name, nil
+	}
+	return "", nil
+}
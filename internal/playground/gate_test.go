@@ -0,0 +1,301 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"manifold/internal/playground/dataset"
+	"manifold/internal/playground/eval"
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
+	"manifold/internal/playground/registry"
+	"manifold/internal/playground/worker"
+)
+
+type memoryPlaygroundStore struct {
+	prompts     map[string]registry.Prompt
+	versions    map[string]registry.PromptVersion
+	rows        []dataset.Row
+	experiments map[string]experiment.ExperimentSpec
+	runs        []Run
+	results     map[string][]RunResult
+}
+
+func newMemoryPlaygroundStore() *memoryPlaygroundStore {
+	return &memoryPlaygroundStore{
+		prompts:     map[string]registry.Prompt{},
+		versions:    map[string]registry.PromptVersion{},
+		experiments: map[string]experiment.ExperimentSpec{},
+		results:     map[string][]RunResult{},
+	}
+}
+
+func (m *memoryPlaygroundStore) CreatePrompt(_ context.Context, p registry.Prompt) (registry.Prompt, error) {
+	m.prompts[p.ID] = p
+	return p, nil
+}
+
+func (m *memoryPlaygroundStore) UpdatePrompt(_ context.Context, p registry.Prompt) (registry.Prompt, error) {
+	if _, ok := m.prompts[p.ID]; !ok {
+		return registry.Prompt{}, registry.ErrPromptNotFound
+	}
+	m.prompts[p.ID] = p
+	return p, nil
+}
+
+func (m *memoryPlaygroundStore) GetPrompt(_ context.Context, id string) (registry.Prompt, bool, error) {
+	p, ok := m.prompts[id]
+	return p, ok, nil
+}
+
+func (m *memoryPlaygroundStore) ListPrompts(context.Context, registry.ListFilter) ([]registry.Prompt, error) {
+	return nil, nil
+}
+
+func (m *memoryPlaygroundStore) CreatePromptVersion(_ context.Context, v registry.PromptVersion) (registry.PromptVersion, error) {
+	m.versions[v.ID] = v
+	return v, nil
+}
+
+func (m *memoryPlaygroundStore) ListPromptVersions(context.Context, string) ([]registry.PromptVersion, error) {
+	return nil, nil
+}
+
+func (m *memoryPlaygroundStore) GetPromptVersion(_ context.Context, id string) (registry.PromptVersion, bool, error) {
+	v, ok := m.versions[id]
+	return v, ok, nil
+}
+
+func (m *memoryPlaygroundStore) DeletePrompt(context.Context, string) error { return nil }
+
+func (m *memoryPlaygroundStore) CreateDataset(_ context.Context, ds dataset.Dataset) (dataset.Dataset, error) {
+	return ds, nil
+}
+
+func (m *memoryPlaygroundStore) UpdateDataset(_ context.Context, ds dataset.Dataset) (dataset.Dataset, error) {
+	return ds, nil
+}
+
+func (m *memoryPlaygroundStore) GetDataset(context.Context, string) (dataset.Dataset, bool, error) {
+	return dataset.Dataset{}, false, nil
+}
+
+func (m *memoryPlaygroundStore) ListDatasets(context.Context) ([]dataset.Dataset, error) {
+	return nil, nil
+}
+
+func (m *memoryPlaygroundStore) CreateSnapshot(_ context.Context, s dataset.Snapshot, _ []dataset.Row) (dataset.Snapshot, error) {
+	return s, nil
+}
+
+func (m *memoryPlaygroundStore) ListSnapshotRows(context.Context, string, string) ([]dataset.Row, error) {
+	return m.rows, nil
+}
+
+func (m *memoryPlaygroundStore) DeleteDataset(context.Context, string) error { return nil }
+
+func (m *memoryPlaygroundStore) CreateExperiment(_ context.Context, spec experiment.ExperimentSpec) (experiment.ExperimentSpec, error) {
+	m.experiments[spec.ID] = spec
+	return spec, nil
+}
+
+func (m *memoryPlaygroundStore) GetExperiment(_ context.Context, id string) (experiment.ExperimentSpec, bool, error) {
+	spec, ok := m.experiments[id]
+	return spec, ok, nil
+}
+
+func (m *memoryPlaygroundStore) ListExperiments(context.Context) ([]experiment.ExperimentSpec, error) {
+	return nil, nil
+}
+
+func (m *memoryPlaygroundStore) CreateRun(_ context.Context, run Run) (Run, error) {
+	m.runs = append(m.runs, run)
+	return run, nil
+}
+
+func (m *memoryPlaygroundStore) UpdateRunStatus(_ context.Context, id string, status RunStatus, endedAt time.Time, errMsg string, metrics map[string]float64) error {
+	for i := range m.runs {
+		if m.runs[i].ID == id {
+			m.runs[i].Status = status
+			m.runs[i].EndedAt = endedAt
+			m.runs[i].Error = errMsg
+			m.runs[i].Metrics = metrics
+		}
+	}
+	return nil
+}
+
+func (m *memoryPlaygroundStore) AppendResults(_ context.Context, runID string, results []RunResult) error {
+	for _, res := range results {
+		i := slices.IndexFunc(m.results[runID], func(r RunResult) bool { return r.ID == res.ID })
+		if i < 0 {
+			m.results[runID] = append(m.results[runID], res)
+		} else {
+			m.results[runID][i] = res
+		}
+	}
+	return nil
+}
+
+func (m *memoryPlaygroundStore) CompleteShard(ctx context.Context, runID, shardID string, results []RunResult) error {
+	for i := range m.runs {
+		if m.runs[i].ID == runID {
+			m.runs[i].CompletedShards = append(m.runs[i].CompletedShards, shardID)
+		}
+	}
+	return m.AppendResults(ctx, runID, results)
+}
+
+func (m *memoryPlaygroundStore) ListRuns(_ context.Context, experimentID string) ([]Run, error) {
+	var out []Run
+	for _, r := range m.runs {
+		if r.ExperimentID == experimentID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryPlaygroundStore) ListRunResults(_ context.Context, runID string) ([]RunResult, error) {
+	return m.results[runID], nil
+}
+
+func (m *memoryPlaygroundStore) DeleteExperiment(context.Context, string) error { return nil }
+
+// echoExecutor returns the rendered template so the evaluator can score it.
+type echoExecutor struct{}
+
+func (echoExecutor) ExecuteTask(_ context.Context, task worker.Task) (worker.Result, error) {
+	return worker.Result{
+		ID:              task.RunID + "/" + task.Row.ID + "/" + task.Variant.ID,
+		RunID:           task.RunID,
+		ShardID:         task.ShardID,
+		RowID:           task.Row.ID,
+		VariantID:       task.Variant.ID,
+		PromptVersionID: task.Variant.PromptVersionID,
+		Output:          task.PromptTemplate,
+	}, nil
+}
+
+// politeEvaluator scores the share of outputs that say "please".
+type politeEvaluator struct{}
+
+func (politeEvaluator) Name() string { return "polite" }
+
+func (politeEvaluator) Evaluate(_ context.Context, _ experiment.ExperimentSpec, results []worker.Result) (eval.Outcome, error) {
+	var hits float64
+	for _, r := range results {
+		if strings.Contains(r.Output, "please") {
+			hits++
+		}
+	}
+	return eval.Outcome{Aggregate: map[string]float64{"polite": hits / float64(len(results))}}, nil
+}
+
+func newGateTestService(t *testing.T) (*Service, *memoryPlaygroundStore) {
+	t.Helper()
+	store := newMemoryPlaygroundStore()
+	store.rows = []dataset.Row{{ID: "r1"}, {ID: "r2"}}
+	evals := eval.NewRegistry()
+	evals.Register("polite", func(experiment.EvaluatorConfig, provider.Provider) (eval.Evaluator, error) {
+		return politeEvaluator{}, nil
+	})
+	svc := NewService(Config{}, registry.New(store), dataset.NewService(store), experiment.NewRepository(),
+		experiment.NewPlanner(experiment.PlannerConfig{MaxRowsPerShard: 8, MaxVariantsPerShard: 4}),
+		echoExecutor{}, eval.NewRunner(evals, nil), store)
+
+	ctx := context.Background()
+	if _, err := svc.CreatePrompt(ctx, registry.Prompt{ID: "p1", Name: "greeting"}); err != nil {
+		t.Fatalf("CreatePrompt: %v", err)
+	}
+	for id, tmpl := range map[string]string{"v1": "hello", "v2": "hello, please"} {
+		if _, err := svc.CreatePromptVersion(ctx, "p1", registry.PromptVersion{
+			ID:       id,
+			Template: tmpl,
+			EvalGate: &registry.EvalGate{ExperimentID: "exp", MinScore: 0.9},
+		}); err != nil {
+			t.Fatalf("CreatePromptVersion %s: %v", id, err)
+		}
+	}
+	if _, err := svc.CreateExperiment(ctx, experiment.ExperimentSpec{
+		ID:         "exp",
+		DatasetID:  "ds",
+		Variants:   []experiment.Variant{{ID: "baseline", PromptVersionID: "v1", Model: "m"}},
+		Evaluators: []experiment.EvaluatorConfig{{Name: "polite"}},
+	}); err != nil {
+		t.Fatalf("CreateExperiment: %v", err)
+	}
+	return svc, store
+}
+
+func TestDeployPromptVersionRunsGateAgainstCandidate(t *testing.T) {
+	svc, store := newGateTestService(t)
+	ctx := context.Background()
+
+	_, gate, err := svc.DeployPromptVersion(ctx, "p1", "v1")
+	if !errors.Is(err, ErrGateFailed) {
+		t.Fatalf("expected gate failure for v1, got %v", err)
+	}
+	if gate == nil || gate.Passed || gate.Score != 0 || !gate.Ran {
+		t.Fatalf("unexpected gate result: %+v", gate)
+	}
+	if store.prompts["p1"].ActiveVersionID != "" {
+		t.Fatalf("failed gate must not activate the version")
+	}
+
+	prompt, gate, err := svc.DeployPromptVersion(ctx, "p1", "v2")
+	if err != nil {
+		t.Fatalf("deploy v2: %v", err)
+	}
+	if gate == nil || !gate.Passed || gate.Metric != "polite" || gate.Score != 1 {
+		t.Fatalf("unexpected gate result: %+v", gate)
+	}
+	if prompt.ActiveVersionID != "v2" || store.prompts["p1"].ActiveVersionID != "v2" {
+		t.Fatalf("expected v2 to be active, got %+v", prompt)
+	}
+	if spec := store.experiments["exp"]; spec.Variants[0].PromptVersionID != "v1" {
+		t.Fatalf("gate run must not modify the stored experiment")
+	}
+}
+
+func TestEvaluateGateVerifyUsesExistingRun(t *testing.T) {
+	svc, store := newGateTestService(t)
+	ctx := context.Background()
+	gate := registry.EvalGate{ExperimentID: "exp", MinScore: 0.5, Mode: registry.GateModeVerify}
+
+	if _, err := svc.EvaluateGate(ctx, gate, "v2"); !errors.Is(err, ErrGateNoRun) {
+		t.Fatalf("expected ErrGateNoRun before any run, got %v", err)
+	}
+	if _, err := svc.EvaluateGate(ctx, registry.EvalGate{ExperimentID: "exp", MinScore: 0.5}, "v2"); err != nil {
+		t.Fatalf("run gate: %v", err)
+	}
+	runs := len(store.runs)
+
+	result, err := svc.EvaluateGate(ctx, gate, "v2")
+	if err != nil {
+		t.Fatalf("verify gate: %v", err)
+	}
+	if result.Ran || !result.Passed || len(store.runs) != runs {
+		t.Fatalf("verify should reuse the existing run: %+v (runs %d -> %d)", result, runs, len(store.runs))
+	}
+	if _, err := svc.EvaluateGate(ctx, gate, "v1"); !errors.Is(err, ErrGateNoRun) {
+		t.Fatalf("expected no run for v1, got %v", err)
+	}
+}
+
+func TestEvalGateValidate(t *testing.T) {
+	if err := (&registry.EvalGate{}).Validate(); err == nil {
+		t.Fatalf("expected missing experiment error")
+	}
+	if err := (&registry.EvalGate{ExperimentID: "exp", Mode: "later"}).Validate(); err == nil {
+		t.Fatalf("expected invalid mode error")
+	}
+	g := registry.EvalGate{ExperimentID: " exp ", Mode: "VERIFY"}
+	if err := g.Validate(); err != nil || g.Mode != registry.GateModeVerify || g.ExperimentID != "exp" {
+		t.Fatalf("unexpected normalisation: %+v err=%v", g, err)
+	}
+}
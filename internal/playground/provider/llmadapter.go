@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"manifold/internal/llm"
 )
@@ -29,14 +30,24 @@ func (a *LLMAdapter) Complete(ctx context.Context, req Request) (Response, error
 	if model == "" {
 		model = a.model
 	}
+	ctx, usage := llm.WithUsage(ctx)
+	start := time.Now()
 	msg, err := a.provider.Chat(ctx, msgs, nil, model)
 	if err != nil {
 		return Response{}, err
 	}
+	latency := time.Since(start)
+	prompt, completion := usage.Tokens()
+	if prompt == 0 && completion == 0 {
+		// The provider reported no usage; estimate from text length.
+		prompt, completion = len(req.Prompt)/4, len(msg.Content)/4
+	}
 	return Response{
-		Output:       msg.Content,
-		Tokens:       len(msg.Content) / 4,
-		Latency:      0,
-		ProviderName: a.Name(),
+		Output:           msg.Content,
+		Tokens:           prompt + completion,
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		Latency:          latency,
+		ProviderName:     a.Name(),
 	}, nil
 }
@@ -16,12 +16,14 @@ type Request struct {
 
 // Response wraps the LLM output and metrics returned by the provider.
 type Response struct {
-	Output       string
-	Tokens       int
-	Latency      time.Duration
-	Cost         float64
-	Raw          map[string]any
-	ProviderName string
+	Output           string
+	Tokens           int
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+	Cost             float64
+	Raw              map[string]any
+	ProviderName     string
 }
 
 // Provider abstracts prompt execution against an LLM.
@@ -29,3 +31,21 @@ type Provider interface {
 	Name() string
 	Complete(ctx context.Context, req Request) (Response, error)
 }
+
+// Price is a model's cost in currency units per million tokens.
+type Price struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// PriceTable maps model names to prices.
+type PriceTable map[string]Price
+
+// Cost estimates the cost of a call to model. Unknown models cost 0.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	p, ok := t[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1e6
+}
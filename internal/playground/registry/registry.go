@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"manifold/internal/auth"
@@ -18,6 +19,9 @@ type Prompt struct {
 	Metadata    map[string]string `json:"metadata"`
 	OwnerID     int64             `json:"ownerId"`
 	CreatedAt   time.Time         `json:"createdAt"`
+	// ActiveVersionID is the version currently deployed for this prompt.
+	ActiveVersionID string    `json:"activeVersionId,omitempty"`
+	DeployedAt      time.Time `json:"deployedAt,omitempty"`
 }
 
 // VariableSchema describes one templated variable for a prompt.
@@ -46,6 +50,47 @@ type PromptVersion struct {
 	CreatedBy   string                    `json:"createdBy"`
 	OwnerID     int64                     `json:"ownerId"`
 	CreatedAt   time.Time                 `json:"createdAt"`
+	// EvalGate, when set, must pass before the version can be deployed.
+	EvalGate *EvalGate `json:"evalGate,omitempty"`
+}
+
+// Eval gate modes.
+const (
+	// GateModeRun executes the gate experiment against the candidate.
+	GateModeRun = "run"
+	// GateModeVerify accepts the latest completed run of the gate experiment
+	// that exercised the candidate, without starting a new one.
+	GateModeVerify = "verify"
+)
+
+// EvalGate declares the playground experiment a deployment must pass and the
+// minimum aggregate score required on Metric.
+type EvalGate struct {
+	ExperimentID string `json:"experimentId"`
+	// Metric names the run metric to compare. It may be omitted when the
+	// experiment produces a single metric.
+	Metric   string  `json:"metric,omitempty"`
+	MinScore float64 `json:"minScore"`
+	// Mode is GateModeRun (default) or GateModeVerify.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Validate checks the gate is well-formed and normalises Mode.
+func (g *EvalGate) Validate() error {
+	g.ExperimentID = strings.TrimSpace(g.ExperimentID)
+	if g.ExperimentID == "" {
+		return fmt.Errorf("evalGate.experimentId must be provided")
+	}
+	g.Metric = strings.TrimSpace(g.Metric)
+	switch strings.ToLower(strings.TrimSpace(g.Mode)) {
+	case "", GateModeRun:
+		g.Mode = GateModeRun
+	case GateModeVerify:
+		g.Mode = GateModeVerify
+	default:
+		return fmt.Errorf("evalGate.mode must be %q or %q", GateModeRun, GateModeVerify)
+	}
+	return nil
 }
 
 // Manifest records a signed statement of prompt version integrity.
@@ -60,6 +105,7 @@ type Manifest struct {
 // Store defines the persistence contract the registry relies on.
 type Store interface {
 	CreatePrompt(ctx context.Context, prompt Prompt) (Prompt, error)
+	UpdatePrompt(ctx context.Context, prompt Prompt) (Prompt, error)
 	GetPrompt(ctx context.Context, id string) (Prompt, bool, error)
 	ListPrompts(ctx context.Context, filter ListFilter) ([]Prompt, error)
 	CreatePromptVersion(ctx context.Context, version PromptVersion) (PromptVersion, error)
@@ -109,6 +155,8 @@ var (
 	ErrPromptExists = errors.New("playground/registry: prompt already exists")
 	// ErrPromptNotFound is returned when a prompt cannot be located.
 	ErrPromptNotFound = errors.New("playground/registry: prompt not found")
+	// ErrPromptVersionNotFound is returned when a prompt version cannot be located.
+	ErrPromptVersionNotFound = errors.New("playground/registry: prompt version not found")
 )
 
 // CreatePrompt persists a new prompt definition.
@@ -128,6 +176,11 @@ func (r *Registry) CreatePromptVersion(ctx context.Context, promptID string, ver
 	if version.Template == "" {
 		return PromptVersion{}, fmt.Errorf("template must be provided")
 	}
+	if version.EvalGate != nil {
+		if err := version.EvalGate.Validate(); err != nil {
+			return PromptVersion{}, err
+		}
+	}
 	version.PromptID = promptID
 	if u, ok := auth.CurrentUser(ctx); ok && u != nil {
 		version.OwnerID = u.ID
@@ -141,6 +194,28 @@ func (r *Registry) CreatePromptVersion(ctx context.Context, promptID string, ver
 	return r.store.CreatePromptVersion(ctx, version)
 }
 
+// SetActiveVersion marks versionID as the deployed version of promptID.
+// Callers are responsible for enforcing any eval gate beforehand.
+func (r *Registry) SetActiveVersion(ctx context.Context, promptID, versionID string) (Prompt, error) {
+	prompt, ok, err := r.store.GetPrompt(ctx, promptID)
+	if err != nil {
+		return Prompt{}, err
+	}
+	if !ok {
+		return Prompt{}, ErrPromptNotFound
+	}
+	version, ok, err := r.store.GetPromptVersion(ctx, versionID)
+	if err != nil {
+		return Prompt{}, err
+	}
+	if !ok || version.PromptID != promptID {
+		return Prompt{}, ErrPromptVersionNotFound
+	}
+	prompt.ActiveVersionID = versionID
+	prompt.DeployedAt = r.clock.Now()
+	return r.store.UpdatePrompt(ctx, prompt)
+}
+
 // ListPrompts returns prompts applying an optional filter.
 func (r *Registry) ListPrompts(ctx context.Context, filter ListFilter) ([]Prompt, error) {
 	return r.store.ListPrompts(ctx, filter)
@@ -0,0 +1,161 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"manifold/internal/playground/eval"
+)
+
+// ErrNoCompletedRun is returned when an experiment has no completed run to
+// report on.
+var ErrNoCompletedRun = errors.New("playground: experiment has no completed run")
+
+// VariantReport summarises one variant's results in a run.
+type VariantReport struct {
+	VariantID string `json:"variantId"`
+	Results   int    `json:"results"`
+	// Scores is the mean of each per-result metric.
+	Scores map[string]float64 `json:"scores"`
+	Usage  UsageSummary       `json:"usage"`
+}
+
+// UsageSummary totals the tokens and cost of a set of provider calls and
+// gives their latency distribution.
+type UsageSummary struct {
+	Calls            int           `json:"calls"`
+	PromptTokens     int           `json:"promptTokens"`
+	CompletionTokens int           `json:"completionTokens"`
+	Cost             float64       `json:"cost"`
+	LatencyMean      time.Duration `json:"latencyMean"`
+	LatencyP50       time.Duration `json:"latencyP50"`
+	LatencyP95       time.Duration `json:"latencyP95"`
+	LatencyP99       time.Duration `json:"latencyP99"`
+}
+
+// summariseUsage builds a UsageSummary from results. Percentiles use the
+// nearest-rank method.
+func summariseUsage(results []RunResult) UsageSummary {
+	u := UsageSummary{Calls: len(results)}
+	if len(results) == 0 {
+		return u
+	}
+	latencies := make([]time.Duration, 0, len(results))
+	var total time.Duration
+	for _, r := range results {
+		u.PromptTokens += r.PromptTokens
+		u.CompletionTokens += r.CompletionTokens
+		u.Cost += r.Cost
+		total += r.Latency
+		latencies = append(latencies, r.Latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(latencies)))) - 1
+		return latencies[max(i, 0)]
+	}
+	u.LatencyMean = total / time.Duration(len(latencies))
+	u.LatencyP50, u.LatencyP95, u.LatencyP99 = rank(0.50), rank(0.95), rank(0.99)
+	return u
+}
+
+// ExperimentReport summarises a completed run of an experiment.
+type ExperimentReport struct {
+	ExperimentID string               `json:"experimentId"`
+	RunID        string               `json:"runId"`
+	Metrics      map[string]float64   `json:"metrics,omitempty"`
+	Variants     []VariantReport      `json:"variants"`
+	Pairwise     []eval.PairwiseStats `json:"pairwise,omitempty"`
+	// Usage covers every result in the run.
+	Usage UsageSummary `json:"usage"`
+}
+
+// ExperimentReport builds the report for runID, or for the experiment's most
+// recent completed run when runID is empty.
+func (s *Service) ExperimentReport(ctx context.Context, experimentID, runID string) (ExperimentReport, error) {
+	spec, ok, err := s.GetExperiment(ctx, experimentID)
+	if err != nil {
+		return ExperimentReport{}, err
+	}
+	if !ok {
+		return ExperimentReport{}, ErrUnknownExperiment
+	}
+	var run Run
+	if runID == "" {
+		if run, ok, err = s.latestGateRun(ctx, experimentID, ""); err != nil {
+			return ExperimentReport{}, err
+		}
+	} else {
+		runs, err := s.store.ListRuns(ctx, experimentID)
+		if err != nil {
+			return ExperimentReport{}, err
+		}
+		ok = false
+		for _, r := range runs {
+			if r.ID == runID && r.Status == RunStatusCompleted {
+				run, ok = r, true
+			}
+		}
+	}
+	if !ok {
+		return ExperimentReport{}, ErrNoCompletedRun
+	}
+	results, err := s.store.ListRunResults(ctx, run.ID)
+	if err != nil {
+		return ExperimentReport{}, err
+	}
+
+	report := ExperimentReport{
+		ExperimentID: experimentID,
+		RunID:        run.ID,
+		Metrics:      run.Metrics,
+		Variants:     []VariantReport{},
+		Usage:        summariseUsage(results),
+	}
+	type tally struct {
+		results []RunResult
+		sums    map[string]float64
+		counts  map[string]int
+		pairs   []float64
+	}
+	tallies := map[string]*tally{}
+	var order []string
+	for _, res := range results {
+		t := tallies[res.VariantID]
+		if t == nil {
+			t = &tally{sums: map[string]float64{}, counts: map[string]int{}}
+			tallies[res.VariantID] = t
+			order = append(order, res.VariantID)
+		}
+		t.results = append(t.results, res)
+		for metric, v := range res.Scores {
+			t.sums[metric] += v
+			t.counts[metric]++
+			if metric == eval.PairwiseMetric {
+				t.pairs = append(t.pairs, v)
+			}
+		}
+	}
+	sort.Strings(order)
+	baseline := eval.PairwiseBaseline(spec)
+	for _, id := range order {
+		t := tallies[id]
+		vr := VariantReport{
+			VariantID: id,
+			Results:   len(t.results),
+			Scores:    make(map[string]float64, len(t.sums)),
+			Usage:     summariseUsage(t.results),
+		}
+		for metric, sum := range t.sums {
+			vr.Scores[metric] = sum / float64(t.counts[metric])
+		}
+		report.Variants = append(report.Variants, vr)
+		if len(t.pairs) > 0 {
+			report.Pairwise = append(report.Pairwise, eval.NewPairwiseStats(baseline, id, t.pairs))
+		}
+	}
+	return report, nil
+}
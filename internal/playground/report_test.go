@@ -0,0 +1,74 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"manifold/internal/playground/experiment"
+)
+
+func TestExperimentReportPairwise(t *testing.T) {
+	svc, _ := newGateTestService(t)
+	ctx := context.Background()
+	if _, err := svc.CreateExperiment(ctx, experiment.ExperimentSpec{
+		ID:        "ab",
+		DatasetID: "ds",
+		Variants: []experiment.Variant{
+			{ID: "baseline", PromptVersionID: "v1", Model: "m"},
+			{ID: "candidate", PromptVersionID: "v2", Model: "m"},
+		},
+		Evaluators: []experiment.EvaluatorConfig{{Name: "polite"}, {Name: "pairwise-judge"}},
+	}); err != nil {
+		t.Fatalf("CreateExperiment: %v", err)
+	}
+	if _, err := svc.ExperimentReport(ctx, "ab", ""); !errors.Is(err, ErrNoCompletedRun) {
+		t.Fatalf("expected ErrNoCompletedRun before any run, got %v", err)
+	}
+	run, err := svc.StartRun(ctx, "ab")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if _, ok := run.Metrics["pairwise/candidate/win_rate"]; !ok {
+		t.Fatalf("run metrics missing pairwise win rate: %v", run.Metrics)
+	}
+
+	report, err := svc.ExperimentReport(ctx, "ab", "")
+	if err != nil {
+		t.Fatalf("ExperimentReport: %v", err)
+	}
+	if report.RunID != run.ID || len(report.Variants) != 2 || report.Variants[0].VariantID != "baseline" || report.Variants[0].Results != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	// Without a judge provider or expected values every comparison is a tie.
+	if len(report.Pairwise) != 1 {
+		t.Fatalf("expected one pairwise comparison, got %+v", report.Pairwise)
+	}
+	if pw := report.Pairwise[0]; pw.Baseline != "baseline" || pw.Candidate != "candidate" || pw.Ties != 2 || pw.WinRate != 0.5 {
+		t.Fatalf("unexpected pairwise stats %+v", pw)
+	}
+	if _, err := svc.ExperimentReport(ctx, "ab", "missing"); !errors.Is(err, ErrNoCompletedRun) {
+		t.Fatalf("expected ErrNoCompletedRun for unknown run, got %v", err)
+	}
+}
+
+func TestSummariseUsage(t *testing.T) {
+	var results []RunResult
+	for i := 1; i <= 20; i++ {
+		results = append(results, RunResult{PromptTokens: 10, CompletionTokens: 5, Cost: 0.5, Latency: time.Duration(i) * time.Millisecond})
+	}
+	u := summariseUsage(results)
+	if u.Calls != 20 || u.PromptTokens != 200 || u.CompletionTokens != 100 || u.Cost != 10 {
+		t.Fatalf("unexpected totals %+v", u)
+	}
+	if u.LatencyP50 != 10*time.Millisecond || u.LatencyP95 != 19*time.Millisecond || u.LatencyP99 != 20*time.Millisecond {
+		t.Fatalf("unexpected percentiles %+v", u)
+	}
+	if u.LatencyMean != 10500*time.Microsecond {
+		t.Fatalf("unexpected mean %v", u.LatencyMean)
+	}
+	if empty := summariseUsage(nil); empty.Calls != 0 || empty.LatencyP99 != 0 {
+		t.Fatalf("unexpected empty summary %+v", empty)
+	}
+}
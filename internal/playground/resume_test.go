@@ -0,0 +1,64 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"manifold/internal/playground/worker"
+)
+
+// flakyExecutor fails the first task for failRow, then behaves like echoExecutor.
+type flakyExecutor struct {
+	failRow string
+	failed  bool
+	calls   int
+}
+
+func (f *flakyExecutor) ExecuteTask(ctx context.Context, task worker.Task) (worker.Result, error) {
+	f.calls++
+	if task.Row.ID == f.failRow && !f.failed {
+		f.failed = true
+		return worker.Result{}, errors.New("provider unavailable")
+	}
+	return echoExecutor{}.ExecuteTask(ctx, task)
+}
+
+func TestResumeRunSkipsCompletedShards(t *testing.T) {
+	svc, store := newGateTestService(t)
+	exec := &flakyExecutor{failRow: "r2"}
+	svc.workers = exec
+	ctx := context.Background()
+	spec := store.experiments["exp"]
+	spec.Concurrency.MaxRowsPerShard = 1
+	store.experiments["exp"] = spec
+
+	if _, err := svc.ResumeRun(ctx, "exp"); !errors.Is(err, ErrNoResumableRun) {
+		t.Fatalf("expected ErrNoResumableRun before any run, got %v", err)
+	}
+	failed, err := svc.StartRun(ctx, "exp")
+	if err == nil || failed.Status != RunStatusFailed {
+		t.Fatalf("expected the first run to fail, got %+v err=%v", failed, err)
+	}
+	if got := store.runs[0].CompletedShards; len(got) != 1 || got[0] != "shard-1" {
+		t.Fatalf("expected shard-1 checkpointed, got %v", got)
+	}
+
+	exec.calls = 0
+	run, err := svc.ResumeRun(ctx, "exp")
+	if err != nil {
+		t.Fatalf("ResumeRun: %v", err)
+	}
+	if run.ID != failed.ID || run.Status != RunStatusCompleted || exec.calls != 1 {
+		t.Fatalf("expected the same run to finish with one new task, got %+v (calls %d)", run, exec.calls)
+	}
+	if results := store.results[run.ID]; len(results) != 2 {
+		t.Fatalf("expected one result per row, got %+v", results)
+	}
+	if _, ok := run.Metrics["polite"]; !ok {
+		t.Fatalf("expected metrics over all shards, got %v", run.Metrics)
+	}
+	if _, err := svc.ResumeRun(ctx, "exp"); !errors.Is(err, ErrNoResumableRun) {
+		t.Fatalf("expected nothing to resume after completion, got %v", err)
+	}
+}
@@ -28,22 +28,29 @@ type Run struct {
 	EndedAt      time.Time          `json:"endedAt,omitempty"`
 	Error        string             `json:"error,omitempty"`
 	Metrics      map[string]float64 `json:"metrics,omitempty"`
+	// CompletedShards lists plan shards whose results are already stored, so
+	// an interrupted run can resume after them.
+	CompletedShards []string `json:"completedShards,omitempty"`
 }
 
 // RunResult stores the per-row evaluation outcome of a run.
 type RunResult struct {
-	ID              string             `json:"id"`
-	RunID           string             `json:"runId"`
-	RowID           string             `json:"rowId"`
-	VariantID       string             `json:"variantId"`
-	PromptVersionID string             `json:"promptVersionId,omitempty"`
-	Model           string             `json:"model,omitempty"`
-	Rendered        string             `json:"rendered,omitempty"`
-	Output          string             `json:"output,omitempty"`
-	ProviderName    string             `json:"providerName,omitempty"`
-	Tokens          int                `json:"tokens,omitempty"`
-	Latency         time.Duration      `json:"latency,omitempty"`
-	Artifacts       map[string]string  `json:"artifacts,omitempty"`
-	Scores          map[string]float64 `json:"scores,omitempty"`
-	Expected        any                `json:"expected,omitempty"`
+	ID               string             `json:"id"`
+	RunID            string             `json:"runId"`
+	RowID            string             `json:"rowId"`
+	VariantID        string             `json:"variantId"`
+	ShardID          string             `json:"shardId,omitempty"`
+	PromptVersionID  string             `json:"promptVersionId,omitempty"`
+	Model            string             `json:"model,omitempty"`
+	Rendered         string             `json:"rendered,omitempty"`
+	Output           string             `json:"output,omitempty"`
+	ProviderName     string             `json:"providerName,omitempty"`
+	Tokens           int                `json:"tokens,omitempty"`
+	PromptTokens     int                `json:"promptTokens,omitempty"`
+	CompletionTokens int                `json:"completionTokens,omitempty"`
+	Cost             float64            `json:"cost,omitempty"`
+	Latency          time.Duration      `json:"latency,omitempty"`
+	Artifacts        map[string]string  `json:"artifacts,omitempty"`
+	Scores           map[string]float64 `json:"scores,omitempty"`
+	Expected         any                `json:"expected,omitempty"`
 }
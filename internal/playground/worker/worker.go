@@ -27,21 +27,24 @@ type Task struct {
 
 // Result contains the output from executing a task.
 type Result struct {
-	ID              string
-	RunID           string
-	ShardID         string
-	RowID           string
-	VariantID       string
-	PromptVersionID string
-	Model           string
-	RenderedPrompt  string
-	Output          string
-	Tokens          int
-	Latency         time.Duration
-	ProviderName    string
-	Artifacts       map[string]string
-	Expected        any
-	Scores          map[string]float64
+	ID               string
+	RunID            string
+	ShardID          string
+	RowID            string
+	VariantID        string
+	PromptVersionID  string
+	Model            string
+	RenderedPrompt   string
+	Output           string
+	Tokens           int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Latency          time.Duration
+	ProviderName     string
+	Artifacts        map[string]string
+	Expected         any
+	Scores           map[string]float64
 }
 
 // Executor defines the worker behaviour required by the service.
@@ -53,6 +56,7 @@ type Executor interface {
 type Worker struct {
 	provider  provider.Provider
 	artifacts artifacts.Store
+	prices    provider.PriceTable
 }
 
 // NewWorker constructs a worker.
@@ -60,6 +64,12 @@ func NewWorker(provider provider.Provider, artifacts artifacts.Store) *Worker {
 	return &Worker{provider: provider, artifacts: artifacts}
 }
 
+// SetPrices sets the table used to estimate the cost of calls whose
+// provider does not report one.
+func (w *Worker) SetPrices(prices provider.PriceTable) {
+	w.prices = prices
+}
+
 // ExecuteTask renders the prompt, invokes the provider, and persists artifacts.
 func (w *Worker) ExecuteTask(ctx context.Context, task Task) (Result, error) {
 	rendered, err := renderTemplate(task.PromptTemplate, task.Row.Inputs)
@@ -77,6 +87,11 @@ func (w *Worker) ExecuteTask(ctx context.Context, task Task) (Result, error) {
 		return Result{}, fmt.Errorf("provider execute: %w", err)
 	}
 
+	cost := resp.Cost
+	if cost <= 0 {
+		cost = w.prices.Cost(task.Variant.Model, resp.PromptTokens, resp.CompletionTokens)
+	}
+
 	ares := make(map[string]string)
 	if w.artifacts != nil {
 		artifactName := fmt.Sprintf("%s-%s.txt", task.ShardID, task.Row.ID)
@@ -91,20 +106,23 @@ func (w *Worker) ExecuteTask(ctx context.Context, task Task) (Result, error) {
 	}
 
 	return Result{
-		ID:              uuid.NewString(),
-		RunID:           task.RunID,
-		ShardID:         task.ShardID,
-		RowID:           task.Row.ID,
-		VariantID:       task.Variant.ID,
-		PromptVersionID: task.Variant.PromptVersionID,
-		Model:           task.Variant.Model,
-		RenderedPrompt:  rendered,
-		Output:          resp.Output,
-		Tokens:          resp.Tokens,
-		Latency:         resp.Latency,
-		ProviderName:    resp.ProviderName,
-		Artifacts:       ares,
-		Expected:        task.Row.Expected,
+		ID:               uuid.NewString(),
+		RunID:            task.RunID,
+		ShardID:          task.ShardID,
+		RowID:            task.Row.ID,
+		VariantID:        task.Variant.ID,
+		PromptVersionID:  task.Variant.PromptVersionID,
+		Model:            task.Variant.Model,
+		RenderedPrompt:   rendered,
+		Output:           resp.Output,
+		Tokens:           resp.Tokens,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		Cost:             cost,
+		Latency:          resp.Latency,
+		ProviderName:     resp.ProviderName,
+		Artifacts:        ares,
+		Expected:         task.Row.Expected,
 	}, nil
 }
 
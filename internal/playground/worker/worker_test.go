@@ -1,9 +1,15 @@
 package worker
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"manifold/internal/playground/dataset"
+	"manifold/internal/playground/experiment"
+	"manifold/internal/playground/provider"
 )
 
 func TestRenderTemplateReplacesPlaceholders(t *testing.T) {
@@ -21,3 +27,28 @@ func TestRenderTemplateDetectsMissingPlaceholder(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, rendered)
 }
+
+type usageProvider struct{}
+
+func (usageProvider) Name() string { return "usage" }
+
+func (usageProvider) Complete(_ context.Context, req provider.Request) (provider.Response, error) {
+	return provider.Response{Output: "ok", Tokens: 3000, PromptTokens: 2000, CompletionTokens: 1000, Latency: time.Second}, nil
+}
+
+func TestExecuteTaskEstimatesCostFromPrices(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorker(usageProvider{}, nil)
+	w.SetPrices(provider.PriceTable{"m": {InputPerMillion: 1, OutputPerMillion: 4}})
+	res, err := w.ExecuteTask(context.Background(), Task{
+		Variant:        experiment.Variant{ID: "v", Model: "m"},
+		Row:            dataset.Row{ID: "r"},
+		PromptTemplate: "hi",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2000, res.PromptTokens)
+	require.Equal(t, 1000, res.CompletionTokens)
+	require.InDelta(t, 0.006, res.Cost, 1e-12)
+	require.Equal(t, time.Second, res.Latency)
+}
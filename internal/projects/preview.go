@@ -0,0 +1,432 @@
+package projects
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoder for thumbnails
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+)
+
+// Preview kinds.
+const (
+	PreviewKindCSV      = "csv"
+	PreviewKindMarkdown = "markdown"
+	PreviewKindImage    = "image"
+	PreviewKindAudio    = "audio"
+	PreviewKindText     = "text"
+	PreviewKindBinary   = "binary"
+)
+
+const (
+	defaultPreviewRows      = 20
+	maxPreviewRows          = 500
+	defaultThumbnailSize    = 256
+	maxThumbnailSize        = 1024
+	previewTextBytes        = 4 << 10
+	previewMarkdownBytes    = 256 << 10
+	previewImageBytes       = 20 << 20
+	previewImagePixels      = 50_000_000
+	defaultPreviewCacheSize = 256
+)
+
+// PreviewOptions tunes preview generation. Zero values use defaults.
+type PreviewOptions struct {
+	// Rows is the number of CSV data rows returned.
+	Rows int
+	// ThumbnailSize bounds the longer edge of image thumbnails in pixels.
+	ThumbnailSize int
+}
+
+func (o PreviewOptions) normalized() PreviewOptions {
+	if o.Rows <= 0 {
+		o.Rows = defaultPreviewRows
+	}
+	if o.Rows > maxPreviewRows {
+		o.Rows = maxPreviewRows
+	}
+	if o.ThumbnailSize <= 0 {
+		o.ThumbnailSize = defaultThumbnailSize
+	}
+	if o.ThumbnailSize > maxThumbnailSize {
+		o.ThumbnailSize = maxThumbnailSize
+	}
+	return o
+}
+
+// Preview is a typed summary of a project file. Only the fields relevant to
+// Kind are populated.
+type Preview struct {
+	Path        string    `json:"path"`
+	Kind        string    `json:"kind"`
+	ContentType string    `json:"contentType"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	ModTime     time.Time `json:"modTime,omitempty"`
+	// Truncated reports that the preview covers only part of the file.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// CSV
+	Columns []string   `json:"columns,omitempty"`
+	Rows    [][]string `json:"rows,omitempty"`
+
+	// Markdown renders to sanitized HTML (raw HTML in the source is dropped).
+	HTML string `json:"html,omitempty"`
+	// Text holds the leading text of markdown and plain text files.
+	Text string `json:"text,omitempty"`
+
+	// Image
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"` // data:image/png;base64,...
+
+	// Audio
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	SampleRate      int     `json:"sampleRate,omitempty"`
+	Channels        int     `json:"channels,omitempty"`
+}
+
+// Previewer builds previews of project files and caches them by path, size
+// and modification time, so repeat requests for an unchanged file are free.
+type Previewer struct {
+	svc ProjectService
+
+	mu    sync.Mutex
+	max   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type previewEntry struct {
+	key     string
+	preview Preview
+}
+
+// NewPreviewer returns a Previewer over svc caching up to cacheSize previews.
+func NewPreviewer(svc ProjectService, cacheSize int) *Previewer {
+	if cacheSize <= 0 {
+		cacheSize = defaultPreviewCacheSize
+	}
+	return &Previewer{svc: svc, max: cacheSize, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Preview returns the preview of path within the project.
+func (p *Previewer) Preview(ctx context.Context, userID int64, projectID, path string, opts PreviewOptions) (Preview, error) {
+	opts = opts.normalized()
+	rc, err := p.svc.ReadFile(ctx, userID, projectID, path)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer rc.Close()
+
+	out := Preview{Path: filepath.ToSlash(path)}
+	key := ""
+	if st, ok := rc.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if info, err := st.Stat(); err == nil {
+			if info.IsDir() {
+				return Preview{}, fmt.Errorf("%s is a directory", path)
+			}
+			out.SizeBytes = info.Size()
+			out.ModTime = info.ModTime().UTC()
+			key = fmt.Sprintf("%d|%s|%s|%d|%d|%d|%d", userID, projectID, out.Path, out.SizeBytes, out.ModTime.UnixNano(), opts.Rows, opts.ThumbnailSize)
+			if cached, ok := p.get(key); ok {
+				return cached, nil
+			}
+		}
+	}
+
+	if err := buildPreview(&out, rc, opts); err != nil {
+		return Preview{}, err
+	}
+	if key != "" {
+		p.put(key, out)
+	}
+	return out, nil
+}
+
+func (p *Previewer) get(key string) (Preview, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	el, ok := p.items[key]
+	if !ok {
+		return Preview{}, false
+	}
+	p.ll.MoveToFront(el)
+	return el.Value.(*previewEntry).preview, true
+}
+
+func (p *Previewer) put(key string, preview Preview) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if el, ok := p.items[key]; ok {
+		el.Value.(*previewEntry).preview = preview
+		p.ll.MoveToFront(el)
+		return
+	}
+	p.items[key] = p.ll.PushFront(&previewEntry{key: key, preview: preview})
+	for p.ll.Len() > p.max {
+		oldest := p.ll.Back()
+		p.ll.Remove(oldest)
+		delete(p.items, oldest.Value.(*previewEntry).key)
+	}
+}
+
+// buildPreview classifies the file by extension, falling back to content
+// sniffing, and fills in the kind-specific fields.
+func buildPreview(out *Preview, r io.Reader, opts PreviewOptions) error {
+	var sniff [512]byte
+	n, err := io.ReadFull(r, sniff[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head := sniff[:n]
+	r = io.MultiReader(bytes.NewReader(head), r)
+
+	ext := strings.ToLower(filepath.Ext(out.Path))
+	out.ContentType = mime.TypeByExtension(ext)
+	if out.ContentType == "" {
+		out.ContentType = http.DetectContentType(head)
+	}
+	mediaType, _, _ := mime.ParseMediaType(out.ContentType)
+
+	switch {
+	case ext == ".csv" || ext == ".tsv" || mediaType == "text/csv":
+		out.Kind = PreviewKindCSV
+		return previewCSV(out, r, ext == ".tsv", opts.Rows)
+	case ext == ".md" || ext == ".markdown" || mediaType == "text/markdown":
+		out.Kind = PreviewKindMarkdown
+		return previewMarkdown(out, r)
+	case strings.HasPrefix(mediaType, "image/"):
+		out.Kind = PreviewKindImage
+		return previewImage(out, r, opts.ThumbnailSize)
+	case strings.HasPrefix(mediaType, "audio/"):
+		out.Kind = PreviewKindAudio
+		return previewAudio(out, r)
+	case strings.HasPrefix(mediaType, "text/") || utf8.Valid(head):
+		out.Kind = PreviewKindText
+		return previewText(out, r)
+	default:
+		out.Kind = PreviewKindBinary
+		return nil
+	}
+}
+
+func previewCSV(out *Preview, r io.Reader, tsv bool, rows int) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = false
+	if tsv {
+		cr.Comma = '\t'
+	}
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("parse csv: %w", err)
+	}
+	out.Columns = header
+	out.Rows = make([][]string, 0, rows)
+	for len(out.Rows) < rows {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse csv: %w", err)
+		}
+		out.Rows = append(out.Rows, rec)
+	}
+	if _, err := cr.Read(); err == nil {
+		out.Truncated = true
+	}
+	return nil
+}
+
+func previewMarkdown(out *Preview, r io.Reader) error {
+	src, truncated, err := readLimited(r, previewMarkdownBytes)
+	if err != nil {
+		return err
+	}
+	var html bytes.Buffer
+	if err := goldmark.Convert(src, &html); err != nil {
+		return fmt.Errorf("render markdown: %w", err)
+	}
+	out.HTML = html.String()
+	out.Text = leadingText(src, previewTextBytes)
+	out.Truncated = truncated || len(out.Text) < len(src)
+	return nil
+}
+
+func previewText(out *Preview, r io.Reader) error {
+	src, truncated, err := readLimited(r, previewTextBytes)
+	if err != nil {
+		return err
+	}
+	out.Text = leadingText(src, previewTextBytes)
+	out.Truncated = truncated
+	return nil
+}
+
+func previewImage(out *Preview, r io.Reader, size int) error {
+	src, truncated, err := readLimited(r, previewImageBytes)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		// Formats without a registered decoder (svg, webp, ...) still report
+		// their kind; the UI can fall back to the raw file.
+		return nil
+	}
+	out.Width, out.Height = cfg.Width, cfg.Height
+	if truncated || cfg.Width*cfg.Height > previewImagePixels {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumbnail(img, size)); err != nil {
+		return fmt.Errorf("encode thumbnail: %w", err)
+	}
+	out.Thumbnail = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return nil
+}
+
+// thumbnail scales img so its longer edge is at most size, averaging the
+// source pixels covered by each destination pixel.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, size
+	if w >= h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var rs, gs, bs, as, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(img.At(sx, sy)).(color.NRGBA)
+					rs += uint64(c.R)
+					gs += uint64(c.G)
+					bs += uint64(c.B)
+					as += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(rs / n), G: uint8(gs / n), B: uint8(bs / n), A: uint8(as / n)})
+		}
+	}
+	return dst
+}
+
+// previewAudio reads duration from WAV and FLAC headers. Other formats
+// report their kind without a duration.
+func previewAudio(out *Preview, r io.Reader) error {
+	head, _, err := readLimited(r, 64<<10)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		parseWAVHeader(out, head)
+	case len(head) >= 4 && string(head[:4]) == "fLaC":
+		parseFLACHeader(out, head)
+	}
+	return nil
+}
+
+func parseWAVHeader(out *Preview, head []byte) {
+	var byteRate uint32
+	for off := 12; off+8 <= len(head); {
+		id := string(head[off : off+4])
+		size := binary.LittleEndian.Uint32(head[off+4 : off+8])
+		body := off + 8
+		switch id {
+		case "fmt ":
+			if body+16 <= len(head) {
+				out.Channels = int(binary.LittleEndian.Uint16(head[body+2:]))
+				out.SampleRate = int(binary.LittleEndian.Uint32(head[body+4:]))
+				byteRate = binary.LittleEndian.Uint32(head[body+8:])
+			}
+		case "data":
+			// Streaming writers leave the size at 0 or 0xFFFFFFFF; use the file size.
+			if size == 0 || size == 0xFFFFFFFF {
+				size = uint32(max(out.SizeBytes-int64(body), 0))
+			}
+			if byteRate > 0 {
+				out.DurationSeconds = float64(size) / float64(byteRate)
+			}
+			return
+		}
+		off = body + int(size) + int(size&1)
+	}
+}
+
+func parseFLACHeader(out *Preview, head []byte) {
+	// The first metadata block is always STREAMINFO (34 bytes).
+	if len(head) < 8+34 || head[4]&0x7f != 0 {
+		return
+	}
+	info := head[8:]
+	packed := binary.BigEndian.Uint64(info[10:18])
+	out.SampleRate = int(packed >> 44)
+	out.Channels = int((packed>>41)&0x7) + 1
+	samples := packed & 0xFFFFFFFFF
+	if out.SampleRate > 0 && samples > 0 {
+		out.DurationSeconds = float64(samples) / float64(out.SampleRate)
+	}
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
+// leadingText returns up to limit bytes of src, cut at a rune boundary.
+func leadingText(src []byte, limit int) string {
+	if len(src) <= limit {
+		return string(src)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(src[cut]) {
+		cut--
+	}
+	return string(src[:cut])
+}
@@ -0,0 +1,170 @@
+package projects
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPreviewProject(t *testing.T) (*Service, string) {
+	t.Helper()
+	svc := NewService(t.TempDir(), "")
+	p, err := svc.CreateProject(context.Background(), 1, "Preview")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	return svc, p.ID
+}
+
+func upload(t *testing.T, svc *Service, projectID, name string, data []byte) {
+	t.Helper()
+	if err := svc.UploadFile(context.Background(), 1, projectID, "/", name, bytes.NewReader(data)); err != nil {
+		t.Fatalf("UploadFile %s: %v", name, err)
+	}
+}
+
+func wavBytes(seconds, sampleRate, channels int) []byte {
+	data := make([]byte, seconds*sampleRate*channels*2)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&b, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&b, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&b, binary.LittleEndian, uint32(sampleRate))
+	_ = binary.Write(&b, binary.LittleEndian, uint32(sampleRate*channels*2))
+	_ = binary.Write(&b, binary.LittleEndian, uint16(channels*2))
+	_ = binary.Write(&b, binary.LittleEndian, uint16(16))
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestPreviewKinds(t *testing.T) {
+	t.Parallel()
+	svc, pid := newPreviewProject(t)
+	ctx := context.Background()
+
+	var csv strings.Builder
+	csv.WriteString("name,score\n")
+	for i := 0; i < 30; i++ {
+		csv.WriteString("row,1\n")
+	}
+	upload(t, svc, pid, "scores.csv", []byte(csv.String()))
+	upload(t, svc, pid, "notes.md", []byte("# Title\n\nSome *text*.\n\n<script>alert(1)</script>\n"))
+	upload(t, svc, pid, "clip.wav", wavBytes(2, 8000, 1))
+	upload(t, svc, pid, "data.bin", []byte{0x00, 0xff, 0x10, 0x80})
+
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	upload(t, svc, pid, "chart.png", pngBuf.Bytes())
+
+	pv := NewPreviewer(svc, 0)
+
+	got, err := pv.Preview(ctx, 1, pid, "scores.csv", PreviewOptions{Rows: 5})
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if got.Kind != PreviewKindCSV || len(got.Columns) != 2 || len(got.Rows) != 5 || !got.Truncated {
+		t.Fatalf("unexpected csv preview: %+v", got)
+	}
+
+	got, err = pv.Preview(ctx, 1, pid, "notes.md", PreviewOptions{})
+	if err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	if got.Kind != PreviewKindMarkdown || !strings.Contains(got.HTML, "<h1>Title</h1>") || strings.Contains(got.HTML, "<script>") {
+		t.Fatalf("unexpected markdown preview: %+v", got)
+	}
+
+	got, err = pv.Preview(ctx, 1, pid, "chart.png", PreviewOptions{ThumbnailSize: 100})
+	if err != nil {
+		t.Fatalf("image: %v", err)
+	}
+	if got.Kind != PreviewKindImage || got.Width != 800 || got.Height != 400 || !strings.HasPrefix(got.Thumbnail, "data:image/png;base64,") {
+		t.Fatalf("unexpected image preview: %+v", got)
+	}
+	thumbCfg, err := png.DecodeConfig(bytes.NewReader(decodeDataURL(t, got.Thumbnail)))
+	if err != nil || thumbCfg.Width != 100 || thumbCfg.Height != 50 {
+		t.Fatalf("unexpected thumbnail size %dx%d (err %v)", thumbCfg.Width, thumbCfg.Height, err)
+	}
+
+	got, err = pv.Preview(ctx, 1, pid, "clip.wav", PreviewOptions{})
+	if err != nil {
+		t.Fatalf("audio: %v", err)
+	}
+	if got.Kind != PreviewKindAudio || got.DurationSeconds != 2 || got.SampleRate != 8000 || got.Channels != 1 {
+		t.Fatalf("unexpected audio preview: %+v", got)
+	}
+
+	got, err = pv.Preview(ctx, 1, pid, "data.bin", PreviewOptions{})
+	if err != nil {
+		t.Fatalf("binary: %v", err)
+	}
+	if got.Kind != PreviewKindBinary {
+		t.Fatalf("expected binary kind, got %+v", got)
+	}
+
+	if _, err := pv.Preview(ctx, 1, pid, "missing.csv", PreviewOptions{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestPreviewCacheInvalidatesOnChange(t *testing.T) {
+	t.Parallel()
+	svc, pid := newPreviewProject(t)
+	ctx := context.Background()
+	upload(t, svc, pid, "a.txt", []byte("first"))
+
+	pv := NewPreviewer(svc, 4)
+	got, err := pv.Preview(ctx, 1, pid, "a.txt", PreviewOptions{})
+	if err != nil || got.Text != "first" {
+		t.Fatalf("first preview: %+v err=%v", got, err)
+	}
+	if pv.ll.Len() != 1 {
+		t.Fatalf("expected preview to be cached")
+	}
+
+	upload(t, svc, pid, "a.txt", []byte("second version"))
+	// Force a distinct mtime even on coarse filesystem clocks.
+	future := time.Now().Add(time.Minute)
+	root, _ := svc.projectRoot(1, pid)
+	if err := os.Chtimes(filepath.Join(root, "a.txt"), future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	got, err = pv.Preview(ctx, 1, pid, "a.txt", PreviewOptions{})
+	if err != nil || got.Text != "second version" {
+		t.Fatalf("expected fresh preview after change, got %+v err=%v", got, err)
+	}
+}
+
+func decodeDataURL(t *testing.T, s string) []byte {
+	t.Helper()
+	_, b64, _ := strings.Cut(s, ",")
+	out, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		t.Fatalf("decode data url: %v", err)
+	}
+	return out
+}
@@ -40,10 +40,15 @@ type FileEntry struct {
 	ModTime time.Time `json:"mtime"`
 }
 
+// ErrQuotaExceeded is returned by UploadFile when the upload would take the
+// user past the configured storage quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
 // Service provides filesystem-backed project operations under a WORKDIR.
 type Service struct {
 	workdir          string
 	defaultSkillsDir string
+	maxBytes         int64
 }
 
 // NewService creates a new filesystem-backed projects service.
@@ -51,6 +56,10 @@ func NewService(workdir, defaultSkillsDir string) *Service {
 	return &Service{workdir: workdir, defaultSkillsDir: defaultSkillsDir}
 }
 
+// SetQuota caps the bytes a user may store across their projects through
+// UploadFile. Zero or a negative value means unlimited.
+func (s *Service) SetQuota(maxBytes int64) { s.maxBytes = maxBytes }
+
 func (s *Service) userRoot(userID int64) string {
 	return filepath.Join(s.workdir, "users", fmt.Sprint(userID), "projects")
 }
@@ -242,6 +251,12 @@ func (s *Service) writeUpdatedAt(userID int64, projectID string, t time.Time, bu
 	}
 }
 
+// Usage reports the bytes stored across all of a user's projects.
+func (s *Service) Usage(_ context.Context, userID int64) (int64, error) {
+	bytes, _ := s.computeUsage(s.userRoot(userID))
+	return bytes, nil
+}
+
 func (s *Service) computeUsage(root string) (int64, int) {
 	var (
 		bytes int64
@@ -346,12 +361,34 @@ func (s *Service) UploadFile(_ context.Context, userID int64, projectID, path, n
 		return err
 	}
 	dst := filepath.Join(dir, name)
-	f, err := os.Create(dst)
+	if s.maxBytes > 0 {
+		used, _ := s.computeUsage(s.userRoot(userID))
+		if st, err := os.Lstat(dst); err == nil && st.Mode().IsRegular() {
+			used -= st.Size()
+		}
+		// Read one byte past the allowance so an oversized upload is detected
+		// without buffering it.
+		r = &quotaReader{r: io.LimitReader(r, s.maxBytes-used+1), left: s.maxBytes - used}
+	}
+	// Stream into a temp file and rename so a failed or rejected upload never
+	// leaves a truncated file behind.
+	f, err := os.CreateTemp(dir, "."+name+uploadTempMarker+"*")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	if _, err := io.Copy(f, r); err != nil {
+	tmp := f.Name()
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
 		return err
 	}
 	fullRel := filepath.ToSlash(filepath.Join(rel, name))
@@ -360,6 +397,29 @@ func (s *Service) UploadFile(_ context.Context, userID int64, projectID, path, n
 	return nil
 }
 
+// uploadTempMarker tags the temp files UploadFile streams into.
+const uploadTempMarker = ".upload-"
+
+// IsUploadTemp reports whether name is a temp file left by an UploadFile
+// that did not finish.
+func IsUploadTemp(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, uploadTempMarker)
+}
+
+// quotaReader fails with ErrQuotaExceeded once more than left bytes are read.
+type quotaReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	if q.left -= int64(n); q.left < 0 {
+		return n, ErrQuotaExceeded
+	}
+	return n, err
+}
+
 // DeleteFile removes a single filesystem entry within a project.
 //
 // If the path points to a file, it is deleted. If it points to a directory,
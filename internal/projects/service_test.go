@@ -3,6 +3,7 @@ package projects
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -244,3 +245,67 @@ func TestCreateProject_NoDefaultSkills(t *testing.T) {
 		t.Fatalf("expected SkillsGeneration=0, got %d", p.SkillsGeneration)
 	}
 }
+
+func TestServiceUsageSumsProjects(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	svc := NewService(tmp, "")
+	ctx := context.TODO()
+	var ids []string
+	for _, name := range []string{"one", "two"} {
+		p, err := svc.CreateProject(ctx, 3, name)
+		if err != nil {
+			t.Fatalf("CreateProject error: %v", err)
+		}
+		ids = append(ids, p.ID)
+	}
+	before, err := svc.Usage(ctx, 3)
+	if err != nil {
+		t.Fatalf("Usage error: %v", err)
+	}
+	for _, id := range ids {
+		if err := svc.UploadFile(ctx, 3, id, ".", "f.txt", strings.NewReader("12345")); err != nil {
+			t.Fatalf("UploadFile error: %v", err)
+		}
+	}
+	if used, _ := svc.Usage(ctx, 3); used != before+10 {
+		t.Fatalf("Usage = %d, want %d", used, before+10)
+	}
+	if used, _ := svc.Usage(ctx, 4); used != 0 {
+		t.Fatalf("Usage for other user = %d, want 0", used)
+	}
+}
+
+func TestUploadFileEnforcesQuota(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	svc := NewService(tmp, "")
+	ctx := context.TODO()
+	p, err := svc.CreateProject(ctx, 5, "quota")
+	if err != nil {
+		t.Fatalf("CreateProject error: %v", err)
+	}
+	base, _ := svc.Usage(ctx, 5)
+	svc.SetQuota(base + 10)
+
+	if err := svc.UploadFile(ctx, 5, p.ID, ".", "a.txt", strings.NewReader("12345678")); err != nil {
+		t.Fatalf("UploadFile within quota: %v", err)
+	}
+	// Replacing a file only counts the difference in size.
+	if err := svc.UploadFile(ctx, 5, p.ID, ".", "a.txt", strings.NewReader("1234567890")); err != nil {
+		t.Fatalf("UploadFile replacing within quota: %v", err)
+	}
+	err = svc.UploadFile(ctx, 5, p.ID, ".", "b.txt", strings.NewReader("x"))
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	entries, err := svc.ListTree(ctx, 5, p.ID, ".")
+	if err != nil {
+		t.Fatalf("ListTree error: %v", err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name, "b.txt") {
+			t.Fatalf("rejected upload left %q behind", e.Name)
+		}
+	}
+}
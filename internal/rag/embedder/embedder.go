@@ -2,13 +2,12 @@ package embedder
 
 import (
 	"context"
+	"fmt"
 	"hash/fnv"
 	"math"
-	"sync"
-	"time"
 
 	"manifold/internal/config"
-	"manifold/internal/embedding"
+	"manifold/internal/llm/embeddings"
 )
 
 // Embedder defines the interface for converting text to embedding vectors.
@@ -23,77 +22,58 @@ type Embedder interface {
 	Ping(ctx context.Context) error
 }
 
-// clientEmbedder wraps the embedding.EmbedText HTTP client for real embeddings.
+// Deferrer is implemented by embedders that can hold texts while every
+// embedding endpoint is down and embed them once one recovers. Defer
+// returns embeddings.ErrQueueFull when the texts cannot be queued.
+type Deferrer interface {
+	Defer(texts []string, fn embeddings.DeferredFunc) error
+}
+
+// clientEmbedder adapts an embeddings.Failover, which handles batching,
+// rate limiting, and retries per endpoint and fails over between the
+// configured endpoints.
 type clientEmbedder struct {
-	cfg       config.EmbeddingConfig
-	dim       int
-	batchSize int           // max texts per API call
-	mu        sync.Mutex    // serializes API calls
-	lastCall  time.Time     // last API call timestamp
-	minDelay  time.Duration // minimum delay between API calls
+	cfg      config.EmbeddingConfig
+	dim      int
+	provider *embeddings.Failover
+	err      error
 }
 
-// NewClient constructs an embedder that calls the configured embedding endpoint.
-// It sends one chunk per request to avoid batch inference issues with some
-// embedding servers (e.g. llama.cpp). Rate limiting remains configurable.
+// NewClient constructs an embedder that calls the configured embedding endpoint,
+// falling back to cfg.Fallbacks in order when it is down.
+// Batch size defaults per provider; llama.cpp-compatible servers receive one
+// chunk per request to avoid batch inference issues.
 func NewClient(cfg config.EmbeddingConfig, dim int) Embedder {
-	// Use single-item batches so each chunk is sent individually.
-	// This avoids llama.cpp crashes related to batching/concurrency.
-	return &clientEmbedder{
-		cfg:       cfg,
-		dim:       dim,
-		batchSize: 1,
-		minDelay:  0,
-	}
+	p, err := embeddings.NewFailover(cfg, nil)
+	return &clientEmbedder{cfg: cfg, dim: dim, provider: p, err: err}
 }
 
 func (c *clientEmbedder) Name() string   { return c.cfg.Model }
 func (c *clientEmbedder) Dimension() int { return c.dim }
 
+// Ping succeeds when any configured endpoint can embed a probe text.
 func (c *clientEmbedder) Ping(ctx context.Context) error {
-	return embedding.CheckReachability(ctx, c.cfg)
-}
-
-func (c *clientEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	if len(texts) == 0 {
-		return nil, nil
+	if c.err != nil {
+		return c.err
 	}
-
-	// If texts fit in a single batch, call directly with rate limiting
-	if len(texts) <= c.batchSize {
-		return c.rateLimitedCall(ctx, texts)
+	if _, err := c.provider.Embed(ctx, []string{"ping"}); err != nil {
+		return fmt.Errorf("embedding endpoint reachability check failed: %w", err)
 	}
+	return nil
+}
 
-	// Otherwise, batch the requests with rate limiting
-	var allEmbeddings [][]float32
-	for i := 0; i < len(texts); i += c.batchSize {
-		end := i + c.batchSize
-		if end > len(texts) {
-			end = len(texts)
-		}
-		batch := texts[i:end]
-		embeddings, err := c.rateLimitedCall(ctx, batch)
-		if err != nil {
-			return allEmbeddings, err
-		}
-		allEmbeddings = append(allEmbeddings, embeddings...)
+func (c *clientEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if c.err != nil {
+		return nil, c.err
 	}
-	return allEmbeddings, nil
+	return c.provider.Embed(ctx, texts)
 }
 
-// rateLimitedCall ensures a minimum delay between API calls to avoid overwhelming the server
-func (c *clientEmbedder) rateLimitedCall(ctx context.Context, texts []string) ([][]float32, error) {
-	c.mu.Lock()
-	if !c.lastCall.IsZero() {
-		elapsed := time.Since(c.lastCall)
-		if elapsed < c.minDelay {
-			time.Sleep(c.minDelay - elapsed)
-		}
+func (c *clientEmbedder) Defer(texts []string, fn embeddings.DeferredFunc) error {
+	if c.err != nil {
+		return c.err
 	}
-	c.lastCall = time.Now()
-	c.mu.Unlock()
-
-	return embedding.EmbedText(ctx, c.cfg, texts)
+	return c.provider.Defer(texts, fn)
 }
 
 // deterministicEmbedder is a lightweight, deterministic embedder suitable for tests.
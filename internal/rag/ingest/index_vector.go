@@ -2,15 +2,22 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
+	"manifold/internal/llm/embeddings"
 	"manifold/internal/persistence/databases"
 	"manifold/internal/rag/embedder"
 )
 
+// ErrEmbeddingsDeferred reports that chunk vectors were queued because every
+// embedding endpoint is down; they are upserted once one recovers.
+var ErrEmbeddingsDeferred = errors.New("embeddings deferred until the embedding endpoint recovers")
+
 // UpsertChunkEmbeddings embeds chunk texts and upserts vectors into the vector store.
 // It returns the number of upserts performed. Metadata includes doc_id, tenant, lang,
-// model, and version.
+// model, and version. When every embedding endpoint is down and emb is an
+// embedder.Deferrer, the chunks are queued and ErrEmbeddingsDeferred is returned.
 func UpsertChunkEmbeddings(ctx context.Context, vec databases.VectorStore, emb embedder.Embedder, docID string, lang string, chunks []ChunkRecord, in IngestRequest, version int) (int, error) {
 	if vec == nil || emb == nil || len(chunks) == 0 {
 		return 0, nil
@@ -24,13 +31,28 @@ func UpsertChunkEmbeddings(ctx context.Context, vec databases.VectorStore, emb e
 	}
 	embs, err := emb.EmbedBatch(ctx, texts)
 	if err != nil {
-		return 0, err
+		d, ok := emb.(embedder.Deferrer)
+		if !ok || !errors.Is(err, embeddings.ErrUnavailable) {
+			return 0, err
+		}
+		deferred := func(ctx context.Context, vecs [][]float32) error {
+			_, err := upsertChunkVectors(ctx, vec, emb.Name(), docID, lang, ids, vecs, in, version)
+			return err
+		}
+		if derr := d.Defer(texts, deferred); derr != nil {
+			return 0, errors.Join(err, derr)
+		}
+		return 0, ErrEmbeddingsDeferred
 	}
+	return upsertChunkVectors(ctx, vec, emb.Name(), docID, lang, ids, embs, in, version)
+}
+
+func upsertChunkVectors(ctx context.Context, vec databases.VectorStore, model, docID, lang string, ids []string, embs [][]float32, in IngestRequest, version int) (int, error) {
 	// Prepare shared metadata base
 	base := map[string]string{
 		"type":   "chunk",
 		"doc_id": docID,
-		"model":  emb.Name(),
+		"model":  model,
 	}
 	if in.Tenant != "" {
 		base["tenant"] = in.Tenant
@@ -2,8 +2,10 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"manifold/internal/llm/embeddings"
 	"manifold/internal/persistence/databases"
 	"manifold/internal/rag/embedder"
 )
@@ -39,3 +41,46 @@ func TestUpsertChunkEmbeddings_MemoryVector(t *testing.T) {
 		t.Fatalf("expected top result chunk 0, got %s", res[0].ID)
 	}
 }
+
+// deferringEmbedder is down until flush is called with the queued work.
+type deferringEmbedder struct {
+	embedder.Embedder
+	queued embeddings.DeferredFunc
+	texts  []string
+}
+
+func (d *deferringEmbedder) EmbedBatch(context.Context, []string) ([][]float32, error) {
+	return nil, embeddings.ErrUnavailable
+}
+
+func (d *deferringEmbedder) Defer(texts []string, fn embeddings.DeferredFunc) error {
+	d.texts, d.queued = texts, fn
+	return nil
+}
+
+func TestUpsertChunkEmbeddings_DeferredWhileUnavailable(t *testing.T) {
+	ctx := context.Background()
+	vec := databases.NewMemoryVector()
+	inner := embedder.NewDeterministic(8, true, 42)
+	emb := &deferringEmbedder{Embedder: inner}
+	in := IngestRequest{ID: "doc:acme:2", Tenant: "acme"}
+	chunks := []ChunkRecord{{Index: 0, Text: "hello world"}, {Index: 1, Text: "goodbye"}}
+
+	n, err := UpsertChunkEmbeddings(ctx, vec, emb, in.ID, "english", chunks, in, 1)
+	if !errors.Is(err, ErrEmbeddingsDeferred) || n != 0 {
+		t.Fatalf("expected deferred embeddings, got n=%d err=%v", n, err)
+	}
+	if len(emb.texts) != 2 || emb.queued == nil {
+		t.Fatalf("expected chunks to be queued, got %v", emb.texts)
+	}
+
+	// Recovery: the queued callback upserts the vectors.
+	vecs, _ := inner.EmbedBatch(ctx, emb.texts)
+	if err := emb.queued(ctx, vecs); err != nil {
+		t.Fatalf("deferred upsert: %v", err)
+	}
+	res, err := vec.SimilaritySearch(ctx, vecs[0], 5, map[string]string{"doc_id": in.ID})
+	if err != nil || len(res) != 2 || res[0].ID != "chunk:"+in.ID+":0" {
+		t.Fatalf("expected both chunks after recovery, got %v (%v)", res, err)
+	}
+}
@@ -22,6 +22,9 @@ type RetrieveOptions struct {
 	Diversify bool
 	// Rerank toggles an optional cross-encoder reranking stage.
 	Rerank bool
+	// RerankCandidates widens the fused candidate pool handed to the reranker
+	// before the final cut to K. Ignored unless Rerank is set.
+	RerankCandidates int
 	// GraphAugment toggles graph-based neighborhood expansion.
 	GraphAugment bool
 	// Tenant for multi-tenant isolation.
@@ -221,11 +221,8 @@ func FuseAndDiversify(fts []databases.SearchResult, vec []databases.VectorResult
 			},
 		})
 	}
-	// Final cap by requested K
-	k := opt.K
-	if k <= 0 {
-		k = 10
-	}
+	// Final cap by requested K (or the rerank pool)
+	k := CandidatePool(opt)
 	if len(items) > k {
 		items = items[:k]
 	}
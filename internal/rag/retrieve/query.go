@@ -27,10 +27,7 @@ func BuildQueryPlan(ctx context.Context, q string, opt RetrieveOptions) QueryPla
 	nq := normalizeQuery(q)
 	lang := detectLang(nq)
 
-	k := opt.K
-	if k <= 0 {
-		k = 10
-	}
+	k := CandidatePool(opt)
 	if k > 1000 {
 		k = 1000 // sanity cap to avoid runaway allocations
 	}
@@ -128,3 +125,16 @@ func splitBudgets(k int, opt RetrieveOptions) (int, int) {
 	}
 	return ft, vc
 }
+
+// CandidatePool returns how many fused candidates to gather: K (default 10),
+// widened to RerankCandidates when reranking.
+func CandidatePool(opt RetrieveOptions) int {
+	k := opt.K
+	if k <= 0 {
+		k = 10
+	}
+	if opt.Rerank && opt.RerankCandidates > k {
+		k = opt.RerankCandidates
+	}
+	return k
+}
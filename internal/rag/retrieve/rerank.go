@@ -1,6 +1,17 @@
 package retrieve
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"manifold/internal/llm"
+)
 
 // Reranker optionally reorders retrieved items (e.g., via a cross-encoder).
 // Implementations should not drop items and should preserve Metadata fields.
@@ -14,3 +25,149 @@ type NoopReranker struct{}
 func (NoopReranker) Rerank(_ context.Context, _ string, items []RetrievedItem) ([]RetrievedItem, error) {
 	return items, nil
 }
+
+// rerankText picks the best available passage text for an item.
+func rerankText(it RetrievedItem, maxLen int) string {
+	t := strings.TrimSpace(it.Text)
+	if t == "" {
+		t = strings.TrimSpace(it.Snippet)
+	}
+	if maxLen > 0 && len(t) > maxLen {
+		t = t[:maxLen]
+	}
+	return t
+}
+
+// applyOrder reorders items by order (indices into items, best first),
+// appending any items the reranker omitted in their original order. Each
+// item's Explanation records its pre-rerank position and rerank score.
+func applyOrder(items []RetrievedItem, order []int, scores map[int]float64) []RetrievedItem {
+	out := make([]RetrievedItem, 0, len(items))
+	used := make([]bool, len(items))
+	push := func(i int) {
+		it := items[i]
+		if it.Explanation == nil {
+			it.Explanation = map[string]any{}
+		}
+		it.Explanation["pre_rerank_pos"] = i + 1
+		if s, ok := scores[i]; ok {
+			it.Explanation["rerank_score"] = s
+		}
+		out = append(out, it)
+		used[i] = true
+	}
+	for _, i := range order {
+		if i >= 0 && i < len(items) && !used[i] {
+			push(i)
+		}
+	}
+	for i := range items {
+		if !used[i] {
+			push(i)
+		}
+	}
+	return out
+}
+
+// LLMReranker asks a chat model to order passages by relevance.
+type LLMReranker struct {
+	Provider llm.Provider
+	Model    string
+	// MaxPassageChars truncates each passage in the prompt. Default: 800.
+	MaxPassageChars int
+}
+
+func (r LLMReranker) Rerank(ctx context.Context, query string, items []RetrievedItem) ([]RetrievedItem, error) {
+	if r.Provider == nil || len(items) < 2 {
+		return items, nil
+	}
+	maxLen := r.MaxPassageChars
+	if maxLen <= 0 {
+		maxLen = 800
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n\nPassages:\n", query)
+	for i, it := range items {
+		fmt.Fprintf(&b, "[%d] %s\n", i, strings.ReplaceAll(rerankText(it, maxLen), "\n", " "))
+	}
+	b.WriteString("\nReturn only a JSON array of passage numbers ordered from most to least relevant to the query.")
+	msgs := []llm.Message{
+		{Role: "system", Content: "You rank search results by relevance. Respond with JSON only."},
+		{Role: "user", Content: b.String()},
+	}
+	resp, err := r.Provider.Chat(ctx, msgs, nil, r.Model)
+	if err != nil {
+		return items, err
+	}
+	raw := strings.TrimSpace(resp.Content)
+	if start, end := strings.Index(raw, "["), strings.LastIndex(raw, "]"); start >= 0 && end > start {
+		raw = raw[start : end+1]
+	}
+	var order []int
+	if err := json.Unmarshal([]byte(raw), &order); err != nil {
+		return items, fmt.Errorf("llm rerank: parse order: %w", err)
+	}
+	return applyOrder(items, order, nil), nil
+}
+
+// CrossEncoderReranker calls a hosted cross-encoder using the Cohere/Jina
+// rerank API shape: POST {BaseURL}/rerank with {model, query, documents}
+// returning {results: [{index, relevance_score}]}.
+type CrossEncoderReranker struct {
+	BaseURL string
+	Model   string
+	APIKey  string
+	Client  *http.Client
+}
+
+func (r CrossEncoderReranker) Rerank(ctx context.Context, query string, items []RetrievedItem) ([]RetrievedItem, error) {
+	if len(items) < 2 {
+		return items, nil
+	}
+	docs := make([]string, len(items))
+	for i, it := range items {
+		docs[i] = rerankText(it, 0)
+	}
+	body, err := json.Marshal(map[string]any{"model": r.Model, "query": query, "documents": docs})
+	if err != nil {
+		return items, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(r.BaseURL, "/")+"/rerank", bytes.NewReader(body))
+	if err != nil {
+		return items, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return items, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return items, fmt.Errorf("rerank: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	var out struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return items, fmt.Errorf("rerank: decode: %w", err)
+	}
+	sort.SliceStable(out.Results, func(i, j int) bool { return out.Results[i].RelevanceScore > out.Results[j].RelevanceScore })
+	order := make([]int, len(out.Results))
+	scores := make(map[int]float64, len(out.Results))
+	for i, res := range out.Results {
+		order[i] = res.Index
+		scores[res.Index] = res.RelevanceScore
+	}
+	return applyOrder(items, order, scores), nil
+}
@@ -0,0 +1,92 @@
+package retrieve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"manifold/internal/llm"
+	"manifold/internal/testhelpers"
+)
+
+func rerankItems() []RetrievedItem {
+	return []RetrievedItem{
+		{ID: "a", Text: "apples grow on trees"},
+		{ID: "b", Snippet: "bananas are yellow"},
+		{ID: "c", Text: "cherries are red"},
+	}
+}
+
+func ids(items []RetrievedItem) string {
+	s := ""
+	for _, it := range items {
+		s += it.ID
+	}
+	return s
+}
+
+func TestLLMRerankerOrdersByModelResponse(t *testing.T) {
+	prov := &testhelpers.FakeProvider{Resp: llm.Message{Content: "Sure: [2, 0]"}}
+	out, err := LLMReranker{Provider: prov}.Rerank(context.Background(), "red fruit", rerankItems())
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+	// Omitted passages keep their relative order at the end.
+	if got := ids(out); got != "cab" {
+		t.Fatalf("unexpected order %q", got)
+	}
+	if out[0].Explanation["pre_rerank_pos"] != 3 {
+		t.Fatalf("expected provenance, got %v", out[0].Explanation)
+	}
+}
+
+func TestLLMRerankerRejectsUnparseableOutput(t *testing.T) {
+	prov := &testhelpers.FakeProvider{Resp: llm.Message{Content: "no idea"}}
+	in := rerankItems()
+	out, err := LLMReranker{Provider: prov}.Rerank(context.Background(), "q", in)
+	if err == nil || ids(out) != "abc" {
+		t.Fatalf("expected error and original order, got %q %v", ids(out), err)
+	}
+}
+
+func TestCrossEncoderReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rerank" || r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Documents []string `json:"documents"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Documents) != 3 || body.Documents[1] != "bananas are yellow" {
+			t.Errorf("unexpected documents %v", body.Documents)
+		}
+		_, _ = w.Write([]byte(`{"results":[{"index":0,"relevance_score":0.1},{"index":1,"relevance_score":0.9},{"index":2,"relevance_score":0.5}]}`))
+	}))
+	defer srv.Close()
+
+	out, err := CrossEncoderReranker{BaseURL: srv.URL, APIKey: "k"}.Rerank(context.Background(), "yellow", rerankItems())
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+	if got := ids(out); got != "bca" {
+		t.Fatalf("unexpected order %q", got)
+	}
+	if out[0].Explanation["rerank_score"] != 0.9 {
+		t.Fatalf("expected rerank score, got %v", out[0].Explanation)
+	}
+}
+
+func TestCandidatePool(t *testing.T) {
+	if n := CandidatePool(RetrieveOptions{}); n != 10 {
+		t.Fatalf("expected default 10, got %d", n)
+	}
+	if n := CandidatePool(RetrieveOptions{K: 5, RerankCandidates: 30}); n != 5 {
+		t.Fatalf("expected pool unchanged without rerank, got %d", n)
+	}
+	if n := CandidatePool(RetrieveOptions{K: 5, Rerank: true, RerankCandidates: 30}); n != 30 {
+		t.Fatalf("expected widened pool, got %d", n)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"manifold/internal/persistence/databases"
@@ -48,6 +49,15 @@ type Option func(*Service)
 // WithEmbedder sets a custom embedder implementation used during ingestion.
 func WithEmbedder(e embedder.Embedder) Option { return func(s *Service) { s.emb = e } }
 
+// WithReranker sets the reranker applied when RetrieveOptions.Rerank is set.
+func WithReranker(r retrieve.Reranker) Option {
+	return func(s *Service) {
+		if r != nil {
+			s.rerank = r
+		}
+	}
+}
+
 // Ingest performs chunk-centric ingestion. Stubbed for Milestone 3.
 func (s *Service) Ingest(ctx context.Context, in ingest.IngestRequest) (ingest.IngestResponse, error) {
 	start := s.clock.Now()
@@ -116,10 +126,15 @@ func (s *Service) Ingest(ctx context.Context, in ingest.IngestRequest) (ingest.I
 
 	// Step 5: embeddings (optional)
 	vecUpserts := 0
+	var warnings []string
 	if in.Options.Embedding.Enabled && s.vector != nil {
 		t0 = s.clock.Now()
 		n, err := ingest.UpsertChunkEmbeddings(ctx, s.vector, s.emb, in.ID, pre.Language, crecs, in, decision.Version)
-		if err != nil {
+		switch {
+		case errors.Is(err, ingest.ErrEmbeddingsDeferred):
+			s.metrics.IncCounter("ingestion_embeddings_deferred_total", map[string]string{"tenant": in.Tenant})
+			warnings = append(warnings, err.Error())
+		case err != nil:
 			return ingest.IngestResponse{}, err
 		}
 		vecUpserts = n
@@ -147,7 +162,7 @@ func (s *Service) Ingest(ctx context.Context, in ingest.IngestRequest) (ingest.I
 			VectorUpserts: vecUpserts,
 			Duration:      dur,
 		},
-		Warnings: nil,
+		Warnings: warnings,
 	}, nil
 }
 
@@ -204,15 +219,25 @@ func (s *Service) Retrieve(ctx context.Context, q string, opt retrieve.RetrieveO
 		for _, r := range vecRes {
 			items = append(items, retrieve.RetrievedItem{ID: r.ID, Score: r.Score, Metadata: r.Metadata})
 		}
-		// Cap to K
-		k := opt.K
-		if k <= 0 {
-			k = 10
-		}
+		// Cap to K (or the rerank pool)
+		k := retrieve.CandidatePool(opt)
 		if len(items) > k {
 			items = items[:k]
 		}
 	}
+	// Rerankers score passage text, which vector-only hits lack.
+	hydrated := map[string]bool{}
+	if opt.Rerank && s.search != nil {
+		for i := range items {
+			if items[i].Text != "" {
+				continue
+			}
+			if doc, ok, _ := s.search.GetByID(ctx, items[i].ID); ok {
+				items[i].Text = doc.Text
+				hydrated[items[i].ID] = true
+			}
+		}
+	}
 	// Graph augment + optional rerank + final prune
 	items, addDbg, err := retrieve.AssembleResults(ctx, s.graph, s.rerank, plan, opt, items)
 	if err != nil {
@@ -235,6 +260,13 @@ func (s *Service) Retrieve(ctx context.Context, q string, opt retrieve.RetrieveO
 	if opt.IncludeSnippet {
 		items = retrieve.GenerateSnippets(ctx, s.search, items, retrieve.SnippetOptions{Lang: plan.Lang, Query: plan.Query})
 	}
+	if !opt.IncludeText {
+		for i := range items {
+			if hydrated[items[i].ID] {
+				items[i].Text = ""
+			}
+		}
+	}
 	if opt.IncludeText && s.search != nil {
 		// ensure Text present for items lacking it
 		for i := range items {
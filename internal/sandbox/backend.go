@@ -0,0 +1,34 @@
+package sandbox
+
+import "context"
+
+// Execution backends for tools that spawn processes.
+const (
+	ExecBackendHost      = "host"
+	ExecBackendContainer = "container"
+)
+
+type execBackendCtxKey struct{}
+
+// WithExecBackend attaches the execution backend ("host" or "container")
+// that process-spawning tools should use, overriding their configured
+// default. Other values are ignored.
+func WithExecBackend(ctx context.Context, backend string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if backend != ExecBackendHost && backend != ExecBackendContainer {
+		return ctx
+	}
+	return context.WithValue(ctx, execBackendCtxKey{}, backend)
+}
+
+// ExecBackendFromContext returns the backend set with WithExecBackend. The
+// boolean is false if none is present.
+func ExecBackendFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	b, ok := ctx.Value(execBackendCtxKey{}).(string)
+	return b, ok
+}
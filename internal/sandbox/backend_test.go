@@ -0,0 +1,19 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecBackendFromContext(t *testing.T) {
+	if _, ok := ExecBackendFromContext(context.Background()); ok {
+		t.Fatalf("expected no backend on a bare context")
+	}
+	if _, ok := ExecBackendFromContext(WithExecBackend(context.Background(), "vm")); ok {
+		t.Fatalf("expected unknown backend to be ignored")
+	}
+	got, ok := ExecBackendFromContext(WithExecBackend(context.Background(), ExecBackendContainer))
+	if !ok || got != ExecBackendContainer {
+		t.Fatalf("ExecBackendFromContext = %q, %v; want %q, true", got, ok, ExecBackendContainer)
+	}
+}
@@ -0,0 +1,68 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Context key for per-run environment variables injected into tool execution.
+type envCtxKey struct{}
+
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvNames cannot be overridden per run because they change how
+// binaries are located or loaded.
+var reservedEnvNames = map[string]struct{}{
+	"PATH": {}, "HOME": {}, "SHELL": {}, "USER": {}, "IFS": {}, "ENV": {}, "BASH_ENV": {},
+}
+
+// ValidateEnvName reports whether name may be used as a per-run environment
+// variable. Loader variables (LD_*, DYLD_*) and a few shell essentials are
+// rejected.
+func ValidateEnvName(name string) error {
+	if !envNameRe.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	upper := strings.ToUpper(name)
+	if _, ok := reservedEnvNames[upper]; ok || strings.HasPrefix(upper, "LD_") || strings.HasPrefix(upper, "DYLD_") {
+		return fmt.Errorf("environment variable %q cannot be overridden", name)
+	}
+	return nil
+}
+
+// WithEnv attaches per-run environment variables to ctx. Tools that spawn
+// processes append them to the inherited environment. Invalid names are
+// dropped.
+func WithEnv(ctx context.Context, env map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	clean := make(map[string]string, len(env))
+	for k, v := range env {
+		if ValidateEnvName(k) == nil {
+			clean[k] = v
+		}
+	}
+	return context.WithValue(ctx, envCtxKey{}, clean)
+}
+
+// EnvFromContext returns the variables set with WithEnv as sorted KEY=VALUE
+// pairs, or nil when none are present.
+func EnvFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	env, _ := ctx.Value(envCtxKey{}).(map[string]string)
+	if len(env) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(env))
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
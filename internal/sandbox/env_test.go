@@ -0,0 +1,30 @@
+package sandbox
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestValidateEnvName(t *testing.T) {
+	for _, name := range []string{"API_KEY", "_private", "Token2"} {
+		if err := ValidateEnvName(name); err != nil {
+			t.Errorf("ValidateEnvName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "1BAD", "WITH-DASH", "PATH", "LD_PRELOAD", "dyld_insert_libraries"} {
+		if err := ValidateEnvName(name); err == nil {
+			t.Errorf("ValidateEnvName(%q) = nil, want error", name)
+		}
+	}
+}
+
+func TestEnvFromContext(t *testing.T) {
+	if env := EnvFromContext(context.Background()); env != nil {
+		t.Fatalf("expected no env, got %v", env)
+	}
+	ctx := WithEnv(context.Background(), map[string]string{"B": "2", "A": "1", "LD_PRELOAD": "x"})
+	if got, want := EnvFromContext(ctx), []string{"A=1", "B=2"}; !slices.Equal(got, want) {
+		t.Fatalf("EnvFromContext = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+// Package secrets keeps named secrets encrypted at rest and resolves
+// {{secret:NAME}} references in specialist, MCP server and tool configs, so
+// plaintext keys do not have to live in those configs.
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"manifold/internal/persistence"
+)
+
+// sealedPrefix marks ciphertext values: sec:v1:<base64(nonce|sealed)>.
+const sealedPrefix = "sec:v1:"
+
+var (
+	// Ref matches {{secret:NAME}} references; the first group is the name.
+	Ref = regexp.MustCompile(`\{\{\s*secret:([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+	validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// ErrInvalidName is returned for names that cannot be referenced.
+	ErrInvalidName = errors.New("secret names must match [A-Za-z_][A-Za-z0-9_]*")
+
+	// ErrNoDomains is returned when a secret is not bound to any domain.
+	ErrNoDomains = errors.New("secrets must be bound to at least one domain")
+)
+
+// Scope limits where tools may send a secret: to hosts under Domains, on
+// behalf of the users in UserIDs.
+type Scope struct {
+	Domains []string
+	UserIDs []int64
+}
+
+// Allows reports whether a secret with this scope may be sent to host on
+// behalf of userID, by the same rules http_request applies to stored
+// secrets.
+func (sc Scope) Allows(userID int64, host string) bool {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" || !slices.Contains(sc.UserIDs, userID) {
+		return false
+	}
+	for _, d := range normalizeDomains(sc.Domains) {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+type entry struct {
+	value string
+	scope Scope
+}
+
+// ValidName reports whether name can be stored and referenced.
+func ValidName(name string) bool { return validName.MatchString(name) }
+
+// Service encrypts secrets into a SecretsStore and keeps the decrypted
+// values in memory for synchronous lookups while building providers and
+// tool clients.
+type Service struct {
+	store persistence.SecretsStore
+	aead  cipher.AEAD
+
+	mu     sync.RWMutex
+	values map[string]entry
+}
+
+// NewService returns a service sealing values with masterKey, a
+// base64-encoded 32-byte AES key.
+func NewService(store persistence.SecretsStore, masterKey string) (*Service, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(masterKey))
+	if err != nil {
+		return nil, fmt.Errorf("secrets: master key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets: master key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{store: store, aead: aead, values: map[string]entry{}}, nil
+}
+
+// aad binds the name so a sealed value cannot be copied to another secret.
+func aad(name string) []byte { return []byte("manifold-secret|" + name) }
+
+func (s *Service) seal(name, plaintext string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), aad(name))
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Service) open(name, value string) (string, error) {
+	payload, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return "", fmt.Errorf("secrets: %s is not sealed", name)
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("secrets: %s: %w", name, err)
+	}
+	n := s.aead.NonceSize()
+	if len(raw) < n {
+		return "", fmt.Errorf("secrets: %s: ciphertext too short", name)
+	}
+	pt, err := s.aead.Open(nil, raw[:n], raw[n:], aad(name))
+	if err != nil {
+		return "", fmt.Errorf("secrets: %s: %w", name, err)
+	}
+	return string(pt), nil
+}
+
+// Load decrypts every stored secret into memory. Secrets that cannot be
+// decrypted, e.g. after the master key changed, are reported together and
+// left unresolvable.
+func (s *Service) Load(ctx context.Context) error {
+	stored, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]entry, len(stored))
+	var errs []error
+	for _, sec := range stored {
+		pt, err := s.open(sec.Name, sec.Value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		values[sec.Name] = entry{value: pt, scope: Scope{Domains: sec.Domains, UserIDs: sec.UserIDs}}
+	}
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// List returns secret metadata ordered by name; values are never included.
+func (s *Service) List(ctx context.Context) ([]persistence.Secret, error) {
+	stored, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stored {
+		stored[i].Value = ""
+	}
+	return stored, nil
+}
+
+// Set creates name or rotates it to a new value and scope. The scope must
+// name at least one domain.
+func (s *Service) Set(ctx context.Context, name, value string, scope Scope) (persistence.Secret, error) {
+	if !ValidName(name) {
+		return persistence.Secret{}, ErrInvalidName
+	}
+	scope.Domains = normalizeDomains(scope.Domains)
+	if len(scope.Domains) == 0 {
+		return persistence.Secret{}, ErrNoDomains
+	}
+	sealed, err := s.seal(name, value)
+	if err != nil {
+		return persistence.Secret{}, err
+	}
+	sec, err := s.store.Put(ctx, persistence.Secret{Name: name, Value: sealed, Domains: scope.Domains, UserIDs: scope.UserIDs})
+	if err != nil {
+		return persistence.Secret{}, err
+	}
+	s.mu.Lock()
+	s.values[name] = entry{value: value, scope: scope}
+	s.mu.Unlock()
+	sec.Value = ""
+	return sec, nil
+}
+
+// Delete removes name. Deleting a missing secret returns
+// persistence.ErrNotFound.
+func (s *Service) Delete(ctx context.Context, name string) error {
+	if err := s.store.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.values, name)
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the plaintext of name.
+func (s *Service) Lookup(name string) (string, bool) {
+	v, _, ok := s.Scoped(name)
+	return v, ok
+}
+
+// Scoped returns the plaintext of name and the scope it was stored with.
+func (s *Service) Scoped(name string) (string, Scope, bool) {
+	if s == nil {
+		return "", Scope{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.values[name]
+	return e.value, e.scope, ok
+}
+
+func normalizeDomains(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, d := range in {
+		if d = strings.ToLower(strings.Trim(strings.TrimSpace(d), ".")); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// ResolveFor replaces {{secret:NAME}} references in v with the values of
+// secrets whose scope allows sending them to host on behalf of userID, the
+// owner of the config being resolved. Other references are left in place.
+func (s *Service) ResolveFor(v string, userID int64, host string) string {
+	if s == nil || !strings.Contains(v, "{{") {
+		return v
+	}
+	return Ref.ReplaceAllStringFunc(v, func(m string) string {
+		if val, scope, ok := s.Scoped(Ref.FindStringSubmatch(m)[1]); ok && scope.Allows(userID, host) {
+			return val
+		}
+		return m
+	})
+}
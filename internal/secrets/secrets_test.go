@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"manifold/internal/persistence"
+)
+
+// memStore is a minimal SecretsStore for tests.
+type memStore struct {
+	mu      sync.Mutex
+	secrets map[string]persistence.Secret
+}
+
+func (m *memStore) Init(context.Context) error { return nil }
+
+func (m *memStore) List(context.Context) ([]persistence.Secret, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []persistence.Secret
+	for _, s := range m.secrets {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b persistence.Secret) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (m *memStore) Get(_ context.Context, name string) (persistence.Secret, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.secrets[name]
+	return s, ok, nil
+}
+
+func (m *memStore) Put(_ context.Context, in persistence.Secret) (persistence.Secret, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.secrets[in.Name]
+	s.Name, s.Value, s.Domains, s.UserIDs, s.UpdatedAt = in.Name, in.Value, in.Domains, in.UserIDs, time.Now()
+	s.Version++
+	m.secrets[in.Name] = s
+	return s, nil
+}
+
+func (m *memStore) Delete(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[name]; !ok {
+		return persistence.ErrNotFound
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestServiceSealsAndResolves(t *testing.T) {
+	store := &memStore{secrets: map[string]persistence.Secret{}}
+	svc, err := NewService(store, testKey('k'))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	ctx := context.Background()
+	openai := Scope{Domains: []string{"api.openai.com"}}
+	if _, err := svc.Set(ctx, "OPENAI_KEY", "sk-one", openai); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	sec, err := svc.Set(ctx, "OPENAI_KEY", "sk-two", Scope{Domains: []string{" API.OpenAI.com. "}, UserIDs: []int64{7}})
+	if err != nil || sec.Version != 2 || sec.Value != "" {
+		t.Fatalf("rotate: %+v (%v)", sec, err)
+	}
+	if raw := store.secrets["OPENAI_KEY"].Value; strings.Contains(raw, "sk-two") || !strings.HasPrefix(raw, sealedPrefix) {
+		t.Fatalf("stored value is not sealed: %q", raw)
+	}
+	if _, err := svc.Set(ctx, "bad-name", "x", openai); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName, got %v", err)
+	}
+	if _, err := svc.Set(ctx, "UNBOUND", "x", Scope{Domains: []string{" "}}); !errors.Is(err, ErrNoDomains) {
+		t.Fatalf("expected ErrNoDomains, got %v", err)
+	}
+
+	// A fresh service with the same key reads what the first one wrote.
+	reloaded, _ := NewService(store, testKey('k'))
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got := reloaded.ResolveFor("Bearer {{secret:OPENAI_KEY}} {{ secret:MISSING }}", 7, "api.openai.com")
+	if got != "Bearer sk-two {{ secret:MISSING }}" {
+		t.Fatalf("ResolveFor = %q", got)
+	}
+	// Another user's config, or one pointed at another host, keeps the
+	// reference instead of receiving the value.
+	for _, tc := range []struct {
+		user int64
+		host string
+	}{{8, "api.openai.com"}, {7, "attacker.example"}, {7, "api.openai.com.attacker.example"}, {7, ""}} {
+		if got := reloaded.ResolveFor("{{secret:OPENAI_KEY}}", tc.user, tc.host); got != "{{secret:OPENAI_KEY}}" {
+			t.Fatalf("ResolveFor(user %d, %q) = %q", tc.user, tc.host, got)
+		}
+	}
+	if v, scope, ok := reloaded.Scoped("OPENAI_KEY"); !ok || v != "sk-two" || !slices.Equal(scope.Domains, []string{"api.openai.com"}) || !slices.Equal(scope.UserIDs, []int64{7}) {
+		t.Fatalf("Scoped = %q %+v %v", v, scope, ok)
+	}
+	list, _ := reloaded.List(ctx)
+	if len(list) != 1 || list[0].Value != "" {
+		t.Fatalf("List leaked values: %+v", list)
+	}
+
+	// Values sealed under one name do not open under another.
+	moved := store.secrets["OPENAI_KEY"]
+	moved.Name = "OTHER"
+	store.secrets["OTHER"] = moved
+	if err := reloaded.Load(ctx); err == nil {
+		t.Fatalf("expected an error for a value copied between names")
+	}
+	if _, ok := reloaded.Lookup("OTHER"); ok {
+		t.Fatalf("copied value should not resolve")
+	}
+
+	wrongKey, _ := NewService(store, testKey('x'))
+	if err := wrongKey.Load(ctx); err == nil {
+		t.Fatalf("expected Load to fail with the wrong master key")
+	}
+
+	if err := svc.Delete(ctx, "OPENAI_KEY"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := svc.Lookup("OPENAI_KEY"); ok {
+		t.Fatalf("deleted secret still resolves")
+	}
+	if err := svc.Delete(ctx, "OPENAI_KEY"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewServiceRejectsBadKeys(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := NewService(&memStore{}, key); err == nil {
+			t.Errorf("expected error for key %q", key)
+		}
+	}
+}
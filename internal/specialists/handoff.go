@@ -0,0 +1,107 @@
+package specialists
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+// ErrNoHandoffScope is returned by RequestHandoff when the run is not part of
+// a chat session that can be handed off.
+var ErrNoHandoffScope = errors.New("handoff is only available in chat sessions")
+
+type handoffScopeKey struct{}
+
+// handoffScope collects the handoff requested during one chat run.
+type handoffScope struct {
+	from  string
+	known func(name string) bool
+
+	mu        sync.Mutex
+	requested *persistence.Handoff
+}
+
+// WithHandoff scopes ctx to a chat run served by agent from (empty for the
+// orchestrator) so tools may request a handoff. known reports whether a
+// specialist may receive the session. The returned func yields the handoff
+// requested during the run, if any.
+func WithHandoff(ctx context.Context, from string, known func(name string) bool) (context.Context, func() (persistence.Handoff, bool)) {
+	if strings.TrimSpace(from) == "" {
+		from = OrchestratorName
+	}
+	s := &handoffScope{from: from, known: known}
+	return context.WithValue(ctx, handoffScopeKey{}, s), func() (persistence.Handoff, bool) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.requested == nil {
+			return persistence.Handoff{}, false
+		}
+		return *s.requested, true
+	}
+}
+
+// RequestHandoff asks for the chat session in ctx to pass to h.To once the
+// current turn finishes. h.From and h.At are filled in. A later request in
+// the same run replaces an earlier one.
+func RequestHandoff(ctx context.Context, h persistence.Handoff) (persistence.Handoff, error) {
+	s, _ := ctx.Value(handoffScopeKey{}).(*handoffScope)
+	if s == nil {
+		return persistence.Handoff{}, ErrNoHandoffScope
+	}
+	h.To = strings.TrimSpace(h.To)
+	switch {
+	case h.To == "":
+		return persistence.Handoff{}, errors.New("handoff target is required")
+	case isOrchestrator(h.To):
+		h.To = OrchestratorName
+	case s.known != nil && !s.known(h.To):
+		return persistence.Handoff{}, errors.New("unknown specialist: " + h.To)
+	}
+	if strings.EqualFold(h.To, s.from) {
+		return persistence.Handoff{}, errors.New("session is already with " + s.from)
+	}
+	h.From = s.from
+	h.At = time.Now().UTC()
+	s.mu.Lock()
+	s.requested = &h
+	s.mu.Unlock()
+	return h, nil
+}
+
+// HandoffMessage encodes h as a chat turn message for storage.
+func HandoffMessage(h persistence.Handoff) llm.Message {
+	b, _ := json.Marshal(h)
+	return llm.Message{Role: persistence.ChatRoleHandoff, Content: string(b)}
+}
+
+// ParseHandoff decodes a chat message stored by a handoff.
+func ParseHandoff(msg persistence.ChatMessage) (persistence.Handoff, bool) {
+	if msg.Role != persistence.ChatRoleHandoff {
+		return persistence.Handoff{}, false
+	}
+	var h persistence.Handoff
+	if err := json.Unmarshal([]byte(msg.Content), &h); err != nil || h.To == "" {
+		return persistence.Handoff{}, false
+	}
+	return h, true
+}
+
+// ActiveAgent returns the specialist holding a session according to its most
+// recent handoff, or "" when the orchestrator holds it.
+func ActiveAgent(msgs []persistence.ChatMessage) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if h, ok := ParseHandoff(msgs[i]); ok {
+			if isOrchestrator(h.To) {
+				return ""
+			}
+			return h.To
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package specialists
+
+import (
+	"testing"
+
+	"manifold/internal/persistence"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveAgent(t *testing.T) {
+	t.Parallel()
+
+	stored := func(h persistence.Handoff) persistence.ChatMessage {
+		m := HandoffMessage(h)
+		return persistence.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	msgs := []persistence.ChatMessage{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hello"},
+	}
+	require.Equal(t, "", ActiveAgent(msgs))
+
+	msgs = append(msgs, stored(persistence.Handoff{From: OrchestratorName, To: "coder", Summary: "s"}))
+	require.Equal(t, "coder", ActiveAgent(msgs))
+
+	h, ok := ParseHandoff(msgs[2])
+	require.True(t, ok)
+	require.Equal(t, "s", h.Summary)
+
+	msgs = append(msgs, persistence.ChatMessage{Role: "assistant", Content: "working"})
+	require.Equal(t, "coder", ActiveAgent(msgs))
+
+	msgs = append(msgs, stored(persistence.Handoff{From: "coder", To: OrchestratorName, Summary: "done"}))
+	require.Equal(t, "", ActiveAgent(msgs))
+
+	_, ok = ParseHandoff(persistence.ChatMessage{Role: persistence.ChatRoleHandoff, Content: "not json"})
+	require.False(t, ok)
+}
@@ -0,0 +1,246 @@
+package specialists
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/persistence"
+)
+
+// Health states reported for specialists.
+const (
+	HealthOnline   = "online"
+	HealthDegraded = "degraded"
+	HealthOffline  = "offline"
+)
+
+const (
+	defaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultGoogleBaseURL    = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+// HealthChecker probes each specialist's endpoint and records the outcome.
+// A probe lists the endpoint's models and, when the endpoint has no model
+// list or cfg.CompletionProbe is set, sends a one-word completion.
+type HealthChecker struct {
+	cfg    config.SpecialistHealthConfig
+	base   config.LLMClientConfig
+	specs  persistence.SpecialistsStore
+	health persistence.SpecialistHealthStore
+	client *http.Client
+	// users lists the users whose specialists are probed.
+	users func(ctx context.Context) ([]int64, error)
+	now   func() time.Time
+}
+
+// NewHealthChecker constructs a checker. users returns the user IDs whose
+// specialists are probed on each pass.
+func NewHealthChecker(cfg config.SpecialistHealthConfig, base config.LLMClientConfig, specs persistence.SpecialistsStore, health persistence.SpecialistHealthStore, client *http.Client, users func(ctx context.Context) ([]int64, error)) *HealthChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HealthChecker{cfg: cfg, base: base, specs: specs, health: health, client: client, users: users, now: time.Now}
+}
+
+// Start runs CheckAll immediately and then every interval until ctx is done.
+func (h *HealthChecker) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			if err := h.CheckAll(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("specialist_health_check_failed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// CheckAll probes every non-paused specialist of every user and records the
+// results.
+func (h *HealthChecker) CheckAll(ctx context.Context) error {
+	users, err := h.users(ctx)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	var errs []error
+	for _, userID := range users {
+		list, err := h.specs.List(ctx, userID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, s := range list {
+			if s.Paused || isOrchestrator(s.Name) {
+				continue
+			}
+			res := h.Probe(ctx, s)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			res.UserID = userID
+			if err := h.health.Record(ctx, res); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Probe checks one specialist's endpoint.
+func (h *HealthChecker) Probe(ctx context.Context, s persistence.Specialist) persistence.SpecialistHealth {
+	timeout := time.Duration(h.cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sc := ConfigsFromStore([]persistence.Specialist{s})[0]
+	provName := strings.TrimSpace(sc.Provider)
+	if provName == "" {
+		provName = h.base.Provider
+	}
+	res := persistence.SpecialistHealth{Name: s.Name}
+	start := h.now()
+	models, listed, err := h.listModels(ctx, provName, sc)
+	switch {
+	case err != nil:
+		res.State, res.Error = HealthOffline, err.Error()
+	case listed && sc.Model != "" && len(models) > 0 && !hasModel(models, sc.Model):
+		res.State, res.Error = HealthDegraded, fmt.Sprintf("model %q is not listed by the endpoint", sc.Model)
+	default:
+		res.State = HealthOnline
+	}
+	if err == nil && (!listed || h.cfg.CompletionProbe) {
+		if err := h.complete(ctx, provName, sc); err != nil {
+			res.State, res.Error = HealthOffline, err.Error()
+		}
+	}
+	latency := h.now().Sub(start)
+	res.LatencyMs = latency.Milliseconds()
+	res.CheckedAt = h.now().UTC()
+	if slow := time.Duration(h.cfg.DegradedLatencyMs) * time.Millisecond; res.State == HealthOnline && slow > 0 && latency > slow {
+		res.State, res.Error = HealthDegraded, fmt.Sprintf("probe took %s", latency.Round(time.Millisecond))
+	}
+	return res
+}
+
+// listModels fetches the endpoint's model IDs. listed is false when the
+// endpoint does not offer a model list.
+func (h *HealthChecker) listModels(ctx context.Context, provName string, sc config.SpecialistConfig) (models []string, listed bool, err error) {
+	req, err := h.modelsRequest(ctx, provName, sc)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+		return nil, false, nil
+	case resp.StatusCode/100 != 2:
+		return nil, false, fmt.Errorf("model list returned %s", resp.Status)
+	}
+	var body struct {
+		Data   []struct{ ID string }   `json:"data"`
+		Models []struct{ Name string } `json:"models"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&body); err != nil {
+		return nil, true, nil
+	}
+	for _, m := range body.Data {
+		models = append(models, m.ID)
+	}
+	for _, m := range body.Models {
+		models = append(models, strings.TrimPrefix(m.Name, "models/"))
+	}
+	return models, true, nil
+}
+
+func (h *HealthChecker) modelsRequest(ctx context.Context, provName string, sc config.SpecialistConfig) (*http.Request, error) {
+	baseURL := strings.TrimSpace(sc.BaseURL)
+	var apiKey, endpoint string
+	header := http.Header{}
+	switch strings.ToLower(provName) {
+	case "anthropic":
+		baseURL = firstNonEmpty(baseURL, h.base.Anthropic.BaseURL, defaultAnthropicBaseURL)
+		apiKey = firstNonEmpty(resolveAPIKey(sc.APIKey, sc.OwnerID, baseURL), h.base.Anthropic.APIKey)
+		endpoint = strings.TrimRight(baseURL, "/")
+		if !strings.HasSuffix(endpoint, "/v1") {
+			endpoint += "/v1"
+		}
+		endpoint += "/models"
+		header.Set("x-api-key", apiKey)
+		header.Set("anthropic-version", "2023-06-01")
+	case "google":
+		baseURL = firstNonEmpty(baseURL, h.base.Google.BaseURL, defaultGoogleBaseURL)
+		apiKey = firstNonEmpty(resolveAPIKey(sc.APIKey, sc.OwnerID, baseURL), h.base.Google.APIKey)
+		endpoint = strings.TrimRight(baseURL, "/") + "/models"
+		header.Set("x-goog-api-key", apiKey)
+	default:
+		baseURL = firstNonEmpty(baseURL, h.base.OpenAI.BaseURL)
+		apiKey = firstNonEmpty(resolveAPIKey(sc.APIKey, sc.OwnerID, baseURL), h.base.OpenAI.APIKey)
+		endpoint = strings.TrimRight(baseURL, "/") + "/models"
+		if apiKey != "" {
+			header.Set("Authorization", "Bearer "+apiKey)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	for k, v := range sc.ExtraHeaders {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// complete sends a minimal chat request through the specialist's provider.
+func (h *HealthChecker) complete(ctx context.Context, provName string, sc config.SpecialistConfig) error {
+	prov, model := buildProvider(provName, h.base, sc, h.client)
+	if prov == nil || model == "" {
+		return errors.New("specialist has no usable provider or model")
+	}
+	_, err := prov.Chat(ctx, []llm.Message{{Role: "user", Content: "Reply with OK."}}, nil, model)
+	if err != nil {
+		return fmt.Errorf("completion probe: %w", err)
+	}
+	return nil
+}
+
+func hasModel(models []string, model string) bool {
+	for _, m := range models {
+		if strings.EqualFold(m, model) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package specialists
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHealthStore struct {
+	records map[string]persistence.SpecialistHealth
+}
+
+func (s *recordingHealthStore) Init(context.Context) error { return nil }
+
+func (s *recordingHealthStore) Record(_ context.Context, h persistence.SpecialistHealth) error {
+	s.records[h.Name] = h
+	return nil
+}
+
+func (s *recordingHealthStore) List(context.Context, int64) ([]persistence.SpecialistHealth, error) {
+	return nil, nil
+}
+
+func TestHealthCheckerCheckAll(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok/models", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer key-1", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data":[{"id":"m1"},{"id":"m2"}]}`))
+	})
+	mux.HandleFunc("/down/models", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	specs := &stubSpecialistsStore{list: []persistence.Specialist{
+		{Name: OrchestratorName, BaseURL: srv.URL + "/down"},
+		{Name: "alpha", Provider: "openai", BaseURL: srv.URL + "/ok", APIKey: "key-1", Model: "m1"},
+		{Name: "beta", Provider: "openai", BaseURL: srv.URL + "/ok", APIKey: "key-1", Model: "missing"},
+		{Name: "gamma", Provider: "openai", BaseURL: srv.URL + "/down", Model: "m1"},
+		{Name: "paused", Provider: "openai", BaseURL: srv.URL + "/down", Model: "m1", Paused: true},
+	}}
+	health := &recordingHealthStore{records: map[string]persistence.SpecialistHealth{}}
+	users := func(context.Context) ([]int64, error) { return []int64{7}, nil }
+	checker := NewHealthChecker(config.SpecialistHealthConfig{TimeoutSeconds: 5, DegradedLatencyMs: 3000}, config.LLMClientConfig{}, specs, health, srv.Client(), users)
+
+	require.NoError(t, checker.CheckAll(context.Background()))
+	require.Len(t, health.records, 3)
+	require.Equal(t, HealthOnline, health.records["alpha"].State)
+	require.Equal(t, int64(7), health.records["alpha"].UserID)
+	require.False(t, health.records["alpha"].CheckedAt.IsZero())
+	require.Equal(t, HealthDegraded, health.records["beta"].State)
+	require.Contains(t, health.records["beta"].Error, "not listed")
+	require.Equal(t, HealthOffline, health.records["gamma"].State)
+	require.Contains(t, health.records["gamma"].Error, "502")
+}
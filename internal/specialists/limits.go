@@ -0,0 +1,184 @@
+package specialists
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+
+	"manifold/internal/config"
+)
+
+const defaultQueueTimeout = 30 * time.Second
+
+// Reasons a request is rejected by a specialist's limiter.
+const (
+	RejectQueueFull    = "queue_full"
+	RejectQueueTimeout = "queue_timeout"
+)
+
+// CapacityError is returned when a specialist has no free request slot.
+type CapacityError struct {
+	Specialist string
+	Reason     string
+}
+
+func (e *CapacityError) Error() string {
+	if e.Reason == RejectQueueTimeout {
+		return fmt.Sprintf("specialist %s is busy: timed out waiting for a free slot", e.Specialist)
+	}
+	return fmt.Sprintf("specialist %s is at capacity", e.Specialist)
+}
+
+// StatusCode reports the HTTP status matching the error.
+func (e *CapacityError) StatusCode() int { return http.StatusServiceUnavailable }
+
+// LimitStats is a point-in-time view of one specialist's limiter.
+type LimitStats struct {
+	Specialist    string `json:"specialist"`
+	MaxConcurrent int    `json:"maxConcurrent"`
+	InFlight      int    `json:"inFlight"`
+	Queued        int    `json:"queued"`
+	Rejected      int64  `json:"rejected"`
+}
+
+var (
+	limitMetricsOnce sync.Once
+	rejectedCounter  otelmetric.Int64Counter
+	queueWaitHist    otelmetric.Int64Histogram
+)
+
+func ensureLimitInstruments() {
+	limitMetricsOnce.Do(func() {
+		m := otel.Meter("internal/specialists")
+		rejectedCounter, _ = m.Int64Counter("specialists.requests.rejected", otelmetric.WithDescription("Specialist requests rejected because the specialist was at capacity"))
+		queueWaitHist, _ = m.Int64Histogram("specialists.queue.wait.ms", otelmetric.WithDescription("Time specialist requests waited for a free slot"))
+	})
+}
+
+// Limits hands out the concurrency limiters for specialists. One Limits is
+// shared by every registry so limits hold across per-user and per-team
+// registries and survive registry rebuilds.
+type Limits struct {
+	cfg config.SpecialistLimitsConfig
+
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+// NewLimits constructs Limits from cfg.
+func NewLimits(cfg config.SpecialistLimitsConfig) *Limits {
+	return &Limits{cfg: cfg, limiters: map[string]*limiter{}}
+}
+
+// forSpecialist returns the limiter for sc, or nil when it is unlimited.
+// Specialists sharing a name but served by different endpoints or models
+// get separate limiters.
+func (l *Limits) forSpecialist(sc config.SpecialistConfig, model string) *limiter {
+	if l == nil {
+		return nil
+	}
+	lim := l.cfg.Default
+	if override, ok := l.cfg.Specialists[sc.Name]; ok {
+		lim = override
+	}
+	if lim.MaxConcurrent <= 0 {
+		return nil
+	}
+	key := sc.Name + "|" + sc.BaseURL + "|" + model
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if existing, ok := l.limiters[key]; ok && existing.limit == lim {
+		return existing
+	}
+	nl := newLimiter(sc.Name, lim)
+	l.limiters[key] = nl
+	return nl
+}
+
+// limiter bounds in-flight requests to one specialist, letting a bounded
+// number of further requests wait for a slot.
+type limiter struct {
+	name     string
+	limit    config.SpecialistLimit
+	slots    chan struct{}
+	timeout  time.Duration
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+func newLimiter(name string, lim config.SpecialistLimit) *limiter {
+	timeout := time.Duration(lim.QueueTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultQueueTimeout
+	}
+	return &limiter{name: name, limit: lim, slots: make(chan struct{}, lim.MaxConcurrent), timeout: timeout}
+}
+
+// acquire takes a slot and returns its release func. It fails with a
+// *CapacityError when the queue is full or the wait times out, or with ctx's
+// error if ctx ends first. A nil limiter imposes no limit.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return l.releaseFunc(), nil
+	default:
+	}
+	if l.queued.Add(1) > int64(l.limit.QueueDepth) {
+		l.queued.Add(-1)
+		return nil, l.reject(ctx, RejectQueueFull)
+	}
+	defer l.queued.Add(-1)
+	ensureLimitInstruments()
+	start := time.Now()
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		queueWaitHist.Record(ctx, time.Since(start).Milliseconds(), otelmetric.WithAttributes(attribute.String("specialist", l.name)))
+		return l.releaseFunc(), nil
+	case <-timer.C:
+		return nil, l.reject(ctx, RejectQueueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *limiter) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(func() { <-l.slots }) }
+}
+
+func (l *limiter) reject(ctx context.Context, reason string) error {
+	l.rejected.Add(1)
+	ensureLimitInstruments()
+	rejectedCounter.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("specialist", l.name), attribute.String("reason", reason)))
+	return &CapacityError{Specialist: l.name, Reason: reason}
+}
+
+// LimitStats reports the specialist's concurrency limiter, if it has one.
+func (a *Agent) LimitStats() (LimitStats, bool) {
+	if a.limiter == nil {
+		return LimitStats{}, false
+	}
+	return a.limiter.stats(), true
+}
+
+func (l *limiter) stats() LimitStats {
+	return LimitStats{
+		Specialist:    l.name,
+		MaxConcurrent: l.limit.MaxConcurrent,
+		InFlight:      len(l.slots),
+		Queued:        int(l.queued.Load()),
+		Rejected:      l.rejected.Load(),
+	}
+}
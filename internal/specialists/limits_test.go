@@ -0,0 +1,92 @@
+package specialists
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterQueuesThenRejects(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter("slow", config.SpecialistLimit{MaxConcurrent: 1, QueueDepth: 1})
+	l.timeout = 50 * time.Millisecond
+	ctx := context.Background()
+
+	release, err := l.acquire(ctx)
+	require.NoError(t, err)
+
+	// The queued request gets the slot once it is released.
+	got := make(chan error, 1)
+	go func() {
+		r, err := l.acquire(ctx)
+		if err == nil {
+			r()
+		}
+		got <- err
+	}()
+	require.Eventually(t, func() bool { return l.stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	// The queue is full, so a third request is turned away at once.
+	_, err = l.acquire(ctx)
+	var capErr *CapacityError
+	require.True(t, errors.As(err, &capErr))
+	require.Equal(t, RejectQueueFull, capErr.Reason)
+	require.Equal(t, 503, capErr.StatusCode())
+
+	release()
+	release() // releasing twice must not free a second slot
+	require.NoError(t, <-got)
+
+	// A queued request that never gets a slot times out.
+	release, err = l.acquire(ctx)
+	require.NoError(t, err)
+	_, err = l.acquire(ctx)
+	require.True(t, errors.As(err, &capErr))
+	require.Equal(t, RejectQueueTimeout, capErr.Reason)
+	release()
+
+	st := l.stats()
+	require.Equal(t, 0, st.InFlight)
+	require.Equal(t, 0, st.Queued)
+	require.Equal(t, int64(2), st.Rejected)
+}
+
+func TestRegistryLimitsSurviveRebuild(t *testing.T) {
+	t.Parallel()
+
+	specs := []config.SpecialistConfig{
+		{Name: "slow", Model: "m", BaseURL: "http://localhost:1"},
+		{Name: "fast", Model: "m", BaseURL: "http://localhost:2"},
+	}
+	limits := NewLimits(config.SpecialistLimitsConfig{
+		Specialists: map[string]config.SpecialistLimit{"slow": {MaxConcurrent: 1}},
+	})
+	reg := NewRegistry(config.LLMClientConfig{Provider: "openai"}, specs, nil, nil)
+	reg.SetLimits(limits)
+
+	slow, ok := reg.Get("slow")
+	require.True(t, ok)
+	release, err := slow.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	fast, ok := reg.Get("fast")
+	require.True(t, ok)
+	_, limited := fast.LimitStats()
+	require.False(t, limited)
+
+	// Rebuilding the registry keeps the in-flight count.
+	reg.SetWorkdir("/tmp/elsewhere")
+	slow, _ = reg.Get("slow")
+	st, ok := slow.LimitStats()
+	require.True(t, ok)
+	require.Equal(t, 1, st.InFlight)
+	_, err = slow.Acquire(context.Background())
+	require.Error(t, err)
+}
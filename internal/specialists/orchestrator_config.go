@@ -26,7 +26,7 @@ func ApplyLLMClientOverride(base config.LLMClientConfig, sp persistence.Speciali
 			cfg.Anthropic.BaseURL = strings.TrimSpace(sp.BaseURL)
 		}
 		if strings.TrimSpace(sp.APIKey) != "" {
-			cfg.Anthropic.APIKey = strings.TrimSpace(sp.APIKey)
+			cfg.Anthropic.APIKey = resolveAPIKey(sp.APIKey, sp.UserID, firstNonEmpty(cfg.Anthropic.BaseURL, defaultAnthropicBaseURL))
 		}
 		if strings.TrimSpace(sp.Model) != "" {
 			cfg.Anthropic.Model = strings.TrimSpace(sp.Model)
@@ -39,7 +39,7 @@ func ApplyLLMClientOverride(base config.LLMClientConfig, sp persistence.Speciali
 			cfg.Google.BaseURL = strings.TrimSpace(sp.BaseURL)
 		}
 		if strings.TrimSpace(sp.APIKey) != "" {
-			cfg.Google.APIKey = strings.TrimSpace(sp.APIKey)
+			cfg.Google.APIKey = resolveAPIKey(sp.APIKey, sp.UserID, firstNonEmpty(cfg.Google.BaseURL, defaultGoogleBaseURL))
 		}
 		if strings.TrimSpace(sp.Model) != "" {
 			cfg.Google.Model = strings.TrimSpace(sp.Model)
@@ -52,7 +52,7 @@ func ApplyLLMClientOverride(base config.LLMClientConfig, sp persistence.Speciali
 			cfg.OpenAI.BaseURL = strings.TrimSpace(sp.BaseURL)
 		}
 		if strings.TrimSpace(sp.APIKey) != "" {
-			cfg.OpenAI.APIKey = strings.TrimSpace(sp.APIKey)
+			cfg.OpenAI.APIKey = resolveAPIKey(sp.APIKey, sp.UserID, firstNonEmpty(cfg.OpenAI.BaseURL, defaultOpenAIBaseURL))
 		}
 		if strings.TrimSpace(sp.Model) != "" {
 			cfg.OpenAI.Model = strings.TrimSpace(sp.Model)
@@ -134,3 +134,23 @@ func boolPtr(value bool) *bool {
 	v := value
 	return &v
 }
+
+func TestApplyLLMClientOverrideScopesSecrets(t *testing.T) {
+	SetSecretResolver(func(v string, userID int64, host string) string {
+		if userID == 7 && host == "api.openai.com" {
+			return "sk-real"
+		}
+		return v
+	})
+	defer SetSecretResolver(nil)
+
+	own, _ := ApplyLLMClientOverride(config.LLMClientConfig{}, persistence.Specialist{UserID: 7, APIKey: "{{secret:KEY}}"})
+	require.Equal(t, "sk-real", own.OpenAI.APIKey)
+
+	// Another user's specialist, or one pointed at another host, keeps the
+	// reference.
+	other, _ := ApplyLLMClientOverride(config.LLMClientConfig{}, persistence.Specialist{UserID: 8, APIKey: "{{secret:KEY}}"})
+	require.Equal(t, "{{secret:KEY}}", other.OpenAI.APIKey)
+	moved, _ := ApplyLLMClientOverride(config.LLMClientConfig{}, persistence.Specialist{UserID: 7, BaseURL: "https://attacker.example/v1", APIKey: "{{secret:KEY}}"})
+	require.Equal(t, "{{secret:KEY}}", moved.OpenAI.APIKey)
+}
@@ -15,8 +15,10 @@ import (
 	"manifold/internal/llm/anthropic"
 	"manifold/internal/llm/google"
 	openaillm "manifold/internal/llm/openai"
+	"manifold/internal/sandbox"
 	"manifold/internal/tools"
 	tooldiscovery "manifold/internal/tools/discovery"
+	"manifold/internal/tools/tts"
 )
 
 // Agent represents a configured specialist bound to a specific endpoint/model.
@@ -30,12 +32,20 @@ type Agent struct {
 	EnableTools                bool
 	AutoDiscover               bool
 	ReasoningEffort            string // optional: "low"|"medium"|"high"
+	ExecBackend                string // optional: "host"|"container"
+	Voice                      string // optional: text_to_speech voice
 	ExtraParams                map[string]any
 
-	provider llm.Provider
-	tools    tools.Registry
+	provider      llm.Provider
+	tools         tools.Registry
+	resolvePrompt PromptResolver
+	limiter       *limiter
 }
 
+// PromptResolver expands references to externally hosted prompts, such as
+// MCP prompt URIs, in a system prompt before it is sent.
+type PromptResolver func(ctx context.Context, system string) string
+
 type chatWithOptionsProvider interface {
 	ChatWithOptions(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, extra map[string]any) (llm.Message, error)
 }
@@ -53,6 +63,8 @@ type Registry struct {
 	toolIndex            *tooldiscovery.ToolIndex
 	autoDiscover         bool
 	maxDiscovered        int
+	promptResolver       PromptResolver
+	limits               *Limits
 }
 
 // NewRegistry builds a registry from config.SpecialistConfig entries.
@@ -98,6 +110,26 @@ func (r *Registry) SetToolDiscovery(index *tooldiscovery.ToolIndex, autoDiscover
 	r.rebuildLocked()
 }
 
+// SetPromptResolver installs the resolver specialists apply to their system
+// prompt on every request.
+func (r *Registry) SetPromptResolver(fn PromptResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promptResolver = fn
+	for _, a := range r.agents {
+		a.resolvePrompt = fn
+	}
+}
+
+// SetLimits installs the concurrency limits applied to the registry's
+// specialists. A nil Limits removes them.
+func (r *Registry) SetLimits(limits *Limits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = limits
+	r.rebuildLocked()
+}
+
 func buildProvider(provider string, base config.LLMClientConfig, sc config.SpecialistConfig, httpClient *http.Client) (llm.Provider, string) {
 	hc := httpClient
 	if len(sc.ExtraHeaders) > 0 {
@@ -118,7 +150,7 @@ func buildProvider(provider string, base config.LLMClientConfig, sc config.Speci
 			cfg.BaseURL = strings.TrimSpace(sc.BaseURL)
 		}
 		if strings.TrimSpace(sc.APIKey) != "" {
-			cfg.APIKey = strings.TrimSpace(sc.APIKey)
+			cfg.APIKey = resolveAPIKey(sc.APIKey, sc.OwnerID, firstNonEmpty(cfg.BaseURL, defaultGoogleBaseURL))
 		}
 		if strings.TrimSpace(sc.Model) != "" {
 			cfg.Model = strings.TrimSpace(sc.Model)
@@ -137,7 +169,7 @@ func buildProvider(provider string, base config.LLMClientConfig, sc config.Speci
 			cfg.BaseURL = strings.TrimSpace(sc.BaseURL)
 		}
 		if strings.TrimSpace(sc.APIKey) != "" {
-			cfg.APIKey = strings.TrimSpace(sc.APIKey)
+			cfg.APIKey = resolveAPIKey(sc.APIKey, sc.OwnerID, firstNonEmpty(cfg.BaseURL, defaultAnthropicBaseURL))
 		}
 		if strings.TrimSpace(sc.Model) != "" {
 			cfg.Model = strings.TrimSpace(sc.Model)
@@ -160,7 +192,7 @@ func buildProvider(provider string, base config.LLMClientConfig, sc config.Speci
 			oc.BaseURL = strings.TrimSpace(sc.BaseURL)
 		}
 		if strings.TrimSpace(sc.APIKey) != "" {
-			oc.APIKey = strings.TrimSpace(sc.APIKey)
+			oc.APIKey = resolveAPIKey(sc.APIKey, sc.OwnerID, firstNonEmpty(oc.BaseURL, defaultOpenAIBaseURL))
 		}
 		if strings.TrimSpace(sc.Model) != "" {
 			oc.Model = strings.TrimSpace(sc.Model)
@@ -224,6 +256,18 @@ func (r *Registry) rebuildLocked() {
 		} else {
 			toolsView = nil
 		}
+		execBackend := strings.TrimSpace(sc.ExecBackend)
+		if execBackend != "" && toolsView != nil {
+			toolsView = tools.NewContextRegistry(toolsView, func(ctx context.Context) context.Context {
+				return sandbox.WithExecBackend(ctx, execBackend)
+			})
+		}
+		voice := strings.TrimSpace(sc.Voice)
+		if voice != "" && toolsView != nil {
+			toolsView = tools.NewContextRegistry(toolsView, func(ctx context.Context) context.Context {
+				return tts.WithVoice(ctx, voice)
+			})
+		}
 
 		// Prepend default system prompt to specialist's configured system prompt
 		// This ensures specialists get tool usage rules, memory instructions, etc.
@@ -245,9 +289,13 @@ func (r *Registry) rebuildLocked() {
 			EnableTools:                sc.EnableTools,
 			AutoDiscover:               resolvedAutoDiscover,
 			ReasoningEffort:            strings.TrimSpace(sc.ReasoningEffort),
+			ExecBackend:                execBackend,
+			Voice:                      voice,
 			ExtraParams:                sc.ExtraParams,
 			provider:                   prov,
 			tools:                      toolsView,
+			resolvePrompt:              r.promptResolver,
+			limiter:                    r.limits.forSpecialist(sc, model),
 		}
 		if a.Name != "" {
 			agents[a.Name] = a
@@ -336,6 +384,13 @@ func (a *Agent) Provider() llm.Provider { return a.provider }
 // ToolsRegistry returns the filtered tool registry view for this specialist, or nil when tools are disabled.
 func (a *Agent) ToolsRegistry() tools.Registry { return a.tools }
 
+// Acquire reserves one of the specialist's request slots, waiting in its
+// queue when all are taken. It fails with a *CapacityError when the
+// specialist is saturated. Callers must call the returned release func.
+func (a *Agent) Acquire(ctx context.Context) (func(), error) {
+	return a.limiter.acquire(ctx)
+}
+
 // Inference performs a single-turn completion with optional history.
 // If tools are disabled, no tool schema is sent at all.
 // If ReasoningEffort is set, a provider-specific reasoning block is attached.
@@ -343,7 +398,12 @@ func (a *Agent) Inference(ctx context.Context, user string, history []llm.Messag
 	if a.provider == nil {
 		return "", errors.New("provider not configured")
 	}
-	msgs := a.buildMessages(history, user)
+	release, err := a.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	msgs := a.buildMessages(ctx, history, user)
 
 	// Extra fields for the request: start with configured extra params
 	extra := a.mergedExtraParams()
@@ -393,15 +453,24 @@ func (a *Agent) Stream(ctx context.Context, user string, history []llm.Message,
 	if a.provider == nil {
 		return errors.New("provider not configured")
 	}
-	msgs := a.buildMessages(history, user)
+	release, err := a.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	msgs := a.buildMessages(ctx, history, user)
 	// Streaming path intentionally skips tool schemas to avoid executing tools
 	// mid-stream. This keeps the UX similar to a plain chat completion.
 	return a.provider.ChatStream(ctx, msgs, nil, a.Model, handler)
 }
 
-func (a *Agent) buildMessages(history []llm.Message, user string) []llm.Message {
+func (a *Agent) buildMessages(ctx context.Context, history []llm.Message, user string) []llm.Message {
 	msgs := make([]llm.Message, 0, len(history)+2)
-	if sys := strings.TrimSpace(a.System); sys != "" {
+	sys := a.System
+	if a.resolvePrompt != nil {
+		sys = a.resolvePrompt(ctx, sys)
+	}
+	if sys := strings.TrimSpace(sys); sys != "" {
 		msgs = append(msgs, llm.Message{Role: "system", Content: sys})
 	}
 	msgs = append(msgs, history...)
@@ -164,3 +164,27 @@ func TestLocalSpecialistIgnoresResponsesAPIOverride(t *testing.T) {
 		}
 	}
 }
+
+func TestPromptResolverAppliesToSystemMessage(t *testing.T) {
+	t.Parallel()
+
+	base := config.LLMClientConfig{Provider: "openai", OpenAI: config.OpenAIConfig{APIKey: "basekey", Model: "basemodel"}}
+	r := NewRegistry(base, []config.SpecialistConfig{{Name: "alpha", Model: "m", System: "mcp://docs/prompts/style"}}, http.DefaultClient, nil)
+	r.SetPromptResolver(func(_ context.Context, system string) string {
+		return strings.ReplaceAll(system, "mcp://docs/prompts/style", "Write tersely.")
+	})
+
+	// Rebuilds keep the resolver.
+	r.SetWorkdir("workspace")
+	a, _ := r.Get("alpha")
+	msgs := a.buildMessages(context.Background(), nil, "hi")
+	if len(msgs) != 2 || msgs[0].Role != "system" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+	if !strings.Contains(msgs[0].Content, "Write tersely.") || strings.Contains(msgs[0].Content, "mcp://") {
+		t.Fatalf("expected resolved system prompt, got %q", msgs[0].Content)
+	}
+	if !strings.Contains(a.System, "mcp://docs/prompts/style") {
+		t.Fatalf("expected the stored system prompt to keep the reference, got %q", a.System)
+	}
+}
@@ -0,0 +1,278 @@
+package specialists
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+// Routing methods recorded on a Decision.
+const (
+	RouteByRule       = "rule"
+	RouteByEmbedding  = "embedding"
+	RouteByClassifier = "classifier"
+	RouteFallback     = "fallback"
+)
+
+const classifierPrompt = `Pick the specialist best suited to handle the user's request.
+
+Specialists:
+%s
+User request:
+%s
+
+Reply with JSON only: {"specialist": "<name or none>", "confidence": <0 to 1>}. Use "none" when no specialist clearly fits.`
+
+// Candidate is a specialist the router may select.
+type Candidate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Decision records how a prompt was routed. An empty Specialist means the
+// prompt stays with the orchestrator.
+type Decision struct {
+	At         time.Time `json:"at"`
+	Prompt     string    `json:"prompt"`
+	Specialist string    `json:"specialist"`
+	Method     string    `json:"method"`
+	Confidence float64   `json:"confidence"`
+	// RunnerUp and RunnerUpScore describe the second-best embedding match.
+	RunnerUp      string  `json:"runnerUp,omitempty"`
+	RunnerUpScore float64 `json:"runnerUpScore,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// EmbedFunc embeds texts, one vector per input.
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Router picks a specialist for a prompt. Static rules win outright; the
+// configured mode then matches the prompt against specialist descriptions
+// by embedding similarity or by asking a classifier model. Matches below the
+// confidence threshold fall back to the orchestrator.
+type Router struct {
+	cfg        config.SpecialistRoutingConfig
+	rules      []config.SpecialistRoute
+	embed      EmbedFunc
+	classifier llm.Provider
+	record     func(Decision)
+
+	mu    sync.Mutex
+	cache map[string][]float32
+}
+
+// NewRouter constructs a router. embed is required for the embedding mode
+// and classifier for the classifier mode; record, when set, receives every
+// decision.
+func NewRouter(cfg config.SpecialistRoutingConfig, rules []config.SpecialistRoute, embed EmbedFunc, classifier llm.Provider, record func(Decision)) *Router {
+	return &Router{cfg: cfg, rules: rules, embed: embed, classifier: classifier, record: record, cache: map[string][]float32{}}
+}
+
+// Route selects a specialist from candidates for text.
+func (r *Router) Route(ctx context.Context, text string, candidates []Candidate) Decision {
+	d := r.decide(ctx, text, candidates)
+	d.At = time.Now().UTC()
+	d.Prompt = text
+	if d.Specialist == "" && d.Method == "" {
+		d.Method = RouteFallback
+	}
+	log.Debug().Str("specialist", d.Specialist).Str("method", d.Method).Float64("confidence", d.Confidence).Str("error", d.Error).Msg("specialist_route")
+	if r.record != nil {
+		r.record(d)
+	}
+	return d
+}
+
+func (r *Router) decide(ctx context.Context, text string, candidates []Candidate) Decision {
+	if name := Route(r.rules, text); name != "" {
+		return Decision{Specialist: name, Method: RouteByRule, Confidence: 1}
+	}
+	if strings.TrimSpace(text) == "" || len(candidates) == 0 {
+		return Decision{}
+	}
+	var d Decision
+	var err error
+	switch strings.ToLower(r.cfg.Mode) {
+	case RouteByEmbedding:
+		d, err = r.byEmbedding(ctx, text, candidates)
+	case RouteByClassifier:
+		d, err = r.byClassifier(ctx, text, candidates)
+	default:
+		return Decision{}
+	}
+	if err != nil {
+		return Decision{Method: RouteFallback, Error: err.Error()}
+	}
+	if d.Specialist == "" || d.Confidence < r.cfg.MinConfidence {
+		d.Specialist, d.Method = "", RouteFallback
+	}
+	return d
+}
+
+func (r *Router) byEmbedding(ctx context.Context, text string, candidates []Candidate) (Decision, error) {
+	if r.embed == nil {
+		return Decision{}, fmt.Errorf("embedding routing has no embedder")
+	}
+	descs, err := r.descriptionVectors(ctx, candidates)
+	if err != nil {
+		return Decision{}, err
+	}
+	vecs, err := r.embed(ctx, []string{text})
+	if err != nil {
+		return Decision{}, fmt.Errorf("embed prompt: %w", err)
+	}
+	if len(vecs) != 1 {
+		return Decision{}, fmt.Errorf("embed prompt: got %d vectors", len(vecs))
+	}
+	best, second := -1, -1
+	scores := make([]float64, len(candidates))
+	for i := range candidates {
+		scores[i] = cosine(vecs[0], descs[i])
+		switch {
+		case best < 0 || scores[i] > scores[best]:
+			best, second = i, best
+		case second < 0 || scores[i] > scores[second]:
+			second = i
+		}
+	}
+	d := Decision{Specialist: candidates[best].Name, Method: RouteByEmbedding, Confidence: scores[best]}
+	if second >= 0 {
+		d.RunnerUp, d.RunnerUpScore = candidates[second].Name, scores[second]
+		// Two descriptions matching about equally well is not a decision.
+		if scores[best]-scores[second] < r.cfg.MinMargin {
+			d.Specialist = ""
+		}
+	}
+	return d, nil
+}
+
+// descriptionVectors embeds candidate descriptions, caching them by text.
+func (r *Router) descriptionVectors(ctx context.Context, candidates []Candidate) ([][]float32, error) {
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = c.Name + ": " + c.Description
+	}
+	r.mu.Lock()
+	var missing []string
+	for _, t := range texts {
+		if _, ok := r.cache[t]; !ok {
+			missing = append(missing, t)
+		}
+	}
+	r.mu.Unlock()
+	if len(missing) > 0 {
+		vecs, err := r.embed(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("embed descriptions: %w", err)
+		}
+		if len(vecs) != len(missing) {
+			return nil, fmt.Errorf("embed descriptions: got %d vectors for %d texts", len(vecs), len(missing))
+		}
+		r.mu.Lock()
+		for i, t := range missing {
+			r.cache[t] = vecs[i]
+		}
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = r.cache[t]
+	}
+	return out, nil
+}
+
+func (r *Router) byClassifier(ctx context.Context, text string, candidates []Candidate) (Decision, error) {
+	if r.classifier == nil {
+		return Decision{}, fmt.Errorf("classifier routing has no provider")
+	}
+	var list strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&list, "- %s: %s\n", c.Name, strings.TrimSpace(c.Description))
+	}
+	msg, err := r.classifier.Chat(ctx, []llm.Message{{Role: "user", Content: fmt.Sprintf(classifierPrompt, list.String(), text)}}, nil, r.cfg.ClassifierModel)
+	if err != nil {
+		return Decision{}, fmt.Errorf("classifier: %w", err)
+	}
+	var reply struct {
+		Specialist string  `json:"specialist"`
+		Confidence float64 `json:"confidence"`
+	}
+	content := msg.Content
+	if i, j := strings.Index(content, "{"), strings.LastIndex(content, "}"); i >= 0 && j > i {
+		content = content[i : j+1]
+	}
+	if err := json.Unmarshal([]byte(content), &reply); err != nil {
+		return Decision{}, fmt.Errorf("classifier reply: %w", err)
+	}
+	d := Decision{Method: RouteByClassifier, Confidence: reply.Confidence}
+	for _, c := range candidates {
+		if strings.EqualFold(c.Name, strings.TrimSpace(reply.Specialist)) {
+			d.Specialist = c.Name
+		}
+	}
+	return d, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// NewDecisionLog returns a recorder that appends decisions as JSON lines to
+// path, for offline evaluation of routing quality.
+func NewDecisionLog(path string) (func(Decision), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(f)
+	return func(d Decision) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(d); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("specialist_route_log_failed")
+		}
+	}, nil
+}
+
+// Candidates lists the registry's specialists with their descriptions.
+func (r *Registry) Candidates() []Candidate {
+	names := r.Names()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Candidate, 0, len(names))
+	for _, name := range names {
+		if a, ok := r.agents[name]; ok {
+			out = append(out, Candidate{Name: name, Description: a.Description})
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package specialists
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+
+	"github.com/stretchr/testify/require"
+)
+
+var routingCandidates = []Candidate{
+	{Name: "coder", Description: "writes code"},
+	{Name: "writer", Description: "drafts prose"},
+}
+
+// keywordEmbed maps texts onto two axes: code and prose.
+func keywordEmbed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := []float32{0.1, 0.1}
+		if strings.Contains(t, "code") {
+			v[0] = 1
+		}
+		if strings.Contains(t, "prose") || strings.Contains(t, "essay") {
+			v[1] = 1
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type replyProvider struct{ reply string }
+
+func (p replyProvider) Chat(context.Context, []llm.Message, []llm.ToolSchema, string) (llm.Message, error) {
+	return llm.Message{Role: "assistant", Content: p.reply}, nil
+}
+
+func (p replyProvider) ChatStream(context.Context, []llm.Message, []llm.ToolSchema, string, llm.StreamHandler) error {
+	return nil
+}
+
+func TestRouterEmbedding(t *testing.T) {
+	t.Parallel()
+
+	var decisions []Decision
+	cfg := config.SpecialistRoutingConfig{Mode: "embedding", MinConfidence: 0.5, MinMargin: 0.05}
+	rules := []config.SpecialistRoute{{Name: "writer", Contains: []string{"poem"}}}
+	r := NewRouter(cfg, rules, keywordEmbed, nil, func(d Decision) { decisions = append(decisions, d) })
+	ctx := context.Background()
+
+	d := r.Route(ctx, "fix this code", routingCandidates)
+	require.Equal(t, "coder", d.Specialist)
+	require.Equal(t, RouteByEmbedding, d.Method)
+	require.Equal(t, "writer", d.RunnerUp)
+
+	d = r.Route(ctx, "a poem about code", routingCandidates)
+	require.Equal(t, "writer", d.Specialist)
+	require.Equal(t, RouteByRule, d.Method)
+
+	d = r.Route(ctx, "what time is it", routingCandidates)
+	require.Empty(t, d.Specialist)
+	require.Equal(t, RouteFallback, d.Method)
+
+	require.Len(t, decisions, 3)
+	require.Equal(t, "what time is it", decisions[2].Prompt)
+}
+
+func TestRouterClassifier(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.SpecialistRoutingConfig{Mode: "classifier", MinConfidence: 0.5}
+	ctx := context.Background()
+
+	r := NewRouter(cfg, nil, nil, replyProvider{reply: "Sure: {\"specialist\": \"Writer\", \"confidence\": 0.9}"}, nil)
+	d := r.Route(ctx, "draft an essay", routingCandidates)
+	require.Equal(t, "writer", d.Specialist)
+	require.Equal(t, RouteByClassifier, d.Method)
+
+	r = NewRouter(cfg, nil, nil, replyProvider{reply: `{"specialist": "coder", "confidence": 0.2}`}, nil)
+	d = r.Route(ctx, "hmm", routingCandidates)
+	require.Empty(t, d.Specialist)
+	require.Equal(t, RouteFallback, d.Method)
+
+	r = NewRouter(cfg, nil, nil, replyProvider{reply: "no idea"}, nil)
+	d = r.Route(ctx, "hmm", routingCandidates)
+	require.Empty(t, d.Specialist)
+	require.NotEmpty(t, d.Error)
+}
+
+func TestDecisionLog(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "logs", "routes.jsonl")
+	record, err := NewDecisionLog(path)
+	require.NoError(t, err)
+	record(Decision{Prompt: "p1", Specialist: "coder", Method: RouteByRule, Confidence: 1})
+	record(Decision{Prompt: "p2", Method: RouteFallback})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"specialist":"coder"`)
+}
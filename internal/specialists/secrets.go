@@ -0,0 +1,39 @@
+package specialists
+
+import (
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+var secretResolver atomic.Pointer[func(v string, userID int64, host string) string]
+
+// SetSecretResolver installs fn to expand {{secret:NAME}} references in
+// specialist API keys, so stored specialists can hold a reference instead of
+// the key. fn receives the specialist's owner and the host the key is sent
+// to, and must only expand secrets scoped to both. A nil fn removes it.
+func SetSecretResolver(fn func(v string, userID int64, host string) string) {
+	if fn == nil {
+		secretResolver.Store(nil)
+		return
+	}
+	secretResolver.Store(&fn)
+}
+
+// resolveAPIKey trims key and expands the secret references in it that owner
+// may send to the host of baseURL.
+func resolveAPIKey(key string, owner int64, baseURL string) string {
+	key = strings.TrimSpace(key)
+	if fn := secretResolver.Load(); fn != nil && key != "" {
+		return (*fn)(key, owner, urlHost(baseURL))
+	}
+	return key
+}
+
+func urlHost(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -34,9 +34,12 @@ func ConfigsFromStore(list []persistence.Specialist) []config.SpecialistConfig {
 			Paused:                     s.Paused,
 			AllowTools:                 s.AllowTools,
 			ReasoningEffort:            s.ReasoningEffort,
+			ExecBackend:                s.ExecBackend,
+			Voice:                      s.Voice,
 			System:                     s.System,
 			ExtraHeaders:               s.ExtraHeaders,
 			ExtraParams:                s.ExtraParams,
+			OwnerID:                    s.UserID,
 		})
 	}
 	return out
@@ -104,6 +107,8 @@ func SeedStore(ctx context.Context, store persistence.SpecialistsStore, userID i
 			Paused:                     sc.Paused,
 			AllowTools:                 sc.AllowTools,
 			ReasoningEffort:            sc.ReasoningEffort,
+			ExecBackend:                sc.ExecBackend,
+			Voice:                      sc.Voice,
 			System:                     sc.System,
 			ExtraHeaders:               sc.ExtraHeaders,
 			ExtraParams:                sc.ExtraParams,
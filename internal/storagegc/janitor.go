@@ -0,0 +1,179 @@
+// Package storagegc removes stale files from the agentd workdir: truncated
+// command output, playground artifacts and temp files left by interrupted
+// project uploads. Without it these directories grow without bound.
+package storagegc
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/projects"
+)
+
+// Rule removes regular files under Root that Match accepts once they are
+// older than MaxAge.
+type Rule struct {
+	Name   string
+	Root   string
+	MaxAge time.Duration
+	// Match filters by base name; nil accepts every file.
+	Match func(name string) bool
+	// PruneDirs also removes directories the sweep left empty.
+	PruneDirs bool
+}
+
+// Report summarises one rule's sweep.
+type Report struct {
+	Rule  string   `json:"rule"`
+	Paths []string `json:"paths"`
+	Bytes int64    `json:"bytes"`
+	// DryRun is set when Paths were only reported, not removed.
+	DryRun bool `json:"dryRun"`
+}
+
+// Rules returns the retention rules for workdir.
+func Rules(workdir string, cfg config.StorageGCConfig) []Rule {
+	hours := func(h int) time.Duration { return time.Duration(h) * time.Hour }
+	return []Rule{
+		{
+			Name:   "cli_artifacts",
+			Root:   filepath.Join(workdir, "cli-artifacts"),
+			MaxAge: hours(cfg.CLIArtifactsHours),
+			Match:  func(name string) bool { return strings.HasSuffix(name, ".log") },
+		},
+		{
+			Name:      "playground_artifacts",
+			Root:      filepath.Join(workdir, "playground-artifacts"),
+			MaxAge:    hours(cfg.PlaygroundArtifactsHours),
+			PruneDirs: true,
+		},
+		{
+			Name:   "upload_temp",
+			Root:   filepath.Join(workdir, "users"),
+			MaxAge: hours(cfg.UploadTempHours),
+			Match:  projects.IsUploadTemp,
+		},
+	}
+}
+
+// Sweep applies each rule as of now. With dryRun set nothing is removed and
+// the reports list what would be.
+func Sweep(ctx context.Context, rules []Rule, now time.Time, dryRun bool) ([]Report, error) {
+	reports := make([]Report, 0, len(rules))
+	for _, r := range rules {
+		rep, err := sweep(ctx, r, now, dryRun)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, rep)
+	}
+	return reports, nil
+}
+
+func sweep(ctx context.Context, r Rule, now time.Time, dryRun bool) (Report, error) {
+	rep := Report{Rule: r.Name, Paths: []string{}, DryRun: dryRun}
+	if r.MaxAge <= 0 || r.Root == "" {
+		return rep, nil
+	}
+	cutoff := now.Add(-r.MaxAge)
+	var dirs []string
+	err := filepath.WalkDir(r.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A missing root just means nothing has been written yet.
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != r.Root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (r.Match != nil && !r.Match(d.Name())) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(p); err != nil {
+				log.Warn().Err(err).Str("path", p).Msg("storage_gc_remove_failed")
+				return nil
+			}
+		}
+		rep.Paths = append(rep.Paths, p)
+		rep.Bytes += info.Size()
+		return nil
+	})
+	if err != nil || dryRun || !r.PruneDirs || len(rep.Paths) == 0 {
+		return rep, err
+	}
+	// Deepest first so parents empty out after their children. Only
+	// directories that held removed files are candidates, and Remove fails
+	// on any that still have entries.
+	slices.Reverse(dirs)
+	for _, dir := range dirs {
+		if holdsAny(dir, rep.Paths) {
+			_ = os.Remove(dir)
+		}
+	}
+	return rep, nil
+}
+
+func holdsAny(dir string, paths []string) bool {
+	prefix := dir + string(filepath.Separator)
+	for _, p := range paths {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start runs Sweep immediately and then every interval until ctx is done,
+// logging what each pass removed.
+func Start(ctx context.Context, rules []Rule, interval time.Duration, dryRun bool) {
+	if interval <= 0 {
+		return
+	}
+	run := func() {
+		reports, err := Sweep(ctx, rules, time.Now(), dryRun)
+		if err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("storage_gc_failed")
+		}
+		for _, rep := range reports {
+			if len(rep.Paths) == 0 {
+				continue
+			}
+			ev := log.Info().Str("rule", rep.Rule).Int("files", len(rep.Paths)).Int64("bytes", rep.Bytes).Bool("dry_run", rep.DryRun)
+			if rep.DryRun {
+				ev = ev.Strs("paths", rep.Paths)
+			}
+			ev.Msg("storage_gc_swept")
+		}
+	}
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
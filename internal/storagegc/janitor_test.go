@@ -0,0 +1,73 @@
+package storagegc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+)
+
+func writeAged(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mt := time.Now().Add(-age)
+	if err := os.Chtimes(path, mt, mt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSweep(t *testing.T) {
+	workdir := t.TempDir()
+	day := 24 * time.Hour
+	oldLog := filepath.Join(workdir, "cli-artifacts", "1", "aaaa-stdout.log")
+	newLog := filepath.Join(workdir, "cli-artifacts", "1", "bbbb-stdout.log")
+	oldRun := filepath.Join(workdir, "playground-artifacts", "run-1", "response.json")
+	newRun := filepath.Join(workdir, "playground-artifacts", "run-2", "response.json")
+	project := filepath.Join(workdir, "users", "1", "projects", "p1")
+	oldTemp := filepath.Join(project, ".a.txt.upload-123")
+	oldFile := filepath.Join(project, "a.txt")
+	writeAged(t, oldLog, 10*day)
+	writeAged(t, newLog, time.Hour)
+	writeAged(t, oldRun, 40*day)
+	writeAged(t, newRun, day)
+	writeAged(t, oldTemp, 2*day)
+	writeAged(t, oldFile, 400*day)
+
+	rules := Rules(workdir, config.StorageGCConfig{CLIArtifactsHours: 168, PlaygroundArtifactsHours: 720, UploadTempHours: 24})
+
+	reports, err := Sweep(context.Background(), rules, time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rep := range reports {
+		if len(rep.Paths) != 1 || !rep.DryRun {
+			t.Fatalf("unexpected dry-run report %+v", rep)
+		}
+	}
+	if !exists(oldLog) || !exists(oldRun) || !exists(oldTemp) {
+		t.Fatal("dry run removed files")
+	}
+
+	if _, err := Sweep(context.Background(), rules, time.Now(), false); err != nil {
+		t.Fatal(err)
+	}
+	if exists(oldLog) || exists(oldTemp) || exists(filepath.Dir(oldRun)) {
+		t.Fatal("stale files were not removed")
+	}
+	if !exists(newLog) || !exists(newRun) || !exists(oldFile) {
+		t.Fatal("files within retention or outside the rules were removed")
+	}
+}
@@ -0,0 +1,135 @@
+// Package stt holds the transcript model shared by the /stt endpoint and the
+// transcribe_audio tool.
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SpeakerTurn is the token whisper.cpp's tinydiarize models emit where the
+// speaker changes.
+const SpeakerTurn = "[SPEAKER_TURN]"
+
+// Options selects what a transcription returns.
+type Options struct {
+	// Timestamps returns per-segment start and end times.
+	Timestamps bool
+	// Diarize labels each segment with its speaker. Servers that return
+	// speakers themselves are used as is; otherwise the labels come from
+	// tinydiarize speaker-turn markers.
+	Diarize bool
+	// Language is an ISO-639-1 hint such as "en". Empty lets the server detect it.
+	Language string
+}
+
+// Verbose reports whether the server must be asked for segments.
+func (o Options) Verbose() bool { return o.Timestamps || o.Diarize }
+
+// Segment is one timed stretch of speech. Start and End are in seconds.
+type Segment struct {
+	ID      int     `json:"id"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+// Transcript is a transcription with optional segments. Speakers lists the
+// speaker labels in order of first appearance.
+type Transcript struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language,omitempty"`
+	Duration float64   `json:"duration,omitempty"`
+	Speakers []string  `json:"speakers,omitempty"`
+	Segments []Segment `json:"segments,omitempty"`
+}
+
+// Transcriber turns audio into a transcript.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio []byte, opts Options) (Transcript, error)
+}
+
+// ParseResponse decodes an OpenAI-compatible transcription response, either
+// {"text": ...} or verbose_json with segments. Segments are kept only when
+// opts asks for them.
+func ParseResponse(body []byte, opts Options) (Transcript, error) {
+	var raw struct {
+		Text     string  `json:"text"`
+		Language string  `json:"language"`
+		Duration float64 `json:"duration"`
+		Segments []struct {
+			ID      int     `json:"id"`
+			Start   float64 `json:"start"`
+			End     float64 `json:"end"`
+			Text    string  `json:"text"`
+			Speaker string  `json:"speaker"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Transcript{}, fmt.Errorf("stt: decode response: %w", err)
+	}
+	out := Transcript{
+		Text:     cleanText(raw.Text),
+		Language: raw.Language,
+		Duration: raw.Duration,
+	}
+	if !opts.Verbose() || len(raw.Segments) == 0 {
+		return out, nil
+	}
+
+	serverSpeakers := false
+	for _, s := range raw.Segments {
+		if strings.TrimSpace(s.Speaker) != "" {
+			serverSpeakers = true
+			break
+		}
+	}
+	turn := 0
+	for i, s := range raw.Segments {
+		seg := Segment{ID: s.ID, Start: s.Start, End: s.End, Text: cleanText(s.Text)}
+		if i > 0 && seg.ID == 0 {
+			seg.ID = i
+		}
+		if opts.Diarize {
+			if serverSpeakers {
+				seg.Speaker = strings.TrimSpace(s.Speaker)
+			} else {
+				// tinydiarize marks turns, not identities, so labels
+				// alternate between two speakers.
+				seg.Speaker = fmt.Sprintf("SPEAKER_%d", turn%2+1)
+				if strings.Contains(s.Text, SpeakerTurn) {
+					turn++
+				}
+			}
+			if seg.Speaker != "" && !containsString(out.Speakers, seg.Speaker) {
+				out.Speakers = append(out.Speakers, seg.Speaker)
+			}
+		}
+		if seg.Text == "" && seg.End <= seg.Start {
+			continue
+		}
+		out.Segments = append(out.Segments, seg)
+	}
+	if out.Duration == 0 && len(out.Segments) > 0 {
+		out.Duration = out.Segments[len(out.Segments)-1].End
+	}
+	return out, nil
+}
+
+// cleanText drops speaker-turn markers and collapses the whitespace they
+// leave behind.
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, SpeakerTurn, " ")), " ")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
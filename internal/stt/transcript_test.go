@@ -0,0 +1,60 @@
+package stt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const verboseTinydiarize = `{
+  "text": " Shall we start? [SPEAKER_TURN] Yes, budget first.",
+  "language": "en",
+  "segments": [
+    {"id": 0, "start": 0.0, "end": 1.4, "text": " Shall we start? [SPEAKER_TURN]"},
+    {"id": 1, "start": 1.6, "end": 3.2, "text": " Yes, budget first. [SPEAKER_TURN]"},
+    {"id": 2, "start": 3.5, "end": 5.0, "text": " Agreed."}
+  ]
+}`
+
+func TestParseResponseTinydiarize(t *testing.T) {
+	t.Parallel()
+
+	tr, err := ParseResponse([]byte(verboseTinydiarize), Options{Timestamps: true, Diarize: true})
+	require.NoError(t, err)
+	require.Equal(t, "Shall we start? Yes, budget first.", tr.Text)
+	require.Equal(t, []string{"SPEAKER_1", "SPEAKER_2"}, tr.Speakers)
+	require.Len(t, tr.Segments, 3)
+	require.Equal(t, Segment{ID: 1, Start: 1.6, End: 3.2, Text: "Yes, budget first.", Speaker: "SPEAKER_2"}, tr.Segments[1])
+	require.Equal(t, "SPEAKER_1", tr.Segments[2].Speaker)
+	require.Equal(t, 5.0, tr.Duration)
+}
+
+func TestParseResponsePrefersServerSpeakers(t *testing.T) {
+	t.Parallel()
+
+	body := `{"text":"hi there","duration":2,"segments":[
+	  {"id":0,"start":0,"end":1,"text":"hi","speaker":"alice"},
+	  {"id":1,"start":1,"end":2,"text":"there","speaker":"bob"}]}`
+	tr, err := ParseResponse([]byte(body), Options{Diarize: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "bob"}, tr.Speakers)
+	require.Equal(t, "bob", tr.Segments[1].Speaker)
+}
+
+func TestParseResponseTextOnly(t *testing.T) {
+	t.Parallel()
+
+	tr, err := ParseResponse([]byte(verboseTinydiarize), Options{})
+	require.NoError(t, err)
+	require.Equal(t, "Shall we start? Yes, budget first.", tr.Text)
+	require.Empty(t, tr.Segments)
+	require.Empty(t, tr.Speakers)
+
+	tr, err = ParseResponse([]byte(verboseTinydiarize), Options{Timestamps: true})
+	require.NoError(t, err)
+	require.Len(t, tr.Segments, 3)
+	require.Empty(t, tr.Segments[0].Speaker)
+
+	_, err = ParseResponse([]byte("not json"), Options{})
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"time"
 
 	"manifold/internal/llm"
 )
@@ -36,6 +37,55 @@ func (f *FakeProvider) ChatStream(ctx context.Context, msgs []llm.Message, tools
 	return nil
 }
 
+// ToolLoopProvider simulates a model that calls ToolName once per turn and
+// then answers, sleeping Latency before each response. It is stateless and
+// safe for concurrent use, which makes it suitable for benchmarks and load
+// generation. An empty ToolName answers immediately.
+type ToolLoopProvider struct {
+	ToolName string
+	ToolArgs string
+	Answer   string
+	Latency  time.Duration
+}
+
+func (p *ToolLoopProvider) Chat(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string) (llm.Message, error) {
+	if p.Latency > 0 {
+		t := time.NewTimer(p.Latency)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return llm.Message{}, ctx.Err()
+		case <-t.C:
+		}
+	}
+	if p.ToolName != "" && (len(msgs) == 0 || msgs[len(msgs)-1].Role != "tool") {
+		args := p.ToolArgs
+		if args == "" {
+			args = "{}"
+		}
+		return llm.Message{
+			Role:      "assistant",
+			ToolCalls: []llm.ToolCall{{Name: p.ToolName, Args: []byte(args), ID: "call_1"}},
+		}, nil
+	}
+	return llm.Message{Role: "assistant", Content: p.Answer}, nil
+}
+
+func (p *ToolLoopProvider) ChatStream(ctx context.Context, msgs []llm.Message, tools []llm.ToolSchema, model string, h llm.StreamHandler) error {
+	msg, err := p.Chat(ctx, msgs, tools, model)
+	if err != nil {
+		return err
+	}
+	if len(msg.ToolCalls) > 0 {
+		for _, tc := range msg.ToolCalls {
+			h.OnToolCall(tc)
+		}
+		return nil
+	}
+	h.OnDelta(msg.Content)
+	return nil
+}
+
 // NewTestServer returns an httptest.Server for the given handler func.
 func NewTestServer(handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(handler))
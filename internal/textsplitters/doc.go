@@ -21,6 +21,8 @@
 //     Diagram: fn a(){...} | class C{...}
 //   - Semantic breakpoints
 //     Diagram: ... high sim ... | low sim | ...
+//     Similarity comes from lexical overlap, or from embedding vectors when
+//     SemanticConfig.Embedder is set.
 //   - TextTiling-style lexical segmentation
 //   - Rolling n-sentence windows
 //   - Layout-aware pages/tables (heuristic)
@@ -1,16 +1,35 @@
 package textsplitters
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"slices"
 	"strings"
 )
 
+// Embedder turns sentences into vectors. It is satisfied by
+// embeddings.Provider.
+type Embedder interface {
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
 // SemanticConfig configures semantic/lexical segmentation.
 type SemanticConfig struct {
 	// Window number of sentences for local similarity context (>=1)
 	Window int
 	// Threshold below which we consider a boundary (0..1). Lower -> more splits.
+	// With an Embedder, 0 selects breakpoints by BreakpointPercentile instead,
+	// since absolute similarity levels vary by embedding model.
 	Threshold float64
+	// BreakpointPercentile places boundaries at the lowest N percent of
+	// adjacent similarities when Threshold is 0 and an Embedder is set.
+	// Defaults to 10.
+	BreakpointPercentile float64
+	// Embedder, when set, computes similarity from embedding vectors instead
+	// of lexical overlap. If embedding fails, Split falls back to the lexical
+	// heuristic; SplitContext returns the error.
+	Embedder Embedder
 	// After determining boundaries, group by BoundaryConfig (e.g., sentences with target size)
 	Within BoundaryConfig
 }
@@ -47,64 +66,134 @@ func cosine(a, b map[string]float64) float64 {
 	return dot / (math.Sqrt(na) * math.Sqrt(nb))
 }
 
+func cosineDense(a, b []float32) float64 {
+	n := min(len(a), len(b))
+	var dot, na, nb float64
+	for i := range n {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
 type semanticSplitter struct{ cfg SemanticConfig }
 
 func newSemanticSplitter(cfg SemanticConfig) (Splitter, error) {
+	if cfg.BreakpointPercentile < 0 || cfg.BreakpointPercentile >= 100 {
+		return nil, fmt.Errorf("semantic: breakpoint percentile must be in [0, 100), got %v", cfg.BreakpointPercentile)
+	}
 	return &semanticSplitter{cfg: cfg}, nil
 }
 
 func (s *semanticSplitter) Split(text string) []string {
+	if s.cfg.Embedder != nil {
+		if out, err := s.SplitContext(context.Background(), text); err == nil {
+			return out
+		}
+	}
 	ss := sentencesOf(text)
 	if len(ss) == 0 {
 		return nil
 	}
-	w := s.cfg.Window
-	if w <= 0 {
-		w = 1
+	vecs := make([]map[string]float64, len(ss))
+	for i, s := range ss {
+		vecs[i] = sentVec(s)
 	}
 	thr := s.cfg.Threshold
 	if thr <= 0 {
 		thr = 0.15
 	}
-	// compute pairwise similarity between adjacent sentences using simple cos
-	vecs := make([]map[string]float64, len(ss))
-	for i, s := range ss {
-		vecs[i] = sentVec(s)
+	sims := windowSimilarities(len(ss), s.window(), func(i, j int) float64 { return cosine(vecs[i], vecs[j]) })
+	return s.group(ss, sims, thr)
+}
+
+// SplitContext computes breakpoints from embedding similarity between
+// adjacent sentences. Without an Embedder it behaves like Split.
+func (s *semanticSplitter) SplitContext(ctx context.Context, text string) ([]string, error) {
+	if s.cfg.Embedder == nil {
+		return s.Split(text), nil
 	}
-	// boundaries: where similarity dips below threshold
-	var segments []string
-	var cur []string
-	sim := func(i int, j int) float64 { return cosine(vecs[i], vecs[j]) }
-	push := func() {
-		if len(cur) == 0 {
-			return
-		}
-		segments = append(segments, strings.Join(cur, " "))
-		cur = cur[:0]
+	ss := sentencesOf(text)
+	if len(ss) == 0 {
+		return nil, nil
 	}
-	cur = append(cur, ss[0])
-	for i := 1; i < len(ss); i++ {
-		// measure similarity to previous w sentences (average)
-		start := i - w
-		if start < 0 {
-			start = 0
+	vecs, err := s.cfg.Embedder.Embed(ctx, ss)
+	if err != nil {
+		return nil, fmt.Errorf("semantic: embed sentences: %w", err)
+	}
+	if len(vecs) != len(ss) {
+		return nil, fmt.Errorf("semantic: got %d embeddings for %d sentences", len(vecs), len(ss))
+	}
+	sims := windowSimilarities(len(ss), s.window(), func(i, j int) float64 { return cosineDense(vecs[i], vecs[j]) })
+	thr := s.cfg.Threshold
+	if thr <= 0 {
+		p := s.cfg.BreakpointPercentile
+		if p <= 0 {
+			p = 10
 		}
+		thr = percentileCutoff(sims, p)
+	}
+	return s.group(ss, sims, thr), nil
+}
+
+func (s *semanticSplitter) window() int {
+	if s.cfg.Window <= 0 {
+		return 1
+	}
+	return s.cfg.Window
+}
+
+// windowSimilarities returns, for each sentence i >= 1, the average
+// similarity to the previous w sentences. Index 0 is unused.
+func windowSimilarities(n, w int, sim func(i, j int) float64) []float64 {
+	out := make([]float64, n)
+	for i := 1; i < n; i++ {
+		start := max(0, i-w)
 		var total float64
-		var cnt int
 		for k := start; k < i; k++ {
 			total += sim(k, i)
-			cnt++
-		}
-		avg := 0.0
-		if cnt > 0 {
-			avg = total / float64(cnt)
 		}
-		if avg < thr {
-			push()
+		out[i] = total / float64(i-start)
+	}
+	return out
+}
+
+// percentileCutoff returns a threshold such that similarities strictly below
+// it fall in the lowest p percent.
+func percentileCutoff(sims []float64, p float64) float64 {
+	if len(sims) < 2 {
+		return 0
+	}
+	sorted := slices.Clone(sims[1:])
+	slices.Sort(sorted)
+	k := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if k <= 0 {
+		return 0
+	}
+	if k >= len(sorted) {
+		return math.Inf(1)
+	}
+	return sorted[k]
+}
+
+// group starts a new segment wherever similarity dips below thr, then groups
+// segments to the target size.
+func (s *semanticSplitter) group(ss []string, sims []float64, thr float64) []string {
+	var segments []string
+	cur := []string{ss[0]}
+	for i := 1; i < len(ss); i++ {
+		if sims[i] < thr {
+			segments = append(segments, strings.Join(cur, " "))
+			cur = cur[:0]
 		}
 		cur = append(cur, ss[i])
 	}
-	push()
+	segments = append(segments, strings.Join(cur, " "))
 
 	// Now group segments using target size
 	if s.cfg.Within.Size > 0 {
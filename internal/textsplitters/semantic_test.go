@@ -0,0 +1,161 @@
+package textsplitters
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// topicVocab gives each synthetic topic a vocabulary. Sentences within a
+// topic draw different words, so adjacent sentences share little lexical
+// overlap while remaining on-topic.
+var topicVocab = [][]string{
+	{"volcano", "magma", "eruption", "lava", "crater", "ash", "tectonic", "basalt"},
+	{"invoice", "ledger", "payment", "refund", "balance", "audit", "receipt", "tax"},
+	{"striker", "goalkeeper", "penalty", "midfield", "referee", "stadium", "league", "tackle"},
+	{"enzyme", "protein", "membrane", "receptor", "cell", "genome", "mitosis", "ribosome"},
+}
+
+// topicEmbedder maps each word to its topic dimension, standing in for an
+// embedding model that captures meaning beyond shared tokens.
+type topicEmbedder struct{ calls int }
+
+func (e *topicEmbedder) Embed(_ context.Context, inputs []string) ([][]float32, error) {
+	e.calls++
+	out := make([][]float32, len(inputs))
+	for i, in := range inputs {
+		v := make([]float32, len(topicVocab))
+		for _, w := range strings.Fields(strings.ToLower(strings.Trim(in, "."))) {
+			for t, vocab := range topicVocab {
+				for _, tw := range vocab {
+					if w == tw {
+						v[t]++
+					}
+				}
+			}
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(context.Context, []string) ([][]float32, error) {
+	return nil, errors.New("embedding service down")
+}
+
+// topicDocument returns sentencesPerTopic sentences per topic and the
+// sentence indexes where a new topic starts.
+func topicDocument(sentencesPerTopic int) (string, []int) {
+	var sb strings.Builder
+	var boundaries []int
+	n := 0
+	for t, vocab := range topicVocab {
+		if t > 0 {
+			boundaries = append(boundaries, n)
+		}
+		for s := range sentencesPerTopic {
+			a, b := vocab[(2*s)%len(vocab)], vocab[(2*s+1)%len(vocab)]
+			sb.WriteString("the report covers " + a + " and " + b + " in the region. ")
+			n++
+		}
+	}
+	return sb.String(), boundaries
+}
+
+// predictedBoundaries returns the sentence indexes at which each chunk after
+// the first begins.
+func predictedBoundaries(chunks []string) []int {
+	var out []int
+	n := 0
+	for i, c := range chunks {
+		if i > 0 {
+			out = append(out, n)
+		}
+		n += len(sentencesOf(c))
+	}
+	return out
+}
+
+func boundaryF1(pred, truth []int) float64 {
+	if len(pred) == 0 || len(truth) == 0 {
+		return 0
+	}
+	want := map[int]bool{}
+	for _, b := range truth {
+		want[b] = true
+	}
+	hits := 0
+	for _, b := range pred {
+		if want[b] {
+			hits++
+		}
+	}
+	if hits == 0 {
+		return 0
+	}
+	p := float64(hits) / float64(len(pred))
+	r := float64(hits) / float64(len(truth))
+	return 2 * p * r / (p + r)
+}
+
+func TestSemanticEmbedderFindsTopicShifts(t *testing.T) {
+	t.Parallel()
+	doc, truth := topicDocument(4)
+	emb := &topicEmbedder{}
+	s, err := NewFromConfig(Config{Kind: KindSemantic, Semantic: SemanticConfig{Embedder: emb, Threshold: 0.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := SplitContext(context.Background(), s, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := predictedBoundaries(chunks); boundaryF1(got, truth) != 1 {
+		t.Fatalf("boundaries=%v want %v (chunks=%q)", got, truth, chunks)
+	}
+	if emb.calls != 1 {
+		t.Fatalf("expected sentences embedded in one call, got %d", emb.calls)
+	}
+}
+
+func TestSemanticPercentileBreakpoints(t *testing.T) {
+	t.Parallel()
+	doc, truth := topicDocument(5)
+	// 3 shifts among 19 adjacent pairs: the lowest 15% selects exactly them.
+	s, err := NewFromConfig(Config{Kind: KindSemantic, Semantic: SemanticConfig{Embedder: &topicEmbedder{}, BreakpointPercentile: 15}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := SplitContext(context.Background(), s, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := predictedBoundaries(chunks); boundaryF1(got, truth) != 1 {
+		t.Fatalf("boundaries=%v want %v", got, truth)
+	}
+}
+
+func TestSemanticEmbedderFailure(t *testing.T) {
+	t.Parallel()
+	doc, _ := topicDocument(2)
+	s, err := NewFromConfig(Config{Kind: KindSemantic, Semantic: SemanticConfig{Embedder: failingEmbedder{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SplitContext(context.Background(), s, doc); err == nil {
+		t.Fatal("expected SplitContext to surface the embedding error")
+	}
+	if chunks := s.Split(doc); len(chunks) == 0 {
+		t.Fatal("expected Split to fall back to lexical segmentation")
+	}
+}
+
+func TestSemanticRejectsBadPercentile(t *testing.T) {
+	t.Parallel()
+	if _, err := NewFromConfig(Config{Kind: KindSemantic, Semantic: SemanticConfig{BreakpointPercentile: 100}}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -1,8 +1,26 @@
 package textsplitters
 
+import "context"
+
 // Splitter splits text into chunks appropriate for RAG ingestion.
 // Implementations should be stateless or concurrency-safe after construction.
 type Splitter interface {
 	// Split yields non-empty chunks for the input text.
 	Split(text string) []string
 }
+
+// ContextSplitter is implemented by splitters that call external services,
+// such as the embedding-backed semantic splitter, and can report failures.
+type ContextSplitter interface {
+	Splitter
+	SplitContext(ctx context.Context, text string) ([]string, error)
+}
+
+// SplitContext splits text with s, honouring ctx and surfacing errors when s
+// implements ContextSplitter.
+func SplitContext(ctx context.Context, s Splitter, text string) ([]string, error) {
+	if cs, ok := s.(ContextSplitter); ok {
+		return cs.SplitContext(ctx, text)
+	}
+	return s.Split(text), nil
+}
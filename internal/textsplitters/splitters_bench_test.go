@@ -0,0 +1,94 @@
+package textsplitters
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// benchDocument builds a markdown document of roughly 60 KB with headings,
+// paragraphs, and varied sentence lengths.
+func benchDocument() string {
+	var b strings.Builder
+	for sec := range 20 {
+		b.WriteString("## Section ")
+		b.WriteString(strings.Repeat("I", sec%5+1))
+		b.WriteString("\n\n")
+		for para := range 6 {
+			for sent := range 8 {
+				b.WriteString("The quick brown fox jumps over the lazy dog while the agent plans step ")
+				b.WriteString(strings.Repeat("x", (para+sent)%7))
+				b.WriteString(". ")
+			}
+			b.WriteString("\n\n")
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkSplitters(b *testing.B) {
+	doc := benchDocument()
+	cases := []struct {
+		name string
+		cfg  Config
+	}{
+		{"fixed", Config{Kind: KindFixed, Fixed: FixedConfig{Unit: UnitChars, Size: 1000, Overlap: 100}}},
+		{"sentences", Config{Kind: KindSentences, Boundary: BoundaryConfig{Unit: UnitChars, Size: 1000}}},
+		{"paragraphs", Config{Kind: KindParagraphs, Boundary: BoundaryConfig{Unit: UnitChars, Size: 1000}}},
+		{"markdown", Config{Kind: KindMarkdown, Markdown: MarkdownConfig{Within: BoundaryConfig{Unit: UnitChars, Size: 1000}}}},
+		{"semantic", Config{Kind: KindSemantic, Semantic: SemanticConfig{Window: 2, Threshold: 0.3, Within: BoundaryConfig{Unit: UnitChars, Size: 1000}}}},
+		{"recursive", Config{Kind: KindRecursive, Recursive: RecursiveConfig{
+			Markdown:   MarkdownConfig{Within: BoundaryConfig{Unit: UnitChars, Size: 1000}},
+			Paragraphs: BoundaryConfig{Unit: UnitChars, Size: 1000},
+			Sentences:  BoundaryConfig{Unit: UnitChars, Size: 1000},
+			Fallback:   FixedConfig{Unit: UnitChars, Size: 1000},
+		}}},
+	}
+	for _, tc := range cases {
+		s, err := NewFromConfig(tc.cfg)
+		if err != nil {
+			b.Fatalf("%s: %v", tc.name, err)
+		}
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(doc)))
+			for b.Loop() {
+				if chunks := s.Split(doc); len(chunks) == 0 {
+					b.Fatal("no chunks")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSemanticQuality compares lexical and embedding-backed breakpoints
+// on a document with known topic shifts, reporting boundary F1 alongside
+// speed. Sentences within a topic share few words, which is where lexical
+// similarity struggles.
+func BenchmarkSemanticQuality(b *testing.B) {
+	doc, truth := topicDocument(6)
+	cases := []struct {
+		name string
+		cfg  SemanticConfig
+	}{
+		{"lexical", SemanticConfig{Window: 2}},
+		{"embedding", SemanticConfig{Window: 2, Embedder: &topicEmbedder{}, BreakpointPercentile: 15}},
+	}
+	for _, tc := range cases {
+		s, err := NewFromConfig(Config{Kind: KindSemantic, Semantic: tc.cfg})
+		if err != nil {
+			b.Fatalf("%s: %v", tc.name, err)
+		}
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var chunks []string
+			for b.Loop() {
+				if chunks, err = SplitContext(context.Background(), s, doc); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(boundaryF1(predictedBoundaries(chunks), truth), "boundary_f1")
+			b.ReportMetric(float64(len(chunks)), "chunks")
+		})
+	}
+}
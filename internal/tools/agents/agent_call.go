@@ -3,6 +3,7 @@ package agents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -158,7 +159,12 @@ func (t *AgentCallTool) Call(ctx context.Context, raw json.RawMessage) (any, err
 			observability.LoggerWithTrace(ctx).Info().Str("agent_call", name).Msg("agent_call_specialist_infer")
 			out, err := a.Inference(dispatchCtx, args.Prompt, args.History)
 			if err != nil {
-				return map[string]any{"ok": false, "agent": name, "error": err.Error()}, nil
+				res := map[string]any{"ok": false, "agent": name, "error": err.Error()}
+				var capErr *specialists.CapacityError
+				if errors.As(err, &capErr) {
+					res["status"] = capErr.StatusCode()
+				}
+				return res, nil
 			}
 			return map[string]any{"ok": true, "agent": name, "output": out}, nil
 		}
@@ -13,6 +13,7 @@ import (
 	"manifold/internal/sandbox"
 	"manifold/internal/specialists"
 	"manifold/internal/tools"
+	"manifold/internal/tools/resultcache"
 	"manifold/internal/workspaces"
 )
 
@@ -30,6 +31,7 @@ type Delegator struct {
 	reMemLLM       llm.Provider
 	reMemModel     string
 	reMemMaxSteps  int
+	toolCache      *resultcache.Cache
 }
 
 func NewDelegator(reg tools.Registry, specReg *specialists.Registry, wsMgr workspaces.WorkspaceManager, defaultMaxSteps int) *Delegator {
@@ -53,6 +55,11 @@ func (d *Delegator) SetEvolvingMemory(em *memory.EvolvingMemory) {
 	d.evolvingMemory = em
 }
 
+// SetToolCache shares the tool-result cache with delegated agent runs.
+func (d *Delegator) SetToolCache(c *resultcache.Cache) {
+	d.toolCache = c
+}
+
 func (d *Delegator) ConfigureReMem(provider llm.Provider, model string, maxInnerSteps int) {
 	d.reMemLLM = provider
 	d.reMemModel = model
@@ -80,6 +87,11 @@ func (d *Delegator) Run(ctx context.Context, req agent.DelegateRequest, tracer a
 
 	if req.AgentName != "" && d.specReg != nil {
 		if a, ok := d.specReg.Get(req.AgentName); ok && a != nil {
+			release, err := a.Acquire(dispatchCtx)
+			if err != nil {
+				return "", err
+			}
+			defer release()
 			prov = a.Provider()
 			toolsReg = a.ToolsRegistry()
 			// The specialist's System field already has the default prompt prepended
@@ -139,6 +151,7 @@ func (d *Delegator) Run(ctx context.Context, req agent.DelegateRequest, tracer a
 		Delegator:      d,
 		AgentTracer:    tracer,
 		AgentDepth:     req.Depth,
+		ToolCache:      d.toolCache,
 	}
 	if d.evolvingMemory != nil && d.reMemLLM != nil {
 		eng.ReMemEnabled = true
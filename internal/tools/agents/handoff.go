@@ -0,0 +1,75 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"manifold/internal/persistence"
+	"manifold/internal/specialists"
+)
+
+// HandoffTool transfers the current chat session to a specialist, or back to
+// the orchestrator. Unlike agent_call, which runs a one-shot delegation, the
+// receiving agent answers the session's following turns with the full chat
+// history plus the summary passed here.
+type HandoffTool struct{}
+
+// NewHandoffTool constructs a HandoffTool.
+func NewHandoffTool() *HandoffTool { return &HandoffTool{} }
+
+func (t *HandoffTool) Name() string { return "handoff" }
+
+func (t *HandoffTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Hand the ongoing conversation over to a specialist (see /api/specialists), or back to the orchestrator, for the rest of the session. The receiving agent answers the user's next messages. After calling this, end your turn with a short note telling the user who will take over.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"to": map[string]any{
+					"type":        "string",
+					"description": "Specialist name to hand the session to, or \"orchestrator\" to hand it back.",
+				},
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "Context package for the receiving agent: the user's goal, what has been done and decided so far, and open questions.",
+				},
+				"reason": map[string]any{
+					"type":        "string",
+					"description": "Optional short reason for the handoff, shown to the user.",
+				},
+			},
+			"required": []string{"to", "summary"},
+		},
+	}
+}
+
+func (t *HandoffTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		To      string `json:"to"`
+		Summary string `json:"summary"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return map[string]any{"ok": false, "error": fmt.Sprintf("invalid arguments: %v", err)}, nil
+	}
+	if strings.TrimSpace(args.Summary) == "" {
+		return map[string]any{"ok": false, "error": "summary is required"}, nil
+	}
+	h, err := specialists.RequestHandoff(ctx, persistence.Handoff{
+		To:      args.To,
+		Summary: strings.TrimSpace(args.Summary),
+		Reason:  strings.TrimSpace(args.Reason),
+	})
+	if err != nil {
+		return map[string]any{"ok": false, "error": err.Error()}, nil
+	}
+	return map[string]any{
+		"ok":   true,
+		"from": h.From,
+		"to":   h.To,
+		"note": fmt.Sprintf("%s will answer the user's next message. Finish this turn with a brief note to the user.", h.To),
+	}, nil
+}
@@ -0,0 +1,72 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"manifold/internal/specialists"
+)
+
+func TestHandoff_RecordsRequest(t *testing.T) {
+	known := func(name string) bool { return name == "coder" }
+	ctx, requested := specialists.WithHandoff(context.Background(), "", known)
+
+	raw, _ := json.Marshal(map[string]any{"to": "coder", "summary": "User wants a CLI in Go.", "reason": "needs code"})
+	out, err := NewHandoffTool().Call(ctx, raw)
+	if err != nil {
+		t.Fatalf("call err: %v", err)
+	}
+	if resp := out.(map[string]any); resp["ok"] != true {
+		t.Fatalf("unexpected response: %#v", out)
+	}
+	h, ok := requested()
+	if !ok {
+		t.Fatal("expected a handoff to be requested")
+	}
+	if h.From != specialists.OrchestratorName || h.To != "coder" || h.Summary != "User wants a CLI in Go." || h.Reason != "needs code" || h.At.IsZero() {
+		t.Fatalf("unexpected handoff: %#v", h)
+	}
+}
+
+func TestHandoff_Rejects(t *testing.T) {
+	known := func(name string) bool { return name == "coder" }
+	cases := map[string]struct {
+		ctx  context.Context
+		args map[string]any
+	}{
+		"no session":         {context.Background(), map[string]any{"to": "coder", "summary": "s"}},
+		"unknown specialist": {nil, map[string]any{"to": "nobody", "summary": "s"}},
+		"missing summary":    {nil, map[string]any{"to": "coder"}},
+		"same agent":         {nil, map[string]any{"to": "orchestrator", "summary": "s"}},
+	}
+	for name, tc := range cases {
+		ctx, requested := specialists.WithHandoff(context.Background(), "", known)
+		if tc.ctx != nil {
+			ctx = tc.ctx
+		}
+		raw, _ := json.Marshal(tc.args)
+		out, err := NewHandoffTool().Call(ctx, raw)
+		if err != nil {
+			t.Fatalf("%s: call err: %v", name, err)
+		}
+		if resp := out.(map[string]any); resp["ok"] != false {
+			t.Fatalf("%s: expected failure, got %#v", name, out)
+		}
+		if _, ok := requested(); ok {
+			t.Fatalf("%s: no handoff should be recorded", name)
+		}
+	}
+}
+
+func TestHandoff_BackToOrchestrator(t *testing.T) {
+	ctx, requested := specialists.WithHandoff(context.Background(), "coder", nil)
+	raw, _ := json.Marshal(map[string]any{"to": "Orchestrator", "summary": "Done with the CLI."})
+	if _, err := NewHandoffTool().Call(ctx, raw); err != nil {
+		t.Fatalf("call err: %v", err)
+	}
+	h, ok := requested()
+	if !ok || h.From != "coder" || h.To != specialists.OrchestratorName {
+		t.Fatalf("unexpected handoff: %#v (ok=%v)", h, ok)
+	}
+}
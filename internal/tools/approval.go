@@ -0,0 +1,19 @@
+package tools
+
+import "context"
+
+type approvedToolKey struct{}
+
+// WithApproval marks ctx as running a call to the named tool that a person
+// approved.
+func WithApproval(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, approvedToolKey{}, tool)
+}
+
+// Approved reports whether a person approved the call to the named tool
+// running with ctx. Tools that must not act on their own, such as sending
+// email, check it.
+func Approved(ctx context.Context, tool string) bool {
+	name, _ := ctx.Value(approvedToolKey{}).(string)
+	return name != "" && name == tool
+}
@@ -0,0 +1,102 @@
+// Package browser provides the browser tool, which drives a pooled headless
+// Chrome through chromedp for pages that need JavaScript to render.
+package browser
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Pool shares one headless Chrome process between tool calls and caps how
+// many tabs are open at once. The browser starts on first use and is
+// restarted if it exits.
+type Pool struct {
+	execPath string
+	slots    chan struct{}
+
+	mu            sync.Mutex
+	browserCtx    context.Context
+	cancelBrowser context.CancelFunc
+	cancelAlloc   context.CancelFunc
+}
+
+// NewPool returns a pool allowing size concurrent tabs. An empty execPath
+// falls back to CHROME_PATH and then chromedp's lookup.
+func NewPool(size int, execPath string) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+	if execPath == "" {
+		execPath = os.Getenv("CHROME_PATH")
+	}
+	return &Pool{execPath: execPath, slots: make(chan struct{}, size)}
+}
+
+// Tab waits for a free slot and opens a new tab. The tab is closed and the
+// slot released when release is called or ctx is done.
+func (p *Pool) Tab(ctx context.Context) (context.Context, func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	browserCtx, err := p.browser()
+	if err != nil {
+		<-p.slots
+		return nil, nil, err
+	}
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	stop := context.AfterFunc(ctx, cancelTab)
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			cancelTab()
+			<-p.slots
+		})
+	}
+	return tabCtx, release, nil
+}
+
+func (p *Pool) browser() (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return p.browserCtx, nil
+	}
+	p.closeLocked()
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("disable-gpu", true),
+	)
+	if p.execPath != "" {
+		opts = append(opts, chromedp.ExecPath(p.execPath))
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	// Running with no actions launches the browser.
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, err
+	}
+	p.browserCtx, p.cancelBrowser, p.cancelAlloc = browserCtx, cancelBrowser, cancelAlloc
+	return browserCtx, nil
+}
+
+// Close shuts the browser down. A later Tab starts a new one.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeLocked()
+}
+
+func (p *Pool) closeLocked() {
+	if p.cancelBrowser != nil {
+		p.cancelBrowser()
+		p.cancelAlloc()
+	}
+	p.browserCtx, p.cancelBrowser, p.cancelAlloc = nil, nil, nil
+}
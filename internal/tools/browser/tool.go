@@ -0,0 +1,331 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+)
+
+const (
+	defaultMaxChars   = 20000
+	maxTimeoutSeconds = 300
+)
+
+// Action is one step run against the page, in order, after the initial
+// navigation.
+type Action struct {
+	Type       string `json:"type"`
+	URL        string `json:"url,omitempty"`
+	Selector   string `json:"selector,omitempty"`
+	Attribute  string `json:"attribute,omitempty"`
+	OutputPath string `json:"output_path,omitempty"`
+	FullPage   bool   `json:"full_page,omitempty"`
+}
+
+type stepResult struct {
+	Type      string   `json:"type"`
+	OK        bool     `json:"ok"`
+	Error     string   `json:"error,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Text      []string `json:"text,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Bytes     int      `json:"bytes,omitempty"`
+}
+
+type result struct {
+	OK    bool         `json:"ok"`
+	Error string       `json:"error,omitempty"`
+	URL   string       `json:"url,omitempty"`
+	Title string       `json:"title,omitempty"`
+	Steps []stepResult `json:"steps,omitempty"`
+}
+
+type tool struct {
+	pool    *Pool
+	timeout time.Duration
+	allow   []string
+}
+
+// NewTool constructs the web_browser tool on top of pool.
+func NewTool(pool *Pool, cfg config.BrowserConfig) *tool {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	allow := make([]string, 0, len(cfg.AllowedDomains))
+	for _, d := range cfg.AllowedDomains {
+		if d = strings.ToLower(strings.Trim(strings.TrimSpace(d), ".")); d != "" {
+			allow = append(allow, d)
+		}
+	}
+	return &tool{pool: pool, timeout: timeout, allow: allow}
+}
+
+func (t *tool) Name() string { return "web_browser" }
+
+func (t *tool) JSONSchema() map[string]any {
+	desc := "Open a page in a headless Chrome browser, which runs JavaScript, and then run actions in order: navigate, click, wait for a selector, extract text or attributes, or save a screenshot to the project. Use this for pages web_fetch cannot render. With no actions, the page's text is returned."
+	if len(t.allow) > 0 {
+		desc += " Navigation is limited to: " + strings.Join(t.allow, ", ") + "."
+	}
+	return map[string]any{
+		"name":        t.Name(),
+		"description": desc,
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url": map[string]any{"type": "string", "description": "Absolute http(s) URL to open."},
+				"actions": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"type":        map[string]any{"type": "string", "enum": []string{"navigate", "click", "wait", "extract", "screenshot"}},
+							"url":         map[string]any{"type": "string", "description": "navigate: absolute URL."},
+							"selector":    map[string]any{"type": "string", "description": "CSS selector. Required for click and wait; extract defaults to body; screenshot captures only this element when set."},
+							"attribute":   map[string]any{"type": "string", "description": "extract: return this attribute (e.g. href) instead of text."},
+							"output_path": map[string]any{"type": "string", "description": "screenshot: PNG path relative to the project (default browser_screenshot.png)."},
+							"full_page":   map[string]any{"type": "boolean", "description": "screenshot: capture the full page instead of the viewport."},
+						},
+						"required": []string{"type"},
+					},
+				},
+				"timeout_seconds": map[string]any{"type": "integer", "minimum": 1, "maximum": maxTimeoutSeconds, "description": "Overall time limit for the call."},
+				"max_chars":       map[string]any{"type": "integer", "minimum": 1, "description": "Maximum characters returned per extract (default 20000)."},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+func (t *tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		URL            string   `json:"url"`
+		Actions        []Action `json:"actions"`
+		TimeoutSeconds int      `json:"timeout_seconds"`
+		MaxChars       int      `json:"max_chars"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if err := t.checkURL(args.URL); err != nil {
+		return result{OK: false, Error: err.Error()}, nil
+	}
+	actions := args.Actions
+	if len(actions) == 0 {
+		actions = []Action{{Type: "extract"}}
+	}
+	if err := t.validate(actions); err != nil {
+		return result{OK: false, Error: err.Error()}, nil
+	}
+	timeout := t.timeout
+	if args.TimeoutSeconds > 0 {
+		timeout = time.Duration(min(args.TimeoutSeconds, maxTimeoutSeconds)) * time.Second
+	}
+	maxChars := args.MaxChars
+	if maxChars <= 0 {
+		maxChars = defaultMaxChars
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tabCtx, release, err := t.pool.Tab(ctx)
+	if err != nil {
+		return result{OK: false, Error: fmt.Sprintf("browser unavailable: %v", err)}, nil
+	}
+	defer release()
+	tabCtx, cancelTab := context.WithTimeout(tabCtx, timeout)
+	defer cancelTab()
+
+	res := result{OK: true}
+	if err := t.navigate(tabCtx, args.URL); err != nil {
+		return result{OK: false, Error: err.Error()}, nil
+	}
+	for _, a := range actions {
+		step := t.run(ctx, tabCtx, a, maxChars)
+		res.Steps = append(res.Steps, step)
+		if !step.OK {
+			// Later steps usually depend on earlier ones, so stop here.
+			res.OK = false
+			res.Error = fmt.Sprintf("%s failed: %s", step.Type, step.Error)
+			break
+		}
+	}
+	_ = chromedp.Run(tabCtx, chromedp.Location(&res.URL), chromedp.Title(&res.Title))
+	return res, nil
+}
+
+// checkURL accepts absolute http(s) URLs on an allowed domain.
+func (t *tool) checkURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if len(t.allow) == 0 {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range t.allow {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return nil
+		}
+	}
+	return fmt.Errorf("domain %q is not in the browser allow-list", host)
+}
+
+func (t *tool) validate(actions []Action) error {
+	for i, a := range actions {
+		switch a.Type {
+		case "navigate":
+			if err := t.checkURL(a.URL); err != nil {
+				return fmt.Errorf("action %d: %w", i+1, err)
+			}
+		case "click", "wait":
+			if strings.TrimSpace(a.Selector) == "" {
+				return fmt.Errorf("action %d: %s requires a selector", i+1, a.Type)
+			}
+		case "extract", "screenshot":
+		default:
+			return fmt.Errorf("action %d: unknown type %q", i+1, a.Type)
+		}
+	}
+	return nil
+}
+
+func (t *tool) navigate(tabCtx context.Context, u string) error {
+	if err := chromedp.Run(tabCtx, chromedp.Navigate(u), chromedp.WaitReady("body", chromedp.ByQuery)); err != nil {
+		return fmt.Errorf("navigate %s: %w", u, err)
+	}
+	return t.checkLocation(tabCtx)
+}
+
+// checkLocation re-applies the allow-list after anything that may have
+// moved the page, such as redirects or clicked links.
+func (t *tool) checkLocation(tabCtx context.Context) error {
+	if len(t.allow) == 0 {
+		return nil
+	}
+	var loc string
+	if err := chromedp.Run(tabCtx, chromedp.Location(&loc)); err != nil {
+		return err
+	}
+	if err := t.checkURL(loc); err != nil {
+		return fmt.Errorf("page left the allow-list: %w", err)
+	}
+	return nil
+}
+
+func (t *tool) run(ctx, tabCtx context.Context, a Action, maxChars int) stepResult {
+	step := stepResult{Type: a.Type}
+	var err error
+	switch a.Type {
+	case "navigate":
+		step.URL = a.URL
+		err = t.navigate(tabCtx, a.URL)
+	case "click":
+		err = chromedp.Run(tabCtx,
+			chromedp.Click(a.Selector, chromedp.ByQuery, chromedp.NodeVisible),
+			chromedp.WaitReady("body", chromedp.ByQuery),
+		)
+		if err == nil {
+			err = t.checkLocation(tabCtx)
+		}
+	case "wait":
+		err = chromedp.Run(tabCtx, chromedp.WaitVisible(a.Selector, chromedp.ByQuery))
+	case "extract":
+		step.Text, step.Truncated, err = extract(tabCtx, a, maxChars)
+	case "screenshot":
+		step.Path, step.Bytes, err = screenshot(ctx, tabCtx, a)
+	}
+	if err != nil {
+		step.Error = err.Error()
+		return step
+	}
+	step.OK = true
+	return step
+}
+
+// extractScript returns the text, or the given attribute, of every element
+// matching the selector.
+func extractScript(selector, attribute string) string {
+	sel, _ := json.Marshal(selector)
+	attr, _ := json.Marshal(attribute)
+	return fmt.Sprintf(`Array.from(document.querySelectorAll(%s)).map(e => %s ? (e.getAttribute(%s) || "") : (e.innerText || ""))`, sel, attr, attr)
+}
+
+func extract(tabCtx context.Context, a Action, maxChars int) ([]string, bool, error) {
+	selector := strings.TrimSpace(a.Selector)
+	if selector == "" {
+		selector = "body"
+	}
+	var values []string
+	if err := chromedp.Run(tabCtx, chromedp.Evaluate(extractScript(selector, strings.TrimSpace(a.Attribute)), &values)); err != nil {
+		return nil, false, err
+	}
+	out, truncated := truncateTexts(values, maxChars)
+	return out, truncated, nil
+}
+
+// truncateTexts keeps values up to a total of maxChars characters.
+func truncateTexts(values []string, maxChars int) ([]string, bool) {
+	out := make([]string, 0, len(values))
+	left := maxChars
+	for _, v := range values {
+		r := []rune(v)
+		if len(r) > left {
+			out = append(out, string(r[:left]))
+			return out, true
+		}
+		out = append(out, v)
+		left -= len(r)
+	}
+	return out, false
+}
+
+// screenshot saves a PNG under the project base directory in ctx.
+func screenshot(ctx, tabCtx context.Context, a Action) (string, int, error) {
+	base, ok := sandbox.BaseDirFromContext(ctx)
+	if !ok || base == "" {
+		return "", 0, fmt.Errorf("no project base directory in context; screenshots must be taken inside a project")
+	}
+	out := strings.TrimSpace(a.OutputPath)
+	if out == "" {
+		out = "browser_screenshot.png"
+	}
+	rel, err := sandbox.SanitizeArg(base, out)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid output_path: %w", err)
+	}
+	var png []byte
+	var capture chromedp.Action
+	switch {
+	case strings.TrimSpace(a.Selector) != "":
+		capture = chromedp.Screenshot(a.Selector, &png, chromedp.ByQuery, chromedp.NodeVisible)
+	case a.FullPage:
+		capture = chromedp.FullScreenshot(&png, 90)
+	default:
+		capture = chromedp.CaptureScreenshot(&png)
+	}
+	if err := chromedp.Run(tabCtx, capture); err != nil {
+		return "", 0, err
+	}
+	full := filepath.Join(base, rel)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(full, png, 0o644); err != nil {
+		return "", 0, err
+	}
+	return filepath.ToSlash(rel), len(png), nil
+}
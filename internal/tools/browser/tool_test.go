@@ -0,0 +1,73 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+)
+
+func TestCheckURLAllowList(t *testing.T) {
+	t.Parallel()
+
+	tl := NewTool(nil, config.BrowserConfig{AllowedDomains: []string{" Example.com ", ".docs.io", ""}})
+	for _, u := range []string{"https://example.com/a", "http://www.example.com", "https://api.docs.io/x"} {
+		if err := tl.checkURL(u); err != nil {
+			t.Errorf("checkURL(%q) = %v, want nil", u, err)
+		}
+	}
+	for _, u := range []string{"https://example.com.evil.net", "https://notexample.com", "file:///etc/passwd", "javascript:alert(1)", "/relative", ""} {
+		if err := tl.checkURL(u); err == nil {
+			t.Errorf("checkURL(%q) = nil, want error", u)
+		}
+	}
+
+	open := NewTool(nil, config.BrowserConfig{})
+	if err := open.checkURL("https://anything.test"); err != nil {
+		t.Fatalf("empty allow-list should permit any host, got %v", err)
+	}
+	if err := open.checkURL("ftp://anything.test"); err == nil {
+		t.Fatalf("expected non-http scheme to be rejected")
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	t.Parallel()
+
+	tl := NewTool(nil, config.BrowserConfig{AllowedDomains: []string{"example.com"}})
+	if err := tl.validate([]Action{{Type: "click", Selector: "#go"}, {Type: "extract"}, {Type: "screenshot"}}); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, actions := range [][]Action{
+		{{Type: "click"}},
+		{{Type: "wait", Selector: " "}},
+		{{Type: "navigate", URL: "https://other.org"}},
+		{{Type: "scroll"}},
+	} {
+		if err := tl.validate(actions); err == nil {
+			t.Errorf("validate(%+v) = nil, want error", actions)
+		}
+	}
+}
+
+func TestExtractScriptQuotesInputs(t *testing.T) {
+	t.Parallel()
+
+	script := extractScript(`a[title="x"]`, "href")
+	if !strings.Contains(script, `querySelectorAll("a[title=\"x\"]")`) || !strings.Contains(script, `getAttribute("href")`) {
+		t.Fatalf("unexpected script %s", script)
+	}
+}
+
+func TestTruncateTexts(t *testing.T) {
+	t.Parallel()
+
+	out, truncated := truncateTexts([]string{"abc", "défgh", "ij"}, 5)
+	if !truncated || len(out) != 2 || out[0] != "abc" || out[1] != "dé" {
+		t.Fatalf("truncateTexts = %q, %v", out, truncated)
+	}
+	out, truncated = truncateTexts([]string{"abc"}, 5)
+	if truncated || len(out) != 1 {
+		t.Fatalf("truncateTexts = %q, %v", out, truncated)
+	}
+}
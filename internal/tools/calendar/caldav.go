@@ -0,0 +1,139 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes bounds one CalDAV response body.
+const maxResponseBytes = 10 << 20
+
+// multistatus is the WebDAV REPORT response; only calendar-data is read.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// davAccount is a resolved calendar account ready for requests.
+type davAccount struct {
+	url      string
+	username string
+	password string
+}
+
+func (a davAccount) do(ctx context.Context, hc *http.Client, method, target string, header http.Header, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if a.password != "" {
+		req.SetBasicAuth(a.username, a.password)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("caldav %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("caldav %s: %w", method, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, resp.StatusCode, fmt.Errorf("caldav %s: access denied (%s)", method, resp.Status)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// query returns the events overlapping [start, end). The server is asked
+// to expand recurring events into instances within the range.
+func (a davAccount) query(ctx context.Context, hc *http.Client, start, end time.Time, loc *time.Location) ([]Event, error) {
+	s, e := start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z")
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data><C:expand start="` + s + `" end="` + e + `"/></C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="` + s + `" end="` + e + `"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+	header := http.Header{
+		"Content-Type": {"application/xml; charset=utf-8"},
+		"Depth":        {"1"},
+	}
+	data, status, err := a.do(ctx, hc, "REPORT", a.url, header, []byte(body))
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusMultiStatus {
+		return nil, fmt.Errorf("caldav REPORT: unexpected status %d", status)
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("caldav REPORT: %w", err)
+	}
+	var out []Event
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if strings.TrimSpace(ps.Prop.CalendarData) == "" {
+				continue
+			}
+			events, err := parseEvents(ps.Prop.CalendarData, loc)
+			if err != nil {
+				return nil, err
+			}
+			// Servers that ignore <expand> return whole objects; keep only
+			// what overlaps the range.
+			for _, ev := range events {
+				if ev.Start.Before(end) && (ev.End.After(start) || ev.End.Equal(ev.Start) && !ev.Start.Before(start)) {
+					out = append(out, ev)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// put creates the event as a new calendar object named after its UID.
+func (a davAccount) put(ctx context.Context, hc *http.Client, ev Event, now time.Time) error {
+	base, err := url.Parse(a.url)
+	if err != nil {
+		return fmt.Errorf("calendar url: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	target := base.JoinPath(ev.UID + ".ics").String()
+	header := http.Header{
+		"Content-Type":  {"text/calendar; charset=utf-8"},
+		"If-None-Match": {"*"},
+	}
+	_, status, err := a.do(ctx, hc, http.MethodPut, target, header, []byte(encodeEvent(ev, now)))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated && status != http.StatusNoContent && status != http.StatusOK {
+		return fmt.Errorf("caldav PUT: unexpected status %d", status)
+	}
+	return nil
+}
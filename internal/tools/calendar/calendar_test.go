@@ -0,0 +1,185 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+const reportBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/standup.ics</d:href>
+    <d:propstat><d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Stand-up\, daily
+DTSTART;TZID=Europe/Berlin:20261019T100000
+DURATION:PT30M
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:reminder
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop></d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/cal/lunch.ics</d:href>
+    <d:propstat><d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:lunch
+SUMMARY:Lunch with a very long title that the server folded across
+  two lines
+DTSTART:20261019T110000Z
+DTEND:20261019T120000Z
+LOCATION:Cafe
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+STATUS:CANCELLED
+SUMMARY:Gone
+DTSTART:20261019T140000Z
+DTEND:20261019T150000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:focus
+SUMMARY:Focus time
+TRANSP:TRANSPARENT
+DTSTART:20261019T140000Z
+DTEND:20261019T150000Z
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop></d:propstat>
+  </d:response>
+</d:multistatus>`
+
+type davServer struct {
+	report string
+	put    string
+	putURL string
+}
+
+func newTestClient(t *testing.T, readOnly bool) (*Client, *davServer) {
+	t.Helper()
+	dav := &davServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "pw-cal" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch r.Method {
+		case "REPORT":
+			dav.report = string(body)
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = io.WriteString(w, reportBody)
+		case http.MethodPut:
+			if r.Header.Get("If-None-Match") != "*" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			dav.put, dav.putURL = string(body), r.URL.Path
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(config.CalendarConfig{
+		ReadOnly:     readOnly,
+		Timezone:     "Europe/Berlin",
+		WorkdayStart: "09:00",
+		WorkdayEnd:   "17:00",
+		Accounts:     []config.CalendarAccountConfig{{UserID: 3, URL: srv.URL + "/cal", Username: "me", PasswordSecret: "cal"}},
+	}, func(name string) (string, bool) { return "pw-" + name, name == "cal" })
+	c.now = func() time.Time { return time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC) }
+	return c, dav
+}
+
+func TestListEvents(t *testing.T) {
+	c, dav := newTestClient(t, false)
+	ctx := llm.WithUserID(context.Background(), 3)
+
+	out, err := NewListTool(c).Call(ctx, json.RawMessage(`{"start":"2026-10-19","end":"2026-10-19","timezone":"America/New_York"}`))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(dav.report, `<C:time-range start="20261019T040000Z" end="20261020T040000Z"/>`) {
+		t.Fatalf("range not converted to UTC:\n%s", dav.report)
+	}
+	events := out.(map[string]any)["events"].([]Event)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	standup, lunch := events[0], events[1]
+	if standup.Summary != "Stand-up, daily" || standup.Start.Format(time.RFC3339) != "2026-10-19T04:00:00-04:00" || standup.End.Sub(standup.Start) != 30*time.Minute {
+		t.Fatalf("unexpected stand-up %+v", standup)
+	}
+	if lunch.Summary != "Lunch with a very long title that the server folded across two lines" || lunch.Start.Format("15:04") != "07:00" {
+		t.Fatalf("unexpected lunch %+v", lunch)
+	}
+	if !events[2].Free {
+		t.Fatalf("transparent event should be free: %+v", events[2])
+	}
+
+	if _, err := NewListTool(c).Call(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "no calendar") {
+		t.Fatalf("expected missing account error, got %v", err)
+	}
+}
+
+func TestFindFreeSlots(t *testing.T) {
+	c, _ := newTestClient(t, false)
+	ctx := llm.WithUserID(context.Background(), 3)
+
+	// Monday 19 October in Berlin (UTC+2): busy 10:00-10:30 and 13:00-14:00.
+	out, err := NewFreeSlotsTool(c).Call(ctx, json.RawMessage(`{"start":"2026-10-17","end":"2026-10-19","duration_minutes":60}`))
+	if err != nil {
+		t.Fatalf("free slots: %v", err)
+	}
+	var got []string
+	for _, s := range out.(map[string]any)["slots"].([]Slot) {
+		got = append(got, s.Start.Format("Mon 15:04")+"-"+s.End.Format("15:04"))
+	}
+	want := "Mon 09:00-10:00 Mon 10:30-13:00 Mon 14:00-17:00"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("slots = %v, want %s", got, want)
+	}
+}
+
+func TestCreateEvent(t *testing.T) {
+	c, dav := newTestClient(t, false)
+	ctx := llm.WithUserID(context.Background(), 3)
+
+	out, err := NewCreateTool(c).Call(ctx, json.RawMessage(`{"summary":"Review; notes, etc","start":"2026-10-20T15:00","end":"2026-10-20T15:45"}`))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	ev := out.(map[string]any)["event"].(Event)
+	if !strings.HasPrefix(dav.putURL, "/cal/") || !strings.HasSuffix(dav.putURL, ".ics") {
+		t.Fatalf("unexpected PUT path %q", dav.putURL)
+	}
+	for _, want := range []string{"UID:" + ev.UID + "\r\n", "DTSTART:20261020T130000Z\r\n", "DTEND:20261020T134500Z\r\n", `SUMMARY:Review\; notes\, etc`} {
+		if !strings.Contains(dav.put, want) {
+			t.Fatalf("calendar object missing %q:\n%s", want, dav.put)
+		}
+	}
+	parsed, err := parseEvents(dav.put, time.UTC)
+	if err != nil || len(parsed) != 1 || parsed[0].Summary != "Review; notes, etc" {
+		t.Fatalf("round trip failed: %+v %v", parsed, err)
+	}
+
+	ro, _ := newTestClient(t, true)
+	if _, err := NewCreateTool(ro).Call(ctx, json.RawMessage(`{"summary":"x","start":"2026-10-20","end":"2026-10-20","all_day":true}`)); err == nil {
+		t.Fatal("expected read-only calendar to refuse")
+	}
+}
@@ -0,0 +1,261 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one calendar entry. Start and End are rendered in the caller's
+// timezone; End is exclusive, so an all-day event on a single day ends at
+// the following midnight.
+type Event struct {
+	UID         string    `json:"uid"`
+	Summary     string    `json:"summary"`
+	Location    string    `json:"location,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"all_day,omitempty"`
+	// Free is set for events marked TRANSP:TRANSPARENT, which do not block
+	// time when finding free slots.
+	Free bool `json:"free,omitempty"`
+}
+
+// property is one content line: NAME;PARAM=V:value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+var durationRE = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseEvents returns the VEVENTs in an iCalendar object. Floating times
+// and unknown TZIDs are interpreted in loc; cancelled events are skipped.
+func parseEvents(data string, loc *time.Location) ([]Event, error) {
+	var (
+		out   []Event
+		props []property
+		depth int
+		inEv  bool
+	)
+	for _, line := range unfold(data) {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && !inEv:
+			inEv, depth, props = true, 0, nil
+		case !inEv:
+		case p.name == "BEGIN":
+			depth++ // nested component such as VALARM
+		case p.name == "END" && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			inEv = false
+			ev, keep, err := buildEvent(props, loc)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				out = append(out, ev)
+			}
+		case depth == 0:
+			props = append(props, p)
+		}
+	}
+	return out, nil
+}
+
+func buildEvent(props []property, loc *time.Location) (Event, bool, error) {
+	var (
+		ev       Event
+		end      *property
+		duration string
+		haveDate bool
+	)
+	for i, p := range props {
+		switch p.name {
+		case "UID":
+			ev.UID = p.value
+		case "SUMMARY":
+			ev.Summary = unescapeText(p.value)
+		case "LOCATION":
+			ev.Location = unescapeText(p.value)
+		case "DESCRIPTION":
+			ev.Description = unescapeText(p.value)
+		case "STATUS":
+			if strings.EqualFold(p.value, "CANCELLED") {
+				return ev, false, nil
+			}
+		case "TRANSP":
+			ev.Free = strings.EqualFold(p.value, "TRANSPARENT")
+		case "DTSTART":
+			t, allDay, err := parseDateTime(p, loc)
+			if err != nil {
+				return ev, false, fmt.Errorf("event %s: DTSTART: %w", ev.UID, err)
+			}
+			ev.Start, ev.AllDay, haveDate = t, allDay, true
+		case "DTEND":
+			end = &props[i]
+		case "DURATION":
+			duration = p.value
+		}
+	}
+	if !haveDate {
+		return ev, false, nil
+	}
+	switch {
+	case end != nil:
+		t, _, err := parseDateTime(*end, loc)
+		if err != nil {
+			return ev, false, fmt.Errorf("event %s: DTEND: %w", ev.UID, err)
+		}
+		ev.End = t
+	case duration != "":
+		d, err := parseDuration(duration)
+		if err != nil {
+			return ev, false, fmt.Errorf("event %s: DURATION: %w", ev.UID, err)
+		}
+		ev.End = ev.Start.Add(d)
+	case ev.AllDay:
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	default:
+		ev.End = ev.Start
+	}
+	return ev, true, nil
+}
+
+// parseDateTime reads a DATE or DATE-TIME value: UTC ("...Z"), zoned by
+// TZID, or floating.
+func parseDateTime(p property, loc *time.Location) (time.Time, bool, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == 8 {
+		t, err := time.ParseInLocation("20060102", p.value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.value)
+		return t, false, err
+	}
+	if tzid := strings.Trim(p.params["TZID"], `"`); tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", p.value, loc)
+	return t, false, err
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	m := durationRE.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// unfold joins RFC 5545 continuation lines.
+func unfold(data string) []string {
+	var lines []string
+	sc := bufio.NewScanner(strings.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func parseProperty(line string) (property, bool) {
+	// The value starts at the first colon outside a quoted parameter.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return property{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(parts[0]), value: line[colon+1:], params: map[string]string{}}
+	for _, param := range parts[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			p.params[strings.ToUpper(k)] = v
+		}
+	}
+	return p, true
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// encodeEvent renders ev as a VCALENDAR with times in UTC.
+func encodeEvent(ev Event, now time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		// Fold at 75 octets without splitting UTF-8 sequences.
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+	utc := func(t time.Time) string { return t.UTC().Format("20060102T150405Z") }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//manifold//calendar tool//EN")
+	line("BEGIN:VEVENT")
+	line("UID:" + ev.UID)
+	line("DTSTAMP:" + utc(now))
+	if ev.AllDay {
+		line("DTSTART;VALUE=DATE:" + ev.Start.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + ev.End.Format("20060102"))
+	} else {
+		line("DTSTART:" + utc(ev.Start))
+		line("DTEND:" + utc(ev.End))
+	}
+	line("SUMMARY:" + escapeText(ev.Summary))
+	if ev.Location != "" {
+		line("LOCATION:" + escapeText(ev.Location))
+	}
+	if ev.Description != "" {
+		line("DESCRIPTION:" + escapeText(ev.Description))
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return b.String()
+}
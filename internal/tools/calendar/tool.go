@@ -0,0 +1,423 @@
+// Package calendar provides CalDAV tools for the calling user's calendar:
+// calendar_list_events, calendar_find_free_slots and, unless the calendar
+// is read-only, calendar_create_event. Passwords come from the secrets
+// store and never reach the model.
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+)
+
+const (
+	defaultRangeDays = 7
+	maxRangeDays     = 92
+	maxSlots         = 50
+)
+
+// Client resolves the caller's account and talks to its CalDAV server.
+type Client struct {
+	accounts  map[int64]config.CalendarAccountConfig
+	lookup    func(name string) (string, bool)
+	readOnly  bool
+	loc       *time.Location
+	workStart time.Duration
+	workEnd   time.Duration
+	hc        *http.Client
+	now       func() time.Time
+}
+
+// NewClient returns a client for the configured accounts. lookup resolves
+// an account's passwordSecret, typically from the secrets store.
+func NewClient(cfg config.CalendarConfig, lookup func(name string) (string, bool)) *Client {
+	c := &Client{
+		accounts:  make(map[int64]config.CalendarAccountConfig, len(cfg.Accounts)),
+		lookup:    lookup,
+		readOnly:  cfg.ReadOnly,
+		loc:       time.UTC,
+		workStart: 9 * time.Hour,
+		workEnd:   17 * time.Hour,
+		hc:        &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}
+	if l, err := time.LoadLocation(strings.TrimSpace(cfg.Timezone)); err == nil {
+		c.loc = l
+	}
+	if t, err := time.Parse("15:04", cfg.WorkdayStart); err == nil {
+		c.workStart = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if t, err := time.Parse("15:04", cfg.WorkdayEnd); err == nil {
+		c.workEnd = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	for _, a := range cfg.Accounts {
+		c.accounts[a.UserID] = a
+	}
+	return c
+}
+
+func (c *Client) account(ctx context.Context) (davAccount, error) {
+	userID, _ := llm.UserIDFromContext(ctx)
+	acct, ok := c.accounts[userID]
+	if !ok {
+		return davAccount{}, errors.New("no calendar is configured for this user")
+	}
+	out := davAccount{url: acct.URL, username: acct.Username}
+	if acct.PasswordSecret == "" {
+		return out, nil
+	}
+	if c.lookup == nil {
+		return out, errors.New("calendar passwords need the secrets store; set secrets.masterKey")
+	}
+	password, ok := c.lookup(acct.PasswordSecret)
+	if !ok {
+		return out, fmt.Errorf("secret %q for the calendar password is not set", acct.PasswordSecret)
+	}
+	out.password = password
+	return out, nil
+}
+
+// location returns the named zone, or the calendar default when empty.
+func (c *Client) location(name string) (*time.Location, error) {
+	if name = strings.TrimSpace(name); name == "" {
+		return c.loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// parseTime accepts RFC 3339, or a local date or date-time interpreted in
+// loc. dateOnly reports whether s was a bare date.
+func parseTime(s string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q: use RFC 3339 or YYYY-MM-DD[THH:MM]", s)
+}
+
+// timeRange resolves optional start/end arguments. A bare end date is
+// inclusive. The default range is the next week.
+func (c *Client) timeRange(start, end string, loc *time.Location) (time.Time, time.Time, error) {
+	from := c.now().In(loc)
+	if start != "" {
+		t, _, err := parseTime(start, loc)
+		if err != nil {
+			return from, from, err
+		}
+		from = t
+	}
+	to := from.AddDate(0, 0, defaultRangeDays)
+	if end != "" {
+		t, dateOnly, err := parseTime(end, loc)
+		if err != nil {
+			return from, to, err
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	if !to.After(from) {
+		return from, to, errors.New("end must be after start")
+	}
+	if to.Sub(from) > maxRangeDays*24*time.Hour {
+		return from, to, fmt.Errorf("range is limited to %d days", maxRangeDays)
+	}
+	return from, to, nil
+}
+
+func (c *Client) events(ctx context.Context, from, to time.Time, loc *time.Location) ([]Event, error) {
+	acct, err := c.account(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := acct.query(ctx, c.hc, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Start = events[i].Start.In(loc)
+		events[i].End = events[i].End.In(loc)
+	}
+	slices.SortFunc(events, func(a, b Event) int { return a.Start.Compare(b.Start) })
+	return events, nil
+}
+
+var timezoneParam = map[string]any{"type": "string", "description": "IANA timezone for inputs without an offset and for results, e.g. \"America/New_York\". Defaults to the calendar timezone."}
+
+type rangeArgs struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+type listTool struct{ c *Client }
+
+// NewListTool constructs calendar_list_events.
+func NewListTool(c *Client) *listTool { return &listTool{c: c} }
+
+func (t *listTool) Name() string { return "calendar_list_events" }
+
+func (t *listTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "List the user's calendar events between start and end (default: the next 7 days), with recurring events expanded. Times are returned in the requested timezone.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"start":    map[string]any{"type": "string", "description": "Range start: RFC 3339 or YYYY-MM-DD[THH:MM] (default now)."},
+				"end":      map[string]any{"type": "string", "description": "Range end; a bare date includes that whole day."},
+				"timezone": timezoneParam,
+			},
+		},
+	}
+}
+
+func (t *listTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args rangeArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	loc, err := t.c.location(args.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := t.c.timeRange(args.Start, args.End, loc)
+	if err != nil {
+		return nil, err
+	}
+	events, err := t.c.events(ctx, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []Event{}
+	}
+	return map[string]any{"ok": true, "timezone": loc.String(), "start": from, "end": to, "events": events}, nil
+}
+
+type freeSlotsTool struct{ c *Client }
+
+// NewFreeSlotsTool constructs calendar_find_free_slots.
+func NewFreeSlotsTool(c *Client) *freeSlotsTool { return &freeSlotsTool{c: c} }
+
+func (t *freeSlotsTool) Name() string { return "calendar_find_free_slots" }
+
+func (t *freeSlotsTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Find free periods of at least duration_minutes in the user's calendar during working hours between start and end (default: the next 7 days). Weekends are skipped unless include_weekends is true.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"duration_minutes": map[string]any{"type": "integer", "minimum": 5, "description": "Required slot length (default 30)."},
+				"start":            map[string]any{"type": "string", "description": "Range start: RFC 3339 or YYYY-MM-DD[THH:MM] (default now)."},
+				"end":              map[string]any{"type": "string", "description": "Range end; a bare date includes that whole day."},
+				"timezone":         timezoneParam,
+				"include_weekends": map[string]any{"type": "boolean"},
+			},
+		},
+	}
+}
+
+// Slot is a free period.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (t *freeSlotsTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		rangeArgs
+		DurationMinutes int  `json:"duration_minutes"`
+		IncludeWeekends bool `json:"include_weekends"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if args.DurationMinutes <= 0 {
+		args.DurationMinutes = 30
+	}
+	loc, err := t.c.location(args.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := t.c.timeRange(args.Start, args.End, loc)
+	if err != nil {
+		return nil, err
+	}
+	events, err := t.c.events(ctx, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	slots := freeSlots(events, from, to, loc, t.c.workStart, t.c.workEnd, time.Duration(args.DurationMinutes)*time.Minute, args.IncludeWeekends)
+	truncated := len(slots) > maxSlots
+	if truncated {
+		slots = slots[:maxSlots]
+	}
+	return map[string]any{"ok": true, "timezone": loc.String(), "slots": slots, "truncated": truncated}, nil
+}
+
+// freeSlots returns the gaps of at least minLen between busy events inside
+// each day's working hours in loc, clipped to [from, to).
+func freeSlots(events []Event, from, to time.Time, loc *time.Location, workStart, workEnd, minLen time.Duration, weekends bool) []Slot {
+	var busy []Slot
+	for _, ev := range events {
+		if !ev.Free {
+			busy = append(busy, Slot{Start: ev.Start, End: ev.End})
+		}
+	}
+	slices.SortFunc(busy, func(a, b Slot) int { return a.Start.Compare(b.Start) })
+
+	slots := []Slot{}
+	y, m, d := from.In(loc).Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !weekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		// Wall-clock bounds, so working hours hold across DST changes.
+		ws, we := wallClock(day, workStart), wallClock(day, workEnd)
+		if ws.Before(from) {
+			ws = from
+		}
+		if we.After(to) {
+			we = to
+		}
+		cursor := ws
+		for _, b := range busy {
+			if !b.End.After(cursor) || !b.Start.Before(we) {
+				continue
+			}
+			if b.Start.Sub(cursor) >= minLen {
+				slots = append(slots, Slot{Start: cursor, End: b.Start})
+			}
+			if b.End.After(cursor) {
+				cursor = b.End
+			}
+		}
+		if we.Sub(cursor) >= minLen {
+			slots = append(slots, Slot{Start: cursor, End: we})
+		}
+	}
+	return slots
+}
+
+// wallClock returns the time of day d on day's date in day's location.
+func wallClock(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+type createTool struct{ c *Client }
+
+// NewCreateTool constructs calendar_create_event.
+func NewCreateTool(c *Client) *createTool { return &createTool{c: c} }
+
+func (t *createTool) Name() string { return "calendar_create_event" }
+
+func (t *createTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Create an event in the user's calendar. Give start and end as RFC 3339 or local YYYY-MM-DDTHH:MM in timezone; for an all-day event give dates (YYYY-MM-DD, end inclusive) and set all_day.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary":     map[string]any{"type": "string", "description": "Event title."},
+				"start":       map[string]any{"type": "string"},
+				"end":         map[string]any{"type": "string"},
+				"all_day":     map[string]any{"type": "boolean"},
+				"location":    map[string]any{"type": "string"},
+				"description": map[string]any{"type": "string"},
+				"timezone":    timezoneParam,
+			},
+			"required": []string{"summary", "start", "end"},
+		},
+	}
+}
+
+func (t *createTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	if t.c.readOnly {
+		return nil, errors.New("the calendar is read-only")
+	}
+	var args struct {
+		Summary     string `json:"summary"`
+		Start       string `json:"start"`
+		End         string `json:"end"`
+		AllDay      bool   `json:"all_day"`
+		Location    string `json:"location"`
+		Description string `json:"description"`
+		Timezone    string `json:"timezone"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Summary) == "" {
+		return nil, errors.New("summary is required")
+	}
+	loc, err := t.c.location(args.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	start, _, err := parseTime(args.Start, loc)
+	if err != nil {
+		return nil, err
+	}
+	end, endDateOnly, err := parseTime(args.End, loc)
+	if err != nil {
+		return nil, err
+	}
+	if args.AllDay {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+		if endDateOnly || !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	if !end.After(start) {
+		return nil, errors.New("end must be after start")
+	}
+	acct, err := t.c.account(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	ev := Event{
+		UID:         hex.EncodeToString(id[:]) + "@manifold",
+		Summary:     args.Summary,
+		Location:    args.Location,
+		Description: args.Description,
+		Start:       start.In(loc),
+		End:         end.In(loc),
+		AllDay:      args.AllDay,
+	}
+	if err := acct.put(ctx, t.c.hc, ev, t.c.now()); err != nil {
+		return nil, err
+	}
+	return map[string]any{"ok": true, "event": ev}, nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"manifold/internal/llm"
+)
+
+// ErrArtifactNotFound is returned by OutputStore.Open for unknown IDs.
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+var artifactIDPattern = regexp.MustCompile(`^[0-9a-f]{16}-(stdout|stderr)$`)
+
+// OutputStore keeps the full stdout or stderr of commands whose output was
+// truncated, so it can be fetched later instead of being lost. Artifacts
+// are stored per user under root/<userID>/<id>.log.
+type OutputStore struct {
+	root string
+}
+
+// NewOutputStore returns a store rooted at root.
+func NewOutputStore(root string) *OutputStore {
+	return &OutputStore{root: root}
+}
+
+// Save writes data for stream ("stdout" or "stderr") under the user in ctx
+// and returns the artifact ID.
+func (s *OutputStore) Save(ctx context.Context, stream string, data []byte) (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b[:]) + "-" + stream
+	userID, _ := llm.UserIDFromContext(ctx)
+	dir := filepath.Join(s.root, fmt.Sprint(userID))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("ensure artifact dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".log"), data, 0o644); err != nil {
+		return "", fmt.Errorf("write artifact: %w", err)
+	}
+	return id, nil
+}
+
+// Open returns the artifact id saved for userID.
+func (s *OutputStore) Open(userID int64, id string) (*os.File, error) {
+	if !artifactIDPattern.MatchString(id) {
+		return nil, ErrArtifactNotFound
+	}
+	f, err := os.Open(filepath.Join(s.root, fmt.Sprint(userID), id+".log"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrArtifactNotFound
+	}
+	return f, err
+}
@@ -2,10 +2,15 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/sandbox"
 )
 
 func TestNormalizeCommandArgs(t *testing.T) {
@@ -60,3 +65,123 @@ func TestExecutorRunBlocksInlineBlockedBinary(t *testing.T) {
 		t.Fatalf("error = %q, want blocked rm error", err.Error())
 	}
 }
+
+func TestExecutorRunInjectsContextEnvAndWorkdir(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	exec := NewExecutor(config.ExecConfig{MaxCommandSeconds: 5}, t.TempDir(), 0)
+	ctx := sandbox.WithEnv(sandbox.WithBaseDir(context.Background(), base), map[string]string{"PROJECT_TOKEN": "s3cret"})
+	res, err := exec.Run(ctx, ExecRequest{Command: "sh", Args: []string{"-c", "printf '%s %s' \"$PROJECT_TOKEN\" \"$PWD\""}})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "s3cret " + base; res.Stdout != want {
+		t.Fatalf("stdout = %q, want %q", res.Stdout, want)
+	}
+}
+
+func TestExecutorRunSavesTruncatedOutputArtifact(t *testing.T) {
+	t.Parallel()
+
+	exec := NewExecutor(config.ExecConfig{MaxCommandSeconds: 5}, t.TempDir(), 8)
+	store := NewOutputStore(t.TempDir())
+	exec.SetOutputStore(store)
+	ctx := llm.WithUserID(context.Background(), 7)
+	res, err := exec.Run(ctx, ExecRequest{Command: "sh", Args: []string{"-c", "printf '0123456789abcdef'; printf 'short' >&2"}})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !res.Truncated || res.Stdout != "01234567\n[TRUNCATED]" || res.StdoutArtifact == "" {
+		t.Fatalf("expected truncated stdout with artifact, got %#v", res)
+	}
+	if res.Stderr != "short" || res.StderrArtifact != "" {
+		t.Fatalf("stderr should be untouched, got %#v", res)
+	}
+
+	f, err := store.Open(7, res.StdoutArtifact)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	full, _ := io.ReadAll(f)
+	if string(full) != "0123456789abcdef" {
+		t.Fatalf("artifact = %q, want full stdout", full)
+	}
+	if _, err := store.Open(8, res.StdoutArtifact); !errors.Is(err, ErrArtifactNotFound) {
+		t.Fatalf("other users must not see the artifact, got %v", err)
+	}
+	if _, err := store.Open(7, "../../etc/passwd"); !errors.Is(err, ErrArtifactNotFound) {
+		t.Fatalf("expected invalid id to be rejected, got %v", err)
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	t.Parallel()
+
+	exec := NewExecutor(config.ExecConfig{Container: config.ExecContainerConfig{
+		Runtime: "podman", Image: "golang:1.24", CPUs: "2", Memory: "1g", Network: "bridge", User: "1000:1000",
+	}}, t.TempDir(), 0)
+	ctx := sandbox.WithEnv(context.Background(), map[string]string{"TOKEN": "s3cret"})
+	if got := exec.backend(ctx); got != sandbox.ExecBackendHost {
+		t.Fatalf("backend = %q, want host by default", got)
+	}
+	ctx = sandbox.WithExecBackend(ctx, sandbox.ExecBackendContainer)
+	if got := exec.backend(ctx); got != sandbox.ExecBackendContainer {
+		t.Fatalf("backend = %q, want container from context", got)
+	}
+
+	c := exec.containerCommand(ctx, "/work/project", "go", []string{"test", "./..."}, false)
+	args := strings.Join(c.Args, " ")
+	if !strings.HasPrefix(args, "podman run --rm --name manifold-cli-") {
+		t.Fatalf("unexpected command %q", args)
+	}
+	for _, want := range []string{
+		"--workdir /workspace --volume /work/project:/workspace:rw",
+		"--network bridge --cpus 2 --memory 1g --user 1000:1000 --env TOKEN golang:1.24 go test ./...",
+	} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("command %q missing %q", args, want)
+		}
+	}
+	if strings.Contains(args, "s3cret") || strings.Contains(args, "--interactive") {
+		t.Fatalf("command %q leaks env values or requests stdin", args)
+	}
+	if c.Env[len(c.Env)-1] != "TOKEN=s3cret" {
+		t.Fatalf("expected per-run env on the client process, got %v", c.Env[len(c.Env)-1])
+	}
+}
+
+func TestExecutorRunKillsSpawnedProcessesOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	exec := NewExecutor(config.ExecConfig{MaxCommandSeconds: 30}, t.TempDir(), 0)
+	start := time.Now()
+	// The background sleep inherits stdout; without killing the process
+	// group it would keep Run waiting for the full 30 seconds.
+	res, err := exec.Run(context.Background(), ExecRequest{Command: "sh", Args: []string{"-c", "sleep 30 & sleep 30"}, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Run took %s after the timeout", elapsed)
+	}
+	if res.OK || !res.TimedOut || res.ExitCode != 124 || !strings.Contains(res.Error, "timed out") {
+		t.Fatalf("unexpected result %#v", res)
+	}
+}
+
+func TestExecutorRunReportsCallerDeadline(t *testing.T) {
+	t.Parallel()
+
+	exec := NewExecutor(config.ExecConfig{MaxCommandSeconds: 30}, t.TempDir(), 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := exec.Run(ctx, ExecRequest{Command: "sleep", Args: []string{"30"}, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !res.TimedOut || res.ExitCode != 124 || !strings.Contains(res.Error, "caller's deadline") || strings.Contains(res.Error, "timed out after 10s") {
+		t.Fatalf("expected the caller's deadline to be reported, got %#v", res)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+)
+
+// containerWorkdir is where the base directory is mounted in the container.
+const containerWorkdir = "/workspace"
+
+// backend returns the execution backend for a run: the one set on ctx, e.g.
+// by a specialist, or else the configured default.
+func (e *ExecutorImpl) backend(ctx context.Context) string {
+	return Backend(ctx, e.cfg.Container)
+}
+
+// Backend returns the execution backend for a run: the one set on ctx, e.g.
+// by a specialist, or else the default from cc.
+func Backend(ctx context.Context, cc config.ExecContainerConfig) string {
+	if b, ok := sandbox.ExecBackendFromContext(ctx); ok {
+		return b
+	}
+	if cc.Enabled {
+		return sandbox.ExecBackendContainer
+	}
+	return sandbox.ExecBackendHost
+}
+
+// containerCommand wraps command in an ephemeral container with base
+// bind-mounted read-write as the working directory.
+func (e *ExecutorImpl) containerCommand(ctx context.Context, base, command string, args []string, stdin bool) *exec.Cmd {
+	return ContainerCommand(ctx, e.cfg.Container, ContainerSpec{
+		Base:    base,
+		Env:     sandbox.EnvFromContext(ctx),
+		Command: command,
+		Args:    args,
+		Stdin:   stdin,
+	})
+}
+
+// ContainerSpec describes a command run by ContainerCommand.
+type ContainerSpec struct {
+	// Image overrides the configured image.
+	Image string
+	// Base is mounted read-write at /workspace, the working directory.
+	Base string
+	// Mounts are extra --volume specs, e.g. "/tmp/x:/x:ro".
+	Mounts []string
+	// Env holds KEY=VALUE pairs for the container. They are forwarded by
+	// name so the values stay out of the argument list.
+	Env     []string
+	Command string
+	Args    []string
+	Stdin   bool
+}
+
+// ContainerCommand wraps spec in an ephemeral "<runtime> run --rm" limited
+// by cc. Cancelling ctx removes the container as well as the client.
+func ContainerCommand(ctx context.Context, cc config.ExecContainerConfig, spec ContainerSpec) *exec.Cmd {
+	runtime := cc.Runtime
+	if runtime == "" {
+		runtime = "docker"
+	}
+	name := containerName()
+
+	runArgs := []string{"run", "--rm", "--name", name, "--workdir", containerWorkdir,
+		"--volume", spec.Base + ":" + containerWorkdir + ":rw"}
+	for _, m := range spec.Mounts {
+		runArgs = append(runArgs, "--volume", m)
+	}
+	if spec.Stdin {
+		runArgs = append(runArgs, "--interactive")
+	}
+	network := cc.Network
+	if network == "" {
+		network = "none"
+	}
+	runArgs = append(runArgs, "--network", network)
+	if cc.CPUs != "" {
+		runArgs = append(runArgs, "--cpus", cc.CPUs)
+	}
+	if cc.Memory != "" {
+		runArgs = append(runArgs, "--memory", cc.Memory)
+	}
+	if cc.User != "" {
+		runArgs = append(runArgs, "--user", cc.User)
+	}
+	for _, kv := range spec.Env {
+		k, _, _ := strings.Cut(kv, "=")
+		runArgs = append(runArgs, "--env", k)
+	}
+	image := spec.Image
+	if image == "" {
+		image = cc.Image
+	}
+	if image == "" {
+		image = "alpine:3"
+	}
+	runArgs = append(runArgs, image, spec.Command)
+	runArgs = append(runArgs, spec.Args...)
+
+	c := exec.CommandContext(ctx, runtime, runArgs...)
+	c.Env = append(os.Environ(), spec.Env...)
+	// Killing the client does not stop the container, so remove it too
+	// when the run is cancelled or times out.
+	c.Cancel = func() error {
+		rmCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = exec.CommandContext(rmCtx, runtime, "rm", "--force", name).Run()
+		return c.Process.Kill()
+	}
+	return c
+}
+
+func containerName() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return "manifold-cli-" + hex.EncodeToString(b[:])
+}
@@ -14,6 +14,7 @@ import (
 	"manifold/internal/config"
 	"manifold/internal/sandbox"
 
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	otelmetric "go.opentelemetry.io/otel/metric"
@@ -33,6 +34,16 @@ type ExecResult struct {
 	Stderr    string `json:"stderr"`
 	Duration  int64  `json:"duration_ms"`
 	Truncated bool   `json:"truncated"`
+	// TimedOut is set when the command ran past its timeout and was killed.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Error explains a command that was killed for timing out or because
+	// the run was cancelled.
+	Error string `json:"error,omitempty"`
+	// StdoutArtifact and StderrArtifact reference the full output when it
+	// was truncated and an OutputStore is configured.
+	StdoutArtifact string `json:"stdout_artifact,omitempty"`
+	StderrArtifact string `json:"stderr_artifact,omitempty"`
+	ArtifactHint   string `json:"artifact_hint,omitempty"`
 }
 
 type Executor interface {
@@ -46,6 +57,8 @@ type ExecutorImpl struct {
 	blocked map[string]struct{}
 	// output limit in bytes
 	outLimit int
+	// artifacts keeps full output that exceeds outLimit; nil drops it.
+	artifacts *OutputStore
 }
 
 func NewExecutor(cfg config.ExecConfig, workdir string, outLimit int) *ExecutorImpl {
@@ -60,6 +73,11 @@ func NewExecutor(cfg config.ExecConfig, workdir string, outLimit int) *ExecutorI
 	return &ExecutorImpl{cfg: cfg, workdir: workdir, blocked: blocked, outLimit: outLimit}
 }
 
+// SetOutputStore makes Run save truncated stdout and stderr to store.
+func (e *ExecutorImpl) SetOutputStore(store *OutputStore) {
+	e.artifacts = store
+}
+
 func normalizeCommandArgs(command string, args []string) (string, []string) {
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
@@ -110,12 +128,24 @@ func (e *ExecutorImpl) Run(ctx context.Context, req ExecRequest) (ExecResult, er
 	if tout <= 0 || tout > time.Duration(e.cfg.MaxCommandSeconds)*time.Second {
 		tout = time.Duration(e.cfg.MaxCommandSeconds) * time.Second
 	}
-	ctx, cancel := context.WithTimeout(ctx, tout)
+	parent := ctx
+	ctx, cancel := context.WithTimeout(parent, tout)
 	defer cancel()
 
-	c := exec.CommandContext(ctx, req.Command, safeArgs...)
-	c.Dir = base
-	c.Env = os.Environ()
+	backend := e.backend(ctx)
+	var c *exec.Cmd
+	if backend == sandbox.ExecBackendContainer {
+		c = e.containerCommand(ctx, base, req.Command, safeArgs, req.Stdin != "")
+	} else {
+		c = exec.CommandContext(ctx, req.Command, safeArgs...)
+		c.Dir = base
+		// Per-run variables (e.g. project secrets) come last so they take precedence.
+		c.Env = append(os.Environ(), sandbox.EnvFromContext(ctx)...)
+		killProcessGroup(c)
+	}
+	// Stop waiting for output shortly after a kill, even if something still
+	// holds the pipes.
+	c.WaitDelay = 2 * time.Second
 	var stdout, stderr bytes.Buffer
 	c.Stdout = &stdout
 	c.Stderr = &stderr
@@ -129,34 +159,54 @@ func (e *ExecutorImpl) Run(ctx context.Context, req ExecRequest) (ExecResult, er
 	cmdCounter.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("command", req.Command)))
 	durHist.Record(ctx, dur.Milliseconds(), otelmetric.WithAttributes(attribute.String("command", req.Command)))
 
-	exit := 0
+	res := ExecResult{OK: err == nil, Duration: dur.Milliseconds()}
 	if err != nil {
 		var ee *exec.ExitError
-		if errors.As(err, &ee) {
-			exit = ee.ExitCode()
-		} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			exit = 124
-		} else {
-			exit = 1
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil:
+			res.ExitCode = 124
+			res.TimedOut = true
+			res.Error = fmt.Sprintf("command timed out after %s and was killed", tout)
+		case errors.Is(parent.Err(), context.DeadlineExceeded):
+			// The tool call or run deadline came before the command's own.
+			res.ExitCode = 124
+			res.TimedOut = true
+			res.Error = fmt.Sprintf("command hit the caller's deadline after %s, before its %s timeout, and was killed", dur.Round(time.Second), tout)
+		case ctx.Err() != nil:
+			res.ExitCode = 130
+			res.Error = "command was cancelled and killed"
+		case errors.As(err, &ee):
+			res.ExitCode = ee.ExitCode()
+		default:
+			res.ExitCode = 1
 		}
 	}
-	span.SetAttributes(attribute.String("cli.command", req.Command), attribute.Int("cli.exit_code", exit), attribute.Int64("cli.duration_ms", dur.Milliseconds()))
-
-	outS := stdout.String()
-	errS := stderr.String()
-	trunc := false
-	if e.outLimit > 0 {
-		if len(outS) > e.outLimit {
-			outS = outS[:e.outLimit] + "\n[TRUNCATED]"
-			trunc = true
-		}
-		if len(errS) > e.outLimit {
-			errS = errS[:e.outLimit] + "\n[TRUNCATED]"
-			trunc = true
-		}
+	span.SetAttributes(attribute.String("cli.command", req.Command), attribute.String("cli.backend", backend), attribute.Int("cli.exit_code", res.ExitCode), attribute.Bool("cli.timed_out", res.TimedOut), attribute.Int64("cli.duration_ms", dur.Milliseconds()))
+	res.Stdout, res.StdoutArtifact = e.truncate(ctx, "stdout", stdout.Bytes())
+	res.Stderr, res.StderrArtifact = e.truncate(ctx, "stderr", stderr.Bytes())
+	res.Truncated = e.outLimit > 0 && (stdout.Len() > e.outLimit || stderr.Len() > e.outLimit)
+	if res.StdoutArtifact != "" || res.StderrArtifact != "" {
+		res.ArtifactHint = "Output was truncated; the full text is saved as the referenced artifact and can be fetched from /api/artifacts/cli/{id}. Narrow the command (grep, head, tail) to see specific parts."
 	}
+	return res, nil
+}
 
-	return ExecResult{OK: err == nil, ExitCode: exit, Stdout: outS, Stderr: errS, Duration: dur.Milliseconds(), Truncated: trunc}, nil
+// truncate caps data at the output limit. When it cuts and an OutputStore
+// is configured, the full output is saved and its artifact ID returned.
+func (e *ExecutorImpl) truncate(ctx context.Context, stream string, data []byte) (string, string) {
+	if e.outLimit <= 0 || len(data) <= e.outLimit {
+		return string(data), ""
+	}
+	preview := string(data[:e.outLimit]) + "\n[TRUNCATED]"
+	if e.artifacts == nil {
+		return preview, ""
+	}
+	id, err := e.artifacts.Save(ctx, stream, data)
+	if err != nil {
+		log.Warn().Err(err).Str("stream", stream).Msg("cli_artifact_save_failed")
+		return preview, ""
+	}
+	return preview, id
 }
 
 // Tool adapter ---------------------------------------------------------------
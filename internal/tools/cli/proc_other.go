@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are unavailable; only
+// the command itself is killed when its context ends.
+func killProcessGroup(*exec.Cmd) {}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package cli
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts c in its own process group and kills the whole
+// group when c's context ends, so processes the command spawned neither keep
+// running nor hold its output pipes open.
+func killProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -10,7 +10,7 @@ func buildSchema(t *tool) map[string]any {
 	}
 	return map[string]any{
 		"name":        "run_cli",
-		"description": "Execute a CLI command in a restricted working directory (no shell, no absolute paths).",
+		"description": "Execute a CLI command in a restricted working directory (no shell, no absolute paths). Long output is truncated; the full text is then saved and referenced by stdout_artifact or stderr_artifact.",
 		"parameters": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
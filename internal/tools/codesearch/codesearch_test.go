@@ -0,0 +1,132 @@
+package codesearch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+)
+
+var project = map[string]string{
+	"store/store.go": `// Package store keeps widgets. It is in memory.
+package store
+
+// Store holds widgets.
+type Store struct{ items map[string]int }
+
+// Get returns a widget count.
+func (s *Store) Get(name string) (int, bool) {
+	n, ok := s.items[name]
+	return n, ok
+}
+
+func New() *Store { return &Store{items: map[string]int{}} }
+`,
+	"cmd/main.go": `package main
+
+import "example/store"
+
+func main() {
+	s := store.New()
+	s.Get("a")
+}
+`,
+	"tools/report.py": `"""Report generation helpers."""
+
+class Report:
+    def render(self, rows):
+        return Store(rows)
+
+def build_report(rows):
+    return Report().render(rows)
+`,
+	"node_modules/dep/index.js": "function ignored() {}\n",
+}
+
+func newTestTool(t *testing.T, search databases.FullTextSearch) (*tool, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range project {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewTool(NewIndex(config.ExecCodeSearchConfig{Ctags: "off"}, search), dir), dir
+}
+
+func call(t *testing.T, tl *tool, raw string) map[string]any {
+	t.Helper()
+	out, err := tl.Call(context.Background(), json.RawMessage(raw))
+	if err != nil {
+		t.Fatalf("Call(%s): %v", raw, err)
+	}
+	return out.(map[string]any)
+}
+
+func TestSymbolsAndReferences(t *testing.T) {
+	tl, _ := newTestTool(t, nil)
+
+	syms := call(t, tl, `{"action":"symbol","query":"Store.Get"}`)["symbols"].([]SymbolHit)
+	if len(syms) != 1 || syms[0].Path != "store/store.go" || syms[0].Line != 8 || syms[0].Kind != "method" ||
+		syms[0].Signature != "func (s *Store) Get(name string) (int, bool)" || syms[0].References != 1 {
+		t.Fatalf("unexpected Store.Get %+v", syms)
+	}
+	syms = call(t, tl, `{"action":"symbol","query":"render"}`)["symbols"].([]SymbolHit)
+	if len(syms) != 1 || syms[0].Scope != "Report" || syms[0].Kind != "method" || syms[0].Line != 4 {
+		t.Fatalf("unexpected python method %+v", syms)
+	}
+	if syms := call(t, tl, `{"action":"symbol","query":"ignored"}`)["symbols"].([]SymbolHit); len(syms) != 0 {
+		t.Fatalf("node_modules should not be indexed: %+v", syms)
+	}
+
+	refs := call(t, tl, `{"action":"references","query":"Store"}`)["references"].([]Reference)
+	var got []string
+	for _, r := range refs {
+		got = append(got, r.Path)
+	}
+	// The definition line itself is not a reference.
+	if strings.Join(got, " ") != "store/store.go store/store.go store/store.go tools/report.py" {
+		t.Fatalf("unexpected references %+v", refs)
+	}
+}
+
+func TestSearchAndRepoMap(t *testing.T) {
+	search := databases.NewMemorySearch()
+	tl, dir := newTestTool(t, search)
+
+	files := call(t, tl, `{"action":"search","query":"widgets"}`)["files"].([]FileHit)
+	if len(files) != 1 || files[0].Path != "store/store.go" || files[0].Summary != "Package store keeps widgets." {
+		t.Fatalf("unexpected search result %+v", files)
+	}
+	if _, ok, _ := search.GetByID(context.Background(), docID(dir, "tools/report.py")); !ok {
+		t.Fatal("file summary not stored in the search backend")
+	}
+
+	m := call(t, tl, `{"action":"map"}`)["map"].(string)
+	if !strings.HasPrefix(m, "store/store.go — Package store keeps widgets.\n") || !strings.Contains(m, "  8: func (s *Store) Get(name string) (int, bool)\n") {
+		t.Fatalf("unexpected map:\n%s", m)
+	}
+	if small := call(t, tl, `{"action":"map","tokens":20}`)["map"].(string); !strings.Contains(small, "more files") {
+		t.Fatalf("expected a truncated map, got:\n%s", small)
+	}
+}
+
+func TestParseCtags(t *testing.T) {
+	out := []byte(`{"_type": "ptag", "name": "JSON_OUTPUT_VERSION"}
+{"_type": "tag", "name": "Widget", "path": "src/widget.rs", "pattern": "/^pub struct Widget {$/", "line": 3, "kind": "struct"}
+{"_type": "tag", "name": "count", "path": "src/widget.rs", "pattern": "/^    let count = 1;$/", "line": 9, "kind": "variable"}
+`)
+	got := parseCtags(out)["src/widget.rs"]
+	if len(got) != 1 || got[0].Name != "Widget" || got[0].Line != 3 || got[0].Signature != "pub struct Widget" {
+		t.Fatalf("unexpected tags %+v", got)
+	}
+}
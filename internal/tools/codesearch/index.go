@@ -0,0 +1,282 @@
+package codesearch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"manifold/internal/config"
+	"manifold/internal/persistence/databases"
+)
+
+// skipDirs are never indexed.
+var skipDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, "node_modules": true, "vendor": true, "dist": true,
+	"build": true, "target": true, ".venv": true, "venv": true, "__pycache__": true, ".next": true,
+	".cache": true, ".idea": true, ".vscode": true,
+}
+
+// minRefresh is how often a project is re-walked for changes.
+const minRefresh = 5 * time.Second
+
+// Index keeps per-project symbol tables, refreshed incrementally from file
+// modification times, and mirrors one document per file into the search
+// backend.
+type Index struct {
+	cfg    config.ExecCodeSearchConfig
+	search databases.FullTextSearch
+	ctags  string
+
+	mu    sync.Mutex
+	repos map[string]*repo
+}
+
+type repo struct {
+	mu        sync.Mutex
+	root      string
+	files     map[string]*fileEntry
+	refreshed time.Time
+	truncated bool
+	refs      map[string]int
+}
+
+type fileEntry struct {
+	modTime time.Time
+	size    int64
+	summary string
+	symbols []Symbol
+	idents  map[string]struct{}
+}
+
+// NewIndex returns an index. search may be nil, in which case full-text
+// queries scan the in-memory symbols.
+func NewIndex(cfg config.ExecCodeSearchConfig, search databases.FullTextSearch) *Index {
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = 5000
+	}
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = 512 << 10
+	}
+	ix := &Index{cfg: cfg, search: search, repos: map[string]*repo{}}
+	switch strings.TrimSpace(cfg.Ctags) {
+	case "off":
+	case "":
+		ix.ctags, _ = exec.LookPath("ctags")
+	default:
+		ix.ctags = cfg.Ctags
+	}
+	return ix
+}
+
+// load returns the up-to-date index for root.
+func (ix *Index) load(ctx context.Context, root string) (*repo, error) {
+	root = filepath.Clean(root)
+	ix.mu.Lock()
+	r, ok := ix.repos[root]
+	if !ok {
+		r = &repo{root: root, files: map[string]*fileEntry{}}
+		ix.repos[root] = r
+	}
+	ix.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.refreshed) < minRefresh {
+		return r, nil
+	}
+	if err := ix.refresh(ctx, r); err != nil {
+		return nil, err
+	}
+	r.refreshed = time.Now()
+	return r, nil
+}
+
+func (ix *Index) refresh(ctx context.Context, r *repo) error {
+	type candidate struct {
+		path string
+		info fs.FileInfo
+	}
+	var found []candidate
+	r.truncated = false
+	err := filepath.WalkDir(r.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != r.root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := languages[strings.ToLower(filepath.Ext(p))]; !ok || !d.Type().IsRegular() {
+			return nil
+		}
+		if len(found) >= ix.cfg.MaxFiles {
+			r.truncated = true
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > ix.cfg.MaxFileBytes {
+			return nil
+		}
+		rel, _ := filepath.Rel(r.root, p)
+		found = append(found, candidate{path: filepath.ToSlash(rel), info: info})
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(found))
+	var changed []string
+	for _, c := range found {
+		seen[c.path] = true
+		if e, ok := r.files[c.path]; ok && e.modTime.Equal(c.info.ModTime()) && e.size == c.info.Size() {
+			continue
+		}
+		r.files[c.path] = &fileEntry{modTime: c.info.ModTime(), size: c.info.Size()}
+		changed = append(changed, c.path)
+	}
+	for p := range r.files {
+		if !seen[p] {
+			delete(r.files, p)
+			ix.unpublish(ctx, r.root, p)
+			r.refs = nil
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	r.refs = nil
+
+	// Non-Go files go through ctags in batches when it is available.
+	var viaCtags map[string][]Symbol
+	if ix.ctags != "" {
+		var others []string
+		for _, p := range changed {
+			if filepath.Ext(p) != ".go" {
+				others = append(others, p)
+			}
+		}
+		viaCtags = map[string][]Symbol{}
+		for batch := range slices.Chunk(others, 200) {
+			res, ok := runCtags(ctx, ix.ctags, r.root, batch)
+			if !ok {
+				log.Debug().Str("ctags", ix.ctags).Msg("code_search_ctags_failed")
+				viaCtags = nil
+				break
+			}
+			for p, syms := range res {
+				viaCtags[p] = syms
+			}
+		}
+	}
+
+	for _, p := range changed {
+		src, err := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(p)))
+		if err != nil {
+			delete(r.files, p)
+			continue
+		}
+		e := r.files[p]
+		lang := languages[strings.ToLower(filepath.Ext(p))]
+		switch {
+		case lang == "go":
+			e.symbols, e.summary = extractGo(p, src)
+		case viaCtags != nil:
+			e.symbols = viaCtags[p]
+			_, e.summary = extractPatterns(lang, p, firstLines(src, 5))
+		default:
+			e.symbols, e.summary = extractPatterns(lang, p, src)
+		}
+		e.idents = identifiers(src)
+		ix.publish(ctx, r.root, p, e)
+	}
+	return nil
+}
+
+func firstLines(src []byte, n int) []byte {
+	for i, b := range src {
+		if b == '\n' {
+			if n--; n == 0 {
+				return src[:i]
+			}
+		}
+	}
+	return src
+}
+
+// docID identifies a file's document in the search backend.
+func docID(root, path string) string {
+	sum := sha256.Sum256([]byte(root))
+	return "code:" + hex.EncodeToString(sum[:8]) + ":" + path
+}
+
+func (ix *Index) publish(ctx context.Context, root, path string, e *fileEntry) {
+	if ix.search == nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(path + "\n")
+	if e.summary != "" {
+		b.WriteString(e.summary + "\n")
+	}
+	names := make([]string, 0, len(e.symbols))
+	for _, s := range e.symbols {
+		fmt.Fprintf(&b, "%s %s %s\n", s.Kind, s.Name, s.Signature)
+		names = append(names, s.Name)
+	}
+	meta := map[string]string{"type": "code_file", "root": root, "path": path, "symbols": strings.Join(names, ",")}
+	if err := ix.search.Index(ctx, docID(root, path), b.String(), meta); err != nil {
+		log.Debug().Err(err).Str("path", path).Msg("code_search_index_failed")
+	}
+}
+
+func (ix *Index) unpublish(ctx context.Context, root, path string) {
+	if ix.search == nil {
+		return
+	}
+	_ = ix.search.Remove(ctx, docID(root, path))
+}
+
+// refCounts returns, per symbol name, how many files other than the
+// defining ones mention it. The result is cached until files change.
+func (r *repo) refCounts() map[string]int {
+	if r.refs != nil {
+		return r.refs
+	}
+	defining := map[string]int{}
+	for _, e := range r.files {
+		seen := map[string]bool{}
+		for _, s := range e.symbols {
+			if !seen[s.Name] {
+				seen[s.Name] = true
+				defining[s.Name]++
+			}
+		}
+	}
+	counts := make(map[string]int, len(defining))
+	for _, e := range r.files {
+		for id := range e.idents {
+			if _, ok := defining[id]; ok {
+				counts[id]++
+			}
+		}
+	}
+	for name, n := range defining {
+		counts[name] = max(counts[name]-n, 0)
+	}
+	r.refs = counts
+	return counts
+}
@@ -0,0 +1,328 @@
+package codesearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Symbol is one definition found in a source file.
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Signature string `json:"signature,omitempty"`
+	// Scope is the enclosing type or class, e.g. the receiver of a Go
+	// method.
+	Scope string `json:"scope,omitempty"`
+}
+
+// languages maps file extensions to the pattern set used when neither the
+// Go parser nor ctags applies.
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".js": "js", ".jsx": "js", ".mjs": "js", ".cjs": "js",
+	".ts": "js", ".tsx": "js", ".rs": "rust", ".java": "java", ".kt": "java", ".cs": "java",
+	".rb": "ruby", ".php": "php", ".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".hpp": "c",
+	".swift": "swift", ".scala": "java",
+}
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// patterns are deliberately simple line-based definitions. They miss some
+// forms but never need a toolchain.
+var patterns = map[string][]pattern{
+	"python": {
+		{"class", regexp.MustCompile(`^(\s*)class\s+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:async\s+)?def\s+([A-Za-z_]\w*)\s*\(`)},
+	},
+	"js": {
+		{"class", regexp.MustCompile(`^(\s*)(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		{"interface", regexp.MustCompile(`^(\s*)(?:export\s+)?(?:interface|type)\s+([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:export\s+)?(?:const|let)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*=>`)},
+	},
+	"rust": {
+		{"function", regexp.MustCompile(`^(\s*)(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^(\s*)(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|union)\s+([A-Za-z_]\w*)`)},
+	},
+	"java": {
+		{"class", regexp.MustCompile(`^(\s*)(?:(?:public|private|protected|internal|abstract|final|static|sealed|data|open)\s+)*(?:class|interface|enum|record|object|struct)\s+([A-Za-z_]\w*)`)},
+		{"method", regexp.MustCompile(`^(\s+)(?:(?:public|private|protected|internal|static|final|abstract|override|async|virtual|synchronized)\s+)+[\w<>\[\],.? ]+?\s+([A-Za-z_]\w*)\s*\(`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:(?:private|public|internal|override|suspend)\s+)*fun\s+(?:<[^>]*>\s*)?([A-Za-z_]\w*)`)},
+	},
+	"ruby": {
+		{"class", regexp.MustCompile(`^(\s*)(?:class|module)\s+([A-Z]\w*)`)},
+		{"method", regexp.MustCompile(`^(\s*)def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`)},
+	},
+	"php": {
+		{"class", regexp.MustCompile(`^(\s*)(?:abstract\s+|final\s+)?(?:class|interface|trait)\s+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+([A-Za-z_]\w*)`)},
+	},
+	"c": {
+		{"type", regexp.MustCompile(`^()(?:typedef\s+)?(?:struct|class|enum|union)\s+([A-Za-z_]\w*)\s*[{:]`)},
+		{"function", regexp.MustCompile(`^()[A-Za-z_][\w\s\*&:<>,]*?[\s\*&]([A-Za-z_]\w*)\s*\([^;]*$`)},
+	},
+	"swift": {
+		{"type", regexp.MustCompile(`^(\s*)(?:(?:public|private|internal|open|final)\s+)*(?:class|struct|enum|protocol|extension)\s+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^(\s*)(?:(?:public|private|internal|open|static|override|mutating)\s+)*func\s+([A-Za-z_]\w*)`)},
+	},
+}
+
+// cKeywords are control statements the C function pattern would otherwise
+// match.
+var cKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true}
+
+// extractGo parses Go source with go/parser. Files that do not parse yield
+// no symbols.
+func extractGo(path string, src []byte) ([]Symbol, string) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, ""
+	}
+	var out []Symbol
+	add := func(name, kind, scope string, pos token.Pos, sig string) {
+		if name == "_" {
+			return
+		}
+		out = append(out, Symbol{Name: name, Kind: kind, Path: path, Line: fset.Position(pos).Line, Signature: sig, Scope: scope})
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind, scope := "func", ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				kind, scope = "method", receiverType(d.Recv.List[0].Type)
+			}
+			fn := *d
+			fn.Body, fn.Doc = nil, nil
+			add(d.Name.Name, kind, scope, d.Pos(), nodeString(fset, &fn))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch s.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					add(s.Name.Name, kind, "", s.Pos(), "type "+s.Name.Name+" "+typeSummary(fset, s.Type))
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, n := range s.Names {
+						add(n.Name, kind, "", n.Pos(), "")
+					}
+				}
+			}
+		}
+	}
+	summary := ""
+	if f.Doc != nil {
+		summary = firstSentence(f.Doc.Text())
+	}
+	return out, summary
+}
+
+func receiverType(e ast.Expr) string {
+	for {
+		switch t := e.(type) {
+		case *ast.StarExpr:
+			e = t.X
+		case *ast.IndexExpr:
+			e = t.X
+		case *ast.IndexListExpr:
+			e = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// typeSummary renders a type without struct or interface bodies.
+func typeSummary(fset *token.FileSet, e ast.Expr) string {
+	switch e.(type) {
+	case *ast.StructType:
+		return "struct{...}"
+	case *ast.InterfaceType:
+		return "interface{...}"
+	}
+	return nodeString(fset, e)
+}
+
+func nodeString(fset *token.FileSet, n any) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, fset, n); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// extractPatterns finds definitions line by line. Indented definitions are
+// scoped to the nearest less-indented class.
+func extractPatterns(lang, path string, src []byte) ([]Symbol, string) {
+	pats := patterns[lang]
+	var (
+		out     []Symbol
+		summary string
+		classes []struct {
+			name   string
+			indent int
+		}
+	)
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if summary == "" && line <= 5 {
+			summary = commentText(text)
+		}
+		for _, p := range pats {
+			m := p.re.FindStringSubmatch(text)
+			if m == nil || (lang == "c" && cKeywords[m[2]]) {
+				continue
+			}
+			indent := len(m[1])
+			for len(classes) > 0 && classes[len(classes)-1].indent >= indent {
+				classes = classes[:len(classes)-1]
+			}
+			sym := Symbol{Name: m[2], Kind: p.kind, Path: path, Line: line, Signature: signatureLine(text)}
+			if len(classes) > 0 {
+				sym.Scope = classes[len(classes)-1].name
+				if sym.Kind == "function" {
+					sym.Kind = "method"
+				}
+			}
+			if p.kind == "class" || p.kind == "type" || p.kind == "interface" {
+				classes = append(classes, struct {
+					name   string
+					indent int
+				}{m[2], indent})
+			}
+			out = append(out, sym)
+			break
+		}
+	}
+	return out, summary
+}
+
+// signatureLine trims a definition line to its declaration.
+func signatureLine(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimSpace(strings.TrimSuffix(s, "{")), ":")
+	if len(s) > 160 {
+		s = s[:160] + "..."
+	}
+	return s
+}
+
+func commentText(line string) string {
+	line = strings.TrimSpace(line)
+	for _, p := range []string{"//", "#", "/**", "/*", `"""`, "*"} {
+		if rest, ok := strings.CutPrefix(line, p); ok && !strings.HasPrefix(line, "#!") {
+			return firstSentence(strings.TrimSuffix(strings.TrimSuffix(rest, "*/"), `"""`))
+		}
+	}
+	return ""
+}
+
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i > 0 {
+		s = s[:i+1]
+	}
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+// ctagsTag is one line of `ctags --output-format=json`.
+type ctagsTag struct {
+	Type      string `json:"_type"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Kind      string `json:"kind"`
+	Scope     string `json:"scope"`
+	Signature string `json:"signature"`
+	Pattern   string `json:"pattern"`
+}
+
+// runCtags extracts symbols for files (relative to root) with Universal
+// Ctags. It returns ok=false when ctags is unusable so callers can fall
+// back to patterns.
+func runCtags(ctx context.Context, bin, root string, files []string) (map[string][]Symbol, bool) {
+	args := append([]string{"--output-format=json", "--fields=+nKSZ", "--extras=-F", "-f", "-"}, files...)
+	c := exec.CommandContext(ctx, bin, args...)
+	c.Dir = root
+	out, err := c.Output()
+	if err != nil {
+		return nil, false
+	}
+	return parseCtags(out), true
+}
+
+func parseCtags(out []byte) map[string][]Symbol {
+	res := map[string][]Symbol{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var t ctagsTag
+		if json.Unmarshal(sc.Bytes(), &t) != nil || t.Type != "tag" || t.Name == "" {
+			continue
+		}
+		switch t.Kind {
+		case "variable", "local", "parameter", "import", "namespace", "package", "label":
+			continue
+		}
+		sig := t.Name + t.Signature
+		if p := strings.TrimSuffix(strings.TrimPrefix(t.Pattern, "/^"), "$/"); p != "" {
+			sig = signatureLine(p)
+		}
+		path := filepath.ToSlash(t.Path)
+		res[path] = append(res[path], Symbol{Name: t.Name, Kind: t.Kind, Path: path, Line: t.Line, Signature: sig, Scope: t.Scope})
+	}
+	return res
+}
+
+// identifiers returns the distinct identifiers in src, used to count
+// references cheaply.
+func identifiers(src []byte) map[string]struct{} {
+	ids := map[string]struct{}{}
+	start := -1
+	for i, r := range string(src) {
+		word := r == '_' || unicode.IsLetter(r) || (start >= 0 && unicode.IsDigit(r))
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			ids[string(src[start:i])] = struct{}{}
+			start = -1
+		}
+	}
+	if start >= 0 {
+		ids[string(src[start:])] = struct{}{}
+	}
+	return ids
+}
@@ -0,0 +1,358 @@
+// Package codesearch provides the code_search tool: symbol lookup,
+// references, full-text search over file summaries and a condensed repo map
+// for the project in the run's working directory.
+package codesearch
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"manifold/internal/sandbox"
+)
+
+const (
+	defaultLimit      = 20
+	maxLimit          = 200
+	defaultMapTokens  = 1024
+	maxSymbolsPerFile = 12
+)
+
+type tool struct {
+	ix      *Index
+	workdir string
+}
+
+// NewTool constructs code_search over the run's base directory, falling
+// back to workdir.
+func NewTool(ix *Index, workdir string) *tool { return &tool{ix: ix, workdir: workdir} }
+
+func (t *tool) Name() string { return "code_search" }
+
+func (t *tool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name": t.Name(),
+		"description": "Navigate the project's source code without reading whole files. " +
+			"action=symbol finds definitions by name (\"Type.Method\" narrows to a scope) with signatures and locations; " +
+			"action=references lists lines that use an identifier; " +
+			"action=search ranks files by free text over paths, summaries and symbol names; " +
+			"action=map returns a condensed map of the most referenced files and their symbols.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{"type": "string", "enum": []string{"symbol", "references", "search", "map"}},
+				"query":  map[string]any{"type": "string", "description": "Symbol name, identifier or search text. Not used by map."},
+				"path":   map[string]any{"type": "string", "description": "Limit results to files under this directory."},
+				"kind":   map[string]any{"type": "string", "description": "For symbol: only this kind, e.g. func, method, struct, class."},
+				"limit":  map[string]any{"type": "integer", "minimum": 1, "maximum": maxLimit, "description": fmt.Sprintf("Maximum results (default %d).", defaultLimit)},
+				"tokens": map[string]any{"type": "integer", "minimum": 128, "description": fmt.Sprintf("Approximate size of the map (default %d).", defaultMapTokens)},
+			},
+			"required": []string{"action"},
+		},
+	}
+}
+
+type args struct {
+	Action string `json:"action"`
+	Query  string `json:"query"`
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Limit  int    `json:"limit"`
+	Tokens int    `json:"tokens"`
+}
+
+func (t *tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var a args
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if a.Limit <= 0 {
+		a.Limit = defaultLimit
+	}
+	a.Limit = min(a.Limit, maxLimit)
+	if a.Path = strings.Trim(filepath.ToSlash(strings.TrimSpace(a.Path)), "/"); a.Path == "." {
+		a.Path = ""
+	}
+	if a.Path != "" && !filepath.IsLocal(a.Path) {
+		return nil, fmt.Errorf("path must stay inside the project: %q", a.Path)
+	}
+	a.Query = strings.TrimSpace(a.Query)
+	if a.Query == "" && a.Action != "map" {
+		return nil, errors.New("query is required")
+	}
+
+	root := sandbox.ResolveBaseDir(ctx, t.workdir)
+	r, err := t.ix.load(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := map[string]any{"ok": true}
+	if r.truncated {
+		res["note"] = fmt.Sprintf("only the first %d files are indexed", t.ix.cfg.MaxFiles)
+	}
+	switch a.Action {
+	case "symbol":
+		res["symbols"] = r.findSymbols(a)
+	case "references":
+		res["references"], err = r.references(a)
+	case "search":
+		res["files"] = t.ix.searchFiles(ctx, r, a)
+	case "map":
+		tokens := a.Tokens
+		if tokens <= 0 {
+			tokens = defaultMapTokens
+		}
+		res["map"] = r.repoMap(a.Path, tokens)
+	default:
+		return nil, fmt.Errorf("unknown action %q", a.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RepoMap renders a condensed map of root of roughly tokens size for use in
+// a system prompt.
+func (ix *Index) RepoMap(ctx context.Context, root string, tokens int) (string, error) {
+	r, err := ix.load(ctx, root)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.repoMap("", tokens), nil
+}
+
+func underPath(p, dir string) bool {
+	return dir == "" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// SymbolHit is a symbol with the number of other files that mention it.
+type SymbolHit struct {
+	Symbol
+	References int `json:"references"`
+}
+
+func (r *repo) findSymbols(a args) []SymbolHit {
+	scope, name := "", a.Query
+	if i := strings.LastIndex(a.Query, "."); i > 0 {
+		scope, name = a.Query[:i], a.Query[i+1:]
+	}
+	refs := r.refCounts()
+	var exact, partial []SymbolHit
+	lower := strings.ToLower(name)
+	for p, e := range r.files {
+		if !underPath(p, a.Path) {
+			continue
+		}
+		for _, s := range e.symbols {
+			if (a.Kind != "" && !strings.EqualFold(s.Kind, a.Kind)) || (scope != "" && !strings.EqualFold(s.Scope, scope)) {
+				continue
+			}
+			hit := SymbolHit{Symbol: s, References: refs[s.Name]}
+			switch {
+			case s.Name == name:
+				exact = append(exact, hit)
+			case strings.Contains(strings.ToLower(s.Name), lower):
+				partial = append(partial, hit)
+			}
+		}
+	}
+	byRank := func(a, b SymbolHit) int {
+		return cmp.Or(cmp.Compare(b.References, a.References), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Line, b.Line))
+	}
+	slices.SortFunc(exact, byRank)
+	slices.SortFunc(partial, byRank)
+	out := append(exact, partial...)
+	if len(out) > a.Limit {
+		out = out[:a.Limit]
+	}
+	if out == nil {
+		out = []SymbolHit{}
+	}
+	return out
+}
+
+// Reference is a line that mentions an identifier.
+type Reference struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func (r *repo) references(a args) ([]Reference, error) {
+	word, err := regexp.Compile(`(^|[^\w$])` + regexp.QuoteMeta(a.Query) + `($|[^\w$])`)
+	if err != nil {
+		return nil, err
+	}
+	defs := map[string]bool{}
+	var paths []string
+	for p, e := range r.files {
+		if _, ok := e.idents[a.Query]; ok && underPath(p, a.Path) {
+			paths = append(paths, p)
+			for _, s := range e.symbols {
+				if s.Name == a.Query {
+					defs[fmt.Sprintf("%s:%d", p, s.Line)] = true
+				}
+			}
+		}
+	}
+	slices.Sort(paths)
+	out := []Reference{}
+	for _, p := range paths {
+		src, err := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(bytes.NewReader(src))
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		for line := 1; sc.Scan(); line++ {
+			text := sc.Text()
+			if defs[fmt.Sprintf("%s:%d", p, line)] || !word.MatchString(text) {
+				continue
+			}
+			out = append(out, Reference{Path: p, Line: line, Text: signatureLine(text)})
+			if len(out) >= a.Limit {
+				return out, nil
+			}
+		}
+	}
+	return out, nil
+}
+
+// FileHit is a file matched by full-text search.
+type FileHit struct {
+	Path    string   `json:"path"`
+	Summary string   `json:"summary,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+func (ix *Index) searchFiles(ctx context.Context, r *repo, a args) []FileHit {
+	hit := func(p string) FileHit {
+		h := FileHit{Path: p}
+		if e := r.files[p]; e != nil {
+			h.Summary = e.summary
+			for _, s := range e.symbols {
+				if len(h.Symbols) == maxSymbolsPerFile {
+					break
+				}
+				h.Symbols = append(h.Symbols, s.Name)
+			}
+		}
+		return h
+	}
+	out := []FileHit{}
+	if ix.search != nil {
+		// The backend is shared, so over-fetch and keep this project's files.
+		if results, err := ix.search.Search(ctx, a.Query, a.Limit*10); err == nil {
+			for _, res := range results {
+				p := res.Metadata["path"]
+				if res.Metadata["type"] != "code_file" || res.Metadata["root"] != r.root || r.files[p] == nil || !underPath(p, a.Path) {
+					continue
+				}
+				out = append(out, hit(p))
+				if len(out) == a.Limit {
+					break
+				}
+			}
+			return out
+		}
+	}
+	terms := strings.Fields(strings.ToLower(a.Query))
+	type scored struct {
+		path  string
+		score int
+	}
+	var ranked []scored
+	for p, e := range r.files {
+		if !underPath(p, a.Path) {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(strings.ToLower(p + " " + e.summary))
+		for _, s := range e.symbols {
+			b.WriteString(" " + strings.ToLower(s.Name))
+		}
+		text, score := b.String(), 0
+		for _, term := range terms {
+			score += strings.Count(text, term)
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{p, score})
+		}
+	}
+	slices.SortFunc(ranked, func(a, b scored) int { return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.path, b.path)) })
+	for _, s := range ranked[:min(len(ranked), a.Limit)] {
+		out = append(out, hit(s.path))
+	}
+	return out
+}
+
+// repoMap lists the most referenced files first, each with its most
+// referenced symbols in source order, until about tokens*4 bytes.
+func (r *repo) repoMap(dir string, tokens int) string {
+	refs := r.refCounts()
+	type ranked struct {
+		path  string
+		score int
+	}
+	var files []ranked
+	for p, e := range r.files {
+		if !underPath(p, dir) || len(e.symbols) == 0 {
+			continue
+		}
+		score := 0
+		for _, s := range e.symbols {
+			score += refs[s.Name]
+		}
+		files = append(files, ranked{p, score})
+	}
+	slices.SortFunc(files, func(a, b ranked) int { return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.path, b.path)) })
+
+	budget := tokens * 4
+	var b strings.Builder
+	omitted := 0
+	for _, f := range files {
+		e := r.files[f.path]
+		syms := slices.Clone(e.symbols)
+		if len(syms) > maxSymbolsPerFile {
+			slices.SortStableFunc(syms, func(a, b Symbol) int { return cmp.Compare(refs[b.Name], refs[a.Name]) })
+			syms = syms[:maxSymbolsPerFile]
+			slices.SortFunc(syms, func(a, b Symbol) int { return cmp.Compare(a.Line, b.Line) })
+		}
+		var entry strings.Builder
+		entry.WriteString(f.path)
+		if e.summary != "" {
+			entry.WriteString(" — " + e.summary)
+		}
+		entry.WriteString("\n")
+		for _, s := range syms {
+			sig := s.Signature
+			if sig == "" {
+				sig = s.Kind + " " + s.Name
+			}
+			fmt.Fprintf(&entry, "  %d: %s\n", s.Line, sig)
+		}
+		if b.Len()+entry.Len() > budget {
+			omitted++
+			continue
+		}
+		b.WriteString(entry.String())
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, "(%d more files; use code_search for details)\n", omitted)
+	}
+	return b.String()
+}
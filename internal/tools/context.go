@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"manifold/internal/llm"
+)
+
+type contextRegistry struct {
+	base Registry
+	wrap func(context.Context) context.Context
+}
+
+// NewContextRegistry exposes base unchanged but passes every dispatch
+// through wrap first, so a view can attach per-caller settings (such as a
+// specialist's execution backend) to the context tools run with.
+func NewContextRegistry(base Registry, wrap func(context.Context) context.Context) Registry {
+	if base == nil || wrap == nil {
+		return base
+	}
+	return &contextRegistry{base: base, wrap: wrap}
+}
+
+func (r *contextRegistry) Schemas() []llm.ToolSchema { return r.base.Schemas() }
+
+func (r *contextRegistry) Dispatch(ctx context.Context, name string, raw json.RawMessage) ([]byte, error) {
+	return r.base.Dispatch(r.wrap(ctx), name, raw)
+}
+
+func (r *contextRegistry) Register(t Tool) { r.base.Register(t) }
+
+func (r *contextRegistry) Unregister(name string) { r.base.Unregister(name) }
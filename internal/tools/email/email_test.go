@@ -0,0 +1,197 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+)
+
+const rawMessage = "From: =?utf-8?q?J=C3=B6rg?= <jorg@example.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Quarterly report\r\n" +
+	"Date: Mon, 12 Oct 2026 09:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Numbers are <b>up</b> &amp; to the right.</p>\r\n" +
+	"--b1\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=\"q3.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--b1--\r\n"
+
+// fakeIMAP answers the commands the tools send and records them.
+func fakeIMAP(commands *[]string) func(context.Context, string) (net.Conn, error) {
+	return func(context.Context, string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			r := bufio.NewReader(server)
+			fmt.Fprint(server, "* OK ready\r\n")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+				*commands = append(*commands, cmd)
+				switch {
+				case strings.HasPrefix(cmd, "UID SEARCH"):
+					fmt.Fprint(server, "* SEARCH 3 7 9\r\n")
+				case strings.HasPrefix(cmd, "UID FETCH"):
+					header := "From: a@example.com\r\nSubject: Hi\r\n\r\n"
+					for _, uid := range []string{"7", "9"} {
+						if strings.Contains(cmd, "BODY.PEEK[]") {
+							if uid != "9" {
+								continue
+							}
+							header = rawMessage
+						}
+						fmt.Fprintf(server, "* 1 FETCH (UID %s FLAGS (\\Seen) RFC822.SIZE 120 BODY[] {%d}\r\n%s)\r\n", uid, len(header), header)
+					}
+				}
+				fmt.Fprintf(server, "%s OK done\r\n", tag)
+				if cmd == "LOGOUT" {
+					return
+				}
+			}
+		}()
+		return client, nil
+	}
+}
+
+func newTestClient(t *testing.T, commands *[]string, allowDirect bool) *Client {
+	t.Helper()
+	c := NewClient(config.EmailConfig{
+		Enabled:         true,
+		AllowDirectSend: allowDirect,
+		Accounts: []config.EmailAccountConfig{{
+			UserID: 5, Address: "me@example.com", PasswordSecret: "mail",
+			IMAPAddr: "imap.example.com:993", SMTPAddr: "smtp.example.com:587",
+		}},
+	}, func(name string) (string, bool) { return "pw-" + name, name == "mail" })
+	c.dialIMAP = fakeIMAP(commands)
+	return c
+}
+
+func TestSearchAndRead(t *testing.T) {
+	var commands []string
+	c := newTestClient(t, &commands, false)
+	ctx := llm.WithUserID(context.Background(), 5)
+
+	out, err := NewSearchTool(c).Call(ctx, json.RawMessage(`{"subject":"report","since":"2026-10-01","unread":true,"limit":2}`))
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	res := out.(map[string]any)
+	msgs := res["messages"].([]summary)
+	if res["total"] != 3 || len(msgs) != 2 || msgs[0].UID != 9 || msgs[0].Subject != "Hi" || msgs[0].Unread {
+		t.Fatalf("unexpected search result %+v", res)
+	}
+	want := []string{`LOGIN "me@example.com" "pw-mail"`, `EXAMINE "INBOX"`, `UID SEARCH UNSEEN SINCE 1-Oct-2026 SUBJECT "report"`}
+	for i, w := range want {
+		if commands[i] != w {
+			t.Fatalf("command %d = %q, want %q", i, commands[i], w)
+		}
+	}
+	if !strings.HasPrefix(commands[3], "UID FETCH 7,9 ") {
+		t.Fatalf("expected fetch of the newest uids, got %q", commands[3])
+	}
+
+	out, err = NewReadTool(c).Call(ctx, json.RawMessage(`{"uid":9}`))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	m := out.(message)
+	if m.From != "Jörg <jorg@example.com>" || m.Subject != "Quarterly report" {
+		t.Fatalf("unexpected headers %+v", m.summary)
+	}
+	if m.Body != "Numbers are up & to the right." || len(m.Attachments) != 1 || m.Attachments[0] != "q3.pdf" {
+		t.Fatalf("unexpected body %q attachments %v", m.Body, m.Attachments)
+	}
+}
+
+func TestAccountResolution(t *testing.T) {
+	var commands []string
+	c := newTestClient(t, &commands, false)
+	if _, err := NewSearchTool(c).Call(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "no email account") {
+		t.Fatalf("expected missing account error, got %v", err)
+	}
+	c.accounts[5] = config.EmailAccountConfig{UserID: 5, PasswordSecret: "other"}
+	if _, err := NewSearchTool(c).Call(llm.WithUserID(context.Background(), 5), nil); err == nil || !strings.Contains(err.Error(), `"other"`) {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+}
+
+func TestSendRequiresApproval(t *testing.T) {
+	var commands []string
+	c := newTestClient(t, &commands, false)
+	var sent []byte
+	c.send = func(_ context.Context, _ config.EmailAccountConfig, password string, to []string, msg []byte) error {
+		if password != "pw-mail" || len(to) != 2 {
+			t.Errorf("unexpected send %q %v", password, to)
+		}
+		sent = msg
+		return nil
+	}
+	ctx := llm.WithUserID(context.Background(), 5)
+	args := json.RawMessage(`{"to":["Ann <ann@example.com>"],"cc":["bob@example.com"],"subject":"Grüße","body":"line one\nline two"}`)
+
+	out, err := NewSendTool(c).Call(ctx, args)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if res := out.(map[string]any); res["sent"] != false || sent != nil {
+		t.Fatalf("draft-only send went out: %+v", res)
+	}
+
+	out, err = NewSendTool(c).Call(tools.WithApproval(ctx, "email_send"), args)
+	if err != nil {
+		t.Fatalf("approved send: %v", err)
+	}
+	if res := out.(map[string]any); res["sent"] != true {
+		t.Fatalf("approved send not sent: %+v", res)
+	}
+	s := string(sent)
+	for _, want := range []string{"From: me@example.com\r\n", "Cc: bob@example.com\r\n", "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n", "\r\n\r\nline one\r\nline two"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("message missing %q:\n%s", want, s)
+		}
+	}
+
+	if _, err := NewSendTool(c).Call(ctx, json.RawMessage(`{"to":["a@example.com"],"subject":"x\r\nBcc: evil@example.com","body":""}`)); err == nil {
+		t.Fatal("expected header injection to be rejected")
+	}
+}
+
+func TestSearchCriteria(t *testing.T) {
+	for _, tc := range []struct {
+		args searchArgs
+		want string
+	}{
+		{searchArgs{}, "ALL"},
+		{searchArgs{From: `a"b`}, `FROM "a\"b"`},
+		{searchArgs{Text: "café"}, `CHARSET UTF-8 TEXT "café"`},
+	} {
+		got, err := tc.args.criteria()
+		if err != nil || got != tc.want {
+			t.Fatalf("criteria(%+v) = %q, %v; want %q", tc.args, got, err, tc.want)
+		}
+	}
+	if _, err := (searchArgs{Since: "yesterday"}).criteria(); err == nil {
+		t.Fatal("expected invalid since to fail")
+	}
+}
@@ -0,0 +1,213 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLiteral bounds a single IMAP literal, i.e. one fetched message.
+const maxLiteral = 25 << 20
+
+var (
+	literalSuffix = regexp.MustCompile(`\{(\d+)\}$`)
+	fetchUID      = regexp.MustCompile(`\bUID (\d+)`)
+	fetchFlags    = regexp.MustCompile(`\bFLAGS \(([^)]*)\)`)
+	fetchSize     = regexp.MustCompile(`\bRFC822\.SIZE (\d+)`)
+)
+
+// imapConn is a minimal IMAP4rev1 client covering the read-only commands
+// the email tools need: LOGIN, EXAMINE, UID SEARCH and UID FETCH.
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is one untagged response line with its literals cut out.
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+func dialIMAP(ctx context.Context, addr string, dial func(ctx context.Context, addr string) (net.Conn, error)) (*imapConn, error) {
+	if dial == nil {
+		dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			host, _, _ := net.SplitHostPort(addr)
+			d := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+			return d.DialContext(ctx, "tcp", addr)
+		}
+	}
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("imap dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.line, "* OK") && !strings.HasPrefix(greeting.line, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", greeting.line)
+	}
+	return c, nil
+}
+
+func (c *imapConn) Close() error {
+	_, _ = c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// readResponse reads one response line, following {n} literals.
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var b strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, fmt.Errorf("imap read: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		m := literalSuffix.FindStringSubmatch(line)
+		if m == nil {
+			b.WriteString(line)
+			resp.line = b.String()
+			return resp, nil
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n > maxLiteral {
+			return resp, fmt.Errorf("imap: literal of %d bytes exceeds limit", n)
+		}
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return resp, fmt.Errorf("imap read: %w", err)
+		}
+		b.WriteString(line[:len(line)-len(m[0])])
+		b.WriteString("{}")
+		resp.literals = append(resp.literals, lit)
+	}
+}
+
+// command sends one tagged command and returns the untagged responses that
+// preceded its completion.
+func (c *imapConn) command(format string, args ...any) ([]imapResponse, error) {
+	c.tag++
+	tag := "M" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, fmt.Errorf("imap write: %w", err)
+	}
+	var untagged []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		rest, ok := strings.CutPrefix(resp.line, tag+" ")
+		if !ok {
+			untagged = append(untagged, resp)
+			continue
+		}
+		if strings.HasPrefix(rest, "OK") {
+			return untagged, nil
+		}
+		return nil, fmt.Errorf("imap: %s", rest)
+	}
+}
+
+func (c *imapConn) login(user, pass string) error {
+	_, err := c.command("LOGIN %s %s", quote(user), quote(pass))
+	if err != nil {
+		return errors.New("imap: login failed")
+	}
+	return nil
+}
+
+// examine opens mailbox read-only so fetching does not change flags.
+func (c *imapConn) examine(mailbox string) error {
+	_, err := c.command("EXAMINE %s", quote(mailbox))
+	return err
+}
+
+func (c *imapConn) search(criteria string) ([]uint32, error) {
+	resps, err := c.command("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, r := range resps {
+		rest, ok := strings.CutPrefix(r.line, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(rest) {
+			if n, err := strconv.ParseUint(f, 10, 32); err == nil {
+				uids = append(uids, uint32(n))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// fetched is one FETCH response: the message UID, flags, size and the
+// requested body section.
+type fetched struct {
+	uid   uint32
+	flags []string
+	size  int64
+	body  []byte
+}
+
+func (c *imapConn) fetch(uids []uint32, items string) ([]fetched, error) {
+	set := make([]string, len(uids))
+	for i, u := range uids {
+		set[i] = strconv.FormatUint(uint64(u), 10)
+	}
+	resps, err := c.command("UID FETCH %s (%s)", strings.Join(set, ","), items)
+	if err != nil {
+		return nil, err
+	}
+	var out []fetched
+	for _, r := range resps {
+		if !strings.Contains(r.line, " FETCH (") {
+			continue
+		}
+		var f fetched
+		if m := fetchUID.FindStringSubmatch(r.line); m != nil {
+			n, _ := strconv.ParseUint(m[1], 10, 32)
+			f.uid = uint32(n)
+		}
+		if m := fetchFlags.FindStringSubmatch(r.line); m != nil {
+			f.flags = strings.Fields(m[1])
+		}
+		if m := fetchSize.FindStringSubmatch(r.line); m != nil {
+			f.size, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		if len(r.literals) > 0 {
+			f.body = r.literals[0]
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// quote renders s as an IMAP quoted string. CR and LF cannot be quoted, so
+// they are dropped rather than allowed to split the command.
+func quote(s string) string {
+	s = strings.NewReplacer("\r", "", "\n", "", `\`, `\\`, `"`, `\"`).Replace(s)
+	return `"` + s + `"`
+}
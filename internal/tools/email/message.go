@@ -0,0 +1,217 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxBodyChars caps the text returned for one message.
+const maxBodyChars = 20000
+
+var (
+	htmlTags   = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	decoder    = &mime.WordDecoder{}
+)
+
+// draft is a message to send.
+type draft struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Cc      []string `json:"cc,omitempty"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// recipients parses and validates every To and Cc address.
+func (d draft) recipients() ([]string, error) {
+	var out []string
+	for _, list := range [][]string{d.To, d.Cc} {
+		for _, raw := range list {
+			addr, err := mail.ParseAddress(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q", raw)
+			}
+			out = append(out, addr.Address)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+	return out, nil
+}
+
+// bytes renders d as an RFC 5322 message with a quoted-printable UTF-8
+// text body.
+func (d draft) bytes(now time.Time) ([]byte, error) {
+	for _, v := range slices.Concat([]string{d.From, d.Subject}, d.To, d.Cc) {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, errors.New("header values must not contain line breaks")
+		}
+	}
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", d.From)
+	header("To", strings.Join(d.To, ", "))
+	if len(d.Cc) > 0 {
+		header("Cc", strings.Join(d.Cc, ", "))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", d.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(d.From))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	body := strings.ReplaceAll(strings.ReplaceAll(d.Body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func messageID(from string) string {
+	var r [12]byte
+	_, _ = rand.Read(r[:])
+	domain := "manifold.local"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = d
+		}
+	}
+	return "<" + hex.EncodeToString(r[:]) + "@" + domain + ">"
+}
+
+// summary is the header view of a message returned by email_search.
+type summary struct {
+	UID     uint32 `json:"uid"`
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"`
+	Subject string `json:"subject"`
+	Date    string `json:"date,omitempty"`
+	Unread  bool   `json:"unread"`
+	Size    int64  `json:"size,omitempty"`
+}
+
+// message is the full view returned by email_read.
+type message struct {
+	summary
+	Cc          string   `json:"cc,omitempty"`
+	MessageID   string   `json:"message_id,omitempty"`
+	Body        string   `json:"body"`
+	Truncated   bool     `json:"truncated,omitempty"`
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+func decodeHeader(v string) string {
+	if out, err := decoder.DecodeHeader(v); err == nil {
+		return out
+	}
+	return v
+}
+
+func summarize(f fetched) summary {
+	s := summary{UID: f.uid, Size: f.size, Unread: true}
+	for _, flag := range f.flags {
+		if strings.EqualFold(flag, `\Seen`) {
+			s.Unread = false
+		}
+	}
+	if m, err := mail.ReadMessage(bytes.NewReader(append(f.body, "\r\n"...))); err == nil {
+		s.From = decodeHeader(m.Header.Get("From"))
+		s.To = decodeHeader(m.Header.Get("To"))
+		s.Subject = decodeHeader(m.Header.Get("Subject"))
+		s.Date = m.Header.Get("Date")
+	}
+	return s
+}
+
+// parseMessage extracts headers, a readable text body and attachment names
+// from a raw RFC 5322 message. HTML-only bodies are reduced to text.
+func parseMessage(f fetched) (message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(f.body))
+	if err != nil {
+		return message{}, fmt.Errorf("parse message: %w", err)
+	}
+	out := message{summary: summarize(f)}
+	out.Cc = decodeHeader(m.Header.Get("Cc"))
+	out.MessageID = m.Header.Get("Message-Id")
+
+	var plain, html string
+	walkPart(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), "", m.Body, &plain, &html, &out.Attachments)
+	body := plain
+	if strings.TrimSpace(body) == "" && html != "" {
+		body = htmlToText(html)
+	}
+	if r := []rune(body); len(r) > maxBodyChars {
+		body = string(r[:maxBodyChars])
+		out.Truncated = true
+	}
+	out.Body = strings.TrimSpace(body)
+	return out, nil
+}
+
+func walkPart(contentType, encoding, disposition string, r io.Reader, plain, html *string, attachments *[]string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			walkPart(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p.Header.Get("Content-Disposition"), p, plain, html, attachments)
+		}
+	}
+	if disp, dparams, err := mime.ParseMediaType(disposition); err == nil && disp == "attachment" {
+		*attachments = append(*attachments, decodeHeader(dparams["filename"]))
+		return
+	}
+	switch mediaType {
+	case "text/plain", "text/html":
+	default:
+		if name := params["name"]; name != "" {
+			*attachments = append(*attachments, decodeHeader(name))
+		}
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	data, _ := io.ReadAll(io.LimitReader(r, maxLiteral))
+	if mediaType == "text/html" {
+		*html += string(data)
+	} else {
+		*plain += string(data)
+	}
+}
+
+func htmlToText(s string) string {
+	s = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</div>", "\n").Replace(s)
+	s = htmlTags.ReplaceAllString(s, "")
+	s = strings.NewReplacer("&nbsp;", " ", "&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'").Replace(s)
+	return blankLines.ReplaceAllString(s, "\n\n")
+}
@@ -0,0 +1,400 @@
+// Package email provides mailbox tools for the calling user's account:
+// email_search and email_read over IMAP and email_send over SMTP.
+// Passwords come from the secrets store and never reach the model.
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+	"unicode"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/tools"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	sendToolName = "email_send"
+)
+
+// Client resolves the caller's account and talks to its mail servers.
+type Client struct {
+	accounts        map[int64]config.EmailAccountConfig
+	allowDirectSend bool
+	lookup          func(name string) (string, bool)
+
+	// dialIMAP and send are replaced in tests.
+	dialIMAP func(ctx context.Context, addr string) (net.Conn, error)
+	send     func(ctx context.Context, acct config.EmailAccountConfig, password string, to []string, msg []byte) error
+}
+
+// NewClient returns a client for the configured accounts. lookup resolves
+// an account's passwordSecret, typically from the secrets store.
+func NewClient(cfg config.EmailConfig, lookup func(name string) (string, bool)) *Client {
+	c := &Client{
+		accounts:        make(map[int64]config.EmailAccountConfig, len(cfg.Accounts)),
+		allowDirectSend: cfg.AllowDirectSend,
+		lookup:          lookup,
+		send:            sendSMTP,
+	}
+	for _, a := range cfg.Accounts {
+		c.accounts[a.UserID] = a
+	}
+	return c
+}
+
+// account returns the caller's account and password.
+func (c *Client) account(ctx context.Context) (config.EmailAccountConfig, string, error) {
+	userID, _ := llm.UserIDFromContext(ctx)
+	acct, ok := c.accounts[userID]
+	if !ok {
+		return acct, "", errors.New("no email account is configured for this user")
+	}
+	if c.lookup == nil {
+		return acct, "", errors.New("email passwords need the secrets store; set secrets.masterKey")
+	}
+	password, ok := c.lookup(acct.PasswordSecret)
+	if !ok {
+		return acct, "", fmt.Errorf("secret %q for the email password is not set", acct.PasswordSecret)
+	}
+	return acct, password, nil
+}
+
+func (c *Client) openMailbox(ctx context.Context, mailbox string) (*imapConn, error) {
+	acct, password, err := c.account(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if acct.IMAPAddr == "" {
+		return nil, errors.New("no IMAP server is configured for this account")
+	}
+	conn, err := dialIMAP(ctx, acct.IMAPAddr, c.dialIMAP)
+	if err != nil {
+		return nil, err
+	}
+	username := acct.Username
+	if username == "" {
+		username = acct.Address
+	}
+	if err := conn.login(username, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if err := conn.examine(mailbox); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+type searchTool struct{ c *Client }
+
+// NewSearchTool constructs email_search.
+func NewSearchTool(c *Client) *searchTool { return &searchTool{c: c} }
+
+func (t *searchTool) Name() string { return "email_search" }
+
+func (t *searchTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Search the user's mailbox and return matching messages newest first (uid, from, subject, date, unread). Use email_read with a uid for the body. Nothing is marked as read.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"mailbox": map[string]any{"type": "string", "description": "Mailbox to search (default INBOX)."},
+				"from":    map[string]any{"type": "string", "description": "Sender contains this text."},
+				"subject": map[string]any{"type": "string", "description": "Subject contains this text."},
+				"text":    map[string]any{"type": "string", "description": "Headers or body contain this text."},
+				"since":   map[string]any{"type": "string", "description": "Only messages on or after this date (YYYY-MM-DD)."},
+				"unread":  map[string]any{"type": "boolean", "description": "Only unread messages."},
+				"limit":   map[string]any{"type": "integer", "minimum": 1, "maximum": maxLimit, "description": fmt.Sprintf("Maximum messages to return (default %d).", defaultLimit)},
+			},
+		},
+	}
+}
+
+type searchArgs struct {
+	Mailbox string `json:"mailbox"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Since   string `json:"since"`
+	Unread  bool   `json:"unread"`
+	Limit   int    `json:"limit"`
+}
+
+// criteria renders args as IMAP SEARCH keys.
+func (a searchArgs) criteria() (string, error) {
+	var keys []string
+	if a.Unread {
+		keys = append(keys, "UNSEEN")
+	}
+	if a.Since != "" {
+		d, err := time.Parse("2006-01-02", a.Since)
+		if err != nil {
+			return "", errors.New("since must be YYYY-MM-DD")
+		}
+		keys = append(keys, "SINCE "+d.Format("2-Jan-2006"))
+	}
+	ascii := true
+	for _, kv := range [][2]string{{"FROM", a.From}, {"SUBJECT", a.Subject}, {"TEXT", a.Text}} {
+		if v := strings.TrimSpace(kv[1]); v != "" {
+			keys = append(keys, kv[0]+" "+quote(v))
+			ascii = ascii && isASCII(v)
+		}
+	}
+	if len(keys) == 0 {
+		return "ALL", nil
+	}
+	out := strings.Join(keys, " ")
+	if !ascii {
+		out = "CHARSET UTF-8 " + out
+	}
+	return out, nil
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *searchTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args searchArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	criteria, err := args.criteria()
+	if err != nil {
+		return nil, err
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+
+	conn, err := t.c.openMailbox(ctx, args.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	uids, err := conn.search(criteria)
+	if err != nil {
+		return nil, err
+	}
+	total := len(uids)
+	if len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+	out := map[string]any{"ok": true, "total": total, "messages": []summary{}}
+	if len(uids) == 0 {
+		return out, nil
+	}
+	fetchedMsgs, err := conn.fetch(uids, "UID FLAGS RFC822.SIZE BODY.PEEK[HEADER.FIELDS (FROM TO SUBJECT DATE)]")
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]summary, 0, len(fetchedMsgs))
+	for i := len(fetchedMsgs) - 1; i >= 0; i-- {
+		msgs = append(msgs, summarize(fetchedMsgs[i]))
+	}
+	out["messages"] = msgs
+	return out, nil
+}
+
+type readTool struct{ c *Client }
+
+// NewReadTool constructs email_read.
+func NewReadTool(c *Client) *readTool { return &readTool{c: c} }
+
+func (t *readTool) Name() string { return "email_read" }
+
+func (t *readTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Read one message by the uid email_search returned: headers, text body (HTML reduced to text) and attachment names. The message is not marked as read.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"uid":     map[string]any{"type": "integer", "minimum": 1, "description": "Message uid."},
+				"mailbox": map[string]any{"type": "string", "description": "Mailbox the uid belongs to (default INBOX)."},
+			},
+			"required": []string{"uid"},
+		},
+	}
+}
+
+func (t *readTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		UID     uint32 `json:"uid"`
+		Mailbox string `json:"mailbox"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.UID == 0 {
+		return nil, errors.New("uid is required")
+	}
+	conn, err := t.c.openMailbox(ctx, args.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	fetchedMsgs, err := conn.fetch([]uint32{args.UID}, "UID FLAGS RFC822.SIZE BODY.PEEK[]")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fetchedMsgs {
+		if f.uid == args.UID {
+			return parseMessage(f)
+		}
+	}
+	return nil, fmt.Errorf("message %d not found", args.UID)
+}
+
+type sendTool struct{ c *Client }
+
+// NewSendTool constructs email_send. Unless direct sending is allowed it
+// only sends calls a person approved (see tools.Approved); otherwise it
+// returns the draft for review.
+func NewSendTool(c *Client) *sendTool { return &sendTool{c: c} }
+
+func (t *sendTool) Name() string { return sendToolName }
+
+func (t *sendTool) JSONSchema() map[string]any {
+	desc := "Send a plain-text email from the user's account."
+	if !t.c.allowDirectSend {
+		desc += " Sending requires a person to approve the call; when it is not approved the draft is returned unsent."
+	}
+	return map[string]any{
+		"name":        t.Name(),
+		"description": desc,
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"to":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Recipient addresses."},
+				"cc":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Cc addresses."},
+				"subject": map[string]any{"type": "string"},
+				"body":    map[string]any{"type": "string", "description": "Plain-text body."},
+			},
+			"required": []string{"to", "subject", "body"},
+		},
+	}
+}
+
+func (t *sendTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var d draft
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	acct, password, err := t.c.account(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.From = acct.Address
+	to, err := d.recipients()
+	if err != nil {
+		return nil, err
+	}
+	msg, err := d.bytes(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !t.c.allowDirectSend && !tools.Approved(ctx, sendToolName) {
+		return map[string]any{
+			"ok":      false,
+			"sent":    false,
+			"draft":   d,
+			"message": "Draft only: email_send needs a person to approve the call before it sends. Show the draft to the user.",
+		}, nil
+	}
+	if acct.SMTPAddr == "" {
+		return nil, errors.New("no SMTP server is configured for this account")
+	}
+	if err := t.c.send(ctx, acct, password, to, msg); err != nil {
+		return nil, err
+	}
+	return map[string]any{"ok": true, "sent": true, "to": to}, nil
+}
+
+// sendSMTP delivers msg through the account's submission server: implicit
+// TLS on port 465, STARTTLS elsewhere.
+func sendSMTP(ctx context.Context, acct config.EmailAccountConfig, password string, to []string, msg []byte) error {
+	host, port, err := net.SplitHostPort(acct.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("smtp address: %w", err)
+	}
+	tlsCfg := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = (&tls.Dialer{Config: tlsCfg}).DialContext(ctx, "tcp", acct.SMTPAddr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", acct.SMTPAddr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	sc, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer sc.Close()
+	if port != "465" {
+		if ok, _ := sc.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not offer STARTTLS")
+		}
+		if err := sc.StartTLS(tlsCfg); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	username := acct.Username
+	if username == "" {
+		username = acct.Address
+	}
+	if err := sc.Auth(smtp.PlainAuth("", username, password, host)); err != nil {
+		return errors.New("smtp: authentication failed")
+	}
+	if err := sc.Mail(acct.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := sc.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp: recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := sc.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return sc.Quit()
+}
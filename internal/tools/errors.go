@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// ErrorCategory classifies a tool failure so the model can decide how to
+// recover.
+type ErrorCategory string
+
+const (
+	ErrInvalidArgs      ErrorCategory = "invalid_args"
+	ErrNotFound         ErrorCategory = "not_found"
+	ErrPermissionDenied ErrorCategory = "permission_denied"
+	ErrTimeout          ErrorCategory = "timeout"
+	ErrRateLimited      ErrorCategory = "rate_limited"
+	ErrInternal         ErrorCategory = "internal"
+)
+
+var errorHints = map[ErrorCategory]string{
+	ErrInvalidArgs:      "Check the arguments against the tool's schema and call it again with corrected values.",
+	ErrNotFound:         "Verify the name, path, or ID exists (list or search first) before retrying.",
+	ErrPermissionDenied: "Do not retry this call; take a different approach or ask the user for access.",
+	ErrTimeout:          "Retry with a smaller request, or split the work into smaller steps.",
+	ErrRateLimited:      "Wait before retrying, or call this tool less often.",
+	ErrInternal:         "The tool failed unexpectedly; retry once, then try a different approach.",
+}
+
+// Retryable reports whether repeating the same call may succeed.
+func (c ErrorCategory) Retryable() bool {
+	return c == ErrTimeout || c == ErrRateLimited || c == ErrInternal
+}
+
+// Hint returns the default remediation hint for c.
+func (c ErrorCategory) Hint() string { return errorHints[c] }
+
+// Error is a categorized tool failure. Tools return it from Call to control
+// the category and hint reported to the model; other errors are classified
+// by Classify.
+type Error struct {
+	Category ErrorCategory
+	Message  string
+	// Hint overrides the category's default remediation hint.
+	Hint string
+	Err  error
+}
+
+// NewError returns an Error of category c with message msg.
+func NewError(c ErrorCategory, msg string) *Error {
+	return &Error{Category: c, Message: msg}
+}
+
+// WrapError returns an Error of category c wrapping err.
+func WrapError(c ErrorCategory, err error) *Error {
+	return &Error{Category: c, Message: err.Error(), Err: err}
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Err }
+
+// Classify returns err as an *Error, inferring the category from well-known
+// error values and, failing that, from the message.
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var te *Error
+	if errors.As(err, &te) {
+		return te
+	}
+	return &Error{Category: classifyError(err), Message: err.Error(), Err: err}
+}
+
+func classifyError(err error) ErrorCategory {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		netErr    net.Error
+	)
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrInvalidArgs
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermissionDenied
+	}
+	return classifyMessage(err.Error())
+}
+
+// classifyMessage maps free-form error text to a category. Checks run from
+// most to least specific.
+func classifyMessage(msg string) ErrorCategory {
+	m := strings.ToLower(msg)
+	switch {
+	case containsAny(m, "rate limit", "rate-limit", "too many requests", "429", "quota exceeded"):
+		return ErrRateLimited
+	case containsAny(m, "timed out", "timeout", "deadline exceeded"):
+		return ErrTimeout
+	case containsAny(m, "permission denied", "forbidden", "not allowed", "unauthorized", "access denied", "denied"):
+		return ErrPermissionDenied
+	case containsAny(m, "not found", "no such file", "does not exist", "unknown tool"):
+		return ErrNotFound
+	case containsAny(m, "invalid", "required", "missing", "must be", "malformed", "cannot unmarshal", "unexpected end of json"):
+		return ErrInvalidArgs
+	}
+	return ErrInternal
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorPayload is the JSON shape of every failed tool result. Error stays a
+// plain string so existing consumers keep working.
+type errorPayload struct {
+	OK        bool          `json:"ok"`
+	Error     string        `json:"error"`
+	Category  ErrorCategory `json:"category"`
+	Hint      string        `json:"hint,omitempty"`
+	Retryable bool          `json:"retryable"`
+	// TimedOut marks a call stopped at its timeout by NewTimeoutRegistry.
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// ErrorPayload renders err as the standard tool error JSON:
+// {"ok":false,"error":"...","category":"...","hint":"...","retryable":bool}.
+func ErrorPayload(err error) []byte {
+	te := Classify(err)
+	if te == nil {
+		te = &Error{Category: ErrInternal, Message: "unknown error"}
+	}
+	category := te.Category
+	if _, ok := errorHints[category]; !ok {
+		category = ErrInternal
+	}
+	hint := te.Hint
+	if hint == "" {
+		hint = category.Hint()
+	}
+	var timeout *TimeoutError
+	b, _ := json.Marshal(errorPayload{
+		Error:     te.Message,
+		Category:  category,
+		Hint:      hint,
+		Retryable: category.Retryable(),
+		TimedOut:  errors.As(err, &timeout),
+	})
+	return b
+}
+
+// NormalizeErrorPayload adds category, hint, and retryable to a tool result
+// that reports failure with {"ok":false,"error":"..."} but no category, so
+// tools that build their own error maps share the standard shape. Other
+// payloads are returned unchanged.
+func NormalizeErrorPayload(payload []byte) []byte {
+	if !bytes.Contains(payload, []byte(`"error"`)) {
+		return payload
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return payload
+	}
+	if ok, present := obj["ok"].(bool); present && ok {
+		return payload
+	}
+	msg, isString := obj["error"].(string)
+	if !isString || msg == "" {
+		return payload
+	}
+	if _, has := obj["category"]; has {
+		return payload
+	}
+	category := classifyMessage(msg)
+	obj["ok"] = false
+	obj["category"] = category
+	if _, has := obj["hint"]; !has {
+		obj["hint"] = category.Hint()
+	}
+	obj["retryable"] = category.Retryable()
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return payload
+	}
+	return b
+}
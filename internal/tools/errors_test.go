@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+type failingTool struct{ err error }
+
+func (failingTool) Name() string               { return "fail" }
+func (failingTool) JSONSchema() map[string]any { return map[string]any{"description": "fails"} }
+func (t failingTool) Call(context.Context, json.RawMessage) (any, error) {
+	return nil, t.err
+}
+
+type mapErrorTool struct{}
+
+func (mapErrorTool) Name() string               { return "map_error" }
+func (mapErrorTool) JSONSchema() map[string]any { return map[string]any{"description": "map error"} }
+func (mapErrorTool) Call(context.Context, json.RawMessage) (any, error) {
+	return map[string]any{"ok": false, "error": "HTTP 429: too many requests"}, nil
+}
+
+func decodeErrorPayload(t *testing.T, b []byte) errorPayload {
+	t.Helper()
+	var p errorPayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return p
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	var args struct{ N int }
+	jsonErr := json.Unmarshal([]byte(`{"N":"x"}`), &args)
+	cases := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{fmt.Errorf("invalid arguments: %w", jsonErr), ErrInvalidArgs},
+		{errors.New("path is required"), ErrInvalidArgs},
+		{fmt.Errorf("open: %w", fs.ErrNotExist), ErrNotFound},
+		{fmt.Errorf("open: %w", fs.ErrPermission), ErrPermissionDenied},
+		{errors.New("binary blocked: command not allowed"), ErrPermissionDenied},
+		{fmt.Errorf("fetch: %w", context.DeadlineExceeded), ErrTimeout},
+		{errors.New("upstream returned 429 Too Many Requests"), ErrRateLimited},
+		{errors.New("boom"), ErrInternal},
+		{WrapError(ErrNotFound, errors.New("no such room")), ErrNotFound},
+		{fmt.Errorf("outer: %w", NewError(ErrTimeout, "slow")), ErrTimeout},
+	}
+	for _, tc := range cases {
+		if got := Classify(tc.err).Category; got != tc.want {
+			t.Errorf("Classify(%q) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestDispatchReturnsStandardErrorPayload(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	reg.Register(failingTool{err: &Error{Category: ErrInvalidArgs, Message: "limit must be positive", Hint: "Pass limit >= 1."}})
+	reg.Register(mapErrorTool{})
+
+	b, err := reg.Dispatch(context.Background(), "fail", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := decodeErrorPayload(t, b)
+	if p.OK || p.Error != "limit must be positive" || p.Category != ErrInvalidArgs || p.Hint != "Pass limit >= 1." || p.Retryable {
+		t.Fatalf("unexpected payload: %s", b)
+	}
+
+	b, _ = reg.Dispatch(context.Background(), "missing", nil)
+	if p := decodeErrorPayload(t, b); p.Category != ErrNotFound || p.Error != "tool not found" || p.Hint == "" {
+		t.Fatalf("unexpected not-found payload: %s", b)
+	}
+
+	b, _ = NewFilteredRegistry(reg, []string{"map_error"}).Dispatch(context.Background(), "fail", nil)
+	if p := decodeErrorPayload(t, b); p.Category != ErrPermissionDenied || p.Retryable {
+		t.Fatalf("unexpected not-allowed payload: %s", b)
+	}
+
+	b, _ = reg.Dispatch(context.Background(), "map_error", nil)
+	if p := decodeErrorPayload(t, b); p.Category != ErrRateLimited || !p.Retryable || p.Hint == "" {
+		t.Fatalf("tool-built error map not normalized: %s", b)
+	}
+}
+
+func TestNormalizeErrorPayloadLeavesOtherPayloadsAlone(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{
+		`{"ok":true,"error":"ignored"}`,
+		`{"result":"fine"}`,
+		`{"ok":false,"error":"x","category":"timeout"}`,
+		`[1,2]`,
+		`{"ok":false,"error":{"code":1}}`,
+	} {
+		if got := string(NormalizeErrorPayload([]byte(in))); got != in {
+			t.Errorf("NormalizeErrorPayload(%s) = %s", in, got)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package filetool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxListEntries = 500
+	maxListEntries        = 5000
+)
+
+type fileInfo struct {
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+	ModTime string `json:"mod_time,omitempty"`
+}
+
+func newFileInfo(rel string, info fs.FileInfo) fileInfo {
+	typ := "file"
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		typ = "symlink"
+	case info.IsDir():
+		typ = "dir"
+	}
+	out := fileInfo{
+		Path:    filepath.ToSlash(rel),
+		Type:    typ,
+		Mode:    info.Mode().Perm().String(),
+		ModTime: info.ModTime().UTC().Format(time.RFC3339),
+	}
+	if typ == "file" {
+		out.Size = info.Size()
+	}
+	return out
+}
+
+// resolveDir is resolvePath for directories, where an empty path or "."
+// means the project root.
+func resolveDir(base, input string) (string, string, error) {
+	if p := cleanInputPath(input); p == "" || p == "." {
+		return ".", base, nil
+	}
+	return resolvePath(base, input)
+}
+
+type listTool struct {
+	guard rootGuard
+}
+
+type listArgs struct {
+	Path       string `json:"path"`
+	Recursive  bool   `json:"recursive"`
+	MaxEntries int    `json:"max_entries"`
+}
+
+type listResult struct {
+	OK        bool       `json:"ok"`
+	Error     string     `json:"error,omitempty"`
+	Path      string     `json:"path,omitempty"`
+	Entries   []fileInfo `json:"entries,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+func NewListTool(allowedRoots []string) *listTool {
+	return &listTool{guard: newRootGuard(allowedRoots)}
+}
+
+func (t *listTool) Name() string { return "file_list" }
+
+func (t *listTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "List files and directories in the current project workspace. Symlinks are reported but not followed.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":        map[string]any{"type": "string", "description": "Directory path relative to the project root (defaults to the root)."},
+				"recursive":   map[string]any{"type": "boolean", "description": "Include the contents of subdirectories."},
+				"max_entries": map[string]any{"type": "integer", "minimum": 1, "maximum": maxListEntries, "description": "Maximum entries to return (default 500)."},
+			},
+		},
+	}
+}
+
+func (t *listTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args listArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+	}
+	limit := args.MaxEntries
+	if limit <= 0 {
+		limit = defaultMaxListEntries
+	}
+	if limit > maxListEntries {
+		limit = maxListEntries
+	}
+
+	base, err := t.guard.baseDir(ctx)
+	if err != nil {
+		return listResult{OK: false, Error: err.Error()}, nil
+	}
+	rel, full, err := resolveDir(base, args.Path)
+	if err != nil {
+		return listResult{OK: false, Error: fmt.Sprintf("invalid path: %v", err)}, nil
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return listResult{OK: false, Error: err.Error()}, nil
+	}
+	if !info.IsDir() {
+		return listResult{OK: false, Error: "path is not a directory"}, nil
+	}
+
+	entries := make([]fileInfo, 0)
+	truncated := false
+	errStop := errors.New("stop")
+	err = filepath.WalkDir(full, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == full {
+			return err
+		}
+		// Project metadata is managed by the server, not the agent.
+		if d.IsDir() && d.Name() == ".meta" && filepath.Dir(p) == base {
+			return filepath.SkipDir
+		}
+		if len(entries) >= limit {
+			truncated = true
+			return errStop
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		relPath, err := filepath.Rel(base, p)
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, newFileInfo(relPath, info))
+		if d.IsDir() && !args.Recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return listResult{OK: false, Error: err.Error()}, nil
+	}
+	return listResult{OK: true, Path: filepath.ToSlash(rel), Entries: entries, Truncated: truncated}, nil
+}
+
+type statTool struct {
+	guard rootGuard
+}
+
+type statArgs struct {
+	Path string `json:"path"`
+}
+
+type statResult struct {
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	Exists bool   `json:"exists"`
+	fileInfo
+}
+
+func NewStatTool(allowedRoots []string) *statTool {
+	return &statTool{guard: newRootGuard(allowedRoots)}
+}
+
+func (t *statTool) Name() string { return "file_stat" }
+
+func (t *statTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Report whether a path exists in the current project workspace and its type, size, permissions and modification time.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{"type": "string", "description": "File or directory path relative to the project root."},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+func (t *statTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args statArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return statResult{OK: false, Error: "missing path"}, nil
+	}
+
+	base, err := t.guard.baseDir(ctx)
+	if err != nil {
+		return statResult{OK: false, Error: err.Error()}, nil
+	}
+	rel, full, err := resolveDir(base, args.Path)
+	if err != nil {
+		return statResult{OK: false, Error: fmt.Sprintf("invalid path: %v", err)}, nil
+	}
+	info, err := os.Lstat(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return statResult{OK: true, Exists: false, fileInfo: fileInfo{Path: filepath.ToSlash(rel)}}, nil
+	}
+	if err != nil {
+		return statResult{OK: false, Error: err.Error()}, nil
+	}
+	return statResult{OK: true, Exists: true, fileInfo: newFileInfo(rel, info)}, nil
+}
@@ -0,0 +1,235 @@
+package filetool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"manifold/internal/docloader"
+	"manifold/internal/textsplitters"
+)
+
+const defaultMaxDocumentChars = 64 * 1024
+
+// DocumentIngester stores a loaded document in the RAG index. It is
+// satisfied by ragtool.DocumentIngester.
+type DocumentIngester interface {
+	IngestDocument(ctx context.Context, id string, doc *docloader.Document) (map[string]any, error)
+}
+
+type loadDocumentTool struct {
+	guard    rootGuard
+	maxChars int
+	opts     docloader.Options
+	ingester DocumentIngester
+}
+
+type loadDocumentArgs struct {
+	Path      string `json:"path"`
+	Pages     []int  `json:"pages"`
+	MaxChars  int    `json:"max_chars"`
+	ChunkSize int    `json:"chunk_size"`
+	Overlap   int    `json:"overlap"`
+	Ingest    bool   `json:"ingest"`
+	DocID     string `json:"doc_id"`
+}
+
+type loadDocumentResult struct {
+	OK        bool              `json:"ok"`
+	Error     string            `json:"error,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Format    string            `json:"format,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	PageCount int               `json:"page_count,omitempty"`
+	Pages     []docloader.Page  `json:"pages,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	Chunks    []docloader.Chunk `json:"chunks,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Ingested  map[string]any    `json:"ingested,omitempty"`
+}
+
+// NewLoadDocumentTool returns the load_document tool, which extracts text
+// from PDF, DOCX, HTML, and text files in the project workspace. Scanned
+// PDFs and images are recognized when opts carries an OCR backend. When
+// ingester is non-nil the tool can also add the document to RAG.
+func NewLoadDocumentTool(allowedRoots []string, maxChars int, opts docloader.Options, ingester DocumentIngester) *loadDocumentTool {
+	if maxChars <= 0 {
+		maxChars = defaultMaxDocumentChars
+	}
+	return &loadDocumentTool{guard: newRootGuard(allowedRoots), maxChars: maxChars, opts: opts, ingester: ingester}
+}
+
+func (t *loadDocumentTool) Name() string { return "load_document" }
+
+func (t *loadDocumentTool) JSONSchema() map[string]any {
+	props := map[string]any{
+		"path":       map[string]any{"type": "string", "description": "Document path relative to the project root (.pdf, .docx, .html, .md, .txt)."},
+		"pages":      map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 1}, "description": "Only return these 1-based pages (paged formats)."},
+		"max_chars":  map[string]any{"type": "integer", "minimum": 1, "description": "Maximum characters of text to return."},
+		"chunk_size": map[string]any{"type": "integer", "minimum": 1, "description": "When set, also return chunks of about this many characters, each tagged with its page."},
+		"overlap":    map[string]any{"type": "integer", "minimum": 0, "description": "Character overlap between chunks."},
+	}
+	desc := "Extract structured text (with page numbers) from a user-uploaded PDF, DOCX, HTML, or text file in the project workspace."
+	if t.opts.OCR != nil {
+		props["path"] = map[string]any{"type": "string", "description": "Document path relative to the project root (.pdf, .docx, .html, .md, .txt, or an image such as .png/.jpg/.tiff)."}
+		desc += " Scanned PDFs and images are OCRed; OCR pages report a confidence between 0 and 1."
+	}
+	if t.ingester != nil {
+		props["ingest"] = map[string]any{"type": "boolean", "description": "Also ingest the full document into RAG for later retrieval."}
+		props["doc_id"] = map[string]any{"type": "string", "description": "RAG document ID when ingesting (defaults to doc:file:<path>)."}
+		desc += " Optionally ingest it into RAG."
+	}
+	return map[string]any{
+		"name":        t.Name(),
+		"description": desc,
+		"parameters": map[string]any{
+			"type":       "object",
+			"properties": props,
+			"required":   []any{"path"},
+		},
+	}
+}
+
+func (t *loadDocumentTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args loadDocumentArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return loadDocumentResult{OK: false, Error: "missing path"}, nil
+	}
+	base, err := t.guard.baseDir(ctx)
+	if err != nil {
+		return loadDocumentResult{OK: false, Error: err.Error()}, nil
+	}
+	rel, full, err := resolvePath(base, args.Path)
+	if err != nil {
+		return loadDocumentResult{OK: false, Error: fmt.Sprintf("invalid path: %v", err)}, nil
+	}
+	// Clean so "./notes.md" and "notes.md" share one RAG document ID.
+	relSlash := filepath.ToSlash(filepath.Clean(rel))
+	info, err := os.Lstat(full)
+	if err != nil {
+		return loadDocumentResult{OK: false, Path: relSlash, Error: err.Error()}, nil
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return loadDocumentResult{OK: false, Path: relSlash, Error: "refusing to read symlink"}, nil
+	}
+	if info.IsDir() {
+		return loadDocumentResult{OK: false, Path: relSlash, Error: "path is a directory"}, nil
+	}
+	doc, err := docloader.LoadFileWithOptions(ctx, full, t.opts)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case errors.Is(err, docloader.ErrEncrypted):
+			msg = "document is password protected"
+		case errors.Is(err, docloader.ErrOCRUnavailable):
+			msg = "image files require OCR, which is not enabled"
+		}
+		return loadDocumentResult{OK: false, Path: relSlash, Error: msg}, nil
+	}
+
+	res := loadDocumentResult{
+		OK:        true,
+		Path:      relSlash,
+		Format:    string(doc.Format),
+		Title:     doc.Title,
+		Metadata:  doc.Metadata,
+		PageCount: len(doc.Pages),
+	}
+	if t.ingester != nil && args.Ingest {
+		id := strings.TrimSpace(args.DocID)
+		if id == "" {
+			id = "doc:file:" + relSlash
+		}
+		ingested, err := t.ingester.IngestDocument(ctx, id, doc)
+		if err != nil {
+			return loadDocumentResult{OK: false, Path: relSlash, Error: "ingest: " + err.Error()}, nil
+		}
+		res.Ingested = ingested
+	}
+
+	selected := selectPages(doc, args.Pages)
+	maxChars := t.maxChars
+	if args.MaxChars > 0 && args.MaxChars < maxChars {
+		maxChars = args.MaxChars
+	}
+	budget := maxChars
+	if len(doc.Pages) > 0 {
+		for _, p := range selected.Pages {
+			if budget <= 0 {
+				res.Truncated = true
+				break
+			}
+			text, cut := truncateRunes(p.Text, budget)
+			res.Truncated = res.Truncated || cut
+			budget -= len([]rune(text))
+			p.Text = text
+			res.Pages = append(res.Pages, p)
+		}
+	} else {
+		res.Text, res.Truncated = truncateRunes(doc.Text, budget)
+	}
+
+	if args.ChunkSize > 0 {
+		splitter, err := textsplitters.NewFromConfig(textsplitters.Config{
+			Kind: textsplitters.KindRecursive,
+			Recursive: textsplitters.RecursiveConfig{
+				Markdown:   textsplitters.MarkdownConfig{Within: textsplitters.BoundaryConfig{Unit: textsplitters.UnitChars, Size: args.ChunkSize, Overlap: args.Overlap}},
+				Paragraphs: textsplitters.BoundaryConfig{Unit: textsplitters.UnitChars, Size: args.ChunkSize, Overlap: args.Overlap},
+				Sentences:  textsplitters.BoundaryConfig{Unit: textsplitters.UnitChars, Size: args.ChunkSize, Overlap: args.Overlap},
+				Fallback:   textsplitters.FixedConfig{Unit: textsplitters.UnitChars, Size: args.ChunkSize, Overlap: args.Overlap},
+			},
+		})
+		if err != nil {
+			return loadDocumentResult{OK: false, Path: relSlash, Error: err.Error()}, nil
+		}
+		budget := maxChars
+		for _, c := range selected.Split(splitter) {
+			if budget <= 0 {
+				res.Truncated = true
+				break
+			}
+			budget -= len([]rune(c.Text))
+			res.Chunks = append(res.Chunks, c)
+		}
+	}
+	return res, nil
+}
+
+// selectPages returns a copy of doc limited to the requested pages.
+func selectPages(doc *docloader.Document, pages []int) *docloader.Document {
+	if len(pages) == 0 || len(doc.Pages) == 0 {
+		return doc
+	}
+	want := make(map[int]bool, len(pages))
+	for _, p := range pages {
+		want[p] = true
+	}
+	out := *doc
+	out.Pages = nil
+	for _, p := range doc.Pages {
+		if want[p.Number] {
+			out.Pages = append(out.Pages, p)
+		}
+	}
+	return &out
+}
+
+func truncateRunes(s string, n int) (string, bool) {
+	if n <= 0 {
+		return "", s != ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s, false
+	}
+	return string(r[:n]), true
+}
@@ -0,0 +1,69 @@
+package filetool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"manifold/internal/docloader"
+	"manifold/internal/sandbox"
+)
+
+type recordingIngester struct {
+	id  string
+	doc *docloader.Document
+}
+
+func (r *recordingIngester) IngestDocument(_ context.Context, id string, doc *docloader.Document) (map[string]any, error) {
+	r.id, r.doc = id, doc
+	return map[string]any{"id": id}, nil
+}
+
+func TestLoadDocumentToolMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	body := "# Notes\n\nFirst paragraph.\n\nSecond paragraph."
+	require.NoError(t, os.WriteFile(filepath.Join(base, "notes.md"), []byte(body), 0o644))
+
+	ing := &recordingIngester{}
+	tool := NewLoadDocumentTool([]string{tmp}, 0, docloader.Options{}, ing)
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"notes.md","chunk_size":20,"ingest":true}`))
+	require.NoError(t, err)
+
+	resp := respAny.(loadDocumentResult)
+	require.True(t, resp.OK, resp.Error)
+	require.Equal(t, "markdown", resp.Format)
+	require.Equal(t, body, resp.Text)
+	require.NotEmpty(t, resp.Chunks)
+	require.Equal(t, "doc:file:notes.md", ing.id)
+	require.Equal(t, "doc:file:notes.md", resp.Ingested["id"])
+}
+
+func TestLoadDocumentToolTruncates(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "long.txt"), []byte("abcdefghij"), 0o644))
+
+	tool := NewLoadDocumentTool([]string{tmp}, 0, docloader.Options{}, nil)
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"long.txt","max_chars":4}`))
+	require.NoError(t, err)
+
+	resp := respAny.(loadDocumentResult)
+	require.True(t, resp.OK, resp.Error)
+	require.Equal(t, "abcd", resp.Text)
+	require.True(t, resp.Truncated)
+}
@@ -0,0 +1,80 @@
+package filetool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"manifold/internal/llm"
+)
+
+const maxDiffBytes = 64 * 1024
+
+// Quota caps the bytes a user may store across their projects. Usage
+// reports what the user currently stores; a zero MaxBytes disables the cap.
+type Quota struct {
+	MaxBytes int64
+	Usage    func(ctx context.Context, userID int64) (int64, error)
+}
+
+// check rejects a write that grows the caller's storage by delta bytes past
+// the quota.
+func (q Quota) check(ctx context.Context, delta int64) error {
+	if q.MaxBytes <= 0 || q.Usage == nil || delta <= 0 {
+		return nil
+	}
+	userID, _ := llm.UserIDFromContext(ctx)
+	used, err := q.Usage(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("check storage quota: %w", err)
+	}
+	if used+delta > q.MaxBytes {
+		return fmt.Errorf("storage quota exceeded (%d + %d > %d bytes)", used, delta, q.MaxBytes)
+	}
+	return nil
+}
+
+// unifiedDiff renders the change from before to after as a unified diff
+// with git-style file headers. A nil before marks a new file. Binary
+// content yields no diff and long diffs are truncated.
+func unifiedDiff(rel string, before, after []byte) string {
+	if !isText(before) || !isText(after) {
+		return ""
+	}
+	rel = strings.TrimPrefix(rel, "./")
+	from := "a/" + rel
+	if before == nil {
+		from = "/dev/null"
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(before),
+		B:        diffLines(after),
+		FromFile: from,
+		ToFile:   "b/" + rel,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	if len(diff) > maxDiffBytes {
+		diff = diff[:maxDiffBytes] + "\n[TRUNCATED]"
+	}
+	return diff
+}
+
+// diffLines splits data into newline-terminated lines. Unlike
+// difflib.SplitLines it adds no phantom empty line after a final newline.
+func diffLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
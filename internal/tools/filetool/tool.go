@@ -361,6 +361,7 @@ func (t *readTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
 type writeTool struct {
 	guard    rootGuard
 	maxBytes int
+	quota    Quota
 }
 
 type writeArgs struct {
@@ -375,6 +376,7 @@ type writeResult struct {
 	Path    string `json:"path,omitempty"`
 	Bytes   int    `json:"bytes,omitempty"`
 	Created bool   `json:"created,omitempty"`
+	Diff    string `json:"diff,omitempty"`
 }
 
 func NewWriteTool(allowedRoots []string, maxBytes int) *writeTool {
@@ -387,12 +389,15 @@ func NewWriteTool(allowedRoots []string, maxBytes int) *writeTool {
 	}
 }
 
+// SetQuota caps the bytes each user may store through this tool.
+func (t *writeTool) SetQuota(q Quota) { t.quota = q }
+
 func (t *writeTool) Name() string { return "file_write" }
 
 func (t *writeTool) JSONSchema() map[string]any {
 	return map[string]any{
 		"name":        t.Name(),
-		"description": "Create or overwrite a single file in the current project workspace.",
+		"description": "Create or overwrite a single file in the current project workspace. Returns a unified diff of the change.",
 		"parameters": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -431,6 +436,7 @@ func (t *writeTool) Call(ctx context.Context, raw json.RawMessage) (any, error)
 	}
 
 	var perm fs.FileMode = 0o644
+	var before []byte
 	created := true
 	if info, err := os.Lstat(full); err == nil {
 		created = false
@@ -441,9 +447,15 @@ func (t *writeTool) Call(ctx context.Context, raw json.RawMessage) (any, error)
 			return writeResult{OK: false, Error: "path is a directory"}, nil
 		}
 		perm = info.Mode().Perm()
+		if before, err = os.ReadFile(full); err != nil {
+			return writeResult{OK: false, Error: err.Error()}, nil
+		}
 	} else if !errors.Is(err, os.ErrNotExist) {
 		return writeResult{OK: false, Error: err.Error()}, nil
 	}
+	if err := t.quota.check(ctx, int64(len(data)-len(before))); err != nil {
+		return writeResult{OK: false, Error: err.Error()}, nil
+	}
 
 	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
 		return writeResult{OK: false, Error: fmt.Sprintf("create directories: %v", err)}, nil
@@ -457,12 +469,14 @@ func (t *writeTool) Call(ctx context.Context, raw json.RawMessage) (any, error)
 		Path:    filepath.ToSlash(rel),
 		Bytes:   len(data),
 		Created: created,
+		Diff:    unifiedDiff(filepath.ToSlash(rel), before, data),
 	}, nil
 }
 
 type patchTool struct {
 	guard        rootGuard
 	maxFileBytes int64
+	quota        Quota
 }
 
 type patchArgs struct {
@@ -480,6 +494,7 @@ type patchResult struct {
 	EndLine      int    `json:"end_line,omitempty"`
 	LineCount    int    `json:"line_count,omitempty"`
 	NewLineCount int    `json:"new_line_count,omitempty"`
+	Diff         string `json:"diff,omitempty"`
 }
 
 func NewPatchTool(allowedRoots []string, maxFileBytes int64) *patchTool {
@@ -492,12 +507,15 @@ func NewPatchTool(allowedRoots []string, maxFileBytes int64) *patchTool {
 	}
 }
 
+// SetQuota caps the bytes each user may store through this tool.
+func (t *patchTool) SetQuota(q Quota) { t.quota = q }
+
 func (t *patchTool) Name() string { return "file_patch" }
 
 func (t *patchTool) JSONSchema() map[string]any {
 	return map[string]any{
 		"name":        t.Name(),
-		"description": "Replace a specific line range in a single file without modifying other lines.",
+		"description": "Replace a specific line range in a single file without modifying other lines. Returns a unified diff of the change.",
 		"parameters": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -577,6 +595,9 @@ func (t *patchTool) Call(ctx context.Context, raw json.RawMessage) (any, error)
 		out += newline
 	}
 
+	if err := t.quota.check(ctx, int64(len(out)-len(data))); err != nil {
+		return patchResult{OK: false, Error: err.Error()}, nil
+	}
 	if err := writeFileAtomic(full, []byte(out), info.Mode().Perm()); err != nil {
 		return patchResult{OK: false, Error: fmt.Sprintf("write file: %v", err)}, nil
 	}
@@ -588,6 +609,7 @@ func (t *patchTool) Call(ctx context.Context, raw json.RawMessage) (any, error)
 		EndLine:      args.EndLine,
 		LineCount:    lineCount,
 		NewLineCount: len(updated),
+		Diff:         unifiedDiff(filepath.ToSlash(rel), data, []byte(out)),
 	}, nil
 }
 
@@ -174,3 +174,132 @@ func TestDeleteToolDeletesDirectoryRecursive(t *testing.T) {
 	_, err = os.Stat(filepath.Join(base, "dir"))
 	require.True(t, errors.Is(err, os.ErrNotExist))
 }
+
+func TestWriteToolReturnsDiff(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "doc.txt"), []byte("one\ntwo\n"), 0o644))
+
+	tool := NewWriteTool([]string{tmp}, 0)
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"doc.txt","content":"one\n2\n"}`))
+	require.NoError(t, err)
+	resp := respAny.(writeResult)
+	require.True(t, resp.OK)
+	require.Equal(t, "--- a/doc.txt\n+++ b/doc.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n", resp.Diff)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"new.txt","content":"hi\n"}`))
+	require.NoError(t, err)
+	resp = respAny.(writeResult)
+	require.True(t, resp.Created)
+	require.Contains(t, resp.Diff, "--- /dev/null\n+++ b/new.txt\n")
+}
+
+func TestWriteToolEnforcesQuota(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "doc.txt"), []byte("12345"), 0o644))
+
+	tool := NewWriteTool([]string{tmp}, 0)
+	tool.SetQuota(Quota{MaxBytes: 8, Usage: func(context.Context, int64) (int64, error) { return 5, nil }})
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"other.txt","content":"abcd"}`))
+	require.NoError(t, err)
+	resp := respAny.(writeResult)
+	require.False(t, resp.OK)
+	require.Contains(t, resp.Error, "quota exceeded")
+
+	// Replacing a file only counts the growth.
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"doc.txt","content":"12345678"}`))
+	require.NoError(t, err)
+	require.True(t, respAny.(writeResult).OK)
+}
+
+func TestPatchToolReturnsDiff(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "doc.txt"), []byte("one\ntwo\nthree\n"), 0o644))
+
+	tool := NewPatchTool([]string{tmp}, 0)
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"doc.txt","start_line":3,"end_line":3,"content":"THREE"}`))
+	require.NoError(t, err)
+	resp := respAny.(patchResult)
+	require.True(t, resp.OK)
+	require.Contains(t, resp.Diff, "-three\n+THREE\n")
+}
+
+func TestListTool(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "dir", "nest"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, ".meta"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "a.txt"), []byte("abc"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "dir", "nest", "b.txt"), []byte("b"), 0o644))
+
+	tool := NewListTool([]string{tmp})
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	resp := respAny.(listResult)
+	require.True(t, resp.OK)
+	require.Len(t, resp.Entries, 2)
+	require.Equal(t, "a.txt", resp.Entries[0].Path)
+	require.Equal(t, int64(3), resp.Entries[0].Size)
+	require.Equal(t, "dir", resp.Entries[1].Type)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"dir","recursive":true}`))
+	require.NoError(t, err)
+	resp = respAny.(listResult)
+	require.True(t, resp.OK)
+	require.Len(t, resp.Entries, 2)
+	require.Equal(t, "dir/nest/b.txt", resp.Entries[1].Path)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"recursive":true,"max_entries":2}`))
+	require.NoError(t, err)
+	resp = respAny.(listResult)
+	require.True(t, resp.Truncated)
+	require.Len(t, resp.Entries, 2)
+}
+
+func TestStatTool(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "a.txt"), []byte("abc"), 0o600))
+
+	tool := NewStatTool([]string{tmp})
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"a.txt"}`))
+	require.NoError(t, err)
+	resp := respAny.(statResult)
+	require.True(t, resp.OK)
+	require.True(t, resp.Exists)
+	require.Equal(t, "file", resp.Type)
+	require.Equal(t, int64(3), resp.Size)
+	require.Equal(t, "-rw-------", resp.Mode)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"missing.txt"}`))
+	require.NoError(t, err)
+	resp = respAny.(statResult)
+	require.True(t, resp.OK)
+	require.False(t, resp.Exists)
+}
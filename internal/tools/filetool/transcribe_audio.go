@@ -0,0 +1,109 @@
+package filetool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"manifold/internal/stt"
+)
+
+// maxAudioBytes matches the upload limit of hosted transcription APIs.
+const maxAudioBytes = 25 << 20
+
+type transcribeAudioTool struct {
+	guard       rootGuard
+	transcriber stt.Transcriber
+}
+
+type transcribeAudioArgs struct {
+	Path       string `json:"path"`
+	Timestamps *bool  `json:"timestamps"`
+	Diarize    bool   `json:"diarize"`
+	Language   string `json:"language"`
+}
+
+type transcribeAudioResult struct {
+	OK         bool            `json:"ok"`
+	Path       string          `json:"path,omitempty"`
+	Transcript *stt.Transcript `json:"transcript,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// NewTranscribeAudioTool returns a tool that transcribes audio files in the
+// project workspace with timestamps and optional speaker labels.
+func NewTranscribeAudioTool(allowedRoots []string, transcriber stt.Transcriber) *transcribeAudioTool {
+	return &transcribeAudioTool{guard: newRootGuard(allowedRoots), transcriber: transcriber}
+}
+
+func (t *transcribeAudioTool) Name() string { return "transcribe_audio" }
+
+func (t *transcribeAudioTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Transcribe a recording in the project workspace (.wav, .mp3, .m4a, .ogg, .webm, .flac). Returns {text, language, duration, speakers, segments[{id, start, end, text, speaker}]} with times in seconds, suitable for meeting notes.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":       map[string]any{"type": "string", "description": "Audio file path relative to the project root."},
+				"timestamps": map[string]any{"type": "boolean", "description": "Return timed segments (default true)."},
+				"diarize":    map[string]any{"type": "boolean", "description": "Label each segment with its speaker. Needs a diarizing STT model; without one every segment is SPEAKER_1."},
+				"language":   map[string]any{"type": "string", "description": "ISO-639-1 language hint such as \"en\"."},
+			},
+			"required": []any{"path"},
+		},
+	}
+}
+
+func (t *transcribeAudioTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args transcribeAudioArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return transcribeAudioResult{OK: false, Error: "missing path"}, nil
+	}
+	if t.transcriber == nil {
+		return transcribeAudioResult{OK: false, Error: "speech-to-text is not configured"}, nil
+	}
+	base, err := t.guard.baseDir(ctx)
+	if err != nil {
+		return transcribeAudioResult{OK: false, Error: err.Error()}, nil
+	}
+	rel, full, err := resolvePath(base, args.Path)
+	if err != nil {
+		return transcribeAudioResult{OK: false, Error: fmt.Sprintf("invalid path: %v", err)}, nil
+	}
+	relSlash := filepath.ToSlash(rel)
+	info, err := os.Lstat(full)
+	if err != nil {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: err.Error()}, nil
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: "refusing to read symlink"}, nil
+	}
+	if info.IsDir() {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: "path is a directory"}, nil
+	}
+	if info.Size() > maxAudioBytes {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: fmt.Sprintf("audio file is %d bytes; the limit is %d", info.Size(), maxAudioBytes)}, nil
+	}
+	audio, err := os.ReadFile(full)
+	if err != nil {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: err.Error()}, nil
+	}
+
+	opts := stt.Options{Timestamps: true, Diarize: args.Diarize, Language: args.Language}
+	if args.Timestamps != nil {
+		opts.Timestamps = *args.Timestamps
+	}
+	tr, err := t.transcriber.Transcribe(ctx, audio, opts)
+	if err != nil {
+		return transcribeAudioResult{OK: false, Path: relSlash, Error: err.Error()}, nil
+	}
+	return transcribeAudioResult{OK: true, Path: relSlash, Transcript: &tr}, nil
+}
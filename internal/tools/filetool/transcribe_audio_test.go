@@ -0,0 +1,54 @@
+package filetool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"manifold/internal/sandbox"
+	"manifold/internal/stt"
+)
+
+type recordingTranscriber struct {
+	audio []byte
+	opts  stt.Options
+}
+
+func (r *recordingTranscriber) Transcribe(_ context.Context, audio []byte, opts stt.Options) (stt.Transcript, error) {
+	r.audio, r.opts = audio, opts
+	return stt.Transcript{Text: "hello", Segments: []stt.Segment{{Start: 0, End: 1, Text: "hello", Speaker: "SPEAKER_1"}}}, nil
+}
+
+func TestTranscribeAudioTool(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	base := filepath.Join(tmp, "project")
+	require.NoError(t, os.MkdirAll(base, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "standup.mp3"), []byte("ID3audio"), 0o644))
+
+	tr := &recordingTranscriber{}
+	tool := NewTranscribeAudioTool([]string{tmp}, tr)
+	ctx := sandbox.WithBaseDir(context.Background(), base)
+
+	respAny, err := tool.Call(ctx, json.RawMessage(`{"path":"standup.mp3","diarize":true}`))
+	require.NoError(t, err)
+	resp := respAny.(transcribeAudioResult)
+	require.True(t, resp.OK, resp.Error)
+	require.Equal(t, "./standup.mp3", resp.Path)
+	require.Equal(t, "hello", resp.Transcript.Text)
+	require.Equal(t, []byte("ID3audio"), tr.audio)
+	require.Equal(t, stt.Options{Timestamps: true, Diarize: true}, tr.opts)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"../escape.wav"}`))
+	require.NoError(t, err)
+	require.False(t, respAny.(transcribeAudioResult).OK)
+
+	respAny, err = tool.Call(ctx, json.RawMessage(`{"path":"missing.wav","timestamps":false}`))
+	require.NoError(t, err)
+	require.False(t, respAny.(transcribeAudioResult).OK)
+}
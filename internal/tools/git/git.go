@@ -0,0 +1,214 @@
+// Package git provides repository tools that run the git binary inside the
+// run's working directory: git_clone, git_status, git_diff, git_branch,
+// git_commit and git_push. Git runs on the backend run_cli would use.
+// Pushing needs a person's approval unless configured otherwise, and never
+// forces.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+	"manifold/internal/tools/cli"
+)
+
+// commandTimeout bounds one git invocation; clones and pushes talk to the
+// network.
+const commandTimeout = 5 * time.Minute
+
+// cloneURL accepts https, http, ssh and git URLs and scp-style
+// "user@host:path" remotes. Local paths and transports such as ext:: are
+// rejected.
+var cloneURL = regexp.MustCompile(`^((https?|ssh|git)://[^\s]+|[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^\s]+)$`)
+
+// hostEnv names the server environment variables host runs get; the rest,
+// e.g. DATABASE_URL or provider keys, is withheld.
+var hostEnv = []string{"PATH", "HOME", "LANG", "LC_ALL", "TZ", "TMPDIR", "SSH_AUTH_SOCK", "GIT_CONFIG_GLOBAL"}
+
+// safeConfig overrides repository settings that make git run programs. The
+// repository is writable by run_cli, so its .git/config cannot be trusted.
+var safeConfig = []string{"-c", "core.fsmonitor=", "-c", "core.hooksPath=/dev/null", "-c", "core.pager=cat"}
+
+// Runner runs git for the tools. Paths are relative to the run's base
+// directory and must stay inside it.
+type Runner struct {
+	cfg       config.ExecGitConfig
+	container config.ExecContainerConfig
+	workdir   string
+	outLimit  int
+}
+
+// NewRunner returns a runner rooted at workdir from the exec config; outLimit
+// caps the output a tool returns.
+func NewRunner(cfg config.ExecConfig, workdir string, outLimit int) *Runner {
+	git := cfg.Git
+	if git.AuthorName == "" {
+		git.AuthorName = "Manifold Agent"
+	}
+	if git.AuthorEmail == "" {
+		git.AuthorEmail = "agent@manifold.local"
+	}
+	if outLimit <= 0 {
+		outLimit = 64 * 1024
+	}
+	return &Runner{cfg: git, container: cfg.Container, workdir: workdir, outLimit: outLimit}
+}
+
+// dir resolves a repository path under the run's base directory.
+func (r *Runner) dir(ctx context.Context, path string) (string, error) {
+	base := sandbox.ResolveBaseDir(ctx, r.workdir)
+	path = strings.TrimSpace(path)
+	if path == "" || path == "." {
+		return base, nil
+	}
+	if filepath.IsAbs(path) || !filepath.IsLocal(path) {
+		return "", fmt.Errorf("path must stay inside the working directory: %q", path)
+	}
+	return filepath.Join(base, path), nil
+}
+
+// repo resolves path and checks that it is inside a git work tree.
+func (r *Runner) repo(ctx context.Context, path string) (string, error) {
+	dir, err := r.dir(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.run(ctx, dir, nil, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return "", fmt.Errorf("%q is not a git repository", path)
+	}
+	return dir, nil
+}
+
+// run executes git in dir and returns its stdout. Failures carry git's
+// stderr.
+func (r *Runner) run(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	return r.invoke(ctx, dir, env, "", args)
+}
+
+// runInput is run with stdin.
+func (r *Runner) runInput(ctx context.Context, dir, stdin string, args ...string) (string, error) {
+	return r.invoke(ctx, dir, nil, stdin, args)
+}
+
+func (r *Runner) invoke(ctx context.Context, dir string, env []string, stdin string, args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	// Per-run variables (e.g. project secrets) precede the git settings.
+	// Never wait on a credential prompt.
+	env = append(append(sandbox.EnvFromContext(ctx),
+		"GIT_CONFIG_NOSYSTEM=1", "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=", "GIT_SSH_COMMAND=ssh -o BatchMode=yes"), env...)
+	args = append(slices.Clone(safeConfig), args...)
+	var c *exec.Cmd
+	if cli.Backend(ctx, r.container) == sandbox.ExecBackendContainer {
+		base := sandbox.ResolveBaseDir(ctx, r.workdir)
+		rel, err := filepath.Rel(base, dir)
+		if err != nil {
+			return "", err
+		}
+		c = cli.ContainerCommand(ctx, r.container, cli.ContainerSpec{
+			Base:    base,
+			Env:     env,
+			Command: "git",
+			Args:    append([]string{"-C", filepath.ToSlash(rel)}, args...),
+			Stdin:   stdin != "",
+		})
+	} else {
+		c = exec.CommandContext(ctx, "git", args...)
+		c.Dir = dir
+		c.Env = append(environ(), env...)
+	}
+	c.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			msg = "timed out: " + msg
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", subcommand(args), msg)
+	}
+	return stdout.String(), nil
+}
+
+// environ returns the allow-listed part of the server environment.
+func environ() []string {
+	var env []string
+	for _, name := range hostEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// subcommand names the git command in args, skipping leading -c options.
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// truncate caps s at the output limit, cutting on a rune boundary.
+func (r *Runner) truncate(s string) (string, bool) {
+	if len(s) <= r.outLimit {
+		return s, false
+	}
+	n := r.outLimit
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n[TRUNCATED]", true
+}
+
+// checkName rejects values git could read as options.
+func checkName(kind, v string) error {
+	if strings.HasPrefix(v, "-") {
+		return fmt.Errorf("%s must not start with '-': %q", kind, v)
+	}
+	return nil
+}
+
+// checkBranch validates a branch name with git check-ref-format.
+func (r *Runner) checkBranch(ctx context.Context, dir, name string) error {
+	if err := checkName("branch", name); err != nil {
+		return err
+	}
+	if _, err := r.run(ctx, dir, nil, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	return nil
+}
+
+// localPaths validates pathspecs relative to the repository.
+func localPaths(files []string) error {
+	for _, f := range files {
+		if f == "" || filepath.IsAbs(f) || !filepath.IsLocal(f) {
+			return fmt.Errorf("file must stay inside the repository: %q", f)
+		}
+		if err := checkName("file", f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
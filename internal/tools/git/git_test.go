@@ -0,0 +1,207 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+	"manifold/internal/tools"
+)
+
+type caller interface {
+	Call(ctx context.Context, raw json.RawMessage) (any, error)
+}
+
+func call(t *testing.T, ctx context.Context, tl caller, args string) map[string]any {
+	t.Helper()
+	out, err := tl.Call(ctx, json.RawMessage(args))
+	if err != nil {
+		t.Fatalf("%T(%s): %v", tl, args, err)
+	}
+	return out.(map[string]any)
+}
+
+func newRepo(t *testing.T) (*Runner, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	if out, err := exec.Command("git", "init", "--quiet", "--initial-branch=main", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v %s", err, out)
+	}
+	return NewRunner(config.ExecConfig{}, base, 0), repo
+}
+
+func TestStatusCommitDiffBranch(t *testing.T) {
+	r, repo := newRepo(t)
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := call(t, ctx, NewStatusTool(r), `{"path":"repo"}`)
+	files := st["files"].([]FileStatus)
+	if st["branch"] != "main" || len(files) != 1 || files[0].Path != "a.txt" || files[0].Unstaged != "?" {
+		t.Fatalf("unexpected status %+v", st)
+	}
+
+	c := call(t, ctx, NewCommitTool(r), `{"path":"repo","message":"Add a","all":true}`)
+	if c["subject"] != "Add a" || len(c["commit"].(string)) != 40 {
+		t.Fatalf("unexpected commit %+v", c)
+	}
+	author, _ := exec.Command("git", "-C", repo, "log", "-1", "--format=%an <%ae>").Output()
+	if strings.TrimSpace(string(author)) != "Manifold Agent <agent@manifold.local>" {
+		t.Fatalf("unexpected author %q", author)
+	}
+	if _, err := NewCommitTool(r).Call(ctx, json.RawMessage(`{"path":"repo","message":"empty","all":true}`)); err == nil {
+		t.Fatal("expected nothing to commit")
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d := call(t, ctx, NewDiffTool(r), `{"path":"repo"}`)
+	if diff := d["diff"].(string); !strings.Contains(diff, "-one\n+two") {
+		t.Fatalf("unexpected diff %q", diff)
+	}
+	if d := call(t, ctx, NewDiffTool(r), `{"path":"repo","staged":true}`); d["diff"] != "" {
+		t.Fatalf("expected no staged changes, got %q", d["diff"])
+	}
+
+	b := call(t, ctx, NewBranchTool(r), `{"path":"repo","create":"feature/x"}`)
+	if b["current"] != "feature/x" || len(b["branches"].([]string)) != 2 {
+		t.Fatalf("unexpected branches %+v", b)
+	}
+	if _, err := NewBranchTool(r).Call(ctx, json.RawMessage(`{"path":"repo","create":"--orphan"}`)); err == nil {
+		t.Fatal("expected option-like branch to be rejected")
+	}
+}
+
+func TestPathsStayInWorkdir(t *testing.T) {
+	r, _ := newRepo(t)
+	ctx := context.Background()
+	for _, args := range []string{`{"path":"../x"}`, `{"path":"/etc"}`} {
+		if _, err := NewStatusTool(r).Call(ctx, json.RawMessage(args)); err == nil {
+			t.Fatalf("expected %s to be rejected", args)
+		}
+	}
+	if _, err := NewDiffTool(r).Call(ctx, json.RawMessage(`{"path":"repo","files":["../../etc/passwd"]}`)); err == nil {
+		t.Fatal("expected file outside the repository to be rejected")
+	}
+	for _, url := range []string{"/tmp/other", "file:///tmp/other", "ext::sh -c touch% /tmp/pwned"} {
+		raw, _ := json.Marshal(map[string]string{"url": url})
+		if _, err := NewCloneTool(r).Call(ctx, raw); err == nil {
+			t.Fatalf("expected clone of %q to be rejected", url)
+		}
+	}
+}
+
+func TestPushRequiresApproval(t *testing.T) {
+	r, repo := newRepo(t)
+	ctx := context.Background()
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	for _, args := range [][]string{
+		{"init", "--quiet", "--bare", remote},
+		{"-C", repo, "remote", "add", "origin", remote},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	call(t, ctx, NewCommitTool(r), `{"path":"repo","message":"init","files":["a.txt"]}`)
+
+	if p := call(t, ctx, NewPushTool(r), `{"path":"repo"}`); p["pushed"] != false {
+		t.Fatalf("unapproved push went out: %+v", p)
+	}
+	if out, _ := exec.Command("git", "-C", remote, "branch", "--list").Output(); len(out) != 0 {
+		t.Fatalf("remote changed without approval: %s", out)
+	}
+
+	p := call(t, tools.WithApproval(ctx, "git_push"), NewPushTool(r), `{"path":"repo","set_upstream":true}`)
+	if p["pushed"] != true || p["branch"] != "main" {
+		t.Fatalf("approved push failed: %+v", p)
+	}
+	st := call(t, ctx, NewStatusTool(r), `{"path":"repo"}`)
+	if st["upstream"] != "origin/main" {
+		t.Fatalf("upstream not set: %+v", st)
+	}
+}
+
+func TestRepoConfigCannotRunPrograms(t *testing.T) {
+	r, repo := newRepo(t)
+	ctx := context.Background()
+	// What a run_cli command could leave in the shared working directory.
+	marker := filepath.Join(t.TempDir(), "pwned")
+	for _, kv := range [][2]string{{"core.fsmonitor", "touch " + marker + "-fsmonitor; false"}, {"core.pager", "touch " + marker + "-pager; cat"}} {
+		if out, err := exec.Command("git", "-C", repo, "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config: %v %s", err, out)
+		}
+	}
+	hook := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ntouch "+marker+"-hook\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	call(t, ctx, NewStatusTool(r), `{"path":"repo"}`)
+	call(t, ctx, NewCommitTool(r), `{"path":"repo","message":"Add a","all":true}`)
+	call(t, ctx, NewDiffTool(r), `{"path":"repo","ref":"HEAD"}`)
+	for _, suffix := range []string{"-fsmonitor", "-pager", "-hook"} {
+		if _, err := os.Stat(marker + suffix); err == nil {
+			t.Fatalf("repository config ran a program (%s)", suffix)
+		}
+	}
+}
+
+func TestContainerBackend(t *testing.T) {
+	// A fake runtime records its arguments and environment.
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"$0.args\"\nenv > \"$0.env\"\n"
+	if err := os.WriteFile(runtime, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	base := t.TempDir()
+	r := NewRunner(config.ExecConfig{Container: config.ExecContainerConfig{Runtime: runtime, Image: "alpine/git"}}, base, 0)
+	t.Setenv("MANIFOLD_TEST_SERVER_SECRET", "s3cret")
+
+	ctx := sandbox.WithExecBackend(context.Background(), sandbox.ExecBackendContainer)
+	call(t, ctx, NewDiffTool(r), `{"path":"repo"}`)
+	raw, err := os.ReadFile(runtime + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(strings.Fields(string(raw)), " ")
+	for _, want := range []string{"run --rm", "--network none", base + ":/workspace:rw", "--env GIT_CONFIG_NOSYSTEM", "alpine/git git -C repo -c core.fsmonitor= -c core.hooksPath=/dev/null -c core.pager=cat diff"} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("container args %q missing %q", args, want)
+		}
+	}
+	if strings.Contains(args, "MANIFOLD_TEST_SERVER_SECRET") {
+		t.Fatalf("server environment forwarded to the container: %q", args)
+	}
+}
+
+func TestTruncateCutsOnRuneBoundary(t *testing.T) {
+	r := NewRunner(config.ExecConfig{}, t.TempDir(), 4)
+	got, cut := r.truncate("ab日本")
+	if !cut || got != "ab\n[TRUNCATED]" || !utf8.ValidString(got) {
+		t.Fatalf("truncate = %q, %v", got, cut)
+	}
+}
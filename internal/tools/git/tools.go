@@ -0,0 +1,492 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"manifold/internal/tools"
+)
+
+const pushToolName = "git_push"
+
+var pathParam = map[string]any{"type": "string", "description": "Repository directory relative to the working directory (default \".\")."}
+
+func decode(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+type cloneTool struct{ r *Runner }
+
+// NewCloneTool constructs git_clone.
+func NewCloneTool(r *Runner) *cloneTool { return &cloneTool{r: r} }
+
+func (t *cloneTool) Name() string { return "git_clone" }
+
+func (t *cloneTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Clone a remote repository (https, ssh or git URL) into a new directory under the working directory.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url":    map[string]any{"type": "string"},
+				"dir":    map[string]any{"type": "string", "description": "Target directory (default: the repository name)."},
+				"branch": map[string]any{"type": "string", "description": "Branch or tag to check out."},
+				"depth":  map[string]any{"type": "integer", "minimum": 1, "description": "Create a shallow clone with this many commits."},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+func (t *cloneTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		URL    string `json:"url"`
+		Dir    string `json:"dir"`
+		Branch string `json:"branch"`
+		Depth  int    `json:"depth"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if !cloneURL.MatchString(args.URL) {
+		return nil, errors.New("url must be an https, http, ssh or git remote")
+	}
+	if args.Dir == "" {
+		args.Dir = strings.TrimSuffix(path.Base(strings.TrimRight(args.URL, "/")), ".git")
+		if _, after, ok := strings.Cut(args.Dir, ":"); ok {
+			args.Dir = after
+		}
+	}
+	target, err := t.r.dir(ctx, args.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%q already exists", args.Dir)
+	}
+	base, err := t.r.dir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	cmd := []string{"clone", "--quiet"}
+	if args.Branch != "" {
+		if err := checkName("branch", args.Branch); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, "--branch", args.Branch)
+	}
+	if args.Depth > 0 {
+		cmd = append(cmd, "--depth", strconv.Itoa(args.Depth))
+	}
+	// The target is relative to base so it holds in a container as well.
+	cmd = append(cmd, "--", args.URL, filepath.Clean(args.Dir))
+	// Only network transports, whatever the URL or its submodules say.
+	if _, err := t.r.run(ctx, base, []string{"GIT_ALLOW_PROTOCOL=https:http:ssh:git"}, cmd...); err != nil {
+		return nil, err
+	}
+	head, _ := t.r.run(ctx, target, nil, "rev-parse", "--abbrev-ref", "HEAD")
+	return map[string]any{"ok": true, "dir": args.Dir, "branch": strings.TrimSpace(head)}, nil
+}
+
+// FileStatus is one changed path from git status.
+type FileStatus struct {
+	Path string `json:"path"`
+	// Staged and Unstaged are the porcelain X and Y codes, e.g. "M", "A",
+	// "D", "?" for untracked.
+	Staged   string `json:"staged,omitempty"`
+	Unstaged string `json:"unstaged,omitempty"`
+}
+
+type statusTool struct{ r *Runner }
+
+// NewStatusTool constructs git_status.
+func NewStatusTool(r *Runner) *statusTool { return &statusTool{r: r} }
+
+func (t *statusTool) Name() string { return "git_status" }
+
+func (t *statusTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Show the current branch, its upstream and ahead/behind counts, and changed files with their staged and unstaged status.",
+		"parameters": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"path": pathParam},
+		},
+	}
+}
+
+func (t *statusTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	dir, err := t.r.repo(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.r.run(ctx, dir, nil, "status", "--porcelain=v1", "--branch", "-z")
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]any{"ok": true}
+	files := []FileStatus{}
+	entries := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		if head, ok := strings.CutPrefix(e, "## "); ok {
+			head = strings.TrimPrefix(head, "No commits yet on ")
+			branch, rest, _ := strings.Cut(head, " ")
+			branch, upstream, _ := strings.Cut(branch, "...")
+			res["branch"] = branch
+			if upstream != "" {
+				res["upstream"] = upstream
+			}
+			for _, part := range strings.Split(strings.Trim(rest, "[]"), ", ") {
+				if n, ok := strings.CutPrefix(part, "ahead "); ok {
+					res["ahead"], _ = strconv.Atoi(n)
+				} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+					res["behind"], _ = strconv.Atoi(n)
+				}
+			}
+			continue
+		}
+		if len(e) < 4 {
+			continue
+		}
+		fs := FileStatus{Path: e[3:], Staged: strings.TrimSpace(e[:1]), Unstaged: strings.TrimSpace(e[1:2])}
+		if e[0] == '?' {
+			fs.Staged = ""
+		}
+		// Renames and copies are followed by the original path.
+		if e[0] == 'R' || e[0] == 'C' {
+			i++
+		}
+		files = append(files, fs)
+	}
+	res["files"] = files
+	res["clean"] = len(files) == 0
+	return res, nil
+}
+
+type diffTool struct{ r *Runner }
+
+// NewDiffTool constructs git_diff.
+func NewDiffTool(r *Runner) *diffTool { return &diffTool{r: r} }
+
+func (t *diffTool) Name() string { return "git_diff" }
+
+func (t *diffTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Show a unified diff: unstaged changes by default, staged changes with staged=true, or the work tree against a commit or branch with ref.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":   pathParam,
+				"staged": map[string]any{"type": "boolean"},
+				"ref":    map[string]any{"type": "string", "description": "Commit, branch or range such as main...HEAD."},
+				"files":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Limit the diff to these paths."},
+				"stat":   map[string]any{"type": "boolean", "description": "Return a diffstat summary instead of the patch."},
+			},
+		},
+	}
+}
+
+func (t *diffTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path   string   `json:"path"`
+		Staged bool     `json:"staged"`
+		Ref    string   `json:"ref"`
+		Files  []string `json:"files"`
+		Stat   bool     `json:"stat"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if err := localPaths(args.Files); err != nil {
+		return nil, err
+	}
+	dir, err := t.r.repo(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	cmd := []string{"diff", "--no-color", "--no-ext-diff"}
+	if args.Staged {
+		cmd = append(cmd, "--cached")
+	}
+	if args.Stat {
+		cmd = append(cmd, "--stat")
+	}
+	if args.Ref != "" {
+		if err := checkName("ref", args.Ref); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, args.Ref)
+	}
+	cmd = append(append(cmd, "--"), args.Files...)
+	out, err := t.r.run(ctx, dir, nil, cmd...)
+	if err != nil {
+		return nil, err
+	}
+	diff, truncated := t.r.truncate(out)
+	return map[string]any{"ok": true, "diff": diff, "truncated": truncated}, nil
+}
+
+type branchTool struct{ r *Runner }
+
+// NewBranchTool constructs git_branch.
+func NewBranchTool(r *Runner) *branchTool { return &branchTool{r: r} }
+
+func (t *branchTool) Name() string { return "git_branch" }
+
+func (t *branchTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "List local branches, create a branch (optionally from start_point) and switch to it, or switch to an existing branch. Switching fails rather than discarding uncommitted changes.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":        pathParam,
+				"create":      map[string]any{"type": "string", "description": "Name of a new branch to create and switch to."},
+				"start_point": map[string]any{"type": "string", "description": "Commit or branch the new branch starts from (default HEAD)."},
+				"switch":      map[string]any{"type": "string", "description": "Existing branch to switch to."},
+			},
+		},
+	}
+}
+
+func (t *branchTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path       string `json:"path"`
+		Create     string `json:"create"`
+		StartPoint string `json:"start_point"`
+		Switch     string `json:"switch"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	dir, err := t.r.repo(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case args.Create != "" && args.Switch != "":
+		return nil, errors.New("give either create or switch, not both")
+	case args.Create != "":
+		if err := t.r.checkBranch(ctx, dir, args.Create); err != nil {
+			return nil, err
+		}
+		cmd := []string{"switch", "--quiet", "--create", args.Create}
+		if args.StartPoint != "" {
+			if err := checkName("start_point", args.StartPoint); err != nil {
+				return nil, err
+			}
+			cmd = append(cmd, args.StartPoint)
+		}
+		if _, err := t.r.run(ctx, dir, nil, cmd...); err != nil {
+			return nil, err
+		}
+	case args.Switch != "":
+		if err := t.r.checkBranch(ctx, dir, args.Switch); err != nil {
+			return nil, err
+		}
+		if _, err := t.r.run(ctx, dir, nil, "switch", "--quiet", args.Switch); err != nil {
+			return nil, err
+		}
+	}
+	out, err := t.r.run(ctx, dir, nil, "branch", "--list", "--format=%(HEAD)%(refname:short)")
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]any{"ok": true}
+	branches := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if name, ok := strings.CutPrefix(line, "*"); ok {
+			res["current"] = name
+			branches = append(branches, name)
+		} else if name := strings.TrimSpace(line); name != "" {
+			branches = append(branches, name)
+		}
+	}
+	if _, ok := res["current"]; !ok {
+		// A new repository has no branch refs until the first commit.
+		head, _ := t.r.run(ctx, dir, nil, "symbolic-ref", "--short", "HEAD")
+		res["current"] = strings.TrimSpace(head)
+	}
+	res["branches"] = branches
+	return res, nil
+}
+
+type commitTool struct{ r *Runner }
+
+// NewCommitTool constructs git_commit.
+func NewCommitTool(r *Runner) *commitTool { return &commitTool{r: r} }
+
+func (t *commitTool) Name() string { return "git_commit" }
+
+func (t *commitTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Stage the given files (or every change with all=true) and create a commit. With neither, commits what is already staged.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":    pathParam,
+				"message": map[string]any{"type": "string"},
+				"files":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Paths to stage, including deletions."},
+				"all":     map[string]any{"type": "boolean", "description": "Stage all changes, including untracked files."},
+			},
+			"required": []string{"message"},
+		},
+	}
+}
+
+func (t *commitTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path    string   `json:"path"`
+		Message string   `json:"message"`
+		Files   []string `json:"files"`
+		All     bool     `json:"all"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Message) == "" {
+		return nil, errors.New("message is required")
+	}
+	if err := localPaths(args.Files); err != nil {
+		return nil, err
+	}
+	dir, err := t.r.repo(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case args.All:
+		_, err = t.r.run(ctx, dir, nil, "add", "--all")
+	case len(args.Files) > 0:
+		_, err = t.r.run(ctx, dir, nil, append([]string{"add", "--all", "--"}, args.Files...)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.r.run(ctx, dir, nil, "diff", "--cached", "--quiet"); err == nil {
+		return nil, errors.New("nothing to commit")
+	}
+	var cmd []string
+	// Respect an identity the repository or user already configured.
+	if email, _ := t.r.run(ctx, dir, nil, "config", "user.email"); strings.TrimSpace(email) == "" {
+		cmd = append(cmd, "-c", "user.name="+t.r.cfg.AuthorName, "-c", "user.email="+t.r.cfg.AuthorEmail)
+	}
+	cmd = append(cmd, "commit", "--quiet", "--no-verify", "--file=-")
+	if _, err := t.r.runInput(ctx, dir, args.Message, cmd...); err != nil {
+		return nil, err
+	}
+	out, err := t.r.run(ctx, dir, nil, "log", "-1", "--format=%H%x00%h%x00%s")
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(strings.TrimSpace(out), "\x00", 3)
+	res := map[string]any{"ok": true, "commit": parts[0]}
+	if len(parts) == 3 {
+		res["short"], res["subject"] = parts[1], parts[2]
+	}
+	stat, _ := t.r.run(ctx, dir, nil, "show", "--stat", "--format=", "HEAD")
+	res["stat"] = strings.TrimSpace(stat)
+	return res, nil
+}
+
+type pushTool struct{ r *Runner }
+
+// NewPushTool constructs git_push. Unless direct pushing is allowed it only
+// pushes calls a person approved (see tools.Approved).
+func NewPushTool(r *Runner) *pushTool { return &pushTool{r: r} }
+
+func (t *pushTool) Name() string { return pushToolName }
+
+func (t *pushTool) JSONSchema() map[string]any {
+	desc := "Push a branch to a remote. Force pushes are not supported."
+	if !t.r.cfg.AllowDirectPush {
+		desc += " A person must approve the call before anything is pushed."
+	}
+	return map[string]any{
+		"name":        t.Name(),
+		"description": desc,
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":         pathParam,
+				"remote":       map[string]any{"type": "string", "description": "Remote name (default origin)."},
+				"branch":       map[string]any{"type": "string", "description": "Branch to push (default: the current branch)."},
+				"set_upstream": map[string]any{"type": "boolean", "description": "Record the remote branch as upstream."},
+			},
+		},
+	}
+}
+
+func (t *pushTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Path        string `json:"path"`
+		Remote      string `json:"remote"`
+		Branch      string `json:"branch"`
+		SetUpstream bool   `json:"set_upstream"`
+	}
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Remote == "" {
+		args.Remote = "origin"
+	}
+	if err := checkName("remote", args.Remote); err != nil {
+		return nil, err
+	}
+	dir, err := t.r.repo(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if args.Branch == "" {
+		head, err := t.r.run(ctx, dir, nil, "symbolic-ref", "--short", "HEAD")
+		if err != nil {
+			return nil, errors.New("HEAD is detached; name the branch to push")
+		}
+		args.Branch = strings.TrimSpace(head)
+	}
+	if err := t.r.checkBranch(ctx, dir, args.Branch); err != nil {
+		return nil, err
+	}
+	if !t.r.cfg.AllowDirectPush && !tools.Approved(ctx, pushToolName) {
+		return map[string]any{
+			"ok":      false,
+			"pushed":  false,
+			"remote":  args.Remote,
+			"branch":  args.Branch,
+			"message": "git_push needs a person to approve the call before it pushes.",
+		}, nil
+	}
+	cmd := []string{"push", "--porcelain"}
+	if args.SetUpstream {
+		cmd = append(cmd, "--set-upstream")
+	}
+	cmd = append(cmd, args.Remote, "refs/heads/"+args.Branch+":refs/heads/"+args.Branch)
+	out, err := t.r.run(ctx, dir, nil, cmd...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"ok": true, "pushed": true, "remote": args.Remote, "branch": args.Branch, "output": strings.TrimSpace(out)}, nil
+}
@@ -1,3 +1,376 @@
+// Package http provides the http_request tool, which lets agents call REST
+// endpoints. Credentials are referenced by name and substituted server-side
+// so their values never reach the model.
 package http
 
-// Future HTTP tool (safe GET/HEAD with allowlist).
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/sandbox"
+)
+
+const (
+	defaultMaxBytes = 64 * 1024
+	maxRedirects    = 10
+	redacted        = "[REDACTED]"
+)
+
+// secretRef matches {{secret:NAME}} placeholders.
+var secretRef = regexp.MustCompile(`\{\{\s*secret:([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+type credential struct {
+	value   string
+	domains []string
+}
+
+// StoredSecret is a secret from the instance secrets store with the hosts
+// it may be sent to and the users whose calls may send it.
+type StoredSecret struct {
+	Value   string
+	Domains []string
+	UserIDs []int64
+}
+
+type tool struct {
+	client      *nethttp.Client
+	allow       []string
+	timeout     time.Duration
+	maxBytes    int
+	credentials map[string]credential
+	lookup      func(name string) (StoredSecret, bool)
+}
+
+// NewTool constructs the http_request tool. A nil client uses a default one.
+func NewTool(cfg config.HTTPRequestConfig, client *nethttp.Client) *tool {
+	if client == nil {
+		client = &nethttp.Client{}
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	maxBytes := cfg.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = 1 << 20
+	}
+	t := &tool{
+		client:      client,
+		allow:       normalizeDomains(cfg.AllowedDomains),
+		timeout:     timeout,
+		maxBytes:    maxBytes,
+		credentials: make(map[string]credential, len(cfg.Credentials)),
+	}
+	for _, c := range cfg.Credentials {
+		domains := normalizeDomains(c.Domains)
+		if len(domains) == 0 {
+			domains = t.allow
+		}
+		t.credentials[strings.TrimSpace(c.Name)] = credential{value: c.Value, domains: domains}
+	}
+	return t
+}
+
+// SetSecretLookup adds fn, typically the instance secrets store, as the last
+// place {{secret:NAME}} references are resolved from.
+func (t *tool) SetSecretLookup(fn func(name string) (StoredSecret, bool)) {
+	t.lookup = fn
+}
+
+func normalizeDomains(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, d := range in {
+		if d = strings.ToLower(strings.Trim(strings.TrimSpace(d), ".")); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func matchDomain(domains []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tool) Name() string { return "http_request" }
+
+func (t *tool) JSONSchema() map[string]any {
+	desc := "Call an HTTP(S) endpoint with any method, headers and body, and return the status, headers and (possibly truncated) body. Reference credentials by name as {{secret:NAME}} in the URL, header values or body; they are filled in server-side and redacted from the response."
+	if len(t.allow) > 0 {
+		desc += " Requests are limited to: " + strings.Join(t.allow, ", ") + "."
+	}
+	if len(t.credentials) > 0 {
+		names := make([]string, 0, len(t.credentials))
+		for name := range t.credentials {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		desc += " Available credentials: " + strings.Join(names, ", ") + "."
+	}
+	return map[string]any{
+		"name":        t.Name(),
+		"description": desc,
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"method":          map[string]any{"type": "string", "description": "HTTP method (default GET)."},
+				"url":             map[string]any{"type": "string", "description": "Absolute http(s) URL."},
+				"headers":         map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}, "description": "Request headers, e.g. {\"Authorization\": \"Bearer {{secret:API_TOKEN}}\"}."},
+				"body":            map[string]any{"type": "string", "description": "Raw request body."},
+				"json":            map[string]any{"description": "JSON request body; sets Content-Type to application/json. Ignored when body is set."},
+				"max_bytes":       map[string]any{"type": "integer", "minimum": 1, "description": "Maximum response bytes to return (default 65536)."},
+				"timeout_seconds": map[string]any{"type": "integer", "minimum": 1, "description": "Request timeout, capped at the configured limit."},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+type args struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`
+	Body           string            `json:"body"`
+	JSON           json.RawMessage   `json:"json"`
+	MaxBytes       int               `json:"max_bytes"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+type result struct {
+	OK         bool              `json:"ok"`
+	Error      string            `json:"error,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Encoding   string            `json:"encoding,omitempty"`
+	Bytes      int               `json:"bytes,omitempty"`
+	Truncated  bool              `json:"truncated,omitempty"`
+}
+
+func (t *tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var a args
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, err
+	}
+	method := strings.ToUpper(strings.TrimSpace(a.Method))
+	if method == "" {
+		method = nethttp.MethodGet
+	}
+
+	// Check the host before substituting anything so a secret is never
+	// resolved for a request that would be refused.
+	u, err := t.checkURL(a.URL)
+	if err != nil {
+		return result{OK: false, Error: err.Error()}, nil
+	}
+	subst := &substituter{t: t, ctx: ctx, host: u.Hostname()}
+	target := subst.apply(a.URL)
+	body := a.Body
+	contentType := ""
+	if body == "" && len(a.JSON) > 0 && string(a.JSON) != "null" {
+		body = string(a.JSON)
+		contentType = "application/json"
+	}
+	body = subst.apply(body)
+	headers := make(map[string]string, len(a.Headers))
+	for k, v := range a.Headers {
+		headers[k] = subst.apply(v)
+	}
+	if subst.err != nil {
+		return result{OK: false, Error: subst.err.Error()}, nil
+	}
+	// A substituted URL must still point at the host that was checked.
+	if u2, err := url.Parse(target); err != nil || !strings.EqualFold(u2.Hostname(), u.Hostname()) {
+		return result{OK: false, Error: "secrets may not change the request host"}, nil
+	}
+
+	// The model may shorten the configured timeout but never extend it.
+	timeout := t.timeout
+	if d := time.Duration(a.TimeoutSeconds) * time.Second; d > 0 && d < timeout {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := nethttp.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return result{OK: false, Error: subst.redact(err.Error())}, nil
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := *t.client
+	client.CheckRedirect = t.checkRedirect(u.Hostname(), len(subst.used) > 0)
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{OK: false, Error: subst.redact(err.Error())}, nil
+	}
+	defer resp.Body.Close()
+
+	maxBytes := defaultMaxBytes
+	if a.MaxBytes > 0 {
+		maxBytes = a.MaxBytes
+	}
+	maxBytes = min(maxBytes, t.maxBytes)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return result{OK: false, Error: subst.redact(err.Error())}, nil
+	}
+	res := result{
+		OK:         resp.StatusCode >= 200 && resp.StatusCode < 300,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string]string, len(resp.Header)),
+	}
+	if len(data) > maxBytes {
+		data = data[:maxBytes]
+		res.Truncated = true
+	}
+	res.Bytes = len(data)
+	for k, v := range resp.Header {
+		res.Headers[k] = subst.redact(strings.Join(v, ", "))
+	}
+	if utf8.Valid(data) && bytes.IndexByte(data, 0) == -1 {
+		res.Body = subst.redact(string(data))
+	} else {
+		res.Body = base64.StdEncoding.EncodeToString(data)
+		res.Encoding = "base64"
+	}
+	return res, nil
+}
+
+func (t *tool) checkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, errors.New("url must be an absolute http(s) URL")
+	}
+	if len(t.allow) > 0 && !matchDomain(t.allow, u.Hostname()) {
+		return nil, fmt.Errorf("domain %q is not in the http_request allow-list", u.Hostname())
+	}
+	return u, nil
+}
+
+// checkRedirect keeps redirects inside the allow-list and, once secrets are
+// attached, on the original host so they are not forwarded elsewhere.
+func (t *tool) checkRedirect(host string, withSecrets bool) func(*nethttp.Request, []*nethttp.Request) error {
+	return func(req *nethttp.Request, via []*nethttp.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("too many redirects")
+		}
+		if _, err := t.checkURL(req.URL.String()); err != nil {
+			return err
+		}
+		if withSecrets && !strings.EqualFold(req.URL.Hostname(), host) {
+			return fmt.Errorf("refusing to follow redirect to %q with credentials attached", req.URL.Hostname())
+		}
+		return nil
+	}
+}
+
+// substituter fills {{secret:NAME}} references for a request to host and
+// remembers the values it used so they can be redacted from the output.
+type substituter struct {
+	t    *tool
+	ctx  context.Context
+	host string
+	used []string
+	err  error
+}
+
+func (s *substituter) apply(in string) string {
+	if !strings.Contains(in, "{{") {
+		return in
+	}
+	return secretRef.ReplaceAllStringFunc(in, func(m string) string {
+		name := secretRef.FindStringSubmatch(m)[1]
+		value, err := s.t.secret(s.ctx, name, s.host)
+		if err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			return m
+		}
+		if value != "" {
+			s.used = append(s.used, value)
+		}
+		return value
+	})
+}
+
+func (s *substituter) redact(text string) string {
+	for _, v := range s.used {
+		text = strings.ReplaceAll(text, v, redacted)
+	}
+	return text
+}
+
+// secret resolves name from the configured credentials, then from the
+// project's environment variables, then from the secrets store. Project
+// variables carry no domain binding, so they are only sent when the tool has
+// an allow-list; stored secrets go only to their own domains and only for
+// the users they are scoped to.
+func (t *tool) secret(ctx context.Context, name, host string) (string, error) {
+	if c, ok := t.credentials[name]; ok {
+		if len(c.domains) == 0 {
+			return "", fmt.Errorf("credential %q has no domains configured", name)
+		}
+		if !matchDomain(c.domains, host) {
+			return "", fmt.Errorf("credential %q may not be sent to %q", name, host)
+		}
+		return c.value, nil
+	}
+	for _, kv := range sandbox.EnvFromContext(ctx) {
+		if k, v, _ := strings.Cut(kv, "="); k == name {
+			if len(t.allow) == 0 {
+				return "", fmt.Errorf("project secret %q requires web.http.allowedDomains to be set", name)
+			}
+			return v, nil
+		}
+	}
+	if t.lookup != nil {
+		if sec, ok := t.lookup(name); ok {
+			if len(sec.Domains) == 0 {
+				return "", fmt.Errorf("stored secret %q has no domains configured", name)
+			}
+			if !matchDomain(normalizeDomains(sec.Domains), host) {
+				return "", fmt.Errorf("stored secret %q may not be sent to %q", name, host)
+			}
+			// A secret scoped to users is never sent by a run that has no
+			// user, such as a webhook or background run.
+			uid, hasUser := llm.UserIDFromContext(ctx)
+			if hasUser && !slices.Contains(sec.UserIDs, uid) || !hasUser && len(sec.UserIDs) > 0 {
+				return "", fmt.Errorf("stored secret %q is not available to this user", name)
+			}
+			return sec.Value, nil
+		}
+	}
+	return "", fmt.Errorf("unknown credential %q", name)
+}
@@ -1,5 +1,177 @@
 package http
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
 
-func TestPackageBuilds(t *testing.T) {}
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/sandbox"
+)
+
+func call(t *testing.T, tl *tool, ctx context.Context, v any) result {
+	t.Helper()
+	raw, _ := json.Marshal(v)
+	out, err := tl.Call(ctx, raw)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	return out.(result)
+}
+
+func TestHTTPRequestSubstitutesAndRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, r.Method+" "+r.Header.Get("Content-Type")+" "+string(body)+" "+r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	tl := NewTool(config.HTTPRequestConfig{
+		Credentials: []config.HTTPCredentialConfig{{Name: "API_TOKEN", Value: "tok-123", Domains: []string{"127.0.0.1"}}},
+	}, srv.Client())
+	res := call(t, tl, context.Background(), map[string]any{
+		"method":  "post",
+		"url":     srv.URL,
+		"headers": map[string]string{"Authorization": "Bearer {{secret:API_TOKEN}}"},
+		"json":    map[string]any{"a": 1},
+	})
+	if !res.OK || res.StatusCode != 200 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.Body != `POST application/json {"a":1} Bearer [REDACTED]` || res.Headers["X-Echo"] != "Bearer [REDACTED]" {
+		t.Fatalf("secret not substituted or not redacted: %+v", res)
+	}
+	if strings.Contains(tl.JSONSchema()["description"].(string), "tok-123") {
+		t.Fatalf("schema must not expose credential values")
+	}
+}
+
+func TestHTTPRequestCredentialDomains(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+	defer srv.Close()
+
+	tl := NewTool(config.HTTPRequestConfig{
+		Credentials: []config.HTTPCredentialConfig{
+			{Name: "ELSEWHERE", Value: "x", Domains: []string{"api.example.com"}},
+			{Name: "UNBOUND", Value: "y"},
+		},
+	}, srv.Client())
+	vault := func(name string) (StoredSecret, bool) {
+		switch name {
+		case "STORED":
+			return StoredSecret{Value: "v", Domains: []string{"127.0.0.1"}, UserIDs: []int64{7}}, true
+		case "STORED_ANYONE":
+			return StoredSecret{Value: "a", Domains: []string{"127.0.0.1"}}, true
+		case "STORED_ELSEWHERE":
+			return StoredSecret{Value: "w", Domains: []string{"api.example.com"}}, true
+		case "STORED_UNBOUND":
+			return StoredSecret{Value: "u"}, true
+		}
+		return StoredSecret{}, false
+	}
+	tl.SetSecretLookup(vault)
+	ctx := sandbox.WithEnv(context.Background(), map[string]string{"PROJECT_KEY": "z"})
+	for name, want := range map[string]string{
+		"ELSEWHERE":        "may not be sent",
+		"UNBOUND":          "no domains configured",
+		"PROJECT_KEY":      "requires web.http.allowedDomains",
+		"STORED_ELSEWHERE": "may not be sent",
+		"STORED_UNBOUND":   "no domains configured",
+		"MISSING":          "unknown credential",
+	} {
+		res := call(t, tl, ctx, map[string]any{"url": srv.URL + "?key={{secret:" + name + "}}"})
+		if res.OK || !strings.Contains(res.Error, want) {
+			t.Errorf("%s: got %+v, want error containing %q", name, res, want)
+		}
+	}
+
+	// Stored secrets are bound to their users; runs without a user may use
+	// only unscoped ones.
+	if res := call(t, tl, llm.WithUserID(ctx, 8), map[string]any{"url": srv.URL + "?s={{secret:STORED}}"}); res.OK || !strings.Contains(res.Error, "not available to this user") {
+		t.Fatalf("stored secret sent for another user: %+v", res)
+	}
+	if res := call(t, tl, llm.WithUserID(ctx, 7), map[string]any{"url": srv.URL + "?s={{secret:STORED}}"}); !res.OK {
+		t.Fatalf("stored secret for its user: %+v", res)
+	}
+	if res := call(t, tl, ctx, map[string]any{"url": srv.URL + "?s={{secret:STORED}}"}); res.OK || !strings.Contains(res.Error, "not available to this user") {
+		t.Fatalf("user-scoped stored secret sent without a user: %+v", res)
+	}
+	if res := call(t, tl, ctx, map[string]any{"url": srv.URL + "?s={{secret:STORED_ANYONE}}"}); !res.OK {
+		t.Fatalf("unscoped stored secret without a user: %+v", res)
+	}
+
+	scoped := NewTool(config.HTTPRequestConfig{AllowedDomains: []string{"127.0.0.1"}}, srv.Client())
+	scoped.SetSecretLookup(vault)
+	if res := call(t, scoped, llm.WithUserID(ctx, 7), map[string]any{"url": srv.URL + "?key={{secret:PROJECT_KEY}}&s={{secret:STORED}}"}); !res.OK {
+		t.Fatalf("project and stored secrets with allow-list: %+v", res)
+	}
+}
+
+func TestHTTPRequestAllowListAndRedirects(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nethttp.Redirect(w, r, "http://example.com/next", nethttp.StatusFound)
+	}))
+	defer srv.Close()
+
+	tl := NewTool(config.HTTPRequestConfig{AllowedDomains: []string{"127.0.0.1"}}, srv.Client())
+	if res := call(t, tl, context.Background(), map[string]any{"url": "https://example.com"}); res.OK || !strings.Contains(res.Error, "allow-list") {
+		t.Fatalf("expected allow-list rejection, got %+v", res)
+	}
+	if res := call(t, tl, context.Background(), map[string]any{"url": srv.URL}); res.OK || !strings.Contains(res.Error, "allow-list") {
+		t.Fatalf("expected redirect off the allow-list to fail, got %+v", res)
+	}
+	if res := call(t, tl, context.Background(), map[string]any{"url": "file:///etc/passwd"}); res.OK {
+		t.Fatalf("expected non-http URL to be rejected")
+	}
+}
+
+func TestHTTPRequestTruncatesResponse(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusNotFound)
+		_, _ = io.WriteString(w, "0123456789")
+	}))
+	defer srv.Close()
+
+	tl := NewTool(config.HTTPRequestConfig{MaxResponseBytes: 6}, srv.Client())
+	res := call(t, tl, context.Background(), map[string]any{"url": srv.URL, "max_bytes": 100})
+	if res.OK || res.StatusCode != 404 || res.Body != "012345" || !res.Truncated {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestHTTPRequestTimeoutIsCapped(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	tl := NewTool(config.HTTPRequestConfig{TimeoutSeconds: 1}, srv.Client())
+	start := time.Now()
+	res := call(t, tl, context.Background(), map[string]any{"url": srv.URL, "timeout_seconds": 60})
+	if res.OK {
+		t.Fatalf("expected the request to time out, got %+v", res)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout_seconds extended the configured timeout: took %s", elapsed)
+	}
+}
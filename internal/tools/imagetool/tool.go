@@ -186,7 +186,7 @@ func (t *DescribeTool) Call(ctx context.Context, raw json.RawMessage) (any, erro
 	// tools use the same model as the invoking agent/specialist.
 
 	// Try to use OpenAI-specific image attachment method if available
-	if openaiClient, ok := p.(*openai.Client); ok {
+	if openaiClient, ok := llm.Unwrap(p).(*openai.Client); ok {
 		out, err := openaiClient.ChatWithImageAttachment(ctx, msgs, mime, b64, nil, model)
 		if err != nil {
 			return map[string]any{"ok": false, "error": err.Error()}, nil
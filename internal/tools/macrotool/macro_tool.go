@@ -0,0 +1,323 @@
+// Package macrotool registers composite tools that chain existing tools with
+// templated argument mapping, so a multi-step operation such as "search then
+// fetch then summarize" is exposed to the model as a single tool.
+package macrotool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"manifold/internal/config"
+	"manifold/internal/tools"
+)
+
+// maxDepth bounds macros that call other macros.
+const maxDepth = 4
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ErrNotFound is returned when deleting an unknown macro.
+var ErrNotFound = errors.New("macro tool not found")
+
+type depthKey struct{}
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type step struct {
+	id   string
+	tool string
+	args any
+}
+
+type macroTool struct {
+	def    config.MacroToolConfig
+	steps  []step
+	output *template.Template
+	reg    tools.Registry
+}
+
+func (t *macroTool) Name() string { return t.def.Name }
+
+func (t *macroTool) JSONSchema() map[string]any {
+	params := t.def.Parameters
+	if params == nil {
+		params = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	desc := strings.TrimSpace(t.def.Description)
+	if desc == "" {
+		names := make([]string, len(t.steps))
+		for i, s := range t.steps {
+			names[i] = s.tool
+		}
+		desc = "Runs " + strings.Join(names, " → ") + " in sequence."
+	}
+	return map[string]any{
+		"name":        t.def.Name,
+		"description": desc,
+		"parameters":  params,
+	}
+}
+
+func (t *macroTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	if depth >= maxDepth {
+		return nil, tools.NewError(tools.ErrInvalidArgs, fmt.Sprintf("macro %s: nesting deeper than %d", t.def.Name, maxDepth))
+	}
+	ctx = context.WithValue(ctx, depthKey{}, depth+1)
+
+	args := map[string]any{}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, tools.WrapError(tools.ErrInvalidArgs, err)
+		}
+	}
+	results := map[string]any{}
+	data := map[string]any{"args": args, "steps": results}
+	var last any
+	for i, s := range t.steps {
+		rendered, err := render(s.args, data)
+		if err != nil {
+			return nil, tools.NewError(tools.ErrInvalidArgs, fmt.Sprintf("macro %s step %s: %v", t.def.Name, s.id, err))
+		}
+		b, err := json.Marshal(rendered)
+		if err != nil {
+			return nil, fmt.Errorf("macro %s step %s: %w", t.def.Name, s.id, err)
+		}
+		payload, err := t.reg.Dispatch(ctx, s.tool, b)
+		if err != nil {
+			return nil, fmt.Errorf("macro %s step %s: %w", t.def.Name, s.id, err)
+		}
+		var out any
+		if err := json.Unmarshal(payload, &out); err != nil {
+			out = string(payload)
+		}
+		if failed(out) {
+			res, _ := out.(map[string]any)
+			res["failed_step"] = s.id
+			res["step_index"] = i
+			return res, nil
+		}
+		results[s.id] = out
+		last = out
+	}
+	if t.output == nil {
+		return last, nil
+	}
+	var buf bytes.Buffer
+	if err := t.output.Execute(&buf, data); err != nil {
+		return nil, tools.NewError(tools.ErrInvalidArgs, fmt.Sprintf("macro %s output: %v", t.def.Name, err))
+	}
+	return map[string]any{"ok": true, "output": buf.String(), "steps": results}, nil
+}
+
+// failed reports whether a step result is a standard tool error payload.
+func failed(out any) bool {
+	m, ok := out.(map[string]any)
+	if !ok {
+		return false
+	}
+	if okVal, present := m["ok"].(bool); present {
+		return !okVal
+	}
+	msg, _ := m["error"].(string)
+	return msg != ""
+}
+
+// compile parses every string in v as a template.
+func compile(name string, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		return template.New(name).Funcs(funcs).Option("missingkey=error").Parse(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			c, err := compile(name+"."+k, item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			c, err := compile(fmt.Sprintf("%s[%d]", name, i), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// render executes the templates in a compiled value. A template consisting
+// of a single action whose output is JSON (for example {{json .steps.x}})
+// yields the decoded value, so objects and numbers keep their type.
+func render(v any, data map[string]any) (any, error) {
+	switch val := v.(type) {
+	case *template.Template:
+		var buf bytes.Buffer
+		if err := val.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		if isSingleAction(val) {
+			var decoded any
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err == nil {
+				if _, isString := decoded.(string); !isString {
+					return decoded, nil
+				}
+			}
+		}
+		return buf.String(), nil
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			r, err := render(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			r, err := render(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func isSingleAction(t *template.Template) bool {
+	if t.Tree == nil || t.Tree.Root == nil || len(t.Tree.Root.Nodes) != 1 {
+		return false
+	}
+	_, ok := t.Tree.Root.Nodes[0].(*parse.ActionNode)
+	return ok
+}
+
+// Manager registers macro tools in a registry and tracks their definitions
+// so they can be listed, replaced, and removed at runtime.
+type Manager struct {
+	mu   sync.Mutex
+	reg  tools.Registry
+	defs map[string]config.MacroToolConfig
+}
+
+// NewManager returns a manager that registers macros in reg. Steps dispatch
+// through reg as well.
+func NewManager(reg tools.Registry) *Manager {
+	return &Manager{reg: reg, defs: map[string]config.MacroToolConfig{}}
+}
+
+// Upsert validates def and registers it, replacing an earlier macro with the
+// same name. It refuses to shadow a tool that is not a macro.
+func (m *Manager) Upsert(def config.MacroToolConfig) error {
+	def.Name = strings.TrimSpace(def.Name)
+	if !namePattern.MatchString(def.Name) {
+		return fmt.Errorf("macro name %q must be 1-64 letters, digits, '_' or '-'", def.Name)
+	}
+	if len(def.Steps) == 0 {
+		return fmt.Errorf("macro %s: at least one step required", def.Name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	known := map[string]bool{}
+	for _, s := range m.reg.Schemas() {
+		known[s.Name] = true
+	}
+	if _, isMacro := m.defs[def.Name]; known[def.Name] && !isMacro {
+		return fmt.Errorf("macro %s: a tool with that name already exists", def.Name)
+	}
+	t := &macroTool{def: def, reg: m.reg}
+	seen := map[string]bool{}
+	for i, s := range def.Steps {
+		name := strings.TrimSpace(s.Tool)
+		if name == def.Name {
+			return fmt.Errorf("macro %s: step %d calls the macro itself", def.Name, i)
+		}
+		if !known[name] {
+			return fmt.Errorf("macro %s: step %d: unknown tool %q", def.Name, i, s.Tool)
+		}
+		id := strings.TrimSpace(s.ID)
+		if id == "" {
+			id = name
+		}
+		if seen[id] {
+			return fmt.Errorf("macro %s: duplicate step id %q; set id on repeated tools", def.Name, id)
+		}
+		seen[id] = true
+		args, err := compile(id, map[string]any(s.Args))
+		if err != nil {
+			return fmt.Errorf("macro %s step %s: %w", def.Name, id, err)
+		}
+		t.steps = append(t.steps, step{id: id, tool: name, args: args})
+	}
+	if strings.TrimSpace(def.Output) != "" {
+		tmpl, err := template.New("output").Funcs(funcs).Option("missingkey=error").Parse(def.Output)
+		if err != nil {
+			return fmt.Errorf("macro %s output: %w", def.Name, err)
+		}
+		t.output = tmpl
+	}
+	m.reg.Register(t)
+	m.defs[def.Name] = def
+	return nil
+}
+
+// Delete unregisters the named macro.
+func (m *Manager) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.defs[name]; !ok {
+		return ErrNotFound
+	}
+	m.reg.Unregister(name)
+	delete(m.defs, name)
+	return nil
+}
+
+// Get returns the named macro's definition.
+func (m *Manager) Get(name string) (config.MacroToolConfig, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	def, ok := m.defs[name]
+	return def, ok
+}
+
+// List returns every macro definition sorted by name.
+func (m *Manager) List() []config.MacroToolConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]config.MacroToolConfig, 0, len(m.defs))
+	for _, def := range m.defs {
+		out = append(out, def)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,143 @@
+package macrotool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"manifold/internal/config"
+	"manifold/internal/tools"
+)
+
+// funcTool is a tool backed by a function that records its arguments.
+type funcTool struct {
+	name string
+	fn   func(args map[string]any) (any, error)
+	got  []map[string]any
+}
+
+func (f *funcTool) Name() string { return f.name }
+func (f *funcTool) JSONSchema() map[string]any {
+	return map[string]any{"name": f.name, "parameters": map[string]any{"type": "object"}}
+}
+func (f *funcTool) Call(_ context.Context, raw json.RawMessage) (any, error) {
+	var args map[string]any
+	_ = json.Unmarshal(raw, &args)
+	f.got = append(f.got, args)
+	return f.fn(args)
+}
+
+func newTestRegistry() (tools.Registry, *funcTool, *funcTool) {
+	reg := tools.NewRegistry()
+	search := &funcTool{name: "web_search", fn: func(args map[string]any) (any, error) {
+		return map[string]any{"ok": true, "results": []any{map[string]any{"url": "https://example.com/" + args["query"].(string)}}}, nil
+	}}
+	fetch := &funcTool{name: "web_fetch", fn: func(args map[string]any) (any, error) {
+		if args["url"] == "https://example.com/missing" {
+			return nil, errors.New("page not found")
+		}
+		return map[string]any{"ok": true, "text": "body of " + args["url"].(string)}, nil
+	}}
+	reg.Register(search)
+	reg.Register(fetch)
+	return reg, search, fetch
+}
+
+func searchThenFetch() config.MacroToolConfig {
+	return config.MacroToolConfig{
+		Name: "search_and_fetch",
+		Steps: []config.MacroToolStep{
+			{ID: "search", Tool: "web_search", Args: map[string]any{"query": "{{.args.query}}", "max_results": 1}},
+			{Tool: "web_fetch", Args: map[string]any{"url": "{{(index .steps.search.results 0).url}}", "hits": "{{json .steps.search.results}}"}},
+		},
+	}
+}
+
+func TestMacroChainsStepsWithTemplatedArgs(t *testing.T) {
+	t.Parallel()
+	reg, search, fetch := newTestRegistry()
+	m := NewManager(reg)
+	if err := m.Upsert(searchThenFetch()); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	payload, err := reg.Dispatch(context.Background(), "search_and_fetch", json.RawMessage(`{"query":"go"}`))
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(payload, &out); err != nil || out["text"] != "body of https://example.com/go" {
+		t.Fatalf("unexpected macro result %s (%v)", payload, err)
+	}
+	if search.got[0]["max_results"] != float64(1) {
+		t.Fatalf("literal args should keep their type, got %#v", search.got[0])
+	}
+	if hits, ok := fetch.got[0]["hits"].([]any); !ok || len(hits) != 1 {
+		t.Fatalf("{{json}} should pass structured values, got %#v", fetch.got[0]["hits"])
+	}
+}
+
+func TestMacroStopsAtFailedStep(t *testing.T) {
+	t.Parallel()
+	reg, _, _ := newTestRegistry()
+	m := NewManager(reg)
+	if err := m.Upsert(searchThenFetch()); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	payload, _ := reg.Dispatch(context.Background(), "search_and_fetch", json.RawMessage(`{"query":"missing"}`))
+	var out map[string]any
+	_ = json.Unmarshal(payload, &out)
+	if out["ok"] != false || out["failed_step"] != "web_fetch" || out["category"] != "not_found" {
+		t.Fatalf("expected failed web_fetch step, got %s", payload)
+	}
+
+	payload, _ = reg.Dispatch(context.Background(), "search_and_fetch", json.RawMessage(`{}`))
+	_ = json.Unmarshal(payload, &out)
+	if out["ok"] != false || out["category"] != "invalid_args" {
+		t.Fatalf("expected invalid_args for a missing template key, got %s", payload)
+	}
+}
+
+func TestManagerValidationAndLifecycle(t *testing.T) {
+	t.Parallel()
+	reg, _, _ := newTestRegistry()
+	m := NewManager(reg)
+
+	for _, def := range []config.MacroToolConfig{
+		{Name: "bad name", Steps: []config.MacroToolStep{{Tool: "web_search"}}},
+		{Name: "empty"},
+		{Name: "web_fetch", Steps: []config.MacroToolStep{{Tool: "web_search"}}},
+		{Name: "unknown", Steps: []config.MacroToolStep{{Tool: "nope"}}},
+		{Name: "dup", Steps: []config.MacroToolStep{{Tool: "web_search"}, {Tool: "web_search"}}},
+		{Name: "self", Steps: []config.MacroToolStep{{Tool: "self"}}},
+		{Name: "tmpl", Steps: []config.MacroToolStep{{Tool: "web_search", Args: map[string]any{"q": "{{.args"}}}},
+	} {
+		if err := m.Upsert(def); err == nil {
+			t.Fatalf("expected error for %+v", def)
+		}
+	}
+
+	if err := m.Upsert(searchThenFetch()); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	def := searchThenFetch()
+	def.Description = "replaced"
+	if err := m.Upsert(def); err != nil {
+		t.Fatalf("replacing a macro: %v", err)
+	}
+	if got := m.List(); len(got) != 1 || got[0].Description != "replaced" {
+		t.Fatalf("unexpected macros %+v", got)
+	}
+	if err := m.Delete("search_and_fetch"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := m.Delete("search_and_fetch"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	for _, s := range reg.Schemas() {
+		if s.Name == "search_and_fetch" {
+			t.Fatal("deleted macro is still registered")
+		}
+	}
+}
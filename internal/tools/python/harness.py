@@ -0,0 +1,122 @@
+"""Runs agent code for the run_python tool.
+
+Usage: harness.py CODE_FILE [REPORT_FILE]. Rich outputs and the error, if
+any, are written as JSON to REPORT_FILE, or to file descriptor 3 without one,
+so stdout and stderr stay exactly what the code printed.
+"""
+
+import ast
+import base64
+import io
+import json
+import linecache
+import os
+import resource
+import sys
+import traceback
+
+FILENAME = "<code>"
+MAX_OUTPUTS = 20
+
+_outputs = []
+
+
+def _limit():
+    mb = int(os.environ.pop("MANIFOLD_PY_MEMORY_MB", "0") or 0)
+    cpu = int(os.environ.pop("MANIFOLD_PY_CPU_SECONDS", "0") or 0)
+    if mb > 0:
+        resource.setrlimit(resource.RLIMIT_AS, (mb << 20, mb << 20))
+    if cpu > 0:
+        resource.setrlimit(resource.RLIMIT_CPU, (cpu, cpu + 1))
+
+
+def _add(kind, mime, data):
+    if len(_outputs) < MAX_OUTPUTS:
+        _outputs.append({"type": kind, "mime_type": mime, "data": data})
+
+
+def _png(data):
+    _add("image", "image/png", base64.b64encode(data).decode("ascii"))
+
+
+def display(obj):
+    """Adds obj to the rich outputs: figures and images as PNG, objects
+    with an HTML representation (e.g. DataFrames) as HTML, others as text."""
+    if hasattr(obj, "savefig"):
+        buf = io.BytesIO()
+        obj.savefig(buf, format="png", bbox_inches="tight")
+        _png(buf.getvalue())
+        return
+    if hasattr(obj, "_repr_png_"):
+        data = obj._repr_png_()
+        if data:
+            _png(data)
+            return
+    if hasattr(obj, "save") and hasattr(obj, "mode") and hasattr(obj, "size"):
+        buf = io.BytesIO()
+        obj.save(buf, format="PNG")
+        _png(buf.getvalue())
+        return
+    if hasattr(obj, "_repr_html_"):
+        html = obj._repr_html_()
+        if html:
+            _add("html", "text/html", html)
+            return
+    _add("text", "text/plain", repr(obj))
+
+
+def _flush_figures():
+    plt = sys.modules.get("matplotlib.pyplot")
+    if plt is None:
+        return
+    for num in plt.get_fignums():
+        display(plt.figure(num))
+    plt.close("all")
+
+
+def _user_traceback(exc):
+    # Drop the harness frames so the traceback starts in the agent's code.
+    tb = exc.__traceback__
+    while tb is not None and tb.tb_frame.f_code.co_filename != FILENAME:
+        tb = tb.tb_next
+    return "".join(traceback.format_exception(type(exc), exc, tb))
+
+
+def main():
+    with open(sys.argv[1], encoding="utf-8") as f:
+        src = f.read()
+    if len(sys.argv) > 2:
+        report = open(sys.argv[2], "w", encoding="utf-8")
+    else:
+        report = os.fdopen(3, "w")
+    linecache.cache[FILENAME] = (len(src), None, src.splitlines(True), FILENAME)
+    _limit()
+
+    scope = {"__name__": "__main__", "__builtins__": __builtins__, "display": display}
+    error = None
+    try:
+        tree = ast.parse(src, filename=FILENAME)
+        last = None
+        if tree.body and isinstance(tree.body[-1], ast.Expr):
+            last = ast.Expression(tree.body.pop().value)
+        exec(compile(tree, FILENAME, "exec"), scope)
+        if last is not None:
+            value = eval(compile(last, FILENAME, "eval"), scope)
+            if value is not None:
+                display(value)
+    except SystemExit as exc:
+        if exc.code not in (None, 0):
+            error = {"type": "SystemExit", "message": str(exc.code)}
+    except BaseException as exc:
+        error = {"type": type(exc).__name__, "message": str(exc), "traceback": _user_traceback(exc)}
+    try:
+        _flush_figures()
+    except Exception:
+        pass
+    sys.stdout.flush()
+    json.dump({"outputs": _outputs, "error": error}, report)
+    report.close()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,347 @@
+// Package python provides the run_python tool, which executes agent-written
+// code in an interpreter subprocess, on the host or in a container, for data
+// analysis tasks.
+package python
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+	"manifold/internal/tools/cli"
+)
+
+//go:embed harness.py
+var harness []byte
+
+const (
+	// maxReportBytes bounds the rich-output report read back from the harness.
+	maxReportBytes = 32 << 20
+	// containerDir is where the harness and code are mounted in a container.
+	containerDir = "/manifold-python"
+)
+
+// hostEnv names the server environment variables every host run gets. The
+// rest of the server environment, e.g. DATABASE_URL or provider keys, is
+// withheld unless listed in python.passEnv.
+var hostEnv = []string{"PATH", "HOME", "LANG", "LC_ALL", "TZ", "TMPDIR"}
+
+// Output is a rich value produced by the code: a figure or image (base64
+// PNG), HTML such as a rendered DataFrame, or the repr of the last
+// expression.
+type Output struct {
+	Type     string `json:"type"`
+	MIMEType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
+// Error describes an exception raised by the code.
+type Error struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Traceback string `json:"traceback,omitempty"`
+}
+
+type result struct {
+	OK        bool     `json:"ok"`
+	ExitCode  int      `json:"exit_code"`
+	Stdout    string   `json:"stdout"`
+	Stderr    string   `json:"stderr"`
+	Outputs   []Output `json:"outputs,omitempty"`
+	Error     *Error   `json:"error,omitempty"`
+	Duration  int64    `json:"duration_ms"`
+	Truncated bool     `json:"truncated"`
+}
+
+type tool struct {
+	cfg       config.ExecPythonConfig
+	container config.ExecContainerConfig
+	blocked   map[string]struct{}
+	workdir   string
+	outLimit  int
+}
+
+// NewTool constructs run_python from the exec config. Code runs with the
+// run's sandbox base directory as its working directory, on the backend
+// run_cli would use; outLimit caps stdout and stderr.
+func NewTool(cfg config.ExecConfig, workdir string, outLimit int) *tool {
+	py := cfg.Python
+	if py.Interpreter == "" {
+		py.Interpreter = "python3"
+	}
+	if py.Image == "" {
+		py.Image = "python:3-slim"
+	}
+	if py.TimeoutSeconds <= 0 {
+		py.TimeoutSeconds = 60
+	}
+	if outLimit <= 0 {
+		outLimit = 64 * 1024
+	}
+	blocked := make(map[string]struct{}, len(cfg.BlockBinaries))
+	for _, b := range cfg.BlockBinaries {
+		blocked[b] = struct{}{}
+	}
+	return &tool{cfg: py, container: cfg.Container, blocked: blocked, workdir: workdir, outLimit: outLimit}
+}
+
+func (t *tool) Name() string { return "run_python" }
+
+func (t *tool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Run Python code for data analysis and return stdout, stderr, rich outputs and any exception. The working directory is the project directory. The value of a trailing expression is displayed like in a notebook; call display(obj) to show more values. matplotlib figures are returned as base64 PNG images and DataFrames as HTML. Each call starts a fresh interpreter, so persist intermediate results to files.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"code":            map[string]any{"type": "string", "description": "Python source to execute."},
+				"timeout_seconds": map[string]any{"type": "integer", "minimum": 1, "description": fmt.Sprintf("Run timeout (max %d).", t.cfg.TimeoutSeconds)},
+			},
+			"required": []string{"code"},
+		},
+	}
+}
+
+func (t *tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Code           string `json:"code"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	backend := cli.Backend(ctx, t.container)
+	interpreter := t.cfg.Interpreter
+	if backend == sandbox.ExecBackendContainer {
+		interpreter = "python3"
+	}
+	if t.isBlocked(interpreter) {
+		return nil, fmt.Errorf("interpreter is blocked: %q", interpreter)
+	}
+	timeout := time.Duration(t.cfg.TimeoutSeconds) * time.Second
+	if d := time.Duration(args.TimeoutSeconds) * time.Second; d > 0 && d < timeout {
+		timeout = d
+	}
+	return t.run(ctx, backend, args.Code, timeout)
+}
+
+// isBlocked reports whether exec.blockBinaries names interpreter, by its
+// file name or without a version suffix, so blocking python3 also blocks
+// /usr/bin/python3.12.
+func (t *tool) isBlocked(interpreter string) bool {
+	name := filepath.Base(interpreter)
+	short, _, _ := strings.Cut(name, ".")
+	_, full := t.blocked[name]
+	_, base := t.blocked[short]
+	return full || base
+}
+
+// environ returns the allow-listed part of the server environment.
+func (t *tool) environ() []string {
+	var env []string
+	for _, names := range [][]string{hostEnv, t.cfg.PassEnv} {
+		for _, name := range names {
+			if v, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+v)
+			}
+		}
+	}
+	return env
+}
+
+func (t *tool) run(ctx context.Context, backend, code string, timeout time.Duration) (result, error) {
+	dir, err := os.MkdirTemp("", "manifold-python-")
+	if err != nil {
+		return result{}, err
+	}
+	defer os.RemoveAll(dir)
+	harnessPath := filepath.Join(dir, "harness.py")
+	codePath := filepath.Join(dir, "code.py")
+	reportPath := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(harnessPath, harness, 0o644); err != nil {
+		return result{}, err
+	}
+	if err := os.WriteFile(codePath, []byte(code), 0o644); err != nil {
+		return result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	base := sandbox.ResolveBaseDir(ctx, t.workdir)
+	// Per-run variables (e.g. project secrets) precede the harness settings.
+	env := append(sandbox.EnvFromContext(ctx),
+		"MPLBACKEND=Agg",
+		"PYTHONDONTWRITEBYTECODE=1",
+		"MANIFOLD_PY_MEMORY_MB="+strconv.Itoa(t.cfg.MemoryMB),
+		"MANIFOLD_PY_CPU_SECONDS="+strconv.Itoa(int(timeout/time.Second)+1),
+	)
+	var c *exec.Cmd
+	var reportR, reportW *os.File
+	if backend == sandbox.ExecBackendContainer {
+		// The container user may differ from ours, so the files must be
+		// readable and the report writable by anyone.
+		if err := os.WriteFile(reportPath, nil, 0o666); err != nil {
+			return result{}, err
+		}
+		for path, mode := range map[string]os.FileMode{dir: 0o755, reportPath: 0o666} {
+			if err := os.Chmod(path, mode); err != nil {
+				return result{}, err
+			}
+		}
+		c = cli.ContainerCommand(ctx, t.container, cli.ContainerSpec{
+			Image:   t.cfg.Image,
+			Base:    base,
+			Mounts:  []string{dir + ":" + containerDir + ":rw"},
+			Env:     env,
+			Command: "python3",
+			Args:    []string{"-u", containerDir + "/harness.py", containerDir + "/code.py", containerDir + "/report.json"},
+		})
+	} else {
+		// The report travels over an extra pipe (fd 3 in the child) so
+		// stdout and stderr are exactly what the code printed.
+		reportR, reportW, err = os.Pipe()
+		if err != nil {
+			return result{}, err
+		}
+		defer reportR.Close()
+		c = exec.CommandContext(ctx, t.cfg.Interpreter, "-u", harnessPath, codePath)
+		c.Dir = base
+		c.Env = append(t.environ(), env...)
+		c.ExtraFiles = []*os.File{reportW}
+	}
+	c.WaitDelay = 2 * time.Second
+	stdout := &cappedBuffer{limit: t.outLimit}
+	stderr := &cappedBuffer{limit: t.outLimit}
+	c.Stdout = stdout
+	c.Stderr = stderr
+
+	start := time.Now()
+	if err := c.Start(); err != nil {
+		if reportW != nil {
+			reportW.Close()
+		}
+		return result{}, fmt.Errorf("start %s: %w", c.Path, err)
+	}
+	var report []byte
+	if reportW != nil {
+		reportW.Close()
+		report, _ = io.ReadAll(io.LimitReader(reportR, maxReportBytes))
+	}
+	runErr := c.Wait()
+	dur := time.Since(start)
+	if reportW == nil {
+		if f, err := os.Open(reportPath); err == nil {
+			report, _ = io.ReadAll(io.LimitReader(f, maxReportBytes))
+			f.Close()
+		}
+	}
+
+	res := result{OK: runErr == nil, Duration: dur.Milliseconds()}
+	if runErr != nil {
+		var ee *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			res.ExitCode = 124
+		case errors.As(runErr, &ee):
+			res.ExitCode = ee.ExitCode()
+		default:
+			res.ExitCode = 1
+		}
+	}
+
+	var parsed struct {
+		Outputs []Output `json:"outputs"`
+		Error   *Error   `json:"error"`
+	}
+	if len(report) > 0 && json.Unmarshal(report, &parsed) == nil {
+		res.Outputs = parsed.Outputs
+		res.Error = parsed.Error
+	}
+	switch {
+	case res.ExitCode == 124:
+		res.Error = &Error{Type: "Timeout", Message: fmt.Sprintf("execution exceeded %s", timeout)}
+	case res.Error == nil && runErr != nil:
+		// The interpreter died without reporting, e.g. killed at a limit.
+		res.Error = &Error{Type: "ProcessError", Message: runErr.Error()}
+	}
+	res.OK = res.OK && res.Error == nil
+
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	res.Truncated = stdout.truncated || stderr.truncated
+	for i := range res.Outputs {
+		if res.Outputs[i].Type != "image" && len(res.Outputs[i].Data) > t.outLimit {
+			res.Outputs[i].Data = cutUTF8(res.Outputs[i].Data, t.outLimit) + truncatedMarker
+			res.Truncated = true
+		}
+	}
+	return res, nil
+}
+
+const truncatedMarker = "\n[TRUNCATED]"
+
+// cappedBuffer keeps the first limit bytes written to it, cut back to a rune
+// boundary, and discards the rest, so a chatty script cannot grow the
+// server's memory without bound.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.truncated {
+		return len(p), nil
+	}
+	room := b.limit - b.buf.Len()
+	if len(p) <= room {
+		return b.buf.Write(p)
+	}
+	b.buf.Write(p[:room])
+	b.truncated = true
+	// p[room] is the first byte dropped; if it continues a rune, drop the
+	// start of that rune too.
+	if !utf8.RuneStart(p[room]) {
+		data, n := b.buf.Bytes(), b.buf.Len()-1
+		for n > 0 && !utf8.RuneStart(data[n]) {
+			n--
+		}
+		b.buf.Truncate(n)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + truncatedMarker
+	}
+	return b.buf.String()
+}
+
+// cutUTF8 returns at most the first n bytes of s, backing off to a rune
+// boundary so the result stays valid UTF-8.
+func cutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
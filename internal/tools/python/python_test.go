@@ -0,0 +1,194 @@
+package python
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"manifold/internal/config"
+	"manifold/internal/sandbox"
+)
+
+func newTestTool(t *testing.T) (*tool, string) {
+	t.Helper()
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	dir := t.TempDir()
+	return NewTool(config.ExecConfig{Python: config.ExecPythonConfig{MemoryMB: 512}}, dir, 1024), dir
+}
+
+func call(t *testing.T, tl *tool, args map[string]any) result {
+	t.Helper()
+	return callCtx(t, tl, context.Background(), args)
+}
+
+func callCtx(t *testing.T, tl *tool, ctx context.Context, args map[string]any) result {
+	t.Helper()
+	raw, _ := json.Marshal(args)
+	out, err := tl.Call(ctx, raw)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	return out.(result)
+}
+
+func TestRunPythonOutputs(t *testing.T) {
+	t.Parallel()
+	tl, dir := newTestTool(t)
+	if err := os.WriteFile(filepath.Join(dir, "data.csv"), []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := call(t, tl, map[string]any{"code": "import sys\nprint(open('data.csv').read().strip())\nprint('warn', file=sys.stderr)\nclass Pic:\n    def _repr_png_(self):\n        return b'\\x89PNG'\ndisplay(Pic())\n{'n': 1 + 1}"})
+	if !res.OK || res.Error != nil {
+		t.Fatalf("unexpected failure %+v", res)
+	}
+	if res.Stdout != "a,b\n1,2\n" || res.Stderr != "warn\n" {
+		t.Fatalf("unexpected streams %q %q", res.Stdout, res.Stderr)
+	}
+	if len(res.Outputs) != 2 || res.Outputs[0].Type != "image" || res.Outputs[1].Data != "{'n': 2}" {
+		t.Fatalf("unexpected outputs %+v", res.Outputs)
+	}
+	if png, _ := base64.StdEncoding.DecodeString(res.Outputs[0].Data); string(png) != "\x89PNG" {
+		t.Fatalf("image not base64 PNG: %q", res.Outputs[0].Data)
+	}
+}
+
+func TestRunPythonErrors(t *testing.T) {
+	t.Parallel()
+	tl, _ := newTestTool(t)
+
+	res := call(t, tl, map[string]any{"code": "x = 1\nraise ValueError('bad input')"})
+	if res.OK || res.Error == nil || res.Error.Type != "ValueError" || res.Error.Message != "bad input" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if !strings.Contains(res.Error.Traceback, "line 2") || strings.Contains(res.Error.Traceback, "harness.py") {
+		t.Fatalf("traceback should point at the code only: %s", res.Error.Traceback)
+	}
+
+	res = call(t, tl, map[string]any{"code": "def f(:\n  pass"})
+	if res.OK || res.Error == nil || res.Error.Type != "SyntaxError" {
+		t.Fatalf("expected SyntaxError, got %+v", res)
+	}
+
+	res = call(t, tl, map[string]any{"code": "print('x' * 5000)"})
+	if !res.Truncated || len(res.Stdout) > 1100 {
+		t.Fatalf("expected truncated stdout, got %d bytes", len(res.Stdout))
+	}
+
+	// Three-byte runes put the 1024-byte cap inside one.
+	res = call(t, tl, map[string]any{"code": "import sys\nprint('日本語' * 2000)\nprint('日本語' * 2000, file=sys.stderr)\n'日本語' * 2000"})
+	for name, s := range map[string]string{"stdout": res.Stdout, "stderr": res.Stderr, "output": res.Outputs[0].Data} {
+		if !res.Truncated || !utf8.ValidString(s) || len(s) > 1100 {
+			t.Fatalf("expected %s cut on a rune boundary, got %d bytes (valid=%v)", name, len(s), utf8.ValidString(s))
+		}
+	}
+}
+
+func TestCappedBufferCutsOnRuneBoundary(t *testing.T) {
+	t.Parallel()
+	b := &cappedBuffer{limit: 4}
+	for _, chunk := range []string{"ab", "日", "本"} {
+		if n, err := b.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if !b.truncated || b.buf.String() != "ab" {
+		t.Fatalf("expected %q truncated, got %q (truncated=%v)", "ab", b.buf.String(), b.truncated)
+	}
+}
+
+func TestRunPythonLimits(t *testing.T) {
+	t.Parallel()
+	tl, _ := newTestTool(t)
+
+	start := time.Now()
+	res := call(t, tl, map[string]any{"code": "while True: pass", "timeout_seconds": 1})
+	if res.OK || res.ExitCode != 124 || res.Error == nil || res.Error.Type != "Timeout" {
+		t.Fatalf("expected timeout, got %+v", res)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("timeout took too long: %s", time.Since(start))
+	}
+
+	res = call(t, tl, map[string]any{"code": "b = bytearray(2 << 30)"})
+	if res.OK || res.Error == nil || res.Error.Type != "MemoryError" {
+		t.Fatalf("expected MemoryError, got %+v", res)
+	}
+}
+
+func TestRunPythonEnvironment(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	t.Setenv("MANIFOLD_TEST_SERVER_SECRET", "server")
+	t.Setenv("MANIFOLD_TEST_PASSED", "passed")
+	tl := NewTool(config.ExecConfig{Python: config.ExecPythonConfig{PassEnv: []string{"MANIFOLD_TEST_PASSED"}}}, t.TempDir(), 0)
+
+	ctx := sandbox.WithEnv(context.Background(), map[string]string{"PROJECT_TOKEN": "project"})
+	res := callCtx(t, tl, ctx, map[string]any{"code": "import os\nprint(os.environ.get('MANIFOLD_TEST_SERVER_SECRET'), os.environ.get('MANIFOLD_TEST_PASSED'), os.environ.get('PROJECT_TOKEN'), 'PATH' in os.environ)"})
+	if !res.OK || res.Stdout != "None passed project True\n" {
+		t.Fatalf("unexpected environment %+v", res)
+	}
+}
+
+func TestRunPythonBlockedInterpreter(t *testing.T) {
+	t.Parallel()
+	tl := NewTool(config.ExecConfig{BlockBinaries: []string{"python3"}, Python: config.ExecPythonConfig{Interpreter: "/usr/bin/python3.12"}}, t.TempDir(), 0)
+	raw, _ := json.Marshal(map[string]any{"code": "print(1)"})
+	if _, err := tl.Call(context.Background(), raw); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Fatalf("expected blocked interpreter error, got %v", err)
+	}
+}
+
+func TestRunPythonContainerBackend(t *testing.T) {
+	t.Parallel()
+	// A fake runtime records its arguments and environment and reports a
+	// result through the mounted report file, as the harness would.
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	script := `#!/bin/sh
+printf '%s\n' "$@" > "$0.args"
+env > "$0.env"
+for a in "$@"; do
+  case "$a" in *:/manifold-python:rw) host="${a%%:/manifold-python:rw}" ;; esac
+done
+printf '{"outputs": [{"type": "text", "mime_type": "text/plain", "data": "42"}], "error": null}' > "$host/report.json"
+echo ran
+`
+	if err := os.WriteFile(runtime, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tl := NewTool(config.ExecConfig{
+		Container: config.ExecContainerConfig{Runtime: runtime, Image: "alpine:3"},
+		Python:    config.ExecPythonConfig{Interpreter: "/opt/venv/bin/python", Image: "python:3.12-slim"},
+	}, t.TempDir(), 0)
+
+	ctx := sandbox.WithExecBackend(context.Background(), sandbox.ExecBackendContainer)
+	ctx = sandbox.WithEnv(ctx, map[string]string{"PROJECT_TOKEN": "s3cret"})
+	res := callCtx(t, tl, ctx, map[string]any{"code": "42"})
+	if !res.OK || res.Stdout != "ran\n" || len(res.Outputs) != 1 || res.Outputs[0].Data != "42" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	raw, err := os.ReadFile(runtime + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(strings.Fields(string(raw)), " ")
+	for _, want := range []string{"run --rm", "--network none", "--env PROJECT_TOKEN", "python:3.12-slim python3 -u /manifold-python/harness.py /manifold-python/code.py /manifold-python/report.json"} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("container args %q missing %q", args, want)
+		}
+	}
+	if strings.Contains(args, "s3cret") {
+		t.Fatalf("container args leak env values: %q", args)
+	}
+}
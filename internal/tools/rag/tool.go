@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"manifold/internal/docloader"
 	"manifold/internal/persistence/databases"
 	"manifold/internal/rag/ingest"
 	"manifold/internal/rag/retrieve"
@@ -220,3 +221,103 @@ func (t *retrieveTool) Call(ctx context.Context, raw json.RawMessage) (any, erro
 	}
 	return map[string]any{"ok": true, "query": resp.Query, "items": resp.Items, "debug": resp.Debug}, nil
 }
+
+// Query tool
+type queryTool struct {
+	s      *ragservice.Service
+	alpha  float64
+	rerank bool
+	pool   int
+}
+
+// NewQueryTool constructs the rag_query tool: hybrid full-text + vector
+// retrieval fused with RRF and, when rerank is true, reranked over a pool of
+// candidates before the final cut.
+func NewQueryTool(mgr databases.Manager, alpha float64, rerank bool, candidates int, opts ...ragservice.Option) *queryTool {
+	return &queryTool{s: ragservice.New(mgr, opts...), alpha: alpha, rerank: rerank, pool: candidates}
+}
+
+func (t *queryTool) Name() string { return "rag_query" }
+
+func (t *queryTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        t.Name(),
+		"description": "Search ingested documents with hybrid keyword + semantic retrieval and return the most relevant passages with citations.",
+		"parameters": map[string]any{
+			"type":     "object",
+			"required": []string{"query"},
+			"properties": map[string]any{
+				"query":  map[string]any{"type": "string"},
+				"k":      map[string]any{"type": "integer", "description": "Number of passages to return (default 5)"},
+				"tenant": map[string]any{"type": "string"},
+				"filter": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			},
+		},
+	}
+}
+
+// Options returns the retrieval options rag_query applies for a request.
+func (t *queryTool) Options(k int, tenant string, filter map[string]string) retrieve.RetrieveOptions {
+	if k <= 0 {
+		k = 5
+	}
+	return retrieve.RetrieveOptions{
+		K: k, Alpha: t.alpha, UseRRF: true, Diversify: true,
+		IncludeSnippet: true, IncludeText: true,
+		Rerank: t.rerank, RerankCandidates: t.pool,
+		Tenant: tenant, Filter: filter,
+	}
+}
+
+// Query runs a rag_query retrieval.
+func (t *queryTool) Query(ctx context.Context, query string, k int, tenant string, filter map[string]string) (retrieve.RetrieveResponse, error) {
+	return t.s.Retrieve(ctx, query, t.Options(k, tenant, filter))
+}
+
+func (t *queryTool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Query  string            `json:"query"`
+		K      int               `json:"k"`
+		Tenant string            `json:"tenant"`
+		Filter map[string]string `json:"filter"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	resp, err := t.Query(ctx, args.Query, args.K, args.Tenant, args.Filter)
+	if err != nil {
+		return map[string]any{"ok": false, "error": err.Error()}, nil
+	}
+	return map[string]any{"ok": true, "query": resp.Query, "items": resp.Items}, nil
+}
+
+// DocumentIngester ingests documents extracted by docloader, for use by the
+// load_document tool.
+type DocumentIngester struct{ s *ragservice.Service }
+
+// NewDocumentIngester constructs a DocumentIngester backed by the RAG service.
+func NewDocumentIngester(mgr databases.Manager, opts ...ragservice.Option) *DocumentIngester {
+	return &DocumentIngester{s: ragservice.New(mgr, opts...)}
+}
+
+// IngestDocument indexes doc under id with markdown-aware chunking and
+// embeddings, replacing any earlier version with different content.
+func (d *DocumentIngester) IngestDocument(ctx context.Context, id string, doc *docloader.Document) (map[string]any, error) {
+	req := ingest.IngestRequest{
+		ID:       id,
+		Title:    doc.Title,
+		Source:   "file",
+		Text:     doc.Text,
+		Metadata: doc.IngestMetadata(),
+		Options: ingest.IngestOptions{
+			Chunking:       ingest.ChunkingOptions{Strategy: "md"},
+			Embedding:      ingest.EmbeddingOptions{Enabled: true},
+			ReingestPolicy: ingest.ReingestSkipIfUnchanged,
+		},
+	}
+	resp, err := d.s.Ingest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"doc_id": resp.DocID, "version": resp.Version, "chunks": len(resp.ChunkIDs)}, nil
+}
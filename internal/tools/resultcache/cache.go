@@ -0,0 +1,255 @@
+// Package resultcache caches tool results keyed by tool name, the calling
+// user and project, and normalized arguments so repeated deterministic calls
+// (web_fetch, split_text, ...) can skip execution.
+package resultcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+	"manifold/internal/sandbox"
+)
+
+// Backend stores cached payloads.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr atomically increments an integer counter and returns the new value.
+	Incr(ctx context.Context, key string) (int64, error)
+	Close() error
+}
+
+// Policy is the resolved cache policy for a single tool.
+type Policy struct {
+	TTL          time.Duration
+	InvalidateOn []string
+}
+
+// Cache decides which tool calls are cacheable and reads/writes results
+// through a Backend. A nil *Cache is valid and caches nothing.
+type Cache struct {
+	backend Backend
+	prefix  string
+	// policies maps cacheable tool names to their policy.
+	policies map[string]Policy
+	// invalidates maps a tool name to the cached tools it invalidates.
+	invalidates map[string][]string
+}
+
+// New builds a Cache from config. It returns nil when caching is disabled or
+// no tools are configured.
+func New(cfg config.ToolCacheConfig) (*Cache, error) {
+	if !cfg.Enabled || len(cfg.Tools) == 0 {
+		return nil, nil
+	}
+	var (
+		backend Backend
+		prefix  string
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "memory":
+		backend = NewLRU(cfg.MaxEntries)
+	case "redis":
+		if strings.TrimSpace(cfg.Redis.Addr) == "" {
+			return nil, fmt.Errorf("toolCache.redis.addr is required for the redis backend")
+		}
+		backend = NewRedis(cfg.Redis)
+		prefix = cfg.Redis.KeyPrefix
+	default:
+		return nil, fmt.Errorf("unknown toolCache backend %q", cfg.Backend)
+	}
+	return NewWithBackend(backend, prefix, cfg)
+}
+
+// NewWithBackend builds a Cache over an explicit backend.
+func NewWithBackend(backend Backend, prefix string, cfg config.ToolCacheConfig) (*Cache, error) {
+	defaultTTL := time.Duration(cfg.DefaultTTLSeconds) * time.Second
+	c := &Cache{
+		backend:     backend,
+		prefix:      prefix,
+		policies:    make(map[string]Policy, len(cfg.Tools)),
+		invalidates: map[string][]string{},
+	}
+	for name, p := range cfg.Tools {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		ttl := defaultTTL
+		if p.TTLSeconds > 0 {
+			ttl = time.Duration(p.TTLSeconds) * time.Second
+		}
+		if ttl <= 0 {
+			return nil, fmt.Errorf("toolCache.tools.%s: ttl must be positive", name)
+		}
+		c.policies[name] = Policy{TTL: ttl, InvalidateOn: append([]string(nil), p.InvalidateOn...)}
+		for _, trigger := range p.InvalidateOn {
+			trigger = strings.TrimSpace(trigger)
+			if trigger != "" {
+				c.invalidates[trigger] = append(c.invalidates[trigger], name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Cacheable reports whether results for tool are cached.
+func (c *Cache) Cacheable(tool string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.policies[tool]
+	return ok
+}
+
+// Lookup returns a cached payload for the call, if any. Backend errors are
+// logged and treated as a miss.
+func (c *Cache) Lookup(ctx context.Context, tool string, args json.RawMessage) ([]byte, bool) {
+	if !c.Cacheable(tool) {
+		return nil, false
+	}
+	key, err := c.key(ctx, tool, args)
+	if err != nil {
+		observability.LoggerWithTrace(ctx).Debug().Err(err).Str("tool", tool).Msg("tool_cache_key_failed")
+		return nil, false
+	}
+	val, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		observability.LoggerWithTrace(ctx).Warn().Err(err).Str("tool", tool).Msg("tool_cache_get_failed")
+		return nil, false
+	}
+	return val, ok
+}
+
+// Store caches a successful payload for the call. Error payloads are skipped
+// so transient failures are retried on the next call.
+func (c *Cache) Store(ctx context.Context, tool string, args json.RawMessage, payload []byte) {
+	if !c.Cacheable(tool) || isErrorPayload(payload) {
+		return
+	}
+	key, err := c.key(ctx, tool, args)
+	if err != nil {
+		return
+	}
+	if err := c.backend.Set(ctx, key, payload, c.policies[tool].TTL); err != nil {
+		observability.LoggerWithTrace(ctx).Warn().Err(err).Str("tool", tool).Msg("tool_cache_set_failed")
+	}
+}
+
+// Observe records that tool ran and invalidates any cached tools configured
+// with it in invalidateOn.
+func (c *Cache) Observe(ctx context.Context, tool string, payload []byte) {
+	if c == nil || isErrorPayload(payload) {
+		return
+	}
+	for _, target := range c.invalidates[tool] {
+		c.Invalidate(ctx, target)
+	}
+}
+
+// Invalidate drops every cached result for tool by bumping its generation.
+func (c *Cache) Invalidate(ctx context.Context, tool string) {
+	if c == nil {
+		return
+	}
+	if _, err := c.backend.Incr(ctx, c.generationKey(tool)); err != nil {
+		observability.LoggerWithTrace(ctx).Warn().Err(err).Str("tool", tool).Msg("tool_cache_invalidate_failed")
+	}
+}
+
+// Close releases backend resources.
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.backend.Close()
+}
+
+func (c *Cache) generationKey(tool string) string {
+	return c.prefix + "gen:" + tool
+}
+
+// key combines the tool name, its current generation, and a hash of the
+// caller's scope and the normalized arguments. The scope, the user and
+// the project and working directory from ctx, keeps one user's results
+// (file contents, command output) from being served to another.
+func (c *Cache) key(ctx context.Context, tool string, args json.RawMessage) (string, error) {
+	norm, err := NormalizeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	gen := "0"
+	if raw, ok, err := c.backend.Get(ctx, c.generationKey(tool)); err != nil {
+		return "", err
+	} else if ok {
+		gen = string(raw)
+	}
+	h := sha256.New()
+	h.Write([]byte(scope(ctx)))
+	h.Write([]byte{0})
+	h.Write(norm)
+	sum := h.Sum(nil)
+	return c.prefix + tool + ":" + gen + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// scope identifies whose data a tool call sees.
+func scope(ctx context.Context) string {
+	var userID int64
+	if id, ok := llm.UserIDFromContext(ctx); ok {
+		userID = id
+	}
+	project, _ := sandbox.ProjectIDFromContext(ctx)
+	dir, _ := sandbox.BaseDirFromContext(ctx)
+	return strconv.FormatInt(userID, 10) + "\x00" + project + "\x00" + dir
+}
+
+// workflowArgs are injected into every tool schema for WARPP bindings and do
+// not affect the tool's result.
+var workflowArgs = []string{"output_attr", "output_from", "output_value"}
+
+// NormalizeArgs re-encodes JSON arguments with sorted keys and insignificant
+// whitespace removed so equivalent calls share a cache key.
+func NormalizeArgs(args json.RawMessage) ([]byte, error) {
+	if len(bytes.TrimSpace(args)) == 0 {
+		return []byte("{}"), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("normalize args: %w", err)
+	}
+	if m, ok := v.(map[string]any); ok {
+		for _, k := range workflowArgs {
+			delete(m, k)
+		}
+	}
+	// encoding/json sorts map keys, which gives a canonical form.
+	return json.Marshal(v)
+}
+
+func isErrorPayload(payload []byte) bool {
+	var probe struct {
+		OK    *bool           `json:"ok"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return false
+	}
+	if probe.OK != nil && !*probe.OK {
+		return true
+	}
+	return len(probe.Error) > 0 && string(probe.Error) != "null" && string(probe.Error) != `""`
+}
+
+func formatInt(n int64) []byte { return []byte(strconv.FormatInt(n, 10)) }
@@ -0,0 +1,262 @@
+package resultcache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/sandbox"
+)
+
+func newTestCache(t *testing.T, tools map[string]config.ToolCachePolicy) (*Cache, *LRU) {
+	t.Helper()
+	lru := NewLRU(10)
+	c, err := NewWithBackend(lru, "", config.ToolCacheConfig{Enabled: true, DefaultTTLSeconds: 60, Tools: tools})
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	return c, lru
+}
+
+func TestNewDisabledReturnsNil(t *testing.T) {
+	c, err := New(config.ToolCacheConfig{Tools: map[string]config.ToolCachePolicy{"web_fetch": {}}})
+	if err != nil || c != nil {
+		t.Fatalf("expected nil cache when disabled, got %v, %v", c, err)
+	}
+	if _, ok := c.Lookup(context.Background(), "web_fetch", nil); ok {
+		t.Fatalf("nil cache should always miss")
+	}
+	c.Store(context.Background(), "web_fetch", nil, []byte(`{}`))
+}
+
+func TestNewRejectsUnknownBackend(t *testing.T) {
+	_, err := New(config.ToolCacheConfig{Enabled: true, Backend: "memcached", Tools: map[string]config.ToolCachePolicy{"x": {}}})
+	if err == nil {
+		t.Fatalf("expected error for unknown backend")
+	}
+}
+
+func TestNormalizeArgs(t *testing.T) {
+	a, err := NormalizeArgs(json.RawMessage(`{ "url": "https://x", "max": 10, "output_attr": "page" }`))
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	b, _ := NormalizeArgs(json.RawMessage(`{"max":10,"url":"https://x"}`))
+	if string(a) != string(b) {
+		t.Fatalf("expected equal normalization, got %s vs %s", a, b)
+	}
+	if _, err := NormalizeArgs(json.RawMessage(`{`)); err == nil {
+		t.Fatalf("expected error for invalid json")
+	}
+}
+
+func TestLookupStoreAndSkipErrors(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCache(t, map[string]config.ToolCachePolicy{"web_fetch": {}})
+
+	args := json.RawMessage(`{"url":"https://example.com"}`)
+	if _, ok := c.Lookup(ctx, "web_fetch", args); ok {
+		t.Fatalf("expected miss")
+	}
+	c.Store(ctx, "web_fetch", args, []byte(`{"ok":true,"markdown":"hi"}`))
+	got, ok := c.Lookup(ctx, "web_fetch", json.RawMessage(`{ "url" : "https://example.com" }`))
+	if !ok || !strings.Contains(string(got), "hi") {
+		t.Fatalf("expected hit, got %q %v", got, ok)
+	}
+
+	errArgs := json.RawMessage(`{"url":"https://down.example.com"}`)
+	c.Store(ctx, "web_fetch", errArgs, []byte(`{"ok":false,"error":"timeout"}`))
+	if _, ok := c.Lookup(ctx, "web_fetch", errArgs); ok {
+		t.Fatalf("error payloads must not be cached")
+	}
+
+	c.Store(ctx, "run_cli", args, []byte(`{"ok":true}`))
+	if _, ok := c.Lookup(ctx, "run_cli", args); ok {
+		t.Fatalf("unconfigured tools must not be cached")
+	}
+}
+
+func TestLookupIsScopedToUserAndProject(t *testing.T) {
+	c, _ := newTestCache(t, map[string]config.ToolCachePolicy{"file_read": {}})
+	args := json.RawMessage(`{"path":"notes.txt"}`)
+
+	alice := sandbox.WithBaseDir(sandbox.WithProjectID(llm.WithUserID(context.Background(), 1), "p1"), "/data/users/1/projects/p1")
+	c.Store(alice, "file_read", args, []byte(`"alice's notes"`))
+	if got, ok := c.Lookup(alice, "file_read", args); !ok || string(got) != `"alice's notes"` {
+		t.Fatalf("expected hit for the same user and project, got %q %v", got, ok)
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"other user":    sandbox.WithBaseDir(sandbox.WithProjectID(llm.WithUserID(context.Background(), 2), "p1"), "/data/users/1/projects/p1"),
+		"other project": sandbox.WithBaseDir(sandbox.WithProjectID(llm.WithUserID(context.Background(), 1), "p2"), "/data/users/1/projects/p1"),
+		"other workdir": sandbox.WithBaseDir(sandbox.WithProjectID(llm.WithUserID(context.Background(), 1), "p1"), "/data/users/1/projects/p2"),
+		"no scope":      context.Background(),
+	} {
+		if got, ok := c.Lookup(ctx, "file_read", args); ok {
+			t.Fatalf("%s: served another scope's result %q", name, got)
+		}
+	}
+}
+
+func TestObserveInvalidatesDependentTools(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCache(t, map[string]config.ToolCachePolicy{
+		"file_read": {InvalidateOn: []string{"file_write"}},
+	})
+	args := json.RawMessage(`{"path":"a.txt"}`)
+	c.Store(ctx, "file_read", args, []byte(`"v1"`))
+
+	c.Observe(ctx, "file_write", []byte(`{"ok":false,"error":"denied"}`))
+	if _, ok := c.Lookup(ctx, "file_read", args); !ok {
+		t.Fatalf("failed writes should not invalidate")
+	}
+
+	c.Observe(ctx, "file_write", []byte(`{"ok":true}`))
+	if _, ok := c.Lookup(ctx, "file_read", args); ok {
+		t.Fatalf("expected invalidation after file_write")
+	}
+}
+
+func TestLRUExpiryAndEviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	l := NewLRU(2)
+	l.now = func() time.Time { return now }
+
+	_ = l.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = l.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = l.Get(ctx, "a")
+	_ = l.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, ok, _ := l.Get(ctx, "b"); ok {
+		t.Fatalf("expected least recently used entry to be evicted")
+	}
+	if _, ok, _ := l.Get(ctx, "a"); !ok {
+		t.Fatalf("expected recently used entry to survive")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := l.Get(ctx, "a"); ok {
+		t.Fatalf("expected entry to expire")
+	}
+}
+
+// fakeRedis implements just enough RESP to exercise the Redis backend.
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(rd)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					var reply string
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							reply = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+						} else {
+							reply = "$-1\r\n"
+						}
+					case "SET":
+						data[args[1]] = args[2]
+						reply = "+OK\r\n"
+					case "INCR":
+						n := 0
+						if v, ok := data[args[1]]; ok {
+							n, _ = strconv.Atoi(v)
+						}
+						n++
+						data[args[1]] = strconv.Itoa(n)
+						reply = ":" + strconv.Itoa(n) + "\r\n"
+					default:
+						reply = "-ERR unknown command\r\n"
+					}
+					mu.Unlock()
+					if _, err := conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := readReply(rd)
+		if err != nil {
+			return nil, err
+		}
+		b, _ := v.([]byte)
+		args = append(args, string(b))
+	}
+	return args, nil
+}
+
+func TestRedisBackend(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedis(config.ToolCacheRedisConfig{Addr: fakeRedis(t)})
+	defer r.Close()
+
+	if _, ok, err := r.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("expected miss, got ok=%v err=%v", ok, err)
+	}
+	if err := r.Set(ctx, "k", []byte("hello\r\nworld"), time.Minute); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, ok, err := r.Get(ctx, "k")
+	if err != nil || !ok || string(got) != "hello\r\nworld" {
+		t.Fatalf("unexpected get: %q %v %v", got, ok, err)
+	}
+	if n, err := r.Incr(ctx, "gen"); err != nil || n != 1 {
+		t.Fatalf("unexpected incr: %d %v", n, err)
+	}
+
+	c, err := NewWithBackend(r, "test:", config.ToolCacheConfig{Enabled: true, DefaultTTLSeconds: 60, Tools: map[string]config.ToolCachePolicy{"split_text": {}}})
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	args := json.RawMessage(`{"text":"abc"}`)
+	c.Store(ctx, "split_text", args, []byte(`["a","b"]`))
+	if _, ok := c.Lookup(ctx, "split_text", args); !ok {
+		t.Fatalf("expected redis-backed hit")
+	}
+	c.Invalidate(ctx, "split_text")
+	if _, ok := c.Lookup(ctx, "split_text", args); ok {
+		t.Fatalf("expected miss after invalidation")
+	}
+}
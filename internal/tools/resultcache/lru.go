@@ -0,0 +1,98 @@
+package resultcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-memory Backend with per-entry expiry and a bounded size.
+type LRU struct {
+	mu       sync.Mutex
+	max      int
+	ll       *list.List
+	items    map[string]*list.Element
+	counters map[string]int64
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU returns an LRU holding at most maxEntries results.
+func NewLRU(maxEntries int) *LRU {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &LRU{
+		max:      maxEntries,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		counters: make(map[string]int64),
+		now:      time.Now,
+	}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n, ok := l.counters[key]; ok {
+		return formatInt(n), true, nil
+	}
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	ent := el.Value.(*lruEntry)
+	if !ent.expires.IsZero() && !l.now().Before(ent.expires) {
+		l.ll.Remove(el)
+		delete(l.items, key)
+		return nil, false, nil
+	}
+	l.ll.MoveToFront(el)
+	return ent.value, true, nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+	if el, ok := l.items[key]; ok {
+		ent := el.Value.(*lruEntry)
+		ent.value = value
+		ent.expires = expires
+		l.ll.MoveToFront(el)
+		return nil
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.ll.Len() > l.max {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Incr keeps counters outside the LRU so generations are never evicted.
+func (l *LRU) Incr(_ context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counters[key]++
+	return l.counters[key], nil
+}
+
+func (l *LRU) Close() error { return nil }
+
+// Len returns the number of cached results (excluding counters).
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
@@ -0,0 +1,205 @@
+package resultcache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+)
+
+const (
+	redisDialTimeout = 2 * time.Second
+	redisIOTimeout   = 2 * time.Second
+	redisMaxIdle     = 4
+)
+
+// Redis is a Backend speaking the RESP protocol to a Redis-compatible server.
+// It implements only the commands the cache needs (GET, SET PX, INCR) to avoid
+// pulling in a full client library.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+type redisConn struct {
+	nc net.Conn
+	rd *bufio.Reader
+}
+
+// NewRedis returns a Redis backend. Connections are opened lazily.
+func NewRedis(cfg config.ToolCacheRedisConfig) *Redis {
+	return &Redis{addr: cfg.Addr, password: cfg.Password, db: cfg.DB}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if v == nil {
+		return nil, false, nil
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis GET: unexpected reply %T", v)
+	}
+	return b, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	v, err := r.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis INCR: unexpected reply %T", v)
+	}
+	return n, nil
+}
+
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.idle {
+		_ = c.nc.Close()
+	}
+	r.idle = nil
+	return nil
+}
+
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	c, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(redisIOTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.nc.SetDeadline(deadline)
+	v, err := c.roundTrip(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection state is unknown after an I/O failure.
+		_ = c.nc.Close()
+		return nil, err
+	}
+	r.release(c)
+	return v, err
+}
+
+func (r *Redis) acquire(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		c := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return c, nil
+	}
+	r.mu.Unlock()
+
+	d := net.Dialer{Timeout: redisDialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis dial: %w", err)
+	}
+	c := &redisConn{nc: nc, rd: bufio.NewReader(nc)}
+	_ = nc.SetDeadline(time.Now().Add(redisIOTimeout))
+	if r.password != "" {
+		if _, err := c.roundTrip("AUTH", r.password); err != nil {
+			_ = nc.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.roundTrip("SELECT", strconv.Itoa(r.db)); err != nil {
+			_ = nc.Close()
+			return nil, fmt.Errorf("redis select: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (r *Redis) release(c *redisConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.idle) >= redisMaxIdle {
+		_ = c.nc.Close()
+		return
+	}
+	r.idle = append(r.idle, c)
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (c *redisConn) roundTrip(args ...string) (any, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.nc, sb.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.rd)
+}
+
+// readReply parses a single RESP reply. Bulk strings are returned as []byte,
+// integers as int64, and nil bulk strings as nil.
+func readReply(rd *bufio.Reader) (any, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported reply %q", line)
+	}
+}
@@ -3,15 +3,37 @@ package textsplitter
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
+	"manifold/internal/config"
+	"manifold/internal/llm/embeddings"
 	"manifold/internal/textsplitters"
 )
 
 // Tool exposes text splitting strategies as a registry tool.
-type Tool struct{}
+type Tool struct {
+	embedder textsplitters.Embedder
+}
 
 func New() *Tool { return &Tool{} }
 
+// NewWithEmbedder returns a Tool whose semantic strategy computes breakpoints
+// from embedding similarity rather than lexical overlap.
+func NewWithEmbedder(e textsplitters.Embedder) *Tool { return &Tool{embedder: e} }
+
+// NewForEmbedding returns a Tool backed by the configured embedding endpoint,
+// or a lexical-only Tool when no endpoint is configured.
+func NewForEmbedding(cfg config.EmbeddingConfig) *Tool {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		return New()
+	}
+	p, err := embeddings.New(cfg, nil)
+	if err != nil {
+		return New()
+	}
+	return NewWithEmbedder(p)
+}
+
 func (t *Tool) Name() string { return "split_text" }
 
 func (t *Tool) JSONSchema() map[string]any {
@@ -29,7 +51,7 @@ func (t *Tool) JSONSchema() map[string]any {
 				"tokenizer": map[string]any{"type": "string", "description": "Tokenizer to use when unit='tokens' (default 'whitespace')"},
 				// additional knobs (optional, minimal exposure)
 				"window":         map[string]any{"type": "integer", "description": "Semantic/TextTiling/rolling sentence window size"},
-				"threshold":      map[string]any{"type": "number", "description": "Semantic/TextTiling threshold (0..1)"},
+				"threshold":      map[string]any{"type": "number", "description": "Semantic/TextTiling threshold (0..1). For embedding-backed semantic splitting, omit to place breakpoints at the least similar 10% of sentence pairs"},
 				"language":       map[string]any{"type": "string", "description": "Code language hint (go, python, js)"},
 				"headers":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Markdown header levels to split on (e.g., ['#','##'])"},
 				"rolling_step":   map[string]any{"type": "integer", "description": "Rolling sentences step"},
@@ -40,7 +62,7 @@ func (t *Tool) JSONSchema() map[string]any {
 	}
 }
 
-func (t *Tool) Call(ctx context.Context, raw json.RawMessage) (any, error) { //nolint:revive // ctx kept for future use
+func (t *Tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
 	var args struct {
 		Text          string   `json:"text"`
 		Kind          string   `json:"kind"`
@@ -97,7 +119,7 @@ func (t *Tool) Call(ctx context.Context, raw json.RawMessage) (any, error) { //n
 	case textsplitters.KindCode:
 		cfg.Code = textsplitters.CodeConfig{Language: args.Language, Within: textsplitters.BoundaryConfig{Unit: unit, Size: size, Overlap: overlap}}
 	case textsplitters.KindSemantic:
-		cfg.Semantic = textsplitters.SemanticConfig{Window: args.Window, Threshold: args.Threshold, Within: textsplitters.BoundaryConfig{Unit: unit, Size: size, Overlap: overlap}}
+		cfg.Semantic = textsplitters.SemanticConfig{Window: args.Window, Threshold: args.Threshold, Embedder: t.embedder, Within: textsplitters.BoundaryConfig{Unit: unit, Size: size, Overlap: overlap}}
 	case textsplitters.KindTextTiling:
 		cfg.TextTiling = textsplitters.TextTilingConfig{BlockSize: args.Window, Threshold: args.Threshold, Within: textsplitters.BoundaryConfig{Unit: unit, Size: size, Overlap: overlap}}
 	case textsplitters.KindRollingSentences:
@@ -117,7 +139,10 @@ func (t *Tool) Call(ctx context.Context, raw json.RawMessage) (any, error) { //n
 	if err != nil {
 		return map[string]any{"ok": false, "error": err.Error()}, nil
 	}
-	chunks := splitter.Split(args.Text)
+	chunks, err := textsplitters.SplitContext(ctx, splitter, args.Text)
+	if err != nil {
+		return map[string]any{"ok": false, "error": err.Error()}, nil
+	}
 	return map[string]any{
 		"ok":      true,
 		"chunks":  chunks,
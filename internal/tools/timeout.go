@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"manifold/internal/config"
+	"manifold/internal/llm"
+	"manifold/internal/observability"
+)
+
+// Timeouts bounds how long tool calls may run. A zero or negative duration
+// means no limit beyond the caller's context.
+type Timeouts struct {
+	Default time.Duration
+	// PerTool overrides Default by tool name.
+	PerTool map[string]time.Duration
+}
+
+// For returns the timeout of tool name.
+func (t Timeouts) For(name string) time.Duration {
+	if d, ok := t.PerTool[name]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// TimeoutsFromConfig converts the toolTimeouts config section.
+func TimeoutsFromConfig(c config.ToolTimeoutsConfig) Timeouts {
+	t := Timeouts{Default: time.Duration(c.DefaultSeconds) * time.Second}
+	if len(c.Tools) > 0 {
+		t.PerTool = make(map[string]time.Duration, len(c.Tools))
+		for name, secs := range c.Tools {
+			t.PerTool[name] = time.Duration(secs) * time.Second
+		}
+	}
+	return t
+}
+
+// TimeoutError reports a tool call that ran past its timeout.
+type TimeoutError struct {
+	Tool  string
+	After time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("tool %s timed out after %s and was cancelled", e.Tool, e.After)
+}
+
+type timeoutRegistry struct {
+	base     Registry
+	timeouts Timeouts
+}
+
+// NewTimeoutRegistry wraps base so every call gets a context cancelled at
+// its tool's timeout or when the caller's context ends. The call returns
+// then even if the tool ignores its context, so a hung tool cannot stall
+// the run; the tool keeps running in the background until it returns.
+func NewTimeoutRegistry(base Registry, timeouts Timeouts) Registry {
+	return &timeoutRegistry{base: base, timeouts: timeouts}
+}
+
+func (r *timeoutRegistry) Schemas() []llm.ToolSchema { return r.base.Schemas() }
+func (r *timeoutRegistry) Register(t Tool)           { r.base.Register(t) }
+func (r *timeoutRegistry) Unregister(name string)    { r.base.Unregister(name) }
+
+func (r *timeoutRegistry) Dispatch(parent context.Context, name string, raw json.RawMessage) ([]byte, error) {
+	limit := r.timeouts.For(name)
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if limit > 0 {
+		ctx, cancel = context.WithTimeout(parent, limit)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	defer cancel()
+
+	type result struct {
+		payload []byte
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		payload, err := r.base.Dispatch(ctx, name, raw)
+		done <- result{payload, err}
+	}()
+	select {
+	case res := <-done:
+		// A tool that gave up on the deadline itself is reported like one
+		// that did not return in time.
+		if !deadlineHit(ctx, parent, limit) || res.err != nil || TimedOut(res.payload) || !failed(res.payload) {
+			return res.payload, res.err
+		}
+	case <-ctx.Done():
+	}
+	if deadlineHit(ctx, parent, limit) {
+		observability.LoggerWithTrace(ctx).Warn().Str("tool", name).Dur("timeout", limit).Msg("tool_timeout")
+		return ErrorPayload(&TimeoutError{Tool: name, After: limit}), nil
+	}
+	return ErrorPayload(WrapError(ErrInternal, fmt.Errorf("tool %s cancelled: %w", name, ctx.Err()))), nil
+}
+
+// deadlineHit reports whether ctx ended at the tool's own timeout. A parent
+// deadline, such as the run's, ends ctx too but is reported as cancellation.
+func deadlineHit(ctx, parent context.Context, limit time.Duration) bool {
+	return limit > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+}
+
+// TimedOut reports whether payload is the result of a call stopped at its
+// timeout.
+func TimedOut(payload []byte) bool {
+	var p struct {
+		TimedOut bool `json:"timed_out"`
+	}
+	return json.Unmarshal(payload, &p) == nil && p.TimedOut
+}
+
+// failed reports whether payload is a tool error result.
+func failed(payload []byte) bool {
+	var p struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}
+	return json.Unmarshal(payload, &p) == nil && p.Error != "" && (p.OK == nil || !*p.OK)
+}
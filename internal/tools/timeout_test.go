@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hangingTool blocks until release is closed, ignoring its context.
+type hangingTool struct{ release chan struct{} }
+
+func (hangingTool) Name() string               { return "hang" }
+func (hangingTool) JSONSchema() map[string]any { return map[string]any{"description": "hangs"} }
+func (t hangingTool) Call(context.Context, json.RawMessage) (any, error) {
+	<-t.release
+	return map[string]any{"ok": true}, nil
+}
+
+// deadlineTool returns the error of its context once it ends.
+type deadlineTool struct{}
+
+func (deadlineTool) Name() string               { return "wait" }
+func (deadlineTool) JSONSchema() map[string]any { return map[string]any{"description": "waits"} }
+func (deadlineTool) Call(ctx context.Context, _ json.RawMessage) (any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeoutRegistry(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+	base := NewRegistry()
+	base.Register(hangingTool{release: release})
+	base.Register(deadlineTool{})
+	base.Register(mapErrorTool{})
+	reg := NewTimeoutRegistry(base, Timeouts{
+		Default: time.Hour,
+		PerTool: map[string]time.Duration{"hang": 50 * time.Millisecond, "wait": 50 * time.Millisecond},
+	})
+
+	start := time.Now()
+	b, err := reg.Dispatch(context.Background(), "hang", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("hung tool held Dispatch for %s", time.Since(start))
+	}
+	if p := decodeErrorPayload(t, b); p.OK || !p.TimedOut || p.Category != ErrTimeout || !TimedOut(b) {
+		t.Fatalf("unexpected timeout payload: %s", b)
+	}
+
+	b, _ = reg.Dispatch(context.Background(), "wait", nil)
+	if p := decodeErrorPayload(t, b); !p.TimedOut || p.Error != "tool wait timed out after 50ms and was cancelled" {
+		t.Fatalf("tool stopping at its deadline not reported as a timeout: %s", b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b, _ = reg.Dispatch(ctx, "wait", nil)
+	if p := decodeErrorPayload(t, b); p.TimedOut || p.OK {
+		t.Fatalf("cancelled call reported as a timeout: %s", b)
+	}
+
+	// A run deadline shorter than the tool's own limit is not the tool's
+	// timeout.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	b, _ = reg.Dispatch(ctx, "wait", nil)
+	if p := decodeErrorPayload(t, b); p.TimedOut || strings.Contains(p.Error, "timed out after 50ms") {
+		t.Fatalf("parent deadline reported as the tool's timeout: %s", b)
+	}
+
+	b, _ = reg.Dispatch(context.Background(), "map_error", nil)
+	if p := decodeErrorPayload(t, b); p.TimedOut || p.Category != ErrRateLimited {
+		t.Fatalf("ordinary failure changed: %s", b)
+	}
+}
@@ -0,0 +1,395 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"manifold/internal/config"
+)
+
+// Provider types accepted in tts.providers[].type.
+const (
+	ProviderOpenAI     = "openai"
+	ProviderElevenLabs = "elevenlabs"
+	ProviderPiper      = "piper"
+)
+
+// DefaultProviderName names the provider built from the top-level tts
+// settings.
+const DefaultProviderName = "default"
+
+// openAIVoices are the built-in voices of the OpenAI speech endpoint, which
+// has no listing API.
+var openAIVoices = []string{"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse"}
+
+// Capabilities describes what a provider supports.
+type Capabilities struct {
+	Streaming      bool     `json:"streaming"`
+	VoiceDiscovery bool     `json:"voiceDiscovery"`
+	Formats        []string `json:"formats"`
+}
+
+// Voice is a voice offered by a provider.
+type Voice struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// ProviderStatus is the discovery view of a provider.
+type ProviderStatus struct {
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Capabilities Capabilities `json:"capabilities"`
+	Healthy      bool         `json:"healthy"`
+	LastError    string       `json:"lastError,omitempty"`
+	RetryAt      *time.Time   `json:"retryAt,omitempty"`
+	Voices       []Voice      `json:"voices,omitempty"`
+	VoicesError  string       `json:"voicesError,omitempty"`
+}
+
+type provider struct {
+	name string
+	cfg  config.TTSProviderConfig
+}
+
+// defaultProvider builds the implicit provider from tts.baseURL, tts.model
+// and tts.voice, borrowing the OpenAI base URL and key when unset.
+func defaultProvider(cfg config.Config) *provider {
+	baseURL := cfg.TTS.BaseURL
+	if baseURL == "" {
+		baseURL = cfg.OpenAI.BaseURL
+	}
+	if baseURL == "" {
+		baseURL = "https://api.openai.com"
+	}
+	model := cfg.TTS.Model
+	if model == "" {
+		model = "gpt-4o-mini-tts"
+	}
+	voice := cfg.TTS.Voice
+	if _, named := cfg.TTS.Voices[voice]; named {
+		voice = ""
+	}
+	return &provider{name: DefaultProviderName, cfg: config.TTSProviderConfig{
+		Name:    DefaultProviderName,
+		Type:    ProviderOpenAI,
+		BaseURL: baseURL,
+		APIKey:  cfg.OpenAI.APIKey,
+		Model:   model,
+		Voice:   voice,
+	}}
+}
+
+func (p *provider) baseURL() string {
+	base := strings.TrimRight(p.cfg.BaseURL, "/")
+	switch {
+	case base != "":
+		return base
+	case p.cfg.Type == ProviderOpenAI:
+		return "https://api.openai.com"
+	}
+	return ""
+}
+
+func (p *provider) capabilities() Capabilities {
+	switch p.cfg.Type {
+	case ProviderElevenLabs:
+		return Capabilities{VoiceDiscovery: true, Formats: []string{"mp3"}}
+	case ProviderPiper:
+		return Capabilities{VoiceDiscovery: true, Formats: []string{"wav"}}
+	default:
+		return Capabilities{Streaming: true, Formats: []string{"mp3", "wav"}}
+	}
+}
+
+// speechRequest builds the synthesis request for text.
+func (p *provider) speechRequest(ctx context.Context, text, voice, model string, stream bool) (*http.Request, error) {
+	if voice == "" {
+		voice = p.cfg.Voice
+	}
+	if model == "" {
+		model = p.cfg.Model
+	}
+	var (
+		reqURL string
+		body   any
+	)
+	switch p.cfg.Type {
+	case ProviderElevenLabs:
+		if voice == "" {
+			return nil, fmt.Errorf("tts provider %q: a voice id is required", p.name)
+		}
+		if model == "" {
+			model = "eleven_multilingual_v2"
+		}
+		reqURL = p.baseURL() + "/v1/text-to-speech/" + url.PathEscape(voice)
+		body = map[string]string{"text": text, "model_id": model}
+	case ProviderPiper:
+		reqURL = p.baseURL() + "/"
+		body = struct {
+			Text  string `json:"text"`
+			Voice string `json:"voice,omitempty"`
+		}{text, voice}
+	default:
+		// Streaming requests assume the provider exposes /v1/audio/speech/stream.
+		reqURL = p.baseURL() + "/v1/audio/speech"
+		if stream {
+			reqURL += "/stream"
+		}
+		body = struct {
+			Model string `json:"model,omitempty"`
+			Voice string `json:"voice,omitempty"`
+			Input string `json:"input"`
+		}{model, voice, text}
+	}
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	p.authorize(req)
+	return req, nil
+}
+
+func (p *provider) authorize(req *http.Request) {
+	if p.cfg.APIKey == "" {
+		return
+	}
+	if p.cfg.Type == ProviderElevenLabs {
+		req.Header.Set("xi-api-key", p.cfg.APIKey)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+}
+
+// voices lists the provider's voices. OpenAI-compatible providers return the
+// built-in OpenAI voices.
+func (p *provider) voices(ctx context.Context, client *http.Client) ([]Voice, error) {
+	var reqURL string
+	switch p.cfg.Type {
+	case ProviderElevenLabs:
+		reqURL = p.baseURL() + "/v1/voices"
+	case ProviderPiper:
+		reqURL = p.baseURL() + "/voices"
+	default:
+		out := make([]Voice, len(openAIVoices))
+		for i, v := range openAIVoices {
+			out[i] = Voice{ID: v}
+		}
+		return out, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.authorize(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("list voices: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if p.cfg.Type == ProviderElevenLabs {
+		var payload struct {
+			Voices []struct {
+				VoiceID string `json:"voice_id"`
+				Name    string `json:"name"`
+			} `json:"voices"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode voices: %w", err)
+		}
+		out := make([]Voice, 0, len(payload.Voices))
+		for _, v := range payload.Voices {
+			out = append(out, Voice{ID: v.VoiceID, Name: v.Name})
+		}
+		return out, nil
+	}
+	// Piper's HTTP server returns an object keyed by voice name.
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode voices: %w", err)
+	}
+	out := make([]Voice, 0, len(payload))
+	for id := range payload {
+		out = append(out, Voice{ID: id})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// providerHealth tracks providers in cooldown after a failure.
+type providerHealth struct {
+	mu        sync.Mutex
+	cooldown  time.Duration
+	downUntil map[string]time.Time
+	lastErr   map[string]string
+	now       func() time.Time
+}
+
+func newProviderHealth(cooldown time.Duration) *providerHealth {
+	return &providerHealth{
+		cooldown:  cooldown,
+		downUntil: map[string]time.Time{},
+		lastErr:   map[string]string{},
+		now:       time.Now,
+	}
+}
+
+func (h *providerHealth) markDown(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.downUntil[name] = h.now().Add(h.cooldown)
+	h.lastErr[name] = err.Error()
+}
+
+func (h *providerHealth) markUp(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.downUntil, name)
+	delete(h.lastErr, name)
+}
+
+// status reports whether name is outside its cooldown, with the last error
+// and retry time when it is not.
+func (h *providerHealth) status(name string) (bool, string, time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	until, ok := h.downUntil[name]
+	if !ok || !h.now().Before(until) {
+		return true, "", time.Time{}
+	}
+	return false, h.lastErr[name], until
+}
+
+func (h *providerHealth) available(name string) bool {
+	ok, _, _ := h.status(name)
+	return ok
+}
+
+// selection is a provider with the voice and model to request from it.
+type selection struct {
+	provider *provider
+	voice    string
+	model    string
+}
+
+func (t *Tool) lookup(name string) *provider {
+	for _, p := range t.providers {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// resolve maps a voice to a provider. Names from tts.voices carry their own
+// provider and model; anything else is passed as is to providerName, or to
+// the default provider.
+func (t *Tool) resolve(voice, providerName, model string) (selection, error) {
+	if v, ok := t.cfg.TTS.Voices[voice]; ok && (providerName == "" || providerName == v.Provider) {
+		p := t.lookup(v.Provider)
+		if p == nil {
+			return selection{}, fmt.Errorf("tts voice %q: unknown provider %q", voice, v.Provider)
+		}
+		if model == "" {
+			model = v.Model
+		}
+		return selection{provider: p, voice: v.Voice, model: model}, nil
+	}
+	if providerName == "" {
+		providerName = DefaultProviderName
+	}
+	p := t.lookup(providerName)
+	if p == nil {
+		return selection{}, fmt.Errorf("unknown tts provider %q", providerName)
+	}
+	return selection{provider: p, voice: voice, model: model}, nil
+}
+
+// candidates returns first followed by the other providers with their own
+// default voice and model. Providers in cooldown are left out unless every
+// provider is.
+func (t *Tool) candidates(first selection) []selection {
+	all := []selection{first}
+	for _, p := range t.providers {
+		if p != first.provider {
+			all = append(all, selection{provider: p})
+		}
+	}
+	var up []selection
+	for _, s := range all {
+		if t.health.available(s.provider.name) {
+			up = append(up, s)
+		}
+	}
+	if len(up) == 0 {
+		return all
+	}
+	return up
+}
+
+// Providers reports every configured provider with its capabilities, health
+// and voices.
+func (t *Tool) Providers(ctx context.Context) []ProviderStatus {
+	out := make([]ProviderStatus, 0, len(t.providers))
+	for _, p := range t.providers {
+		healthy, lastErr, until := t.health.status(p.name)
+		st := ProviderStatus{
+			Name:         p.name,
+			Type:         p.cfg.Type,
+			Capabilities: p.capabilities(),
+			Healthy:      healthy,
+			LastError:    lastErr,
+		}
+		if !until.IsZero() {
+			st.RetryAt = &until
+		}
+		voices, err := p.voices(ctx, t.httpClient)
+		if err != nil {
+			st.VoicesError = err.Error()
+		}
+		st.Voices = voices
+		out = append(out, st)
+	}
+	return out
+}
+
+// Voices returns the named voices from tts.voices.
+func (t *Tool) Voices() map[string]config.TTSVoiceConfig {
+	return t.cfg.TTS.Voices
+}
+
+type voiceKey struct{}
+
+// WithVoice sets the voice text_to_speech uses when the call names none,
+// typically the voice of the specialist running the tool.
+func WithVoice(ctx context.Context, voice string) context.Context {
+	return context.WithValue(ctx, voiceKey{}, voice)
+}
+
+// VoiceFromContext returns the voice set by WithVoice.
+func VoiceFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(voiceKey{}).(string)
+	return v, ok && v != ""
+}
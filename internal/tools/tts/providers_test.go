@@ -0,0 +1,128 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"manifold/internal/config"
+)
+
+// fakeMP3 starts with an ID3 tag so saveFinalAudio stores it as mp3.
+var fakeMP3 = []byte("ID3\x03\x00fake")
+
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestCallFallsBackAndCoolsDown(t *testing.T) {
+	chdirTemp(t)
+
+	var openaiCalls int
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openaiCalls++
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer openai.Close()
+
+	var gotVoice string
+	piper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text, Voice string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotVoice = body.Voice
+		_, _ = w.Write(fakeMP3)
+	}))
+	defer piper.Close()
+
+	cfg := config.Config{TTS: config.TTSConfig{
+		BaseURL:         openai.URL,
+		CooldownSeconds: 60,
+		Providers:       []config.TTSProviderConfig{{Name: "local", Type: ProviderPiper, BaseURL: piper.URL, Voice: "en_US-amy-medium"}},
+	}}
+	tool := New(cfg, openai.Client())
+
+	res, err := tool.Call(context.Background(), json.RawMessage(`{"text":"hello"}`))
+	require.NoError(t, err)
+	m := res.(map[string]any)
+	require.Equal(t, "local", m["provider"])
+	require.Equal(t, []string{DefaultProviderName}, m["fallback_from"])
+	require.Equal(t, "en_US-amy-medium", gotVoice)
+	require.Equal(t, 1, openaiCalls)
+
+	// The default provider is in cooldown, so the next call skips it.
+	res, err = tool.Call(context.Background(), json.RawMessage(`{"text":"again"}`))
+	require.NoError(t, err)
+	require.Equal(t, "local", res.(map[string]any)["provider"])
+	require.Nil(t, res.(map[string]any)["fallback_from"])
+	require.Equal(t, 1, openaiCalls)
+
+	st := tool.Providers(context.Background())
+	require.Len(t, st, 2)
+	require.False(t, st[0].Healthy)
+	require.NotNil(t, st[0].RetryAt)
+	require.True(t, st[1].Healthy)
+}
+
+func TestCallResolvesNamedAndContextVoices(t *testing.T) {
+	chdirTemp(t)
+
+	var path, key, model string
+	eleven := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, key = r.URL.Path, r.Header.Get("xi-api-key")
+		var body struct {
+			ModelID string `json:"model_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model = body.ModelID
+		_, _ = w.Write(fakeMP3)
+	}))
+	defer eleven.Close()
+
+	var openaiBody map[string]string
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &openaiBody)
+		_, _ = w.Write(fakeMP3)
+	}))
+	defer openai.Close()
+
+	cfg := config.Config{TTS: config.TTSConfig{
+		BaseURL: openai.URL,
+		Voice:   "alloy",
+		Providers: []config.TTSProviderConfig{
+			{Name: "eleven", Type: ProviderElevenLabs, BaseURL: eleven.URL, APIKey: "xi-key"},
+		},
+		Voices: map[string]config.TTSVoiceConfig{
+			"narrator": {Provider: "eleven", Voice: "voice123", Model: "eleven_turbo_v2"},
+		},
+	}}
+	tool := New(cfg, openai.Client())
+
+	ctx := WithVoice(context.Background(), "narrator")
+	res, err := tool.Call(ctx, json.RawMessage(`{"text":"once upon a time"}`))
+	require.NoError(t, err)
+	require.Equal(t, "eleven", res.(map[string]any)["provider"])
+	require.Equal(t, "/v1/text-to-speech/voice123", path)
+	require.Equal(t, "xi-key", key)
+	require.Equal(t, "eleven_turbo_v2", model)
+
+	// A voice in the call wins over the context voice.
+	res, err = tool.Call(ctx, json.RawMessage(`{"text":"hi","voice":"nova"}`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultProviderName, res.(map[string]any)["provider"])
+	require.Equal(t, "nova", openaiBody["voice"])
+	require.Equal(t, "gpt-4o-mini-tts", openaiBody["model"])
+
+	_, err = tool.Call(ctx, json.RawMessage(`{"text":"hi","provider":"missing"}`))
+	require.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,49 +25,67 @@ import (
 	"manifold/internal/observability"
 )
 
-// Tool implements a simple TTS tool that calls the OpenAI /v1/audio/speech endpoint.
-// It prefers a provider present in context but falls back to the configured
-// TTSBaseURL in the top-level config. The tool saves the audio file and returns
-// success status with file information.
+// Tool implements a TTS tool over one or more providers. The implicit
+// "default" provider is the OpenAI-compatible /v1/audio/speech endpoint at
+// tts.baseURL (falling back to the OpenAI base URL); tts.providers adds
+// ElevenLabs, Piper or further OpenAI-compatible servers. When a provider
+// fails the request moves on to the next one, and the failed provider is
+// skipped until its cooldown expires. The tool saves the audio file and
+// returns success status with file information.
 type Tool struct {
 	cfg        config.Config
 	httpClient *http.Client
+	providers  []*provider
+	health     *providerHealth
 }
 
 func New(cfg config.Config, httpClient *http.Client) *Tool {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
-	return &Tool{cfg: cfg, httpClient: httpClient}
+	providers := []*provider{defaultProvider(cfg)}
+	for _, pc := range cfg.TTS.Providers {
+		providers = append(providers, &provider{name: pc.Name, cfg: pc})
+	}
+	cooldown := time.Duration(cfg.TTS.CooldownSeconds) * time.Second
+	return &Tool{cfg: cfg, httpClient: httpClient, providers: providers, health: newProviderHealth(cooldown)}
 }
 
 func (t *Tool) Name() string { return "text_to_speech" }
 
 func (t *Tool) JSONSchema() map[string]any {
+	voiceDesc := "Voice name (optional)"
+	if len(t.cfg.TTS.Voices) > 0 {
+		names := make([]string, 0, len(t.cfg.TTS.Voices))
+		for name := range t.cfg.TTS.Voices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		voiceDesc = "Voice name (optional). Configured voices: " + strings.Join(names, ", ")
+	}
+	props := map[string]any{
+		"text":   map[string]any{"type": "string", "description": "Text to synthesize"},
+		"model":  map[string]any{"type": "string", "description": "TTS model to use (optional)"},
+		"voice":  map[string]any{"type": "string", "description": voiceDesc},
+		"stream": map[string]any{"type": "boolean", "description": "If true, stream audio chunks (SSE) and return final file when complete"},
+	}
+	if len(t.providers) > 1 {
+		names := make([]string, 0, len(t.providers))
+		for _, p := range t.providers {
+			names = append(names, p.name)
+		}
+		props["provider"] = map[string]any{"type": "string", "enum": names, "description": "Provider to try first (optional)"}
+	}
 	return map[string]any{
-		"description": "Create speech audio from text using OpenAI-compatible TTS endpoint",
+		"description": "Create speech audio from text using the configured TTS providers",
 		"parameters": map[string]any{
-			"type": "object",
-			"properties": map[string]any{
-				"text":   map[string]any{"type": "string", "description": "Text to synthesize"},
-				"model":  map[string]any{"type": "string", "description": "TTS model to use (optional)"},
-				"voice":  map[string]any{"type": "string", "description": "Voice name (optional)"},
-				"stream": map[string]any{"type": "boolean", "description": "If true, stream audio chunks (SSE) and return final file when complete"},
-			},
-			"required": []string{"text"},
+			"type":       "object",
+			"properties": props,
+			"required":   []string{"text"},
 		},
 	}
 }
 
-// callBody represents request fields accepted by many OpenAI-compatible TTS
-// endpoints. We send a simple JSON payload; some gateways may require
-// multipart/form-data instead. Adjust if needed for a specific gateway.
-type callBody struct {
-	Model string `json:"model,omitempty"`
-	Voice string `json:"voice,omitempty"`
-	Input string `json:"input"`
-}
-
 // streaming context key + helpers -------------------------------------------------
 type streamChunkKey struct{}
 
@@ -91,96 +110,91 @@ func getStreamChunkCallback(ctx context.Context) func([]byte) {
 
 func (t *Tool) Call(ctx context.Context, raw json.RawMessage) (any, error) {
 	logger := observability.LoggerWithTrace(ctx)
-	// Parse args
-	var args map[string]any
+	var args struct {
+		Text     string `json:"text"`
+		Model    string `json:"model"`
+		Voice    string `json:"voice"`
+		Provider string `json:"provider"`
+		Stream   bool   `json:"stream"`
+	}
 	if err := json.Unmarshal(raw, &args); err != nil {
 		return nil, fmt.Errorf("invalid args: %w", err)
 	}
-	text, _ := args["text"].(string)
-	if strings.TrimSpace(text) == "" {
+	if strings.TrimSpace(args.Text) == "" {
 		return nil, fmt.Errorf("text is required")
 	}
-	modelArg, _ := args["model"].(string)
-	voice, _ := args["voice"].(string)
-	streamFlag, _ := args["stream"].(bool)
-
-	// Apply defaults from config when not provided in-call
-	model := modelArg
-	if model == "" {
-		model = t.cfg.TTS.Model
-	}
-	if model == "" {
-		model = "gpt-4o-mini-tts"
+	voice := strings.TrimSpace(args.Voice)
+	if voice == "" {
+		voice, _ = VoiceFromContext(ctx)
 	}
 	if voice == "" {
 		voice = t.cfg.TTS.Voice
 	}
-
-	// Determine base URL and API key
-	// For TTS, we prioritize the explicit TTS configuration over provider context
-	// since TTS might use a different endpoint than the main LLM
-	baseURL := t.cfg.TTS.BaseURL
-	apiKey := t.cfg.OpenAI.APIKey
-
-	logger.Debug().Str("config_tts_baseURL", t.cfg.TTS.BaseURL).Str("config_openai_baseURL", t.cfg.OpenAI.BaseURL).Msg("tts_config_urls")
-
-	// Only fall back to OpenAI config if no TTS-specific config is provided
-	if baseURL == "" {
-		baseURL = t.cfg.OpenAI.BaseURL
-		logger.Debug().Str("using_openai_baseURL", baseURL).Msg("tts_fallback_to_openai")
-	}
-	if baseURL == "" {
-		baseURL = "https://api.openai.com"
-		logger.Debug().Msg("tts_using_default_openai")
+	first, err := t.resolve(voice, strings.TrimSpace(args.Provider), strings.TrimSpace(args.Model))
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Debug().Str("final_baseURL", baseURL).Msg("tts_request")
-
-	// Build request URL (ensure no double slashes). For streaming requests assume provider exposes /v1/audio/speech/stream
-	base := strings.TrimRight(baseURL, "/")
-	reqURL := base + "/v1/audio/speech"
-	if streamFlag {
-		reqURL = base + "/v1/audio/speech/stream"
+	var failed []string
+	var lastErr error
+	for _, sel := range t.candidates(first) {
+		stream := args.Stream && sel.provider.capabilities().Streaming
+		logger.Debug().Str("provider", sel.provider.name).Str("voice", sel.voice).Str("model", sel.model).Bool("stream", stream).Msg("tts_request")
+		res, err := t.synthesize(ctx, sel, args.Text, stream)
+		if err != nil {
+			lastErr = err
+			failed = append(failed, sel.provider.name)
+			logger.Warn().Err(err).Str("provider", sel.provider.name).Msg("tts_provider_failed")
+			continue
+		}
+		if m, ok := res.(map[string]any); ok {
+			m["provider"] = sel.provider.name
+			m["voice"] = sel.voice
+			if len(failed) > 0 {
+				m["fallback_from"] = failed
+			}
+		}
+		return res, nil
 	}
+	return nil, lastErr
+}
 
-	body := callBody{Model: model, Voice: voice, Input: text}
-	b, _ := json.Marshal(body)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(string(b)))
+// synthesize runs one request against sel's provider. Transport errors and
+// server-side failures put the provider in cooldown.
+func (t *Tool) synthesize(ctx context.Context, sel selection, text string, stream bool) (any, error) {
+	req, err := sel.provider.speechRequest(ctx, text, sel.voice, sel.model, stream)
 	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if streamFlag {
-		// Request SSE / chunked stream where supported
-		req.Header.Set("Accept", "text/event-stream")
-	}
-	// SDK may set auth header when using option.WithAPIKey only on its own requests;
-	// here we set Authorization explicitly for the raw http request.
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
+		return nil, err
 	}
-
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
+		t.health.markDown(sel.provider.name, err)
 		return nil, fmt.Errorf("tts request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
-		return nil, fmt.Errorf("tts server error: %d %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		err := fmt.Errorf("tts server error: %d %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			t.health.markDown(sel.provider.name, err)
+		}
+		return nil, err
 	}
-	// If not streaming, read entire body same as before
-	if !streamFlag {
+	t.health.markUp(sel.provider.name)
+	if !stream {
 		audio, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, fmt.Errorf("read audio: %w", err)
 		}
 		return t.saveFinalAudio(ctx, audio)
 	}
+	return t.streamAudio(ctx, resp)
+}
 
-	// Streaming path: parse SSE style events line-by-line.
+// streamAudio reads an SSE speech stream, passing each chunk to the stream
+// callback, and saves the joined audio.
+func (t *Tool) streamAudio(ctx context.Context, resp *http.Response) (any, error) {
 	cb := getStreamChunkCallback(ctx)
 	reader := bufio.NewReader(resp.Body)
 
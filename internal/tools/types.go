@@ -152,7 +152,7 @@ func (f *filteredRegistry) Schemas() []llm.ToolSchema {
 func (f *filteredRegistry) Dispatch(ctx context.Context, name string, raw json.RawMessage) ([]byte, error) {
 	if len(f.allow) != 0 && !f.allow[name] {
 		observability.LoggerWithTrace(ctx).Error().Str("tool", name).Msg("tool_not_allowed")
-		return []byte(`{"error":"tool not allowed"}`), nil
+		return ErrorPayload(NewError(ErrPermissionDenied, "tool not allowed")), nil
 	}
 	return f.base.Dispatch(ctx, name, raw)
 }
@@ -161,19 +161,19 @@ func (r *defaultRegistry) Dispatch(ctx context.Context, name string, raw json.Ra
 	t := r.byName[name]
 	if t == nil {
 		observability.LoggerWithTrace(ctx).Error().Str("tool", name).Msg("tool_not_found")
-		return []byte(`{"error":"tool not found"}`), nil
+		return ErrorPayload(&Error{Category: ErrNotFound, Message: "tool not found", Hint: "Call one of the tools listed in your tool schema."}), nil
 	}
 	if r.logPayloads {
 		observability.LoggerWithTrace(ctx).Debug().Str("tool", name).RawJSON("args", observability.RedactJSON(raw)).Msg("tool_dispatch")
 	}
 	val, err := t.Call(ctx, raw)
 	if err != nil {
-		// return structured error payload
-		b, _ := json.Marshal(map[string]any{"ok": false, "error": err.Error()})
-		observability.LoggerWithTrace(ctx).Error().Str("tool", name).Err(err).Msg("tool_error")
-		return b, nil
+		te := Classify(err)
+		observability.LoggerWithTrace(ctx).Error().Str("tool", name).Str("category", string(te.Category)).Err(err).Msg("tool_error")
+		return ErrorPayload(te), nil
 	}
 	b, _ := json.Marshal(val)
+	b = NormalizeErrorPayload(b)
 	if r.logPayloads {
 		observability.LoggerWithTrace(ctx).Debug().Str("tool", name).RawJSON("payload", observability.RedactJSON(b)).Msg("tool_ok")
 	}
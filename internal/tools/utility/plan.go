@@ -0,0 +1,94 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"manifold/internal/tools"
+)
+
+// PlanToolName is the registry name of the plan tool.
+const PlanToolName = "update_plan"
+
+// Plan step statuses accepted by the plan tool.
+const (
+	PlanStatusPending    = "pending"
+	PlanStatusInProgress = "in_progress"
+	PlanStatusCompleted  = "completed"
+)
+
+// PlanStep is a single entry in the agent's working plan.
+type PlanStep struct {
+	Step   string `json:"step"`
+	Status string `json:"status"`
+}
+
+// PlanArgs is the full plan submitted on each update.
+type PlanArgs struct {
+	Explanation string     `json:"explanation,omitempty"`
+	Steps       []PlanStep `json:"steps"`
+}
+
+// PlanTool lets the model publish and revise a step-by-step plan. Each call
+// replaces the previous plan; the UI renders the latest one.
+type PlanTool struct{}
+
+// Name implements tools.Tool.
+func (PlanTool) Name() string { return PlanToolName }
+
+// JSONSchema implements tools.Tool.
+func (PlanTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        PlanToolName,
+		"description": "Publish or revise your plan for the current task. Send the complete list of steps every time, marking at most one step in_progress.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"explanation": map[string]any{
+					"type":        "string",
+					"description": "Optional short note on why the plan changed.",
+				},
+				"steps": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"step":   map[string]any{"type": "string"},
+							"status": map[string]any{"type": "string", "enum": []any{PlanStatusPending, PlanStatusInProgress, PlanStatusCompleted}},
+						},
+						"required": []any{"step", "status"},
+					},
+				},
+			},
+			"required": []any{"steps"},
+		},
+	}
+}
+
+// Call implements tools.Tool.
+func (PlanTool) Call(_ context.Context, raw json.RawMessage) (any, error) {
+	var args PlanArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return map[string]any{"ok": false, "error": "invalid arguments: " + err.Error()}, nil
+	}
+	inProgress := 0
+	for i, s := range args.Steps {
+		args.Steps[i].Step = strings.TrimSpace(s.Step)
+		switch s.Status {
+		case PlanStatusPending, PlanStatusCompleted:
+		case PlanStatusInProgress:
+			inProgress++
+		default:
+			return map[string]any{"ok": false, "error": fmt.Sprintf("step %d: unknown status %q", i+1, s.Status)}, nil
+		}
+	}
+	if inProgress > 1 {
+		return map[string]any{"ok": false, "error": "at most one step may be in_progress"}, nil
+	}
+	return map[string]any{"ok": true, "explanation": args.Explanation, "steps": args.Steps}, nil
+}
+
+// NewPlanTool constructs the plan tool.
+func NewPlanTool() tools.Tool { return PlanTool{} }
@@ -0,0 +1,78 @@
+package utility
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"manifold/internal/tools"
+)
+
+// ScratchpadToolName is the registry name of the scratchpad tool.
+const ScratchpadToolName = "scratchpad"
+
+// Scratchpad operations.
+const (
+	ScratchpadAppend  = "append"
+	ScratchpadReplace = "replace"
+	ScratchpadClear   = "clear"
+)
+
+// ScratchpadArgs describes one scratchpad edit.
+type ScratchpadArgs struct {
+	Op      string `json:"op"`
+	Content string `json:"content"`
+}
+
+// ScratchpadTool gives the model a place for working notes that are shown
+// to the user separately from the answer. The notes live in the
+// conversation; the tool only validates and echoes the edit.
+type ScratchpadTool struct{}
+
+// Name implements tools.Tool.
+func (ScratchpadTool) Name() string { return ScratchpadToolName }
+
+// JSONSchema implements tools.Tool.
+func (ScratchpadTool) JSONSchema() map[string]any {
+	return map[string]any{
+		"name":        ScratchpadToolName,
+		"description": "Record working notes (intermediate findings, open questions) without adding them to the answer.",
+		"parameters": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"op": map[string]any{
+					"type":        "string",
+					"enum":        []any{ScratchpadAppend, ScratchpadReplace, ScratchpadClear},
+					"description": "append adds to the notes, replace overwrites them, clear empties them. Defaults to append.",
+				},
+				"content": map[string]any{"type": "string", "description": "Note text."},
+			},
+		},
+	}
+}
+
+// Call implements tools.Tool.
+func (ScratchpadTool) Call(_ context.Context, raw json.RawMessage) (any, error) {
+	var args ScratchpadArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return map[string]any{"ok": false, "error": "invalid arguments: " + err.Error()}, nil
+		}
+	}
+	switch args.Op {
+	case "":
+		args.Op = ScratchpadAppend
+	case ScratchpadAppend, ScratchpadReplace, ScratchpadClear:
+	default:
+		return map[string]any{"ok": false, "error": "unknown op " + args.Op}, nil
+	}
+	if args.Op == ScratchpadClear {
+		args.Content = ""
+	} else if strings.TrimSpace(args.Content) == "" {
+		return map[string]any{"ok": false, "error": "content is required"}, nil
+	}
+	return map[string]any{"ok": true, "op": args.Op, "content": args.Content}, nil
+}
+
+// NewScratchpadTool constructs the scratchpad tool.
+func NewScratchpadTool() tools.Tool { return ScratchpadTool{} }
@@ -3,6 +3,7 @@ package warpptool
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -13,6 +14,10 @@ import (
 // ToolPrefix keeps backward compatibility with the previous WARPP workflow tool names.
 const ToolPrefix = "warpp_"
 
+// ErrWorkflowNotFound is returned by a WorkflowRunner when the workflow does
+// not exist for the user.
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
 // WorkflowRunner executes a saved workflow synchronously for tool callers.
 type WorkflowRunner interface {
 	ExecuteWorkflowSync(ctx context.Context, userID int64, workflowID string, input map[string]any) (map[string]any, error)
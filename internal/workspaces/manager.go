@@ -150,7 +150,7 @@ func (m *LegacyWorkspaceManager) Checkout(ctx context.Context, userID int64, pro
 	}
 
 	// Build and validate the project path
-	baseRoot := filepath.Join(m.workdir, "users", fmt.Sprint(userID), "projects")
+	baseRoot := filepath.Join(UserRoot(m.workdir, userID), "projects")
 	base := filepath.Join(baseRoot, cleanPID)
 
 	// Get absolute paths for comparison
@@ -194,6 +194,26 @@ func (m *LegacyWorkspaceManager) Cleanup(ctx context.Context, ws Workspace) erro
 	return nil
 }
 
+// UserRoot returns the directory under workdir that holds everything owned by
+// userID: its projects and its scratch space.
+func UserRoot(workdir string, userID int64) string {
+	return filepath.Join(workdir, "users", fmt.Sprint(userID))
+}
+
+// ScratchDir returns the per-user directory tools operate in when a run has
+// no project, creating it if needed. It keeps project-less runs of different
+// users out of each other's files and out of the shared workdir.
+func ScratchDir(workdir string, userID int64) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(UserRoot(workdir, userID), "scratch"))
+	if err != nil {
+		return "", fmt.Errorf("resolve scratch dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create scratch dir: %w", err)
+	}
+	return dir, nil
+}
+
 // ValidateProjectID checks if a project ID is safe for use in filesystem paths.
 // Returns cleaned project ID and error if validation fails.
 // Deprecated: Use validation.ProjectID directly for new code.
@@ -212,3 +212,18 @@ func TestLegacyWorkspaceManager_Mode(t *testing.T) {
 	mgr := newLegacyManager("/tmp/workdir")
 	assert.Equal(t, "legacy", mgr.Mode())
 }
+
+func TestScratchDir_PerUser(t *testing.T) {
+	workdir := t.TempDir()
+
+	a, err := ScratchDir(workdir, 1)
+	require.NoError(t, err)
+	b, err := ScratchDir(workdir, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(workdir, "users", "1", "scratch"), a)
+	assert.NotEqual(t, a, b)
+	st, err := os.Stat(a)
+	require.NoError(t, err)
+	assert.True(t, st.IsDir())
+}
@@ -1,7 +1,8 @@
-import { apiClient } from "./client";
+import { apiClient, readApiError } from "./client";
 import type { ChatMessage, ChatSessionMeta } from "@/types/chat";
 
 export type ChatStreamEventType =
+  | "queued"
   | "thought_summary"
   | "delta"
   | "final"
@@ -18,7 +19,28 @@ export type ChatStreamEventType =
   | "agent_tool_start"
   | "agent_tool_result"
   | "agent_error"
-  | "agent_thought_summary";
+  | "agent_thought_summary"
+  // Thoughts panel events (docs/thoughts-events.md)
+  | "plan"
+  | "scratchpad"
+  | "citations";
+
+export interface PlanStep {
+  step: string;
+  status: "pending" | "in_progress" | "completed";
+}
+
+export interface ThoughtsCitation {
+  index: number;
+  source: "rag" | "web";
+  query?: string;
+  id?: string;
+  doc_id?: string;
+  title?: string;
+  url?: string;
+  snippet?: string;
+  score?: number;
+}
 
 export interface ChatStreamEvent {
   type: ChatStreamEventType;
@@ -43,11 +65,18 @@ export interface ChatStreamEvent {
   content?: string;
   error?: string;
   thought_summary?: string;
+  // Run queue position for queued events
+  position?: number;
   // Summary event fields
   input_tokens?: number;
   token_budget?: number;
   message_count?: number;
   summarized_count?: number;
+  // Thoughts panel event fields (schema version in `v`)
+  v?: number;
+  plan?: { explanation?: string; steps: PlanStep[] };
+  scratchpad?: { op: "append" | "replace" | "clear"; content: string };
+  citations?: ThoughtsCitation[];
   [key: string]: unknown;
 }
 
@@ -223,7 +252,10 @@ export async function streamAgentRun(
   }
 
   if (!response.ok) {
-    const message = `agent run failed (${response.status})`;
+    const message = await readApiError(
+      response,
+      `agent run failed (${response.status})`,
+    );
     onEvent({ type: "error", data: message });
     throw new Error(message);
   }
@@ -387,7 +419,10 @@ export async function streamAgentVisionRun(
   }
 
   if (!response.ok) {
-    const message = `agent vision run failed (${response.status})`;
+    const message = await readApiError(
+      response,
+      `agent vision run failed (${response.status})`,
+    );
     onEvent({ type: "error", data: message });
     throw new Error(message);
   }
@@ -8,12 +8,66 @@ export const apiClient = axios.create({
   withCredentials: true,
 });
 
+// ApiErrorBody is the JSON body agentd sends with every error response.
+// Branch on code; message is for display.
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: unknown;
+}
+
+export function isApiErrorBody(value: unknown): value is ApiErrorBody {
+  return (
+    !!value &&
+    typeof value === "object" &&
+    typeof (value as ApiErrorBody).code === "string" &&
+    typeof (value as ApiErrorBody).message === "string"
+  );
+}
+
+// readApiError returns the message from a failed fetch response, falling
+// back to the raw body text and then to fallback.
+export async function readApiError(
+  resp: Response,
+  fallback: string,
+): Promise<string> {
+  const text = await resp.text().catch(() => "");
+  try {
+    const body = JSON.parse(text);
+    if (isApiErrorBody(body)) return body.message;
+  } catch {
+    // not JSON
+  }
+  return text.trim() || fallback;
+}
+
+// Views show error.response.data directly, so structured errors are
+// flattened to their message there; the full body stays on error.apiError.
+apiClient.interceptors.response.use(undefined, (error) => {
+  const data = error?.response?.data;
+  if (isApiErrorBody(data)) {
+    error.apiError = data;
+    error.response.data = data.message;
+    error.message = data.message;
+  }
+  return Promise.reject(error);
+});
+
 export interface AgentStatus {
   id: string;
   name: string;
   state: "online" | "offline" | "degraded";
   model: string;
   updatedAt: string;
+  kind?: "llm_provider";
+  breaker?: {
+    baseUrl: string;
+    state: "closed" | "open" | "half_open";
+    consecutiveFailures: number;
+    openedAt?: string;
+    lastError?: string;
+    lastFailureAt?: string;
+  };
 }
 
 export async function fetchAgentStatus(): Promise<AgentStatus[]> {
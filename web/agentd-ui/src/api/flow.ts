@@ -9,6 +9,7 @@ import type {
   FlowV2RunResponse,
   FlowV2Tool,
 } from "@/types/flowV2";
+import { readApiError } from "./client";
 
 const baseURL = (import.meta.env.VITE_AGENTD_BASE_URL || "").replace(/\/$/, "");
 const flowV2ApiBase = `${baseURL}/api/flows/v2`;
@@ -21,8 +22,9 @@ export interface FlowRunProgress {
 
 async function handleResponse<T>(resp: Response): Promise<T> {
   if (!resp.ok) {
-    const text = await resp.text();
-    throw new Error(text || `request failed (${resp.status})`);
+    throw new Error(
+      await readApiError(resp, `request failed (${resp.status})`),
+    );
   }
   return (await resp.json()) as T;
 }
@@ -67,8 +69,9 @@ export async function deleteFlowWorkflow(workflowId: string): Promise<void> {
     { method: "DELETE" },
   );
   if (!resp.ok) {
-    const text = await resp.text();
-    throw new Error(text || `request failed (${resp.status})`);
+    throw new Error(
+      await readApiError(resp, `request failed (${resp.status})`),
+    );
   }
 }
 
@@ -705,6 +705,15 @@ export const useChatStore = defineStore("chat", () => {
   ) {
     if (!isStreamCurrent(sessionId, streamId)) return;
     switch (event.type) {
+      case "queued": {
+        if (typeof event.position === "number") {
+          updateMessage(sessionId, assistantId, (m) => ({
+            ...m,
+            queuePosition: event.position,
+          }));
+        }
+        break;
+      }
       case "thought_summary": {
         if (typeof event.data === "string" && event.data.trim()) {
           appendThoughtSummary(sessionId, event.data);
@@ -716,6 +725,7 @@ export const useChatStore = defineStore("chat", () => {
           updateMessage(sessionId, assistantId, (m) => ({
             ...m,
             content: m.content + event.data,
+            queuePosition: undefined,
           }));
         }
         break;
@@ -726,6 +736,7 @@ export const useChatStore = defineStore("chat", () => {
           ...m,
           content: text || m.content,
           streaming: false,
+          queuePosition: undefined,
         }));
         if (text) touchSession(sessionId, snippet(text));
         try {
@@ -22,6 +22,8 @@ export interface ChatMessage {
   content: string;
   createdAt: string;
   streaming?: boolean;
+  // Set while the run waits in the server's run queue.
+  queuePosition?: number;
   title?: string;
   error?: string;
   // Deprecated: thought summaries are now streamed into the Active Specialist panel.
@@ -43,6 +43,8 @@ export interface FlowV2InputBinding {
 export interface FlowV2RetryPolicy {
   max?: number;
   backoff?: FlowV2BackoffStrategy;
+  delay?: string;
+  max_delay?: string;
 }
 
 export interface FlowV2NodeExecution {
@@ -61,6 +63,7 @@ export interface FlowV2Node {
   publish_result?: boolean;
   publish_mode?: string;
   inputs?: Record<string, FlowV2InputBinding>;
+  for_each?: string;
   execution?: FlowV2NodeExecution;
 }
 
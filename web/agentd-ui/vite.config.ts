@@ -55,6 +55,7 @@ export default defineConfig(({ mode }) => {
               target: proxyTarget,
               changeOrigin: true,
               secure: false,
+              ws: true,
             },
             "/audio": {
               target: proxyTarget,